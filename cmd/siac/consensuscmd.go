@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules/consensus"
 	"go.sia.tech/siad/node/api"
 )
 
@@ -17,6 +20,20 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot [destination]",
+		Short: "Export a consensus snapshot",
+		Long: `Export a snapshot of the consensus set to the specified file. The snapshot
+can be imported by a new node using the --consensus-snapshot flag of siad,
+which is much faster than synchronizing the blockchain. By default the
+snapshot is taken at the current height.
+
+The checksum of the snapshot is printed after exporting it. siad only imports
+the snapshot together with this checksum, which has to be obtained from a
+trusted source, using the --consensus-snapshot-checksum flag.`,
+		Run: wrap(consensussnapshotcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
@@ -30,6 +47,10 @@ func consensuscmd() {
 	} else if err != nil {
 		die("Could not get current consensus state:", err)
 	}
+	if jsonOutput {
+		printJSON(cg)
+		return
+	}
 
 	if cg.Synced {
 		fmt.Printf(`Synced: %v
@@ -60,3 +81,45 @@ Progress (estimated): %.1f%%
 		fmt.Println("Genesis Timestamp:", time.Unix(int64(cg.GenesisTimestamp), 0))
 	}
 }
+
+// consensussnapshotcmd is the handler for the command `siac consensus
+// snapshot`. Exports a snapshot of the consensus set to a file.
+func consensussnapshotcmd(destination string) {
+	height := consensusSnapshotHeight
+	if height == 0 {
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get current consensus state:", err)
+		}
+		height = cg.Height
+	}
+	destination = abs(destination)
+	file, err := os.Create(destination)
+	if err != nil {
+		die("Could not create snapshot file:", err)
+	}
+	err = httpClient.ConsensusSnapshotGet(file, height)
+	err = errors.Compose(err, file.Close())
+	if err != nil {
+		_ = os.Remove(destination)
+		die("Could not export snapshot:", err)
+	}
+	checksum, err := snapshotChecksum(destination)
+	if err != nil {
+		die("Could not compute snapshot checksum:", err)
+	}
+	fmt.Printf("Exported consensus snapshot at height %v to %v\n", height, destination)
+	fmt.Println("Checksum:", checksum)
+}
+
+// snapshotChecksum returns the checksum of the consensus snapshot at path.
+func snapshotChecksum(path string) (_ crypto.Hash, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	return consensus.SnapshotChecksum(f)
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
 )
 
 var (
@@ -22,8 +25,10 @@ var (
 	stopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the Sia daemon",
-		Long:  "Stop the Sia daemon.",
-		Run:   wrap(stopcmd),
+		Long: `Stop the Sia daemon. With --drain, new uploads and downloads are
+rejected and the daemon waits for the operations in progress to complete
+before stopping.`,
+		Run: wrap(stopcmd),
 	}
 
 	updateCheckCmd = &cobra.Command{
@@ -33,6 +38,15 @@ var (
 		Run:   wrap(updatecheckcmd),
 	}
 
+	diagnosticsCmd = &cobra.Command{
+		Use:   "diagnostics",
+		Short: "Download a diagnostics bundle of the daemon",
+		Long: `Download a diagnostics bundle of the daemon for bug reports. The bundle
+contains recent logs, goroutine and heap profiles, the config with secrets
+redacted, alerts and metrics.`,
+		Run: wrap(diagnosticscmd),
+	}
+
 	globalRatelimitCmd = &cobra.Command{
 		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
 		Short: "set the global maxdownloadspeed and maxuploadspeed",
@@ -100,6 +114,10 @@ func alertscmd() {
 		fmt.Println("Could not get daemon alerts:", err)
 		return
 	}
+	if jsonOutput {
+		printJSON(al)
+		return
+	}
 	if len(al.Alerts) == 0 {
 		fmt.Println("There are no alerts registered.")
 		return
@@ -142,7 +160,7 @@ func alertscmd() {
 // profilecmd displays the usage info for the command.
 func profilecmd(cmd *cobra.Command, args []string) {
 	_ = cmd.UsageFunc()(cmd)
-	os.Exit(exitCodeUsage)
+	exit(exitCodeUsage)
 }
 
 // profilestartcmd starts the profile for the daemon.
@@ -178,6 +196,20 @@ func profilestopcmd() {
 
 // version prints the version of siac and siad.
 func versioncmd() {
+	if jsonOutput {
+		dvg, err := httpClient.DaemonVersionGet()
+		if err != nil {
+			die("Could not get daemon version:", err)
+		}
+		printJSON(struct {
+			Siac api.DaemonVersion `json:"siac"`
+			Siad api.DaemonVersion `json:"siad"`
+		}{
+			Siac: api.DaemonVersion{Version: build.NodeVersion, GitRevision: build.GitRevision, BuildTime: build.BuildTime},
+			Siad: api.DaemonVersion{Version: dvg.Version, GitRevision: dvg.GitRevision, BuildTime: dvg.BuildTime},
+		})
+		return
+	}
 	fmt.Println("Sia Client")
 	fmt.Println("\tVersion " + build.NodeVersion)
 	if build.GitRevision != "" {
@@ -200,6 +232,23 @@ func versioncmd() {
 // stopcmd is the handler for the command `siac stop`.
 // Stops the daemon.
 func stopcmd() {
+	if daemonStopDrain {
+		p, err := httpClient.DaemonStopDrainGet(daemonStopTimeout, func(p api.DaemonStopProgress) {
+			fmt.Printf("%-9v uploads: %v, downloads: %v, rescans: %v, background downloads: %v, url uploads: %v\n",
+				p.Phase, p.Uploads, p.Downloads, p.Rescans, p.BackgroundDownloads, p.BackgroundURLUploads)
+		})
+		if err != nil {
+			die("Could not stop daemon:", err)
+		}
+		if p.TimedOut {
+			fmt.Println("Timed out waiting for operations to complete.")
+		}
+		if p.Error != "" {
+			fmt.Println("Could not flush the renter's packs:", p.Error)
+		}
+		fmt.Println("Sia daemon stopped.")
+		return
+	}
 	err := httpClient.DaemonStopGet()
 	if err != nil {
 		die("Could not stop daemon:", err)
@@ -236,6 +285,26 @@ func stackcmd() {
 	fmt.Println("Current stack trace written to:", daemonStackOutputFile)
 }
 
+// diagnosticscmd is the handler for the command `siac diagnostics`.
+// Downloads a diagnostics bundle of the daemon.
+func diagnosticscmd() {
+	if daemonDiagnosticsCPU > 0 {
+		fmt.Printf("Recording a CPU profile for %v...\n", daemonDiagnosticsCPU)
+	}
+	bundle, err := httpClient.DaemonDiagnosticsGet(daemonDiagnosticsCPU)
+	if err != nil {
+		die("Could not get the diagnostics bundle:", err)
+	}
+	filename := daemonDiagnosticsFile
+	if filename == "" {
+		filename = fmt.Sprintf("siad-diagnostics-%v.zip", time.Now().UTC().Format("20060102-150405"))
+	}
+	if err := ioutil.WriteFile(filename, bundle, 0600); err != nil {
+		die("Unable to write the diagnostics bundle:", err)
+	}
+	fmt.Println("Diagnostics bundle written to:", filename)
+}
+
 // updatecmd is the handler for the command `siac update`.
 // Updates the daemon version to latest general release.
 func updatecmd() {
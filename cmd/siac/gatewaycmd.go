@@ -141,6 +141,10 @@ func gatewayaddresscmd() {
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info.NetAddress)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 }
 
@@ -151,6 +155,10 @@ func gatewaybandwidthcmd() {
 	if err != nil {
 		die("Could not get bandwidth monitor", err)
 	}
+	if jsonOutput {
+		printJSON(bandwidth)
+		return
+	}
 
 	fmt.Printf(`Download: %v 
 Upload:   %v 
@@ -165,6 +173,10 @@ func gatewaycmd() {
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 	fmt.Println("Active peers:", len(info.Peers))
 	fmt.Println("Max download speed:", info.MaxDownloadSpeed)
@@ -178,6 +190,10 @@ func gatewayblocklistcmd() {
 	if err != nil {
 		die("Could not get gateway blocklist", err)
 	}
+	if jsonOutput {
+		printJSON(gbg)
+		return
+	}
 	fmt.Println(len(gbg.Blocklist), "ip addresses currently on the gateway blocklist")
 	for _, ip := range gbg.Blocklist {
 		fmt.Println(ip)
@@ -191,7 +207,7 @@ func gatewayblocklistappendcmd(cmd *cobra.Command, addresses []string) {
 	if len(addresses) == 0 {
 		fmt.Println("No IP addresses submitted to append")
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 	err := httpClient.GatewayAppendBlocklistPost(addresses)
 	if err != nil {
@@ -218,7 +234,7 @@ func gatewayblocklistremovecmd(cmd *cobra.Command, addresses []string) {
 	if len(addresses) == 0 {
 		fmt.Println("No IP addresses submitted to remove")
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 	err := httpClient.GatewayRemoveBlocklistPost(addresses)
 	if err != nil {
@@ -234,7 +250,7 @@ func gatewayblocklistsetcmd(cmd *cobra.Command, addresses []string) {
 	if len(addresses) == 0 {
 		fmt.Println("No IP addresses submitted")
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 	err := httpClient.GatewaySetBlocklistPost(addresses)
 	if err != nil {
@@ -250,6 +266,10 @@ func gatewaylistcmd() {
 	if err != nil {
 		die("Could not get peer list:", err)
 	}
+	if jsonOutput {
+		printJSON(info.Peers)
+		return
+	}
 	if len(info.Peers) == 0 {
 		fmt.Println("No peers to show.")
 		return
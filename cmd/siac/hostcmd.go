@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -28,10 +29,24 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
-Doing so will override the standard connectivity checks.`,
+Doing so will override the standard connectivity checks.
+With --check the address is detected using the peers of the gateway if none is
+configured, or verified to resolve to the public IP of the node. The peers also
+have to be able to dial back the announced port. --dry-run performs the same
+checks and estimates the fee without announcing the host.`,
 		Run: hostannouncecmd,
 	}
 
+	hostBackupCmd = &cobra.Command{
+		Use:   "backup [destination]",
+		Short: "Create a backup of the host",
+		Long: `Create a backup of the host's keys, settings and contracts at the specified
+destination. The backup doesn't contain any sector data but the information
+required to reconnect the existing storage folders with 'siac host restore'.
+The backup contains the host's private key and should be kept secret.`,
+		Run: wrap(hostbackupcmd),
+	}
+
 	hostCmd = &cobra.Command{
 		Use:   "host",
 		Short: "Perform host actions",
@@ -52,6 +67,8 @@ Available settings:
      netaddress:           string
      windowsize:           blocks
 
+     alternativenetaddresses: comma separated list of addresses
+
      collateral:       currency
      collateralbudget: currency
      maxcollateral:    currency
@@ -67,12 +84,19 @@ Available settings:
      maxephemeralaccountbalance: currency
      maxephemeralaccountrisk:    currency
 	 
-     registrysize:       filesize
-     customregistrypath: string
+     registrysize:              filesize
+     customregistrypath:        string
+     registryevictionpolicy:    none | earliestexpiry
+     registryexpirygraceperiod: blocks
+
+     dynamicpricing:            boolean
+     maxdownloadbandwidthprice: currency / TB
+     maxstorageprice:           currency / TB / Month
+     maxuploadbandwidthprice:   currency / TB
 
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
+Durations (maxduration, windowsize and registryexpirygraceperiod) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
@@ -100,6 +124,15 @@ Available output types:
 		Run: wrap(hostcontractcmd),
 	}
 
+	hostEarningsCmd = &cobra.Command{
+		Use:   "earnings",
+		Short: "Show a breakdown of the host's earnings",
+		Long: `Show a breakdown of the host's earnings by category and contract. Only
+contracts with a proof deadline between the start and end height are included.
+An end height of 0 includes all contracts after the start height.`,
+		Run: wrap(hostearningscmd),
+	}
+
 	hostFolderAddCmd = &cobra.Command{
 		Use:   "add [path] [size]",
 		Short: "Add a storage folder to the host",
@@ -127,10 +160,31 @@ data; it will instead be distributed across the remaining storage folders unless
 		Short: "Resize a storage folder",
 		Long: `Change how much data a storage folder should store. If the new size is less
 than what the folder is currently storing, data will be distributed across the
-other storage folders.`,
+other storage folders. With --async the command returns immediately and the
+progress of the resize is shown by 'siac host -v'.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostObligationsCmd = &cobra.Command{
+		Use:   "obligations",
+		Short: "Show the upcoming storage proofs and their risks",
+		Long: `Show the host's unresolved storage obligations sorted by proof deadline
+together with the risks of failing their storage proofs, like missing sectors or
+unconfirmed revisions. The proofs due within the next --window blocks are
+summarized.`,
+		Run: wrap(hostobligationscmd),
+	}
+
+	hostRestoreCmd = &cobra.Command{
+		Use:   "restore [source]",
+		Short: "Restore a backup of the host",
+		Long: `Restore a backup created with 'siac host backup'. The host can't have any
+contracts or storage folders of its own. The storage folders of the backup need
+to be available at the same paths. The host rescans the blockchain after
+restoring the backup, which might take a while.`,
+		Run: wrap(hostrestorecmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -138,6 +192,16 @@ other storage folders.`,
 deleting a sector may impact host revenue.`,
 	}
 
+	hostSectorAccessCmd = &cobra.Command{
+		Use:   "access",
+		Short: "Show how frequently sectors are read",
+		Long: `Show how frequently the host's sectors are read by renters since the host
+was started. Only the reads of a sample of the sectors are tracked, so the
+number of sectors is estimated once many sectors are read. The most frequently
+read sectors are candidates for faster storage folders.`,
+		Run: wrap(hostsectoraccesscmd),
+	}
+
 	hostSectorDeleteCmd = &cobra.Command{
 		Use:   "delete [root]",
 		Short: "Delete a sector",
@@ -163,6 +227,13 @@ func hostcmd() {
 	if err != nil {
 		die("Could not fetch storage info:", err)
 	}
+	if jsonOutput {
+		printJSON(struct {
+			Host    api.HostGET    `json:"host"`
+			Storage api.StorageGET `json:"storage"`
+		}{hg, sg})
+		return
+	}
 
 	es := hg.ExternalSettings
 	fm := hg.FinancialMetrics
@@ -194,6 +265,14 @@ func hostcmd() {
 	} else {
 		netaddr += " (manually specified)"
 	}
+	altNetAddrs := "none"
+	if len(is.AlternativeNetAddresses) > 0 {
+		var addrs []string
+		for _, addr := range is.AlternativeNetAddresses {
+			addrs = append(addrs, string(addr))
+		}
+		altNetAddrs = strings.Join(addrs, ", ")
+	}
 
 	var connectabilityString string
 	if hg.WorkingStatus == "working" {
@@ -220,6 +299,8 @@ Host Internal Settings:
 	netaddress:           %v
 	windowsize:           %v Hours
 
+	alternativenetaddresses: %v
+
 	collateral:       %v / TB / Month
 	collateralbudget: %v
 	maxcollateral:    %v Per Contract
@@ -275,6 +356,8 @@ RPC Stats:
 			netaddr,
 			is.WindowSize/6,
 
+			altNetAddrs,
+
 			currencyUnits(is.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.CollateralBudget),
 			currencyUnits(is.MaxCollateral),
@@ -364,6 +447,20 @@ RPC Stats:
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
+
+	// display the progress of storage folder resizes
+	for _, folder := range sg.Folders {
+		if folder.ResizeTarget != 0 {
+			var progress float64
+			if folder.ProgressDenominator != 0 {
+				progress = 100 * float64(folder.ProgressNumerator) / float64(folder.ProgressDenominator)
+			}
+			fmt.Printf("Resizing %v to %v: %.2f%% (ETA %v)\n", folder.Path, modules.FilesizeUnits(folder.ResizeTarget), progress, time.Duration(folder.ProgressETA)*time.Second)
+		}
+		if folder.ResizeError != "" {
+			fmt.Printf("Resizing %v failed: %v\n", folder.Path, folder.ResizeError)
+		}
+	}
 }
 
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
@@ -379,7 +476,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// currency/TB (convert to hastings/byte)
-	case "mindownloadbandwidthprice", "minuploadbandwidthprice":
+	case "mindownloadbandwidthprice", "minuploadbandwidthprice", "maxdownloadbandwidthprice", "maxuploadbandwidthprice":
 		hastings, err := types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -389,7 +486,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// currency/TB/month (convert to hastings/byte/block)
-	case "collateral", "minstorageprice":
+	case "collateral", "minstorageprice", "maxstorageprice":
 		hastings, err := types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -399,7 +496,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "dynamicpricing":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -408,7 +505,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "windowsize", "registryexpirygraceperiod":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -429,7 +526,7 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "alternativenetaddresses", "customregistrypath", "registryevictionpolicy":
 
 	// invalid settings
 	default:
@@ -460,6 +557,10 @@ func hostcontractcmd() {
 		die("Could not fetch host contract info:", err)
 	}
 	sort.Slice(cg.Contracts, func(i, j int) bool { return cg.Contracts[i].ExpirationHeight < cg.Contracts[j].ExpirationHeight })
+	if jsonOutput {
+		printJSON(cg)
+		return
+	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
 	switch hostContractOutputType {
 	case "value":
@@ -483,19 +584,134 @@ func hostcontractcmd() {
 	}
 }
 
+// hostearningscmd is the handler for the command `siac host earnings`.
+func hostearningscmd() {
+	eg, err := httpClient.HostEarningsGet(hostEarningsStartHeight, hostEarningsEndHeight)
+	if err != nil {
+		die("Could not fetch host earnings:", err)
+	}
+	if jsonOutput {
+		printJSON(eg)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "\tContract Fees\tStorage\tDownload\tUpload\tAccount Funding\tTotal\n")
+	for _, row := range []struct {
+		name     string
+		earnings modules.HostEarnings
+	}{
+		{"Pending", eg.Pending},
+		{"Confirmed", eg.Confirmed},
+		{"Lost", eg.Lost},
+	} {
+		e := row.earnings
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.name, currencyUnits(e.ContractFees), currencyUnits(e.StorageRevenue),
+			currencyUnits(e.DownloadRevenue), currencyUnits(e.UploadRevenue), currencyUnits(e.AccountFunding), currencyUnits(e.Total()))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+	fmt.Printf("\nLost Collateral: %v\n\n", currencyUnits(eg.LostCollateral))
+
+	fmt.Fprintf(w, "Obligation Id\tObligation Status\tProof Deadline\tPending\tConfirmed\tLost\tLost Collateral\n")
+	for _, ce := range eg.Contracts {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", ce.ObligationID, strings.TrimPrefix(ce.ObligationStatus, "obligation"), ce.ProofDeadline,
+			currencyUnits(ce.Pending.Total()), currencyUnits(ce.Confirmed.Total()), currencyUnits(ce.Lost.Total()), currencyUnits(ce.LostCollateral))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
+// hostobligationscmd is the handler for the command `siac host obligations`.
+func hostobligationscmd() {
+	og, err := httpClient.HostObligationsGet(hostObligationsWindow)
+	if err != nil {
+		die("Could not fetch host obligations:", err)
+	}
+	if hostObligationsAtRisk {
+		var atRisk []modules.HostObligationRisk
+		for _, o := range og.Obligations {
+			if len(o.Risks) > 0 {
+				atRisk = append(atRisk, o)
+			}
+		}
+		og.Obligations = atRisk
+	}
+	if jsonOutput {
+		printJSON(og)
+		return
+	}
+	fmt.Printf(`Proofs due in the next %v blocks: %v (%v at risk)
+  Data:             %v
+  Value at Stake:   %v
+
+Obligations at Risk: %v
+  Collateral:       %v
+
+`, og.Window, og.ProofsDue, og.ProofsDueAtRisk, modules.FilesizeUnits(og.ProofsDueData), currencyUnits(og.ProofsDueValue),
+		og.ObligationsAtRisk, currencyUnits(og.CollateralAtRisk))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "Obligation Id\tProof Window\tData Size\tMissing Sectors\tValue at Stake\tRisks\n")
+	for _, o := range og.Obligations {
+		risks := strings.Join(o.Risks, "; ")
+		if risks == "" {
+			risks = "-"
+		}
+		fmt.Fprintf(w, "%s\t%d-%d\t%s\t%d\t%s\t%s\n", o.ObligationID, o.ExpirationHeight, o.ProofDeadline,
+			modules.FilesizeUnits(o.DataSize), o.MissingSectors, currencyUnits(o.ValueAtStake), risks)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
+// hostbackupcmd is the handler for the command `siac host backup`.
+func hostbackupcmd(destination string) {
+	err := httpClient.HostBackupPost(abs(destination))
+	if err != nil {
+		die("Could not create host backup:", err)
+	}
+	fmt.Println("Created host backup at", abs(destination))
+}
+
+// hostrestorecmd is the handler for the command `siac host restore`.
+func hostrestorecmd(source string) {
+	err := httpClient.HostRestorePost(abs(source))
+	if err != nil {
+		die("Could not restore host backup:", err)
+	}
+	fmt.Println("Host backup restored.")
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
 func hostannouncecmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	var addr modules.NetAddress
+	if len(args) == 1 {
+		addr = modules.NetAddress(args[0])
+	}
 	var err error
-	switch len(args) {
-	case 0:
+	if hostAnnounceCheck || hostAnnounceDryRun {
+		var hacp api.HostAnnounceCheckedPOST
+		hacp, err = httpClient.HostAnnounceCheckedPost(addr, hostAnnounceDryRun)
+		if err != nil {
+			die("Could not announce host:", err)
+		}
+		printHostAnnounceReport(hacp.HostAnnounceReport)
+		if hostAnnounceDryRun {
+			return
+		}
+	} else if addr != "" {
+		err = httpClient.HostAnnounceAddrPost(addr)
+	} else {
 		err = httpClient.HostAnnouncePost()
-	case 1:
-		err = httpClient.HostAnnounceAddrPost(modules.NetAddress(args[0]))
-	default:
-		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
 	}
 	if err != nil {
 		die("Could not announce host:", err)
@@ -512,6 +728,33 @@ To revert this, run:
 	siac host config acceptingcontracts false`)
 }
 
+// printHostAnnounceReport prints the report of a checked host announcement.
+func printHostAnnounceReport(report modules.HostAnnounceReport) {
+	detected := ""
+	if report.AutoDetected {
+		detected = " (detected)"
+	}
+	publicIP := report.PublicIP
+	if publicIP == "" {
+		publicIP = "unknown"
+	}
+	fmt.Printf(`Announcement:
+  Address:      %v%v
+  Public IP:    %v
+  Reachable:    %v
+  Fee:          %v
+`, report.NetAddress, detected, publicIP, yesNo(report.Reachable), currencyUnits(report.Fee))
+	for _, alt := range report.AlternativeNetAddresses {
+		fmt.Println("  Alternative: ", alt)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+	if report.DryRun {
+		fmt.Println("Dry run, the announcement wasn't submitted.")
+	}
+}
+
 // hostfolderaddcmd adds a folder to the host.
 func hostfolderaddcmd(path, size string) {
 	size, err := parseFilesize(size)
@@ -562,6 +805,14 @@ func hostfolderresizecmd(path, newsize string) {
 	sizeUint64 /= 64 * modules.SectorSize
 	sizeUint64 *= 64 * modules.SectorSize
 
+	if hostFolderResizeAsync {
+		err = httpClient.HostStorageFoldersResizeAsyncPost(abs(path), sizeUint64)
+		if err != nil {
+			die("Could not resize folder:", err)
+		}
+		fmt.Printf("Started resizing folder %v to %v\n", path, newsize)
+		return
+	}
 	err = httpClient.HostStorageFoldersResizePost(abs(path), sizeUint64)
 	if err != nil {
 		die("Could not resize folder:", err)
@@ -569,6 +820,53 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostsectoraccesscmd is the handler for the command `siac host sector access`.
+// It prints how frequently the host's sectors are read.
+func hostsectoraccesscmd() {
+	ssag, err := httpClient.HostStorageSectorsAccessGet(hostSectorAccessLimit)
+	if err != nil {
+		die("Could not fetch sector access statistics:", err)
+	}
+	if jsonOutput {
+		printJSON(ssag)
+		return
+	}
+	fmt.Printf(`Sector Reads since %v ago:
+  Reads:            %v
+  Data:             %v
+  Sectors Read:     %v (%v tracked, sample rate %.4g)
+
+`, fmtDuration(time.Since(ssag.StartTime)), ssag.TotalReads, modules.FilesizeUnits(ssag.TotalBytes),
+		ssag.EstimatedSectorsRead, ssag.TrackedSectors, ssag.SampleRate)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintf(w, "Reads per Sector\tSectors\tShare of Reads\n")
+	for _, b := range ssag.Distribution {
+		reads := fmt.Sprintf("%v+", b.MinReads)
+		if b.MaxReads == b.MinReads {
+			reads = fmt.Sprint(b.MinReads)
+		} else if b.MaxReads != 0 {
+			reads = fmt.Sprintf("%v-%v", b.MinReads, b.MaxReads)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%.2f%%\n", reads, b.EstimatedSectors, b.ReadFraction*100)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+	if len(ssag.HotSectors) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Fprintf(w, "Merkle Root\tReads\tData\tLast Read\n")
+	for _, hs := range ssag.HotSectors {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v ago\n", hs.MerkleRoot, hs.Reads, modules.FilesizeUnits(hs.Bytes), fmtDuration(time.Since(hs.LastRead)))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	var hash crypto.Hash
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -18,8 +22,11 @@ import (
 const scanHistoryLen = 30
 
 var (
-	hostdbNumHosts int
-	hostdbVerbose  bool
+	hostdbNumHosts      int
+	hostdbScanBandwidth string
+	hostdbScanInterval  string
+	hostdbScanThreads   string
+	hostdbVerbose       bool
 )
 
 var (
@@ -47,6 +54,63 @@ var (
 		Run: hostdbsetfiltermodecmd,
 	}
 
+	hostdbBenchmarkCmd = &cobra.Command{
+		Use:   "benchmark [pubkey] [pubkey]...",
+		Short: "Benchmark hosts.",
+		Long: `Benchmark the given hosts, or all hosts the renter has contracts with if no
+hosts are given. A benchmark writes a sector to the host, reads it back and
+reads a registry entry. The sector is paid for from the host's contract. The
+results are stored in the hostdb and considered when scoring the hosts.`,
+		Run: hostdbbenchmarkcmd,
+	}
+
+	hostdbBenchmarksCmd = &cobra.Command{
+		Use:   "benchmarks",
+		Short: "View the results of host benchmarks.",
+		Long:  "View the most recent benchmark of every host which was benchmarked.",
+		Run:   wrap(hostdbbenchmarkscmd),
+	}
+
+	hostdbExportCmd = &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export a snapshot of the hostdb.",
+		Long:  "Export the hosts known to the hostdb, including their scan history and score,\nto a file which can be imported by another renter.",
+		Run:   wrap(hostdbexportcmd),
+	}
+
+	hostdbImportCmd = &cobra.Command{
+		Use:   "import [file]",
+		Short: "Import a snapshot of the hostdb.",
+		Long:  "Import a snapshot of the hostdb which was exported by another renter. Hosts\nwhich are unknown or haven't been scanned yet are taken from the snapshot,\nallowing a new renter to select hosts before the initial scan is complete.",
+		Run:   wrap(hostdbimportcmd),
+	}
+
+	hostdbOfflineCmd = &cobra.Command{
+		Use:   "offline [pubkey]",
+		Short: "Mark a host offline.",
+		Long:  "Record a failed scan for a host without scanning it. The host is considered\noffline until it is scanned successfully again.",
+		Run:   wrap(hostdbofflinecmd),
+	}
+
+	hostdbScanCmd = &cobra.Command{
+		Use:   "scan [pubkey]",
+		Short: "Scan a host right away.",
+		Long:  "Scan a host right away instead of waiting for the next regular scan and\nupdate its entry in the hostdb.",
+		Run:   wrap(hostdbscancmd),
+	}
+
+	hostdbScanSettingsCmd = &cobra.Command{
+		Use:   "scansettings",
+		Short: "View or change the hostdb scan settings.",
+		Long: `View or change the settings which control how the hostdb scans hosts.
+Only the settings which are passed as flags are changed. A value of 0 selects
+the default of the hostdb.
+        --interval is the time between two scans, e.g. 12h.
+        --threads is the maximum number of hosts which are scanned in parallel.
+        --bandwidth is the maximum bandwidth used by scans in each direction, e.g. 1MB/s.`,
+		Run: wrap(hostdbscansettingscmd),
+	}
+
 	hostdbViewCmd = &cobra.Command{
 		Use:   "view [pubkey]",
 		Short: "View the full information for a host.",
@@ -65,6 +129,7 @@ func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
 	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
 	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
+	fmt.Fprintf(w, "\t\tPerformance:\t %.3f\n", info.ScoreBreakdown.PerformanceAdjustment)
 	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", info.ScoreBreakdown.PriceAdjustment*1e24)
 	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", info.ScoreBreakdown.StorageRemainingAdjustment)
 	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", info.ScoreBreakdown.UptimeAdjustment)
@@ -97,6 +162,10 @@ func hostdbcmd() {
 		if hostdbNumHosts != 0 && hostdbNumHosts < len(info.Hosts) {
 			info.Hosts = info.Hosts[len(info.Hosts)-hostdbNumHosts:]
 		}
+		if jsonOutput {
+			printJSON(info.Hosts)
+			return
+		}
 
 		fmt.Println(len(info.Hosts), "Active Hosts:")
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
@@ -113,6 +182,10 @@ func hostdbcmd() {
 		if err != nil {
 			die("Could not fetch host list:", err)
 		}
+		if jsonOutput {
+			printJSON(info.Hosts)
+			return
+		}
 		if len(info.Hosts) == 0 {
 			fmt.Println("No known hosts")
 			return
@@ -347,7 +420,7 @@ func hostdbsetfiltermodecmd(cmd *cobra.Command, args []string) {
 	switch len(args) {
 	case 0:
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	case 1:
 		filterModeStr = args[0]
 		if filterModeStr != "disable" {
@@ -388,6 +461,10 @@ func hostdbviewcmd(pubkey string) {
 	if err != nil {
 		die("Could not fetch provided host:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 
 	fmt.Println("Host information:")
 	fmt.Println("  Public Key:               ", info.Entry.PublicKeyString)
@@ -460,3 +537,191 @@ func hostdbviewcmd(pubkey string) {
 
 	fmt.Println()
 }
+
+// hostdbexportcmd is the handler for the command `siac hostdb export`. Exports
+// a snapshot of the hostdb to a file.
+func hostdbexportcmd(destination string) {
+	snapshot, err := httpClient.HostDbSnapshotGet()
+	if err != nil {
+		die("Could not export hostdb snapshot:", err)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		die("Could not encode hostdb snapshot:", err)
+	}
+	destination = abs(destination)
+	if err := ioutil.WriteFile(destination, data, 0600); err != nil {
+		die("Could not write hostdb snapshot:", err)
+	}
+	fmt.Printf("Exported %v hosts to %v\n", len(snapshot.Hosts), destination)
+}
+
+// hostdbimportcmd is the handler for the command `siac hostdb import`. Imports
+// a snapshot of the hostdb from a file.
+func hostdbimportcmd(source string) {
+	data, err := ioutil.ReadFile(abs(source))
+	if err != nil {
+		die("Could not read hostdb snapshot:", err)
+	}
+	var snapshot modules.HostDBSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		die("Could not decode hostdb snapshot:", err)
+	}
+	result, err := httpClient.HostDbSnapshotPost(snapshot)
+	if err != nil {
+		die("Could not import hostdb snapshot:", err)
+	}
+	fmt.Printf("Imported hostdb snapshot: %v hosts added, %v updated, %v skipped\n", result.Added, result.Updated, result.Skipped)
+}
+
+// hostdbofflinecmd is the handler for the command `siac hostdb offline`. Marks
+// a host offline.
+func hostdbofflinecmd(pubkey string) {
+	var publicKey types.SiaPublicKey
+	if err := publicKey.LoadString(pubkey); err != nil {
+		die("Could not parse host public key:", err)
+	}
+	if err := httpClient.HostDbHostsOfflinePost(publicKey); err != nil {
+		die("Could not mark host offline:", err)
+	}
+	fmt.Println("Host was marked offline.")
+}
+
+// hostdbscancmd is the handler for the command `siac hostdb scan`. Scans a host
+// right away.
+func hostdbscancmd(pubkey string) {
+	var publicKey types.SiaPublicKey
+	if err := publicKey.LoadString(pubkey); err != nil {
+		die("Could not parse host public key:", err)
+	}
+	if err := httpClient.HostDbHostsScanPost(publicKey); err != nil {
+		die("Could not scan host:", err)
+	}
+	info, err := httpClient.HostDbHostsGet(publicKey)
+	if err != nil {
+		die("Could not fetch host:", err)
+	}
+	scans := info.Entry.ScanHistory
+	if len(scans) > 0 && scans[len(scans)-1].Success {
+		fmt.Println("Host is online.")
+	} else {
+		fmt.Println("Host is offline.")
+	}
+}
+
+// hostdbscansettingscmd is the handler for the command `siac hostdb
+// scansettings`. Displays or changes the hostdb scan settings.
+func hostdbscansettingscmd() {
+	hssg, err := httpClient.HostDbScanSettingsGet()
+	if err != nil {
+		die("Could not get hostdb scan settings:", err)
+	}
+	settings := modules.HostDBScanSettings{
+		ScanInterval:     time.Duration(hssg.ScanInterval) * time.Second,
+		ScanThreads:      hssg.ScanThreads,
+		MaxScanBandwidth: hssg.MaxScanBandwidth,
+	}
+	if hostdbScanInterval == "" && hostdbScanThreads == "" && hostdbScanBandwidth == "" {
+		printScanSettings(settings)
+		return
+	}
+
+	if hostdbScanInterval != "" {
+		interval := "0"
+		if hostdbScanInterval != "0" {
+			interval, err = parseTimeout(hostdbScanInterval)
+			if err != nil {
+				die("Could not parse scan interval:", err)
+			}
+		}
+		seconds, err := strconv.ParseUint(interval, 10, 64)
+		if err != nil {
+			die("Could not parse scan interval:", err)
+		}
+		settings.ScanInterval = time.Duration(seconds) * time.Second
+	}
+	if hostdbScanThreads != "" {
+		settings.ScanThreads, err = strconv.Atoi(hostdbScanThreads)
+		if err != nil {
+			die("Could not parse scan threads:", err)
+		}
+	}
+	if hostdbScanBandwidth != "" {
+		settings.MaxScanBandwidth, err = parseRatelimit(hostdbScanBandwidth)
+		if err != nil {
+			die("Could not parse scan bandwidth:", err)
+		}
+	}
+	if err := httpClient.HostDbScanSettingsPost(settings); err != nil {
+		die("Could not set hostdb scan settings:", err)
+	}
+	fmt.Println("Hostdb scan settings updated.")
+	printScanSettings(settings)
+}
+
+// printScanSettings prints the hostdb scan settings.
+func printScanSettings(settings modules.HostDBScanSettings) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	interval, threads, bandwidth := "default", "default", "unlimited"
+	if settings.ScanInterval > 0 {
+		interval = settings.ScanInterval.String()
+	}
+	if settings.ScanThreads > 0 {
+		threads = fmt.Sprint(settings.ScanThreads)
+	}
+	if settings.MaxScanBandwidth > 0 {
+		bandwidth = ratelimitUnits(settings.MaxScanBandwidth)
+	}
+	fmt.Fprintf(w, "Scan Interval:\t%v\n", interval)
+	fmt.Fprintf(w, "Scan Threads:\t%v\n", threads)
+	fmt.Fprintf(w, "Max Scan Bandwidth:\t%v\n", bandwidth)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// hostdbbenchmarkcmd is the handler for the command `siac hostdb benchmark`.
+// Benchmarks hosts.
+func hostdbbenchmarkcmd(_ *cobra.Command, args []string) {
+	var hosts []types.SiaPublicKey
+	for _, arg := range args {
+		var pk types.SiaPublicKey
+		if err := pk.LoadString(arg); err != nil {
+			die("Could not parse host public key:", err)
+		}
+		hosts = append(hosts, pk)
+	}
+	hbg, err := httpClient.HostDbBenchmarkPost(hosts...)
+	if err != nil {
+		die("Could not benchmark hosts:", err)
+	}
+	printBenchmarks(hbg.Hosts)
+}
+
+// hostdbbenchmarkscmd is the handler for the command `siac hostdb
+// benchmarks`. Displays the results of host benchmarks.
+func hostdbbenchmarkscmd() {
+	hbg, err := httpClient.HostDbBenchmarkGet()
+	if err != nil {
+		die("Could not get host benchmarks:", err)
+	}
+	if len(hbg.Hosts) == 0 {
+		fmt.Println("No hosts have been benchmarked.")
+		return
+	}
+	printBenchmarks(hbg.Hosts)
+}
+
+// printBenchmarks prints the results of host benchmarks.
+func printBenchmarks(results []modules.HostBenchmarkResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Host\tTime\tRead Latency\tRead\tWrite\tRegistry Latency\tError")
+	for _, r := range results {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", r.PublicKey.String(), r.Time.Format(time.RFC3339),
+			r.ReadLatency.Round(time.Millisecond), ratelimitUnits(int64(r.ReadThroughput)),
+			ratelimitUnits(int64(r.WriteThroughput)), r.RegistryReadLatency.Round(time.Millisecond), r.Error)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,32 +18,52 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/node/api/client"
+	"go.sia.tech/siad/types"
 )
 
 var (
 	// General Flags
 	alertSuppress bool
 	siaDir        string // Path to sia data dir
+	tlsCACert     string // Path to the CA certificate of the API's TLS certificate
+	useTLS        bool   // Connect to the API over TLS
 	verbose       bool   // Display additional information
 
 	// Module Specific Flags
 	//
+	// Consensus Flags
+	consensusSnapshotHeight types.BlockHeight // height of the exported consensus snapshot
+
 	// Daemon Flags
-	daemonStackOutputFile  string // The file that the stack trace will be written to
-	daemonCPUProfile       bool   // Indicates that the CPU profile should be started
-	daemonMemoryProfile    bool   // Indicates that the Memory profile should be started
-	daemonProfileDirectory string // The Directory where the profile logs are saved
-	daemonTraceProfile     bool   // Indicates that the Trace profile should be started
+	daemonStackOutputFile  string        // The file that the stack trace will be written to
+	daemonDiagnosticsFile  string        // The file that the diagnostics bundle will be written to
+	daemonDiagnosticsCPU   time.Duration // Duration of the CPU profile of the diagnostics bundle
+	daemonStopDrain        bool          // Wait for operations to complete before stopping the daemon
+	daemonStopTimeout      time.Duration // Maximum duration to wait for operations to complete
+	daemonCPUProfile       bool          // Indicates that the CPU profile should be started
+	daemonMemoryProfile    bool          // Indicates that the Memory profile should be started
+	daemonProfileDirectory string        // The Directory where the profile logs are saved
+	daemonTraceProfile     bool          // Indicates that the Trace profile should be started
 
 	// Host Flags
-	hostContractOutputType string // output type for host contracts
-	hostFolderRemoveForce  bool   // force folder remove
+	hostAnnounceCheck       bool              // check the address before announcing
+	hostAnnounceDryRun      bool              // only validate the announcement
+	hostContractOutputType  string            // output type for host contracts
+	hostEarningsEndHeight   types.BlockHeight // end of the range for host earnings
+	hostEarningsStartHeight types.BlockHeight // start of the range for host earnings
+	hostObligationsWindow   types.BlockHeight // number of blocks for the proofs due
+	hostObligationsAtRisk   bool              // only show obligations at risk
+	hostFolderRemoveForce   bool              // force folder remove
+	hostFolderResizeAsync   bool              // don't wait for folder resize
+	hostSectorAccessLimit   int               // number of hot sectors to show
 
 	// Renter Flags
 	dataPieces                string // the number of data pieces a file should be uploaded with
 	parityPieces              string // the number of parity pieces a file should be uploaded with
 	renterAllContracts        bool   // Show all active and expired contracts
+	renterAuditSamples        uint64 // Number of chunks sampled by an audit.
 	renterBubbleAll           bool   // Bubble the entire directory tree
+	renterBulkDryRun          bool   // List the files matching a glob pattern without changing them.
 	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
 	renterDownloadAsync       bool   // Downloads files asynchronously
 	renterDownloadRecursive   bool   // Downloads folders recursively.
@@ -48,6 +73,9 @@ var (
 	renterListRoot            bool   // List path start from root instead of the UserFolder.
 	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
 	renterShowHistory         bool   // Show download history in addition to download queue.
+	renterUploadSparse        bool   // Record chunks of zeros as holes instead of uploading them.
+	renterUploadURLAsync      bool   // Don't wait for url uploads to finish.
+	renterUploadURLMaxSize    string // Maximum size of url uploads.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -67,6 +95,9 @@ var (
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
 
+	allowanceMaxPeriodContractChurn string // max number of contracts churned per period
+	allowanceRenewWindowPolicy      string // when contracts are renewed within the renew window
+
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
 	skykeyName            string // Name used to identify a Skykey.
@@ -80,10 +111,19 @@ var (
 	// Wallet Flags
 	initForce            bool   // destroy and re-encrypt the wallet on init if it already exists
 	initPassword         bool   // supply a custom password when creating a wallet
+	walletSeedPassphrase bool   // prompt for a seed passphrase
 	walletRawTxn         bool   // Encode/decode transactions in base64-encoded binary.
 	walletStartHeight    uint64 // Start height for transaction search.
 	walletEndHeight      uint64 // End height for transaction search.
 	walletTxnFeeIncluded bool   // include the fee in the balance being sent
+	walletTxnTarget      uint64 // confirmation target in blocks used to estimate the fee
+	walletHistoryStart   string // Start date of an exported history.
+	walletHistoryEnd     string // End date of an exported history.
+	walletHistoryFiat    string // Fiat currency used to value an exported history.
+	walletHistoryCSV     bool   // Export the history as CSV.
+	walletSweepCoins     string // Amount of siacoins to sweep from a seed.
+	walletSweepFunds     string // Amount of siafunds to sweep from a seed.
+	walletSweepDryRun    bool   // Only show the plan of a sweep.
 	insecureInput        bool   // Insecure password/seed input. Disables the shoulder-surfing and Mac secure input feature.
 )
 
@@ -117,7 +157,7 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 	return func(cmd *cobra.Command, args []string) {
 		if len(args) != fnType.NumIn() {
 			_ = cmd.UsageFunc()(cmd)
-			os.Exit(exitCodeUsage)
+			exit(exitCodeUsage)
 		}
 		argVals := make([]reflect.Value, fnType.NumIn())
 		for i := range args {
@@ -127,18 +167,16 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 	}
 }
 
-// die prints its arguments to stderr, in production exits the program with the
-// default error code, during tests it passes panic so that tests can catch the
-// panic and check printed errors
+// die prints its arguments to stderr, or as the error of the JSON output if the
+// --json flag is set, and stops the command with the default error code. See
+// exit.
 func die(args ...interface{}) {
-	fmt.Fprintln(os.Stderr, args...)
-
-	if build.Release == "testing" {
-		// In testing pass panic that can be catched and the test can continue
-		panic(errors.New("die panic for testing"))
+	if capture != nil {
+		finishJSONOutput(strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+	} else {
+		fmt.Fprintln(os.Stderr, args...)
 	}
-	// In production exit
-	os.Exit(exitCodeGeneral)
+	exit(exitCodeGeneral)
 }
 
 // statuscmd is the handler for the command `siac`
@@ -248,21 +286,27 @@ func main() {
 
 	// initialize client
 	initClient(rootCmd, &verbose, &httpClient, &siaDir, &alertSuppress)
+	rootCmd.PersistentFlags().BoolVarP(&useTLS, "tls", "", false, "connect to the API over TLS")
+	rootCmd.PersistentFlags().StringVarP(&tlsCACert, "tls-ca-cert", "", "", "location of the CA certificate to verify the API's TLS certificate with, e.g. for self-signed certificates. Implies --tls")
 
 	// Perform some basic actions after cobra has initialized.
 	cobra.OnInitialize(func() {
 		// set API password if it was not set
 		setAPIPasswordIfNotSet()
 
+		// set up TLS if requested
+		setAPITLSConfig()
+
 		// Check if the siaDir is set.
 		if siaDir == "" {
 			// No siaDir passed in, fetch the siaDir
 			siaDir = build.SiaDir()
 		}
 
-		// Check for Critical Alerts
+		// Check for Critical Alerts. They aren't printed with --json to keep
+		// the output valid JSON.
 		alerts, err := httpClient.DaemonAlertsGet()
-		if err == nil && len(alerts.CriticalAlerts) > 0 && !alertSuppress {
+		if err == nil && len(alerts.CriticalAlerts) > 0 && !alertSuppress && !jsonOutput {
 			printAlerts(alerts.CriticalAlerts, modules.SeverityCritical)
 			fmt.Println("------------------")
 			fmt.Printf("\n  The above %v critical alerts should be resolved ASAP\n\n", len(alerts.CriticalAlerts))
@@ -286,26 +330,51 @@ func initCmds() *cobra.Command {
 		Short: "siac v" + build.NodeVersion,
 		Long:  "siac v" + build.NodeVersion,
 		Run:   wrap(statuscmd),
+
+		// Capture the output of every command if the --json flag is set,
+		// wrapping the output of the commands which don't support it.
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			checkJSONSupport(cmd)
+			startJSONOutput()
+		},
+		PersistentPostRun: func(*cobra.Command, []string) {
+			finishJSONOutput("")
+		},
 	}
+	root.PersistentFlags().BoolVar(&jsonOutput, "json", false, "Print the output of the command as JSON")
 
 	// create command tree (alphabetized by root command)
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
+	consensusSnapshotCmd.Flags().Uint64Var((*uint64)(&consensusSnapshotHeight), "height", 0, "Height of the snapshot (default: current height)")
 	root.AddCommand(jsonCmd)
+	root.AddCommand(shellCmd)
 
 	root.AddCommand(gatewayCmd)
 	gatewayCmd.AddCommand(gatewayAddressCmd, gatewayBandwidthCmd, gatewayBlocklistCmd, gatewayConnectCmd, gatewayDisconnectCmd, gatewayListCmd, gatewayRatelimitCmd)
 	gatewayBlocklistCmd.AddCommand(gatewayBlocklistAppendCmd, gatewayBlocklistClearCmd, gatewayBlocklistRemoveCmd, gatewayBlocklistSetCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
+	hostCmd.AddCommand(hostAnnounceCmd, hostBackupCmd, hostConfigCmd, hostContractCmd, hostEarningsCmd, hostFolderCmd, hostObligationsCmd, hostRestoreCmd, hostSectorCmd)
 	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
-	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostSectorCmd.AddCommand(hostSectorAccessCmd, hostSectorDeleteCmd)
+	hostAnnounceCmd.Flags().BoolVar(&hostAnnounceCheck, "check", false, "Detect or verify the address and check that it is reachable before announcing")
+	hostAnnounceCmd.Flags().BoolVar(&hostAnnounceDryRun, "dry-run", false, "Validate the announcement like --check without submitting it")
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostEarningsCmd.Flags().Uint64Var((*uint64)(&hostEarningsStartHeight), "start", 0, "Only include contracts with a proof deadline at or after this height")
+	hostEarningsCmd.Flags().Uint64Var((*uint64)(&hostEarningsEndHeight), "end", 0, "Only include contracts with a proof deadline at or before this height")
+	hostObligationsCmd.Flags().Uint64Var((*uint64)(&hostObligationsWindow), "window", uint64(types.BlocksPerDay), "Number of blocks to aggregate the proofs due for")
+	hostObligationsCmd.Flags().BoolVar(&hostObligationsAtRisk, "at-risk", false, "Only show obligations at risk")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
+	hostFolderResizeCmd.Flags().BoolVarP(&hostFolderResizeAsync, "async", "", false, "Return as soon as the resize was started")
+	hostSectorAccessCmd.Flags().IntVar(&hostSectorAccessLimit, "limit", 10, "Number of the most frequently read sectors to show")
 
 	root.AddCommand(hostdbCmd)
-	hostdbCmd.AddCommand(hostdbFiltermodeCmd, hostdbSetFiltermodeCmd, hostdbViewCmd)
+	hostdbCmd.AddCommand(hostdbBenchmarkCmd, hostdbBenchmarksCmd, hostdbExportCmd, hostdbFiltermodeCmd, hostdbImportCmd, hostdbOfflineCmd, hostdbScanCmd, hostdbScanSettingsCmd, hostdbSetFiltermodeCmd, hostdbViewCmd)
 	hostdbCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display from the hostdb")
+	hostdbScanSettingsCmd.Flags().StringVar(&hostdbScanInterval, "interval", "", "Time between two scans of the hosts, 0 for the default")
+	hostdbScanSettingsCmd.Flags().StringVar(&hostdbScanThreads, "threads", "", "Maximum number of hosts scanned in parallel, 0 for the default")
+	hostdbScanSettingsCmd.Flags().StringVar(&hostdbScanBandwidth, "bandwidth", "", "Maximum bandwidth used by scans, 0 for no limit")
 
 	root.AddCommand(minerCmd)
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
@@ -315,26 +384,41 @@ func initCmds() *cobra.Command {
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
-	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
-
-	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
+		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterRepairCmd, renterSetAllowanceCmd,
+		renterReshardCmd, renterSetLocalPathCmd, renterTransferCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterUploadURLCmd, renterWorkersCmd,
+		renterHealthSummaryCmd, renterChurnCmd, renterPaymentBudgetsCmd, renterAuditCmd)
+	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd, renterWorkersViewCmd)
+
+	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd, renterAllowancePlanCmd, renterAllowanceForecastCmd)
+	renterAllowancePlanCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
+	renterAllowancePlanCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
+	renterAllowancePlanCmd.Flags().StringVar(&allowanceHosts, "hosts", "", "number of hosts the renter will spread the uploaded data across")
+	renterAllowancePlanCmd.Flags().StringVar(&allowanceRenewWindow, "renew-window", "", "renew window in blocks (b), hours (h), days (d) or weeks (w)")
+	renterAuditCmd.Flags().Uint64Var(&renterAuditSamples, "samples", 0, "Number of random chunks to sample (default: the number of samples of the periodic audits)")
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
+	renterRepairCmd.AddCommand(renterRepairPauseCmd, renterRepairResumeCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesDeleteCmd.Flags().BoolVar(&renterDeleteRoot, "root", false, "Delete files and folders from root instead of from the user home directory")
+	renterFilesDeleteCmd.Flags().BoolVar(&renterBulkDryRun, "dry-run", false, "List the files matching a glob pattern without deleting them")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
+	renterFilesDownloadCmd.Flags().BoolVar(&renterBulkDryRun, "dry-run", false, "List the files matching a glob pattern without downloading them")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
+	renterReshardCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces the file should be re-encoded with")
+	renterReshardCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces the file should be re-encoded with")
+	renterUploadURLCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
+	renterUploadURLCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterUploadURLCmd.Flags().StringVar(&renterUploadURLMaxSize, "max-size", "", "the maximum size of the upload, e.g. 10GB, defaults to 16GiB")
+	renterUploadURLCmd.Flags().BoolVar(&renterUploadURLAsync, "async", false, "return before the upload is done")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterFilesUploadCmd.Flags().BoolVar(&renterUploadSparse, "sparse", false, "record chunks which only contain zeros as holes instead of uploading them")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
 
@@ -352,12 +436,18 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxSectorAccessPrice, "max-sector-access-price", "", "the maximum price that the renter will pay to access a sector on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxStoragePrice, "max-storage-price", "", "the maximum price that the renter will pay to store data on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxPeriodContractChurn, "max-period-contract-churn", "", "the maximum number of contracts churned for a poor host score per period, 0 for no limit")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceRenewWindowPolicy, "renew-window-policy", "", "when contracts are renewed within the renew window, 'start' or 'spread'")
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
 
 	// Daemon Commands
-	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
+	root.AddCommand(alertsCmd, diagnosticsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
+	diagnosticsCmd.Flags().StringVarP(&daemonDiagnosticsFile, "filename", "f", "", "Specify the output file for the diagnostics bundle (default: siad-diagnostics-<time>.zip)")
+	stopCmd.Flags().BoolVar(&daemonStopDrain, "drain", false, "Wait for uploads, downloads and rescans to complete before stopping")
+	stopCmd.Flags().DurationVar(&daemonStopTimeout, "timeout", 5*time.Minute, "Maximum duration to wait for operations to complete with --drain")
+	diagnosticsCmd.Flags().DurationVar(&daemonDiagnosticsCPU, "cpu", 0, "Include a CPU profile of this duration, e.g. 30s")
 	profileCmd.AddCommand(profileStartCmd, profileStopCmd)
 	profileStartCmd.Flags().BoolVarP(&daemonCPUProfile, "cpu", "c", false, "Start the CPU profile")
 	profileStartCmd.Flags().BoolVarP(&daemonMemoryProfile, "memory", "m", false, "Start the Memory profile")
@@ -374,22 +464,51 @@ func initCmds() *cobra.Command {
 	utilsVerifySeedCmd.Flags().StringVarP(&dictionaryLanguage, "language", "l", "english", "which dictionary you want to use")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd,
-		walletInitCmd, walletInitSeedCmd, walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
-		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletBroadcastCmd, walletChangepasswordCmd, walletEventsCmd, walletHistoryCmd,
+		walletInitCmd, walletInitSeedCmd, walletInitWatchOnlyCmd, walletLabelCmd, walletLabelsCmd, walletLoadCmd, walletLockCmd, walletOfflineCmd, walletRescanCmd, walletSeedsCmd, walletSendCmd,
+		walletSignCmd, walletSweepCmd, walletTransactionsCmd, walletUnlockCmd, walletFreezeCmd,
+		walletUnfreezeCmd, walletUnspentCmd, walletWebhooksCmd)
+	walletOfflineCmd.AddCommand(walletOfflineBroadcastCmd, walletOfflineExportCmd, walletOfflineSignCmd)
+	walletRescanCmd.AddCommand(walletRescanCancelCmd, walletRescanStatusCmd)
+	walletWebhooksCmd.AddCommand(walletWebhooksAddCmd, walletWebhooksRemoveCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
+	walletInitWatchOnlyCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
+	walletInitCmd.Flags().BoolVarP(&walletSeedPassphrase, "passphrase", "", false, "Prompt for a seed passphrase the wallet's keys are derived from")
+	walletInitSeedCmd.Flags().BoolVarP(&walletSeedPassphrase, "passphrase", "", false, "Prompt for the seed passphrase the wallet's keys are derived from")
+	walletOfflineSignCmd.Flags().BoolVarP(&walletSeedPassphrase, "passphrase", "", false, "Prompt for the seed passphrase the wallet's keys are derived from")
+	walletSignCmd.Flags().BoolVarP(&walletSeedPassphrase, "passphrase", "", false, "Prompt for the seed passphrase when signing without siad")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
 	walletSendSiacoinsCmd.Flags().BoolVarP(&walletTxnFeeIncluded, "fee-included", "", false, "Take the transaction fee out of the balance being submitted instead of the fee being additional")
+	walletSendSiacoinsCmd.Flags().Uint64VarP(&walletTxnTarget, "target", "", 0, "Use a fee that targets the transaction to be confirmed within this many blocks")
+	walletSweepCmd.Flags().StringVar(&walletSweepCoins, "coins", "", "Amount of siacoins to sweep, e.g. 100SC. The remainder is sent back to the seed.")
+	walletSweepCmd.Flags().StringVar(&walletSweepFunds, "funds", "", "Amount of siafunds to sweep. The remainder is sent back to the seed.")
+	walletSweepCmd.Flags().BoolVar(&walletSweepDryRun, "dry-run", false, "Only show the outputs and fees of the sweep without broadcasting it.")
 	walletUnlockCmd.Flags().BoolVarP(&insecureInput, "insecure-input", "", false, "Disable shoulder-surf protection (echoing passwords and seeds)")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
 	walletBroadcastCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Decode transaction as base64 instead of JSON")
 	walletSignCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode signed transaction as base64 instead of JSON")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryStart, "start", "", "Date (YYYY-MM-DD) where the history should begin.")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryEnd, "end", "", "Date (YYYY-MM-DD) where the history should end, inclusive.")
+	walletHistoryCmd.Flags().StringVar(&walletHistoryFiat, "currency", "", "Fiat currency used to value the transactions. Requires siad to be started with --wallet-price-source.")
+	walletHistoryCmd.Flags().BoolVar(&walletHistoryCSV, "csv", false, "Print the history as CSV.")
 	walletTransactionsCmd.Flags().Uint64Var(&walletStartHeight, "startheight", 0, " Height of the block where transaction history should begin.")
 	walletTransactionsCmd.Flags().Uint64Var(&walletEndHeight, "endheight", math.MaxUint64, " Height of the block where transaction history should end.")
 
+	// mark the commands supporting the --json flag
+	supportJSON(alertsCmd, consensusCmd, jsonCmd, minerCmd, versionCmd)
+	supportJSON(gatewayCmd, gatewayAddressCmd, gatewayBandwidthCmd, gatewayBlocklistCmd, gatewayListCmd)
+	supportJSON(hostCmd, hostContractCmd, hostEarningsCmd, hostObligationsCmd, hostSectorAccessCmd)
+	supportJSON(hostdbCmd, hostdbViewCmd)
+	supportJSON(renterCmd, renterAllowanceCmd, renterAllowanceForecastCmd, renterAllowancePlanCmd, renterAuditCmd,
+		renterBackupListCmd, renterChurnCmd, renterContractsCmd, renterContractsViewCmd, renterDownloadsCmd,
+		renterFilesDeleteCmd, renterFilesDownloadCmd, renterFilesListCmd, renterPaymentBudgetsCmd, renterTransferCmd,
+		renterUploadsCmd, renterWorkersCmd, renterWorkersViewCmd)
+	supportJSON(walletAddressCmd, walletAddressesCmd, walletBalanceCmd, walletHistoryCmd, walletRescanStatusCmd,
+		walletTransactionsCmd, walletUnspentCmd, walletWebhooksCmd)
+
 	return root
 }
 
@@ -410,6 +529,26 @@ func initClient(root *cobra.Command, verbose *bool, client *client.Client, siaDi
 	root.PersistentFlags().BoolVarP(alertSuppress, "alert-suppress", "s", false, "suppress siac alerts")
 }
 
+// setAPITLSConfig sets the TLS config of the client if the API should be
+// requested over TLS.
+func setAPITLSConfig() {
+	if !useTLS && tlsCACert == "" {
+		return
+	}
+	tlsConfig := &tls.Config{}
+	if tlsCACert != "" {
+		pem, err := ioutil.ReadFile(tlsCACert)
+		if err != nil {
+			die("Could not read the TLS CA certificate:", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			die("Could not parse the TLS CA certificate")
+		}
+	}
+	httpClient.TLSConfig = tlsConfig
+}
+
 // setAPIPasswordIfNotSet sets API password if it was not set
 func setAPIPasswordIfNotSet() {
 	// Check if the API Password is set
@@ -52,6 +52,10 @@ func minercmd() {
 	} else if err != nil {
 		die("Could not get miner status:", err)
 	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
 
 	miningStr := "off"
 	if status.CPUMining {
@@ -0,0 +1,174 @@
+package main
+
+// output.go implements the --json flag, which makes siac commands print
+// machine-readable JSON instead of human readable text. The commands marked
+// with supportJSON print their own JSON, the text output of the other commands
+// is wrapped in a jsonResult.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+)
+
+// jsonAnnotation is the cobra annotation marking the commands which support the
+// --json flag.
+const jsonAnnotation = "json"
+
+var (
+	// errDiePanic is the panic passed by exit during tests and in the
+	// interactive shell so that the caller can recover instead of exiting.
+	errDiePanic = errors.New("die panic for testing")
+
+	// jsonOutput indicates that commands should print JSON.
+	jsonOutput bool
+
+	// capture holds the output of the running command while jsonOutput is
+	// set.
+	capture *outputCapture
+
+	// wrapOutput indicates that the captured output of the running command is
+	// human readable text which is printed as the output of a jsonResult.
+	wrapOutput bool
+)
+
+type (
+	// jsonResult is printed instead of the output of a command when the
+	// command fails or doesn't support the --json flag. It contains the error
+	// that stopped the command and the text output of the commands which don't
+	// print JSON themselves.
+	jsonResult struct {
+		Output string `json:"output,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// outputCapture captures everything written to stdout.
+	outputCapture struct {
+		stdout *os.File
+		w      *os.File
+		buf    bytes.Buffer
+		done   chan struct{}
+	}
+)
+
+// supportJSON marks the commands as supporting the --json flag. The commands
+// must print their output with printJSON when jsonOutput is set.
+func supportJSON(cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[jsonAnnotation] = "true"
+	}
+}
+
+// checkJSONSupport wraps the output of the command in a jsonResult if the
+// command doesn't support the --json flag.
+func checkJSONSupport(cmd *cobra.Command) {
+	wrapOutput = cmd.Annotations[jsonAnnotation] == ""
+}
+
+// wrapJSONOutput wraps the output of the running command in a jsonResult. The
+// commands supporting the --json flag call it when they print text for some of
+// their arguments.
+func wrapJSONOutput() {
+	wrapOutput = true
+}
+
+// startCapture starts capturing stdout.
+func startCapture() (*outputCapture, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &outputCapture{
+		stdout: os.Stdout,
+		w:      w,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		_, _ = io.Copy(&c.buf, r)
+		_ = r.Close()
+		close(c.done)
+	}()
+	os.Stdout = w
+	return c, nil
+}
+
+// stop stops capturing stdout and returns the captured output.
+func (c *outputCapture) stop() string {
+	_ = c.w.Close()
+	<-c.done
+	os.Stdout = c.stdout
+	return c.buf.String()
+}
+
+// startJSONOutput starts capturing the output of a command if the --json flag
+// is set.
+func startJSONOutput() {
+	if !jsonOutput || capture != nil {
+		return
+	}
+	c, err := startCapture()
+	if err != nil {
+		die("Could not capture output:", err)
+	}
+	capture = c
+}
+
+// finishJSONOutput stops capturing the output of a command and prints it. If
+// the command failed or its output is wrapped, a jsonResult containing errMsg
+// is printed instead.
+func finishJSONOutput(errMsg string) {
+	if capture == nil {
+		return
+	}
+	output := capture.stop()
+	capture = nil
+	if errMsg == "" && !wrapOutput {
+		fmt.Print(output)
+		return
+	}
+	result := jsonResult{Error: errMsg}
+	if wrapOutput {
+		result.Output = output
+	}
+	js, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Could not marshal JSON:", err)
+		return
+	}
+	fmt.Println(string(js))
+}
+
+// printJSON prints v as indented JSON. Commands call it instead of printing
+// human readable output when jsonOutput is set.
+func printJSON(v interface{}) {
+	js, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		die("Could not marshal JSON:", err)
+	}
+	fmt.Println(string(js))
+}
+
+// exit stops the running command with the given exit code, printing the
+// captured output first if the --json flag is set. During tests and in the
+// interactive shell it passes a panic instead so that the caller can recover
+// and continue.
+func exit(code int) {
+	if code == exitCodeUsage {
+		finishJSONOutput("invalid usage")
+	} else {
+		finishJSONOutput("command failed")
+	}
+	if build.Release == "testing" || shellActive {
+		panic(errDiePanic)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestJSONOutput tests that the output of commands is printed as JSON when
+// the --json flag is set.
+func TestJSONOutput(t *testing.T) {
+	jsonOutput = true
+	defer func() {
+		jsonOutput = false
+	}()
+
+	// runCommand runs fn like a command and returns its output.
+	runCommand := func(fn func()) string {
+		c, err := newOutputCatcher()
+		if err != nil {
+			t.Fatal(err)
+		}
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil && rec != errDiePanic {
+					panic(rec)
+				}
+			}()
+			startJSONOutput()
+			fn()
+			finishJSONOutput("")
+		}()
+		output, err := c.stop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output
+	}
+
+	// Errors should replace the output of the command.
+	var result jsonResult
+	output := runCommand(func() {
+		fmt.Println("Starting")
+		die("Could not connect:", "connection refused")
+	})
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatal(err, output)
+	}
+	if result.Error != "Could not connect: connection refused" {
+		t.Fatal("unexpected result", result)
+	}
+
+	// JSON printed by the command should be printed as is.
+	output = runCommand(func() {
+		printJSON(map[string]int{"height": 10})
+	})
+	var height map[string]int
+	if err := json.Unmarshal([]byte(output), &height); err != nil {
+		t.Fatal(err, output)
+	}
+	if height["height"] != 10 {
+		t.Fatal("unexpected output", output)
+	}
+	if capture != nil {
+		t.Fatal("output is still being captured")
+	}
+
+	// The output of commands not supporting JSON should be wrapped.
+	unsupported := &cobra.Command{Use: "unsupported"}
+	checkJSONSupport(unsupported)
+	output = runCommand(func() {
+		fmt.Println("Unlocked")
+	})
+	result = jsonResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatal(err, output)
+	}
+	if result.Output != "Unlocked\n" || result.Error != "" {
+		t.Fatal("unexpected result", result)
+	}
+	output = runCommand(func() {
+		fmt.Println("Sending")
+		die("Could not send:", "insufficient balance")
+	})
+	result = jsonResult{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatal(err, output)
+	}
+	if result.Output != "Sending\n" || result.Error != "Could not send: insufficient balance" {
+		t.Fatal("unexpected result", result)
+	}
+	supported := &cobra.Command{Use: "supported"}
+	supportJSON(supported)
+	checkJSONSupport(supported)
+	output = runCommand(func() {
+		printJSON(10)
+	})
+	if output != "10\n" {
+		t.Fatal("unexpected output", output)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -49,6 +48,24 @@ var (
 		Run:   wrap(renterallowancecancelcmd),
 	}
 
+	renterAllowancePlanCmd = &cobra.Command{
+		Use:   "plan",
+		Short: "Preview the contracts formed for an allowance",
+		Long: `Preview the contracts the renter would form for an allowance without forming
+any contracts. Allowance fields that are not specified default to the current
+allowance.`,
+		Run: wrap(renterallowanceplancmd),
+	}
+
+	renterAllowanceForecastCmd = &cobra.Command{
+		Use:   "forecast",
+		Short: "Project the spending of the allowance",
+		Long: `Project the spending of the allowance to the end of the current period based
+on the spending rate during the period so far and estimate the cost of renewing
+the contracts.`,
+		Run: wrap(renterallowanceforecastcmd),
+	}
+
 	renterAllowanceCmd = &cobra.Command{
 		Use:   "allowance",
 		Short: "View the current allowance",
@@ -113,7 +130,7 @@ the command 'siac renter lost' to see the renter's lost files.`,
 	renterContractsRecoveryScanProgressCmd = &cobra.Command{
 		Use:   "recoveryscanprogress",
 		Short: "Returns the recovery scan progress.",
-		Long:  "Returns the progress of a potentially ongoing recovery scan.",
+		Long:  "Returns the progress of a potentially ongoing recovery scan and lists the contracts that failed to be recovered.",
 		Run:   wrap(rentercontractrecoveryscanprogresscmd),
 	}
 
@@ -142,15 +159,22 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Use:     "delete [path]",
 		Aliases: []string{"rm"},
 		Short:   "Delete a file or folder",
-		Long:    "Delete a file or folder. Does not delete the file/folder on disk.  Multiple files may be deleted with space separation.",
-		Run:     renterfilesdeletecmd,
+		Long: `Delete a file or folder. Does not delete the file/folder on disk.  Multiple files may be deleted with space separation.
+Paths containing the wildcards '*', '?' or '[' are glob patterns deleting all
+the matching files in a single request, e.g. 'siac renter delete "backups/2020-*"'.
+Use --dry-run to list the matching files without deleting them.`,
+		Run: renterfilesdeletecmd,
 	}
 
 	renterFilesDownloadCmd = &cobra.Command{
 		Use:   "download [path] [destination]",
 		Short: "Download a file or folder",
-		Long:  "Download a previously-uploaded file or folder to a specified destination.",
-		Run:   wrap(renterfilesdownloadcmd),
+		Long: `Download a previously-uploaded file or folder to a specified destination.
+Paths containing the wildcards '*', '?' or '[' are glob patterns downloading all
+the matching files asynchronously into the destination folder, keeping their
+paths relative to the user home directory. Use --dry-run to list the matching
+files without downloading them.`,
+		Run: wrap(renterfilesdownloadcmd),
 	}
 
 	renterFilesListCmd = &cobra.Command{
@@ -212,7 +236,10 @@ local path where the Sia folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the Sia network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. Folders are uploaded
+recursively, their progress can be followed with 'siac renter transfer'. With
+--sparse, chunks of a file which only contain zeros are recorded as holes
+instead of being uploaded, e.g. for VM images.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
@@ -232,6 +259,30 @@ For Example: 'siac renter upload pause 3h' would pause uploads for 3 hours.`,
 		Run:   wrap(renterfilesuploadresumecmd),
 	}
 
+	renterRepairCmd = &cobra.Command{
+		Use:   "repair",
+		Short: "Display the renter's repair backlog",
+		Long:  "Display the renter's repair backlog and whether repairs are paused.",
+		Run:   wrap(renterrepaircmd),
+	}
+
+	renterRepairPauseCmd = &cobra.Command{
+		Use:   "pause [duration]",
+		Short: "Pause the repair of existing files",
+		Long: `Pause the repair of existing files while uploads of new files continue.
+If a duration is provided, repairs resume automatically after it, e.g.
+'siac renter repair pause 8h'. Otherwise repairs are paused until they are
+resumed. The pause persists across restarts.`,
+		Run: renterrepairpausecmd,
+	}
+
+	renterRepairResumeCmd = &cobra.Command{
+		Use:   "resume",
+		Short: "Resume the repair of existing files",
+		Long:  "Resume the repair of existing files that was previously paused.",
+		Run:   wrap(renterrepairresumecmd),
+	}
+
 	renterPricesCmd = &cobra.Command{
 		Use:   "prices [amount] [period] [hosts] [renew window]",
 		Short: "Display the price of storage and bandwidth",
@@ -266,7 +317,8 @@ corresponding field flag, for example '--amount 500SC'.
 
 Allowance can be automatically renewed periodically. If the current
 blockheight + the renew window >= the end height the contract, then the contract
-is renewed automatically.
+is renewed automatically. With the 'spread' renew window policy, the renewals
+are spread across the first half of the renew window instead.
 
 Note that setting the allowance will cause siad to immediately begin forming
 contracts! You should only set the allowance once you are fully synced and you
@@ -274,6 +326,25 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run: rentersetallowancecmd,
 	}
 
+	renterReshardCmd = &cobra.Command{
+		Use:   "reshard [path]",
+		Short: "Change the erasure coding of a file",
+		Long: `Change the number of data and parity pieces of the file at [path]. The renter
+re-encodes and re-uploads the file in the background. The file stays
+downloadable while this happens and the progress can be followed with
+'siac renter uploads'.`,
+		Run: wrap(renterreshardcmd),
+	}
+
+	renterTransferCmd = &cobra.Command{
+		Use:   "transfer [id]",
+		Short: "Show the progress of a folder upload or download",
+		Long: `Show the progress of a folder upload or download until it completes. The
+transfer ID is printed when uploading a folder or downloading a folder
+asynchronously.`,
+		Run: wrap(rentertransfercmd),
+	}
+
 	renterTriggerContractRecoveryScanCmd = &cobra.Command{
 		Use:   "triggerrecoveryscan",
 		Short: "Triggers a recovery scan.",
@@ -281,6 +352,16 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(rentertriggercontractrecoveryrescancmd),
 	}
 
+	renterUploadURLCmd = &cobra.Command{
+		Use:   "uploadurl [url] [path]",
+		Short: "Upload a file from a URL",
+		Long: `Have the renter fetch the data from an HTTP(S) URL and upload it to [path] on the
+Sia network without proxying the data through siac. The --max-size flag limits
+the size of the upload. With --async the command returns right away and the
+progress can be followed with 'siac renter uploads'.`,
+		Run: wrap(renteruploadurlcmd),
+	}
+
 	renterUploadsCmd = &cobra.Command{
 		Use:   "uploads",
 		Short: "View the upload queue",
@@ -344,6 +425,15 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(renterworkersreadregistrycmd),
 	}
 
+	renterWorkersViewCmd = &cobra.Command{
+		Use:   "view [pubkey]",
+		Short: "View the status of a single worker",
+		Long: `View the detailed status of the worker for the host with the given public
+key, including its job queues, recent job times, cooldowns, account balance and
+price table age.`,
+		Run: wrap(renterworkersviewcmd),
+	}
+
 	renterWorkersUpdateRegistryCmd = &cobra.Command{
 		Use:   "urj",
 		Short: "View the workers' update registry jobs",
@@ -351,6 +441,31 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(renterworkersupdateregistrycmd),
 	}
 
+	renterChurnCmd = &cobra.Command{
+		Use:   "churn",
+		Short: "Display the contract churn of the current period",
+		Long: `Display how much data and how many contracts were churned in the current
+period, and the recent churn events with their reasons.`,
+		Run: wrap(renterchurncmd),
+	}
+
+	renterPaymentBudgetsCmd = &cobra.Command{
+		Use:   "paymentbudgets",
+		Short: "Display the payment budgets of third-party applications",
+		Long: `Display the budgets third-party applications use to pay hosts for MDM
+programs, and how much of them was spent.`,
+		Run: wrap(renterpaymentbudgetscmd),
+	}
+
+	renterAuditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the retrievability of uploaded files",
+		Long: `Download random segments of the pieces of random chunks from the hosts
+which store them and verify their proofs. Displays the results per host and the
+files with chunks which couldn't be retrieved.`,
+		Run: wrap(renterauditcmd),
+	}
+
 	renterHealthSummaryCmd = &cobra.Command{
 		Use:   "health",
 		Short: "Display a health summary of uploaded files",
@@ -406,6 +521,10 @@ func rentercmd() {
 	} else if err != nil {
 		die("Could not get renter info:", err)
 	}
+	if jsonOutput {
+		printJSON(rg)
+		return
+	}
 
 	// Print Allowance info
 	rate, err := types.ParseExchangeRate(build.ExchangeRate())
@@ -456,11 +575,14 @@ func rentercmd() {
 	fmt.Fprintf(w, "\nMemory Status\tUser Download\tUser Upload\tRegistry\tSystem\tTotal\n")
 	fmt.Fprintf(w, "  Available Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.Available), sizeString(uu.Available), sizeString(reg.Available), sizeString(sys.Available), sizeString(ms.Available))
 	fmt.Fprintf(w, "  Starting Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.Base), sizeString(uu.Base), sizeString(reg.Base), sizeString(sys.Base), sizeString(ms.Base))
+	fmt.Fprintf(w, "  Granted Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.Granted), sizeString(uu.Granted), sizeString(reg.Granted), sizeString(sys.Granted), sizeString(ms.Granted))
 	fmt.Fprintf(w, "  Requested Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.Requested), sizeString(uu.Requested), sizeString(reg.Requested), sizeString(sys.Requested), sizeString(ms.Requested))
+	fmt.Fprintf(w, "  Queued Requests\t%v\t%v\t%v\t%v\t%v\n", ud.Queued, uu.Queued, reg.Queued, sys.Queued, ms.Queued)
 	fmt.Fprintf(w, " \t \t \t \t \t \n")
 	fmt.Fprintf(w, "  Available Priority Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.PriorityAvailable), sizeString(uu.PriorityAvailable), sizeString(reg.PriorityAvailable), sizeString(sys.PriorityAvailable), sizeString(ms.PriorityAvailable))
 	fmt.Fprintf(w, "  Starting Priority Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.PriorityBase), sizeString(uu.PriorityBase), sizeString(reg.PriorityBase), sizeString(sys.PriorityBase), sizeString(ms.PriorityBase))
 	fmt.Fprintf(w, "  Requested Priority Memory\t%v\t%v\t%v\t%v\t%v\n", sizeString(ud.PriorityRequested), sizeString(uu.PriorityRequested), sizeString(reg.PriorityRequested), sizeString(sys.PriorityRequested), sizeString(ms.PriorityRequested))
+	fmt.Fprintf(w, "  Queued Priority Requests\t%v\t%v\t%v\t%v\t%v\n", ud.PriorityQueued, uu.PriorityQueued, reg.PriorityQueued, sys.PriorityQueued, ms.PriorityQueued)
 	fmt.Fprintln(w, "")
 
 	// Print out if the uploads are paused
@@ -495,6 +617,125 @@ func renterlostcmd() {
 	}
 }
 
+// renterchurncmd is the handler for displaying the contract churn of the
+// current period.
+func renterchurncmd() {
+	cs, err := httpClient.RenterContractorChurnStatus()
+	if err != nil {
+		die("Could not get churn status:", err)
+	}
+	if jsonOutput {
+		printJSON(cs)
+		return
+	}
+	maxContractChurn := "no limit"
+	if cs.MaxPeriodContractChurn != 0 {
+		maxContractChurn = fmt.Sprint(cs.MaxPeriodContractChurn)
+	}
+	fmt.Printf(`Churn in current period:
+  Data:       %v / %v
+  Contracts:  %v / %v
+`, modules.FilesizeUnits(cs.AggregateCurrentPeriodChurn), modules.FilesizeUnits(cs.MaxPeriodChurn),
+		cs.CurrentPeriodContractChurn, maxContractChurn)
+
+	if len(cs.RecentEvents) == 0 {
+		fmt.Println("\nNo recent churn events.")
+		return
+	}
+	fmt.Println("\nRecent Churn Events:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Height\tHost\tSize\tChurned\tReason")
+	for i := len(cs.RecentEvents) - 1; i >= 0; i-- {
+		e := cs.RecentEvents[i]
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", e.BlockHeight, e.HostPublicKey.String(), modules.FilesizeUnits(e.Size), yesNo(!e.Prevented), e.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		die(err)
+	}
+}
+
+// renterpaymentbudgetscmd is the handler for displaying the payment budgets of
+// third-party applications.
+func renterpaymentbudgetscmd() {
+	rpbg, err := httpClient.RenterPaymentBudgetsGet()
+	if err != nil {
+		die("Could not get payment budgets:", err)
+	}
+	if jsonOutput {
+		printJSON(rpbg)
+		return
+	}
+	if len(rpbg.Budgets) == 0 {
+		fmt.Println("No payment budgets.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tName\tSpent\tLimit\tPrograms\tExpiry")
+	for _, b := range rpbg.Budgets {
+		expiry := "never"
+		if !b.Expiry.IsZero() {
+			expiry = b.Expiry.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", b.ID, b.Name, currencyUnits(b.Spent), currencyUnits(b.Limit), b.Programs, expiry)
+	}
+	if err := w.Flush(); err != nil {
+		die(err)
+	}
+}
+
+// renterauditcmd is the handler for auditing the retrievability of uploaded
+// files.
+func renterauditcmd() {
+	samples := renterAuditSamples
+	if samples == 0 {
+		rag, err := httpClient.RenterAuditsGet()
+		if err != nil {
+			die("Could not get audit settings:", err)
+		}
+		samples = rag.Settings.Samples
+	}
+	report, err := httpClient.RenterAuditPost(samples)
+	if err != nil {
+		die("Could not audit files:", err)
+	}
+	if jsonOutput {
+		printJSON(report)
+		return
+	}
+	fmt.Printf("Audited %v chunks in %v.\n\n", report.Samples, report.End.Sub(report.Start).Round(time.Millisecond))
+	if len(report.Hosts) == 0 {
+		fmt.Println("No pieces were audited.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Host\tSampled\tPassed\tFailed\tLast Error")
+	for _, h := range report.Hosts {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", h.HostKey, h.Sampled, h.Passed, h.Failed, h.LastError)
+	}
+	if err := w.Flush(); err != nil {
+		die(err)
+	}
+	var unretrievable []modules.FileAuditResult
+	for _, f := range report.Files {
+		if f.ChunksRetrievable < f.ChunksSampled {
+			unretrievable = append(unretrievable, f)
+		}
+	}
+	if len(unretrievable) == 0 {
+		fmt.Println("\nAll sampled chunks are retrievable.")
+		return
+	}
+	fmt.Println("\nFiles with unretrievable chunks:")
+	w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Path\tRetrievable Chunks\tUnretrievable Chunks")
+	for _, f := range unretrievable {
+		fmt.Fprintf(w, "%v\t%v/%v\t%v\n", f.SiaPath, f.ChunksRetrievable, f.ChunksSampled, f.UnretrievableChunks)
+	}
+	if err := w.Flush(); err != nil {
+		die(err)
+	}
+}
+
 // renterhealthsummarycmd is the handler for displaying the overall health
 // summary for uploaded files.
 func renterhealthsummarycmd() {
@@ -523,6 +764,39 @@ func renteruploadscmd() {
 			filteredFiles = append(filteredFiles, fi)
 		}
 	}
+
+	// Filter out the URL uploads which completed successfully.
+	uploads, err := httpClient.RenterUploadURLGet()
+	if err != nil {
+		die("Could not get url uploads:", err)
+	}
+	var urlUploads []modules.URLUploadInfo
+	for _, u := range uploads.Uploads {
+		if !u.Completed || u.Error != "" {
+			urlUploads = append(urlUploads, u)
+		}
+	}
+
+	// Filter out the reshards which completed successfully.
+	reshards, err := httpClient.RenterReshardGet()
+	if err != nil {
+		die("Could not get reshards:", err)
+	}
+	var filteredReshards []modules.ReshardInfo
+	for _, rs := range reshards.Reshards {
+		if !rs.Completed || rs.Error != "" {
+			filteredReshards = append(filteredReshards, rs)
+		}
+	}
+	if jsonOutput {
+		printJSON(struct {
+			Files      []modules.FileInfo      `json:"files"`
+			URLUploads []modules.URLUploadInfo `json:"urluploads"`
+			Reshards   []modules.ReshardInfo   `json:"reshards"`
+		}{filteredFiles, urlUploads, filteredReshards})
+		return
+	}
+
 	if len(filteredFiles) == 0 {
 		fmt.Println("No files are uploading.")
 		return
@@ -531,6 +805,33 @@ func renteruploadscmd() {
 	for _, file := range filteredFiles {
 		fmt.Printf("%13s  %s (uploading, %0.2f%%)\n", modules.FilesizeUnits(file.Filesize), file.SiaPath, file.UploadProgress)
 	}
+
+	// Print the URL uploads which are still fetching data or failed.
+	if len(urlUploads) > 0 {
+		fmt.Println()
+		fmt.Println("URL uploads:")
+		for _, u := range urlUploads {
+			status := "fetching"
+			if u.Error != "" {
+				status = "failed: " + u.Error
+			}
+			fmt.Printf("%13s  %s from %s (%s)\n", modules.FilesizeUnits(u.Fetched), u.SiaPath, u.URL, status)
+		}
+	}
+
+	// Print the reshards which are still in progress or failed.
+	if len(filteredReshards) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Reshards:")
+	for _, rs := range filteredReshards {
+		status := "re-encoding"
+		if rs.Error != "" {
+			status = "failed: " + rs.Error
+		}
+		fmt.Printf("%v/%v chunks  %s to %v-of-%v (%s)\n", rs.ChunksReencoded, rs.NumChunks, rs.SiaPath, rs.DataPieces, rs.DataPieces+rs.ParityPieces, status)
+	}
 }
 
 // renterdownloadscmd is the handler for the command `siac renter downloads`.
@@ -541,6 +842,17 @@ func renterdownloadscmd() {
 	if err != nil {
 		die("Could not get download queue:", err)
 	}
+	if jsonOutput {
+		// Only include the downloaded files if the history was requested.
+		var downloads []api.DownloadInfo
+		for _, file := range queue.Downloads {
+			if !file.Completed || renterShowHistory {
+				downloads = append(downloads, file)
+			}
+		}
+		printJSON(downloads)
+		return
+	}
 	// Filter out files that have been downloaded.
 	var downloading []api.DownloadInfo
 	for _, file := range queue.Downloads {
@@ -585,6 +897,10 @@ func renterallowancecmd() {
 		die("Could not get allowance:", err)
 	}
 	allowance := rg.Settings.Allowance
+	if jsonOutput {
+		printJSON(allowance)
+		return
+	}
 
 	// Show allowance info
 	rate, err := types.ParseExchangeRate(build.ExchangeRate())
@@ -592,12 +908,26 @@ func renterallowancecmd() {
 		fmt.Printf("Warning: ignoring exchange rate - %s\n", err)
 	}
 
+	policy := allowance.RenewWindowPolicy
+	if policy == modules.RenewWindowPolicyStart {
+		policy = "start"
+	}
+	maxContractChurn := "no limit"
+	if allowance.MaxPeriodContractChurn != 0 {
+		maxContractChurn = fmt.Sprint(allowance.MaxPeriodContractChurn)
+	}
+
 	fmt.Printf(`Allowance:
   Amount:               %v
   Period:               %v blocks
   Renew Window:         %v blocks
+  Renew Window Policy:  %v
   Hosts:                %v
 
+Churn Limits:
+  Max Period Churn:     %v
+  Max Contract Churn:   %v
+
 Expectations for period:
   Expected Storage:     %v
   Expected Upload:      %v
@@ -612,7 +942,8 @@ Price Protections:
   MaxStoragePrice:           %v per TB per Month
   MaxUploadBandwidthPrice:   %v per TB
 `, currencyUnitsWithExchangeRate(allowance.Funds, rate), allowance.Period, allowance.RenewWindow,
-		allowance.Hosts,
+		policy, allowance.Hosts,
+		modules.FilesizeUnits(allowance.MaxPeriodChurn), maxContractChurn,
 		modules.FilesizeUnits(allowance.ExpectedStorage),
 		modules.FilesizeUnits(allowance.ExpectedUpload*uint64(allowance.Period)),
 		modules.FilesizeUnits(allowance.ExpectedDownload*uint64(allowance.Period)),
@@ -659,6 +990,137 @@ to be accessible after a short period of time.`)
 	fmt.Println("Allowance canceled.")
 }
 
+// renterallowanceplancmd is the handler for `siac renter allowance plan`. It
+// previews the contracts the renter would form for an allowance.
+func renterallowanceplancmd() {
+	var allowance modules.Allowance
+	if allowanceFunds != "" {
+		hastings, err := types.ParseCurrency(allowanceFunds)
+		if err != nil {
+			die("Could not parse amount:", err)
+		}
+		if _, err := fmt.Sscan(hastings, &allowance.Funds); err != nil {
+			die("Could not parse amount:", err)
+		}
+	}
+	if allowancePeriod != "" {
+		blocks, err := parsePeriod(allowancePeriod)
+		if err != nil {
+			die("Could not parse period:", err)
+		}
+		if _, err := fmt.Sscan(blocks, &allowance.Period); err != nil {
+			die("Could not parse period:", err)
+		}
+	}
+	if allowanceHosts != "" {
+		hosts, err := strconv.ParseUint(allowanceHosts, 10, 64)
+		if err != nil {
+			die("Could not parse host count:", err)
+		}
+		allowance.Hosts = hosts
+	}
+	if allowanceRenewWindow != "" {
+		rw, err := parsePeriod(allowanceRenewWindow)
+		if err != nil {
+			die("Could not parse renew window:", err)
+		}
+		if _, err := fmt.Sscan(rw, &allowance.RenewWindow); err != nil {
+			die("Could not parse renew window:", err)
+		}
+	}
+	plan, err := httpClient.RenterAllowancePlanGet(allowance)
+	if err != nil {
+		die("Could not create contract formation plan:", err)
+	}
+	if jsonOutput {
+		printJSON(plan)
+		return
+	}
+
+	fmt.Printf(`Contract Formation Plan:
+  Existing Contracts:  %v
+  Needed Contracts:    %v
+  Planned Contracts:   %v
+  Total Funding:       %v
+  Total Fees:          %v
+  Total Locked Funds:  %v
+  Host Collateral:     %v
+  Funds Remaining:     %v
+`, plan.ExistingContracts, plan.NeededContracts, len(plan.Contracts),
+		currencyUnits(plan.TotalFunding), currencyUnits(plan.TotalFees),
+		currencyUnits(plan.TotalLockedFunds), currencyUnits(plan.TotalHostCollateral),
+		currencyUnits(plan.FundsRemaining))
+	for _, warning := range plan.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+	if len(plan.Contracts) == 0 {
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Host\tStorage Price\tContract Price\tFunding\tFees")
+	for _, pc := range plan.Contracts {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", pc.NetAddress,
+			currencyUnits(pc.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte))+"/TB/Mo",
+			currencyUnits(pc.ContractPrice), currencyUnits(pc.Funding), currencyUnits(pc.Fees))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterallowanceforecastcmd is the handler for `siac renter allowance
+// forecast`. It displays the projected spending of the allowance.
+func renterallowanceforecastcmd() {
+	forecast, err := httpClient.RenterForecastGet()
+	if err != nil {
+		die("Could not create spending forecast:", err)
+	}
+	if jsonOutput {
+		printJSON(forecast)
+		return
+	}
+
+	fmt.Printf(`Spending Forecast:
+  Height:               %v
+  Period:               %v - %v
+  Allowance:            %v
+  Estimated Renewal:    %v
+  Wallet Funds:         %v
+`, forecast.BlockHeight, forecast.PeriodStart, forecast.PeriodEnd,
+		currencyUnits(forecast.Funds), currencyUnits(forecast.EstimatedRenewCost),
+		currencyUnits(forecast.WalletFunds))
+	if forecast.DepletionHeight != 0 {
+		fmt.Printf("  Depletion Height:     %v\n", forecast.DepletionHeight)
+	} else {
+		fmt.Printf("  Projected Remaining:  %v\n", currencyUnits(forecast.ProjectedRemaining))
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  \tSpent\tProjected")
+	rows := []struct {
+		name             string
+		spent, projected types.Currency
+	}{
+		{"Contract Fees", forecast.Spent.ContractFees, forecast.Projected.ContractFees},
+		{"Storage", forecast.Spent.StorageSpending, forecast.Projected.StorageSpending},
+		{"Upload", forecast.Spent.UploadSpending, forecast.Projected.UploadSpending},
+		{"Download", forecast.Spent.DownloadSpending, forecast.Projected.DownloadSpending},
+		{"Fund Account", forecast.Spent.FundAccountSpending, forecast.Projected.FundAccountSpending},
+		{"Maintenance", forecast.Spent.MaintenanceSpending, forecast.Projected.MaintenanceSpending},
+		{"Total", forecast.Spent.Total, forecast.Projected.Total},
+	}
+	for _, row := range rows {
+		fmt.Fprintf(w, "  %v\t%v\t%v\n", row.name, currencyUnits(row.spent), currencyUnits(row.projected))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	for _, warning := range forecast.Warnings {
+		fmt.Println("Warning:", warning)
+	}
+}
+
 // rentersetallowancecmd is the handler for `siac renter setallowance`.
 // set the allowance or modify individual allowance fields.
 func rentersetallowancecmd(_ *cobra.Command, _ []string) {
@@ -863,6 +1325,28 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		changedFields++
 	}
 
+	// parse maxperiodcontractchurn
+	if allowanceMaxPeriodContractChurn != "" {
+		maxContractChurn, err := strconv.ParseUint(allowanceMaxPeriodContractChurn, 10, 64)
+		if err != nil {
+			die("Could not parse max period contract churn:", err)
+		}
+		req = req.WithMaxPeriodContractChurn(maxContractChurn)
+		changedFields++
+	}
+	// parse renewwindowpolicy
+	if allowanceRenewWindowPolicy != "" {
+		policy := modules.RenewWindowPolicy(allowanceRenewWindowPolicy)
+		if policy == "start" {
+			policy = modules.RenewWindowPolicyStart
+		}
+		if !policy.IsValid() {
+			die("Unknown renew window policy:", allowanceRenewWindowPolicy)
+		}
+		req = req.WithRenewWindowPolicy(policy)
+		changedFields++
+	}
+
 	// check if any fields were updated.
 	if changedFields == 0 {
 		// If no fields were set then walk the user through the interactive
@@ -1382,6 +1866,9 @@ func renterbackuplistcmd() {
 	ubs, err := httpClient.RenterBackups()
 	if err != nil {
 		die("Failed to retrieve backups", err)
+	} else if jsonOutput {
+		printJSON(ubs.Backups)
+		return
 	} else if len(ubs.Backups) == 0 {
 		fmt.Println("No uploaded backups.")
 		return
@@ -1404,6 +1891,10 @@ func rentercontractscmd() {
 	if err != nil {
 		die("Could not get contracts:", err)
 	}
+	if jsonOutput {
+		printJSON(rc)
+		return
+	}
 
 	// Build Current Period summary
 	fmt.Println("Current Period Summary")
@@ -1515,6 +2006,15 @@ func rentercontractsviewcmd(cid string) {
 	contracts = append(contracts, rc.DisabledContracts...)
 	contracts = append(contracts, rc.ExpiredContracts...)
 	contracts = append(contracts, rc.ExpiredRefreshedContracts...)
+	if jsonOutput {
+		for _, c := range contracts {
+			if c.ID.String() == cid {
+				printJSON(c)
+				return
+			}
+		}
+		die("Contract not found")
+	}
 
 	err = printContractInfo(cid, contracts)
 	if err != nil {
@@ -1522,7 +2022,7 @@ func rentercontractsviewcmd(cid string) {
 	}
 }
 
-// renterfilesdownload downloads the dir at the given path from the Sia network
+// renterdirdownload downloads the dir at the given path from the Sia network
 // to the local specified destination.
 func renterdirdownload(path, destination string) {
 	destination = abs(destination)
@@ -1531,43 +2031,34 @@ func renterdirdownload(path, destination string) {
 	if err != nil {
 		die("Couldn't parse SiaPath:", err)
 	}
-	// If root is not set we need to rebase.
-	if !renterDownloadRoot {
-		siaPath, err = siaPath.Rebase(modules.RootSiaPath(), modules.UserFolder)
-		if err != nil {
-			die("Couldn't rebase SiaPath:", err)
-		}
-	}
 	// Download dir.
 	start := time.Now()
-	tfs, skipped, totalSize, downloadErr := downloadDir(siaPath, destination)
-	if renterDownloadAsync && downloadErr != nil {
-		fmt.Println("At least one error occurred when initializing the download:", downloadErr)
+	rdt, err := httpClient.RenterDownloadDirGet(siaPath, destination, renterDownloadRecursive, renterDownloadRoot, false)
+	if err != nil {
+		die("Failed to download folder:", err)
 	}
 	// If the download is async, report success.
 	if renterDownloadAsync {
-		fmt.Printf("Queued Download '%s' to %s.\n", siaPath.String(), abs(destination))
-		return
-	}
-	// If the download is blocking, display progress as the file downloads.
-	failedDownloads := downloadProgress(tfs)
-	// Print skipped files.
-	for _, s := range skipped {
-		fmt.Printf("Skipped file '%v' since it already exists\n", s)
-	}
-	// Handle potential errors.
-	if len(failedDownloads) == 0 {
-		fmt.Printf("\nDownloaded '%s' to '%s - %v in %v'.\n", path, abs(destination), modules.FilesizeUnits(totalSize), time.Since(start).Round(time.Millisecond))
+		fmt.Printf("Queued Download '%s' to %s.\n", path, destination)
+		fmt.Println("Transfer ID:", rdt.ID)
 		return
 	}
-	// Print errors.
-	if downloadErr != nil {
-		fmt.Println("At least one error occurred when initializing the download:", downloadErr)
+	// If the download is blocking, display progress as the files download.
+	rdt = dirTransferProgress(rdt.ID)
+	// Print skipped files and errors.
+	var failed bool
+	for _, f := range rdt.Files {
+		if f.Skipped {
+			fmt.Printf("Skipped file '%v' since it already exists\n", f.LocalPath)
+		} else if f.Error != "" {
+			fmt.Printf("Download of file '%v' to destination '%v' failed: %v\n", f.SiaPath, f.LocalPath, f.Error)
+			failed = true
+		}
 	}
-	for _, fd := range failedDownloads {
-		fmt.Printf("Download of file '%v' to destination '%v' failed: %v\n", fd.SiaPath, fd.Destination, fd.Error)
+	if failed {
+		exit(exitCodeGeneral)
 	}
-	os.Exit(1)
+	fmt.Printf("\nDownloaded '%s' to '%s - %v in %v'.\n", path, destination, modules.FilesizeUnits(rdt.TotalBytes), time.Since(start).Round(time.Millisecond))
 }
 
 // renterdownloadcancelcmd is the handler for the command `siac renter download cancel [cancelID]`
@@ -1583,6 +2074,20 @@ func renterdownloadcancelcmd(cancelID modules.DownloadID) {
 // Removes the specified path from the Sia network.
 func renterfilesdeletecmd(cmd *cobra.Command, paths []string) {
 	for _, path := range paths {
+		if isGlobPattern(path) {
+			rbp, err := httpClient.RenterBulkDeletePost(path, renterDeleteRoot, renterBulkDryRun)
+			if err != nil {
+				die(fmt.Sprintf("Failed to delete files matching %v: %v", path, err))
+			}
+			printBulkResult(rbp, "Deleted")
+			continue
+		}
+		wrapJSONOutput()
+		if renterBulkDryRun {
+			fmt.Printf("Would delete '%v'\n", path)
+			continue
+		}
+
 		// Parse SiaPath.
 		siaPath, err := modules.NewSiaPath(path)
 		if err != nil {
@@ -1631,6 +2136,20 @@ func renterfilesdeletecmd(cmd *cobra.Command, paths []string) {
 // [path] [destination]`. It determines whether a file or a folder is downloaded
 // and calls the corresponding sub-handler.
 func renterfilesdownloadcmd(path, destination string) {
+	if isGlobPattern(path) {
+		destination = abs(destination)
+		rbp, err := httpClient.RenterBulkDownloadPost(path, destination, renterDownloadRoot, renterBulkDryRun)
+		if err != nil {
+			die(fmt.Sprintf("Failed to download files matching %v: %v", path, err))
+		}
+		printBulkResult(rbp, "Queued the download of")
+		return
+	}
+	wrapJSONOutput()
+	if renterBulkDryRun {
+		fmt.Printf("Would download '%v'\n", path)
+		return
+	}
 	// Parse SiaPath.
 	siaPath, err := modules.NewSiaPath(path)
 	if err != nil {
@@ -1660,6 +2179,22 @@ func renterfilesdownloadcmd(path, destination string) {
 	die(fmt.Sprintf("Unknown path '%v'", path))
 }
 
+// rentertransfercmd is the handler for the command `siac renter transfer
+// [id]`. It displays the progress of a folder upload or download.
+func rentertransfercmd(id string) {
+	rdt := dirTransferProgress(id)
+	if jsonOutput {
+		printJSON(rdt)
+		return
+	}
+	fmt.Printf("%v of %v files completed, %v failed, %v skipped.\n", rdt.CompletedFiles, rdt.TotalFiles, rdt.FailedFiles, rdt.SkippedFiles)
+	for _, f := range rdt.Files {
+		if f.Error != "" {
+			fmt.Printf("Transfer of file '%v' failed: %v\n", f.SiaPath, f.Error)
+		}
+	}
+}
+
 // rentertriggercontractrecoveryrescancmd starts a new scan for recoverable
 // contracts on the blockchain.
 func rentertriggercontractrecoveryrescancmd() {
@@ -1687,10 +2222,32 @@ func rentercontractrecoveryscanprogresscmd() {
 	}
 	if crpg.ScanInProgress {
 		fmt.Println("Scan in progress")
-		fmt.Println("Scanned height:\t", crpg.ScannedHeight)
+		fmt.Printf("Scanned height:\t %v / %v\n", crpg.ScannedHeight, crpg.TargetHeight)
 	} else {
 		fmt.Println("No scan in progress")
 	}
+	fmt.Println("Contracts found:\t", crpg.ContractsFound)
+	fmt.Println("Contracts recovered:\t", crpg.ContractsRecovered)
+	fmt.Println("Contracts pending:\t", crpg.PendingContracts)
+
+	// List the contracts that failed to be recovered.
+	rc, err := httpClient.RenterUnrecoverableContractsGet()
+	if err != nil {
+		die("Failed to get unrecoverable contracts", err)
+	}
+	if len(rc.UnrecoverableContracts) == 0 {
+		return
+	}
+	fmt.Println()
+	fmt.Println("Unrecoverable contracts:")
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tHost PubKey\tAttempts\tReason")
+	for _, uc := range rc.UnrecoverableContracts {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", uc.ID, uc.HostPublicKey, uc.FailedAttempts, uc.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
 }
 
 // renterfileslistcmd is the handler for the command `siac renter ls`. Lists
@@ -1704,7 +2261,7 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 		path = args[0]
 	default:
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 	// Parse the input siapath.
 	var sp modules.SiaPath
@@ -1726,7 +2283,10 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 		} else {
 			rf, err = httpClient.RenterFileGet(sp)
 		}
-		if err == nil {
+		if err == nil && jsonOutput {
+			printJSON(rf.File)
+			return
+		} else if err == nil {
 			json, err := json.MarshalIndent(rf.File, "", "  ")
 			if err != nil {
 				log.Fatal(err)
@@ -1743,6 +2303,15 @@ func renterfileslistcmd(cmd *cobra.Command, args []string) {
 
 	// Get dirs with their corresponding files.
 	dirs := getDir(sp, renterListRoot, renterListRecursive)
+	if jsonOutput {
+		var rd api.RenterDirectory
+		for _, dir := range dirs {
+			rd.Directories = append(rd.Directories, dir.dir)
+			rd.Files = append(rd.Files, dir.files...)
+		}
+		printJSON(rd)
+		return
+	}
 
 	// Sort the directories and the files.
 	sort.Sort(byDirectoryInfo(dirs))
@@ -2019,40 +2588,26 @@ func renterfilesuploadcmd(source, path string) {
 
 	if stat.IsDir() {
 		// folder
-		var files []string
-		err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				fmt.Println("Warning: skipping file:", err)
-				return nil
-			}
-			if info.IsDir() {
-				return nil
-			}
-			files = append(files, path)
-			return nil
-		})
+		if renterUploadSparse {
+			die("--sparse is only supported for single files")
+		}
+		siaPath, err := modules.NewSiaPath(path)
 		if err != nil {
-			die("Could not read folder:", err)
-		} else if len(files) == 0 {
+			die("Couldn't parse SiaPath:", err)
+		}
+		rdt, err := httpClient.RenterUploadDirPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces), false)
+		if err != nil {
+			die("Could not upload folder:", err)
+		} else if rdt.TotalFiles == 0 {
 			die("Nothing to upload.")
 		}
-		failed := 0
-		for _, file := range files {
-			fpath, _ := filepath.Rel(source, file)
-			fpath = filepath.Join(path, fpath)
-			fpath = filepath.ToSlash(fpath)
-			// Parse SiaPath.
-			fSiaPath, err := modules.NewSiaPath(fpath)
-			if err != nil {
-				die("Couldn't parse SiaPath:", err)
-			}
-			err = httpClient.RenterUploadPost(abs(file), fSiaPath, uint64(numDataPieces), uint64(numParityPieces))
-			if err != nil {
-				failed++
-				fmt.Printf("Could not upload file %s :%v\n", file, err)
+		for _, f := range rdt.Files {
+			if f.Error != "" {
+				fmt.Printf("Could not upload file %s :%v\n", f.LocalPath, f.Error)
 			}
 		}
-		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", len(files)-failed, len(files), path)
+		fmt.Printf("\nUploaded %d of %d files into '%s'.\n", rdt.TotalFiles-rdt.FailedFiles, rdt.TotalFiles, path)
+		fmt.Println("Transfer ID:", rdt.ID)
 	} else {
 		// single file
 		// Parse SiaPath.
@@ -2060,7 +2615,11 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse SiaPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		if renterUploadSparse {
+			err = httpClient.RenterUploadSparsePost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		} else {
+			err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		}
 		if err != nil {
 			die("Could not upload file:", err)
 		}
@@ -2068,6 +2627,61 @@ func renterfilesuploadcmd(source, path string) {
 	}
 }
 
+// renteruploadurlcmd is the handler for the command `siac renter uploadurl
+// [url] [path]`. It has the renter upload a file from a URL.
+func renteruploadurlcmd(sourceURL, path string) {
+	// Check for and parse any redundancy settings
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	var maxSize uint64
+	if renterUploadURLMaxSize != "" {
+		size, err := parseFilesize(renterUploadURLMaxSize)
+		if err != nil {
+			die("Could not parse max size:", err)
+		}
+		_, err = fmt.Sscan(size, &maxSize)
+		if err != nil {
+			die("Could not parse max size:", err)
+		}
+	}
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	err = httpClient.RenterUploadURLPost(sourceURL, siaPath, uint64(numDataPieces), uint64(numParityPieces), maxSize, false, renterUploadURLAsync)
+	if err != nil {
+		die("Could not upload file:", err)
+	}
+	if renterUploadURLAsync {
+		fmt.Printf("Started uploading '%s' as '%s'.\n", sourceURL, path)
+		return
+	}
+	fmt.Printf("Uploaded '%s' as '%s'.\n", sourceURL, path)
+}
+
+// renterreshardcmd is the handler for the command `siac renter reshard
+// [path]`. It changes the erasure coding parameters of a file.
+func renterreshardcmd(path string) {
+	if dataPieces == "" || parityPieces == "" {
+		die("Both --data-pieces and --parity-pieces must be provided")
+	}
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		die("Couldn't parse SiaPath:", err)
+	}
+	err = httpClient.RenterReshardPost(siaPath, uint64(numDataPieces), uint64(numParityPieces))
+	if err != nil {
+		die("Could not reshard file:", err)
+	}
+	fmt.Printf("Started resharding '%s' to %v data and %v parity pieces.\n", path, numDataPieces, numParityPieces)
+}
+
 // renterfilesuploadpausecmd is the handler for the command `siac renter upload
 // pause`.  It pauses all renter uploads for the duration (in minutes)
 // passed in.
@@ -2093,6 +2707,71 @@ func renterfilesuploadresumecmd() {
 	fmt.Println("Renter uploads have been resumed")
 }
 
+// renterrepaircmd is the handler for the command `siac renter repair`. It
+// displays the renter's repair backlog.
+func renterrepaircmd() {
+	backlog, err := httpClient.RenterRepairGet()
+	if err != nil {
+		die("Could not get the repair backlog:", err)
+	}
+	switch {
+	case !backlog.Paused:
+		fmt.Println("Repairs are running.")
+	case backlog.PauseEndTime.IsZero():
+		fmt.Printf("Repairs are paused since %v until they are resumed.\n", backlog.PauseStart.Format(time.RFC822))
+	default:
+		fmt.Printf("Repairs are paused since %v until %v.\n", backlog.PauseStart.Format(time.RFC822), backlog.PauseEndTime.Format(time.RFC822))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\nQueued Chunks:\t%v\n", backlog.QueuedChunks)
+	fmt.Fprintf(w, "Repairing Chunks:\t%v\n", backlog.RepairingChunks)
+	fmt.Fprintf(w, "Stuck Chunks:\t%v\n", backlog.StuckChunks)
+	fmt.Fprintf(w, "Repair Size:\t%v\n", modules.FilesizeUnits(backlog.RepairBytes))
+	fmt.Fprintf(w, "Stuck Size:\t%v\n", modules.FilesizeUnits(backlog.StuckBytes))
+	fmt.Fprintf(w, "Estimated Cost:\t%v\n", currencyUnits(backlog.EstimatedCost))
+	fmt.Fprintf(w, "Last Health Check:\t%v\n", backlog.LastHealthCheckTime.Format(time.RFC822))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterrepairpausecmd is the handler for the command `siac renter repair
+// pause`. It pauses the repair of existing files.
+func renterrepairpausecmd(cmd *cobra.Command, args []string) {
+	var duration time.Duration
+	switch len(args) {
+	case 0:
+	case 1:
+		var err error
+		duration, err = time.ParseDuration(args[0])
+		if err != nil {
+			die("Couldn't parse duration:", err)
+		}
+	default:
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	err := httpClient.RenterRepairPausePost(duration)
+	if err != nil {
+		die("Could not pause repairs:", err)
+	}
+	if duration == 0 {
+		fmt.Println("Repairs have been paused until they are resumed")
+		return
+	}
+	fmt.Println("Repairs have been paused for", duration)
+}
+
+// renterrepairresumecmd is the handler for the command `siac renter repair
+// resume`. It resumes the repair of existing files.
+func renterrepairresumecmd() {
+	err := httpClient.RenterRepairResumePost()
+	if err != nil {
+		die("Could not resume repairs:", err)
+	}
+	fmt.Println("Repairs have been resumed")
+}
+
 // renterpricescmd is the handler for the command `siac renter prices`, which
 // displays the prices of various storage operations. The user can submit an
 // allowance to have the estimate reflect those settings or the user can submit
@@ -2102,7 +2781,7 @@ func renterpricescmd(cmd *cobra.Command, args []string) {
 
 	if len(args) != 0 && len(args) != 4 {
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 	if len(args) > 0 {
 		hastings, err := types.ParseCurrency(args[0])
@@ -2198,6 +2877,10 @@ func renterworkerscmd() {
 	if err != nil {
 		die("Could not get contracts:", err)
 	}
+	if jsonOutput {
+		printJSON(rw)
+		return
+	}
 
 	// Sort workers by public key.
 	sort.Slice(rw.Workers, func(i, j int) bool {
@@ -2278,7 +2961,7 @@ func renterworkerseacmd() {
 
 	// print header
 	hostInfo := "Host PubKey"
-	accountInfo := "\tAvailBal\tNegBal\tTargetBal"
+	accountInfo := "\tAvailBal\tNegBal\tTargetBal\tRefillAt\tDrift+\tDrift-\tRefills"
 	errorInfo := "\tSucceededAt\tErrorAt\tError"
 	header := hostInfo + accountInfo + errorInfo
 	fmt.Fprintln(w, "\nWorker Accounts Detail  \n\n"+header)
@@ -2291,10 +2974,14 @@ func renterworkerseacmd() {
 		fmt.Fprintf(w, "%v", worker.HostPubKey.String())
 
 		// Account Info
-		fmt.Fprintf(w, "\t%s\t%s\t%s",
+		fmt.Fprintf(w, "\t%s\t%s\t%s\t%s\t%s\t%s\t%v",
 			as.AvailableBalance.HumanString(),
 			as.NegativeBalance.HumanString(),
-			worker.AccountBalanceTarget.HumanString())
+			worker.AccountBalanceTarget.HumanString(),
+			worker.AccountRefillThreshold.HumanString(),
+			as.BalanceDriftPositive.HumanString(),
+			as.BalanceDriftNegative.HumanString(),
+			as.NumRefills)
 
 		// Error Info
 		fmt.Fprintf(w, "\t%v\t%v\t%v\n",
@@ -2365,7 +3052,7 @@ func renterworkersptcmd() {
 
 	// print header
 	hostInfo := "Host PubKey"
-	priceTableInfo := "\tActive\tExpiry\tUpdate"
+	priceTableInfo := "\tActive\tAge\tExpiry\tUpdate"
 	queueInfo := "\tErrorAt\tError"
 	header := hostInfo + priceTableInfo + queueInfo
 	fmt.Fprintln(w, "\nWorker Price Tables Detail  \n\n"+header)
@@ -2378,8 +3065,9 @@ func renterworkersptcmd() {
 		fmt.Fprintf(w, "%v", worker.HostPubKey.String())
 
 		// Price Table Info
-		fmt.Fprintf(w, "\t%t\t%s\t%s",
+		fmt.Fprintf(w, "\t%t\t%v\t%s\t%s",
 			pts.Active,
+			pts.Age.Round(time.Second),
 			sanitizeTime(pts.ExpiryTime, pts.Active),
 			sanitizeTime(pts.UpdateTime, pts.Active))
 
@@ -2505,6 +3193,84 @@ func renterworkersuploadscmd() {
 	writeWorkerDownloadUploadInfo(false, w, rw)
 }
 
+// renterworkersviewcmd is the handler for the command `siac renter workers
+// view [pubkey]`. It prints the detailed status of a single worker.
+func renterworkersviewcmd(pubkey string) {
+	var hostPubKey types.SiaPublicKey
+	if err := hostPubKey.LoadString(pubkey); err != nil {
+		die("Could not parse host pubkey:", err)
+	}
+	ws, err := httpClient.RenterWorkerGet(hostPubKey)
+	if err != nil {
+		die("Could not get worker status:", err)
+	}
+	if jsonOutput {
+		printJSON(ws)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	defer func() {
+		err := w.Flush()
+		if err != nil {
+			die("Could not flush tabwriter:", err)
+		}
+	}()
+
+	// print contract and account info
+	as := ws.AccountStatus
+	fmt.Fprintf(w, "Host PubKey:\t%v\n", ws.HostPubKey.String())
+	fmt.Fprintf(w, "Contract ID:\t%v\n", ws.ContractID)
+	fmt.Fprintf(w, "GoodForUpload:\t%v\n", ws.ContractUtility.GoodForUpload)
+	fmt.Fprintf(w, "GoodForRenew:\t%v\n", ws.ContractUtility.GoodForRenew)
+	fmt.Fprintf(w, "Account Balance:\t%v\n", as.AvailableBalance.HumanString())
+	fmt.Fprintf(w, "Account Target:\t%v\n", ws.AccountBalanceTarget.HumanString())
+	fmt.Fprintf(w, "Account Error:\t%v\n", sanitizeErr(as.RecentErr))
+
+	// print price table info
+	pts := ws.PriceTableStatus
+	fmt.Fprintf(w, "Price Table Active:\t%v\n", pts.Active)
+	fmt.Fprintf(w, "Price Table Fetched:\t%v\n", sanitizeTime(pts.FetchTime, !pts.FetchTime.IsZero()))
+	fmt.Fprintf(w, "Price Table Age:\t%v\n", pts.Age.Round(time.Second))
+	fmt.Fprintf(w, "Price Table Error:\t%v\n", sanitizeErr(pts.RecentErr))
+
+	// print cooldowns
+	cooldown := func(onCooldown bool, d time.Duration, errStr string) string {
+		if !onCooldown {
+			return "-"
+		}
+		return fmt.Sprintf("%v (%v)", d.Round(time.Second), sanitizeErr(errStr))
+	}
+	fmt.Fprintf(w, "Download Cooldown:\t%v\n", cooldown(ws.DownloadOnCoolDown, ws.DownloadCoolDownTime, ws.DownloadCoolDownError))
+	fmt.Fprintf(w, "Upload Cooldown:\t%v\n", cooldown(ws.UploadOnCoolDown, ws.UploadCoolDownTime, ws.UploadCoolDownError))
+	fmt.Fprintf(w, "Maintenance Cooldown:\t%v\n", cooldown(ws.MaintenanceOnCooldown, ws.MaintenanceCoolDownTime, ws.MaintenanceCoolDownError))
+
+	// print job queues
+	fmt.Fprintln(w, "\nJob Queue\tJobs\tCooldownUntil\tConsecFail\tRecentJobTimes (ms)\tErrorAt\tError")
+	queue := func(name string, size uint64, onCooldown bool, cooldownUntil time.Time, consecutiveFailures uint64, jobTimes []uint64, errTime time.Time, errStr string) {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			name,
+			size,
+			sanitizeTime(cooldownUntil, onCooldown),
+			consecutiveFailures,
+			jobTimes,
+			sanitizeTime(errTime, errStr != ""),
+			sanitizeErr(errStr))
+	}
+	rjs := ws.ReadJobsStatus
+	queue("Read", rjs.JobQueueSize, rjs.OnCooldown, rjs.OnCooldownUntil, rjs.ConsecutiveFailures, rjs.RecentJobTimes, rjs.RecentErrTime, rjs.RecentErr)
+	hsjs := ws.HasSectorJobsStatus
+	queue("HasSector", hsjs.JobQueueSize, hsjs.OnCooldown, hsjs.OnCooldownUntil, hsjs.ConsecutiveFailures, hsjs.RecentJobTimes, hsjs.RecentErrTime, hsjs.RecentErr)
+	rrjs := ws.ReadRegistryJobsStatus
+	queue("ReadRegistry", rrjs.JobQueueSize, rrjs.OnCooldown, rrjs.OnCooldownUntil, rrjs.ConsecutiveFailures, rrjs.RecentJobTimes, rrjs.RecentErrTime, rrjs.RecentErr)
+	urjs := ws.UpdateRegistryJobsStatus
+	queue("UpdateRegistry", urjs.JobQueueSize, urjs.OnCooldown, urjs.OnCooldownUntil, urjs.ConsecutiveFailures, urjs.RecentJobTimes, urjs.RecentErrTime, urjs.RecentErr)
+	fmt.Fprintf(w, "Download\t%v\n", ws.DownloadQueueSize)
+	fmt.Fprintf(w, "Upload\t%v\n", ws.UploadQueueSize)
+	fmt.Fprintf(w, "DownloadSnapshot\t%v\n", ws.DownloadSnapshotJobQueueSize)
+	fmt.Fprintf(w, "UploadSnapshot\t%v\n", ws.UploadSnapshotJobQueueSize)
+}
+
 // writeWorkers is a helper function to display workers
 func writeWorkers(workers []modules.WorkerStatus) {
 	fmt.Println("  Number of Workers:", len(workers))
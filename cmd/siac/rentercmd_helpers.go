@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -96,60 +97,36 @@ func contractStats(contracts []api.RenterContract) (size uint64, spent, remainin
 	return
 }
 
-// downloadDir downloads the dir at the specified siaPath to the specified
-// location. It returns all the files for which a download was initialized as
-// tracked files and the ones which were ignored as skipped. Errors are composed
-// into a single error.
-func downloadDir(siaPath modules.SiaPath, destination string) (tfs []trackedFile, skipped []string, totalSize uint64, err error) {
-	// Get dir info.
-	rd, err := httpClient.RenterDirRootGet(siaPath)
-	if err != nil {
-		err = errors.AddContext(err, "failed to get dir info")
-		return
-	}
-	// Create destination on disk.
-	if err = os.MkdirAll(destination, 0750); err != nil {
-		err = errors.AddContext(err, "failed to create destination dir")
-		return
-	}
-	// Download files.
-	for _, file := range rd.Files {
-		// Skip files that already exist.
-		dst := filepath.Join(destination, file.SiaPath.Name())
-		if _, err = os.Stat(dst); err == nil {
-			skipped = append(skipped, dst)
+// dirTransferProgress displays the progress of a directory transfer until it
+// is completed and returns its final status. The progress isn't displayed if
+// the --json flag is set.
+func dirTransferProgress(id string) api.RenterDirTransfer {
+	for range time.Tick(OutputRefreshRate) {
+		rdt, err := httpClient.RenterDirTransferGet(id)
+		if err != nil {
+			die("Could not get the progress of the transfer:", err)
+		}
+		if jsonOutput {
+			if rdt.Completed {
+				return rdt
+			}
 			continue
-		} else if !os.IsNotExist(err) {
-			err = errors.AddContext(err, "failed to get file stats")
-			return
 		}
-		// Download file.
-		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.SiaPath, dst, true, true)
-		if err != nil {
-			err = errors.AddContext(err, "Failed to start download")
-			return
+		done := rdt.CompletedFiles + rdt.FailedFiles + rdt.SkippedFiles
+		elapsed := time.Since(rdt.StartTime)
+		elapsed -= elapsed % time.Second // round to nearest second
+		verb := "Downloading"
+		if rdt.Type == "upload" {
+			verb = "Uploading"
+		}
+		fmt.Printf("\r%v %v... %5.1f%% of %v, %v/%v files, %v elapsed    ", verb, rdt.SiaPath, rdt.Progress, modules.FilesizeUnits(rdt.TotalBytes), done, rdt.TotalFiles, elapsed)
+		if rdt.Completed {
+			fmt.Println()
+			return rdt
 		}
-		// Append file to tracked files.
-		tfs = append(tfs, trackedFile{
-			siaPath: file.SiaPath,
-			dst:     dst,
-		})
-	}
-	// If the download isn't recursive we are done.
-	if !renterDownloadRecursive {
-		return
-	}
-	// Call downloadDir on all subdirs.
-	for i := 1; i < len(rd.Directories); i++ {
-		subDir := rd.Directories[i]
-		rtfs, rskipped, totalSubSize, rerr := downloadDir(subDir.SiaPath, filepath.Join(destination, subDir.SiaPath.Name()))
-		tfs = append(tfs, rtfs...)
-		skipped = append(skipped, rskipped...)
-		totalSize += totalSubSize
-		err = errors.Compose(err, rerr)
 	}
-	return
+	// This code is unreachable, but the compiler requires this to be here.
+	return api.RenterDirTransfer{}
 }
 
 // downloadProgress will display the progress of the provided files and return a
@@ -362,6 +339,40 @@ func getDir(siaPath modules.SiaPath, root, recursive bool) (dirs []directoryInfo
 	return
 }
 
+// isGlobPattern returns true if a path contains wildcards and should be matched
+// against the files of the renter.
+func isGlobPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// printBulkResult prints the files matched by a bulk operation and the files
+// the operation failed for. The verb describes the action applied to the
+// files.
+func printBulkResult(rbp api.RenterBulkPOST, verb string) {
+	if jsonOutput {
+		printJSON(rbp)
+		return
+	}
+	if len(rbp.SiaPaths) == 0 {
+		fmt.Println("No files match the pattern")
+		return
+	}
+	if renterBulkDryRun {
+		for _, sp := range rbp.SiaPaths {
+			fmt.Println(sp)
+		}
+		fmt.Printf("%v files match the pattern\n", len(rbp.SiaPaths))
+		return
+	}
+	for _, e := range rbp.Errors {
+		fmt.Printf("Failed '%v': %v\n", e.SiaPath, e.Error)
+	}
+	fmt.Printf("%v %v of %v files\n", verb, len(rbp.SiaPaths)-len(rbp.Errors), len(rbp.SiaPaths))
+	if len(rbp.Errors) > 0 {
+		exit(exitCodeGeneral)
+	}
+}
+
 // printContractInfo is a helper function for printing the information about a
 // specific contract
 func printContractInfo(cid string, contracts []api.RenterContract) error {
@@ -631,6 +642,9 @@ func writeWorkerDownloadUploadInfo(download bool, w *tabwriter.Writer, rw module
 	// print header
 	hostInfo := "Host PubKey"
 	info := "\tOn Cooldown\tCooldown Time\tLast Error\tQueue\tTerminated"
+	if download {
+		info += "\tStalls"
+	}
 	header := hostInfo + info
 	if download {
 		fmt.Fprintln(w, "\nWorker Downloads Detail  \n\n"+header)
@@ -645,12 +659,13 @@ func writeWorkerDownloadUploadInfo(download bool, w *tabwriter.Writer, rw module
 
 		// Download Info
 		if download {
-			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\n",
+			fmt.Fprintf(w, "\t%v\t%v\t%v\t%v\t%v\t%v\n",
 				worker.DownloadOnCoolDown,
 				absDuration(worker.DownloadCoolDownTime),
 				sanitizeErr(worker.DownloadCoolDownError),
 				worker.DownloadQueueSize,
-				worker.DownloadTerminated)
+				worker.DownloadTerminated,
+				worker.DownloadStalls)
 			continue
 		}
 		// Upload Info
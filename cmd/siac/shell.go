@@ -0,0 +1,333 @@
+package main
+
+// shell.go implements an interactive shell that runs siac commands.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// shellPrompt is the prompt displayed by the interactive shell.
+	shellPrompt = "siac> "
+)
+
+var (
+	// errUnterminatedQuote is returned when a shell command has an
+	// unterminated quote.
+	errUnterminatedQuote = errors.New("unterminated quote")
+
+	// shellActive indicates that commands are run by the interactive shell.
+	shellActive bool
+)
+
+var (
+	shellCmd = &cobra.Command{
+		Use:   "shell",
+		Short: "Start an interactive shell",
+		Long: `Start an interactive shell that runs siac commands without the 'siac' prefix.
+The global flags passed to the shell apply to every command. Previous commands
+are available with the up and down arrows and the 'history' command, Tab
+completes commands, siapaths and host keys. Type 'exit' or press Ctrl-D to
+leave the shell.
+
+If stdin is not a terminal, commands are read line by line, which allows
+running scripts of siac commands.`,
+		Run: shellcmd,
+	}
+)
+
+// shellcmd is the handler for the command `siac shell`.
+// Runs siac commands read from stdin until exit is called.
+func shellcmd(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	root := cmd.Root()
+
+	// Remember the global flags so that every command starts with them.
+	globalFlags := make(map[string]string)
+	root.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		globalFlags[f.Name] = f.Value.String()
+	})
+
+	readLine, closeFn := newShellReader(root)
+	defer closeFn()
+
+	shellActive = true
+	defer func() {
+		shellActive = false
+	}()
+	var history []string
+	for {
+		line, err := readLine()
+		if errors.Contains(err, io.EOF) {
+			return
+		} else if err != nil {
+			die("Could not read command:", err)
+		}
+		args, err := splitShellArgs(line)
+		if err != nil {
+			fmt.Println("Could not parse command:", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		history = append(history, line)
+
+		switch args[0] {
+		case "exit", "quit":
+			return
+		case "history":
+			for i, line := range history {
+				fmt.Printf("%5d  %v\n", i+1, line)
+			}
+			continue
+		case cmd.Name():
+			fmt.Println("Already in the shell")
+			continue
+		}
+		runShellCommand(root, args, globalFlags)
+	}
+}
+
+// newShellReader returns a function reading the commands of the shell. If
+// stdin is a terminal, the commands can be edited with history and
+// autocompletion. The returned close function restores the terminal.
+func newShellReader(root *cobra.Command) (readLine func() (string, error), closeFn func()) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		scanner := bufio.NewScanner(os.Stdin)
+		readLine = func() (string, error) {
+			if !scanner.Scan() {
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			return scanner.Text(), nil
+		}
+		return readLine, func() {}
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, shellPrompt)
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		return completeShellLine(root, line, pos, key)
+	}
+	readLine = func() (string, error) {
+		// The terminal is only in raw mode while reading so that the output
+		// of the commands is printed normally.
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = term.Restore(fd, state)
+		}()
+		if width, height, err := term.GetSize(fd); err == nil {
+			_ = t.SetSize(width, height)
+		}
+		return t.ReadLine()
+	}
+	return readLine, func() { fmt.Println() }
+}
+
+// runShellCommand runs a siac command in the shell. The flags of all commands
+// are reset first so that they don't carry over from previous commands.
+func runShellCommand(root *cobra.Command, args []string, globalFlags map[string]string) {
+	defer func() {
+		// Recover from the panic passed by die so that the shell continues.
+		if rec := recover(); rec != nil && rec != errDiePanic {
+			panic(rec)
+		}
+	}()
+	resetFlags(root, globalFlags)
+	root.SetArgs(args)
+	_ = root.Execute()
+}
+
+// resetFlags sets the flags of a command and its subcommands back to their
+// default values, and the global flags to the provided values.
+func resetFlags(root *cobra.Command, globalFlags map[string]string) {
+	var reset func(cmd *cobra.Command)
+	reset = func(cmd *cobra.Command) {
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if root.PersistentFlags().Lookup(f.Name) == f {
+				return
+			}
+			_ = f.Value.Set(f.DefValue)
+			f.Changed = false
+		})
+		for _, sub := range cmd.Commands() {
+			reset(sub)
+		}
+	}
+	reset(root)
+	root.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		_ = f.Value.Set(globalFlags[f.Name])
+		f.Changed = false
+	})
+}
+
+// splitShellArgs splits a line of the shell into arguments. Arguments are
+// separated by whitespace and may be quoted with single or double quotes.
+func splitShellArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errUnterminatedQuote
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// completeShellLine completes the word before the cursor when Tab is pressed.
+// If there are several candidates, the word is completed up to their common
+// prefix.
+func completeShellLine(root *cobra.Command, line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+	prefix, suffix := line[:pos], line[pos:]
+	fields := strings.Fields(prefix)
+	var word string
+	if len(fields) > 0 && !strings.HasSuffix(prefix, " ") {
+		word = fields[len(fields)-1]
+		fields = fields[:len(fields)-1]
+	}
+
+	var matches []string
+	for _, c := range shellCompletions(root, fields, word) {
+		if strings.HasPrefix(c, word) {
+			matches = append(matches, c)
+		}
+	}
+	if len(matches) == 0 {
+		return "", 0, false
+	}
+	sort.Strings(matches)
+	completion := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(m, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(matches) == 1 && !strings.HasSuffix(completion, "/") {
+		completion += " "
+	}
+	if completion == word {
+		return "", 0, false
+	}
+	newPrefix := prefix[:len(prefix)-len(word)] + completion
+	return newPrefix + suffix, len(newPrefix), true
+}
+
+// shellCompletions returns the candidates for completing a word of the shell
+// following the given arguments. Subcommands are completed for commands
+// that have them, host keys for `hostdb view` and siapaths for the renter
+// commands.
+func shellCompletions(root *cobra.Command, fields []string, word string) []string {
+	cmd, args, err := root.Find(fields)
+	if err != nil {
+		return nil
+	}
+	if len(args) == 0 && cmd.HasAvailableSubCommands() {
+		var names []string
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() {
+				names = append(names, sub.Name())
+			}
+		}
+		return names
+	}
+	if cmd == hostdbViewCmd {
+		hosts, err := httpClient.HostDbAllGet()
+		if err != nil {
+			return nil
+		}
+		keys := make([]string, 0, len(hosts.Hosts))
+		for _, host := range hosts.Hosts {
+			keys = append(keys, host.PublicKeyString)
+		}
+		return keys
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == renterCmd {
+			return siaPathCompletions(word)
+		}
+	}
+	return nil
+}
+
+// siaPathCompletions returns the files and directories in the directory of
+// the siapath being completed. Directories end with a slash.
+func siaPathCompletions(word string) []string {
+	var dir string
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		dir = word[:i+1]
+	}
+	siaPath := modules.RootSiaPath()
+	if dir != "" {
+		var err error
+		siaPath, err = modules.NewSiaPath(dir)
+		if err != nil {
+			return nil
+		}
+	}
+	rd, err := httpClient.RenterDirGet(siaPath)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for i, di := range rd.Directories {
+		// The first directory is the directory itself.
+		if i == 0 {
+			continue
+		}
+		paths = append(paths, dir+di.SiaPath.Name()+"/")
+	}
+	for _, fi := range rd.Files {
+		paths = append(paths, dir+fi.SiaPath.Name())
+	}
+	return paths
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestSplitShellArgs tests splitting lines of the shell into arguments.
+func TestSplitShellArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		args []string
+		err  error
+	}{
+		{"", nil, nil},
+		{"   ", nil, nil},
+		{"renter ls", []string{"renter", "ls"}, nil},
+		{"  renter   ls  -R ", []string{"renter", "ls", "-R"}, nil},
+		{`renter upload "my file.txt" 'dir/with space'`, []string{"renter", "upload", "my file.txt", "dir/with space"}, nil},
+		{`wallet send siacoins "" addr`, []string{"wallet", "send", "siacoins", "", "addr"}, nil},
+		{`a"b c"d`, []string{"ab cd"}, nil},
+		{`renter ls "dir`, nil, errUnterminatedQuote},
+	}
+	for _, test := range tests {
+		args, err := splitShellArgs(test.line)
+		if err != test.err {
+			t.Fatalf("%q: expected error %v, got %v", test.line, test.err, err)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Fatalf("%q: expected %q, got %q", test.line, test.args, args)
+		}
+	}
+}
+
+// TestCompleteShellLine tests completing the commands of the shell.
+func TestCompleteShellLine(t *testing.T) {
+	run := func(*cobra.Command, []string) {}
+	root := &cobra.Command{Use: "siac", Run: run}
+	gateway := &cobra.Command{Use: "gateway", Run: run}
+	gateway.AddCommand(&cobra.Command{Use: "connect", Run: run}, &cobra.Command{Use: "list", Run: run})
+	root.AddCommand(gateway, &cobra.Command{Use: "gateways", Run: run}, &cobra.Command{Use: "wallet", Run: run})
+
+	tests := []struct {
+		line    string
+		pos     int
+		newLine string
+		ok      bool
+	}{
+		{"wal", 3, "wallet ", true},
+		{"gat", 3, "gateway", true},
+		{"gateway ", 8, "", false},
+		{"gateway c", 9, "gateway connect ", true},
+		{"gateway l --verbose", 9, "gateway list  --verbose", true},
+		{"foo", 3, "", false},
+	}
+	for _, test := range tests {
+		newLine, newPos, ok := completeShellLine(root, test.line, test.pos, '\t')
+		if ok != test.ok || newLine != test.newLine {
+			t.Fatalf("%q: expected %q %v, got %q %v", test.line, test.newLine, test.ok, newLine, ok)
+		}
+		if ok && newPos > len(newLine) {
+			t.Fatalf("%q: invalid position %v", test.line, newPos)
+		}
+	}
+
+	// Other keys shouldn't be handled.
+	if _, _, ok := completeShellLine(root, "wal", 3, 'a'); ok {
+		t.Fatal("only tab should complete")
+	}
+}
@@ -5,15 +5,19 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
+	"golang.org/x/net/websocket"
 	"golang.org/x/term"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -82,19 +86,59 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletFreezeCmd = &cobra.Command{
+		Use:   "freeze [outputid]...",
+		Short: "Freeze outputs",
+		Long: `Prevent the wallet from using the given outputs to fund transactions until
+they are unfrozen.`,
+		Run: walletfreezecmd,
+	}
+
+	walletEventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "Follow the events of the wallet",
+		Long: `Print the events of the wallet as they happen until interrupted. Events are
+emitted when a transaction affecting the wallet appears in the transaction
+pool, is confirmed, receives further confirmations or is reverted.`,
+		Run: wrap(walleteventscmd),
+	}
+
+	walletHistoryCmd = &cobra.Command{
+		Use:   "history",
+		Short: "Export the transaction history",
+		Long: `Export the confirmed transactions of the wallet together with the running
+siacoin and siafund balances, e.g. for tax and accounting purposes. Use --csv to
+print the history as CSV and --currency to value the transactions in a fiat
+currency.`,
+		Run: wrap(wallethistorycmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
 		Long: `Generate a new wallet from a randomly generated seed, and encrypt it.
-By default the wallet encryption / unlock password is the same as the generated seed.`,
+By default the wallet encryption / unlock password is the same as the generated seed.
+With --passphrase the wallet's keys are derived from the seed and a passphrase,
+so the seed alone can't recover the wallet. The passphrase must be entered
+every time the wallet is unlocked and can't be recovered if it is lost.`,
 		Run: wrap(walletinitcmd),
 	}
 
 	walletInitSeedCmd = &cobra.Command{
 		Use:   "init-seed",
 		Short: "Initialize and encrypt a new wallet using a pre-existing seed",
-		Long:  `Initialize and encrypt a new wallet using a pre-existing seed.`,
-		Run:   wrap(walletinitseedcmd),
+		Long: `Initialize and encrypt a new wallet using a pre-existing seed. Use
+--passphrase if the seed was used with a seed passphrase.`,
+		Run: wrap(walletinitseedcmd),
+	}
+
+	walletInitWatchOnlyCmd = &cobra.Command{
+		Use:   "init-watchonly",
+		Short: "Initialize and encrypt a new watch-only wallet",
+		Long: `Initialize and encrypt a new wallet without a seed. A watch-only wallet
+can only track addresses and public keys added with the watch endpoint and
+build unsigned transactions spending from them.`,
+		Run: wrap(walletinitwatchonlycmd),
 	}
 
 	walletLoad033xCmd = &cobra.Command{
@@ -104,6 +148,23 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletload033xcmd),
 	}
 
+	walletLabelCmd = &cobra.Command{
+		Use:   "label [address] [label]",
+		Short: "Label an address",
+		Long: `Assign a label to an address of the wallet. Addresses sharing a label can be
+viewed together with 'siac wallet labels [label]'. Omitting the label removes
+the label from the address.`,
+		Run: walletlabelcmd,
+	}
+
+	walletLabelsCmd = &cobra.Command{
+		Use:   "labels [label]",
+		Short: "List address labels",
+		Long: `List all labeled addresses. If a label is given, the addresses, balance
+and transactions of that label are shown instead.`,
+		Run: walletlabelscmd,
+	}
+
 	walletLoadCmd = &cobra.Command{
 		Use:   "load",
 		Short: "Load a wallet seed, v0.3.3.x wallet, or siag keyset",
@@ -133,6 +194,64 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletlockcmd),
 	}
 
+	walletOfflineCmd = &cobra.Command{
+		Use:   "offline",
+		Short: "Sign transactions on an offline machine",
+		Long: `Export an unsigned transaction to a file, sign it on an offline machine
+using only the wallet seed and broadcast the signed file on the online node.`,
+		Run: walletofflinecmd,
+	}
+
+	walletOfflineBroadcastCmd = &cobra.Command{
+		Use:   "broadcast [file]",
+		Short: "Broadcast a signed offline transaction",
+		Long:  "Broadcast a transaction which was exported and signed offline.",
+		Run:   wrap(walletofflinebroadcastcmd),
+	}
+
+	walletOfflineExportCmd = &cobra.Command{
+		Use:   "export [txn] [file]",
+		Short: "Export an unsigned transaction for offline signing",
+		Long: `Export an unsigned transaction together with the outputs it spends and its
+signature hashes to a file. txn may be either JSON, base64, or a file
+containing either.`,
+		Run: wrap(walletofflineexportcmd),
+	}
+
+	walletOfflineSignCmd = &cobra.Command{
+		Use:   "sign [file]",
+		Short: "Sign an exported transaction without siad",
+		Long: `Sign a transaction exported with 'siac wallet offline export'. The
+signing keys are derived from the wallet seed, so siad doesn't need to be
+running. The signed transaction is written back to the file.`,
+		Run: wrap(walletofflinesigncmd),
+	}
+
+	walletRescanCmd = &cobra.Command{
+		Use:   "rescan [startheight]",
+		Short: "Rescan the blockchain",
+		Long: `Rescan the blockchain starting at the given height, or from the beginning if
+no height is given. This finds the outputs of keys and addresses which were
+imported without a rescan. The rescan runs in the background, use
+'siac wallet rescan status' to view its progress.`,
+		Run: walletrescancmd,
+	}
+
+	walletRescanCancelCmd = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel the ongoing rescan",
+		Long: `Cancel the ongoing rescan. The rescan is resumed when the wallet is
+unlocked again or can be resumed with 'siac wallet rescan [startheight]'.`,
+		Run: wrap(walletrescancancelcmd),
+	}
+
+	walletRescanStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "View the progress of the latest rescan",
+		Long:  "View the progress of the latest rescan started with 'siac wallet rescan'.",
+		Run:   wrap(walletrescanstatuscmd),
+	}
+
 	walletSeedsCmd = &cobra.Command{
 		Use:   "seeds",
 		Short: "View information about your seeds",
@@ -155,7 +274,8 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+Use --target to pick a fee that targets the transaction to be confirmed within a number of blocks.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -186,7 +306,9 @@ provided, the wallet will fill in every TransactionSignature it has keys for.`,
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
 		Long: `Sweep siacoins and siafunds from a seed. The outputs belonging to the seed
-will be sent to your wallet.`,
+will be sent to your wallet. Use --coins and --funds to only sweep part of the
+seed's balance; the remainder is sent back to the seed. The outputs found and
+the fees are shown for confirmation before the sweep is broadcast.`,
 		Run: wrap(walletsweepcmd),
 	}
 
@@ -197,6 +319,21 @@ will be sent to your wallet.`,
 		Run:   wrap(wallettransactionscmd),
 	}
 
+	walletUnfreezeCmd = &cobra.Command{
+		Use:   "unfreeze [outputid]...",
+		Short: "Unfreeze outputs",
+		Long:  "Allow the wallet to use the given frozen outputs to fund transactions again.",
+		Run:   walletunfreezecmd,
+	}
+
+	walletUnspentCmd = &cobra.Command{
+		Use:   "unspent",
+		Short: "List unspent outputs",
+		Long: `List the unspent outputs of the wallet with their age, value and address.
+Frozen outputs are not used to fund transactions.`,
+		Run: wrap(walletunspentcmd),
+	}
+
 	walletUnlockCmd = &cobra.Command{
 		Use:   `unlock`,
 		Short: "Unlock the wallet",
@@ -206,6 +343,29 @@ SIA_WALLET_PASSWORD environment variable is set, the unlock command will
 use it instead of displaying the typical interactive prompt.`,
 		Run: wrap(walletunlockcmd),
 	}
+
+	walletWebhooksCmd = &cobra.Command{
+		Use:   "webhooks",
+		Short: "List the webhooks of the wallet",
+		Long: `List the URLs the wallet POSTs its events to. Each event is sent as JSON
+when a transaction affecting the wallet appears in the transaction pool, is
+confirmed, receives further confirmations or is reverted.`,
+		Run: wrap(walletwebhookscmd),
+	}
+
+	walletWebhooksAddCmd = &cobra.Command{
+		Use:   "add [url]",
+		Short: "Add a webhook",
+		Long:  "Add a URL the wallet POSTs its events to.",
+		Run:   wrap(walletwebhooksaddcmd),
+	}
+
+	walletWebhooksRemoveCmd = &cobra.Command{
+		Use:   "remove [url]",
+		Short: "Remove a webhook",
+		Long:  "Remove a URL the wallet POSTs its events to.",
+		Run:   wrap(walletwebhooksremovecmd),
+	}
 )
 
 const askPasswordText = "We need to encrypt the new data using the current wallet password, please provide: "
@@ -238,6 +398,23 @@ func passwordPrompt(prompt string) (pw string, err error) {
 }
 
 // confirmPassword requests confirmation of a previously-entered password.
+// seedPassphrasePrompt prompts for a seed passphrase. If confirm is set, the
+// passphrase has to be entered twice.
+func seedPassphrasePrompt(confirm bool) string {
+	passphrase, err := passwordPrompt("Seed passphrase: ")
+	if err != nil {
+		die("Reading seed passphrase failed:", err)
+	} else if passphrase == "" {
+		die("Seed passphrase can't be empty")
+	}
+	if confirm {
+		if err := confirmPassword(passphrase); err != nil {
+			die(err)
+		}
+	}
+	return passphrase
+}
+
 func confirmPassword(prev string) error {
 	pw, err := passwordPrompt(confirmPasswordText)
 	if err != nil {
@@ -255,6 +432,10 @@ func walletaddresscmd() {
 	if err != nil {
 		die("Could not generate new address:", err)
 	}
+	if jsonOutput {
+		printJSON(addr)
+		return
+	}
 	fmt.Printf("Created new address: %s\n", addr.Address)
 }
 
@@ -264,6 +445,10 @@ func walletaddressescmd() {
 	if err != nil {
 		die("Failed to fetch addresses:", err)
 	}
+	if jsonOutput {
+		printJSON(addrs.Addresses)
+		return
+	}
 	for _, addr := range addrs.Addresses {
 		fmt.Println(addr)
 	}
@@ -288,6 +473,158 @@ func walletchangepasswordcmd() {
 	fmt.Println("Password changed successfully.")
 }
 
+// walletfreezecmd freezes the given outputs.
+func walletfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	err := httpClient.WalletFreezePost(parseOutputIDs(args))
+	if err != nil {
+		die("Could not freeze outputs:", err)
+	}
+	fmt.Printf("Froze %v outputs.\n", len(args))
+}
+
+// walletunfreezecmd unfreezes the given outputs.
+func walletunfreezecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	err := httpClient.WalletUnfreezePost(parseOutputIDs(args))
+	if err != nil {
+		die("Could not unfreeze outputs:", err)
+	}
+	fmt.Printf("Unfroze %v outputs.\n", len(args))
+}
+
+// walletunspentcmd lists the unspent outputs of the wallet.
+func walletunspentcmd() {
+	wug, err := httpClient.WalletUnspentGet()
+	if err != nil {
+		die("Could not get unspent outputs:", err)
+	}
+	if jsonOutput {
+		printJSON(wug.Outputs)
+		return
+	}
+	wg, err := httpClient.WalletGet()
+	if err != nil {
+		die("Could not get wallet status:", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tType\tAge\tValue\tAddress\tFrozen")
+	for _, o := range wug.Outputs {
+		typ, value := "SC", currencyUnits(o.Value)
+		if o.FundType == types.SpecifierSiafundOutput {
+			typ, value = "SF", o.Value.String()+" SF"
+		}
+		var age types.BlockHeight
+		if wg.Height > o.ConfirmationHeight {
+			age = wg.Height - o.ConfirmationHeight
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", o.ID, typ, age, value, o.UnlockHash, o.Frozen)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// parseOutputIDs parses a list of hex encoded output ids.
+func parseOutputIDs(args []string) []types.OutputID {
+	ids := make([]types.OutputID, len(args))
+	for i, arg := range args {
+		if err := ids[i].UnmarshalJSON([]byte(`"` + arg + `"`)); err != nil {
+			die("Invalid output id", arg+":", err)
+		}
+	}
+	return ids
+}
+
+// wallethistorycmd exports the transaction history of the wallet.
+func wallethistorycmd() {
+	start, end := types.Timestamp(0), types.Timestamp(math.MaxUint64)
+	if walletHistoryStart != "" {
+		t, err := time.Parse("2006-01-02", walletHistoryStart)
+		if err != nil {
+			die("Could not parse start date:", err)
+		}
+		start = types.Timestamp(t.Unix())
+	}
+	if walletHistoryEnd != "" {
+		t, err := time.Parse("2006-01-02", walletHistoryEnd)
+		if err != nil {
+			die("Could not parse end date:", err)
+		}
+		// include the whole end date
+		end = types.Timestamp(t.AddDate(0, 0, 1).Unix() - 1)
+	}
+
+	if walletHistoryCSV && jsonOutput {
+		die("--csv can't be used together with --json")
+	}
+	if walletHistoryCSV {
+		csv, err := httpClient.WalletHistoryCSVGet(start, end, 0, 0, walletHistoryFiat)
+		if err != nil {
+			die("Could not export history:", err)
+		}
+		fmt.Print(string(csv))
+		return
+	}
+
+	whg, err := httpClient.WalletHistoryGet(start, end, 0, 0, walletHistoryFiat)
+	if err != nil {
+		die("Could not export history:", err)
+	}
+	if jsonOutput {
+		printJSON(whg.Entries)
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "Date\tHeight\tTransaction ID\tSiacoins\tSC Balance\tSiafunds\tSF Balance"
+	if walletHistoryFiat != "" {
+		header += "\tValue (" + strings.ToUpper(walletHistoryFiat) + ")"
+	}
+	fmt.Fprintln(w, header)
+	var untracked bool
+	for _, e := range whg.Entries {
+		var sc, sf string
+		if e.SiacoinOutgoing.Cmp(e.SiacoinIncoming) > 0 {
+			sc = "-" + currencyUnits(e.SiacoinOutgoing.Sub(e.SiacoinIncoming))
+		} else {
+			sc = currencyUnits(e.SiacoinIncoming.Sub(e.SiacoinOutgoing))
+		}
+		if e.SiafundOutgoing.Cmp(e.SiafundIncoming) > 0 {
+			sf = "-" + e.SiafundOutgoing.Sub(e.SiafundIncoming).String()
+		} else {
+			sf = e.SiafundIncoming.Sub(e.SiafundOutgoing).String()
+		}
+		// mark transactions which spend untracked funds since their net value
+		// doesn't match the change of the balance.
+		if !e.SiacoinUntracked.IsZero() {
+			sc += " *"
+			untracked = true
+		}
+		if !e.SiafundUntracked.IsZero() {
+			sf += " *"
+			untracked = true
+		}
+		date := time.Unix(int64(e.ConfirmationTimestamp), 0).Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v", date, e.ConfirmationHeight, e.TransactionID, sc, currencyUnits(e.SiacoinBalance), sf, e.SiafundBalance)
+		if walletHistoryFiat != "" {
+			fmt.Fprintf(w, "\t%.2f", e.FiatValue)
+		}
+		fmt.Fprintln(w)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if untracked {
+		fmt.Println("\n* spends funds received without a transaction in the history, e.g. contract payouts")
+	}
+}
+
 // walletinitcmd encrypts the wallet with the given password
 func walletinitcmd() {
 	var password string
@@ -300,7 +637,11 @@ func walletinitcmd() {
 			die(err)
 		}
 	}
-	er, err := httpClient.WalletInitPost(password, initForce)
+	var passphrase string
+	if walletSeedPassphrase {
+		passphrase = seedPassphrasePrompt(true)
+	}
+	er, err := httpClient.WalletInitPassphrasePost(password, passphrase, initForce)
 	if err != nil {
 		die("Error when encrypting wallet:", err)
 	}
@@ -327,7 +668,11 @@ func walletinitseedcmd() {
 			die(err)
 		}
 	}
-	err = httpClient.WalletInitSeedPost(seed, password, initForce)
+	var passphrase string
+	if walletSeedPassphrase {
+		passphrase = seedPassphrasePrompt(true)
+	}
+	err = httpClient.WalletInitSeedPassphrasePost(seed, password, passphrase, initForce)
 	if err != nil {
 		die("Could not initialize wallet from seed:", err)
 	}
@@ -338,6 +683,21 @@ func walletinitseedcmd() {
 	}
 }
 
+// walletinitwatchonlycmd initializes a watch-only wallet.
+func walletinitwatchonlycmd() {
+	password, err := passwordPrompt("Wallet password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	} else if err = confirmPassword(password); err != nil {
+		die(err)
+	}
+	err = httpClient.WalletInitWatchOnlyPost(password, initForce)
+	if err != nil {
+		die("Could not initialize watch-only wallet:", err)
+	}
+	fmt.Println("Watch-only wallet initialized and encrypted with given password.")
+}
+
 // walletload033xcmd loads a v0.3.3.x wallet into the current wallet.
 func walletload033xcmd(source string) {
 	password, err := passwordPrompt(askPasswordText)
@@ -381,6 +741,70 @@ func walletloadsiagcmd(keyfiles string) {
 	fmt.Println("Wallet loading successful.")
 }
 
+// walletlabelcmd assigns a label to an address or removes it.
+func walletlabelcmd(cmd *cobra.Command, args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	var addr types.UnlockHash
+	if err := addr.LoadString(args[0]); err != nil {
+		die("Could not parse address:", err)
+	}
+	var label string
+	if len(args) == 2 {
+		label = args[1]
+	}
+	if err := httpClient.WalletLabelsPost(addr, label); err != nil {
+		die("Could not label address:", err)
+	}
+	if label == "" {
+		fmt.Printf("Removed label of %v.\n", addr)
+		return
+	}
+	fmt.Printf("Labeled %v as %q.\n", addr, label)
+}
+
+// walletlabelscmd lists the labeled addresses or the details of a label.
+func walletlabelscmd(cmd *cobra.Command, args []string) {
+	switch len(args) {
+	case 0:
+		wlg, err := httpClient.WalletLabelsGet()
+		if err != nil {
+			die("Could not get address labels:", err)
+		}
+		if len(wlg.Labels) == 0 {
+			fmt.Println("No labeled addresses.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Label\tAddress")
+		for _, l := range wlg.Labels {
+			fmt.Fprintf(w, "%v\t%v\n", l.Label, l.Address)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	case 1:
+		wlg, err := httpClient.WalletLabelGet(args[0])
+		if err != nil {
+			die("Could not get label:", err)
+		}
+		fmt.Printf(`Label:     %v
+Addresses: %v
+Siacoins:  %v
+Siafunds:  %v SF
+`, wlg.Label, len(wlg.Addresses), currencyUnits(wlg.ConfirmedSiacoinBalance), wlg.SiafundBalance)
+		for _, addr := range wlg.Addresses {
+			fmt.Println("  " + addr.String())
+		}
+		fmt.Printf("\n%v confirmed and %v unconfirmed transactions.\n", len(wlg.ConfirmedTransactions), len(wlg.UnconfirmedTransactions))
+	default:
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+}
+
 // walletlockcmd locks the wallet
 func walletlockcmd() {
 	err := httpClient.WalletLockPost()
@@ -389,6 +813,92 @@ func walletlockcmd() {
 	}
 }
 
+// walleteventscmd prints the events of the wallet as they happen.
+func walleteventscmd() {
+	conn, err := httpClient.WalletEventsSubscribe()
+	if err != nil {
+		die("Could not subscribe to wallet events:", err)
+	}
+	defer conn.Close()
+
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tEvent\tTransaction ID\tNet Siacoins\tNet Siafunds\tConfirmations")
+	w.Flush()
+	for {
+		var e modules.WalletEvent
+		if err := websocket.JSON.Receive(conn, &e); err != nil {
+			die("Could not receive wallet event:", err)
+		}
+		var netSiacoins string
+		if e.SiacoinOutgoing.Cmp(e.SiacoinIncoming) > 0 {
+			netSiacoins = "-" + currencyUnits(e.SiacoinOutgoing.Sub(e.SiacoinIncoming))
+		} else {
+			netSiacoins = currencyUnits(e.SiacoinIncoming.Sub(e.SiacoinOutgoing))
+		}
+		netSiafunds := new(big.Int).Sub(e.SiafundIncoming.Big(), e.SiafundOutgoing.Big())
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", e.Timestamp.Format(time.RFC3339), e.Type, e.TransactionID, netSiacoins, netSiafunds, e.Confirmations)
+		w.Flush()
+	}
+}
+
+// walletrescancmd starts a rescan of the blockchain.
+func walletrescancmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		_ = cmd.UsageFunc()(cmd)
+		exit(exitCodeUsage)
+	}
+	var startHeight uint64
+	if len(args) == 1 {
+		var err error
+		startHeight, err = strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			die("Could not parse start height:", err)
+		}
+	}
+	if err := httpClient.WalletRescanPost(types.BlockHeight(startHeight)); err != nil {
+		die("Could not start rescan:", err)
+	}
+	fmt.Println("Rescan started. Use 'siac wallet rescan status' to view its progress.")
+}
+
+// walletrescancancelcmd cancels the ongoing rescan.
+func walletrescancancelcmd() {
+	if err := httpClient.WalletRescanCancelPost(); err != nil {
+		die("Could not cancel rescan:", err)
+	}
+	fmt.Println("Rescan cancelled.")
+}
+
+// walletrescanstatuscmd prints the progress of the latest rescan.
+func walletrescanstatuscmd() {
+	wrg, err := httpClient.WalletRescanGet()
+	if err != nil {
+		die("Could not get rescan progress:", err)
+	}
+	if jsonOutput {
+		printJSON(wrg)
+		return
+	}
+	switch {
+	case wrg.StartTime.IsZero():
+		fmt.Println("No rescan has been started.")
+		return
+	case wrg.Rescanning:
+		fmt.Println("Status: rescanning")
+	case wrg.Cancelled:
+		fmt.Println("Status: cancelled")
+	case wrg.Error != "":
+		fmt.Println("Status: failed:", wrg.Error)
+	default:
+		fmt.Println("Status: finished")
+	}
+	fmt.Printf("Started:  %v at height %v\n", wrg.StartTime.Format(time.RFC1123), wrg.StartHeight)
+	fmt.Printf("Progress: %v / %v\n", wrg.Height, wrg.TargetHeight)
+	if wrg.Rescanning && wrg.ETA > 0 {
+		fmt.Println("ETA:     ", wrg.ETA.Round(time.Second))
+	}
+}
+
 // walletseedcmd returns the current seed {
 func walletseedscmd() {
 	seedInfo, err := httpClient.WalletSeedsGet()
@@ -426,7 +936,11 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	if walletTxnTarget != 0 {
+		_, err = httpClient.WalletSiacoinsTargetPost(value, hash, walletTxnFeeIncluded, types.BlockHeight(walletTxnTarget))
+	} else {
+		_, err = httpClient.WalletSiacoinsPost(value, hash, walletTxnFeeIncluded)
+	}
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
@@ -465,10 +979,20 @@ func walletbalancecmd() {
 	if err != nil {
 		die("Could not get fee estimation:", err)
 	}
+	if jsonOutput {
+		printJSON(struct {
+			Wallet api.WalletGET   `json:"wallet"`
+			Fees   api.TpoolFeeGET `json:"fees"`
+		}{status, fees})
+		return
+	}
 	encStatus := "Unencrypted"
 	if status.Encrypted {
 		encStatus = "Encrypted"
 	}
+	if status.WatchOnly {
+		encStatus += " (watch-only)"
+	}
 	if !status.Unlocked {
 		fmt.Printf(`Wallet status:
 %v, Locked
@@ -520,18 +1044,177 @@ func walletsweepcmd() {
 		die("Reading seed failed:", err)
 	}
 
-	swept, err := httpClient.WalletSweepPost(seed)
+	var coins, funds types.Currency
+	if walletSweepCoins != "" {
+		hastings, err := types.ParseCurrency(walletSweepCoins)
+		if err != nil {
+			die("Could not parse coins:", err)
+		}
+		if _, err := fmt.Sscan(hastings, &coins); err != nil {
+			die("Failed to parse coins", err)
+		}
+	}
+	if walletSweepFunds != "" {
+		if _, err := fmt.Sscan(walletSweepFunds, &funds); err != nil {
+			die("Failed to parse funds", err)
+		}
+	}
+
+	// plan the sweep and ask for confirmation before broadcasting it
+	plan, err := httpClient.WalletSweepOptionsPost(seed, coins, funds, true)
+	if err != nil {
+		die("Could not plan sweep:", err)
+	}
+	fmt.Println("Outputs found:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  ID\tType\tValue\tSwept")
+	for _, o := range plan.Outputs {
+		value := fmt.Sprintf("%v SF", o.Value)
+		if o.FundType == types.SpecifierSiacoinOutput {
+			value = currencyUnits(o.Value)
+		}
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", o.ID, o.FundType, value, o.Swept)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	fmt.Printf(`
+Sweeping:  %v and %v SF
+Fee:       %v
+Remaining: %v and %v SF
+`, currencyUnits(plan.Coins), plan.Funds, currencyUnits(plan.Fee), currencyUnits(plan.RemainingCoins), plan.RemainingFunds)
+	if walletSweepDryRun || !askForConfirmation("Sweep the seed?") {
+		return
+	}
+
+	swept, err := httpClient.WalletSweepOptionsPost(seed, coins, funds, false)
 	if err != nil {
 		die("Could not sweep seed:", err)
 	}
 	fmt.Printf("Swept %v and %v SF from seed.\n", currencyUnits(swept.Coins), swept.Funds)
 }
 
+// walletofflinecmd displays the usage info for the command.
+func walletofflinecmd(cmd *cobra.Command, args []string) {
+	_ = cmd.UsageFunc()(cmd)
+	exit(exitCodeUsage)
+}
+
+// walletofflinebroadcastcmd broadcasts a transaction signed offline.
+func walletofflinebroadcastcmd(path string) {
+	otxn := readOfflineTransaction(path)
+	wobr, err := httpClient.WalletOfflineBroadcastPost(otxn)
+	if err != nil {
+		die("Could not broadcast transaction:", err)
+	}
+	fmt.Println("Transaction has been broadcast successfully:", wobr.TransactionID)
+}
+
+// walletofflineexportcmd exports an unsigned transaction to a file.
+func walletofflineexportcmd(txnStr, path string) {
+	txn, err := parseTxn(txnStr)
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	otxn, err := httpClient.WalletOfflineExportPost(txn, nil)
+	if err != nil {
+		die("Could not export transaction:", err)
+	}
+	writeOfflineTransaction(path, otxn)
+	fmt.Println("Transaction exported to", path)
+}
+
+// walletofflinesigncmd signs an exported transaction using keys derived from
+// the wallet seed.
+func walletofflinesigncmd(path string) {
+	otxn := readOfflineTransaction(path)
+	verified, err := wallet.VerifyOfflineTransactionInputs(otxn)
+	if err != nil {
+		die("Could not verify transaction inputs:", err)
+	}
+
+	// print a summary for the user to verify before signing. The values of
+	// inputs without a parent transaction can't be verified and are marked
+	// accordingly.
+	var in, out types.Currency
+	var unverified int
+	fmt.Println("Inputs:")
+	for i, input := range otxn.Inputs {
+		if verified[i] {
+			fmt.Printf("  %v  %v\n", input.UnlockHash, currencyUnits(input.Value))
+		} else {
+			fmt.Printf("  %v  %v (unverified)\n", input.UnlockHash, currencyUnits(input.Value))
+			unverified++
+		}
+		in = in.Add(input.Value)
+	}
+	fmt.Println("Outputs:")
+	for _, sco := range otxn.Transaction.SiacoinOutputs {
+		fmt.Printf("  %v  %v\n", sco.UnlockHash, currencyUnits(sco.Value))
+		out = out.Add(sco.Value)
+	}
+	fmt.Println("Fees:")
+	for _, fee := range otxn.Transaction.MinerFees {
+		fmt.Printf("  %v\n", currencyUnits(fee))
+		out = out.Add(fee)
+	}
+	if !in.Equals(out) {
+		fmt.Println("WARNING: the inputs don't match the outputs and fees of the transaction")
+	}
+	if unverified > 0 {
+		fmt.Printf("WARNING: the values of %v inputs could not be verified, the fees of the transaction might be higher than shown\n", unverified)
+	}
+	if !askForConfirmation("Sign this transaction?") {
+		return
+	}
+
+	seedString, err := passwordPrompt("Seed: ")
+	if err != nil {
+		die("Reading seed failed:", err)
+	}
+	seed, err := modules.StringToSeed(seedString, mnemonics.English)
+	if err != nil {
+		die("Invalid seed:", err)
+	}
+	if walletSeedPassphrase {
+		seed = modules.SeedWithPassphrase(seed, seedPassphrasePrompt(false))
+	}
+	err = wallet.SignOfflineTransaction(&otxn, seed)
+	if err != nil {
+		die("Failed to sign transaction:", err)
+	}
+	writeOfflineTransaction(path, otxn)
+	fmt.Println("Signed transaction written to", path)
+}
+
+// readOfflineTransaction reads an offline transaction from a file.
+func readOfflineTransaction(path string) (otxn modules.OfflineTransaction) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		die("Could not read transaction file:", err)
+	}
+	if err := json.Unmarshal(b, &otxn); err != nil {
+		die("Could not decode transaction file:", err)
+	}
+	return
+}
+
+// writeOfflineTransaction writes an offline transaction to a file.
+func writeOfflineTransaction(path string, otxn modules.OfflineTransaction) {
+	b, err := json.MarshalIndent(otxn, "", "  ")
+	if err != nil {
+		die("Could not encode transaction:", err)
+	}
+	if err := ioutil.WriteFile(path, b, modules.DefaultFilePerm); err != nil {
+		die("Could not write transaction file:", err)
+	}
+}
+
 // walletsigncmd signs a transaction.
 func walletsigncmd(cmd *cobra.Command, args []string) {
 	if len(args) < 1 {
 		_ = cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		exit(exitCodeUsage)
 	}
 
 	txn, err := parseTxn(args[0])
@@ -588,6 +1271,9 @@ func walletsigncmdoffline(txn *types.Transaction, toSign []crypto.Hash) {
 	if err != nil {
 		die("Invalid seed:", err)
 	}
+	if walletSeedPassphrase {
+		seed = modules.SeedWithPassphrase(seed, seedPassphrasePrompt(false))
+	}
 	// signing via seed may take a while, since we need to regenerate
 	// keys. If it takes longer than a second, print a message to assure
 	// the user that this is normal.
@@ -617,12 +1303,16 @@ func wallettransactionscmd() {
 	if err != nil {
 		die("Could not fetch consensus information:", err)
 	}
-	fmt.Println("             [timestamp]    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]")
 	txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
 	sts, err := wallet.ComputeValuedTransactions(txns, cg.Height)
 	if err != nil {
 		die("Could not compute valued transaction: ", err)
 	}
+	if jsonOutput {
+		printJSON(sts)
+		return
+	}
+	fmt.Println("             [timestamp]    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]")
 	for _, txn := range sts {
 		// Determine the number of outgoing siacoins and siafunds.
 		var outgoingSiafunds types.Currency
@@ -667,12 +1357,18 @@ func wallettransactionscmd() {
 
 // walletunlockcmd unlocks a saved wallet
 func walletunlockcmd() {
+	// ask for the seed passphrase if the wallet requires one
+	var passphrase string
+	if wg, err := httpClient.WalletGet(); err == nil && wg.SeedPassphraseRequired {
+		passphrase = seedPassphrasePrompt(false)
+	}
+
 	// try reading from environment variable first, then fallback to
 	// interactive method. Also allow overriding auto-unlock via -p
 	password := build.WalletPassword()
 	if password != "" && !initPassword {
 		fmt.Println("Using SIA_WALLET_PASSWORD environment variable")
-		err := httpClient.WalletUnlockPost(password)
+		err := httpClient.WalletUnlockPassphrasePost(password, passphrase)
 		if err != nil {
 			fmt.Println("Automatic unlock failed!")
 		} else {
@@ -684,8 +1380,43 @@ func walletunlockcmd() {
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	err = httpClient.WalletUnlockPost(password)
+	err = httpClient.WalletUnlockPassphrasePost(password, passphrase)
 	if err != nil {
 		die("Could not unlock wallet:", err)
 	}
 }
+
+// walletwebhookscmd lists the webhooks of the wallet.
+func walletwebhookscmd() {
+	wwg, err := httpClient.WalletWebhooksGet()
+	if err != nil {
+		die("Could not get webhooks:", err)
+	}
+	if jsonOutput {
+		printJSON(wwg.Webhooks)
+		return
+	}
+	if len(wwg.Webhooks) == 0 {
+		fmt.Println("No webhooks.")
+		return
+	}
+	for _, hook := range wwg.Webhooks {
+		fmt.Println(hook.URL)
+	}
+}
+
+// walletwebhooksaddcmd adds a webhook to the wallet.
+func walletwebhooksaddcmd(url string) {
+	if err := httpClient.WalletWebhooksAddPost(url); err != nil {
+		die("Could not add webhook:", err)
+	}
+	fmt.Println("Added webhook", url)
+}
+
+// walletwebhooksremovecmd removes a webhook from the wallet.
+func walletwebhooksremovecmd(url string) {
+	if err := httpClient.WalletWebhooksRemovePost(url); err != nil {
+		die("Could not remove webhook:", err)
+	}
+	fmt.Println("Removed webhook", url)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// loadConfigFile loads the config file of siad and sets the flags that were
+// not set on the command line to the values of its daemon section. If no
+// config file was specified, siad.yml in the sia directory is loaded if it
+// exists. It returns the path of the loaded config file, or "" if none was
+// loaded.
+func loadConfigFile(cmd *cobra.Command, config Config) (string, error) {
+	path := config.Siad.ConfigFile
+	if path == "" {
+		path = filepath.Join(config.Siad.SiaDir, modules.ConfigFileName)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return "", nil
+		}
+	}
+	cf, err := modules.LoadConfigFile(path)
+	if err != nil {
+		return "", errors.AddContext(err, "unable to load "+path)
+	}
+	flags := cmd.Flags()
+	for name, value := range cf.Daemon {
+		f := flags.Lookup(name)
+		if f == nil || name == "config" {
+			return "", errors.Compose(modules.ErrInvalidConfigFile, errors.New("unknown daemon setting "+name))
+		}
+		// Flags on the command line take precedence over the config file.
+		if f.Changed {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return "", errors.Compose(modules.ErrInvalidConfigFile, errors.AddContext(err, "daemon."+name))
+		}
+	}
+	return path, nil
+}
+
+// configFlags returns the effective values of siad's flags.
+func configFlags(cmd *cobra.Command) map[string]string {
+	values := make(map[string]string)
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "config" || f.Name == "help" {
+			return
+		}
+		values[f.Name] = f.Value.String()
+	})
+	return values
+}
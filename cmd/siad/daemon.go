@@ -15,8 +15,10 @@ import (
 	"golang.org/x/term"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api/server"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/profile"
 )
 
@@ -34,8 +36,15 @@ func verifyAPISecurity(config Config) error {
 	// Make sure that only the loopback address is allowed unless the
 	// --disable-api-security flag has been used.
 	if !config.Siad.AllowAPIBind {
-		addr := modules.NetAddress(config.Siad.APIaddr)
-		if !addr.IsLoopback() {
+		addrs := []string{config.Siad.APIaddr}
+		if config.Siad.GRPCAddr != "" {
+			addrs = append(addrs, config.Siad.GRPCAddr)
+		}
+		for _, a := range addrs {
+			addr := modules.NetAddress(a)
+			if addr.IsLoopback() {
+				continue
+			}
 			if addr.Host() == "" {
 				return fmt.Errorf("a blank host will listen on all interfaces, did you mean localhost:%v?\nyou must pass --disable-api-security to bind Siad to a non-localhost address", addr.Port())
 			}
@@ -108,8 +117,11 @@ func processModules(modules string) (string, error) {
 // processConfig checks the configuration values and performs cleanup on
 // incorrect-but-allowed values.
 func processConfig(config Config) (Config, error) {
-	var err1, err2 error
+	var err1, err2, err4, err5 error
 	config.Siad.APIaddr = processNetAddr(config.Siad.APIaddr)
+	if config.Siad.GRPCAddr != "" {
+		config.Siad.GRPCAddr = processNetAddr(config.Siad.GRPCAddr)
+	}
 	config.Siad.RPCaddr = processNetAddr(config.Siad.RPCaddr)
 	config.Siad.HostAddr = processNetAddr(config.Siad.HostAddr)
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
@@ -117,13 +129,32 @@ func processConfig(config Config) (Config, error) {
 		config.Siad.Profile, err2 = profile.ProcessProfileFlags(config.Siad.Profile)
 	}
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	if config.Siad.LogLevel != "" {
+		_, err4 = persist.ParseLogLevel(config.Siad.LogLevel)
+	}
+	if config.Siad.ConsensusSnapshot != "" {
+		err5 = verifySnapshotChecksum(config.Siad.SnapshotChecksum)
+	}
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
 	return config, nil
 }
 
+// verifySnapshotChecksum checks that a valid checksum was provided for the
+// consensus snapshot.
+func verifySnapshotChecksum(checksum string) error {
+	if checksum == "" {
+		return errors.New("--consensus-snapshot requires --consensus-snapshot-checksum")
+	}
+	var h crypto.Hash
+	if err := h.LoadString(checksum); err != nil {
+		return errors.AddContext(err, "invalid consensus snapshot checksum")
+	}
+	return nil
+}
+
 // loadAPIPassword determines whether to use an API password from disk or a
 // temporary one entered by the user according to the provided config.
 func loadAPIPassword(config Config) (_ Config, err error) {
@@ -185,6 +216,28 @@ func installKillSignalHandler() chan os.Signal {
 	return sigChan
 }
 
+// installReloadSignalHandler installs a signal handler for SIGHUP and returns
+// a channel that receives the signal.
+func installReloadSignalHandler() chan os.Signal {
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	return reloadChan
+}
+
+// reloadConfigFile reloads the config file of the server and prints the
+// result.
+func reloadConfigFile(srv *server.Server) {
+	restartRequired, err := srv.ReloadConfigFile()
+	if err != nil {
+		fmt.Println("Failed to reload config file:", err)
+		return
+	}
+	fmt.Println("Reloaded config file")
+	if len(restartRequired) > 0 {
+		fmt.Println("Restart siad to apply the changed settings:", strings.Join(restartRequired, ", "))
+	}
+}
+
 // startDaemon uses the config parameters to initialize Sia modules and start
 // siad.
 func startDaemon(config Config) (err error) {
@@ -206,6 +259,21 @@ func startDaemon(config Config) (err error) {
 	// Print a startup message.
 	fmt.Println("Loading...")
 
+	// Configure the log files before the modules create them.
+	if config.Siad.LogLevel != "" {
+		logLevel, err := persist.ParseLogLevel(config.Siad.LogLevel)
+		if err != nil {
+			return err
+		}
+		persist.SetLogLevel("", logLevel)
+	}
+	persist.SetLogConfig(persist.LogConfig{
+		JSON:       config.Siad.LogJSON,
+		MaxAge:     config.Siad.LogMaxAge,
+		MaxBackups: config.Siad.LogMaxBackups,
+		MaxSize:    config.Siad.LogMaxSize * 1e6,
+	})
+
 	// Create the node params by parsing the modules specified in the config.
 	nodeParams := parseModules(config)
 	// set the wallet password from the environment variable
@@ -220,18 +288,25 @@ func startDaemon(config Config) (err error) {
 	// listen for kill signals
 	sigChan := installKillSignalHandler()
 
+	// reload the config file on SIGHUP
+	reloadChan := installReloadSignalHandler()
+
 	// Print a 'startup complete' message.
 	startupTime := time.Since(loadStart)
 	fmt.Printf("Finished full setup in %s\n", startupTime.Truncate(time.Second).String())
 
 	// wait for Serve to return or for kill signal to be caught
 	err = func() error {
-		select {
-		case err := <-srv.ServeErr():
-			return err
-		case <-sigChan:
-			fmt.Println("\rCaught stop signal, quitting...")
-			return srv.Close()
+		for {
+			select {
+			case err := <-srv.ServeErr():
+				return err
+			case <-sigChan:
+				fmt.Println("\rCaught stop signal, quitting...")
+				return srv.Close()
+			case <-reloadChan:
+				reloadConfigFile(srv)
+			}
 		}
 	}()
 	if err != nil {
@@ -246,6 +321,15 @@ func startDaemon(config Config) (err error) {
 
 // startDaemonCmd is a passthrough function for startDaemon.
 func startDaemonCmd(cmd *cobra.Command, _ []string) {
+	// Apply the config file to the flags that were not set on the command
+	// line.
+	configFile, err := loadConfigFile(cmd, globalConfig)
+	if err != nil {
+		die(errors.AddContext(err, "failed to load config file"))
+	}
+	globalConfig.Siad.ConfigFile = configFile
+	globalConfig.ConfigFlags = configFlags(cmd)
+
 	// Process the config variables after they are parsed by cobra.
 	config, err := processConfig(globalConfig)
 	if err != nil {
@@ -1,7 +1,16 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 )
 
 // TestUnitProcessNetAddr probes the 'processNetAddr' function.
@@ -186,6 +195,15 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOn was accepted")
 	}
 
+	// Check that a public gRPC address is rejected when security is enabled.
+	var securityOnPublicGRPC Config
+	securityOnPublicGRPC.Siad.APIaddr = "127.0.0.1:9980"
+	securityOnPublicGRPC.Siad.GRPCAddr = "sia.tech:9990"
+	err = verifyAPISecurity(securityOnPublicGRPC)
+	if err == nil {
+		t.Error("public gRPC + securityOn was accepted")
+	}
+
 	// Check that a public hostname is rejected when security is disabled and
 	// there is no api password.
 	var securityOffPublic Config
@@ -207,3 +225,56 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
 }
+
+// TestLoadConfigFile tests that the config file sets the flags that were not
+// set on the command line.
+func TestLoadConfigFile(t *testing.T) {
+	dir := build.TempDir("siad", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, modules.ConfigFileName)
+	err := ioutil.WriteFile(path, []byte("daemon:\n  api-addr: localhost:1234\n  rpc-addr: :5678\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config Config
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVarP(&config.Siad.APIaddr, "api-addr", "", "localhost:9980", "")
+	cmd.Flags().StringVarP(&config.Siad.RPCaddr, "rpc-addr", "", ":9981", "")
+	if err := cmd.Flags().Parse([]string{"--rpc-addr", ":1111"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default config file in the sia directory should be loaded.
+	config.Siad.SiaDir = dir
+	loaded, err := loadConfigFile(cmd, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != path {
+		t.Fatal("config file wasn't loaded", loaded)
+	}
+	if config.Siad.APIaddr != "localhost:1234" || config.Siad.RPCaddr != ":1111" {
+		t.Fatal("unexpected flags", config.Siad.APIaddr, config.Siad.RPCaddr)
+	}
+	if flags := configFlags(cmd); flags["api-addr"] != "localhost:1234" {
+		t.Fatal("unexpected effective flags", flags)
+	}
+
+	// Unknown flags should be rejected.
+	err = ioutil.WriteFile(path, []byte("daemon:\n  unknown: true\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFile(cmd, config); !errors.Contains(err, modules.ErrInvalidConfigFile) {
+		t.Fatal("expected ErrInvalidConfigFile, got", err)
+	}
+
+	// Without a config file nothing should be loaded.
+	config.Siad.SiaDir = filepath.Join(dir, "empty")
+	if loaded, err := loadConfigFile(cmd, config); err != nil || loaded != "" {
+		t.Fatal("unexpected result", loaded, err)
+	}
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules/consensus"
 )
 
 var (
@@ -29,6 +31,10 @@ type Config struct {
 	// --authenticate-api flag is set.
 	APIPassword string
 
+	// ConfigFlags contains the effective values of the flags after the config
+	// file was applied.
+	ConfigFlags map[string]string
+
 	// The Siad variables are referenced directly by cobra, and are set
 	// according to the flags.
 	Siad struct {
@@ -39,12 +45,48 @@ type Config struct {
 		SiaMuxWSAddr  string
 		AllowAPIBind  bool
 
+		APITLSCert        string
+		APITLSKey         string
+		APITLSACMEDomains string
+		APITLSACMEEmail   string
+
+		GRPCAddr string
+
+		APICORSOrigins string
+		APICORSMethods string
+		APICORSHeaders string
+
+		APIAuditLog       bool
+		APIAuditRetention time.Duration
+
+		APIRateLimit          float64
+		APIRateBurst          int
+		APIEndpointRateLimits string
+		APIMaxUploads         int
+		APIMaxDownloads       int
+		APIMaxRescans         int
+
+		AllowDegradedStartup bool
+
+		ConfigFile        string
 		Modules           string
 		NoBootstrap       bool
+		ConsensusDatabase string
+		ConsensusSnapshot string
+		SnapshotChecksum  string
+		ExplorerIndex     bool
 		UseUPNP           bool
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 		TempPassword      bool
+		WalletSigner      string
+		WalletPriceSource string
+
+		LogJSON       bool
+		LogLevel      string
+		LogMaxAge     time.Duration
+		LogMaxBackups int
+		LogMaxSize    int64
 
 		Profile    string
 		ProfileDir string
@@ -193,8 +235,35 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", defaultRHP2Addr, "which port the host listens on")
 	root.Flags().StringVarP(&globalConfig.Siad.ProfileDir, "profile-directory", "", "profiles", "location of the profiling directory")
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", defaultAPIAddr, "which host:port the API server listens on")
+	root.Flags().StringVarP(&globalConfig.Siad.GRPCAddr, "grpc-addr", "", "", "which host:port the gRPC API listens on. The gRPC API is disabled if it is not set")
+	root.Flags().StringVarP(&globalConfig.Siad.APITLSCert, "api-tls-cert", "", "", "location of the TLS certificate the API is served with. The certificate is reloaded when the file changes")
+	root.Flags().StringVarP(&globalConfig.Siad.APITLSKey, "api-tls-key", "", "", "location of the private key of the API's TLS certificate")
+	root.Flags().StringVarP(&globalConfig.Siad.APITLSACMEDomains, "api-tls-acme-domains", "", "", "comma separated domains to obtain TLS certificates for via ACME (Let's Encrypt). Requires the API to be reachable on port 443 of the domains")
+	root.Flags().StringVarP(&globalConfig.Siad.APITLSACMEEmail, "api-tls-acme-email", "", "", "contact email of the ACME account")
+	root.Flags().StringVarP(&globalConfig.Siad.APICORSOrigins, "api-cors-origins", "", "", "comma separated origins which browsers may access the API from, e.g. https://example.com. '*' allows all origins")
+	root.Flags().StringVarP(&globalConfig.Siad.APICORSMethods, "api-cors-methods", "", "", "comma separated methods which may be used by cross-origin requests. Defaults to GET and POST")
+	root.Flags().StringVarP(&globalConfig.Siad.APICORSHeaders, "api-cors-headers", "", "", "comma separated headers which may be sent by cross-origin requests. Defaults to Authorization, Content-Type, Range and User-Agent")
+	root.Flags().BoolVarP(&globalConfig.Siad.APIAuditLog, "api-audit-log", "", false, "record the API calls which change the state of the node in an audit log, which can be queried via /daemon/audit")
+	root.Flags().DurationVarP(&globalConfig.Siad.APIAuditRetention, "api-audit-retention", "", 90*24*time.Hour, "age at which entries are removed from the audit log, e.g. '720h'. 0 keeps entries forever")
+	root.Flags().Float64VarP(&globalConfig.Siad.APIRateLimit, "api-rate-limit", "", 0, "requests per second each client may send to the API. 0 disables the limit")
+	root.Flags().IntVarP(&globalConfig.Siad.APIRateBurst, "api-rate-burst", "", 0, "number of requests each client may send to the API in a burst. Defaults to the requests per second")
+	root.Flags().StringVarP(&globalConfig.Siad.APIEndpointRateLimits, "api-endpoint-rate-limits", "", "", "comma separated rate limits of each client for the endpoints with a certain prefix, e.g. '/renter/upload=1,/wallet/siacoins=0.1:5' for 1 request per second and 0.1 requests per second with a burst of 5")
+	root.Flags().IntVarP(&globalConfig.Siad.APIMaxUploads, "api-max-uploads", "", 0, "maximum number of uploads served concurrently by the API. 0 disables the limit")
+	root.Flags().IntVarP(&globalConfig.Siad.APIMaxDownloads, "api-max-downloads", "", 0, "maximum number of downloads served concurrently by the API. 0 disables the limit")
+	root.Flags().IntVarP(&globalConfig.Siad.APIMaxRescans, "api-max-rescans", "", 0, "maximum number of wallet rescans served concurrently by the API. 0 disables the limit")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.Flags().StringVarP(&globalConfig.Siad.ConfigFile, "config", "", "", "location of the YAML config file. Defaults to siad.yml in the sia directory if it exists. Flags take precedence over the config file")
+	root.Flags().BoolVarP(&globalConfig.Siad.AllowDegradedStartup, "allow-degraded-startup", "", false, "start without modules that fail to load instead of exiting. Failed modules and the modules depending on them are reported by /daemon/alerts")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusDatabase, "consensus-db", "", consensus.DatabaseBolt, "database backend of the consensus set, either 'bolt' or 'leveldb'. Switching backends requires resyncing the blockchain")
+	root.Flags().StringVarP(&globalConfig.Siad.ConsensusSnapshot, "consensus-snapshot", "", "", "consensus snapshot used to initialize a new consensus set instead of synchronizing the blockchain from genesis. Requires --consensus-snapshot-checksum")
+	root.Flags().StringVarP(&globalConfig.Siad.SnapshotChecksum, "consensus-snapshot-checksum", "", "", "checksum of the consensus snapshot as printed by 'siac consensus snapshot' on a trusted node. The snapshot is only imported if it matches")
+	root.Flags().BoolVarP(&globalConfig.Siad.ExplorerIndex, "explorer-index", "", false, "maintain an index of the unspent outputs of each address in the explorer, which is required to look up balances. Enabling the index rebuilds the explorer database")
+	root.Flags().BoolVarP(&globalConfig.Siad.LogJSON, "log-json", "", false, "write log files as JSON, one entry per line")
+	root.Flags().StringVarP(&globalConfig.Siad.LogLevel, "log-level", "", "info", "minimum level of logged entries, one of 'debug', 'info', 'warn' or 'error'. Can be changed per module at runtime")
+	root.Flags().DurationVarP(&globalConfig.Siad.LogMaxAge, "log-max-age", "", 0, "age at which log files are rotated, e.g. '24h'. 0 disables rotation by age")
+	root.Flags().IntVarP(&globalConfig.Siad.LogMaxBackups, "log-max-backups", "", 5, "number of rotated log files kept for each module")
+	root.Flags().Int64VarP(&globalConfig.Siad.LogMaxSize, "log-max-size", "", 0, "size in MB at which log files are rotated. 0 disables rotation by size")
 	root.Flags().BoolVarP(&globalConfig.Siad.UseUPNP, "upnp", "", true, "use UPnP for port forwarding and external IP discovery")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", defaultRPCAddr, "which port the gateway listens on")
@@ -203,6 +272,8 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "gctwrhfa", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
+	root.Flags().StringVarP(&globalConfig.Siad.WalletSigner, "wallet-signer", "", "", "address of an external signer, e.g. a hardware wallet bridge, used to sign for watched public keys")
+	root.Flags().StringVarP(&globalConfig.Siad.WalletPriceSource, "wallet-price-source", "", "", "url of an http price source used to value exported wallet history in fiat currencies")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
@@ -42,12 +42,49 @@ func parseModules(config Config) node.NodeParams {
 		params.CreateAccounting = true
 	}
 	// Parse remaining fields.
+	params.AllowDegradedStartup = config.Siad.AllowDegradedStartup
 	params.Bootstrap = !config.Siad.NoBootstrap
+	params.ConsensusDatabase = config.Siad.ConsensusDatabase
+	params.ConsensusSnapshot = config.Siad.ConsensusSnapshot
+	// The checksum has already been verified by processConfig.
+	_ = params.ConsensusSnapshotChecksum.LoadString(config.Siad.SnapshotChecksum)
+	params.ExplorerIndex = config.Siad.ExplorerIndex
 	params.UseUPNP = config.Siad.UseUPNP
 	params.HostAddress = config.Siad.HostAddr
 	params.RPCAddress = config.Siad.RPCaddr
 	params.SiaMuxTCPAddress = config.Siad.SiaMuxTCPAddr
 	params.SiaMuxWSAddress = config.Siad.SiaMuxWSAddr
 	params.Dir = config.Siad.SiaDir
+	params.ConfigFile = config.Siad.ConfigFile
+	params.ConfigFlags = config.ConfigFlags
+	params.WalletSigner = config.Siad.WalletSigner
+	params.WalletPriceSource = config.Siad.WalletPriceSource
+	params.GRPCAddress = config.Siad.GRPCAddr
+	params.APITLSCertFile = config.Siad.APITLSCert
+	params.APITLSKeyFile = config.Siad.APITLSKey
+	params.APITLSACMEEmail = config.Siad.APITLSACMEEmail
+	params.APITLSACMEDomains = splitList(config.Siad.APITLSACMEDomains)
+	params.APICORSOrigins = splitList(config.Siad.APICORSOrigins)
+	params.APICORSMethods = splitList(config.Siad.APICORSMethods)
+	params.APICORSHeaders = splitList(config.Siad.APICORSHeaders)
+	params.APIAuditLog = config.Siad.APIAuditLog
+	params.APIAuditRetention = config.Siad.APIAuditRetention
+	params.APIRateLimit = config.Siad.APIRateLimit
+	params.APIRateBurst = config.Siad.APIRateBurst
+	params.APIEndpointRateLimits = splitList(config.Siad.APIEndpointRateLimits)
+	params.APIMaxUploads = config.Siad.APIMaxUploads
+	params.APIMaxDownloads = config.Siad.APIMaxDownloads
+	params.APIMaxRescans = config.Siad.APIMaxRescans
 	return params
 }
+
+// splitList splits a comma separated list and drops empty elements.
+func splitList(list string) []string {
+	var elems []string
+	for _, elem := range strings.Split(list, ",") {
+		if elem = strings.TrimSpace(elem); elem != "" {
+			elems = append(elems, elem)
+		}
+	}
+	return elems
+}
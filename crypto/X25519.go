@@ -21,6 +21,14 @@ func GenerateX25519KeyPair() (xsk X25519SecretKey, xpk X25519PublicKey) {
 	return
 }
 
+// GenerateX25519KeyPairDeterministic generates a key pair from the provided
+// entropy.
+func GenerateX25519KeyPairDeterministic(entropy [EntropySize]byte) (xsk X25519SecretKey, xpk X25519PublicKey) {
+	copy(xsk[:], entropy[:])
+	curve25519.ScalarBaseMult((*[32]byte)(&xpk), (*[32]byte)(&xsk))
+	return
+}
+
 // DeriveSharedSecret derives 32 bytes of entropy from a secret key and public
 // key. Derivation is via ScalarMult of the private and public keys, followed
 // by a 256-bit unkeyed blake2b hash.
@@ -16,3 +16,20 @@ func TestDeriveSharedSecret(t *testing.T) {
 		t.Fatal("shared secret should not match")
 	}
 }
+
+// TestGenerateX25519KeyPairDeterministic tests that the same entropy results
+// in the same keypair and that the keypair can be used to derive a shared
+// secret.
+func TestGenerateX25519KeyPairDeterministic(t *testing.T) {
+	var entropy [EntropySize]byte
+	entropy[0] = 1
+	sk1, pk1 := GenerateX25519KeyPairDeterministic(entropy)
+	sk2, pk2 := GenerateX25519KeyPairDeterministic(entropy)
+	if sk1 != sk2 || pk1 != pk2 {
+		t.Fatal("keypairs don't match")
+	}
+	sk3, pk3 := GenerateX25519KeyPair()
+	if DeriveSharedSecret(sk1, pk3) != DeriveSharedSecret(sk3, pk1) {
+		t.Fatal("shared secret does not match")
+	}
+}
@@ -13,6 +13,8 @@ require (
 	github.com/klauspost/reedsolomon v1.9.3
 	github.com/pkg/errors v0.9.1
 	github.com/spf13/cobra v1.0.0
+	github.com/spf13/pflag v1.0.3
+	github.com/syndtr/goleveldb v1.0.0
 	github.com/vbauerster/mpb/v5 v5.0.3
 	gitlab.com/NebulousLabs/bolt v1.4.4
 	gitlab.com/NebulousLabs/demotemutex v0.0.0-20151003192217-235395f71c40
@@ -32,4 +34,7 @@ require (
 	golang.org/x/crypto v0.0.0-20220507011949-2cf3adece122
 	golang.org/x/net v0.0.0-20211112202133-69e39bad7dc2
 	golang.org/x/term v0.0.0-20210421210424-b80969c67360
+	google.golang.org/grpc v1.40.1
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v2 v2.2.3
 )
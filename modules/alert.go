@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"go.sia.tech/siad/build"
 )
@@ -49,6 +50,12 @@ const (
 	// call to 'gateway.Offline' if the value returned is 'false' and
 	// unregistered when it returns 'true'.
 	AlertIDGatewayOffline = "gateway-offline"
+	// AlertIDGatewayUnreachable is the id of the alert that is registered if
+	// the peers of the gateway are unable to dial back the gateway's port.
+	AlertIDGatewayUnreachable = "gateway-unreachable"
+	// AlertIDHostCollateralBudgetLow is the id of the alert that is
+	// registered if the host's collateral budget is nearly exhausted
+	AlertIDHostCollateralBudgetLow = "host-collateral-budget-low"
 	// AlertIDHostDiskTrouble is the id of the alert that is registered when the
 	// host is encountering problems interacting with one or more of his disks
 	AlertIDHostDiskTrouble = "host-disk-trouble"
@@ -56,8 +63,18 @@ const (
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDHostRestoredKeyMismatch is the id of the alert that is registered
+	// if the host's key was restored from a backup but the siamux is still
+	// using a different key
+	AlertIDHostRestoredKeyMismatch = "host-restored-key-mismatch"
 )
 
+// AlertIDModuleLoadFailed uses the name of a module to create a unique AlertID
+// for a module that failed to load.
+func AlertIDModuleLoadFailed(module string) AlertID {
+	return AlertID(fmt.Sprintf("module-load-failed:%v", module))
+}
+
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
 // for a low redundancy alert.
 func AlertIDSiafileLowRedundancy(uid string) AlertID {
@@ -73,6 +90,15 @@ type (
 
 	// Alert is a type that contains essential information about an alert.
 	Alert struct {
+		// ID is the stable identifier of the Alert. It doesn't change while
+		// the condition that caused the Alert persists.
+		ID AlertID `json:"id"`
+		// Time is the time at which the Alert was first registered.
+		Time time.Time `json:"time"`
+		// Acknowledged and Silenced indicate whether the Alert was
+		// acknowledged or silenced by the user.
+		Acknowledged bool `json:"acknowledged"`
+		Silenced     bool `json:"silenced"`
 		// Cause is the cause for the Alert.
 		// e.g. "Wallet is locked"
 		Cause string `json:"cause"`
@@ -124,19 +150,28 @@ func (a *AlertSeverity) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &severityStr); err != nil {
 		return err
 	}
-	switch severityStr {
+	severity, err := ParseAlertSeverity(severityStr)
+	if err != nil {
+		return err
+	}
+	*a = severity
+	return nil
+}
+
+// ParseAlertSeverity parses the string representation of an AlertSeverity.
+func ParseAlertSeverity(s string) (AlertSeverity, error) {
+	switch s {
 	case "info":
-		*a = SeverityInfo
+		return SeverityInfo, nil
 	case "warning":
-		*a = SeverityWarning
+		return SeverityWarning, nil
 	case "error":
-		*a = SeverityError
+		return SeverityError, nil
 	case "critical":
-		*a = SeverityCritical
+		return SeverityCritical, nil
 	default:
-		return fmt.Errorf("unknown severity '%v'", severityStr)
+		return SeverityUnknown, fmt.Errorf("unknown severity '%v'", s)
 	}
-	return nil
 }
 
 // String converts an alertSeverity to a string
@@ -200,7 +235,13 @@ func (a *GenericAlerter) Alerts() (crit, err, warn, info []Alert) {
 func (a *GenericAlerter) RegisterAlert(id AlertID, msg, cause string, severity AlertSeverity) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
+	registered := time.Now()
+	if alert, exists := a.alerts[id]; exists {
+		registered = alert.Time
+	}
 	a.alerts[id] = Alert{
+		ID:       id,
+		Time:     registered,
 		Cause:    cause,
 		Module:   a.module,
 		Msg:      msg,
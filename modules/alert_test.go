@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"strconv"
 	"testing"
+	"time"
 )
 
 // TestMarshalUnmarshalAlertSeverity tests the custom marshaling/unmarshaling
@@ -97,3 +98,32 @@ func TestAlertsSorted(t *testing.T) {
 		}
 	}
 }
+
+// TestAlertIDAndTime tests that registered alerts carry their ID and keep the
+// time of their first registration.
+func TestAlertIDAndTime(t *testing.T) {
+	alerter := NewAlerter(t.Name())
+	alerter.RegisterAlert("id", "msg", "cause", SeverityWarning)
+	_, _, warn, _ := alerter.Alerts()
+	if len(warn) != 1 || warn[0].ID != "id" || warn[0].Time.IsZero() {
+		t.Fatal("unexpected alert", warn)
+	}
+	registered := warn[0].Time
+
+	// Registering the alert again should keep its time.
+	time.Sleep(time.Millisecond)
+	alerter.RegisterAlert("id", "msg", "new cause", SeverityWarning)
+	_, _, warn, _ = alerter.Alerts()
+	if len(warn) != 1 || warn[0].Cause != "new cause" || !warn[0].Time.Equal(registered) {
+		t.Fatal("unexpected alert", warn)
+	}
+
+	// Registering it after it was unregistered should update the time.
+	alerter.UnregisterAlert("id")
+	time.Sleep(time.Millisecond)
+	alerter.RegisterAlert("id", "msg", "cause", SeverityWarning)
+	_, _, warn, _ = alerter.Alerts()
+	if len(warn) != 1 || !warn[0].Time.After(registered) {
+		t.Fatal("unexpected alert", warn)
+	}
+}
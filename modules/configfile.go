@@ -0,0 +1,252 @@
+package modules
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/url"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gopkg.in/yaml.v2"
+
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// ConfigFileName is the default name of the siad config file in the siad data
+// directory.
+const ConfigFileName = "siad.yml"
+
+type (
+	// ConfigFile is the YAML configuration file of siad. The Daemon section
+	// maps the names of siad's flags to their values and is only applied at
+	// startup. The other sections are applied at startup and whenever the file
+	// is reloaded. Unset fields leave the current setting unchanged.
+	ConfigFile struct {
+		Daemon    map[string]string   `json:"daemon" yaml:"daemon"`
+		Log       ConfigFileLog       `json:"log" yaml:"log"`
+		Ratelimit ConfigFileRatelimit `json:"ratelimit" yaml:"ratelimit"`
+		Host      ConfigFileHost      `json:"host" yaml:"host"`
+		Renter    ConfigFileRenter    `json:"renter" yaml:"renter"`
+		Alerts    *ConfigFileAlerts   `json:"alerts,omitempty" yaml:"alerts"`
+	}
+
+	// ConfigFileAlerts configures the notifications about alerts. If the
+	// section is set, it replaces the current notification settings.
+	ConfigFileAlerts struct {
+		MinSeverity string          `json:"minseverity,omitempty" yaml:"minseverity"`
+		Webhooks    []string        `json:"webhooks,omitempty" yaml:"webhooks"`
+		Email       ConfigFileEmail `json:"email" yaml:"email"`
+	}
+
+	// ConfigFileEmail configures the SMTP server that alerts are emailed with.
+	// No emails are sent without recipients.
+	ConfigFileEmail struct {
+		SMTPServer string   `json:"smtpserver,omitempty" yaml:"smtpserver"`
+		Username   string   `json:"username,omitempty" yaml:"username"`
+		Password   string   `json:"password,omitempty" yaml:"password"`
+		From       string   `json:"from,omitempty" yaml:"from"`
+		To         []string `json:"to,omitempty" yaml:"to"`
+	}
+
+	// ConfigFileLog contains the log levels. Modules maps the names of modules
+	// to their level.
+	ConfigFileLog struct {
+		Level   string            `json:"level,omitempty" yaml:"level"`
+		Modules map[string]string `json:"modules,omitempty" yaml:"modules"`
+	}
+
+	// ConfigFileRatelimit contains the global bandwidth limits of siad in
+	// bytes per second.
+	ConfigFileRatelimit struct {
+		MaxDownloadSpeed *int64 `json:"maxdownloadspeed,omitempty" yaml:"maxdownloadspeed"`
+		MaxUploadSpeed   *int64 `json:"maxuploadspeed,omitempty" yaml:"maxuploadspeed"`
+	}
+
+	// ConfigFileHost contains the dynamically adjustable settings of the host.
+	// Currency amounts are specified with units, e.g. "100SC".
+	ConfigFileHost struct {
+		AcceptingContracts *bool              `json:"acceptingcontracts,omitempty" yaml:"acceptingcontracts"`
+		MaxDuration        *types.BlockHeight `json:"maxduration,omitempty" yaml:"maxduration"`
+		WindowSize         *types.BlockHeight `json:"windowsize,omitempty" yaml:"windowsize"`
+
+		Collateral       string `json:"collateral,omitempty" yaml:"collateral"`
+		CollateralBudget string `json:"collateralbudget,omitempty" yaml:"collateralbudget"`
+		MaxCollateral    string `json:"maxcollateral,omitempty" yaml:"maxcollateral"`
+
+		MinContractPrice          string `json:"mincontractprice,omitempty" yaml:"mincontractprice"`
+		MinDownloadBandwidthPrice string `json:"mindownloadbandwidthprice,omitempty" yaml:"mindownloadbandwidthprice"`
+		MinStoragePrice           string `json:"minstorageprice,omitempty" yaml:"minstorageprice"`
+		MinUploadBandwidthPrice   string `json:"minuploadbandwidthprice,omitempty" yaml:"minuploadbandwidthprice"`
+	}
+
+	// ConfigFileRenter contains the dynamically adjustable settings of the
+	// renter.
+	ConfigFileRenter struct {
+		IPViolationCheck *bool  `json:"ipviolationcheck,omitempty" yaml:"ipviolationcheck"`
+		MaxDownloadSpeed *int64 `json:"maxdownloadspeed,omitempty" yaml:"maxdownloadspeed"`
+		MaxUploadSpeed   *int64 `json:"maxuploadspeed,omitempty" yaml:"maxuploadspeed"`
+	}
+)
+
+var (
+	// ErrInvalidConfigFile is returned when the config file fails validation.
+	ErrInvalidConfigFile = errors.New("invalid config file")
+)
+
+// LoadConfigFile loads and validates the config file at path. Unknown fields
+// are rejected to catch typos.
+func LoadConfigFile(path string) (ConfigFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ConfigFile{}, err
+	}
+	var cf ConfigFile
+	if err := yaml.UnmarshalStrict(b, &cf); err != nil {
+		return ConfigFile{}, errors.Compose(ErrInvalidConfigFile, err)
+	}
+	if err := cf.Validate(); err != nil {
+		return ConfigFile{}, errors.Compose(ErrInvalidConfigFile, err)
+	}
+	return cf, nil
+}
+
+// Validate checks that the values of the config file are valid.
+func (cf ConfigFile) Validate() error {
+	var errs []error
+	if cf.Log.Level != "" {
+		if _, err := persist.ParseLogLevel(cf.Log.Level); err != nil {
+			errs = append(errs, errors.AddContext(err, "log.level"))
+		}
+	}
+	for module, level := range cf.Log.Modules {
+		if _, err := persist.ParseLogLevel(level); err != nil {
+			errs = append(errs, errors.AddContext(err, "log.modules."+module))
+		}
+	}
+	speeds := map[string]*int64{
+		"ratelimit.maxdownloadspeed": cf.Ratelimit.MaxDownloadSpeed,
+		"ratelimit.maxuploadspeed":   cf.Ratelimit.MaxUploadSpeed,
+		"renter.maxdownloadspeed":    cf.Renter.MaxDownloadSpeed,
+		"renter.maxuploadspeed":      cf.Renter.MaxUploadSpeed,
+	}
+	for name, speed := range speeds {
+		if speed != nil && *speed < 0 {
+			errs = append(errs, errors.New(name+" can't be negative"))
+		}
+	}
+	for name, amount := range cf.Host.currencies() {
+		if _, err := ParseConfigCurrency(amount); err != nil {
+			errs = append(errs, errors.AddContext(err, "host."+name))
+		}
+	}
+	if cf.Alerts != nil {
+		errs = append(errs, cf.Alerts.validate())
+	}
+	return errors.Compose(errs...)
+}
+
+// validate checks that the values of the alerts section are valid.
+func (a ConfigFileAlerts) validate() error {
+	var errs []error
+	if a.MinSeverity != "" {
+		if _, err := ParseAlertSeverity(a.MinSeverity); err != nil {
+			errs = append(errs, errors.AddContext(err, "alerts.minseverity"))
+		}
+	}
+	for _, webhook := range a.Webhooks {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, errors.New("alerts.webhooks: invalid url "+webhook))
+		}
+	}
+	if len(a.Email.To) > 0 {
+		if _, _, err := net.SplitHostPort(a.Email.SMTPServer); err != nil {
+			errs = append(errs, errors.AddContext(err, "alerts.email.smtpserver"))
+		}
+		if a.Email.From == "" {
+			errs = append(errs, errors.New("alerts.email.from is required to send emails"))
+		}
+	}
+	return errors.Compose(errs...)
+}
+
+// currencies returns the set currency fields of the host section by name.
+func (h ConfigFileHost) currencies() map[string]string {
+	all := map[string]string{
+		"collateral":                h.Collateral,
+		"collateralbudget":          h.CollateralBudget,
+		"maxcollateral":             h.MaxCollateral,
+		"mincontractprice":          h.MinContractPrice,
+		"mindownloadbandwidthprice": h.MinDownloadBandwidthPrice,
+		"minstorageprice":           h.MinStoragePrice,
+		"minuploadbandwidthprice":   h.MinUploadBandwidthPrice,
+	}
+	set := make(map[string]string)
+	for name, amount := range all {
+		if amount != "" {
+			set[name] = amount
+		}
+	}
+	return set
+}
+
+// Apply applies the set fields of the host section to the host's internal
+// settings.
+func (h ConfigFileHost) Apply(his HostInternalSettings) (HostInternalSettings, error) {
+	if h.AcceptingContracts != nil {
+		his.AcceptingContracts = *h.AcceptingContracts
+	}
+	if h.MaxDuration != nil {
+		his.MaxDuration = *h.MaxDuration
+	}
+	if h.WindowSize != nil {
+		his.WindowSize = *h.WindowSize
+	}
+	fields := map[string]*types.Currency{
+		"collateral":                &his.Collateral,
+		"collateralbudget":          &his.CollateralBudget,
+		"maxcollateral":             &his.MaxCollateral,
+		"mincontractprice":          &his.MinContractPrice,
+		"mindownloadbandwidthprice": &his.MinDownloadBandwidthPrice,
+		"minstorageprice":           &his.MinStoragePrice,
+		"minuploadbandwidthprice":   &his.MinUploadBandwidthPrice,
+	}
+	for name, amount := range h.currencies() {
+		c, err := ParseConfigCurrency(amount)
+		if err != nil {
+			return HostInternalSettings{}, errors.AddContext(err, name)
+		}
+		*fields[name] = c
+	}
+	return his, nil
+}
+
+// Apply applies the set fields of the renter section to the renter's
+// settings.
+func (r ConfigFileRenter) Apply(rs RenterSettings) RenterSettings {
+	if r.IPViolationCheck != nil {
+		rs.IPViolationCheck = *r.IPViolationCheck
+	}
+	if r.MaxDownloadSpeed != nil {
+		rs.MaxDownloadSpeed = *r.MaxDownloadSpeed
+	}
+	if r.MaxUploadSpeed != nil {
+		rs.MaxUploadSpeed = *r.MaxUploadSpeed
+	}
+	return rs
+}
+
+// ParseConfigCurrency parses a currency amount with units, e.g. "100SC" or
+// "1000H".
+func ParseConfigCurrency(amount string) (types.Currency, error) {
+	hastings, err := types.ParseCurrency(amount)
+	if err != nil {
+		return types.Currency{}, err
+	}
+	i, ok := new(big.Int).SetString(hastings, 10)
+	if !ok || i.Sign() < 0 {
+		return types.Currency{}, types.ErrParseCurrencyAmount
+	}
+	return types.NewCurrency(i), nil
+}
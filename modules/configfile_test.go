@@ -0,0 +1,97 @@
+package modules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestLoadConfigFile probes LoadConfigFile.
+func TestLoadConfigFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("configfile", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigFileName)
+	write := func(s string) {
+		if err := ioutil.WriteFile(path, []byte(s), persist.DefaultDiskPermissionsTest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Load a valid config file.
+	write(`
+daemon:
+  api-addr: localhost:9980
+log:
+  level: warn
+  modules:
+    renter: debug
+ratelimit:
+  maxdownloadspeed: 1000
+host:
+  acceptingcontracts: true
+  minstorageprice: 100SC
+renter:
+  ipviolationcheck: false
+alerts:
+  minseverity: error
+  webhooks:
+    - https://example.com/hook
+`)
+	cf, err := LoadConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cf.Daemon["api-addr"] != "localhost:9980" || cf.Log.Modules["renter"] != "debug" {
+		t.Fatalf("unexpected config %+v", cf)
+	}
+	if cf.Ratelimit.MaxDownloadSpeed == nil || *cf.Ratelimit.MaxDownloadSpeed != 1000 || cf.Ratelimit.MaxUploadSpeed != nil {
+		t.Fatal("unexpected ratelimit", cf.Ratelimit)
+	}
+	if cf.Alerts == nil || cf.Alerts.MinSeverity != "error" || len(cf.Alerts.Webhooks) != 1 {
+		t.Fatal("unexpected alerts", cf.Alerts)
+	}
+
+	// Only the set fields should be applied.
+	his, err := cf.Host.Apply(HostInternalSettings{MaxDuration: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !his.AcceptingContracts || his.MaxDuration != 10 || !his.MinStoragePrice.Equals(types.SiacoinPrecision.Mul64(100)) {
+		t.Fatalf("unexpected host settings %+v", his)
+	}
+	rs := cf.Renter.Apply(RenterSettings{IPViolationCheck: true, MaxUploadSpeed: 5})
+	if rs.IPViolationCheck || rs.MaxUploadSpeed != 5 {
+		t.Fatalf("unexpected renter settings %+v", rs)
+	}
+
+	// Invalid config files should be rejected.
+	invalid := []string{
+		"unknown: true",
+		"log:\n  level: verbose",
+		"ratelimit:\n  maxuploadspeed: -1",
+		"host:\n  minstorageprice: 100",
+		"renter:\n  ipviolationcheck: maybe",
+		"alerts:\n  minseverity: fatal",
+		"alerts:\n  webhooks: [example.com]",
+		"alerts:\n  email:\n    to: [ops@example.com]",
+	}
+	for _, s := range invalid {
+		write(s)
+		if _, err := LoadConfigFile(path); !errors.Contains(err, ErrInvalidConfigFile) {
+			t.Errorf("expected ErrInvalidConfigFile for %q, got %v", s, err)
+		}
+	}
+}
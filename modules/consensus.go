@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
 )
@@ -52,6 +53,16 @@ var (
 	// in a fork that is the heaviest known fork - the consensus set has not
 	// changed as a result of seeing the block.
 	ErrNonExtendingBlock = errors.New("block does not extend the longest fork")
+
+	// ConsensusSnapshotMaxDepth is the maximum number of blocks a consensus
+	// snapshot can be below the current height. Exporting a snapshot reverts
+	// the blocks above its height while holding the consensus lock.
+	ConsensusSnapshotMaxDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Testnet:  types.BlockHeight(144),
+		Dev:      types.BlockHeight(50),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
 )
 
 type (
@@ -222,10 +233,22 @@ type (
 		// A channel can be provided to abort the subscription process.
 		ConsensusSetSubscribe(ConsensusSetSubscriber, ConsensusChangeID, <-chan struct{}) error
 
+		// ConsensusChangeAtHeight returns the id of the latest consensus
+		// change after which the blockchain had a height of at most 'height'
+		// and never dropped below that height again. Subscribing with the
+		// returned id replays the changes of all blocks starting at
+		// startHeight, which is at most height+1.
+		ConsensusChangeAtHeight(height types.BlockHeight) (id ConsensusChangeID, startHeight types.BlockHeight, err error)
+
 		// CurrentBlock returns the latest block in the heaviest known
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportSnapshot writes a snapshot of the consensus set at the given
+		// height to the writer. The snapshot can be imported to bootstrap a
+		// new node.
+		ExportSnapshot(w io.Writer, height types.BlockHeight) error
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
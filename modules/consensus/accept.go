@@ -15,6 +15,7 @@ import (
 )
 
 var (
+	errBadNonce       = errors.New("block does not meet nonce requirements")
 	errDoSBlock       = errors.New("block is known to be invalid")
 	errNoBlockMap     = errors.New("block map is not in database")
 	errNonLinearChain = errors.New("block set is not a contiguous chain")
@@ -109,7 +110,7 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 
 	// Check that the nonce is a legal nonce.
 	if parent.Height+1 >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(h.Nonce[:])%types.ASICHardforkFactor != 0 {
-		return errors.New("block does not meet nonce requirements")
+		return errBadNonce
 	}
 	// Check that the target of the new block is sufficient.
 	if !checkHeaderTarget(h, parent.ChildTarget) {
@@ -150,7 +151,7 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 // on the block. Such errors are handled outside of the transaction by the
 // caller. Switching to a managed tx through bolt will make this complexity
 // unneeded.
-func (cs *ConsensusSet) addBlockToTree(tx *bolt.Tx, b types.Block, parent *processedBlock) (ce changeEntry, err error) {
+func (cs *ConsensusSet) addBlockToTree(tx databaseTx, b types.Block, parent *processedBlock) (ce changeEntry, err error) {
 	// Prepare the child processed block associated with the parent block.
 	newNode := cs.newChild(tx, parent, b)
 
@@ -244,11 +245,11 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	// invalid blocks (which includes the children of invalid blocks).
 	chainExtended := false
 	changes := make([]changeEntry, 0, len(blocks))
-	setErr := cs.db.Update(func(tx *bolt.Tx) error {
+	setErr := cs.db.Update(func(tx databaseTx) error {
 		for i := 0; i < len(blocks); i++ {
 			// Start by checking the header of the block.
 			startTime := time.Now()
-			parent, err := cs.validateHeaderAndBlock(boltTxWrapper{tx}, blocks[i], blockIDs[i])
+			parent, err := cs.validateHeaderAndBlock(txWrapper{tx}, blocks[i], blockIDs[i])
 			cs.log.Debugf("validateHeaderAndBlock time: %v", time.Since(startTime).Round(time.Millisecond))
 
 			if errors.Contains(err, modules.ErrBlockKnown) {
@@ -6,7 +6,6 @@ import (
 	"time"
 	"unsafe"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 
@@ -1223,7 +1222,7 @@ func TestChainedAcceptBlock(t *testing.T) {
 	// Check that every change recorded in 'bcs' is also available in the
 	// consensus set.
 	for _, change := range bcs.changes {
-		err := cst2.cs.db.Update(func(tx *bolt.Tx) error {
+		err := cst2.cs.db.Update(func(tx databaseTx) error {
 			_, exists := getEntry(tx, change)
 			if !exists {
 				t.Error("an entry was provided that doesn't exist")
@@ -1261,7 +1260,7 @@ func TestFoundationUpdateBlocks(t *testing.T) {
 	}
 
 	foundationOutput := func(height types.BlockHeight) (id types.SiacoinOutputID, sco types.SiacoinOutput, exists bool) {
-		err := cst.cs.db.View(func(tx *bolt.Tx) error {
+		err := cst.cs.db.View(func(tx databaseTx) error {
 			bid, err := getPath(tx, height)
 			if err != nil {
 				t.Fatal(err)
@@ -6,7 +6,6 @@ package consensus
 import (
 	"bytes"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/encoding"
 
 	"go.sia.tech/siad/build"
@@ -16,7 +15,7 @@ import (
 
 // applySiacoinInputs takes all of the siacoin inputs in a transaction and
 // applies them to the state, updating the diffs in the processed block.
-func applySiacoinInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applySiacoinInputs(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	// Remove all siacoin inputs from the unspent siacoin outputs list.
 	for _, sci := range t.SiacoinInputs {
 		sco, err := getSiacoinOutput(tx, sci.ParentID)
@@ -35,7 +34,7 @@ func applySiacoinInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 
 // applySiacoinOutputs takes all of the siacoin outputs in a transaction and
 // applies them to the state, updating the diffs in the processed block.
-func applySiacoinOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applySiacoinOutputs(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	// Add all siacoin outputs to the unspent siacoin outputs list.
 	for i, sco := range t.SiacoinOutputs {
 		scoid := t.SiacoinOutputID(uint64(i))
@@ -52,7 +51,7 @@ func applySiacoinOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // applyFileContracts iterates through all of the file contracts in a
 // transaction and applies them to the state, updating the diffs in the proccesed
 // block.
-func applyFileContracts(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applyFileContracts(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	for i, fc := range t.FileContracts {
 		fcid := t.FileContractID(uint64(i))
 		fcd := modules.FileContractDiff{
@@ -79,7 +78,7 @@ func applyFileContracts(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // applyFileContractRevisions iterates through all of the file contract
 // revisions in a transaction and applies them to the state, updating the diffs
 // in the processed block.
-func applyFileContractRevisions(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applyFileContractRevisions(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	for _, fcr := range t.FileContractRevisions {
 		fc, err := getFileContract(tx, fcr.ParentID)
 		if build.DEBUG && err != nil {
@@ -120,7 +119,7 @@ func applyFileContractRevisions(tx *bolt.Tx, pb *processedBlock, t types.Transac
 // applyTxStorageProofs iterates through all of the storage proofs in a
 // transaction and applies them to the state, updating the diffs in the processed
 // block.
-func applyStorageProofs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applyStorageProofs(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	for _, sp := range t.StorageProofs {
 		fc, err := getFileContract(tx, sp.ParentID)
 		if build.DEBUG && err != nil {
@@ -152,7 +151,7 @@ func applyStorageProofs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 
 // applyTxSiafundInputs takes all of the siafund inputs in a transaction and
 // applies them to the state, updating the diffs in the processed block.
-func applySiafundInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applySiafundInputs(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	for _, sfi := range t.SiafundInputs {
 		// Calculate the volume of siacoins to put in the claim output.
 		sfo, err := getSiafundOutput(tx, sfi.ParentID)
@@ -189,7 +188,7 @@ func applySiafundInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 }
 
 // applySiafundOutputs applies a siafund output to the consensus set.
-func applySiafundOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applySiafundOutputs(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	for i, sfo := range t.SiafundOutputs {
 		sfoid := t.SiafundOutputID(uint64(i))
 		sfo.ClaimStart = getSiafundPool(tx)
@@ -212,7 +211,7 @@ func applySiafundOutputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // Accordingly, this function dispatches on the various ArbitraryData values
 // that are recognized by consensus. Currently, types.FoundationUnlockHashUpdate
 // is the only recognized value.
-func applyArbitraryData(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applyArbitraryData(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	// No ArbitraryData values were recognized prior to the Foundation hardfork.
 	if pb.Height < types.FoundationHardforkHeight {
 		return
@@ -247,7 +246,7 @@ func applyArbitraryData(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 // transferFoundationOutputs transfers all unspent subsidy outputs to
 // newPrimary. This allows subsidies to be recovered in the event that the
 // primary key is lost or unusable when a subsidy is created.
-func transferFoundationOutputs(tx *bolt.Tx, currentHeight types.BlockHeight, newPrimary types.UnlockHash) {
+func transferFoundationOutputs(tx databaseTx, currentHeight types.BlockHeight, newPrimary types.UnlockHash) {
 	for height := types.FoundationHardforkHeight; height < currentHeight; height += types.FoundationSubsidyFrequency {
 		blockID, err := getPath(tx, height)
 		if err != nil {
@@ -270,7 +269,7 @@ func transferFoundationOutputs(tx *bolt.Tx, currentHeight types.BlockHeight, new
 // applyTransaction applies the contents of a transaction to the ConsensusSet.
 // This produces a set of diffs, which are stored in the blockNode containing
 // the transaction. No verification is done by this function.
-func applyTransaction(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
+func applyTransaction(tx databaseTx, pb *processedBlock, t types.Transaction) {
 	applySiacoinInputs(tx, pb, t)
 	applySiacoinOutputs(tx, pb, t)
 	applyFileContracts(tx, pb, t)
@@ -3,7 +3,6 @@ package consensus
 import (
 	"testing"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/types"
 )
@@ -911,7 +910,7 @@ func TestApplyArbitraryData(t *testing.T) {
 	}()
 
 	apply := func(txn types.Transaction, height types.BlockHeight) {
-		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+		err := cst.cs.db.Update(func(tx databaseTx) error {
 			// applyArbitraryData expects a BlockPath entry at this height
 			tx.Bucket(BlockPath).Put(encoding.Marshal(height), encoding.Marshal(types.BlockID{}))
 			applyArbitraryData(tx, &processedBlock{Height: height}, txn)
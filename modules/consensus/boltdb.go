@@ -0,0 +1,80 @@
+package consensus
+
+import (
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/persist"
+)
+
+type (
+	// boltDatabase is the bolt backend of the consensus database.
+	boltDatabase struct {
+		*persist.BoltDatabase
+	}
+
+	// boltTx wraps a bolt.Tx so that it matches the databaseTx interface.
+	boltTx struct {
+		tx *bolt.Tx
+	}
+)
+
+// openBoltDatabase opens the bolt database at filename.
+func openBoltDatabase(filename string) (database, error) {
+	db, err := persist.OpenDatabase(dbMetadata, filename)
+	if err != nil {
+		return nil, err
+	}
+	return boltDatabase{db}, nil
+}
+
+// Update executes fn within a read-write bolt transaction.
+func (db boltDatabase) Update(fn func(tx databaseTx) error) error {
+	return db.BoltDatabase.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// View executes fn within a read-only bolt transaction.
+func (db boltDatabase) View(fn func(tx databaseTx) error) error {
+	return db.BoltDatabase.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// wrapBoltBucket converts a bolt.Bucket to a databaseBucket without wrapping
+// a nil bucket in a non-nil interface.
+func wrapBoltBucket(b *bolt.Bucket) databaseBucket {
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// Bucket returns the bucket with the given name or nil if it doesn't exist.
+func (tx boltTx) Bucket(name []byte) databaseBucket {
+	return wrapBoltBucket(tx.tx.Bucket(name))
+}
+
+// CreateBucket creates a new bucket.
+func (tx boltTx) CreateBucket(name []byte) (databaseBucket, error) {
+	b, err := tx.tx.CreateBucket(name)
+	return wrapBoltBucket(b), err
+}
+
+// CreateBucketIfNotExists creates a new bucket if it doesn't exist yet.
+func (tx boltTx) CreateBucketIfNotExists(name []byte) (databaseBucket, error) {
+	b, err := tx.tx.CreateBucketIfNotExists(name)
+	return wrapBoltBucket(b), err
+}
+
+// DeleteBucket deletes a bucket.
+func (tx boltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+// ForEach calls fn for every bucket.
+func (tx boltTx) ForEach(fn func(name []byte, b databaseBucket) error) error {
+	return tx.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		return fn(name, wrapBoltBucket(b))
+	})
+}
@@ -11,14 +11,14 @@ package consensus
 // forward to the next change. In bolt, the key is a hash of the changeEntry
 // and the value is a struct containing the changeEntry and the key of the next
 // changeEntry. The empty hash key leads to the 'changeTail', which contains
-// the id of the most recent changeEntry.
+// the id of the most recent changeEntry. A separate bucket maps every
+// changeEntry to the previous changeEntry so that the changelog can be walked
+// backwards from the tail.
 //
 // Initialization only needs to worry about creating the blank change entry,
 // the genesis block will call 'append' later on during initialization.
 
 import (
-	"gitlab.com/NebulousLabs/bolt"
-
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -35,6 +35,10 @@ var (
 	// ChangeLogTailID is a key that points to the id of the current changelog
 	// tail.
 	ChangeLogTailID = []byte("ChangeLogTailID")
+
+	// ChangeLogPrev maps the id of every change entry to the id of the
+	// previous change entry.
+	ChangeLogPrev = []byte("ChangeLogPrev")
 )
 
 type (
@@ -53,7 +57,7 @@ type (
 )
 
 // appendChangeLog adds a new change entry to the change log.
-func appendChangeLog(tx *bolt.Tx, ce changeEntry) error {
+func appendChangeLog(tx databaseTx, ce changeEntry) error {
 	// Insert the change entry.
 	cl := tx.Bucket(ChangeLog)
 	ceid := ce.ID()
@@ -84,6 +88,16 @@ func appendChangeLog(tx *bolt.Tx, ce changeEntry) error {
 		}
 	}
 
+	// Point the new change entry back to the old tail.
+	prev := tx.Bucket(ChangeLogPrev)
+	if prev == nil {
+		return errNilBucket
+	}
+	err = prev.Put(ceid[:], tailID[:])
+	if err != nil {
+		return err
+	}
+
 	// Update the tail id.
 	err = cl.Put(ChangeLogTailID, ceid[:])
 	if err != nil {
@@ -94,7 +108,7 @@ func appendChangeLog(tx *bolt.Tx, ce changeEntry) error {
 
 // getEntry returns the change entry with a given id, using a bool to indicate
 // existence.
-func getEntry(tx *bolt.Tx, id modules.ConsensusChangeID) (ce changeEntry, exists bool) {
+func getEntry(tx databaseTx, id modules.ConsensusChangeID) (ce changeEntry, exists bool) {
 	var cn changeNode
 	cl := tx.Bucket(ChangeLog)
 	changeNodeBytes := cl.Get(id[:])
@@ -114,7 +128,7 @@ func (ce *changeEntry) ID() modules.ConsensusChangeID {
 }
 
 // NextEntry returns the entry after the current entry.
-func (ce *changeEntry) NextEntry(tx *bolt.Tx) (nextEntry changeEntry, exists bool) {
+func (ce *changeEntry) NextEntry(tx databaseTx) (nextEntry changeEntry, exists bool) {
 	// Get the change node associated with the provided change entry.
 	ceid := ce.ID()
 	var cn changeNode
@@ -128,8 +142,15 @@ func (ce *changeEntry) NextEntry(tx *bolt.Tx) (nextEntry changeEntry, exists boo
 	return getEntry(tx, cn.Next)
 }
 
+// prevEntryID returns the id of the entry before the entry with the provided
+// id. The genesis entry has no previous entry and returns the empty id.
+func prevEntryID(tx databaseTx, id modules.ConsensusChangeID) (prevID modules.ConsensusChangeID) {
+	copy(prevID[:], tx.Bucket(ChangeLogPrev).Get(id[:]))
+	return prevID
+}
+
 // createChangeLog assumes that no change log exists and creates a new one.
-func (cs *ConsensusSet) createChangeLog(tx *bolt.Tx) error {
+func (cs *ConsensusSet) createChangeLog(tx databaseTx) error {
 	// Create the changelog bucket.
 	cl, err := tx.CreateBucket(ChangeLog)
 	if err != nil {
@@ -154,6 +175,33 @@ func (cs *ConsensusSet) createChangeLog(tx *bolt.Tx) error {
 	return nil
 }
 
+// initChangeLogPrev creates the bucket which links every change entry to the
+// previous change entry if it doesn't exist yet. Older databases only link the
+// changelog forward, so the bucket is filled by walking the changelog once.
+func (cs *ConsensusSet) initChangeLogPrev(tx databaseTx) error {
+	if tx.Bucket(ChangeLogPrev) != nil {
+		return nil
+	}
+	prev, err := tx.CreateBucket(ChangeLogPrev)
+	if err != nil {
+		return err
+	}
+	// The database only copies keys and values when the transaction commits,
+	// so every entry needs its own copy of the previous id.
+	var prevID modules.ConsensusChangeID
+	entry, exists := cs.genesisEntry(), true
+	for exists {
+		id, prevIDCopy := entry.ID(), prevID
+		err = prev.Put(id[:], prevIDCopy[:])
+		if err != nil {
+			return err
+		}
+		prevID = id
+		entry, exists = entry.NextEntry(tx)
+	}
+	return nil
+}
+
 // genesisEntry returns the id of the genesis block log entry.
 func (cs *ConsensusSet) genesisEntry() changeEntry {
 	return changeEntry{
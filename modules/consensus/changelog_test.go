@@ -61,3 +61,59 @@ func TestIntegrationChangeLog(t *testing.T) {
 		t.Error("subscribers have inconsistent update chains")
 	}
 }
+
+// TestChangeLogPrev checks that every change entry is linked to the previous
+// entry, both when appending entries and when linking the changelog of an
+// older database.
+func TestChangeLogPrev(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		cst.testSimpleBlock()
+	}
+
+	// checkLinks walks the changelog forward and checks that every entry
+	// points back to the entry before it.
+	checkLinks := func() {
+		t.Helper()
+		err := cst.cs.db.View(func(tx databaseTx) error {
+			var prevID modules.ConsensusChangeID
+			entry, exists := cst.cs.genesisEntry(), true
+			for exists {
+				if prevEntryID(tx, entry.ID()) != prevID {
+					t.Fatal("entry isn't linked to the previous entry")
+				}
+				prevID = entry.ID()
+				entry, exists = entry.NextEntry(tx)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkLinks()
+
+	// Remove the links and link the changelog again.
+	err = cst.cs.db.Update(func(tx databaseTx) error {
+		if err := tx.DeleteBucket(ChangeLogPrev); err != nil {
+			return err
+		}
+		return cst.cs.initChangeLogPrev(tx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLinks()
+}
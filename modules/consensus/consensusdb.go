@@ -7,8 +7,6 @@ package consensus
 // ignored otherwise, which is suboptimal.
 
 import (
-	"gitlab.com/NebulousLabs/bolt"
-
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -90,7 +88,7 @@ var (
 )
 
 // createConsensusObjects initializes the consensus portions of the database.
-func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
+func (cs *ConsensusSet) createConsensusDB(tx databaseTx) error {
 	// Enumerate and create the database buckets.
 	buckets := [][]byte{
 		BlockHeight,
@@ -149,7 +147,7 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 }
 
 // blockHeight returns the height of the blockchain.
-func blockHeight(tx *bolt.Tx) types.BlockHeight {
+func blockHeight(tx databaseTx) types.BlockHeight {
 	var height types.BlockHeight
 	bh := tx.Bucket(BlockHeight)
 	err := encoding.Unmarshal(bh.Get(BlockHeight), &height)
@@ -160,7 +158,7 @@ func blockHeight(tx *bolt.Tx) types.BlockHeight {
 }
 
 // currentBlockID returns the id of the most recent block in the consensus set.
-func currentBlockID(tx *bolt.Tx) types.BlockID {
+func currentBlockID(tx databaseTx) types.BlockID {
 	id, err := getPath(tx, blockHeight(tx))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -169,9 +167,9 @@ func currentBlockID(tx *bolt.Tx) types.BlockID {
 }
 
 // dbCurrentBlockID is a convenience function allowing currentBlockID to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbCurrentBlockID() (id types.BlockID) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		id = currentBlockID(tx)
 		return nil
 	})
@@ -182,7 +180,7 @@ func (cs *ConsensusSet) dbCurrentBlockID() (id types.BlockID) {
 }
 
 // currentProcessedBlock returns the most recent block in the consensus set.
-func currentProcessedBlock(tx *bolt.Tx) *processedBlock {
+func currentProcessedBlock(tx databaseTx) *processedBlock {
 	pb, err := getBlockMap(tx, currentBlockID(tx))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -191,7 +189,7 @@ func currentProcessedBlock(tx *bolt.Tx) *processedBlock {
 }
 
 // getBlockMap returns a processed block with the input id.
-func getBlockMap(tx *bolt.Tx, id types.BlockID) (*processedBlock, error) {
+func getBlockMap(tx databaseTx, id types.BlockID) (*processedBlock, error) {
 	// Look up the encoded block.
 	pbBytes := tx.Bucket(BlockMap).Get(id[:])
 	if pbBytes == nil {
@@ -208,7 +206,7 @@ func getBlockMap(tx *bolt.Tx, id types.BlockID) (*processedBlock, error) {
 }
 
 // addBlockMap adds a processed block to the block map.
-func addBlockMap(tx *bolt.Tx, pb *processedBlock) {
+func addBlockMap(tx databaseTx, pb *processedBlock) {
 	id := pb.Block.ID()
 	err := tx.Bucket(BlockMap).Put(id[:], encoding.Marshal(*pb))
 	if build.DEBUG && err != nil {
@@ -217,7 +215,7 @@ func addBlockMap(tx *bolt.Tx, pb *processedBlock) {
 }
 
 // getPath returns the block id at 'height' in the block path.
-func getPath(tx *bolt.Tx, height types.BlockHeight) (id types.BlockID, err error) {
+func getPath(tx databaseTx, height types.BlockHeight) (id types.BlockID, err error) {
 	idBytes := tx.Bucket(BlockPath).Get(encoding.Marshal(height))
 	if idBytes == nil {
 		return types.BlockID{}, errNilItem
@@ -231,7 +229,7 @@ func getPath(tx *bolt.Tx, height types.BlockHeight) (id types.BlockID, err error
 }
 
 // pushPath adds a block to the BlockPath at current height + 1.
-func pushPath(tx *bolt.Tx, bid types.BlockID) {
+func pushPath(tx databaseTx, bid types.BlockID) {
 	// Fetch and update the block height.
 	bh := tx.Bucket(BlockHeight)
 	heightBytes := bh.Get(BlockHeight)
@@ -256,7 +254,7 @@ func pushPath(tx *bolt.Tx, bid types.BlockID) {
 
 // popPath removes a block from the "end" of the chain, i.e. the block
 // with the largest height.
-func popPath(tx *bolt.Tx) {
+func popPath(tx databaseTx) {
 	// Fetch and update the block height.
 	bh := tx.Bucket(BlockHeight)
 	oldHeightBytes := bh.Get(BlockHeight)
@@ -282,7 +280,7 @@ func popPath(tx *bolt.Tx) {
 
 // isSiacoinOutput returns true if there is a siacoin output of that id in the
 // database.
-func isSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) bool {
+func isSiacoinOutput(tx databaseTx, id types.SiacoinOutputID) bool {
 	bucket := tx.Bucket(SiacoinOutputs)
 	sco := bucket.Get(id[:])
 	return sco != nil
@@ -290,7 +288,7 @@ func isSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) bool {
 
 // getSiacoinOutput fetches a siacoin output from the database. An error is
 // returned if the siacoin output does not exist.
-func getSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (types.SiacoinOutput, error) {
+func getSiacoinOutput(tx databaseTx, id types.SiacoinOutputID) (types.SiacoinOutput, error) {
 	scoBytes := tx.Bucket(SiacoinOutputs).Get(id[:])
 	if scoBytes == nil {
 		return types.SiacoinOutput{}, errNilItem
@@ -305,7 +303,7 @@ func getSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (types.SiacoinOutpu
 
 // addSiacoinOutput adds a siacoin output to the database. An error is returned
 // if the siacoin output is already in the database.
-func addSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, sco types.SiacoinOutput) {
+func addSiacoinOutput(tx databaseTx, id types.SiacoinOutputID, sco types.SiacoinOutput) {
 	// While this is not supposed to be allowed, there's a bug in the consensus
 	// code which means that earlier versions have accetped 0-value outputs
 	// onto the blockchain. A hardfork to remove 0-value outputs will fix this,
@@ -328,7 +326,7 @@ func addSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, sco types.SiacoinOu
 
 // removeSiacoinOutput removes a siacoin output from the database. An error is
 // returned if the siacoin output is not in the database prior to removal.
-func removeSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) {
+func removeSiacoinOutput(tx databaseTx, id types.SiacoinOutputID) {
 	scoBucket := tx.Bucket(SiacoinOutputs)
 	// Sanity check - should not be removing an item that is not in the db.
 	if build.DEBUG && scoBucket.Get(id[:]) == nil {
@@ -342,7 +340,7 @@ func removeSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) {
 
 // getFileContract fetches a file contract from the database, returning an
 // error if it is not there.
-func getFileContract(tx *bolt.Tx, id types.FileContractID) (fc types.FileContract, err error) {
+func getFileContract(tx databaseTx, id types.FileContractID) (fc types.FileContract, err error) {
 	fcBytes := tx.Bucket(FileContracts).Get(id[:])
 	if fcBytes == nil {
 		return types.FileContract{}, errNilItem
@@ -356,7 +354,7 @@ func getFileContract(tx *bolt.Tx, id types.FileContractID) (fc types.FileContrac
 
 // addFileContract adds a file contract to the database. An error is returned
 // if the file contract is already in the database.
-func addFileContract(tx *bolt.Tx, id types.FileContractID, fc types.FileContract) {
+func addFileContract(tx databaseTx, id types.FileContractID, fc types.FileContract) {
 	// Add the file contract to the database.
 	fcBucket := tx.Bucket(FileContracts)
 	// Sanity check - should not be adding a zero-payout file contract.
@@ -385,7 +383,7 @@ func addFileContract(tx *bolt.Tx, id types.FileContractID, fc types.FileContract
 }
 
 // removeFileContract removes a file contract from the database.
-func removeFileContract(tx *bolt.Tx, id types.FileContractID) {
+func removeFileContract(tx databaseTx, id types.FileContractID) {
 	// Delete the file contract entry.
 	fcBucket := tx.Bucket(FileContracts)
 	fcBytes := fcBucket.Get(id[:])
@@ -422,7 +420,7 @@ var (
 
 // getSiafundOutput fetches a siafund output from the database. An error is
 // returned if the siafund output does not exist.
-func getSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) (types.SiafundOutput, error) {
+func getSiafundOutput(tx databaseTx, id types.SiafundOutputID) (types.SiafundOutput, error) {
 	sfoBytes := tx.Bucket(SiafundOutputs).Get(id[:])
 	if sfoBytes == nil {
 		return types.SiafundOutput{}, errNilItem
@@ -440,7 +438,7 @@ func getSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) (types.SiafundOutpu
 
 // addSiafundOutput adds a siafund output to the database. An error is returned
 // if the siafund output is already in the database.
-func addSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, sfo types.SiafundOutput) {
+func addSiafundOutput(tx databaseTx, id types.SiafundOutputID, sfo types.SiafundOutput) {
 	siafundOutputs := tx.Bucket(SiafundOutputs)
 	// Sanity check - should not be adding a siafund output with a value of
 	// zero.
@@ -459,7 +457,7 @@ func addSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, sfo types.SiafundOu
 
 // removeSiafundOutput removes a siafund output from the database. An error is
 // returned if the siafund output is not in the database prior to removal.
-func removeSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) {
+func removeSiafundOutput(tx databaseTx, id types.SiafundOutputID) {
 	sfoBucket := tx.Bucket(SiafundOutputs)
 	if build.DEBUG && sfoBucket.Get(id[:]) == nil {
 		panic("nil siafund output")
@@ -472,7 +470,7 @@ func removeSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) {
 
 // getSiafundPool returns the current value of the siafund pool. No error is
 // returned as the siafund pool should always be available.
-func getSiafundPool(tx *bolt.Tx) (pool types.Currency) {
+func getSiafundPool(tx databaseTx) (pool types.Currency) {
 	bucket := tx.Bucket(SiafundPool)
 	poolBytes := bucket.Get(SiafundPool)
 	// An error should only be returned if the object stored in the siafund
@@ -486,7 +484,7 @@ func getSiafundPool(tx *bolt.Tx) (pool types.Currency) {
 }
 
 // setSiafundPool updates the saved siafund pool on disk
-func setSiafundPool(tx *bolt.Tx, c types.Currency) {
+func setSiafundPool(tx databaseTx, c types.Currency) {
 	err := tx.Bucket(SiafundPool).Put(SiafundPool, encoding.Marshal(c))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -495,7 +493,7 @@ func setSiafundPool(tx *bolt.Tx, c types.Currency) {
 
 // getFoundationUnlockHashes returns the current primary and failsafe Foundation
 // addresses.
-func getFoundationUnlockHashes(tx *bolt.Tx) (primary, failsafe types.UnlockHash) {
+func getFoundationUnlockHashes(tx databaseTx) (primary, failsafe types.UnlockHash) {
 	err := encoding.UnmarshalAll(tx.Bucket(FoundationUnlockHashes).Get(FoundationUnlockHashes), &primary, &failsafe)
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -505,7 +503,7 @@ func getFoundationUnlockHashes(tx *bolt.Tx) (primary, failsafe types.UnlockHash)
 
 // setFoundationUnlockHashes updates the primary and failsafe Foundation
 // addresses.
-func setFoundationUnlockHashes(tx *bolt.Tx, primary, failsafe types.UnlockHash) {
+func setFoundationUnlockHashes(tx databaseTx, primary, failsafe types.UnlockHash) {
 	err := tx.Bucket(FoundationUnlockHashes).Put(FoundationUnlockHashes, encoding.MarshalAll(primary, failsafe))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -514,14 +512,14 @@ func setFoundationUnlockHashes(tx *bolt.Tx, primary, failsafe types.UnlockHash)
 
 // getPriorFoundationUnlockHashes returns the primary and failsafe Foundation
 // addresses immediately prior to the application of the specified block.
-func getPriorFoundationUnlockHashes(tx *bolt.Tx, height types.BlockHeight) (primary, failsafe types.UnlockHash, exists bool) {
+func getPriorFoundationUnlockHashes(tx databaseTx, height types.BlockHeight) (primary, failsafe types.UnlockHash, exists bool) {
 	exists = encoding.UnmarshalAll(tx.Bucket(FoundationUnlockHashes).Get(encoding.Marshal(height)), &primary, &failsafe) == nil
 	return
 }
 
 // setPriorFoundationUnlockHashes sets the primary and failsafe Foundation
 // addresses immediately prior to the application of the specified block.
-func setPriorFoundationUnlockHashes(tx *bolt.Tx, height types.BlockHeight) {
+func setPriorFoundationUnlockHashes(tx databaseTx, height types.BlockHeight) {
 	err := tx.Bucket(FoundationUnlockHashes).Put(encoding.Marshal(height), encoding.MarshalAll(getFoundationUnlockHashes(tx)))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -530,7 +528,7 @@ func setPriorFoundationUnlockHashes(tx *bolt.Tx, height types.BlockHeight) {
 
 // deletePriorFoundationUnlockHashes deletes the primary and failsafe Foundation
 // addresses for the specified height.
-func deletePriorFoundationUnlockHashes(tx *bolt.Tx, height types.BlockHeight) {
+func deletePriorFoundationUnlockHashes(tx databaseTx, height types.BlockHeight) {
 	err := tx.Bucket(FoundationUnlockHashes).Delete(encoding.Marshal(height))
 	if build.DEBUG && err != nil {
 		panic(err)
@@ -538,7 +536,7 @@ func deletePriorFoundationUnlockHashes(tx *bolt.Tx, height types.BlockHeight) {
 }
 
 // addDSCO adds a delayed siacoin output to the consnesus set.
-func addDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID, sco types.SiacoinOutput) {
+func addDSCO(tx databaseTx, bh types.BlockHeight, id types.SiacoinOutputID, sco types.SiacoinOutput) {
 	// Sanity check - dsco should never have a value of zero.
 	// An error in the consensus code means sometimes there are 0-value dscos
 	// in the blockchain. A hardfork will fix this.
@@ -564,7 +562,7 @@ func addDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID, sco ty
 }
 
 // removeDSCO removes a delayed siacoin output from the consensus set.
-func removeDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID) {
+func removeDSCO(tx databaseTx, bh types.BlockHeight, id types.SiacoinOutputID) {
 	bucketID := append(prefixDSCO, encoding.Marshal(bh)...)
 	// Sanity check - should not remove an item not in the db.
 	dscoBucket := tx.Bucket(bucketID)
@@ -579,7 +577,7 @@ func removeDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID) {
 
 // createDSCOBucket creates a bucket for the delayed siacoin outputs at the
 // input height.
-func createDSCOBucket(tx *bolt.Tx, bh types.BlockHeight) {
+func createDSCOBucket(tx databaseTx, bh types.BlockHeight) {
 	bucketID := append(prefixDSCO, encoding.Marshal(bh)...)
 	_, err := tx.CreateBucket(bucketID)
 	if build.DEBUG && err != nil {
@@ -589,7 +587,7 @@ func createDSCOBucket(tx *bolt.Tx, bh types.BlockHeight) {
 
 // deleteDSCOBucket deletes the bucket that held a set of delayed siacoin
 // outputs.
-func deleteDSCOBucket(tx *bolt.Tx, bh types.BlockHeight) {
+func deleteDSCOBucket(tx databaseTx, bh types.BlockHeight) {
 	// Delete the bucket.
 	bucketID := append(prefixDSCO, encoding.Marshal(bh)...)
 	bucket := tx.Bucket(bucketID)
@@ -4,16 +4,16 @@ package consensus
 // compatibility with the test suite.
 
 import (
-	"gitlab.com/NebulousLabs/bolt"
+	"errors"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/types"
 )
 
 // dbBlockHeight is a convenience function allowing blockHeight to be called
-// without a bolt.Tx.
+// without a database transaction.
 func (cs *ConsensusSet) dbBlockHeight() (bh types.BlockHeight) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		bh = blockHeight(tx)
 		return nil
 	})
@@ -24,9 +24,9 @@ func (cs *ConsensusSet) dbBlockHeight() (bh types.BlockHeight) {
 }
 
 // dbCurrentProcessedBlock is a convenience function allowing
-// currentProcessedBlock to be called without a bolt.Tx.
+// currentProcessedBlock to be called without a database transaction.
 func (cs *ConsensusSet) dbCurrentProcessedBlock() (pb *processedBlock) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		pb = currentProcessedBlock(tx)
 		return nil
 	})
@@ -37,9 +37,9 @@ func (cs *ConsensusSet) dbCurrentProcessedBlock() (pb *processedBlock) {
 }
 
 // dbGetPath is a convenience function allowing getPath to be called without a
-// bolt.Tx.
+// database transaction.
 func (cs *ConsensusSet) dbGetPath(bh types.BlockHeight) (id types.BlockID, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		id, err = getPath(tx, bh)
 		return nil
 	})
@@ -50,9 +50,9 @@ func (cs *ConsensusSet) dbGetPath(bh types.BlockHeight) (id types.BlockID, err e
 }
 
 // dbPushPath is a convenience function allowing pushPath to be called without a
-// bolt.Tx.
+// database transaction.
 func (cs *ConsensusSet) dbPushPath(bid types.BlockID) {
-	dbErr := cs.db.Update(func(tx *bolt.Tx) error {
+	dbErr := cs.db.Update(func(tx databaseTx) error {
 		pushPath(tx, bid)
 		return nil
 	})
@@ -62,9 +62,9 @@ func (cs *ConsensusSet) dbPushPath(bid types.BlockID) {
 }
 
 // dbGetBlockMap is a convenience function allowing getBlockMap to be called
-// without a bolt.Tx.
+// without a database transaction.
 func (cs *ConsensusSet) dbGetBlockMap(id types.BlockID) (pb *processedBlock, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		pb, err = getBlockMap(tx, id)
 		return nil
 	})
@@ -75,9 +75,9 @@ func (cs *ConsensusSet) dbGetBlockMap(id types.BlockID) (pb *processedBlock, err
 }
 
 // dbGetSiacoinOutput is a convenience function allowing getSiacoinOutput to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbGetSiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		sco, err = getSiacoinOutput(tx, id)
 		return nil
 	})
@@ -90,11 +90,22 @@ func (cs *ConsensusSet) dbGetSiacoinOutput(id types.SiacoinOutputID) (sco types.
 // getArbSiacoinOutput is a convenience function fetching a single random
 // siacoin output from the database.
 func (cs *ConsensusSet) getArbSiacoinOutput() (scoid types.SiacoinOutputID, sco types.SiacoinOutput, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
-		cursor := tx.Bucket(SiacoinOutputs).Cursor()
-		scoidBytes, scoBytes := cursor.First()
-		copy(scoid[:], scoidBytes)
-		return encoding.Unmarshal(scoBytes, &sco)
+	dbErr := cs.db.View(func(tx databaseTx) error {
+		// Stop iterating after the first output.
+		errFound := errors.New("found output")
+		err := tx.Bucket(SiacoinOutputs).ForEach(func(scoidBytes, scoBytes []byte) error {
+			copy(scoid[:], scoidBytes)
+			if err := encoding.Unmarshal(scoBytes, &sco); err != nil {
+				return err
+			}
+			return errFound
+		})
+		if err == nil {
+			return errors.New("no siacoin output found")
+		} else if err != errFound {
+			return err
+		}
+		return nil
 	})
 	if dbErr != nil {
 		panic(dbErr)
@@ -103,9 +114,9 @@ func (cs *ConsensusSet) getArbSiacoinOutput() (scoid types.SiacoinOutputID, sco
 }
 
 // dbGetFileContract is a convenience function allowing getFileContract to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbGetFileContract(id types.FileContractID) (fc types.FileContract, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		fc, err = getFileContract(tx, id)
 		return nil
 	})
@@ -116,9 +127,9 @@ func (cs *ConsensusSet) dbGetFileContract(id types.FileContractID) (fc types.Fil
 }
 
 // dbAddFileContract is a convenience function allowing addFileContract to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbAddFileContract(id types.FileContractID, fc types.FileContract) {
-	dbErr := cs.db.Update(func(tx *bolt.Tx) error {
+	dbErr := cs.db.Update(func(tx databaseTx) error {
 		addFileContract(tx, id, fc)
 		return nil
 	})
@@ -128,9 +139,9 @@ func (cs *ConsensusSet) dbAddFileContract(id types.FileContractID, fc types.File
 }
 
 // dbRemoveFileContract is a convenience function allowing removeFileContract
-// to be called without a bolt.Tx.
+// to be called without a database transaction.
 func (cs *ConsensusSet) dbRemoveFileContract(id types.FileContractID) {
-	dbErr := cs.db.Update(func(tx *bolt.Tx) error {
+	dbErr := cs.db.Update(func(tx databaseTx) error {
 		removeFileContract(tx, id)
 		return nil
 	})
@@ -140,9 +151,9 @@ func (cs *ConsensusSet) dbRemoveFileContract(id types.FileContractID) {
 }
 
 // dbGetSiafundOutput is a convenience function allowing getSiafundOutput to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbGetSiafundOutput(id types.SiafundOutputID) (sfo types.SiafundOutput, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		sfo, err = getSiafundOutput(tx, id)
 		return nil
 	})
@@ -153,9 +164,9 @@ func (cs *ConsensusSet) dbGetSiafundOutput(id types.SiafundOutputID) (sfo types.
 }
 
 // dbAddSiafundOutput is a convenience function allowing addSiafundOutput to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbAddSiafundOutput(id types.SiafundOutputID, sfo types.SiafundOutput) {
-	dbErr := cs.db.Update(func(tx *bolt.Tx) error {
+	dbErr := cs.db.Update(func(tx databaseTx) error {
 		addSiafundOutput(tx, id, sfo)
 		return nil
 	})
@@ -165,9 +176,9 @@ func (cs *ConsensusSet) dbAddSiafundOutput(id types.SiafundOutputID, sfo types.S
 }
 
 // dbGetSiafundPool is a convenience function allowing getSiafundPool to be
-// called without a bolt.Tx.
+// called without a database transaction.
 func (cs *ConsensusSet) dbGetSiafundPool() (siafundPool types.Currency) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		siafundPool = getSiafundPool(tx)
 		return nil
 	})
@@ -178,10 +189,10 @@ func (cs *ConsensusSet) dbGetSiafundPool() (siafundPool types.Currency) {
 }
 
 // dbGetDSCO is a convenience function allowing a delayed siacoin output to be
-// fetched without a bolt.Tx. An error is returned if the delayed output is not
+// fetched without a database transaction. An error is returned if the delayed output is not
 // found at the maturity height indicated by the input.
 func (cs *ConsensusSet) dbGetDSCO(height types.BlockHeight, id types.SiacoinOutputID) (dsco types.SiacoinOutput, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		dscoBucketID := append(prefixDSCO, encoding.Marshal(height)...)
 		dscoBucket := tx.Bucket(dscoBucketID)
 		if dscoBucket == nil {
@@ -208,7 +219,7 @@ func (cs *ConsensusSet) dbGetDSCO(height types.BlockHeight, id types.SiacoinOutp
 // dbStorageProofSegment is a convenience function allowing
 // 'storageProofSegment' to be called during testing without a tx.
 func (cs *ConsensusSet) dbStorageProofSegment(fcid types.FileContractID) (index uint64, err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		index, err = storageProofSegment(tx, fcid)
 		return nil
 	})
@@ -221,7 +232,7 @@ func (cs *ConsensusSet) dbStorageProofSegment(fcid types.FileContractID) (index
 // dbValidStorageProofs is a convenience function allowing 'validStorageProofs'
 // to be called during testing without a tx.
 func (cs *ConsensusSet) dbValidStorageProofs(t types.Transaction) (err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		err = validStorageProofs(tx, t)
 		return nil
 	})
@@ -234,7 +245,7 @@ func (cs *ConsensusSet) dbValidStorageProofs(t types.Transaction) (err error) {
 // dbValidFileContractRevisions is a convenience function allowing
 // 'validFileContractRevisions' to be called during testing without a tx.
 func (cs *ConsensusSet) dbValidFileContractRevisions(t types.Transaction) (err error) {
-	dbErr := cs.db.View(func(tx *bolt.Tx) error {
+	dbErr := cs.db.View(func(tx databaseTx) error {
 		err = validFileContractRevisions(tx, t)
 		return nil
 	})
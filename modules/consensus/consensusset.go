@@ -9,7 +9,6 @@ package consensus
 import (
 	"errors"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/demotemutex"
 	"gitlab.com/NebulousLabs/threadgroup"
 
@@ -87,7 +86,8 @@ type ConsensusSet struct {
 	blockValidator  blockValidator
 
 	// Utilities
-	db         *persist.BoltDatabase
+	db         database
+	dbBackend  string
 	staticDeps modules.Dependencies
 	log        *persist.Logger
 	mu         demotemutex.DemoteMutex
@@ -95,13 +95,9 @@ type ConsensusSet struct {
 	tg         threadgroup.ThreadGroup
 }
 
-// consensusSetBlockingStartup handles the blocking portion of NewCustomConsensusSet.
-func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, deps modules.Dependencies) (*ConsensusSet, error) {
-	// Check for nil dependencies.
-	if gateway == nil {
-		return nil, errNilGateway
-	}
-	// Create the ConsensusSet object.
+// newConsensusSet creates a ConsensusSet containing the genesis block. The
+// persistence structures of the ConsensusSet are not initialized.
+func newConsensusSet(gateway modules.Gateway, persistDir, dbBackend string, deps modules.Dependencies) *ConsensusSet {
 	cs := &ConsensusSet{
 		gateway: gateway,
 
@@ -119,6 +115,7 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 		blockRuleHelper: stdBlockRuleHelper{},
 		blockValidator:  NewBlockValidator(),
 
+		dbBackend:  dbBackend,
 		staticDeps: deps,
 		persistDir: persistDir,
 	}
@@ -145,6 +142,21 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 			cs.blockRoot.SiafundOutputDiffs = append(cs.blockRoot.SiafundOutputDiffs, sfod)
 		}
 	}
+	return cs
+}
+
+// consensusSetBlockingStartup handles the blocking portion of NewCustomConsensusSet.
+func consensusSetBlockingStartup(gateway modules.Gateway, persistDir, dbBackend string, deps modules.Dependencies) (*ConsensusSet, error) {
+	// Check for nil dependencies.
+	if gateway == nil {
+		return nil, errNilGateway
+	}
+	// Check the database backend.
+	if _, err := databasePath(dbBackend, persistDir); err != nil {
+		return nil, err
+	}
+	// Create the ConsensusSet object.
+	cs := newConsensusSet(gateway, persistDir, dbBackend, deps)
 	// Initialize the consensus persistence structures.
 	err := cs.initPersist()
 	if err != nil {
@@ -172,11 +184,15 @@ func consensusSetAsyncStartup(cs *ConsensusSet, bootstrap bool) error {
 	cs.gateway.RegisterRPC("SendBlocks", cs.rpcSendBlocks)
 	cs.gateway.RegisterRPC("RelayHeader", cs.threadedRPCRelayHeader)
 	cs.gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+	cs.gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
+	cs.gateway.RegisterRPC("SendBatch", cs.rpcSendBatch)
 	cs.gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 	err := cs.tg.OnStop(func() error {
 		cs.gateway.UnregisterRPC("SendBlocks")
 		cs.gateway.UnregisterRPC("RelayHeader")
 		cs.gateway.UnregisterRPC("SendBlk")
+		cs.gateway.UnregisterRPC("SendHeaders")
+		cs.gateway.UnregisterRPC("SendBatch")
 		cs.gateway.UnregisterConnectCall("SendBlocks")
 		return nil
 	})
@@ -202,9 +218,17 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
+	return NewCustomConsensusSetWithDatabase(gateway, bootstrap, persistDir, DatabaseBolt, deps)
+}
+
+// NewCustomConsensusSetWithDatabase returns a new ConsensusSet which persists
+// its state using the given database backend. The databases of different
+// backends are independent, switching the backend of an existing node
+// requires resyncing the blockchain.
+func NewCustomConsensusSetWithDatabase(gateway modules.Gateway, bootstrap bool, persistDir, dbBackend string, deps modules.Dependencies) (*ConsensusSet, <-chan error) {
 	// Handle blocking consensus startup first.
 	errChan := make(chan error, 1)
-	cs, err := consensusSetBlockingStartup(gateway, persistDir, deps)
+	cs, err := consensusSetBlockingStartup(gateway, persistDir, dbBackend, deps)
 	if err != nil {
 		errChan <- err
 		return nil, errChan
@@ -231,7 +255,7 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 
 // BlockAtHeight returns the block at a given height.
 func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Block, exists bool) {
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		id, err := getPath(tx, height)
 		if err != nil {
 			return err
@@ -249,7 +273,7 @@ func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Blo
 
 // BlockByID returns the block for a given BlockID.
 func (cs *ConsensusSet) BlockByID(id types.BlockID) (block types.Block, height types.BlockHeight, exists bool) {
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -271,7 +295,7 @@ func (cs *ConsensusSet) ChildTarget(id types.BlockID) (target types.Target, exis
 	}
 	defer cs.tg.Done()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -293,7 +317,7 @@ func (cs *ConsensusSet) managedCurrentBlock() (block types.Block) {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb := currentProcessedBlock(tx)
 		block = pb.Block
 		return nil
@@ -316,7 +340,7 @@ func (cs *ConsensusSet) CurrentBlock() (block types.Block) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb := currentProcessedBlock(tx)
 		block = pb.Block
 		return nil
@@ -339,7 +363,7 @@ func (cs *ConsensusSet) Height() (height types.BlockHeight) {
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		height = blockHeight(tx)
 		return nil
 	})
@@ -356,7 +380,7 @@ func (cs *ConsensusSet) InCurrentPath(id types.BlockID) (inPath bool) {
 	}
 	defer cs.tg.Done()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			inPath = false
@@ -384,7 +408,7 @@ func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (timestamp
 	defer cs.tg.Done()
 
 	// Error is not checked because it does not matter.
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -406,7 +430,7 @@ func (cs *ConsensusSet) StorageProofSegment(fcid types.FileContractID) (index ui
 	}
 	defer cs.tg.Done()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		index, err = storageProofSegment(tx, fcid)
 		return nil
 	})
@@ -421,7 +445,7 @@ func (cs *ConsensusSet) FoundationUnlockHashes() (primary, failsafe types.Unlock
 	}
 	defer cs.tg.Done()
 
-	_ = cs.db.View(func(tx *bolt.Tx) error {
+	_ = cs.db.View(func(tx databaseTx) error {
 		primary, failsafe = getFoundationUnlockHashes(tx)
 		return nil
 	})
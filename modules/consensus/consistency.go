@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -15,7 +14,7 @@ import (
 )
 
 // manageErr handles an error detected by the consistency checks.
-func manageErr(tx *bolt.Tx, err error) {
+func manageErr(tx databaseTx, err error) {
 	markInconsistency(tx)
 	if build.DEBUG {
 		panic(err)
@@ -28,13 +27,13 @@ func manageErr(tx *bolt.Tx, err error) {
 // the elements in sorted order into a merkle tree and taking the root. All
 // consensus sets with the same current block should have identical consensus
 // checksums.
-func consensusChecksum(tx *bolt.Tx) crypto.Hash {
+func consensusChecksum(tx databaseTx) crypto.Hash {
 	// Create a checksum tree.
 	tree := crypto.NewTree()
 
 	// For all of the constant buckets, push every key and every value. Buckets
 	// are sorted in byte-order, therefore this operation is deterministic.
-	consensusSetBuckets := []*bolt.Bucket{
+	consensusSetBuckets := []databaseBucket{
 		tx.Bucket(BlockPath),
 		tx.Bucket(SiacoinOutputs),
 		tx.Bucket(FileContracts),
@@ -56,7 +55,7 @@ func consensusChecksum(tx *bolt.Tx) crypto.Hash {
 	// Iterate through all the buckets looking for buckets prefixed with
 	// prefixDSCO or prefixFCEX. Buckets are presented in byte-sorted order by
 	// name.
-	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+	err := tx.ForEach(func(name []byte, b databaseBucket) error {
 		// If the bucket is not a delayed siacoin output bucket or a file
 		// contract expiration bucket, skip.
 		if !bytes.HasPrefix(name, prefixDSCO) && !bytes.HasPrefix(name, prefixFCEX) {
@@ -79,11 +78,11 @@ func consensusChecksum(tx *bolt.Tx) crypto.Hash {
 
 // checkSiacoinCount checks that the number of siacoins countable within the
 // consensus set equal the expected number of siacoins for the block height.
-func checkSiacoinCount(tx *bolt.Tx) {
+func checkSiacoinCount(tx databaseTx) {
 	// Iterate through all the buckets looking for the delayed siacoin output
 	// buckets, and check that they are for the correct heights.
 	var dscoSiacoins types.Currency
-	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+	err := tx.ForEach(func(name []byte, b databaseBucket) error {
 		// Check if the bucket is a delayed siacoin output bucket.
 		if !bytes.HasPrefix(name, prefixDSCO) {
 			return nil
@@ -175,7 +174,7 @@ func checkSiacoinCount(tx *bolt.Tx) {
 
 // checkSiafundCount checks that the number of siafunds countable within the
 // consensus set equal the expected number of siafunds for the block height.
-func checkSiafundCount(tx *bolt.Tx) {
+func checkSiafundCount(tx databaseTx) {
 	var total types.Currency
 	err := tx.Bucket(SiafundOutputs).ForEach(func(_, siafundOutputBytes []byte) error {
 		var sfo types.SiafundOutput
@@ -196,7 +195,7 @@ func checkSiafundCount(tx *bolt.Tx) {
 
 // checkDSCOs scans the sets of delayed siacoin outputs and checks for
 // consistency.
-func checkDSCOs(tx *bolt.Tx) {
+func checkDSCOs(tx databaseTx) {
 	// Create a map to track which delayed siacoin output maps exist, and
 	// another map to track which ids have appeared in the dsco set.
 	dscoTracker := make(map[types.BlockHeight]struct{})
@@ -204,7 +203,7 @@ func checkDSCOs(tx *bolt.Tx) {
 
 	// Iterate through all the buckets looking for the delayed siacoin output
 	// buckets, and check that they are for the correct heights.
-	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+	err := tx.ForEach(func(name []byte, b databaseBucket) error {
 		// If the bucket is not a delayed siacoin output bucket or a file
 		// contract expiration bucket, skip.
 		if !bytes.HasPrefix(name, prefixDSCO) {
@@ -281,7 +280,7 @@ func checkDSCOs(tx *bolt.Tx) {
 // consensus set hash matches the hash obtained for the previous block. Then it
 // applies the block again and checks that the consensus set hash matches the
 // original consensus set hash.
-func (cs *ConsensusSet) checkRevertApply(tx *bolt.Tx) {
+func (cs *ConsensusSet) checkRevertApply(tx databaseTx) {
 	current := currentProcessedBlock(tx)
 	// Don't perform the check if this block is the genesis block.
 	if current.Block.ID() == cs.blockRoot.Block.ID() {
@@ -313,7 +312,7 @@ func (cs *ConsensusSet) checkRevertApply(tx *bolt.Tx) {
 
 // checkConsistency runs a series of checks to make sure that the consensus set
 // is consistent with some rules that should always be true.
-func (cs *ConsensusSet) checkConsistency(tx *bolt.Tx) {
+func (cs *ConsensusSet) checkConsistency(tx databaseTx) {
 	if cs.checkingConsistency {
 		return
 	}
@@ -332,7 +331,7 @@ func (cs *ConsensusSet) checkConsistency(tx *bolt.Tx) {
 // Useful for detecting database corruption in production without needing to go
 // through the extremely slow process of running a consistency check every
 // block.
-func (cs *ConsensusSet) maybeCheckConsistency(tx *bolt.Tx) {
+func (cs *ConsensusSet) maybeCheckConsistency(tx databaseTx) {
 	if fastrand.Intn(1000) == 0 {
 		cs.checkConsistency(tx)
 	}
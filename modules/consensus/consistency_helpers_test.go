@@ -1,15 +1,13 @@
 package consensus
 
 import (
-	"gitlab.com/NebulousLabs/bolt"
-
 	"go.sia.tech/siad/crypto"
 )
 
 // dbConsensusChecksum is a convenience function to call consensusChecksum
-// without a bolt.Tx.
+// without a database transaction.
 func (cs *ConsensusSet) dbConsensusChecksum() (checksum crypto.Hash) {
-	err := cs.db.Update(func(tx *bolt.Tx) error {
+	err := cs.db.Update(func(tx databaseTx) error {
 		checksum = consensusChecksum(tx)
 		return nil
 	})
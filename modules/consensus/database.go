@@ -6,8 +6,8 @@ package consensus
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -15,6 +15,17 @@ import (
 	"go.sia.tech/siad/persist"
 )
 
+const (
+	// DatabaseBolt is the name of the bolt database backend. It is the default
+	// backend of the consensus set.
+	DatabaseBolt = "bolt"
+
+	// DatabaseLevelDB is the name of the LevelDB database backend. LevelDB is
+	// a log-structured merge tree, which avoids the write amplification of
+	// bolt's copy-on-write B+tree when the consensus set grows large.
+	DatabaseLevelDB = "leveldb"
+)
+
 var (
 	dbMetadata = persist.Metadata{
 		Header:  "Consensus Set Database",
@@ -24,6 +35,10 @@ var (
 	errNilBucket    = errors.New("using a bucket that does not exist")
 	errNilItem      = errors.New("requested item does not exist")
 	errRepeatInsert = errors.New("attempting to add an already existing item to the consensus set")
+
+	// errUnknownDatabase is returned if the consensus set is created with an
+	// unknown database backend.
+	errUnknownDatabase = errors.New("unknown consensus database backend")
 )
 
 type (
@@ -38,17 +53,99 @@ type (
 		Bucket(name []byte) dbBucket
 	}
 
-	// boltTxWrapper wraps a bolt.Tx so that it matches the dbTx interface. The
-	// wrap is necessary because bolt.Tx.Bucket() returns a fixed type
-	// (bolt.Bucket), but we want it to return an interface (dbBucket).
-	boltTxWrapper struct {
-		tx *bolt.Tx
+	// database is a key/value store holding the consensus set. Its API
+	// mirrors a subset of bolt's API, which allows the consensus set to be
+	// persisted by different backends.
+	database interface {
+		// Close closes the database.
+		Close() error
+
+		// Update executes fn within a read-write transaction. If fn returns
+		// an error, none of its changes are committed.
+		Update(fn func(tx databaseTx) error) error
+
+		// View executes fn within a read-only transaction.
+		View(fn func(tx databaseTx) error) error
+	}
+
+	// databaseTx is a transaction on the database. Buckets are top-level
+	// collections of key/value pairs and can't be nested.
+	databaseTx interface {
+		// Bucket returns the bucket with the given name or nil if it doesn't
+		// exist.
+		Bucket(name []byte) databaseBucket
+
+		// CreateBucket creates a new bucket. An error is returned if the
+		// bucket already exists.
+		CreateBucket(name []byte) (databaseBucket, error)
+
+		// CreateBucketIfNotExists creates a new bucket if it doesn't exist
+		// yet and returns it.
+		CreateBucketIfNotExists(name []byte) (databaseBucket, error)
+
+		// DeleteBucket deletes a bucket and all of its key/value pairs.
+		DeleteBucket(name []byte) error
+
+		// ForEach calls fn for every bucket in byte-sorted order by name.
+		ForEach(fn func(name []byte, b databaseBucket) error) error
+	}
+
+	// databaseBucket is a collection of key/value pairs inside a database
+	// transaction.
+	databaseBucket interface {
+		dbBucket
+
+		// Delete removes a key from the bucket.
+		Delete(key []byte) error
+
+		// ForEach calls fn for every key/value pair in byte-sorted order by
+		// key. The bucket must not be modified by fn.
+		ForEach(fn func(k, v []byte) error) error
+
+		// Put sets the value of a key in the bucket.
+		Put(key, value []byte) error
+	}
+
+	// txWrapper wraps a databaseTx so that it matches the dbTx interface. The
+	// wrap is necessary because databaseTx.Bucket() returns a databaseBucket,
+	// but we want it to return a dbBucket.
+	txWrapper struct {
+		tx databaseTx
 	}
 )
 
 // Bucket returns the dbBucket associated with the given bucket name.
-func (b boltTxWrapper) Bucket(name []byte) dbBucket {
-	return b.tx.Bucket(name)
+func (w txWrapper) Bucket(name []byte) dbBucket {
+	// Avoid wrapping a nil bucket in a non-nil interface.
+	b := w.tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	return b
+}
+
+// databasePath returns the path of the database of the given backend within
+// the consensus persist directory.
+func databasePath(backend, persistDir string) (string, error) {
+	switch backend {
+	case DatabaseBolt:
+		return filepath.Join(persistDir, DatabaseFilename), nil
+	case DatabaseLevelDB:
+		return filepath.Join(persistDir, LevelDatabaseDir), nil
+	}
+	return "", errors.AddContext(errUnknownDatabase, backend)
+}
+
+// openDatabase opens the database of the given backend at path and validates
+// its metadata.
+func openDatabase(backend, path string) (database, error) {
+	switch backend {
+	case DatabaseBolt:
+		return openBoltDatabase(path)
+	case DatabaseLevelDB:
+		return openLevelDatabase(path)
+	}
+	return nil, errors.AddContext(errUnknownDatabase, backend)
 }
 
 // replaceDatabase backs up the existing database and creates a new one.
@@ -62,7 +159,7 @@ func (cs *ConsensusSet) replaceDatabase(filename string) error {
 
 	// Try again to create a new database, this time without checking for an
 	// outdated database error.
-	cs.db, err = persist.OpenDatabase(dbMetadata, filename)
+	cs.db, err = openDatabase(cs.dbBackend, filename)
 	if err != nil {
 		return errors.New("error opening consensus database: " + err.Error())
 	}
@@ -71,7 +168,7 @@ func (cs *ConsensusSet) replaceDatabase(filename string) error {
 
 // openDB loads the set database and populates it with the necessary buckets
 func (cs *ConsensusSet) openDB(filename string) (err error) {
-	cs.db, err = persist.OpenDatabase(dbMetadata, filename)
+	cs.db, err = openDatabase(cs.dbBackend, filename)
 	if errors.Contains(err, persist.ErrBadVersion) {
 		return cs.replaceDatabase(filename)
 	}
@@ -83,7 +180,7 @@ func (cs *ConsensusSet) openDB(filename string) (err error) {
 
 // initDB is run if there is no existing consensus database, creating a
 // database with all the required buckets and sane initial values.
-func (cs *ConsensusSet) initDB(tx *bolt.Tx) error {
+func (cs *ConsensusSet) initDB(tx databaseTx) error {
 	// If the database has already been initialized, there is nothing to do.
 	// Initialization can be detected by looking for the presence of the siafund
 	// pool bucket. (legacy design choice - ultimately probably not the best way
@@ -113,7 +210,7 @@ func (cs *ConsensusSet) initDB(tx *bolt.Tx) error {
 
 // markInconsistency flags the database to indicate that inconsistency has been
 // detected.
-func markInconsistency(tx *bolt.Tx) {
+func markInconsistency(tx databaseTx) {
 	// Place a 'true' in the consistency bucket to indicate that
 	// inconsistencies have been found.
 	err := tx.Bucket(Consistency).Put(Consistency, encoding.Marshal(true))
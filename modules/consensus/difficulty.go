@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"math/big"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/types"
@@ -144,17 +143,16 @@ func (cs *ConsensusSet) childTargetOak(parentTotalTime int64, parentTotalTarget,
 
 // getBlockTotals returns the block totals values that get stored in
 // storeBlockTotals.
-func (cs *ConsensusSet) getBlockTotals(tx *bolt.Tx, id types.BlockID) (totalTime int64, totalTarget types.Target) {
+func (cs *ConsensusSet) getBlockTotals(tx databaseTx, id types.BlockID) (totalTime int64, totalTarget types.Target) {
 	totalsBytes := tx.Bucket(BucketOak).Get(id[:])
 	totalTime = int64(binary.LittleEndian.Uint64(totalsBytes[:8]))
 	copy(totalTarget[:], totalsBytes[8:])
 	return
 }
 
-// storeBlockTotals computes the new total time and total target for the current
-// block and stores that new time in the database. It also returns the new
-// totals.
-func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+// blockTotals computes the new total time and total target for the current
+// block.
+func blockTotals(currentHeight types.BlockHeight, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target) {
 	// Reset the prevTotalTime to a delta of zero just before the hardfork.
 	//
 	// NOTICE: This code is broken, an incorrectly executed hardfork. The
@@ -186,6 +184,14 @@ func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockH
 		newTotalTime = types.ASICHardforkTotalTime
 		newTotalTarget = types.ASICHardforkTotalTarget
 	}
+	return newTotalTime, newTotalTarget
+}
+
+// storeBlockTotals computes the new total time and total target for the current
+// block and stores that new time in the database. It also returns the new
+// totals.
+func (cs *ConsensusSet) storeBlockTotals(tx databaseTx, currentHeight types.BlockHeight, currentBlockID types.BlockID, prevTotalTime int64, parentTimestamp, currentTimestamp types.Timestamp, prevTotalTarget, targetOfCurrentBlock types.Target) (newTotalTime int64, newTotalTarget types.Target, err error) {
+	newTotalTime, newTotalTarget = blockTotals(currentHeight, prevTotalTime, parentTimestamp, currentTimestamp, prevTotalTarget, targetOfCurrentBlock)
 
 	// Store the new total time and total target in the database at the
 	// appropriate id.
@@ -205,7 +211,7 @@ func (cs *ConsensusSet) storeBlockTotals(tx *bolt.Tx, currentHeight types.BlockH
 //
 // After oak initialization is complete, a specific field in the oak bucket is
 // marked so that oak initialization can be skipped in the future.
-func (cs *ConsensusSet) initOak(tx *bolt.Tx) error {
+func (cs *ConsensusSet) initOak(tx databaseTx) error {
 	// Prep the oak bucket.
 	bucketOak, err := tx.CreateBucketIfNotExists(BucketOak)
 	if err != nil {
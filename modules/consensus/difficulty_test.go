@@ -5,8 +5,6 @@ import (
 	"math/big"
 	"testing"
 
-	"gitlab.com/NebulousLabs/bolt"
-
 	"go.sia.tech/siad/types"
 )
 
@@ -290,7 +288,7 @@ func TestStoreBlockTotals(t *testing.T) {
 	// Check that as totals get stored over and over, the values getting
 	// returned follow a decay. While storing repeatedly, check that the
 	// getBlockTotals values match the values that were stored.
-	err = cs.db.Update(func(tx *bolt.Tx) error {
+	err = cs.db.Update(func(tx databaseTx) error {
 		var totalTime int64
 		var id types.BlockID
 		var parentTimestamp, currentTimestamp types.Timestamp
@@ -3,8 +3,6 @@ package consensus
 import (
 	"testing"
 
-	"gitlab.com/NebulousLabs/bolt"
-
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -44,7 +42,7 @@ func TestCommitDelayedSiacoinOutputDiffBadMaturity(t *testing.T) {
 		SiacoinOutput:  dsco,
 		MaturityHeight: maturityHeight,
 	}
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		commitDelayedSiacoinOutputDiff(tx, dscod, modules.DiffApply)
 		return nil
 	})
@@ -66,7 +64,7 @@ func TestCommitNodeDiffs(t *testing.T) {
  	}
 }()
 	pb := cst.cs.dbCurrentProcessedBlock()
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		commitDiffSet(tx, pb, modules.DiffRevert) // pull the block node out of the consensus set.
 		return nil
 	})
@@ -108,7 +106,7 @@ func TestCommitNodeDiffs(t *testing.T) {
 		MaturityHeight: cst.cs.dbBlockHeight() + types.MaturityDelay,
 	}
 	var siafundPool types.Currency
-	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	err = cst.cs.db.Update(func(tx databaseTx) error {
 		siafundPool = getSiafundPool(tx)
 		return nil
 	})
@@ -128,11 +126,11 @@ func TestCommitNodeDiffs(t *testing.T) {
 	pb.SiafundOutputDiffs = append(pb.SiafundOutputDiffs, sfod1)
 	pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
 	pb.SiafundPoolDiffs = append(pb.SiafundPoolDiffs, sfpd)
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		createUpcomingDelayedOutputMaps(tx, pb, modules.DiffApply)
 		return nil
 	})
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		commitNodeDiffs(tx, pb, modules.DiffApply)
 		return nil
 	})
@@ -148,7 +146,7 @@ func TestCommitNodeDiffs(t *testing.T) {
 	if exists {
 		t.Error("intradependent outputs not treated correctly")
 	}
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		commitNodeDiffs(tx, pb, modules.DiffRevert)
 		return nil
 	})
@@ -585,7 +583,7 @@ func TestDeleteObsoleteDelayedOutputMapsSanity(t *testing.T) {
 		t.Fatal(err)
 	}
 	pb := cst.cs.currentProcessedBlock()
-	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	err = cst.cs.db.Update(func(tx databaseTx) error {
 		return commitDiffSet(tx, pb, modules.DiffRevert)
 	})
 	if err != nil {
@@ -605,19 +603,19 @@ func TestDeleteObsoleteDelayedOutputMapsSanity(t *testing.T) {
 		}
 
 		// Trigger a panic by deleting a map with outputs in it during revert.
-		err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		err = cst.cs.db.Update(func(tx databaseTx) error {
 			return createUpcomingDelayedOutputMaps(tx, pb, modules.DiffApply)
 		})
 		if err != nil {
 			t.Fatal(err)
 		}
-		err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		err = cst.cs.db.Update(func(tx databaseTx) error {
 			return commitNodeDiffs(tx, pb, modules.DiffApply)
 		})
 		if err != nil {
 			t.Fatal(err)
 		}
-		err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		err = cst.cs.db.Update(func(tx databaseTx) error {
 			return deleteObsoleteDelayedOutputMaps(tx, pb, modules.DiffRevert)
 		})
 		if err != nil {
@@ -626,7 +624,7 @@ func TestDeleteObsoleteDelayedOutputMapsSanity(t *testing.T) {
 	}()
 
 	// Trigger a panic by deleting a map with outputs in it during apply.
-	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	err = cst.cs.db.Update(func(tx databaseTx) error {
 		return deleteObsoleteDelayedOutputMaps(tx, pb, modules.DiffApply)
 	})
 	if err != nil {
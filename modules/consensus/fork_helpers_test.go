@@ -1,11 +1,9 @@
 package consensus
 
-import "gitlab.com/NebulousLabs/bolt"
-
 // dbBacktrackToCurrentPath is a convenience function to call
-// backtrackToCurrentPath without a bolt.Tx.
+// backtrackToCurrentPath without a database transaction.
 func (cs *ConsensusSet) dbBacktrackToCurrentPath(pb *processedBlock) (pbs []*processedBlock) {
-	_ = cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cs.db.Update(func(tx databaseTx) error {
 		pbs = backtrackToCurrentPath(tx, pb)
 		return nil
 	})
@@ -13,9 +11,9 @@ func (cs *ConsensusSet) dbBacktrackToCurrentPath(pb *processedBlock) (pbs []*pro
 }
 
 // dbRevertToNode is a convenience function to call revertToBlock without a
-// bolt.Tx.
+// database transaction.
 func (cs *ConsensusSet) dbRevertToNode(pb *processedBlock) (pbs []*processedBlock) {
-	_ = cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cs.db.Update(func(tx databaseTx) error {
 		pbs = cs.revertToBlock(tx, pb)
 		return nil
 	})
@@ -23,9 +21,9 @@ func (cs *ConsensusSet) dbRevertToNode(pb *processedBlock) (pbs []*processedBloc
 }
 
 // dbForkBlockchain is a convenience function to call forkBlockchain without a
-// bolt.Tx.
+// database transaction.
 func (cs *ConsensusSet) dbForkBlockchain(pb *processedBlock) (revertedBlocks, appliedBlocks []*processedBlock, err error) {
-	updateErr := cs.db.Update(func(tx *bolt.Tx) error {
+	updateErr := cs.db.Update(func(tx databaseTx) error {
 		revertedBlocks, appliedBlocks, err = cs.forkBlockchain(tx, pb)
 		return nil
 	})
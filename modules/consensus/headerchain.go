@@ -0,0 +1,302 @@
+package consensus
+
+// headerchain.go contains the validation of header chains. Validating the
+// headers of a chain before downloading its blocks allows the initial
+// blockchain download to pick the best chain offered by its peers and to
+// download the blocks of that chain from multiple peers in parallel. The
+// headers are staged on disk, only the information required to validate the
+// next header is kept in memory.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"sort"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errHeaderChainBase is returned if a header chain doesn't extend a block
+	// of the current path.
+	errHeaderChainBase = errors.New("header chain doesn't extend the current path")
+
+	// errNonLinearHeaders is returned when appending a header to a header
+	// chain which isn't a child of the last header.
+	errNonLinearHeaders = errors.New("headers are not a contiguous chain")
+)
+
+type (
+	// headerNode is a validated header of a headerChain. Like a
+	// processedBlock, it contains the information required to validate the
+	// children of the header.
+	headerNode struct {
+		header      types.BlockHeader
+		id          types.BlockID
+		height      types.BlockHeight
+		depth       types.Target
+		childTarget types.Target
+		totalTime   int64
+		totalTarget types.Target
+	}
+
+	// headerChain is a chain of validated headers extending a block of the
+	// current path. The headers are validated using the same rules as
+	// validateHeader, but without requiring their parents to be in the
+	// database.
+	headerChain struct {
+		// base is the block of the current path which is extended by the
+		// chain and last is the last header of the chain. The headers of the
+		// chain are staged in file.
+		base   headerNode
+		last   headerNode
+		length int
+		file   *os.File
+		w      *bufio.Writer
+
+		// timestamps contains the timestamps of the most recent blocks of the
+		// chain, starting at timestampsHeight. Initially, they are the
+		// timestamps of the blocks of the current path up to and including
+		// the base. They are required to compute the target and the minimum
+		// timestamp of the next header.
+		timestamps       []types.Timestamp
+		timestampsHeight types.BlockHeight
+
+		cs *ConsensusSet
+	}
+)
+
+// headerChainWindow is the number of timestamps preceding the tip of a header
+// chain which are required to validate the next header.
+func headerChainWindow() types.BlockHeight {
+	window := types.TargetWindow
+	if types.BlockHeight(types.MedianTimestampWindow) > window {
+		window = types.BlockHeight(types.MedianTimestampWindow)
+	}
+	return window
+}
+
+// newHeaderChain creates an empty header chain extending the block with the
+// given id, which has to be in the current path. The headers of the chain are
+// staged in a file at path.
+func (cs *ConsensusSet) newHeaderChain(tx databaseTx, id types.BlockID, path string) (*headerChain, error) {
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return nil, errHeaderChainBase
+	}
+	pathID, err := getPath(tx, pb.Height)
+	if err != nil || pathID != id {
+		return nil, errHeaderChainBase
+	}
+	totalTime, totalTarget := cs.getBlockTotals(tx, id)
+	hc := &headerChain{
+		base: headerNode{
+			header:      pb.Block.Header(),
+			id:          id,
+			height:      pb.Height,
+			depth:       pb.Depth,
+			childTarget: pb.ChildTarget,
+			totalTime:   totalTime,
+			totalTarget: totalTarget,
+		},
+		cs: cs,
+	}
+
+	// Load the timestamps of the blocks within the target window and the
+	// median timestamp window of the base. Only the timestamps are decoded,
+	// see minimumValidChildTimestamp.
+	window := headerChainWindow()
+	if pb.Height > window {
+		hc.timestampsHeight = pb.Height - window
+	}
+	blockMap := tx.Bucket(BlockMap)
+	for height := hc.timestampsHeight; height <= pb.Height; height++ {
+		pathID, err := getPath(tx, height)
+		if err != nil {
+			return nil, err
+		}
+		pbBytes := blockMap.Get(pathID[:])
+		if len(pbBytes) < 48 {
+			return nil, errNilItem
+		}
+		hc.timestamps = append(hc.timestamps, types.Timestamp(encoding.DecUint64(pbBytes[40:48])))
+	}
+
+	hc.file, err = os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	hc.w = bufio.NewWriter(hc.file)
+	return hc, nil
+}
+
+// append validates a header and appends it to the chain.
+func (hc *headerChain) append(h types.BlockHeader) error {
+	parent := hc.tip()
+	if h.ParentID != parent.id {
+		return errNonLinearHeaders
+	}
+	id := h.ID()
+
+	// Check that the nonce is a legal nonce.
+	if parent.height+1 >= types.ASICHardforkHeight && binary.LittleEndian.Uint64(h.Nonce[:])%types.ASICHardforkFactor != 0 {
+		return errBadNonce
+	}
+	// Check that the target of the new block is sufficient.
+	if !checkHeaderTarget(h, parent.childTarget) {
+		return modules.ErrBlockUnsolved
+	}
+	// Check that the timestamp is neither too far in the past nor in the
+	// extreme future.
+	if h.Timestamp < hc.minimumValidChildTimestamp(parent) {
+		return ErrEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.ExtremeFutureThreshold {
+		return ErrExtremeFutureTimestamp
+	}
+
+	// Compute the difficulty information of the header the same way as
+	// newChild does.
+	node := headerNode{
+		header: h,
+		id:     id,
+		height: parent.height + 1,
+		depth:  parent.depth.AddDifficulties(parent.childTarget),
+	}
+	node.totalTime, node.totalTarget = blockTotals(node.height, parent.totalTime, parent.header.Timestamp, h.Timestamp, parent.totalTarget, parent.childTarget)
+	if parent.height < types.OakHardforkBlock {
+		node.childTarget = hc.childTarget(parent, node)
+	} else {
+		node.childTarget = hc.cs.childTargetOak(parent.totalTime, parent.totalTarget, parent.childTarget, parent.height, parent.header.Timestamp)
+	}
+	if _, err := hc.w.Write(encoding.Marshal(h)); err != nil {
+		return err
+	}
+	hc.last = node
+	hc.length++
+
+	// Only keep the timestamps required to validate the next header. They
+	// are trimmed once twice as many have accumulated to avoid copying them
+	// for every header.
+	hc.timestamps = append(hc.timestamps, h.Timestamp)
+	if keep := int(headerChainWindow()) + 1; len(hc.timestamps) > 2*keep {
+		drop := len(hc.timestamps) - keep
+		hc.timestamps = append(hc.timestamps[:0], hc.timestamps[drop:]...)
+		hc.timestampsHeight += types.BlockHeight(drop)
+	}
+	return nil
+}
+
+// childTarget computes the target of the children of a node before the oak
+// hardfork. It mirrors setChildTarget.
+func (hc *headerChain) childTarget(parent, node headerNode) types.Target {
+	if node.height%(types.TargetWindow/2) != 0 {
+		return parent.childTarget
+	}
+	windowSize := types.TargetWindow
+	if node.height < windowSize {
+		windowSize = node.height
+	}
+	timePassed := node.header.Timestamp - hc.timestampAt(node.height-windowSize)
+	expectedTimePassed := types.BlockFrequency * windowSize
+	adjustment := clampTargetAdjustment(big.NewRat(int64(timePassed), int64(expectedTimePassed)))
+	return types.RatToTarget(new(big.Rat).Mul(parent.childTarget.Rat(), adjustment))
+}
+
+// close closes and removes the staged headers of the chain.
+func (hc *headerChain) close() error {
+	return errors.Compose(hc.file.Close(), os.Remove(hc.file.Name()))
+}
+
+// flush writes the buffered headers to the staging file. It has to be called
+// before the ids of the chain are read.
+func (hc *headerChain) flush() error {
+	return hc.w.Flush()
+}
+
+// ids returns the ids of n headers of the chain, starting with the header at
+// the given index. The first header of the chain has index 0.
+func (hc *headerChain) ids(start, n int) ([]types.BlockID, error) {
+	b := make([]byte, n*types.BlockHeaderSize)
+	if _, err := hc.file.ReadAt(b, int64(start)*types.BlockHeaderSize); err != nil {
+		return nil, err
+	}
+	ids := make([]types.BlockID, n)
+	for i := range ids {
+		var h types.BlockHeader
+		if err := encoding.Unmarshal(b[i*types.BlockHeaderSize:(i+1)*types.BlockHeaderSize], &h); err != nil {
+			return nil, err
+		}
+		ids[i] = h.ID()
+	}
+	return ids, nil
+}
+
+// sharedHeaders returns the number of headers at the start of two flushed
+// chains which are identical. Chains with a different base share no headers.
+func sharedHeaders(a, b *headerChain) (int, error) {
+	if a.base.id != b.base.id {
+		return 0, nil
+	}
+	n := a.length
+	if b.length < n {
+		n = b.length
+	}
+	// Once two chains diverge they can't share any later header, so the
+	// number of shared headers can be found using a binary search.
+	shared, end := 0, n
+	for shared < end {
+		i := (shared + end) / 2
+		idA, err := a.ids(i, 1)
+		if err != nil {
+			return 0, err
+		}
+		idB, err := b.ids(i, 1)
+		if err != nil {
+			return 0, err
+		}
+		if idA[0] == idB[0] {
+			shared = i + 1
+		} else {
+			end = i
+		}
+	}
+	return shared, nil
+}
+
+// minimumValidChildTimestamp returns the earliest timestamp that the children
+// of a node can have. It mirrors stdBlockRuleHelper.minimumValidChildTimestamp.
+func (hc *headerChain) minimumValidChildTimestamp(parent headerNode) types.Timestamp {
+	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
+	windowTimes[0] = parent.header.Timestamp
+	for i := uint64(1); i < types.MedianTimestampWindow; i++ {
+		// Use the genesis block timestamp for all blocks before the genesis
+		// block.
+		if types.BlockHeight(i) > parent.height {
+			windowTimes[i] = windowTimes[i-1]
+			continue
+		}
+		windowTimes[i] = hc.timestampAt(parent.height - types.BlockHeight(i))
+	}
+	sort.Sort(windowTimes)
+	return windowTimes[len(windowTimes)/2]
+}
+
+// timestampAt returns the timestamp of the block at the given height of the
+// chain. The height has to be within headerChainWindow of the tip.
+func (hc *headerChain) timestampAt(height types.BlockHeight) types.Timestamp {
+	return hc.timestamps[height-hc.timestampsHeight]
+}
+
+// tip returns the last node of the chain.
+func (hc *headerChain) tip() headerNode {
+	if hc.length == 0 {
+		return hc.base
+	}
+	return hc.last
+}
@@ -0,0 +1,152 @@
+package consensus
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestHeaderChain checks that a header chain computes the same targets and
+// depths as the consensus set and rejects invalid headers.
+func TestHeaderChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Mine past the oak hardfork.
+	for cst.cs.Height() <= types.OakHardforkFixBlock+10 {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Validate the whole chain starting at the genesis block.
+	var hc *headerChain
+	var expected []*processedBlock
+	path := filepath.Join(cst.cs.persistDir, "chain.headers")
+	err = cst.cs.db.View(func(tx databaseTx) error {
+		hc, err = cst.cs.newHeaderChain(tx, types.GenesisID, path)
+		if err != nil {
+			return err
+		}
+		for height := types.BlockHeight(1); height <= blockHeight(tx); height++ {
+			id, err := getPath(tx, height)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			expected = append(expected, pb)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pb := range expected {
+		if err := hc.append(pb.Block.Header()); err != nil {
+			t.Fatalf("height %v: %v", pb.Height, err)
+		}
+		tip := hc.tip()
+		if tip.height != pb.Height || tip.id != pb.Block.ID() {
+			t.Fatalf("height %v: wrong tip", pb.Height)
+		}
+		if tip.childTarget != pb.ChildTarget {
+			t.Fatalf("height %v: expected child target %v, got %v", pb.Height, pb.ChildTarget, tip.childTarget)
+		}
+		if tip.depth != pb.Depth {
+			t.Fatalf("height %v: expected depth %v, got %v", pb.Height, pb.Depth, tip.depth)
+		}
+	}
+	if err := hc.flush(); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := hc.ids(0, hc.length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pb := range expected {
+		if ids[i] != pb.Block.ID() {
+			t.Fatalf("height %v: wrong staged header", pb.Height)
+		}
+	}
+
+	// A chain containing the first half of the headers shares them with the
+	// whole chain.
+	var half *headerChain
+	err = cst.cs.db.View(func(tx databaseTx) error {
+		half, err = cst.cs.newHeaderChain(tx, types.GenesisID, path+".half")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pb := range expected[:len(expected)/2] {
+		if err := half.append(pb.Block.Header()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := half.flush(); err != nil {
+		t.Fatal(err)
+	}
+	if shared, err := sharedHeaders(hc, half); err != nil || shared != len(expected)/2 {
+		t.Fatalf("expected %v shared headers, got %v (%v)", len(expected)/2, shared, err)
+	}
+	if err := half.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".half"); !os.IsNotExist(err) {
+		t.Fatal("staged headers weren't removed", err)
+	}
+
+	// Invalid headers should be rejected.
+	b, err := cst.miner.FindBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := b.Header()
+	h.ParentID = types.GenesisID
+	if err := hc.append(h); !errors.Contains(err, errNonLinearHeaders) {
+		t.Fatal("expected errNonLinearHeaders, got", err)
+	}
+	h = b.Header()
+	h.Timestamp = 0
+	h.Nonce = types.BlockNonce{}
+	for !checkHeaderTarget(h, hc.tip().childTarget) {
+		binary.LittleEndian.PutUint64(h.Nonce[:], binary.LittleEndian.Uint64(h.Nonce[:])+types.ASICHardforkFactor)
+	}
+	if err := hc.append(h); !errors.Contains(err, ErrEarlyTimestamp) {
+		t.Fatal("expected ErrEarlyTimestamp, got", err)
+	}
+	if err := hc.append(b.Header()); err != nil {
+		t.Fatal(err)
+	}
+	if err := hc.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The base of a chain has to be in the current path.
+	err = cst.cs.db.View(func(tx databaseTx) error {
+		_, err := cst.cs.newHeaderChain(tx, types.BlockID{1}, path)
+		return err
+	})
+	if !errors.Contains(err, errHeaderChainBase) {
+		t.Fatal("expected errHeaderChainBase, got", err)
+	}
+}
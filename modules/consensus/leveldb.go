@@ -0,0 +1,342 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/persist"
+)
+
+// LevelDB has no notion of buckets, so they are emulated by prefixing keys.
+// Every bucket has a marker key consisting of levelPrefixBucket and its name.
+// The keys of a bucket are prefixed with levelPrefixEntry and the
+// length-prefixed name of the bucket, which keeps them in byte-sorted order
+// within the bucket.
+const (
+	levelPrefixBucket byte = iota
+	levelPrefixEntry
+)
+
+// The pending writes of an Update are tagged to distinguish deleted keys
+// from keys with an empty value.
+const (
+	levelTagDelete byte = iota
+	levelTagPut
+)
+
+var (
+	// errBucketExists is returned when creating a bucket that already exists.
+	errBucketExists = errors.New("bucket already exists")
+
+	// errBucketNotFound is returned when deleting a bucket that doesn't
+	// exist.
+	errBucketNotFound = errors.New("bucket not found")
+
+	// errTxNotWritable is returned when modifying the database within a
+	// read-only transaction.
+	errTxNotWritable = errors.New("tx not writable")
+
+	// levelMetadataBucket is the name of the bucket holding the database
+	// metadata. It matches the bucket used by persist.BoltDatabase.
+	levelMetadataBucket = []byte("Metadata")
+)
+
+type (
+	// levelDatabase is the LevelDB backend of the consensus database.
+	levelDatabase struct {
+		db *leveldb.DB
+
+		// mu serializes read-write transactions. Read-only transactions
+		// operate on a snapshot and don't need to hold mu.
+		mu sync.Mutex
+	}
+
+	// levelTx is a transaction on a levelDatabase. Reads are served from a
+	// snapshot of the database, overlayed by the pending writes of the
+	// transaction. The writes are collected in a batch which is written
+	// atomically when the transaction is committed.
+	levelTx struct {
+		snap *leveldb.Snapshot
+
+		// batch and pending are nil for read-only transactions.
+		batch   *leveldb.Batch
+		pending *memdb.DB
+	}
+
+	// levelBucket is a bucket within a levelTx.
+	levelBucket struct {
+		prefix []byte
+		tx     *levelTx
+	}
+)
+
+// levelBucketKey returns the marker key of the bucket with the given name.
+func levelBucketKey(name []byte) []byte {
+	return append([]byte{levelPrefixBucket}, name...)
+}
+
+// levelEntryPrefix returns the prefix of the keys of the bucket with the
+// given name.
+func levelEntryPrefix(name []byte) []byte {
+	prefix := make([]byte, 5, 5+len(name))
+	prefix[0] = levelPrefixEntry
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(name)))
+	return append(prefix, name...)
+}
+
+// openLevelDatabase opens the LevelDB database in dir and validates its
+// metadata.
+func openLevelDatabase(dir string) (database, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	ldb := &levelDatabase{db: db}
+	err = ldb.checkMetadata(dbMetadata)
+	if err != nil {
+		return nil, errors.Compose(err, db.Close())
+	}
+	return ldb, nil
+}
+
+// checkMetadata confirms that the metadata in the database is correct. If
+// there is no metadata, correct metadata is inserted.
+func (ldb *levelDatabase) checkMetadata(md persist.Metadata) error {
+	return ldb.Update(func(tx databaseTx) error {
+		b := tx.Bucket(levelMetadataBucket)
+		if b == nil {
+			b, err := tx.CreateBucket(levelMetadataBucket)
+			if err != nil {
+				return err
+			}
+			return errors.Compose(b.Put([]byte("Header"), []byte(md.Header)), b.Put([]byte("Version"), []byte(md.Version)))
+		}
+		if string(b.Get([]byte("Header"))) != md.Header {
+			return persist.ErrBadHeader
+		}
+		if string(b.Get([]byte("Version"))) != md.Version {
+			return persist.ErrBadVersion
+		}
+		return nil
+	})
+}
+
+// Close closes the database.
+func (ldb *levelDatabase) Close() error {
+	return ldb.db.Close()
+}
+
+// Update executes fn within a read-write transaction. The changes of fn are
+// written atomically once it returns without an error.
+func (ldb *levelDatabase) Update(fn func(tx databaseTx) error) error {
+	ldb.mu.Lock()
+	defer ldb.mu.Unlock()
+
+	snap, err := ldb.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+	tx := &levelTx{
+		snap:    snap,
+		batch:   new(leveldb.Batch),
+		pending: memdb.New(comparer.DefaultComparer, 0),
+	}
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+	return ldb.db.Write(tx.batch, &opt.WriteOptions{Sync: true})
+}
+
+// View executes fn within a read-only transaction.
+func (ldb *levelDatabase) View(fn func(tx databaseTx) error) error {
+	snap, err := ldb.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+	return fn(&levelTx{snap: snap})
+}
+
+// get returns the value of a key or nil if it doesn't exist.
+func (tx *levelTx) get(key []byte) []byte {
+	if tx.pending != nil {
+		v, err := tx.pending.Get(key)
+		if err == nil {
+			if v[0] == levelTagDelete {
+				return nil
+			}
+			return append([]byte{}, v[1:]...)
+		}
+	}
+	v, err := tx.snap.Get(key, nil)
+	if err != nil {
+		return nil
+	}
+	if v == nil {
+		v = []byte{}
+	}
+	return v
+}
+
+// put sets the value of a key.
+func (tx *levelTx) put(key, value []byte) error {
+	if tx.batch == nil {
+		return errTxNotWritable
+	}
+	tx.batch.Put(key, value)
+	return tx.pending.Put(key, append([]byte{levelTagPut}, value...))
+}
+
+// delete removes a key.
+func (tx *levelTx) delete(key []byte) error {
+	if tx.batch == nil {
+		return errTxNotWritable
+	}
+	tx.batch.Delete(key)
+	return tx.pending.Put(key, []byte{levelTagDelete})
+}
+
+// iterate calls fn for every key with the given prefix in byte-sorted order,
+// merging the pending writes of the transaction into the snapshot.
+func (tx *levelTx) iterate(prefix []byte, fn func(k, v []byte) error) error {
+	r := util.BytesPrefix(prefix)
+	it := tx.snap.NewIterator(r, nil)
+	defer it.Release()
+	pit := iterator.NewEmptyIterator(nil)
+	if tx.pending != nil {
+		pit = tx.pending.NewIterator(r)
+	}
+	defer pit.Release()
+
+	ok, pok := it.Next(), pit.Next()
+	for ok || pok {
+		// Compare the current keys of both iterators. If a key exists in
+		// both, the pending write takes precedence.
+		var cmp int
+		switch {
+		case !pok:
+			cmp = -1
+		case !ok:
+			cmp = 1
+		default:
+			cmp = bytes.Compare(it.Key(), pit.Key())
+		}
+		var err error
+		if cmp < 0 {
+			err = fn(append([]byte{}, it.Key()...), append([]byte{}, it.Value()...))
+		} else if pv := pit.Value(); pv[0] == levelTagPut {
+			err = fn(append([]byte{}, pit.Key()...), append([]byte{}, pv[1:]...))
+		}
+		if err != nil {
+			return err
+		}
+		if cmp <= 0 {
+			ok = it.Next()
+		}
+		if cmp >= 0 {
+			pok = pit.Next()
+		}
+	}
+	return errors.Compose(it.Error(), pit.Error())
+}
+
+// Bucket returns the bucket with the given name or nil if it doesn't exist.
+func (tx *levelTx) Bucket(name []byte) databaseBucket {
+	if tx.get(levelBucketKey(name)) == nil {
+		return nil
+	}
+	return &levelBucket{
+		prefix: levelEntryPrefix(name),
+		tx:     tx,
+	}
+}
+
+// CreateBucket creates a new bucket.
+func (tx *levelTx) CreateBucket(name []byte) (databaseBucket, error) {
+	if tx.Bucket(name) != nil {
+		return nil, errBucketExists
+	}
+	return tx.CreateBucketIfNotExists(name)
+}
+
+// CreateBucketIfNotExists creates a new bucket if it doesn't exist yet.
+func (tx *levelTx) CreateBucketIfNotExists(name []byte) (databaseBucket, error) {
+	if b := tx.Bucket(name); b != nil {
+		return b, nil
+	}
+	err := tx.put(levelBucketKey(name), []byte{1})
+	if err != nil {
+		return nil, err
+	}
+	return tx.Bucket(name), nil
+}
+
+// DeleteBucket deletes a bucket and all of its keys.
+func (tx *levelTx) DeleteBucket(name []byte) error {
+	if tx.Bucket(name) == nil {
+		return errBucketNotFound
+	}
+	var keys [][]byte
+	err := tx.iterate(levelEntryPrefix(name), func(k, _ []byte) error {
+		keys = append(keys, k)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := tx.delete(k); err != nil {
+			return err
+		}
+	}
+	return tx.delete(levelBucketKey(name))
+}
+
+// ForEach calls fn for every bucket.
+func (tx *levelTx) ForEach(fn func(name []byte, b databaseBucket) error) error {
+	return tx.iterate([]byte{levelPrefixBucket}, func(k, _ []byte) error {
+		name := k[1:]
+		return fn(name, &levelBucket{
+			prefix: levelEntryPrefix(name),
+			tx:     tx,
+		})
+	})
+}
+
+// key returns the database key of a key in the bucket.
+func (b *levelBucket) key(key []byte) []byte {
+	return append(b.prefix[:len(b.prefix):len(b.prefix)], key...)
+}
+
+// Delete removes a key from the bucket.
+func (b *levelBucket) Delete(key []byte) error {
+	return b.tx.delete(b.key(key))
+}
+
+// ForEach calls fn for every key/value pair in the bucket.
+func (b *levelBucket) ForEach(fn func(k, v []byte) error) error {
+	return b.tx.iterate(b.prefix, func(k, v []byte) error {
+		return fn(k[len(b.prefix):], v)
+	})
+}
+
+// Get returns the value of a key or nil if it doesn't exist.
+func (b *levelBucket) Get(key []byte) []byte {
+	return b.tx.get(b.key(key))
+}
+
+// Put sets the value of a key in the bucket.
+func (b *levelBucket) Put(key, value []byte) error {
+	return b.tx.put(b.key(key), value)
+}
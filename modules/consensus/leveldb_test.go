@@ -0,0 +1,250 @@
+package consensus
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestLevelDatabase tests the bucket emulation and the transaction semantics
+// of the LevelDB backend.
+func TestLevelDatabase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := filepath.Join(build.TempDir(modules.ConsensusDir, t.Name()), LevelDatabaseDir)
+	if err := os.MkdirAll(filepath.Dir(dir), 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := openLevelDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bucketA, bucketB := []byte("a"), []byte("ab")
+	err = db.Update(func(tx databaseTx) error {
+		if tx.Bucket(bucketA) != nil {
+			t.Fatal("bucket shouldn't exist")
+		}
+		a, err := tx.CreateBucket(bucketA)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucket(bucketA); err != errBucketExists {
+			t.Fatal("expected errBucketExists, got", err)
+		}
+		b, err := tx.CreateBucketIfNotExists(bucketB)
+		if err != nil {
+			return err
+		}
+		// Writes should be visible within the transaction.
+		for _, k := range []string{"3", "1", "2"} {
+			if err := a.Put([]byte(k), []byte("a"+k)); err != nil {
+				return err
+			}
+		}
+		if err := b.Put([]byte("0"), []byte("b0")); err != nil {
+			return err
+		}
+		if err := a.Delete([]byte("2")); err != nil {
+			return err
+		}
+		if v := a.Get([]byte("1")); !bytes.Equal(v, []byte("a1")) {
+			t.Fatal("unexpected value", v)
+		}
+		if v := a.Get([]byte("2")); v != nil {
+			t.Fatal("deleted key should be nil", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkKeys asserts the keys of a bucket in iteration order.
+	checkKeys := func(tx databaseTx, name []byte, expected string) {
+		t.Helper()
+		var keys string
+		err := tx.Bucket(name).ForEach(func(k, _ []byte) error {
+			keys += string(k)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keys != expected {
+			t.Fatalf("expected keys %q, got %q", expected, keys)
+		}
+	}
+
+	// Merge pending writes with committed ones, then roll them back.
+	errRollback := errors.New("rollback")
+	err = db.Update(func(tx databaseTx) error {
+		a := tx.Bucket(bucketA)
+		if err := a.Put([]byte("0"), []byte("a0")); err != nil {
+			return err
+		}
+		if err := a.Put([]byte("2"), []byte("a2")); err != nil {
+			return err
+		}
+		if err := a.Delete([]byte("3")); err != nil {
+			return err
+		}
+		checkKeys(tx, bucketA, "012")
+		checkKeys(tx, bucketB, "0")
+		if err := tx.DeleteBucket(bucketB); err != nil {
+			return err
+		}
+		if tx.Bucket(bucketB) != nil {
+			t.Fatal("bucket should be deleted")
+		}
+		return errRollback
+	})
+	if err != errRollback {
+		t.Fatal("expected errRollback, got", err)
+	}
+
+	// None of the rolled back changes should have been committed.
+	err = db.View(func(tx databaseTx) error {
+		checkKeys(tx, bucketA, "13")
+		checkKeys(tx, bucketB, "0")
+		var names []string
+		err := tx.ForEach(func(name []byte, _ databaseBucket) error {
+			names = append(names, string(name))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(names) != 3 || names[0] != "Metadata" || names[1] != "a" || names[2] != "ab" {
+			t.Fatal("unexpected buckets", names)
+		}
+		if err := tx.Bucket(bucketA).Put([]byte("4"), nil); err != errTxNotWritable {
+			t.Fatal("expected errTxNotWritable, got", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The data should be persisted.
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = openLevelDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.View(func(tx databaseTx) error {
+		checkKeys(tx, bucketA, "13")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening a database with a different version should fail.
+	err = db.Update(func(tx databaseTx) error {
+		return tx.Bucket(levelMetadataBucket).Put([]byte("Version"), []byte("0.0.0"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openLevelDatabase(dir); err != persist.ErrBadVersion {
+		t.Fatal("expected ErrBadVersion, got", err)
+	}
+}
+
+// TestLevelDBConsensusSet checks that a consensus set using the LevelDB
+// backend ends up in the same state as one using the bolt backend.
+func TestLevelDBConsensusSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Send some coins to have a non-trivial consensus state.
+	_, err = cst.wallet.SendSiacoins(types.SiacoinPrecision, randAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a consensus set using the LevelDB backend.
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-leveldb")
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	persistDir := filepath.Join(testdir, modules.ConsensusDir)
+	cs, errChan := NewCustomConsensusSetWithDatabase(g, false, persistDir, DatabaseLevelDB, modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(persistDir, LevelDatabaseDir)); err != nil {
+		t.Fatal("expected LevelDB database to exist", err)
+	}
+
+	// Feed it the blockchain of the tester.
+	for height := types.BlockHeight(1); height <= cst.cs.Height(); height++ {
+		b, _ := cst.cs.BlockAtHeight(height)
+		if err := cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("consensus checksums don't match")
+	}
+
+	// The state should be persisted.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, errChan = NewCustomConsensusSetWithDatabase(g, false, persistDir, DatabaseLevelDB, modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cs.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if cs.Height() != cst.cs.Height() || cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("consensus set wasn't persisted")
+	}
+
+	// Unknown backends are rejected.
+	_, errChan = NewCustomConsensusSetWithDatabase(g, false, persistDir, "unknown", modules.ProdDependencies)
+	if err := <-errChan; !errors.Contains(err, errUnknownDatabase) {
+		t.Fatal("expected errUnknownDatabase, got", err)
+	}
+}
@@ -3,8 +3,6 @@ package consensus
 import (
 	"errors"
 
-	"gitlab.com/NebulousLabs/bolt"
-
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -20,7 +18,7 @@ var (
 // applyFoundationSubsidy adds a Foundation subsidy to the consensus set as a
 // delayed siacoin output. If no subsidy is due on the given block, no output is
 // added.
-func applyFoundationSubsidy(tx *bolt.Tx, pb *processedBlock) {
+func applyFoundationSubsidy(tx databaseTx, pb *processedBlock) {
 	// NOTE: this conditional is split up to better visualize test coverage
 	if pb.Height < types.FoundationHardforkHeight {
 		return
@@ -48,7 +46,7 @@ func applyFoundationSubsidy(tx *bolt.Tx, pb *processedBlock) {
 
 // applyMinerPayouts adds a block's miner payouts to the consensus set as
 // delayed siacoin outputs.
-func applyMinerPayouts(tx *bolt.Tx, pb *processedBlock) {
+func applyMinerPayouts(tx databaseTx, pb *processedBlock) {
 	for i := range pb.Block.MinerPayouts {
 		mpid := pb.Block.MinerPayoutID(uint64(i))
 		dscod := modules.DelayedSiacoinOutputDiff{
@@ -65,7 +63,7 @@ func applyMinerPayouts(tx *bolt.Tx, pb *processedBlock) {
 // applyMaturedSiacoinOutputs goes through the list of siacoin outputs that
 // have matured and adds them to the consensus set. This also updates the block
 // node diff set.
-func applyMaturedSiacoinOutputs(tx *bolt.Tx, pb *processedBlock) {
+func applyMaturedSiacoinOutputs(tx databaseTx, pb *processedBlock) {
 	// Skip this step if the blockchain is not old enough to have maturing
 	// outputs.
 	if pb.Height < types.MaturityDelay {
@@ -131,7 +129,7 @@ func applyMaturedSiacoinOutputs(tx *bolt.Tx, pb *processedBlock) {
 
 // applyMissedStorageProof adds the outputs and diffs that result from a file
 // contract expiring.
-func applyMissedStorageProof(tx *bolt.Tx, pb *processedBlock, fcid types.FileContractID) (dscods []modules.DelayedSiacoinOutputDiff, fcd modules.FileContractDiff) {
+func applyMissedStorageProof(tx databaseTx, pb *processedBlock, fcid types.FileContractID) (dscods []modules.DelayedSiacoinOutputDiff, fcd modules.FileContractDiff) {
 	// Sanity checks.
 	fc, err := getFileContract(tx, fcid)
 	if build.DEBUG && err != nil {
@@ -175,7 +173,7 @@ func applyMissedStorageProof(tx *bolt.Tx, pb *processedBlock, fcid types.FileCon
 // applyFileContractMaintenance looks for all of the file contracts that have
 // expired without an appropriate storage proof, and calls 'applyMissedProof'
 // for the file contract.
-func applyFileContractMaintenance(tx *bolt.Tx, pb *processedBlock) {
+func applyFileContractMaintenance(tx databaseTx, pb *processedBlock) {
 	// Get the bucket pointing to all of the expiring file contracts.
 	fceBucketID := append(prefixFCEX, encoding.Marshal(pb.Height)...)
 	fceBucket := tx.Bucket(fceBucketID)
@@ -214,7 +212,7 @@ func applyFileContractMaintenance(tx *bolt.Tx, pb *processedBlock) {
 // applyMaintenance applies block-level alterations to the consensus set.
 // Maintenance is applied after all of the transactions for the block have been
 // applied.
-func applyMaintenance(tx *bolt.Tx, pb *processedBlock) {
+func applyMaintenance(tx databaseTx, pb *processedBlock) {
 	applyMinerPayouts(tx, pb)
 	applyFoundationSubsidy(tx, pb)
 	applyMaturedSiacoinOutputs(tx, pb)
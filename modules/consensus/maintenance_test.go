@@ -3,7 +3,6 @@ package consensus
 import (
 	"testing"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -40,7 +39,7 @@ func TestApplyMinerPayouts(t *testing.T) {
 	mpid0 := pb.Block.MinerPayoutID(0)
 
 	// Apply the single miner payout.
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		applyMinerPayouts(tx, pb)
 		return nil
 	})
@@ -82,7 +81,7 @@ func TestApplyMinerPayouts(t *testing.T) {
 	}
 	mpid1 := pb2.Block.MinerPayoutID(0)
 	mpid2 := pb2.Block.MinerPayoutID(1)
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		applyMinerPayouts(tx, pb2)
 		return nil
 	})
@@ -112,12 +111,12 @@ func TestApplyMinerPayouts(t *testing.T) {
 		}
 		cst.cs.db.rmDelayedSiacoinOutputsHeight(pb.Height+types.MaturityDelay, mpid0)
 		cst.cs.db.addSiacoinOutputs(mpid0, types.SiacoinOutput{})
-		_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		_ = cst.cs.db.Update(func(tx databaseTx) error {
 			applyMinerPayouts(tx, pb)
 			return nil
 		})
 	}()
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		applyMinerPayouts(tx, pb)
 		return nil
 	})
@@ -148,12 +147,12 @@ func TestApplyMaturedSiacoinOutputs(t *testing.T) {
 		}
 	}()
 	cst.cs.db.addSiacoinOutputs(types.SiacoinOutputID{}, types.SiacoinOutput{})
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		createDSCOBucket(tx, pb.Height)
 		return nil
 	})
 	cst.cs.db.addDelayedSiacoinOutputsHeight(pb.Height, types.SiacoinOutputID{}, types.SiacoinOutput{})
-	_ = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.Update(func(tx databaseTx) error {
 		applyMaturedSiacoinOutputs(tx, pb)
 		return nil
 	})
@@ -284,7 +283,7 @@ func TestApplyFileContractMaintenance(t *testing.T) {
 	cst.cs.db.addFileContracts(types.FileContractID{}, expiringFC)
 	cst.cs.db.addFCExpirations(pb.Height)
 	cst.cs.db.addFCExpirationsHeight(pb.Height, types.FileContractID{})
-	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+	err = cst.cs.db.Update(func(tx databaseTx) error {
 		applyFileContractMaintenance(tx, pb)
 		return nil
 	})
@@ -328,7 +327,7 @@ func TestApplyFoundationSubsidy(t *testing.T) {
 	}()
 
 	apply := func(height types.BlockHeight) (dscod modules.DelayedSiacoinOutputDiff, created bool) {
-		err := cst.cs.db.Update(func(tx *bolt.Tx) error {
+		err := cst.cs.db.Update(func(tx databaseTx) error {
 			pb := &processedBlock{
 				Height: height,
 			}
@@ -368,7 +367,7 @@ func TestApplyFoundationSubsidy(t *testing.T) {
 
 	// set new primary address
 	newPrimary := types.UnlockHash{1, 2, 3}
-	cst.cs.db.Update(func(tx *bolt.Tx) error {
+	cst.cs.db.Update(func(tx databaseTx) error {
 		setFoundationUnlockHashes(tx, newPrimary, types.UnlockHash{})
 		return nil
 	})
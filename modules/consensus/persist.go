@@ -6,8 +6,6 @@ import (
 	"os"
 	"path/filepath"
 
-	"gitlab.com/NebulousLabs/bolt"
-
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
@@ -19,6 +17,10 @@ const (
 	// when managing consensus.
 	DatabaseFilename = modules.ConsensusDir + ".db"
 	logFile          = modules.ConsensusDir + ".log"
+
+	// LevelDatabaseDir contains the name of the directory holding the
+	// database when using the LevelDB backend.
+	LevelDatabaseDir = modules.ConsensusDir + ".leveldb"
 )
 
 var (
@@ -30,14 +32,18 @@ var (
 // loadDB pulls all the blocks that have been saved to disk into memory, using
 // them to fill out the ConsensusSet.
 func (cs *ConsensusSet) loadDB() error {
-	// Open the database - a new bolt database will be created if none exists.
-	err := cs.openDB(filepath.Join(cs.persistDir, DatabaseFilename))
+	// Open the database - a new database will be created if none exists.
+	filename, err := databasePath(cs.dbBackend, cs.persistDir)
+	if err != nil {
+		return err
+	}
+	err = cs.openDB(filename)
 	if err != nil {
 		return err
 	}
 
 	// Walk through initialization for Sia.
-	return cs.db.Update(func(tx *bolt.Tx) error {
+	return cs.db.Update(func(tx databaseTx) error {
 		// Check if the database has been initialized.
 		err = cs.initDB(tx)
 		if err != nil {
@@ -60,6 +66,12 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Link the changelog backwards, if necessary.
+		err = cs.initChangeLogPrev(tx)
+		if err != nil {
+			return err
+		}
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
@@ -81,7 +93,7 @@ func (cs *ConsensusSet) loadDB() error {
 
 // initFoundation initializes the database fields relating to the Foundation
 // subsidy hardfork. If these fields have already been set, it does nothing.
-func (cs *ConsensusSet) initFoundation(tx *bolt.Tx) error {
+func (cs *ConsensusSet) initFoundation(tx databaseTx) error {
 	b, err := tx.CreateBucketIfNotExists(FoundationUnlockHashes)
 	if err != nil {
 		return err
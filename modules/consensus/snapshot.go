@@ -0,0 +1,440 @@
+package consensus
+
+// snapshot.go contains the export and import of consensus snapshots. A
+// snapshot contains every block of the current path up to a given height
+// together with the diffs of the blocks. Importing a snapshot verifies the
+// header chain from the genesis block, but applies the diffs of the blocks
+// instead of validating their transactions, which makes bootstrapping a new
+// node much faster than downloading and validating the blockchain. Since the
+// transactions aren't validated, the snapshot itself can't be trusted. An
+// import requires the checksum of the snapshot header, which has to come from
+// a trusted source, e.g. `siac consensus snapshot` on a trusted node. The
+// header contains the id of the last block and the checksum of the resulting
+// consensus set, which are both checked after applying the blocks.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// snapshotImportDir is the directory within the consensus persist
+	// directory in which a snapshot is imported before moving the database
+	// into place.
+	snapshotImportDir = "snapshot-import"
+)
+
+var (
+	// ErrSnapshotDatabaseExists is returned when importing a snapshot into a
+	// persist directory which already contains a consensus database.
+	ErrSnapshotDatabaseExists = errors.New("consensus database already exists")
+
+	// errSnapshotChanged is returned if the current path changes while a
+	// snapshot is being exported.
+	errSnapshotChanged = errors.New("current path changed while exporting snapshot")
+
+	// errSnapshotChecksum is returned if the consensus set doesn't match the
+	// checksum of an imported snapshot.
+	errSnapshotChecksum = errors.New("consensus checksum doesn't match snapshot")
+
+	// errSnapshotDiff is returned if a block of a snapshot contains a diff
+	// which can't be applied to the consensus set.
+	errSnapshotDiff = errors.New("snapshot contains an invalid diff")
+
+	// errSnapshotDepth is returned when exporting a snapshot more than
+	// modules.ConsensusSnapshotMaxDepth blocks below the current height.
+	errSnapshotDepth = fmt.Errorf("snapshot height can't be more than %v blocks below the current height", modules.ConsensusSnapshotMaxDepth)
+
+	// errSnapshotHeight is returned when exporting a snapshot above the
+	// current height.
+	errSnapshotHeight = errors.New("snapshot height is above the current height")
+
+	// errSnapshotTip is returned if the last block of an imported snapshot
+	// doesn't match the block of the snapshot header.
+	errSnapshotTip = errors.New("last block doesn't match snapshot header")
+
+	// errSnapshotUntrusted is returned when importing a snapshot whose header
+	// doesn't match the trusted checksum.
+	errSnapshotUntrusted = errors.New("snapshot doesn't match the trusted checksum")
+
+	// errSnapshotVersion is returned when importing a snapshot with an
+	// unknown version.
+	errSnapshotVersion = errors.New("unknown snapshot version")
+
+	// snapshotVersion is the version of the snapshot format.
+	snapshotVersion = types.NewSpecifier("Snapshot1")
+
+	// snapshotBatchSize is the number of blocks that are read or written
+	// within a single database transaction during an export or import.
+	snapshotBatchSize = build.Select(build.Var{
+		Standard: types.BlockHeight(1000),
+		Testnet:  types.BlockHeight(1000),
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(3),
+	}).(types.BlockHeight)
+
+	// snapshotBlockAllocLimit is the maximum number of bytes allocated when
+	// decoding a single block of a snapshot. The diffs of a block can be
+	// larger than the block itself.
+	snapshotBlockAllocLimit = 10 * int(types.BlockSizeLimit)
+)
+
+type (
+	// snapshotHeader is the header of a snapshot.
+	snapshotHeader struct {
+		Version  types.Specifier
+		Height   types.BlockHeight
+		BlockID  types.BlockID
+		Checksum crypto.Hash
+	}
+
+	// snapshotBlock is a block of a snapshot together with its diffs.
+	snapshotBlock struct {
+		Block                     types.Block
+		SiacoinOutputDiffs        []modules.SiacoinOutputDiff
+		FileContractDiffs         []modules.FileContractDiff
+		SiafundOutputDiffs        []modules.SiafundOutputDiff
+		DelayedSiacoinOutputDiffs []modules.DelayedSiacoinOutputDiff
+		SiafundPoolDiffs          []modules.SiafundPoolDiff
+	}
+
+	// snapshotDSCO identifies a delayed siacoin output.
+	snapshotDSCO struct {
+		maturityHeight types.BlockHeight
+		id             types.SiacoinOutputID
+	}
+)
+
+// ExportSnapshot writes a snapshot of the consensus set at the given height
+// of the current path to w.
+func (cs *ConsensusSet) ExportSnapshot(w io.Writer, height types.BlockHeight) error {
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	header, err := cs.managedSnapshotHeader(height)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	enc := encoding.NewEncoder(bw)
+	err = enc.Encode(header)
+	if err != nil {
+		return err
+	}
+
+	// Write the blocks in batches to avoid holding a database transaction for
+	// the whole export. Every block has to be a child of the previously
+	// written one, otherwise the current path was changed by a reorg.
+	prevID := cs.blockRoot.Block.ID()
+	for start := types.BlockHeight(1); start <= header.Height; start += snapshotBatchSize {
+		err = cs.db.View(func(tx databaseTx) error {
+			for h := start; h < start+snapshotBatchSize && h <= header.Height; h++ {
+				id, err := getPath(tx, h)
+				if err != nil {
+					return errSnapshotChanged
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return err
+				}
+				if pb.Block.ParentID != prevID {
+					return errSnapshotChanged
+				}
+				prevID = id
+				err = enc.Encode(snapshotBlock{
+					Block:                     pb.Block,
+					SiacoinOutputDiffs:        pb.SiacoinOutputDiffs,
+					FileContractDiffs:         pb.FileContractDiffs,
+					SiafundOutputDiffs:        pb.SiafundOutputDiffs,
+					DelayedSiacoinOutputDiffs: pb.DelayedSiacoinOutputDiffs,
+					SiafundPoolDiffs:          pb.SiafundPoolDiffs,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if prevID != header.BlockID {
+		return errSnapshotChanged
+	}
+	return bw.Flush()
+}
+
+// managedSnapshotHeader returns the header of a snapshot at the given height.
+// The checksum of the consensus set at that height is computed by reverting
+// the blocks above it within a transaction that is rolled back afterwards. The
+// height can be at most modules.ConsensusSnapshotMaxDepth blocks below the
+// current height to bound the time the consensus set is locked.
+func (cs *ConsensusSet) managedSnapshotHeader(height types.BlockHeight) (header snapshotHeader, err error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	// Like in tryTransactionSet, errSuccess is returned to roll back the
+	// transaction.
+	errSuccess := errors.New("success")
+	err = cs.db.Update(func(tx databaseTx) error {
+		current := blockHeight(tx)
+		if height > current {
+			return errSnapshotHeight
+		}
+		if current-height > modules.ConsensusSnapshotMaxDepth {
+			return errSnapshotDepth
+		}
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		cs.revertToBlock(tx, pb)
+		header = snapshotHeader{
+			Version:  snapshotVersion,
+			Height:   height,
+			BlockID:  id,
+			Checksum: consensusChecksum(tx),
+		}
+		return errSuccess
+	})
+	if !errors.Contains(err, errSuccess) {
+		return snapshotHeader{}, err
+	}
+	return header, nil
+}
+
+// SnapshotChecksum returns the checksum of the header of the snapshot read
+// from r, which is required to import the snapshot.
+func SnapshotChecksum(r io.Reader) (crypto.Hash, error) {
+	var header snapshotHeader
+	err := encoding.NewDecoder(r, encoding.DefaultAllocLimit).Decode(&header)
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "unable to decode snapshot header")
+	}
+	return crypto.HashObject(header), nil
+}
+
+// ImportSnapshot creates a new consensus database in persistDir using the
+// given database backend and initializes it with the snapshot read from r.
+// The snapshot is only imported if the checksum of its header matches the
+// trusted checksum. The height of the snapshot is returned. The database is
+// built in a temporary directory and only moved into place once the snapshot
+// has been verified. ErrSnapshotDatabaseExists is returned if persistDir
+// already contains a database.
+func ImportSnapshot(r io.Reader, checksum crypto.Hash, persistDir, dbBackend string) (types.BlockHeight, error) {
+	path, err := databasePath(dbBackend, persistDir)
+	if err != nil {
+		return 0, err
+	}
+	_, err = os.Stat(path)
+	if err == nil {
+		return 0, ErrSnapshotDatabaseExists
+	} else if !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	// Remove any leftovers of an interrupted import.
+	importDir := filepath.Join(persistDir, snapshotImportDir)
+	err = os.RemoveAll(importDir)
+	if err != nil {
+		return 0, err
+	}
+	cs := newConsensusSet(nil, importDir, dbBackend, modules.ProdDependencies)
+	err = cs.initPersist()
+	if err != nil {
+		return 0, errors.Compose(err, cs.tg.Stop(), os.RemoveAll(importDir))
+	}
+	height, err := cs.importSnapshot(r, checksum)
+	err = errors.Compose(err, cs.tg.Stop())
+	if err != nil {
+		return 0, errors.Compose(err, os.RemoveAll(importDir))
+	}
+
+	// Move the database into place.
+	importPath, err := databasePath(dbBackend, importDir)
+	if err != nil {
+		return 0, err
+	}
+	err = os.Rename(importPath, path)
+	if err != nil {
+		return 0, errors.Compose(err, os.RemoveAll(importDir))
+	}
+	return height, os.RemoveAll(importDir)
+}
+
+// importSnapshot applies the blocks of the snapshot read from r to the
+// consensus set, which must only contain the genesis block. The header of the
+// snapshot has to match the trusted checksum.
+func (cs *ConsensusSet) importSnapshot(r io.Reader, checksum crypto.Hash) (types.BlockHeight, error) {
+	// A new decoder is used for every object because the allocation limit
+	// of a decoder applies to everything it decodes.
+	br := bufio.NewReader(r)
+	var header snapshotHeader
+	err := encoding.NewDecoder(br, encoding.DefaultAllocLimit).Decode(&header)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to decode snapshot header")
+	}
+	if header.Version != snapshotVersion {
+		return 0, errSnapshotVersion
+	}
+	if crypto.HashObject(header) != checksum {
+		return 0, errSnapshotUntrusted
+	}
+
+	for height := types.BlockHeight(1); height <= header.Height; {
+		err = cs.db.Update(func(tx databaseTx) error {
+			for i := types.BlockHeight(0); i < snapshotBatchSize && height <= header.Height; i++ {
+				var sb snapshotBlock
+				err := encoding.NewDecoder(br, snapshotBlockAllocLimit).Decode(&sb)
+				if err != nil {
+					return errors.AddContext(err, fmt.Sprintf("unable to decode block at height %v", height))
+				}
+				err = cs.applySnapshotBlock(tx, sb)
+				if err != nil {
+					return errors.AddContext(err, fmt.Sprintf("invalid block at height %v", height))
+				}
+				height++
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Verify the resulting consensus set.
+	err = cs.db.View(func(tx databaseTx) error {
+		if currentBlockID(tx) != header.BlockID {
+			return errSnapshotTip
+		}
+		if consensusChecksum(tx) != header.Checksum {
+			return errSnapshotChecksum
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return header.Height, nil
+}
+
+// applySnapshotBlock verifies the header of a snapshot block and applies the
+// block using the diffs of the snapshot.
+func (cs *ConsensusSet) applySnapshotBlock(tx databaseTx, sb snapshotBlock) error {
+	if sb.Block.ParentID != currentBlockID(tx) {
+		return errNonLinearChain
+	}
+	id := sb.Block.ID()
+	parent, err := cs.validateHeaderAndBlock(txWrapper{tx}, sb.Block, id)
+	if err != nil {
+		return err
+	}
+	pb := cs.newChild(tx, parent, sb.Block)
+	pb.SiacoinOutputDiffs = sb.SiacoinOutputDiffs
+	pb.FileContractDiffs = sb.FileContractDiffs
+	pb.SiafundOutputDiffs = sb.SiafundOutputDiffs
+	pb.DelayedSiacoinOutputDiffs = sb.DelayedSiacoinOutputDiffs
+	pb.SiafundPoolDiffs = sb.SiafundPoolDiffs
+	pb.DiffsGenerated = true
+	err = validSnapshotDiffs(tx, pb)
+	if err != nil {
+		return err
+	}
+	commitDiffSet(tx, pb, modules.DiffApply)
+	if build.DEBUG {
+		pb.ConsensusChecksum = consensusChecksum(tx)
+	}
+	addBlockMap(tx, pb)
+	cs.maybeCheckConsistency(tx)
+	return appendChangeLog(tx, changeEntry{AppliedBlocks: []types.BlockID{id}})
+}
+
+// validSnapshotDiffs checks that the diffs of a snapshot block can be applied
+// to the consensus set. Diffs adding an object require that the object
+// doesn't exist yet and diffs removing an object require that it does. The
+// diffs are applied in order, so the existence of the objects touched by
+// earlier diffs of the block is tracked separately.
+func validSnapshotDiffs(tx databaseTx, pb *processedBlock) error {
+	scos := make(map[types.SiacoinOutputID]bool)
+	for _, scod := range pb.SiacoinOutputDiffs {
+		exists, ok := scos[scod.ID]
+		if !ok {
+			exists = isSiacoinOutput(tx, scod.ID)
+		}
+		if exists != (scod.Direction == modules.DiffRevert) {
+			return errSnapshotDiff
+		}
+		scos[scod.ID] = !exists
+	}
+
+	fcs := make(map[types.FileContractID]bool)
+	for _, fcd := range pb.FileContractDiffs {
+		exists, ok := fcs[fcd.ID]
+		if !ok {
+			exists = tx.Bucket(FileContracts).Get(fcd.ID[:]) != nil
+		}
+		if exists != (fcd.Direction == modules.DiffRevert) {
+			return errSnapshotDiff
+		}
+		fcs[fcd.ID] = !exists
+	}
+
+	sfos := make(map[types.SiafundOutputID]bool)
+	for _, sfod := range pb.SiafundOutputDiffs {
+		exists, ok := sfos[sfod.ID]
+		if !ok {
+			exists = tx.Bucket(SiafundOutputs).Get(sfod.ID[:]) != nil
+		}
+		if exists != (sfod.Direction == modules.DiffRevert) {
+			return errSnapshotDiff
+		}
+		sfos[sfod.ID] = !exists
+	}
+
+	// Delayed outputs can only be added to existing buckets or to the bucket
+	// which is created by the block.
+	dscos := make(map[snapshotDSCO]bool)
+	for _, dscod := range pb.DelayedSiacoinOutputDiffs {
+		key := snapshotDSCO{dscod.MaturityHeight, dscod.ID}
+		exists, ok := dscos[key]
+		if !ok {
+			bucket := tx.Bucket(append(prefixDSCO, encoding.Marshal(dscod.MaturityHeight)...))
+			if bucket == nil && dscod.MaturityHeight != pb.Height+types.MaturityDelay {
+				return errSnapshotDiff
+			}
+			exists = bucket != nil && bucket.Get(dscod.ID[:]) != nil
+		}
+		if exists != (dscod.Direction == modules.DiffRevert) {
+			return errSnapshotDiff
+		}
+		dscos[key] = !exists
+	}
+
+	pool := getSiafundPool(tx)
+	for _, sfpd := range pb.SiafundPoolDiffs {
+		if sfpd.Direction != modules.DiffApply || !sfpd.Previous.Equals(pool) || sfpd.Adjusted.Cmp(sfpd.Previous) < 0 {
+			return errSnapshotDiff
+		}
+		pool = sfpd.Adjusted
+	}
+	return nil
+}
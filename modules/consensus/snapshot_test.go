@@ -0,0 +1,269 @@
+package consensus
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// TestSnapshot tests exporting a snapshot of a consensus set and importing it
+// into a new consensus set.
+func TestSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	// Send some coins to have a non-trivial consensus state.
+	_, err = cst.wallet.SendSiacoins(types.SiacoinPrecision, randAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Export a snapshot at the current height and import it.
+	var snapshot bytes.Buffer
+	if err := cst.cs.ExportSnapshot(&snapshot, cst.cs.Height()); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := SnapshotChecksum(bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testdir := build.TempDir(modules.ConsensusDir, t.Name()+"-import")
+	persistDir := filepath.Join(testdir, modules.ConsensusDir)
+	height, err := ImportSnapshot(bytes.NewReader(snapshot.Bytes()), checksum, persistDir, DatabaseBolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != cst.cs.Height() {
+		t.Fatalf("expected height %v, got %v", cst.cs.Height(), height)
+	}
+	if _, err := os.Stat(filepath.Join(persistDir, snapshotImportDir)); !os.IsNotExist(err) {
+		t.Fatal("import directory wasn't removed", err)
+	}
+	_, err = ImportSnapshot(bytes.NewReader(snapshot.Bytes()), checksum, persistDir, DatabaseBolt)
+	if !errors.Contains(err, ErrSnapshotDatabaseExists) {
+		t.Fatal("expected ErrSnapshotDatabaseExists, got", err)
+	}
+
+	// The imported consensus set should match the original one and accept
+	// new blocks.
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cs, errChan := New(g, false, persistDir)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if cs.dbCurrentBlockID() != cst.cs.dbCurrentBlockID() || cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("imported consensus set doesn't match")
+	}
+	b, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("consensus sets diverged after accepting a block")
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export a snapshot below the current height and import it using the
+	// LevelDB backend.
+	snapshotHeight := cst.cs.Height() - 3
+	snapshot.Reset()
+	if err := cst.cs.ExportSnapshot(&snapshot, snapshotHeight); err != nil {
+		t.Fatal(err)
+	}
+	checksum, err = SnapshotChecksum(bytes.NewReader(snapshot.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	levelDir := filepath.Join(testdir, "leveldb")
+	if _, err := ImportSnapshot(&snapshot, checksum, levelDir, DatabaseLevelDB); err != nil {
+		t.Fatal(err)
+	}
+	cs2, errChan := NewCustomConsensusSetWithDatabase(g, false, levelDir, DatabaseLevelDB, modules.ProdDependencies)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cs2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	expected, _ := cst.cs.BlockAtHeight(snapshotHeight)
+	if cs2.Height() != snapshotHeight || cs2.CurrentBlock().ID() != expected.ID() {
+		t.Fatal("imported snapshot has the wrong tip")
+	}
+
+	// Snapshots above the current height can't be exported.
+	err = cst.cs.ExportSnapshot(&snapshot, cst.cs.Height()+1)
+	if !errors.Contains(err, errSnapshotHeight) {
+		t.Fatal("expected errSnapshotHeight, got", err)
+	}
+
+	// Snapshots too far below the current height can't be exported either.
+	for cst.cs.Height() <= modules.ConsensusSnapshotMaxDepth {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cst.cs.ExportSnapshot(&snapshot, cst.cs.Height()-modules.ConsensusSnapshotMaxDepth-1)
+	if !errors.Contains(err, errSnapshotDepth) {
+		t.Fatal("expected errSnapshotDepth, got", err)
+	}
+}
+
+// TestSnapshotInvalid tests that invalid snapshots are rejected.
+func TestSnapshotInvalid(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	var snapshot bytes.Buffer
+	if err := cst.cs.ExportSnapshot(&snapshot, cst.cs.Height()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Split the snapshot into its header and its blocks.
+	var header snapshotHeader
+	if err := encoding.Unmarshal(snapshot.Bytes(), &header); err != nil {
+		t.Fatal(err)
+	}
+	headerLen := len(encoding.Marshal(header))
+	blocks := snapshot.Bytes()[headerLen:]
+	var sb snapshotBlock
+	if err := encoding.Unmarshal(blocks, &sb); err != nil {
+		t.Fatal(err)
+	}
+	firstBlockLen := len(encoding.Marshal(sb))
+
+	// withHeader returns the snapshot with a modified header.
+	withHeader := func(fn func(h *snapshotHeader)) []byte {
+		h := header
+		fn(&h)
+		return append(encoding.Marshal(h), blocks...)
+	}
+	// withFirstBlock returns the snapshot with a modified first block.
+	withFirstBlock := func(fn func(sb *snapshotBlock)) []byte {
+		var b snapshotBlock
+		if err := encoding.Unmarshal(blocks, &b); err != nil {
+			t.Fatal(err)
+		}
+		fn(&b)
+		s := append(encoding.Marshal(header), encoding.Marshal(b)...)
+		return append(s, blocks[firstBlockLen:]...)
+	}
+
+	// Unless a test specifies a trusted checksum, the checksum of the
+	// snapshot's own header is trusted to exercise the checks performed while
+	// importing.
+	tests := []struct {
+		name     string
+		snapshot []byte
+		trusted  crypto.Hash
+		err      error
+	}{
+		{
+			name:     "untrusted",
+			snapshot: withHeader(func(h *snapshotHeader) { h.Checksum[0] ^= 1 }),
+			trusted:  crypto.HashObject(header),
+			err:      errSnapshotUntrusted,
+		},
+		{
+			name:     "version",
+			snapshot: withHeader(func(h *snapshotHeader) { h.Version = types.NewSpecifier("Snapshot0") }),
+			err:      errSnapshotVersion,
+		},
+		{
+			name:     "checksum",
+			snapshot: withHeader(func(h *snapshotHeader) { h.Checksum[0] ^= 1 }),
+			err:      errSnapshotChecksum,
+		},
+		{
+			name:     "tip",
+			snapshot: withHeader(func(h *snapshotHeader) { h.BlockID[0] ^= 1 }),
+			err:      errSnapshotTip,
+		},
+		{
+			name:     "parent",
+			snapshot: withFirstBlock(func(sb *snapshotBlock) { sb.Block.ParentID[0] ^= 1 }),
+			err:      errNonLinearChain,
+		},
+		{
+			name: "diff",
+			snapshot: withFirstBlock(func(sb *snapshotBlock) {
+				sb.DelayedSiacoinOutputDiffs[0].Direction = modules.DiffRevert
+			}),
+			err: errSnapshotDiff,
+		},
+		{
+			name:     "truncated",
+			snapshot: snapshot.Bytes()[:snapshot.Len()-1],
+		},
+	}
+	for _, test := range tests {
+		trusted := test.trusted
+		if trusted == (crypto.Hash{}) {
+			trusted, err = SnapshotChecksum(bytes.NewReader(test.snapshot))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		persistDir := filepath.Join(build.TempDir(modules.ConsensusDir, t.Name()+"-import", test.name), modules.ConsensusDir)
+		_, err := ImportSnapshot(bytes.NewReader(test.snapshot), trusted, persistDir, DatabaseBolt)
+		if err == nil || (test.err != nil && !errors.Contains(err, test.err)) {
+			t.Fatalf("%v: expected %v, got %v", test.name, test.err, err)
+		}
+		// Nothing should be left behind by a failed import.
+		if _, err := os.Stat(filepath.Join(persistDir, DatabaseFilename)); !os.IsNotExist(err) {
+			t.Fatalf("%v: database was created", test.name)
+		}
+		if _, err := os.Stat(filepath.Join(persistDir, snapshotImportDir)); !os.IsNotExist(err) {
+			t.Fatalf("%v: import directory wasn't removed", test.name)
+		}
+	}
+}
@@ -2,12 +2,12 @@ package consensus
 
 import (
 	"errors"
+	"math"
 	"time"
 
-	"gitlab.com/NebulousLabs/bolt"
-
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 
 	siasync "go.sia.tech/siad/sync"
 )
@@ -58,7 +58,7 @@ func computeConsensusChangeDiffs(pb *processedBlock, apply bool) modules.Consens
 
 // computeConsensusChange computes the consensus change from the change entry
 // at index 'i' in the change log. If i is out of bounds, an error is returned.
-func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (modules.ConsensusChange, error) {
+func (cs *ConsensusSet) computeConsensusChange(tx databaseTx, ce changeEntry) (modules.ConsensusChange, error) {
 	cc := modules.ConsensusChange{
 		ID: ce.ID(),
 	}
@@ -116,7 +116,7 @@ func (cs *ConsensusSet) updateSubscribers(ce changeEntry) {
 	}
 	// Get the consensus change and send it to all subscribers.
 	var cc modules.ConsensusChange
-	err := cs.db.View(func(tx *bolt.Tx) error {
+	err := cs.db.View(func(tx databaseTx) error {
 		// Compute the consensus change so it can be sent to subscribers.
 		var err error
 		cc, err = cs.computeConsensusChange(tx, ce)
@@ -155,7 +155,7 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 	var exists bool
 	var entry changeEntry
 	cs.mu.RLock()
-	err := cs.db.View(func(tx *bolt.Tx) error {
+	err := cs.db.View(func(tx databaseTx) error {
 		if start == modules.ConsensusChangeBeginning {
 			// Special case: for modules.ConsensusChangeBeginning, create an
 			// initial node pointing to the genesis block. The subscriber will
@@ -197,7 +197,7 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 		// Send changes in batches of 100 so that we don't hold the
 		// lock for too long.
 		cs.mu.RLock()
-		err = cs.db.View(func(tx *bolt.Tx) error {
+		err = cs.db.View(func(tx databaseTx) error {
 			for i := 0; i < 100 && exists; i++ {
 				latestChangeID = entry.ID()
 				select {
@@ -225,7 +225,7 @@ func (cs *ConsensusSet) managedInitializeSubscribe(subscriber modules.ConsensusS
 // recentConsensusChangeID gets the ConsensusChangeID of the most recent
 // change.
 func (cs *ConsensusSet) recentConsensusChangeID() (cid modules.ConsensusChangeID, err error) {
-	err = cs.db.View(func(tx *bolt.Tx) error {
+	err = cs.db.View(func(tx databaseTx) error {
 		cl := tx.Bucket(ChangeLog)
 		d := cl.Get(ChangeLogTailID)
 		if d == nil {
@@ -237,6 +237,54 @@ func (cs *ConsensusSet) recentConsensusChangeID() (cid modules.ConsensusChangeID
 	return
 }
 
+// ConsensusChangeAtHeight returns the id of the latest consensus change after
+// which the blockchain had a height of at most 'height' and never dropped
+// below that height again. Subscribing with the returned id replays the
+// changes of all blocks starting at startHeight, which is at most height+1.
+func (cs *ConsensusSet) ConsensusChangeAtHeight(height types.BlockHeight) (id modules.ConsensusChangeID, startHeight types.BlockHeight, err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return modules.ConsensusChangeID{}, 0, err
+	}
+	defer cs.tg.Done()
+
+	// walk the changelog backwards from the tail and track the height of the
+	// blockchain after each change as well as the lowest height any later
+	// change reverted to. The first change which resulted in a height of at
+	// most 'height' without any later change reverting below it is the latest
+	// one. The genesis block is never reverted, so the genesis change always
+	// qualifies.
+	id = modules.ConsensusChangeBeginning
+	cs.mu.RLock()
+	err = cs.db.View(func(tx databaseTx) error {
+		var changeID modules.ConsensusChangeID
+		copy(changeID[:], tx.Bucket(ChangeLog).Get(ChangeLogTailID))
+		resulted := int64(blockHeight(tx))
+		lowestLater := int64(math.MaxInt64)
+		for {
+			entry, exists := getEntry(tx, changeID)
+			if !exists {
+				return nil
+			}
+			if resulted <= int64(height) && resulted <= lowestLater {
+				id, startHeight = changeID, types.BlockHeight(resulted+1)
+				return nil
+			}
+			lowest := resulted - int64(len(entry.AppliedBlocks))
+			if lowest < lowestLater {
+				lowestLater = lowest
+			}
+			resulted = lowest + int64(len(entry.RevertedBlocks))
+			changeID = prevEntryID(tx, changeID)
+		}
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return modules.ConsensusChangeID{}, 0, err
+	}
+	return id, startHeight, nil
+}
+
 // ConsensusSetSubscribe adds a subscriber to the list of subscribers, and
 // gives them every consensus change that has occurred since the change with
 // the provided id.
@@ -7,7 +7,6 @@ import (
 	"testing"
 	"time"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
@@ -222,7 +221,7 @@ func TestModuleDesync(t *testing.T) {
 	// Get all the updates from the consensusSet.
 	updates := make([]modules.ConsensusChange, 0)
 	cst.cs.mu.Lock()
-	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+	err = cst.cs.db.View(func(tx databaseTx) error {
 		entry := cst.cs.genesisEntry()
 		exists := true
 		for ; exists; entry, exists = entry.NextEntry(tx) {
@@ -378,3 +377,55 @@ func TestConsensusBlockHeight(t *testing.T) {
 	}
 	testExpectedHeight(15)
 }
+
+// TestConsensusChangeAtHeight checks that subscribing with the id returned by
+// ConsensusChangeAtHeight replays the changes starting at the returned height.
+func TestConsensusChangeAtHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = cst.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		cst.testSimpleBlock()
+	}
+
+	tests := []struct {
+		height      types.BlockHeight
+		startHeight types.BlockHeight
+	}{
+		{0, 1},
+		{4, 5},
+		{9, 10},
+		{10, 11},
+		{100, 11},
+	}
+	for _, test := range tests {
+		id, startHeight, err := cst.cs.ConsensusChangeAtHeight(test.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if startHeight != test.startHeight {
+			t.Fatalf("height %v: expected start height %v, got %v", test.height, test.startHeight, startHeight)
+		}
+		ms := newMockSubscriber()
+		if err := cst.cs.ConsensusSetSubscribe(&ms, id, nil); err != nil {
+			t.Fatal(err)
+		}
+		cst.cs.Unsubscribe(&ms)
+		if len(ms.updates) != int(11-startHeight) {
+			t.Fatalf("height %v: expected %v updates, got %v", test.height, 11-startHeight, len(ms.updates))
+		}
+		if len(ms.updates) > 0 && ms.updates[0].BlockHeight != startHeight {
+			t.Fatalf("height %v: expected first update at height %v, got %v", test.height, startHeight, ms.updates[0].BlockHeight)
+		}
+	}
+}
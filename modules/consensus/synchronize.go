@@ -5,7 +5,6 @@ import (
 	"sync"
 	"time"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/threadgroup"
 
@@ -102,7 +101,7 @@ func isTimeoutErr(err error) bool {
 // to find a common parent that is reasonably recent, usually the most recent
 // common parent is found, but always a common parent within a factor of 2 is
 // found.
-func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
+func blockHistory(tx databaseTx) (blockIDs [32]types.BlockID) {
 	height := blockHeight(tx)
 	step := types.BlockHeight(1)
 	// The final step is to include the genesis block, which is why the final
@@ -171,7 +170,7 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 	// Get blockIDs to send.
 	var history [32]types.BlockID
 	cs.mu.RLock()
-	err = cs.db.View(func(tx *bolt.Tx) error {
+	err = cs.db.View(func(tx databaseTx) error {
 		history = blockHistory(tx)
 		return nil
 	})
@@ -265,6 +264,39 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) error {
 	return cs.managedReceiveBlocks(conn)
 }
 
+// managedFindStart finds the most recent block of knownBlocks in the current
+// path and returns the height of its child. If no block is found, or if the
+// most recent block is the current block, found is false.
+func (cs *ConsensusSet) managedFindStart(knownBlocks [32]types.BlockID) (start types.BlockHeight, found bool, err error) {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	err = cs.db.View(func(tx databaseTx) error {
+		csHeight := blockHeight(tx)
+		for _, id := range knownBlocks {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				continue
+			}
+			pathID, err := getPath(tx, pb.Height)
+			if err != nil {
+				continue
+			}
+			if pathID != pb.Block.ID() {
+				continue
+			}
+			if pb.Height == csHeight {
+				break
+			}
+			found = true
+			// Start from the child of the common block.
+			start = pb.Height + 1
+			break
+		}
+		return nil
+	})
+	return start, found, err
+}
+
 // rpcSendBlocks is the receiving end of the SendBlocks RPC. It returns a
 // sequential set of blocks based on the 32 input block IDs. The most recent
 // known ID is used as the starting point, and up to 'MaxCatchUpBlocks' from
@@ -293,41 +325,13 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	// Read a list of blocks known to the requester and find the most recent
 	// block from the current path.
 	var knownBlocks [32]types.BlockID
-	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	err = modules.ReadPeerObject(conn, &knownBlocks, 32*crypto.HashSize)
 	if err != nil {
 		return err
 	}
 
 	// Find the most recent block from knownBlocks in the current path.
-	found := false
-	var start types.BlockHeight
-	var csHeight types.BlockHeight
-	cs.mu.RLock()
-	err = cs.db.View(func(tx *bolt.Tx) error {
-		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
-		}
-		return nil
-	})
-	cs.mu.RUnlock()
+	start, found, err := cs.managedFindStart(knownBlocks)
 	if err != nil {
 		return err
 	}
@@ -350,7 +354,7 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 		// Get the set of blocks to send.
 		var blocks []types.Block
 		cs.mu.RLock()
-		err = cs.db.View(func(tx *bolt.Tx) error {
+		err = cs.db.View(func(tx databaseTx) error {
 			height := blockHeight(tx)
 			for i := start; i <= height && i < start+MaxCatchUpBlocks; i++ {
 				id, err := getPath(tx, i)
@@ -420,16 +424,16 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 
 	// Decode the block header from the connection.
 	var h types.BlockHeader
-	err = encoding.ReadObject(conn, &h, types.BlockHeaderSize)
+	err = modules.ReadPeerObject(conn, &h, types.BlockHeaderSize)
 	if err != nil {
 		return err
 	}
 
 	// Start verification inside of a bolt View tx.
 	cs.mu.RLock()
-	err = cs.db.View(func(tx *bolt.Tx) error {
+	err = cs.db.View(func(tx databaseTx) error {
 		// Do some relatively inexpensive checks to validate the header
-		return cs.validateHeader(boltTxWrapper{tx}, h)
+		return cs.validateHeader(txWrapper{tx}, h)
 	})
 	cs.mu.RUnlock()
 	// WARN: orphan multithreading logic (dangerous areas, see below)
@@ -444,7 +448,11 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 	// Because it is not, we have to do weird threading to prevent
 	// deadlocks, and we also have to be concerned every time the code in
 	// managedReceiveBlock is adjusted.
-	if errors.Contains(err, errOrphan) { // WARN: orphan multithreading logic case #1
+	if errors.Contains(err, errDoSBlock) || errors.Contains(err, modules.ErrBlockUnsolved) ||
+		errors.Contains(err, errBadNonce) || errors.Contains(err, ErrEarlyTimestamp) {
+		// The header is invalid no matter which blocks the node knows about.
+		return errors.Compose(err, modules.ErrPeerMisbehavior)
+	} else if errors.Contains(err, errOrphan) { // WARN: orphan multithreading logic case #1
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
@@ -493,14 +501,14 @@ func (cs *ConsensusSet) rpcSendBlk(conn modules.PeerConn) error {
 
 	// Decode the block id from the connection.
 	var id types.BlockID
-	err = encoding.ReadObject(conn, &id, crypto.HashSize)
+	err = modules.ReadPeerObject(conn, &id, crypto.HashSize)
 	if err != nil {
 		return err
 	}
 	// Lookup the corresponding block.
 	var b types.Block
 	cs.mu.RLock()
-	err = cs.db.View(func(tx *bolt.Tx) error {
+	err = cs.db.View(func(tx databaseTx) error {
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -543,6 +551,16 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 	}
 }
 
+// hasOutboundPeers returns true if any of the peers is an outbound peer.
+func hasOutboundPeers(peers []modules.Peer) bool {
+	for _, p := range peers {
+		if !p.Inbound {
+			return true
+		}
+	}
+	return false
+}
+
 // managedInitialBlockchainDownload performs the IBD on outbound peers. Blocks
 // are downloaded from one peer at a time in 5 minute intervals, so as to
 // prevent any one peer from significantly slowing down IBD.
@@ -562,7 +580,22 @@ func (cs *ConsensusSet) managedInitialBlockchainDownload() error {
 	deadline := time.Now().Add(minIBDWaitTime)
 	numOutboundSynced := 0
 	numOutboundNotSynced := 0
+	headersFirstDone := false
 	for {
+		// Download the heaviest chain offered by the outbound peers using the
+		// headers-first RPCs once there are outbound peers. It only runs once
+		// per IBD, peers which don't support the RPCs, and blocks mined in
+		// the meantime, are handled by the SendBlocks loop below.
+		if !headersFirstDone && hasOutboundPeers(cs.gateway.Peers()) {
+			headersFirstDone = true
+			err := cs.managedHeadersFirstDownload()
+			if errors.Contains(err, threadgroup.ErrStopped) {
+				return err
+			} else if err != nil {
+				cs.log.Printf("WARN: headers-first download failed: %v", err)
+			}
+		}
+
 		numOutboundSynced = 0
 		numOutboundNotSynced = 0
 		for _, p := range cs.gateway.Peers() {
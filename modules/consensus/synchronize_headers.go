@@ -0,0 +1,721 @@
+package consensus
+
+// synchronize_headers.go implements the headers-first initial blockchain
+// download. The headers of the chains of all outbound peers are downloaded,
+// validated and staged on disk first. Chains which aren't heavier than the
+// best chain seen so far are dropped as soon as they are complete. The blocks
+// of the heaviest chain are then downloaded in batches from all peers offering
+// them in parallel. Batches may arrive out of order, so they are staged on
+// disk until all of their predecessors have been applied.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// ibdStagingDir is the directory within the consensus persist directory
+	// which holds the downloaded header chains and the downloaded batches of
+	// blocks that can't be applied yet.
+	ibdStagingDir = "ibd-staging"
+)
+
+var (
+	// errIBDNoSources is returned if a batch of blocks can't be downloaded
+	// because no remaining peer offers it.
+	errIBDNoSources = errors.New("no peer left to download blocks from")
+
+	// errHeadersProtocol is returned if a peer violates the SendHeaders
+	// protocol. Such peers are banned, since they could otherwise stall the
+	// initial blockchain download.
+	errHeadersProtocol = errors.New("peer violated the SendHeaders protocol")
+
+	// errEmptyHeaderBatch is returned if a peer sends an empty batch of
+	// headers while claiming that more headers are available.
+	errEmptyHeaderBatch = errors.New("peer sent an empty batch of headers")
+
+	// errHeadersBeyondHeight is returned if a peer sends more headers than
+	// its reported height allows.
+	errHeadersBeyondHeight = errors.New("peer sent headers beyond its reported height")
+
+	// errUnexpectedBlocks is returned if a peer responds to a SendBatch
+	// request with blocks other than the requested ones.
+	errUnexpectedBlocks = errors.New("peer sent unexpected blocks")
+
+	// headersBanDuration is the duration for which peers violating the
+	// SendHeaders protocol are banned.
+	headersBanDuration = 24 * time.Hour
+
+	// ibdMaxStagedBatches is the maximum number of batches that are downloaded
+	// ahead of the batch which is applied next. It limits the disk space used
+	// for staging.
+	ibdMaxStagedBatches = build.Select(build.Var{
+		Standard: 100,
+		Testnet:  100,
+		Dev:      20,
+		Testing:  4,
+	}).(int)
+
+	// maxCatchUpHeaders is the maximum number of headers sent in a single
+	// batch of the SendHeaders RPC.
+	maxCatchUpHeaders = build.Select(build.Var{
+		Standard: types.BlockHeight(2000),
+		Testnet:  types.BlockHeight(2000),
+		Dev:      types.BlockHeight(500),
+		Testing:  types.BlockHeight(7),
+	}).(types.BlockHeight)
+
+	// sendBatchTimeout is the timeout for the SendBatch RPC.
+	sendBatchTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Testnet:  120 * time.Second,
+		Dev:      40 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// sendHeadersTimeout is the timeout for a single batch of the SendHeaders
+	// RPC.
+	sendHeadersTimeout = build.Select(build.Var{
+		Standard: 120 * time.Second,
+		Testnet:  120 * time.Second,
+		Dev:      40 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// chainSelection picks the heaviest of the header chains downloaded from
+	// the outbound peers. Complete chains which aren't heavier than both the
+	// current path and the best chain are dropped immediately, so at most one
+	// chain is kept besides the ones being downloaded. The peers of dropped
+	// chains remain sources for the headers they share with the best chain.
+	chainSelection struct {
+		best         *headerChain
+		currentDepth types.Target
+
+		// sources maps the peers to the number of headers at the start of
+		// the best chain which their chains contain.
+		sources map[modules.NetAddress]int
+
+		mu sync.Mutex
+	}
+
+	// blockDownload is the state of the parallel download of the blocks of a
+	// header chain.
+	blockDownload struct {
+		// chain is the header chain whose blocks are downloaded in batches
+		// of at most MaxCatchUpBlocks.
+		chain      *headerChain
+		numBatches int
+		stagingDir string
+
+		// pending contains the indices of the batches that haven't been
+		// assigned to a worker yet, inFlight is the number of batches being
+		// downloaded, staged contains the batches which were downloaded but
+		// not applied yet and applied is the number of batches that have been
+		// applied.
+		pending  []int
+		inFlight int
+		staged   map[int]struct{}
+		applied  int
+		workers  int
+		done     bool
+
+		cond *sync.Cond
+		mu   sync.Mutex
+		cs   *ConsensusSet
+	}
+)
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. It works like
+// the SendBlocks RPC, but only sends the headers of the blocks, in batches of
+// up to 'maxCatchUpHeaders'. The current height is sent first and no headers
+// beyond it are sent, which allows the caller to limit the number of batches.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Read a list of blocks known to the requester and find the most recent
+	// block from the current path.
+	var knownBlocks [32]types.BlockID
+	err = modules.ReadPeerObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	reportedHeight := cs.Height()
+	if err := encoding.WriteObject(conn, reportedHeight); err != nil {
+		return err
+	}
+	start, found, err := cs.managedFindStart(knownBlocks)
+	if err != nil {
+		return err
+	}
+	if !found {
+		err = encoding.WriteObject(conn, []types.BlockHeader{})
+		if err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, false)
+	}
+
+	// Send the caller the headers of all of the blocks that they are missing.
+	moreAvailable := true
+	for moreAvailable {
+		err = conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+		if err != nil {
+			return err
+		}
+		var headers []types.BlockHeader
+		cs.mu.RLock()
+		err = cs.db.View(func(tx databaseTx) error {
+			height := blockHeight(tx)
+			if height > reportedHeight {
+				height = reportedHeight
+			}
+			for i := start; i <= height && i < start+maxCatchUpHeaders; i++ {
+				id, err := getPath(tx, i)
+				if err != nil {
+					return err
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return err
+				}
+				headers = append(headers, pb.Block.Header())
+			}
+			moreAvailable = start+maxCatchUpHeaders <= height
+			start += maxCatchUpHeaders
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, headers); err != nil {
+			return err
+		}
+		if err = encoding.WriteObject(conn, moreAvailable); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxExpectedHeight returns the highest height a peer can plausibly report.
+// It is the height expected from the time passed since the genesis block with
+// some slack, since blocks can be found faster than the block frequency.
+func maxExpectedHeight() types.BlockHeight {
+	now := types.CurrentTimestamp()
+	if now < types.GenesisTimestamp {
+		return maxCatchUpHeaders
+	}
+	expected := types.BlockHeight(now-types.GenesisTimestamp) / types.BlockFrequency
+	return expected + expected/4 + maxCatchUpHeaders
+}
+
+// managedReceiveHeaders returns an RPCFunc which is the calling end of the
+// SendHeaders RPC. The received headers are validated and appended to the
+// chain pointed to by hc, which is created and staged at path when the first
+// header is received. The reported height of the peer is capped at
+// maxExpectedHeight. Empty batches announcing more headers and more batches or
+// headers than the reported height allows are protocol violations.
+func (cs *ConsensusSet) managedReceiveHeaders(hc **headerChain, path string) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		// Get blockIDs to send.
+		var history [32]types.BlockID
+		cs.mu.RLock()
+		err := cs.db.View(func(tx databaseTx) error {
+			history = blockHistory(tx)
+			return nil
+		})
+		cs.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, history); err != nil {
+			return err
+		}
+		var reportedHeight types.BlockHeight
+		if err := encoding.ReadObject(conn, &reportedHeight, 8); err != nil {
+			return err
+		}
+		if maxHeight := maxExpectedHeight(); reportedHeight > maxHeight {
+			reportedHeight = maxHeight
+		}
+		maxBatches := reportedHeight/maxCatchUpHeaders + 1
+
+		moreAvailable := true
+		for batches := types.BlockHeight(1); moreAvailable; batches++ {
+			if batches > maxBatches {
+				return errors.Compose(errHeadersProtocol, errHeadersBeyondHeight)
+			}
+			err = conn.SetDeadline(time.Now().Add(sendHeadersTimeout))
+			if err != nil {
+				return err
+			}
+			var headers []types.BlockHeader
+			if err := encoding.ReadObject(conn, &headers, uint64(maxCatchUpHeaders)*types.BlockHeaderSize+8); err != nil {
+				return err
+			}
+			if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
+				return err
+			}
+			if len(headers) == 0 {
+				if moreAvailable {
+					return errors.Compose(errHeadersProtocol, errEmptyHeaderBatch)
+				}
+				continue
+			}
+			if *hc == nil {
+				cs.mu.RLock()
+				err = cs.db.View(func(tx databaseTx) error {
+					*hc, err = cs.newHeaderChain(tx, headers[0].ParentID, path)
+					return err
+				})
+				cs.mu.RUnlock()
+				if err != nil {
+					return err
+				}
+			}
+			for _, h := range headers {
+				if err := (*hc).append(h); err != nil {
+					return err
+				}
+			}
+			if (*hc).tip().height > reportedHeight {
+				return errors.Compose(errHeadersProtocol, errHeadersBeyondHeight)
+			}
+		}
+		if *hc == nil {
+			return nil
+		}
+		return (*hc).flush()
+	}
+}
+
+// rpcSendBatch is the receiving end of the SendBatch RPC. It sends
+// the requested blocks, which don't need to be in the current path.
+func (cs *ConsensusSet) rpcSendBatch(conn modules.PeerConn) error {
+	err := conn.SetDeadline(time.Now().Add(sendBatchTimeout))
+	if err != nil {
+		return err
+	}
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-cs.tg.StopChan():
+		case <-finishedChan:
+		}
+		conn.Close()
+	}()
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Decode the block ids from the connection.
+	var ids []types.BlockID
+	err = modules.ReadPeerObject(conn, &ids, uint64(MaxCatchUpBlocks)*crypto.HashSize+8)
+	if err != nil {
+		return err
+	}
+	if types.BlockHeight(len(ids)) > MaxCatchUpBlocks {
+		return errors.Compose(errors.New("too many blocks requested"), modules.ErrPeerMisbehavior)
+	}
+	// Lookup the corresponding blocks.
+	blocks := make([]types.Block, 0, len(ids))
+	cs.mu.RLock()
+	err = cs.db.View(func(tx databaseTx) error {
+		for _, id := range ids {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, pb.Block)
+		}
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, blocks)
+}
+
+// managedReceiveBatch returns an RPCFunc which is the calling end of the
+// SendBatch RPC. The received blocks are checked against the requested
+// ids and stored in blocks.
+func managedReceiveBatch(ids []types.BlockID, blocks *[]types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		err := conn.SetDeadline(time.Now().Add(sendBatchTimeout))
+		if err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, ids); err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, blocks, uint64(len(ids))*types.BlockSizeLimit+8); err != nil {
+			return err
+		}
+		if len(*blocks) != len(ids) {
+			return errUnexpectedBlocks
+		}
+		for i := range ids {
+			if (*blocks)[i].ID() != ids[i] {
+				return errUnexpectedBlocks
+			}
+		}
+		return nil
+	}
+}
+
+// managedHeadersFirstDownload downloads the headers of the chains of the
+// outbound peers, picks the heaviest valid chain and downloads its blocks in
+// parallel from all peers offering them. Peers which don't support the
+// headers-first RPCs are ignored, they are synchronized with by the SendBlocks
+// loop of managedInitialBlockchainDownload.
+func (cs *ConsensusSet) managedHeadersFirstDownload() (err error) {
+	err = cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	// Only chains which are heavier than the current path are downloaded.
+	current := cs.managedCurrentBlock()
+	s := &chainSelection{
+		sources: make(map[modules.NetAddress]int),
+	}
+	cs.mu.RLock()
+	err = cs.db.View(func(tx databaseTx) error {
+		pb, err := getBlockMap(tx, current.ID())
+		if err != nil {
+			return err
+		}
+		s.currentDepth = pb.Depth
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	stagingDir := filepath.Join(cs.persistDir, ibdStagingDir)
+	if err := os.MkdirAll(stagingDir, 0700); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, os.RemoveAll(stagingDir))
+	}()
+
+	// Download the header chains of all outbound peers in parallel.
+	var wg sync.WaitGroup
+	for i, p := range cs.gateway.Peers() {
+		if p.Inbound {
+			continue
+		}
+		wg.Add(1)
+		go func(addr modules.NetAddress, path string) {
+			defer wg.Done()
+			var hc *headerChain
+			err := cs.gateway.RPC(addr, "SendHeaders", cs.managedReceiveHeaders(&hc, path))
+			if err != nil && hc != nil {
+				err = errors.Compose(err, hc.close())
+			}
+			if errors.Contains(err, errHeadersProtocol) {
+				cs.log.Printf("WARN: banning peer %v: %v", addr, err)
+				if err := cs.gateway.Ban(addr.Host(), headersBanDuration, err.Error()); err != nil {
+					cs.log.Printf("WARN: unable to ban peer %v: %v", addr, err)
+				}
+				return
+			} else if err != nil {
+				cs.log.Debugf("WARN: failed to download headers from %v: %v", addr, err)
+				return
+			}
+			if hc == nil {
+				return
+			}
+			if err := s.managedAdd(addr, hc); err != nil {
+				cs.log.Printf("WARN: failed to select the header chain of %v: %v", addr, err)
+			}
+		}(p.NetAddress, filepath.Join(stagingDir, fmt.Sprintf("%d.headers", i)))
+	}
+	wg.Wait()
+	if s.best == nil {
+		return nil
+	}
+	defer func() {
+		err = errors.Compose(err, s.best.close())
+	}()
+
+	// Download the blocks of the chain from all peers whose chain contains at
+	// least some of them.
+	d := &blockDownload{
+		chain:      s.best,
+		stagingDir: stagingDir,
+		staged:     make(map[int]struct{}),
+		cs:         cs,
+	}
+	d.cond = sync.NewCond(&d.mu)
+	d.numBatches = (s.best.length + int(MaxCatchUpBlocks) - 1) / int(MaxCatchUpBlocks)
+	for i := 0; i < d.numBatches; i++ {
+		d.pending = append(d.pending, i)
+	}
+	cs.log.Printf("INFO: downloading %v blocks from %v peers", s.best.length, len(s.sources))
+	return d.run(s.sources)
+}
+
+// managedAdd adds the complete header chain of a peer to the selection. The
+// chain becomes the best chain if it is heavier than both the current path
+// and the previous best chain. Otherwise it is dropped and the peer is only
+// used as a source for the headers it shares with the best chain.
+func (s *chainSelection) managedAdd(addr modules.NetAddress, hc *headerChain) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	depth := hc.tip().depth
+	if depth.Cmp(s.currentDepth) >= 0 || (s.best != nil && depth.Cmp(s.best.tip().depth) >= 0) {
+		if s.best != nil {
+			shared, err := sharedHeaders(hc, s.best)
+			if err != nil {
+				return errors.Compose(err, hc.close())
+			}
+			if shared > 0 {
+				s.sources[addr] = shared
+			}
+		}
+		return hc.close()
+	}
+
+	// The peers sharing a number of headers with the previous best chain
+	// share at least as many with the new best chain, up to the number of
+	// headers both best chains share.
+	old := s.best
+	s.best = hc
+	s.sources[addr] = hc.length
+	if old == nil {
+		return nil
+	}
+	shared, err := sharedHeaders(old, hc)
+	if err != nil {
+		shared = 0
+	}
+	for a, n := range s.sources {
+		if a == addr || n <= shared {
+			continue
+		}
+		if shared == 0 {
+			delete(s.sources, a)
+		} else {
+			s.sources[a] = shared
+		}
+	}
+	return errors.Compose(err, old.close())
+}
+
+// run downloads the blocks using one worker per peer and applies them in
+// order. sources maps the peers to the number of headers at the start of the
+// chain which they offer.
+func (d *blockDownload) run(sources map[modules.NetAddress]int) error {
+	var wg sync.WaitGroup
+	d.mu.Lock()
+	for addr, shared := range sources {
+		d.workers++
+		wg.Add(1)
+		go func(addr modules.NetAddress, shared int) {
+			defer wg.Done()
+			d.threadedDownload(addr, shared)
+		}(addr, shared)
+	}
+	d.mu.Unlock()
+
+	// Wake up waiting workers when the consensus set is stopped.
+	finishedChan := make(chan struct{})
+	defer close(finishedChan)
+	go func() {
+		select {
+		case <-d.cs.tg.StopChan():
+		case <-finishedChan:
+			return
+		}
+		d.mu.Lock()
+		d.done = true
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}()
+
+	err := d.managedApply()
+
+	// Stop the workers. The staged batches are removed by the caller.
+	d.mu.Lock()
+	d.done = true
+	d.cond.Broadcast()
+	d.mu.Unlock()
+	wg.Wait()
+	return err
+}
+
+// managedApply applies the downloaded batches in order.
+func (d *blockDownload) managedApply() error {
+	for i := 0; i < d.numBatches; i++ {
+		d.mu.Lock()
+		for {
+			if _, staged := d.staged[i]; staged || d.workers == 0 {
+				break
+			}
+			d.cond.Wait()
+		}
+		_, staged := d.staged[i]
+		d.mu.Unlock()
+		if !staged {
+			return errIBDNoSources
+		}
+
+		b, err := ioutil.ReadFile(d.batchPath(i))
+		if err != nil {
+			return err
+		}
+		var blocks []types.Block
+		if err := encoding.Unmarshal(b, &blocks); err != nil {
+			return err
+		}
+		_, err = d.cs.managedAcceptBlocks(blocks)
+		if err != nil && !errors.Contains(err, modules.ErrNonExtendingBlock) && !errors.Contains(err, modules.ErrBlockKnown) {
+			return err
+		}
+		if err := os.Remove(d.batchPath(i)); err != nil {
+			return err
+		}
+
+		d.mu.Lock()
+		delete(d.staged, i)
+		d.applied++
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// threadedDownload downloads batches of blocks from a peer until all batches
+// have been downloaded. If a download fails, the batch is returned to the
+// pending batches and the peer isn't used anymore.
+func (d *blockDownload) threadedDownload(addr modules.NetAddress, shared int) {
+	defer func() {
+		d.mu.Lock()
+		d.workers--
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}()
+	for {
+		i, ok := d.managedNextBatch(shared)
+		if !ok {
+			return
+		}
+		var blocks []types.Block
+		ids, err := d.batchIDs(i)
+		if err == nil {
+			err = d.cs.gateway.RPC(addr, "SendBatch", managedReceiveBatch(ids, &blocks))
+		}
+		if err == nil {
+			err = ioutil.WriteFile(d.batchPath(i), encoding.Marshal(blocks), 0600)
+		}
+		d.mu.Lock()
+		d.inFlight--
+		if err != nil {
+			d.cs.log.Debugf("WARN: failed to download blocks from %v: %v", addr, err)
+			d.pending = append(d.pending, i)
+			sort.Ints(d.pending)
+			d.cond.Broadcast()
+			d.mu.Unlock()
+			return
+		}
+		d.staged[i] = struct{}{}
+		d.cond.Broadcast()
+		d.mu.Unlock()
+	}
+}
+
+// managedNextBatch assigns the next pending batch within the given number of
+// shared headers to a worker. It blocks while the staging window is full and
+// returns false once there are no more batches which the worker can download.
+func (d *blockDownload) managedNextBatch(shared int) (int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for {
+		if d.done {
+			return 0, false
+		}
+		available := false
+		for j, i := range d.pending {
+			start, n := d.batchRange(i)
+			if start+n > shared {
+				continue
+			}
+			available = true
+			if i >= d.applied+ibdMaxStagedBatches {
+				break
+			}
+			d.pending = append(d.pending[:j], d.pending[j+1:]...)
+			d.inFlight++
+			return i, true
+		}
+		// Batches which are being downloaded by other workers might be
+		// returned to the pending batches, so only stop if there are none.
+		if !available && d.inFlight == 0 {
+			return 0, false
+		}
+		d.cond.Wait()
+	}
+}
+
+// batchIDs returns the ids of the blocks of a batch.
+func (d *blockDownload) batchIDs(i int) ([]types.BlockID, error) {
+	start, n := d.batchRange(i)
+	return d.chain.ids(start, n)
+}
+
+// batchRange returns the index of the first header of a batch within the
+// chain and the number of headers of the batch.
+func (d *blockDownload) batchRange(i int) (start, n int) {
+	start = i * int(MaxCatchUpBlocks)
+	n = d.chain.length - start
+	if n > int(MaxCatchUpBlocks) {
+		n = int(MaxCatchUpBlocks)
+	}
+	return start, n
+}
+
+// batchPath returns the path of the staging file of a batch.
+func (d *blockDownload) batchPath(i int) string {
+	return filepath.Join(d.stagingDir, fmt.Sprintf("%d.blocks", i))
+}
@@ -0,0 +1,253 @@
+package consensus
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/gateway"
+	"go.sia.tech/siad/types"
+)
+
+// TestHeadersFirstDownload tests that the blocks of the heaviest chain offered
+// by the outbound peers are downloaded from all peers offering them.
+func TestHeadersFirstDownload(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	local, err := blankConsensusSetTester(t.Name()+"-local", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	remote, err := blankConsensusSetTester(t.Name()+"-remote", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remote.Close()
+	partial, err := blankConsensusSetTester(t.Name()+"-partial", modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer partial.Close()
+
+	// Connect the local peer to the other peers before mining, so that the
+	// blocks aren't exchanged by the OnConnect RPCs.
+	if err := local.gateway.Connect(remote.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.gateway.Connect(partial.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Mine a chain on the remote peer which spans many batches. The partial
+	// peer only has the first half of the chain and the local peer has a
+	// shorter fork.
+	numBlocks := 4 * types.BlockHeight(ibdMaxStagedBatches) * MaxCatchUpBlocks
+	for i := types.BlockHeight(0); i < numBlocks; i++ {
+		b, err := remote.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := remote.cs.managedAcceptBlocks([]types.Block{b}); err != nil {
+			t.Fatal(err)
+		}
+		if i < numBlocks/2 {
+			if _, err := partial.cs.managedAcceptBlocks([]types.Block{b}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		b, err := local.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := local.cs.managedAcceptBlocks([]types.Block{b}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := local.cs.managedHeadersFirstDownload(); err != nil {
+		t.Fatal(err)
+	}
+	if local.cs.CurrentBlock().ID() != remote.cs.CurrentBlock().ID() {
+		t.Fatalf("local peer is at height %v, expected %v", local.cs.Height(), remote.cs.Height())
+	}
+	if local.cs.dbConsensusChecksum() != remote.cs.dbConsensusChecksum() {
+		t.Fatal("consensus sets don't match")
+	}
+	if _, err := os.Stat(filepath.Join(local.cs.persistDir, ibdStagingDir)); !os.IsNotExist(err) {
+		t.Fatal("staging directory wasn't removed", err)
+	}
+
+	// Downloading again shouldn't change anything.
+	if err := local.cs.managedHeadersFirstDownload(); err != nil {
+		t.Fatal(err)
+	}
+	if local.cs.CurrentBlock().ID() != remote.cs.CurrentBlock().ID() {
+		t.Fatal("local peer changed its current block")
+	}
+}
+
+// TestHeadersFirstDownloadEmptyBatches tests that a peer which keeps sending
+// empty batches of headers while announcing more headers doesn't stall the
+// download and is banned.
+func TestHeadersFirstDownloadEmptyBatches(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	local, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	// Create a peer which sends empty batches until the connection is closed.
+	g, err := gateway.New("localhost:0", false, build.TempDir(modules.ConsensusDir, t.Name(), "evil"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	g.RegisterRPC("SendHeaders", func(conn modules.PeerConn) error {
+		var knownBlocks [32]types.BlockID
+		if err := encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, types.BlockHeight(1e6)); err != nil {
+			return err
+		}
+		for {
+			if err := encoding.WriteObject(conn, []types.BlockHeader{}); err != nil {
+				return err
+			}
+			if err := encoding.WriteObject(conn, true); err != nil {
+				return err
+			}
+		}
+	})
+	if err := local.gateway.Connect(g.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- local.cs.managedHeadersFirstDownload()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("download didn't finish")
+	}
+	bans := local.gateway.Bans()
+	if len(bans) != 1 {
+		t.Fatal("peer wasn't banned", bans)
+	}
+}
+
+// TestChainSelection tests that complete header chains which aren't heavier
+// than the best chain are dropped and that their peers remain sources for the
+// headers they share with the best chain.
+func TestChainSelection(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// newChain creates a chain containing the first n blocks of the current
+	// path.
+	var genesisDepth types.Target
+	newChain := func(name string, n types.BlockHeight) *headerChain {
+		var hc *headerChain
+		err := cst.cs.db.View(func(tx databaseTx) (err error) {
+			genesis, err := getBlockMap(tx, types.GenesisID)
+			if err != nil {
+				return err
+			}
+			genesisDepth = genesis.Depth
+			hc, err = cst.cs.newHeaderChain(tx, types.GenesisID, filepath.Join(cst.cs.persistDir, name))
+			if err != nil {
+				return err
+			}
+			for height := types.BlockHeight(1); height <= n; height++ {
+				id, err := getPath(tx, height)
+				if err != nil {
+					return err
+				}
+				pb, err := getBlockMap(tx, id)
+				if err != nil {
+					return err
+				}
+				if err := hc.append(pb.Block.Header()); err != nil {
+					return err
+				}
+			}
+			return hc.flush()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hc
+	}
+	height := cst.cs.Height()
+	half := newChain("half", height/2)
+	full := newChain("full", height)
+	equal := newChain("equal", height)
+
+	s := &chainSelection{
+		currentDepth: genesisDepth,
+		sources:      make(map[modules.NetAddress]int),
+	}
+	if err := s.managedAdd("half", half); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.managedAdd("full", full); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.managedAdd("equal", equal); err != nil {
+		t.Fatal(err)
+	}
+	if s.best != full {
+		t.Fatal("the heaviest chain wasn't selected")
+	}
+	expected := map[modules.NetAddress]int{
+		"half":  int(height / 2),
+		"full":  int(height),
+		"equal": int(height),
+	}
+	for addr, n := range expected {
+		if s.sources[addr] != n {
+			t.Fatalf("expected %v to share %v headers, got %v", addr, n, s.sources[addr])
+		}
+	}
+	// Only the staged headers of the best chain should be left.
+	for _, name := range []string{"half", "equal"} {
+		if _, err := os.Stat(filepath.Join(cst.cs.persistDir, name)); !os.IsNotExist(err) {
+			t.Fatalf("staged headers of %v weren't removed: %v", name, err)
+		}
+	}
+	if err := full.close(); err != nil {
+		t.Fatal(err)
+	}
+}
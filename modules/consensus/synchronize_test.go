@@ -10,7 +10,6 @@ import (
 	"testing"
 	"time"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -143,7 +142,7 @@ func TestBlockHistory(t *testing.T) {
 	}
 
 	var history [32]types.BlockID
-	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+	_ = cst.cs.db.View(func(tx databaseTx) error {
 		history = blockHistory(tx)
 		return nil
 	})
@@ -632,7 +631,7 @@ func TestRPCSendBlockSendsOnlyNecessaryBlocks(t *testing.T) {
 		// Get blockIDs to send.
 		var history [32]types.BlockID
 		cs.mu.RLock()
-		err := cs.db.View(func(tx *bolt.Tx) error {
+		err := cs.db.View(func(tx databaseTx) error {
 			history = blockHistory(tx)
 			return nil
 		})
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"math/big"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -30,7 +29,7 @@ var (
 
 // validSiacoins checks that the siacoin inputs and outputs are valid in the
 // context of the current consensus set.
-func validSiacoins(tx *bolt.Tx, t types.Transaction) error {
+func validSiacoins(tx databaseTx, t types.Transaction) error {
 	scoBucket := tx.Bucket(SiacoinOutputs)
 	var inputSum types.Currency
 	for _, sci := range t.SiacoinInputs {
@@ -60,7 +59,7 @@ func validSiacoins(tx *bolt.Tx, t types.Transaction) error {
 
 // storageProofSegment returns the index of the segment that needs to be proven
 // exists in a file contract.
-func storageProofSegment(tx *bolt.Tx, fcid types.FileContractID) (uint64, error) {
+func storageProofSegment(tx databaseTx, fcid types.FileContractID) (uint64, error) {
 	// Check that the parent file contract exists.
 	fcBucket := tx.Bucket(FileContracts)
 	fcBytes := fcBucket.Get(fcid[:])
@@ -108,7 +107,7 @@ func storageProofSegment(tx *bolt.Tx, fcid types.FileContractID) (uint64, error)
 // zero. A hardfork was added triggering at block 100,000 to enable an
 // optimization where hosts could submit empty storage proofs for files of size
 // 0, saving space on the blockchain in conditions where the renter is content.
-func validStorageProofs100e3(tx *bolt.Tx, t types.Transaction) error {
+func validStorageProofs100e3(tx databaseTx, t types.Transaction) error {
 	for _, sp := range t.StorageProofs {
 		// Check that the storage proof itself is valid.
 		segmentIndex, err := storageProofSegment(tx, sp.ParentID)
@@ -164,7 +163,7 @@ func validStorageProofs100e3(tx *bolt.Tx, t types.Transaction) error {
 
 // validStorageProofs checks that the storage proofs are valid in the context
 // of the consensus set.
-func validStorageProofs(tx *bolt.Tx, t types.Transaction) error {
+func validStorageProofs(tx databaseTx, t types.Transaction) error {
 	height := blockHeight(tx)
 	if height < types.StorageProofHardforkHeight {
 		return validStorageProofs100e3(tx, t)
@@ -210,7 +209,7 @@ func validStorageProofs(tx *bolt.Tx, t types.Transaction) error {
 
 // validFileContractRevision checks that each file contract revision is valid
 // in the context of the current consensus set.
-func validFileContractRevisions(tx *bolt.Tx, t types.Transaction) error {
+func validFileContractRevisions(tx databaseTx, t types.Transaction) error {
 	for _, fcr := range t.FileContractRevisions {
 		fc, err := getFileContract(tx, fcr.ParentID)
 		if err != nil {
@@ -254,7 +253,7 @@ func validFileContractRevisions(tx *bolt.Tx, t types.Transaction) error {
 
 // validSiafunds checks that the siafund portions of the transaction are valid
 // in the context of the consensus set.
-func validSiafunds(tx *bolt.Tx, t types.Transaction) (err error) {
+func validSiafunds(tx databaseTx, t types.Transaction) (err error) {
 	// Compare the number of input siafunds to the output siafunds.
 	var siafundInputSum types.Currency
 	var siafundOutputSum types.Currency
@@ -283,7 +282,7 @@ func validSiafunds(tx *bolt.Tx, t types.Transaction) (err error) {
 // validArbitraryData checks that the ArbitraryData portions of the transaction are
 // valid in the context of the consensus set. Currently, only ArbitraryData with
 // the types.SpecifierFoundation prefix is examined.
-func validArbitraryData(tx *bolt.Tx, t types.Transaction, currentHeight types.BlockHeight) error {
+func validArbitraryData(tx databaseTx, t types.Transaction, currentHeight types.BlockHeight) error {
 	if currentHeight < types.FoundationHardforkHeight {
 		return nil
 	}
@@ -305,7 +304,7 @@ func validArbitraryData(tx *bolt.Tx, t types.Transaction, currentHeight types.Bl
 // This function does not actually validate the signature. By the time
 // foundationUpdateIsSigned is called, all of the transaction's signatures have
 // already been validated by StandaloneValid.
-func foundationUpdateIsSigned(tx *bolt.Tx, t types.Transaction) bool {
+func foundationUpdateIsSigned(tx databaseTx, t types.Transaction) bool {
 	primary, failsafe := getFoundationUnlockHashes(tx)
 	for _, sci := range t.SiacoinInputs {
 		// NOTE: this conditional is split up to better visualize test coverage
@@ -326,7 +325,7 @@ func foundationUpdateIsSigned(tx *bolt.Tx, t types.Transaction) bool {
 
 // validTransaction checks that all fields are valid within the current
 // consensus state. If not an error is returned.
-func validTransaction(tx *bolt.Tx, t types.Transaction) error {
+func validTransaction(tx databaseTx, t types.Transaction) error {
 	// StandaloneValid will check things like signatures and properties that
 	// should be inherent to the transaction. (storage proof rules, etc.)
 	currentHeight := blockHeight(tx)
@@ -378,7 +377,7 @@ func (cs *ConsensusSet) tryTransactionSet(txns []types.Transaction) (modules.Con
 	// manually manage the tx instead of using 'Update', but that has safety
 	// concerns and is more difficult to implement correctly.
 	errSuccess := errors.New("success")
-	err := cs.db.Update(func(tx *bolt.Tx) error {
+	err := cs.db.Update(func(tx databaseTx) error {
 		diffHolder.Height = blockHeight(tx)
 		for _, txn := range txns {
 			err := validTransaction(tx, txn)
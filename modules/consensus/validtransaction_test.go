@@ -3,7 +3,6 @@ package consensus
 import (
 	"testing"
 
-	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -364,7 +363,7 @@ func TestValidSiacoins(t *testing.T) {
 	txn := types.Transaction{
 		SiacoinInputs: []types.SiacoinInput{{}},
 	}
-	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+	err = cst.cs.db.View(func(tx databaseTx) error {
 		err := validSiacoins(tx, txn)
 		if !errors.Contains(err, errMissingSiacoinOutput) {
 			t.Fatal(err)
@@ -385,7 +384,7 @@ func TestValidSiacoins(t *testing.T) {
 			ParentID: scoid,
 		}},
 	}
-	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+	err = cst.cs.db.View(func(tx databaseTx) error {
 		err := validSiacoins(tx, txn)
 		if !errors.Contains(err, errWrongUnlockConditions) {
 			t.Fatal(err)
@@ -402,7 +401,7 @@ func TestValidSiacoins(t *testing.T) {
 			Value: types.NewCurrency64(1),
 		}},
 	}
-	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+	err = cst.cs.db.View(func(tx databaseTx) error {
 		err := validSiacoins(tx, txn)
 		if !errors.Contains(err, errSiacoinInputOutputMismatch) {
 			t.Fatal(err)
@@ -908,7 +907,7 @@ func TestValidArbitraryData(t *testing.T) {
 	}()
 
 	validate := func(t types.Transaction, height types.BlockHeight) error {
-		return cst.cs.db.View(func(tx *bolt.Tx) error {
+		return cst.cs.db.View(func(tx databaseTx) error {
 			return validArbitraryData(tx, t, height)
 		})
 	}
@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"errors"
+
 	"go.sia.tech/siad/types"
 )
 
@@ -10,7 +12,23 @@ const (
 	ExplorerDir = "explorer"
 )
 
+var (
+	// ErrExplorerIndexDisabled is returned when requesting information from
+	// the explorer which requires the output index, while the index is
+	// disabled.
+	ErrExplorerIndexDisabled = errors.New("the explorer output index is disabled")
+)
+
 type (
+	// ExplorerBalance is the balance of the unspent outputs of an unlock
+	// hash.
+	ExplorerBalance struct {
+		Siacoins              types.Currency `json:"siacoins"`
+		Siafunds              types.Currency `json:"siafunds"`
+		UnspentSiacoinOutputs uint64         `json:"unspentsiacoinoutputs"`
+		UnspentSiafundOutputs uint64         `json:"unspentsiafundoutputs"`
+	}
+
 	// BlockFacts returns a bunch of statistics about the consensus set as they
 	// were at a specific block.
 	BlockFacts struct {
@@ -73,10 +91,24 @@ type (
 		// provided unlock hash.
 		UnlockHash(types.UnlockHash) []types.TransactionID
 
+		// UnlockHashTransactions returns the ids of the transactions
+		// associated with the provided unlock hash, newest first, skipping
+		// the first offset transactions and returning at most limit
+		// transactions. The total number of transactions is returned as well.
+		UnlockHashTransactions(uh types.UnlockHash, offset, limit uint64) ([]types.TransactionID, uint64)
+
+		// UnlockHashBalance returns the balance of the unspent outputs of the
+		// provided unlock hash. It requires the output index.
+		UnlockHashBalance(types.UnlockHash) (ExplorerBalance, error)
+
 		// SiacoinOutput will return the siacoin output associated with the
 		// input id.
 		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
 
+		// SiacoinOutputSpent returns whether the siacoin output associated
+		// with the input id has been spent. It requires the output index.
+		SiacoinOutputSpent(types.SiacoinOutputID) (bool, error)
+
 		// SiacoinOutputID returns all of the transaction ids associated with
 		// the provided siacoin output id.
 		SiacoinOutputID(types.SiacoinOutputID) []types.TransactionID
@@ -101,6 +133,10 @@ type (
 		// the provided siafund output id.
 		SiafundOutputID(types.SiafundOutputID) []types.TransactionID
 
+		// SiafundOutputSpent returns whether the siafund output associated
+		// with the input id has been spent. It requires the output index.
+		SiafundOutputSpent(types.SiafundOutputID) (bool, error)
+
 		Close() error
 	}
 )
@@ -25,11 +25,17 @@ var (
 	bucketSiafundOutputs   = []byte("SiafundOutputs")
 	bucketTransactionIDs   = []byte("TransactionIDs")
 	bucketUnlockHashes     = []byte("UnlockHashes")
+	// bucketUnspentSiacoinOutputs and bucketUnspentSiafundOutputs contain a
+	// bucket of unspent outputs for each unlock hash. They are only
+	// maintained if the output index is enabled.
+	bucketUnspentSiacoinOutputs = []byte("UnspentSiacoinOutputs")
+	bucketUnspentSiafundOutputs = []byte("UnspentSiafundOutputs")
 
 	errNotExist = errors.New("entry does not exist")
 
 	// keys for bucketInternal
 	internalBlockHeight  = []byte("BlockHeight")
+	internalOutputIndex  = []byte("OutputIndex")
 	internalRecentChange = []byte("RecentChange")
 )
 
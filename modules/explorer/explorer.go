@@ -47,12 +47,23 @@ type (
 		cs         modules.ConsensusSet
 		db         *persist.BoltDatabase
 		persistDir string
+
+		// indexOutputs indicates whether the unspent outputs of each unlock
+		// hash are tracked, which is required to look up balances.
+		indexOutputs bool
 	}
 )
 
 // New creates the internal data structures, and subscribes to
 // consensus for changes to the blockchain
 func New(cs modules.ConsensusSet, persistDir string) (*Explorer, error) {
+	return NewCustomExplorer(cs, persistDir, false)
+}
+
+// NewCustomExplorer creates an explorer which maintains the output index if
+// indexOutputs is true. Enabling the index for an existing explorer rebuilds
+// its database.
+func NewCustomExplorer(cs modules.ConsensusSet, persistDir string, indexOutputs bool) (*Explorer, error) {
 	// Check that input modules are non-nil
 	if cs == nil {
 		return nil, errNilCS
@@ -60,8 +71,9 @@ func New(cs modules.ConsensusSet, persistDir string) (*Explorer, error) {
 
 	// Initialize the explorer.
 	e := &Explorer{
-		cs:         cs,
-		persistDir: persistDir,
+		cs:           cs,
+		persistDir:   persistDir,
+		indexOutputs: indexOutputs,
 	}
 
 	// Initialize the persistent structures, including the database.
@@ -69,6 +81,10 @@ func New(cs modules.ConsensusSet, persistDir string) (*Explorer, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = e.initOutputIndex()
+	if err != nil {
+		return nil, err
+	}
 
 	// retrieve the current ConsensusChangeID
 	var recentChange modules.ConsensusChangeID
@@ -32,8 +32,14 @@ type explorerTester struct {
 
 // createExplorerTester creates a tester object for the explorer module.
 func createExplorerTester(name string) (*explorerTester, error) {
+	return createCustomExplorerTester(name, false)
+}
+
+// createCustomExplorerTester creates a tester object for the explorer module
+// that optionally indexes unspent outputs.
+func createCustomExplorerTester(name string, indexOutputs bool) (*explorerTester, error) {
 	if testing.Short() {
-		panic("createExplorerTester called when in a short test")
+		panic("createCustomExplorerTester called when in a short test")
 	}
 
 	// Create and assemble the dependencies.
@@ -67,7 +73,7 @@ func createExplorerTester(name string) (*explorerTester, error) {
 	if err != nil {
 		return nil, err
 	}
-	e, err := New(cs, filepath.Join(testdir, modules.ExplorerDir))
+	e, err := NewCustomExplorer(cs, filepath.Join(testdir, modules.ExplorerDir), indexOutputs)
 	if err != nil {
 		return nil, err
 	}
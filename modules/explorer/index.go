@@ -0,0 +1,141 @@
+package explorer
+
+import (
+	"encoding/binary"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// unlockHashKey returns the key of a transaction in the bucket of an unlock
+// hash. The key is prefixed with the big-endian height of the transaction, so
+// that the transactions of an unlock hash are sorted by height.
+func unlockHashKey(height types.BlockHeight, txid types.TransactionID) []byte {
+	key := make([]byte, 8+crypto.HashSize)
+	binary.BigEndian.PutUint64(key, uint64(height))
+	copy(key[8:], txid[:])
+	return key
+}
+
+// dbGetUnlockHashTransactions returns a 'func(*bolt.Tx) error' that decodes a
+// page of the transaction ids of an unlock hash, newest first, and the total
+// number of transactions. If the unlock hash doesn't exist, no ids are
+// returned.
+func dbGetUnlockHashTransactions(uh types.UnlockHash, offset, limit uint64, ids *[]types.TransactionID, total *uint64) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketUnlockHashes).Bucket(encoding.Marshal(uh))
+		if b == nil {
+			return nil
+		}
+		*total = uint64(b.Stats().KeyN)
+		c := b.Cursor()
+		var n uint64
+		for k, _ := c.Last(); k != nil && uint64(len(*ids)) < limit; k, _ = c.Prev() {
+			if n < offset {
+				n++
+				continue
+			}
+			var txid types.TransactionID
+			copy(txid[:], k[8:])
+			*ids = append(*ids, txid)
+		}
+		return nil
+	}
+}
+
+// dbGetUnspentOutputs returns a 'func(*bolt.Tx) error' that sums the values of
+// the unspent outputs of an unlock hash in the provided bucket.
+func dbGetUnspentOutputs(bucket []byte, uh types.UnlockHash, value *types.Currency, count *uint64) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket(encoding.Marshal(uh))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, v []byte) error {
+			var c types.Currency
+			if err := encoding.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			*value = value.Add(c)
+			*count++
+			return nil
+		})
+	}
+}
+
+// dbIsUnspent returns a 'func(*bolt.Tx) error' that checks whether an output
+// of an unlock hash is in the provided bucket of unspent outputs.
+func dbIsUnspent(bucket []byte, uh types.UnlockHash, id interface{}, unspent *bool) func(*bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket).Bucket(encoding.Marshal(uh))
+		*unspent = b != nil && b.Get(encoding.Marshal(id)) != nil
+		return nil
+	}
+}
+
+// UnlockHashTransactions returns a page of the IDs of the transactions that
+// contain the unlock hash, newest first, and the total number of transactions.
+func (e *Explorer) UnlockHashTransactions(uh types.UnlockHash, offset, limit uint64) ([]types.TransactionID, uint64) {
+	var ids []types.TransactionID
+	var total uint64
+	err := e.db.View(dbGetUnlockHashTransactions(uh, offset, limit, &ids, &total))
+	if err != nil {
+		return nil, 0
+	}
+	return ids, total
+}
+
+// UnlockHashBalance returns the balance of the unspent outputs of the unlock
+// hash.
+func (e *Explorer) UnlockHashBalance(uh types.UnlockHash) (modules.ExplorerBalance, error) {
+	if !e.indexOutputs {
+		return modules.ExplorerBalance{}, modules.ErrExplorerIndexDisabled
+	}
+	var balance modules.ExplorerBalance
+	err := e.db.View(func(tx *bolt.Tx) error {
+		err := dbGetUnspentOutputs(bucketUnspentSiacoinOutputs, uh, &balance.Siacoins, &balance.UnspentSiacoinOutputs)(tx)
+		if err != nil {
+			return err
+		}
+		return dbGetUnspentOutputs(bucketUnspentSiafundOutputs, uh, &balance.Siafunds, &balance.UnspentSiafundOutputs)(tx)
+	})
+	return balance, err
+}
+
+// SiacoinOutputSpent returns whether the siacoin output with the specified ID
+// has been spent.
+func (e *Explorer) SiacoinOutputSpent(id types.SiacoinOutputID) (bool, error) {
+	if !e.indexOutputs {
+		return false, modules.ErrExplorerIndexDisabled
+	}
+	var unspent bool
+	err := e.db.View(func(tx *bolt.Tx) error {
+		var sco types.SiacoinOutput
+		if err := dbGetAndDecode(bucketSiacoinOutputs, id, &sco)(tx); err != nil {
+			return err
+		}
+		return dbIsUnspent(bucketUnspentSiacoinOutputs, sco.UnlockHash, id, &unspent)(tx)
+	})
+	return !unspent, err
+}
+
+// SiafundOutputSpent returns whether the siafund output with the specified ID
+// has been spent.
+func (e *Explorer) SiafundOutputSpent(id types.SiafundOutputID) (bool, error) {
+	if !e.indexOutputs {
+		return false, modules.ErrExplorerIndexDisabled
+	}
+	var unspent bool
+	err := e.db.View(func(tx *bolt.Tx) error {
+		var sfo types.SiafundOutput
+		if err := dbGetAndDecode(bucketSiafundOutputs, id, &sfo)(tx); err != nil {
+			return err
+		}
+		return dbIsUnspent(bucketUnspentSiafundOutputs, sfo.UnlockHash, id, &unspent)(tx)
+	})
+	return !unspent, err
+}
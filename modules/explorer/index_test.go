@@ -0,0 +1,122 @@
+package explorer
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestExplorerIndexDisabled checks that the balance and spent status of
+// outputs are unavailable when the explorer doesn't index outputs.
+func TestExplorerIndexDisabled(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createExplorerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := et.explorer.UnlockHashBalance(types.UnlockHash{}); !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		t.Fatal("expected ErrExplorerIndexDisabled, got", err)
+	}
+	if _, err := et.explorer.SiacoinOutputSpent(types.SiacoinOutputID{}); !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		t.Fatal("expected ErrExplorerIndexDisabled, got", err)
+	}
+	if _, err := et.explorer.SiafundOutputSpent(types.SiafundOutputID{}); !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		t.Fatal("expected ErrExplorerIndexDisabled, got", err)
+	}
+}
+
+// TestExplorerIndex checks that the explorer tracks the balances and
+// transactions of an address as outputs are created and spent.
+func TestExplorerIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	et, err := createCustomExplorerTester(t.Name(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The siafunds of the genesis block should be indexed.
+	var genesisFunds types.Currency
+	for _, sfo := range types.GenesisBlock.Transactions[0].SiafundOutputs {
+		genesisFunds = genesisFunds.Add(sfo.Value)
+	}
+	sfoUH := types.GenesisBlock.Transactions[0].SiafundOutputs[0].UnlockHash
+	balance, err := et.explorer.UnlockHashBalance(sfoUH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Siafunds.IsZero() || balance.Siafunds.Cmp(genesisFunds) > 0 {
+		t.Fatal("wrong genesis siafund balance", balance.Siafunds)
+	}
+
+	// Send several payments to a new address, mining a block after each.
+	uc, err := et.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uh := uc.UnlockHash()
+	amount := types.SiacoinPrecision.Mul64(100)
+	var txns []types.Transaction
+	for i := 0; i < 3; i++ {
+		sent, err := et.wallet.SendSiacoins(amount, uh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txns = append(txns, sent[len(sent)-1])
+		b, _ := et.miner.FindBlock()
+		if err := et.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	balance, err = et.explorer.UnlockHashBalance(uh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Siacoins.Equals(amount.Mul64(3)) || balance.UnspentSiacoinOutputs != 3 {
+		t.Fatalf("wrong balance: %v siacoins in %v outputs", balance.Siacoins, balance.UnspentSiacoinOutputs)
+	}
+
+	// The transactions should be paginated newest first.
+	ids, total := et.explorer.UnlockHashTransactions(uh, 0, 2)
+	if total != 3 || len(ids) != 2 {
+		t.Fatalf("expected 2 of 3 transactions, got %v of %v", len(ids), total)
+	}
+	if ids[0] != txns[2].ID() || ids[1] != txns[1].ID() {
+		t.Fatal("transactions are not sorted newest first")
+	}
+	ids, _ = et.explorer.UnlockHashTransactions(uh, 2, 2)
+	if len(ids) != 1 || ids[0] != txns[0].ID() {
+		t.Fatal("wrong last page of transactions", ids)
+	}
+
+	// The inputs of the payments should be spent, the new outputs should not.
+	for _, txn := range txns {
+		for _, sci := range txn.SiacoinInputs {
+			spent, err := et.explorer.SiacoinOutputSpent(sci.ParentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !spent {
+				t.Fatal("input of a confirmed transaction is not spent")
+			}
+		}
+		for i, sco := range txn.SiacoinOutputs {
+			if sco.UnlockHash != uh {
+				continue
+			}
+			spent, err := et.explorer.SiacoinOutputSpent(txn.SiacoinOutputID(uint64(i)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spent {
+				t.Fatal("new output is spent")
+			}
+		}
+	}
+}
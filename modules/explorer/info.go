@@ -1,6 +1,8 @@
 package explorer
 
 import (
+	"math"
+
 	"gitlab.com/NebulousLabs/bolt"
 
 	"go.sia.tech/siad/build"
@@ -75,7 +77,8 @@ func (e *Explorer) Transaction(id types.TransactionID) (types.Block, types.Block
 // blockchain.
 func (e *Explorer) UnlockHash(uh types.UnlockHash) []types.TransactionID {
 	var ids []types.TransactionID
-	err := e.db.View(dbGetTransactionIDSet(bucketUnlockHashes, uh, &ids))
+	var total uint64
+	err := e.db.View(dbGetUnlockHashTransactions(uh, 0, math.MaxUint64, &ids, &total))
 	if err != nil {
 		ids = nil
 	}
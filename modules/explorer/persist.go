@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 
 	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/modules"
@@ -12,9 +13,14 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// dbFilename is the filename of the explorer database.
+	dbFilename = "explorer.db"
+)
+
 var explorerMetadata = persist.Metadata{
 	Header:  "Sia Explorer",
-	Version: "0.5.2",
+	Version: "1.5.10",
 }
 
 // initPersist initializes the persistent structures of the explorer module.
@@ -25,8 +31,17 @@ func (e *Explorer) initPersist() error {
 		return err
 	}
 
-	// Open the database
-	db, err := persist.OpenDatabase(explorerMetadata, filepath.Join(e.persistDir, "explorer.db"))
+	// Open the database. Everything in the database is derived from the
+	// consensus set, so a database of an older version is removed and rebuilt
+	// from scratch.
+	dbPath := filepath.Join(e.persistDir, dbFilename)
+	db, err := persist.OpenDatabase(explorerMetadata, dbPath)
+	if errors.Contains(err, persist.ErrBadVersion) {
+		if err := os.Remove(dbPath); err != nil {
+			return err
+		}
+		db, err = persist.OpenDatabase(explorerMetadata, dbPath)
+	}
 	if err != nil {
 		return err
 	}
@@ -48,6 +63,8 @@ func (e *Explorer) initPersist() error {
 			bucketSiafundOutputs,
 			bucketTransactionIDs,
 			bucketUnlockHashes,
+			bucketUnspentSiacoinOutputs,
+			bucketUnspentSiafundOutputs,
 		}
 		for _, b := range buckets {
 			_, err := tx.CreateBucketIfNotExists(b)
@@ -61,6 +78,7 @@ func (e *Explorer) initPersist() error {
 			key, val []byte
 		}{
 			{internalBlockHeight, encoding.Marshal(types.BlockHeight(0))},
+			{internalOutputIndex, encoding.Marshal(false)},
 			{internalRecentChange, encoding.Marshal(modules.ConsensusChangeID{})},
 		}
 		b := tx.Bucket(bucketInternal)
@@ -82,3 +100,49 @@ func (e *Explorer) initPersist() error {
 
 	return nil
 }
+
+// initOutputIndex makes sure that the output index of the database matches
+// e.indexOutputs. If the index is enabled for a database which was built
+// without it, the database is rebuilt from scratch. If the index is disabled,
+// the index is removed from the database.
+func (e *Explorer) initOutputIndex() error {
+	var indexed bool
+	var recentChange modules.ConsensusChangeID
+	err := e.db.View(func(tx *bolt.Tx) error {
+		if err := dbGetInternal(internalOutputIndex, &indexed)(tx); err != nil {
+			return err
+		}
+		return dbGetInternal(internalRecentChange, &recentChange)(tx)
+	})
+	if err != nil {
+		return err
+	}
+	if indexed == e.indexOutputs {
+		return nil
+	}
+
+	// Rebuild the database if the index is enabled and the database already
+	// contains blocks.
+	if e.indexOutputs && recentChange != modules.ConsensusChangeBeginning {
+		if err := e.db.Close(); err != nil {
+			return err
+		}
+		if err := os.Remove(filepath.Join(e.persistDir, dbFilename)); err != nil {
+			return err
+		}
+		if err := e.initPersist(); err != nil {
+			return err
+		}
+	}
+	return e.db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{bucketUnspentSiacoinOutputs, bucketUnspentSiafundOutputs} {
+			if err := tx.DeleteBucket(b); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(b); err != nil {
+				return err
+			}
+		}
+		return dbSetInternal(internalOutputIndex, e.indexOutputs)(tx)
+	})
+}
@@ -26,7 +26,9 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}()
 
-		// Update cumulative stats for reverted blocks.
+		// Update cumulative stats for reverted blocks. The blocks are reverted
+		// starting at the tip of the chain before the change.
+		height := cc.InitialHeight() + types.BlockHeight(len(cc.RevertedBlocks))
 		for _, block := range cc.RevertedBlocks {
 			bid := block.ID()
 			tbid := types.TransactionID(bid)
@@ -44,7 +46,7 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
 				dbRemoveSiacoinOutputID(tx, scoid, tbid)
-				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid)
+				dbRemoveUnlockHash(tx, payout.UnlockHash, tbid, height)
 			}
 
 			// Remove transactions
@@ -54,43 +56,43 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 
 				for _, sci := range txn.SiacoinInputs {
 					dbRemoveSiacoinOutputID(tx, sci.ParentID, txid)
-					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbRemoveUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, height)
 				}
 				for k, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(k))
 					dbRemoveSiacoinOutputID(tx, scoid, txid)
-					dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					dbRemoveSiacoinOutput(tx, scoid)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
 					dbRemoveFileContractID(tx, fcid, txid)
-					dbRemoveUnlockHash(tx, fc.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, fc.UnlockHash, txid, height)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					dbRemoveFileContract(tx, fcid)
 				}
 				for _, fcr := range txn.FileContractRevisions {
 					dbRemoveFileContractID(tx, fcr.ParentID, txid)
-					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbRemoveUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, height)
+					dbRemoveUnlockHash(tx, fcr.NewUnlockHash, txid, height)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbRemoveSiacoinOutputID(tx, scoid, txid)
-						dbRemoveUnlockHash(tx, sco.UnlockHash, txid)
+						dbRemoveUnlockHash(tx, sco.UnlockHash, txid, height)
 					}
 					// Remove the file contract revision from the revision chain.
 					dbRemoveFileContractRevision(tx, fcr.ParentID)
@@ -100,18 +102,19 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 				for _, sfi := range txn.SiafundInputs {
 					dbRemoveSiafundOutputID(tx, sfi.ParentID, txid)
-					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbRemoveUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, height)
+					dbRemoveUnlockHash(tx, sfi.ClaimUnlockHash, txid, height)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
 					dbRemoveSiafundOutputID(tx, sfoid, txid)
-					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid)
+					dbRemoveUnlockHash(tx, sfo.UnlockHash, txid, height)
 				}
 			}
 
 			// remove the associated block facts
 			dbRemoveBlockFacts(tx, bid)
+			height--
 		}
 
 		blockheight := cc.InitialHeight()
@@ -140,7 +143,7 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			for j, payout := range block.MinerPayouts {
 				scoid := block.MinerPayoutID(uint64(j))
 				dbAddSiacoinOutputID(tx, scoid, tbid)
-				dbAddUnlockHash(tx, payout.UnlockHash, tbid)
+				dbAddUnlockHash(tx, payout.UnlockHash, tbid, blockheight)
 			}
 
 			// Update cumulative stats for applied transactions.
@@ -151,42 +154,42 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 
 				for _, sci := range txn.SiacoinInputs {
 					dbAddSiacoinOutputID(tx, sci.ParentID, txid)
-					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid)
+					dbAddUnlockHash(tx, sci.UnlockConditions.UnlockHash(), txid, blockheight)
 				}
 				for j, sco := range txn.SiacoinOutputs {
 					scoid := txn.SiacoinOutputID(uint64(j))
 					dbAddSiacoinOutputID(tx, scoid, txid)
-					dbAddUnlockHash(tx, sco.UnlockHash, txid)
+					dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 				}
 				for k, fc := range txn.FileContracts {
 					fcid := txn.FileContractID(uint64(k))
 					dbAddFileContractID(tx, fcid, txid)
-					dbAddUnlockHash(tx, fc.UnlockHash, txid)
+					dbAddUnlockHash(tx, fc.UnlockHash, txid, blockheight)
 					dbAddFileContract(tx, fcid, fc)
 					for l, sco := range fc.ValidProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fc.MissedProofOutputs {
 						scoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 				}
 				for _, fcr := range txn.FileContractRevisions {
 					dbAddFileContractID(tx, fcr.ParentID, txid)
-					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid)
+					dbAddUnlockHash(tx, fcr.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, fcr.NewUnlockHash, txid, blockheight)
 					for l, sco := range fcr.NewValidProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofValid, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					for l, sco := range fcr.NewMissedProofOutputs {
 						scoid := fcr.ParentID.StorageProofOutputID(types.ProofMissed, uint64(l))
 						dbAddSiacoinOutputID(tx, scoid, txid)
-						dbAddUnlockHash(tx, sco.UnlockHash, txid)
+						dbAddUnlockHash(tx, sco.UnlockHash, txid, blockheight)
 					}
 					dbAddFileContractRevision(tx, fcr.ParentID, fcr)
 				}
@@ -196,13 +199,13 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 				for _, sfi := range txn.SiafundInputs {
 					dbAddSiafundOutputID(tx, sfi.ParentID, txid)
-					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid)
-					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid)
+					dbAddUnlockHash(tx, sfi.UnlockConditions.UnlockHash(), txid, blockheight)
+					dbAddUnlockHash(tx, sfi.ClaimUnlockHash, txid, blockheight)
 				}
 				for k, sfo := range txn.SiafundOutputs {
 					sfoid := txn.SiafundOutputID(uint64(k))
 					dbAddSiafundOutputID(tx, sfoid, txid)
-					dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+					dbAddUnlockHash(tx, sfo.UnlockHash, txid, blockheight)
 				}
 			}
 
@@ -218,6 +221,9 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			if scod.Direction == modules.DiffApply {
 				dbAddSiacoinOutput(tx, scod.ID, scod.SiacoinOutput)
 			}
+			if e.indexOutputs {
+				dbUpdateUnspentSiacoinOutput(tx, scod)
+			}
 		}
 
 		// Update stats according to SiafundOutputDiffs
@@ -225,6 +231,9 @@ func (e *Explorer) ProcessConsensusChange(cc modules.ConsensusChange) {
 			if sfod.Direction == modules.DiffApply {
 				dbAddSiafundOutput(tx, sfod.ID, sfod.SiafundOutput)
 			}
+			if e.indexOutputs {
+				dbUpdateUnspentSiafundOutput(tx, sfod)
+			}
 		}
 
 		// Compute the changes in the active set. Note, because this is calculated
@@ -421,19 +430,48 @@ func dbRemoveTransactionID(tx *bolt.Tx, id types.TransactionID) {
 }
 
 // Add/Remove txid from unlock hash bucket
-func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbAddUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
 	b, err := tx.Bucket(bucketUnlockHashes).CreateBucketIfNotExists(encoding.Marshal(uh))
 	assertNil(err)
-	mustPutSet(b, txid)
+	assertNil(b.Put(unlockHashKey(height, txid), nil))
 }
-func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID) {
+func dbRemoveUnlockHash(tx *bolt.Tx, uh types.UnlockHash, txid types.TransactionID, height types.BlockHeight) {
+	// The unlock hash may appear multiple times in a transaction, in which
+	// case the bucket might already be gone.
 	bucket := tx.Bucket(bucketUnlockHashes).Bucket(encoding.Marshal(uh))
-	mustDelete(bucket, txid)
+	if bucket == nil {
+		return
+	}
+	assertNil(bucket.Delete(unlockHashKey(height, txid)))
 	if bucketIsEmpty(bucket) {
 		tx.Bucket(bucketUnlockHashes).DeleteBucket(encoding.Marshal(uh))
 	}
 }
 
+// Add/Remove unspent outputs of an unlock hash
+func dbUpdateUnspentSiacoinOutput(tx *bolt.Tx, scod modules.SiacoinOutputDiff) {
+	dbUpdateUnspentOutput(tx.Bucket(bucketUnspentSiacoinOutputs), scod.SiacoinOutput.UnlockHash, scod.ID, scod.SiacoinOutput.Value, scod.Direction)
+}
+func dbUpdateUnspentSiafundOutput(tx *bolt.Tx, sfod modules.SiafundOutputDiff) {
+	dbUpdateUnspentOutput(tx.Bucket(bucketUnspentSiafundOutputs), sfod.SiafundOutput.UnlockHash, sfod.ID, sfod.SiafundOutput.Value, sfod.Direction)
+}
+func dbUpdateUnspentOutput(outputs *bolt.Bucket, uh types.UnlockHash, id interface{}, value types.Currency, dir modules.DiffDirection) {
+	if dir == modules.DiffApply {
+		b, err := outputs.CreateBucketIfNotExists(encoding.Marshal(uh))
+		assertNil(err)
+		mustPut(b, id, value)
+		return
+	}
+	b := outputs.Bucket(encoding.Marshal(uh))
+	if b == nil {
+		return
+	}
+	mustDelete(b, id)
+	if bucketIsEmpty(b) {
+		assertNil(outputs.DeleteBucket(encoding.Marshal(uh)))
+	}
+}
+
 func dbCalculateBlockFacts(tx *bolt.Tx, cs modules.ConsensusSet, block types.Block) blockFacts {
 	// get the parent block facts
 	var bf blockFacts
@@ -528,7 +566,7 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 		for i, sco := range transaction.SiacoinOutputs {
 			scoid := transaction.SiacoinOutputID(uint64(i))
 			dbAddSiacoinOutputID(tx, scoid, txid)
-			dbAddUnlockHash(tx, sco.UnlockHash, txid)
+			dbAddUnlockHash(tx, sco.UnlockHash, txid, 0)
 			dbAddSiacoinOutput(tx, scoid, sco)
 		}
 
@@ -536,7 +574,7 @@ func dbAddGenesisBlock(tx *bolt.Tx) {
 		for i, sfo := range transaction.SiafundOutputs {
 			sfoid := transaction.SiafundOutputID(uint64(i))
 			dbAddSiafundOutputID(tx, sfoid, txid)
-			dbAddUnlockHash(tx, sfo.UnlockHash, txid)
+			dbAddUnlockHash(tx, sfo.UnlockHash, txid, 0)
 			dbAddSiafundOutput(tx, sfoid, sfo)
 		}
 	}
@@ -1,9 +1,14 @@
 package modules
 
 import (
+	"fmt"
+	"io"
 	"net"
 	"time"
 
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/build"
 )
 
@@ -14,6 +19,13 @@ const (
 )
 
 var (
+	// ErrPeerMisbehavior is composed with the error returned by an RPC handler
+	// if the calling peer violated the protocol, e.g. by sending an object
+	// which can't be decoded or which is invalid regardless of the state of
+	// the node. Only such errors count towards the misbehavior score of the
+	// peer, timeouts and closed connections don't.
+	ErrPeerMisbehavior = errors.New("peer violated the protocol")
+
 	// BootstrapPeers is a list of peers that can be used to find other peers -
 	// when a client first connects to the network, the only options for
 	// finding peers are either manual entry of peers or to use a hardcoded
@@ -120,6 +132,40 @@ type (
 		Version    string     `json:"version"`
 	}
 
+	// GatewayConnectivity describes whether the gateway can be reached by
+	// other nodes. PortForwarding is the protocol used to forward the
+	// gateway's port, "upnp", "pcp" or "nat-pmp", and empty if the port isn't
+	// forwarded. Reachable is the result of the last reachability check, in
+	// which peers are asked to dial back the gateway.
+	GatewayConnectivity struct {
+		PortForwarding        string    `json:"portforwarding"`
+		PortForwardingError   string    `json:"portforwardingerror"`
+		Reachable             bool      `json:"reachable"`
+		ReachabilityError     string    `json:"reachabilityerror"`
+		LastReachabilityCheck time.Time `json:"lastreachabilitycheck"`
+	}
+
+	// PeerBan bans the peers within a subnet from connecting to the gateway.
+	// Bans with a zero Expiry are permanent.
+	PeerBan struct {
+		Subnet string    `json:"subnet"`
+		Expiry time.Time `json:"expiry"`
+		Reason string    `json:"reason"`
+	}
+
+	// PeerStats contains the quality metrics of a connected peer. When making
+	// room for new peers, the gateway prefers kicking the inbound peers with
+	// the highest misbehavior score.
+	PeerStats struct {
+		Peer
+		Persistent       bool          `json:"persistent"`
+		ConnectedSince   time.Time     `json:"connectedsince"`
+		Latency          time.Duration `json:"latency"`
+		Downloaded       uint64        `json:"downloaded"`
+		Uploaded         uint64        `json:"uploaded"`
+		MisbehaviorScore int           `json:"misbehaviorscore"`
+	}
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -166,6 +212,16 @@ type (
 		// the mapping is established or until it is interrupted by a shutdown.
 		ForwardPort(port string) error
 
+		// CheckReachability asks peers to dial back the given port on the IP
+		// address they see the gateway connecting from. An empty port checks
+		// the gateway's own port. An error is returned if no peer was able to
+		// perform the check.
+		CheckReachability(port string) (bool, error)
+
+		// Connectivity returns the port forwarding status and the result of
+		// the last reachability check of the gateway's own port.
+		Connectivity() GatewayConnectivity
+
 		// DisconnectManual is a Disconnect wrapper for a user-initiated
 		// disconnect
 		DisconnectManual(NetAddress) error
@@ -183,6 +239,27 @@ type (
 		// SetBlocklist sets the blocklist of the gateway
 		SetBlocklist(addresses []string) error
 
+		// Ban bans the peers within a subnet for a duration. A duration of 0
+		// bans them permanently.
+		Ban(subnet string, duration time.Duration, reason string) error
+
+		// Bans returns the active bans of the gateway.
+		Bans() []PeerBan
+
+		// Unban removes the ban of a subnet.
+		Unban(subnet string) error
+
+		// AddPersistentPeer adds a peer that the gateway always reconnects
+		// to.
+		AddPersistentPeer(NetAddress) error
+
+		// PersistentPeers returns the persistent peers of the gateway.
+		PersistentPeers() []NetAddress
+
+		// RemovePersistentPeer removes a persistent peer without
+		// disconnecting from it.
+		RemovePersistentPeer(NetAddress) error
+
 		// Address returns the Gateway's address.
 		Address() NetAddress
 
@@ -190,6 +267,9 @@ type (
 		// to.
 		Peers() []Peer
 
+		// PeerStats returns the quality metrics of the connected peers.
+		PeerStats() []PeerStats
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -233,3 +313,26 @@ type (
 		Close() error
 	}
 )
+
+// ReadPeerObject reads a length-prefixed and marshalled object from a peer
+// like encoding.ReadObject. Errors caused by the peer sending an oversized or
+// malformed object are composed with ErrPeerMisbehavior, errors of the
+// connection itself are not.
+func ReadPeerObject(r io.Reader, obj interface{}, maxLen uint64) error {
+	prefix := make([]byte, 8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return err
+	}
+	dataLen := encoding.DecUint64(prefix)
+	if dataLen > maxLen {
+		return errors.Compose(fmt.Errorf("length %d exceeds maxLen of %d", dataLen, maxLen), ErrPeerMisbehavior)
+	}
+	data := make([]byte, dataLen)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+	if err := encoding.Unmarshal(data, obj); err != nil {
+		return errors.Compose(err, ErrPeerMisbehavior)
+	}
+	return nil
+}
@@ -12,6 +12,10 @@ var (
 	// AlertMSGGatewayOffline indicates that the last time the gateway checked
 	// the network status it was offline.
 	AlertMSGGatewayOffline = "not connected to the internet"
+
+	// AlertMSGGatewayUnreachable indicates that the peers of the gateway were
+	// unable to dial back the gateway during the last reachability check.
+	AlertMSGGatewayUnreachable = "gateway is not reachable by other nodes, check the port forwarding of your router"
 )
 
 const (
@@ -32,6 +36,31 @@ const (
 	// connect to itself, this number can be reduced.
 	maxLocalOutboundPeers = 3
 
+	// misbehaviorBanDuration is the duration for which the IP of a peer is
+	// banned once its misbehavior score reaches misbehaviorBanThreshold.
+	misbehaviorBanDuration = 24 * time.Hour
+
+	// misbehaviorBanThreshold is the misbehavior score at which a peer is
+	// disconnected and banned.
+	misbehaviorBanThreshold = 100
+
+	// misbehaviorPenaltyProtocolViolation and misbehaviorPenaltyUnknownRPC are
+	// added to the misbehavior score of a peer when an RPC it called fails
+	// with modules.ErrPeerMisbehavior or when it calls an RPC that isn't
+	// registered. Other failures, like timeouts, orphan blocks or closed
+	// connections, are not penalized since honest peers cause them too.
+	misbehaviorPenaltyProtocolViolation = 10
+	misbehaviorPenaltyUnknownRPC        = 10
+
+	// portMappingLifetime is the lifetime requested for port mappings created
+	// with PCP or NAT-PMP. Mappings are renewed halfway through the lifetime
+	// granted by the router.
+	portMappingLifetime = 2 * time.Hour
+
+	// reachabilityCheckPeers is the maximum number of peers asked to dial back
+	// the gateway during a reachability check.
+	reachabilityCheckPeers = 3
+
 	// saveFrequency defines how often the gateway saves its persistence.
 	saveFrequency = time.Minute * 2
 
@@ -108,6 +137,24 @@ var (
 		Testing:  20 * time.Millisecond,
 	}).(time.Duration)
 
+	// persistentPeerReconnectInterval defines how often the gateway tries to
+	// reconnect to the persistent peers it is not connected to.
+	persistentPeerReconnectInterval = build.Select(build.Var{
+		Standard: 1 * time.Minute,
+		Testnet:  1 * time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  200 * time.Millisecond,
+	}).(time.Duration)
+
+	// reachabilityCheckInterval defines how often the gateway checks whether
+	// its peers can dial back its port.
+	reachabilityCheckInterval = build.Select(build.Var{
+		Standard: 30 * time.Minute,
+		Testnet:  30 * time.Minute,
+		Dev:      2 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
 	// pruneNodeListLen defines the number of nodes that the gateway must have
 	// to be pruning nodes from the node list.
 	pruneNodeListLen = build.Select(build.Var{
@@ -232,6 +279,15 @@ var (
 		Testing:  500 * time.Millisecond,
 	}).(time.Duration)
 
+	// dialBackTimeout is the timeout for dialing back a peer during the
+	// DialBack RPC.
+	dialBackTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// rpcStdDeadline defines the standard deadline that should be used for all
 	// incoming RPC calls.
 	rpcStdDeadline = build.Select(build.Var{
@@ -131,10 +131,14 @@ type Gateway struct {
 
 	// blocklist are peers that the gateway shouldn't connect to
 	//
+	// bans maps subnets to the bans of the peers within them.
+	//
 	// nodes is the set of all known nodes (i.e. potential peers).
 	//
 	// peers are the nodes that the gateway is currently connected to.
 	//
+	// persistentPeers are the nodes that the gateway always reconnects to.
+	//
 	// peerTG is a special thread group for tracking peer connections, and will
 	// block shutdown until all peer connections have been closed out. The peer
 	// connections are put in a separate TG because of their unique
@@ -146,10 +150,16 @@ type Gateway struct {
 	// and would block any threads.Flush() calls. So a second threadgroup is
 	// added which handles clean-shutdown for the peers, without blocking
 	// threads.Flush() calls.
-	blocklist map[string]struct{}
-	nodes     map[modules.NetAddress]*node
-	peers     map[modules.NetAddress]*peer
-	peerTG    threadgroup.ThreadGroup
+	blocklist       map[string]struct{}
+	bans            map[string]modules.PeerBan
+	nodes           map[modules.NetAddress]*node
+	peers           map[modules.NetAddress]*peer
+	persistentPeers map[modules.NetAddress]struct{}
+	peerTG          threadgroup.ThreadGroup
+
+	// connectivity is the port forwarding status and the result of the last
+	// reachability check.
+	connectivity modules.GatewayConnectivity
 
 	// Utilities.
 	log           *persist.Logger
@@ -376,9 +386,11 @@ func NewCustomGateway(addr string, bootstrap bool, useUPNP bool, persistDir stri
 		handlers: make(map[rpcID]modules.RPCFunc),
 		initRPCs: make(map[string]modules.RPCFunc),
 
-		blocklist: make(map[string]struct{}),
-		nodes:     make(map[modules.NetAddress]*node),
-		peers:     make(map[modules.NetAddress]*peer),
+		blocklist:       make(map[string]struct{}),
+		bans:            make(map[string]modules.PeerBan),
+		nodes:           make(map[modules.NetAddress]*node),
+		peers:           make(map[modules.NetAddress]*peer),
+		persistentPeers: make(map[modules.NetAddress]struct{}),
 
 		persistDir:    persistDir,
 		staticAlerter: modules.NewAlerter("gateway"),
@@ -420,11 +432,13 @@ func NewCustomGateway(addr string, bootstrap bool, useUPNP bool, persistDir stri
 	// Register RPCs.
 	g.RegisterRPC("ShareNodes", g.shareNodes)
 	g.RegisterRPC("DiscoverIP", g.discoverPeerIP)
+	g.RegisterRPC("DialBack", g.dialBack)
 	g.RegisterConnectCall("ShareNodes", g.requestNodes)
 	// Establish the de-registration of the RPCs.
 	g.threads.OnStop(func() error {
 		g.UnregisterRPC("ShareNodes")
 		g.UnregisterRPC("DiscoverIP")
+		g.UnregisterRPC("DialBack")
 		g.UnregisterConnectCall("ShareNodes")
 		return nil
 	})
@@ -529,6 +543,15 @@ func NewCustomGateway(addr string, bootstrap bool, useUPNP bool, persistDir stri
 	})
 	go g.permanentNodePurger(nodePurgerClosedChan)
 
+	// Spawn the persistent peer manager and provide tools for ensuring clean
+	// shutdown.
+	persistentPeerManagerClosedChan := make(chan struct{})
+	g.threads.OnStop(func() error {
+		<-persistentPeerManagerClosedChan
+		return nil
+	})
+	go g.permanentPersistentPeerManager(persistentPeerManagerClosedChan)
+
 	// Spawn threads to take care of port forwarding and hostname discovery.
 	go g.threadedForwardPort(g.port)
 	go g.threadedLearnHostname()
@@ -536,6 +559,9 @@ func NewCustomGateway(addr string, bootstrap bool, useUPNP bool, persistDir stri
 	// Spawn thread to periodically check if the gateway is online.
 	go g.threadedOnlineCheck()
 
+	// Spawn thread to periodically check if the gateway is reachable.
+	go g.threadedCheckReachability()
+
 	return g, nil
 }
 
@@ -0,0 +1,313 @@
+package gateway
+
+import (
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errInvalidSubnet is returned when a ban doesn't specify an IP address or
+	// a subnet in CIDR notation.
+	errInvalidSubnet = errors.New("subnet must be an IP address or in CIDR notation")
+
+	// errNegativeBanDuration is returned when banning a subnet for a negative
+	// duration.
+	errNegativeBanDuration = errors.New("ban duration can't be negative")
+
+	// errNotBanned is returned when unbanning a subnet that isn't banned.
+	errNotBanned = errors.New("subnet is not banned")
+
+	// errNotPersistentPeer is returned when removing a persistent peer that
+	// doesn't exist.
+	errNotPersistentPeer = errors.New("address is not a persistent peer")
+)
+
+// parseSubnet parses an IP address or a subnet in CIDR notation. An IP
+// address is treated as a subnet containing only that address.
+func parseSubnet(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errInvalidSubnet
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, subnet, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, errInvalidSubnet
+	}
+	return subnet, nil
+}
+
+// inSubnet returns whether the host of addr is an IP address within subnet.
+func inSubnet(addr modules.NetAddress, subnet *net.IPNet) bool {
+	ip := net.ParseIP(addr.Host())
+	return ip != nil && subnet.Contains(ip)
+}
+
+// activeBans returns the bans that haven't expired at the given time sorted
+// by subnet.
+func (g *Gateway) activeBans(now time.Time) []modules.PeerBan {
+	bans := make([]modules.PeerBan, 0, len(g.bans))
+	for _, b := range g.bans {
+		if b.Expiry.IsZero() || b.Expiry.After(now) {
+			bans = append(bans, b)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Subnet < bans[j].Subnet
+	})
+	return bans
+}
+
+// banned returns whether host is an IP address within a banned subnet.
+func (g *Gateway) banned(host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, b := range g.activeBans(time.Now()) {
+		subnet, err := parseSubnet(b.Subnet)
+		if err == nil && subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ban bans the peers within a subnet. The connected peers within the subnet
+// are disconnected and the nodes within it are removed from the node list.
+func (g *Gateway) ban(subnet *net.IPNet, duration time.Duration, reason string) error {
+	b := modules.PeerBan{
+		Subnet: subnet.String(),
+		Reason: reason,
+	}
+	if duration > 0 {
+		b.Expiry = time.Now().Add(duration)
+	}
+	g.bans[b.Subnet] = b
+
+	var err error
+	for addr, p := range g.peers {
+		if inSubnet(addr, subnet) {
+			err = errors.Compose(err, p.sess.Close())
+			delete(g.peers, addr)
+		}
+	}
+	for addr := range g.nodes {
+		if inSubnet(addr, subnet) {
+			delete(g.nodes, addr)
+		}
+	}
+	g.log.Printf("INFO: banned %v for %v: %v", b.Subnet, duration, reason)
+	return errors.Compose(err, g.saveSync())
+}
+
+// persistentPeerList returns the persistent peers sorted by address.
+func (g *Gateway) persistentPeerList() []modules.NetAddress {
+	addrs := make([]modules.NetAddress, 0, len(g.persistentPeers))
+	for addr := range g.persistentPeers {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i] < addrs[j]
+	})
+	return addrs
+}
+
+// managedPenalizePeer adds a penalty to the misbehavior score of a connected
+// peer. Peers whose score reaches misbehaviorBanThreshold are banned for
+// misbehaviorBanDuration unless they are persistent peers.
+func (g *Gateway) managedPenalizePeer(addr modules.NetAddress, penalty int, reason string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, exists := g.peers[addr]
+	if !exists {
+		return
+	}
+	p.misbehavior += penalty
+	if p.misbehavior < misbehaviorBanThreshold {
+		return
+	}
+	if _, persistent := g.persistentPeers[addr]; persistent {
+		return
+	}
+	subnet, err := parseSubnet(addr.Host())
+	if err != nil {
+		return
+	}
+	if err := g.ban(subnet, misbehaviorBanDuration, "misbehavior: "+reason); err != nil {
+		g.log.Println("WARN: unable to ban misbehaving peer:", err)
+	}
+}
+
+// permanentPersistentPeerManager periodically reconnects to the persistent
+// peers the gateway is not connected to.
+func (g *Gateway) permanentPersistentPeerManager(closedChan chan struct{}) {
+	defer close(closedChan)
+
+	for {
+		g.mu.RLock()
+		var addrs []modules.NetAddress
+		for addr := range g.persistentPeers {
+			if _, connected := g.peers[addr]; !connected {
+				addrs = append(addrs, addr)
+			}
+		}
+		g.mu.RUnlock()
+
+		for _, addr := range addrs {
+			func() {
+				if err := g.threads.Add(); err != nil {
+					return
+				}
+				defer g.threads.Done()
+				err := g.managedConnect(addr)
+				if err != nil && !errors.Contains(err, errPeerExists) {
+					g.log.Debugf("WARN: unable to reconnect to persistent peer %v: %v", addr, err)
+				}
+			}()
+		}
+
+		if !g.managedSleep(persistentPeerReconnectInterval) {
+			return
+		}
+	}
+}
+
+// AddPersistentPeer adds a peer that the gateway always reconnects to. The
+// gateway connects to the peer right away if it isn't connected yet. A failed
+// connection attempt is retried in the background.
+func (g *Gateway) AddPersistentPeer(addr modules.NetAddress) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	if err := addr.IsStdValid(); err != nil {
+		return errors.AddContext(err, "invalid address")
+	}
+	if net.ParseIP(addr.Host()) == nil {
+		return errors.New("address must be an IP address")
+	}
+
+	g.mu.Lock()
+	g.persistentPeers[addr] = struct{}{}
+	err := g.saveSync()
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = g.managedConnect(addr)
+	if err != nil && !errors.Contains(err, errPeerExists) {
+		g.log.Printf("WARN: unable to connect to persistent peer %v: %v", addr, err)
+	}
+	return nil
+}
+
+// Ban bans the peers within a subnet for a duration. The subnet is either an
+// IP address or in CIDR notation. A duration of 0 bans the peers permanently.
+// Bans also apply to persistent peers.
+func (g *Gateway) Ban(subnet string, duration time.Duration, reason string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	if duration < 0 {
+		return errNegativeBanDuration
+	}
+	ipnet, err := parseSubnet(subnet)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.ban(ipnet, duration, reason)
+}
+
+// Bans returns the active bans of the gateway.
+func (g *Gateway) Bans() []modules.PeerBan {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.activeBans(time.Now())
+}
+
+// PeerStats returns the quality metrics of the connected peers sorted by
+// address.
+func (g *Gateway) PeerStats() []modules.PeerStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	stats := make([]modules.PeerStats, 0, len(g.peers))
+	for addr, p := range g.peers {
+		_, persistent := g.persistentPeers[addr]
+		s := modules.PeerStats{
+			Peer:             p.Peer,
+			Persistent:       persistent,
+			ConnectedSince:   p.connectedSince,
+			Latency:          p.latency,
+			MisbehaviorScore: p.misbehavior,
+		}
+		if p.m != nil {
+			s.Downloaded, s.Uploaded = p.m.Counts()
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].NetAddress < stats[j].NetAddress
+	})
+	return stats
+}
+
+// PersistentPeers returns the persistent peers of the gateway sorted by
+// address.
+func (g *Gateway) PersistentPeers() []modules.NetAddress {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.persistentPeerList()
+}
+
+// RemovePersistentPeer removes a persistent peer. The gateway stays connected
+// to the peer but no longer reconnects to it.
+func (g *Gateway) RemovePersistentPeer(addr modules.NetAddress) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.persistentPeers[addr]; !exists {
+		return errNotPersistentPeer
+	}
+	delete(g.persistentPeers, addr)
+	return g.saveSync()
+}
+
+// Unban removes the ban of a subnet. The subnet must match the subnet of the
+// ban.
+func (g *Gateway) Unban(subnet string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+	ipnet, err := parseSubnet(subnet)
+	if err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, exists := g.bans[ipnet.String()]; !exists {
+		return errNotBanned
+	}
+	delete(g.bans, ipnet.String())
+	return g.saveSync()
+}
@@ -0,0 +1,357 @@
+package gateway
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestParseSubnet tests parsing IP addresses and subnets.
+func TestParseSubnet(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+		err error
+	}{
+		{"1.2.3.4", "1.2.3.4/32", nil},
+		{"1.2.3.4/24", "1.2.3.0/24", nil},
+		{"::1", "::1/128", nil},
+		{"2001:db8::/32", "2001:db8::/32", nil},
+		{"", "", errInvalidSubnet},
+		{"foo.com", "", errInvalidSubnet},
+		{"1.2.3.4/33", "", errInvalidSubnet},
+	}
+	for _, test := range tests {
+		subnet, err := parseSubnet(test.in)
+		if err != test.err {
+			t.Fatalf("%q: expected error %v, got %v", test.in, test.err, err)
+		}
+		if err == nil && subnet.String() != test.out {
+			t.Fatalf("%q: expected %v, got %v", test.in, test.out, subnet)
+		}
+	}
+}
+
+// TestBan tests banning and unbanning the subnet of a peer.
+func TestBan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := connectToNode(g, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid bans should be rejected.
+	if err := g.Ban("foo.com", 0, ""); !errors.Contains(err, errInvalidSubnet) {
+		t.Fatal("expected errInvalidSubnet, got", err)
+	}
+	if err := g.Ban(g2.Address().Host(), -time.Second, ""); !errors.Contains(err, errNegativeBanDuration) {
+		t.Fatal("expected errNegativeBanDuration, got", err)
+	}
+
+	// Banning the subnet of g2 should disconnect it.
+	if err := g.Ban(g2.Address().Host()+"/8", 0, "test"); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range g.Peers() {
+		if p.NetAddress == g2.Address() {
+			t.Fatal("banned peer is still connected")
+		}
+	}
+	if err := g.Connect(g2.Address()); err == nil {
+		t.Fatal("shouldn't be able to connect to a banned peer")
+	}
+	bans := g.Bans()
+	if len(bans) != 1 || bans[0].Subnet != "127.0.0.0/8" || bans[0].Reason != "test" || !bans[0].Expiry.IsZero() {
+		t.Fatal("unexpected bans", bans)
+	}
+
+	// The ban should be persisted.
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New("localhost:0", false, g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bans := g.Bans(); len(bans) != 1 || bans[0].Subnet != "127.0.0.0/8" {
+		t.Fatal("ban wasn't persisted", bans)
+	}
+
+	// Unbanning the subnet should allow connecting again.
+	if err := g.Unban(g2.Address().Host()); !errors.Contains(err, errNotBanned) {
+		t.Fatal("expected errNotBanned, got", err)
+	}
+	if err := g.Unban("127.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := connectToNode(g, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expired bans should be ignored.
+	if err := g.Ban(g2.Address().Host(), time.Millisecond, "test"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if bans := g.Bans(); len(bans) != 0 {
+		t.Fatal("expired ban should be ignored", bans)
+	}
+	if err := connectToNode(g, g2, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPersistentPeers tests that the gateway reconnects to persistent peers.
+func TestPersistentPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Adding a persistent peer should connect to it.
+	if err := g.AddPersistentPeer("foo.com:123"); err == nil {
+		t.Fatal("expected hostnames to be rejected")
+	}
+	if err := g.AddPersistentPeer(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if pp := g.PersistentPeers(); len(pp) != 1 || pp[0] != g2.Address() {
+		t.Fatal("unexpected persistent peers", pp)
+	}
+	connected := func() error {
+		for _, s := range g.PeerStats() {
+			if s.NetAddress == g2.Address() {
+				if !s.Persistent {
+					return errors.New("peer should be marked as persistent")
+				}
+				return nil
+			}
+		}
+		return errors.New("not connected to persistent peer")
+	}
+	if err := build.Retry(100, 10*time.Millisecond, connected); err != nil {
+		t.Fatal(err)
+	}
+
+	// The gateway should reconnect after disconnecting.
+	if err := g.Disconnect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := build.Retry(100, 50*time.Millisecond, connected); err != nil {
+		t.Fatal(err)
+	}
+
+	// The persistent peers should be persisted.
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New("localhost:0", false, g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pp := g.PersistentPeers(); len(pp) != 1 || pp[0] != g2.Address() {
+		t.Fatal("persistent peers weren't persisted", pp)
+	}
+
+	// Remove the persistent peer.
+	if err := g.RemovePersistentPeer(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.RemovePersistentPeer(g2.Address()); !errors.Contains(err, errNotPersistentPeer) {
+		t.Fatal("expected errNotPersistentPeer, got", err)
+	}
+	if pp := g.PersistentPeers(); len(pp) != 0 {
+		t.Fatal("unexpected persistent peers", pp)
+	}
+}
+
+// TestPenalizePeer tests that peers are banned once their misbehavior score
+// reaches the threshold.
+func TestPenalizePeer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	g.mu.Lock()
+	for _, addr := range []modules.NetAddress{"1.2.3.4:1234", "5.6.7.8:1234"} {
+		g.addPeer(&peer{
+			Peer: modules.Peer{
+				NetAddress: addr,
+				Inbound:    true,
+			},
+			sess: newClientStream(new(dummyConn), ProtocolVersion),
+		})
+	}
+	g.persistentPeers["5.6.7.8:1234"] = struct{}{}
+	g.mu.Unlock()
+
+	// Penalties below the threshold should only increase the score.
+	g.managedPenalizePeer("1.2.3.4:1234", misbehaviorBanThreshold-1, "test")
+	stats := g.PeerStats()
+	if len(stats) != 2 || stats[0].MisbehaviorScore != misbehaviorBanThreshold-1 {
+		t.Fatal("unexpected peer stats", stats)
+	}
+
+	// Reaching the threshold should ban the peer unless it is persistent.
+	g.managedPenalizePeer("1.2.3.4:1234", misbehaviorPenaltyProtocolViolation, "test")
+	g.managedPenalizePeer("5.6.7.8:1234", misbehaviorBanThreshold, "test")
+	stats = g.PeerStats()
+	if len(stats) != 1 || stats[0].NetAddress != "5.6.7.8:1234" {
+		t.Fatal("misbehaving peer should have been kicked", stats)
+	}
+	bans := g.Bans()
+	if len(bans) != 1 || bans[0].Subnet != "1.2.3.4/32" || bans[0].Expiry.IsZero() {
+		t.Fatal("misbehaving peer should have been banned temporarily", bans)
+	}
+}
+
+// TestHandleConnMisbehavior tests that only RPCs failing with
+// modules.ErrPeerMisbehavior count towards the misbehavior score of the peer.
+func TestHandleConnMisbehavior(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	handled := make(chan struct{}, 2)
+	g2.RegisterRPC("Fail", func(conn modules.PeerConn) error {
+		defer func() { handled <- struct{}{} }()
+		return errors.New("timeout")
+	})
+	g2.RegisterRPC("Violate", func(conn modules.PeerConn) error {
+		defer func() { handled <- struct{}{} }()
+		return errors.Compose(errors.New("bad object"), modules.ErrPeerMisbehavior)
+	})
+	score := func() int {
+		for _, p := range g2.PeerStats() {
+			if p.NetAddress == g1.Address() {
+				return p.MisbehaviorScore
+			}
+		}
+		t.Fatal("peer not found")
+		return 0
+	}
+
+	// A failing RPC shouldn't be penalized.
+	_ = g1.RPC(g2.Address(), "Fail", func(modules.PeerConn) error { return nil })
+	<-handled
+	err := build.Retry(10, 10*time.Millisecond, func() error {
+		if s := score(); s != 0 {
+			return fmt.Errorf("expected score 0 but got %v", s)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A protocol violation should be.
+	_ = g1.RPC(g2.Address(), "Violate", func(modules.PeerConn) error { return nil })
+	<-handled
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if s := score(); s != misbehaviorPenaltyProtocolViolation {
+			return fmt.Errorf("expected score %v but got %v", misbehaviorPenaltyProtocolViolation, s)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAcceptPeerMisbehavior tests that acceptPeer kicks the peer with the
+// highest misbehavior score.
+func TestAcceptPeerMisbehavior(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Fill the gateway with inbound peers, one of which misbehaved.
+	for i := 0; i < fullyConnectedThreshold; i++ {
+		g.acceptPeer(&peer{
+			Peer: modules.Peer{
+				NetAddress: modules.NetAddress(fmt.Sprintf("1.2.3.%d:1234", i)),
+				Inbound:    true,
+			},
+			sess:        newClientStream(new(dummyConn), ProtocolVersion),
+			misbehavior: i % 2,
+		})
+	}
+	g.peers["1.2.3.3:1234"].misbehavior = 5
+
+	g.acceptPeer(&peer{
+		Peer: modules.Peer{
+			NetAddress: "9.9.9.9:1234",
+			Inbound:    true,
+		},
+		sess: newClientStream(new(dummyConn), ProtocolVersion),
+	})
+	if _, exists := g.peers["1.2.3.3:1234"]; exists {
+		t.Fatal("acceptPeer didn't kick the misbehaving peer")
+	}
+	if len(g.peers) != fullyConnectedThreshold {
+		t.Fatal("unexpected number of peers", len(g.peers))
+	}
+}
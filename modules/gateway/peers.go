@@ -46,6 +46,13 @@ type peer struct {
 	m    *connmonitor.Monitor
 	rl   *ratelimit.RateLimit
 	sess streamSession
+
+	// connectedSince, latency and misbehavior are the quality metrics of the
+	// peer. m only monitors the bandwidth of the peer. misbehavior is
+	// protected by the gateway's mutex.
+	connectedSince time.Time
+	latency        time.Duration
+	misbehavior    int
 }
 
 // sessionHeader is sent after the initial version exchange. It prevents peers
@@ -163,12 +170,18 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 
 	g.mu.RLock()
 	_, exists := g.blocklist[addr.Host()]
+	banned := g.banned(addr.Host())
 	g.mu.RUnlock()
 	if exists {
 		g.log.Debugf("INFO: %v was rejected. (blocklisted)", addr)
 		conn.Close()
 		return
 	}
+	if banned {
+		g.log.Debugf("INFO: %v was rejected. (banned)", addr)
+		conn.Close()
+		return
+	}
 	remoteVersion, err := acceptVersionHandshake(conn, ProtocolVersion)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -224,10 +237,13 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
 		return err
 	}
+	// Sending our header and reading the response takes one round trip.
+	start := time.Now()
 	if err := exchangeOurHeader(conn, ourHeader); err != nil {
 		g.log.Debugln("Unable to Accept Connection with Peer. Conn, err:", conn.RemoteAddr(), conn.LocalAddr(), err)
 		return err
 	}
+	latency := time.Since(start)
 
 	// Get the remote address on which the connecting peer is listening on.
 	// This means we need to combine the incoming connections ip address with
@@ -238,6 +254,7 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 	g.log.Debugln("Making connection with remote peer", remoteAddr)
 
 	// Accept the peer.
+	m := connmonitor.NewMonitor()
 	peer := &peer{
 		Peer: modules.Peer{
 			Inbound: true,
@@ -249,9 +266,12 @@ func (g *Gateway) managedAcceptConnPeer(conn net.Conn, remoteVersion string) err
 			NetAddress: remoteAddr,
 			Version:    remoteVersion,
 		},
-		m:    g.m,
+		m:    m,
 		rl:   rl,
-		sess: newServerStream(conn, remoteVersion),
+		sess: newServerStream(connmonitor.NewMonitoredConn(conn, m), remoteVersion),
+
+		connectedSince: time.Now(),
+		latency:        latency,
 	}
 	g.mu.Lock()
 	g.acceptPeer(peer)
@@ -282,14 +302,17 @@ func (g *Gateway) acceptPeer(p *peer) {
 		return
 	}
 
-	// Select a peer to kick. Outbound peers and local peers are not
-	// available to be kicked.
+	// Select a peer to kick. Outbound peers, local peers and persistent peers
+	// are not available to be kicked.
 	var addrs, preferredAddrs []modules.NetAddress
 	for addr, peer := range g.peers {
-		// Do not kick outbound peers or local peers.
+		// Do not kick outbound peers, local peers or persistent peers.
 		if !peer.Inbound || peer.Local {
 			continue
 		}
+		if _, persistent := g.persistentPeers[addr]; persistent {
+			continue
+		}
 
 		// Prefer kicking a peer with the same hostname.
 		if addr.Host() == p.NetAddress.Host() {
@@ -308,8 +331,21 @@ func (g *Gateway) acceptPeer(p *peer) {
 		return
 	}
 
-	// Of the remaining options, select one at random.
-	kick := addrs[fastrand.Intn(len(addrs))]
+	// Of the remaining options, select one of the peers with the highest
+	// misbehavior score at random.
+	var worstAddrs []modules.NetAddress
+	maxScore := -1
+	for _, addr := range addrs {
+		score := g.peers[addr].misbehavior
+		if score > maxScore {
+			maxScore = score
+			worstAddrs = worstAddrs[:0]
+		}
+		if score == maxScore {
+			worstAddrs = append(worstAddrs, addr)
+		}
+	}
+	kick := worstAddrs[fastrand.Intn(len(worstAddrs))]
 
 	g.peers[kick].sess.Close()
 	delete(g.peers, kick)
@@ -412,8 +448,9 @@ func exchangeRemoteHeader(conn net.Conn, ourHeader sessionHeader) (sessionHeader
 }
 
 // managedConnectPeer connects to peers >= v1.3.1. The peer is added as a
-// node and a peer. The peer is only added if a nil error is returned.
-func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) error {
+// node and a peer. The peer is only added if a nil error is returned. The
+// returned latency is the round trip time of the header exchange.
+func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remoteAddr modules.NetAddress) (time.Duration, error) {
 	g.log.Debugln("Sending sessionHeader with address", g.myAddr, g.myAddr.IsLocal())
 	// Perform header handshake.
 	g.mu.RLock()
@@ -424,12 +461,15 @@ func (g *Gateway) managedConnectPeer(conn net.Conn, remoteVersion string, remote
 	}
 	g.mu.RUnlock()
 
+	start := time.Now()
 	if err := exchangeOurHeader(conn, ourHeader); err != nil {
-		return err
-	} else if _, err := exchangeRemoteHeader(conn, ourHeader); err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	latency := time.Since(start)
+	if _, err := exchangeRemoteHeader(conn, ourHeader); err != nil {
+		return 0, err
+	}
+	return latency, nil
 }
 
 // managedConnect establishes a persistent connection to a peer, and adds it to
@@ -455,14 +495,21 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
-	if _, exists := g.blocklist[addr.Host()]; exists {
+	g.mu.RLock()
+	_, blocklisted := g.blocklist[addr.Host()]
+	banned := g.banned(addr.Host())
+	_, exists := g.peers[addr]
+	g.mu.RUnlock()
+	if blocklisted {
 		err := errors.New("can't connect to blocklisted address")
 		g.log.Debugln("Unable to connect to", addr, "error:", err)
 		return err
 	}
-	g.mu.RLock()
-	_, exists := g.peers[addr]
-	g.mu.RUnlock()
+	if banned {
+		err := errors.New("can't connect to banned address")
+		g.log.Debugln("Unable to connect to", addr, "error:", err)
+		return err
+	}
 	if exists {
 		g.log.Debugln("Unable to connect to", addr, "error:", errPeerExists)
 		return errPeerExists
@@ -484,8 +531,9 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 		return err
 	}
 
+	var latency time.Duration
 	if err = acceptableVersion(remoteVersion); err == nil {
-		err = g.managedConnectPeer(conn, remoteVersion, addr)
+		latency, err = g.managedConnectPeer(conn, remoteVersion, addr)
 	}
 	if err != nil {
 		conn.Close()
@@ -501,6 +549,7 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	m := connmonitor.NewMonitor()
 	g.addPeer(&peer{
 		Peer: modules.Peer{
 			Inbound:    false,
@@ -508,9 +557,12 @@ func (g *Gateway) managedConnect(addr modules.NetAddress) error {
 			NetAddress: addr,
 			Version:    remoteVersion,
 		},
-		m:    g.m,
+		m:    m,
 		rl:   g.rl,
-		sess: newClientStream(conn, remoteVersion),
+		sess: newClientStream(connmonitor.NewMonitoredConn(conn, m), remoteVersion),
+
+		connectedSince: time.Now(),
+		latency:        latency,
 	})
 	g.addNode(addr)
 	g.nodes[addr].WasOutboundPeer = true
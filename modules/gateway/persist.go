@@ -37,6 +37,10 @@ type (
 
 		// blocklisted IPs
 		Blocklist []string
+
+		// banned subnets and persistent peers
+		Bans            []modules.PeerBan
+		PersistentPeers []modules.NetAddress
 	}
 )
 
@@ -94,6 +98,13 @@ func (g *Gateway) load() error {
 	for _, ip := range g.persist.Blocklist {
 		g.blocklist[ip] = struct{}{}
 	}
+	// create maps from the bans and persistent peers
+	for _, b := range g.persist.Bans {
+		g.bans[b.Subnet] = b
+	}
+	for _, addr := range g.persist.PersistentPeers {
+		g.persistentPeers[addr] = struct{}{}
+	}
 	return nil
 }
 
@@ -104,6 +115,8 @@ func (g *Gateway) saveSync() error {
 	for ip := range g.blocklist {
 		g.persist.Blocklist = append(g.persist.Blocklist, ip)
 	}
+	g.persist.Bans = g.activeBans(time.Now())
+	g.persist.PersistentPeers = g.persistentPeerList()
 	return persist.SaveJSON(persistMetadata, g.persist, filepath.Join(g.persistDir, persistFilename))
 }
 
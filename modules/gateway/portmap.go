@@ -0,0 +1,287 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// Port forwarding protocols reported by Connectivity.
+const (
+	portForwardingNATPMP = "nat-pmp"
+	portForwardingPCP    = "pcp"
+	portForwardingUPNP   = "upnp"
+)
+
+const (
+	// natpmpServerPort is the port on which routers listen for PCP and
+	// NAT-PMP requests.
+	natpmpServerPort = 5351
+
+	// natpmpVersion and pcpVersion are the protocol versions of NAT-PMP
+	// (RFC 6886) and PCP (RFC 6887).
+	natpmpVersion = 0
+	pcpVersion    = 2
+
+	// natpmpOpMapTCP and pcpOpMap are the opcodes of the requests that map a
+	// TCP port. Responses set the most significant bit of the opcode.
+	natpmpOpMapTCP = 2
+	pcpOpMap       = 1
+	opResponse     = 0x80
+
+	// natpmpRequestAttempts is the number of times a request is sent before
+	// giving up. The timeout starts at natpmpInitialTimeout and doubles after
+	// every attempt.
+	natpmpRequestAttempts = 4
+	natpmpInitialTimeout  = 250 * time.Millisecond
+
+	// protocolTCP is the IANA protocol number of TCP used by PCP.
+	protocolTCP = 6
+)
+
+var (
+	// errNoRouter is returned when the router of the local network can't be
+	// determined.
+	errNoRouter = errors.New("unable to determine the router of the local network")
+
+	// errUnsupportedVersion is returned when the router doesn't support the
+	// version of a request.
+	errUnsupportedVersion = errors.New("router doesn't support the protocol version")
+)
+
+// portMapper maps ports on the router of the local network using PCP or
+// NAT-PMP. It uses PCP if the router supports it and falls back to NAT-PMP
+// otherwise. A portMapper is not safe for concurrent use.
+type portMapper struct {
+	staticRouter *net.UDPAddr
+
+	// protocol is the protocol the router supports. It is empty until the
+	// first mapping succeeds. nonce identifies the PCP mappings of the
+	// mapper.
+	protocol string
+	nonce    [12]byte
+}
+
+// newPortMapper returns a portMapper for the router at the given address.
+func newPortMapper(router *net.UDPAddr) *portMapper {
+	pm := &portMapper{staticRouter: router}
+	fastrand.Read(pm.nonce[:])
+	return pm
+}
+
+// defaultRouter returns the address of the default gateway of the local
+// network. On Linux it is read from the routing table. Elsewhere it is assumed
+// to be the first address of the /24 subnet of the local IPv4 address used for
+// outgoing connections, which is what most home routers use.
+func defaultRouter() (net.IP, error) {
+	if ip, err := defaultRouterLinux(); err == nil {
+		return ip, nil
+	}
+	conn, err := net.Dial("udp4", "192.0.2.1:9")
+	if err != nil {
+		return nil, errors.Compose(errNoRouter, err)
+	}
+	local := conn.LocalAddr().(*net.UDPAddr).IP.To4()
+	if err := conn.Close(); err != nil {
+		return nil, err
+	}
+	if local == nil || !isPrivateIPv4(local) {
+		return nil, errNoRouter
+	}
+	return net.IPv4(local[0], local[1], local[2], 1), nil
+}
+
+// defaultRouterLinux reads the default gateway from /proc/net/route.
+func defaultRouterLinux() (net.IP, error) {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		// The columns are Iface, Destination and Gateway, with addresses
+		// encoded as little endian hex.
+		fields := strings.Fields(s.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != net.IPv4len {
+			continue
+		}
+		return net.IPv4(b[3], b[2], b[1], b[0]), nil
+	}
+	return nil, errNoRouter
+}
+
+// isPrivateIPv4 returns whether ip belongs to one of the private IPv4 ranges.
+func isPrivateIPv4(ip net.IP) bool {
+	return ip[0] == 10 ||
+		(ip[0] == 172 && ip[1]&0xf0 == 16) ||
+		(ip[0] == 192 && ip[1] == 168)
+}
+
+// request sends a request to the router and returns its response. Requests
+// are retransmitted with an exponential backoff until a response arrives or
+// the attempts run out. cancel interrupts the request.
+func (pm *portMapper) request(req []byte, cancel <-chan struct{}) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, pm.staticRouter)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	timeout := natpmpInitialTimeout
+	buf := make([]byte, 1100)
+	for i := 0; i < natpmpRequestAttempts; i++ {
+		select {
+		case <-cancel:
+			return nil, errors.New("request was interrupted")
+		default:
+		}
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+		n, err := conn.Read(buf)
+		if err, ok := err.(net.Error); ok && err.Timeout() {
+			timeout *= 2
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+	return nil, errors.New("router didn't respond")
+}
+
+// mapPCP sends a PCP MAP request for a TCP port. A lifetime of 0 deletes the
+// mapping. The lifetime granted by the router is returned.
+func (pm *portMapper) mapPCP(port uint16, lifetime time.Duration, cancel <-chan struct{}) (time.Duration, error) {
+	// PCP requests contain the address the client sends them from.
+	conn, err := net.DialUDP("udp", nil, pm.staticRouter)
+	if err != nil {
+		return 0, err
+	}
+	client := conn.LocalAddr().(*net.UDPAddr).IP
+	if err := conn.Close(); err != nil {
+		return 0, err
+	}
+
+	// The request consists of the 24 byte common header followed by the 36
+	// byte MAP payload.
+	req := make([]byte, 60)
+	req[0] = pcpVersion
+	req[1] = pcpOpMap
+	binary.BigEndian.PutUint32(req[4:8], uint32(lifetime/time.Second))
+	copy(req[8:24], client.To16())
+	copy(req[24:36], pm.nonce[:])
+	req[36] = protocolTCP
+	binary.BigEndian.PutUint16(req[40:42], port)
+	binary.BigEndian.PutUint16(req[42:44], port)
+	// Suggest the all-zeros IPv4 address as the external address.
+	copy(req[44:60], net.IPv4zero.To16())
+
+	resp, err := pm.request(req, cancel)
+	if err != nil {
+		return 0, err
+	}
+	// NAT-PMP routers respond to PCP requests with a NAT-PMP response.
+	if len(resp) >= 4 && resp[0] == natpmpVersion {
+		return 0, errUnsupportedVersion
+	}
+	if len(resp) < 60 || resp[0] != pcpVersion || resp[1] != pcpOpMap|opResponse {
+		return 0, errors.New("invalid PCP response")
+	}
+	if resp[3] == 1 {
+		return 0, errUnsupportedVersion
+	} else if resp[3] != 0 {
+		return 0, fmt.Errorf("PCP request failed with result code %v", resp[3])
+	}
+	if lifetime > 0 {
+		if external := binary.BigEndian.Uint16(resp[42:44]); external != port {
+			return 0, fmt.Errorf("router mapped port %v to external port %v", port, external)
+		}
+	}
+	return time.Duration(binary.BigEndian.Uint32(resp[4:8])) * time.Second, nil
+}
+
+// mapNATPMP sends a NAT-PMP request mapping a TCP port. A lifetime of 0
+// deletes the mapping. The lifetime granted by the router is returned.
+func (pm *portMapper) mapNATPMP(port uint16, lifetime time.Duration, cancel <-chan struct{}) (time.Duration, error) {
+	req := make([]byte, 12)
+	req[0] = natpmpVersion
+	req[1] = natpmpOpMapTCP
+	binary.BigEndian.PutUint16(req[4:6], port)
+	if lifetime > 0 {
+		binary.BigEndian.PutUint16(req[6:8], port)
+	}
+	binary.BigEndian.PutUint32(req[8:12], uint32(lifetime/time.Second))
+
+	resp, err := pm.request(req, cancel)
+	if err != nil {
+		return 0, err
+	}
+	if len(resp) < 16 || resp[0] != natpmpVersion || resp[1] != natpmpOpMapTCP|opResponse {
+		return 0, errors.New("invalid NAT-PMP response")
+	}
+	if code := binary.BigEndian.Uint16(resp[2:4]); code == 1 {
+		return 0, errUnsupportedVersion
+	} else if code != 0 {
+		return 0, fmt.Errorf("NAT-PMP request failed with result code %v", code)
+	}
+	if lifetime > 0 {
+		if external := binary.BigEndian.Uint16(resp[10:12]); external != port {
+			return 0, fmt.Errorf("router mapped port %v to external port %v", port, external)
+		}
+	}
+	return time.Duration(binary.BigEndian.Uint32(resp[12:16])) * time.Second, nil
+}
+
+// Map maps a TCP port of the router to the same port of the local machine
+// for the requested lifetime. The lifetime granted by the router is returned.
+func (pm *portMapper) Map(port uint16, lifetime time.Duration, cancel <-chan struct{}) (time.Duration, error) {
+	switch pm.protocol {
+	case portForwardingPCP:
+		return pm.mapPCP(port, lifetime, cancel)
+	case portForwardingNATPMP:
+		return pm.mapNATPMP(port, lifetime, cancel)
+	}
+	granted, pcpErr := pm.mapPCP(port, lifetime, cancel)
+	if pcpErr == nil {
+		pm.protocol = portForwardingPCP
+		return granted, nil
+	}
+	granted, natpmpErr := pm.mapNATPMP(port, lifetime, cancel)
+	if natpmpErr == nil {
+		pm.protocol = portForwardingNATPMP
+		return granted, nil
+	}
+	return 0, errors.Compose(errors.AddContext(pcpErr, "PCP"), errors.AddContext(natpmpErr, "NAT-PMP"))
+}
+
+// Unmap deletes the mapping of a TCP port.
+func (pm *portMapper) Unmap(port uint16, cancel <-chan struct{}) error {
+	if pm.protocol == "" {
+		return nil
+	}
+	_, err := pm.Map(port, 0, cancel)
+	return err
+}
+
+// Protocol returns the protocol used by the mapper, either "pcp" or
+// "nat-pmp". It is empty until a port was mapped.
+func (pm *portMapper) Protocol() string {
+	return pm.protocol
+}
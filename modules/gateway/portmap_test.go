@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTestRouter starts a UDP server that responds to requests using respond.
+// It returns a portMapper for the server.
+func newTestRouter(t *testing.T, respond func(req []byte) []byte) *portMapper {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	go func() {
+		buf := make([]byte, 1100)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if resp := respond(buf[:n]); resp != nil {
+				_, _ = conn.WriteToUDP(resp, addr)
+			}
+		}
+	}()
+	return newPortMapper(conn.LocalAddr().(*net.UDPAddr))
+}
+
+// pcpResponse responds to PCP MAP requests like a router granting the
+// requested mapping.
+func pcpResponse(req []byte) []byte {
+	if len(req) != 60 || req[0] != pcpVersion {
+		return []byte{natpmpVersion, natpmpOpMapTCP | opResponse, 0, 1}
+	}
+	resp := make([]byte, 60)
+	copy(resp, req)
+	resp[1] = pcpOpMap | opResponse
+	resp[2], resp[3] = 0, 0
+	return resp
+}
+
+// natpmpResponse responds to NAT-PMP requests like a router granting the
+// requested mapping. It doesn't support PCP.
+func natpmpResponse(req []byte) []byte {
+	if req[0] != natpmpVersion {
+		return []byte{natpmpVersion, req[1] | opResponse, 0, 1}
+	}
+	resp := make([]byte, 16)
+	resp[0] = natpmpVersion
+	resp[1] = natpmpOpMapTCP | opResponse
+	copy(resp[8:12], req[4:8])
+	copy(resp[12:16], req[8:12])
+	return resp
+}
+
+// TestPortMapperPCP tests mapping a port on a router supporting PCP.
+func TestPortMapperPCP(t *testing.T) {
+	t.Parallel()
+	pm := newTestRouter(t, pcpResponse)
+
+	granted, err := pm.Map(9981, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted != time.Hour || pm.Protocol() != portForwardingPCP {
+		t.Fatal("unexpected mapping", granted, pm.Protocol())
+	}
+	if err := pm.Unmap(9981, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPortMapperNATPMP tests that the portMapper falls back to NAT-PMP if the
+// router doesn't support PCP.
+func TestPortMapperNATPMP(t *testing.T) {
+	t.Parallel()
+	pm := newTestRouter(t, natpmpResponse)
+
+	granted, err := pm.Map(9981, time.Hour, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if granted != time.Hour || pm.Protocol() != portForwardingNATPMP {
+		t.Fatal("unexpected mapping", granted, pm.Protocol())
+	}
+	if err := pm.Unmap(9981, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPortMapperExternalPort tests that mappings to a different external port
+// are rejected.
+func TestPortMapperExternalPort(t *testing.T) {
+	t.Parallel()
+	pm := newTestRouter(t, func(req []byte) []byte {
+		resp := natpmpResponse(req)
+		if len(resp) == 16 {
+			binary.BigEndian.PutUint16(resp[10:12], 1234)
+		}
+		return resp
+	})
+	if _, err := pm.Map(9981, time.Hour, nil); err == nil {
+		t.Fatal("expected mapping to a different external port to fail")
+	}
+	if pm.Protocol() != "" {
+		t.Fatal("protocol shouldn't be set after a failed mapping")
+	}
+}
+
+// TestPortMapperNoResponse tests that requests to an unresponsive router can be
+// interrupted.
+func TestPortMapperNoResponse(t *testing.T) {
+	t.Parallel()
+	pm := newTestRouter(t, func([]byte) []byte { return nil })
+	cancel := make(chan struct{})
+	close(cancel)
+	if _, err := pm.Map(9981, time.Hour, cancel); err == nil {
+		t.Fatal("expected interrupted request to fail")
+	}
+}
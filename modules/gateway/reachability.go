@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxEncodedDialBackResultLen is the maximum length of the result of a
+	// DialBack RPC.
+	maxEncodedDialBackResultLen = 1024
+
+	// maxEncodedPortLen is the maximum length of a port sent in a DialBack
+	// RPC.
+	maxEncodedPortLen = 16
+)
+
+var (
+	// errNoDialBack is returned when none of the peers was able to check
+	// whether a port is reachable.
+	errNoDialBack = errors.New("no peer was able to dial back")
+
+	// errInvalidPort is returned by the DialBack RPC for ports that aren't
+	// valid.
+	errInvalidPort = errors.New("invalid port")
+)
+
+// dialBack is the handler for the DialBack RPC. It dials the port requested by
+// the caller and reports whether the connection succeeded. Only the IP address
+// the caller is connected from is dialed, which prevents the RPC from being
+// used to make the gateway connect to arbitrary addresses.
+func (g *Gateway) dialBack(conn modules.PeerConn) error {
+	conn.SetDeadline(time.Now().Add(connStdDeadline))
+	var port string
+	if err := modules.ReadPeerObject(conn, &port, maxEncodedPortLen); err != nil {
+		return errors.AddContext(err, "failed to read port")
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return errors.Compose(errInvalidPort, encoding.WriteObject(conn, errInvalidPort.Error()))
+	}
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return errors.AddContext(err, "failed to split host from port")
+	}
+
+	dialer := &net.Dialer{
+		Cancel:  g.threads.StopChan(),
+		Timeout: dialBackTimeout,
+	}
+	var result string
+	dialConn, err := dialer.Dial("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		result = err.Error()
+	} else if err := dialConn.Close(); err != nil {
+		g.log.Debugln("WARN: failed to close dial back connection:", err)
+	}
+	return encoding.WriteObject(conn, result)
+}
+
+// managedCheckReachability asks up to reachabilityCheckPeers peers to dial
+// back a port. Outbound peers are asked first since inbound peers are easier
+// for an attacker to control. The port is reachable if any peer was able to
+// connect to it. An error is returned if no peer was able to perform the
+// check.
+func (g *Gateway) managedCheckReachability(port string) (bool, error) {
+	// Select the peers, outbound peers first.
+	peers := g.Peers()
+	perm := fastrand.Perm(len(peers))
+	var addrs []modules.NetAddress
+	for _, inbound := range []bool{false, true} {
+		for _, i := range perm {
+			if peers[i].Inbound == inbound {
+				addrs = append(addrs, peers[i].NetAddress)
+			}
+		}
+	}
+	if len(addrs) > reachabilityCheckPeers {
+		addrs = addrs[:reachabilityCheckPeers]
+	}
+	if len(addrs) == 0 {
+		return false, errors.Compose(errNoDialBack, errNoPeers)
+	}
+
+	// Ask the peers in parallel.
+	var mu sync.Mutex
+	var reachable, unreachable int
+	var errs []error
+	var wg sync.WaitGroup
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer wg.Done()
+			var result string
+			err := g.managedRPC(addr, "DialBack", func(conn modules.PeerConn) error {
+				if err := encoding.WriteObject(conn, port); err != nil {
+					return err
+				}
+				return encoding.ReadObject(conn, &result, maxEncodedDialBackResultLen)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, errors.AddContext(err, string(addr)))
+			} else if result != "" {
+				g.log.Debugf("INFO: %v was unable to dial back port %v: %v", addr, port, result)
+				unreachable++
+			} else {
+				reachable++
+			}
+		}(addr)
+	}
+	wg.Wait()
+
+	if reachable > 0 {
+		return true, nil
+	} else if unreachable > 0 {
+		return false, nil
+	}
+	return false, errors.Compose(append(errs, errNoDialBack)...)
+}
+
+// managedCheckOwnReachability checks whether the gateway's port is reachable
+// and updates the connectivity of the gateway accordingly.
+func (g *Gateway) managedCheckOwnReachability() (bool, error) {
+	g.mu.RLock()
+	port := g.port
+	g.mu.RUnlock()
+	reachable, err := g.managedCheckReachability(port)
+
+	g.mu.Lock()
+	g.connectivity.LastReachabilityCheck = time.Now()
+	g.connectivity.Reachable = reachable
+	g.connectivity.ReachabilityError = ""
+	if err != nil {
+		g.connectivity.ReachabilityError = err.Error()
+	} else if !reachable {
+		g.connectivity.ReachabilityError = "peers were unable to dial back the gateway"
+	}
+	g.mu.Unlock()
+
+	// Only change the alert if the check was conclusive.
+	if err == nil && reachable {
+		g.staticAlerter.UnregisterAlert(modules.AlertIDGatewayUnreachable)
+	} else if err == nil {
+		g.staticAlerter.RegisterAlert(modules.AlertIDGatewayUnreachable, AlertMSGGatewayUnreachable, "", modules.SeverityWarning)
+	}
+	return reachable, err
+}
+
+// threadedCheckReachability periodically checks whether the gateway is
+// reachable by its peers.
+func (g *Gateway) threadedCheckReachability() {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	// Reachability checks create additional connections between the test
+	// nodes, so they are only performed when requested during testing.
+	if build.Release == "testing" {
+		return
+	}
+
+	for {
+		if !g.managedSleep(reachabilityCheckInterval) {
+			return
+		}
+		if _, err := g.managedCheckOwnReachability(); err != nil {
+			g.log.Debugln("WARN: reachability check failed:", err)
+		}
+	}
+}
+
+// CheckReachability asks peers to dial back a port on the IP address they see
+// the gateway connecting from. If port is empty, the gateway's own port is
+// checked and the result is reported by Connectivity.
+func (g *Gateway) CheckReachability(port string) (bool, error) {
+	if err := g.threads.Add(); err != nil {
+		return false, err
+	}
+	defer g.threads.Done()
+	if port == "" {
+		return g.managedCheckOwnReachability()
+	}
+	return g.managedCheckReachability(port)
+}
+
+// Connectivity returns the port forwarding status of the gateway and the
+// result of the last check of whether its port is reachable.
+func (g *Gateway) Connectivity() modules.GatewayConnectivity {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.connectivity
+}
@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"net"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestCheckReachability tests that peers dial back the ports of the gateway.
+func TestCheckReachability(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without peers the reachability can't be determined.
+	if _, err := g.CheckReachability(""); !errors.Contains(err, errNoDialBack) {
+		t.Fatal("expected errNoDialBack, got", err)
+	}
+	if c := g.Connectivity(); c.Reachable || c.ReachabilityError == "" || c.LastReachabilityCheck.IsZero() {
+		t.Fatal("unexpected connectivity", c)
+	}
+
+	g2 := newNamedTestingGateway(t, "2")
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := connectToNode(g, g2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The gateway's own port should be reachable.
+	reachable, err := g.CheckReachability("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := g.Connectivity(); !reachable || !c.Reachable || c.ReachabilityError != "" {
+		t.Fatal("gateway should be reachable", reachable, c)
+	}
+
+	// Other ports should be reachable only while something listens on them.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	reachable, err = g.CheckReachability(port)
+	if err != nil || !reachable {
+		t.Fatal("listening port should be reachable", reachable, err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reachable, err = g.CheckReachability(port)
+	if err != nil || reachable {
+		t.Fatal("closed port should be unreachable", reachable, err)
+	}
+
+	// Checking a specific port shouldn't change the connectivity of the
+	// gateway.
+	if c := g.Connectivity(); !c.Reachable {
+		t.Fatal("unexpected connectivity", c)
+	}
+
+	// Invalid ports should be rejected by the peer.
+	var result string
+	err = g.managedRPC(g2.Address(), "DialBack", func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, "0"); err != nil {
+			return err
+		}
+		return encoding.ReadObject(conn, &result, maxEncodedDialBackResultLen)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != errInvalidPort.Error() {
+		t.Fatal("expected invalid port to be rejected, got", result)
+	}
+}
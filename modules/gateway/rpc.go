@@ -221,6 +221,7 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	g.mu.RUnlock()
 	if !ok {
 		g.log.Debugf("WARN: incoming conn %v requested unknown RPC \"%v\"", conn.RPCAddr(), id)
+		g.managedPenalizePeer(conn.RPCAddr(), misbehaviorPenaltyUnknownRPC, "unknown RPC")
 		return
 	}
 	g.log.Debugf("INFO: incoming conn %v requested RPC \"%v\"", conn.RPCAddr(), id)
@@ -235,6 +236,9 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	if err != nil {
 		g.log.Debugf("WARN: incoming RPC \"%v\" from conn %v failed: %v", id, conn.RPCAddr(), err)
 	}
+	if errors.Contains(err, modules.ErrPeerMisbehavior) {
+		g.managedPenalizePeer(conn.RPCAddr(), misbehaviorPenaltyProtocolViolation, "protocol violation")
+	}
 	// Log the amount of time it took the handler to do the RPC.
 	g.log.Debugf("%s RPC time: %v", id, time.Since(startRPCTime).Round(time.Millisecond))
 }
@@ -182,17 +182,47 @@ func (g *Gateway) managedForwardPort(port string) error {
 		}
 	}()
 
+	// Look for UPnP-enabled devices and forward the port. If that fails, fall
+	// back to PCP and NAT-PMP.
+	protocol := portForwardingUPNP
+	err = g.managedForwardPortUPNP(ctx, port, uint16(portInt))
+	if err != nil {
+		var pmpErr error
+		protocol, pmpErr = g.managedForwardPortPMP(uint16(portInt))
+		err = errors.Compose(errors.AddContext(err, "UPnP"), pmpErr)
+		if pmpErr == nil {
+			err = nil
+		}
+	}
+
+	// Report the status of the gateway's own port.
+	g.mu.Lock()
+	if port == g.port {
+		g.connectivity.PortForwarding = protocol
+		g.connectivity.PortForwardingError = ""
+		if err != nil {
+			g.connectivity.PortForwarding = ""
+			g.connectivity.PortForwardingError = err.Error()
+		}
+	}
+	g.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("WARN: could not automatically forward port %s: %v", port, err)
+	}
+	return nil
+}
+
+// managedForwardPortUPNP forwards a port using UPnP.
+func (g *Gateway) managedForwardPortUPNP(ctx context.Context, port string, portInt uint16) error {
 	// Look for UPnP-enabled devices
 	d, err := upnp.DiscoverCtx(ctx)
 	if err != nil {
-		err = fmt.Errorf("WARN: could not automatically forward port %s: no UPnP-enabled devices found: %v", port, err)
-		return err
+		return fmt.Errorf("no UPnP-enabled devices found: %v", err)
 	}
 
 	// Forward port
-	err = d.ForwardTCP(uint16(portInt), "Sia RPC")
+	err = d.ForwardTCP(portInt, "Sia RPC")
 	if err != nil {
-		err = fmt.Errorf("WARN: could not automatically forward port %s: %v", port, err)
 		return err
 	}
 
@@ -204,6 +234,58 @@ func (g *Gateway) managedForwardPort(port string) error {
 	return nil
 }
 
+// managedForwardPortPMP forwards a port using PCP or NAT-PMP and returns the
+// protocol that was used. The mapping is renewed until shutdown.
+func (g *Gateway) managedForwardPortPMP(port uint16) (string, error) {
+	router, err := defaultRouter()
+	if err != nil {
+		return "", err
+	}
+	pm := newPortMapper(&net.UDPAddr{IP: router, Port: natpmpServerPort})
+	lifetime, err := pm.Map(port, portMappingLifetime, g.threads.StopChan())
+	if err != nil {
+		return "", err
+	}
+	go g.threadedRenewPortMapping(pm, port, lifetime)
+
+	// Delete the mapping at shutdown. The request can't be interrupted by
+	// the shutdown, but it gives up after a few seconds.
+	g.threads.AfterStop(func() error {
+		if err := pm.Unmap(port, nil); err != nil {
+			g.log.Printf("WARN: could not delete the %v mapping of port %v: %v", pm.Protocol(), port, err)
+		}
+		return nil
+	})
+	return pm.Protocol(), nil
+}
+
+// threadedRenewPortMapping renews a PCP or NAT-PMP port mapping halfway
+// through its lifetime.
+func (g *Gateway) threadedRenewPortMapping(pm *portMapper, port uint16, lifetime time.Duration) {
+	if err := g.threads.Add(); err != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	for {
+		// Don't renew more often than once a minute, even if the router only
+		// grants short lifetimes.
+		wait := lifetime / 2
+		if wait < time.Minute {
+			wait = time.Minute
+		}
+		if !g.managedSleep(wait) {
+			return
+		}
+		granted, err := pm.Map(port, portMappingLifetime, g.threads.StopChan())
+		if err != nil {
+			g.log.Printf("WARN: could not renew the %v mapping of port %v: %v", pm.Protocol(), port, err)
+			continue
+		}
+		lifetime = granted
+	}
+}
+
 // managedClearPort removes a port mapping from the router.
 func (g *Gateway) managedClearPort(port string) {
 	if build.Release == "testing" {
@@ -243,7 +325,7 @@ func (g *Gateway) threadedForwardPort(port string) {
 
 	if err := g.managedForwardPort(port); err != nil {
 		g.log.Debugf("WARN: %v", err)
+		return
 	}
 	g.log.Println("INFO: successfully forwarded port", port)
-	return
 }
@@ -0,0 +1,53 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// TestReadPeerObject tests that ReadPeerObject only flags errors caused by the
+// object sent by the peer as misbehavior.
+func TestReadPeerObject(t *testing.T) {
+	t.Parallel()
+
+	// A valid object is decoded.
+	var buf bytes.Buffer
+	if err := encoding.WriteObject(&buf, uint64(42)); err != nil {
+		t.Fatal(err)
+	}
+	var i uint64
+	if err := ReadPeerObject(&buf, &i, 8); err != nil || i != 42 {
+		t.Fatal("unexpected result", i, err)
+	}
+
+	// A truncated stream is a connection error.
+	buf.Reset()
+	buf.Write(encoding.Marshal(uint64(8)))
+	err := ReadPeerObject(&buf, &i, 8)
+	if err == nil || errors.Contains(err, ErrPeerMisbehavior) || !errors.Contains(err, io.EOF) {
+		t.Fatal("expected connection error", err)
+	}
+
+	// An oversized object is misbehavior.
+	buf.Reset()
+	if err := encoding.WriteObject(&buf, []byte("too long")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadPeerObject(&buf, &i, 8); !errors.Contains(err, ErrPeerMisbehavior) {
+		t.Fatal("expected misbehavior", err)
+	}
+
+	// An object which can't be decoded is misbehavior.
+	buf.Reset()
+	if err := encoding.WriteObject(&buf, uint8(2)); err != nil {
+		t.Fatal(err)
+	}
+	var b bool
+	if err := ReadPeerObject(&buf, &b, 8); !errors.Contains(err, ErrPeerMisbehavior) {
+		t.Fatal("expected misbehavior", err)
+	}
+}
@@ -226,6 +226,17 @@ var (
 	HostWorkingStatusWorking = HostWorkingStatus("working")
 )
 
+var (
+	// HostRegistryEvictionNone means that the host rejects new registry entries
+	// once its registry is full.
+	HostRegistryEvictionNone = HostRegistryEvictionPolicy("none")
+
+	// HostRegistryEvictionEarliestExpiry means that the host evicts the entry
+	// with the earliest expiry to make room for a new entry once its registry
+	// is full.
+	HostRegistryEvictionEarliestExpiry = HostRegistryEvictionPolicy("earliestexpiry")
+)
+
 type (
 	// HostFinancialMetrics provides financial statistics for the host,
 	// including money that is locked in contracts. Though verbose, these
@@ -264,6 +275,95 @@ type (
 		UploadBandwidthRevenue            types.Currency `json:"uploadbandwidthrevenue"`
 	}
 
+	// HostEarnings breaks down the host's revenue by category. Revenue from
+	// ephemeral accounts, which includes payments for registry operations and
+	// MDM programs, is reported as AccountFunding.
+	HostEarnings struct {
+		AccountFunding  types.Currency `json:"accountfunding"`
+		ContractFees    types.Currency `json:"contractfees"`
+		DownloadRevenue types.Currency `json:"downloadrevenue"`
+		StorageRevenue  types.Currency `json:"storagerevenue"`
+		UploadRevenue   types.Currency `json:"uploadrevenue"`
+	}
+
+	// HostContractEarnings contains the earnings of a single storage
+	// obligation. Depending on the status of the obligation, the earnings are
+	// either pending, confirmed or lost.
+	HostContractEarnings struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ObligationStatus string               `json:"obligationstatus"`
+
+		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight `json:"expirationheight"`
+		ProofDeadline     types.BlockHeight `json:"proofdeadline"`
+
+		Pending        HostEarnings   `json:"pending"`
+		Confirmed      HostEarnings   `json:"confirmed"`
+		Lost           HostEarnings   `json:"lost"`
+		LostCollateral types.Currency `json:"lostcollateral"`
+	}
+
+	// HostEarningsReport is a financial report over all storage obligations
+	// with a proof deadline in the range [StartHeight, EndHeight]. An
+	// EndHeight of 0 means that the range is open-ended.
+	HostEarningsReport struct {
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+
+		Pending        HostEarnings   `json:"pending"`
+		Confirmed      HostEarnings   `json:"confirmed"`
+		Lost           HostEarnings   `json:"lost"`
+		LostCollateral types.Currency `json:"lostcollateral"`
+
+		Contracts []HostContractEarnings `json:"contracts"`
+	}
+
+	// HostObligationRisk describes the risk of the host failing the storage
+	// proof of an unresolved storage obligation. MissingSectors is the number
+	// of sectors of the obligation that the host can't find in its storage
+	// folders. ValueAtStake is the value the host loses if the proof is missed.
+	HostObligationRisk struct {
+		ObligationID types.FileContractID `json:"obligationid"`
+
+		ExpirationHeight types.BlockHeight `json:"expirationheight"`
+		ProofDeadline    types.BlockHeight `json:"proofdeadline"`
+
+		DataSize         uint64 `json:"datasize"`
+		SectorRootsCount uint64 `json:"sectorrootscount"`
+		MissingSectors   uint64 `json:"missingsectors"`
+
+		OriginConfirmed   bool `json:"originconfirmed"`
+		RevisionConfirmed bool `json:"revisionconfirmed"`
+		ProofRequired     bool `json:"proofrequired"`
+		ProofConstructed  bool `json:"proofconstructed"`
+
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+		ValueAtStake     types.Currency `json:"valueatstake"`
+
+		// Risks contains a description of every problem found with the
+		// obligation. An obligation without risks is expected to be proven
+		// successfully.
+		Risks []string `json:"risks"`
+	}
+
+	// HostObligationRiskReport lists the host's unresolved storage obligations
+	// sorted by proof deadline. The Proofs fields aggregate the obligations
+	// that require a proof and whose proof window opens within the next Window
+	// blocks or is already open.
+	HostObligationRiskReport struct {
+		BlockHeight types.BlockHeight `json:"blockheight"`
+		Window      types.BlockHeight `json:"window"`
+
+		ProofsDue         uint64         `json:"proofsdue"`
+		ProofsDueData     uint64         `json:"proofsduedata"`
+		ProofsDueValue    types.Currency `json:"proofsduevalue"`
+		ProofsDueAtRisk   uint64         `json:"proofsdueatrisk"`
+		ObligationsAtRisk uint64         `json:"obligationsatrisk"`
+		CollateralAtRisk  types.Currency `json:"collateralatrisk"`
+
+		Obligations []HostObligationRisk `json:"obligations"`
+	}
+
 	// HostInternalSettings contains a list of settings that can be changed.
 	HostInternalSettings struct {
 		AcceptingContracts   bool              `json:"acceptingcontracts"`
@@ -273,6 +373,11 @@ type (
 		NetAddress           NetAddress        `json:"netaddress"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// AlternativeNetAddresses are announced in addition to the NetAddress
+		// to allow renters to reach the host over other transports, e.g. IPv6
+		// or Tor.
+		AlternativeNetAddresses []NetAddress `json:"alternativenetaddresses"`
+
 		Collateral       types.Currency `json:"collateral"`
 		CollateralBudget types.Currency `json:"collateralbudget"`
 		MaxCollateral    types.Currency `json:"maxcollateral"`
@@ -290,6 +395,20 @@ type (
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		// RegistryEvictionPolicy determines what happens to new registry
+		// entries once the registry is full. RegistryExpiryGracePeriod is the
+		// number of blocks an expired entry is kept before it is pruned.
+		RegistryEvictionPolicy    HostRegistryEvictionPolicy `json:"registryevictionpolicy"`
+		RegistryExpiryGracePeriod types.BlockHeight          `json:"registryexpirygraceperiod"`
+
+		// Dynamic pricing settings. If enabled, the host will adjust its
+		// storage and bandwidth prices between the Min and Max prices based on
+		// its utilization and recent demand.
+		DynamicPricing            bool           `json:"dynamicpricing"`
+		MaxDownloadBandwidthPrice types.Currency `json:"maxdownloadbandwidthprice"`
+		MaxStoragePrice           types.Currency `json:"maxstorageprice"`
+		MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -304,6 +423,109 @@ type (
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
 
+	// HostAnnounceReport is the result of a checked host announcement. The
+	// address is either verified or detected using the peers of the gateway
+	// before the announcement is submitted.
+	HostAnnounceReport struct {
+		// NetAddress is the announced address. AutoDetected is true if the
+		// address was detected because none was provided or configured.
+		NetAddress              NetAddress   `json:"netaddress"`
+		AlternativeNetAddresses []NetAddress `json:"alternativenetaddresses"`
+		AutoDetected            bool         `json:"autodetected"`
+
+		// PublicIP is the IP address the peers of the gateway see the node
+		// connecting from. It is empty if it couldn't be determined.
+		PublicIP string `json:"publicip"`
+
+		// Reachable is true if a peer was able to dial back the port of the
+		// announced address.
+		Reachable bool `json:"reachable"`
+
+		// Fee is the estimated fee of the announcement transaction.
+		Fee types.Currency `json:"fee"`
+
+		// DryRun is true if the announcement was only validated. Otherwise
+		// it was submitted to the network.
+		DryRun bool `json:"dryrun"`
+
+		// Warnings contains problems which don't prevent the announcement but
+		// might make the host hard to reach.
+		Warnings []string `json:"warnings"`
+	}
+
+	// HostRegistryMetrics reports the usage of the host's registry. The
+	// counters are reset when the host restarts.
+	HostRegistryMetrics struct {
+		EntriesStored uint64 `json:"entriesstored"`
+		EntriesTotal  uint64 `json:"entriestotal"`
+
+		Updates         uint64 `json:"updates"`
+		RejectedUpdates uint64 `json:"rejectedupdates"`
+		EvictedEntries  uint64 `json:"evictedentries"`
+		PrunedEntries   uint64 `json:"prunedentries"`
+
+		// UpdateRate is the average number of successful updates per hour
+		// since StartTime.
+		StartTime  time.Time `json:"starttime"`
+		UpdateRate float64   `json:"updaterate"`
+	}
+
+	// HostSectorAccessStats reports how frequently the host's sectors are read
+	// by renters. To bound the memory usage, only the reads of a sample of the
+	// sectors are tracked. The statistics are reset when the host restarts.
+	HostSectorAccessStats struct {
+		// TotalReads and TotalBytes count all reads since StartTime.
+		StartTime  time.Time `json:"starttime"`
+		TotalBytes uint64    `json:"totalbytes"`
+		TotalReads uint64    `json:"totalreads"`
+
+		// SampleRate is the fraction of the sectors which are tracked.
+		// EstimatedSectorsRead extrapolates the number of distinct sectors
+		// read from the tracked ones.
+		SampleRate           float64 `json:"samplerate"`
+		TrackedSectors       uint64  `json:"trackedsectors"`
+		EstimatedSectorsRead uint64  `json:"estimatedsectorsread"`
+
+		// Distribution groups the tracked sectors by their number of reads.
+		// HotSectors contains the most frequently read tracked sectors.
+		Distribution []HostSectorAccessBucket `json:"distribution"`
+		HotSectors   []HostSectorAccess       `json:"hotsectors"`
+	}
+
+	// HostSectorAccessBucket contains the sectors which were read between
+	// MinReads and MaxReads times. A MaxReads of 0 means there is no upper
+	// bound. ReadFraction is the fraction of the tracked reads which were
+	// reads of the sectors in the bucket.
+	HostSectorAccessBucket struct {
+		MinReads         uint64  `json:"minreads"`
+		MaxReads         uint64  `json:"maxreads"`
+		EstimatedSectors uint64  `json:"estimatedsectors"`
+		ReadFraction     float64 `json:"readfraction"`
+	}
+
+	// HostSectorAccess contains the reads of a single sector.
+	HostSectorAccess struct {
+		MerkleRoot crypto.Hash `json:"merkleroot"`
+		Bytes      uint64      `json:"bytes"`
+		LastRead   time.Time   `json:"lastread"`
+		Reads      uint64      `json:"reads"`
+	}
+
+	// HostPriceChange describes a single adjustment of the host's prices made
+	// by the dynamic pricing engine, together with the inputs that led to it.
+	HostPriceChange struct {
+		BlockHeight types.BlockHeight `json:"blockheight"`
+		Timestamp   time.Time         `json:"timestamp"`
+
+		// Demand and Utilization are both in the range [0, 1].
+		Demand      float64 `json:"demand"`
+		Utilization float64 `json:"utilization"`
+
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	}
+
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
@@ -352,6 +574,11 @@ type (
 	// one of "checking", "connectable", or "not connectable"
 	HostConnectabilityStatus string
 
+	// HostRegistryEvictionPolicy describes how the host makes room for new
+	// registry entries once its registry is full. Can be one of "none" or
+	// "earliestexpiry".
+	HostRegistryEvictionPolicy string
+
 	// A Host can take storage from disk and offer it to the network, managing
 	// things such as announcements, settings, and implementing all of the RPCs
 	// of the host protocol.
@@ -391,9 +618,21 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// CheckedAnnounce detects or verifies the address of the host,
+		// checks that it is reachable and estimates the fee before submitting
+		// an announcement. If dryRun is true, the announcement is only
+		// validated.
+		CheckedAnnounce(addr NetAddress, dryRun bool) (HostAnnounceReport, error)
+
 		// The host needs to be able to shut down.
 		Close() error
 
+		// CreateBackup creates a backup of the host's keys, settings and
+		// storage obligations, as well as the information required to
+		// reconnect its storage folders. The backup doesn't contain any sector
+		// data.
+		CreateBackup(dst string) error
+
 		// ConnectabilityStatus returns the connectability status of the host,
 		// that is, if it can connect to itself on the configured NetAddress.
 		ConnectabilityStatus() HostConnectabilityStatus
@@ -406,6 +645,16 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// EarningsReport returns a breakdown of the host's earnings for all
+		// storage obligations with a proof deadline within the provided range.
+		EarningsReport(startHeight, endHeight types.BlockHeight) HostEarningsReport
+
+		// ObligationRiskReport returns the host's unresolved storage
+		// obligations sorted by proof deadline together with the risks of
+		// failing their storage proofs. The proofs due within the next
+		// 'window' blocks are aggregated.
+		ObligationRiskReport(window types.BlockHeight) (HostObligationRiskReport, error)
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -420,12 +669,20 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// LoadBackup restores a backup created with CreateBackup. The host
+		// can't have any storage obligations or storage folders of its own.
+		LoadBackup(src string) error
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
 		PaymentProcessor
 
+		// PriceHistory returns the price changes made by the host's dynamic
+		// pricing engine, ordered from oldest to newest.
+		PriceHistory() []HostPriceChange
+
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
@@ -447,6 +704,15 @@ type (
 		// 'length' bytes at offset 'offset' that match the input sector root.
 		ReadPartialSector(sectorRoot crypto.Hash, offset, length uint64) ([]byte, error)
 
+		// RegistryMetrics returns information about the usage of the host's
+		// registry.
+		RegistryMetrics() HostRegistryMetrics
+
+		// SectorAccessStats returns how frequently the host's sectors are read
+		// by renters, including up to limit of the most frequently read
+		// sectors.
+		SectorAccessStats(limit int) HostSectorAccessStats
+
 		// RemoveSector will remove a sector from the host. The height at which
 		// the sector expires should be provided, so that the auto-expiry
 		// information for that sector can be properly updated.
@@ -514,6 +780,22 @@ func (his HostInternalSettings) MaxSectorAccessPrice() types.Currency {
 	return his.MinDownloadBandwidthPrice.Mul64(MaxSectorAccessPriceVsBandwidth)
 }
 
+// Add returns the sum of two HostEarnings.
+func (he HostEarnings) Add(other HostEarnings) HostEarnings {
+	return HostEarnings{
+		AccountFunding:  he.AccountFunding.Add(other.AccountFunding),
+		ContractFees:    he.ContractFees.Add(other.ContractFees),
+		DownloadRevenue: he.DownloadRevenue.Add(other.DownloadRevenue),
+		StorageRevenue:  he.StorageRevenue.Add(other.StorageRevenue),
+		UploadRevenue:   he.UploadRevenue.Add(other.UploadRevenue),
+	}
+}
+
+// Total returns the sum of all categories.
+func (he HostEarnings) Total() types.Currency {
+	return he.AccountFunding.Add(he.ContractFees).Add(he.DownloadRevenue).Add(he.StorageRevenue).Add(he.UploadRevenue)
+}
+
 // DefaultHostExternalSettings returns HostExternalSettings with certain default
 // fields set. NetAddress, RemainingStorage, TotalStorage, UnlockHash, RevisionNumber and SiaMuxPort are not set.
 func DefaultHostExternalSettings() HostExternalSettings {
@@ -1,6 +1,11 @@
 package host
 
-import "go.sia.tech/siad/modules"
+import (
+	"fmt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
 
 // Alerts implements the modules.Alerter interface for the host.
 func (h *Host) Alerts() (crit, err, warn, info []modules.Alert) {
@@ -23,3 +28,31 @@ func (h *Host) tryUnregisterInsufficientCollateralBudgetAlert() {
 		h.staticAlerter.UnregisterAlert(modules.AlertIDHostInsufficientCollateral)
 	}
 }
+
+// updateCollateralBudgetAlerts refreshes both collateral budget alerts. It has
+// to be called whenever the locked storage collateral or the collateral budget
+// changes, since either one can push the host above or below the alert
+// thresholds.
+func (h *Host) updateCollateralBudgetAlerts() {
+	h.tryUnregisterInsufficientCollateralBudgetAlert()
+	h.updateCollateralBudgetLowAlert()
+}
+
+// collateralBudgetLow returns true if less than 1/collateralBudgetLowDivisor
+// of the collateral budget remains after subtracting the locked collateral.
+func collateralBudgetLow(budget, locked types.Currency) bool {
+	return locked.Add(budget.Div64(collateralBudgetLowDivisor)).Cmp(budget) > 0
+}
+
+// updateCollateralBudgetLowAlert registers or unregisters the alert for a
+// nearly exhausted collateral budget.
+func (h *Host) updateCollateralBudgetLowAlert() {
+	budget := h.settings.CollateralBudget
+	locked := h.financialMetrics.LockedStorageCollateral
+	if !collateralBudgetLow(budget, locked) {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostCollateralBudgetLow)
+		return
+	}
+	cause := fmt.Sprintf("%v of the collateral budget of %v are locked in contracts", locked.HumanString(), budget.HumanString())
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostCollateralBudgetLow, AlertMSGHostCollateralBudgetLow, cause, modules.SeverityWarning)
+}
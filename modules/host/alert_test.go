@@ -0,0 +1,42 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestAdvertisedCollateral is a unit test for collateralBudgetLow and
+// advertisedCollateral.
+func TestAdvertisedCollateral(t *testing.T) {
+	t.Parallel()
+
+	collateral := types.NewCurrency64(100)
+	budget := types.NewCurrency64(1000)
+	tests := []struct {
+		locked     uint64
+		low        bool
+		collateral uint64
+	}{
+		{0, false, 100},
+		{900, false, 100},
+		{901, true, 99},
+		{950, true, 50},
+		{1000, true, 0},
+		{1100, true, 0},
+	}
+	for _, test := range tests {
+		locked := types.NewCurrency64(test.locked)
+		if low := collateralBudgetLow(budget, locked); low != test.low {
+			t.Errorf("%v: expected low to be %v", test.locked, test.low)
+		}
+		if c := advertisedCollateral(collateral, budget, locked); !c.Equals64(test.collateral) {
+			t.Errorf("%v: expected collateral %v but got %v", test.locked, test.collateral, c)
+		}
+	}
+
+	// A zero budget without locked collateral isn't considered low.
+	if collateralBudgetLow(types.ZeroCurrency, types.ZeroCurrency) {
+		t.Fatal("zero budget shouldn't be low")
+	}
+}
@@ -3,17 +3,44 @@ package host
 import (
 	"fmt"
 	"net"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// announcementTxnSize is the estimated size in bytes of a host
+	// announcement transaction.
+	announcementTxnSize = 600
+)
+
+var (
+	// announceDiscoveryTimeout is the time a checked announcement waits for
+	// the peers of the gateway to report the public IP of the node.
+	announceDiscoveryTimeout = build.Select(build.Var{
+		Standard: time.Minute,
+		Testnet:  time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
 )
 
 var (
 	// errAnnWalletLocked is returned during a host announcement if the wallet
 	// is locked.
 	errAnnWalletLocked = errors.New("cannot announce the host while the wallet is locked")
+
+	// errAnnInsufficientBalance is returned by a checked announcement if the
+	// wallet can't pay the fee of the announcement.
+	errAnnInsufficientBalance = errors.New("the wallet balance is insufficient to pay the announcement fee")
+
+	// errAnnUnreachable is returned by a checked announcement if the peers of
+	// the gateway were unable to dial back the announced port.
+	errAnnUnreachable = errors.New("the announced port isn't reachable by the peers of the gateway")
 )
 
 // differentTypeIPs is a helper that returns true if two IPs are of a different
@@ -22,6 +49,20 @@ func differentTypeIPs(ip1, ip2 net.IP) bool {
 	return (ip1.To4() == nil) != (ip2.To4() == nil)
 }
 
+// equalNetAddresses is a helper that returns true if two lists of net
+// addresses are equal.
+func equalNetAddresses(addrs1, addrs2 []modules.NetAddress) bool {
+	if len(addrs1) != len(addrs2) {
+		return false
+	}
+	for i := range addrs1 {
+		if addrs1[i] != addrs2[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // staticVerifyAnnouncementAddress checks that the address is sane and not local.
 func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	// Check that the address is sane, and that the address is also not local.
@@ -49,10 +90,48 @@ func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	return nil
 }
 
+// staticVerifyAlternativeAddress checks that an alternative address is sane
+// and not local. Unlike the primary address, alternative addresses aren't
+// required to resolve to an IP since they might only be reachable over
+// alternative transports like Tor.
+func staticVerifyAlternativeAddress(addr modules.NetAddress) error {
+	if err := addr.IsStdValid(); err != nil {
+		return build.ExtendErr("announcement requested with bad alternative net address", err)
+	}
+	if addr.IsLocal() && (build.Release == "standard" || build.Release == "testnet") {
+		return errors.New("announcement requested with local alternative net address")
+	}
+	return nil
+}
+
+// managedVerifyAnnouncement verifies the announced address and the
+// alternative addresses of the host. It returns the alternative addresses.
+func (h *Host) managedVerifyAnnouncement(addr modules.NetAddress) ([]modules.NetAddress, error) {
+	if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
+		return nil, err
+	}
+	h.mu.RLock()
+	alternatives := append([]modules.NetAddress(nil), h.settings.AlternativeNetAddresses...)
+	h.mu.RUnlock()
+	for _, alt := range alternatives {
+		if err := staticVerifyAlternativeAddress(alt); err != nil {
+			return nil, err
+		}
+	}
+	return alternatives, nil
+}
+
+// staticAnnouncementFee estimates the fee of a host announcement transaction.
+func (h *Host) staticAnnouncementFee() types.Currency {
+	_, fee := h.tpool.FeeEstimation()
+	return fee.Mul64(announcementTxnSize)
+}
+
 // managedAnnounce creates an announcement transaction and submits it to the network.
 func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	// Verify address first.
-	if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
+	alternatives, err := h.managedVerifyAnnouncement(addr)
+	if err != nil {
 		return err
 	}
 
@@ -78,7 +157,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 
 	// Create the announcement that's going to be added to the arbitrary data
 	// field of the transaction.
-	signedAnnouncement, err := modules.CreateAnnouncement(addr, pubKey, secKey)
+	signedAnnouncement, err := modules.CreateAnnouncementWithAddresses(addr, alternatives, pubKey, secKey)
 	if err != nil {
 		return err
 	}
@@ -93,8 +172,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 			txnBuilder.Drop()
 		}
 	}()
-	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	fee := h.staticAnnouncementFee()
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
 		return err
@@ -115,7 +193,11 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	h.mu.Lock()
 	h.announced = true
 	h.mu.Unlock()
-	h.log.Printf("INFO: Successfully announced as %v", addr)
+	if len(alternatives) > 0 {
+		h.log.Printf("INFO: Successfully announced as %v with alternative addresses %v", addr, alternatives)
+	} else {
+		h.log.Printf("INFO: Successfully announced as %v", addr)
+	}
 	return nil
 }
 
@@ -174,3 +256,111 @@ func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
 	h.mu.Unlock()
 	return nil
 }
+
+// CheckedAnnounce announces the host after checking that it can be reached.
+// If addr is empty, the configured address of the host is used. If no address
+// is configured either, the address is detected by asking the peers of the
+// gateway for the public IP of the node. A configured address is verified to
+// resolve to that IP. The port of the address has to be reachable by the peers
+// of the gateway. If dryRun is true, the announcement is validated but not
+// submitted.
+func (h *Host) CheckedAnnounce(addr modules.NetAddress, dryRun bool) (modules.HostAnnounceReport, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostAnnounceReport{}, err
+	}
+	defer h.tg.Done()
+
+	h.mu.RLock()
+	userSet := h.settings.NetAddress
+	port := h.port
+	h.mu.RUnlock()
+	manual := addr != ""
+	if !manual {
+		addr = userSet
+	}
+	report := modules.HostAnnounceReport{
+		DryRun:   dryRun,
+		Warnings: []string{},
+	}
+
+	// Ask the peers of the gateway for the public IP of the node. It is used
+	// to detect the address or to verify the provided one.
+	cancel := make(chan struct{})
+	timer := time.AfterFunc(announceDiscoveryTimeout, func() { close(cancel) })
+	ip, err := h.g.DiscoverAddress(cancel)
+	timer.Stop()
+	if err == nil {
+		report.PublicIP = ip.String()
+	}
+	if addr == "" {
+		if err != nil {
+			return report, errors.AddContext(err, "failed to detect the address of the host")
+		}
+		addr = modules.NetAddress(net.JoinHostPort(ip.String(), port))
+		report.AutoDetected = true
+	} else if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("unable to verify the address since the public IP couldn't be determined: %v", err))
+	} else if ips, err := h.dependencies.LookupIP(addr.Host()); err == nil && !containsIP(ips, ip) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("%v doesn't resolve to the public IP %v of the node", addr.Host(), ip))
+	}
+	report.NetAddress = addr
+
+	// Validate the addresses.
+	alternatives, err := h.managedVerifyAnnouncement(addr)
+	if err != nil {
+		return report, err
+	}
+	report.AlternativeNetAddresses = alternatives
+
+	// Ask the peers of the gateway to dial back the announced port. An
+	// inconclusive check doesn't prevent the announcement.
+	reachable, err := h.g.CheckReachability(addr.Port())
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("unable to check whether the host is reachable: %v", err))
+	} else if !reachable {
+		return report, errAnnUnreachable
+	}
+	report.Reachable = reachable
+
+	// Check that the wallet can pay the fee.
+	report.Fee = h.staticAnnouncementFee()
+	unlocked, err := h.wallet.Unlocked()
+	if err != nil {
+		return report, err
+	}
+	if !unlocked {
+		return report, errAnnWalletLocked
+	}
+	balance, _, _, err := h.wallet.ConfirmedBalance()
+	if err != nil {
+		return report, err
+	}
+	if balance.Cmp(report.Fee) < 0 {
+		return report, errAnnInsufficientBalance
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	// Submit the announcement. Like AnnounceAddress, a manually provided
+	// address becomes the address of the host.
+	if err := h.managedAnnounce(addr); err != nil {
+		return report, errors.AddContext(err, "unable to perform checked host announcement")
+	}
+	if manual {
+		h.mu.Lock()
+		h.settings.NetAddress = addr
+		h.mu.Unlock()
+	}
+	return report, nil
+}
+
+// containsIP is a helper that returns true if the list of IPs contains the IP.
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
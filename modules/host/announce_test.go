@@ -154,6 +154,75 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 }
 
+// TestHostCheckedAnnounce probes the validation of a checked announcement.
+func TestHostCheckedAnnounce(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := af.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// An invalid address is rejected.
+	if _, err := ht.host.CheckedAnnounce("foo", true); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+
+	// The gateway of the tester has no peers. The address can't be verified
+	// but is still valid.
+	addr := modules.NetAddress(net.JoinHostPort("localhost", ht.host.port))
+	report, err := ht.host.CheckedAnnounce(addr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.NetAddress != addr || report.AutoDetected || !report.DryRun || report.Reachable {
+		t.Fatal("unexpected report", report)
+	}
+	if report.Fee.IsZero() || len(report.Warnings) != 2 {
+		t.Fatal("expected a fee and warnings about the missing peers", report.Fee, report.Warnings)
+	}
+
+	// A dry run doesn't submit the announcement.
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(af.netAddresses) != 0 {
+		t.Fatal("dry run was announced")
+	}
+	report, err = ht.host.CheckedAnnounce(addr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.DryRun {
+		t.Fatal("unexpected report", report)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(af.netAddresses) != 1 || af.netAddresses[0] != addr {
+		t.Fatal("could not find host announcement in blockchain", af.netAddresses)
+	}
+	if ht.host.InternalSettings().NetAddress != addr {
+		t.Fatal("address of the host wasn't updated")
+	}
+}
+
 // TestHostAnnounceCheckUnlockHash verifies that the host's unlock hash is
 // checked when an announcement is performed.
 func TestHostAnnounceCheckUnlockHash(t *testing.T) {
@@ -0,0 +1,186 @@
+package host
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+var (
+	// hostBackupMetadata is the header of a host backup file.
+	hostBackupMetadata = persist.Metadata{
+		Header:  "Sia Host Backup",
+		Version: "1.5.1",
+	}
+
+	// errRestoreHostNotEmpty is returned if a backup is restored into a host
+	// which already has storage obligations.
+	errRestoreHostNotEmpty = errors.New("can't restore a backup into a host which already has storage obligations")
+
+	// errHostRescanning is returned to RPCs which are received while the
+	// host rescans the consensus set.
+	errHostRescanning = errors.New("host is rescanning the consensus set")
+)
+
+// hostBackup is the data written to disk when creating a backup of the host.
+type hostBackup struct {
+	Persistence        persistence                  `json:"persistence"`
+	StorageManager     modules.StorageManagerBackup `json:"storagemanager"`
+	StorageObligations []storageObligation          `json:"storageobligations"`
+}
+
+// CreateBackup creates a backup of the host's keys, settings and storage
+// obligations, as well as the information required to reconnect its storage
+// folders. The backup doesn't contain any sector data.
+func (h *Host) CreateBackup(dst string) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	backup := hostBackup{
+		StorageManager: h.StorageManager.StorageFoldersBackup(),
+	}
+	h.mu.RLock()
+	backup.Persistence = h.persistData()
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return errors.AddContext(err, "unable to unmarshal storage obligation")
+			}
+			backup.StorageObligations = append(backup.StorageObligations, so)
+			return nil
+		})
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		return errors.AddContext(err, "failed to read storage obligations")
+	}
+	return persist.SaveJSON(hostBackupMetadata, backup, dst)
+}
+
+// LoadBackup restores a backup created with CreateBackup. The host can't have
+// any storage obligations or storage folders of its own. After restoring the
+// backup, the host rescans the consensus set to pick up any changes to the
+// restored storage obligations since the backup was created.
+func (h *Host) LoadBackup(src string) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	var backup hostBackup
+	err := persist.LoadJSON(hostBackupMetadata, &backup, src)
+	if err != nil {
+		return errors.AddContext(err, "failed to load backup")
+	}
+
+	// Make sure the host doesn't have any storage obligations yet.
+	h.mu.RLock()
+	var numObligations int
+	err = h.db.View(func(tx *bolt.Tx) error {
+		numObligations = tx.Bucket(bucketStorageObligations).Stats().KeyN
+		return nil
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	if numObligations > 0 {
+		return errRestoreHostNotEmpty
+	}
+
+	// Reconnect the storage folders. The sectors of resolved storage
+	// obligations have already been removed.
+	var sectorRoots []crypto.Hash
+	for _, so := range backup.StorageObligations {
+		if so.ObligationStatus == obligationUnresolved {
+			sectorRoots = append(sectorRoots, so.SectorRoots...)
+		}
+	}
+	err = h.StorageManager.RestoreStorageFolders(backup.StorageManager, sectorRoots)
+	if err != nil {
+		return errors.AddContext(err, "failed to restore storage folders")
+	}
+
+	// Restore the storage obligations and the host's identity. The registry
+	// isn't part of the backup, so its settings are kept.
+	h.mu.Lock()
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		for _, so := range backup.StorageObligations {
+			if err := putStorageObligation(tx, so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.mu.Unlock()
+		return errors.AddContext(err, "failed to restore storage obligations")
+	}
+	p := backup.Persistence
+	p.BlockHeight = h.blockHeight
+	p.RecentChange = h.recentChange
+	p.Settings.CustomRegistryPath = h.settings.CustomRegistryPath
+	p.Settings.RegistrySize = h.settings.RegistrySize
+	h.loadPersistObject(&p)
+	h.revisionNumber++
+	err = h.saveSync()
+	publicKey := h.publicKey
+	h.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "failed to save restored host")
+	}
+
+	// The siamux only picks up the restored key after a restart.
+	muxKey := h.staticMux.PublicKey()
+	if !bytes.Equal(publicKey.Key, muxKey[:]) {
+		h.log.Println("WARN: restored host key doesn't match the siamux key, siad needs to be restarted")
+		h.staticAlerter.RegisterAlert(modules.AlertIDHostRestoredKeyMismatch, AlertMSGHostRestoredKeyMismatch, "", modules.SeverityWarning)
+	}
+
+	// Rescan the consensus set to update the restored storage obligations.
+	err = h.managedRescan()
+	if err != nil {
+		return errors.AddContext(err, "failed to rescan consensus set")
+	}
+	h.managedUpdatePriceTable()
+	return nil
+}
+
+// managedRescan resubscribes the host to the consensus set from the beginning.
+// This is a blocking call that will not return until the host has fully caught
+// up to the current block. Since the host's block height is reset, incoming
+// RPCs are refused until the rescan is done.
+func (h *Host) managedRescan() error {
+	atomic.StoreUint64(&h.atomicRescanning, 1)
+	defer atomic.StoreUint64(&h.atomicRescanning, 0)
+
+	h.cs.Unsubscribe(h)
+
+	h.mu.Lock()
+	h.blockHeight = 0
+	allObligations, err := h.resetStorageObligationsForRescan()
+	h.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = h.cs.ConsensusSetSubscribe(h, modules.ConsensusChangeBeginning, h.tg.StopChan())
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	h.requeueRescannedObligations(allObligations)
+	h.mu.Unlock()
+	return nil
+}
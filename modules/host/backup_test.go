@@ -0,0 +1,161 @@
+package host
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// TestHostBackup checks that a backup of a host can be restored into a new
+// host which reuses the storage folders of the original host.
+func TestHostBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a storage obligation with a sector.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoot, sectorData := randSector()
+	so.SectorRoots = []crypto.Hash{sectorRoot}
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedUnlockStorageObligation(so.id())
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the backup and close the host.
+	backupPath := filepath.Join(ht.persistDir, "host.backup")
+	err = ht.host.CreateBackup(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKey := ht.host.PublicKey()
+	storageFolders := ht.host.StorageFolders()
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore the backup into a new host.
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, "restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.LoadBackup(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The identity, storage folders, obligation and sector should be
+	// restored.
+	if ht.host.PublicKey().String() != publicKey.String() {
+		t.Fatal("public key wasn't restored")
+	}
+	sfs := ht.host.StorageFolders()
+	if len(sfs) != len(storageFolders) {
+		t.Fatal("wrong number of storage folders", len(sfs), len(storageFolders))
+	}
+	remaining := make(map[string]uint64)
+	for _, sf := range storageFolders {
+		remaining[sf.Path] = sf.CapacityRemaining
+	}
+	for _, sf := range sfs {
+		if cr, exists := remaining[sf.Path]; !exists || cr != sf.CapacityRemaining {
+			t.Fatal("storage folder wasn't restored", sf)
+		}
+	}
+	restored, err := ht.host.managedGetStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored.OriginConfirmed {
+		t.Fatal("origin of restored obligation should be confirmed after the rescan")
+	}
+	data, err := ht.host.ReadSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, sectorData) {
+		t.Fatal("wrong sector data")
+	}
+	if ht.host.BlockHeight() != ht.cs.Height() {
+		t.Fatal("host didn't catch up with the consensus set", ht.host.BlockHeight(), ht.cs.Height())
+	}
+
+	// Restoring the backup again should fail.
+	err = ht.host.LoadBackup(backupPath)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The obligations should survive a restart.
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, "restored"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.host.managedGetStorageObligation(so.id()); err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.PublicKey().String() != publicKey.String() {
+		t.Fatal("public key wasn't persisted")
+	}
+}
+
+// TestRescanRefusesRPCs checks that the host refuses RPCs while it rescans the
+// consensus set.
+func TestRescanRefusesRPCs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	pair, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := pair.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	atomic.StoreUint64(&pair.staticHT.host.atomicRescanning, 1)
+	err = pair.managedUpdatePriceTable(true)
+	if err == nil || !strings.Contains(err.Error(), errHostRescanning.Error()) {
+		t.Fatal("expected errHostRescanning, got", err)
+	}
+	atomic.StoreUint64(&pair.staticHT.host.atomicRescanning, 0)
+	err = pair.managedUpdatePriceTable(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -9,12 +9,27 @@ import (
 
 // Constants related to the host's alerts.
 const (
+	// AlertMSGHostCollateralBudgetLow indicates that a host's collateral
+	// budget is nearly exhausted
+	AlertMSGHostCollateralBudgetLow = "host's collateral budget is nearly exhausted"
+
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+
+	// AlertMSGHostRestoredKeyMismatch indicates that the host's key was
+	// restored from a backup and siad needs to be restarted for the siamux to
+	// use it
+	AlertMSGHostRestoredKeyMismatch = "host key was restored from a backup, restart siad to use it for incoming connections"
 )
 
 const (
+	// collateralBudgetLowDivisor determines when the host's collateral budget
+	// is considered low. Once less than 1/collateralBudgetLowDivisor of the
+	// budget remains, the host registers an alert and starts reducing its
+	// advertised collateral.
+	collateralBudgetLowDivisor = 10
+
 	// iteratedConnectionTime is the amount of time that is allowed to pass
 	// before the host will stop accepting new iterations on an iterated
 	// connection.
@@ -149,3 +149,16 @@ var (
 		Testing:  time.Second * 8,
 	}).(time.Duration)
 )
+
+var (
+	// sectorMigrationRate is the maximum number of bytes per second that are
+	// moved between storage folders when a storage folder is shrunk or
+	// removed. The migration is throttled to leave enough disk bandwidth for
+	// serving renters.
+	sectorMigrationRate = build.Select(build.Var{
+		Dev:      uint64(1 << 28), // 256 MiB/s
+		Standard: uint64(1 << 26), // 64 MiB/s
+		Testnet:  uint64(1 << 26), // 64 MiB/s
+		Testing:  uint64(1 << 40), // 1 TiB/s
+	}).(uint64)
+)
@@ -270,6 +270,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		err = errors.New("startup disrupted")
 		return nil, err
 	}
+
+	// Resume the storage folder resizes that were interrupted by the last
+	// shutdown.
+	go cm.threadedResumeStorageFolderResizes()
 	return cm, nil
 }
 
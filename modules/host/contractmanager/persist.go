@@ -22,6 +22,11 @@ type (
 		Index uint16
 		Path  string
 		Usage []uint64
+
+		// ResizeTarget and ResizeForce describe a resize of the storage
+		// folder that was in progress and needs to be resumed at startup.
+		ResizeTarget uint32
+		ResizeForce  bool
 	}
 
 	// savedSettings contains fields that are saved atomically to disk inside
@@ -41,7 +46,7 @@ func (s *savedSettings) equals(sb savedSettings) bool {
 	for i, sf := range s.StorageFolders {
 		sfb := sb.StorageFolders[i]
 
-		if sf.Index != sfb.Index || sf.Path != sfb.Path || len(sf.Usage) != len(sfb.Usage) {
+		if sf.Index != sfb.Index || sf.Path != sfb.Path || len(sf.Usage) != len(sfb.Usage) || sf.ResizeTarget != sfb.ResizeTarget || sf.ResizeForce != sfb.ResizeForce {
 			return false
 		}
 
@@ -61,6 +66,9 @@ func (sf *storageFolder) savedStorageFolder() savedStorageFolder {
 		Index: sf.index,
 		Path:  sf.path,
 		Usage: make([]uint64, len(sf.usage)),
+
+		ResizeTarget: sf.resizeTarget,
+		ResizeForce:  sf.resizeForce,
 	}
 	copy(ssf.Usage, sf.usage)
 	return ssf
@@ -102,6 +110,8 @@ func (cm *ContractManager) loadSettings() error {
 		sf.index = ss.StorageFolders[i].Index
 		sf.path = ss.StorageFolders[i].Path
 		sf.usage = ss.StorageFolders[i].Usage
+		sf.resizeTarget = ss.StorageFolders[i].ResizeTarget
+		sf.resizeForce = ss.StorageFolders[i].ResizeForce
 		sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(ss.StorageFolders[i].Path, metadataFile), os.O_RDWR, 0700)
 		if err != nil {
 			// Mark the folder as unavailable and log an error.
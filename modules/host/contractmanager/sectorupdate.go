@@ -196,6 +196,7 @@ func (wal *writeAheadLog) managedAddVirtualSector(id sectorID, location sectorLo
 	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 		// Need to check that the storage folder exists before syncing the
 		// commit that increases the virtual sector count.
+		wal.cm.sectorMu.Unlock()
 		wal.mu.Unlock()
 		return errStorageFolderNotFound
 	}
@@ -585,6 +586,39 @@ func (cm *ContractManager) AddSector(root crypto.Hash, sectorData []byte) error
 	return nil
 }
 
+// AddVirtualSector adds a virtual sector for a sector which is already stored
+// by the contract manager. Unlike AddSector, it never writes a new physical
+// sector. If the sector doesn't exist, ErrSectorNotFound is returned. The
+// existence check and the increment happen under the same sector lock, so a
+// concurrent removal of the last reference can't slip in between.
+func (cm *ContractManager) AddVirtualSector(root crypto.Hash) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	id := cm.managedSectorID(root)
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	cm.sectorMu.Lock()
+	location, exists := cm.sectorLocations[id]
+	cm.sectorMu.Unlock()
+	if !exists {
+		return ErrSectorNotFound
+	}
+	err = cm.wal.managedAddVirtualSector(id, location)
+	if errors.Contains(err, errDiskTrouble) {
+		cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
+	}
+	if err != nil {
+		cm.log.Println("ERROR: Unable to add virtual sector:", err)
+		return err
+	}
+	return nil
+}
+
 // AddSectorBatch is a non-ACID call to add a bunch of sectors at once.
 // Necessary for compatibility with old renters.
 //
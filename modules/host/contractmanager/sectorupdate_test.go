@@ -692,6 +692,72 @@ func TestAddVirtualSectorSerial(t *testing.T) {
 	}
 }
 
+// TestAddVirtualSectorMissing checks that AddVirtualSector only adds a
+// reference to sectors which are already stored and never writes a new
+// physical sector.
+func TestAddVirtualSectorMissing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder to the contract manager tester.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding a reference to a sector which isn't stored should fail.
+	root, data := randSector()
+	err = cmt.cm.AddVirtualSector(root)
+	if !errors.Is(err, ErrSectorNotFound) {
+		t.Fatal("expected ErrSectorNotFound", err)
+	}
+	if len(cmt.cm.sectorLocations) != 0 {
+		t.Fatal("no sector should have been added")
+	}
+
+	// Once the sector is stored, the reference should be added.
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddVirtualSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sl := range cmt.cm.sectorLocations {
+		if sl.count != 2 {
+			t.Fatal("expected the sector to have 2 references", sl.count)
+		}
+	}
+
+	// After removing both references, adding a reference should fail again.
+	for i := 0; i < 2; i++ {
+		err = cmt.cm.RemoveSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddVirtualSector(root)
+	if !errors.Is(err, ErrSectorNotFound) {
+		t.Fatal("expected ErrSectorNotFound", err)
+	}
+	if len(cmt.cm.sectorLocations) != 0 {
+		t.Fatal("no sector should have been added")
+	}
+}
+
 // TestAddVirtualSectorParallel adds a sector and a virual sector in parallel
 // to the contract manager.
 func TestAddVirtualSectorParallel(t *testing.T) {
@@ -2,7 +2,6 @@ package contractmanager
 
 import (
 	"encoding/hex"
-	"errors"
 	"fmt"
 	"math"
 	"os"
@@ -10,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/modules"
@@ -31,6 +31,10 @@ var (
 	// storage folder would exceed the maximum allowed size.
 	ErrLargeStorageFolder = fmt.Errorf("maximum allowed size for a storage folder is %v (%v bytes)", modules.FilesizeUnits(maxFolderSize), maxFolderSize)
 
+	// errFolderOperationInterrupted is returned if a long running operation on
+	// a storage folder was interrupted by shutdown.
+	errFolderOperationInterrupted = errors.New("storage folder operation was interrupted by shutdown")
+
 	// errMaxStorageFolders indicates that the limit on the number of allowed
 	// storage folders has been reached.
 	errMaxStorageFolders = fmt.Errorf("host can only accept up to %v storage folders", maximumStorageFolders)
@@ -45,6 +49,10 @@ var (
 	// that is the same as the current size of the storage folder.
 	ErrNoResize = errors.New("storage folder selected for resize, but new size is same as current size")
 
+	// errResizeInProgress is returned if a storage folder is resized while
+	// another resize of the folder is still in progress.
+	errResizeInProgress = errors.New("storage folder is already being resized")
+
 	// errRelativePath is returned if a path must be absolute.
 	errRelativePath = errors.New("storage folder paths must be absolute")
 
@@ -80,9 +88,12 @@ type storageFolder struct {
 	mu sync.TryRWMutex
 
 	// Progress statistics that can be reported to the user. Typically for long
-	// running actions like adding or resizing a storage folder.
+	// running actions like adding or resizing a storage folder. The start time
+	// of the action is stored in unix nanoseconds to estimate the remaining
+	// time.
 	atomicProgressNumerator   uint64
 	atomicProgressDenominator uint64
+	atomicProgressStart       uint64
 
 	// Disk statistics for this boot cycle.
 	atomicFailedReads      uint64
@@ -110,6 +121,14 @@ type storageFolder struct {
 	availableSectors map[sectorID]uint32
 	sectors          uint64
 
+	// resizeTarget is the number of sectors the storage folder is being
+	// resized to, or 0 if there is no resize in progress. It is persisted
+	// together with resizeForce so that an interrupted resize can be resumed
+	// after a restart. resizeErr is the error of the last failed resize.
+	resizeTarget uint32
+	resizeForce  bool
+	resizeErr    string
+
 	// An open file handle is kept so that writes can easily be made to the
 	// storage folder without needing to grab a new file handle. This also
 	// makes it easy to do delayed-syncing.
@@ -279,6 +298,37 @@ func (sf *storageFolder) setUsage(sectorIndex uint32) {
 	}
 }
 
+// startProgress resets the progress statistics of the storage folder for a
+// new long running action that processes 'total' bytes.
+func (sf *storageFolder) startProgress(total uint64) {
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, total)
+	atomic.StoreUint64(&sf.atomicProgressStart, uint64(time.Now().UnixNano()))
+}
+
+// resetProgress clears the progress statistics of the storage folder after a
+// long running action has finished.
+func (sf *storageFolder) resetProgress() {
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	atomic.StoreUint64(&sf.atomicProgressStart, 0)
+}
+
+// progressETA estimates the remaining time of the long running action of the
+// storage folder from the progress made so far. 0 is returned if there is no
+// action in progress or no progress has been made yet.
+func (sf *storageFolder) progressETA() time.Duration {
+	numerator := atomic.LoadUint64(&sf.atomicProgressNumerator)
+	denominator := atomic.LoadUint64(&sf.atomicProgressDenominator)
+	start := atomic.LoadUint64(&sf.atomicProgressStart)
+	if numerator == 0 || start == 0 || numerator >= denominator {
+		return 0
+	}
+	elapsed := time.Since(time.Unix(0, int64(start)))
+	remaining := float64(denominator-numerator) / float64(numerator)
+	return time.Duration(float64(elapsed) * remaining)
+}
+
 // availableStorageFolders returns the contract manager's storage folders as a
 // slice, excluding any unavailable storeage folders.
 func (cm *ContractManager) availableStorageFolders() []*storageFolder {
@@ -376,10 +426,86 @@ func (cm *ContractManager) ResetStorageFolderHealth(index uint16) error {
 	return nil
 }
 
+// managedResizeStorageFolder grows or shrinks the storage folder to the
+// provided number of sectors. The resize is persisted with the storage folder
+// until it completes, so that a resize that was interrupted by a shutdown can
+// be resumed after a restart.
+func (cm *ContractManager) managedResizeStorageFolder(sf *storageFolder, newSectorCount uint32, force bool) error {
+	cm.sectorMu.Lock()
+	oldSectorCount := uint32(len(sf.usage)) * storageFolderGranularity
+	sf.resizeTarget = newSectorCount
+	sf.resizeForce = force
+	sf.resizeErr = ""
+	cm.sectorMu.Unlock()
+
+	// create a unique alert ID per storage folder resize and unregister it after completion.
+	alertID := modules.AlertID("cm-resize-folder-" + hex.EncodeToString(fastrand.Bytes(12)))
+	defer cm.staticAlerter.UnregisterAlert(alertID)
+
+	cm.staticAlerter.RegisterAlert(alertID,
+		fmt.Sprintf("Resizing folder %s from %s to %s",
+			sf.path,
+			modules.FilesizeUnits(uint64(oldSectorCount)*modules.SectorSize),
+			modules.FilesizeUnits(uint64(newSectorCount)*modules.SectorSize)),
+		"folder op", modules.SeverityInfo)
+
+	var err error
+	if oldSectorCount > newSectorCount {
+		err = cm.wal.shrinkStorageFolder(sf.index, newSectorCount, force)
+	} else if oldSectorCount < newSectorCount {
+		err = cm.wal.growStorageFolder(sf.index, newSectorCount)
+	}
+	if errors.Contains(err, errFolderOperationInterrupted) {
+		// Keep the resize target to resume the resize after the restart.
+		return err
+	}
+
+	// The resize is done, clear the target and remember the error.
+	cm.sectorMu.Lock()
+	sf.resizeTarget = 0
+	sf.resizeForce = false
+	if err != nil {
+		sf.resizeErr = err.Error()
+	}
+	cm.sectorMu.Unlock()
+	return err
+}
+
+// threadedResumeStorageFolderResizes resumes the storage folder resizes that
+// were interrupted by the last shutdown.
+func (cm *ContractManager) threadedResumeStorageFolderResizes() {
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	cm.sectorMu.Lock()
+	var sfs []*storageFolder
+	for _, sf := range cm.storageFolders {
+		if sf.resizeTarget != 0 && atomic.LoadUint64(&sf.atomicUnavailable) == 0 {
+			sfs = append(sfs, sf)
+		}
+	}
+	cm.sectorMu.Unlock()
+
+	for _, sf := range sfs {
+		cm.sectorMu.Lock()
+		newSectorCount, force := sf.resizeTarget, sf.resizeForce
+		cm.sectorMu.Unlock()
+		cm.log.Printf("Resuming the resize of storage folder %v to %v sectors\n", sf.path, newSectorCount)
+		err := cm.managedResizeStorageFolder(sf, newSectorCount, force)
+		if err != nil {
+			cm.log.Printf("ERROR: unable to resume the resize of storage folder %v: %v\n", sf.path, err)
+		}
+	}
+}
+
 // ResizeStorageFolder will resize a storage folder, moving sectors as
 // necessary. The resize operation will stop and return an error if any of the
 // sector move operations fail. If the force flag is set to true, the resize
 // operation will continue through failures, meaning that data will be lost.
+// Sectors stay available for reading while the storage folder is resized.
 func (cm *ContractManager) ResizeStorageFolder(index uint16, newSize uint64, force bool) error {
 	err := cm.tg.Add()
 	if err != nil {
@@ -401,27 +527,17 @@ func (cm *ContractManager) ResizeStorageFolder(index uint16, newSize uint64, for
 		return ErrLargeStorageFolder
 	}
 
+	cm.sectorMu.Lock()
 	oldSize := uint64(len(sf.usage)) * storageFolderGranularity * modules.SectorSize
+	resizing := sf.resizeTarget != 0
+	cm.sectorMu.Unlock()
 	if oldSize == newSize {
 		return ErrNoResize
 	}
-
-	// create a unique alert ID per storage folder resize and unregister it after completion.
-	alertID := modules.AlertID("cm-resize-folder-" + hex.EncodeToString(fastrand.Bytes(12)))
-	defer cm.staticAlerter.UnregisterAlert(alertID)
-
-	cm.staticAlerter.RegisterAlert(alertID,
-		fmt.Sprintf("Resizing folder %s from %s to %s",
-			sf.path,
-			modules.FilesizeUnits(uint64(len(sf.usage))*64*modules.SectorSize),
-			modules.FilesizeUnits(newSize)),
-		"folder op", modules.SeverityInfo)
-
-	newSectorCount := uint32(newSize / modules.SectorSize)
-	if oldSize > newSize {
-		return cm.wal.shrinkStorageFolder(index, newSectorCount, force)
+	if resizing {
+		return errResizeInProgress
 	}
-	return cm.wal.growStorageFolder(index, newSectorCount)
+	return cm.managedResizeStorageFolder(sf, uint32(newSize/modules.SectorSize), force)
 }
 
 // StorageFolders will return a list of storage folders in the host, each
@@ -444,6 +560,10 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 		sfm := modules.StorageFolderMetadata{
 			ProgressNumerator:   atomic.LoadUint64(&sf.atomicProgressNumerator),
 			ProgressDenominator: atomic.LoadUint64(&sf.atomicProgressDenominator),
+			ProgressETA:         uint64(sf.progressETA().Seconds()),
+
+			ResizeTarget: uint64(sf.resizeTarget) * modules.SectorSize,
+			ResizeError:  sf.resizeErr,
 
 			FailedReads:      atomic.LoadUint64(&sf.atomicFailedReads),
 			FailedWrites:     atomic.LoadUint64(&sf.atomicFailedWrites),
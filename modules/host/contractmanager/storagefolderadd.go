@@ -171,7 +171,7 @@ func (wal *writeAheadLog) managedAddStorageFolder(sf *storageFolder) error {
 		}
 		// Establish the progress fields for the add operation in the storage
 		// folder.
-		atomic.StoreUint64(&sf.atomicProgressDenominator, totalSize)
+		sf.startProgress(totalSize)
 
 		// Add the storage folder to the list of storage folders.
 		wal.cm.storageFolders[index] = sf
@@ -306,8 +306,7 @@ func (wal *writeAheadLog) managedAddStorageFolder(sf *storageFolder) error {
 	<-syncChan
 
 	// Set the progress back to '0'.
-	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
-	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	sf.resetProgress()
 	return nil
 }
 
@@ -3,8 +3,10 @@ package contractmanager
 import (
 	"encoding/hex"
 	"fmt"
+	"math/bits"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -197,6 +199,14 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 	var errCount, movedCount uint64
 	totalSectors := (64 * uint64(len(sf.usage))) - uint64(startingPoint)
 
+	// Report the progress in bytes of the sectors that need to be migrated.
+	var migrationSize uint64
+	for _, usage := range sf.usage[startingPoint/storageFolderGranularity:] {
+		migrationSize += uint64(bits.OnesCount64(usage)) * modules.SectorSize
+	}
+	sf.startProgress(migrationSize)
+	defer sf.resetProgress()
+
 	// create a unique alert ID per empty and unregister it after completion.
 	alertID := modules.AlertID("cm-empty-folder-" + hex.EncodeToString(fastrand.Bytes(12)))
 	defer wal.cm.staticAlerter.UnregisterAlert(alertID)
@@ -223,6 +233,7 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 					} else {
 						atomic.AddUint64(&movedCount, 1)
 					}
+					atomic.AddUint64(&sf.atomicProgressNumerator, modules.SectorSize)
 
 					wal.cm.staticAlerter.RegisterAlert(alertID,
 						fmt.Sprintf("Migrating %d sectors from %s: %d migrated, %d errored",
@@ -241,8 +252,13 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 	}
 
 	// Iterate through all of the sectors and perform the move operation on
-	// them.
+	// them. The moves are throttled to the sectorMigrationRate and stop on
+	// shutdown.
+	var interrupted bool
+	var queuedCount uint64
+	migrationStart := time.Now()
 	readHead := startingPoint * sectorMetadataDiskSize
+LOOP:
 	for _, usage := range sf.usage[startingPoint/storageFolderGranularity:] {
 		// The usage is a bitfield indicating where sectors exist. Iterate
 		// through each bit to check for a sector.
@@ -264,6 +280,16 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 					continue
 				}
 
+				// Wait until the migration is within the rate limit.
+				queuedCount++
+				migrationTime := time.Duration(float64(queuedCount*modules.SectorSize) / float64(sectorMigrationRate) * float64(time.Second))
+				select {
+				case <-wal.cm.tg.StopChan():
+					interrupted = true
+					break LOOP
+				case <-time.After(migrationTime - time.Since(migrationStart)):
+				}
+
 				// Queue the sector move.
 				wg.Add(1)
 				workChan <- id
@@ -274,6 +300,9 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 	}
 	wg.Wait()
 	close(doneChan)
+	if interrupted {
+		return errCount, errFolderOperationInterrupted
+	}
 
 	// Return errPartialRelocation if not every sector was migrated out
 	// successfully.
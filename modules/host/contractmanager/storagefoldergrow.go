@@ -162,11 +162,21 @@ func (wal *writeAheadLog) growStorageFolder(index uint16, newSectorCount uint32)
 		}
 	}(sf, currentMetadataSize, currentHousingSize)
 
-	// Extend the sector file and metadata file on disk.
-	atomic.StoreUint64(&sf.atomicProgressDenominator, uint64(housingWriteSize+metadataWriteSize))
+	// Extend the sector file and metadata file on disk. The progress is reset
+	// once the operation is done, no matter whether it succeeded.
+	sf.startProgress(uint64(housingWriteSize + metadataWriteSize))
+	defer sf.resetProgress()
 
 	stepCount := housingWriteSize / folderAllocationStepSize
 	for i := int64(0); i < stepCount; i++ {
+		// Stop growing the folder on shutdown, the extension will be reverted
+		// and resumed after the restart.
+		select {
+		case <-wal.cm.tg.StopChan():
+			err = errFolderOperationInterrupted
+			return err
+		default:
+		}
 		err = sf.sectorFile.Truncate(currentHousingSize + (folderAllocationStepSize * (i + 1)))
 		if err != nil {
 			return build.ExtendErr("could not allocate storage folder", err)
@@ -237,9 +247,5 @@ func (wal *writeAheadLog) growStorageFolder(index uint16, newSectorCount uint32)
 	// Wait to confirm the storage folder addition has completed until the WAL
 	// entry has synced.
 	<-syncChan
-
-	// Set the progress back to '0'.
-	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
-	atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
 	return nil
 }
@@ -2,11 +2,14 @@ package contractmanager
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 )
 
@@ -537,3 +540,72 @@ func TestGrowStorageFolderWAL(t *testing.T) {
 		t.Error("sector file is the wrong size:", sfi.Size(), modules.SectorSize*storageFolderGranularity*25)
 	}
 }
+
+// TestGrowStorageFolderResume checks that a storage folder resize which was
+// interrupted by a shutdown is resumed after a restart.
+func TestGrowStorageFolderResume(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester("TestGrowStorageFolderResume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder.
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderOne, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity*3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfIndex := cmt.cm.StorageFolders()[0].Index
+
+	// Mark a resize as in progress, like an interrupted resize would.
+	cmt.cm.sectorMu.Lock()
+	cmt.cm.storageFolders[sfIndex].resizeTarget = storageFolderGranularity * 5
+	cmt.cm.sectorMu.Unlock()
+	sfs := cmt.cm.StorageFolders()
+	if sfs[0].ResizeTarget != modules.SectorSize*storageFolderGranularity*5 {
+		t.Fatal("wrong resize target", sfs[0].ResizeTarget)
+	}
+
+	// Another resize should be rejected while the resize is in progress.
+	err = cmt.cm.ResizeStorageFolder(sfIndex, modules.SectorSize*storageFolderGranularity*4, false)
+	if err != errResizeInProgress {
+		t.Fatal("expected errResizeInProgress but got", err)
+	}
+
+	// Restart the contract manager.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The resize should be resumed and completed.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sfs := cmt.cm.StorageFolders()
+		if sfs[0].ResizeTarget != 0 {
+			return errors.New("resize still in progress")
+		}
+		if sfs[0].Capacity != modules.SectorSize*storageFolderGranularity*5 {
+			return fmt.Errorf("wrong capacity %v", sfs[0].Capacity)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sfs := cmt.cm.StorageFolders(); sfs[0].ResizeError != "" {
+		t.Fatal("unexpected resize error", sfs[0].ResizeError)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/modules"
 )
@@ -85,8 +86,9 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 		"folder op", modules.SeverityInfo)
 
 	// Clear out the sectors in the storage folder.
+	// Even a forced removal is aborted on shutdown.
 	_, err = cm.wal.managedEmptyStorageFolder(index, 0)
-	if err != nil && !force {
+	if errors.Contains(err, errFolderOperationInterrupted) || (err != nil && !force) {
 		return err
 	}
 
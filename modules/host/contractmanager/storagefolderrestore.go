@@ -0,0 +1,229 @@
+package contractmanager
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errRestoreStorageFoldersExist is returned if a backup is restored into a
+	// contract manager which already has storage folders.
+	errRestoreStorageFoldersExist = errors.New("can't restore storage folders into a contract manager which already has storage folders")
+)
+
+// openRestoredStorageFolder opens the files of an existing storage folder and
+// rebuilds its usage from the sector metadata. Only the sectors which are
+// still wanted and haven't been found in another storage folder yet are
+// considered to be in use. The metadata of removed sectors isn't cleared on
+// disk, which is why it can't be used on its own.
+func (cm *ContractManager) openRestoredStorageFolder(sfb modules.StorageFolderBackup, wanted map[sectorID]uint64, found map[sectorID]sectorLocation) (_ *storageFolder, err error) {
+	// Perform the same checks as AddStorageFolder.
+	sectors := sfb.Capacity / modules.SectorSize
+	if sectors > MaximumSectorsPerStorageFolder {
+		return nil, ErrLargeStorageFolder
+	}
+	if sectors < MinimumSectorsPerStorageFolder {
+		return nil, ErrSmallStorageFolder
+	}
+	if sectors%storageFolderGranularity != 0 {
+		return nil, errStorageFolderGranularity
+	}
+	if !filepath.IsAbs(sfb.Path) {
+		return nil, errRelativePath
+	}
+
+	sf := &storageFolder{
+		index: sfb.Index,
+		path:  sfb.Path,
+		usage: make([]uint64, sectors/storageFolderGranularity),
+
+		availableSectors: make(map[sectorID]uint32),
+	}
+	sf.metadataFile, err = cm.dependencies.OpenFile(filepath.Join(sf.path, metadataFile), os.O_RDWR, 0700)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open sector metadata file")
+	}
+	sf.sectorFile, err = cm.dependencies.OpenFile(filepath.Join(sf.path, sectorFile), os.O_RDWR, 0700)
+	if err != nil {
+		err = errors.AddContext(err, "unable to open sector file")
+		return nil, errors.Compose(err, sf.metadataFile.Close())
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, sf.metadataFile.Close(), sf.sectorFile.Close())
+		}
+	}()
+
+	// Make sure the files on disk match the size from the backup.
+	fi, err := sf.sectorFile.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(fi.Size()) != sectors*modules.SectorSize {
+		return nil, fmt.Errorf("sector file of storage folder %v has size %v, expected %v", sf.path, fi.Size(), sectors*modules.SectorSize)
+	}
+	metadata, err := readFullMetadata(sf.metadataFile, int(sectors))
+	if err != nil {
+		return nil, err
+	}
+
+	// Rebuild the usage from the metadata.
+	for i := uint32(0); i < uint32(sectors); i++ {
+		readHead := sectorMetadataDiskSize * i
+		var id sectorID
+		copy(id[:], metadata[readHead:readHead+12])
+		count := binary.LittleEndian.Uint16(metadata[readHead+12 : readHead+14])
+		if count == 0 {
+			continue
+		}
+		wantedCount, isWanted := wanted[id]
+		if _, isFound := found[id]; !isWanted || isFound {
+			continue
+		}
+		found[id] = sectorLocation{
+			index:         i,
+			storageFolder: sf.index,
+			count:         wantedCount,
+		}
+		sf.setUsage(i)
+	}
+	return sf, nil
+}
+
+// RestoreStorageFolders reconnects the storage folders of a backup to the
+// contract manager. Only the sectors with the provided roots are restored, a
+// root that appears multiple times is restored as a virtual sector. The space
+// of all other sectors is freed up. The contract manager can't have any
+// storage folders yet.
+func (cm *ContractManager) RestoreStorageFolders(backup modules.StorageManagerBackup, sectorRoots []crypto.Hash) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.sectorMu.Lock()
+	numFolders := len(cm.storageFolders)
+	cm.sectorMu.Unlock()
+	if numFolders > 0 {
+		return errRestoreStorageFoldersExist
+	}
+	if uint64(len(backup.StorageFolders)) > maximumStorageFolders {
+		return errMaxStorageFolders
+	}
+
+	// Count the references to each sector, using the salt of the backup to
+	// compute the sector ids.
+	wanted := make(map[sectorID]uint64)
+	for _, root := range sectorRoots {
+		var id sectorID
+		saltedRoot := crypto.HashAll(root, backup.SectorSalt)
+		copy(id[:], saltedRoot[:])
+		wanted[id]++
+	}
+
+	// Open all the storage folders before touching the state.
+	var sfs []*storageFolder
+	found := make(map[sectorID]sectorLocation)
+	for _, sfb := range backup.StorageFolders {
+		sf, err := cm.openRestoredStorageFolder(sfb, wanted, found)
+		if err != nil {
+			for _, sf := range sfs {
+				err = errors.Compose(err, sf.metadataFile.Close(), sf.sectorFile.Close())
+			}
+			return errors.AddContext(err, fmt.Sprintf("failed to restore storage folder %v", sfb.Path))
+		}
+		sfs = append(sfs, sf)
+	}
+	if len(found) < len(wanted) {
+		cm.log.Printf("WARN: %v of %v sectors could not be found in the restored storage folders", len(wanted)-len(found), len(wanted))
+	}
+	sort.Slice(sfs, func(i, j int) bool {
+		return sfs[i].index < sfs[j].index
+	})
+
+	// Add the storage folders and sectors to the state and commit the
+	// addition through the WAL.
+	cm.wal.mu.Lock()
+	cm.sectorMu.Lock()
+	if len(cm.storageFolders) > 0 {
+		cm.sectorMu.Unlock()
+		cm.wal.mu.Unlock()
+		for _, sf := range sfs {
+			err = errors.Compose(err, sf.metadataFile.Close(), sf.sectorFile.Close())
+		}
+		return errors.Compose(errRestoreStorageFoldersExist, err)
+	}
+	cm.sectorSalt = backup.SectorSalt
+	var ssfs []savedStorageFolder
+	for _, sf := range sfs {
+		if _, exists := cm.storageFolders[sf.index]; exists {
+			build.Critical("storage folder index was used twice in backup", sf.index)
+		}
+		cm.storageFolders[sf.index] = sf
+		ssfs = append(ssfs, sf.savedStorageFolder())
+	}
+	var sus []sectorUpdate
+	for id, sl := range found {
+		cm.sectorLocations[id] = sl
+		sus = append(sus, sectorUpdate{
+			Count:  sl.count,
+			Folder: sl.storageFolder,
+			ID:     id,
+			Index:  sl.index,
+		})
+	}
+	cm.sectorMu.Unlock()
+	cm.wal.appendChange(stateChange{
+		StorageFolderAdditions: ssfs,
+		SectorUpdates:          sus,
+	})
+	syncChan := cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+
+	// Update the sector counts in the metadata.
+	for _, su := range sus {
+		cm.sectorMu.Lock()
+		sf := cm.storageFolders[su.Folder]
+		cm.sectorMu.Unlock()
+		if err := cm.wal.writeSectorMetadata(sf, su); err != nil {
+			return errors.AddContext(err, "failed to update restored sector metadata")
+		}
+	}
+
+	// The sector salt isn't part of the WAL. It is written to the settings
+	// file during the sync above but only renamed into place during the next
+	// one.
+	cm.wal.mu.Lock()
+	syncChan = cm.wal.syncChan
+	cm.wal.mu.Unlock()
+	<-syncChan
+	return nil
+}
+
+// StorageFoldersBackup returns the information required to reconnect the
+// contract manager's storage folders after its persist dir was lost.
+func (cm *ContractManager) StorageFoldersBackup() modules.StorageManagerBackup {
+	ss := cm.savedSettings()
+	backup := modules.StorageManagerBackup{
+		SectorSalt: ss.SectorSalt,
+	}
+	for _, ssf := range ss.StorageFolders {
+		backup.StorageFolders = append(backup.StorageFolders, modules.StorageFolderBackup{
+			Capacity: uint64(len(ssf.Usage)) * storageFolderGranularity * modules.SectorSize,
+			Index:    ssf.Index,
+			Path:     ssf.Path,
+		})
+	}
+	return backup
+}
@@ -0,0 +1,144 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRestoreStorageFolders checks that the storage folders of a contract
+// manager can be reconnected to a new contract manager using a backup.
+func TestRestoreStorageFolders(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a storage folder with some sectors. One of the sectors is virtual
+	// and one is removed again.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	root3, data3 := randSector()
+	for _, sector := range []struct {
+		root crypto.Hash
+		data []byte
+	}{{root1, data1}, {root1, data1}, {root2, data2}, {root3, data3}} {
+		if err := cmt.cm.AddSector(sector.root, sector.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cmt.cm.RemoveSector(root3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the backup and close the contract manager.
+	backup := cmt.cm.StorageFoldersBackup()
+	if len(backup.StorageFolders) != 1 {
+		t.Fatal("expected 1 storage folder in backup", len(backup.StorageFolders))
+	}
+	if backup.StorageFolders[0].Capacity != modules.SectorSize*storageFolderGranularity*2 {
+		t.Fatal("wrong capacity", backup.StorageFolders[0].Capacity)
+	}
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a new contract manager in a different dir.
+	cmDir := filepath.Join(cmt.persistDir, "restored")
+	cm, err := New(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cm.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The sectors which are still referenced. root1 is referenced twice.
+	roots := []crypto.Hash{root1, root1, root2}
+
+	// A backup with a relative path can't be restored.
+	badBackup := backup
+	badBackup.StorageFolders = []modules.StorageFolderBackup{backup.StorageFolders[0]}
+	badBackup.StorageFolders[0].Path = "storageFolderOne"
+	if err := cm.RestoreStorageFolders(badBackup, roots); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Restore the backup.
+	err = cm.RestoreStorageFolders(backup, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs := cm.StorageFolders()
+	if len(sfs) != 1 || sfs[0].Path != storageFolderDir {
+		t.Fatal("storage folder wasn't restored", sfs)
+	}
+	if sfs[0].CapacityRemaining != sfs[0].Capacity-2*modules.SectorSize {
+		t.Fatal("wrong remaining capacity", sfs[0].CapacityRemaining)
+	}
+	for _, sector := range []struct {
+		root crypto.Hash
+		data []byte
+	}{{root1, data1}, {root2, data2}} {
+		data, err := cm.ReadSector(sector.root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, sector.data) {
+			t.Fatal("wrong sector data")
+		}
+	}
+	if cm.HasSector(root3) {
+		t.Fatal("removed sector shouldn't be restored")
+	}
+
+	// Restoring again should fail.
+	if err := cm.RestoreStorageFolders(backup, roots); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// The virtual sector needs to be removed twice.
+	if err := cm.RemoveSector(root1); err != nil {
+		t.Fatal(err)
+	}
+	if !cm.HasSector(root1) {
+		t.Fatal("virtual sector should still exist")
+	}
+
+	// Restart the contract manager. The restored state should be persisted.
+	err = cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm, err = New(cmDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := cm.ReadSector(root2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, data2) {
+		t.Fatal("wrong sector data after restart")
+	}
+}
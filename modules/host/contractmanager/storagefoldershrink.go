@@ -3,6 +3,8 @@ package contractmanager
 import (
 	"sync/atomic"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/modules"
 )
 
@@ -73,8 +75,9 @@ func (wal *writeAheadLog) shrinkStorageFolder(index uint16, newSectorCount uint3
 	defer sf.mu.Unlock()
 
 	// Clear out the sectors in the storage folder.
+	// Even a forced shrink is aborted on shutdown.
 	_, err := wal.managedEmptyStorageFolder(index, newSectorCount)
-	if err != nil && !force {
+	if errors.Contains(err, errFolderOperationInterrupted) || (err != nil && !force) {
 		return err
 	}
 
@@ -0,0 +1,80 @@
+package host
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// obligationEarnings returns the earnings of a storage obligation.
+func obligationEarnings(so modules.StorageObligation) modules.HostEarnings {
+	return modules.HostEarnings{
+		AccountFunding:  so.PotentialAccountFunding,
+		ContractFees:    so.ContractCost,
+		DownloadRevenue: so.PotentialDownloadRevenue,
+		StorageRevenue:  so.PotentialStorageRevenue,
+		UploadRevenue:   so.PotentialUploadRevenue,
+	}
+}
+
+// earningsReport creates a HostEarningsReport from the provided storage
+// obligations. Only obligations with a proof deadline within [start, end] are
+// considered. If end is 0, the range is open-ended. Rejected obligations never
+// made it onto the blockchain and are ignored.
+func earningsReport(sos []modules.StorageObligation, start, end types.BlockHeight) modules.HostEarningsReport {
+	report := modules.HostEarningsReport{
+		StartHeight: start,
+		EndHeight:   end,
+		Contracts:   make([]modules.HostContractEarnings, 0, len(sos)),
+	}
+	for _, so := range sos {
+		if so.ProofDeadLine < start || (end != 0 && so.ProofDeadLine > end) {
+			continue
+		}
+		ce := modules.HostContractEarnings{
+			ObligationID:     so.ObligationId,
+			ObligationStatus: so.ObligationStatus,
+
+			NegotiationHeight: so.NegotiationHeight,
+			ExpirationHeight:  so.ExpirationHeight,
+			ProofDeadline:     so.ProofDeadLine,
+		}
+		earnings := obligationEarnings(so)
+		switch so.ObligationStatus {
+		case obligationUnresolved.String():
+			ce.Pending = earnings
+		case obligationSucceeded.String():
+			ce.Confirmed = earnings
+		case obligationFailed.String():
+			// The same as in resetFinancialMetrics, the contract fees of a
+			// failed obligation are always earned and the revenue is only
+			// lost if collateral was at risk.
+			ce.Confirmed.ContractFees = so.ContractCost
+			if !so.RiskedCollateral.IsZero() {
+				ce.Lost = earnings
+				ce.Lost.ContractFees = types.ZeroCurrency
+				ce.LostCollateral = so.RiskedCollateral
+			}
+		default:
+			continue
+		}
+		report.Pending = report.Pending.Add(ce.Pending)
+		report.Confirmed = report.Confirmed.Add(ce.Confirmed)
+		report.Lost = report.Lost.Add(ce.Lost)
+		report.LostCollateral = report.LostCollateral.Add(ce.LostCollateral)
+		report.Contracts = append(report.Contracts, ce)
+	}
+	// Sort the contracts by their proof deadline.
+	sort.Slice(report.Contracts, func(i, j int) bool {
+		return report.Contracts[i].ProofDeadline < report.Contracts[j].ProofDeadline
+	})
+	return report
+}
+
+// EarningsReport returns a breakdown of the host's earnings for all storage
+// obligations with a proof deadline within [startHeight, endHeight]. An
+// endHeight of 0 means that the range is open-ended.
+func (h *Host) EarningsReport(startHeight, endHeight types.BlockHeight) modules.HostEarningsReport {
+	return earningsReport(h.StorageObligations(), startHeight, endHeight)
+}
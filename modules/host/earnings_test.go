@@ -0,0 +1,80 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestEarningsReport is a unit test for earningsReport.
+func TestEarningsReport(t *testing.T) {
+	so := func(status storageObligationStatus, deadline types.BlockHeight) modules.StorageObligation {
+		return modules.StorageObligation{
+			ObligationId:             types.FileContractID{byte(deadline)},
+			ObligationStatus:         status.String(),
+			ProofDeadLine:            deadline,
+			ContractCost:             types.NewCurrency64(1),
+			PotentialStorageRevenue:  types.NewCurrency64(2),
+			PotentialDownloadRevenue: types.NewCurrency64(3),
+			PotentialUploadRevenue:   types.NewCurrency64(4),
+			PotentialAccountFunding:  types.NewCurrency64(5),
+			RiskedCollateral:         types.NewCurrency64(6),
+		}
+	}
+	sos := []modules.StorageObligation{
+		so(obligationFailed, 30),
+		so(obligationUnresolved, 40),
+		so(obligationSucceeded, 10),
+		so(obligationRejected, 20),
+		so(obligationSucceeded, 50),
+	}
+	// A failed obligation without risked collateral doesn't lose any revenue.
+	safe := so(obligationFailed, 35)
+	safe.RiskedCollateral = types.ZeroCurrency
+	sos = append(sos, safe)
+
+	// Without bounds all obligations but the rejected one are included. The
+	// contract fees of the failed obligations are confirmed.
+	report := earningsReport(sos, 0, 0)
+	if len(report.Contracts) != 5 {
+		t.Fatal("wrong number of contracts", len(report.Contracts))
+	}
+	for i, deadline := range []types.BlockHeight{10, 30, 35, 40, 50} {
+		if report.Contracts[i].ProofDeadline != deadline {
+			t.Fatal("contracts aren't sorted", i, report.Contracts[i].ProofDeadline)
+		}
+	}
+	if !report.Pending.Total().Equals64(15) {
+		t.Fatal("wrong pending earnings", report.Pending.Total())
+	}
+	if !report.Confirmed.Total().Equals64(32) || !report.Confirmed.ContractFees.Equals64(4) {
+		t.Fatal("wrong confirmed earnings", report.Confirmed.Total())
+	}
+	if !report.Lost.Total().Equals64(14) || !report.Lost.ContractFees.IsZero() || !report.LostCollateral.Equals64(6) {
+		t.Fatal("wrong lost earnings", report.Lost.Total(), report.LostCollateral)
+	}
+	if !report.Confirmed.StorageRevenue.Equals64(4) {
+		t.Fatal("wrong confirmed storage revenue", report.Confirmed.StorageRevenue)
+	}
+
+	// Only include the obligations with a deadline within [20, 40].
+	report = earningsReport(sos, 20, 40)
+	if len(report.Contracts) != 3 {
+		t.Fatal("wrong number of contracts", len(report.Contracts))
+	}
+	if !report.Confirmed.Total().Equals64(2) {
+		t.Fatal("wrong confirmed earnings", report.Confirmed.Total())
+	}
+	if !report.Pending.Total().Equals64(15) || !report.Lost.Total().Equals64(14) {
+		t.Fatal("wrong earnings", report.Pending.Total(), report.Lost.Total())
+	}
+	ce := report.Contracts[1]
+	if !ce.Confirmed.Total().Equals64(1) || !ce.Lost.Total().IsZero() || !ce.LostCollateral.IsZero() {
+		t.Fatal("wrong contract earnings", ce)
+	}
+	ce = report.Contracts[2]
+	if !ce.Pending.Total().Equals64(15) || !ce.Confirmed.Total().IsZero() || !ce.Lost.Total().IsZero() {
+		t.Fatal("wrong contract earnings", ce)
+	}
+}
@@ -139,6 +139,16 @@ type Host struct {
 	atomicSettingsCalls     uint64
 	atomicUnrecognizedCalls uint64
 
+	// Registry metrics. These values are not persistent.
+	atomicRegistryUpdates         uint64
+	atomicRegistryRejectedUpdates uint64
+	atomicRegistryEvictions       uint64
+	atomicRegistryPruned          uint64
+
+	// atomicRescanning is set while the host rescans the consensus set after
+	// restoring a backup. Incoming RPCs are refused during that time.
+	atomicRescanning uint64
+
 	// Error management. There are a few different types of errors returned by
 	// the host. These errors intentionally not persistent, so that the logging
 	// limits of each error type will be reset each time the host is reset.
@@ -164,6 +174,7 @@ type Host struct {
 	staticMDM                   *mdm.MDM
 	staticRegistry              *registry.Registry
 	staticRegistrySubscriptions *registrySubscriptions
+	staticSectorAccess          *sectorAccessTracker
 
 	// Host ACID fields - these fields need to be updated in serial, ACID
 	// transactions.
@@ -180,6 +191,8 @@ type Host struct {
 	financialMetrics     modules.HostFinancialMetrics
 	settings             modules.HostInternalSettings
 	revisionNumber       uint64
+	priceHistory         []modules.HostPriceChange
+	pricingLastCalls     uint64
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
@@ -200,6 +213,10 @@ type Host struct {
 	atomicStreamUpload   uint64
 	atomicStreamDownload uint64
 
+	// Registry pruning state.
+	atomicRegistryPruning      uint32
+	staticRegistryMetricsStart time.Time
+
 	// Misc state.
 	db            *persist.BoltDatabase
 	listener      net.Listener
@@ -458,6 +475,8 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 			},
 		},
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
+		staticRegistryMetricsStart:  time.Now(),
+		staticSectorAccess:          newSectorAccessTracker(sectorAccessMaxTracked),
 		persistDir:                  persistDir,
 	}
 
@@ -563,6 +582,9 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Periodically re-evaluate the prices if dynamic pricing is enabled.
+	go h.threadedUpdateDynamicPrices()
+
 	return h, nil
 }
 
@@ -687,6 +709,15 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	err = validatePricingSettings(settings)
+	if err != nil {
+		return errors.AddContext(err, "internal settings not updated")
+	}
+	err = validateRegistryEvictionPolicy(settings.RegistryEvictionPolicy)
+	if err != nil {
+		return errors.AddContext(err, "internal settings not updated")
+	}
+
 	if settings.NetAddress != "" {
 		err := settings.NetAddress.IsValid()
 		if err != nil {
@@ -694,12 +725,25 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if len(settings.AlternativeNetAddresses) > modules.MaxAlternativeNetAddresses {
+		return fmt.Errorf("internal settings not updated, can't have more than %v alternative net addresses", modules.MaxAlternativeNetAddresses)
+	}
+	for _, addr := range settings.AlternativeNetAddresses {
+		if err := addr.IsValid(); err != nil {
+			return errors.New("internal settings not updated, invalid alternative net address: " + err.Error())
+		}
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
-	// another blockchain announcement.
+	// another blockchain announcement. The same is true if the alternative
+	// net addresses have changed.
 	if h.settings.NetAddress != settings.NetAddress && settings.NetAddress != h.autoAddress {
 		h.announced = false
 	}
+	if !equalNetAddresses(h.settings.AlternativeNetAddresses, settings.AlternativeNetAddresses) {
+		h.announced = false
+	}
 
 	// Translate the size of the registry in bytes to the number of entries. Adjust
 	// the input in case it's not a multiple of 64 times the size of a persisted
@@ -728,9 +772,7 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 	h.settings = settings
 	h.revisionNumber++
 
-	// The locked storage collateral was altered, we potentially want to
-	// unregister the insufficient collateral budget alert
-	h.tryUnregisterInsufficientCollateralBudgetAlert()
+	h.updateCollateralBudgetAlerts()
 
 	err = h.saveSync()
 	if err != nil {
@@ -796,10 +838,12 @@ func (h *Host) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPu
 		return modules.SignedRegistryValue{}, nil
 	}
 	// Update the registry.
-	existingSRV, err := h.staticRegistry.Update(rv, pubKey, expiry)
+	existingSRV, err := h.managedUpdateRegistry(rv, pubKey, expiry)
 	if err != nil {
+		atomic.AddUint64(&h.atomicRegistryRejectedUpdates, 1)
 		return existingSRV, errors.AddContext(err, "failed to update registry")
 	}
+	atomic.AddUint64(&h.atomicRegistryUpdates, 1)
 	// On success, we notify the subscribers.
 	go h.threadedNotifySubscribers(pubKey, rv)
 	return existingSRV, nil
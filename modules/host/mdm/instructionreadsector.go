@@ -77,6 +77,7 @@ func executeReadSector(previousOutput output, ps *programState, length, offset u
 	if err != nil {
 		return errOutput(err), nil
 	}
+	ps.host.RecordSectorRead(sectorRoot, length)
 	readData := sectorData[offset : offset+length]
 
 	// Construct the Merkle proof, if requested.
@@ -9,8 +9,10 @@ import (
 
 // StorageObligation defines an interface the storage obligation must adhere to.
 type StorageObligation interface {
-	// Update updates the storage obligation.
-	Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error
+	// Update updates the storage obligation. refsAdded and refsRemoved
+	// contain a root for every reference to a sector which was added or
+	// removed.
+	Update(sectorRoots, refsAdded, refsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error
 }
 
 // StorageObligationSnapshot defines an interface the snapshot must adhere to in
@@ -35,6 +37,7 @@ type Host interface {
 	BlockHeight() types.BlockHeight
 	HasSector(crypto.Hash) bool
 	ReadSector(sectorRoot crypto.Hash) ([]byte, error)
+	RecordSectorRead(sectorRoot crypto.Hash, length uint64)
 	RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error)
 	RegistryGet(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool)
 }
@@ -132,6 +132,10 @@ func (h *TestHost) ReadSector(sectorRoot crypto.Hash) ([]byte, error) {
 	return data, nil
 }
 
+// RecordSectorRead implements the Host interface. The test host doesn't track
+// the reads of its sectors.
+func (h *TestHost) RecordSectorRead(crypto.Hash, uint64) {}
+
 // AddRandomSector adds a random sector to the obligation and corresponding
 // host.
 func (so *TestStorageObligation) AddRandomSector() {
@@ -199,18 +203,29 @@ func (so *TestStorageObligation) SectorRoots() []crypto.Hash {
 }
 
 // Update implements the StorageObligation interface.
-func (so *TestStorageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error {
-	for removedSector := range sectorsRemoved {
-		if _, exists := so.sectorMap[removedSector]; !exists {
+func (so *TestStorageObligation) Update(sectorRoots, refsAdded, refsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+	refs := make(map[crypto.Hash]int)
+	for _, root := range so.sectorRoots {
+		refs[root]++
+	}
+	for _, root := range refsRemoved {
+		if refs[root] == 0 {
 			return errors.New("sector doesn't exist")
 		}
-		delete(so.sectorMap, removedSector)
+		refs[root]--
+		if refs[root] == 0 {
+			delete(so.sectorMap, root)
+		}
 	}
-	for gainedSector, gainedSectorData := range sectorsGained {
-		if _, exists := so.sectorMap[gainedSector]; exists {
-			return errors.New("sector already exists")
+	for _, root := range refsAdded {
+		if refs[root] == 0 {
+			data, gained := sectorsGained[root]
+			if !gained {
+				return errors.New("sector data missing")
+			}
+			so.sectorMap[root] = data
 		}
-		so.sectorMap[gainedSector] = gainedSectorData
+		refs[root]++
 	}
 	so.sectorRoots = sectorRoots
 	return nil
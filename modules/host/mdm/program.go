@@ -304,7 +304,8 @@ func (p *program) managedFinalize(so StorageObligation) error {
 	}
 	// Commit the changes to the storage obligation.
 	s := p.staticProgramState.sectors
-	err = so.Update(s.merkleRoots, s.sectorsRemoved, s.sectorsGained)
+	refsAdded, refsRemoved := s.refChanges()
+	err = so.Update(s.merkleRoots, refsAdded, refsRemoved, s.sectorsGained)
 	if err != nil {
 		return err
 	}
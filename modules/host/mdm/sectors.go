@@ -7,23 +7,37 @@ import (
 	"go.sia.tech/siad/modules"
 )
 
-// sectors contains the program cache, including gained and removed sectors as
-// well as the list of sector roots.
+// sectors contains the program cache, including the change in references to
+// sectors, the data of gained sectors as well as the list of sector roots.
 type sectors struct {
-	sectorsRemoved map[crypto.Hash]struct{}
-	sectorsGained  map[crypto.Hash][]byte
-	merkleRoots    []crypto.Hash
+	sectorRefs    map[crypto.Hash]int
+	sectorsGained map[crypto.Hash][]byte
+	merkleRoots   []crypto.Hash
 }
 
 // newSectors creates a program cache given an initial list of sector roots.
 func newSectors(roots []crypto.Hash) sectors {
 	return sectors{
-		sectorsRemoved: make(map[crypto.Hash]struct{}),
-		sectorsGained:  make(map[crypto.Hash][]byte),
-		merkleRoots:    roots,
+		sectorRefs:    make(map[crypto.Hash]int),
+		sectorsGained: make(map[crypto.Hash][]byte),
+		merkleRoots:   roots,
 	}
 }
 
+// refChanges returns the sector roots which gained and lost references due to
+// the program. A root is returned once for every reference it gained or lost.
+func (s *sectors) refChanges() (added, removed []crypto.Hash) {
+	for root, n := range s.sectorRefs {
+		for ; n > 0; n-- {
+			added = append(added, root)
+		}
+		for ; n < 0; n++ {
+			removed = append(removed, root)
+		}
+	}
+	return added, removed
+}
+
 // appendSector adds the data to the program cache and returns the new merkle
 // root.
 func (s *sectors) appendSector(sectorData []byte) (crypto.Hash, error) {
@@ -32,13 +46,10 @@ func (s *sectors) appendSector(sectorData []byte) (crypto.Hash, error) {
 	}
 	newRoot := crypto.MerkleRoot(sectorData)
 
-	// Update the program cache.
-	_, removed := s.sectorsRemoved[newRoot]
-	if removed {
-		// If the sector has been marked as removed, unmark it.
-		delete(s.sectorsRemoved, newRoot)
-	} else {
-		// Add the sector to the cache.
+	// Update the program cache. If the append only restores a reference
+	// which was dropped by the program, the host still has the data.
+	s.sectorRefs[newRoot]++
+	if s.sectorRefs[newRoot] > 0 {
 		s.sectorsGained[newRoot] = sectorData
 	}
 
@@ -64,13 +75,11 @@ func (s *sectors) dropSectors(numSectorsDropped uint64) (crypto.Hash, error) {
 
 	// Update the program cache.
 	for _, droppedRoot := range droppedRoots {
-		_, gained := s.sectorsGained[droppedRoot]
-		if gained {
-			// Remove the sectors from the cache.
+		// Remove the data from the cache once the program doesn't add any
+		// references to the sector anymore.
+		s.sectorRefs[droppedRoot]--
+		if s.sectorRefs[droppedRoot] <= 0 {
 			delete(s.sectorsGained, droppedRoot)
-		} else {
-			// Mark the sectors as removed in the cache.
-			s.sectorsRemoved[droppedRoot] = struct{}{}
 		}
 	}
 
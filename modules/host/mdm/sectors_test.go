@@ -117,8 +117,8 @@ func TestAppendSector(t *testing.T) {
 	}
 
 	// Check each field of `sectors`.
-	if len(s.sectorsRemoved) > 0 {
-		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(s.sectorsRemoved))
+	if _, removed := s.refChanges(); len(removed) > 0 {
+		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(removed))
 	}
 	if len(s.sectorsGained) != 1 {
 		t.Fatalf("expected sectors gained length to be %v but was %v", 1, len(s.sectorsGained))
@@ -146,8 +146,8 @@ func TestAppendSector(t *testing.T) {
 	}
 
 	// Check that the program cache is correct.
-	if len(s.sectorsRemoved) > 0 {
-		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(s.sectorsRemoved))
+	if _, removed := s.refChanges(); len(removed) > 0 {
+		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(removed))
 	}
 	if len(s.sectorsGained) != 1 {
 		t.Fatalf("expected sectors gained length to be %v but was %v", 1, len(s.sectorsGained))
@@ -184,8 +184,8 @@ func TestAppendSector(t *testing.T) {
 	sectorRoots = sectorRoots[:len(sectorRoots)-1]
 
 	// Check that the program cache hasn't changed.
-	if len(s.sectorsRemoved) > 0 {
-		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(s.sectorsRemoved))
+	if _, removed := s.refChanges(); len(removed) > 0 {
+		t.Fatalf("expected sectors removed length to be %v but was %v", 0, len(removed))
 	}
 	if len(s.sectorsGained) != 1 {
 		t.Fatalf("expected sectors gained length to be %v but was %v", 1, len(s.sectorsGained))
@@ -242,6 +242,30 @@ func TestDropSectors(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error when dropping too many sectors")
 	}
+
+	// Append the same sector twice and drop one of them. The data should be
+	// kept since the sector is still referenced.
+	sectorData := randomSectorData()
+	for i := 0; i < 2; i++ {
+		if _, err := s.appendSector(sectorData); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.dropSectors(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := s.sectorsGained[crypto.MerkleRoot(sectorData)]; !exists {
+		t.Fatal("data of referenced sector was dropped")
+	}
+	if added, _ := s.refChanges(); len(added) != 1 {
+		t.Fatal("expected 1 added reference, got", len(added))
+	}
+	if _, err := s.dropSectors(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.sectorsGained) != 0 {
+		t.Fatal("data of dropped sector wasn't removed")
+	}
 }
 
 // TestHasSector tests checking if a sector exists in the cache or host.
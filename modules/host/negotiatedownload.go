@@ -95,6 +95,7 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			if err != nil {
 				return extendErr("failed to load sector: ", ErrorInternal(err.Error()))
 			}
+			h.RecordSectorRead(request.MerkleRoot, request.Length)
 			payload = append(payload, sectorData[request.Offset:request.Offset+request.Length])
 		}
 		return nil
@@ -129,7 +130,7 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 		FileContractRevisions: []types.FileContractRevision{paymentRevision},
 		TransactionSignatures: []types.TransactionSignature{renterSignature, txn.TransactionSignatures[1]},
 	}}
-	err = h.managedModifyStorageObligation(*so, nil, nil)
+	err = h.managedModifyStorageObligation(*so, nil, nil, nil)
 	if err != nil {
 		return extendErr("failed to modify storage obligation: ", ErrorInternal(modules.WriteNegotiationRejection(conn, err).Error()))
 	}
@@ -79,8 +79,8 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	var bandwidthRevenue types.Currency // Upload bandwidth.
 	var storageRevenue types.Currency
 	var newCollateral types.Currency
-	var sectorsRemoved []crypto.Hash
 	sectorsGained := make(map[crypto.Hash][]byte)
+	refs := make(sectorRefs)
 	err = func() error {
 		for _, modification := range modifications {
 			// Check that the index points to an existing sector root. If the type
@@ -101,7 +101,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 			case modules.ActionDelete:
 				// There is no financial information to change, it is enough to
 				// remove the sector.
-				sectorsRemoved = append(sectorsRemoved, so.SectorRoots[modification.SectorIndex])
+				refs.remove(so.SectorRoots[modification.SectorIndex])
 				so.SectorRoots = append(so.SectorRoots[0:modification.SectorIndex], so.SectorRoots[modification.SectorIndex+1:]...)
 			case modules.ActionInsert:
 				// Check that the sector size is correct.
@@ -119,6 +119,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				// Insert the sector into the root list.
 				newRoot := crypto.MerkleRoot(modification.Data)
 				sectorsGained[newRoot] = modification.Data
+				refs.add(newRoot)
 				so.SectorRoots = append(so.SectorRoots[:modification.SectorIndex], append([]crypto.Hash{newRoot}, so.SectorRoots[modification.SectorIndex:]...)...)
 			case modules.ActionModify:
 				// Check that the offset and length are okay. Length is already
@@ -139,11 +140,12 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				// Update finances.
 				bandwidthRevenue = bandwidthRevenue.Add(settings.UploadBandwidthPrice.Mul64(uint64(len(modification.Data))))
 
-				// Update the sectors removed and gained to indicate that the old
-				// sector has been replaced with a new sector.
+				// Update the sectors gained to indicate that the old sector has
+				// been replaced with a new sector.
 				newRoot := crypto.MerkleRoot(sector)
-				sectorsRemoved = append(sectorsRemoved, so.SectorRoots[modification.SectorIndex])
 				sectorsGained[newRoot] = sector
+				refs.remove(so.SectorRoots[modification.SectorIndex])
+				refs.add(newRoot)
 				so.SectorRoots[modification.SectorIndex] = newRoot
 			default:
 				return ErrUnknownModification
@@ -179,7 +181,8 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	so.RiskedCollateral = so.RiskedCollateral.Add(newCollateral)
 	so.PotentialUploadRevenue = so.PotentialUploadRevenue.Add(bandwidthRevenue)
 	so.RevisionTransactionSet = []types.Transaction{txn}
-	err = h.managedModifyStorageObligation(*so, sectorsRemoved, sectorsGained)
+	refsAdded, refsRemoved := refs.changes()
+	err = h.managedModifyStorageObligation(*so, refsAdded, refsRemoved, sectorsGained)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
 		return extendErr("could not modify storage obligation: ", ErrorInternal(err.Error()))
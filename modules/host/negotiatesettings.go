@@ -24,6 +24,20 @@ func (h *Host) capacity() (total, remaining uint64) {
 	return total, remaining
 }
 
+// advertisedCollateral returns the collateral per byte per block the host
+// advertises. Once the collateral budget is low, the collateral is reduced
+// proportionally to the remaining budget to slow down its consumption. It drops
+// to zero when the budget is exhausted.
+func advertisedCollateral(collateral, budget, locked types.Currency) types.Currency {
+	if locked.Cmp(budget) >= 0 {
+		return types.ZeroCurrency
+	}
+	if !collateralBudgetLow(budget, locked) {
+		return collateral
+	}
+	return collateral.Mul(budget.Sub(locked)).Div(budget.Div64(collateralBudgetLowDivisor))
+}
+
 // externalSettings compiles and returns the external settings for the host.
 func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExternalSettings {
 	// Increment the revision number for the external settings
@@ -63,6 +77,10 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 	} else if h.financialMetrics.LockedStorageCollateral.Add(maxCollateral).Cmp(h.settings.CollateralBudget) > 0 {
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
+	collateral := advertisedCollateral(h.settings.Collateral, h.settings.CollateralBudget, h.financialMetrics.LockedStorageCollateral)
+
+	// Get the prices, these might be adjusted by the dynamic pricing engine.
+	storagePrice, downloadPrice, uploadPrice := h.currentPrices()
 
 	// Extract the port from the SiaMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
@@ -82,15 +100,15 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		UnlockHash:           h.unlockHash,
 		WindowSize:           h.settings.WindowSize,
 
-		Collateral:    h.settings.Collateral,
+		Collateral:    collateral,
 		MaxCollateral: maxCollateral,
 
 		BaseRPCPrice:           h.settings.MinBaseRPCPrice,
 		ContractPrice:          contractPrice,
-		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		DownloadBandwidthPrice: downloadPrice,
 		SectorAccessPrice:      h.settings.MinSectorAccessPrice,
-		StoragePrice:           h.settings.MinStoragePrice,
-		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		StoragePrice:           storagePrice,
+		UploadBandwidthPrice:   uploadPrice,
 
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
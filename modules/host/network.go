@@ -154,6 +154,16 @@ func (h *Host) threadedTrackConnectabilityStatus(closeChan chan struct{}) {
 		} else {
 			conn.Close()
 			status = modules.HostConnectabilityStatusConnectable
+
+			// Dialing our own address might succeed even if the host isn't
+			// reachable from outside of the local network. Ask the peers of
+			// the gateway to dial back and only trust a conclusive answer.
+			if _, port, err := net.SplitHostPort(string(activeAddr)); err == nil {
+				reachable, err := h.g.CheckReachability(port)
+				if err == nil && !reachable {
+					status = modules.HostConnectabilityStatusNotConnectable
+				}
+			}
 		}
 		h.mu.Lock()
 		h.connectabilityStatus = status
@@ -269,6 +279,13 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 	defer h.tg.Done()
 
+	// Refuse the connection while the host is rescanning the consensus set.
+	if atomic.LoadUint64(&h.atomicRescanning) == 1 {
+		conn.Close()
+		h.log.Debugf("WARN: refused incoming conn %v: %v", conn.RemoteAddr(), errHostRescanning)
+		return
+	}
+
 	// Close the conn on host.Close or when the method terminates, whichever
 	// comes first.
 	connCloseChan := make(chan struct{})
@@ -394,6 +411,14 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		return
 	}
 
+	// refuse the stream while the host is rescanning the consensus set
+	if atomic.LoadUint64(&h.atomicRescanning) == 1 {
+		if wErr := modules.RPCWriteError(stream, errHostRescanning); wErr != nil {
+			h.managedLogError(wErr)
+		}
+		return
+	}
+
 	// read the RPC id
 	var rpcID types.Specifier
 	err = modules.RPCRead(stream, &rpcID)
@@ -406,13 +431,28 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		return
 	}
 
+	// if the renter accepts compressed payloads, the actual RPC id follows
+	var compress bool
+	if rpcID == modules.RPCCompression {
+		compress = true
+		err = modules.RPCRead(stream, &rpcID)
+		if err != nil {
+			err = errors.AddContext(err, "Failed to read RPC id")
+			if wErr := modules.RPCWriteError(stream, err); wErr != nil {
+				h.managedLogError(wErr)
+			}
+			atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
+			return
+		}
+	}
+
 	switch rpcID {
 	case modules.RPCAccountBalance:
 		err = h.managedRPCAccountBalance(stream)
 	case modules.RPCExecuteProgram:
 		err = h.managedRPCExecuteProgram(stream)
 	case modules.RPCUpdatePriceTable:
-		err = h.managedRPCUpdatePriceTable(stream)
+		err = h.managedRPCUpdatePriceTable(stream, compress)
 	case modules.RPCFundAccount:
 		err = h.managedRPCFundEphemeralAccount(stream)
 	case modules.RPCLatestRevision:
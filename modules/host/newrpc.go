@@ -198,8 +198,8 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 	newRoots := append([]crypto.Hash(nil), s.so.SectorRoots...)
 	sectorsChanged := make(map[uint64]struct{}) // for construct Merkle proof
 	var bandwidthRevenue types.Currency
-	var sectorsRemoved []crypto.Hash
 	sectorsGained := make(map[crypto.Hash][]byte)
+	refs := make(sectorRefs)
 	for _, action := range req.Actions {
 		switch action.Type {
 		case modules.WriteActionAppend:
@@ -211,6 +211,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 			newRoot := crypto.MerkleRoot(action.Data)
 			newRoots = append(newRoots, newRoot)
 			sectorsGained[newRoot] = action.Data
+			refs.add(newRoot)
 
 			sectorsChanged[uint64(len(newRoots))-1] = struct{}{}
 
@@ -225,7 +226,9 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 				return err
 			}
 			// Update sector roots.
-			sectorsRemoved = append(sectorsRemoved, newRoots[uint64(len(newRoots))-numSectors:]...)
+			for _, root := range newRoots[uint64(len(newRoots))-numSectors:] {
+				refs.remove(root)
+			}
 			newRoots = newRoots[:uint64(len(newRoots))-numSectors]
 
 			sectorsChanged[uint64(len(newRoots))] = struct{}{}
@@ -261,8 +264,9 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 			}
 			copy(sector[offset:], action.Data)
 			newRoot := crypto.MerkleRoot(sector)
-			sectorsRemoved = append(sectorsRemoved, newRoots[sectorIndex])
 			sectorsGained[newRoot] = sector
+			refs.remove(newRoots[sectorIndex])
+			refs.add(newRoot)
 			newRoots[sectorIndex] = newRoot
 
 			// Update finances.
@@ -386,7 +390,8 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 	s.so.RiskedCollateral = s.so.RiskedCollateral.Add(newCollateral)
 	s.so.PotentialUploadRevenue = s.so.PotentialUploadRevenue.Add(bandwidthRevenue)
 	s.so.RevisionTransactionSet = []types.Transaction{txn}
-	err = h.managedModifyStorageObligation(s.so, sectorsRemoved, sectorsGained)
+	refsAdded, refsRemoved := refs.changes()
+	err = h.managedModifyStorageObligation(s.so, refsAdded, refsRemoved, sectorsGained)
 	if err != nil {
 		err = errors.Compose(err, s.writeError(err))
 		return err
@@ -528,7 +533,7 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 	paymentTransfer := currentRevision.ValidRenterPayout().Sub(newRevision.ValidRenterPayout())
 	s.so.PotentialDownloadRevenue = s.so.PotentialDownloadRevenue.Add(paymentTransfer)
 	s.so.RevisionTransactionSet = []types.Transaction{txn}
-	err = h.managedModifyStorageObligation(s.so, nil, nil)
+	err = h.managedModifyStorageObligation(s.so, nil, nil, nil)
 	if err != nil {
 		err = errors.Compose(err, s.writeError(err))
 		return err
@@ -542,6 +547,7 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 			err = errors.Compose(err, s.writeError(err))
 			return err
 		}
+		h.RecordSectorRead(sec.MerkleRoot, uint64(sec.Length))
 		data := sectorData[sec.Offset : sec.Offset+sec.Length]
 
 		// Construct the Merkle proof, if requested.
@@ -771,7 +777,7 @@ func (h *Host) managedRPCLoopSectorRoots(s *rpcSession) error {
 	paymentTransfer := currentRevision.ValidRenterPayout().Sub(newRevision.ValidRenterPayout())
 	s.so.PotentialDownloadRevenue = s.so.PotentialDownloadRevenue.Add(paymentTransfer)
 	s.so.RevisionTransactionSet = []types.Transaction{txn}
-	err = h.managedModifyStorageObligation(s.so, nil, nil)
+	err = h.managedModifyStorageObligation(s.so, nil, nil, nil)
 	if err != nil {
 		err = errors.Compose(err, s.writeError(err))
 		return extendErr("failed to modify storage obligation: ", err)
@@ -0,0 +1,125 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// obligationRisk returns the risk of failing the storage proof of an
+// unresolved storage obligation at the provided block height. hasSector
+// reports whether a sector is stored by the host.
+func obligationRisk(so storageObligation, blockHeight types.BlockHeight, hasSector func(crypto.Hash) bool) modules.HostObligationRisk {
+	or := modules.HostObligationRisk{
+		ObligationID:      so.id(),
+		ExpirationHeight:  so.expiration(),
+		ProofDeadline:     so.proofDeadline(),
+		DataSize:          so.fileSize(),
+		SectorRootsCount:  uint64(len(so.SectorRoots)),
+		OriginConfirmed:   so.OriginConfirmed,
+		RevisionConfirmed: so.RevisionConfirmed,
+		ProofRequired:     so.requiresProof(),
+		ProofConstructed:  so.ProofConstructed,
+		RiskedCollateral:  so.RiskedCollateral,
+		ValueAtStake:      so.value(),
+		Risks:             []string{},
+	}
+	for _, root := range so.SectorRoots {
+		if !hasSector(root) {
+			or.MissingSectors++
+		}
+	}
+
+	if !so.OriginConfirmed {
+		or.Risks = append(or.Risks, "the contract is not confirmed on the blockchain")
+	}
+	if len(so.RevisionTransactionSet) > 0 && !so.RevisionConfirmed {
+		or.Risks = append(or.Risks, "the latest revision is not confirmed on the blockchain")
+	}
+	if or.MissingSectors > 0 {
+		or.Risks = append(or.Risks, fmt.Sprintf("%v of %v sectors are missing", or.MissingSectors, or.SectorRootsCount))
+	}
+	if or.ProofRequired && !so.ProofConstructed && blockHeight >= or.ExpirationHeight {
+		or.Risks = append(or.Risks, "the proof window is open but no proof was submitted")
+	}
+	return or
+}
+
+// obligationRiskReport aggregates the risks of the host's unresolved storage
+// obligations into a HostObligationRiskReport. Obligations which require a
+// proof and whose proof window opens before blockHeight+window are counted as
+// due.
+func obligationRiskReport(ors []modules.HostObligationRisk, blockHeight, window types.BlockHeight) modules.HostObligationRiskReport {
+	report := modules.HostObligationRiskReport{
+		BlockHeight: blockHeight,
+		Window:      window,
+		Obligations: ors,
+	}
+	if report.Obligations == nil {
+		report.Obligations = []modules.HostObligationRisk{}
+	}
+	for _, or := range ors {
+		atRisk := len(or.Risks) > 0
+		if atRisk {
+			report.ObligationsAtRisk++
+			report.CollateralAtRisk = report.CollateralAtRisk.Add(or.RiskedCollateral)
+		}
+		if !or.ProofRequired || or.ExpirationHeight > blockHeight+window {
+			continue
+		}
+		report.ProofsDue++
+		report.ProofsDueData += or.DataSize
+		report.ProofsDueValue = report.ProofsDueValue.Add(or.ValueAtStake)
+		if atRisk {
+			report.ProofsDueAtRisk++
+		}
+	}
+	// Sort the obligations by their proof deadline.
+	sort.Slice(report.Obligations, func(i, j int) bool {
+		return report.Obligations[i].ProofDeadline < report.Obligations[j].ProofDeadline
+	})
+	return report
+}
+
+// ObligationRiskReport returns the host's unresolved storage obligations
+// sorted by proof deadline together with the risks of failing their storage
+// proofs. The proofs due within the next 'window' blocks are aggregated.
+func (h *Host) ObligationRiskReport(window types.BlockHeight) (modules.HostObligationRiskReport, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostObligationRiskReport{}, err
+	}
+	defer h.tg.Done()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	blockHeight := h.blockHeight
+
+	// The risk is computed while iterating over the obligations to avoid
+	// holding all sector roots in memory at once.
+	var ors []modules.HostObligationRisk
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return errors.AddContext(err, "unable to unmarshal storage obligation")
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			ors = append(ors, obligationRisk(so, blockHeight, h.HasSector))
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostObligationRiskReport{}, errors.AddContext(err, "unable to load storage obligations")
+	}
+	return obligationRiskReport(ors, blockHeight, window), nil
+}
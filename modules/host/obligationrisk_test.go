@@ -0,0 +1,82 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestObligationRiskReport is a unit test for obligationRisk and
+// obligationRiskReport.
+func TestObligationRiskReport(t *testing.T) {
+	// Create an obligation with 2 sectors, one of which is missing, and an
+	// unconfirmed revision.
+	stored := crypto.Hash{1}
+	missing := crypto.Hash{2}
+	so := storageObligation{
+		SectorRoots:      []crypto.Hash{stored, missing},
+		RiskedCollateral: types.NewCurrency64(10),
+		OriginConfirmed:  true,
+		OriginTransactionSet: []types.Transaction{{
+			FileContracts: []types.FileContract{{}},
+		}},
+		RevisionTransactionSet: []types.Transaction{{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewFileSize:           2 * modules.SectorSize,
+				NewWindowStart:        100,
+				NewWindowEnd:          110,
+				NewValidProofOutputs:  []types.SiacoinOutput{{Value: types.NewCurrency64(1)}, {Value: types.NewCurrency64(2)}},
+				NewMissedProofOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}, {Value: types.NewCurrency64(1)}, {}},
+			}},
+		}},
+	}
+	hasSector := func(root crypto.Hash) bool {
+		return root == stored
+	}
+	or := obligationRisk(so, 50, hasSector)
+	if or.MissingSectors != 1 || or.SectorRootsCount != 2 || or.DataSize != 2*modules.SectorSize {
+		t.Fatal("wrong sector info", or)
+	}
+	if !or.ProofRequired || or.ExpirationHeight != 100 || or.ProofDeadline != 110 {
+		t.Fatal("wrong proof info", or)
+	}
+	if len(or.Risks) != 2 {
+		t.Fatal("expected 2 risks", or.Risks)
+	}
+
+	// Once the proof window is open without a proof, that's another risk.
+	if or := obligationRisk(so, 100, hasSector); len(or.Risks) != 3 {
+		t.Fatal("expected 3 risks", or.Risks)
+	}
+
+	// Without the problems there are no risks.
+	so.RevisionConfirmed = true
+	so.SectorRoots = []crypto.Hash{stored}
+	healthy := obligationRisk(so, 50, hasSector)
+	if len(healthy.Risks) != 0 {
+		t.Fatal("expected no risks", healthy.Risks)
+	}
+
+	// Create a report with a healthy obligation whose proof is due later and
+	// the risky obligation whose proof is due soon.
+	healthy.ExpirationHeight = 200
+	healthy.ProofDeadline = 210
+	report := obligationRiskReport([]modules.HostObligationRisk{healthy, or}, 50, 60)
+	if len(report.Obligations) != 2 || report.Obligations[0].ProofDeadline != 110 {
+		t.Fatal("obligations aren't sorted", report.Obligations)
+	}
+	if report.ProofsDue != 1 || report.ProofsDueAtRisk != 1 || report.ProofsDueData != 2*modules.SectorSize {
+		t.Fatal("wrong proofs due", report)
+	}
+	if report.ObligationsAtRisk != 1 || !report.CollateralAtRisk.Equals64(10) {
+		t.Fatal("wrong obligations at risk", report)
+	}
+
+	// With a larger window both proofs are due.
+	report = obligationRiskReport([]modules.HostObligationRisk{healthy, or}, 50, 150)
+	if report.ProofsDue != 2 || report.ProofsDueAtRisk != 1 {
+		t.Fatal("wrong proofs due", report)
+	}
+}
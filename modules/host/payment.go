@@ -116,7 +116,7 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 	}}
 
 	// update the storage obligation
-	err = h.managedModifyStorageObligation(so, nil, nil)
+	err = h.managedModifyStorageObligation(so, nil, nil, nil)
 	if err != nil {
 		return nil, errors.AddContext(err, "Could not modify storage obligation")
 	}
@@ -217,7 +217,7 @@ func (h *Host) managedFundAccount(stream siamux.Stream, request modules.FundAcco
 	so.PotentialAccountFunding = so.PotentialAccountFunding.Add(deposit)
 
 	// update the storage obligation
-	err = h.managedModifyStorageObligation(so, nil, nil)
+	err = h.managedModifyStorageObligation(so, nil, nil, nil)
 	if err != nil {
 		return types.ZeroCurrency, errors.AddContext(err, "Could not modify storage obligation")
 	}
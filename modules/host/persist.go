@@ -30,6 +30,9 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Dynamic pricing.
+	PriceHistory []modules.HostPriceChange `json:"pricehistory"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +51,9 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Dynamic pricing.
+		PriceHistory: h.priceHistory,
 	}
 }
 
@@ -75,6 +81,8 @@ func (h *Host) establishDefaults() error {
 		EphemeralAccountExpiry:     modules.DefaultEphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
+
+		RegistryEvictionPolicy: modules.HostRegistryEvictionNone,
 	}
 
 	// Load the host's key pair, use the same keys as the SiaMux.
@@ -121,6 +129,9 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+
+	// Copy over the dynamic pricing state.
+	h.priceHistory = p.PriceHistory
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -253,6 +264,7 @@ func (h *Host) load() error {
 	if err != nil {
 		return err
 	}
+	h.updateCollateralBudgetLowAlert()
 
 	return nil
 }
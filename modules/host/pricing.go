@@ -0,0 +1,215 @@
+package host
+
+import (
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// dynamicPricingUtilizationWeight is the weight of the host's capacity
+	// utilization when computing the load that determines the dynamic prices.
+	// The remaining weight is given to the recent demand.
+	dynamicPricingUtilizationWeight = 0.75
+
+	// maxPriceHistoryLen is the maximum number of price changes the host keeps
+	// track of. Older entries are dropped first.
+	maxPriceHistoryLen = 1000
+)
+
+var (
+	// dynamicPricingFrequency is the frequency at which the host re-evaluates
+	// its prices when dynamic pricing is enabled.
+	dynamicPricingFrequency = build.Select(build.Var{
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Dev:      5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// dynamicPricingDemandSaturation is the number of contract related RPC
+	// calls within a single pricing interval at which the demand is considered
+	// to be at its maximum.
+	dynamicPricingDemandSaturation = build.Select(build.Var{
+		Standard: uint64(1000),
+		Testnet:  uint64(1000),
+		Dev:      uint64(100),
+		Testing:  uint64(10),
+	}).(uint64)
+)
+
+var (
+	// errMaxPriceBelowMin is returned if dynamic pricing is enabled and one of
+	// the max prices is lower than the corresponding min price.
+	errMaxPriceBelowMin = errors.New("max price can't be lower than the min price when dynamic pricing is enabled")
+)
+
+// clampPrice makes sure a price lies within the provided bounds. A zero max
+// price is interpreted as no headroom above the min price.
+func clampPrice(price, min, max types.Currency) types.Currency {
+	if price.Cmp(min) < 0 || max.Cmp(min) <= 0 {
+		return min
+	}
+	if price.Cmp(max) > 0 {
+		return max
+	}
+	return price
+}
+
+// dynamicPrice interpolates between the min and max price according to the
+// load, which is expected to be in the range [0, 1].
+func dynamicPrice(min, max types.Currency, load float64) types.Currency {
+	if max.Cmp(min) <= 0 || load <= 0 {
+		return min
+	}
+	if load >= 1 {
+		return max
+	}
+	return min.Add(max.Sub(min).MulFloat(load))
+}
+
+// pricingLoad combines the host's utilization and demand into a single load
+// value in the range [0, 1].
+func pricingLoad(utilization, demand float64) float64 {
+	load := dynamicPricingUtilizationWeight*utilization + (1-dynamicPricingUtilizationWeight)*demand
+	if load < 0 {
+		return 0
+	}
+	if load > 1 {
+		return 1
+	}
+	return load
+}
+
+// validatePricingSettings checks that the dynamic pricing bounds are sane.
+func validatePricingSettings(settings modules.HostInternalSettings) error {
+	if !settings.DynamicPricing {
+		return nil
+	}
+	if settings.MaxStoragePrice.Cmp(settings.MinStoragePrice) < 0 ||
+		settings.MaxDownloadBandwidthPrice.Cmp(settings.MinDownloadBandwidthPrice) < 0 ||
+		settings.MaxUploadBandwidthPrice.Cmp(settings.MinUploadBandwidthPrice) < 0 {
+		return errMaxPriceBelowMin
+	}
+	return nil
+}
+
+// currentPrices returns the storage and bandwidth prices the host is
+// advertising. Without dynamic pricing these are the min prices from the
+// settings.
+func (h *Host) currentPrices() (storage, download, upload types.Currency) {
+	storage = h.settings.MinStoragePrice
+	download = h.settings.MinDownloadBandwidthPrice
+	upload = h.settings.MinUploadBandwidthPrice
+	if !h.settings.DynamicPricing || len(h.priceHistory) == 0 {
+		return
+	}
+	// Clamp the latest prices since the bounds might have changed since the
+	// last update.
+	last := h.priceHistory[len(h.priceHistory)-1]
+	storage = clampPrice(last.StoragePrice, storage, h.settings.MaxStoragePrice)
+	download = clampPrice(last.DownloadBandwidthPrice, download, h.settings.MaxDownloadBandwidthPrice)
+	upload = clampPrice(last.UploadBandwidthPrice, upload, h.settings.MaxUploadBandwidthPrice)
+	return
+}
+
+// managedUpdateDynamicPrices re-evaluates the host's prices based on the
+// current utilization and the demand since the last evaluation. A new entry is
+// added to the price history if the prices changed.
+func (h *Host) managedUpdateDynamicPrices() error {
+	calls := atomic.LoadUint64(&h.atomicFormContractCalls) +
+		atomic.LoadUint64(&h.atomicRenewCalls) +
+		atomic.LoadUint64(&h.atomicReviseCalls)
+	total, remaining := h.capacity()
+
+	h.mu.Lock()
+	demandCalls := calls - h.pricingLastCalls
+	h.pricingLastCalls = calls
+	if !h.settings.DynamicPricing {
+		h.mu.Unlock()
+		return nil
+	}
+
+	var utilization float64
+	if total > 0 && remaining <= total {
+		utilization = float64(total-remaining) / float64(total)
+	}
+	demand := float64(demandCalls) / float64(dynamicPricingDemandSaturation)
+	if demand > 1 {
+		demand = 1
+	}
+	load := pricingLoad(utilization, demand)
+
+	change := modules.HostPriceChange{
+		BlockHeight: h.blockHeight,
+		Timestamp:   time.Now(),
+
+		Demand:      demand,
+		Utilization: utilization,
+
+		DownloadBandwidthPrice: dynamicPrice(h.settings.MinDownloadBandwidthPrice, h.settings.MaxDownloadBandwidthPrice, load),
+		StoragePrice:           dynamicPrice(h.settings.MinStoragePrice, h.settings.MaxStoragePrice, load),
+		UploadBandwidthPrice:   dynamicPrice(h.settings.MinUploadBandwidthPrice, h.settings.MaxUploadBandwidthPrice, load),
+	}
+
+	// Only record the change if the prices actually changed.
+	storage, download, upload := h.currentPrices()
+	if len(h.priceHistory) > 0 && storage.Equals(change.StoragePrice) &&
+		download.Equals(change.DownloadBandwidthPrice) && upload.Equals(change.UploadBandwidthPrice) {
+		h.mu.Unlock()
+		return nil
+	}
+	h.priceHistory = append(h.priceHistory, change)
+	if len(h.priceHistory) > maxPriceHistoryLen {
+		h.priceHistory = h.priceHistory[len(h.priceHistory)-maxPriceHistoryLen:]
+	}
+	err := h.saveSync()
+	h.mu.Unlock()
+
+	// The prices changed, update the price table.
+	h.managedUpdatePriceTable()
+	return err
+}
+
+// threadedUpdateDynamicPrices periodically re-evaluates the host's prices.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedUpdateDynamicPrices() {
+	if h.dependencies.Disrupt("DisableDynamicPricingLoop") {
+		return
+	}
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.managedUpdateDynamicPrices(); err != nil {
+				h.log.Println("WARN: failed to update dynamic prices:", err)
+			}
+		}()
+
+		// Block until next cycle.
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(dynamicPricingFrequency):
+			continue
+		}
+	}
+}
+
+// PriceHistory returns the price changes made by the dynamic pricing engine,
+// ordered from oldest to newest.
+func (h *Host) PriceHistory() []modules.HostPriceChange {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	history := make([]modules.HostPriceChange, len(h.priceHistory))
+	copy(history, h.priceHistory)
+	return history
+}
@@ -0,0 +1,136 @@
+package host
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestDynamicPrice is a unit test for dynamicPrice.
+func TestDynamicPrice(t *testing.T) {
+	min := types.NewCurrency64(100)
+	max := types.NewCurrency64(200)
+
+	tests := []struct {
+		min, max types.Currency
+		load     float64
+		result   types.Currency
+	}{
+		{min, max, -1, min},
+		{min, max, 0, min},
+		{min, max, 0.5, types.NewCurrency64(150)},
+		{min, max, 1, max},
+		{min, max, 2, max},
+		{min, types.ZeroCurrency, 0.5, min},
+		{max, min, 0.5, max},
+	}
+	for i, test := range tests {
+		if price := dynamicPrice(test.min, test.max, test.load); !price.Equals(test.result) {
+			t.Errorf("%v: expected %v but got %v", i, test.result, price)
+		}
+	}
+}
+
+// TestClampPrice is a unit test for clampPrice.
+func TestClampPrice(t *testing.T) {
+	min := types.NewCurrency64(100)
+	max := types.NewCurrency64(200)
+
+	tests := []struct {
+		price, min, max types.Currency
+		result          types.Currency
+	}{
+		{types.NewCurrency64(50), min, max, min},
+		{types.NewCurrency64(150), min, max, types.NewCurrency64(150)},
+		{types.NewCurrency64(250), min, max, max},
+		{types.NewCurrency64(150), min, types.ZeroCurrency, min},
+	}
+	for i, test := range tests {
+		if price := clampPrice(test.price, test.min, test.max); !price.Equals(test.result) {
+			t.Errorf("%v: expected %v but got %v", i, test.result, price)
+		}
+	}
+}
+
+// TestHostDynamicPricing checks that the host adjusts its prices according to
+// the demand once dynamic pricing is enabled.
+func TestHostDynamicPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankMockHostTester(&dependencies.DependencyDisableDynamicPricingLoop{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Max prices below the min prices are rejected.
+	settings := h.InternalSettings()
+	settings.DynamicPricing = true
+	err = h.SetInternalSettings(settings)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Enable dynamic pricing with valid bounds.
+	settings.MaxStoragePrice = settings.MinStoragePrice.Mul64(2)
+	settings.MaxDownloadBandwidthPrice = settings.MinDownloadBandwidthPrice.Mul64(2)
+	settings.MaxUploadBandwidthPrice = settings.MinUploadBandwidthPrice.Mul64(2)
+	err = h.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without any demand or utilization the prices remain at the minimum.
+	err = h.managedUpdateDynamicPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := h.PriceHistory()
+	if len(history) != 1 {
+		t.Fatalf("expected 1 price change but got %v", len(history))
+	}
+	if !history[0].StoragePrice.Equals(settings.MinStoragePrice) {
+		t.Fatal("storage price should be the min price")
+	}
+
+	// Simulate maximum demand. The prices should go up and a new change
+	// should be recorded.
+	atomic.AddUint64(&h.atomicFormContractCalls, dynamicPricingDemandSaturation)
+	err = h.managedUpdateDynamicPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	history = h.PriceHistory()
+	if len(history) != 2 {
+		t.Fatalf("expected 2 price changes but got %v", len(history))
+	}
+	if history[1].Demand != 1 {
+		t.Fatal("expected demand to be 1 but was", history[1].Demand)
+	}
+	expected := dynamicPrice(settings.MinStoragePrice, settings.MaxStoragePrice, pricingLoad(0, 1))
+	if !history[1].StoragePrice.Equals(expected) {
+		t.Fatalf("expected storage price %v but got %v", expected, history[1].StoragePrice)
+	}
+	if es := h.ExternalSettings(); !es.StoragePrice.Equals(expected) {
+		t.Fatalf("external settings should advertise %v but got %v", expected, es.StoragePrice)
+	}
+
+	// Disabling dynamic pricing resets the advertised prices.
+	settings.DynamicPricing = false
+	err = h.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es := h.ExternalSettings(); !es.StoragePrice.Equals(settings.MinStoragePrice) {
+		t.Fatal("external settings should advertise the min price")
+	}
+}
@@ -447,22 +447,64 @@ func (r *Registry) Prune(expiry types.BlockHeight) (uint64, error) {
 			entry.mu.Unlock()
 			continue // not expired
 		}
-		// Delete the entry from disk.
-		if err := r.staticSaveEntry(entry, false); err != nil {
+		// Delete the entry.
+		if err := r.managedDeleteEntry(entry); err != nil {
 			errs = errors.Compose(errs, err)
-			entry.mu.Unlock()
 			continue
 		}
-		// Invalidate the entry.
-		entry.invalid = true
-		entry.mu.Unlock()
-		// Delete the entry from the registry.
-		r.managedDeleteFromMemory(entry)
 		pruned++
 	}
 	return pruned, errs
 }
 
+// EvictEarliestExpiring deletes the entry with the lowest expiry from the
+// registry to make room for a new one. It returns false if there was no entry
+// to evict.
+func (r *Registry) EvictEarliestExpiring() (bool, error) {
+	// Find the candidate while holding the registry lock.
+	r.mu.Lock()
+	var candidate *value
+	for _, v := range r.entries {
+		v.mu.Lock()
+		if !v.invalid && (candidate == nil || v.expiry < candidate.expiry) {
+			candidate = v
+		}
+		v.mu.Unlock()
+	}
+	r.mu.Unlock()
+	if candidate == nil {
+		return false, nil
+	}
+
+	// Lock the entry and make sure it wasn't deleted in the meantime.
+	candidate.mu.Lock()
+	if candidate.invalid {
+		candidate.mu.Unlock()
+		return false, nil
+	}
+	if err := r.managedDeleteEntry(candidate); err != nil {
+		return false, errors.AddContext(err, "failed to evict entry")
+	}
+	return true, nil
+}
+
+// managedDeleteEntry deletes an entry from disk, invalidates it and removes it
+// from memory. The entry is expected to be locked by the caller and will be
+// unlocked by managedDeleteEntry.
+func (r *Registry) managedDeleteEntry(entry *value) error {
+	// Delete the entry from disk.
+	if err := r.staticSaveEntry(entry, false); err != nil {
+		entry.mu.Unlock()
+		return err
+	}
+	// Invalidate the entry.
+	entry.invalid = true
+	entry.mu.Unlock()
+	// Delete the entry from the registry.
+	r.managedDeleteFromMemory(entry)
+	return nil
+}
+
 // Migrate migrates the registry to a new location.
 func (r *Registry) Migrate(path string) error {
 	// Return an error if the paths match.
@@ -738,6 +738,75 @@ func TestFullRegistry(t *testing.T) {
 	}
 }
 
+// TestEvictEarliestExpiring tests evicting entries from a full registry.
+func TestEvictEarliestExpiring(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+
+	// Create a new registry.
+	registryPath := filepath.Join(dir, "registry")
+	numEntries := uint64(64)
+	r, err := New(registryPath, numEntries, types.SiaPublicKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c io.Closer) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(r)
+
+	// Evicting from an empty registry is a no-op.
+	evicted, err := r.EvictEarliestExpiring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if evicted {
+		t.Fatal("nothing should have been evicted")
+	}
+
+	// Fill it completely. The entries expire in reverse order.
+	vals := make([]*value, 0, numEntries)
+	for i := uint64(0); i < numEntries; i++ {
+		rv, v, _ := randomValue(0)
+		v.expiry = types.BlockHeight(numEntries - i)
+		_, err := r.Update(rv, v.key, v.expiry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vals = append(vals, v)
+	}
+
+	// Evict an entry. It should be the last one we added.
+	evicted, err = r.EvictEarliestExpiring()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !evicted {
+		t.Fatal("an entry should have been evicted")
+	}
+	if r.Len() != numEntries-1 {
+		t.Fatal("wrong number of entries", r.Len())
+	}
+	if _, _, exists := r.Get(vals[numEntries-1].mapKey()); exists {
+		t.Fatal("wrong entry was evicted")
+	}
+
+	// There should be room for a new entry now.
+	rv, v, _ := randomValue(0)
+	_, err = r.Update(rv, v.key, v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() != numEntries {
+		t.Fatal("wrong number of entries", r.Len())
+	}
+}
+
 // TestRegistryRace is a multithreaded test to make sure the registry is not
 // suffering from race conditions when updating and pruning several entries from
 // multiple threads each.
@@ -0,0 +1,93 @@
+package host
+
+import (
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errUnknownRegistryEvictionPolicy is returned if the user tries to set an
+	// unknown registry eviction policy.
+	errUnknownRegistryEvictionPolicy = errors.New("unknown registry eviction policy")
+)
+
+// validateRegistryEvictionPolicy checks if the provided eviction policy is
+// known to the host. An empty policy is equivalent to
+// HostRegistryEvictionNone.
+func validateRegistryEvictionPolicy(policy modules.HostRegistryEvictionPolicy) error {
+	switch policy {
+	case "", modules.HostRegistryEvictionNone, modules.HostRegistryEvictionEarliestExpiry:
+		return nil
+	default:
+		return errors.AddContext(errUnknownRegistryEvictionPolicy, string(policy))
+	}
+}
+
+// managedUpdateRegistry updates the registry, evicting an entry to make room
+// for the update if the registry is full and the eviction policy allows it.
+func (h *Host) managedUpdateRegistry(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
+	existingSRV, err := h.staticRegistry.Update(rv, pubKey, expiry)
+	if !errors.Contains(err, registry.ErrNoFreeBit) {
+		return existingSRV, err
+	}
+	if h.managedInternalSettings().RegistryEvictionPolicy != modules.HostRegistryEvictionEarliestExpiry {
+		return existingSRV, err
+	}
+	evicted, evictErr := h.staticRegistry.EvictEarliestExpiring()
+	if evictErr != nil || !evicted {
+		return existingSRV, errors.Compose(err, evictErr)
+	}
+	atomic.AddUint64(&h.atomicRegistryEvictions, 1)
+	return h.staticRegistry.Update(rv, pubKey, expiry)
+}
+
+// threadedPruneRegistry prunes all entries from the registry which expired
+// more than RegistryExpiryGracePeriod blocks before the provided height.
+func (h *Host) threadedPruneRegistry(height types.BlockHeight) {
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	// Only prune once at a time.
+	if !atomic.CompareAndSwapUint32(&h.atomicRegistryPruning, 0, 1) {
+		return
+	}
+	defer atomic.StoreUint32(&h.atomicRegistryPruning, 0)
+
+	gracePeriod := h.managedInternalSettings().RegistryExpiryGracePeriod
+	if height <= gracePeriod {
+		return
+	}
+	pruned, err := h.staticRegistry.Prune(height - gracePeriod - 1)
+	atomic.AddUint64(&h.atomicRegistryPruned, pruned)
+	if err != nil {
+		h.log.Println("WARN: failed to prune expired registry entries:", err)
+	}
+}
+
+// RegistryMetrics returns information about the usage of the host's registry.
+func (h *Host) RegistryMetrics() modules.HostRegistryMetrics {
+	updates := atomic.LoadUint64(&h.atomicRegistryUpdates)
+	var rate float64
+	if elapsed := time.Since(h.staticRegistryMetricsStart); elapsed > 0 {
+		rate = float64(updates) / elapsed.Hours()
+	}
+	return modules.HostRegistryMetrics{
+		EntriesStored: h.staticRegistry.Len(),
+		EntriesTotal:  h.staticRegistry.Cap(),
+
+		Updates:         updates,
+		RejectedUpdates: atomic.LoadUint64(&h.atomicRegistryRejectedUpdates),
+		EvictedEntries:  atomic.LoadUint64(&h.atomicRegistryEvictions),
+		PrunedEntries:   atomic.LoadUint64(&h.atomicRegistryPruned),
+
+		StartTime:  h.staticRegistryMetricsStart,
+		UpdateRate: rate,
+	}
+}
@@ -0,0 +1,101 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostRegistryEviction tests the registry metrics as well as the eviction
+// and pruning of registry entries.
+func TestHostRegistryEviction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Unknown eviction policies are rejected.
+	is := h.InternalSettings()
+	is.RegistryEvictionPolicy = "foo"
+	if err := h.SetInternalSettings(is); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Create a registry with room for 64 entries.
+	is.RegistryEvictionPolicy = modules.HostRegistryEvictionNone
+	is.RegistrySize = 64 * modules.RegistryEntrySize
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill it. The first entry expires the earliest.
+	var first modules.SignedRegistryValue
+	var firstKey types.SiaPublicKey
+	for i := 0; i < 64; i++ {
+		rv, spk, _ := randomRegistryValue()
+		_, err := h.RegistryUpdate(rv, spk, types.BlockHeight(100+i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first, firstKey = rv, spk
+		}
+	}
+
+	// Another update should be rejected.
+	rv, spk, _ := randomRegistryValue()
+	_, err = h.RegistryUpdate(rv, spk, 200)
+	if err == nil {
+		t.Fatal("expected update to fail")
+	}
+	rm := h.RegistryMetrics()
+	if rm.EntriesStored != 64 || rm.EntriesTotal != 64 {
+		t.Fatal("wrong number of entries", rm.EntriesStored, rm.EntriesTotal)
+	}
+	if rm.Updates != 64 || rm.RejectedUpdates != 1 || rm.EvictedEntries != 0 {
+		t.Fatal("wrong metrics", rm)
+	}
+
+	// Enable eviction and try again.
+	is.RegistryEvictionPolicy = modules.HostRegistryEvictionEarliestExpiry
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = h.RegistryUpdate(rv, spk, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, found := h.RegistryGet(modules.DeriveRegistryEntryID(firstKey, first.Tweak)); found {
+		t.Fatal("entry with the earliest expiry should have been evicted")
+	}
+	rm = h.RegistryMetrics()
+	if rm.Updates != 65 || rm.EvictedEntries != 1 || rm.EntriesStored != 64 {
+		t.Fatal("wrong metrics", rm)
+	}
+
+	// Prune the entries which expired before height 110 with a grace period
+	// of 5 blocks. That's the 9 entries with an expiry between 101 and 109.
+	is.RegistryExpiryGracePeriod = 5
+	err = h.SetInternalSettings(is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.threadedPruneRegistry(115)
+	rm = h.RegistryMetrics()
+	if rm.PrunedEntries != 9 || rm.EntriesStored != 55 {
+		t.Fatal("wrong metrics", rm)
+	}
+}
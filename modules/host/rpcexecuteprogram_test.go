@@ -296,7 +296,7 @@ func TestExecuteReadPartialSectorProgram(t *testing.T) {
 	}
 	so.SectorRoots = append(so.SectorRoots, sectorRoot)
 	ht.host.managedLockStorageObligation(rhp.staticFCID)
-	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -563,7 +563,7 @@ func addRandomSector(rhp *renterHostPair) (crypto.Hash, []byte, error) {
 
 	// modify the SO
 	host.managedLockStorageObligation(fcid)
-	err = host.managedModifyStorageObligation(so, []crypto.Hash{}, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		host.managedUnlockStorageObligation(fcid)
 		return crypto.Hash{}, nil, err
@@ -623,7 +623,7 @@ func TestExecuteReadOffsetProgram(t *testing.T) {
 	}
 	so.SectorRoots = []crypto.Hash{sectorRoot}
 	ht.host.managedLockStorageObligation(rhp.staticFCID)
-	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -238,7 +238,7 @@ func TestFundEphemeralAccountRPC(t *testing.T) {
 	numRevisions := len(so.RevisionTransactionSet)
 	so.RevisionTransactionSet[numRevisions-1].FileContractRevisions[0].SetMissedVoidPayout(collateral)
 	ht.host.managedLockStorageObligation(so.id())
-	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, make(map[crypto.Hash][]byte))
+	err = ht.host.managedModifyStorageObligation(so, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -268,7 +268,7 @@ func TestFundEphemeralAccountRPC(t *testing.T) {
 	}
 	so.RevisionTransactionSet[numRevisions-1].FileContractRevisions[0].SetMissedVoidPayout(types.ZeroCurrency)
 	ht.host.managedLockStorageObligation(so.id())
-	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, make(map[crypto.Hash][]byte))
+	err = ht.host.managedModifyStorageObligation(so, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -110,8 +110,9 @@ func (h *Host) managedPriceTableForRenter() *modules.RPCPriceTable {
 
 // managedRPCUpdatePriceTable returns a copy of the host's current rpc price
 // table. These prices are valid for the duration of the
-// rpcPriceGuaranteePeriod, which is defined by the price table's Expiry
-func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) (err error) {
+// rpcPriceGuaranteePeriod, which is defined by the price table's Expiry. If
+// compress is true, the JSON encoded price table is compressed.
+func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream, compress bool) (err error) {
 	pt := *h.managedPriceTableForRenter()
 
 	// json encode the price table
@@ -119,6 +120,12 @@ func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) (err error) {
 	if err != nil {
 		return errors.AddContext(err, "Failed to JSON encode the price table")
 	}
+	if compress {
+		ptBytes, err = modules.CompressRPCPayload(ptBytes)
+		if err != nil {
+			return errors.AddContext(err, "Failed to compress the price table")
+		}
+	}
 
 	// send it to the renter
 	uptResp := modules.RPCUpdatePriceTableResponse{PriceTableJSON: ptBytes}
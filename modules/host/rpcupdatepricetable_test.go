@@ -182,6 +182,9 @@ func TestUpdatePriceTableRPC(t *testing.T) {
 	t.Run("HostNoStreamClose", func(t *testing.T) {
 		testUpdatePriceTableHostNoStreamClose(t, rhp)
 	})
+	t.Run("Compression", func(t *testing.T) {
+		testUpdatePriceTableCompression(t, rhp)
+	})
 }
 
 // testUpdatePriceTableBasic verifies the basic functionality of the update
@@ -364,17 +367,50 @@ func testUpdatePriceTableHostNoStreamClose(t *testing.T, rhp *renterHostPair) {
 	testUpdatePriceTableBasic(t, rhp)
 }
 
+// testUpdatePriceTableCompression verifies the host compresses the price table
+// if the renter requests it.
+func testUpdatePriceTableCompression(t *testing.T, rhp *renterHostPair) {
+	// create a payment revision
+	current := rhp.staticHT.host.staticPriceTables.managedCurrent()
+	rev, sig, err := rhp.managedEAFundRevision(current.UpdatePriceTableCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// execute the RPC request with compression
+	request := newPayByContractRequest(rev, sig, rhp.staticAccountID)
+	pt, err := runUpdatePriceTableRPC(rhp, request, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ensure the decompressed price table is tracked by the host
+	_, tracked := rhp.staticHT.host.staticPriceTables.managedGet(pt.UID)
+	if !tracked {
+		t.Fatalf("Expected price table with.UID %v to be tracked after successful update", pt.UID)
+	}
+	if pt.HostBlockHeight != rhp.staticHT.host.BlockHeight() {
+		t.Fatal("Expected host blockheight to be set on the price table")
+	}
+}
+
 // runUpdatePriceTableRPCWithRequest is a helper function that performs the
 // renter-side of the update price table RPC using a custom PayByContractRequest
 // to similate various edge cases or error flows.
 func runUpdatePriceTableRPCWithRequest(rhp *renterHostPair, pbcRequest modules.PayByContractRequest) (_ *modules.RPCPriceTable, err error) {
+	return runUpdatePriceTableRPC(rhp, pbcRequest, false)
+}
+
+// runUpdatePriceTableRPC performs the renter-side of the update price table
+// RPC, optionally requesting a compressed price table.
+func runUpdatePriceTableRPC(rhp *renterHostPair, pbcRequest modules.PayByContractRequest, compress bool) (_ *modules.RPCPriceTable, err error) {
 	stream := rhp.managedNewStream()
 	defer func() {
 		err = errors.Compose(err, stream.Close())
 	}()
 
 	// initiate the RPC
-	err = modules.RPCWrite(stream, modules.RPCUpdatePriceTable)
+	err = modules.RPCWriteID(stream, modules.RPCUpdatePriceTable, compress)
 	if err != nil {
 		return nil, err
 	}
@@ -386,6 +422,12 @@ func runUpdatePriceTableRPCWithRequest(rhp *renterHostPair, pbcRequest modules.P
 	if err != nil {
 		return nil, err
 	}
+	if compress {
+		update.PriceTableJSON, err = modules.DecompressRPCPayload(update.PriceTableJSON, modules.RPCMinLen)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if err = json.Unmarshal(update.PriceTableJSON, &pt); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,176 @@
+package host
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// sectorAccessMaxTracked is the maximum number of sectors the host tracks
+	// the reads of. Once more sectors are read, the sample rate is halved until
+	// the tracked sectors fit again.
+	sectorAccessMaxTracked = build.Select(build.Var{
+		Dev:      10000,
+		Standard: 100000,
+		Testnet:  100000,
+		Testing:  100,
+	}).(int)
+
+	// sectorAccessBuckets are the lower bounds of the read counts the tracked
+	// sectors are grouped by.
+	sectorAccessBuckets = []uint64{1, 2, 10, 100, 1000}
+)
+
+type (
+	// sectorAccessTracker tracks how frequently sectors are read by renters.
+	// To bound its memory usage, only the reads of a sample of the sectors are
+	// tracked. A sector is sampled if the first sampleShift bits of its root
+	// are zero. Since the sample only depends on the root, the read counts of
+	// a sampled sector are exact.
+	sectorAccessTracker struct {
+		sampleShift uint
+		sectors     map[crypto.Hash]*sectorAccess
+		totalBytes  uint64
+		totalReads  uint64
+
+		staticMaxTracked int
+		staticStart      time.Time
+		mu               sync.Mutex
+	}
+
+	// sectorAccess contains the reads of a single sector.
+	sectorAccess struct {
+		bytes    uint64
+		lastRead time.Time
+		reads    uint64
+	}
+)
+
+// newSectorAccessTracker creates a new tracker which tracks at most maxTracked
+// sectors.
+func newSectorAccessTracker(maxTracked int) *sectorAccessTracker {
+	return &sectorAccessTracker{
+		sectors:          make(map[crypto.Hash]*sectorAccess),
+		staticMaxTracked: maxTracked,
+		staticStart:      time.Now(),
+	}
+}
+
+// sampled returns whether the sector with the given root is part of the
+// sample using the provided shift.
+func sampled(root crypto.Hash, shift uint) bool {
+	return shift == 0 || binary.BigEndian.Uint64(root[:8])>>(64-shift) == 0
+}
+
+// managedRecordRead records that length bytes of the sector were read.
+func (sat *sectorAccessTracker) managedRecordRead(root crypto.Hash, length uint64) {
+	sat.mu.Lock()
+	defer sat.mu.Unlock()
+	sat.totalReads++
+	sat.totalBytes += length
+	if !sampled(root, sat.sampleShift) {
+		return
+	}
+	sa, exists := sat.sectors[root]
+	if !exists {
+		sa = &sectorAccess{}
+		sat.sectors[root] = sa
+	}
+	sa.reads++
+	sa.bytes += length
+	sa.lastRead = time.Now()
+
+	// Halve the sample rate until the tracked sectors fit.
+	for len(sat.sectors) > sat.staticMaxTracked && sat.sampleShift < 63 {
+		sat.sampleShift++
+		for r := range sat.sectors {
+			if !sampled(r, sat.sampleShift) {
+				delete(sat.sectors, r)
+			}
+		}
+	}
+}
+
+// managedStats returns the aggregated reads of the tracked sectors and the
+// limit most frequently read sectors of the sample.
+func (sat *sectorAccessTracker) managedStats(limit int) modules.HostSectorAccessStats {
+	sat.mu.Lock()
+	defer sat.mu.Unlock()
+
+	scale := uint64(1) << sat.sampleShift
+	stats := modules.HostSectorAccessStats{
+		StartTime:  sat.staticStart,
+		TotalBytes: sat.totalBytes,
+		TotalReads: sat.totalReads,
+
+		SampleRate:           1 / float64(scale),
+		TrackedSectors:       uint64(len(sat.sectors)),
+		EstimatedSectorsRead: uint64(len(sat.sectors)) * scale,
+
+		Distribution: make([]modules.HostSectorAccessBucket, len(sectorAccessBuckets)),
+		HotSectors:   make([]modules.HostSectorAccess, 0, len(sat.sectors)),
+	}
+	for i, minReads := range sectorAccessBuckets {
+		stats.Distribution[i].MinReads = minReads
+		if i < len(sectorAccessBuckets)-1 {
+			stats.Distribution[i].MaxReads = sectorAccessBuckets[i+1] - 1
+		}
+	}
+
+	// Group the sectors by their reads.
+	var sampledReads uint64
+	bucketReads := make([]uint64, len(sectorAccessBuckets))
+	for root, sa := range sat.sectors {
+		sampledReads += sa.reads
+		i := sort.Search(len(sectorAccessBuckets), func(i int) bool {
+			return sectorAccessBuckets[i] > sa.reads
+		}) - 1
+		stats.Distribution[i].EstimatedSectors += scale
+		bucketReads[i] += sa.reads
+		stats.HotSectors = append(stats.HotSectors, modules.HostSectorAccess{
+			Bytes:      sa.bytes,
+			LastRead:   sa.lastRead,
+			MerkleRoot: root,
+			Reads:      sa.reads,
+		})
+	}
+	if sampledReads > 0 {
+		for i := range stats.Distribution {
+			stats.Distribution[i].ReadFraction = float64(bucketReads[i]) / float64(sampledReads)
+		}
+	}
+
+	// Sort the sectors by their reads and keep the hottest ones.
+	sort.Slice(stats.HotSectors, func(i, j int) bool {
+		si, sj := stats.HotSectors[i], stats.HotSectors[j]
+		if si.Reads != sj.Reads {
+			return si.Reads > sj.Reads
+		}
+		return si.LastRead.After(sj.LastRead)
+	})
+	if limit < len(stats.HotSectors) {
+		stats.HotSectors = stats.HotSectors[:limit]
+	}
+	return stats
+}
+
+// RecordSectorRead records that length bytes of the sector with the given root
+// were read by a renter.
+func (h *Host) RecordSectorRead(sectorRoot crypto.Hash, length uint64) {
+	h.staticSectorAccess.managedRecordRead(sectorRoot, length)
+}
+
+// SectorAccessStats returns how frequently the host's sectors are read by
+// renters, including up to limit of the most frequently read sectors.
+func (h *Host) SectorAccessStats(limit int) modules.HostSectorAccessStats {
+	if limit < 0 {
+		limit = 0
+	}
+	return h.staticSectorAccess.managedStats(limit)
+}
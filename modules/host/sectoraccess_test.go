@@ -0,0 +1,105 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorAccessTracker tests the aggregation of sector reads by the
+// sectorAccessTracker.
+func TestSectorAccessTracker(t *testing.T) {
+	t.Parallel()
+
+	// Read one sector 10 times, one 2 times and one once.
+	sat := newSectorAccessTracker(10)
+	hot, warm, cold := crypto.Hash{1}, crypto.Hash{2}, crypto.Hash{3}
+	for i := 0; i < 10; i++ {
+		sat.managedRecordRead(hot, modules.SectorSize)
+	}
+	sat.managedRecordRead(warm, 64)
+	sat.managedRecordRead(warm, 64)
+	sat.managedRecordRead(cold, 64)
+
+	stats := sat.managedStats(2)
+	if stats.TotalReads != 13 || stats.TotalBytes != 10*modules.SectorSize+3*64 {
+		t.Fatal("wrong totals", stats.TotalReads, stats.TotalBytes)
+	}
+	if stats.SampleRate != 1 || stats.TrackedSectors != 3 || stats.EstimatedSectorsRead != 3 {
+		t.Fatal("wrong sample", stats.SampleRate, stats.TrackedSectors, stats.EstimatedSectorsRead)
+	}
+	if len(stats.HotSectors) != 2 {
+		t.Fatal("wrong number of hot sectors", len(stats.HotSectors))
+	}
+	if hs := stats.HotSectors[0]; hs.MerkleRoot != hot || hs.Reads != 10 || hs.Bytes != 10*modules.SectorSize {
+		t.Fatal("wrong hottest sector", hs)
+	}
+	if hs := stats.HotSectors[1]; hs.MerkleRoot != warm || hs.Reads != 2 || hs.Bytes != 128 {
+		t.Fatal("wrong second hottest sector", hs)
+	}
+
+	// Check the distribution.
+	if len(stats.Distribution) != len(sectorAccessBuckets) {
+		t.Fatal("wrong number of buckets", len(stats.Distribution))
+	}
+	expected := []struct {
+		minReads, maxReads, sectors uint64
+		readFraction                float64
+	}{
+		{1, 1, 1, 1.0 / 13},
+		{2, 9, 1, 2.0 / 13},
+		{10, 99, 1, 10.0 / 13},
+		{100, 999, 0, 0},
+		{1000, 0, 0, 0},
+	}
+	for i, e := range expected {
+		b := stats.Distribution[i]
+		if b.MinReads != e.minReads || b.MaxReads != e.maxReads || b.EstimatedSectors != e.sectors || b.ReadFraction != e.readFraction {
+			t.Fatalf("wrong bucket %v: %+v", i, b)
+		}
+	}
+}
+
+// TestSectorAccessTrackerSampling tests that the sectorAccessTracker bounds the
+// number of tracked sectors by reducing the sample rate.
+func TestSectorAccessTrackerSampling(t *testing.T) {
+	t.Parallel()
+
+	// Read more sectors than can be tracked. The reads of a sector which is
+	// part of the final sample should be counted exactly.
+	maxTracked := 10
+	sat := newSectorAccessTracker(maxTracked)
+	var roots []crypto.Hash
+	for i := 0; i < 100; i++ {
+		root := crypto.HashObject(i)
+		roots = append(roots, root)
+		sat.managedRecordRead(root, 64)
+		sat.managedRecordRead(root, 64)
+	}
+
+	stats := sat.managedStats(maxTracked)
+	if stats.TotalReads != 200 {
+		t.Fatal("wrong total reads", stats.TotalReads)
+	}
+	if stats.TrackedSectors > uint64(maxTracked) || stats.SampleRate >= 1 {
+		t.Fatal("tracked sectors weren't bounded", stats.TrackedSectors, stats.SampleRate)
+	}
+	if stats.EstimatedSectorsRead != stats.TrackedSectors*uint64(1/stats.SampleRate) {
+		t.Fatal("wrong estimate", stats.EstimatedSectorsRead)
+	}
+	var sampledRoots int
+	for _, root := range roots {
+		if sampled(root, sat.sampleShift) {
+			sampledRoots++
+		}
+	}
+	if uint64(sampledRoots) != stats.TrackedSectors || len(stats.HotSectors) != sampledRoots {
+		t.Fatal("wrong sample", sampledRoots, stats.TrackedSectors, len(stats.HotSectors))
+	}
+	for _, hs := range stats.HotSectors {
+		if !sampled(hs.MerkleRoot, sat.sampleShift) || hs.Reads != 2 || hs.Bytes != 128 {
+			t.Fatal("wrong tracked sector", hs)
+		}
+	}
+}
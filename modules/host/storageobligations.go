@@ -44,6 +44,7 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/contractmanager"
 	"go.sia.tech/siad/modules/wallet"
 	"go.sia.tech/siad/types"
 )
@@ -106,6 +107,10 @@ var (
 	//lint:ignore U1000 used in isSane() which is currently unused but we want to keep it around
 	errInsaneRevisionSetRevisionCount = errors.New("revision transaction set of storage obligation should have one file contract revision in the final transaction")
 
+	// errMissingSectorData is returned if a revision adds a reference to a
+	// sector which isn't stored by the host without providing its data.
+	errMissingSectorData = errors.New("revision references a sector which is neither stored by the host nor provided")
+
 	// errInsaneStorageObligationRevision is returned if there is an attempted
 	// storage obligation revision which does not have sensical inputs.
 	errInsaneStorageObligationRevision = errors.New("revision to storage obligation does not make sense")
@@ -367,13 +372,37 @@ func (so *storageObligation) StorageObligation() modules.StorageObligation {
 
 // Update will take a list of sector changes and update the database to account
 // for all of it.
-func (so storageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map[crypto.Hash]struct{}, sectorsGained map[crypto.Hash][]byte) error {
+func (so storageObligation) Update(sectorRoots, refsAdded, refsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
 	so.SectorRoots = sectorRoots
-	sr := make([]crypto.Hash, 0, len(sectorsRemoved))
-	for sector := range sectorsRemoved {
-		sr = append(sr, sector)
+	return so.h.managedModifyStorageObligation(so, refsAdded, refsRemoved, sectorsGained)
+}
+
+// sectorRefs tracks the change in the number of references to sectors caused
+// by the actions applied to the sector roots of an obligation.
+type sectorRefs map[crypto.Hash]int
+
+// add records a new reference to a sector.
+func (refs sectorRefs) add(root crypto.Hash) {
+	refs[root]++
+}
+
+// remove records a removed reference to a sector.
+func (refs sectorRefs) remove(root crypto.Hash) {
+	refs[root]--
+}
+
+// changes returns the sector roots which gained and lost references. A root
+// is returned once for every reference it gained or lost.
+func (refs sectorRefs) changes() (added, removed []crypto.Hash) {
+	for root, n := range refs {
+		for ; n > 0; n-- {
+			added = append(added, root)
+		}
+		for ; n < 0; n++ {
+			removed = append(removed, root)
+		}
 	}
-	return so.h.managedModifyStorageObligation(so, sr, sectorsGained)
+	return added, removed
 }
 
 // expiration returns the height at which the storage obligation expires.
@@ -801,6 +830,7 @@ func (h *Host) updateFinancialMetricsAddSO(so storageObligation) {
 	h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Add(so.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Add(so.TransactionFeesAdded)
+	h.updateCollateralBudgetLowAlert()
 }
 
 // updateFinancialMetricsAddSO updates the host's financial metrics for a
@@ -828,21 +858,19 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(oldSO.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Sub(oldSO.TransactionFeesAdded)
 
-	// The locked storage collateral was altered, we potentially want to
-	// unregister the insufficient collateral budget alert
-	h.tryUnregisterInsufficientCollateralBudgetAlert()
+	h.updateCollateralBudgetAlerts()
 }
 
 // managedModifyStorageObligation will take an updated storage obligation along
-// with a list of sector changes and update the database to account for all of
-// it. The sector modifications are only used to update the sector database,
-// they will not be used to modify the storage obligation (most importantly,
-// this means that sectorRoots needs to be updated by the calling function).
-// Virtual sectors will be removed the number of times that they are listed, to
-// remove multiple instances of the same virtual sector, the virtual sector
-// will need to appear in 'sectorsRemoved' multiple times. Same with
-// 'sectorsGained'.
-func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+// with the data of any new sectors and update the database to account for all
+// of it. The sector roots of the obligation need to be updated by the calling
+// function. The host keeps one reference to a sector for every time its root
+// appears in the sector roots of an obligation, which allows identical sectors
+// to be stored only once. refsAdded and refsRemoved contain a root for every
+// reference the caller added to or removed from the sector roots.
+// sectorsGained only needs to contain the data of sectors which aren't stored
+// by the host yet.
+func (h *Host) managedModifyStorageObligation(so storageObligation, refsAdded, refsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
 	// Sanity check - all of the sector data should be modules.SectorSize
 	for _, data := range sectorsGained {
 		if uint64(len(data)) != modules.SectorSize {
@@ -882,11 +910,19 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 	// considered invalid. If the removing fails, this is okay, it's ignored
 	// and left to consistency checks and user actions to fix (will reduce host
 	// capacity, but will not inhibit the host's ability to submit storage
-	// proofs)
-	var added []crypto.Hash
+	// proofs). Sectors without data must already be stored by the host.
 	var err error
-	for sectorRoot, data := range sectorsGained {
-		err = h.AddSector(sectorRoot, data)
+	var added []crypto.Hash
+	for _, sectorRoot := range refsAdded {
+		sectorData, gained := sectorsGained[sectorRoot]
+		if gained {
+			err = h.AddSector(sectorRoot, sectorData)
+		} else {
+			err = h.AddVirtualSector(sectorRoot)
+			if errors.Contains(err, contractmanager.ErrSectorNotFound) {
+				err = errMissingSectorData
+			}
+		}
 		if err != nil {
 			break
 		}
@@ -923,19 +959,19 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 	if err != nil {
 		// Because there was an error, all of the sectors that got added need
 		// to be reverted.
-		for sectorRoot := range sectorsGained {
+		for _, sectorRoot := range added {
 			// Error is not checked because there's nothing useful that can be
 			// done about an error.
 			_ = h.RemoveSector(sectorRoot)
 		}
 		return err
 	}
-	// Call removeSector for all of the sectors that have been removed.
-	for k := range sectorsRemoved {
+	// Call removeSector for all of the references that have been removed.
+	for _, sectorRoot := range refsRemoved {
 		// Error is not checkeed because there's nothing useful that can be
 		// done about an error. Failing to remove a sector is not a terrible
 		// place to be, especially if the host can run consistency checks.
-		_ = h.RemoveSector(sectorsRemoved[k])
+		_ = h.RemoveSector(sectorRoot)
 	}
 
 	// Update the financial information for the storage obligation
@@ -1007,9 +1043,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 			h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Sub(so.PotentialUploadRevenue)
 			h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(so.RiskedCollateral)
 
-			// The locked storage collateral was altered, we potentially want to
-			// unregister the insufficient collateral budget alert
-			h.tryUnregisterInsufficientCollateralBudgetAlert()
+			h.updateCollateralBudgetAlerts()
 		}
 	}
 	if sos == obligationSucceeded {
@@ -1038,9 +1072,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.DownloadBandwidthRevenue = h.financialMetrics.DownloadBandwidthRevenue.Add(so.PotentialDownloadRevenue)
 		h.financialMetrics.UploadBandwidthRevenue = h.financialMetrics.UploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
 
-		// The locked storage collateral was altered, we potentially want to
-		// unregister the insufficient collateral budget alert
-		h.tryUnregisterInsufficientCollateralBudgetAlert()
+		h.updateCollateralBudgetAlerts()
 	}
 	if sos == obligationFailed {
 		// Remove the obligation statistics as potential risk and income.
@@ -1057,9 +1089,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.LostStorageCollateral = h.financialMetrics.LostStorageCollateral.Add(so.RiskedCollateral)
 		h.financialMetrics.LostRevenue = h.financialMetrics.LostRevenue.Add(so.ContractCost).Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue).Add(so.PotentialAccountFunding)
 
-		// The locked storage collateral was altered, we potentially want to
-		// unregister the insufficient collateral budget alert
-		h.tryUnregisterInsufficientCollateralBudgetAlert()
+		h.updateCollateralBudgetAlerts()
 	}
 
 	// Update the storage obligation to be finalized but still in-database. The
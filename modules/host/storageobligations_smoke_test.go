@@ -620,7 +620,7 @@ func TestSingleSectorStorageObligationStack(t *testing.T) {
 	}}
 	so.RevisionTransactionSet = revisionSet
 	ht.host.managedLockStorageObligation(so.id())
-	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -844,7 +844,7 @@ func TestMultiSectorStorageObligationStack(t *testing.T) {
 	so.RevisionTransactionSet = revisionSet
 	ht.host.managedLockStorageObligation(so.id())
 
-	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -892,7 +892,7 @@ func TestMultiSectorStorageObligationStack(t *testing.T) {
 		}},
 	}}
 	ht.host.managedLockStorageObligation(so.id())
-	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData2})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot2}, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData2})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1083,7 +1083,7 @@ func TestAutoRevisionSubmission(t *testing.T) {
 	so.RevisionTransactionSet = revisionSet
 	ht.host.managedLockStorageObligation(so.id())
 
-	err = ht.host.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1232,7 +1232,7 @@ func TestLargeContractBlock(t *testing.T) {
 	}}
 	so1.RevisionTransactionSet = revisionSet
 	ht.host.managedLockStorageObligation(so1.id())
-	err = ht.host.managedModifyStorageObligation(so1, nil, nil)
+	err = ht.host.managedModifyStorageObligation(so1, nil, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1252,7 +1252,7 @@ func TestLargeContractBlock(t *testing.T) {
 		// largeContractUpdateDelay seconds.
 		defer close(done)
 		start := time.Now()
-		err := ht.host.managedModifyStorageObligation(so1, nil, nil)
+		err := ht.host.managedModifyStorageObligation(so1, nil, nil, nil)
 		delay := time.Since(start)
 		if err != nil {
 			t.Error(err)
@@ -1274,7 +1274,7 @@ LOOP:
 		numMods++
 		ht.host.managedLockStorageObligation(so2.id())
 		start := time.Now()
-		err := ht.host.managedModifyStorageObligation(so2, nil, nil)
+		err := ht.host.managedModifyStorageObligation(so2, nil, nil, nil)
 		delay := time.Since(start)
 		ht.host.managedUnlockStorageObligation(so2.id())
 		if err != nil {
@@ -167,7 +167,7 @@ func TestStorageObligationSnapshot(t *testing.T) {
 	// Update the SO with new data
 	sectorRoot2, sectorData := randSector()
 	ht.host.managedLockStorageObligation(so.id())
-	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2}, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData})
+	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2}, []crypto.Hash{sectorRoot2}, nil, map[crypto.Hash][]byte{sectorRoot2: sectorData})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -186,7 +186,7 @@ func TestStorageObligationSnapshot(t *testing.T) {
 	// Verify we can not update the SO if it is not locked
 	ht.host.managedUnlockStorageObligation(so.id())
 	sectorRoot3, sectorData := randSector()
-	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2, sectorRoot3}, nil, map[crypto.Hash][]byte{sectorRoot3: sectorData})
+	err = so.Update([]crypto.Hash{sectorRoot, sectorRoot2, sectorRoot3}, []crypto.Hash{sectorRoot3}, nil, map[crypto.Hash][]byte{sectorRoot3: sectorData})
 	if err == nil {
 		t.Fatal("Expected Update to fail on unlocked SO")
 	}
@@ -279,7 +279,7 @@ func TestAccountFundingTracking(t *testing.T) {
 	rd2 := fastrand.Intn(10) + 1
 	so.PotentialAccountFunding = so.PotentialAccountFunding.Add64(uint64(rd2))
 	if err = expectDelta(rd2, 0, "modify SO", func() error {
-		return ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, make(map[crypto.Hash][]byte, 0))
+		return ht.host.managedModifyStorageObligation(so, nil, nil, nil)
 	}); err != nil {
 		t.Fatal(err)
 	}
@@ -341,7 +341,7 @@ func TestManagedModifyUnlockedStorageObligation(t *testing.T) {
 	ht.host.managedUnlockStorageObligation(so.id())
 
 	// Modify the obligation. This should fail.
-	if err := ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, nil); err == nil {
+	if err := ht.host.managedModifyStorageObligation(so, nil, nil, nil); err == nil {
 		t.Fatal("shouldn't be able to modify unlocked so")
 	}
 
@@ -349,7 +349,7 @@ func TestManagedModifyUnlockedStorageObligation(t *testing.T) {
 	ht.host.managedLockStorageObligation(so.id())
 
 	// Modify the obligation. This should work.
-	if err := ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, nil); err != nil {
+	if err := ht.host.managedModifyStorageObligation(so, nil, nil, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -357,7 +357,7 @@ func TestManagedModifyUnlockedStorageObligation(t *testing.T) {
 	ht.host.managedUnlockStorageObligation(so.id())
 
 	// Modify the obligation. This should fail again.
-	if err := ht.host.managedModifyStorageObligation(so, []crypto.Hash{}, nil); err == nil {
+	if err := ht.host.managedModifyStorageObligation(so, nil, nil, nil); err == nil {
 		t.Fatal("shouldn't be able to modify unlocked so")
 	}
 }
@@ -434,7 +434,7 @@ func TestManagedBuildStorageProof(t *testing.T) {
 		sectorRoot: sectorData,
 	}
 	ht.host.managedLockStorageObligation(so.id())
-	err = ht.host.managedModifyStorageObligation(so, nil, sectorsGained)
+	err = ht.host.managedModifyStorageObligation(so, []crypto.Hash{sectorRoot}, nil, sectorsGained)
 	ht.host.managedUnlockStorageObligation(so.id())
 	if err != nil {
 		t.Fatal(err)
@@ -520,3 +520,135 @@ func TestStorageObligationRequiresProof(t *testing.T) {
 		t.Fatal("obligation shouldn't require proof")
 	}
 }
+
+// TestSectorRefs is a unit test for sectorRefs.
+func TestSectorRefs(t *testing.T) {
+	t.Parallel()
+	r1, r2, r3 := crypto.Hash{1}, crypto.Hash{2}, crypto.Hash{3}
+	count := func(roots []crypto.Hash) map[crypto.Hash]int {
+		m := make(map[crypto.Hash]int)
+		for _, root := range roots {
+			m[root]++
+		}
+		return m
+	}
+	tests := []struct {
+		added, removed  []crypto.Hash
+		expectedAdded   map[crypto.Hash]int
+		expectedRemoved map[crypto.Hash]int
+	}{
+		{nil, nil, map[crypto.Hash]int{}, map[crypto.Hash]int{}},
+		{[]crypto.Hash{r1, r1, r2}, nil, map[crypto.Hash]int{r1: 2, r2: 1}, map[crypto.Hash]int{}},
+		{nil, []crypto.Hash{r1, r2}, map[crypto.Hash]int{}, map[crypto.Hash]int{r1: 1, r2: 1}},
+		{[]crypto.Hash{r1, r2}, []crypto.Hash{r2, r1}, map[crypto.Hash]int{}, map[crypto.Hash]int{}},
+		{[]crypto.Hash{r3, r2, r3}, []crypto.Hash{r1, r2}, map[crypto.Hash]int{r3: 2}, map[crypto.Hash]int{r1: 1}},
+	}
+	for i, test := range tests {
+		refs := make(sectorRefs)
+		for _, root := range test.added {
+			refs.add(root)
+		}
+		for _, root := range test.removed {
+			refs.remove(root)
+		}
+		added, removed := refs.changes()
+		if !reflect.DeepEqual(count(added), test.expectedAdded) || !reflect.DeepEqual(count(removed), test.expectedRemoved) {
+			t.Errorf("%v: wrong changes %v %v", i, added, removed)
+		}
+	}
+}
+
+// TestStorageObligationDuplicateSectors checks that identical sectors are only
+// stored once by the host while every obligation referencing them keeps them
+// available.
+func TestStorageObligationDuplicateSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Helper to get the number of physical sectors stored by the host.
+	storedSectors := func() uint64 {
+		var used uint64
+		for _, sf := range ht.host.StorageFolders() {
+			used += sf.Capacity - sf.CapacityRemaining
+		}
+		return used / modules.SectorSize
+	}
+
+	// Add two obligations.
+	var sos []storageObligation
+	for i := 0; i < 2; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ht.host.managedLockStorageObligation(so.id())
+		err = ht.host.managedAddStorageObligation(so)
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sos = append(sos, so)
+	}
+	modify := func(so storageObligation, refsAdded, refsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+		ht.host.managedLockStorageObligation(so.id())
+		defer ht.host.managedUnlockStorageObligation(so.id())
+		return ht.host.managedModifyStorageObligation(so, refsAdded, refsRemoved, sectorsGained)
+	}
+
+	// Add the same sector to the first obligation twice in one revision and
+	// to the second obligation once. It should only be stored once.
+	root, data := randSector()
+	sos[0].SectorRoots = []crypto.Hash{root, root}
+	if err := modify(sos[0], []crypto.Hash{root, root}, nil, map[crypto.Hash][]byte{root: data}); err != nil {
+		t.Fatal(err)
+	}
+	sos[1].SectorRoots = []crypto.Hash{root}
+	if err := modify(sos[1], []crypto.Hash{root}, nil, map[crypto.Hash][]byte{root: data}); err != nil {
+		t.Fatal(err)
+	}
+	if n := storedSectors(); n != 1 {
+		t.Fatal("expected 1 stored sector, got", n)
+	}
+
+	// A sector which isn't stored by the host can't be referenced without
+	// providing its data.
+	root2, _ := randSector()
+	sos[1].SectorRoots = []crypto.Hash{root, root2}
+	if err := modify(sos[1], []crypto.Hash{root2}, nil, nil); !errors.Contains(err, errMissingSectorData) {
+		t.Fatal("expected errMissingSectorData, got", err)
+	}
+	sos[1].SectorRoots = []crypto.Hash{root}
+
+	// Remove all references of the first obligation. The sector needs to stay
+	// around for the second obligation.
+	sos[0].SectorRoots = nil
+	if err := modify(sos[0], nil, []crypto.Hash{root, root}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.host.ReadSector(root); err != nil {
+		t.Fatal("sector should still be available", err)
+	}
+
+	// Remove the last reference. The sector should be removed.
+	sos[1].SectorRoots = nil
+	if err := modify(sos[1], nil, []crypto.Hash{root}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.host.ReadSector(root); err == nil {
+		t.Fatal("sector should have been removed")
+	}
+	if n := storedSectors(); n != 0 {
+		t.Fatal("expected 0 stored sectors, got", n)
+	}
+}
@@ -15,17 +15,11 @@ import (
 	"go.sia.tech/siad/types"
 )
 
-// initRescan is a helper function of initConsensusSubscribe, and is called when
-// the host and the consensus set have become desynchronized. Desynchronization
-// typically happens if the user is replacing or altering the persistent files
-// in the consensus set or the host.
-func (h *Host) initRescan() error {
-	// Reset all of the variables that have relevance to the consensus set.
+// resetStorageObligationsForRescan resets the confirmation status of all
+// storage obligations in the database ahead of a rescan of the consensus set.
+// The reset obligations are returned.
+func (h *Host) resetStorageObligationsForRescan() ([]storageObligation, error) {
 	var allObligations []storageObligation
-	// Reset all of the consensus-relevant variables in the host.
-	h.blockHeight = 0
-
-	// Reset all of the storage obligations.
 	err := h.db.Update(func(tx *bolt.Tx) error {
 		bsu := tx.Bucket(bucketStorageObligations)
 		c := bsu.Cursor()
@@ -50,6 +44,44 @@ func (h *Host) initRescan() error {
 		}
 		return nil
 	})
+	return allObligations, err
+}
+
+// requeueRescannedObligations re-queues the action items for the storage
+// obligations after a rescan of the consensus set and resubmits their origin
+// transaction sets.
+func (h *Host) requeueRescannedObligations(allObligations []storageObligation) {
+	for i, so := range allObligations {
+		soid := so.id()
+		err1 := h.queueActionItem(h.blockHeight+resubmissionTimeout, soid)
+		err2 := h.queueActionItem(so.expiration()-revisionSubmissionBuffer, soid)
+		err3 := h.queueActionItem(so.expiration()+resubmissionTimeout, soid)
+		err := composeErrors(err1, err2, err3)
+		if err != nil {
+			h.log.Println("dropping storage obligation during rescan, id", so.id())
+		}
+
+		// AcceptTransactionSet needs to be called in a goroutine to avoid a
+		// deadlock.
+		go func(i int) {
+			err := h.tpool.AcceptTransactionSet(allObligations[i].OriginTransactionSet)
+			if err != nil {
+				h.log.Println("Unable to submit contract transaction set after rescan:", soid)
+			}
+		}(i)
+	}
+}
+
+// initRescan is a helper function of initConsensusSubscribe, and is called when
+// the host and the consensus set have become desynchronized. Desynchronization
+// typically happens if the user is replacing or altering the persistent files
+// in the consensus set or the host.
+func (h *Host) initRescan() error {
+	// Reset all of the consensus-relevant variables in the host.
+	h.blockHeight = 0
+
+	// Reset all of the storage obligations.
+	allObligations, err := h.resetStorageObligationsForRescan()
 	if err != nil {
 		return err
 	}
@@ -71,25 +103,7 @@ func (h *Host) initRescan() error {
 	})
 
 	// Re-queue all of the action items for the storage obligations.
-	for i, so := range allObligations {
-		soid := so.id()
-		err1 := h.queueActionItem(h.blockHeight+resubmissionTimeout, soid)
-		err2 := h.queueActionItem(so.expiration()-revisionSubmissionBuffer, soid)
-		err3 := h.queueActionItem(so.expiration()+resubmissionTimeout, soid)
-		err = composeErrors(err1, err2, err3)
-		if err != nil {
-			h.log.Println("dropping storage obligation during rescan, id", so.id())
-		}
-
-		// AcceptTransactionSet needs to be called in a goroutine to avoid a
-		// deadlock.
-		go func(i int) {
-			err := h.tpool.AcceptTransactionSet(allObligations[i].OriginTransactionSet)
-			if err != nil {
-				h.log.Println("Unable to submit contract transaction set after rescan:", soid)
-			}
-		}(i)
-	}
+	h.requeueRescannedObligations(allObligations)
 	return nil
 }
 
@@ -300,6 +314,11 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 		go h.threadedHandleActionItem(actionItems[i])
 	}
 
+	// Prune expired registry entries if the block height changed.
+	if h.blockHeight != oldHeight {
+		go h.threadedPruneRegistry(h.blockHeight)
+	}
+
 	// Update the host's recent change pointer to point to the most recent
 	// change.
 	h.recentChange = cc.ID
@@ -12,6 +12,36 @@ const (
 	MinerDir = "miner"
 )
 
+type (
+	// MinerTemplateSettings control how the miner builds the blocks it hands
+	// out for work.
+	MinerTemplateSettings struct {
+		// IncludeTransactions are unconfirmed transactions that are added to
+		// the block before any other transaction, regardless of their fees.
+		IncludeTransactions []types.TransactionID `json:"includetransactions"`
+
+		// ExcludeTransactions are unconfirmed transactions that are never
+		// added to the block. Transactions depending on them are left out as
+		// well.
+		ExcludeTransactions []types.TransactionID `json:"excludetransactions"`
+
+		// Payouts splits the block reward across multiple addresses. If it is
+		// empty, the whole reward is paid to an address of the wallet.
+		Payouts []MinerPayoutSplit `json:"payouts"`
+
+		// ReservedSpace is the number of bytes of the block that are left
+		// free of transactions.
+		ReservedSpace uint64 `json:"reservedspace"`
+	}
+
+	// MinerPayoutSplit is the share of the block reward paid to an address.
+	// The reward is split proportionally to the weights of the payouts.
+	MinerPayoutSplit struct {
+		UnlockHash types.UnlockHash `json:"unlockhash"`
+		Weight     uint64           `json:"weight"`
+	}
+)
+
 // BlockManager contains functions that can interface with external miners,
 // providing and receiving blocks that have experienced nonce grinding.
 type BlockManager interface {
@@ -30,6 +60,14 @@ type BlockManager interface {
 	// BlocksMined returns the number of blocks and stale blocks that have been
 	// mined using this miner.
 	BlocksMined() (goodBlocks, staleBlocks int)
+
+	// SetTemplateSettings changes the settings used to build the blocks
+	// returned by HeaderForWork.
+	SetTemplateSettings(MinerTemplateSettings) error
+
+	// TemplateSettings returns the settings used to build the blocks returned
+	// by HeaderForWork.
+	TemplateSettings() MinerTemplateSettings
 }
 
 // CPUMiner provides access to a single-threaded cpu miner.
@@ -27,15 +27,15 @@ func (m *Miner) blockForWork() types.Block {
 		b.Timestamp = types.CurrentTimestamp()
 	}
 
+	// Apply the template settings to the transactions.
+	b.Transactions = m.templateTransactions()
+
 	// Update the address + payouts.
 	err := m.checkAddress()
 	if err != nil {
 		m.log.Println(err)
 	}
-	b.MinerPayouts = []types.SiacoinOutput{{
-		Value:      b.CalculateSubsidy(m.persist.Height + 1),
-		UnlockHash: m.persist.Address,
-	}}
+	b.MinerPayouts = m.templatePayouts(b)
 
 	// Add an arb-data txn to the block to create a unique merkle root.
 	randBytes := fastrand.Bytes(types.SpecifierLen)
@@ -32,6 +32,8 @@ type (
 		Address       types.UnlockHash
 		BlocksFound   []types.BlockID
 		UnsolvedBlock types.Block
+
+		TemplateSettings modules.MinerTemplateSettings
 	}
 )
 
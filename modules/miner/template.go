@@ -0,0 +1,222 @@
+package miner
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errIncludedAndExcluded is returned when a transaction is both included
+	// in and excluded from the block template.
+	errIncludedAndExcluded = errors.New("transaction can't be both included and excluded")
+
+	// errReservedSpaceTooLarge is returned when the reserved space doesn't
+	// leave any room for transactions.
+	errReservedSpaceTooLarge = errors.New("reserved space leaves no room for transactions")
+
+	// errZeroPayoutWeight is returned when a payout split has a zero weight.
+	errZeroPayoutWeight = errors.New("payout split needs a non-zero weight")
+)
+
+// validateTemplateSettings checks that the template settings can be used to
+// build valid blocks.
+func validateTemplateSettings(s modules.MinerTemplateSettings) error {
+	if s.ReservedSpace >= types.BlockSizeLimit-5e3 {
+		return errReservedSpaceTooLarge
+	}
+	for _, p := range s.Payouts {
+		if p.Weight == 0 {
+			return errZeroPayoutWeight
+		}
+	}
+	excluded := make(map[types.TransactionID]struct{}, len(s.ExcludeTransactions))
+	for _, id := range s.ExcludeTransactions {
+		excluded[id] = struct{}{}
+	}
+	for _, id := range s.IncludeTransactions {
+		if _, exists := excluded[id]; exists {
+			return errors.AddContext(errIncludedAndExcluded, id.String())
+		}
+	}
+	return nil
+}
+
+// withoutExcluded returns the transactions of a split set that neither are
+// excluded nor depend on an excluded transaction.
+func withoutExcluded(set []types.Transaction, excluded map[types.TransactionID]struct{}) []types.Transaction {
+	// Sets are ordered by dependency, so the children of an excluded
+	// transaction always come after it.
+	created := make(map[crypto.Hash]struct{})
+	var kept []types.Transaction
+	for _, txn := range set {
+		_, exclude := excluded[txn.ID()]
+		for _, sci := range txn.SiacoinInputs {
+			_, exists := created[crypto.Hash(sci.ParentID)]
+			exclude = exclude || exists
+		}
+		for _, fcr := range txn.FileContractRevisions {
+			_, exists := created[crypto.Hash(fcr.ParentID)]
+			exclude = exclude || exists
+		}
+		for _, sp := range txn.StorageProofs {
+			_, exists := created[crypto.Hash(sp.ParentID)]
+			exclude = exclude || exists
+		}
+		for _, sfi := range txn.SiafundInputs {
+			_, exists := created[crypto.Hash(sfi.ParentID)]
+			exclude = exclude || exists
+		}
+		if !exclude {
+			kept = append(kept, txn)
+			continue
+		}
+		for i := range txn.SiacoinOutputs {
+			created[crypto.Hash(txn.SiacoinOutputID(uint64(i)))] = struct{}{}
+		}
+		for i := range txn.FileContracts {
+			created[crypto.Hash(txn.FileContractID(uint64(i)))] = struct{}{}
+		}
+		for i := range txn.SiafundOutputs {
+			created[crypto.Hash(txn.SiafundOutputID(uint64(i)))] = struct{}{}
+		}
+	}
+	return kept
+}
+
+// templateTransactions returns the transactions of the next block according to
+// the template settings. The split sets of the included transactions come
+// first, followed by the sets of the unsolved block and then by the sets of
+// the overflow with the highest fees, as long as they fit in the block.
+func (m *Miner) templateTransactions() []types.Transaction {
+	s := m.persist.TemplateSettings
+	if len(s.IncludeTransactions) == 0 && len(s.ExcludeTransactions) == 0 && s.ReservedSpace == 0 {
+		return m.persist.UnsolvedBlock.Transactions
+	}
+
+	// Collect the candidate split sets in order of priority.
+	var candidates []splitSetID
+	seen := make(map[splitSetID]struct{})
+	addCandidate := func(id splitSetID) {
+		if _, exists := m.splitSets[id]; !exists {
+			return
+		}
+		if _, exists := seen[id]; exists {
+			return
+		}
+		seen[id] = struct{}{}
+		candidates = append(candidates, id)
+	}
+	for _, txid := range s.IncludeTransactions {
+		if id, exists := m.splitSetIDFromTxID[txid]; exists {
+			addCandidate(id)
+		}
+	}
+	for _, txn := range m.persist.UnsolvedBlock.Transactions {
+		addCandidate(m.splitSetIDFromTxID[txn.ID()])
+	}
+	overflow := make([]*mapElement, len(m.overflowMapHeap.data))
+	copy(overflow, m.overflowMapHeap.data)
+	sort.Slice(overflow, func(i, j int) bool {
+		return overflow[i].set.averageFee.Cmp(overflow[j].set.averageFee) > 0
+	})
+	for _, elem := range overflow {
+		addCandidate(elem.id)
+	}
+
+	// Add the candidates to the block, leaving out the excluded transactions
+	// and the reserved space.
+	excluded := make(map[types.TransactionID]struct{}, len(s.ExcludeTransactions))
+	for _, id := range s.ExcludeTransactions {
+		excluded[id] = struct{}{}
+	}
+	limit := uint64(types.BlockSizeLimit-5e3) - s.ReservedSpace
+	var size uint64
+	var txns []types.Transaction
+	for _, id := range candidates {
+		set := withoutExcluded(m.splitSets[id].transactions, excluded)
+		var setSize uint64
+		for _, txn := range set {
+			setSize += uint64(len(encoding.Marshal(txn)))
+		}
+		if len(set) == 0 || size+setSize >= limit {
+			continue
+		}
+		size += setSize
+		txns = append(txns, set...)
+	}
+	return txns
+}
+
+// templatePayouts returns the miner payouts of a block according to the
+// template settings. The subsidy is split proportionally to the weights of the
+// payouts, with the rounding remainder going to the first payout.
+func (m *Miner) templatePayouts(b types.Block) []types.SiacoinOutput {
+	subsidy := b.CalculateSubsidy(m.persist.Height + 1)
+	splits := m.persist.TemplateSettings.Payouts
+	if len(splits) == 0 {
+		return []types.SiacoinOutput{{
+			Value:      subsidy,
+			UnlockHash: m.persist.Address,
+		}}
+	}
+
+	var totalWeight types.Currency
+	for _, split := range splits {
+		totalWeight = totalWeight.Add(types.NewCurrency64(split.Weight))
+	}
+	payouts := make([]types.SiacoinOutput, 0, len(splits))
+	var paid types.Currency
+	for _, split := range splits {
+		value := subsidy.Mul64(split.Weight).Div(totalWeight)
+		payouts = append(payouts, types.SiacoinOutput{
+			Value:      value,
+			UnlockHash: split.UnlockHash,
+		})
+		paid = paid.Add(value)
+	}
+	payouts[0].Value = payouts[0].Value.Add(subsidy.Sub(paid))
+
+	// Zero value payouts would make the block invalid.
+	nonZero := payouts[:0]
+	for _, payout := range payouts {
+		if !payout.Value.IsZero() {
+			nonZero = append(nonZero, payout)
+		}
+	}
+	return nonZero
+}
+
+// SetTemplateSettings changes the settings used to build the blocks returned
+// by HeaderForWork. Headers handed out after the call use the new settings.
+func (m *Miner) SetTemplateSettings(s modules.MinerTemplateSettings) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	if err := validateTemplateSettings(s); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.persist.TemplateSettings = s
+	if m.sourceBlock != nil {
+		m.newSourceBlock()
+	}
+	return m.saveSync()
+}
+
+// TemplateSettings returns the settings used to build the blocks returned by
+// HeaderForWork.
+func (m *Miner) TemplateSettings() modules.MinerTemplateSettings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.persist.TemplateSettings
+}
@@ -0,0 +1,130 @@
+package miner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// containsTransaction returns true if the block contains the transaction.
+func containsTransaction(b types.Block, id types.TransactionID) bool {
+	for _, txn := range b.Transactions {
+		if txn.ID() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TestTemplateSettings checks that the template settings are applied to the
+// blocks built by the miner.
+func TestTemplateSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid settings should be rejected.
+	if err := mt.miner.SetTemplateSettings(modules.MinerTemplateSettings{ReservedSpace: types.BlockSizeLimit}); err != errReservedSpaceTooLarge {
+		t.Fatal("expected errReservedSpaceTooLarge, got", err)
+	}
+	if err := mt.miner.SetTemplateSettings(modules.MinerTemplateSettings{Payouts: []modules.MinerPayoutSplit{{}}}); err != errZeroPayoutWeight {
+		t.Fatal("expected errZeroPayoutWeight, got", err)
+	}
+
+	// Create two unconfirmed transactions.
+	var txnIDs []types.TransactionID
+	for i := 0; i < 2; i++ {
+		uc, err := mt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		txns, err := mt.wallet.SendSiacoins(types.SiacoinPrecision, uc.UnlockHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		txnIDs = append(txnIDs, txns[len(txns)-1].ID())
+	}
+
+	// Excluding the first transaction should leave it out of the block, while
+	// including the second one should put it first.
+	addr1, addr2 := types.UnlockHash{1}, types.UnlockHash{2}
+	settings := modules.MinerTemplateSettings{
+		IncludeTransactions: []types.TransactionID{txnIDs[1]},
+		ExcludeTransactions: []types.TransactionID{txnIDs[0]},
+		Payouts: []modules.MinerPayoutSplit{
+			{UnlockHash: addr1, Weight: 1},
+			{UnlockHash: addr2, Weight: 3},
+		},
+	}
+	if err := mt.miner.SetTemplateSettings(modules.MinerTemplateSettings{IncludeTransactions: txnIDs, ExcludeTransactions: txnIDs}); err == nil {
+		t.Fatal("expected settings including and excluding the same transaction to be rejected")
+	}
+	if err := mt.miner.SetTemplateSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := mt.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsTransaction(b, txnIDs[0]) || !containsTransaction(b, txnIDs[1]) {
+		t.Fatal("template settings weren't applied to the transactions")
+	}
+
+	// The reward should be split between the two addresses.
+	subsidy := b.CalculateSubsidy(mt.cs.Height() + 1)
+	if len(b.MinerPayouts) != 2 {
+		t.Fatal("unexpected number of payouts", len(b.MinerPayouts))
+	}
+	if b.MinerPayouts[0].UnlockHash != addr1 || b.MinerPayouts[1].UnlockHash != addr2 {
+		t.Fatal("payouts have the wrong addresses")
+	}
+	if !b.MinerPayouts[0].Value.Add(b.MinerPayouts[1].Value).Equals(subsidy) {
+		t.Fatal("payouts don't add up to the subsidy")
+	}
+	if b.MinerPayouts[1].Value.Cmp(b.MinerPayouts[0].Value.Mul64(3)) > 0 {
+		t.Fatal("payouts weren't split according to their weights")
+	}
+
+	// The block should be valid.
+	b, err = mt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsTransaction(b, txnIDs[1]) {
+		t.Fatal("included transaction wasn't mined")
+	}
+	if containsTransaction(b, txnIDs[0]) {
+		t.Fatal("excluded transaction was mined")
+	}
+
+	// Reserving almost the whole block should leave no room for transactions.
+	settings = modules.MinerTemplateSettings{ReservedSpace: types.BlockSizeLimit - 5e3 - 1}
+	if err := mt.miner.SetTemplateSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	b, _, err = mt.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Transactions) != 1 {
+		t.Fatal("block should only contain the arbitrary data transaction", len(b.Transactions))
+	}
+
+	// The settings should persist.
+	if err := mt.miner.Close(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(mt.cs, mt.tpool, mt.wallet, filepath.Join(mt.persistDir, modules.MinerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := m.TemplateSettings(); s.ReservedSpace != settings.ReservedSpace {
+		t.Fatal("template settings weren't persisted", s)
+	}
+}
@@ -23,7 +23,11 @@ import (
 const (
 	// RHPVersion is the version of the Sia renter-host protocol currently
 	// implemented by the host module.
-	RHPVersion = "1.5.9"
+	RHPVersion = "1.5.10"
+
+	// MinRPCCompressionVersion is the minimum version of the renter-host
+	// protocol which supports the compression of RPC payloads.
+	MinRPCCompressionVersion = "1.5.10"
 
 	// MinimumSupportedRenterHostProtocolVersion is the minimum version of Sia
 	// that supports the currently used version of the renter-host protocol.
@@ -60,6 +64,12 @@ const (
 	StopResponse = "stop"
 )
 
+const (
+	// MaxAlternativeNetAddresses is the maximum number of alternative net
+	// addresses a host can include in its announcement.
+	MaxAlternativeNetAddresses = 4
+)
+
 const (
 	// NegotiateDownloadTime defines the amount of time that the renter and
 	// host have to negotiate a download request batch. The time is set high
@@ -193,6 +203,12 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.NewSpecifier("HostAnnouncement")
 
+	// PrefixHostAnnouncementAddresses is used to indicate that a signed host
+	// announcement is followed by a list of alternative net addresses of the
+	// host. Nodes which don't know about the alternative addresses ignore
+	// them.
+	PrefixHostAnnouncementAddresses = types.NewSpecifier("HostAddresses")
+
 	// PrefixFileContractIdentifier is used to indicate that a transaction's
 	// Arbitrary Data field contains a file contract identifier. The identifier
 	// and its signature will follow this prefix.
@@ -264,6 +280,17 @@ type (
 		PublicKey  types.SiaPublicKey
 	}
 
+	// HostAnnouncementAddresses can follow the signature of a
+	// HostAnnouncement. It contains alternative net addresses of the host,
+	// e.g. an IPv6 or onion address, which renters can try if the primary
+	// address isn't reachable. 'Specifier' is always
+	// 'PrefixHostAnnouncementAddresses'. It is followed by a signature of the
+	// whole announcement including the alternative addresses.
+	HostAnnouncementAddresses struct {
+		Specifier    types.Specifier
+		NetAddresses []NetAddress
+	}
+
 	// HostExternalSettings are the parameters advertised by the host. These
 	// are the values that the renter will request from the host in order to
 	// build its database.
@@ -865,50 +892,86 @@ func WriteNegotiationStop(w io.Writer) error {
 // the exact []byte that should be added to the arbitrary data of a
 // transaction.
 func CreateAnnouncement(addr NetAddress, pk types.SiaPublicKey, sk crypto.SecretKey) (signedAnnouncement []byte, err error) {
+	return CreateAnnouncementWithAddresses(addr, nil, pk, sk)
+}
+
+// CreateAnnouncementWithAddresses creates a host announcement like
+// CreateAnnouncement but also includes a list of alternative net addresses
+// which renters can use to reach the host.
+func CreateAnnouncementWithAddresses(addr NetAddress, alternatives []NetAddress, pk types.SiaPublicKey, sk crypto.SecretKey) (signedAnnouncement []byte, err error) {
 	if err := addr.IsValid(); err != nil {
 		return nil, err
 	}
+	if len(alternatives) > MaxAlternativeNetAddresses {
+		return nil, fmt.Errorf("announcement can't contain more than %v alternative net addresses", MaxAlternativeNetAddresses)
+	}
+	for _, alt := range alternatives {
+		if err := alt.IsValid(); err != nil {
+			return nil, errors.AddContext(err, "invalid alternative net address")
+		}
+	}
 
 	// Create the HostAnnouncement and marshal it.
-	annBytes := encoding.Marshal(HostAnnouncement{
+	ha := HostAnnouncement{
 		Specifier:  PrefixHostAnnouncement,
 		NetAddress: addr,
 		PublicKey:  pk,
-	})
+	}
+	annBytes := encoding.Marshal(ha)
 
 	// Create a signature for the announcement.
 	annHash := crypto.HashBytes(annBytes)
 	sig := crypto.SignHash(annHash, sk)
-	// Return the signed announcement.
-	return append(annBytes, sig[:]...), nil
+	signedAnnouncement = append(annBytes, sig[:]...)
+	if len(alternatives) == 0 {
+		return signedAnnouncement, nil
+	}
+
+	// Append the alternative addresses and sign the whole announcement again.
+	haa := HostAnnouncementAddresses{
+		Specifier:    PrefixHostAnnouncementAddresses,
+		NetAddresses: alternatives,
+	}
+	altSig := crypto.SignHash(crypto.HashAll(ha, haa), sk)
+	signedAnnouncement = append(signedAnnouncement, encoding.Marshal(haa)...)
+	return append(signedAnnouncement, altSig[:]...), nil
 }
 
 // DecodeAnnouncement decodes announcement bytes into a host announcement,
 // verifying the prefix and the signature.
 func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
+	na, _, spk, err = DecodeAnnouncementWithAddresses(fullAnnouncement)
+	return
+}
+
+// DecodeAnnouncementWithAddresses decodes announcement bytes into a host
+// announcement and its alternative net addresses, verifying the prefixes and
+// the signatures. Invalid alternative addresses don't invalidate the
+// announcement, they are ignored instead.
+func DecodeAnnouncementWithAddresses(fullAnnouncement []byte) (na NetAddress, alternatives []NetAddress, spk types.SiaPublicKey, err error) {
 	// Read the first part of the announcement to get the intended host
 	// announcement.
 	var ha HostAnnouncement
 	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement), len(fullAnnouncement)*3)
 	err = dec.Decode(&ha)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return "", nil, types.SiaPublicKey{}, err
 	}
 
 	// Check that the announcement was registered as a host announcement.
 	if ha.Specifier != PrefixHostAnnouncement {
-		return "", types.SiaPublicKey{}, ErrAnnNotAnnouncement
+		return "", nil, types.SiaPublicKey{}, ErrAnnNotAnnouncement
 	}
 	// Check that the public key is a recognized type of public key.
 	if ha.PublicKey.Algorithm != types.SignatureEd25519 {
-		return "", types.SiaPublicKey{}, ErrAnnUnrecognizedSignature
+		return "", nil, types.SiaPublicKey{}, ErrAnnUnrecognizedSignature
 	}
 
 	// Read the signature out of the reader.
 	var sig crypto.Signature
 	err = dec.Decode(&sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return "", nil, types.SiaPublicKey{}, err
 	}
 	// Verify the signature.
 	var pk crypto.PublicKey
@@ -916,9 +979,28 @@ func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPu
 	annHash := crypto.HashObject(ha)
 	err = crypto.VerifyHash(annHash, pk, sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return "", nil, types.SiaPublicKey{}, err
+	}
+
+	// Read the optional alternative addresses. Since the base announcement is
+	// valid, any error from here on only drops the alternative addresses.
+	var haa HostAnnouncementAddresses
+	var altSig crypto.Signature
+	if dec.Decode(&haa) != nil || haa.Specifier != PrefixHostAnnouncementAddresses || dec.Decode(&altSig) != nil {
+		return ha.NetAddress, nil, ha.PublicKey, nil
+	}
+	if crypto.VerifyHash(crypto.HashAll(ha, haa), pk, altSig) != nil {
+		return ha.NetAddress, nil, ha.PublicKey, nil
+	}
+	for _, alt := range haa.NetAddresses {
+		if len(alternatives) == MaxAlternativeNetAddresses {
+			break
+		}
+		if alt.IsValid() == nil && alt != ha.NetAddress {
+			alternatives = append(alternatives, alt)
+		}
 	}
-	return ha.NetAddress, ha.PublicKey, nil
+	return ha.NetAddress, alternatives, ha.PublicKey, nil
 }
 
 // IsOOSErr is a helper function to determine whether an error from a host is
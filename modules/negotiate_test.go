@@ -86,6 +86,69 @@ func TestAnnouncementHandling(t *testing.T) {
 	}
 }
 
+// TestAnnouncementAlternativeAddresses checks that alternative net addresses
+// can be added to an announcement without breaking the decoding of the base
+// announcement.
+func TestAnnouncementAlternativeAddresses(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	addr := NetAddress("f.o:1234")
+	alternatives := []NetAddress{"[2001:db8::1]:1234", "abcdefghijklmnop.onion:1234"}
+
+	// Too many alternatives should be rejected.
+	tooMany := make([]NetAddress, MaxAlternativeNetAddresses+1)
+	for i := range tooMany {
+		tooMany[i] = addr
+	}
+	if _, err := CreateAnnouncementWithAddresses(addr, tooMany, spk, sk); err == nil {
+		t.Fatal("expected error")
+	}
+
+	annBytes, err := CreateAnnouncementWithAddresses(addr, alternatives, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The alternatives should be decoded.
+	decAddr, decAlternatives, decPubKey, err := DecodeAnnouncementWithAddresses(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addr || !decPubKey.Equals(spk) {
+		t.Fatal("wrong announcement", decAddr, decPubKey)
+	}
+	if len(decAlternatives) != len(alternatives) {
+		t.Fatal("wrong number of alternatives", decAlternatives)
+	}
+	for i := range alternatives {
+		if decAlternatives[i] != alternatives[i] {
+			t.Fatal("wrong alternative", decAlternatives[i], alternatives[i])
+		}
+	}
+
+	// The announcement should still be a valid announcement without the
+	// alternatives.
+	if decAddr, _, err := DecodeAnnouncement(annBytes); err != nil || decAddr != addr {
+		t.Fatal("failed to decode base announcement", decAddr, err)
+	}
+
+	// Corrupting the alternative signature drops the alternatives but not the
+	// announcement.
+	annBytes[len(annBytes)-1]++
+	decAddr, decAlternatives, _, err = DecodeAnnouncementWithAddresses(annBytes)
+	if err != nil || decAddr != addr {
+		t.Fatal("failed to decode base announcement", decAddr, err)
+	}
+	if len(decAlternatives) != 0 {
+		t.Fatal("alternatives with invalid signature shouldn't be decoded", decAlternatives)
+	}
+}
+
 // TestNegotiationResponses tests the WriteNegotiationAcceptance,
 // WriteNegotiationRejection, and ReadNegotiationAcceptance functions.
 func TestNegotiationResponses(t *testing.T) {
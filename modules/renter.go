@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"time"
 
@@ -57,6 +58,12 @@ var (
 		ExpectedRedundancy: 3.0,                                          // default is 10/30 erasure coding
 		MaxPeriodChurn:     uint64(250e9),                                // 250 GB
 	}
+
+	// DefaultAccountMaxBalanceAtRisk is the default maximum balance the renter
+	// keeps in its ephemeral account with a single host. It is used when the
+	// AccountMaxBalanceAtRisk renter setting is not set.
+	DefaultAccountMaxBalanceAtRisk = types.SiacoinPrecision
+
 	// ErrHostFault indicates if an error is the host's fault.
 	ErrHostFault = errors.New("host has returned an error")
 
@@ -64,6 +71,34 @@ var (
 	// manually by the user.
 	ErrDownloadCancelled = errors.New("download was cancelled")
 
+	// ErrDirQuotaExceeded is returned when an upload would exceed the quota of
+	// the directory it is uploaded to or of one of its parents.
+	ErrDirQuotaExceeded = errors.New("upload would exceed the quota of the directory")
+
+	// ErrLocked is returned when a file or directory which is locked, or which
+	// is within a locked directory, is deleted or overwritten.
+	ErrLocked = errors.New("file or directory is locked against deletion and modification")
+
+	// ErrLockShortened is returned when a lock is shortened or removed before
+	// it expires.
+	ErrLockShortened = errors.New("a lock can't be shortened or removed before it expires")
+
+	// ErrReadOnly is returned when a file which was shared by another renter
+	// is modified, moved or locked.
+	ErrReadOnly = errors.New("file was shared by another renter and is read-only")
+
+	// ErrCostCeilingExceeded is returned by a worker which aborts an operation
+	// because its cost exceeds the ceiling set in the renter's settings.
+	ErrCostCeilingExceeded = errors.New("cost of operation exceeds the renter's cost ceiling")
+
+	// ErrURLUploadTooLarge is returned if the content fetched for a URL upload
+	// exceeds the maximum size of the upload.
+	ErrURLUploadTooLarge = errors.New("content of url exceeds the maximum upload size")
+
+	// ErrURLUploadUnknownSize is returned if the server of a URL upload
+	// doesn't report the size of the content.
+	ErrURLUploadUnknownSize = errors.New("server didn't report the size of the content of url")
+
 	// ErrNotEnoughWorkersInWorkerPool is an error that is returned whenever an
 	// operation expects a certain number of workers but there aren't that many
 	// available.
@@ -82,6 +117,9 @@ var (
 	// BackupKeySpecifier is a specifier that is hashed with the wallet seed to
 	// create a key for encrypting backups.
 	BackupKeySpecifier = types.NewSpecifier("backupkey")
+	// ContractExportKeySpecifier is a specifier that is hashed with the wallet
+	// seed to create a key for encrypting contract exports.
+	ContractExportKeySpecifier = types.NewSpecifier("contractexport")
 )
 
 // DataSourceID is an identifier to uniquely identify a data source, such as for
@@ -158,6 +196,20 @@ const (
 	// permissions are supplied. Changing this value is a compatibility issue
 	// since users expect files to have these permissions.
 	DefaultFilePerm = 0644
+
+	// RepairPriorityTag is the tag of files and dirs hinting the priority of
+	// their repairs. Its value is either RepairPriorityHigh or
+	// RepairPriorityLow. Files without the tag inherit it from their closest
+	// tagged parent dir.
+	RepairPriorityTag = "repairpriority"
+
+	// RepairPriorityHigh is the value of the RepairPriorityTag of files that
+	// should be repaired before other files.
+	RepairPriorityHigh = "high"
+
+	// RepairPriorityLow is the value of the RepairPriorityTag of files that
+	// should be repaired after other files.
+	RepairPriorityLow = "low"
 )
 
 // String returns the string value for the FilterMode
@@ -280,6 +332,15 @@ type Allowance struct {
 	// period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
 
+	// MaxPeriodContractChurn is the maximum number of contracts which may be
+	// churned for a poor host score in a single period. If it is 0, only
+	// MaxPeriodChurn limits the churn.
+	MaxPeriodContractChurn uint64 `json:"maxperiodcontractchurn"`
+
+	// RenewWindowPolicy determines when within the renew window contracts are
+	// renewed.
+	RenewWindowPolicy RenewWindowPolicy `json:"renewwindowpolicy"`
+
 	// The following fields provide price gouging protection for the user. By
 	// setting a particular maximum price for each mechanism that a host can use
 	// to charge users, the workers know to avoid hosts that go outside of the
@@ -304,6 +365,25 @@ type Allowance struct {
 	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
 }
 
+// RenewWindowPolicy determines when within the renew window of an allowance
+// contracts are renewed.
+type RenewWindowPolicy string
+
+const (
+	// RenewWindowPolicyStart renews contracts as soon as the renew window
+	// starts. It is the default policy.
+	RenewWindowPolicyStart RenewWindowPolicy = ""
+
+	// RenewWindowPolicySpread spreads the renewals of the contracts across the
+	// first half of the renew window instead of renewing all of them at once.
+	RenewWindowPolicySpread RenewWindowPolicy = "spread"
+)
+
+// IsValid returns whether the policy is a known renew window policy.
+func (p RenewWindowPolicy) IsValid() bool {
+	return p == RenewWindowPolicyStart || p == RenewWindowPolicySpread
+}
+
 // Active returns true if and only if this allowance has been set in the
 // contractor.
 func (a Allowance) Active() bool {
@@ -362,22 +442,25 @@ type DirectoryInfo struct {
 
 	// The following fields are information specific to the siadir that is not
 	// an aggregate of the entire sub directory tree
-	Health              float64     `json:"health"`
-	LastHealthCheckTime time.Time   `json:"lasthealthchecktime"`
-	MaxHealthPercentage float64     `json:"maxhealthpercentage"`
-	MaxHealth           float64     `json:"maxhealth"`
-	MinRedundancy       float64     `json:"minredundancy"`
-	DirMode             os.FileMode `json:"mode,siamismatch"` // Field is called DirMode for fuse compatibility
-	MostRecentModTime   time.Time   `json:"mostrecentmodtime"`
-	NumFiles            uint64      `json:"numfiles"`
-	NumStuckChunks      uint64      `json:"numstuckchunks"`
-	NumSubDirs          uint64      `json:"numsubdirs"`
-	RepairSize          uint64      `json:"repairsize"`
-	SiaPath             SiaPath     `json:"siapath"`
-	DirSize             uint64      `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
-	StuckHealth         float64     `json:"stuckhealth"`
-	StuckSize           uint64      `json:"stucksize"`
-	UID                 uint64      `json:"uid"`
+	Health              float64           `json:"health"`
+	LastHealthCheckTime time.Time         `json:"lasthealthchecktime"`
+	MaxHealthPercentage float64           `json:"maxhealthpercentage"`
+	MaxHealth           float64           `json:"maxhealth"`
+	MinRedundancy       float64           `json:"minredundancy"`
+	DirMode             os.FileMode       `json:"mode,siamismatch"` // Field is called DirMode for fuse compatibility
+	MostRecentModTime   time.Time         `json:"mostrecentmodtime"`
+	NumFiles            uint64            `json:"numfiles"`
+	NumStuckChunks      uint64            `json:"numstuckchunks"`
+	NumSubDirs          uint64            `json:"numsubdirs"`
+	RepairSize          uint64            `json:"repairsize"`
+	SiaPath             SiaPath           `json:"siapath"`
+	DirSize             uint64            `json:"size,siamismatch"` // Stays as 'size' in json for compatibility
+	StuckHealth         float64           `json:"stuckhealth"`
+	StuckSize           uint64            `json:"stucksize"`
+	Quota               uint64            `json:"quota"` // 0 if the directory has no quota
+	LockedUntil         time.Time         `json:"lockeduntil"`
+	Tags                map[string]string `json:"tags"`
+	UID                 uint64            `json:"uid"`
 }
 
 // Name implements os.FileInfo.
@@ -416,6 +499,103 @@ type DownloadInfo struct {
 	TotalDataTransferred uint64    `json:"totaldatatransferred"` // Total amount of data transferred, including negotiation, etc.
 }
 
+// URLUploadInfo provides information about an upload for which the renter
+// fetches the data from a URL.
+type URLUploadInfo struct {
+	MaxSize uint64  `json:"maxsize"` // The maximum size of the upload.
+	SiaPath SiaPath `json:"siapath"` // The siapath of the uploaded file.
+	Size    uint64  `json:"size"`    // The size reported by the server.
+	URL     string  `json:"url"`     // The URL the data is fetched from.
+
+	Completed bool      `json:"completed"` // Whether or not the upload has completed.
+	EndTime   time.Time `json:"endtime"`   // The time when the upload completed.
+	Error     string    `json:"error"`     // Will be the empty string unless there was an error.
+	Fetched   uint64    `json:"fetched"`   // Amount of data fetched from the URL.
+	StartTime time.Time `json:"starttime"` // The time when the upload was started.
+}
+
+// ReshardInfo provides information about a change of the erasure coding
+// parameters of a file.
+type ReshardInfo struct {
+	DataPieces   int     `json:"datapieces"`   // The new number of data pieces.
+	NumChunks    uint64  `json:"numchunks"`    // The number of chunks of the file with the new parameters.
+	ParityPieces int     `json:"paritypieces"` // The new number of parity pieces.
+	SiaPath      SiaPath `json:"siapath"`      // The siapath of the file.
+
+	ChunksReencoded uint64    `json:"chunksreencoded"` // The number of chunks which have been downloaded and re-encoded.
+	Completed       bool      `json:"completed"`       // Whether or not the reshard has completed.
+	EndTime         time.Time `json:"endtime"`         // The time when the reshard completed.
+	Error           string    `json:"error"`           // Will be the empty string unless there was an error.
+	StartTime       time.Time `json:"starttime"`       // The time when the reshard was started.
+}
+
+// PackedFileInfo provides information about a small file which is packed
+// into a pack together with other small files.
+type PackedFileInfo struct {
+	SiaPath  SiaPath `json:"siapath"`  // The siapath of the file.
+	Pack     SiaPath `json:"pack"`     // The siapath of the pack containing the file.
+	Offset   uint64  `json:"offset"`   // The offset of the file within the pack.
+	Size     uint64  `json:"size"`     // The size of the file.
+	Uploaded bool    `json:"uploaded"` // Whether or not the pack was uploaded.
+}
+
+// RenterCumulativeStats contains the cumulative statistics of the renter,
+// which are persisted across restarts. The stats cover the period between
+// Since and Time.
+type RenterCumulativeStats struct {
+	Since time.Time `json:"since"` // The start of the period covered by the stats.
+	Time  time.Time `json:"time"`  // The end of the period covered by the stats.
+
+	DownloadedBytes       uint64 `json:"downloadedbytes"`       // The number of bytes downloaded from hosts, excluding repairs.
+	RepairDownloadedBytes uint64 `json:"repairdownloadedbytes"` // The number of bytes downloaded from hosts by repairs.
+	RepairUploadedBytes   uint64 `json:"repairuploadedbytes"`   // The number of bytes uploaded to hosts by repairs.
+	UploadedBytes         uint64 `json:"uploadedbytes"`         // The number of bytes uploaded to hosts, excluding repairs.
+
+	DownloadJobs       uint64 `json:"downloadjobs"`       // The number of successful piece downloads.
+	FailedDownloadJobs uint64 `json:"faileddownloadjobs"` // The number of failed piece downloads.
+	FailedUploadJobs   uint64 `json:"faileduploadjobs"`   // The number of failed piece uploads.
+	UploadJobs         uint64 `json:"uploadjobs"`         // The number of successful piece uploads.
+
+	Spending RenterCumulativeSpending `json:"spending"` // The money spent by category.
+}
+
+// RenterCumulativeSpending is the breakdown of the money spent by the renter.
+// Uploads are accounted for with the price of a full sector for the remaining
+// duration of the contract.
+type RenterCumulativeSpending struct {
+	Downloads         types.Currency `json:"downloads"`
+	RegistryReads     types.Currency `json:"registryreads"`
+	RegistryWrites    types.Currency `json:"registrywrites"`
+	RepairDownloads   types.Currency `json:"repairdownloads"`
+	RepairUploads     types.Currency `json:"repairuploads"`
+	SnapshotDownloads types.Currency `json:"snapshotdownloads"`
+	SnapshotUploads   types.Currency `json:"snapshotuploads"`
+	Subscriptions     types.Currency `json:"subscriptions"`
+	Uploads           types.Currency `json:"uploads"`
+	Programs          types.Currency `json:"programs"`
+}
+
+// RepairBacklog describes the repair work of the renter and whether repairs are
+// paused. The sizes and the number of stuck chunks are aggregated from the
+// directory metadata, which is updated by the health loop. The estimated cost
+// is based on the prices of the hosts the renter has contracts with that are
+// good for upload.
+type RepairBacklog struct {
+	Paused       bool      `json:"paused"`       // Whether repairs are paused.
+	PauseStart   time.Time `json:"pausestart"`   // The time at which repairs were paused.
+	PauseEndTime time.Time `json:"pauseendtime"` // The time at which repairs resume. Zero if they are paused until resumed.
+
+	QueuedChunks    int    `json:"queuedchunks"`    // The number of chunks in the upload heap.
+	RepairingChunks int    `json:"repairingchunks"` // The number of chunks which are being repaired.
+	StuckChunks     uint64 `json:"stuckchunks"`     // The number of stuck chunks.
+	RepairBytes     uint64 `json:"repairbytes"`     // The number of bytes which need to be uploaded to repair unstuck chunks.
+	StuckBytes      uint64 `json:"stuckbytes"`      // The number of bytes which need to be uploaded to repair stuck chunks.
+
+	EstimatedCost types.Currency `json:"estimatedcost"` // The estimated cost of uploading the repair and stuck bytes.
+
+	LastHealthCheckTime time.Time `json:"lasthealthchecktime"` // The oldest health check the backlog is based on.
+}
+
 // FileUploadParams contains the information used by the Renter to upload a
 // file.
 type FileUploadParams struct {
@@ -426,6 +606,16 @@ type FileUploadParams struct {
 	DisablePartialChunk bool
 	Repair              bool
 
+	// Append was added later. If it is set, a streamed upload appends its data
+	// to the existing file at SiaPath. The size of the existing file must be a
+	// multiple of its chunk size.
+	Append bool
+
+	// Sparse was added later. If it is set, chunks which only contain zeros
+	// are recorded as holes in the file's metadata instead of being uploaded
+	// and are reconstructed as zeros on download.
+	Sparse bool
+
 	// CipherType was added later. If it is left blank, the renter will use the
 	// default encryption method (as of writing, Threefish)
 	CipherType crypto.CipherType
@@ -434,6 +624,12 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// MasterKeyID was added later. If it is set, CipherKey is an external key
+	// which is held by the user. The renter only persists the ID and a hash of
+	// the key. External keys are only supported by streamed uploads and the
+	// key is required for appending to, repairing and downloading the file.
+	MasterKeyID string
 }
 
 // FileInfo provides information about a file.
@@ -447,12 +643,15 @@ type FileInfo struct {
 	Filesize         uint64            `json:"filesize"`
 	Health           float64           `json:"health"`
 	LocalPath        string            `json:"localpath"`
+	LockedUntil      time.Time         `json:"lockeduntil"`
+	MasterKeyID      string            `json:"masterkeyid"`
 	MaxHealth        float64           `json:"maxhealth"`
 	MaxHealthPercent float64           `json:"maxhealthpercent"`
 	ModificationTime time.Time         `json:"modtime,siamismatch"` // Stays as 'modtime' in json for compatibility
 	FileMode         os.FileMode       `json:"mode,siamismatch"`    // Field is called FileMode for fuse compatibility
 	NumStuckChunks   uint64            `json:"numstuckchunks"`
 	OnDisk           bool              `json:"ondisk"`
+	ReadOnly         bool              `json:"readonly"`
 	Recoverable      bool              `json:"recoverable"`
 	Redundancy       float64           `json:"redundancy"`
 	Renewing         bool              `json:"renewing"`
@@ -462,6 +661,7 @@ type FileInfo struct {
 	Stuck            bool              `json:"stuck"`
 	StuckBytes       uint64            `json:"stuckbytes"`
 	StuckHealth      float64           `json:"stuckhealth"`
+	Tags             map[string]string `json:"tags"`
 	UID              uint64            `json:"uid"`
 	UploadedBytes    uint64            `json:"uploadedbytes"`
 	UploadProgress   float64           `json:"uploadprogress"`
@@ -519,6 +719,320 @@ type HostDBEntry struct {
 	// Filtered says whether or not a HostDBEntry is being filtered out of the
 	// filtered hosttree due to the filter mode of the hosttree
 	Filtered bool `json:"filtered"`
+
+	// AlternativeNetAddresses are the additional addresses from the host's
+	// most recent announcement. ReachableNetAddress is the address the host
+	// was reached at during the last successful scan. It is empty if the host
+	// hasn't been reached yet.
+	AlternativeNetAddresses []NetAddress `json:"alternativenetaddresses"`
+	ReachableNetAddress     NetAddress   `json:"reachablenetaddress"`
+
+	// Benchmark is the result of the most recent benchmark of the host. It
+	// is nil if the host was never benchmarked.
+	Benchmark *HostBenchmark `json:"benchmark,omitempty"`
+}
+
+// HostBenchmark is the result of actively benchmarking a host. The throughputs
+// are in bytes per second.
+type HostBenchmark struct {
+	Time                time.Time     `json:"time"`
+	ReadLatency         time.Duration `json:"readlatency"`
+	ReadThroughput      uint64        `json:"readthroughput"`
+	WriteThroughput     uint64        `json:"writethroughput"`
+	RegistryReadLatency time.Duration `json:"registryreadlatency"`
+
+	// Error is set if the benchmark failed.
+	Error string `json:"error,omitempty"`
+}
+
+// HostBenchmarkResult is the benchmark of a specific host.
+type HostBenchmarkResult struct {
+	PublicKey types.SiaPublicKey `json:"publickey"`
+	HostBenchmark
+}
+
+// ProgramInstructionType is the type of an instruction of an MDM program which
+// is executed on behalf of a third-party application.
+type ProgramInstructionType string
+
+const (
+	// ProgramInstructionHasSector checks whether the host stores a sector.
+	ProgramInstructionHasSector ProgramInstructionType = "hassector"
+
+	// ProgramInstructionReadSector reads a range of a sector.
+	ProgramInstructionReadSector ProgramInstructionType = "readsector"
+
+	// ProgramInstructionReadRegistry reads a registry entry.
+	ProgramInstructionReadRegistry ProgramInstructionType = "readregistry"
+
+	// ProgramInstructionReadOffset reads a range of the data stored under the
+	// renter's contract with the host.
+	ProgramInstructionReadOffset ProgramInstructionType = "readoffset"
+
+	// ProgramInstructionRevision returns the latest revision of the renter's
+	// contract with the host.
+	ProgramInstructionRevision ProgramInstructionType = "revision"
+)
+
+type (
+	// PaymentBudget limits how much a third-party application may spend from
+	// the renter's ephemeral accounts to pay hosts for MDM programs.
+	PaymentBudget struct {
+		ID       string         `json:"id"`
+		Name     string         `json:"name"`
+		Limit    types.Currency `json:"limit"`
+		Spent    types.Currency `json:"spent"`
+		Created  time.Time      `json:"created"`
+		Expiry   time.Time      `json:"expiry"`   // Zero if the budget doesn't expire.
+		Programs uint64         `json:"programs"` // The number of programs paid from the budget.
+	}
+
+	// PaymentReceipt records the payment for an MDM program which was paid
+	// from a payment budget. The payment is made upfront and the host refunds
+	// the cost of the instructions which weren't executed.
+	PaymentReceipt struct {
+		ID            string             `json:"id"`
+		BudgetID      string             `json:"budgetid"`
+		Time          time.Time          `json:"time"`
+		HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+		Instructions  int                `json:"instructions"`
+		Payment       types.Currency     `json:"payment"`
+		Refund        types.Currency     `json:"refund"`
+		Cost          types.Currency     `json:"cost"`
+
+		// Error is set if the program couldn't be executed. The payment is
+		// refunded in that case.
+		Error string `json:"error,omitempty"`
+	}
+
+	// ProgramInstruction is an instruction of an MDM program which a
+	// third-party application executes on a host. Which fields are used
+	// depends on the type of the instruction.
+	ProgramInstruction struct {
+		Type ProgramInstructionType `json:"type"`
+
+		// MerkleRoot is the sector of hassector and readsector instructions.
+		MerkleRoot crypto.Hash `json:"merkleroot"`
+
+		// Offset, Length and MerkleProof describe the range which is read
+		// by readsector and readoffset instructions.
+		Offset      uint64 `json:"offset"`
+		Length      uint64 `json:"length"`
+		MerkleProof bool   `json:"merkleproof"`
+
+		// PublicKey and Tweak identify the entry which is read by
+		// readregistry instructions.
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Tweak     crypto.Hash        `json:"tweak"`
+	}
+
+	// ProgramOutput is the output of a single instruction of an MDM program.
+	// Besides the raw output, the output is decoded according to the type of
+	// the instruction. Proofs and signatures are verified by the renter.
+	ProgramOutput struct {
+		Output []byte        `json:"output"`
+		Proof  []crypto.Hash `json:"proof,omitempty"`
+		Error  string        `json:"error,omitempty"`
+
+		HasSector     *bool                       `json:"hassector,omitempty"`
+		RegistryValue *ProgramRegistryValue       `json:"registryvalue,omitempty"`
+		Revision      *types.FileContractRevision `json:"revision,omitempty"`
+	}
+
+	// ProgramRegistryValue is a registry entry which was read by a
+	// readregistry instruction.
+	ProgramRegistryValue struct {
+		Data      []byte            `json:"data"`
+		Revision  uint64            `json:"revision"`
+		Type      RegistryEntryType `json:"type"`
+		Signature crypto.Signature  `json:"signature"`
+	}
+
+	// ProgramResult is the result of executing an MDM program which was paid
+	// from a payment budget.
+	ProgramResult struct {
+		Receipt PaymentReceipt  `json:"receipt"`
+		Outputs []ProgramOutput `json:"outputs"`
+	}
+)
+
+type (
+	// FileChecksums contains the merkle roots of the pieces of a file, so that
+	// a downloader can verify pieces without trusting the node which serves
+	// them. The root is computed over the piece roots of all chunks in order,
+	// like the merkle root of a contract is computed over its sector roots.
+	// Missing pieces have an empty root.
+	FileChecksums struct {
+		SiaPath      SiaPath          `json:"siapath"`
+		FileSize     uint64           `json:"filesize"`
+		ChunkSize    uint64           `json:"chunksize"`
+		PieceSize    uint64           `json:"piecesize"`
+		DataPieces   int              `json:"datapieces"`
+		ParityPieces int              `json:"paritypieces"`
+		NumChunks    uint64           `json:"numchunks"`
+		Root         crypto.Hash      `json:"root"`
+		Chunks       []ChunkChecksums `json:"chunks"`
+	}
+
+	// ChunkChecksums contains the merkle roots of the pieces of a chunk and a
+	// proof that they are part of the file's root.
+	ChunkChecksums struct {
+		Index  uint64           `json:"index"`
+		Pieces []PieceChecksums `json:"pieces"`
+		Proof  []crypto.Hash    `json:"proof"`
+	}
+
+	// PieceChecksums contains the merkle root of a piece and the hosts which
+	// store it.
+	PieceChecksums struct {
+		MerkleRoot crypto.Hash          `json:"merkleroot"`
+		Hosts      []types.SiaPublicKey `json:"hosts"`
+	}
+)
+
+// VerifyChunk verifies that the piece roots of a chunk are part of the file's
+// root.
+func (fc FileChecksums) VerifyChunk(chunk ChunkChecksums) bool {
+	numPieces := fc.DataPieces + fc.ParityPieces
+	if len(chunk.Pieces) != numPieces {
+		return false
+	}
+	roots := make([]crypto.Hash, 0, numPieces)
+	for _, piece := range chunk.Pieces {
+		roots = append(roots, piece.MerkleRoot)
+	}
+	start := int(chunk.Index) * numPieces
+	return crypto.VerifySectorRangeProof(roots, chunk.Proof, start, start+numPieces, fc.Root)
+}
+
+// AuditReportSpecifier is the specifier used when signing audit reports.
+var AuditReportSpecifier = types.NewSpecifier("AuditReport")
+
+type (
+	// AuditSettings control the periodic audits of the renter's files. A zero
+	// interval disables periodic audits.
+	AuditSettings struct {
+		Interval time.Duration `json:"interval"`
+		Samples  uint64        `json:"samples"`
+	}
+
+	// AuditReport is the result of an audit of the renter's files. Every
+	// sample is a random chunk of a random file. For every piece of the chunk
+	// a random segment is downloaded from every host which stores the piece
+	// and the segment's proof is verified against the piece's merkle root. The
+	// report is signed by a key derived from the renter's seed.
+	AuditReport struct {
+		ID        string             `json:"id"`
+		Start     time.Time          `json:"start"`
+		End       time.Time          `json:"end"`
+		Samples   uint64             `json:"samples"`
+		Files     []FileAuditResult  `json:"files"`
+		Hosts     []HostAuditResult  `json:"hosts"`
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		Signature crypto.Signature   `json:"signature"`
+	}
+
+	// FileAuditResult is the result of an audit for a single file. A chunk is
+	// retrievable if enough of its pieces passed the audit to recover it.
+	FileAuditResult struct {
+		SiaPath             SiaPath  `json:"siapath"`
+		ChunksSampled       uint64   `json:"chunkssampled"`
+		ChunksRetrievable   uint64   `json:"chunksretrievable"`
+		PiecesSampled       uint64   `json:"piecessampled"`
+		PiecesRetrievable   uint64   `json:"piecesretrievable"`
+		UnretrievableChunks []uint64 `json:"unretrievablechunks"`
+	}
+
+	// HostAuditResult is the result of an audit for a single host.
+	HostAuditResult struct {
+		HostKey   types.SiaPublicKey `json:"hostkey"`
+		Sampled   uint64             `json:"sampled"`
+		Passed    uint64             `json:"passed"`
+		Failed    uint64             `json:"failed"`
+		LastError string             `json:"lasterror"`
+	}
+)
+
+// SigHash returns the hash of an audit report which is signed by the renter.
+func (ar AuditReport) SigHash() crypto.Hash {
+	return crypto.HashAll(AuditReportSpecifier, ar.ID, ar.Start.Unix(), ar.End.Unix(), ar.Samples, ar.Files, ar.Hosts, ar.PublicKey)
+}
+
+// VerifySignature verifies the renter's signature of an audit report.
+func (ar AuditReport) VerifySignature() error {
+	if ar.PublicKey.Algorithm != types.SignatureEd25519 || len(ar.PublicKey.Key) != crypto.PublicKeySize {
+		return errors.New("invalid public key")
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], ar.PublicKey.Key)
+	return crypto.VerifyHash(ar.SigHash(), pk, ar.Signature)
+}
+
+// HostDBScanSettings control how the hostdb scans hosts. A zero value selects
+// the default behavior of the hostdb.
+type HostDBScanSettings struct {
+	// ScanInterval is the time between two scans of the hosts. By default
+	// the hostdb waits a random amount of time between scans.
+	ScanInterval time.Duration `json:"scaninterval"`
+
+	// ScanThreads is the maximum number of hosts which are scanned in
+	// parallel.
+	ScanThreads int `json:"scanthreads"`
+
+	// MaxScanBandwidth is the maximum bandwidth in bytes per second which is
+	// used by scans in each direction. 0 means unlimited.
+	MaxScanBandwidth int64 `json:"maxscanbandwidth"`
+}
+
+// HostDBSnapshotVersion is the version of the hostdb snapshot format.
+const HostDBSnapshotVersion = "1.0"
+
+// HostDBSnapshot is an export of the hostdb. It can be imported by a fresh node
+// to select hosts before it has scanned them itself.
+type HostDBSnapshot struct {
+	Version     string               `json:"version"`
+	Time        time.Time            `json:"time"`
+	BlockHeight types.BlockHeight    `json:"blockheight"`
+	Hosts       []HostDBSnapshotHost `json:"hosts"`
+}
+
+// HostDBSnapshotHost is a host of a hostdb snapshot. The score is the score of
+// the host at the time of the export. It is informational since the importing
+// node scores hosts using its own allowance.
+type HostDBSnapshotHost struct {
+	HostDBEntry
+	Score types.Currency `json:"score"`
+}
+
+// HostDBImportResult reports the outcome of importing a hostdb snapshot.
+// Hosts are added if they are unknown and updated if they haven't been scanned
+// by the node yet. All other hosts are skipped.
+type HostDBImportResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// DialAddress returns the address that should be used to dial the host. This
+// is the address the host was last reachable at, falling back to its primary
+// net address.
+func (entry HostDBEntry) DialAddress() NetAddress {
+	if entry.ReachableNetAddress != "" {
+		return entry.ReachableNetAddress
+	}
+	return entry.NetAddress
+}
+
+// NetAddresses returns all the addresses of the host in the order in which
+// they should be tried, starting with the primary net address.
+func (entry HostDBEntry) NetAddresses() []NetAddress {
+	return append([]NetAddress{entry.NetAddress}, entry.AlternativeNetAddresses...)
+}
+
+// SiaMuxAddress returns the address of the host's siamux on the host's dial
+// address.
+func (entry HostDBEntry) SiaMuxAddress() string {
+	return net.JoinHostPort(entry.DialAddress().Host(), entry.SiaMuxPort)
 }
 
 // HostDBScan represents a single scan event.
@@ -545,6 +1059,7 @@ type HostScoreBreakdown struct {
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	DurationAdjustment         float64 `json:"durationadjustment"`
 	InteractionAdjustment      float64 `json:"interactionadjustment"`
+	PerformanceAdjustment      float64 `json:"performanceadjustment"`
 	PriceAdjustment            float64 `json:"pricesmultiplier,siamismatch"`
 	StorageRemainingAdjustment float64 `json:"storageremainingadjustment"`
 	UptimeAdjustment           float64 `json:"uptimeadjustment"`
@@ -566,10 +1081,13 @@ type MemoryStatus struct {
 type MemoryManagerStatus struct {
 	Available uint64 `json:"available"`
 	Base      uint64 `json:"base"`
+	Granted   uint64 `json:"granted"`
+	Queued    uint64 `json:"queued"`
 	Requested uint64 `json:"requested"`
 
 	PriorityAvailable uint64 `json:"priorityavailable"`
 	PriorityBase      uint64 `json:"prioritybase"`
+	PriorityQueued    uint64 `json:"priorityqueued"`
 	PriorityRequested uint64 `json:"priorityrequested"`
 	PriorityReserve   uint64 `json:"priorityreserve"`
 }
@@ -579,9 +1097,12 @@ func (ms MemoryManagerStatus) Add(ms2 MemoryManagerStatus) MemoryManagerStatus {
 	return MemoryManagerStatus{
 		Available:         ms.Available + ms2.Available,
 		Base:              ms.Base + ms2.Base,
+		Granted:           ms.Granted + ms2.Granted,
+		Queued:            ms.Queued + ms2.Queued,
 		Requested:         ms.Requested + ms2.Requested,
 		PriorityAvailable: ms.PriorityAvailable + ms2.PriorityAvailable,
 		PriorityBase:      ms.PriorityBase + ms2.PriorityBase,
+		PriorityQueued:    ms.PriorityQueued + ms2.PriorityQueued,
 		PriorityRequested: ms.PriorityRequested + ms2.PriorityRequested,
 		PriorityReserve:   ms.PriorityReserve + ms2.PriorityReserve,
 	}
@@ -619,6 +1140,132 @@ type RenterSettings struct {
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+
+	// AccountMaxBalanceAtRisk is the maximum balance the renter keeps in its
+	// ephemeral account with a single host. Accounts are refilled up to this
+	// balance. AccountRefillThreshold is the available balance below which an
+	// account is refilled. A zero value means the default is used.
+	AccountMaxBalanceAtRisk types.Currency `json:"accountmaxbalanceatrisk"`
+	AccountRefillThreshold  types.Currency `json:"accountrefillthreshold"`
+
+	// DownloadOverdrive and StreamOverdrive are the number of extra pieces
+	// that are fetched in parallel for downloads and streams to prevent slow
+	// hosts from being a bottleneck. A zero value means the default is used.
+	// If AdaptiveOverdrive is set, the overdrive is increased when the
+	// latencies of the hosts vary a lot.
+	DownloadOverdrive uint64 `json:"downloadoverdrive"`
+	StreamOverdrive   uint64 `json:"streamoverdrive"`
+	AdaptiveOverdrive bool   `json:"adaptiveoverdrive"`
+
+	// LowLatencyFraction is the fraction of each chunk's pieces which are
+	// preferably uploaded to hosts with a low measured latency. If
+	// LowLatencyBudget is set, hosts with a latency within the budget count as
+	// low latency hosts. Otherwise the hosts with the lowest latency are used.
+	// A zero fraction disables the preference.
+	LowLatencyFraction float64       `json:"lowlatencyfraction"`
+	LowLatencyBudget   time.Duration `json:"lowlatencybudget"`
+
+	// DownloadStallTimeout is the time after which a piece download is
+	// considered stalled. Stalled pieces are fetched from a different host
+	// instead. A zero value means the default is used.
+	DownloadStallTimeout time.Duration `json:"downloadstalltimeout"`
+
+	// UploadMemory, DownloadMemory and RepairMemory are the memory budgets in
+	// bytes of user uploads, user downloads and repairs. Every budget is
+	// enforced by its own memory manager, so repairs can't use up the memory
+	// of user downloads. A zero value means the default is used.
+	UploadMemory   uint64 `json:"uploadmemory"`
+	DownloadMemory uint64 `json:"downloadmemory"`
+	RepairMemory   uint64 `json:"repairmemory"`
+
+	// SpillDir and SpillCap configure the disk spill of upload and repair
+	// chunks. If the memory for a chunk is not available, the chunk's pieces
+	// are written to a file in SpillDir instead of waiting for memory, as long
+	// as the spill files stay below SpillCap bytes. An empty SpillDir means
+	// the spill directory within the renter's directory is used. A zero
+	// SpillCap disables the spill.
+	SpillDir string `json:"spilldir"`
+	SpillCap uint64 `json:"spillcap"`
+
+	// PackSize is the size of the packs which small files are packed into. A
+	// pack is uploaded once it is full. A zero value means the default is
+	// used, which is the size of a chunk with the default erasure coding
+	// parameters.
+	PackSize uint64 `json:"packsize"`
+
+	// MaxDownloadCost, MaxRegistryReadCost, MaxRegistryWriteCost and
+	// MaxUploadCost are the maximum amounts the renter is willing to pay a
+	// single host for downloading a sector, reading or updating a registry
+	// entry and uploading a sector. Workers abort operations exceeding these
+	// ceilings with ErrCostCeilingExceeded. A zero value means there is no
+	// ceiling.
+	MaxDownloadCost      types.Currency `json:"maxdownloadcost"`
+	MaxRegistryReadCost  types.Currency `json:"maxregistryreadcost"`
+	MaxRegistryWriteCost types.Currency `json:"maxregistrywritecost"`
+	MaxUploadCost        types.Currency `json:"maxuploadcost"`
+
+	// Gouging contains the thresholds of the renter's price gouging checks.
+	Gouging GougingSettings `json:"gouging"`
+}
+
+// GougingSettings contains the thresholds of the renter's price gouging
+// checks. Every threshold is a fraction between 0 and 1. A nil threshold means
+// the default is used and a threshold of 0 disables the rule. The absolute
+// price limits of the allowance apply regardless of the thresholds.
+type GougingSettings struct {
+	// DownloadUsageFraction, RegistryUsageFraction and UploadUsageFraction
+	// are the fractions of the allowance's expected download and storage
+	// which the allowance funds have to be able to pay for at a host's prices.
+	// A higher fraction is stricter. RegistryUsageFraction applies to the
+	// lookups and updates of registry entries.
+	DownloadUsageFraction *float64 `json:"downloadusagefraction,omitempty"`
+	RegistryUsageFraction *float64 `json:"registryusagefraction,omitempty"`
+	UploadUsageFraction   *float64 `json:"uploadusagefraction,omitempty"`
+
+	// BaseRPCMaxFraction, FundAccountMaxFraction and PriceTableMaxFraction
+	// are the maximum fractions of the allowance funds which may be spent
+	// during a period on the base cost of the RPCs with a host, on funding
+	// ephemeral accounts and on updating price tables. A lower fraction is
+	// stricter.
+	BaseRPCMaxFraction     *float64 `json:"baserpcmaxfraction,omitempty"`
+	FundAccountMaxFraction *float64 `json:"fundaccountmaxfraction,omitempty"`
+	PriceTableMaxFraction  *float64 `json:"pricetablemaxfraction,omitempty"`
+}
+
+// Gouging rules reported by a GougingReport.
+const (
+	GougingRuleBaseRPC     = "baserpc"
+	GougingRuleDownload    = "download"
+	GougingRuleFundAccount = "fundaccount"
+	GougingRulePriceTable  = "pricetable"
+	GougingRuleRegistry    = "registry"
+	GougingRuleUpload      = "upload"
+)
+
+// GougingReport reports which of the renter's hosts are currently filtered by
+// the price gouging checks.
+type GougingReport struct {
+	// Settings are the thresholds the hosts were checked against with the
+	// defaults applied.
+	Settings GougingSettings `json:"settings"`
+
+	// NumHosts is the number of checked hosts. Hosts contains the ones that
+	// are filtered by at least one rule.
+	NumHosts int           `json:"numhosts"`
+	Hosts    []GougingHost `json:"hosts"`
+}
+
+// GougingHost is a host which is filtered by at least one gouging rule.
+type GougingHost struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	Violations    []GougingViolation `json:"violations"`
+}
+
+// GougingViolation is a gouging rule a host is filtered by together with the
+// reason.
+type GougingViolation struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -672,6 +1319,13 @@ func (mrs *MerkleRootSet) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// CustodyTransfer contains the number of files and contracts which were
+// handed over from one renter to another.
+type CustodyTransfer struct {
+	Files     int `json:"files"`
+	Contracts int `json:"contracts"`
+}
+
 // MountOptions specify various settings of a FUSE filesystem mount.
 type MountOptions struct {
 	AllowOther bool `json:"allowother"`
@@ -697,6 +1351,52 @@ type RecoverableContract struct {
 	TxnFee types.Currency `json:"txnfee"`
 }
 
+// ContractRecoveryStatus contains information about the progress of the most
+// recent scan for recoverable contracts.
+type ContractRecoveryStatus struct {
+	// ScanInProgress indicates if a scan is currently in progress.
+	ScanInProgress bool `json:"scaninprogress"`
+	// ScannedHeight is the height up to which the blockchain has been scanned
+	// by an ongoing scan.
+	ScannedHeight types.BlockHeight `json:"scannedheight"`
+	// TargetHeight is the height of the blockchain at the time the most
+	// recent scan was started.
+	TargetHeight types.BlockHeight `json:"targetheight"`
+	// ContractsFound is the number of recoverable contracts found by the most
+	// recent scan.
+	ContractsFound uint64 `json:"contractsfound"`
+	// ContractsRecovered is the number of contracts that were recovered since
+	// the most recent scan was started.
+	ContractsRecovered uint64 `json:"contractsrecovered"`
+	// PendingContracts is the number of recoverable contracts that haven't
+	// been recovered yet.
+	PendingContracts uint64 `json:"pendingcontracts"`
+}
+
+// UnrecoverableContract is a recoverable contract that the contractor failed
+// to recover.
+type UnrecoverableContract struct {
+	// ID is the FileContract's ID.
+	ID types.FileContractID `json:"id"`
+	// HostPublicKey is the public key of the host we formed this contract
+	// with.
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	// WindowEnd is the end of the contract's proof window. After that the
+	// contract can't be recovered anymore.
+	WindowEnd types.BlockHeight `json:"windowend"`
+	// Reason is the reason why the most recent attempt to recover the contract
+	// failed.
+	Reason string `json:"reason"`
+	// FailedAttempts is the number of failed attempts to recover the contract.
+	FailedAttempts uint64 `json:"failedattempts"`
+	// LastAttemptHeight is the block height of the most recent attempt to
+	// recover the contract.
+	LastAttemptHeight types.BlockHeight `json:"lastattemptheight"`
+	// Expired indicates that the contract expired before it could be
+	// recovered. Expired contracts are not retried.
+	Expired bool `json:"expired"`
+}
+
 // A RenterContract contains metadata about a file contract. It is read-only;
 // modifying a RenterContract does not modify the actual file contract.
 type RenterContract struct {
@@ -790,6 +1490,73 @@ func (rc *RenterContract) Size() uint64 {
 	return size
 }
 
+// ContractFormationPlan is the plan the contractor would execute to form the
+// contracts required by an allowance. It is created without forming any
+// contracts.
+type ContractFormationPlan struct {
+	// Allowance is the allowance the plan was created for.
+	Allowance Allowance `json:"allowance"`
+
+	// ExistingContracts is the number of existing contracts that are good for
+	// upload and count towards the hosts of the allowance. NeededContracts is
+	// the number of new contracts that are required on top of them.
+	ExistingContracts uint64 `json:"existingcontracts"`
+	NeededContracts   uint64 `json:"neededcontracts"`
+
+	// Contracts are the contracts that would be formed and SkippedHosts are the
+	// hosts that were considered but wouldn't be used.
+	Contracts    []PlannedContract `json:"contracts"`
+	SkippedHosts []PlanSkippedHost `json:"skippedhosts"`
+
+	// TotalFunding is the amount of money that would be spent on forming the
+	// planned contracts. It is the sum of the fees and the funds locked in the
+	// contracts.
+	TotalFunding        types.Currency `json:"totalfunding"`
+	TotalFees           types.Currency `json:"totalfees"`
+	TotalLockedFunds    types.Currency `json:"totallockedfunds"`
+	TotalHostCollateral types.Currency `json:"totalhostcollateral"`
+
+	// FundsRemaining is the amount of money of the allowance that would remain
+	// for the current period after forming the planned contracts.
+	FundsRemaining types.Currency `json:"fundsremaining"`
+
+	// Warnings contains problems that prevent the plan from forming all the
+	// needed contracts.
+	Warnings []string `json:"warnings"`
+}
+
+// PlannedContract is a contract that the contractor would form as part of a
+// ContractFormationPlan.
+type PlannedContract struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	NetAddress    NetAddress         `json:"netaddress"`
+	Score         types.Currency     `json:"score"`
+
+	// The prices of the host.
+	ContractPrice          types.Currency `json:"contractprice"`
+	StoragePrice           types.Currency `json:"storageprice"`
+	UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+	Collateral             types.Currency `json:"collateral"`
+
+	// Funding is the money spent on forming the contract. Fees is the part of
+	// it that is paid for the contract price, the transaction fee and the
+	// siafund fee. LockedFunds is the part of it that can be spent on storage
+	// and bandwidth.
+	Funding        types.Currency `json:"funding"`
+	Fees           types.Currency `json:"fees"`
+	LockedFunds    types.Currency `json:"lockedfunds"`
+	HostCollateral types.Currency `json:"hostcollateral"`
+}
+
+// PlanSkippedHost is a host that was considered for a ContractFormationPlan
+// but wouldn't be used to form a contract.
+type PlanSkippedHost struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	NetAddress    NetAddress         `json:"netaddress"`
+	Reason        string             `json:"reason"`
+}
+
 // ContractorSpending contains the metrics about how much the Contractor has
 // spent during the current billing period.
 type ContractorSpending struct {
@@ -846,6 +1613,55 @@ func (cs ContractorSpending) SpendingBreakdown() (totalSpent, unspentAllocated,
 	return totalSpent, unspentAllocated, unspentUnallocated
 }
 
+// SpendingForecast projects the spending of the renter's allowance to the end
+// of the current period based on the spending rate during the period so far.
+type SpendingForecast struct {
+	// BlockHeight is the height the forecast was created at. The current
+	// period starts at PeriodStart and ends at PeriodEnd, when the contracts
+	// are renewed.
+	BlockHeight types.BlockHeight `json:"blockheight"`
+	PeriodStart types.BlockHeight `json:"periodstart"`
+	PeriodEnd   types.BlockHeight `json:"periodend"`
+
+	// Funds are the funds of the allowance for the current period.
+	Funds types.Currency `json:"funds"`
+
+	// Spent is the money spent during the current period so far and
+	// Projected is the money projected to be spent by the end of the period.
+	Spent     ForecastSpending `json:"spent"`
+	Projected ForecastSpending `json:"projected"`
+
+	// ProjectedRemaining is the money of the allowance projected to remain at
+	// the end of the period. DepletionHeight is the height at which the
+	// allowance is projected to be depleted. It is 0 if the allowance lasts
+	// until the end of the period.
+	ProjectedRemaining types.Currency    `json:"projectedremaining"`
+	DepletionHeight    types.BlockHeight `json:"depletionheight"`
+
+	// EstimatedRenewCost is the estimated cost of renewing the contracts at
+	// the end of the period at the current prices of their hosts.
+	EstimatedRenewCost types.Currency `json:"estimatedrenewcost"`
+
+	// WalletFunds are the confirmed siacoins of the wallet which fund the
+	// renewals.
+	WalletFunds types.Currency `json:"walletfunds"`
+
+	// Warnings contains the reasons why the renter is projected to run out of
+	// money before the contracts are renewed.
+	Warnings []string `json:"warnings"`
+}
+
+// ForecastSpending is a breakdown of the spending of a SpendingForecast.
+type ForecastSpending struct {
+	ContractFees        types.Currency `json:"contractfees"`
+	DownloadSpending    types.Currency `json:"downloadspending"`
+	FundAccountSpending types.Currency `json:"fundaccountspending"`
+	MaintenanceSpending types.Currency `json:"maintenancespending"`
+	StorageSpending     types.Currency `json:"storagespending"`
+	UploadSpending      types.Currency `json:"uploadspending"`
+	Total               types.Currency `json:"total"`
+}
+
 // ContractorChurnStatus contains the current churn budgets for the Contractor's
 // churnLimiter and the aggregate churn for the current period.
 type ContractorChurnStatus struct {
@@ -854,6 +1670,29 @@ type ContractorChurnStatus struct {
 	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
 	// MaxPeriodChurn is the (adjustable) maximum churn allowed per period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
+	// CurrentPeriodContractChurn is the number of contracts churned in this
+	// period.
+	CurrentPeriodContractChurn uint64 `json:"currentperiodcontractchurn"`
+	// MaxPeriodContractChurn is the maximum number of contracts which may be
+	// churned for a poor host score per period. 0 means there is no limit.
+	MaxPeriodContractChurn uint64 `json:"maxperiodcontractchurn"`
+	// RecentEvents are the most recent churn events, oldest first.
+	RecentEvents []ContractorChurnEvent `json:"recentevents"`
+}
+
+// ContractorChurnEvent describes a contract which lost its GoodForRenew
+// utility, or which kept it because the churn limit was reached.
+type ContractorChurnEvent struct {
+	Time          time.Time            `json:"time"`
+	BlockHeight   types.BlockHeight    `json:"blockheight"`
+	ContractID    types.FileContractID `json:"contractid"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	Size          uint64               `json:"size"`
+	Reason        string               `json:"reason"`
+
+	// Prevented is true if the contract wasn't churned because the churn
+	// limit was reached.
+	Prevented bool `json:"prevented"`
 }
 
 // UploadedBackup contains metadata about an uploaded backup.
@@ -888,6 +1727,7 @@ type (
 		DownloadCoolDownTime  time.Duration `json:"downloadcooldowntime"`
 		DownloadOnCoolDown    bool          `json:"downloadoncooldown"`
 		DownloadQueueSize     int           `json:"downloadqueuesize"`
+		DownloadStalls        uint64        `json:"downloadstalls"`
 		DownloadTerminated    bool          `json:"downloadterminated"`
 
 		// Upload status information
@@ -903,8 +1743,9 @@ type (
 		MaintenanceCoolDownTime  time.Duration `json:"maintenancecooldowntime"`
 
 		// Ephemeral Account information
-		AccountBalanceTarget types.Currency      `json:"accountbalancetarget"`
-		AccountStatus        WorkerAccountStatus `json:"accountstatus"`
+		AccountBalanceTarget   types.Currency      `json:"accountbalancetarget"`
+		AccountRefillThreshold types.Currency      `json:"accountrefillthreshold"`
+		AccountStatus          WorkerAccountStatus `json:"accountstatus"`
 
 		// PriceTable information
 		PriceTableStatus WorkerPriceTableStatus `json:"pricetablestatus"`
@@ -930,28 +1771,53 @@ type (
 	WorkerGenericJobsStatus struct {
 		ConsecutiveFailures uint64    `json:"consecutivefailures"`
 		JobQueueSize        uint64    `json:"jobqueuesize"`
+		JobTimeout          uint64    `json:"jobtimeout"` // in ms
 		OnCooldown          bool      `json:"oncooldown"`
 		OnCooldownUntil     time.Time `json:"oncooldownuntil"`
 		RecentErr           string    `json:"recenterr"`
 		RecentErrTime       time.Time `json:"recenterrtime"`
+		RecentJobTimes      []uint64  `json:"recentjobtimes"` // in ms
 	}
 
 	// WorkerAccountStatus contains detailed information about the account
 	WorkerAccountStatus struct {
-		AvailableBalance types.Currency `json:"availablebalance"`
-		NegativeBalance  types.Currency `json:"negativebalance"`
+		AvailableBalance   types.Currency `json:"availablebalance"`
+		NegativeBalance    types.Currency `json:"negativebalance"`
+		PendingDeposits    types.Currency `json:"pendingdeposits"`
+		PendingWithdrawals types.Currency `json:"pendingwithdrawals"`
+
+		// The drift fields contain the accumulated difference between the
+		// renter's and the host's version of the balance.
+		BalanceDriftPositive types.Currency `json:"balancedriftpositive"`
+		BalanceDriftNegative types.Currency `json:"balancedriftnegative"`
+
+		NumRefills    uint64                `json:"numrefills"`
+		RecentRefills []WorkerAccountRefill `json:"recentrefills"`
 
 		RecentErr         string    `json:"recenterr"`
 		RecentErrTime     time.Time `json:"recenterrtime"`
 		RecentSuccessTime time.Time `json:"recentsuccesstime"`
 	}
 
+	// WorkerAccountRefill contains information about a single attempt to
+	// refill an ephemeral account.
+	WorkerAccountRefill struct {
+		Amount types.Currency `json:"amount"`
+		Error  string         `json:"error"`
+		Time   time.Time      `json:"time"`
+	}
+
 	// WorkerPriceTableStatus contains detailed information about the price
 	// table
 	WorkerPriceTableStatus struct {
 		ExpiryTime time.Time `json:"expirytime"`
 		UpdateTime time.Time `json:"updatetime"`
 
+		// FetchTime is the time at which the price table was fetched from the
+		// host and Age is the time that passed since.
+		FetchTime time.Time     `json:"fetchtime"`
+		Age       time.Duration `json:"age"`
+
 		Active bool `json:"active"`
 
 		RecentErr     string    `json:"recenterr"`
@@ -968,8 +1834,17 @@ type (
 
 		JobQueueSize uint64 `json:"jobqueuesize"`
 
+		JobTimeout64k uint64 `json:"jobtimeout64k"` // in ms
+		JobTimeout1m  uint64 `json:"jobtimeout1m"`  // in ms
+		JobTimeout4m  uint64 `json:"jobtimeout4m"`  // in ms
+
+		OnCooldown      bool      `json:"oncooldown"`
+		OnCooldownUntil time.Time `json:"oncooldownuntil"`
+
 		RecentErr     string    `json:"recenterr"`
 		RecentErrTime time.Time `json:"recenterrtime"`
+
+		RecentJobTimes []uint64 `json:"recentjobtimes"` // in ms
 	}
 
 	// WorkerHasSectorJobsStatus contains detailed information about the has
@@ -981,8 +1856,15 @@ type (
 
 		JobQueueSize uint64 `json:"jobqueuesize"`
 
+		JobTimeout uint64 `json:"jobtimeout"` // in ms
+
+		OnCooldown      bool      `json:"oncooldown"`
+		OnCooldownUntil time.Time `json:"oncooldownuntil"`
+
 		RecentErr     string    `json:"recenterr"`
 		RecentErrTime time.Time `json:"recenterrtime"`
+
+		RecentJobTimes []uint64 `json:"recentjobtimes"` // in ms
 	}
 
 	// WorkerReadRegistryJobStatus contains detailed information about the read
@@ -1027,6 +1909,49 @@ type Renter interface {
 	// nil, the backup will be encrypted using the provided secret.
 	CreateBackup(dst string, secret []byte) error
 
+	// ExportContracts exports the renter's contracts, including their secret
+	// keys and merkle roots, to dst. If a secret is not nil, the export will be
+	// encrypted using the provided secret.
+	ExportContracts(dst string, secret []byte) error
+
+	// ImportContracts imports the contracts of an export created by
+	// ExportContracts and returns the number of imported contracts. Expired
+	// contracts and contracts with hosts the renter already has a contract
+	// with are skipped.
+	ImportContracts(src string, secret []byte) (int, error)
+
+	// ShareKey returns the public key other renters use to create shares for
+	// the renter.
+	ShareKey() (crypto.X25519PublicKey, error)
+
+	// ExportShare writes the siafiles at siaPaths to a share bundle at dst
+	// which only the recipient can import. A zero expiry means that the share
+	// doesn't expire.
+	ExportShare(siaPaths []SiaPath, dst string, recipient crypto.X25519PublicKey, expiry time.Time) error
+
+	// ImportShare imports the siafiles of a share bundle into the directory
+	// at siaPath and returns the number of files within the share.
+	ImportShare(src string, siaPath SiaPath) (int, error)
+
+	// TransferCustody hands the files within the directory at siaPath and
+	// all contracts over to the renter with the recipient's share key by
+	// writing a custody bundle to dst. The renter can't use the transferred
+	// contracts afterwards. The transfer stays unconfirmed until it is
+	// confirmed or cancelled.
+	TransferCustody(siaPath SiaPath, dst string, recipient crypto.X25519PublicKey) (CustodyTransfer, error)
+
+	// ConfirmCustodyTransfer archives the contracts and deletes the files of
+	// the unconfirmed custody transfer.
+	ConfirmCustodyTransfer() (CustodyTransfer, error)
+
+	// CancelCustodyTransfer restores the contracts of the unconfirmed
+	// custody transfer.
+	CancelCustodyTransfer() (CustodyTransfer, error)
+
+	// ImportCustody imports the files and contracts of a custody bundle. The
+	// files are imported into the directory at siaPath.
+	ImportCustody(src string, siaPath SiaPath) (CustodyTransfer, error)
+
 	// LoadBackup loads the siafiles of a previously created backup into the
 	// renter. If the backup is encrypted, secret will be used to decrypt it.
 	// Otherwise the argument is ignored.
@@ -1046,6 +1971,19 @@ type Renter interface {
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
+	// ContractFormationPlan returns the plan the contractor would execute to
+	// form contracts for the provided allowance without forming any
+	// contracts.
+	ContractFormationPlan(a Allowance) (ContractFormationPlan, error)
+
+	// SpendingForecast projects the spending of the allowance to the end of
+	// the current period.
+	SpendingForecast() (SpendingForecast, error)
+
+	// GougingReport reports which hosts are currently filtered by which price
+	// gouging rule.
+	GougingReport() (GougingReport, error)
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.SiaPublicKey) (ContractUtility, bool)
 
@@ -1076,16 +2014,40 @@ type Renter interface {
 	// isn't available for recovery or something went wrong.
 	RecoverableContracts() []RecoverableContract
 
-	// RecoveryScanStatus returns a bool indicating if a scan for recoverable
-	// contracts is in progress and if it is, the current progress of the scan.
-	RecoveryScanStatus() (bool, types.BlockHeight)
+	// RecoveryStatus returns the progress of the most recent scan for
+	// recoverable contracts and the number of contracts it found and
+	// recovered.
+	RecoveryStatus() ContractRecoveryStatus
 
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// UnrecoverableContracts returns the recoverable contracts that the
+	// contractor failed to recover together with the reason of the most
+	// recent failure.
+	UnrecoverableContracts() []UnrecoverableContract
+
 	// SetFileStuck sets the 'stuck' status of a file.
 	SetFileStuck(siaPath SiaPath, stuck bool) error
 
+	// SetDirQuota sets the maximum size of the files in a directory and its
+	// sub directories. A quota of 0 removes the quota.
+	SetDirQuota(siaPath SiaPath, quota uint64) error
+
+	// SetDirLock locks a directory and its contents against deletion and
+	// modification until lockedUntil. An active lock can only be extended.
+	SetDirLock(siaPath SiaPath, lockedUntil time.Time) error
+
+	// SetDirTags replaces the user defined tags of a directory.
+	SetDirTags(siaPath SiaPath, tags map[string]string) error
+
+	// SetFileLock locks a file against deletion and modification until
+	// lockedUntil. An active lock can only be extended.
+	SetFileLock(siaPath SiaPath, lockedUntil time.Time) error
+
+	// SetFileTags replaces the user defined tags of a file.
+	SetFileTags(siaPath SiaPath, tags map[string]string) error
+
 	// UploadBackup uploads a backup to hosts, such that it can be retrieved
 	// using only the seed.
 	UploadBackup(src string, name string) error
@@ -1100,6 +2062,10 @@ type Renter interface {
 	// BackupsOnHost returns the backups stored on the specified host.
 	BackupsOnHost(hostKey types.SiaPublicKey) ([]UploadedBackup, error)
 
+	// ConcatFiles creates a new file at siaPath which consists of the parts
+	// in order without uploading their data again. The parts are deleted.
+	ConcatFiles(siaPath SiaPath, parts []SiaPath) error
+
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(siaPath SiaPath) error
 
@@ -1136,6 +2102,11 @@ type Renter interface {
 	// FileHosts returns a list of hosts that are storing the file data.
 	FileHosts(SiaPath) ([]HostDBEntry, error)
 
+	// FileChecksums returns the merkle roots of the pieces of up to limit
+	// chunks of a file, starting at the chunk with index offset, together
+	// with proofs which allow for verifying them against the file's root.
+	FileChecksums(siaPath SiaPath, offset, limit uint64) (FileChecksums, error)
+
 	// Filter returns the renter's hostdb's filterMode and filteredHosts
 	Filter() (FilterMode, map[string]types.SiaPublicKey, []string, error)
 
@@ -1149,6 +2120,76 @@ type Renter interface {
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
 
+	// HostDBExportSnapshot returns a snapshot of all hosts known to the
+	// renter's hostdb.
+	HostDBExportSnapshot() (HostDBSnapshot, error)
+
+	// HostDBImportSnapshot imports the hosts of a snapshot into the renter's
+	// hostdb.
+	HostDBImportSnapshot(HostDBSnapshot) (HostDBImportResult, error)
+
+	// BenchmarkHosts benchmarks the given hosts, or all hosts the renter has
+	// contracts with if no hosts are given, and stores the results in the
+	// hostdb.
+	BenchmarkHosts([]types.SiaPublicKey) ([]HostBenchmarkResult, error)
+
+	// HostDBScanSettings returns the settings which control how the renter's
+	// hostdb scans hosts.
+	HostDBScanSettings() (HostDBScanSettings, error)
+
+	// SetHostDBScanSettings sets the settings which control how the renter's
+	// hostdb scans hosts.
+	SetHostDBScanSettings(HostDBScanSettings) error
+
+	// ScanHost scans a host right away and updates its entry in the hostdb.
+	ScanHost(types.SiaPublicKey) error
+
+	// MarkHostOffline records a failed scan for a host without scanning it.
+	MarkHostOffline(types.SiaPublicKey) error
+
+	// CreatePaymentBudget creates a budget which third-party applications use
+	// to pay hosts for MDM programs from the renter's ephemeral accounts. A
+	// zero duration creates a budget which doesn't expire.
+	CreatePaymentBudget(name string, limit types.Currency, duration time.Duration) (PaymentBudget, error)
+
+	// PaymentBudgets returns all payment budgets.
+	PaymentBudgets() ([]PaymentBudget, error)
+
+	// PaymentBudget returns a payment budget and the receipts of the programs
+	// which were paid from it.
+	PaymentBudget(id string) (PaymentBudget, []PaymentReceipt, error)
+
+	// SetPaymentBudgetLimit changes the limit of a payment budget.
+	SetPaymentBudgetLimit(id string, limit types.Currency) error
+
+	// DeletePaymentBudget deletes a payment budget and its receipts.
+	DeletePaymentBudget(id string) error
+
+	// ExecuteProgram executes an MDM program on a host the renter has a
+	// contract with and pays for it from the given payment budget. Programs
+	// which cost more than maxCost are rejected, a zero maxCost doesn't
+	// limit the cost beyond the budget.
+	ExecuteProgram(budgetID string, host types.SiaPublicKey, instructions []ProgramInstruction, maxCost types.Currency) (ProgramResult, error)
+
+	// ProgramCost returns the cost of executing an MDM program on a host,
+	// including the bandwidth.
+	ProgramCost(host types.SiaPublicKey, instructions []ProgramInstruction) (types.Currency, error)
+
+	// Audit samples the given number of random chunks of the renter's files,
+	// verifies that their pieces can be retrieved from the hosts and returns
+	// the signed report.
+	Audit(samples uint64) (AuditReport, error)
+
+	// AuditReports returns the reports of the most recent audits, starting
+	// with the most recent one.
+	AuditReports() ([]AuditReport, error)
+
+	// AuditSettings returns the settings of the periodic audits.
+	AuditSettings() (AuditSettings, error)
+
+	// SetAuditSettings changes the settings of the periodic audits.
+	SetAuditSettings(settings AuditSettings) error
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation(allowance Allowance) (RenterPriceEstimation, Allowance, error)
@@ -1194,10 +2235,23 @@ type Renter interface {
 	// ResumeRepairsAndUploads resumes the renter's repairs and uploads
 	ResumeRepairsAndUploads() error
 
+	// PauseRepairs pauses the repair of existing files, while uploads of new
+	// files continue. If duration is 0, repairs are paused until they are
+	// resumed. The pause persists across restarts.
+	PauseRepairs(duration time.Duration) error
+
+	// ResumeRepairs resumes the repair of existing files.
+	ResumeRepairs() error
+
+	// RepairBacklog returns the repair work of the renter and whether repairs
+	// are paused.
+	RepairBacklog() (RepairBacklog, error)
+
 	// Streamer creates a io.ReadSeeker that can be used to stream downloads
 	// from the Sia network and also returns the fileName of the streamed
-	// resource.
-	Streamer(siapath SiaPath, disableLocalFetch bool) (string, Streamer, error)
+	// resource. A non-zero overdrive overrides the stream overdrive of the
+	// renter's settings.
+	Streamer(siapath SiaPath, disableLocalFetch bool, overdrive uint64) (string, Streamer, error)
 
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
@@ -1206,6 +2260,44 @@ type Renter interface {
 	// reached and upload the data to the Sia network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
 
+	// UploadFromURL fetches the data from the provided HTTP(S) URL and uploads
+	// it to the Sia network. The size of the upload is limited by maxSize, or
+	// by a default limit if maxSize is zero.
+	UploadFromURL(up FileUploadParams, url string, maxSize uint64) error
+
+	// URLUploads returns the renter's URL uploads which are in progress or
+	// were completed recently.
+	URLUploads() []URLUploadInfo
+
+	// Reshard changes the erasure coding parameters of a file. The file is
+	// re-encoded and re-uploaded in the background and stays downloadable
+	// while that happens.
+	Reshard(siaPath SiaPath, ec ErasureCoder) error
+
+	// Reshards returns the renter's reshards since startup.
+	Reshards() []ReshardInfo
+
+	// PackUpload reads a small file from the reader and packs it into a pack
+	// which is shared with other small files.
+	PackUpload(siaPath SiaPath, reader io.Reader) error
+
+	// PackedFiles returns the renter's packed files.
+	PackedFiles() ([]PackedFileInfo, error)
+
+	// PackedFile returns a reader for the data of a packed file.
+	PackedFile(siaPath SiaPath) (io.ReadCloser, PackedFileInfo, error)
+
+	// DeletePackedFile deletes a packed file.
+	DeletePackedFile(siaPath SiaPath) error
+
+	// FlushPacks uploads all packs which haven't been uploaded yet.
+	FlushPacks() error
+
+	// CumulativeStats returns the renter's cumulative stats. If since is not
+	// zero, the stats are returned as the difference to the latest snapshot
+	// of the stats taken at or before since.
+	CumulativeStats(since time.Time) (RenterCumulativeStats, error)
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath SiaPath, mode os.FileMode) error
 
@@ -1218,6 +2310,10 @@ type Renter interface {
 	// WorkerPoolStatus returns the current status of the Renter's worker pool
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 
+	// WorkerStatus returns the current status of the worker for the host with
+	// the given public key.
+	WorkerStatus(hostPubKey types.SiaPublicKey) (WorkerStatus, error)
+
 	// BubbleMetadata calculates the updated values of a directory's metadata and
 	// updates the siadir metadata on disk then calls callThreadedBubbleMetadata
 	// on the parent directory so that it is only blocking for the current
@@ -1254,6 +2350,15 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+
+	// Overdrive overrides the overdrive of the renter's settings for this
+	// download if it is not zero.
+	Overdrive uint64
+
+	// CipherKey is the key of a file which is encrypted with an external key.
+	// It is required for downloading such files and must be left blank for
+	// all other files.
+	CipherKey crypto.CipherKey
 }
 
 // HealthPercentage returns the health in a more human understandable format out
@@ -1316,6 +2421,12 @@ type HostDB interface {
 	// provided settings.
 	EstimateHostScore(HostDBEntry, Allowance) (HostScoreBreakdown, error)
 
+	// ExportSnapshot returns a snapshot of all hosts known to the hostdb.
+	ExportSnapshot() (HostDBSnapshot, error)
+
+	// ImportSnapshot imports the hosts of a snapshot into the hostdb.
+	ImportSnapshot(HostDBSnapshot) (HostDBImportResult, error)
+
 	// Filter returns the hostdb's filterMode and filteredHosts
 	Filter() (FilterMode, map[string]types.SiaPublicKey, []string, error)
 
@@ -1341,6 +2452,9 @@ type HostDB interface {
 	// enabled or not.
 	IPViolationsCheck() (bool, error)
 
+	// MarkHostOffline records a failed scan for a host without scanning it.
+	MarkHostOffline(types.SiaPublicKey) error
+
 	// RandomHosts returns a set of random hosts, weighted by their estimated
 	// usefulness / attractiveness to the renter. RandomHosts will not return
 	// any offline or inactive hosts.
@@ -1351,6 +2465,16 @@ type HostDB interface {
 	// renter.
 	RandomHostsWithAllowance(int, []types.SiaPublicKey, []types.SiaPublicKey, Allowance) ([]HostDBEntry, error)
 
+	// ScanHost scans a host right away and updates its entry.
+	ScanHost(types.SiaPublicKey) error
+
+	// SetBenchmark stores the result of benchmarking a host.
+	SetBenchmark(types.SiaPublicKey, HostBenchmark) error
+
+	// ScanSettings returns the settings which control how the hostdb scans
+	// hosts.
+	ScanSettings() (HostDBScanSettings, error)
+
 	// ScoreBreakdown returns a detailed explanation of the various properties
 	// of the host.
 	ScoreBreakdown(HostDBEntry) (HostScoreBreakdown, error)
@@ -1364,6 +2488,10 @@ type HostDB interface {
 	// hostdb.
 	SetIPViolationCheck(enabled bool) error
 
+	// SetScanSettings sets the settings which control how the hostdb scans
+	// hosts.
+	SetScanSettings(HostDBScanSettings) error
+
 	// UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 	// contracts.
 	UpdateContracts([]RenterContract) error
@@ -0,0 +1,447 @@
+package renter
+
+// audit.go audits the retrievability of the renter's files. Instead of relying
+// on the health of a file, which is derived from the renter's contracts, an
+// audit samples random chunks of random files and downloads a random segment of
+// every piece of the chunk from every host which is supposed to store it. The
+// proof of every segment is verified against the piece's merkle root which is
+// stored in the siafile. The results are collected in a report which is signed
+// by a key derived from the renter's seed.
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// auditsFilename is the name of the file which persists the audit
+	// settings and reports.
+	auditsFilename = "audits.json"
+
+	// maxAuditReports is the maximum number of reports which are kept. Older
+	// reports are dropped.
+	maxAuditReports = 50
+
+	// maxAuditSamples is the maximum number of chunks which are sampled by a
+	// single audit.
+	maxAuditSamples = 1000
+)
+
+var (
+	// auditsMetadata is the header of the persisted audits.
+	auditsMetadata = persist.Metadata{
+		Header:  "Renter Audits",
+		Version: "1.0",
+	}
+
+	// auditKeySpecifier is used to derive the key which signs audit reports
+	// from the renter's seed.
+	auditKeySpecifier = types.NewSpecifier("auditkey")
+
+	// auditReadTimeout is the maximum amount of time an audit waits for a
+	// host to return a segment.
+	auditReadTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testnet:  2 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// defaultAuditSettings are the audit settings of a new renter. Periodic
+	// audits are disabled in testing to keep tests deterministic.
+	defaultAuditSettings = modules.AuditSettings{
+		Interval: build.Select(build.Var{
+			Dev:      time.Hour,
+			Standard: 24 * time.Hour,
+			Testnet:  24 * time.Hour,
+			Testing:  time.Duration(0),
+		}).(time.Duration),
+		Samples: 50,
+	}
+)
+
+type (
+	// audits keeps track of the audit settings and the most recent reports.
+	audits struct {
+		reports  []modules.AuditReport
+		settings modules.AuditSettings
+		mu       sync.Mutex
+
+		// staticWakeChan is signaled when the settings change.
+		staticWakeChan chan struct{}
+		staticPath     string
+	}
+
+	// auditsPersist is the persisted form of the audits.
+	auditsPersist struct {
+		Settings modules.AuditSettings `json:"settings"`
+		Reports  []modules.AuditReport `json:"reports"`
+	}
+
+	// auditReadResult is the result of reading a segment of a piece from a
+	// host.
+	auditReadResult struct {
+		pieceIndex int
+		host       types.SiaPublicKey
+		err        error
+	}
+)
+
+// newAudits creates the audits and loads the persisted settings and reports
+// from the provided directory if there are any.
+func newAudits(persistDir string) (*audits, error) {
+	a := &audits{
+		settings:       defaultAuditSettings,
+		staticWakeChan: make(chan struct{}, 1),
+		staticPath:     filepath.Join(persistDir, auditsFilename),
+	}
+	var p auditsPersist
+	err := persist.LoadJSON(auditsMetadata, &p, a.staticPath)
+	if os.IsNotExist(err) {
+		return a, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load audits")
+	}
+	a.settings = p.Settings
+	a.reports = p.Reports
+	return a, nil
+}
+
+// save persists the audits. The caller must hold the lock.
+func (a *audits) save() error {
+	p := auditsPersist{
+		Settings: a.settings,
+		Reports:  a.reports,
+	}
+	return persist.SaveJSON(auditsMetadata, p, a.staticPath)
+}
+
+// managedAddReport adds a report to the audits and drops the oldest report if
+// there are too many.
+func (a *audits) managedAddReport(report modules.AuditReport) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reports = append(a.reports, report)
+	if len(a.reports) > maxAuditReports {
+		a.reports = a.reports[len(a.reports)-maxAuditReports:]
+	}
+	return a.save()
+}
+
+// managedLastAudit returns the time at which the most recent audit finished.
+func (a *audits) managedLastAudit() time.Time {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.reports) == 0 {
+		return time.Time{}
+	}
+	return a.reports[len(a.reports)-1].End
+}
+
+// managedReports returns the reports starting with the most recent one.
+func (a *audits) managedReports() []modules.AuditReport {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reports := make([]modules.AuditReport, 0, len(a.reports))
+	for i := len(a.reports) - 1; i >= 0; i-- {
+		reports = append(reports, a.reports[i])
+	}
+	return reports
+}
+
+// managedSettings returns the audit settings.
+func (a *audits) managedSettings() modules.AuditSettings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.settings
+}
+
+// managedSetSettings changes the audit settings and wakes up the audit loop.
+func (a *audits) managedSetSettings(settings modules.AuditSettings) error {
+	if settings.Interval < 0 {
+		return errors.New("the audit interval can't be negative")
+	}
+	if settings.Samples == 0 || settings.Samples > maxAuditSamples {
+		return fmt.Errorf("the number of samples must be between 1 and %v", maxAuditSamples)
+	}
+	a.mu.Lock()
+	a.settings = settings
+	err := a.save()
+	a.mu.Unlock()
+	select {
+	case a.staticWakeChan <- struct{}{}:
+	default:
+	}
+	return err
+}
+
+// Audit samples the given number of random chunks of the renter's files,
+// verifies that their pieces can be retrieved from the hosts and returns the
+// signed report.
+func (r *Renter) Audit(samples uint64) (modules.AuditReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.AuditReport{}, err
+	}
+	defer r.tg.Done()
+	if samples == 0 || samples > maxAuditSamples {
+		return modules.AuditReport{}, fmt.Errorf("the number of samples must be between 1 and %v", maxAuditSamples)
+	}
+	return r.managedAudit(samples)
+}
+
+// AuditReports returns the reports of the most recent audits, starting with
+// the most recent one.
+func (r *Renter) AuditReports() ([]modules.AuditReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticAudits.managedReports(), nil
+}
+
+// AuditSettings returns the settings of the periodic audits.
+func (r *Renter) AuditSettings() (modules.AuditSettings, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.AuditSettings{}, err
+	}
+	defer r.tg.Done()
+	return r.staticAudits.managedSettings(), nil
+}
+
+// SetAuditSettings changes the settings of the periodic audits.
+func (r *Renter) SetAuditSettings(settings modules.AuditSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticAudits.managedSetSettings(settings)
+}
+
+// managedAudit audits the given number of random chunks, signs the report and
+// adds it to the audits.
+func (r *Renter) managedAudit(samples uint64) (modules.AuditReport, error) {
+	report := modules.AuditReport{
+		ID:    hex.EncodeToString(fastrand.Bytes(16)),
+		Start: time.Now(),
+	}
+
+	// Get the files which can be sampled.
+	var siaPaths []modules.SiaPath
+	err := r.staticFileSystem.CachedList(modules.RootSiaPath(), true, func(fi modules.FileInfo) {
+		if fi.Filesize > 0 {
+			siaPaths = append(siaPaths, fi.SiaPath)
+		}
+	}, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return modules.AuditReport{}, errors.AddContext(err, "failed to list files")
+	}
+
+	// Sample the chunks.
+	files := make(map[modules.SiaPath]*modules.FileAuditResult)
+	hosts := make(map[string]*modules.HostAuditResult)
+	snapshots := make(map[modules.SiaPath]*siafile.Snapshot)
+	for i := uint64(0); i < samples && len(siaPaths) > 0; i++ {
+		siaPath := siaPaths[fastrand.Intn(len(siaPaths))]
+		snap, ok := snapshots[siaPath]
+		if !ok {
+			snap, err = r.managedAuditSnapshot(siaPath)
+			if err != nil {
+				r.log.Debugf("WARN: unable to audit %v: %v", siaPath, err)
+				continue
+			}
+			snapshots[siaPath] = snap
+		}
+		if snap.NumChunks() == 0 {
+			continue
+		}
+		chunkIndex := fastrand.Uint64n(snap.NumChunks())
+		if snap.IsHole(chunkIndex) {
+			continue
+		}
+		file, ok := files[siaPath]
+		if !ok {
+			file = &modules.FileAuditResult{SiaPath: siaPath}
+			files[siaPath] = file
+		}
+		retrievable := r.managedAuditChunk(snap, chunkIndex, file, hosts)
+		file.ChunksSampled++
+		report.Samples++
+		if retrievable {
+			file.ChunksRetrievable++
+		} else {
+			file.UnretrievableChunks = append(file.UnretrievableChunks, chunkIndex)
+		}
+	}
+
+	// Collect the results in a deterministic order.
+	for _, file := range files {
+		report.Files = append(report.Files, *file)
+	}
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].SiaPath.String() < report.Files[j].SiaPath.String()
+	})
+	for _, host := range hosts {
+		report.Hosts = append(report.Hosts, *host)
+	}
+	sort.Slice(report.Hosts, func(i, j int) bool {
+		return report.Hosts[i].HostKey.String() < report.Hosts[j].HostKey.String()
+	})
+	report.End = time.Now()
+
+	// Sign the report.
+	if err := r.managedSignAuditReport(&report); err != nil {
+		return modules.AuditReport{}, errors.AddContext(err, "failed to sign audit report")
+	}
+	if err := r.staticAudits.managedAddReport(report); err != nil {
+		return modules.AuditReport{}, errors.AddContext(err, "failed to save audit report")
+	}
+	return report, nil
+}
+
+// managedAuditSnapshot returns a snapshot of the file with the given siapath.
+func (r *Renter) managedAuditSnapshot(siaPath modules.SiaPath) (*siafile.Snapshot, error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := entry.Snapshot(siaPath)
+	return snap, errors.Compose(err, entry.Close())
+}
+
+// managedAuditChunk downloads a random segment of every piece of a chunk from
+// every host which stores it and updates the results of the file and the hosts.
+// It returns whether enough pieces were retrieved to recover the chunk.
+func (r *Renter) managedAuditChunk(snap *siafile.Snapshot, chunkIndex uint64, file *modules.FileAuditResult, hosts map[string]*modules.HostAuditResult) bool {
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), auditReadTimeout)
+	defer cancel()
+
+	// Read a random segment from every host in parallel.
+	resultChan := make(chan auditReadResult)
+	var reads int
+	for pieceIndex, pieceSet := range snap.Pieces(chunkIndex) {
+		for _, piece := range pieceSet {
+			reads++
+			go func(pieceIndex int, piece siafile.Piece) {
+				resultChan <- auditReadResult{
+					pieceIndex: pieceIndex,
+					host:       piece.HostPubKey,
+					err:        r.managedAuditPiece(ctx, piece),
+				}
+			}(pieceIndex, piece)
+		}
+	}
+
+	// Collect the results. A piece is retrievable if any of its hosts passed
+	// the audit.
+	retrievable := make(map[int]struct{})
+	sampled := make(map[int]struct{})
+	for i := 0; i < reads; i++ {
+		result := <-resultChan
+		sampled[result.pieceIndex] = struct{}{}
+		host, ok := hosts[result.host.String()]
+		if !ok {
+			host = &modules.HostAuditResult{HostKey: result.host}
+			hosts[result.host.String()] = host
+		}
+		host.Sampled++
+		if result.err != nil {
+			host.Failed++
+			host.LastError = result.err.Error()
+			continue
+		}
+		host.Passed++
+		retrievable[result.pieceIndex] = struct{}{}
+	}
+	file.PiecesSampled += uint64(len(sampled))
+	file.PiecesRetrievable += uint64(len(retrievable))
+	return len(retrievable) >= snap.ErasureCode().MinPieces()
+}
+
+// managedAuditPiece downloads a random segment of a piece from the host which
+// stores it. The worker verifies the segment's proof against the piece's
+// merkle root.
+func (r *Renter) managedAuditPiece(ctx context.Context, piece siafile.Piece) error {
+	w, err := r.staticWorkerPool.callWorker(piece.HostPubKey)
+	if err != nil {
+		return errors.AddContext(err, "no worker for host")
+	}
+	offset := fastrand.Uint64n(modules.SectorSize/crypto.SegmentSize) * crypto.SegmentSize
+	_, err = w.ReadSectorLowPrio(ctx, categoryDownload, piece.MerkleRoot, offset, crypto.SegmentSize)
+	return err
+}
+
+// managedSignAuditReport signs an audit report with the key derived from the
+// renter's seed.
+func (r *Renter) managedSignAuditReport(report *modules.AuditReport) error {
+	ws, err := r.w.PrimaryKeySeed()
+	if err != nil {
+		return errors.AddContext(err, "failed to get wallet's primary key seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	entropy := crypto.HashAll(rs, auditKeySpecifier)
+	defer fastrand.Read(entropy[:])
+	sk, pk := crypto.GenerateKeyPairDeterministic(entropy)
+	defer fastrand.Read(sk[:])
+
+	report.PublicKey = types.Ed25519PublicKey(pk)
+	report.Signature = crypto.SignHash(report.SigHash(), sk)
+	return nil
+}
+
+// threadedAuditLoop periodically audits the renter's files according to the
+// audit settings.
+func (r *Renter) threadedAuditLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	lastAudit := r.staticAudits.managedLastAudit()
+	if lastAudit.IsZero() {
+		lastAudit = time.Now()
+	}
+	for {
+		// Wait until the next audit is due or the settings change.
+		settings := r.staticAudits.managedSettings()
+		var wait <-chan time.Time
+		if settings.Interval > 0 {
+			wait = time.After(time.Until(lastAudit.Add(settings.Interval)))
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticAudits.staticWakeChan:
+			continue
+		case <-wait:
+		}
+		if !r.managedBlockUntilOnline() {
+			return
+		}
+		report, err := r.managedAudit(settings.Samples)
+		if err != nil {
+			r.log.Println("WARN: periodic audit failed:", err)
+			lastAudit = time.Now()
+			continue
+		}
+		lastAudit = report.End
+	}
+}
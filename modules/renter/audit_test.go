@@ -0,0 +1,150 @@
+package renter
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestAuditSettings probes the validation and persistence of the audit
+// settings and reports.
+func TestAuditSettings(t *testing.T) {
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	a, err := newAudits(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.managedSettings() != defaultAuditSettings {
+		t.Fatal("unexpected default settings", a.managedSettings())
+	}
+
+	// Invalid settings are rejected.
+	invalid := []modules.AuditSettings{
+		{Interval: -time.Second, Samples: 1},
+		{Interval: time.Hour},
+		{Interval: time.Hour, Samples: maxAuditSamples + 1},
+	}
+	for _, settings := range invalid {
+		if err := a.managedSetSettings(settings); err == nil {
+			t.Fatal("expected settings to be rejected", settings)
+		}
+	}
+	settings := modules.AuditSettings{Interval: time.Hour, Samples: 10}
+	if err := a.managedSetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-a.staticWakeChan:
+	default:
+		t.Fatal("audit loop wasn't woken up")
+	}
+
+	// Only the most recent reports are kept.
+	for i := 0; i < maxAuditReports+1; i++ {
+		if err := a.managedAddReport(modules.AuditReport{Samples: uint64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a, err = newAudits(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := a.managedReports()
+	if a.managedSettings() != settings || len(reports) != maxAuditReports || reports[0].Samples != maxAuditReports || reports[len(reports)-1].Samples != 1 {
+		t.Fatal("audits weren't persisted", a.managedSettings(), len(reports))
+	}
+}
+
+// TestAudit probes auditing a file whose pieces are stored on hosts the renter
+// has no workers for.
+func TestAudit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file whose pieces are stored on an unknown host.
+	path, err := rt.createZeroByteFileOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 1)
+	siaPath, err := modules.NewSiaPath("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.staticFileSystem.NewSiaFile(siaPath, path, rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), modules.SectorSize, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	for chunkIndex := uint64(0); chunkIndex < f.NumChunks(); chunkIndex++ {
+		if err := f.AddPiece(host, chunkIndex, 0, crypto.Hash{1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// None of the chunks is retrievable.
+	report, err := rt.renter.Audit(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Samples != 5 || len(report.Files) != 1 || len(report.Hosts) != 1 {
+		t.Fatal("unexpected report", report)
+	}
+	if file := report.Files[0]; file.ChunksSampled != 5 || file.ChunksRetrievable != 0 || len(file.UnretrievableChunks) != 5 || file.PiecesSampled != 5 {
+		t.Fatal("unexpected file result", file)
+	}
+	if h := report.Hosts[0]; !h.HostKey.Equals(host) || h.Sampled != 5 || h.Failed != 5 || h.LastError == "" {
+		t.Fatal("unexpected host result", h)
+	}
+
+	// The report is signed and can't be changed.
+	if err := report.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+	report.Files[0].ChunksRetrievable++
+	if err := report.VerifySignature(); err == nil {
+		t.Fatal("modified report was verified")
+	}
+	reports, err := rt.renter.AuditReports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].ID != report.ID {
+		t.Fatal("report wasn't saved", reports)
+	}
+	if _, err := rt.renter.Audit(0); err == nil {
+		t.Fatal("expected audit without samples to fail")
+	}
+}
@@ -410,6 +410,12 @@ func (r *Renter) managedUntarDir(tr *tar.Reader) (err error) {
 			if err != nil {
 				return errors.AddContext(err, "could not get directory")
 			}
+			// The archive contains the user's home, so the directory needs to
+			// be rebased onto it.
+			siaPath, err = siaPath.Rebase(modules.RootSiaPath(), modules.UserFolder)
+			if err != nil {
+				return errors.AddContext(err, "could not rebase directory")
+			}
 			err := r.staticFileSystem.NewSiaDir(siaPath, modules.DefaultDirPerm)
 			if errors.Contains(err, filesystem.ErrExists) {
 				// .siadir exists already
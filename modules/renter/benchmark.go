@@ -0,0 +1,193 @@
+package renter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// benchmarkThreads is the maximum number of hosts which are benchmarked
+	// in parallel.
+	benchmarkThreads = 10
+)
+
+var (
+	// benchmarkReadLength is the length of the small read which is used to
+	// measure the read latency of a host.
+	benchmarkReadLength = build.Select(build.Var{
+		Standard: uint64(1 << 16), // 64 KiB
+		Testnet:  uint64(1 << 16), // 64 KiB
+		Dev:      uint64(1 << 12), // 4 KiB
+		Testing:  uint64(1 << 10), // 1 KiB
+	}).(uint64)
+
+	// benchmarkTimeout is the time a host has to complete a benchmark.
+	benchmarkTimeout = build.Select(build.Var{
+		Standard: 5 * time.Minute,
+		Testnet:  5 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// errNoWorker is returned when a host is benchmarked which the renter
+	// doesn't have a worker for.
+	errNoWorker = errors.New("the renter doesn't have a contract with the host")
+)
+
+// throughput returns the throughput in bytes per second of transferring n
+// bytes in d.
+func throughput(n uint64, d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+	return uint64(float64(n) / d.Seconds())
+}
+
+// managedBenchmarkWrite uploads a random sector to the host and returns its
+// root and the time the upload took.
+func (w *worker) managedBenchmarkWrite() (crypto.Hash, time.Duration, error) {
+	e, err := w.renter.hostContractor.Editor(w.staticHostPubKey, w.renter.tg.StopChan())
+	if err != nil {
+		return crypto.Hash{}, 0, errors.AddContext(err, "failed to acquire an editor")
+	}
+	defer func() {
+		if err := e.Close(); err != nil {
+			w.renter.log.Print("managedBenchmarkWrite: failed to close editor", err)
+		}
+	}()
+
+	// Check for price gouging and the cost ceiling like a regular upload.
+	allowance := w.renter.hostContractor.Allowance()
+	hostSettings := e.HostSettings()
+	cache := w.staticCache()
+	if err := checkUploadGouging(allowance, hostSettings, cache.staticGougingThresholds.upload); err != nil {
+		return crypto.Hash{}, 0, errors.AddContext(err, "price gouging detected")
+	}
+	sectorCost := uploadSectorCost(hostSettings, cache.staticBlockHeight, e.EndHeight())
+	if err := cache.staticCostCeilings.checkCost(categoryUpload, sectorCost); err != nil {
+		return crypto.Hash{}, 0, err
+	}
+
+	start := time.Now()
+	root, err := e.Upload(fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		return crypto.Hash{}, 0, errors.AddContext(err, "failed to upload sector")
+	}
+	return root, time.Since(start), nil
+}
+
+// managedBenchmark benchmarks the worker's host. It writes a sector to the
+// host, reads a small part of it and the whole sector back and reads a random
+// registry entry.
+func (w *worker) managedBenchmark(ctx context.Context) modules.HostBenchmark {
+	b := modules.HostBenchmark{
+		Time: time.Now(),
+	}
+	err := func() error {
+		root, writeTime, err := w.managedBenchmarkWrite()
+		if err != nil {
+			return errors.AddContext(err, "write failed")
+		}
+		b.WriteThroughput = throughput(modules.SectorSize, writeTime)
+
+		start := time.Now()
+		if _, err := w.ReadSector(ctx, categoryDownload, root, 0, benchmarkReadLength); err != nil {
+			return errors.AddContext(err, "read failed")
+		}
+		b.ReadLatency = time.Since(start)
+
+		start = time.Now()
+		if _, err := w.ReadSector(ctx, categoryDownload, root, 0, modules.SectorSize); err != nil {
+			return errors.AddContext(err, "read failed")
+		}
+		b.ReadThroughput = throughput(modules.SectorSize, time.Since(start))
+
+		// Reading a random entry measures the latency of a lookup without
+		// depending on any existing entries.
+		_, pk := crypto.GenerateKeyPair()
+		var tweak crypto.Hash
+		fastrand.Read(tweak[:])
+		start = time.Now()
+		if _, err := w.ReadRegistry(ctx, types.Ed25519PublicKey(pk), tweak); err != nil {
+			return errors.AddContext(err, "registry read failed")
+		}
+		b.RegistryReadLatency = time.Since(start)
+		return nil
+	}()
+	if err != nil {
+		b.Error = err.Error()
+	}
+	return b
+}
+
+// BenchmarkHosts benchmarks the given hosts, or all hosts the renter has
+// contracts with if no hosts are given. The results are stored in the hostdb
+// and considered when scoring the hosts. Benchmarking a host uploads a sector
+// to it which is paid for from the host's contract.
+func (r *Renter) BenchmarkHosts(hosts []types.SiaPublicKey) ([]modules.HostBenchmarkResult, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Figure out which workers to benchmark.
+	workers := r.staticWorkerPool.callWorkers()
+	if len(hosts) == 0 {
+		for _, w := range workers {
+			hosts = append(hosts, w.staticHostPubKey)
+		}
+	}
+	workerMap := make(map[string]*worker, len(workers))
+	for _, w := range workers {
+		workerMap[w.staticHostPubKey.String()] = w
+	}
+
+	// Benchmark the hosts in parallel.
+	results := make([]modules.HostBenchmarkResult, len(hosts))
+	sem := make(chan struct{}, benchmarkThreads)
+	var wg sync.WaitGroup
+	for i, pk := range hosts {
+		results[i].PublicKey = pk
+		w, exists := workerMap[pk.String()]
+		if !exists {
+			results[i].Time = time.Now()
+			results[i].Error = errNoWorker.Error()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, w *worker) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-r.tg.StopChan():
+				return
+			}
+			defer func() { <-sem }()
+			ctx, cancel := context.WithTimeout(r.tg.StopCtx(), benchmarkTimeout)
+			defer cancel()
+			results[i].HostBenchmark = w.managedBenchmark(ctx)
+		}(i, w)
+	}
+	wg.Wait()
+
+	// Store the results in the hostdb.
+	var errs error
+	for _, result := range results {
+		if result.Time.IsZero() || result.Error == errNoWorker.Error() {
+			continue
+		}
+		if err := r.hostDB.SetBenchmark(result.PublicKey, result.HostBenchmark); err != nil {
+			errs = errors.Compose(errs, errors.AddContext(err, "unable to store benchmark of "+result.PublicKey.String()))
+		}
+	}
+	return results, errs
+}
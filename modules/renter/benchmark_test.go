@@ -0,0 +1,60 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestThroughput is a unit test for throughput.
+func TestThroughput(t *testing.T) {
+	if tp := throughput(modules.SectorSize, time.Second); tp != modules.SectorSize {
+		t.Fatal("wrong throughput", tp)
+	}
+	if tp := throughput(modules.SectorSize, 2*time.Second); tp != modules.SectorSize/2 {
+		t.Fatal("wrong throughput", tp)
+	}
+	if tp := throughput(modules.SectorSize, 0); tp != 0 {
+		t.Fatal("wrong throughput", tp)
+	}
+}
+
+// TestBenchmarkHostsNoWorker probes benchmarking hosts the renter doesn't have
+// a worker for.
+func TestBenchmarkHostsNoWorker(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without workers, benchmarking all hosts does nothing.
+	results, err := rt.renter.BenchmarkHosts(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Fatal("expected no results", results)
+	}
+
+	// Hosts without a worker fail the benchmark.
+	pk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: make([]byte, 32)}
+	results, err = rt.renter.BenchmarkHosts([]types.SiaPublicKey{pk})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || !results[0].PublicKey.Equals(pk) || results[0].Error != errNoWorker.Error() {
+		t.Fatal("wrong results", results)
+	}
+}
@@ -3,11 +3,14 @@ package renter
 import (
 	"container/list"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 )
 
 // Bubble is the process of updating the filesystem metadata for the renter. It
@@ -16,6 +19,13 @@ import (
 // root directory is reached. This results in any changes in metadata being
 // "bubbled" to the top so that the root directory's metadata reflects the
 // status of the entire filesystem.
+//
+// Only the directory containing the changed files needs to be recalculated from
+// scratch. Its parents are updated incrementally with the new metadata of the
+// changed subdirectory and the metadatas of the other subdirectories which were
+// recorded during the previous bubble of the parent. The metadatas of the
+// parent's own files are taken from the metadata cache. If the aggregate values
+// of a directory don't change, the bubble doesn't continue to its parent.
 
 // bubbleStatus indicates the status of a bubble being executed on a
 // directory
@@ -51,6 +61,17 @@ type (
 		// fifo is a First In Fist Out queue of bubble updates
 		fifo *bubbleQueue
 
+		// childMetadatas maps the siapath of a directory to the metadatas of
+		// its subdirectories which were used for the last bubble of the
+		// directory. They allow for updating the directory incrementally when
+		// only some of its subdirectories changed.
+		childMetadatas map[modules.SiaPath]map[modules.SiaPath]siadir.Metadata
+
+		// fileUpdateTimes maps the siapath of a directory to the time at which
+		// the metadatas of its files were last updated. Incremental bubbles
+		// only update the files which were modified since.
+		fileUpdateTimes map[modules.SiaPath]time.Time
+
 		// Utilities
 		mu           sync.Mutex
 		staticRenter *Renter
@@ -69,6 +90,26 @@ type (
 
 		// Current status of the bubble
 		status bubbleStatus
+
+		// full indicates that the files of the directory might have changed
+		// and its metadata needs to be recalculated from scratch. Otherwise
+		// only the subdirectories in childUpdates changed.
+		full bool
+
+		// childUpdates contains the new metadatas of the subdirectories which
+		// changed since the bubble was queued.
+		childUpdates map[modules.SiaPath]siadir.Metadata
+
+		// job is the work of the active bubble. It is set when the bubble
+		// update is popped off the queue.
+		job bubbleJob
+	}
+
+	// bubbleJob contains the work a bubble worker performs on a directory.
+	bubbleJob struct {
+		staticSiaPath      modules.SiaPath
+		staticFull         bool
+		staticChildUpdates map[modules.SiaPath]siadir.Metadata
 	}
 )
 
@@ -82,9 +123,11 @@ func newBubbleQueue() *bubbleQueue {
 // newBubbleScheduler returns an initialized bubbleScheduler
 func newBubbleScheduler(r *Renter) *bubbleScheduler {
 	return &bubbleScheduler{
-		bubbleNeeded:  make(chan struct{}, 1),
-		bubbleUpdates: make(map[modules.SiaPath]*bubbleUpdate),
-		fifo:          newBubbleQueue(),
+		bubbleNeeded:    make(chan struct{}, 1),
+		bubbleUpdates:   make(map[modules.SiaPath]*bubbleUpdate),
+		fifo:            newBubbleQueue(),
+		childMetadatas:  make(map[modules.SiaPath]map[modules.SiaPath]siadir.Metadata),
+		fileUpdateTimes: make(map[modules.SiaPath]time.Time),
 
 		staticRenter: r,
 	}
@@ -104,8 +147,30 @@ func (bq *bubbleQueue) Push(bu *bubbleUpdate) {
 	_ = bq.List.PushBack(bu)
 }
 
-// callQueueBubble adds a bubble update request to the bubbleScheduler.
+// callQueueBubble adds a bubble update request to the bubbleScheduler. The
+// metadata of the directory will be recalculated from scratch.
 func (bs *bubbleScheduler) callQueueBubble(siaPath modules.SiaPath) chan struct{} {
+	return bs.managedQueueBubble(siaPath, func(bu *bubbleUpdate) {
+		bu.full = true
+	})
+}
+
+// callQueueChildUpdate adds a bubble update request to the bubbleScheduler for
+// a directory of which a subdirectory changed. Unless a full bubble is
+// requested as well, the metadata of the directory is only updated with the
+// new metadata of the subdirectory.
+func (bs *bubbleScheduler) callQueueChildUpdate(siaPath, childSiaPath modules.SiaPath, childMetadata siadir.Metadata) chan struct{} {
+	return bs.managedQueueBubble(siaPath, func(bu *bubbleUpdate) {
+		if bu.childUpdates == nil {
+			bu.childUpdates = make(map[modules.SiaPath]siadir.Metadata)
+		}
+		bu.childUpdates[childSiaPath] = childMetadata
+	})
+}
+
+// managedQueueBubble adds a bubble update request to the bubbleScheduler.
+// request is called on the bubble update to record the requested work.
+func (bs *bubbleScheduler) managedQueueBubble(siaPath modules.SiaPath, request func(*bubbleUpdate)) chan struct{} {
 	bs.mu.Lock()
 	defer bs.mu.Unlock()
 
@@ -128,10 +193,12 @@ func (bs *bubbleScheduler) callQueueBubble(siaPath modules.SiaPath) chan struct{
 			staticSiaPath: siaPath,
 			status:        bubbleQueued,
 		}
+		request(bu)
 		bs.bubbleUpdates[siaPath] = bu
 		bs.fifo.Push(bu)
 		return bu.complete
 	}
+	request(bu)
 
 	// There is already a bubble update in the map, check the status
 	switch bu.status {
@@ -163,10 +230,21 @@ func (bs *bubbleScheduler) callThreadedProcessBubbleUpdates() {
 	defer bs.staticRenter.tg.Done()
 
 	// Define bubble worker
-	bubbleWorker := func(siaPathChan chan modules.SiaPath) {
-		for siaPath := range siaPathChan {
+	bubbleWorker := func(jobChan chan bubbleJob) {
+		for job := range jobChan {
+			siaPath := job.staticSiaPath
+
+			// Remember the metadata before the bubble to tell whether the
+			// parent directory needs to be updated.
+			oldMetadata, oldErr := bs.staticRenter.managedDirectoryMetadata(siaPath)
+
 			// Perform the bubble update
-			err := bs.managedPerformBubbleUpdate(siaPath)
+			var err error
+			if job.staticFull {
+				err = bs.managedPerformBubbleUpdate(siaPath)
+			} else {
+				err = bs.managedPerformIncrementalBubbleUpdate(siaPath, job.staticChildUpdates)
+			}
 			if err != nil {
 				bs.staticRenter.log.Printf("WARN: error performing bubble on '%v': %v", siaPath, err)
 			}
@@ -174,8 +252,13 @@ func (bs *bubbleScheduler) callThreadedProcessBubbleUpdates() {
 			// Complete the bubble
 			bs.managedCompleteBubbleUpdate(siaPath)
 
-			// Queue a bubble on the parent directory
-			err = bs.managedQueueParent(siaPath)
+			// Queue a bubble on the parent directory. If the bubble failed,
+			// the parent is recalculated from scratch.
+			if err != nil || oldErr != nil {
+				err = bs.managedQueueParent(siaPath)
+			} else {
+				err = bs.managedQueueParentUpdate(siaPath, oldMetadata)
+			}
 			if err != nil {
 				bs.staticRenter.log.Printf("WARN: error queuing bubble for parent directory on '%v': %v", siaPath, err)
 			}
@@ -192,7 +275,7 @@ func (bs *bubbleScheduler) callThreadedProcessBubbleUpdates() {
 		}
 
 		// Launch a group of bubble workers
-		bubbleChan := make(chan modules.SiaPath, numBubbleWorkerThreads)
+		bubbleChan := make(chan bubbleJob, numBubbleWorkerThreads)
 		for i := 0; i < numBubbleWorkerThreads; i++ {
 			wg.Add(1)
 			go func() {
@@ -210,7 +293,7 @@ func (bs *bubbleScheduler) callThreadedProcessBubbleUpdates() {
 				close(bubbleChan)
 				wg.Wait()
 				return
-			case bubbleChan <- bu.staticSiaPath:
+			case bubbleChan <- bu.job:
 			}
 			bu = bs.managedPop()
 		}
@@ -255,7 +338,8 @@ func (bs *bubbleScheduler) managedCompleteBubbleUpdate(siaPath modules.SiaPath)
 	case bubblePending:
 		// If the status is bubblePending it means a bubble request was made while
 		// the current bubble was in progress. In this case we add the update back
-		// to the queue with a status of bubbleQueued and a new complete chan.
+		// to the queue with a status of bubbleQueued and a new complete chan. The
+		// work requested in the meantime is kept.
 		bu.status = bubbleQueued
 		bu.complete = make(chan struct{})
 		bs.fifo.Push(bu)
@@ -277,11 +361,9 @@ func (bs *bubbleScheduler) managedPerformBubbleUpdate(siaPath modules.SiaPath) (
 	r := bs.staticRenter
 
 	// Update the File metadatas in the directory.
-	offlineMap, goodForRenewMap, contracts, used := r.callRenterContractsAndUtilities()
-	err = r.managedUpdateFileMetadatasParams(siaPath, offlineMap, goodForRenewMap, contracts, used)
+	err = bs.managedUpdateFileMetadatas(siaPath, time.Time{})
 	if err != nil {
-		e := fmt.Sprintf("unable to update the file metadatas for directory '%v'", siaPath.String())
-		return errors.AddContext(err, e)
+		return err
 	}
 
 	// Calculate the new metadata values of the directory
@@ -291,6 +373,57 @@ func (bs *bubbleScheduler) managedPerformBubbleUpdate(siaPath modules.SiaPath) (
 		return errors.AddContext(err, e)
 	}
 
+	return bs.managedUpdateDirectoryMetadata(siaPath, metadata)
+}
+
+// managedPerformIncrementalBubbleUpdate performs the bubble update on a
+// directory of which only subdirectories changed. The metadatas of the
+// subdirectories recorded during the last bubble are updated with the
+// childUpdates instead of reading them from disk and the metadatas of the
+// files are taken from the metadata cache instead of updating them.
+func (bs *bubbleScheduler) managedPerformIncrementalBubbleUpdate(siaPath modules.SiaPath, childUpdates map[modules.SiaPath]siadir.Metadata) error {
+	// Update the metadatas of the files which changed since the last bubble.
+	// If the directory wasn't bubbled before, all files are updated.
+	bs.mu.Lock()
+	since := bs.fileUpdateTimes[siaPath]
+	bs.mu.Unlock()
+	err := bs.managedUpdateFileMetadatas(siaPath, since)
+	if err != nil {
+		return err
+	}
+
+	// Calculate the new metadata values of the directory
+	metadata, err := bs.staticRenter.callCalculateIncrementalDirectoryMetadata(siaPath, childUpdates)
+	if err != nil {
+		e := fmt.Sprintf("could not calculate the metadata of directory '%v'", siaPath.String())
+		return errors.AddContext(err, e)
+	}
+	return bs.managedUpdateDirectoryMetadata(siaPath, metadata)
+}
+
+// managedUpdateFileMetadatas updates the metadatas of the files within a
+// directory which were modified after since and records the time of the update
+// for the next incremental bubble.
+func (bs *bubbleScheduler) managedUpdateFileMetadatas(siaPath modules.SiaPath, since time.Time) error {
+	r := bs.staticRenter
+	offlineMap, goodForRenewMap, contracts, used := r.callRenterContractsAndUtilities()
+	err := r.managedUpdateFileMetadatasParams(siaPath, since, offlineMap, goodForRenewMap, contracts, used)
+	if err != nil {
+		e := fmt.Sprintf("unable to update the file metadatas for directory '%v'", siaPath.String())
+		return errors.AddContext(err, e)
+	}
+	bs.mu.Lock()
+	bs.fileUpdateTimes[siaPath] = time.Now()
+	bs.mu.Unlock()
+	return nil
+}
+
+// managedUpdateDirectoryMetadata saves the bubbled metadata of a directory to
+// disk and signals the repair loops if the root directory needs repairs.
+func (bs *bubbleScheduler) managedUpdateDirectoryMetadata(siaPath modules.SiaPath, metadata siadir.Metadata) (err error) {
+	// Grab the renter for ease
+	r := bs.staticRenter
+
 	// Update directory metadata with the health information. Don't return here
 	// to avoid skipping the repairNeeded and stuckChunkFound signals.
 	siaDir, err := r.staticFileSystem.OpenSiaDir(siaPath)
@@ -330,6 +463,79 @@ func (bs *bubbleScheduler) managedPerformBubbleUpdate(siaPath modules.SiaPath) (
 	return err
 }
 
+// managedSetChildMetadatas records the metadatas of the subdirectories of a
+// directory which were used to calculate its metadata.
+func (bs *bubbleScheduler) managedSetChildMetadatas(siaPath modules.SiaPath, dirMetadatas []bubbledSiaDirMetadata) {
+	children := make(map[modules.SiaPath]siadir.Metadata, len(dirMetadatas))
+	for _, md := range dirMetadatas {
+		children[md.sp] = md.Metadata
+	}
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	bs.childMetadatas[siaPath] = children
+}
+
+// managedUpdateChildMetadatas updates the recorded metadatas of the
+// subdirectories of a directory and returns all of them. If the metadatas
+// weren't recorded or the recorded subdirectories don't match dirSiaPaths,
+// the record is dropped and false is returned.
+func (bs *bubbleScheduler) managedUpdateChildMetadatas(siaPath modules.SiaPath, dirSiaPaths []modules.SiaPath, childUpdates map[modules.SiaPath]siadir.Metadata) ([]bubbledSiaDirMetadata, bool) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	children, ok := bs.childMetadatas[siaPath]
+	if !ok {
+		return nil, false
+	}
+	for sp, md := range childUpdates {
+		children[sp] = md
+	}
+	if len(children) != len(dirSiaPaths) {
+		delete(bs.childMetadatas, siaPath)
+		return nil, false
+	}
+	dirMetadatas := make([]bubbledSiaDirMetadata, 0, len(children))
+	for _, sp := range dirSiaPaths {
+		md, exists := children[sp]
+		if !exists {
+			delete(bs.childMetadatas, siaPath)
+			return nil, false
+		}
+		dirMetadatas = append(dirMetadatas, bubbledSiaDirMetadata{sp: sp, Metadata: md})
+	}
+	return dirMetadatas, true
+}
+
+// managedForgetChildMetadatas removes the recorded metadatas of the
+// subdirectories of a directory.
+func (bs *bubbleScheduler) managedForgetChildMetadatas(siaPath modules.SiaPath) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	delete(bs.childMetadatas, siaPath)
+}
+
+// callInvalidateChildMetadatas removes the recorded metadatas of the
+// subdirectories of all the ancestors of a directory as well as of the
+// directory and all of its subdirectories. It needs to be called when a
+// directory is created, deleted or renamed since the recorded subdirectories
+// of its parents no longer match the subdirectories on disk. The recorded file
+// update times of the directory and its subdirectories are removed as well.
+func (bs *bubbleScheduler) callInvalidateChildMetadatas(siaPath modules.SiaPath) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	for sp := range bs.childMetadatas {
+		if sp.IsRoot() || siaPath.IsRoot() || sp.Equals(siaPath) ||
+			strings.HasPrefix(siaPath.String(), sp.String()+"/") ||
+			strings.HasPrefix(sp.String(), siaPath.String()+"/") {
+			delete(bs.childMetadatas, sp)
+		}
+	}
+	for sp := range bs.fileUpdateTimes {
+		if siaPath.IsRoot() || sp.Equals(siaPath) || strings.HasPrefix(sp.String(), siaPath.String()+"/") {
+			delete(bs.fileUpdateTimes, sp)
+		}
+	}
+}
+
 // managedPop pops the next bubble update off of the fifo queue and updates the
 // bubble status.
 func (bs *bubbleScheduler) managedPop() *bubbleUpdate {
@@ -351,8 +557,16 @@ func (bs *bubbleScheduler) managedPop() *bubbleUpdate {
 		build.Critical("bubble update popped from queue not found in bubble update map")
 	}
 
-	// Update the status and return
+	// Update the status and hand the requested work to the worker. Work
+	// requested while the bubble is active is recorded for the next bubble.
 	bu.status = bubbleActive
+	bu.job = bubbleJob{
+		staticSiaPath:      bu.staticSiaPath,
+		staticFull:         bu.full,
+		staticChildUpdates: bu.childUpdates,
+	}
+	bu.full = false
+	bu.childUpdates = nil
 	return bu
 }
 
@@ -375,6 +589,37 @@ func (bs *bubbleScheduler) managedQueueParent(siaPath modules.SiaPath) error {
 	return nil
 }
 
+// managedQueueParentUpdate will queue a bubble for the parent directory with
+// the new metadata of the directory. If the aggregate values of the directory
+// didn't change, the parent doesn't need to be updated.
+func (bs *bubbleScheduler) managedQueueParentUpdate(siaPath modules.SiaPath, oldMetadata siadir.Metadata) error {
+	// If we are at the root directory there is nothing to do.
+	if siaPath.IsRoot() {
+		return nil
+	}
+
+	// Grab the new metadata. If it can't be read, the parent is recalculated
+	// from scratch.
+	metadata, err := bs.staticRenter.managedDirectoryMetadata(siaPath)
+	if err != nil {
+		return bs.managedQueueParent(siaPath)
+	}
+	if aggregateMetadataEqual(oldMetadata, metadata) {
+		return nil
+	}
+
+	// Grab the parent directory
+	parentDir, err := siaPath.Dir()
+	if err != nil {
+		return errors.AddContext(err, "failed to get parent dir")
+	}
+
+	// Queue a bubble to update the directory, ignore the return channel as we
+	// do not want to block on this update.
+	_ = bs.callQueueChildUpdate(parentDir, siaPath, metadata)
+	return nil
+}
+
 // BubbleMetadata will queue a bubble update for the directory. A bubble update
 // includes calculating the updated values of a directory's metadata, updating
 // the siadir metadata on disk, and then queuing a bubble update for the parent
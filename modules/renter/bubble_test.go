@@ -8,6 +8,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 )
 
 var (
@@ -141,6 +142,7 @@ func testBubbleScheduler(t *testing.T) {
 	t.Run("Basic", testBubbleScheduler_Basic)
 
 	// Specific Methods
+	t.Run("callQueueChildUpdate", testBubbleScheduler_callQueueChildUpdate)
 	t.Run("managedQueueParent", testBubbleScheduler_managedQueueParent)
 
 	if testing.Short() {
@@ -416,6 +418,87 @@ func testBubbleScheduler_Blocking(t *testing.T) {
 	}
 }
 
+// testBubbleScheduler_callQueueChildUpdate probes the merging of the work
+// requested for a directory.
+func testBubbleScheduler_callQueueChildUpdate(t *testing.T) {
+	// Initialize a bubble scheduler
+	bs := newBubbleScheduler(&Renter{})
+
+	// Queue updates of two children
+	siaPath := modules.RandomSiaPath()
+	child1, err := siaPath.Join("child1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	child2, err := siaPath.Join("child2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = bs.callQueueChildUpdate(siaPath, child1, siadir.Metadata{AggregateHealth: 1})
+	_ = bs.callQueueChildUpdate(siaPath, child2, siadir.Metadata{AggregateHealth: 2})
+	_ = bs.callQueueChildUpdate(siaPath, child1, siadir.Metadata{AggregateHealth: 3})
+
+	// The popped job should be incremental and contain the latest metadata of
+	// both children.
+	bu := bs.managedPop()
+	if bu == nil {
+		t.Fatal("nil bubble update popped")
+	}
+	job := bu.job
+	if job.staticFull {
+		t.Error("job shouldn't be a full bubble")
+	}
+	if len(job.staticChildUpdates) != 2 {
+		t.Fatal("unexpected number of child updates", len(job.staticChildUpdates))
+	}
+	if job.staticChildUpdates[child1].AggregateHealth != 3 || job.staticChildUpdates[child2].AggregateHealth != 2 {
+		t.Error("unexpected child updates", job.staticChildUpdates)
+	}
+
+	// Work requested while the bubble is active is kept for the next bubble.
+	_ = bs.callQueueChildUpdate(siaPath, child2, siadir.Metadata{AggregateHealth: 4})
+	_ = bs.callQueueBubble(siaPath)
+	bs.managedCompleteBubbleUpdate(siaPath)
+	bu = bs.managedPop()
+	if bu == nil {
+		t.Fatal("nil bubble update popped")
+	}
+	job = bu.job
+	if !job.staticFull {
+		t.Error("job should be a full bubble")
+	}
+	if len(job.staticChildUpdates) != 1 || job.staticChildUpdates[child2].AggregateHealth != 4 {
+		t.Error("unexpected child updates", job.staticChildUpdates)
+	}
+	bs.managedCompleteBubbleUpdate(siaPath)
+
+	// Recorded subdirectory metadatas are updated with the child updates.
+	children := []modules.SiaPath{child1, child2}
+	bs.managedSetChildMetadatas(siaPath, []bubbledSiaDirMetadata{{sp: child1}})
+	dirMetadatas, ok := bs.managedUpdateChildMetadatas(siaPath, children, job.staticChildUpdates)
+	if !ok {
+		t.Fatal("subdirectory metadatas weren't recorded")
+	}
+	if len(dirMetadatas) != 2 || dirMetadatas[1].AggregateHealth != 4 {
+		t.Fatal("unexpected subdirectory metadatas", dirMetadatas)
+	}
+
+	// Recorded metadatas that don't match the subdirectories are dropped.
+	if _, ok := bs.managedUpdateChildMetadatas(siaPath, children[:1], nil); ok {
+		t.Error("mismatching metadatas were returned")
+	}
+	if _, ok := bs.managedUpdateChildMetadatas(siaPath, children, nil); ok {
+		t.Error("mismatching metadatas weren't dropped")
+	}
+
+	// Invalidating a descendant forgets the recorded metadatas.
+	bs.managedSetChildMetadatas(siaPath, dirMetadatas)
+	bs.callInvalidateChildMetadatas(child1)
+	if _, ok := bs.managedUpdateChildMetadatas(siaPath, children, nil); ok {
+		t.Error("recorded metadatas weren't invalidated")
+	}
+}
+
 // testBubbleScheduler_managedQueueParent probes the managedQueueParent method.
 func testBubbleScheduler_managedQueueParent(t *testing.T) {
 	// Initialize a bubble scheduler
@@ -0,0 +1,99 @@
+package renter
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// pieceRootsMerkleRoot calculates the merkle root of a file from the roots of
+// its pieces the same way the merkle root of a contract is calculated from its
+// sector roots.
+func pieceRootsMerkleRoot(roots []crypto.Hash) crypto.Hash {
+	tree := crypto.NewCachedTree(0) // the height is only needed for proofs.
+	for _, h := range roots {
+		tree.Push(h)
+	}
+	return tree.Root()
+}
+
+// FileChecksums returns the merkle roots of the pieces of up to limit chunks of
+// a file, starting at the chunk with index offset, together with proofs which
+// allow for verifying them against the file's root.
+func (r *Renter) FileChecksums(siaPath modules.SiaPath, offset, limit uint64) (modules.FileChecksums, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileChecksums{}, err
+	}
+	defer r.tg.Done()
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return modules.FileChecksums{}, errors.AddContext(err, fmt.Sprintf("failed to open file %s", siaPath))
+	}
+	snap, err := entry.Snapshot(siaPath)
+	if err := errors.Compose(err, entry.Close()); err != nil {
+		return modules.FileChecksums{}, errors.AddContext(err, "failed to get snapshot")
+	}
+
+	ec := snap.ErasureCode()
+	numPieces := ec.NumPieces()
+	fc := modules.FileChecksums{
+		SiaPath:      siaPath,
+		FileSize:     snap.Size(),
+		ChunkSize:    snap.ChunkSize(),
+		PieceSize:    snap.PieceSize(),
+		DataPieces:   ec.MinPieces(),
+		ParityPieces: numPieces - ec.MinPieces(),
+		NumChunks:    snap.NumChunks(),
+	}
+	if offset > fc.NumChunks {
+		return modules.FileChecksums{}, fmt.Errorf("offset %v is beyond the file's %v chunks", offset, fc.NumChunks)
+	}
+	end := fc.NumChunks
+	if limit < end-offset {
+		end = offset + limit
+	}
+
+	// Collect the roots of all pieces. All pieces with the same index have the
+	// same root since they contain the same encrypted data.
+	roots := make([]crypto.Hash, 0, fc.NumChunks*uint64(numPieces))
+	for chunkIndex := uint64(0); chunkIndex < fc.NumChunks; chunkIndex++ {
+		pieceSets := snap.Pieces(chunkIndex)
+		var chunk modules.ChunkChecksums
+		if chunkIndex >= offset && chunkIndex < end {
+			chunk.Index = chunkIndex
+			chunk.Pieces = make([]modules.PieceChecksums, numPieces)
+		}
+		for pieceIndex := 0; pieceIndex < numPieces; pieceIndex++ {
+			var root crypto.Hash
+			var hosts []types.SiaPublicKey
+			if pieceIndex < len(pieceSets) && len(pieceSets[pieceIndex]) > 0 {
+				root = pieceSets[pieceIndex][0].MerkleRoot
+				for _, piece := range pieceSets[pieceIndex] {
+					if piece.MerkleRoot == root {
+						hosts = append(hosts, piece.HostPubKey)
+					}
+				}
+			}
+			roots = append(roots, root)
+			if chunk.Pieces != nil {
+				chunk.Pieces[pieceIndex] = modules.PieceChecksums{
+					MerkleRoot: root,
+					Hosts:      hosts,
+				}
+			}
+		}
+		if chunk.Pieces != nil {
+			fc.Chunks = append(fc.Chunks, chunk)
+		}
+	}
+	fc.Root = pieceRootsMerkleRoot(roots)
+	for i := range fc.Chunks {
+		start := int(fc.Chunks[i].Index) * numPieces
+		fc.Chunks[i].Proof = crypto.MerkleSectorRangeProof(roots, start, start+numPieces)
+	}
+	return fc, nil
+}
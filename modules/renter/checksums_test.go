@@ -0,0 +1,97 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestFileChecksums probes the piece roots and proofs returned for a file.
+func TestFileChecksums(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file with multiple chunks. The last piece of every chunk is
+	// missing.
+	path, err := rt.createZeroByteFileOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 2)
+	siaPath, err := modules.NewSiaPath("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.staticFileSystem.NewSiaFile(siaPath, path, rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), 3*modules.SectorSize, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	for chunkIndex := uint64(0); chunkIndex < f.NumChunks(); chunkIndex++ {
+		for pieceIndex := uint64(0); pieceIndex < 2; pieceIndex++ {
+			var root crypto.Hash
+			fastrand.Read(root[:])
+			if err := f.AddPiece(host, chunkIndex, pieceIndex, root); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	numChunks := f.NumChunks()
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every chunk can be verified against the file's root.
+	fc, err := rt.renter.FileChecksums(siaPath, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numChunks < 2 || fc.NumChunks != numChunks || uint64(len(fc.Chunks)) != numChunks || fc.DataPieces != 1 || fc.ParityPieces != 2 {
+		t.Fatal("unexpected checksums", fc)
+	}
+	for _, chunk := range fc.Chunks {
+		if len(chunk.Pieces[0].Hosts) != 1 || len(chunk.Pieces[2].Hosts) != 0 || chunk.Pieces[2].MerkleRoot != (crypto.Hash{}) {
+			t.Fatal("unexpected pieces", chunk.Pieces)
+		}
+		if !fc.VerifyChunk(chunk) {
+			t.Fatal("chunk couldn't be verified", chunk.Index)
+		}
+		chunk.Pieces[1].MerkleRoot[0]++
+		if fc.VerifyChunk(chunk) {
+			t.Fatal("tampered chunk was verified", chunk.Index)
+		}
+	}
+
+	// A page of chunks has the same root.
+	page, err := rt.renter.FileChecksums(siaPath, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Root != fc.Root || len(page.Chunks) != 1 || page.Chunks[0].Index != 1 || !page.VerifyChunk(page.Chunks[0]) {
+		t.Fatal("unexpected page", page)
+	}
+	if _, err := rt.renter.FileChecksums(siaPath, numChunks+1, 1); err == nil {
+		t.Fatal("expected offset beyond the file to fail")
+	}
+}
@@ -50,6 +50,15 @@ var (
 		Testnet:  time.Minute * 10,
 		Testing:  time.Second * 3,
 	}).(time.Duration)
+
+	// urlUploadRetention is how long a completed URL upload is reported by
+	// URLUploads before it is forgotten.
+	urlUploadRetention = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: time.Hour * 24,
+		Testnet:  time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
 )
 
 // Default memory usage parameters.
@@ -161,6 +170,14 @@ const (
 	// DefaultMaxUploadSpeed is set to zero to indicate no limit, the user
 	// can set a custom MaxUploadSpeed through the API
 	DefaultMaxUploadSpeed = 0
+
+	// urlUploadResponseTimeout is the amount of time the renter waits for the
+	// response headers of the server when fetching the data of a URL upload.
+	urlUploadResponseTimeout = time.Minute
+
+	// DefaultURLUploadMaxSize is the maximum size of a URL upload if no
+	// maximum size is provided.
+	DefaultURLUploadMaxSize = 1 << 34 // 16 GiB
 )
 
 // Naming conventions for code readability.
@@ -0,0 +1,157 @@
+package renter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"encoding/json"
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/crypto/twofish"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// contractExportVersion is the version of the contract export format.
+var contractExportVersion = "1.0"
+
+// ExportContracts writes the renter's contracts, including their secret keys
+// and merkle roots, to dst. If a secret is not nil, the export will be
+// encrypted using the provided secret. Like a backup, the export starts with
+// the checksum of its body followed by a JSON header.
+func (r *Renter) ExportContracts(dst string, secret []byte) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+		if err != nil {
+			err = errors.Compose(err, os.Remove(dst))
+		}
+	}()
+	body := io.Writer(f)
+
+	// Prepare the header and wrap the body for encryption if required.
+	bh := backupHeader{
+		Version:    contractExportVersion,
+		Encryption: encryptionPlaintext,
+	}
+	if secret != nil {
+		bh.Encryption = encryptionTwofish
+		bh.IV = fastrand.Bytes(twofish.BlockSize)
+		c, err := twofish.NewCipher(secret)
+		if err != nil {
+			return err
+		}
+		body = cipher.StreamWriter{
+			S: cipher.NewCTR(c, bh.IV),
+			W: body,
+		}
+	}
+
+	// Skip the checksum for now and write the header.
+	if _, err := f.Seek(crypto.HashSize, io.SeekStart); err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(bh); err != nil {
+		return err
+	}
+
+	// Hash the compressed contracts before encrypting them.
+	h := crypto.NewHash()
+	gzw := gzip.NewWriter(io.MultiWriter(body, h))
+	if err := r.hostContractor.ExportContracts(gzw); err != nil {
+		return errors.Compose(err, gzw.Close())
+	}
+	if err := gzw.Close(); err != nil {
+		return err
+	}
+	_, err = f.WriteAt(h.Sum(nil), 0)
+	return err
+}
+
+// ImportContracts imports the contracts of an export created by ExportContracts
+// into the renter. Contracts with hosts that the renter already has a contract
+// with and expired contracts are skipped. If the export is encrypted, secret
+// will be used to decrypt it. It returns the number of imported contracts.
+func (r *Renter) ImportContracts(src string, secret []byte) (_ int, err error) {
+	if err := r.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer r.tg.Done()
+
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+
+	// Read the checksum and the header.
+	var chks crypto.Hash
+	if _, err := io.ReadFull(f, chks[:]); err != nil {
+		return 0, err
+	}
+	headerLine, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to read header")
+	}
+	var bh backupHeader
+	if err := json.Unmarshal(headerLine, &bh); err != nil {
+		return 0, errors.AddContext(err, "failed to decode header")
+	}
+	if bh.Version != contractExportVersion {
+		return 0, errors.New("unknown version")
+	}
+	bodyOffset := int64(crypto.HashSize + len(headerLine))
+
+	// Verify the checksum of the body before importing anything.
+	if _, err := f.Seek(bodyOffset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	body, err := wrapReaderInCipher(f, bh, secret)
+	if err != nil {
+		return 0, err
+	}
+	h := crypto.NewHash()
+	if _, err := io.Copy(h, body); err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(h.Sum(nil), chks[:]) {
+		return 0, errors.New("checksum doesn't match")
+	}
+
+	// Import the contracts.
+	if _, err := f.Seek(bodyOffset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	body, err = wrapReaderInCipher(f, bh, secret)
+	if err != nil {
+		return 0, err
+	}
+	gzr, err := gzip.NewReader(body)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+	n, err := r.hostContractor.ImportContracts(gzr)
+
+	// Update the workers to use the imported contracts.
+	if n > 0 {
+		r.staticWorkerPool.callUpdate()
+	}
+	return n, err
+}
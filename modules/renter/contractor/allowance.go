@@ -38,8 +38,37 @@ var (
 	// ErrAllowanceZeroMaxPeriodChurn is returned if the allowance max period
 	// churn is being set to zero when not cancelling the allowance
 	ErrAllowanceZeroMaxPeriodChurn = errors.New("max period churn must be non-zero")
+	// ErrAllowanceInvalidRenewWindowPolicy is returned if the allowance renew
+	// window policy is unknown
+	ErrAllowanceInvalidRenewWindowPolicy = errors.New("unknown renew window policy")
 )
 
+// validateAllowance returns an error if a field of the allowance is not set.
+func validateAllowance(a modules.Allowance) error {
+	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
+		return ErrAllowanceZeroFunds
+	} else if a.Hosts == 0 {
+		return ErrAllowanceNoHosts
+	} else if a.Period == 0 {
+		return ErrAllowanceZeroPeriod
+	} else if a.RenewWindow == 0 {
+		return ErrAllowanceZeroWindow
+	} else if a.ExpectedStorage == 0 {
+		return ErrAllowanceZeroExpectedStorage
+	} else if a.ExpectedUpload == 0 {
+		return ErrAllowanceZeroExpectedUpload
+	} else if a.ExpectedDownload == 0 {
+		return ErrAllowanceZeroExpectedDownload
+	} else if a.ExpectedRedundancy == 0 {
+		return ErrAllowanceZeroExpectedRedundancy
+	} else if a.MaxPeriodChurn == 0 {
+		return ErrAllowanceZeroMaxPeriodChurn
+	} else if !a.RenewWindowPolicy.IsValid() {
+		return ErrAllowanceInvalidRenewWindowPolicy
+	}
+	return nil
+}
+
 // SetAllowance sets the amount of money the Contractor is allowed to spend on
 // contracts over a given time period, divided among the number of hosts
 // specified. Note that Contractor can start forming contracts as soon as
@@ -66,24 +95,8 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 	}
 
 	// sanity checks
-	if a.Funds.Cmp(types.ZeroCurrency) <= 0 {
-		return ErrAllowanceZeroFunds
-	} else if a.Hosts == 0 {
-		return ErrAllowanceNoHosts
-	} else if a.Period == 0 {
-		return ErrAllowanceZeroPeriod
-	} else if a.RenewWindow == 0 {
-		return ErrAllowanceZeroWindow
-	} else if a.ExpectedStorage == 0 {
-		return ErrAllowanceZeroExpectedStorage
-	} else if a.ExpectedUpload == 0 {
-		return ErrAllowanceZeroExpectedUpload
-	} else if a.ExpectedDownload == 0 {
-		return ErrAllowanceZeroExpectedDownload
-	} else if a.ExpectedRedundancy == 0 {
-		return ErrAllowanceZeroExpectedRedundancy
-	} else if a.MaxPeriodChurn == 0 {
-		return ErrAllowanceZeroMaxPeriodChurn
+	if err := validateAllowance(a); err != nil {
+		return err
 	} else if !c.cs.Synced() {
 		return errAllowanceNotSynced
 	}
@@ -3,6 +3,7 @@ package contractor
 import (
 	"sort"
 	"sync"
+	"time"
 
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -10,6 +11,17 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// Reasons for churning a contract which are reported in the churn events.
+const (
+	churnReasonBadContract  = "contract is marked as bad"
+	churnReasonBadScore     = "host score is below the minimum score"
+	churnReasonChurnLimit   = "host score is below the minimum score but the churn limit was reached"
+	churnReasonDeadScore    = "host score is the lowest possible score"
+	churnReasonHostNotFound = "host is not in the hostdb or is filtered"
+	churnReasonMaxRevision  = "contract reached its maximum revision number"
+	churnReasonOffline      = "host is offline"
+)
+
 // contractScoreAndUtil combines a contract with its host's score and an updated
 // utility.
 type contractScoreAndUtil struct {
@@ -30,14 +42,23 @@ type churnLimiter struct {
 	// churned in the current period.
 	aggregateCurrentPeriodChurn uint64
 
+	// currentPeriodContractChurn is the number of contracts churned in the
+	// current period.
+	currentPeriodContractChurn uint64
+
+	// recentEvents are the most recent churn events, oldest first.
+	recentEvents []modules.ContractorChurnEvent
+
 	mu         sync.Mutex
 	contractor *Contractor
 }
 
 // churnLimiterPersist is the persisted state of a churnLimiter.
 type churnLimiterPersist struct {
-	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
-	RemainingChurnBudget        int    `json:"remainingchurnbudget"`
+	AggregateCurrentPeriodChurn uint64                         `json:"aggregatecurrentperiodchurn"`
+	RemainingChurnBudget        int                            `json:"remainingchurnbudget"`
+	CurrentPeriodContractChurn  uint64                         `json:"currentperiodcontractchurn"`
+	RecentEvents                []modules.ContractorChurnEvent `json:"recentevents"`
 }
 
 // managedMaxPeriodChurn returns the MaxPeriodChurn of the churnLimiter.
@@ -45,12 +66,23 @@ func (cl *churnLimiter) managedMaxPeriodChurn() uint64 {
 	return cl.contractor.Allowance().MaxPeriodChurn
 }
 
+// managedMaxPeriodContractChurn returns the MaxPeriodContractChurn of the
+// churnLimiter.
+func (cl *churnLimiter) managedMaxPeriodContractChurn() uint64 {
+	return cl.contractor.Allowance().MaxPeriodContractChurn
+}
+
 // callPersistData returns the churnLimiterPersist corresponding to this
 // churnLimiter's state
 func (cl *churnLimiter) callPersistData() churnLimiterPersist {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
-	return churnLimiterPersist{cl.aggregateCurrentPeriodChurn, cl.remainingChurnBudget}
+	return churnLimiterPersist{
+		AggregateCurrentPeriodChurn: cl.aggregateCurrentPeriodChurn,
+		RemainingChurnBudget:        cl.remainingChurnBudget,
+		CurrentPeriodContractChurn:  cl.currentPeriodContractChurn,
+		RecentEvents:                append([]modules.ContractorChurnEvent(nil), cl.recentEvents...),
+	}
 }
 
 // newChurnLimiterFromPersist creates a new churnLimiter using persisted state.
@@ -59,6 +91,8 @@ func newChurnLimiterFromPersist(contractor *Contractor, persistData churnLimiter
 		contractor:                  contractor,
 		aggregateCurrentPeriodChurn: persistData.AggregateCurrentPeriodChurn,
 		remainingChurnBudget:        persistData.RemainingChurnBudget,
+		currentPeriodContractChurn:  persistData.CurrentPeriodContractChurn,
+		recentEvents:                persistData.RecentEvents,
 	}
 }
 
@@ -67,13 +101,20 @@ func newChurnLimiter(contractor *Contractor) *churnLimiter {
 	return &churnLimiter{contractor: contractor}
 }
 
-// ChurnStatus returns the current period's aggregate churn and the max churn
-// per period.
+// ChurnStatus returns the current period's aggregate churn, the max churn per
+// period and the recent churn events.
 func (c *Contractor) ChurnStatus() modules.ContractorChurnStatus {
 	aggregateChurn, maxChurn := c.staticChurnLimiter.managedAggregateAndMaxChurn()
+	maxContractChurn := c.staticChurnLimiter.managedMaxPeriodContractChurn()
+	cl := c.staticChurnLimiter
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
 	return modules.ContractorChurnStatus{
 		AggregateCurrentPeriodChurn: aggregateChurn,
 		MaxPeriodChurn:              maxChurn,
+		CurrentPeriodContractChurn:  cl.currentPeriodContractChurn,
+		MaxPeriodContractChurn:      maxContractChurn,
+		RecentEvents:                append([]modules.ContractorChurnEvent{}, cl.recentEvents...),
 	}
 }
 
@@ -82,7 +123,9 @@ func (c *Contractor) ChurnStatus() modules.ContractorChurnStatus {
 func (cl *churnLimiter) callResetAggregateChurn() {
 	cl.mu.Lock()
 	cl.contractor.log.Println("Aggregate Churn for last period: ", cl.aggregateCurrentPeriodChurn)
+	cl.contractor.log.Println("Contracts churned in last period: ", cl.currentPeriodContractChurn)
 	cl.aggregateCurrentPeriodChurn = 0
+	cl.currentPeriodContractChurn = 0
 	cl.mu.Unlock()
 }
 
@@ -100,6 +143,7 @@ func (cl *churnLimiter) callNotifyChurnedContract(contract modules.RenterContrac
 
 	cl.aggregateCurrentPeriodChurn += size
 	cl.remainingChurnBudget -= int(size)
+	cl.currentPeriodContractChurn++
 	cl.contractor.log.Debugf("Increasing aggregate churn by %d to %d (MaxPeriodChurn: %d)", size, cl.aggregateCurrentPeriodChurn, maxPeriodChurn)
 	cl.contractor.log.Debugf("Remaining churn budget: %d", cl.remainingChurnBudget)
 }
@@ -157,10 +201,12 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 			currentBudget, periodBudget := cl.managedChurnBudget()
 			cl.contractor.log.Debugf("Remaining Churn Budget: %d. Remaining Period Budget: %d", currentBudget, periodBudget)
 			queuedContract.util.GoodForRenew = true
+			cl.managedRecordChurnEvent(queuedContract.contract, churnReasonChurnLimit, true)
 		}
 
 		if churningThisContract {
 			cl.contractor.log.Println("Churning contract for bad score: ", queuedContract.contract.ID, queuedContract.score)
+			cl.managedRecordChurnEvent(queuedContract.contract, churnReasonBadScore, false)
 		}
 
 		// Apply changes.
@@ -196,9 +242,16 @@ func (cl *churnLimiter) managedCanChurnContract(contract modules.RenterContract)
 	size := contract.Transaction.FileContractRevisions[0].NewFileSize
 	maxPeriodChurn := cl.managedMaxPeriodChurn()
 	maxChurnBudget := cl.managedMaxChurnBudget()
+	maxContractChurn := cl.managedMaxPeriodContractChurn()
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
 
+	// Never churn more contracts than allowed per period. Contracts without
+	// any data don't count as churn.
+	if size > 0 && maxContractChurn != 0 && cl.currentPeriodContractChurn >= maxContractChurn {
+		return false
+	}
+
 	// Allow any size contract to be churned if the current budget is the max
 	// budget. This allows large contracts to be churned if there is enough budget
 	// remaining for the period, even if the contract is larger than the
@@ -213,6 +266,45 @@ func (cl *churnLimiter) managedCanChurnContract(contract modules.RenterContract)
 	return fitsInPeriodBudget && fitsInCurrentBudget
 }
 
+// managedRecordChurnEvent adds a churn event for the contract to the recent
+// events. Contracts which keep being prevented from churning only get an event
+// the first time. Contracts without any data are not considered churn.
+func (cl *churnLimiter) managedRecordChurnEvent(contract modules.RenterContract, reason string, prevented bool) {
+	size := contract.Transaction.FileContractRevisions[0].NewFileSize
+	if size == 0 {
+		return
+	}
+	cl.contractor.mu.RLock()
+	blockHeight := cl.contractor.blockHeight
+	cl.contractor.mu.RUnlock()
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if prevented {
+		for i := len(cl.recentEvents) - 1; i >= 0; i-- {
+			if cl.recentEvents[i].ContractID != contract.ID {
+				continue
+			}
+			if cl.recentEvents[i].Prevented {
+				return
+			}
+			break
+		}
+	}
+	cl.recentEvents = append(cl.recentEvents, modules.ContractorChurnEvent{
+		Time:          time.Now(),
+		BlockHeight:   blockHeight,
+		ContractID:    contract.ID,
+		HostPublicKey: contract.HostPublicKey,
+		Size:          size,
+		Reason:        reason,
+		Prevented:     prevented,
+	})
+	if len(cl.recentEvents) > maxChurnEvents {
+		cl.recentEvents = cl.recentEvents[len(cl.recentEvents)-maxChurnEvents:]
+	}
+}
+
 // managedMarkContractUtility checks an active contract in the contractor and
 // figures out whether the contract is useful for uploading, and whether the
 // contract should be renewed.
@@ -239,17 +331,19 @@ func (c *Contractor) managedMarkContractUtility(contract modules.RenterContract,
 			c.log.Println("Unable to acquire and update contract utility:", err)
 			return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, errors.AddContext(err, "unable to update utility after hostdb check")
 		}
+		c.managedRecordChurnIfNotGFR(contract, u, churnReasonHostNotFound)
 		return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, nil
 	}
 
 	// Do critical contract checks and update the utility if any checks fail.
-	u, needsUpdate = c.managedCriticalUtilityChecks(sc, host)
+	u, reason, needsUpdate := c.managedCriticalUtilityChecks(sc, host)
 	if needsUpdate {
 		err := c.managedUpdateContractUtility(sc, u)
 		if err != nil {
 			c.log.Println("Unable to acquire and update contract utility:", err)
 			return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, errors.AddContext(err, "unable to update utility after criticalUtilityChecks")
 		}
+		c.managedRecordChurnIfNotGFR(contract, u, reason)
 		return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, nil
 	}
 
@@ -277,6 +371,7 @@ func (c *Contractor) managedMarkContractUtility(contract modules.RenterContract,
 			c.log.Println("Unable to acquire and update contract utility:", err)
 			return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, errors.AddContext(err, "unable to update utility after checkHostScore")
 		}
+		c.managedRecordChurnIfNotGFR(contract, u, churnReasonDeadScore)
 		return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, nil
 
 	default:
@@ -298,6 +393,15 @@ func (c *Contractor) managedMarkContractUtility(contract modules.RenterContract,
 	return modules.HostScoreBreakdown{}, modules.ContractUtility{}, false, nil
 }
 
+// managedRecordChurnIfNotGFR records a churn event if the contract went from
+// GFR to !GFR.
+func (c *Contractor) managedRecordChurnIfNotGFR(contract modules.RenterContract, u modules.ContractUtility, reason string) {
+	if !contract.Utility.GoodForRenew || u.GoodForRenew {
+		return
+	}
+	c.staticChurnLimiter.managedRecordChurnEvent(contract, reason, false)
+}
+
 // managedMarkContractsUtility checks every active contract in the contractor and
 // figures out whether the contract is useful for uploading, and whether the
 // contract should be renewed.
@@ -1,9 +1,11 @@
 package contractor
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -80,4 +82,92 @@ func TestCanChurnContract(t *testing.T) {
 	if ok {
 		t.Fatal("Expected not to be able to churn contract")
 	}
+
+	// Test: can't churn more contracts than allowed per period.
+	cl.contractor.allowance.MaxPeriodContractChurn = 2
+	cl.remainingChurnBudget = 500
+	cl.aggregateCurrentPeriodChurn = 0
+	cl.currentPeriodContractChurn = 1
+	ok = cl.managedCanChurnContract(contractWithSize(500))
+	if !ok {
+		t.Fatal("Expected to be able to churn contract")
+	}
+	cl.currentPeriodContractChurn = 2
+	ok = cl.managedCanChurnContract(contractWithSize(500))
+	if ok {
+		t.Fatal("Expected not to be able to churn contract")
+	}
+
+	// Test: empty contracts don't count towards the contract limit.
+	ok = cl.managedCanChurnContract(contractWithSize(0))
+	if !ok {
+		t.Fatal("Expected to be able to churn contract")
+	}
+}
+
+// TestChurnEvents probes recording churn events.
+func TestChurnEvents(t *testing.T) {
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		allowance:   modules.DefaultAllowance,
+		blockHeight: 10,
+		log:         log,
+	}
+	cl := newChurnLimiter(c)
+	c.staticChurnLimiter = cl
+	contract := contractWithSize(500)
+	contract.ID[0] = 1
+
+	// Churning a contract records an event and counts the contract.
+	cl.callNotifyChurnedContract(contract)
+	cl.managedRecordChurnEvent(contract, churnReasonBadScore, false)
+	status := c.ChurnStatus()
+	if status.CurrentPeriodContractChurn != 1 || status.AggregateCurrentPeriodChurn != 500 {
+		t.Fatal("wrong churn", status)
+	}
+	if len(status.RecentEvents) != 1 {
+		t.Fatal("expected 1 event, got", len(status.RecentEvents))
+	}
+	e := status.RecentEvents[0]
+	if e.ContractID != contract.ID || e.Size != 500 || e.BlockHeight != 10 || e.Reason != churnReasonBadScore || e.Prevented {
+		t.Fatal("wrong event", e)
+	}
+
+	// Empty contracts aren't churn.
+	cl.managedRecordChurnEvent(contractWithSize(0), churnReasonOffline, false)
+	if len(c.ChurnStatus().RecentEvents) != 1 {
+		t.Fatal("empty contract shouldn't be recorded")
+	}
+
+	// A contract which keeps being prevented from churning is only recorded
+	// once.
+	cl.managedRecordChurnEvent(contract, churnReasonChurnLimit, true)
+	cl.managedRecordChurnEvent(contract, churnReasonChurnLimit, true)
+	if events := c.ChurnStatus().RecentEvents; len(events) != 2 || !events[1].Prevented {
+		t.Fatal("wrong events", events)
+	}
+
+	// Only the most recent events are kept.
+	for i := 0; i < maxChurnEvents; i++ {
+		cl.managedRecordChurnEvent(contract, churnReasonOffline, false)
+	}
+	events := c.ChurnStatus().RecentEvents
+	if len(events) != maxChurnEvents {
+		t.Fatal("wrong number of events", len(events))
+	}
+	for _, e := range events {
+		if e.Reason != churnReasonOffline {
+			t.Fatal("old events should be dropped", e)
+		}
+	}
+
+	// A new period resets the contract churn but not the events.
+	cl.callResetAggregateChurn()
+	status = c.ChurnStatus()
+	if status.CurrentPeriodContractChurn != 0 || len(status.RecentEvents) != maxChurnEvents {
+		t.Fatal("wrong churn after reset", status.CurrentPeriodContractChurn, len(status.RecentEvents))
+	}
 }
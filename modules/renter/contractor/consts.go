@@ -98,6 +98,26 @@ var (
 	}).(types.BlockHeight)
 )
 
+// Constants related to the churn limiter.
+var (
+	// maxChurnEvents is the number of recent churn events the churn limiter
+	// keeps.
+	maxChurnEvents = 100
+)
+
+// Constants related to contract recovery.
+var (
+	// unrecoverableContractRetention is the number of blocks after the end of
+	// their proof window for which contracts that failed to be recovered are
+	// still reported.
+	unrecoverableContractRetention = build.Select(build.Var{
+		Dev:      types.BlockHeight(types.BlocksPerDay),
+		Standard: types.BlockHeight(types.BlocksPerMonth), // 30 days
+		Testnet:  types.BlockHeight(types.BlocksPerMonth), // 30 days
+		Testing:  types.BlockHeight(types.BlocksPerHour),
+	}).(types.BlockHeight)
+)
+
 // Constants related to the safety values for when the contractor is forming
 // contracts.
 var (
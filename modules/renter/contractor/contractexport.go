@@ -0,0 +1,221 @@
+package contractor
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// transferredContractsFilename is the name of the file which contains a
+	// copy of the contracts of an unconfirmed transfer.
+	transferredContractsFilename = "transferred.contracts"
+)
+
+var (
+	// errTransferPending is returned if contracts are transferred while a
+	// previous transfer wasn't confirmed or cancelled yet.
+	errTransferPending = errors.New("a previous transfer of the contracts wasn't confirmed or cancelled yet")
+)
+
+// ExportContracts writes the contractor's contracts, including their secret
+// keys and merkle roots, to w.
+func (c *Contractor) ExportContracts(w io.Writer) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+	return c.staticContracts.ExportContracts(w)
+}
+
+// ImportContracts reads contracts written by ExportContracts from r and adds
+// the ones that are not expired and with hosts the contractor doesn't have a
+// contract with yet. The imported contracts are monitored by the watchdog like
+// recovered contracts. It returns the number of imported contracts.
+func (c *Contractor) ImportContracts(r io.Reader) (int, error) {
+	if err := c.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+	imported, importErr := c.staticContracts.ImportContracts(r, blockHeight)
+	return len(imported), errors.Compose(importErr, c.managedMonitorImportedContracts(imported))
+}
+
+// ImportTransferredContracts reads contracts written by TransferContracts
+// from r and adds the ones that are not expired. If the contractor already has
+// one of the contracts or a contract with one of their hosts, no contract is
+// imported. It returns the number of imported contracts.
+func (c *Contractor) ImportTransferredContracts(r io.Reader) (int, error) {
+	if err := c.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+	imported, importErr := c.staticContracts.ImportTransferredContracts(r, blockHeight)
+	return len(imported), errors.Compose(importErr, c.managedMonitorImportedContracts(imported))
+}
+
+// managedMonitorImportedContracts starts tracking imported contracts. The
+// contracts are monitored by the watchdog like recovered contracts.
+func (c *Contractor) managedMonitorImportedContracts(imported []modules.RenterContract) error {
+	// Even if the import failed midway, the contracts that were imported
+	// need to be tracked.
+	c.managedUpdatePubKeyToContractIDMap()
+	var err error
+	for _, contract := range imported {
+		monitorErr := c.staticWatchdog.callMonitorContract(monitorContractArgs{
+			recovered:   true,
+			fcID:        contract.ID,
+			revisionTxn: contract.Transaction,
+		})
+		if errors.Contains(monitorErr, errAlreadyWatchingContract) {
+			monitorErr = nil
+		}
+		err = errors.Compose(err, monitorErr)
+	}
+	if len(imported) > 0 {
+		c.log.Printf("Imported %v contracts", len(imported))
+	}
+	return err
+}
+
+// TransferContracts hands all of the contractor's contracts over to another
+// renter by writing them to w and calling commit afterwards. If commit
+// succeeds, the contracts can't be used by the contractor anymore. Until the
+// transfer is confirmed by ConfirmTransferredContracts, a copy of the
+// contracts is kept to be able to restore them with
+// CancelTransferredContracts. It returns the number of transferred contracts.
+func (c *Contractor) TransferContracts(w io.Writer, commit func() error) (int, error) {
+	if err := c.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	pending := len(c.transferredContracts) > 0
+	c.mu.RUnlock()
+	if pending {
+		return 0, errTransferPending
+	}
+
+	// Keep a copy of the contracts which is written to disk before the
+	// transfer is committed.
+	path := filepath.Join(c.persistDir, transferredContractsFilename)
+	var local bytes.Buffer
+	transferred, err := c.staticContracts.TransferContracts(io.MultiWriter(w, &local), func() error {
+		if err := writeTransferredContracts(path, local.Bytes()); err != nil {
+			return errors.AddContext(err, "failed to keep a copy of the transferred contracts")
+		}
+		if err := commit(); err != nil {
+			return errors.Compose(err, os.Remove(path))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	for _, contract := range transferred {
+		c.transferredContracts[contract.ID] = contract
+	}
+	err = c.save()
+	c.mu.Unlock()
+	c.managedUpdatePubKeyToContractIDMap()
+	if len(transferred) > 0 {
+		c.log.Printf("Transferred %v contracts", len(transferred))
+	}
+	return len(transferred), err
+}
+
+// ConfirmTransferredContracts archives the contracts of an unconfirmed
+// transfer and deletes the copy that was kept to restore them. It returns the
+// number of archived contracts.
+func (c *Contractor) ConfirmTransferredContracts() (int, error) {
+	if err := c.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := len(c.transferredContracts)
+	for id, contract := range c.transferredContracts {
+		c.oldContracts[id] = contract
+	}
+	c.transferredContracts = make(map[types.FileContractID]modules.RenterContract)
+	if err := c.save(); err != nil {
+		return 0, err
+	}
+	err := os.Remove(filepath.Join(c.persistDir, transferredContractsFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return n, err
+	}
+	return n, nil
+}
+
+// CancelTransferredContracts restores the contracts of an unconfirmed
+// transfer. It fails without restoring any contract if the contractor
+// already has a contract with one of their hosts. It returns the number of
+// restored contracts.
+func (c *Contractor) CancelTransferredContracts() (_ int, err error) {
+	if err := c.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	pending := len(c.transferredContracts) > 0
+	c.mu.RUnlock()
+	if !pending {
+		return 0, nil
+	}
+	path := filepath.Join(c.persistDir, transferredContractsFilename)
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to open the copy of the transferred contracts")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	n, err := c.ImportTransferredContracts(f)
+	if err != nil {
+		return n, errors.AddContext(err, "failed to restore the transferred contracts")
+	}
+	c.mu.Lock()
+	c.transferredContracts = make(map[types.FileContractID]modules.RenterContract)
+	err = c.save()
+	c.mu.Unlock()
+	if err != nil {
+		return n, err
+	}
+	return n, os.Remove(path)
+}
+
+// writeTransferredContracts writes the copy of transferred contracts to path
+// and syncs it to disk.
+func writeTransferredContracts(path string, b []byte) (err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	return f.Sync()
+}
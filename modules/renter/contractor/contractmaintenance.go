@@ -389,8 +389,8 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		}
 	}()
 
-	// get the wallet seed.
-	seed, _, err := c.wallet.PrimarySeed()
+	// get the wallet's key seed.
+	seed, err := c.wallet.PrimaryKeySeed()
 	if err != nil {
 		return types.ZeroCurrency, modules.RenterContract{}, err
 	}
@@ -654,8 +654,8 @@ func (c *Contractor) managedRenew(id types.FileContractID, hpk types.SiaPublicKe
 		}
 	}()
 
-	// get the wallet seed
-	seed, _, err := c.wallet.PrimarySeed()
+	// get the wallet's key seed.
+	seed, err := c.wallet.PrimaryKeySeed()
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -939,7 +939,7 @@ func (c *Contractor) managedFindRecoverableContracts() {
 	c.mu.RLock()
 	cc := c.recentRecoveryChange
 	c.mu.RUnlock()
-	if err := c.callInitRecoveryScan(cc); err != nil {
+	if err := c.callInitRecoveryScan(cc, false); err != nil {
 		c.log.Debug(err)
 		return
 	}
@@ -1124,7 +1124,7 @@ func (c *Contractor) threadedContractMaintenance() {
 		// calculate a spending for the contract that is proportional to how
 		// much money was spend on the contract throughout this billing cycle
 		// (which is now ending).
-		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
+		if blockHeight >= renewHeight(contract, allowance) && !c.staticDeps.Disrupt("disableRenew") {
 			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
 			if err != nil {
 				c.log.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
@@ -1370,6 +1370,11 @@ func (c *Contractor) threadedContractMaintenance() {
 	for _, contract := range c.recoverableContracts {
 		blacklist = append(blacklist, contract.HostPublicKey)
 	}
+	// Add the hosts of contracts with an unconfirmed transfer to the blacklist
+	// to be able to restore them if the transfer is cancelled.
+	for _, contract := range c.transferredContracts {
+		blacklist = append(blacklist, contract.HostPublicKey)
+	}
 
 	// Determine the max and min initial contract funding based on the allowance
 	// settings
@@ -1465,6 +1470,7 @@ func (c *Contractor) threadedContractMaintenance() {
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
+			c.log.Println("Performance Adjustment:", sb.PerformanceAdjustment)
 			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
 			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
 			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
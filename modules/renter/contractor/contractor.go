@@ -71,6 +71,9 @@ type Contractor struct {
 	atomicScanInProgress     uint32
 	atomicRecoveryScanHeight int64
 
+	// Only one thread should be recovering contracts at a time.
+	recoverLock sync.Mutex
+
 	allowance     modules.Allowance
 	blockHeight   types.BlockHeight
 	synced        chan struct{}
@@ -83,6 +86,13 @@ type Contractor struct {
 	// is unlocked.
 	recentRecoveryChange modules.ConsensusChangeID
 
+	// recoveryTargetHeight, recoveryContractsFound and
+	// recoveryContractsRecovered track the progress of the most recent scan
+	// for recoverable contracts and are reset whenever a new scan is started.
+	recoveryTargetHeight       types.BlockHeight
+	recoveryContractsFound     uint64
+	recoveryContractsRecovered uint64
+
 	downloaders     map[types.FileContractID]*hostDownloader
 	editors         map[types.FileContractID]*hostEditor
 	sessions        map[types.FileContractID]*hostSession
@@ -98,12 +108,14 @@ type Contractor struct {
 	// renewedTo links the old contract's ID to the new contract's ID
 	// doubleSpentContracts keep track of all contracts that were double spent by
 	// either the renter or host.
-	staticContracts      *proto.ContractSet
-	oldContracts         map[types.FileContractID]modules.RenterContract
-	doubleSpentContracts map[types.FileContractID]types.BlockHeight
-	recoverableContracts map[types.FileContractID]modules.RecoverableContract
-	renewedFrom          map[types.FileContractID]types.FileContractID
-	renewedTo            map[types.FileContractID]types.FileContractID
+	staticContracts        *proto.ContractSet
+	oldContracts           map[types.FileContractID]modules.RenterContract
+	transferredContracts   map[types.FileContractID]modules.RenterContract
+	doubleSpentContracts   map[types.FileContractID]types.BlockHeight
+	recoverableContracts   map[types.FileContractID]modules.RecoverableContract
+	unrecoverableContracts map[types.FileContractID]modules.UnrecoverableContract
+	renewedFrom            map[types.FileContractID]types.FileContractID
+	renewedTo              map[types.FileContractID]types.FileContractID
 
 	staticChurnLimiter *churnLimiter
 	staticWatchdog     *watchdog
@@ -151,7 +163,7 @@ func (c *Contractor) InitRecoveryScan() (err error) {
 		return err
 	}
 	defer c.tg.Done()
-	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning)
+	return c.callInitRecoveryScan(modules.ConsensusChangeBeginning, true)
 }
 
 // PeriodSpending returns the amount spent on contracts during the current
@@ -347,12 +359,21 @@ func (c *Contractor) ProvidePayment(stream io.ReadWriter, pt *modules.RPCPriceTa
 	return nil
 }
 
-// RecoveryScanStatus returns a bool indicating if a scan for recoverable
-// contracts is in progress and if it is, the current progress of the scan.
-func (c *Contractor) RecoveryScanStatus() (bool, types.BlockHeight) {
+// RecoveryStatus returns the progress of the most recent scan for recoverable
+// contracts and the number of contracts it found and recovered.
+func (c *Contractor) RecoveryStatus() modules.ContractRecoveryStatus {
 	bh := types.BlockHeight(atomic.LoadInt64(&c.atomicRecoveryScanHeight))
 	sip := atomic.LoadUint32(&c.atomicScanInProgress)
-	return sip == 1, bh
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return modules.ContractRecoveryStatus{
+		ScanInProgress:     sip == 1,
+		ScannedHeight:      bh,
+		TargetHeight:       c.recoveryTargetHeight,
+		ContractsFound:     c.recoveryContractsFound,
+		ContractsRecovered: c.recoveryContractsRecovered,
+		PendingContracts:   uint64(len(c.recoverableContracts)),
+	}
 }
 
 // RefreshedContract returns a bool indicating if the contract was a refreshed
@@ -482,17 +503,19 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		interruptMaintenance: make(chan struct{}),
 		synced:               make(chan struct{}),
 
-		staticContracts:      contractSet,
-		downloaders:          make(map[types.FileContractID]*hostDownloader),
-		editors:              make(map[types.FileContractID]*hostEditor),
-		sessions:             make(map[types.FileContractID]*hostSession),
-		oldContracts:         make(map[types.FileContractID]modules.RenterContract),
-		doubleSpentContracts: make(map[types.FileContractID]types.BlockHeight),
-		recoverableContracts: make(map[types.FileContractID]modules.RecoverableContract),
-		renewing:             make(map[types.FileContractID]bool),
-		renewedFrom:          make(map[types.FileContractID]types.FileContractID),
-		renewedTo:            make(map[types.FileContractID]types.FileContractID),
-		workerPool:           emptyWorkerPool{},
+		staticContracts:        contractSet,
+		downloaders:            make(map[types.FileContractID]*hostDownloader),
+		editors:                make(map[types.FileContractID]*hostEditor),
+		sessions:               make(map[types.FileContractID]*hostSession),
+		oldContracts:           make(map[types.FileContractID]modules.RenterContract),
+		transferredContracts:   make(map[types.FileContractID]modules.RenterContract),
+		doubleSpentContracts:   make(map[types.FileContractID]types.BlockHeight),
+		recoverableContracts:   make(map[types.FileContractID]modules.RecoverableContract),
+		unrecoverableContracts: make(map[types.FileContractID]modules.UnrecoverableContract),
+		renewing:               make(map[types.FileContractID]bool),
+		renewedFrom:            make(map[types.FileContractID]types.FileContractID),
+		renewedTo:              make(map[types.FileContractID]types.FileContractID),
+		workerPool:             emptyWorkerPool{},
 	}
 	c.staticChurnLimiter = newChurnLimiter(c)
 	c.staticWatchdog = newWatchdog(c)
@@ -598,8 +621,10 @@ func NewCustomContractor(cs modules.ConsensusSet, w modules.Wallet, tp modules.T
 }
 
 // callInitRecoveryScan starts scanning the whole blockchain at a certain
-// ChangeID for recoverable contracts within a separate thread.
-func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (err error) {
+// ChangeID for recoverable contracts within a separate thread. If
+// recoverAfterScan is true, the found contracts are recovered right after the
+// scan instead of during the next contract maintenance.
+func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID, recoverAfterScan bool) (err error) {
 	// Check if we are already scanning the blockchain.
 	if !atomic.CompareAndSwapUint32(&c.atomicScanInProgress, 0, 1) {
 		return errors.New("scan for recoverable contracts is already in progress")
@@ -611,15 +636,20 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 			atomic.StoreInt64(&c.atomicRecoveryScanHeight, 0)
 		}
 	}()
-	// Get the wallet seed.
-	s, _, err := c.wallet.PrimarySeed()
+	// Get the wallet's key seed.
+	s, err := c.wallet.PrimaryKeySeed()
 	if err != nil {
-		return errors.AddContext(err, "failed to get wallet seed")
+		return errors.AddContext(err, "failed to get wallet's key seed")
 	}
 	// Get the renter seed and wipe it once done.
 	rs := modules.DeriveRenterSeed(s)
 	// Reset the scan progress before starting the scan.
 	atomic.StoreInt64(&c.atomicRecoveryScanHeight, 0)
+	c.mu.Lock()
+	c.recoveryTargetHeight = c.blockHeight
+	c.recoveryContractsFound = 0
+	c.recoveryContractsRecovered = 0
+	c.mu.Unlock()
 	// Create the scanner.
 	scanner := c.newRecoveryScanner(rs)
 	// Start the scan.
@@ -630,8 +660,9 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 		}
 		defer c.tg.Done()
 		// Scan blockchain.
-		if err := scanner.threadedScan(c.cs, scanStart, c.tg.StopChan()); err != nil {
-			c.log.Println("Scan failed", err)
+		scanErr := scanner.threadedScan(c.cs, scanStart, c.tg.StopChan())
+		if scanErr != nil {
+			c.log.Println("Scan failed", scanErr)
 		}
 		if c.staticDeps.Disrupt("disableRecoveryStatusReset") {
 			return
@@ -645,6 +676,10 @@ func (c *Contractor) callInitRecoveryScan(scanStart modules.ConsensusChangeID) (
 		c.mu.Lock()
 		c.save()
 		c.mu.Unlock()
+		// Recover the found contracts if requested.
+		if recoverAfterScan && scanErr == nil {
+			c.callRecoverContracts()
+		}
 	}()
 	return nil
 }
@@ -1,6 +1,8 @@
 package contractor
 
 import (
+	"encoding/binary"
+
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/proto"
@@ -15,6 +17,22 @@ func (c *Contractor) contractEndHeight() types.BlockHeight {
 	return c.currentPeriod + c.allowance.Period + c.allowance.RenewWindow
 }
 
+// renewHeight returns the height at which a contract is renewed according to
+// the renew window policy of the allowance. With the spread policy, the
+// renewals are spread deterministically across the first half of the renew
+// window based on the contract's id.
+func renewHeight(contract modules.RenterContract, a modules.Allowance) types.BlockHeight {
+	var start types.BlockHeight
+	if contract.EndHeight > a.RenewWindow {
+		start = contract.EndHeight - a.RenewWindow
+	}
+	if a.RenewWindowPolicy != modules.RenewWindowPolicySpread || a.RenewWindow < 2 {
+		return start
+	}
+	offset := binary.LittleEndian.Uint64(contract.ID[:8]) % uint64(a.RenewWindow/2)
+	return start + types.BlockHeight(offset)
+}
+
 // managedCancelContract cancels a contract by setting its utility fields to
 // false and locking the utilities. The contract can still be used for
 // downloads after this but it won't be used for uploads or renewals.
@@ -189,6 +207,19 @@ func (c *Contractor) RecoverableContracts() []modules.RecoverableContract {
 	return contracts
 }
 
+// UnrecoverableContracts returns the recoverable contracts that the contractor
+// failed to recover together with the reason of the most recent failure.
+// Contracts that failed to be recovered are retried until they expire.
+func (c *Contractor) UnrecoverableContracts() []modules.UnrecoverableContract {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	contracts := make([]modules.UnrecoverableContract, 0, len(c.unrecoverableContracts))
+	for _, c := range c.unrecoverableContracts {
+		contracts = append(contracts, c)
+	}
+	return contracts
+}
+
 // managedMarkContractBad marks an already acquired SafeContract as bad.
 func (c *Contractor) managedMarkContractBad(sc *proto.SafeContract) error {
 	u := sc.Utility()
@@ -158,3 +158,40 @@ func TestTryAddContractToPubKeyMap(t *testing.T) {
 	// Can't test the case of a pubkey already in the pubkey map as that results
 	// in a Critical log
 }
+
+// TestRenewHeight probes the renew height of contracts for the renew window
+// policies.
+func TestRenewHeight(t *testing.T) {
+	a := modules.DefaultAllowance
+	a.RenewWindow = 100
+	contract := modules.RenterContract{EndHeight: 1000}
+	contract.ID[0] = 1
+
+	// The start policy renews at the start of the window.
+	if h := renewHeight(contract, a); h != 900 {
+		t.Fatal("wrong renew height", h)
+	}
+	// Contracts ending within the window are renewed right away.
+	if h := renewHeight(modules.RenterContract{EndHeight: 50}, a); h != 0 {
+		t.Fatal("wrong renew height", h)
+	}
+
+	// The spread policy renews within the first half of the window,
+	// deterministically for every contract.
+	a.RenewWindowPolicy = modules.RenewWindowPolicySpread
+	heights := make(map[types.BlockHeight]struct{})
+	for i := 0; i < 100; i++ {
+		contract.ID = types.FileContractID(crypto.HashObject(i))
+		h := renewHeight(contract, a)
+		if h < 900 || h >= 950 {
+			t.Fatal("renew height outside of the first half of the window", h)
+		}
+		if h != renewHeight(contract, a) {
+			t.Fatal("renew height isn't deterministic")
+		}
+		heights[h] = struct{}{}
+	}
+	if len(heights) < 10 {
+		t.Fatal("renewals aren't spread", len(heights))
+	}
+}
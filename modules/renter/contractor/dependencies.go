@@ -19,6 +19,7 @@ type (
 	walletShim interface {
 		NextAddress() (types.UnlockConditions, error)
 		PrimarySeed() (modules.Seed, uint64, error)
+		PrimaryKeySeed() (modules.Seed, error)
 		StartTransaction() (modules.TransactionBuilder, error)
 		RegisterTransaction(types.Transaction, []types.Transaction) (modules.TransactionBuilder, error)
 		Unlocked() (bool, error)
@@ -58,7 +59,9 @@ type (
 		IncrementFailedInteractions(key types.SiaPublicKey) error
 		InitialScanComplete() (complete bool, err error)
 		RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]modules.HostDBEntry, error)
+		RandomHostsWithAllowance(n int, blacklist, addressBlacklist []types.SiaPublicKey, allowance modules.Allowance) ([]modules.HostDBEntry, error)
 		UpdateContracts([]modules.RenterContract) error
+		EstimateHostScore(modules.HostDBEntry, modules.Allowance) (modules.HostScoreBreakdown, error)
 		ScoreBreakdown(modules.HostDBEntry) (modules.HostScoreBreakdown, error)
 		SetAllowance(allowance modules.Allowance) error
 	}
@@ -82,6 +85,10 @@ func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) { return w
 // PrimarySeed returns the primary wallet seed.
 func (ws *WalletBridge) PrimarySeed() (modules.Seed, uint64, error) { return ws.W.PrimarySeed() }
 
+// PrimaryKeySeed returns the seed the wallet's and renter's keys are derived
+// from.
+func (ws *WalletBridge) PrimaryKeySeed() (modules.Seed, error) { return ws.W.PrimaryKeySeed() }
+
 // StartTransaction creates a new transactionBuilder that can be used to create
 // and sign a transaction.
 func (ws *WalletBridge) StartTransaction() (transactionBuilder, error) {
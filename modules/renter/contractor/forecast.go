@@ -0,0 +1,123 @@
+package contractor
+
+import (
+	"fmt"
+	"reflect"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errNoAllowance is returned when creating a spending forecast without an
+// allowance.
+var errNoAllowance = errors.New("can't forecast spending without an allowance")
+
+// SpendingForecast projects the spending of the allowance to the end of the
+// current period. The spending on storage, bandwidth and ephemeral accounts is
+// extrapolated from its rate during the current period so far. Contract fees
+// are only paid when contracts are formed and are not extrapolated.
+func (c *Contractor) SpendingForecast() (modules.SpendingForecast, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.SpendingForecast{}, err
+	}
+	defer c.tg.Done()
+
+	c.mu.RLock()
+	allowance := c.allowance
+	blockHeight := c.blockHeight
+	currentPeriod := c.currentPeriod
+	c.mu.RUnlock()
+	if reflect.DeepEqual(allowance, modules.Allowance{}) {
+		return modules.SpendingForecast{}, errNoAllowance
+	}
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		return modules.SpendingForecast{}, err
+	}
+	forecast := forecastSpending(spending, allowance, blockHeight, currentPeriod)
+
+	// Estimate the cost of renewing the contracts for another period at the
+	// current prices of their hosts.
+	for _, contract := range c.staticContracts.ViewAll() {
+		if !contract.Utility.GoodForRenew {
+			continue
+		}
+		host, ok, err := c.hdb.Host(contract.HostPublicKey)
+		if err != nil || !ok {
+			continue
+		}
+		storageCost := host.StoragePrice.Mul64(contract.Size()).Mul64(uint64(allowance.Period + allowance.RenewWindow))
+		forecast.EstimatedRenewCost = forecast.EstimatedRenewCost.Add(host.ContractPrice).Add(storageCost)
+	}
+	if forecast.EstimatedRenewCost.Cmp(allowance.Funds) > 0 {
+		forecast.Warnings = append(forecast.Warnings, fmt.Sprintf("renewing the contracts is estimated to cost %v which exceeds the allowance funds of %v", forecast.EstimatedRenewCost.HumanString(), allowance.Funds.HumanString()))
+	}
+	return forecast, nil
+}
+
+// forecastSpending projects the spending of the current period to its end.
+func forecastSpending(spending modules.ContractorSpending, allowance modules.Allowance, blockHeight, currentPeriod types.BlockHeight) modules.SpendingForecast {
+	forecast := modules.SpendingForecast{
+		BlockHeight: blockHeight,
+		PeriodStart: currentPeriod,
+		PeriodEnd:   currentPeriod + allowance.Period,
+		Funds:       allowance.Funds,
+		Spent: modules.ForecastSpending{
+			ContractFees:        spending.ContractFees,
+			DownloadSpending:    spending.DownloadSpending,
+			FundAccountSpending: spending.FundAccountSpending,
+			MaintenanceSpending: spending.MaintenanceSpending.Sum(),
+			StorageSpending:     spending.StorageSpending,
+			UploadSpending:      spending.UploadSpending,
+		},
+		Warnings: []string{},
+	}
+	forecast.Spent.Total = forecast.Spent.ContractFees.Add(forecast.Spent.DownloadSpending).
+		Add(forecast.Spent.FundAccountSpending).Add(forecast.Spent.MaintenanceSpending).
+		Add(forecast.Spent.StorageSpending).Add(forecast.Spent.UploadSpending)
+
+	// Without a single elapsed block there is no rate to extrapolate.
+	var elapsed, remaining uint64
+	if blockHeight > currentPeriod {
+		elapsed = uint64(blockHeight - currentPeriod)
+	}
+	if forecast.PeriodEnd > blockHeight {
+		remaining = uint64(forecast.PeriodEnd - blockHeight)
+	}
+	extrapolate := func(spent types.Currency) types.Currency {
+		if elapsed == 0 {
+			return spent
+		}
+		return spent.Add(spent.Mul64(remaining).Div64(elapsed))
+	}
+	forecast.Projected = modules.ForecastSpending{
+		ContractFees:        forecast.Spent.ContractFees,
+		DownloadSpending:    extrapolate(forecast.Spent.DownloadSpending),
+		FundAccountSpending: extrapolate(forecast.Spent.FundAccountSpending),
+		MaintenanceSpending: extrapolate(forecast.Spent.MaintenanceSpending),
+		StorageSpending:     extrapolate(forecast.Spent.StorageSpending),
+		UploadSpending:      extrapolate(forecast.Spent.UploadSpending),
+	}
+	forecast.Projected.Total = forecast.Projected.ContractFees.Add(forecast.Projected.DownloadSpending).
+		Add(forecast.Projected.FundAccountSpending).Add(forecast.Projected.MaintenanceSpending).
+		Add(forecast.Projected.StorageSpending).Add(forecast.Projected.UploadSpending)
+	if forecast.Projected.Total.Cmp(allowance.Funds) <= 0 {
+		forecast.ProjectedRemaining = allowance.Funds.Sub(forecast.Projected.Total)
+		return forecast
+	}
+
+	// The allowance is projected to be depleted before the end of the period.
+	// Determine when the extrapolated spending exceeds it.
+	forecast.DepletionHeight = blockHeight
+	usage := forecast.Spent.Total.Sub(forecast.Spent.ContractFees)
+	if forecast.Spent.Total.Cmp(allowance.Funds) < 0 && !usage.IsZero() {
+		blocks, err := allowance.Funds.Sub(forecast.Spent.Total).Mul64(elapsed).Div(usage).Uint64()
+		if err == nil {
+			forecast.DepletionHeight += types.BlockHeight(blocks)
+		}
+	}
+	forecast.Warnings = append(forecast.Warnings, fmt.Sprintf("the allowance is projected to be depleted at height %v, %v blocks before the end of the period", forecast.DepletionHeight, forecast.PeriodEnd-forecast.DepletionHeight))
+	return forecast
+}
@@ -0,0 +1,54 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestForecastSpending probes the extrapolation of the spending of the current
+// period.
+func TestForecastSpending(t *testing.T) {
+	allowance := modules.Allowance{
+		Funds:  types.NewCurrency64(1000),
+		Period: 100,
+	}
+	spending := modules.ContractorSpending{
+		ContractFees:    types.NewCurrency64(100),
+		StorageSpending: types.NewCurrency64(50),
+		UploadSpending:  types.NewCurrency64(50),
+	}
+
+	// After 50 blocks of the period the storage and upload spending is
+	// doubled. The fees stay the same.
+	forecast := forecastSpending(spending, allowance, 150, 100)
+	if forecast.PeriodEnd != 200 {
+		t.Fatal("wrong period end", forecast.PeriodEnd)
+	}
+	if !forecast.Spent.Total.Equals64(200) || !forecast.Projected.Total.Equals64(300) {
+		t.Fatal("wrong totals", forecast.Spent.Total, forecast.Projected.Total)
+	}
+	if !forecast.Projected.StorageSpending.Equals64(100) || !forecast.Projected.ContractFees.Equals64(100) {
+		t.Fatal("wrong projection", forecast.Projected)
+	}
+	if !forecast.ProjectedRemaining.Equals64(700) || forecast.DepletionHeight != 0 || len(forecast.Warnings) != 0 {
+		t.Fatal("unexpected depletion", forecast)
+	}
+
+	// After 10 blocks the rate of 10 per block depletes the remaining 800 of
+	// the allowance after another 80 blocks.
+	forecast = forecastSpending(spending, allowance, 110, 100)
+	if !forecast.Projected.Total.Equals64(1100) {
+		t.Fatal("wrong projected total", forecast.Projected.Total)
+	}
+	if forecast.DepletionHeight != 190 || !forecast.ProjectedRemaining.IsZero() || len(forecast.Warnings) != 1 {
+		t.Fatal("unexpected depletion", forecast)
+	}
+
+	// Without an elapsed block nothing is extrapolated.
+	forecast = forecastSpending(spending, allowance, 100, 100)
+	if !forecast.Projected.Total.Equals(forecast.Spent.Total) {
+		t.Fatal("spending was extrapolated", forecast.Projected.Total)
+	}
+}
@@ -0,0 +1,186 @@
+package contractor
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// FormationPlan returns the plan the contractor would execute to form the
+// contracts required by the allowance a during the next contract maintenance.
+// The plan follows the same host selection and funding rules as the contract
+// maintenance but doesn't form any contracts or spend any money. Renewals of
+// existing contracts are not part of the plan.
+func (c *Contractor) FormationPlan(a modules.Allowance) (modules.ContractFormationPlan, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.ContractFormationPlan{}, err
+	}
+	defer c.tg.Done()
+	if err := validateAllowance(a); err != nil {
+		return modules.ContractFormationPlan{}, err
+	}
+
+	// Count the contracts which are good for uploading and assemble the
+	// exclusion lists the same way the contract maintenance does.
+	var existingContracts uint64
+	var blacklist []types.SiaPublicKey
+	var addressBlacklist []types.SiaPublicKey
+	for _, contract := range c.staticContracts.ViewAll() {
+		if contract.Utility.GoodForUpload {
+			existingContracts++
+		}
+		blacklist = append(blacklist, contract.HostPublicKey)
+		if !contract.Utility.Locked || contract.Utility.GoodForRenew || contract.Utility.GoodForUpload {
+			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
+		}
+	}
+	c.mu.RLock()
+	for _, contract := range c.recoverableContracts {
+		blacklist = append(blacklist, contract.HostPublicKey)
+	}
+	blockHeight := c.blockHeight
+	allowanceSet := !reflect.DeepEqual(c.allowance, modules.Allowance{})
+	currentPeriod := c.currentPeriod
+	c.mu.RUnlock()
+
+	// Determine the end height of new contracts. If there is no allowance yet,
+	// the current period is set like SetAllowance would set it.
+	if !allowanceSet {
+		currentPeriod = blockHeight
+		if a.Period > a.RenewWindow {
+			currentPeriod -= a.RenewWindow
+		}
+	}
+	endHeight := currentPeriod + a.Period + a.RenewWindow
+
+	// Determine the funds that remain in the allowance for the current period.
+	fundsRemaining := a.Funds
+	if allowanceSet {
+		spending, err := c.PeriodSpending()
+		if err != nil {
+			return modules.ContractFormationPlan{}, err
+		}
+		if spending.TotalAllocated.Cmp(a.Funds) < 0 {
+			fundsRemaining = a.Funds.Sub(spending.TotalAllocated)
+		} else {
+			fundsRemaining = types.ZeroCurrency
+		}
+	}
+
+	plan := modules.ContractFormationPlan{
+		Allowance:         a,
+		ExistingContracts: existingContracts,
+		Contracts:         []modules.PlannedContract{},
+		SkippedHosts:      []modules.PlanSkippedHost{},
+		Warnings:          []string{},
+	}
+	if existingContracts >= a.Hosts {
+		plan.FundsRemaining = fundsRemaining
+		return plan, nil
+	}
+	plan.NeededContracts = a.Hosts - existingContracts
+	neededContracts := int(plan.NeededContracts)
+
+	// Get the hosts, weighted by the proposed allowance.
+	hosts, err := c.hdb.RandomHostsWithAllowance(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist, a)
+	if err != nil {
+		return modules.ContractFormationPlan{}, err
+	}
+
+	// Determine the max and min initial contract funding and the anticipated
+	// transaction fee.
+	maxInitialContractFunds := a.Funds.Div64(a.Hosts).Mul64(MaxInitialContractFundingMulFactor).Div64(MaxInitialContractFundingDivFactor)
+	minInitialContractFunds := a.Funds.Div64(a.Hosts).Div64(MinInitialContractFundingDivFactor)
+	_, maxFee := c.tpool.FeeEstimation()
+	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+	expectedStorage := a.ExpectedStorage / a.Hosts
+
+	for _, host := range hosts {
+		if neededContracts <= 0 {
+			break
+		}
+		skip := func(reason string) {
+			plan.SkippedHosts = append(plan.SkippedHosts, modules.PlanSkippedHost{
+				HostPublicKey: host.PublicKey,
+				NetAddress:    host.NetAddress,
+				Reason:        reason,
+			})
+		}
+
+		// Apply the same checks as managedNewContract.
+		if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
+			skip(errTooExpensive.Error())
+			continue
+		}
+		if host.MaxDuration < a.Period {
+			skip("insufficient MaxDuration of host")
+			continue
+		}
+		if err := checkFormContractGouging(a, host.HostExternalSettings); err != nil {
+			skip(err.Error())
+			continue
+		}
+		if host.MaxCollateral.Cmp(maxCollateral) > 0 {
+			host.MaxCollateral = maxCollateral
+		}
+
+		// Calculate the contract funding like the contract maintenance.
+		contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
+		if contractFunds.Cmp(maxInitialContractFunds) > 0 {
+			contractFunds = maxInitialContractFunds
+		}
+		if contractFunds.Cmp(minInitialContractFunds) < 0 {
+			contractFunds = minInitialContractFunds
+		}
+		if fundsRemaining.Cmp(contractFunds) < 0 {
+			plan.Warnings = append(plan.Warnings, "the allowance doesn't have enough funds remaining to form all needed contracts")
+			break
+		}
+
+		// Split the funding into fees and locked funds.
+		renterPayout, hostPayout, hostCollateral, err := modules.RenterPayoutsPreTax(host, contractFunds, txnFee, types.ZeroCurrency, types.ZeroCurrency, endHeight-blockHeight, expectedStorage)
+		if err != nil {
+			skip(err.Error())
+			continue
+		}
+		siafundFee := types.Tax(blockHeight, renterPayout.Add(hostPayout))
+		if siafundFee.Cmp(renterPayout) > 0 {
+			skip("not enough money to pay both siafund fee and also host payout")
+			continue
+		}
+		lockedFunds := renterPayout.Sub(siafundFee)
+		fees := contractFunds.Sub(lockedFunds)
+
+		var score types.Currency
+		if sb, err := c.hdb.EstimateHostScore(host, a); err == nil {
+			score = sb.Score
+		}
+		plan.Contracts = append(plan.Contracts, modules.PlannedContract{
+			HostPublicKey:          host.PublicKey,
+			NetAddress:             host.NetAddress,
+			Score:                  score,
+			ContractPrice:          host.ContractPrice,
+			StoragePrice:           host.StoragePrice,
+			UploadBandwidthPrice:   host.UploadBandwidthPrice,
+			DownloadBandwidthPrice: host.DownloadBandwidthPrice,
+			Collateral:             host.Collateral,
+			Funding:                contractFunds,
+			Fees:                   fees,
+			LockedFunds:            lockedFunds,
+			HostCollateral:         hostCollateral,
+		})
+		plan.TotalFunding = plan.TotalFunding.Add(contractFunds)
+		plan.TotalFees = plan.TotalFees.Add(fees)
+		plan.TotalLockedFunds = plan.TotalLockedFunds.Add(lockedFunds)
+		plan.TotalHostCollateral = plan.TotalHostCollateral.Add(hostCollateral)
+		fundsRemaining = fundsRemaining.Sub(contractFunds)
+		neededContracts--
+	}
+	if neededContracts > 0 && len(plan.Warnings) == 0 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("only found %v of %v needed hosts", len(plan.Contracts), plan.NeededContracts))
+	}
+	plan.FundsRemaining = fundsRemaining
+	return plan, nil
+}
@@ -77,6 +77,7 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
+			c.log.Println("Performance Adjustment:", sb.PerformanceAdjustment)
 			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
 			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
 			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
@@ -107,6 +108,7 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
 			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
+			c.log.Println("Performance Adjustment:", sb.PerformanceAdjustment)
 			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
 			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
 			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
@@ -126,18 +128,19 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 // managedCriticalUtilityChecks performs critical checks on a contract that
 // would require, with no exceptions, marking the contract as !GFR and/or !GFU.
 // Returns true if and only if and of the checks passed and require the utility
-// to be updated.
+// to be updated. The returned reason describes why the contract would be
+// churned if it is marked !GFR.
 //
 // NOTE: 'needsUpdate' should return 'true' if the contract should be marked as
 // !GFR and !GFU, even if the contract is already marked as such. If
 // 'needsUpdate' is set to true, other checks which may change those values will
 // be ignored and the contract will remain marked as having no utility.
-func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host modules.HostDBEntry) (modules.ContractUtility, bool) {
+func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host modules.HostDBEntry) (modules.ContractUtility, string, bool) {
 	contract := sc.Metadata()
 
 	c.mu.RLock()
 	blockHeight := c.blockHeight
-	renewWindow := c.allowance.RenewWindow
+	renewHeight := renewHeight(contract, c.allowance)
 	period := c.allowance.Period
 	_, renewed := c.renewedTo[contract.ID]
 	c.mu.RUnlock()
@@ -145,40 +148,40 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 	// A contract that has been renewed should be set to !GFU and !GFR.
 	u, needsUpdate := c.renewedCheck(contract.Utility, renewed)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "", needsUpdate
 	}
 
 	u, needsUpdate = c.maxRevisionCheck(contract.Utility, sc.LastRevision().NewRevisionNumber)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, churnReasonMaxRevision, needsUpdate
 	}
 
 	u, needsUpdate = c.badContractCheck(contract.Utility)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, churnReasonBadContract, needsUpdate
 	}
 
 	u, needsUpdate = c.offlineCheck(contract, host)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, churnReasonOffline, needsUpdate
 	}
 
-	u, needsUpdate = c.upForRenewalCheck(contract, renewWindow, blockHeight)
+	u, needsUpdate = c.upForRenewalCheck(contract, renewHeight, blockHeight)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "", needsUpdate
 	}
 
 	u, needsUpdate = c.sufficientFundsCheck(contract, host, period)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "", needsUpdate
 	}
 
 	u, needsUpdate = c.outOfStorageCheck(contract, blockHeight)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "", needsUpdate
 	}
 
-	return contract.Utility, false
+	return contract.Utility, "", false
 }
 
 // managedHostInHostDBCheck checks if the host is in the hostdb and not
@@ -228,11 +231,11 @@ func (c *Contractor) offlineCheck(contract modules.RenterContract, host modules.
 // upForRenewalCheck checks if this contract is up for renewal.
 // Returns true if a check fails and the utility returned must be used to update
 // the contract state.
-func (c *Contractor) upForRenewalCheck(contract modules.RenterContract, renewWindow, blockHeight types.BlockHeight) (modules.ContractUtility, bool) {
+func (c *Contractor) upForRenewalCheck(contract modules.RenterContract, renewHeight, blockHeight types.BlockHeight) (modules.ContractUtility, bool) {
 	u := contract.Utility
 	// Contract should not be used for uploading if the time has come to
 	// renew the contract.
-	if blockHeight+renewWindow >= contract.EndHeight {
+	if blockHeight >= renewHeight {
 		if u.GoodForUpload {
 			c.log.Println("Marking contract as not good for upload because it is time to renew the contract", contract.ID)
 		}
@@ -27,17 +27,19 @@ var (
 
 // contractorPersist defines what Contractor data persists across sessions.
 type contractorPersist struct {
-	Allowance            modules.Allowance               `json:"allowance"`
-	BlockHeight          types.BlockHeight               `json:"blockheight"`
-	CurrentPeriod        types.BlockHeight               `json:"currentperiod"`
-	LastChange           modules.ConsensusChangeID       `json:"lastchange"`
-	RecentRecoveryChange modules.ConsensusChangeID       `json:"recentrecoverychange"`
-	OldContracts         []modules.RenterContract        `json:"oldcontracts"`
-	DoubleSpentContracts map[string]types.BlockHeight    `json:"doublespentcontracts"`
-	RecoverableContracts []modules.RecoverableContract   `json:"recoverablecontracts"`
-	RenewedFrom          map[string]types.FileContractID `json:"renewedfrom"`
-	RenewedTo            map[string]types.FileContractID `json:"renewedto"`
-	Synced               bool                            `json:"synced"`
+	Allowance              modules.Allowance               `json:"allowance"`
+	BlockHeight            types.BlockHeight               `json:"blockheight"`
+	CurrentPeriod          types.BlockHeight               `json:"currentperiod"`
+	LastChange             modules.ConsensusChangeID       `json:"lastchange"`
+	RecentRecoveryChange   modules.ConsensusChangeID       `json:"recentrecoverychange"`
+	OldContracts           []modules.RenterContract        `json:"oldcontracts"`
+	TransferredContracts   []modules.RenterContract        `json:"transferredcontracts"`
+	DoubleSpentContracts   map[string]types.BlockHeight    `json:"doublespentcontracts"`
+	RecoverableContracts   []modules.RecoverableContract   `json:"recoverablecontracts"`
+	UnrecoverableContracts []modules.UnrecoverableContract `json:"unrecoverablecontracts"`
+	RenewedFrom            map[string]types.FileContractID `json:"renewedfrom"`
+	RenewedTo              map[string]types.FileContractID `json:"renewedto"`
+	Synced                 bool                            `json:"synced"`
 
 	// Subsystem persistence:
 	ChurnLimiter churnLimiterPersist `json:"churnlimiter"`
@@ -72,12 +74,18 @@ func (c *Contractor) persistData() contractorPersist {
 	for _, contract := range c.oldContracts {
 		data.OldContracts = append(data.OldContracts, contract)
 	}
+	for _, contract := range c.transferredContracts {
+		data.TransferredContracts = append(data.TransferredContracts, contract)
+	}
 	for fcID, height := range c.doubleSpentContracts {
 		data.DoubleSpentContracts[fcID.String()] = height
 	}
 	for _, contract := range c.recoverableContracts {
 		data.RecoverableContracts = append(data.RecoverableContracts, contract)
 	}
+	for _, contract := range c.unrecoverableContracts {
+		data.UnrecoverableContracts = append(data.UnrecoverableContracts, contract)
+	}
 	data.ChurnLimiter = c.staticChurnLimiter.callPersistData()
 	data.WatchdogData = c.staticWatchdog.callPersistData()
 	return data
@@ -138,6 +146,9 @@ func (c *Contractor) load() error {
 	for _, contract := range data.OldContracts {
 		c.oldContracts[contract.ID] = contract
 	}
+	for _, contract := range data.TransferredContracts {
+		c.transferredContracts[contract.ID] = contract
+	}
 	for fcIDString, height := range data.DoubleSpentContracts {
 		if err := fcid.LoadString(fcIDString); err != nil {
 			return err
@@ -147,6 +158,9 @@ func (c *Contractor) load() error {
 	for _, contract := range data.RecoverableContracts {
 		c.recoverableContracts[contract.ID] = contract
 	}
+	for _, contract := range data.UnrecoverableContracts {
+		c.unrecoverableContracts[contract.ID] = contract
+	}
 
 	c.staticChurnLimiter = newChurnLimiterFromPersist(c, data.ChurnLimiter)
 
@@ -88,11 +88,21 @@ func TestSaveLoad(t *testing.T) {
 	c.renewedTo = map[types.FileContractID]types.FileContractID{
 		{1}: {2},
 	}
+	expectedUnrecoverable := modules.UnrecoverableContract{
+		ID:             types.FileContractID{3},
+		Reason:         "host is offline",
+		FailedAttempts: 2,
+	}
+	c.unrecoverableContracts = map[types.FileContractID]modules.UnrecoverableContract{
+		{3}: expectedUnrecoverable,
+	}
 	close(c.synced)
 
 	c.staticChurnLimiter = newChurnLimiter(c)
 	c.staticChurnLimiter.aggregateCurrentPeriodChurn = 123456
 	c.staticChurnLimiter.remainingChurnBudget = -789
+	c.staticChurnLimiter.currentPeriodContractChurn = 3
+	c.staticChurnLimiter.recentEvents = []modules.ContractorChurnEvent{{ContractID: types.FileContractID{4}, Size: 42, Reason: churnReasonBadScore}}
 
 	// save, clear, and reload
 	err := c.save()
@@ -102,6 +112,7 @@ func TestSaveLoad(t *testing.T) {
 	c.oldContracts = make(map[types.FileContractID]modules.RenterContract)
 	c.renewedFrom = make(map[types.FileContractID]types.FileContractID)
 	c.renewedTo = make(map[types.FileContractID]types.FileContractID)
+	c.unrecoverableContracts = make(map[types.FileContractID]modules.UnrecoverableContract)
 	err = c.load()
 	if err != nil {
 		t.Fatal(err)
@@ -120,6 +131,9 @@ func TestSaveLoad(t *testing.T) {
 	if c.renewedTo[types.FileContractID{1}] != id {
 		t.Fatal("renewedTo not restored properly:", c.renewedTo)
 	}
+	if !reflect.DeepEqual(c.unrecoverableContracts[types.FileContractID{3}], expectedUnrecoverable) {
+		t.Fatal("unrecoverableContracts not restored properly:", c.unrecoverableContracts)
+	}
 	select {
 	case <-c.synced:
 	default:
@@ -302,6 +316,13 @@ func TestSaveLoad(t *testing.T) {
 	if periodBudget != expectedPeriodBudget {
 		t.Fatal("Expected remainingChurnBudget", periodBudget)
 	}
+	status := c.ChurnStatus()
+	if status.CurrentPeriodContractChurn != 3 {
+		t.Fatal("Expected 3 churned contracts", status.CurrentPeriodContractChurn)
+	}
+	if len(status.RecentEvents) != 1 || status.RecentEvents[0].ContractID != (types.FileContractID{4}) || status.RecentEvents[0].Reason != churnReasonBadScore {
+		t.Fatal("Churn events not restored properly", status.RecentEvents)
+	}
 }
 
 // TestConvertPersist tests that contracts previously stored in the
@@ -12,6 +12,12 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+var (
+	// errContractExpiredBeforeRecovery is the reason for a contract not being
+	// recovered if its proof window ended before it could be recovered.
+	errContractExpiredBeforeRecovery = errors.New("contract expired before it could be recovered")
+)
+
 // TODO If we already have an active contract with a host for
 // which we also have a recoverable contract, we might want to
 // handle that somehow. For now we probably want to ignore a
@@ -158,6 +164,7 @@ func (c *Contractor) findRecoverableContracts(renterSeed modules.RenterSeed, b t
 				TxnFee:        txnFee,
 				StartHeight:   c.blockHeight - 1, // Assume that it takes 1 block to mine the contract
 			}
+			c.recoveryContractsFound++
 		}
 	}
 }
@@ -242,8 +249,11 @@ func (c *Contractor) callRecoverContracts() {
 	if c.staticDeps.Disrupt("DisableContractRecovery") {
 		return
 	}
-	// Get the wallet seed.
-	ws, _, err := c.wallet.PrimarySeed()
+	// Only one thread should recover contracts at a time.
+	c.recoverLock.Lock()
+	defer c.recoverLock.Unlock()
+	// Get the wallet's key seed.
+	ws, err := c.wallet.PrimaryKeySeed()
 	if err != nil {
 		c.log.Println("Can't recover contracts", err)
 		return
@@ -260,8 +270,10 @@ func (c *Contractor) callRecoverContracts() {
 	}
 	c.mu.RUnlock()
 
-	// Remember the deleted contracts.
+	// Remember the deleted contracts and why contracts failed to be
+	// recovered.
 	deleteContract := make([]bool, len(recoverableContracts))
+	recoveryErrs := make([]error, len(recoverableContracts))
 
 	// Try to recover the contracts in parallel.
 	var wg sync.WaitGroup
@@ -272,6 +284,7 @@ func (c *Contractor) callRecoverContracts() {
 			if blockHeight >= rc.WindowEnd {
 				// No need to recover a contract if we are beyond the WindowEnd.
 				deleteContract[j] = true
+				recoveryErrs[j] = errContractExpiredBeforeRecovery
 				c.log.Printf("Not recovering contract since the current blockheight %v is >= the WindowEnd %v: %v",
 					blockHeight, rc.WindowEnd, rc.ID)
 				return
@@ -287,6 +300,7 @@ func (c *Contractor) callRecoverContracts() {
 			// Recover contract.
 			err := c.managedRecoverContract(rc, ers, blockHeight)
 			if err != nil {
+				recoveryErrs[j] = err
 				c.log.Println("Failed to recover contract", rc.ID, err)
 				return
 			}
@@ -299,14 +313,22 @@ func (c *Contractor) callRecoverContracts() {
 	// Wait for the recovery to be done.
 	wg.Wait()
 
-	// Delete the contracts.
+	// Delete the contracts and remember the ones that failed to be
+	// recovered.
 	c.mu.Lock()
 	for i, rc := range recoverableContracts {
+		if recoveryErrs[i] != nil {
+			c.updateUnrecoverableContract(rc, recoveryErrs[i], blockHeight)
+		} else if deleteContract[i] {
+			c.recoveryContractsRecovered++
+			delete(c.unrecoverableContracts, rc.ID)
+		}
 		if deleteContract[i] {
 			delete(c.recoverableContracts, rc.ID)
 			c.log.Println("Deleted contract from recoverable contracts:", rc.ID)
 		}
 	}
+	c.pruneUnrecoverableContracts(blockHeight)
 	err = c.save()
 	if err != nil {
 		c.log.Println("Unable to save while recovering contracts:", err)
@@ -324,6 +346,35 @@ func (c *Contractor) removeRecoverableContracts(b types.Block) {
 			// Delete the contract from the map since we no longer need to
 			// recover it.
 			delete(c.recoverableContracts, fcid)
+			delete(c.unrecoverableContracts, fcid)
+		}
+	}
+}
+
+// updateUnrecoverableContract records that the recovery of a recoverable
+// contract failed with the provided error.
+func (c *Contractor) updateUnrecoverableContract(rc modules.RecoverableContract, recoveryErr error, blockHeight types.BlockHeight) {
+	uc, exists := c.unrecoverableContracts[rc.ID]
+	if !exists {
+		uc = modules.UnrecoverableContract{
+			ID:            rc.ID,
+			HostPublicKey: rc.HostPublicKey,
+			WindowEnd:     rc.WindowEnd,
+		}
+	}
+	uc.Reason = recoveryErr.Error()
+	uc.FailedAttempts++
+	uc.LastAttemptHeight = blockHeight
+	uc.Expired = errors.Contains(recoveryErr, errContractExpiredBeforeRecovery)
+	c.unrecoverableContracts[rc.ID] = uc
+}
+
+// pruneUnrecoverableContracts removes the unrecoverable contracts that expired
+// more than unrecoverableContractRetention blocks ago.
+func (c *Contractor) pruneUnrecoverableContracts(blockHeight types.BlockHeight) {
+	for id, uc := range c.unrecoverableContracts {
+		if blockHeight >= uc.WindowEnd+unrecoverableContractRetention {
+			delete(c.unrecoverableContracts, id)
 		}
 	}
 }
@@ -0,0 +1,55 @@
+package contractor
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestUnrecoverableContracts tests that contracts which failed to be recovered
+// are tracked and pruned correctly.
+func TestUnrecoverableContracts(t *testing.T) {
+	// Create minimum Contractor
+	c := &Contractor{
+		unrecoverableContracts: make(map[types.FileContractID]modules.UnrecoverableContract),
+	}
+
+	rc1 := modules.RecoverableContract{ID: types.FileContractID{1}}
+	rc1.WindowEnd = 100
+	rc2 := modules.RecoverableContract{ID: types.FileContractID{2}}
+	rc2.WindowEnd = 200
+
+	// Fail to recover the first contract twice.
+	c.updateUnrecoverableContract(rc1, errors.New("host is offline"), 10)
+	c.updateUnrecoverableContract(rc1, errors.New("host is busy"), 20)
+	uc := c.UnrecoverableContracts()
+	if len(uc) != 1 {
+		t.Fatal("expected 1 unrecoverable contract but got", len(uc))
+	}
+	if uc[0].ID != rc1.ID || uc[0].WindowEnd != rc1.WindowEnd {
+		t.Fatal("wrong contract", uc[0])
+	}
+	if uc[0].Reason != "host is busy" || uc[0].FailedAttempts != 2 || uc[0].LastAttemptHeight != 20 || uc[0].Expired {
+		t.Fatal("wrong failure info", uc[0])
+	}
+
+	// The second contract expired.
+	c.updateUnrecoverableContract(rc2, errContractExpiredBeforeRecovery, 200)
+	if uc := c.unrecoverableContracts[rc2.ID]; !uc.Expired {
+		t.Fatal("contract should be marked expired", uc)
+	}
+
+	// Pruning right after the first contract expired shouldn't remove it.
+	c.pruneUnrecoverableContracts(rc1.WindowEnd)
+	if len(c.unrecoverableContracts) != 2 {
+		t.Fatal("no contract should have been pruned", len(c.unrecoverableContracts))
+	}
+	// After the retention only the second contract should remain.
+	c.pruneUnrecoverableContracts(rc1.WindowEnd + unrecoverableContractRetention)
+	if _, exists := c.unrecoverableContracts[rc1.ID]; exists || len(c.unrecoverableContracts) != 1 {
+		t.Fatal("first contract should have been pruned", c.unrecoverableContracts)
+	}
+}
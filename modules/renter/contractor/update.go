@@ -74,10 +74,10 @@ func (c *Contractor) managedArchiveContracts() {
 // ProcessConsensusChange will be called by the consensus set every time there
 // is a change in the blockchain. Updates will always be called in order.
 func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
-	// Get the wallet's seed for contract recovery.
+	// Get the wallet's key seed for contract recovery.
 	haveSeed := true
 	missedRecovery := false
-	s, _, err := c.wallet.PrimarySeed()
+	s, err := c.wallet.PrimaryKeySeed()
 	if err != nil {
 		haveSeed = false
 	}
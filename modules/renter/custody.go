@@ -0,0 +1,285 @@
+package renter
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// custodyContractsEntry is the name of the entry of a custody bundle which
+// contains the transferred contracts. Since it has no siafile extension, it
+// can't collide with the entries of the transferred siafiles.
+const custodyContractsEntry = "contracts"
+
+var (
+	// errCustodyPartialTransfer is returned if a transfer would leave files
+	// behind which depend on the transferred contracts.
+	errCustodyPartialTransfer = errors.New("all of the renter's contracts are transferred, but there are files outside of the transferred directory")
+
+	// errCustodyTransferPending is returned if custody is transferred while
+	// a previous transfer wasn't confirmed or cancelled yet.
+	errCustodyTransferPending = errors.New("a previous custody transfer wasn't confirmed or cancelled yet")
+
+	// errNoCustodyTransfer is returned if there is no custody transfer to
+	// confirm or cancel.
+	errNoCustodyTransfer = errors.New("there is no unconfirmed custody transfer")
+)
+
+// TransferCustody hands the siafiles within the directory at siaPath and all
+// of the renter's contracts over to the renter with the recipient's share
+// key. The custody bundle is written to dst. Since all contracts are handed
+// over, the directory has to contain all of the renter's files. Once the
+// bundle is complete, the renter can't use the transferred contracts anymore
+// but keeps the files and a copy of the contracts until the transfer is
+// confirmed with ConfirmCustodyTransfer or cancelled with
+// CancelCustodyTransfer. It returns the number of transferred files and
+// contracts.
+func (r *Renter) TransferCustody(siaPath modules.SiaPath, dst string, recipient crypto.X25519PublicKey) (_ modules.CustodyTransfer, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	pending := r.persist.CustodyTransfer != nil
+	r.mu.RUnlock(id)
+	if pending {
+		return modules.CustodyTransfer{}, errCustodyTransferPending
+	}
+
+	// Locked files would have to be deleted after the transfer.
+	if err := r.managedCheckDirTreeLocks(siaPath); err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	var mu sync.Mutex
+	var siaPaths []modules.SiaPath
+	var outside int
+	flf := func(fi modules.FileInfo) {
+		mu.Lock()
+		if siaPath.IsRoot() || strings.HasPrefix(fi.SiaPath.String(), siaPath.String()+"/") {
+			siaPaths = append(siaPaths, fi.SiaPath)
+		} else {
+			outside++
+		}
+		mu.Unlock()
+	}
+	if err := r.staticFileSystem.CachedList(modules.RootSiaPath(), true, flf, func(modules.DirectoryInfo) {}); err != nil {
+		return modules.CustodyTransfer{}, errors.AddContext(err, "failed to list the renter's files")
+	}
+	if outside > 0 {
+		return modules.CustodyTransfer{}, errors.AddContext(errCustodyPartialTransfer, fmt.Sprintf("%v files are outside of %v", outside, siaPath))
+	}
+
+	bw, err := newShareBundleWriter(dst, shareBundleTypeCustody, recipient, time.Time{})
+	if err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, bw.close())
+	}()
+	for _, fileSiaPath := range siaPaths {
+		relPath, err := fileSiaPath.Rebase(siaPath, modules.RootSiaPath())
+		if err != nil {
+			return modules.CustodyTransfer{}, err
+		}
+		if err := r.managedTarSharedSiaFile(bw.tw, fileSiaPath, relPath.String()+modules.SiaFileExtension); err != nil {
+			return modules.CustodyTransfer{}, errors.AddContext(err, fmt.Sprintf("failed to add %v to the bundle", fileSiaPath))
+		}
+	}
+
+	// Add the contracts last. They can't be used by the renter while the
+	// bundle is completed and until the transfer is cancelled.
+	var buf bytes.Buffer
+	numContracts, err := r.hostContractor.TransferContracts(&buf, func() error {
+		if err := bw.writeEntry(custodyContractsEntry, buf.Bytes()); err != nil {
+			return err
+		}
+		return bw.finish()
+	})
+	if err != nil {
+		return modules.CustodyTransfer{}, errors.AddContext(err, "failed to transfer contracts")
+	}
+	r.staticWorkerPool.callUpdate()
+
+	// Remember the transferred files to delete them once the transfer is
+	// confirmed.
+	id = r.mu.Lock()
+	r.persist.CustodyTransfer = siaPaths
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return modules.CustodyTransfer{}, errors.AddContext(err, "failed to persist the custody transfer")
+	}
+	return modules.CustodyTransfer{
+		Files:     len(siaPaths),
+		Contracts: numContracts,
+	}, nil
+}
+
+// ConfirmCustodyTransfer completes an unconfirmed custody transfer after the
+// recipient imported the custody bundle. The transferred contracts are
+// archived and the transferred files are deleted since the renter can't
+// maintain them without the contracts. It returns the number of deleted files
+// and archived contracts.
+func (r *Renter) ConfirmCustodyTransfer() (modules.CustodyTransfer, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	siaPaths := r.persist.CustodyTransfer
+	r.mu.RUnlock(id)
+	if siaPaths == nil {
+		return modules.CustodyTransfer{}, errNoCustodyTransfer
+	}
+	numContracts, err := r.hostContractor.ConfirmTransferredContracts()
+	if err != nil {
+		return modules.CustodyTransfer{}, errors.AddContext(err, "failed to archive the transferred contracts")
+	}
+
+	// The files were handed over. Delete them unless they were deleted in
+	// the meantime.
+	ct := modules.CustodyTransfer{Contracts: numContracts}
+	dirs := r.newUniqueRefreshPaths()
+	for _, siaPath := range siaPaths {
+		err := r.staticFileSystem.DeleteFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return ct, errors.AddContext(err, fmt.Sprintf("failed to delete transferred file %v", siaPath))
+		}
+		ct.Files++
+		dir, err := siaPath.Dir()
+		if err != nil {
+			return ct, err
+		}
+		if err := dirs.callAdd(dir); err != nil {
+			r.log.Println("WARN: failed to add the directory of a transferred file to be refreshed:", err)
+		}
+	}
+	if err := dirs.callRefreshAll(); err != nil {
+		r.log.Println("WARN: failed to refresh the directories of the transferred files:", err)
+	}
+
+	id = r.mu.Lock()
+	r.persist.CustodyTransfer = nil
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	return ct, err
+}
+
+// CancelCustodyTransfer cancels an unconfirmed custody transfer. The renter
+// restores the transferred contracts and keeps using them for its files. The
+// custody bundle of the transfer must not be imported afterwards. It returns
+// the number of restored contracts.
+func (r *Renter) CancelCustodyTransfer() (modules.CustodyTransfer, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	defer r.tg.Done()
+
+	id := r.mu.RLock()
+	pending := r.persist.CustodyTransfer != nil
+	r.mu.RUnlock(id)
+	if !pending {
+		return modules.CustodyTransfer{}, errNoCustodyTransfer
+	}
+	numContracts, err := r.hostContractor.CancelTransferredContracts()
+	if numContracts > 0 {
+		r.staticWorkerPool.callUpdate()
+	}
+	if err != nil {
+		return modules.CustodyTransfer{}, errors.AddContext(err, "failed to restore the transferred contracts")
+	}
+	id = r.mu.Lock()
+	r.persist.CustodyTransfer = nil
+	err = r.saveSync()
+	r.mu.Unlock(id)
+	return modules.CustodyTransfer{Contracts: numContracts}, err
+}
+
+// ImportCustody imports the siafiles and contracts of a custody bundle created
+// for the renter by TransferCustody. The siafiles are imported into the
+// directory at siaPath. Since the data of the files is stored with the
+// transferred contracts, nothing is imported if the renter already has one of
+// the contracts or a contract with one of their hosts, or if one of the files
+// already exists. It returns the number of imported files and contracts.
+func (r *Renter) ImportCustody(src string, siaPath modules.SiaPath) (modules.CustodyTransfer, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	defer r.tg.Done()
+
+	// Read the contracts and make sure that none of the files exist before
+	// importing anything.
+	var contracts []byte
+	err := r.managedReadShareBundle(src, shareBundleTypeCustody, func(header *tar.Header, tr *tar.Reader) error {
+		if header.Name == custodyContractsEntry {
+			b, err := ioutil.ReadAll(tr)
+			contracts = b
+			return errors.AddContext(err, "could not read contracts")
+		}
+		fileSiaPath, err := siaPathFromShareEntry(siaPath, header.Name)
+		if err != nil {
+			return err
+		}
+		exists, err := r.staticFileSystem.FileExists(fileSiaPath)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return errors.AddContext(filesystem.ErrExists, fmt.Sprintf("could not import %v", fileSiaPath))
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.CustodyTransfer{}, err
+	}
+	if contracts == nil {
+		return modules.CustodyTransfer{}, errors.New("custody bundle doesn't contain any contracts")
+	}
+
+	// Import the contracts first. If that fails, no file is imported.
+	var ct modules.CustodyTransfer
+	ct.Contracts, err = r.hostContractor.ImportTransferredContracts(bytes.NewReader(contracts))
+	// Update the workers to use the imported contracts.
+	if ct.Contracts > 0 {
+		r.staticWorkerPool.callUpdate()
+	}
+	if err != nil {
+		return ct, errors.AddContext(err, "could not import contracts")
+	}
+	err = r.managedReadShareBundle(src, shareBundleTypeCustody, func(header *tar.Header, tr *tar.Reader) error {
+		if header.Name == custodyContractsEntry {
+			return nil
+		}
+		fileSiaPath, err := siaPathFromShareEntry(siaPath, header.Name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.AddContext(err, "could not load the transferred file in memory")
+		}
+		if err := r.staticFileSystem.AddTransferredSiaFileFromReader(bytes.NewReader(b), fileSiaPath); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not add transferred file %v", fileSiaPath))
+		}
+		ct.Files++
+		return nil
+	})
+	if ct.Files > 0 {
+		_ = r.staticBubbleScheduler.callQueueBubble(siaPath)
+	}
+	return ct, err
+}
@@ -0,0 +1,170 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+)
+
+// TestCustody probes handing files over to another renter using a custody
+// bundle.
+func TestCustody(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a directory with a file and a nested file and a locked file in
+	// another directory.
+	path, err := rt.createZeroByteFileOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 1)
+	var siaPaths []modules.SiaPath
+	for _, s := range []string{"dir/file", "dir/sub/file", "locked/file"} {
+		siaPath, err := modules.NewSiaPath(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = rt.renter.staticFileSystem.NewSiaFile(siaPath, path, rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), modules.SectorSize, persist.DefaultDiskPermissionsTest, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	lockedUntil := time.Now().Add(time.Second)
+	if err := rt.renter.SetFileLock(siaPaths[2], lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+	shareKey, err := rt.renter.ShareKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Locked files can't be transferred.
+	lockedDir, err := siaPaths[2].Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(rt.dir, "locked.custody")
+	if _, err := rt.renter.TransferCustody(lockedDir, dst, shareKey); !errors.Contains(err, modules.ErrLocked) {
+		t.Fatal("expected ErrLocked, got", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("bundle of failed transfer was created", err)
+	}
+
+	// The directory can't be transferred while there are files outside of
+	// it.
+	dir, err := siaPaths[0].Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst = filepath.Join(rt.dir, "dir.custody")
+	if _, err := rt.renter.TransferCustody(dir, dst, shareKey); !errors.Contains(err, errCustodyPartialTransfer) {
+		t.Fatal("expected errCustodyPartialTransfer, got", err)
+	}
+	time.Sleep(time.Until(lockedUntil))
+	if err := rt.renter.DeleteFile(siaPaths[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Transfer the directory. The files are kept until the transfer is
+	// confirmed and there can't be another transfer in the meantime.
+	ct, err := rt.renter.TransferCustody(dir, dst, shareKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Files != 2 {
+		t.Fatal("unexpected transfer", ct)
+	}
+	for _, siaPath := range siaPaths[:2] {
+		if _, err := rt.renter.File(siaPath); err != nil {
+			t.Fatal("transferred file was deleted before the transfer was confirmed", siaPath)
+		}
+	}
+	if _, err := rt.renter.TransferCustody(dir, filepath.Join(rt.dir, "pending.custody"), shareKey); !errors.Contains(err, errCustodyTransferPending) {
+		t.Fatal("expected errCustodyTransferPending, got", err)
+	}
+
+	// Cancel the transfer and transfer the directory again. The files are
+	// deleted once the transfer is confirmed.
+	if _, err := rt.renter.CancelCustodyTransfer(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.ConfirmCustodyTransfer(); !errors.Contains(err, errNoCustodyTransfer) {
+		t.Fatal("expected errNoCustodyTransfer, got", err)
+	}
+	dst = filepath.Join(rt.dir, "dir2.custody")
+	if _, err := rt.renter.TransferCustody(dir, dst, shareKey); err != nil {
+		t.Fatal(err)
+	}
+	ct, err = rt.renter.ConfirmCustodyTransfer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Files != 2 {
+		t.Fatal("unexpected confirmation", ct)
+	}
+	for _, siaPath := range siaPaths[:2] {
+		if _, err := rt.renter.File(siaPath); err == nil {
+			t.Fatal("transferred file wasn't deleted", siaPath)
+		}
+	}
+
+	// A custody bundle can't be imported as a share.
+	if _, err := rt.renter.ImportShare(dst, modules.RootSiaPath()); err == nil {
+		t.Fatal("expected importing a custody bundle as a share to fail")
+	}
+
+	// Import the bundle into another directory. The directory structure is
+	// kept.
+	importDir, err := modules.NewSiaPath("imported")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err = rt.renter.ImportCustody(dst, importDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Files != 2 {
+		t.Fatal("unexpected import", ct)
+	}
+	for _, s := range []string{"file", "sub/file"} {
+		siaPath, err := importDir.Join(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, err := rt.renter.File(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.LocalPath != "" {
+			t.Fatal("local path was transferred", fi.LocalPath)
+		}
+	}
+
+	// Importing the bundle again fails since the files exist.
+	if _, err := rt.renter.ImportCustody(dst, importDir); !errors.Contains(err, filesystem.ErrExists) {
+		t.Fatal("expected ErrExists, got", err)
+	}
+}
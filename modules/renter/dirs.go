@@ -1,12 +1,16 @@
 package renter
 
 import (
+	"fmt"
 	"os"
 	"sort"
 	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 )
 
 // CreateDir creates a directory for the renter
@@ -16,6 +20,7 @@ func (r *Renter) CreateDir(siaPath modules.SiaPath, mode os.FileMode) error {
 		return err
 	}
 	defer r.tg.Done()
+	defer r.staticBubbleScheduler.callInvalidateChildMetadatas(siaPath)
 	return r.staticFileSystem.NewSiaDir(siaPath, mode)
 }
 
@@ -26,6 +31,10 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	if err := r.managedCheckDirTreeLocks(siaPath); err != nil {
+		return err
+	}
+	defer r.staticBubbleScheduler.callInvalidateChildMetadatas(siaPath)
 	return r.staticFileSystem.DeleteDir(siaPath)
 }
 
@@ -56,6 +65,202 @@ func (r *Renter) managedDirList(siaPath modules.SiaPath) (dis []modules.Director
 	return dis, nil
 }
 
+// SetDirQuota sets the maximum size of the files in a directory and its sub
+// directories. A quota of 0 removes the quota.
+func (r *Renter) SetDirQuota(siaPath modules.SiaPath, quota uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.SetQuota(quota)
+}
+
+// managedDirMetadata returns the metadata of a directory.
+func (r *Renter) managedDirMetadata(siaPath modules.SiaPath) (_ siadir.Metadata, err error) {
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return siadir.Metadata{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.Metadata()
+}
+
+// managedCheckDirQuotas returns modules.ErrDirQuotaExceeded if adding a file of
+// the given size at siaPath would exceed the quota of its directory or of any
+// of its parents. If the file replaces an existing file, the size of the
+// existing file isn't counted. The usage of the directories is their
+// AggregateSize as of their last bubble.
+func (r *Renter) managedCheckDirQuotas(siaPath modules.SiaPath, size uint64, replace bool) error {
+	var replacedSize uint64
+	if replace {
+		if fi, err := r.staticFileSystem.CachedFileInfo(siaPath); err == nil {
+			replacedSize = fi.Filesize
+		}
+	}
+	dir, err := siaPath.Dir()
+	for err == nil {
+		var md siadir.Metadata
+		md, err = r.managedDirMetadata(dir)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			// The dir will be created by the upload.
+		} else if err != nil {
+			return errors.AddContext(err, "unable to get the quota of "+dir.String())
+		} else if md.Quota > 0 {
+			usage := md.AggregateSize
+			if usage >= replacedSize {
+				usage -= replacedSize
+			}
+			if usage+size > md.Quota {
+				return errors.AddContext(modules.ErrDirQuotaExceeded, fmt.Sprintf("%v uses %v of %v bytes", dir, usage, md.Quota))
+			}
+		}
+		if dir.IsRoot() {
+			return nil
+		}
+		dir, err = dir.Dir()
+	}
+	return err
+}
+
+// SetDirLock locks a directory and its contents against deletion and
+// modification until lockedUntil. An active lock can only be extended.
+func (r *Renter) SetDirLock(siaPath modules.SiaPath, lockedUntil time.Time) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.SetLockedUntil(lockedUntil)
+}
+
+// managedCheckDirLocks returns modules.ErrLocked if the directory at siaPath
+// or any of its parents is locked.
+func (r *Renter) managedCheckDirLocks(siaPath modules.SiaPath) error {
+	now := time.Now()
+	dir := siaPath
+	for {
+		md, err := r.managedDirMetadata(dir)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			// The dir doesn't exist yet.
+		} else if err != nil {
+			return errors.AddContext(err, "unable to get the lock of "+dir.String())
+		} else if now.Before(md.LockedUntil) {
+			return errors.AddContext(modules.ErrLocked, fmt.Sprintf("%v is locked until %v", dir, md.LockedUntil))
+		}
+		if dir.IsRoot() {
+			return nil
+		}
+		dir, err = dir.Dir()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// managedCheckDirTreeLocks returns modules.ErrLocked if the directory at
+// siaPath, any of its parents or anything within it is locked.
+func (r *Renter) managedCheckDirTreeLocks(siaPath modules.SiaPath) error {
+	if err := r.managedCheckDirLocks(siaPath); err != nil {
+		return err
+	}
+	now := time.Now()
+	var mu sync.Mutex
+	var locked []modules.SiaPath
+	flf := func(fi modules.FileInfo) {
+		if now.Before(fi.LockedUntil) {
+			mu.Lock()
+			locked = append(locked, fi.SiaPath)
+			mu.Unlock()
+		}
+	}
+	dlf := func(di modules.DirectoryInfo) {
+		if now.Before(di.LockedUntil) {
+			mu.Lock()
+			locked = append(locked, di.SiaPath)
+			mu.Unlock()
+		}
+	}
+	if err := r.staticFileSystem.CachedList(siaPath, true, flf, dlf); err != nil {
+		return errors.AddContext(err, "unable to get the locks within "+siaPath.String())
+	}
+	if len(locked) > 0 {
+		return errors.AddContext(modules.ErrLocked, fmt.Sprintf("%v contains %v locked files and directories", siaPath, len(locked)))
+	}
+	return nil
+}
+
+// managedCheckFileLocks returns modules.ErrLocked if the file at siaPath or
+// any of its parent directories is locked.
+func (r *Renter) managedCheckFileLocks(siaPath modules.SiaPath) error {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err == nil {
+		lockedUntil := entry.LockedUntil()
+		if err := entry.Close(); err != nil {
+			return err
+		}
+		if time.Now().Before(lockedUntil) {
+			return errors.AddContext(modules.ErrLocked, fmt.Sprintf("%v is locked until %v", siaPath, lockedUntil))
+		}
+	} else if !errors.Contains(err, filesystem.ErrNotExist) {
+		return err
+	}
+	dir, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	return r.managedCheckDirLocks(dir)
+}
+
+// managedCheckFileReadOnly returns modules.ErrReadOnly if the file at siaPath
+// was shared by another renter.
+func (r *Renter) managedCheckFileReadOnly(siaPath modules.SiaPath) error {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	readOnly := entry.ReadOnly()
+	if err := entry.Close(); err != nil {
+		return err
+	}
+	if readOnly {
+		return errors.AddContext(modules.ErrReadOnly, siaPath.String())
+	}
+	return nil
+}
+
+// SetDirTags replaces the user defined tags of a directory.
+func (r *Renter) SetDirTags(siaPath modules.SiaPath, tags map[string]string) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.SetTags(tags)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
@@ -69,5 +274,10 @@ func (r *Renter) RenameDir(oldPath, newPath modules.SiaPath) error {
 	if newPath.IsRoot() {
 		return errors.New("cannot rename a file to the root directory")
 	}
+	if err := r.managedCheckDirTreeLocks(oldPath); err != nil {
+		return err
+	}
+	defer r.staticBubbleScheduler.callInvalidateChildMetadatas(newPath)
+	defer r.staticBubbleScheduler.callInvalidateChildMetadatas(oldPath)
 	return r.staticFileSystem.RenameDir(oldPath, newPath)
 }
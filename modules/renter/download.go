@@ -269,6 +269,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return nil, errors.New("destination must be an absolute path")
 	}
+	if p.Overdrive > maxDownloadOverdrive {
+		return nil, errOverdriveTooHigh
+	}
+	if entry.MasterKeyID() != "" {
+		if err := entry.VerifyMasterKey(p.CipherKey); err != nil {
+			return nil, err
+		}
+	} else if p.CipherKey != nil {
+		return nil, errors.New("a key can only be provided for files which are encrypted with an external key")
+	}
 	if p.Offset == entry.Size() && entry.Size() != 0 {
 		return nil, errors.New("offset equals filesize")
 	}
@@ -317,6 +327,9 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if err != nil {
 		return nil, err
 	}
+	if p.CipherKey != nil {
+		snap = snap.WithMasterKey(p.CipherKey)
+	}
 	// Create the download object.
 	d, err := r.managedNewDownload(downloadParams{
 		destination:       dw,
@@ -329,7 +342,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		length:        p.Length,
 		needsMemory:   true,
 		offset:        p.Offset,
-		overdrive:     3, // TODO: moderate default until full overdrive support is added.
+		overdrive:     r.managedDownloadOverdrive(p.Overdrive, false),
 		priority:      5, // TODO: moderate default until full priority support is added.
 
 		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
@@ -382,6 +395,9 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	if params.offset+params.length > params.file.Size() {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
+	if params.file.MasterKey() == nil {
+		return nil, siafile.ErrMasterKeyRequired
+	}
 
 	// Create the download object.
 	d := &download{
@@ -470,12 +486,17 @@ func (d *download) Start() error {
 
 	// Queue the downloads for each chunk.
 	writeOffset := int64(0) // where to write a chunk within the download destination.
+	var zeroPiece []byte    // shared by the holes of a sparse file.
 	d.chunksRemaining += maxChunk - minChunk + 1
 	for i := minChunk; i <= maxChunk; i++ {
+		masterKey, err := params.file.ChunkMasterKey(i)
+		if err != nil {
+			return errors.AddContext(err, "failed to get master key of chunk")
+		}
 		udc := &unfinishedDownloadChunk{
 			destination: params.destination,
 			erasureCode: params.file.ErasureCode(),
-			masterKey:   params.file.MasterKey(),
+			masterKey:   masterKey,
 
 			staticChunkIndex: i,
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticSiaPath, i),
@@ -532,6 +553,16 @@ func (d *download) Start() error {
 		// and once we can assign overdrive dynamically.
 		udc.staticOverdrive = params.overdrive
 
+		// Holes of sparse files only contain zeros and don't need to be
+		// downloaded.
+		if params.file.IsHole(i) {
+			if zeroPiece == nil {
+				zeroPiece = make([]byte, params.file.PieceSize())
+			}
+			go udc.threadedWriteHole(zeroPiece)
+			continue
+		}
+
 		// Add this chunk to the chunk heap, and notify the download loop that
 		// there is work to do.
 		d.r.managedAddChunkToDownloadHeap(udc)
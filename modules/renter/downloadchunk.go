@@ -3,6 +3,7 @@ package renter
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -239,6 +240,31 @@ func (udc *unfinishedDownloadChunk) threadedRecoverLogicalData() error {
 	return nil
 }
 
+// threadedWriteHole writes the zeros of a chunk which is a hole of a sparse
+// file to the download destination. Nothing needs to be downloaded from the
+// hosts for a hole. The zeroPiece is shared between holes and must not be
+// modified.
+func (udc *unfinishedDownloadChunk) threadedWriteHole(zeroPiece []byte) {
+	if err := udc.download.r.tg.Add(); err != nil {
+		return
+	}
+	defer udc.download.r.tg.Done()
+
+	pieces := make([][]byte, udc.erasureCode.NumPieces())
+	for i := 0; i < udc.erasureCode.MinPieces(); i++ {
+		pieces[i] = zeroPiece
+	}
+	err := udc.destination.WritePieces(udc.erasureCode, pieces, 0, udc.staticWriteOffset, udc.staticFetchLength)
+	if err != nil {
+		udc.mu.Lock()
+		udc.fail(errors.AddContext(err, "unable to write hole to download destination"))
+		udc.mu.Unlock()
+		return
+	}
+	atomic.AddUint64(&udc.download.atomicDataReceived, udc.staticFetchLength)
+	udc.managedFinalizeRecovery()
+}
+
 // bytesToRecover returns the number of bytes we need to recover from the
 // erasure coded segments. The number of bytes we need to recover doesn't
 // always match the chunkFetchLength. e.g. a user might want to fetch 500 bytes
@@ -0,0 +1,119 @@
+package renter
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// defaultDownloadOverdrive is the number of extra pieces that are fetched
+	// for a download if the renter's settings don't specify otherwise.
+	defaultDownloadOverdrive = 3
+
+	// defaultStreamOverdrive is the number of extra pieces that are fetched for
+	// a stream if the renter's settings don't specify otherwise. Streams are
+	// more latency sensitive than downloads and therefore have a higher
+	// overdrive.
+	defaultStreamOverdrive = 5
+
+	// maxDownloadOverdrive is the highest overdrive that can be used for a
+	// download. Every overdrive piece requires additional memory and
+	// bandwidth.
+	maxDownloadOverdrive = 20
+
+	// adaptiveOverdriveMinSamples is the number of recent read job times that
+	// need to be available before the adaptive overdrive policy increases the
+	// overdrive of a download.
+	adaptiveOverdriveMinSamples = 10
+
+	// adaptiveOverdriveVariationStep is the increase in the coefficient of
+	// variation of the recent read job times that causes the adaptive
+	// overdrive policy to fetch another extra piece.
+	adaptiveOverdriveVariationStep = 0.5
+
+	// maxAdaptiveOverdrive is the maximum number of extra pieces that the
+	// adaptive overdrive policy adds to the overdrive of a download.
+	maxAdaptiveOverdrive = 3
+)
+
+var (
+	// errOverdriveTooHigh is returned if an overdrive higher than
+	// maxDownloadOverdrive is requested.
+	errOverdriveTooHigh = fmt.Errorf("overdrive can't be higher than %v", maxDownloadOverdrive)
+)
+
+// adaptiveOverdrive returns the number of extra pieces that should be added to
+// the overdrive of a download given the recent read job times of the workers.
+// The more the job times vary, the more likely it is that a download waits for
+// a slow host, which is prevented by fetching more pieces in parallel.
+func adaptiveOverdrive(jobTimes []time.Duration) int {
+	if len(jobTimes) < adaptiveOverdriveMinSamples {
+		return 0
+	}
+	extra := int(latencyVariation(jobTimes) / adaptiveOverdriveVariationStep)
+	if extra > maxAdaptiveOverdrive {
+		extra = maxAdaptiveOverdrive
+	}
+	return extra
+}
+
+// latencyVariation returns the coefficient of variation, the standard deviation
+// divided by the mean, of the provided job times.
+func latencyVariation(jobTimes []time.Duration) float64 {
+	if len(jobTimes) == 0 {
+		return 0
+	}
+	var mean float64
+	for _, jobTime := range jobTimes {
+		mean += float64(jobTime)
+	}
+	mean /= float64(len(jobTimes))
+	if mean == 0 {
+		return 0
+	}
+	var variance float64
+	for _, jobTime := range jobTimes {
+		variance += (float64(jobTime) - mean) * (float64(jobTime) - mean)
+	}
+	variance /= float64(len(jobTimes))
+	return math.Sqrt(variance) / mean
+}
+
+// overdriveSetting returns the overdrive of a setting or the provided default
+// if the setting isn't set.
+func overdriveSetting(setting uint64, def int) int {
+	if setting == 0 {
+		return def
+	}
+	return int(setting)
+}
+
+// managedRecentReadJobTimes returns the recent read job times of all workers.
+func (r *Renter) managedRecentReadJobTimes() []time.Duration {
+	var jobTimes []time.Duration
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		jobTimes = append(jobTimes, w.staticJobReadQueue.callStatus().recentJobTimes...)
+	}
+	return jobTimes
+}
+
+// managedDownloadOverdrive returns the overdrive of a download. A non-zero
+// override is used as is. Otherwise the overdrive from the renter's settings is
+// used, which is increased by the adaptive overdrive policy if enabled.
+func (r *Renter) managedDownloadOverdrive(override uint64, stream bool) int {
+	if override > 0 {
+		return int(override)
+	}
+	id := r.mu.RLock()
+	overdrive := overdriveSetting(r.persist.DownloadOverdrive, defaultDownloadOverdrive)
+	if stream {
+		overdrive = overdriveSetting(r.persist.StreamOverdrive, defaultStreamOverdrive)
+	}
+	adaptive := r.persist.AdaptiveOverdrive
+	r.mu.RUnlock(id)
+	if adaptive {
+		overdrive += adaptiveOverdrive(r.managedRecentReadJobTimes())
+	}
+	return overdrive
+}
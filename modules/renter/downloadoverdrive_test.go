@@ -0,0 +1,62 @@
+package renter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestAdaptiveOverdrive is a unit test for the adaptive overdrive policy.
+func TestAdaptiveOverdrive(t *testing.T) {
+	t.Parallel()
+
+	// Consistent job times don't vary.
+	consistent := make([]time.Duration, adaptiveOverdriveMinSamples)
+	for i := range consistent {
+		consistent[i] = 100 * time.Millisecond
+	}
+	if v := latencyVariation(consistent); v != 0 {
+		t.Fatal("expected no variation but got", v)
+	}
+	if extra := adaptiveOverdrive(consistent); extra != 0 {
+		t.Fatal("expected no extra overdrive but got", extra)
+	}
+
+	// Half of the jobs taking 3 times as long results in a coefficient of
+	// variation of 0.5 and one extra piece.
+	varying := make([]time.Duration, adaptiveOverdriveMinSamples)
+	for i := range varying {
+		varying[i] = 100 * time.Millisecond
+		if i%2 == 0 {
+			varying[i] = 300 * time.Millisecond
+		}
+	}
+	if v := latencyVariation(varying); math.Abs(v-0.5) > 1e-9 {
+		t.Fatal("expected a variation of 0.5 but got", v)
+	}
+	if extra := adaptiveOverdrive(varying); extra != 1 {
+		t.Fatal("expected one extra piece but got", extra)
+	}
+
+	// Not enough samples don't increase the overdrive.
+	if extra := adaptiveOverdrive(varying[:adaptiveOverdriveMinSamples-1]); extra != 0 {
+		t.Fatal("expected no extra overdrive but got", extra)
+	}
+
+	// A few very slow jobs don't increase the overdrive beyond the max.
+	varying[0] = time.Minute
+	if extra := adaptiveOverdrive(varying); extra != maxAdaptiveOverdrive {
+		t.Fatalf("expected %v extra pieces but got %v", maxAdaptiveOverdrive, extra)
+	}
+}
+
+// TestOverdriveSetting is a unit test for overdriveSetting.
+func TestOverdriveSetting(t *testing.T) {
+	t.Parallel()
+	if od := overdriveSetting(0, defaultDownloadOverdrive); od != defaultDownloadOverdrive {
+		t.Fatal("expected default overdrive but got", od)
+	}
+	if od := overdriveSetting(7, defaultDownloadOverdrive); od != 7 {
+		t.Fatal("expected overdrive of 7 but got", od)
+	}
+}
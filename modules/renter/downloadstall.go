@@ -0,0 +1,104 @@
+package renter
+
+// downloadstall.go contains the watchdog which detects stalled piece downloads.
+// A piece is fetched by a single read job, so there is no partial progress to
+// observe. A piece which doesn't arrive within the stall timeout is considered
+// stalled. The stall is recorded for the host and, as soon as another worker
+// is on standby for the chunk, the read is canceled. Canceling the read
+// unregisters the stalled worker from the chunk, which pulls in the standby
+// workers to fetch a different piece instead. This prevents a single hanging
+// host from keeping a download at 99% forever.
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+var (
+	// defaultDownloadStallTimeout is the time after which a piece download is
+	// considered stalled if the renter's settings don't specify a timeout.
+	defaultDownloadStallTimeout = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+)
+
+var (
+	// errDownloadStallTimeoutNegative is returned if the download stall
+	// timeout is negative.
+	errDownloadStallTimeoutNegative = errors.New("download stall timeout can't be negative")
+)
+
+// managedDownloadStallTimeout returns the time after which a piece download is
+// considered stalled.
+func (r *Renter) managedDownloadStallTimeout() time.Duration {
+	id := r.mu.RLock()
+	timeout := r.persist.DownloadStallTimeout
+	r.mu.RUnlock(id)
+	if timeout == 0 {
+		return defaultDownloadStallTimeout
+	}
+	return timeout
+}
+
+// threadedWatchPieceDownload watches the download of the worker's piece of the
+// chunk until done is closed. Once the piece is stalled, the stall is recorded
+// and the download is canceled as soon as there is a standby worker which can
+// take over.
+func (w *worker) threadedWatchPieceDownload(udc *unfinishedDownloadChunk, cancel context.CancelFunc, done <-chan struct{}) {
+	if err := w.renter.tg.Add(); err != nil {
+		return
+	}
+	defer w.renter.tg.Done()
+
+	ticker := time.NewTicker(w.renter.managedDownloadStallTimeout())
+	defer ticker.Stop()
+	stalled := false
+	for {
+		select {
+		case <-done:
+			return
+		case <-w.renter.tg.StopChan():
+			return
+		case <-ticker.C:
+		}
+
+		// Nothing needs to be reissued if the chunk doesn't need the piece
+		// anymore.
+		udc.mu.Lock()
+		chunkDone := udc.failed || udc.piecesCompleted >= udc.erasureCode.MinPieces()
+		replacementAvailable := len(udc.workersStandby) > 0
+		udc.mu.Unlock()
+		if chunkDone {
+			return
+		}
+
+		// Record the stall once per piece.
+		if !stalled {
+			stalled = true
+			w.managedRecordDownloadStall()
+		}
+		if replacementAvailable {
+			w.renter.log.Debugf("Worker %v: canceling stalled download of chunk %v", w.staticHostPubKeyStr, udc.staticChunkIndex)
+			cancel()
+			return
+		}
+	}
+}
+
+// managedRecordDownloadStall records a stalled piece download of the worker's
+// host. The stall counts as a failed interaction with the host which lowers
+// its score.
+func (w *worker) managedRecordDownloadStall() {
+	atomic.AddUint64(&w.atomicDownloadStalls, 1)
+	if err := w.renter.hostDB.IncrementFailedInteractions(w.staticHostPubKey); err != nil {
+		w.renter.log.Debugf("Worker %v: failed to record download stall: %v", w.staticHostPubKeyStr, err)
+	}
+}
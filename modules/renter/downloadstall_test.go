@@ -0,0 +1,92 @@
+package renter
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestWatchPieceDownload is a unit test for threadedWatchPieceDownload.
+func TestWatchPieceDownload(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Use a short stall timeout. A negative one should be rejected.
+	settings, err := wt.renter.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.DownloadStallTimeout = -time.Second
+	if err := wt.renter.SetSettings(settings); !errors.Contains(err, errDownloadStallTimeoutNegative) {
+		t.Fatal("unexpected error", err)
+	}
+	timeout := 100 * time.Millisecond
+	settings.DownloadStallTimeout = timeout
+	if err := wt.renter.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	newUDC := func() *unfinishedDownloadChunk {
+		return &unfinishedDownloadChunk{
+			erasureCode: modules.NewRSCodeDefault(),
+		}
+	}
+
+	// A piece which arrives in time isn't a stall.
+	udc := newUDC()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go wt.threadedWatchPieceDownload(udc, cancel, done)
+	close(done)
+	time.Sleep(2 * timeout)
+	if ctx.Err() != nil || atomic.LoadUint64(&wt.atomicDownloadStalls) != 0 {
+		t.Fatal("download shouldn't be stalled", ctx.Err())
+	}
+
+	// A stalled piece is recorded but not canceled while no other worker can
+	// take over.
+	udc = newUDC()
+	ctx, cancel = context.WithCancel(context.Background())
+	done = make(chan struct{})
+	defer close(done)
+	go wt.threadedWatchPieceDownload(udc, cancel, done)
+	time.Sleep(3 * timeout)
+	if ctx.Err() != nil {
+		t.Fatal("download shouldn't be canceled without a standby worker")
+	}
+	if stalls := atomic.LoadUint64(&wt.atomicDownloadStalls); stalls != 1 {
+		t.Fatal("expected 1 stall but got", stalls)
+	}
+	if status := wt.callStatus(); status.DownloadStalls != 1 {
+		t.Fatal("expected status to report 1 stall but got", status.DownloadStalls)
+	}
+
+	// Once a standby worker is available, the download is canceled.
+	udc.mu.Lock()
+	udc.workersStandby = append(udc.workersStandby, wt.worker)
+	udc.mu.Unlock()
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * timeout):
+		t.Fatal("stalled download wasn't canceled")
+	}
+	if stalls := atomic.LoadUint64(&wt.atomicDownloadStalls); stalls != 1 {
+		t.Fatal("stall should only be recorded once but got", stalls)
+	}
+}
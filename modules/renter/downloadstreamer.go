@@ -60,6 +60,7 @@ type (
 		cacheOffset             int64
 		cacheReady              chan struct{}
 		staticDisableLocalFetch bool
+		staticOverdrive         uint64
 		readErr                 error
 		targetCacheSize         int64
 
@@ -180,7 +181,7 @@ func (s *streamer) managedFillCache() bool {
 		length:        uint64(fetchLen),
 		needsMemory:   true,
 		offset:        uint64(fetchOffset),
-		overdrive:     5,    // TODO: high default until full overdrive support is added.
+		overdrive:     s.r.managedDownloadOverdrive(s.staticOverdrive, true),
 		priority:      1000, // TODO: high default until full priority support is added.
 
 		staticMemoryManager:    s.r.userDownloadMemoryManager, // user initiated download
@@ -472,11 +473,14 @@ func (s *streamer) Seek(offset int64, whence int) (int64, error) {
 
 // Streamer creates a modules.Streamer that can be used to stream downloads from
 // the sia network.
-func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (_ string, _ modules.Streamer, err error) {
+func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool, overdrive uint64) (_ string, _ modules.Streamer, err error) {
 	if err := r.tg.Add(); err != nil {
 		return "", nil, err
 	}
 	defer r.tg.Done()
+	if overdrive > maxDownloadOverdrive {
+		return "", nil, errOverdriveTooHigh
+	}
 
 	// Lookup the file associated with the nickname.
 	node, err := r.staticFileSystem.OpenSiaFile(siaPath)
@@ -492,7 +496,7 @@ func (r *Renter) Streamer(siaPath modules.SiaPath, disableLocalFetch bool) (_ st
 	if err != nil {
 		return "", nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
+	s := r.managedStreamer(snap, disableLocalFetch, overdrive)
 	return siaPath.String(), s, nil
 }
 
@@ -511,13 +515,14 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 	if err != nil {
 		return nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
+	s := r.managedStreamer(snap, disableLocalFetch, 0)
 	return s, nil
 }
 
 // managedStreamer creates a streamer from a siafile snapshot and starts filling
-// its cache.
-func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool) modules.Streamer {
+// its cache. A non-zero overdrive overrides the stream overdrive of the
+// renter's settings.
+func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool, overdrive uint64) modules.Streamer {
 	s := &streamer{
 		staticFile: snapshot,
 		r:          r,
@@ -525,6 +530,7 @@ func (r *Renter) managedStreamer(snapshot *siafile.Snapshot, disableLocalFetch b
 		activateCache:           make(chan struct{}),
 		cacheReady:              make(chan struct{}),
 		staticDisableLocalFetch: disableLocalFetch,
+		staticOverdrive:         overdrive,
 		targetCacheSize:         initialStreamerCacheSize,
 	}
 	go s.threadedFillCache()
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"fmt"
+	"time"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -18,6 +19,11 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// Locked files can't be deleted.
+	if err := r.managedCheckFileLocks(siaPath); err != nil {
+		return err
+	}
+
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
 	if err != nil {
@@ -97,6 +103,14 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// Locked and read-only files can't be moved.
+	if err := r.managedCheckFileLocks(currentName); err != nil {
+		return err
+	}
+	if err := r.managedCheckFileReadOnly(currentName); err != nil {
+		return err
+	}
+
 	// Rename file.
 	err := r.staticFileSystem.RenameFile(currentName, newName)
 	if err != nil {
@@ -125,6 +139,47 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	return bubblePaths.callRefreshAll()
 }
 
+// ConcatFiles creates a new file at siaPath which consists of the parts in
+// order and deletes the parts. The parts are combined at the metadata level
+// which means that no data needs to be uploaded again.
+func (r *Renter) ConcatFiles(siaPath modules.SiaPath, parts []modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Locked files can't be deleted and read-only files can't be modified.
+	for _, part := range parts {
+		if err := r.managedCheckFileLocks(part); err != nil {
+			return err
+		}
+		if err := r.managedCheckFileReadOnly(part); err != nil {
+			return err
+		}
+	}
+
+	// Concatenate the files.
+	err := r.staticFileSystem.ConcatFiles(siaPath, parts)
+	if err != nil {
+		return err
+	}
+
+	// Call callThreadedBubbleMetadata on the directories of the parts and the
+	// new file to make sure the system metadata is updated.
+	bubblePaths := r.newUniqueRefreshPaths()
+	for _, sp := range append([]modules.SiaPath{siaPath}, parts...) {
+		dirSiaPath, err := sp.Dir()
+		if err != nil {
+			return err
+		}
+		err = bubblePaths.callAdd(dirSiaPath)
+		if err != nil {
+			r.log.Printf("failed to add directory '%v' to bubble paths:  %v", dirSiaPath, err)
+		}
+	}
+	return bubblePaths.callRefreshAll()
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
@@ -143,6 +198,41 @@ func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	return entry.SetAllStuck(stuck)
 }
 
+// SetFileLock locks a file against deletion and modification until
+// lockedUntil. An active lock can only be extended.
+func (r *Renter) SetFileLock(siaPath modules.SiaPath, lockedUntil time.Time) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	return entry.SetLockedUntil(lockedUntil)
+}
+
+// SetFileTags replaces the user defined tags of a file.
+func (r *Renter) SetFileTags(siaPath modules.SiaPath, tags map[string]string) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	// Open the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	// Update the file.
+	return entry.SetTags(tags)
+}
+
 func (r *Renter) FileHosts(sp modules.SiaPath) (hosts []modules.HostDBEntry, _ error) {
 	// open the file
 	entry, err := r.staticFileSystem.OpenSiaFile(sp)
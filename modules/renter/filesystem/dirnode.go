@@ -29,6 +29,19 @@ type (
 		// only be loaded on demand and destroyed as soon as the length of
 		// 'threads' reaches 0.
 		lazySiaDir **siadir.SiaDir
+
+		// staticMetadataCache caches the metadata of the siafiles for
+		// listings and bubbles. It is shared by all the nodes of a
+		// FileSystem.
+		staticMetadataCache *metadataCache
+	}
+
+	// fileLoad is a job for the file workers of managedList. It either
+	// contains the cached metadata of a file or a method to load the file.
+	fileLoad struct {
+		path   string
+		cached *CachedFileMetadata
+		load   func() (*FileNode, error)
 	}
 )
 
@@ -134,6 +147,39 @@ func (n *DirNode) Path() (string, error) {
 	return sd.Path(), nil
 }
 
+// SetQuota is a wrapper for SiaDir.SetQuota.
+func (n *DirNode) SetQuota(quota uint64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetQuota(quota)
+}
+
+// SetLockedUntil is a wrapper for SiaDir.SetLockedUntil.
+func (n *DirNode) SetLockedUntil(lockedUntil time.Time) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetLockedUntil(lockedUntil)
+}
+
+// SetTags is a wrapper for SiaDir.SetTags.
+func (n *DirNode) SetTags(tags map[string]string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetTags(tags)
+}
+
 // UpdateBubbledMetadata is a wrapper for SiaDir.UpdateBubbledMetadata.
 func (n *DirNode) UpdateBubbledMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -167,6 +213,54 @@ func (n *DirNode) UpdateMetadata(md siadir.Metadata) error {
 	return sd.UpdateMetadata(md)
 }
 
+// managedCachedFileMetadata returns the cached metadata of the siafile with
+// the given name. If the file is open, its metadata is taken from memory.
+// Otherwise it is taken from the metadata cache or the siafile is loaded from
+// disk and added to the cache.
+func (n *DirNode) managedCachedFileMetadata(fileName string) (CachedFileMetadata, error) {
+	n.mu.Lock()
+	file, open := n.files[fileName]
+	dirPath := n.absPath()
+	n.mu.Unlock()
+	if open {
+		if file.Deleted() {
+			return CachedFileMetadata{}, ErrNotExist
+		}
+		return newCachedFileMetadata(file.Metadata()), nil
+	}
+	info, err := os.Stat(filepath.Join(dirPath, fileName+modules.SiaFileExtension))
+	if os.IsNotExist(err) {
+		return CachedFileMetadata{}, ErrNotExist
+	}
+	if err != nil {
+		return CachedFileMetadata{}, err
+	}
+	if md, ok := n.staticMetadataCache.managedGet(dirPath, fileName, info); ok {
+		return md, nil
+	}
+	loadTime := time.Now()
+	n.mu.Lock()
+	file, err = n.readonlyOpenFile(fileName)
+	n.mu.Unlock()
+	if err != nil {
+		return CachedFileMetadata{}, err
+	}
+	md := newCachedFileMetadata(file.Metadata())
+	n.staticMetadataCache.managedPut(dirPath, fileName, info, md, loadTime)
+	return md, nil
+}
+
+// managedSaveMetadataIndex updates the on-disk index of the cached metadatas
+// of the siafiles within the dir.
+func (n *DirNode) managedSaveMetadataIndex() error {
+	dirPath := n.managedAbsPath()
+	fis, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+	return n.staticMetadataCache.managedSaveIndex(dirPath, fis)
+}
+
 // managedList returns the files and dirs within the SiaDir specified by siaPath.
 // offlineMap, goodForRenewMap and contractMap don't need to be provided if
 // 'cached' is set to 'true'.
@@ -174,7 +268,7 @@ func (n *DirNode) managedList(fsRoot string, recursive, cached bool, offlineMap
 	// Prepare a pool of workers.
 	numThreads := 40
 	dirLoadChan := make(chan *DirNode, numThreads)
-	fileLoadChan := make(chan func() (*FileNode, error), numThreads)
+	fileLoadChan := make(chan fileLoad, numThreads)
 	dirWorker := func() {
 		for sd := range dirLoadChan {
 			var di modules.DirectoryInfo
@@ -196,8 +290,18 @@ func (n *DirNode) managedList(fsRoot string, recursive, cached bool, offlineMap
 		}
 	}
 	fileWorker := func() {
-		for load := range fileLoadChan {
-			sf, err := load()
+		for fl := range fileLoadChan {
+			// Use the cached metadata if available.
+			if fl.cached != nil {
+				var sp modules.SiaPath
+				if err := sp.FromSysPath(fl.path, fsRoot); err != nil {
+					n.staticLog.Debugf("Failed to get SiaPath of '%v': %v", fl.path, err)
+					continue
+				}
+				flf(fl.cached.fileInfo(sp, newInode()))
+				continue
+			}
+			sf, err := fl.load()
 			if err != nil {
 				n.staticLog.Debugf("Failed to load file: %v", err)
 				continue
@@ -242,7 +346,7 @@ func (n *DirNode) managedList(fsRoot string, recursive, cached bool, offlineMap
 }
 
 // managedRecursiveList returns the files and dirs within the SiaDir.
-func (n *DirNode) managedRecursiveList(recursive, cached bool, fileLoadChan chan func() (*FileNode, error), dirLoadChan chan *DirNode) error {
+func (n *DirNode) managedRecursiveList(recursive, cached bool, fileLoadChan chan fileLoad, dirLoadChan chan *DirNode) error {
 	// Get DirectoryInfo of dir itself.
 	dirLoadChan <- n.managedCopy()
 	// Read dir.
@@ -252,6 +356,7 @@ func (n *DirNode) managedRecursiveList(recursive, cached bool, fileLoadChan chan
 	}
 	// Separate dirs and files.
 	var dirNames, fileNames []string
+	var fileInfos []os.FileInfo
 	for _, info := range fis {
 		// Skip non-siafiles and non-dirs.
 		if !info.IsDir() && filepath.Ext(info.Name()) != modules.SiaFileExtension {
@@ -262,6 +367,7 @@ func (n *DirNode) managedRecursiveList(recursive, cached bool, fileLoadChan chan
 			continue
 		}
 		fileNames = append(fileNames, strings.TrimSuffix(info.Name(), modules.SiaFileExtension))
+		fileInfos = append(fileInfos, info)
 	}
 	// Handle dirs first.
 	for _, dirName := range dirNames {
@@ -291,31 +397,57 @@ func (n *DirNode) managedRecursiveList(recursive, cached bool, fileLoadChan chan
 		}
 		continue
 	}
-	// Check if there are any files to handle.
-	if len(fileNames) == 0 {
+	// Check if there are any files to handle. For cached listings the index
+	// still needs to be updated.
+	if len(fileNames) == 0 && !cached {
 		return nil
 	}
-	// Handle files by sending a method to load them to the workers. Wee add all
-	// of them to the waitgroup to be able to tell when to release the mutex.
+	// Handle files by sending their cached metadata or a method to load them
+	// to the workers. We add all of the loads to the waitgroup to be able to
+	// tell when to release the mutex.
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	dirPath := n.absPath()
 	var wg sync.WaitGroup
-	wg.Add(len(fileNames))
 	for i := range fileNames {
-		fileName := fileNames[i]
+		fileName, info := fileNames[i], fileInfos[i]
+		_, open := n.files[fileName]
+		if cached && !open {
+			md, ok := n.staticMetadataCache.managedGet(dirPath, fileName, info)
+			if ok {
+				fileLoadChan <- fileLoad{
+					path:   filepath.Join(dirPath, info.Name()),
+					cached: &md,
+				}
+				continue
+			}
+		}
+		wg.Add(1)
 		f := func() (*FileNode, error) {
+			defer wg.Done()
+			loadTime := time.Now()
 			file, err := n.readonlyOpenFile(fileName)
-			wg.Done()
 			if err != nil {
 				return nil, err
 			}
+			if cached {
+				md := newCachedFileMetadata(file.Metadata())
+				n.staticMetadataCache.managedPut(dirPath, fileName, info, md, loadTime)
+			}
 			return file, nil // no need to close it since it was created using readonlyOpenFile.
 		}
-		fileLoadChan <- f
+		fileLoadChan <- fileLoad{load: f}
 	}
 	// Wait for the workers to finish all calls to `openFile` before releasing the
 	// lock.
 	wg.Wait()
+	// Update the on-disk index of the cached metadatas.
+	if cached {
+		err = n.staticMetadataCache.managedSaveIndex(dirPath, fis)
+		if err != nil {
+			n.staticLog.Debugf("Failed to save metadata index of '%v': %v", dirPath, err)
+		}
+	}
 	return nil
 }
 
@@ -512,6 +644,7 @@ func (n *DirNode) managedDelete() error {
 	if err != nil {
 		return err
 	}
+	n.staticMetadataCache.managedPurge(n.absPath())
 	// Remove the dir from the parent if it exists.
 	if n.parent != nil {
 		n.parent.removeDir(n)
@@ -608,6 +741,9 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		StuckHealth:         metadata.StuckHealth,
 		StuckSize:           metadata.StuckSize,
 		SiaPath:             siaPath,
+		Quota:               metadata.Quota,
+		LockedUntil:         metadata.LockedUntil,
+		Tags:                metadata.Tags,
 		UID:                 n.staticUID,
 	}, nil
 }
@@ -660,6 +796,27 @@ func (n *DirNode) managedNewSiaFile(fileName string, source string, ec modules.E
 	return errors.AddContext(err, "NewSiaFile: failed to create file")
 }
 
+// managedNewSiaFileFromParts creates a new SiaFile with the given fileName as
+// its child which consists of the chunks of the parts. The parts are deleted
+// and will be removed from their parents once they are closed.
+func (n *DirNode) managedNewSiaFileFromParts(fileName string, parts []*FileNode) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// Make sure we don't have a file or folder with that name already.
+	if exists := n.childExists(fileName); exists {
+		return ErrExists
+	}
+	sfs := make([]*siafile.SiaFile, 0, len(parts))
+	for _, part := range parts {
+		sfs = append(sfs, part.SiaFile)
+	}
+	_, err := siafile.NewFromParts(filepath.Join(n.absPath(), fileName+modules.SiaFileExtension), n.staticWal, sfs)
+	if errors.Contains(err, siafile.ErrPathOverload) {
+		return ErrExists
+	}
+	return errors.AddContext(err, "ConcatFiles: failed to create file")
+}
+
 // managedNewSiaDir creates the SiaDir with the given dirName as its child. We
 // try to create the SiaDir if it exists in memory but not on disk, as it may
 // have just been deleted. We also do not return an error if the SiaDir exists
@@ -759,6 +916,8 @@ func (n *DirNode) openDir(dirName string) (*DirNode, error) {
 		directories: make(map[string]*DirNode),
 		files:       make(map[string]*FileNode),
 		lazySiaDir:  new(*siadir.SiaDir),
+
+		staticMetadataCache: n.staticMetadataCache,
 	}
 	n.directories[*dir.name] = dir
 	return dir.managedCopy(), nil
@@ -884,6 +1043,7 @@ func (n *DirNode) managedRename(newName string, oldParent, newParent *DirNode) e
 		// Add the open dirs to dirsToLock.
 		dirsToLock = append(dirsToLock, d.childDirs()...)
 	}
+	oldBase := n.absPath()
 	newBase := filepath.Join(newParent.absPath(), newName)
 	// Rename the dir.
 	dir, err := n.siaDir()
@@ -897,6 +1057,7 @@ func (n *DirNode) managedRename(newName string, oldParent, newParent *DirNode) e
 	if err != nil {
 		return err
 	}
+	n.staticMetadataCache.managedPurge(oldBase)
 	// Remove dir from old parent and add it to new parent.
 	oldParent.removeDir(n)
 	// Update parent and name.
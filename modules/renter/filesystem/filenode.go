@@ -141,17 +141,19 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		AccessTime:       n.AccessTime(),
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
-		CipherType:       n.MasterKey().Type().String(),
+		CipherType:       n.MasterKeyType().String(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
 		Filesize:         n.Size(),
 		Health:           health,
 		LocalPath:        localPath,
+		MasterKeyID:      n.MasterKeyID(),
 		MaxHealth:        maxHealth,
 		MaxHealthPercent: modules.HealthPercentage(maxHealth),
 		ModificationTime: n.ModTime(),
 		NumStuckChunks:   numStuckChunks,
 		OnDisk:           onDisk,
+		ReadOnly:         n.ReadOnly(),
 		Recoverable:      onDisk || redundancy >= 1,
 		Redundancy:       redundancy,
 		Renewing:         true,
@@ -160,6 +162,8 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		Stuck:            numStuckChunks > 0,
 		StuckHealth:      stuckHealth,
 		StuckBytes:       stuckBytes,
+		Tags:             n.Tags(),
+		LockedUntil:      n.LockedUntil(),
 		UID:              n.staticUID,
 		UploadedBytes:    uploadedBytes,
 		UploadProgress:   uploadProgress,
@@ -211,42 +215,5 @@ func (n *FileNode) managedRename(newName string, oldParent, newParent *DirNode)
 // optimization, the fileInfo takes the maps returned by
 // renter.managedContractUtilityMaps for many files at once.
 func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo, error) {
-	md := n.Metadata()
-
-	// Build the FileInfo
-	var onDisk bool
-	localPath := md.LocalPath
-	if localPath != "" {
-		_, err := os.Stat(localPath)
-		onDisk = err == nil
-	}
-	maxHealth := math.Max(md.CachedHealth, md.CachedStuckHealth)
-	fileInfo := modules.FileInfo{
-		AccessTime:       md.AccessTime,
-		Available:        md.CachedUserRedundancy >= 1,
-		ChangeTime:       md.ChangeTime,
-		CipherType:       md.StaticMasterKeyType.String(),
-		CreateTime:       md.CreateTime,
-		Expiration:       md.CachedExpiration,
-		Filesize:         uint64(md.FileSize),
-		Health:           md.CachedHealth,
-		LocalPath:        localPath,
-		MaxHealth:        maxHealth,
-		MaxHealthPercent: modules.HealthPercentage(maxHealth),
-		ModificationTime: md.ModTime,
-		NumStuckChunks:   md.NumStuckChunks,
-		OnDisk:           onDisk,
-		Recoverable:      onDisk || md.CachedUserRedundancy >= 1,
-		Redundancy:       md.CachedUserRedundancy,
-		Renewing:         true,
-		RepairBytes:      md.CachedRepairBytes,
-		SiaPath:          siaPath,
-		Stuck:            md.NumStuckChunks > 0,
-		StuckBytes:       md.CachedStuckBytes,
-		StuckHealth:      md.CachedStuckHealth,
-		UID:              n.staticUID,
-		UploadedBytes:    md.CachedUploadedBytes,
-		UploadProgress:   md.CachedUploadProgress,
-	}
-	return fileInfo, nil
+	return newCachedFileMetadata(n.Metadata()).fileInfo(siaPath, n.staticUID), nil
 }
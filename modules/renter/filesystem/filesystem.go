@@ -156,6 +156,8 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 			directories: make(map[string]*DirNode),
 			files:       make(map[string]*FileNode),
 			lazySiaDir:  new(*siadir.SiaDir),
+
+			staticMetadataCache: newMetadataCache(maxCachedMetadatas),
 		},
 	}
 	// Prepare root folder.
@@ -171,13 +173,37 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 // already exists with a different UID, the UID will be updated and a unique
 // path will be chosen. If no file exists, the UID will be updated but the path
 // remains the same.
-func (fs *FileSystem) AddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) (err error) {
+func (fs *FileSystem) AddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) error {
+	return fs.managedAddSiaFileFromReader(rs, siaPath, nil)
+}
+
+// AddSharedSiaFileFromReader adds a siafile which was shared by another renter
+// to the filesystem. The fields of its metadata which only apply to the other
+// renter, like the path of the local copy, are reset.
+func (fs *FileSystem) AddSharedSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) error {
+	return fs.managedAddSiaFileFromReader(rs, siaPath, (*siafile.SiaFile).UnmanagedResetLocalState)
+}
+
+// AddTransferredSiaFileFromReader adds a siafile which was handed over by
+// another renter to the filesystem. Unlike for shared files, only the path of
+// the local copy is reset since the renter takes over the file.
+func (fs *FileSystem) AddTransferredSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) error {
+	return fs.managedAddSiaFileFromReader(rs, siaPath, (*siafile.SiaFile).UnmanagedResetLocalPath)
+}
+
+// managedAddSiaFileFromReader adds an existing SiaFile to the set and stores
+// it on disk. If reset is not nil, it is called on the SiaFile before it is
+// saved.
+func (fs *FileSystem) managedAddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath, reset func(*siafile.SiaFile)) (err error) {
 	// Load the file.
 	path := fs.FilePath(siaPath)
 	sf, chunks, err := siafile.LoadSiaFileFromReaderWithChunks(rs, path, fs.staticWal)
 	if err != nil {
 		return err
 	}
+	if reset != nil {
+		reset(sf)
+	}
 	// Create dir with same Mode as file if it doesn't exist already and open
 	// it.
 	dirSiaPath, err := siaPath.Dir()
@@ -203,6 +229,23 @@ func (fs *FileSystem) CachedFileInfo(siaPath modules.SiaPath) (modules.FileInfo,
 	return fs.managedFileInfo(siaPath, true, nil, nil, nil)
 }
 
+// CachedFileMetadata returns the cached metadata of the siafile. The siafile
+// is only loaded from disk if its metadata isn't cached or outdated.
+func (fs *FileSystem) CachedFileMetadata(siaPath modules.SiaPath) (_ CachedFileMetadata, err error) {
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return CachedFileMetadata{}, err
+	}
+	dir, err := fs.managedOpenSiaDir(dirSiaPath)
+	if err != nil {
+		return CachedFileMetadata{}, errors.AddContext(err, "failed to open parent dir of file")
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.managedCachedFileMetadata(siaPath.Name())
+}
+
 // CachedList lists the files and directories within a SiaDir.
 func (fs *FileSystem) CachedList(siaPath modules.SiaPath, recursive bool, flf modules.FileListFunc, dlf modules.DirListFunc) error {
 	return fs.managedList(siaPath, recursive, true, nil, nil, nil, flf, dlf)
@@ -344,6 +387,19 @@ func (fs *FileSystem) ReadDir(siaPath modules.SiaPath) ([]os.FileInfo, error) {
 	return fis, err
 }
 
+// SaveMetadataIndex updates the on-disk index of the cached siafile metadatas
+// within the specified dir.
+func (fs *FileSystem) SaveMetadataIndex(siaPath modules.SiaPath) (err error) {
+	dir, err := fs.managedOpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.managedSaveMetadataIndex()
+}
+
 // DirExists checks to see if a dir with the provided siaPath already exists in
 // the renter.
 func (fs *FileSystem) DirExists(siaPath modules.SiaPath) (bool, error) {
@@ -519,6 +575,42 @@ func (fs *FileSystem) RenameFile(oldSiaPath, newSiaPath modules.SiaPath) (err er
 	return sf.managedRename(newSiaPath.Name(), oldDir, newDir)
 }
 
+// ConcatFiles creates a new file at siaPath which consists of the chunks of
+// the parts in order and deletes the parts. No data is uploaded since the new
+// file references the pieces of the parts.
+func (fs *FileSystem) ConcatFiles(siaPath modules.SiaPath, parts []modules.SiaPath) (err error) {
+	// Open the parts.
+	partNodes := make([]*FileNode, 0, len(parts))
+	defer func() {
+		for _, pn := range partNodes {
+			err = errors.Compose(err, pn.Close())
+		}
+	}()
+	for _, part := range parts {
+		pn, err := fs.managedOpenFile(part.String())
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to open part %v", part))
+		}
+		partNodes = append(partNodes, pn)
+	}
+	// Create and open the SiaDir for the new file.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	if err := fs.NewSiaDir(dirSiaPath, modules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to create SiaDir %v for SiaFile %v", dirSiaPath.String(), siaPath.String()))
+	}
+	dir, err := fs.managedOpenSiaDir(dirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.managedNewSiaFileFromParts(siaPath.Name(), partNodes)
+}
+
 // RenameDir takes an existing directory and changes the path. The original
 // directory must exist, and there must not be any directory that already has
 // the replacement path.  All sia files within directory will also be renamed
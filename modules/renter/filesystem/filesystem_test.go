@@ -757,6 +757,67 @@ func TestRenameFile(t *testing.T) {
 	sf.Close()
 }
 
+// TestConcatFiles tests concatenating files into a new file.
+func TestConcatFiles(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create filesystem.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	// Add a chunk-aligned file and a file with a partial chunk.
+	foo := newSiaPath("foo")
+	barfoo := newSiaPath("bar/foo")
+	foobar := newSiaPath("foobar/foo")
+	ec, err := modules.NewRSSubCode(10, 20, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkSize := (modules.SectorSize - crypto.TypeDefaultRenter.Overhead()) * uint64(ec.MinPieces())
+	err = fs.NewSiaFile(foo, "", ec, crypto.GenerateSiaKey(crypto.TypeDefaultRenter), chunkSize, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.addTestSiaFile(barfoo)
+	// Keep the second part open while concatenating.
+	sf, err := fs.OpenSiaFile(barfoo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partSize := sf.Size()
+	// Parts can only be concatenated in a valid order.
+	if err := fs.ConcatFiles(foobar, []modules.SiaPath{barfoo, foo}); err == nil {
+		t.Fatal("expected concatenation of unaligned part to fail")
+	}
+	if err := fs.ConcatFiles(foobar, []modules.SiaPath{foo, barfoo}); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Deleted() {
+		t.Fatal("part wasn't deleted")
+	}
+	sf.Close()
+	// The parts are gone and the new file contains both of them.
+	for _, part := range []modules.SiaPath{foo, barfoo} {
+		if _, err := fs.OpenSiaFile(part); !errors.Contains(err, ErrNotExist) {
+			t.Fatal("expected ErrNotExist but got:", err)
+		}
+	}
+	sf, err = fs.OpenSiaFile(foobar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.Size() != chunkSize+partSize {
+		t.Fatal("wrong size", sf.Size(), chunkSize+partSize)
+	}
+	sf.Close()
+	// The new file can't overwrite an existing file.
+	fs.addTestSiaFile(foo)
+	if err := fs.ConcatFiles(foobar, []modules.SiaPath{foo}); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists but got:", err)
+	}
+}
+
 // TestThreadedAccess tests rapidly opening and closing files and directories
 // from multiple threads to check the locking conventions.
 func TestThreadedAccess(t *testing.T) {
@@ -0,0 +1,437 @@
+package filesystem
+
+import (
+	"container/list"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// metadataIndexFilename is the name of the file within a directory which
+	// contains the on-disk index of the cached metadata of the directory's
+	// siafiles.
+	metadataIndexFilename = ".siaindex"
+)
+
+var (
+	// maxCachedMetadatas is the maximum number of siafile metadatas kept in
+	// memory by the metadata cache.
+	maxCachedMetadatas = build.Select(build.Var{
+		Dev:      10000,
+		Standard: 250000,
+		Testing:  1000,
+		Testnet:  250000,
+	}).(int)
+
+	// racyCacheWindow is the time that needs to pass between the last
+	// modification of a siafile and loading its metadata before the metadata
+	// is cached. Otherwise a modification within the granularity of the
+	// filesystem's timestamps might not change the modification time and
+	// therefore go unnoticed.
+	racyCacheWindow = time.Second
+)
+
+type (
+	// CachedFileMetadata contains the fields of a siafile's metadata which are
+	// required for listing the file and bubbling its health without loading
+	// the siafile from disk.
+	CachedFileMetadata struct {
+		AccessTime          time.Time          `json:"accesstime"`
+		ChangeTime          time.Time          `json:"changetime"`
+		CreateTime          time.Time          `json:"createtime"`
+		ModTime             time.Time          `json:"modtime"`
+		LastHealthCheckTime time.Time          `json:"lasthealthchecktime"`
+		MasterKeyID         string             `json:"masterkeyid,omitempty"`
+		MasterKeyType       crypto.CipherType  `json:"masterkeytype"`
+		FileSize            int64              `json:"filesize"`
+		LocalPath           string             `json:"localpath"`
+		Tags                map[string]string  `json:"tags,omitempty"`
+		LockedUntil         time.Time          `json:"lockeduntil"`
+		ReadOnly            bool               `json:"readonly,omitempty"`
+		UID                 siafile.SiafileUID `json:"uid"`
+
+		CachedExpiration     types.BlockHeight `json:"cachedexpiration"`
+		CachedHealth         float64           `json:"cachedhealth"`
+		CachedNumStuckChunks uint64            `json:"cachednumstuckchunks"`
+		CachedRedundancy     float64           `json:"cachedredundancy"`
+		CachedRepairBytes    uint64            `json:"cachedrepairbytes"`
+		CachedStuckBytes     uint64            `json:"cachedstuckbytes"`
+		CachedStuckHealth    float64           `json:"cachedstuckhealth"`
+		CachedUploadedBytes  uint64            `json:"cacheduploadedbytes"`
+		CachedUploadProgress float64           `json:"cacheduploadprogress"`
+		CachedUserRedundancy float64           `json:"cacheduserredundancy"`
+		NumStuckChunks       uint64            `json:"numstuckchunks"`
+	}
+
+	// metadataCache is a memory-bounded LRU cache of siafile metadatas which
+	// is backed by an on-disk index within every directory. An entry is only
+	// valid as long as the modification time and size of the siafile on disk
+	// match the ones recorded in the entry.
+	metadataCache struct {
+		// dirs maps the path of a directory to the elements of the cached
+		// siafiles within it.
+		dirs map[string]map[string]*list.Element
+		lru  *list.List
+
+		// indexed contains the directories whose on-disk index was loaded and
+		// dirty the directories whose on-disk index is outdated.
+		indexed map[string]struct{}
+		dirty   map[string]struct{}
+
+		staticMaxEntries int
+		mu               sync.Mutex
+	}
+
+	// metadataCacheEntry is an element of the metadata cache's LRU list.
+	metadataCacheEntry struct {
+		dir  string
+		name string
+		metadataIndexEntry
+	}
+
+	// metadataIndexEntry is an entry of a directory's on-disk index.
+	metadataIndexEntry struct {
+		FileModTime time.Time          `json:"filemodtime"`
+		FileSize    int64              `json:"filesize"`
+		Metadata    CachedFileMetadata `json:"metadata"`
+	}
+)
+
+// newCachedFileMetadata extracts the cached fields from a siafile's metadata.
+func newCachedFileMetadata(md siafile.Metadata) CachedFileMetadata {
+	return CachedFileMetadata{
+		AccessTime:          md.AccessTime,
+		ChangeTime:          md.ChangeTime,
+		CreateTime:          md.CreateTime,
+		ModTime:             md.ModTime,
+		LastHealthCheckTime: md.LastHealthCheckTime,
+		MasterKeyID:         md.StaticMasterKeyID,
+		MasterKeyType:       md.StaticMasterKeyType,
+		FileSize:            md.FileSize,
+		LocalPath:           md.LocalPath,
+		Tags:                md.Tags,
+		LockedUntil:         md.LockedUntil,
+		ReadOnly:            md.StaticReadOnly,
+		UID:                 md.UniqueID,
+
+		CachedExpiration:     md.CachedExpiration,
+		CachedHealth:         md.CachedHealth,
+		CachedNumStuckChunks: md.CachedNumStuckChunks,
+		CachedRedundancy:     md.CachedRedundancy,
+		CachedRepairBytes:    md.CachedRepairBytes,
+		CachedStuckBytes:     md.CachedStuckBytes,
+		CachedStuckHealth:    md.CachedStuckHealth,
+		CachedUploadedBytes:  md.CachedUploadedBytes,
+		CachedUploadProgress: md.CachedUploadProgress,
+		CachedUserRedundancy: md.CachedUserRedundancy,
+		NumStuckChunks:       md.NumStuckChunks,
+	}
+}
+
+// fileInfo builds the cached FileInfo of a file from its metadata.
+func (md CachedFileMetadata) fileInfo(siaPath modules.SiaPath, uid uint64) modules.FileInfo {
+	var onDisk bool
+	localPath := md.LocalPath
+	if localPath != "" {
+		_, err := os.Stat(localPath)
+		onDisk = err == nil
+	}
+	maxHealth := math.Max(md.CachedHealth, md.CachedStuckHealth)
+	return modules.FileInfo{
+		AccessTime:       md.AccessTime,
+		Available:        md.CachedUserRedundancy >= 1,
+		ChangeTime:       md.ChangeTime,
+		CipherType:       md.MasterKeyType.String(),
+		CreateTime:       md.CreateTime,
+		Expiration:       md.CachedExpiration,
+		Filesize:         uint64(md.FileSize),
+		Health:           md.CachedHealth,
+		LocalPath:        localPath,
+		MasterKeyID:      md.MasterKeyID,
+		MaxHealth:        maxHealth,
+		MaxHealthPercent: modules.HealthPercentage(maxHealth),
+		ModificationTime: md.ModTime,
+		NumStuckChunks:   md.NumStuckChunks,
+		OnDisk:           onDisk,
+		ReadOnly:         md.ReadOnly,
+		Recoverable:      onDisk || md.CachedUserRedundancy >= 1,
+		Redundancy:       md.CachedUserRedundancy,
+		Renewing:         true,
+		RepairBytes:      md.CachedRepairBytes,
+		SiaPath:          siaPath,
+		Stuck:            md.NumStuckChunks > 0,
+		StuckBytes:       md.CachedStuckBytes,
+		StuckHealth:      md.CachedStuckHealth,
+		Tags:             md.Tags,
+		LockedUntil:      md.LockedUntil,
+		UID:              uid,
+		UploadedBytes:    md.CachedUploadedBytes,
+		UploadProgress:   md.CachedUploadProgress,
+	}
+}
+
+// newMetadataCache creates a new metadata cache which holds up to maxEntries
+// metadatas in memory.
+func newMetadataCache(maxEntries int) *metadataCache {
+	return &metadataCache{
+		dirs:             make(map[string]map[string]*list.Element),
+		lru:              list.New(),
+		indexed:          make(map[string]struct{}),
+		dirty:            make(map[string]struct{}),
+		staticMaxEntries: maxEntries,
+	}
+}
+
+// loadMetadataIndex loads the on-disk index of a directory. A missing index is
+// treated like an empty one.
+func loadMetadataIndex(dir string) (map[string]metadataIndexEntry, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, metadataIndexFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var index map[string]metadataIndexEntry
+	if err := json.Unmarshal(b, &index); err != nil {
+		return nil, errors.AddContext(err, "failed to unmarshal metadata index")
+	}
+	return index, nil
+}
+
+// saveMetadataIndex atomically writes the on-disk index of a directory. An
+// empty index is removed from disk.
+func saveMetadataIndex(dir string, index map[string]metadataIndexEntry) error {
+	path := filepath.Join(dir, metadataIndexFilename)
+	if len(index) == 0 {
+		err := os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	b, err := json.Marshal(index)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal metadata index")
+	}
+	tmpPath := path + "_temp"
+	if err := ioutil.WriteFile(tmpPath, b, modules.DefaultFilePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// valid returns true if the entry still matches the siafile on disk.
+func (e metadataIndexEntry) valid(fi os.FileInfo) bool {
+	return e.FileModTime.Equal(fi.ModTime()) && e.FileSize == fi.Size()
+}
+
+// managedLoadIndex loads the on-disk index of a directory into the cache if it
+// wasn't loaded before.
+func (mc *metadataCache) managedLoadIndex(dir string) error {
+	mc.mu.Lock()
+	_, indexed := mc.indexed[dir]
+	mc.mu.Unlock()
+	if indexed {
+		return nil
+	}
+	index, err := loadMetadataIndex(dir)
+	if err != nil {
+		return err
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if _, indexed := mc.indexed[dir]; indexed {
+		return nil
+	}
+	mc.indexed[dir] = struct{}{}
+	for name, entry := range index {
+		if _, exists := mc.dirs[dir][name]; !exists {
+			mc.insert(dir, name, entry)
+		}
+	}
+	return nil
+}
+
+// insert adds an entry to the cache or replaces an existing one and evicts the
+// least recently used entries if the cache is full.
+func (mc *metadataCache) insert(dir, name string, entry metadataIndexEntry) {
+	if el, exists := mc.dirs[dir][name]; exists {
+		el.Value.(*metadataCacheEntry).metadataIndexEntry = entry
+		mc.lru.MoveToFront(el)
+		return
+	}
+	if _, exists := mc.dirs[dir]; !exists {
+		mc.dirs[dir] = make(map[string]*list.Element)
+	}
+	mc.dirs[dir][name] = mc.lru.PushFront(&metadataCacheEntry{
+		dir:                dir,
+		name:               name,
+		metadataIndexEntry: entry,
+	})
+	for mc.lru.Len() > mc.staticMaxEntries {
+		mc.remove(mc.lru.Back().Value.(*metadataCacheEntry))
+	}
+}
+
+// remove removes an entry from the cache. If it was the last cached entry of
+// its directory, the directory's on-disk index will be loaded again on the
+// next access.
+func (mc *metadataCache) remove(entry *metadataCacheEntry) {
+	el, exists := mc.dirs[entry.dir][entry.name]
+	if !exists {
+		return
+	}
+	mc.lru.Remove(el)
+	delete(mc.dirs[entry.dir], entry.name)
+	if len(mc.dirs[entry.dir]) == 0 {
+		delete(mc.dirs, entry.dir)
+		delete(mc.indexed, entry.dir)
+	}
+}
+
+// managedGet returns the cached metadata of the siafile with the given name
+// within dir. fi is the siafile's FileInfo on disk which is used to check
+// whether the cached metadata is still valid.
+func (mc *metadataCache) managedGet(dir, name string, fi os.FileInfo) (CachedFileMetadata, bool) {
+	if err := mc.managedLoadIndex(dir); err != nil {
+		// A broken index is treated like a missing one and will be replaced
+		// on the next save.
+		mc.mu.Lock()
+		mc.indexed[dir] = struct{}{}
+		mc.dirty[dir] = struct{}{}
+		mc.mu.Unlock()
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	el, exists := mc.dirs[dir][name]
+	if !exists {
+		return CachedFileMetadata{}, false
+	}
+	entry := el.Value.(*metadataCacheEntry)
+	if !entry.valid(fi) {
+		mc.remove(entry)
+		mc.dirty[dir] = struct{}{}
+		return CachedFileMetadata{}, false
+	}
+	mc.lru.MoveToFront(el)
+	return entry.Metadata, true
+}
+
+// managedPut adds the metadata of the siafile with the given name within dir
+// to the cache. fi is the siafile's FileInfo on disk which was retrieved
+// before loading the metadata and loadTime the time the metadata was loaded.
+// Metadata of files which were modified within the racyCacheWindow before
+// being loaded is not cached.
+func (mc *metadataCache) managedPut(dir, name string, fi os.FileInfo, md CachedFileMetadata, loadTime time.Time) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if loadTime.Sub(fi.ModTime()) < racyCacheWindow {
+		if el, exists := mc.dirs[dir][name]; exists {
+			mc.remove(el.Value.(*metadataCacheEntry))
+			mc.dirty[dir] = struct{}{}
+		}
+		return
+	}
+	mc.insert(dir, name, metadataIndexEntry{
+		FileModTime: fi.ModTime(),
+		FileSize:    fi.Size(),
+		Metadata:    md,
+	})
+	mc.dirty[dir] = struct{}{}
+}
+
+// managedSaveIndex writes the on-disk index of a directory if it is outdated.
+// fis are the contents of the directory. Entries of siafiles which are no
+// longer within the directory are dropped.
+func (mc *metadataCache) managedSaveIndex(dir string, fis []os.FileInfo) error {
+	mc.mu.Lock()
+	_, dirty := mc.dirty[dir]
+	index := make(map[string]metadataIndexEntry)
+	var missing bool
+	for _, fi := range fis {
+		if fi.IsDir() || filepath.Ext(fi.Name()) != modules.SiaFileExtension {
+			continue
+		}
+		name := strings.TrimSuffix(fi.Name(), modules.SiaFileExtension)
+		el, exists := mc.dirs[dir][name]
+		if !exists {
+			missing = true
+			continue
+		}
+		if entry := el.Value.(*metadataCacheEntry); entry.valid(fi) {
+			index[name] = entry.metadataIndexEntry
+		}
+	}
+	for name, el := range mc.dirs[dir] {
+		if _, exists := index[name]; !exists {
+			mc.remove(el.Value.(*metadataCacheEntry))
+			dirty = true
+		}
+	}
+	delete(mc.dirty, dir)
+	if !dirty {
+		mc.mu.Unlock()
+		return nil
+	}
+	mc.mu.Unlock()
+
+	// Keep the still valid entries of the old index which were evicted from
+	// memory.
+	if missing {
+		oldIndex, err := loadMetadataIndex(dir)
+		if err == nil {
+			for _, fi := range fis {
+				name := strings.TrimSuffix(fi.Name(), modules.SiaFileExtension)
+				if _, exists := index[name]; exists || fi.IsDir() || filepath.Ext(fi.Name()) != modules.SiaFileExtension {
+					continue
+				}
+				if entry, exists := oldIndex[name]; exists && entry.valid(fi) {
+					index[name] = entry
+				}
+			}
+		}
+	}
+	return saveMetadataIndex(dir, index)
+}
+
+// managedPurge removes the cached metadatas of a directory and all of its
+// subdirectories from memory.
+func (mc *metadataCache) managedPurge(dir string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	within := func(d string) bool {
+		return d == dir || strings.HasPrefix(d, dir+string(filepath.Separator))
+	}
+	for d, entries := range mc.dirs {
+		if !within(d) {
+			continue
+		}
+		for _, el := range entries {
+			mc.remove(el.Value.(*metadataCacheEntry))
+		}
+	}
+	for d := range mc.indexed {
+		if within(d) {
+			delete(mc.indexed, d)
+		}
+	}
+	for d := range mc.dirty {
+		if within(d) {
+			delete(mc.dirty, d)
+		}
+	}
+}
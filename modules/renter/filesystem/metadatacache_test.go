@@ -0,0 +1,217 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// testFileInfo is a minimal os.FileInfo for testing the metadata cache.
+type testFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi testFileInfo) Name() string       { return fi.name + modules.SiaFileExtension }
+func (fi testFileInfo) Size() int64        { return fi.size }
+func (fi testFileInfo) Mode() os.FileMode  { return 0 }
+func (fi testFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi testFileInfo) IsDir() bool        { return false }
+func (fi testFileInfo) Sys() interface{}   { return nil }
+
+// backdateSiaFiles sets the modification time of the siafiles within a dir to
+// a minute ago to make them eligible for caching.
+func backdateSiaFiles(t *testing.T, dir string) {
+	t.Helper()
+	fis, err := filepath.Glob(filepath.Join(dir, "*"+modules.SiaFileExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Minute)
+	for _, path := range fis {
+		if err := os.Chtimes(path, past, past); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestMetadataCache tests that listings and bubbles use and update the
+// metadata cache and its on-disk index.
+func TestMetadataCache(t *testing.T) {
+	if testing.Short() && !build.VLONG {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a filesystem with a few files in a dir.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	fs := newTestFileSystem(root)
+	dirSiaPath := newSiaPath("dir")
+	names := []string{"file1", "file2", "file3"}
+	for _, name := range names {
+		sp, err := dirSiaPath.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs.addTestSiaFile(sp)
+	}
+	dirPath := fs.DirPath(dirSiaPath)
+
+	// Freshly modified files are not cached.
+	fis, _, err := fs.CachedListCollect(dirSiaPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != len(names) {
+		t.Fatalf("expected %v files but got %v", len(names), len(fis))
+	}
+	if n := len(fs.staticMetadataCache.dirs[dirPath]); n != 0 {
+		t.Fatalf("expected no cached metadatas but got %v", n)
+	}
+
+	// After backdating the files, the listing caches all of them and writes
+	// the index.
+	backdateSiaFiles(t, dirPath)
+	fis, _, err = fs.CachedListCollect(dirSiaPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(fs.staticMetadataCache.dirs[dirPath]); n != len(names) {
+		t.Fatalf("expected %v cached metadatas but got %v", len(names), n)
+	}
+	index, err := loadMetadataIndex(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != len(names) {
+		t.Fatalf("expected %v entries in the index but got %v", len(names), len(index))
+	}
+
+	// A cached listing returns the same infos apart from the UID.
+	cachedFis, _, err := fs.CachedListCollect(dirSiaPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range fis {
+		fis[i].UID, cachedFis[i].UID = 0, 0
+		if !reflect.DeepEqual(fis[i], cachedFis[i]) {
+			t.Fatalf("infos don't match:\n%+v\n%+v", fis[i], cachedFis[i])
+		}
+	}
+
+	// Changing a file invalidates its cached metadata.
+	sp1, _ := dirSiaPath.Join("file1")
+	sf, err := fs.OpenSiaFile(sp1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags := map[string]string{"foo": "bar"}
+	if err := sf.SetTags(tags); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	md, err := fs.CachedFileMetadata(sp1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(md.Tags, tags) {
+		t.Fatalf("expected tags %v but got %v", tags, md.Tags)
+	}
+
+	// A new filesystem loads the metadatas from the index.
+	if err := fs.SaveMetadataIndex(dirSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	fs2 := newTestFileSystem(root)
+	sp2, _ := dirSiaPath.Join("file2")
+	info, err := os.Stat(fs2.FilePath(sp2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md2, ok := fs2.staticMetadataCache.managedGet(dirPath, "file2", info)
+	if !ok {
+		t.Fatal("metadata wasn't loaded from the index")
+	}
+	if !reflect.DeepEqual(md2, index["file2"].Metadata) {
+		t.Fatal("metadata doesn't match the index")
+	}
+
+	// Deleting a file removes it from the index with the next listing.
+	sp3, _ := dirSiaPath.Join("file3")
+	if err := fs2.DeleteFile(sp3); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := fs2.CachedListCollect(dirSiaPath, false); err != nil {
+		t.Fatal(err)
+	}
+	index, err = loadMetadataIndex(dirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := index["file3"]; exists {
+		t.Fatal("deleted file wasn't removed from the index")
+	}
+	if _, exists := index["file2"]; !exists {
+		t.Fatal("unchanged file was removed from the index")
+	}
+
+	// Deleting the dir purges its metadatas from memory.
+	if err := fs2.DeleteDir(dirSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := fs2.staticMetadataCache.dirs[dirPath]; exists {
+		t.Fatal("metadatas of deleted dir weren't purged")
+	}
+}
+
+// TestMetadataCacheEviction tests that the metadata cache doesn't grow beyond
+// its maximum size and doesn't cache racily modified files.
+func TestMetadataCacheEviction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+	mc := newMetadataCache(2)
+	past := time.Now().Add(-time.Minute)
+	fis := []testFileInfo{
+		{name: "a", size: 1, modTime: past},
+		{name: "b", size: 2, modTime: past},
+		{name: "c", size: 3, modTime: past},
+	}
+	for _, fi := range fis {
+		mc.managedPut(dir, fi.name, fi, CachedFileMetadata{FileSize: fi.size}, time.Now())
+	}
+	if mc.lru.Len() != 2 {
+		t.Fatalf("expected 2 entries but got %v", mc.lru.Len())
+	}
+	if _, ok := mc.managedGet(dir, "a", fis[0]); ok {
+		t.Fatal("least recently used entry wasn't evicted")
+	}
+	if md, ok := mc.managedGet(dir, "c", fis[2]); !ok || md.FileSize != 3 {
+		t.Fatal("entry is missing", ok, md.FileSize)
+	}
+
+	// A changed file invalidates the entry.
+	changed := fis[2]
+	changed.modTime = changed.modTime.Add(time.Second)
+	if _, ok := mc.managedGet(dir, "c", changed); ok {
+		t.Fatal("changed file returned cached metadata")
+	}
+
+	// Racily modified files aren't cached.
+	racy := testFileInfo{name: "d", size: 4, modTime: time.Now()}
+	mc.managedPut(dir, racy.name, racy, CachedFileMetadata{}, time.Now())
+	if _, ok := mc.managedGet(dir, "d", racy); ok {
+		t.Fatal("racily modified file was cached")
+	}
+}
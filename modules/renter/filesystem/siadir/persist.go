@@ -149,6 +149,9 @@ func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
+	metadata.Quota = sd.metadata.Quota
+	metadata.Tags = sd.metadata.Tags
+	metadata.LockedUntil = sd.metadata.LockedUntil
 	metadata.Version = sd.metadata.Version
 	return sd.updateMetadata(metadata)
 }
@@ -164,6 +167,45 @@ func (sd *SiaDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHe
 	return sd.updateMetadata(md)
 }
 
+// SetQuota sets the quota of the SiaDir and saves the changes to disk.
+func (sd *SiaDir) SetQuota(quota uint64) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Quota = quota
+	return sd.updateMetadata(md)
+}
+
+// SetLockedUntil locks the SiaDir against deletion and modification until the
+// provided time and saves the changes to disk. An active lock can only be
+// extended.
+func (sd *SiaDir) SetLockedUntil(lockedUntil time.Time) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	if lockedUntil.Before(md.LockedUntil) && time.Now().Before(md.LockedUntil) {
+		return modules.ErrLockShortened
+	}
+	md.LockedUntil = lockedUntil
+	return sd.updateMetadata(md)
+}
+
+// SetTags replaces the user defined tags of the SiaDir and saves the changes
+// to disk.
+func (sd *SiaDir) SetTags(tags map[string]string) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Tags = nil
+	if len(tags) > 0 {
+		md.Tags = make(map[string]string, len(tags))
+		for k, v := range tags {
+			md.Tags[k] = v
+		}
+	}
+	return sd.updateMetadata(md)
+}
+
 // UpdateMetadata updates the SiaDir metadata on disk
 func (sd *SiaDir) UpdateMetadata(metadata Metadata) error {
 	sd.mu.Lock()
@@ -224,6 +266,9 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.Size = metadata.Size
 	sd.metadata.StuckHealth = metadata.StuckHealth
 	sd.metadata.StuckSize = metadata.StuckSize
+	sd.metadata.Quota = metadata.Quota
+	sd.metadata.Tags = metadata.Tags
+	sd.metadata.LockedUntil = metadata.LockedUntil
 
 	sd.metadata.Version = metadata.Version
 
@@ -87,6 +87,14 @@ type (
 		StuckHealth         float64     `json:"stuckhealth"`
 		StuckSize           uint64      `json:"stucksize"`
 
+		// Quota is the maximum AggregateSize of the siadir, 0 if there is
+		// none. Tags are user defined key/value labels of the siadir. Until
+		// LockedUntil, the siadir and its contents can't be deleted or
+		// overwritten. They are not bubbled.
+		Quota       uint64            `json:"quota,omitempty"`
+		Tags        map[string]string `json:"tags,omitempty"`
+		LockedUntil time.Time         `json:"lockeduntil"`
+
 		// Version is the used version of the header file.
 		Version string `json:"version"`
 	}
@@ -0,0 +1,306 @@
+package siafile
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// concat.go contains the logic for concatenating multiple siafiles into a
+// single one without re-uploading any data. The pieces of a chunk are
+// encrypted with keys derived from the master key of the file they were
+// uploaded for and the chunk's index within that file. Since both change when
+// chunks are moved into another file, the new file keeps the master key of
+// every part together with the chunk index its chunks were encrypted with.
+
+var (
+	// errConcatNoParts is returned if no parts are provided for a
+	// concatenation.
+	errConcatNoParts = errors.New("at least one part is required to concatenate siafiles")
+
+	// errConcatDuplicatePart is returned if the same siafile is provided
+	// multiple times as a part.
+	errConcatDuplicatePart = errors.New("a siafile can only be concatenated once")
+
+	// errConcatExternalKey is returned if a part is encrypted with an external
+	// key.
+	errConcatExternalKey = errors.New("siafiles which are encrypted with an external key can't be concatenated")
+
+	// errConcatPartialChunk is returned if a part has a partial chunk.
+	errConcatPartialChunk = errors.New("siafiles with a partial chunk can't be concatenated")
+
+	// errConcatRedundancyMismatch is returned if the parts don't use the same
+	// erasure coding parameters and piece size.
+	errConcatRedundancyMismatch = errors.New("siafiles with different erasure coding parameters can't be concatenated")
+
+	// errConcatUnaligned is returned if a part other than the last one doesn't
+	// end on a chunk boundary.
+	errConcatUnaligned = errors.New("all parts but the last one need to be a multiple of the chunk size")
+)
+
+type (
+	// PartKey is the master key of a range of chunks which were concatenated
+	// from another siafile. The chunks' pieces are still encrypted with that
+	// file's master key and the chunk index they had within that file.
+	PartKey struct {
+		Key  []byte            `json:"key"`
+		Type crypto.CipherType `json:"type"`
+
+		FirstChunk    uint64 `json:"firstchunk"`    // index of the range's first chunk within the file
+		NumChunks     uint64 `json:"numchunks"`     // number of chunks in the range
+		KeyChunkIndex uint64 `json:"keychunkindex"` // chunk index the first chunk was encrypted with
+	}
+
+	// partChunkKey is the master key of a chunk which was concatenated from
+	// another siafile. It derives the piece keys from the index the chunk had
+	// within that file.
+	partChunkKey struct {
+		crypto.CipherKey
+		staticFirstChunk    uint64
+		staticKeyChunkIndex uint64
+	}
+)
+
+// Derive derives the key of a piece from the index the chunk had when it was
+// encrypted.
+func (k partChunkKey) Derive(chunkIndex, pieceIndex uint64) crypto.CipherKey {
+	return k.CipherKey.Derive(chunkIndex-k.staticFirstChunk+k.staticKeyChunkIndex, pieceIndex)
+}
+
+// cipherKey returns the key which derives the piece keys of the chunks in the
+// range.
+func (pk PartKey) cipherKey() (crypto.CipherKey, error) {
+	key, err := crypto.NewSiaKey(pk.Type, pk.Key)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create part key")
+	}
+	return partChunkKey{
+		CipherKey:           key,
+		staticFirstChunk:    pk.FirstChunk,
+		staticKeyChunkIndex: pk.KeyChunkIndex,
+	}, nil
+}
+
+// chunkMasterKey returns the key which derives the piece keys of the chunk at
+// the given index. Chunks which don't belong to a concatenated part use the
+// file's master key.
+func chunkMasterKey(partKeys []PartKey, masterKey crypto.CipherKey, chunkIndex uint64) (crypto.CipherKey, error) {
+	i := sort.Search(len(partKeys), func(i int) bool {
+		return partKeys[i].FirstChunk+partKeys[i].NumChunks > chunkIndex
+	})
+	if masterKey == nil || i == len(partKeys) || partKeys[i].FirstChunk > chunkIndex {
+		return masterKey, nil
+	}
+	return partKeys[i].cipherKey()
+}
+
+// ChunkMasterKey returns the key which derives the piece keys of the chunk at
+// the given index. It returns nil if the file is encrypted with an external
+// key.
+func (sf *SiaFile) ChunkMasterKey(chunkIndex uint64) (crypto.CipherKey, error) {
+	return chunkMasterKey(sf.staticMetadata.StaticPartKeys, sf.staticMasterKey(), chunkIndex)
+}
+
+// keyRanges returns the ranges of chunks which share a master key, covering
+// all the chunks of the file.
+func (sf *SiaFile) keyRanges() []PartKey {
+	md := sf.staticMetadata
+	var ranges []PartKey
+	var next uint64
+	addMasterKeyRange := func(end uint64) {
+		if end > next {
+			ranges = append(ranges, PartKey{
+				Key:           md.StaticMasterKey,
+				Type:          md.StaticMasterKeyType,
+				FirstChunk:    next,
+				NumChunks:     end - next,
+				KeyChunkIndex: next,
+			})
+		}
+	}
+	for _, pk := range md.StaticPartKeys {
+		addMasterKeyRange(pk.FirstChunk)
+		ranges = append(ranges, pk)
+		next = pk.FirstChunk + pk.NumChunks
+	}
+	addMasterKeyRange(uint64(sf.numChunks))
+	return ranges
+}
+
+// NewFromParts creates a new siafile at siaFilePath which consists of the
+// chunks of the provided parts in order and deletes the parts. The new file
+// and the deletion of the parts are persisted within a single wal
+// transaction. The parts need to share the same erasure coding parameters and
+// all but the last part need to end on a chunk boundary.
+func NewFromParts(siaFilePath string, wal *writeaheadlog.WAL, parts []*SiaFile) (_ *SiaFile, err error) {
+	if len(parts) == 0 {
+		return nil, errConcatNoParts
+	}
+	seen := make(map[*SiaFile]struct{}, len(parts))
+	for _, part := range parts {
+		if _, exists := seen[part]; exists {
+			return nil, errConcatDuplicatePart
+		}
+		seen[part] = struct{}{}
+	}
+	for _, part := range parts {
+		part.mu.Lock()
+		defer part.mu.Unlock()
+	}
+	// Check that the parts can be concatenated.
+	first := parts[0].staticMetadata
+	var fileSize int64
+	for i, part := range parts {
+		md := part.staticMetadata
+		if part.deleted {
+			return nil, errors.AddContext(ErrDeleted, "can't concatenate deleted siafile")
+		}
+		if md.StaticMasterKeyID != "" {
+			return nil, errConcatExternalKey
+		}
+		if md.HasPartialChunk {
+			return nil, errConcatPartialChunk
+		}
+		if md.StaticErasureCodeType != first.StaticErasureCodeType || md.StaticErasureCodeParams != first.StaticErasureCodeParams || md.StaticPieceSize != first.StaticPieceSize {
+			return nil, errConcatRedundancyMismatch
+		}
+		if i < len(parts)-1 && uint64(md.FileSize) != uint64(part.numChunks)*part.staticChunkSize() {
+			return nil, errConcatUnaligned
+		}
+		fileSize += md.FileSize
+	}
+	// Check if a file exists at the new location.
+	if _, err := os.Stat(siaFilePath); err == nil {
+		return nil, ErrPathOverload
+	}
+	if err := os.MkdirAll(filepath.Dir(siaFilePath), 0700); err != nil {
+		return nil, err
+	}
+
+	// The new file is encrypted with the master key of the first part. The
+	// chunks of the other parts keep the key they were encrypted with.
+	currentTime := time.Now()
+	md := first.backup()
+	md.UniqueID = uniqueID()
+	md.FileSize = fileSize
+	md.LocalPath = ""
+	md.LockedUntil = time.Time{}
+	md.PartialChunks = nil
+	md.AccessTime = currentTime
+	md.ChangeTime = currentTime
+	md.CreateTime = currentTime
+	md.ModTime = currentTime
+	md.ChunkOffset = defaultReservedMDPages * pageSize
+	md.StaticPartKeys = nil
+	sf := &SiaFile{
+		staticMetadata: md,
+		deps:           parts[0].deps,
+		siaFilePath:    siaFilePath,
+		wal:            wal,
+	}
+
+	// Move the chunks over and merge the host tables.
+	hostOffsets := make(map[string]uint32)
+	var chunks []chunk
+	for _, part := range parts {
+		for _, kr := range part.keyRanges() {
+			kr.FirstChunk += uint64(sf.numChunks)
+			if bytes.Equal(kr.Key, md.StaticMasterKey) && kr.Type == md.StaticMasterKeyType && kr.FirstChunk == kr.KeyChunkIndex {
+				continue // encrypted with the new file's master key
+			}
+			sf.staticMetadata.StaticPartKeys = append(sf.staticMetadata.StaticPartKeys, kr)
+		}
+		err = part.iterateChunksReadonly(func(c chunk) error {
+			for pieceIndex := range c.Pieces {
+				for i, p := range c.Pieces[pieceIndex] {
+					hpk := part.hostKey(p.HostTableOffset)
+					offset, exists := hostOffsets[hpk.PublicKey.String()]
+					if !exists {
+						offset = uint32(len(sf.pubKeyTable))
+						hostOffsets[hpk.PublicKey.String()] = offset
+						sf.pubKeyTable = append(sf.pubKeyTable, HostPublicKey{PublicKey: hpk.PublicKey})
+					}
+					sf.pubKeyTable[offset].Used = sf.pubKeyTable[offset].Used || hpk.Used
+					c.Pieces[pieceIndex][i].HostTableOffset = offset
+				}
+			}
+			c.Index += sf.numChunks
+			chunks = append(chunks, c)
+			return nil
+		})
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to read chunks of part")
+		}
+		sf.numChunks += part.numChunks
+	}
+	sf.staticMetadata.aggregatePartHealth(parts)
+
+	// Write the new file and delete the parts.
+	updates, err := sf.saveHeaderUpdates()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create header updates")
+	}
+	for _, c := range chunks {
+		updates = append(updates, sf.saveChunkUpdate(c))
+	}
+	for _, part := range parts {
+		updates = append(updates, part.createDeleteUpdate())
+	}
+	if err := createAndApplyTransaction(wal, updates...); err != nil {
+		return nil, errors.AddContext(err, "failed to apply concat updates")
+	}
+	for _, part := range parts {
+		part.deleted = true
+	}
+	return sf, nil
+}
+
+// aggregatePartHealth sets the health related fields of the metadata from the
+// metadata of the parts until the next health check updates them.
+func (md *Metadata) aggregatePartHealth(parts []*SiaFile) {
+	md.CachedHealth, md.CachedStuckHealth, md.Health, md.StuckHealth = 0, 0, 0, 0
+	md.CachedRedundancy, md.CachedUserRedundancy, md.Redundancy = math.MaxFloat64, math.MaxFloat64, math.MaxFloat64
+	md.CachedExpiration = math.MaxUint64
+	md.CachedNumStuckChunks, md.CachedRepairBytes, md.CachedStuckBytes, md.CachedUploadedBytes = 0, 0, 0, 0
+	md.NumStuckChunks, md.RepairBytes, md.StuckBytes = 0, 0, 0
+	md.LastHealthCheckTime = time.Time{}
+	var uploadProgress float64
+	for i, part := range parts {
+		pmd := part.staticMetadata
+		md.CachedHealth = math.Max(md.CachedHealth, pmd.CachedHealth)
+		md.CachedStuckHealth = math.Max(md.CachedStuckHealth, pmd.CachedStuckHealth)
+		md.Health = math.Max(md.Health, pmd.Health)
+		md.StuckHealth = math.Max(md.StuckHealth, pmd.StuckHealth)
+		md.CachedRedundancy = math.Min(md.CachedRedundancy, pmd.CachedRedundancy)
+		md.CachedUserRedundancy = math.Min(md.CachedUserRedundancy, pmd.CachedUserRedundancy)
+		md.Redundancy = math.Min(md.Redundancy, pmd.Redundancy)
+		if pmd.CachedExpiration < md.CachedExpiration {
+			md.CachedExpiration = pmd.CachedExpiration
+		}
+		md.CachedNumStuckChunks += pmd.CachedNumStuckChunks
+		md.CachedRepairBytes += pmd.CachedRepairBytes
+		md.CachedStuckBytes += pmd.CachedStuckBytes
+		md.CachedUploadedBytes += pmd.CachedUploadedBytes
+		md.NumStuckChunks += pmd.NumStuckChunks
+		md.RepairBytes += pmd.RepairBytes
+		md.StuckBytes += pmd.StuckBytes
+		if i == 0 || pmd.LastHealthCheckTime.Before(md.LastHealthCheckTime) {
+			md.LastHealthCheckTime = pmd.LastHealthCheckTime
+		}
+		if md.FileSize > 0 {
+			uploadProgress += pmd.CachedUploadProgress * float64(pmd.FileSize) / float64(md.FileSize)
+		}
+	}
+	md.CachedUploadProgress = uploadProgress
+	if md.FileSize == 0 {
+		md.CachedUploadProgress = 100
+	}
+}
@@ -0,0 +1,188 @@
+package siafile
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestNewFromParts tests concatenating siafiles and that the chunks of the new
+// file are decrypted with the keys of the parts they came from.
+func TestNewFromParts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wal, _ := newTestWAL()
+	dir := filepath.Join(os.TempDir(), "siafiles", t.Name(), hex.EncodeToString(fastrand.Bytes(8)))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	rc, err := modules.NewRSCode(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newPath := func() string {
+		return filepath.Join(dir, hex.EncodeToString(fastrand.Bytes(8))+modules.SiaFileExtension)
+	}
+	// newPart creates a siafile with random pieces for every chunk.
+	newPart := func(numChunks int, trim uint64, ec modules.ErasureCoder) *SiaFile {
+		sk := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+		chunkSize := (modules.SectorSize - sk.Type().Overhead()) * uint64(ec.MinPieces())
+		sf, err := New(newPath(), "", wal, ec, sk, uint64(numChunks)*chunkSize-trim, 0777, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+			for pieceIndex := 0; pieceIndex < ec.NumPieces(); pieceIndex++ {
+				pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+				if err := sf.AddPiece(pk, uint64(chunkIndex), uint64(pieceIndex), crypto.Hash{}); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		return sf
+	}
+	// expected are the pieces and piece keys the chunks of a file should
+	// have.
+	type expected struct {
+		pieces [][]Piece
+		key    []byte
+	}
+	chunksOf := func(sf *SiaFile) (chunks []expected) {
+		for chunkIndex := uint64(0); chunkIndex < sf.NumChunks(); chunkIndex++ {
+			pieces, err := sf.Pieces(chunkIndex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			mk, err := sf.ChunkMasterKey(chunkIndex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunks = append(chunks, expected{pieces: pieces, key: mk.Derive(chunkIndex, 1).Key()})
+		}
+		return
+	}
+	checkChunks := func(sf *SiaFile, chunks []expected) {
+		t.Helper()
+		if sf.NumChunks() != uint64(len(chunks)) {
+			t.Fatalf("expected %v chunks but got %v", len(chunks), sf.NumChunks())
+		}
+		snap, err := sf.Snapshot(modules.RandomSiaPath())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for chunkIndex, c := range chunks {
+			pieces, err := sf.Pieces(uint64(chunkIndex))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pieces, c.pieces) {
+				t.Fatalf("pieces of chunk %v don't match", chunkIndex)
+			}
+			mk, err := sf.ChunkMasterKey(uint64(chunkIndex))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(mk.Derive(uint64(chunkIndex), 1).Key(), c.key) {
+				t.Fatalf("key of chunk %v doesn't match", chunkIndex)
+			}
+			mk, err = snap.ChunkMasterKey(uint64(chunkIndex))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(mk.Derive(uint64(chunkIndex), 1).Key(), c.key) {
+				t.Fatalf("snapshot key of chunk %v doesn't match", chunkIndex)
+			}
+		}
+	}
+
+	// Concatenate two parts.
+	a, b := newPart(2, 0, rc), newPart(3, 0, rc)
+	if err := b.SetStuck(1, true); err != nil {
+		t.Fatal(err)
+	}
+	chunks := append(chunksOf(a), chunksOf(b)...)
+	ab, err := NewFromParts(newPath(), wal, []*SiaFile{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChunks(ab, chunks)
+	if ab.Size() != a.Size()+b.Size() {
+		t.Fatal("wrong size", ab.Size())
+	}
+	if !bytes.Equal(ab.MasterKey().Key(), a.MasterKey().Key()) || len(ab.staticMetadata.StaticPartKeys) != 1 {
+		t.Fatal("unexpected keys", ab.staticMetadata.StaticPartKeys)
+	}
+	if stuck, err := ab.StuckChunkByIndex(3); err != nil || !stuck || ab.NumStuckChunks() != 1 {
+		t.Fatal("stuck chunk wasn't moved over", stuck, err)
+	}
+	for _, part := range []*SiaFile{a, b} {
+		if _, err := os.Stat(part.SiaFilePath()); !os.IsNotExist(err) || !part.Deleted() {
+			t.Fatal("part wasn't deleted", err)
+		}
+	}
+
+	// Concatenate the result again behind another part and in front of a part
+	// with a padded last chunk. Every chunk is now at a different index than
+	// it was encrypted with.
+	c, d := newPart(1, 0, rc), newPart(2, 1, rc)
+	chunks = append(append(chunksOf(c), chunks...), chunksOf(d)...)
+	cabd, err := NewFromParts(newPath(), wal, []*SiaFile{c, ab, d})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChunks(cabd, chunks)
+	if cabd.Size() != c.Size()+ab.Size()+d.Size() {
+		t.Fatal("wrong size", cabd.Size())
+	}
+
+	// The keys survive reloading the file.
+	cabd, err = LoadSiaFile(cabd.SiaFilePath(), wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkChunks(cabd, chunks)
+
+	// Parts which can't be concatenated.
+	e, f := newPart(1, 1, rc), newPart(1, 0, rc)
+	if _, err := NewFromParts(newPath(), wal, []*SiaFile{e, f}); !errors.Contains(err, errConcatUnaligned) {
+		t.Fatal("expected unaligned part to be rejected", err)
+	}
+	if _, err := NewFromParts(newPath(), wal, []*SiaFile{f, f}); !errors.Contains(err, errConcatDuplicatePart) {
+		t.Fatal("expected duplicate part to be rejected", err)
+	}
+	rc2, err := modules.NewRSCode(5, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromParts(newPath(), wal, []*SiaFile{f, newPart(1, 0, rc2)}); !errors.Contains(err, errConcatRedundancyMismatch) {
+		t.Fatal("expected mismatching erasure code to be rejected", err)
+	}
+	if _, err := NewFromParts(cabd.SiaFilePath(), wal, []*SiaFile{f}); !errors.Contains(err, ErrPathOverload) {
+		t.Fatal("expected existing file to be rejected", err)
+	}
+	sk := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+	external, err := New(newPath(), "", wal, rc, NewExternalMasterKey("key", sk), 0, 0777, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromParts(newPath(), wal, []*SiaFile{f, external}); !errors.Contains(err, errConcatExternalKey) {
+		t.Fatal("expected external key to be rejected", err)
+	}
+	// The parts of failed concatenations are left untouched.
+	if f.Deleted() {
+		t.Fatal("part of failed concatenation was deleted")
+	}
+}
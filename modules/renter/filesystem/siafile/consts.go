@@ -35,6 +35,11 @@ const (
 	// length prefix for the pieces, and a 1 byte length for the Stuck field.
 	marshaledChunkOverhead = 16 + 2 + 1
 
+	// chunkExtensionHole is the flag set in the first byte of a chunk's
+	// ExtensionInfo if the chunk is a hole of a sparse file. A hole only
+	// contains zeros and therefore doesn't have any pieces.
+	chunkExtensionHole = 1 << 0
+
 	// pubKeyTablePruneThreshold is the number of unused hosts a SiaFile can
 	// store in its host key table before it is pruned.
 	pubKeyTablePruneThreshold = 50
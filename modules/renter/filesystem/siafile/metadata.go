@@ -49,12 +49,41 @@ type (
 		StaticPieceSize     uint64   `json:"piecesize"`     // size of a single piece of the file
 		LocalPath           string   `json:"localpath"`     // file to the local copy of the file used for repairing
 
+		// Tags are user defined key/value labels of the file used to search
+		// files and as hints for repairs.
+		Tags map[string]string `json:"tags,omitempty"`
+
+		// LockedUntil is the time until which the file can't be deleted or
+		// overwritten.
+		LockedUntil time.Time `json:"lockeduntil"`
+
+		// Sparse indicates that chunks of the file which only contain zeros
+		// are recorded as holes instead of being uploaded.
+		Sparse bool `json:"sparse,omitempty"`
+
 		// Fields for encryption
 		StaticMasterKey      []byte            `json:"masterkey"` // masterkey used to encrypt pieces
 		StaticMasterKeyType  crypto.CipherType `json:"masterkeytype"`
 		StaticSharingKey     []byte            `json:"sharingkey"` // key used to encrypt shared pieces
 		StaticSharingKeyType crypto.CipherType `json:"sharingkeytype"`
 
+		// StaticMasterKeyID is set for files which are encrypted with an
+		// external master key that is held by the user. The key itself is
+		// never persisted, only its ID and a hash which is used to check keys
+		// presented for downloads.
+		StaticMasterKeyID   string       `json:"masterkeyid,omitempty"`
+		StaticMasterKeyHash *crypto.Hash `json:"masterkeyhash,omitempty"`
+
+		// StaticPartKeys are the master keys of the ranges of chunks which
+		// were concatenated from other siafiles. Chunks outside of these
+		// ranges are encrypted with the file's own master key.
+		StaticPartKeys []PartKey `json:"partkeys,omitempty"`
+
+		// StaticReadOnly is set for files which were shared by another renter.
+		// Read-only files are never repaired and can't be modified, moved or
+		// locked.
+		StaticReadOnly bool `json:"readonly,omitempty"`
+
 		// Fields for partial uploads
 		DisablePartialChunk bool               `json:"disablepartialchunk"` // determines whether the file should be treated like legacy files
 		PartialChunks       []PartialChunkInfo `json:"partialchunks"`       // information about the partial chunk.
@@ -237,11 +266,68 @@ func (sf *SiaFile) LocalPath() string {
 	return sf.staticMetadata.LocalPath
 }
 
-// MasterKey returns the masterkey used to encrypt the file.
+// Tags returns a copy of the user defined tags of the file.
+func (sf *SiaFile) Tags() map[string]string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return copyTags(sf.staticMetadata.Tags)
+}
+
+// LockedUntil returns the time until which the file can't be deleted or
+// overwritten.
+func (sf *SiaFile) LockedUntil() time.Time {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.LockedUntil
+}
+
+// Sparse returns whether chunks of the file which only contain zeros are
+// recorded as holes instead of being uploaded.
+func (sf *SiaFile) Sparse() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Sparse
+}
+
+// MasterKey returns the masterkey used to encrypt the file. It returns nil if
+// the file is encrypted with an external key.
 func (sf *SiaFile) MasterKey() crypto.CipherKey {
 	return sf.staticMasterKey()
 }
 
+// MasterKeyID returns the ID of the external key the file is encrypted with
+// or an empty string if the renter holds the file's master key.
+func (sf *SiaFile) MasterKeyID() string {
+	return sf.staticMetadata.StaticMasterKeyID
+}
+
+// ReadOnly returns whether the file was shared by another renter and can't be
+// modified.
+func (sf *SiaFile) ReadOnly() bool {
+	return sf.staticMetadata.StaticReadOnly
+}
+
+// MasterKeyType returns the type of the key used to encrypt the file.
+func (sf *SiaFile) MasterKeyType() crypto.CipherType {
+	return sf.staticMetadata.StaticMasterKeyType
+}
+
+// VerifyMasterKey checks that a key which was provided for a file matches the
+// file's external key.
+func (sf *SiaFile) VerifyMasterKey(key crypto.CipherKey) error {
+	md := sf.staticMetadata
+	if md.StaticMasterKeyID == "" {
+		return errors.New("the file isn't encrypted with an external key")
+	}
+	if key == nil {
+		return ErrMasterKeyRequired
+	}
+	if key.Type() != md.StaticMasterKeyType || md.StaticMasterKeyHash == nil || externalMasterKeyHash(key) != *md.StaticMasterKeyHash {
+		return ErrMasterKeyMismatch
+	}
+	return nil
+}
+
 // Metadata returns the SiaFile's metadata, resolving any fields related to
 // partial chunks.
 func (sf *SiaFile) Metadata() Metadata {
@@ -299,6 +385,10 @@ func (md Metadata) backup() (b Metadata) {
 	b.StaticMasterKeyType = md.StaticMasterKeyType
 	b.StaticSharingKey = md.StaticSharingKey
 	b.StaticSharingKeyType = md.StaticSharingKeyType
+	b.StaticMasterKeyID = md.StaticMasterKeyID
+	b.StaticMasterKeyHash = md.StaticMasterKeyHash
+	b.StaticPartKeys = md.StaticPartKeys
+	b.StaticReadOnly = md.StaticReadOnly
 	b.StaticErasureCodeType = md.StaticErasureCodeType
 	b.StaticErasureCodeParams = md.StaticErasureCodeParams
 	b.staticErasureCode = md.staticErasureCode
@@ -337,6 +427,9 @@ func (md Metadata) backup() (b Metadata) {
 	b.GroupID = md.GroupID
 	b.ChunkOffset = md.ChunkOffset
 	b.PubKeyTableOffset = md.PubKeyTableOffset
+	b.Tags = copyTags(md.Tags)
+	b.LockedUntil = md.LockedUntil
+	b.Sparse = md.Sparse
 	// Special handling for slice since reflect.DeepEqual is false when
 	// comparing empty slice to nil.
 	if md.PartialChunks == nil {
@@ -355,11 +448,27 @@ func (md Metadata) backup() (b Metadata) {
 	return
 }
 
+// copyTags returns a copy of the tags. An empty map is copied as nil so that
+// it is omitted when the metadata is persisted.
+func copyTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(tags))
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
+
 // restore restores the metadata from a backup created with the backup() method.
 func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
 	md.FileSize = b.FileSize
 	md.LocalPath = b.LocalPath
+	md.Tags = b.Tags
+	md.LockedUntil = b.LockedUntil
+	md.Sparse = b.Sparse
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
@@ -530,6 +639,83 @@ func (sf *SiaFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetLockedUntil locks the file against deletion and modification until the
+// provided time. An active lock can only be extended.
+func (sf *SiaFile) SetLockedUntil(lockedUntil time.Time) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.staticMetadata.StaticReadOnly {
+		return modules.ErrReadOnly
+	}
+	if lockedUntil.Before(sf.staticMetadata.LockedUntil) && time.Now().Before(sf.staticMetadata.LockedUntil) {
+		return modules.ErrLockShortened
+	}
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.LockedUntil = lockedUntil
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetTags replaces the user defined tags of the file.
+func (sf *SiaFile) SetTags(tags map[string]string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Tags = copyTags(tags)
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetSparse sets whether chunks of the file which only contain zeros should be
+// recorded as holes instead of being uploaded.
+func (sf *SiaFile) SetSparse(sparse bool) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Sparse = sparse
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // Size returns the file's size.
 func (sf *SiaFile) Size() uint64 {
 	sf.mu.RLock()
@@ -584,8 +770,12 @@ func (sf *SiaFile) staticChunkSize() uint64 {
 	return sf.staticMetadata.StaticPieceSize * uint64(sf.staticMetadata.staticErasureCode.MinPieces())
 }
 
-// staticMasterKey returns the masterkey used to encrypt the file.
+// staticMasterKey returns the masterkey used to encrypt the file or nil if the
+// file is encrypted with an external key.
 func (sf *SiaFile) staticMasterKey() crypto.CipherKey {
+	if sf.staticMetadata.StaticMasterKeyID != "" {
+		return nil
+	}
 	sk, err := crypto.NewSiaKey(sf.staticMetadata.StaticMasterKeyType, sf.staticMetadata.StaticMasterKey)
 	if err != nil {
 		// This should never happen since the constructor of the SiaFile takes
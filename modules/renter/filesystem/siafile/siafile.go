@@ -29,9 +29,30 @@ var (
 	// ErrDeleted is returned when an operation failed due to the siafile being
 	// deleted already.
 	ErrDeleted = errors.New("files was deleted")
+
+	// ErrMasterKeyRequired is returned when the master key of a file which is
+	// encrypted with an external key is needed but wasn't provided.
+	ErrMasterKeyRequired = errors.New("the file is encrypted with an external key which needs to be provided")
+	// ErrMasterKeyMismatch is returned when a key is provided for a file which
+	// doesn't match the file's external key.
+	ErrMasterKeyMismatch = errors.New("the provided key doesn't match the file's external key")
+
+	// errHolePartialChunk is returned when trying to mark a partial chunk as a
+	// hole.
+	errHolePartialChunk = errors.New("a partial chunk can't be a hole")
+
+	// externalMasterKeySpecifier is used for hashing external master keys.
+	externalMasterKeySpecifier = types.NewSpecifier("ExternalKey")
 )
 
 type (
+	// externalMasterKey is a master key which is held by the user instead of
+	// the renter. Only its ID is persisted.
+	externalMasterKey struct {
+		crypto.CipherKey
+		staticID string
+	}
+
 	// SiaFile is the disk format for files uploaded to the Sia network.  It
 	// contains all the necessary information to recover a file from its hosts and
 	// allows for easy constant-time updates of the file without having to read or
@@ -94,6 +115,10 @@ type (
 	// Chunk is an exported chunk. It contains exported pieces.
 	Chunk struct {
 		Pieces [][]Piece
+
+		// Hole indicates that the chunk is a hole of a sparse file which
+		// only contains zeros.
+		Hole bool
 	}
 
 	// piece represents a single piece of a chunk on disk
@@ -163,6 +188,23 @@ func (sf *SiaFile) UnmanagedSetDeleted(deleted bool) {
 	sf.deleted = deleted
 }
 
+// UnmanagedResetLocalState clears the fields of the SiaFile's metadata which
+// only apply to the renter the file was loaded from and marks the file as
+// read-only without holding the lock. It is used for siafiles shared by other
+// renters before they are saved.
+func (sf *SiaFile) UnmanagedResetLocalState() {
+	sf.UnmanagedResetLocalPath()
+	sf.staticMetadata.LockedUntil = time.Time{}
+	sf.staticMetadata.StaticReadOnly = true
+}
+
+// UnmanagedResetLocalPath clears the path of the SiaFile's local copy without
+// holding the lock. It is used for siafiles handed over by other renters
+// before they are saved.
+func (sf *SiaFile) UnmanagedResetLocalPath() {
+	sf.staticMetadata.LocalPath = ""
+}
+
 // UnmanagedSetSiaFilePath sets the siaFilePath field of the SiaFile without
 // holding the lock.
 func (sf *SiaFile) UnmanagedSetSiaFilePath(newSiaFilePath string) {
@@ -187,6 +229,27 @@ func (c *chunk) numPieces() (numPieces int) {
 	return
 }
 
+// isHole returns whether the chunk is a hole of a sparse file.
+func (c *chunk) isHole() bool {
+	return c.ExtensionInfo[0]&chunkExtensionHole != 0
+}
+
+// NewExternalMasterKey wraps a master key which is held by the user. SiaFiles
+// which are created with such a key only persist the id of the key and a hash
+// which is used to check keys that are presented later on.
+func NewExternalMasterKey(id string, key crypto.CipherKey) crypto.CipherKey {
+	return externalMasterKey{
+		CipherKey: key,
+		staticID:  id,
+	}
+}
+
+// externalMasterKeyHash returns the hash which is persisted for an external
+// master key.
+func externalMasterKeyHash(key crypto.CipherKey) crypto.Hash {
+	return crypto.HashAll(externalMasterKeySpecifier, key.Type(), key.Key())
+}
+
 // New create a new SiaFile.
 func New(siaFilePath, source string, wal *writeaheadlog.WAL, erasureCode modules.ErasureCoder, masterKey crypto.CipherKey, fileSize uint64, fileMode os.FileMode, partialsSiaFile *SiaFile, disablePartialUpload bool) (*SiaFile, error) {
 	// TODO remove this
@@ -244,6 +307,13 @@ func New(siaFilePath, source string, wal *writeaheadlog.WAL, erasureCode modules
 		return nil, errors.New("can't create a file with a partial chunk without assigning a partialsSiaFile")
 	}
 	file.numChunks = int(numChunks)
+	// Don't persist external keys.
+	if ek, ok := masterKey.(externalMasterKey); ok {
+		keyHash := externalMasterKeyHash(ek.CipherKey)
+		file.staticMetadata.StaticMasterKey = nil
+		file.staticMetadata.StaticMasterKeyID = ek.staticID
+		file.staticMetadata.StaticMasterKeyHash = &keyHash
+	}
 	// Update cached fields for 0-Byte files.
 	if file.staticMetadata.FileSize == 0 {
 		file.staticMetadata.CachedHealth = 0
@@ -1025,6 +1095,50 @@ func (sf *SiaFile) SetStuck(index uint64, stuck bool) (err error) {
 	return sf.setStuck(index, stuck)
 }
 
+// SetHole marks the chunk at the given index as a hole of a sparse file. Since
+// a hole only contains zeros, any pieces of the chunk are dropped and the chunk
+// is considered to be fully redundant from now on.
+func (sf *SiaFile) SetHole(index uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't call SetHole on deleted file")
+	}
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return errHolePartialChunk
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	// Update cache.
+	defer sf.uploadProgressAndBytes()
+
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return err
+	}
+	if chunk.isHole() {
+		return nil
+	}
+	chunk.ExtensionInfo[0] |= chunkExtensionHole
+	chunk.Pieces = make([][]piece, sf.staticMetadata.staticErasureCode.NumPieces())
+	if chunk.Stuck {
+		chunk.Stuck = false
+		sf.staticMetadata.NumStuckChunks--
+	}
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	updates = append(updates, sf.saveChunkUpdate(chunk))
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // StuckChunkByIndex returns if the chunk at the index is marked as Stuck or not
 func (sf *SiaFile) StuckChunkByIndex(index uint64) (bool, error) {
 	sf.mu.Lock()
@@ -1258,6 +1372,11 @@ func (sf *SiaFile) goodPieces(chunk chunk, offlineMap map[string]bool, goodForRe
 	if sf.isIncompletePartialChunk(uint64(chunk.Index)) {
 		return 0, 0
 	}
+	// A hole doesn't need any pieces to be recovered.
+	if chunk.isHole() {
+		numPieces := uint64(sf.staticMetadata.staticErasureCode.NumPieces())
+		return numPieces, numPieces
+	}
 
 	for _, pieceSet := range chunk.Pieces {
 		// Remember if we encountered a goodForRenew piece or a
@@ -1443,16 +1562,17 @@ func (sf *SiaFile) setStuck(index uint64, stuck bool) (err error) {
 // bytes have been uploaded of that file in total. Note that a file may be
 // Available long before UploadProgress reaches 100%.
 func (sf *SiaFile) uploadProgressAndBytes() (float64, uint64, error) {
-	_, uploaded, err := sf.uploadedBytes()
+	_, uploaded, holes, err := sf.uploadedBytes()
 	if err != nil {
 		return 0, 0, err
 	}
-	if sf.staticMetadata.FileSize == 0 {
+	if sf.staticMetadata.FileSize == 0 || holes == uint64(sf.numChunks) {
 		// Update cache.
 		sf.staticMetadata.CachedUploadProgress = 100
 		return 100, uploaded, nil
 	}
-	desired := uint64(sf.numChunks) * modules.SectorSize * uint64(sf.staticMetadata.staticErasureCode.NumPieces())
+	// Holes don't need to be uploaded.
+	desired := (uint64(sf.numChunks) - holes) * modules.SectorSize * uint64(sf.staticMetadata.staticErasureCode.NumPieces())
 	// Update cache.
 	sf.staticMetadata.CachedUploadProgress = math.Min(100*(float64(uploaded)/float64(desired)), 100)
 	return sf.staticMetadata.CachedUploadProgress, uploaded, nil
@@ -1461,10 +1581,15 @@ func (sf *SiaFile) uploadProgressAndBytes() (float64, uint64, error) {
 // uploadedBytes indicates how many bytes of the file have been uploaded via
 // current file contracts in total as well as unique uploaded bytes. Note that
 // this includes padding and redundancy, so uploadedBytes can return a value
-// much larger than the file's original filesize.
-func (sf *SiaFile) uploadedBytes() (uint64, uint64, error) {
-	var total, unique uint64
+// much larger than the file's original filesize. It also returns the number
+// of chunks which are holes and therefore don't need to be uploaded.
+func (sf *SiaFile) uploadedBytes() (uint64, uint64, uint64, error) {
+	var total, unique, holes uint64
 	err := sf.iterateChunksReadonly(func(chunk chunk) error {
+		if chunk.isHole() {
+			holes++
+			return nil
+		}
 		for _, pieceSet := range chunk.Pieces {
 			// Move onto the next pieceSet if nothing has been uploaded yet
 			idx := CombinedChunkIndex(uint64(sf.numChunks), uint64(chunk.Index), len(sf.staticMetadata.PartialChunks))
@@ -1485,9 +1610,9 @@ func (sf *SiaFile) uploadedBytes() (uint64, uint64, error) {
 		return nil
 	})
 	if err != nil {
-		return 0, 0, errors.AddContext(err, "failed to compute uploaded bytes")
+		return 0, 0, 0, errors.AddContext(err, "failed to compute uploaded bytes")
 	}
 	// Update cache.
 	sf.staticMetadata.CachedUploadedBytes = total
-	return total, unique, nil
+	return total, unique, holes, nil
 }
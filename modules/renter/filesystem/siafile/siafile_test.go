@@ -1,6 +1,7 @@
 package siafile
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"math"
@@ -1161,6 +1162,79 @@ func TestStuckChunks(t *testing.T) {
 	}
 }
 
+// TestHoles tests marking chunks of a sparse file as holes.
+func TestHoles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a sparse siafile with a stuck piece in the first chunk.
+	sf, wal, _ := newBlankTestFileAndWAL(2)
+	if err := sf.SetSparse(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.AddPiece(types.SiaPublicKey{}, 0, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.SetStuck(0, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mark the first chunk as a hole.
+	if err := sf.SetHole(0); err != nil {
+		t.Fatal(err)
+	}
+	if sf.NumStuckChunks() != 0 {
+		t.Fatal("hole shouldn't be stuck", sf.NumStuckChunks())
+	}
+
+	// Reload the file and check the hole.
+	sf, err := LoadSiaFile(sf.SiaFilePath(), wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Sparse() {
+		t.Fatal("file should be sparse")
+	}
+	chunk, err := sf.Chunk(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !chunk.isHole() || chunk.numPieces() != 0 {
+		t.Fatal("chunk should be a hole without pieces", chunk.numPieces())
+	}
+	numPieces := uint64(sf.ErasureCode().NumPieces())
+	if renew, upload := sf.GoodPieces(0, nil, nil); renew != numPieces || upload != numPieces {
+		t.Fatal("hole should have all good pieces", renew, upload)
+	}
+	if h, _, repairBytes, err := sf.ChunkHealth(0, nil, nil); err != nil || h != 0 || repairBytes != 0 {
+		t.Fatal("hole should have full health", h, repairBytes, err)
+	}
+	if h, _, _, err := sf.ChunkHealth(1, nil, nil); err != nil || h == 0 {
+		t.Fatal("second chunk shouldn't have full health", h, err)
+	}
+	_, unique, holes, err := sf.uploadedBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unique != 0 || holes != 1 {
+		t.Fatal("wrong uploaded bytes or holes", unique, holes)
+	}
+
+	// The snapshot should know about the hole.
+	snap, err := sf.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snap.IsHole(0) || snap.IsHole(1) {
+		t.Fatal("wrong holes in snapshot")
+	}
+	if err := ensureMetadataValid(sf.Metadata()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestUploadedBytes tests that uploadedBytes() returns the expected values for
 // total and unique uploaded bytes.
 func TestUploadedBytes(t *testing.T) {
@@ -1180,7 +1254,7 @@ func TestUploadedBytes(t *testing.T) {
 			t.Fatal(err)
 		}
 	}
-	totalBytes, uniqueBytes, err := f.uploadedBytes()
+	totalBytes, uniqueBytes, _, err := f.uploadedBytes()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1281,6 +1355,55 @@ func TestFileExpiration(t *testing.T) {
 	}
 }
 
+// TestExternalMasterKey probes creating a file which is encrypted with a key
+// the renter doesn't persist.
+func TestExternalMasterKey(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(1, false)
+	f, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, NewExternalMasterKey("key", sk), fileSize, numChunks, fileMode)
+
+	// The key isn't persisted, not even after reloading the file.
+	f, err := LoadSiaFile(f.SiaFilePath(), wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.MasterKey() != nil || len(f.staticMetadata.StaticMasterKey) != 0 {
+		t.Fatal("external key was persisted")
+	}
+	if f.MasterKeyID() != "key" || f.MasterKeyType() != sk.Type() {
+		t.Fatal("unexpected key info", f.MasterKeyID(), f.MasterKeyType())
+	}
+
+	// Only the original key is accepted.
+	if err := f.VerifyMasterKey(sk); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.VerifyMasterKey(nil); !errors.Contains(err, ErrMasterKeyRequired) {
+		t.Fatal("unexpected error", err)
+	}
+	if err := f.VerifyMasterKey(crypto.GenerateSiaKey(sk.Type())); !errors.Contains(err, ErrMasterKeyMismatch) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// A snapshot uses the key it is given.
+	snap, err := f.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.MasterKey() != nil || !bytes.Equal(snap.WithMasterKey(sk).MasterKey().Key(), sk.Key()) {
+		t.Fatal("unexpected snapshot key")
+	}
+
+	// Regular files can't be verified against an external key.
+	if err := newBlankTestFile().VerifyMasterKey(sk); err == nil {
+		t.Fatal("expected verification of a regular file to fail")
+	}
+}
+
 // BenchmarkLoadSiaFile benchmarks loading an existing siafile's metadata into
 // memory.
 func BenchmarkLoadSiaFile(b *testing.B) {
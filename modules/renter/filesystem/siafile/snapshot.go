@@ -29,6 +29,7 @@ type (
 		staticSiaPath         modules.SiaPath
 		staticLocalPath       string
 		staticPartialChunks   []PartialChunkInfo
+		staticPartKeys        []PartKey
 		staticUID             SiafileUID
 	}
 )
@@ -149,16 +150,38 @@ func (s *Snapshot) LocalPath() string {
 	return s.staticLocalPath
 }
 
-// MasterKey returns the masterkey used to encrypt the file.
+// MasterKey returns the masterkey used to encrypt the file. It returns nil if
+// the file is encrypted with an external key which wasn't provided.
 func (s *Snapshot) MasterKey() crypto.CipherKey {
 	return s.staticMasterKey
 }
 
+// ChunkMasterKey returns the key which derives the piece keys of the chunk at
+// the given index. It returns nil if the file is encrypted with an external
+// key which wasn't provided.
+func (s *Snapshot) ChunkMasterKey(chunkIndex uint64) (crypto.CipherKey, error) {
+	return chunkMasterKey(s.staticPartKeys, s.staticMasterKey, chunkIndex)
+}
+
+// WithMasterKey returns a copy of the snapshot which uses the provided master
+// key. It is used for files which are encrypted with an external key.
+func (s *Snapshot) WithMasterKey(key crypto.CipherKey) *Snapshot {
+	snap := *s
+	snap.staticMasterKey = key
+	return &snap
+}
+
 // Mode returns the FileMode of the file.
 func (s *Snapshot) Mode() os.FileMode {
 	return s.staticMode
 }
 
+// IsHole returns whether the chunk at the given index is a hole of a sparse
+// file.
+func (s *Snapshot) IsHole(chunkIndex uint64) bool {
+	return s.staticChunks[chunkIndex].Hole
+}
+
 // NumChunks returns the number of chunks the file consists of. This will
 // return the number of chunks the file consists of even if the file is not
 // fully uploaded yet.
@@ -271,6 +294,7 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 		}
 		exportedChunks = append(exportedChunks, Chunk{
 			Pieces: pieces,
+			Hole:   chunk.isHole(),
 		})
 	}
 	// Get non-static metadata fields under lock.
@@ -284,6 +308,7 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 	return &Snapshot{
 		staticChunks:          exportedChunks,
 		staticPartialChunks:   pcs,
+		staticPartKeys:        sf.staticMetadata.StaticPartKeys,
 		staticHasPartialChunk: hasPartial,
 		staticFileSize:        fileSize,
 		staticPieceSize:       sf.staticMetadata.StaticPieceSize,
@@ -0,0 +1,216 @@
+package renter
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultBaseRPCGougingFraction is the fraction of the allowance at which
+	// we consider the base cost of the RPCs with a host to be too expensive.
+	// E.g. the base cost of the RPCs needed to download and upload the
+	// expected amount of data during a period should never exceed 1% of the
+	// total allowance.
+	defaultBaseRPCGougingFraction = .01
+
+	// defaultRegistryGougingFraction sets the fraction to 1/4 like the
+	// download and upload fractions since registry lookups and updates are
+	// checked using the download and upload gouging checks.
+	defaultRegistryGougingFraction = 0.25
+)
+
+var (
+	// errBaseRPCGouging is returned when the base cost of the RPCs with a host
+	// is considered too expensive.
+	errBaseRPCGouging = errors.New("price table rejected due to base rpc price gouging")
+
+	// errGougingFractionInvalid is returned when setting a gouging threshold
+	// outside of the range [0, 1].
+	errGougingFractionInvalid = errors.New("gouging fractions must be between 0 and 1")
+)
+
+// gougingThresholds are the thresholds of the price gouging checks with the
+// defaults applied. A threshold of 0 disables the rule.
+type gougingThresholds struct {
+	baseRPC     float64
+	download    float64
+	fundAccount float64
+	priceTable  float64
+	registry    float64
+	upload      float64
+}
+
+// gougingThresholdsWithDefaults returns the thresholds of the provided gouging
+// settings with the defaults applied to the unset ones.
+func gougingThresholdsWithDefaults(gs modules.GougingSettings) gougingThresholds {
+	withDefault := func(f *float64, def float64) float64 {
+		if f == nil {
+			return def
+		}
+		return *f
+	}
+	return gougingThresholds{
+		baseRPC:     withDefault(gs.BaseRPCMaxFraction, defaultBaseRPCGougingFraction),
+		download:    withDefault(gs.DownloadUsageFraction, defaultDownloadGougingFraction),
+		fundAccount: withDefault(gs.FundAccountMaxFraction, defaultFundAccountGougingFraction),
+		priceTable:  withDefault(gs.PriceTableMaxFraction, defaultPriceTableGougingFraction),
+		registry:    withDefault(gs.RegistryUsageFraction, defaultRegistryGougingFraction),
+		upload:      withDefault(gs.UploadUsageFraction, defaultUploadGougingFraction),
+	}
+}
+
+// settings returns the thresholds as gouging settings with every threshold
+// set.
+func (gt gougingThresholds) settings() modules.GougingSettings {
+	return modules.GougingSettings{
+		BaseRPCMaxFraction:     &gt.baseRPC,
+		DownloadUsageFraction:  &gt.download,
+		FundAccountMaxFraction: &gt.fundAccount,
+		PriceTableMaxFraction:  &gt.priceTable,
+		RegistryUsageFraction:  &gt.registry,
+		UploadUsageFraction:    &gt.upload,
+	}
+}
+
+// copyGougingSettings returns a deep copy of the provided gouging settings.
+func copyGougingSettings(gs modules.GougingSettings) modules.GougingSettings {
+	copyFraction := func(f *float64) *float64 {
+		if f == nil {
+			return nil
+		}
+		c := *f
+		return &c
+	}
+	return modules.GougingSettings{
+		BaseRPCMaxFraction:     copyFraction(gs.BaseRPCMaxFraction),
+		DownloadUsageFraction:  copyFraction(gs.DownloadUsageFraction),
+		FundAccountMaxFraction: copyFraction(gs.FundAccountMaxFraction),
+		PriceTableMaxFraction:  copyFraction(gs.PriceTableMaxFraction),
+		RegistryUsageFraction:  copyFraction(gs.RegistryUsageFraction),
+		UploadUsageFraction:    copyFraction(gs.UploadUsageFraction),
+	}
+}
+
+// validateGougingSettings returns an error if any of the set gouging
+// thresholds is outside of the range [0, 1].
+func validateGougingSettings(gs modules.GougingSettings) error {
+	for _, f := range []*float64{gs.BaseRPCMaxFraction, gs.DownloadUsageFraction, gs.FundAccountMaxFraction, gs.PriceTableMaxFraction, gs.RegistryUsageFraction, gs.UploadUsageFraction} {
+		if f != nil && (*f < 0 || *f > 1) {
+			return errGougingFractionInvalid
+		}
+	}
+	return nil
+}
+
+// checkBaseRPCGouging verifies that the base cost of the RPCs needed to
+// download and upload the amount of data the allowance expects during a
+// period is reasonable. The number of RPCs is estimated by assuming that every
+// RPC transfers StreamDownloadSize or StreamUploadSize bytes. The fraction is
+// the fraction of the allowance which may be spent on the base cost, 0
+// disables the check.
+func checkBaseRPCGouging(pt modules.RPCPriceTable, allowance modules.Allowance, fraction float64) error {
+	// If there is no allowance, price gouging checks have to be disabled,
+	// because there is no baseline for understanding what might count as price
+	// gouging.
+	if allowance.Funds.IsZero() || fraction == 0 {
+		return nil
+	}
+
+	period := uint64(allowance.Period)
+	numRPCs := allowance.ExpectedDownload*period/modules.StreamDownloadSize + allowance.ExpectedUpload*period/modules.StreamUploadSize
+	totalBaseCost := pt.InitBaseCost.Mul64(numRPCs)
+	if totalBaseCost.Cmp(allowance.Funds.MulFloat(fraction)) > 0 {
+		return fmt.Errorf("base rpc cost %v is considered too high, the total cost of the expected rpcs during a period exceeds %v%% of the allowance - price gouging protection enabled", pt.InitBaseCost, fraction*100)
+	}
+	return nil
+}
+
+// managedGougingThresholds returns the gouging thresholds from the renter's
+// settings with the defaults applied.
+func (r *Renter) managedGougingThresholds() gougingThresholds {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return gougingThresholdsWithDefaults(r.persist.Gouging)
+}
+
+// GougingReport checks the hosts of the renter's workers against every price
+// gouging rule and reports the hosts which are filtered. Rules which depend on
+// the host's price table are only checked for hosts with a valid price table.
+func (r *Renter) GougingReport() (modules.GougingReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.GougingReport{}, err
+	}
+	defer r.tg.Done()
+
+	allowance := r.hostContractor.Allowance()
+	gt := r.managedGougingThresholds()
+	workers := r.staticWorkerPool.callWorkers()
+	report := modules.GougingReport{
+		Settings: gt.settings(),
+		NumHosts: len(workers),
+		Hosts:    []modules.GougingHost{},
+	}
+	for _, w := range workers {
+		var violations []modules.GougingViolation
+		addViolation := func(rule string, err error) {
+			if err != nil {
+				violations = append(violations, modules.GougingViolation{
+					Rule:   rule,
+					Reason: err.Error(),
+				})
+			}
+		}
+
+		// Check the rules depending on the host's settings.
+		host, ok, err := r.hostDB.Host(w.staticHostPubKey)
+		if err != nil {
+			return modules.GougingReport{}, errors.AddContext(err, "failed to get host "+w.staticHostPubKeyStr)
+		}
+		if ok {
+			addViolation(modules.GougingRuleUpload, checkUploadGouging(allowance, host.HostExternalSettings, gt.upload))
+			addViolation(modules.GougingRuleRegistry, checkUploadGouging(allowance, host.HostExternalSettings, gt.registry))
+		}
+
+		// Check the rules depending on the host's price table. A price table
+		// which was rejected for gouging is never set.
+		wpt := w.staticPriceTable()
+		if wpt.staticValid() {
+			pt := wpt.staticPriceTable
+			err = checkDownloadGouging(allowance, &pt, gt.download)
+			if err == nil {
+				err = checkProjectDownloadGouging(pt, allowance, gt.download)
+			}
+			addViolation(modules.GougingRuleDownload, err)
+			if !containsGougingRule(violations, modules.GougingRuleRegistry) {
+				addViolation(modules.GougingRuleRegistry, checkProjectDownloadGouging(pt, allowance, gt.registry))
+			}
+			addViolation(modules.GougingRuleBaseRPC, checkBaseRPCGouging(pt, allowance, gt.baseRPC))
+			addViolation(modules.GougingRulePriceTable, checkUpdatePriceTableGouging(pt, allowance, gt.priceTable))
+			addViolation(modules.GougingRuleFundAccount, checkFundAccountGouging(pt, allowance, w.staticCache().staticAccountBalanceTarget, gt.fundAccount))
+		} else if errors.Contains(wpt.staticRecentErr, errBaseRPCGouging) {
+			addViolation(modules.GougingRuleBaseRPC, wpt.staticRecentErr)
+		} else if errors.Contains(wpt.staticRecentErr, errPriceTableGouging) {
+			addViolation(modules.GougingRulePriceTable, wpt.staticRecentErr)
+		}
+		if len(violations) > 0 {
+			report.Hosts = append(report.Hosts, modules.GougingHost{
+				HostPublicKey: w.staticHostPubKey,
+				Violations:    violations,
+			})
+		}
+	}
+	return report, nil
+}
+
+// containsGougingRule returns whether the violations contain the rule.
+func containsGougingRule(violations []modules.GougingViolation, rule string) bool {
+	for _, v := range violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestGougingThresholdsWithDefaults probes applying the default gouging
+// thresholds.
+func TestGougingThresholdsWithDefaults(t *testing.T) {
+	gt := gougingThresholdsWithDefaults(modules.GougingSettings{})
+	expected := gougingThresholds{
+		baseRPC:     defaultBaseRPCGougingFraction,
+		download:    defaultDownloadGougingFraction,
+		fundAccount: defaultFundAccountGougingFraction,
+		priceTable:  defaultPriceTableGougingFraction,
+		registry:    defaultRegistryGougingFraction,
+		upload:      defaultUploadGougingFraction,
+	}
+	if gt != expected {
+		t.Fatal("unexpected defaults", gt)
+	}
+	if gougingThresholdsWithDefaults(gt.settings()) != expected {
+		t.Fatal("settings of the thresholds don't round trip")
+	}
+
+	// Set thresholds are kept, including 0 which disables a rule.
+	upload, fundAccount := 0.5, 0.0
+	gt = gougingThresholdsWithDefaults(modules.GougingSettings{
+		FundAccountMaxFraction: &fundAccount,
+		UploadUsageFraction:    &upload,
+	})
+	if gt.upload != 0.5 || gt.fundAccount != 0 || gt.download != defaultDownloadGougingFraction {
+		t.Fatal("unexpected thresholds", gt)
+	}
+
+	// Fractions outside of [0, 1] are invalid.
+	registry := -0.1
+	if err := validateGougingSettings(modules.GougingSettings{RegistryUsageFraction: &registry}); err != errGougingFractionInvalid {
+		t.Fatal("expected invalid fraction", err)
+	}
+	if err := validateGougingSettings(expected.settings()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCopyGougingSettings checks that copied gouging settings don't share
+// their thresholds with the original.
+func TestCopyGougingSettings(t *testing.T) {
+	upload := 0.5
+	gs := modules.GougingSettings{UploadUsageFraction: &upload}
+	c := copyGougingSettings(gs)
+	upload = 1
+	if c.UploadUsageFraction == nil || *c.UploadUsageFraction != 0.5 || c.DownloadUsageFraction != nil {
+		t.Fatal("unexpected copy", c)
+	}
+}
+
+// TestUploadGougingFraction verifies that the upload gouging check respects
+// the configured fraction and that a higher fraction is stricter.
+func TestUploadGougingFraction(t *testing.T) {
+	allowance := modules.Allowance{
+		Funds:           types.SiacoinPrecision,
+		Period:          1,
+		ExpectedStorage: modules.StreamUploadSize,
+	}
+	hostSettings := modules.HostExternalSettings{
+		UploadBandwidthPrice: types.SiacoinPrecision.Mul64(2).Div64(modules.StreamUploadSize),
+	}
+
+	// Uploading a quarter of the expected storage costs half of the funds.
+	if err := checkUploadGouging(allowance, hostSettings, defaultUploadGougingFraction); err != nil {
+		t.Fatal(err)
+	}
+	// Uploading all of it costs twice the funds.
+	if err := checkUploadGouging(allowance, hostSettings, 1); err == nil {
+		t.Fatal("expected price gouging")
+	}
+	// A fraction of 0 disables the check.
+	hostSettings.UploadBandwidthPrice = types.SiacoinPrecision.Mul64(1e6)
+	if err := checkUploadGouging(allowance, hostSettings, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheckBaseRPCGouging verifies that the base rpc gouging check respects
+// the configured fraction and that a lower fraction is stricter.
+func TestCheckBaseRPCGouging(t *testing.T) {
+	allowance := modules.Allowance{
+		Funds:            types.SiacoinPrecision.Mul64(100),
+		Period:           10,
+		ExpectedDownload: modules.StreamDownloadSize * 5,
+		ExpectedUpload:   modules.StreamUploadSize * 5,
+	}
+	// 100 RPCs are expected during the period, costing 10% of the funds.
+	pt := modules.RPCPriceTable{
+		InitBaseCost: types.SiacoinPrecision.Div64(10),
+		Validity:     time.Minute,
+	}
+	if err := checkBaseRPCGouging(pt, allowance, 0.1); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkBaseRPCGouging(pt, allowance, defaultBaseRPCGougingFraction); err == nil {
+		t.Fatal("expected price gouging")
+	}
+	// A fraction of 0 disables the check.
+	if err := checkBaseRPCGouging(pt, allowance, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Without an allowance there is no baseline.
+	if err := checkBaseRPCGouging(pt, modules.Allowance{}, defaultBaseRPCGougingFraction); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -90,6 +90,9 @@ const (
 	// skew.
 	storageSkewMultiplier = 1.75
 
+	// scanPacketSize is the packet size used to limit the bandwidth of scans.
+	scanPacketSize = 4 * 4096
+
 	// txnFeesUpdateRatio is the amount of change we tolerate in the txnFees
 	// before we rebuild the hosttree.
 	txnFeesUpdateRatio = 0.05 // 5%
@@ -105,9 +108,9 @@ var (
 		Testing:  int(5),
 	}).(int)
 
-	// scanningThreads is the number of threads that will be probing hosts for
-	// their settings and checking for reliability.
-	maxScanningThreads = build.Select(build.Var{
+	// defaultMaxScanningThreads is the default number of threads that will be
+	// probing hosts for their settings and checking for reliability.
+	defaultMaxScanningThreads = build.Select(build.Var{
 		Standard: int(80),
 		Testnet:  int(80),
 		Dev:      int(4),
@@ -125,6 +128,15 @@ var (
 		Testing:  time.Second * 5,
 	}).(time.Duration)
 
+	// minScanInterval is the minimum scan interval which can be set by the
+	// user.
+	minScanInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Testnet:  10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
 	// scanCheckInterval is the interval used when waiting for the scanList to
 	// empty itself and for waiting on the consensus set to be synced.
 	scanCheckInterval = build.Select(build.Var{
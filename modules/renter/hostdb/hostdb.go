@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/siamux"
 	"gitlab.com/NebulousLabs/threadgroup"
 
@@ -189,6 +190,14 @@ type HostDB struct {
 	scanningThreads         int
 	synced                  bool
 
+	// scanSettings are the settings set by the user to control the scans.
+	// staticScanRL limits the bandwidth of all scans and
+	// staticScanSettingsChanged wakes up the scan loop when the settings
+	// change.
+	scanSettings              modules.HostDBScanSettings
+	staticScanRL              *ratelimit.RateLimit
+	staticScanSettingsChanged chan struct{}
+
 	// staticFilteredTree is a hosttree that only contains the hosts that align
 	// with the filterMode. The filteredHosts are the hosts that are submitted
 	// with the filterMode to determine which host should be in the
@@ -348,6 +357,9 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		knownContracts:  make(map[string]contractInfo),
 		scanMap:         make(map[string]struct{}),
 		staticAlerter:   modules.NewAlerter("hostdb"),
+
+		staticScanRL:              ratelimit.NewRateLimit(0, 0, 0),
+		staticScanSettingsChanged: make(chan struct{}, 1),
 	}
 
 	// Set the allowance, txnFees and hostweight function.
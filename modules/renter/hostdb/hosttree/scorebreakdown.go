@@ -24,6 +24,7 @@ type HostAdjustments struct {
 	CollateralAdjustment       float64
 	DurationAdjustment         float64
 	InteractionAdjustment      float64
+	PerformanceAdjustment      float64
 	PriceAdjustment            float64
 	StorageRemainingAdjustment float64
 	UptimeAdjustment           float64
@@ -76,6 +77,7 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		CollateralAdjustment:       h.CollateralAdjustment,
 		DurationAdjustment:         h.DurationAdjustment,
 		InteractionAdjustment:      h.InteractionAdjustment,
+		PerformanceAdjustment:      h.PerformanceAdjustment,
 		PriceAdjustment:            h.PriceAdjustment,
 		StorageRemainingAdjustment: h.StorageRemainingAdjustment,
 		UptimeAdjustment:           h.UptimeAdjustment,
@@ -94,6 +96,7 @@ func (h HostAdjustments) Score() types.Currency {
 		h.CollateralAdjustment *
 		h.DurationAdjustment *
 		h.InteractionAdjustment *
+		h.PerformanceAdjustment *
 		h.PriceAdjustment *
 		h.StorageRemainingAdjustment *
 		h.UptimeAdjustment *
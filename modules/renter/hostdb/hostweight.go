@@ -57,6 +57,15 @@ const (
 	// the bad points do not rack up very quickly.
 	interactionExponentiation = 10
 
+	// failedBenchmarkPenalty is the adjustment applied to a host whose most
+	// recent benchmark failed.
+	failedBenchmarkPenalty = 0.5
+
+	// performanceExponentiation is the power to which we raise the
+	// performance adjustment. The sublinear number ensures that measured
+	// performance doesn't outweigh prices and uptime.
+	performanceExponentiation = 0.5
+
 	// priceExponentiationLarge is the number of times that the weight is
 	// divided by the price when the price is large relative to the allowance.
 	// The exponentiation is a lot higher because we care greatly about high
@@ -83,6 +92,20 @@ const (
 	priceFloor = 0.1
 )
 
+var (
+	// benchmarkMaxAge is the age after which a benchmark is no longer
+	// considered when scoring a host.
+	benchmarkMaxAge = 7 * 24 * time.Hour
+
+	// benchmarkTargetLatency is the read latency of a benchmark below which a
+	// host isn't penalized.
+	benchmarkTargetLatency = 500 * time.Millisecond
+
+	// benchmarkTargetThroughput is the read and write throughput of a
+	// benchmark in bytes per second above which a host isn't penalized.
+	benchmarkTargetThroughput = uint64(1 << 21) // 2 MiB/s
+)
+
 // basePriceAdjustments will adjust the weight of the entry according to the prices
 // that it has set for BaseRPCPrice and SectorAccessPrice
 func (hdb *HostDB) basePriceAdjustments(entry modules.HostDBEntry) float64 {
@@ -231,6 +254,28 @@ func (hdb *HostDB) interactionAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(ratio, interactionExponentiation)
 }
 
+// performanceAdjustments penalizes a host according to its most recent
+// benchmark. Hosts which were not benchmarked recently are not penalized.
+func performanceAdjustments(entry modules.HostDBEntry) float64 {
+	b := entry.Benchmark
+	if b == nil || time.Since(b.Time) > benchmarkMaxAge {
+		return 1
+	}
+	if b.Error != "" {
+		return failedBenchmarkPenalty
+	}
+	adjustment := 1.0
+	if b.ReadLatency > benchmarkTargetLatency {
+		adjustment *= float64(benchmarkTargetLatency) / float64(b.ReadLatency)
+	}
+	for _, throughput := range []uint64{b.ReadThroughput, b.WriteThroughput} {
+		if throughput < benchmarkTargetThroughput {
+			adjustment *= float64(throughput) / float64(benchmarkTargetThroughput)
+		}
+	}
+	return math.Pow(adjustment, performanceExponentiation)
+}
+
 // priceAdjustments will adjust the weight of the entry according to the prices
 // that it has set.
 //
@@ -577,6 +622,7 @@ func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hos
 			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
 			InteractionAdjustment:      hdb.interactionAdjustments(entry),
+			PerformanceAdjustment:      performanceAdjustments(entry),
 			PriceAdjustment:            hdb.priceAdjustments(entry, allowance, txnFees),
 			StorageRemainingAdjustment: hdb.storageRemainingAdjustments(entry, allowance),
 			UptimeAdjustment:           hdb.uptimeAdjustments(entry),
@@ -746,3 +746,58 @@ func TestHostWeightAcceptContract(t *testing.T) {
 		t.Error("Entry2 should have smallest weight")
 	}
 }
+
+// TestHostWeightPerformance probes the performance adjustment of hosts which
+// were benchmarked.
+func TestHostWeightPerformance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	err := hdb.SetAllowance(DefaultTestAllowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fast := modules.HostBenchmark{
+		Time:            time.Now(),
+		ReadLatency:     benchmarkTargetLatency / 2,
+		ReadThroughput:  benchmarkTargetThroughput * 2,
+		WriteThroughput: benchmarkTargetThroughput * 2,
+	}
+	slow := fast
+	slow.ReadLatency = benchmarkTargetLatency * 4
+	slow.ReadThroughput = benchmarkTargetThroughput / 4
+	failed := fast
+	failed.Error = "failed"
+	old := slow
+	old.Time = time.Now().Add(-2 * benchmarkMaxAge)
+
+	// Hosts which weren't benchmarked recently and fast hosts aren't
+	// penalized.
+	entry := DefaultHostDBEntry
+	for _, b := range []*modules.HostBenchmark{nil, &fast, &old} {
+		entry.Benchmark = b
+		if adjustment := performanceAdjustments(entry); adjustment != 1 {
+			t.Fatal("host shouldn't be penalized", adjustment)
+		}
+	}
+
+	// Slow hosts and hosts which failed the benchmark have a lower weight.
+	entry.Benchmark = &fast
+	wFast := hdb.weightFunc(entry).Score()
+	entry.Benchmark = &slow
+	if adjustment := performanceAdjustments(entry); adjustment != math.Pow(1.0/16, performanceExponentiation) {
+		t.Fatal("wrong adjustment for slow host", adjustment)
+	}
+	wSlow := hdb.weightFunc(entry).Score()
+	entry.Benchmark = &failed
+	if adjustment := performanceAdjustments(entry); adjustment != failedBenchmarkPenalty {
+		t.Fatal("wrong adjustment for failed benchmark", adjustment)
+	}
+	wFailed := hdb.weightFunc(entry).Score()
+	if wFast.Cmp(wSlow) <= 0 || wFast.Cmp(wFailed) <= 0 {
+		t.Fatal("fast host should have a higher weight", wFast, wSlow, wFailed)
+	}
+}
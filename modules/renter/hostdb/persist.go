@@ -33,6 +33,7 @@ type hdbPersist struct {
 	LastChange               modules.ConsensusChangeID
 	FilteredHosts            map[string]types.SiaPublicKey
 	FilterMode               modules.FilterMode
+	ScanSettings             modules.HostDBScanSettings
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -45,6 +46,7 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.LastChange = hdb.lastChange
 	data.FilteredHosts = hdb.filteredHosts
 	data.FilterMode = hdb.filterMode
+	data.ScanSettings = hdb.scanSettings
 	return data
 }
 
@@ -70,6 +72,8 @@ func (hdb *HostDB) load() error {
 	hdb.knownContracts = data.KnownContracts
 	hdb.filteredHosts = data.FilteredHosts
 	hdb.filterMode = data.FilterMode
+	hdb.scanSettings = data.ScanSettings
+	hdb.setScanBandwidth(data.ScanSettings.MaxScanBandwidth)
 
 	// Overwrite the initialized filteredDomains with the data loaded
 	// from disk
@@ -13,6 +13,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/siamux"
 	"gitlab.com/NebulousLabs/siamux/mux"
 
@@ -119,8 +120,8 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 
 	// Sanity check - the scan map and the scan list should have the same
 	// length.
-	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+maxScanningThreads {
-		hdb.staticLog.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), maxScanningThreads)
+	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+hdb.maxScanningThreads() {
+		hdb.staticLog.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), hdb.maxScanningThreads())
 	}
 
 	// Nobody is emptying the scan list, create and run a scan thread.
@@ -182,7 +183,7 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 			}
 
 			// Create new worker thread.
-			if hdb.scanningThreads < maxScanningThreads || !starterThread {
+			if hdb.scanningThreads < hdb.maxScanningThreads() || !starterThread {
 				starterThread = true
 				hdb.scanningThreads++
 				if err := hdb.tg.Add(); err != nil {
@@ -362,20 +363,33 @@ func (hdb *HostDB) staticLookupIPNets(address modules.NetAddress) (ipNets []stri
 	return
 }
 
+// staticDialHost dials the announced addresses of a host in order and returns
+// a connection to the first one which is reachable, together with that
+// address.
+func (hdb *HostDB) staticDialHost(dialer *net.Dialer, entry modules.HostDBEntry) (net.Conn, modules.NetAddress, error) {
+	var errs error
+	for _, addr := range entry.NetAddresses() {
+		// If we use a custom resolver for testing, we replace the custom
+		// domain with 127.0.0.1. Otherwise the scan will fail.
+		dialAddr := addr
+		if hdb.staticDeps.Disrupt("customResolver") {
+			dialAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", addr.Port()))
+		}
+		conn, err := dialer.Dial("tcp", string(dialAddr))
+		if err == nil {
+			return conn, addr, nil
+		}
+		errs = errors.Compose(errs, errors.AddContext(err, fmt.Sprintf("unable to dial %v", addr)))
+	}
+	return nil, "", errs
+}
+
 // managedScanHost will connect to a host and grab the settings, verifying
 // uptime and updating to the host's preferences.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// Request settings from the queued host entry.
-	netAddr := entry.NetAddress
 	pubKey := entry.PublicKey
-	hdb.staticLog.Debugf("Scanning host %v at %v", pubKey, netAddr)
-
-	// If we use a custom resolver for testing, we replace the custom domain
-	// with 127.0.0.1. Otherwise the scan will fail.
-	if hdb.staticDeps.Disrupt("customResolver") {
-		port := netAddr.Port()
-		netAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
-	}
+	hdb.staticLog.Debugf("Scanning host %v at %v", pubKey, entry.NetAddresses())
 
 	// Resolve the host's used subnets and update the timestamp if they
 	// changed. We only update the timestamp if resolving the ipNets was
@@ -395,6 +409,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	hdb.mu.Unlock()
 
 	var settings modules.HostExternalSettings
+	var reachableAddr modules.NetAddress
 	var latency time.Duration
 	err = func() error {
 		timeout := hostRequestTimeout
@@ -420,11 +435,13 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			Timeout: timeout,
 		}
 		start := time.Now()
-		conn, err := dialer.Dial("tcp", string(netAddr))
+		var conn net.Conn
+		conn, reachableAddr, err = hdb.staticDialHost(dialer, entry)
 		latency = time.Since(start)
 		if err != nil {
 			return err
 		}
+		conn = ratelimit.NewRLConn(conn, hdb.staticScanRL, hdb.tg.StopChan())
 		// Create go routine that will close the channel if the hostdb shuts
 		// down or when this method returns as signalled by closing the
 		// connCloseChan channel
@@ -467,16 +484,17 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			settings.MaxEphemeralAccountBalance = modules.CompatV1412DefaultMaxEphemeralAccountBalance
 		}
 
-		// Need to apply the custom resolver to the siamux address.
-		siamuxAddr := settings.SiaMuxAddress()
+		// The siamux is expected to be reachable at the same address as the
+		// host. Need to apply the custom resolver to the siamux address.
+		siamuxAddr := net.JoinHostPort(reachableAddr.Host(), settings.SiaMuxPort)
 		if hdb.staticDeps.Disrupt("customResolver") {
-			port := modules.NetAddress(siamuxAddr).Port()
-			siamuxAddr = fmt.Sprintf("127.0.0.1:%s", port)
+			siamuxAddr = fmt.Sprintf("127.0.0.1:%s", settings.SiaMuxPort)
 		}
 
 		// Try opening a connection to the siamux, this is a very lightweight
 		// way of checking that RHP3 is supported.
-		_, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, modules.SiaPKToMuxPK(entry.PublicKey))
+		compress := build.VersionCmp(settings.Version, modules.MinRPCCompressionVersion) >= 0
+		_, err = fetchPriceTable(hdb.staticMux, hdb.staticScanRL, siamuxAddr, timeout, modules.SiaPKToMuxPK(entry.PublicKey), compress)
 		if err != nil {
 			hdb.staticLog.Debugf("%v siamux ping not successful: %v\n", entry.PublicKey, err)
 			return err
@@ -488,6 +506,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	} else {
 		hdb.staticLog.Debugf("Scan of host at %v succeeded.", pubKey)
 		entry.HostExternalSettings = settings
+		entry.ReachableNetAddress = reachableAddr
 	}
 	success := err == nil
 
@@ -498,6 +517,10 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	oldEntry, exists := hdb.staticHostTree.Select(entry.PublicKey)
 	if exists {
 		entry.NetAddress = oldEntry.NetAddress
+		entry.AlternativeNetAddresses = oldEntry.AlternativeNetAddresses
+		if !isAnnouncedAddress(entry, entry.ReachableNetAddress) {
+			entry.ReachableNetAddress = ""
+		}
 	}
 	// Update the host tree to have a new entry, including the new error. Then
 	// delete the entry from the scan map as the scan has been successful.
@@ -661,14 +684,23 @@ func (hdb *HostDB) threadedScan() {
 		// scanning. The minimums and maximums keep the scan time reasonable,
 		// while the randomness prevents the scanning from always happening at
 		// the same time of day or week.
+		// If the user set a scan interval, it is used instead.
 		sleepRange := uint64(maxScanSleep - minScanSleep)
-		sleepTime := minScanSleep + time.Duration(fastrand.Uint64n(sleepRange))
+		randomSleep := minScanSleep + time.Duration(fastrand.Uint64n(sleepRange))
 
-		// Sleep until it's time for the next scan cycle.
-		select {
-		case <-hdb.tg.StopChan():
-			return
-		case <-time.After(sleepTime):
+		// Sleep until it's time for the next scan cycle. If the scan settings
+		// change, the remaining time is computed again.
+		sleepStart := time.Now()
+	SLEEP:
+		for {
+			sleepTime := time.Until(sleepStart.Add(hdb.managedScanSleep(randomSleep)))
+			select {
+			case <-hdb.tg.StopChan():
+				return
+			case <-hdb.staticScanSettingsChanged:
+			case <-time.After(sleepTime):
+				break SLEEP
+			}
 		}
 	}
 }
@@ -677,12 +709,15 @@ func (hdb *HostDB) threadedScan() {
 // the price table is only useful for scoring the host and can't be used. This
 // uses an ephemeral stream which is a special type of stream that doesn't leak
 // TCP connections. Otherwise we would end up with one TCP connection for every
-// host in the network after scanning the whole network.
-func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Duration, hpk mux.ED25519PublicKey) (_ *modules.RPCPriceTable, err error) {
-	stream, err := siamux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, hostAddr, timeout, hpk)
+// host in the network after scanning the whole network. If compress is true,
+// the host is asked to compress the price table. The bandwidth of the stream is
+// limited by rl.
+func fetchPriceTable(siamux *siamux.SiaMux, rl *ratelimit.RateLimit, hostAddr string, timeout time.Duration, hpk mux.ED25519PublicKey, compress bool) (_ *modules.RPCPriceTable, err error) {
+	s, err := siamux.NewEphemeralStream(modules.HostSiaMuxSubscriberName, hostAddr, timeout, hpk)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to create ephemeral stream")
 	}
+	stream := ratelimit.NewRLStream(s, rl, nil)
 	defer func() {
 		err = errors.Compose(err, stream.Close())
 	}()
@@ -694,7 +729,7 @@ func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Durati
 	}
 
 	// initiate the RPC
-	err = modules.RPCWrite(stream, modules.RPCUpdatePriceTable)
+	err = modules.RPCWriteID(stream, modules.RPCUpdatePriceTable, compress)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to write price table RPC specifier")
 	}
@@ -706,6 +741,14 @@ func fetchPriceTable(siamux *siamux.SiaMux, hostAddr string, timeout time.Durati
 		return nil, errors.AddContext(err, "failed to read price table response")
 	}
 
+	// decompress the price table if necessary
+	if compress {
+		update.PriceTableJSON, err = modules.DecompressRPCPayload(update.PriceTableJSON, modules.RPCMinLen)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to decompress price table")
+		}
+	}
+
 	// unmarshal the price table
 	var pt modules.RPCPriceTable
 	err = json.Unmarshal(update.PriceTableJSON, &pt)
@@ -0,0 +1,153 @@
+package hostdb
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errHostNotFound is returned when a host which should be scanned or
+	// marked offline isn't known to the hostdb.
+	errHostNotFound = errors.New("host not found in the hostdb")
+
+	// errNegativeScanSetting is returned when a scan setting is negative.
+	errNegativeScanSetting = errors.New("scan settings can't be negative")
+)
+
+// maxScanningThreads returns the maximum number of threads which scan hosts in
+// parallel.
+func (hdb *HostDB) maxScanningThreads() int {
+	if hdb.scanSettings.ScanThreads > 0 {
+		return hdb.scanSettings.ScanThreads
+	}
+	return defaultMaxScanningThreads
+}
+
+// managedScanSleep returns the time to sleep between two scans. If the user
+// didn't set a scan interval, randomSleep is returned.
+func (hdb *HostDB) managedScanSleep(randomSleep time.Duration) time.Duration {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	if hdb.scanSettings.ScanInterval > 0 {
+		return hdb.scanSettings.ScanInterval
+	}
+	return randomSleep
+}
+
+// setScanBandwidth limits the bandwidth used by scans.
+func (hdb *HostDB) setScanBandwidth(bps int64) {
+	if bps == 0 {
+		hdb.staticScanRL.SetLimits(0, 0, 0)
+		return
+	}
+	hdb.staticScanRL.SetLimits(bps, bps, scanPacketSize)
+}
+
+// ScanSettings returns the settings which control how the hostdb scans hosts.
+func (hdb *HostDB) ScanSettings() (modules.HostDBScanSettings, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostDBScanSettings{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.scanSettings, nil
+}
+
+// SetScanSettings sets the settings which control how the hostdb scans hosts.
+// The scan loop picks up a new scan interval right away.
+func (hdb *HostDB) SetScanSettings(settings modules.HostDBScanSettings) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if settings.ScanInterval < 0 || settings.ScanThreads < 0 || settings.MaxScanBandwidth < 0 {
+		return errNegativeScanSetting
+	}
+	if settings.ScanInterval > 0 && settings.ScanInterval < minScanInterval {
+		return fmt.Errorf("scan interval can't be less than %v", minScanInterval)
+	}
+
+	hdb.mu.Lock()
+	hdb.scanSettings = settings
+	hdb.setScanBandwidth(settings.MaxScanBandwidth)
+	err := hdb.saveSync()
+	hdb.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to save the hostdb")
+	}
+	select {
+	case hdb.staticScanSettingsChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ScanHost scans a host right away and updates its entry. ScanHost blocks
+// until the scan is complete.
+func (hdb *HostDB) ScanHost(pk types.SiaPublicKey) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	entry, exists := hdb.staticHostTree.Select(pk)
+	if !exists {
+		return errHostNotFound
+	}
+	hdb.managedScanHost(entry)
+	return nil
+}
+
+// MarkHostOffline records a failed scan for a host without scanning it. The
+// host is considered offline until it is scanned successfully again.
+func (hdb *HostDB) MarkHostOffline(pk types.SiaPublicKey) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	entry, exists := hdb.staticHostTree.Select(pk)
+	if !exists {
+		return errHostNotFound
+	}
+
+	// The scan history needs at least two scans and its timestamps need to be
+	// increasing.
+	now := time.Now()
+	if n := len(entry.ScanHistory); n > 0 && !entry.ScanHistory[n-1].Timestamp.Before(now) {
+		now = entry.ScanHistory[n-1].Timestamp.Add(time.Nanosecond)
+	}
+	if len(entry.ScanHistory) == 0 {
+		entry.ScanHistory = append(entry.ScanHistory, modules.HostDBScan{Timestamp: now, Success: false})
+		now = now.Add(time.Nanosecond)
+	}
+	entry.ScanHistory = append(entry.ScanHistory, modules.HostDBScan{Timestamp: now, Success: false})
+	if err := hdb.modify(entry); err != nil {
+		return errors.AddContext(err, "unable to update host")
+	}
+	hdb.staticLog.Printf("Host %v was marked offline", pk)
+	return nil
+}
+
+// SetBenchmark stores the result of benchmarking a host. The benchmark is
+// considered when scoring the host.
+func (hdb *HostDB) SetBenchmark(pk types.SiaPublicKey, benchmark modules.HostBenchmark) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	entry, exists := hdb.staticHostTree.Select(pk)
+	if !exists {
+		return errHostNotFound
+	}
+	entry.Benchmark = &benchmark
+	return errors.AddContext(hdb.modify(entry), "unable to update host")
+}
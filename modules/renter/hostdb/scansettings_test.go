@@ -0,0 +1,152 @@
+package hostdb
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestScanSettings probes setting the scan settings of the hostdb.
+func TestScanSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The default settings are empty.
+	settings, err := hdbt.hdb.ScanSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings != (modules.HostDBScanSettings{}) {
+		t.Fatal("wrong default settings", settings)
+	}
+
+	// Invalid settings are rejected.
+	invalid := []modules.HostDBScanSettings{
+		{ScanInterval: -time.Second},
+		{ScanInterval: minScanInterval / 2},
+		{ScanThreads: -1},
+		{MaxScanBandwidth: -1},
+	}
+	for _, s := range invalid {
+		if err := hdbt.hdb.SetScanSettings(s); err == nil {
+			t.Fatal("expected an error", s)
+		}
+	}
+
+	// Set the settings.
+	settings = modules.HostDBScanSettings{
+		ScanInterval:     minScanInterval,
+		ScanThreads:      1,
+		MaxScanBandwidth: 1 << 20,
+	}
+	if err := hdbt.hdb.SetScanSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb.mu.RLock()
+	threads := hdbt.hdb.maxScanningThreads()
+	hdbt.hdb.mu.RUnlock()
+	if threads != 1 {
+		t.Fatal("wrong number of scanning threads", threads)
+	}
+	if sleep := hdbt.hdb.managedScanSleep(time.Hour); sleep != minScanInterval {
+		t.Fatal("wrong scan sleep", sleep)
+	}
+	if read, write, _ := hdbt.hdb.staticScanRL.Limits(); read != settings.MaxScanBandwidth || write != settings.MaxScanBandwidth {
+		t.Fatal("wrong scan bandwidth", read, write)
+	}
+
+	// The settings persist across restarts.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var errChan <-chan error
+	hdbt.hdb, errChan = NewCustomHostDB(hdbt.gateway, hdbt.cs, hdbt.tpool, hdbt.mux, filepath.Join(hdbt.persistDir, modules.RenterDir), &quitAfterLoadDeps{})
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := hdbt.hdb.ScanSettings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != settings {
+		t.Fatal("wrong settings after restart", loaded)
+	}
+	if read, _, _ := hdbt.hdb.staticScanRL.Limits(); read != settings.MaxScanBandwidth {
+		t.Fatal("wrong scan bandwidth after restart", read)
+	}
+}
+
+// TestMarkHostOffline probes marking hosts offline and scanning unknown hosts.
+func TestMarkHostOffline(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := hdbt.hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Unknown hosts can't be scanned or marked offline.
+	unknown := makeHostDBEntry()
+	if err := hdbt.hdb.ScanHost(unknown.PublicKey); !errors.Contains(err, errHostNotFound) {
+		t.Fatal("expected errHostNotFound, got", err)
+	}
+	if err := hdbt.hdb.MarkHostOffline(unknown.PublicKey); !errors.Contains(err, errHostNotFound) {
+		t.Fatal("expected errHostNotFound, got", err)
+	}
+
+	// Mark a host without scans and a host which is online offline.
+	unscanned := makeHostDBEntry()
+	unscanned.ScanHistory = nil
+	online := makeHostDBEntry()
+	online.ScanHistory = append(online.ScanHistory, modules.HostDBScan{Timestamp: time.Now(), Success: true})
+	hdbt.hdb.mu.Lock()
+	err1 := hdbt.hdb.insert(unscanned)
+	err2 := hdbt.hdb.insert(online)
+	hdbt.hdb.mu.Unlock()
+	if err1 != nil || err2 != nil {
+		t.Fatal(err1, err2)
+	}
+	for _, host := range []modules.HostDBEntry{unscanned, online} {
+		if err := hdbt.hdb.MarkHostOffline(host.PublicKey); err != nil {
+			t.Fatal(err)
+		}
+		entry, ok, err := hdbt.hdb.Host(host.PublicKey)
+		if err != nil || !ok {
+			t.Fatal("host not found", err)
+		}
+		// A host without scans gets two failed scans, other hosts get one.
+		expected := len(host.ScanHistory) + 1
+		if expected < 2 {
+			expected = 2
+		}
+		scans := entry.ScanHistory
+		if len(scans) != expected {
+			t.Fatal("wrong scan history", scans)
+		}
+		if scans[len(scans)-1].Success {
+			t.Fatal("host should be offline")
+		}
+		for i := 1; i < len(scans); i++ {
+			if !scans[i].Timestamp.After(scans[i-1].Timestamp) {
+				t.Fatal("scan history isn't sorted", scans)
+			}
+		}
+	}
+}
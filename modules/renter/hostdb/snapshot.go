@@ -0,0 +1,123 @@
+package hostdb
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// ExportSnapshot returns a snapshot of all hosts known to the hostdb, including
+// their scan history and their current score.
+func (hdb *HostDB) ExportSnapshot() (modules.HostDBSnapshot, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostDBSnapshot{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+
+	hosts := hdb.staticHostTree.All()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	snapshot := modules.HostDBSnapshot{
+		Version:     modules.HostDBSnapshotVersion,
+		Time:        time.Now(),
+		BlockHeight: hdb.blockHeight,
+		Hosts:       make([]modules.HostDBSnapshotHost, 0, len(hosts)),
+	}
+	for _, host := range hosts {
+		host.Filtered = false
+		snapshot.Hosts = append(snapshot.Hosts, modules.HostDBSnapshotHost{
+			HostDBEntry: host,
+			Score:       hdb.weightFunc(host).Score(),
+		})
+	}
+	return snapshot, nil
+}
+
+// ImportSnapshot imports the hosts of a snapshot into the hostdb. Hosts which
+// are unknown are added and hosts which haven't been scanned yet are replaced
+// by the imported entry. Hosts which were already scanned are skipped since the
+// hostdb's own measurements are more reliable than the imported ones.
+//
+// If any of the imported hosts was scanned, the initial scan is considered
+// complete, so that hosts can be selected before the hostdb has scanned them.
+// The regular scans update the imported hosts over time.
+func (hdb *HostDB) ImportSnapshot(snapshot modules.HostDBSnapshot) (modules.HostDBImportResult, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostDBImportResult{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if snapshot.Version != modules.HostDBSnapshotVersion {
+		return modules.HostDBImportResult{}, fmt.Errorf("unsupported snapshot version %q, expected %q", snapshot.Version, modules.HostDBSnapshotVersion)
+	}
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	var result modules.HostDBImportResult
+	var scanned bool
+	for _, sh := range snapshot.Hosts {
+		host := sh.HostDBEntry
+		host.Filtered = false
+		if host.PublicKey.Algorithm != types.SignatureEd25519 || len(host.PublicKey.Key) != crypto.PublicKeySize {
+			result.Skipped++
+			continue
+		}
+		// Ignore garbage and local hosts like the hostdb does for
+		// announcements.
+		if err := host.NetAddress.IsValid(); err != nil {
+			result.Skipped++
+			continue
+		}
+		if (build.Release == "standard" || build.Release == "testnet") && host.NetAddress.IsLocal() {
+			result.Skipped++
+			continue
+		}
+		if hdb.blockHeight < host.FirstSeen {
+			host.FirstSeen = hdb.blockHeight
+		}
+
+		oldEntry, exists := hdb.staticHostTree.Select(host.PublicKey)
+		if exists && len(oldEntry.ScanHistory) >= 2 {
+			result.Skipped++
+			continue
+		}
+		var err error
+		if exists {
+			// The announcement the hostdb found on the blockchain is more
+			// recent than the snapshot.
+			if oldEntry.NetAddress != "" {
+				host.NetAddress = oldEntry.NetAddress
+				host.AlternativeNetAddresses = oldEntry.AlternativeNetAddresses
+				if !isAnnouncedAddress(host, host.ReachableNetAddress) {
+					host.ReachableNetAddress = ""
+				}
+			}
+			err = hdb.modify(host)
+		} else {
+			err = hdb.insert(host)
+		}
+		if err != nil {
+			hdb.staticLog.Println("ERROR: unable to import host into host tree:", err)
+			result.Skipped++
+			continue
+		}
+		if exists {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+		if len(host.ScanHistory) >= 2 {
+			scanned = true
+		}
+	}
+	if scanned {
+		hdb.initialScanComplete = true
+	}
+	hdb.staticLog.Printf("Imported hostdb snapshot from %v: %v hosts added, %v updated, %v skipped", snapshot.Time, result.Added, result.Updated, result.Skipped)
+	return result, errors.AddContext(hdb.saveSync(), "unable to save the hostdb")
+}
@@ -0,0 +1,127 @@
+package hostdb
+
+import (
+	"fmt"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostDBSnapshot probes exporting a snapshot of a hostdb and importing it
+// into another hostdb.
+func TestHostDBSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	src, err := newHDBTester(t.Name() + "-src")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := src.hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dst, err := newHDBTester(t.Name() + "-dst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := dst.hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add three scanned hosts to the source hostdb.
+	var hosts []modules.HostDBEntry
+	for i := 0; i < 3; i++ {
+		host := makeHostDBEntry()
+		host.NetAddress = modules.NetAddress(fmt.Sprintf("host%d.com:1234", i))
+		host.ScanHistory = append(host.ScanHistory, host.ScanHistory[0])
+		hosts = append(hosts, host)
+	}
+	src.hdb.mu.Lock()
+	for _, host := range hosts {
+		if err := src.hdb.insert(host); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.hdb.mu.Unlock()
+
+	snapshot, err := src.hdb.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Version != modules.HostDBSnapshotVersion || len(snapshot.Hosts) != len(hosts) {
+		t.Fatal("wrong snapshot", snapshot.Version, len(snapshot.Hosts))
+	}
+	for _, host := range snapshot.Hosts {
+		if host.Score.IsZero() || len(host.ScanHistory) != 2 {
+			t.Fatal("wrong host", host.Score, host.ScanHistory)
+		}
+	}
+
+	// The destination already knows the first host without having scanned it
+	// and the second host after scanning it.
+	unscanned := hosts[0]
+	unscanned.NetAddress = "new.host0.com:1234"
+	unscanned.ScanHistory = nil
+	scanned := hosts[1]
+	scanned.ScanHistory = append(scanned.ScanHistory, scanned.ScanHistory...)
+	dst.hdb.mu.Lock()
+	err1 := dst.hdb.insert(unscanned)
+	err2 := dst.hdb.insert(scanned)
+	dst.hdb.initialScanComplete = false
+	dst.hdb.mu.Unlock()
+	if err1 != nil || err2 != nil {
+		t.Fatal(err1, err2)
+	}
+
+	// Add an invalid host to the snapshot.
+	snapshot.Hosts = append(snapshot.Hosts, modules.HostDBSnapshotHost{
+		HostDBEntry: modules.HostDBEntry{PublicKey: types.SiaPublicKey{Key: []byte("foo")}},
+	})
+	result, err := dst.hdb.ImportSnapshot(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 1 || result.Updated != 1 || result.Skipped != 2 {
+		t.Fatal("wrong result", result)
+	}
+	complete, err := dst.hdb.InitialScanComplete()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !complete {
+		t.Fatal("initial scan should be complete after the import")
+	}
+
+	// The updated host keeps its announced address but has the imported scan
+	// history. The scanned host is unchanged.
+	host, ok, err := dst.hdb.Host(unscanned.PublicKey)
+	if err != nil || !ok {
+		t.Fatal("host not found", err)
+	}
+	if host.NetAddress != unscanned.NetAddress || len(host.ScanHistory) != 2 {
+		t.Fatal("wrong updated host", host.NetAddress, host.ScanHistory)
+	}
+	host, ok, err = dst.hdb.Host(scanned.PublicKey)
+	if err != nil || !ok {
+		t.Fatal("host not found", err)
+	}
+	if len(host.ScanHistory) != len(scanned.ScanHistory) {
+		t.Fatal("scanned host shouldn't be updated", host.ScanHistory)
+	}
+	if _, ok, err := dst.hdb.Host(hosts[2].PublicKey); err != nil || !ok {
+		t.Fatal("host wasn't added", err)
+	}
+
+	// Snapshots with an unknown version are rejected.
+	snapshot.Version = "0.0"
+	if _, err := dst.hdb.ImportSnapshot(snapshot); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -16,7 +16,7 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 		// the HostAnnouncement must be prefaced by the standard host
 		// announcement string
 		for _, arb := range t.ArbitraryData {
-			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			addr, alternatives, pubKey, err := modules.DecodeAnnouncementWithAddresses(arb)
 			if err != nil {
 				continue
 			}
@@ -24,6 +24,7 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 			// Add the announcement to the slice being returned.
 			var host modules.HostDBEntry
 			host.NetAddress = addr
+			host.AlternativeNetAddresses = alternatives
 			host.PublicKey = pubKey
 			announcements = append(announcements, host)
 		}
@@ -31,6 +32,17 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 	return
 }
 
+// isAnnouncedAddress returns true if the address is one of the announced
+// addresses of the host.
+func isAnnouncedAddress(entry modules.HostDBEntry, addr modules.NetAddress) bool {
+	for _, announced := range entry.NetAddresses() {
+		if announced == addr {
+			return true
+		}
+	}
+	return false
+}
+
 // insertBlockchainHost adds a host entry to the state. The host will be inserted
 // into the set of all hosts, and if it is online and responding to requests it
 // will be put into the list of active hosts.
@@ -44,6 +56,15 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 	if (build.Release == "standard" || build.Release == "testnet") && host.NetAddress.IsLocal() {
 		return
 	}
+	// Drop local alternative addresses.
+	var alternatives []modules.NetAddress
+	for _, alt := range host.AlternativeNetAddresses {
+		if (build.Release == "standard" || build.Release == "testnet") && alt.IsLocal() {
+			continue
+		}
+		alternatives = append(alternatives, alt)
+	}
+	host.AlternativeNetAddresses = alternatives
 
 	// Make sure the host gets into the host tree so it does not get dropped if
 	// shutdown occurs before a scan can be performed.
@@ -55,6 +76,10 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		// first seen height of zero, but due to rescans hosts can end up with
 		// a zero-value FirstSeen field.
 		oldEntry.NetAddress = host.NetAddress
+		oldEntry.AlternativeNetAddresses = host.AlternativeNetAddresses
+		if !isAnnouncedAddress(oldEntry, oldEntry.ReachableNetAddress) {
+			oldEntry.ReachableNetAddress = ""
+		}
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
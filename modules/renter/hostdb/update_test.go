@@ -37,6 +37,23 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement not found in block")
 	}
 
+	// Alternative addresses should be found as well.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	altAnnBytes, err := modules.CreateAnnouncementWithAddresses("foo.com:1234", []modules.NetAddress{"bar.com:1234"}, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	altAnnouncements := findHostAnnouncements(types.Block{
+		Transactions: []types.Transaction{{ArbitraryData: [][]byte{altAnnBytes}}},
+	})
+	if len(altAnnouncements) != 1 || len(altAnnouncements[0].AlternativeNetAddresses) != 1 || altAnnouncements[0].AlternativeNetAddresses[0] != "bar.com:1234" {
+		t.Fatal("alternative addresses not found in announcement", altAnnouncements)
+	}
+
 	// Try with an altered prefix
 	b.Transactions[0].ArbitraryData[0][0]++
 	announcements = findHostAnnouncements(b)
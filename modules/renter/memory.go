@@ -2,9 +2,6 @@ package renter
 
 // TODO: Move the memory manager to its own package.
 
-// TODO: Add functions that allow a caller to increase or decrease the base
-// memory for the memory manager.
-
 import (
 	"container/list"
 	"context"
@@ -254,6 +251,15 @@ func (mm *memoryManager) Request(ctx context.Context, amount uint64, priority bo
 	}
 }
 
+// TryRequest is a non-blocking request for memory. It returns false if the
+// memory can't be granted right away.
+func (mm *memoryManager) TryRequest(amount uint64, priority bool) bool {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	shouldTry := mm.priorityFifo.Len() == 0 && (priority || mm.fifo.Len() == 0)
+	return shouldTry && mm.try(amount, priority)
+}
+
 // Return will return memory to the manager, waking any blocking threads which
 // now have enough memory to proceed.
 func (mm *memoryManager) Return(amount uint64) {
@@ -280,6 +286,14 @@ func (mm *memoryManager) Return(amount uint64) {
 		mm.available = mm.base
 	}
 
+	mm.wake()
+}
+
+// wake releases as many of the threads blocking in the fifos as the available
+// memory allows.
+//
+// NOTE: mm.mu needs to be held when calling this method.
+func (mm *memoryManager) wake() {
 	// Release as many of the priority threads blocking in the fifo as possible.
 	for mm.priorityFifo.Len() > 0 {
 		req := mm.priorityFifo.Pop()
@@ -329,6 +343,27 @@ func (mm *memoryManager) Return(amount uint64) {
 	}
 }
 
+// callSetBase changes the base memory and priority reserve of the memory
+// manager. Memory which is currently in use stays granted. If the new base is
+// smaller than the memory in use, no requests are granted until enough memory
+// has been returned. If it is larger, blocking requests are woken up.
+func (mm *memoryManager) callSetBase(base, priorityReserve uint64) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	inUse := mm.base - mm.available + mm.underflow
+	mm.base = base
+	mm.priorityReserve = priorityReserve
+	if inUse > base {
+		mm.available = 0
+		mm.underflow = inUse - base
+	} else {
+		mm.available = base - inUse
+		mm.underflow = 0
+	}
+	mm.wake()
+}
+
 // callAvailable returns the current status of the memory manager.
 func (mm *memoryManager) callStatus() modules.MemoryManagerStatus {
 	mm.mu.Lock()
@@ -357,10 +392,13 @@ func (mm *memoryManager) callStatus() modules.MemoryManagerStatus {
 	return modules.MemoryManagerStatus{
 		Available: available,
 		Base:      mm.base - mm.priorityReserve,
+		Granted:   mm.base - mm.available + mm.underflow,
+		Queued:    uint64(mm.fifo.Len()),
 		Requested: requested,
 
 		PriorityAvailable: priorityAvailable,
 		PriorityBase:      mm.base,
+		PriorityQueued:    uint64(mm.priorityFifo.Len()),
 		PriorityRequested: priorityRequested,
 		PriorityReserve:   mm.priorityReserve,
 	}
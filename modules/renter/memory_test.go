@@ -515,6 +515,7 @@ func TestMemoryManagerStatus(t *testing.T) {
 	expectedStatus := modules.MemoryManagerStatus{
 		Available: memoryDefault - memoryPriorityDefault,
 		Base:      memoryDefault - memoryPriorityDefault,
+		Granted:   0,
 		Requested: 0,
 
 		PriorityAvailable: memoryDefault,
@@ -545,6 +546,7 @@ func TestMemoryManagerStatus(t *testing.T) {
 	expectedStatus = modules.MemoryManagerStatus{
 		Available: memoryDefault - memoryPriorityDefault - normalRequest - priorityRequest,
 		Base:      memoryDefault - memoryPriorityDefault,
+		Granted:   normalRequest + priorityRequest,
 		Requested: 0,
 
 		PriorityAvailable: memoryDefault - normalRequest - priorityRequest,
@@ -572,6 +574,7 @@ func TestMemoryManagerStatus(t *testing.T) {
 	expectedStatus = modules.MemoryManagerStatus{
 		Available: 0,
 		Base:      memoryDefault - memoryPriorityDefault,
+		Granted:   memoryDefault,
 		Requested: 0,
 
 		PriorityAvailable: 0,
@@ -619,10 +622,13 @@ func TestMemoryManagerStatus(t *testing.T) {
 	expectedStatus = modules.MemoryManagerStatus{
 		Available: 0,
 		Base:      memoryDefault - memoryPriorityDefault,
+		Granted:   memoryDefault,
+		Queued:    1,
 		Requested: memoryDefault,
 
 		PriorityAvailable: 0,
 		PriorityBase:      memoryDefault,
+		PriorityQueued:    1,
 		PriorityRequested: memoryDefault,
 		PriorityReserve:   memoryPriorityDefault,
 	}
@@ -633,6 +639,81 @@ func TestMemoryManagerStatus(t *testing.T) {
 	}
 }
 
+// TestMemoryManagerSetBase probes resizing the memory manager while memory is
+// in use.
+func TestMemoryManagerSetBase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	stopChan := make(chan struct{})
+	defer close(stopChan)
+	mm := newMemoryManager(100, 0, stopChan)
+
+	// Use up half of the memory and shrink the memory manager below the memory
+	// in use.
+	if !mm.Request(context.Background(), 50, memoryPriorityLow) {
+		t.Fatal("request should have succeeded")
+	}
+	mm.callSetBase(40, 10)
+	ms := mm.callStatus()
+	if ms.PriorityBase != 40 || ms.PriorityReserve != 10 || ms.Granted != 50 || ms.PriorityAvailable != 0 {
+		t.Fatal("unexpected status after shrinking", ms)
+	}
+
+	// A new request blocks until the memory is returned.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if !mm.Request(context.Background(), 20, memoryPriorityLow) {
+			t.Error("request should have succeeded")
+		}
+	}()
+	<-mm.blocking
+	if ms := mm.callStatus(); ms.Queued != 1 {
+		t.Fatal("request should be queued", ms)
+	}
+
+	// Returning the memory only clears the underflow of the shrunk manager.
+	// The request is granted once there is enough memory left after the
+	// priority reserve.
+	mm.Return(20)
+	select {
+	case <-done:
+		t.Fatal("request shouldn't have been granted yet")
+	case <-time.After(100 * time.Millisecond):
+	}
+	mm.Return(30)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("request should have been granted")
+	}
+	if ms := mm.callStatus(); ms.Granted != 20 || ms.Queued != 0 || ms.PriorityAvailable != 20 {
+		t.Fatal("unexpected status after returning memory", ms)
+	}
+
+	// Growing the memory manager wakes up blocking requests.
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		if !mm.Request(context.Background(), 50, memoryPriorityLow) {
+			t.Error("request should have succeeded")
+		}
+	}()
+	<-mm.blocking
+	mm.callSetBase(100, 10)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("request should have been granted after growing")
+	}
+	if ms := mm.callStatus(); ms.Granted != 70 || ms.PriorityAvailable != 30 {
+		t.Fatal("unexpected status after growing", ms)
+	}
+}
+
 // TestMemoryManagerRequestMemoryWithContext verifies the behaviour of
 // RequestWithContext method on the memory manager
 func TestMemoryManagerRequestMemoryWithContext(t *testing.T) {
@@ -715,12 +796,15 @@ func TestAddMemoryStatus(t *testing.T) {
 	mms := modules.MemoryManagerStatus{
 		Available: 1,
 		Base:      2,
-		Requested: 3,
+		Granted:   3,
+		Queued:    4,
+		Requested: 5,
 
-		PriorityAvailable: 4,
-		PriorityBase:      5,
-		PriorityRequested: 6,
-		PriorityReserve:   7,
+		PriorityAvailable: 6,
+		PriorityBase:      7,
+		PriorityQueued:    8,
+		PriorityRequested: 9,
+		PriorityReserve:   10,
 	}
 	total := mms.Add(mms)
 
@@ -730,6 +814,12 @@ func TestAddMemoryStatus(t *testing.T) {
 	if total.Base != 2*mms.Base {
 		t.Fatal("invalid")
 	}
+	if total.Granted != 2*mms.Granted {
+		t.Fatal("invalid")
+	}
+	if total.Queued != 2*mms.Queued {
+		t.Fatal("invalid")
+	}
 	if total.Requested != 2*mms.Requested {
 		t.Fatal("invalid")
 	}
@@ -739,6 +829,9 @@ func TestAddMemoryStatus(t *testing.T) {
 	if total.PriorityBase != 2*mms.PriorityBase {
 		t.Fatal("invalid")
 	}
+	if total.PriorityQueued != 2*mms.PriorityQueued {
+		t.Fatal("invalid")
+	}
 	if total.PriorityRequested != 2*mms.PriorityRequested {
 		t.Fatal("invalid")
 	}
@@ -30,13 +30,11 @@ type bubbledSiaFileMetadata struct {
 	bm siafile.BubbledMetadata
 }
 
-// callCalculateDirectoryMetadata calculates the new values for the
-// directory's metadata and tracks the value, either worst or best, for each to
-// be bubbled up
-func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir.Metadata, error) {
-	// Set default metadata values to start
+// newDirectoryMetadata returns the metadata of a directory before any of its
+// files and subdirectories are accounted for.
+func newDirectoryMetadata() siadir.Metadata {
 	now := time.Now()
-	metadata := siadir.Metadata{
+	return siadir.Metadata{
 		AggregateHealth:              siadir.DefaultDirHealth,
 		AggregateLastHealthCheckTime: now,
 		AggregateMinRedundancy:       math.MaxFloat64,
@@ -63,6 +61,115 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 		StuckHealth:         siadir.DefaultDirHealth,
 		StuckSize:           uint64(0),
 	}
+}
+
+// aggregateDirectoryMetadata tracks the worst or best values of a file or
+// subdirectory in the aggregate fields of the directory's metadata.
+func aggregateDirectoryMetadata(metadata *siadir.Metadata, health, remoteHealth, stuckHealth, minRedundancy float64, lastHealthCheckTime, modTime time.Time) {
+	// Track the max value of aggregate health values
+	metadata.AggregateHealth = math.Max(metadata.AggregateHealth, health)
+	metadata.AggregateRemoteHealth = math.Max(metadata.AggregateRemoteHealth, remoteHealth)
+	metadata.AggregateStuckHealth = math.Max(metadata.AggregateStuckHealth, stuckHealth)
+	// Track the min value for AggregateMinRedundancy
+	if minRedundancy != -1 {
+		metadata.AggregateMinRedundancy = math.Min(metadata.AggregateMinRedundancy, minRedundancy)
+	}
+	// Update LastHealthCheckTime
+	if lastHealthCheckTime.Before(metadata.AggregateLastHealthCheckTime) {
+		metadata.AggregateLastHealthCheckTime = lastHealthCheckTime
+	}
+	// Update ModTime
+	if modTime.After(metadata.AggregateModTime) {
+		metadata.AggregateModTime = modTime
+	}
+}
+
+// finalizeDirectoryMetadata sets the fields of a directory's metadata which
+// weren't updated by any file or subdirectory.
+func finalizeDirectoryMetadata(metadata *siadir.Metadata) {
+	// Sanity check on ModTime. If mod time is still zero it means there were no
+	// files or subdirectories. Set ModTime to now since we just updated this
+	// directory
+	if metadata.AggregateModTime.IsZero() {
+		metadata.AggregateModTime = time.Now()
+	}
+	if metadata.ModTime.IsZero() {
+		metadata.ModTime = time.Now()
+	}
+	// Sanity check on Redundancy. If MinRedundancy is still math.MaxFloat64
+	// then set it to -1 to indicate an empty directory
+	if metadata.AggregateMinRedundancy == math.MaxFloat64 {
+		metadata.AggregateMinRedundancy = -1
+	}
+	if metadata.MinRedundancy == math.MaxFloat64 {
+		metadata.MinRedundancy = -1
+	}
+}
+
+// addSubDirMetadata adds the aggregate metadata of a subdirectory to the
+// metadata of its parent directory.
+func (r *Renter) addSubDirMetadata(metadata *siadir.Metadata, dirMetadata bubbledSiaDirMetadata) {
+	// Check if the directory's AggregateLastHealthCheckTime is Zero. If so
+	// set the time to now and call bubble on that directory to try and fix
+	// the directories metadata.
+	//
+	// The LastHealthCheckTime is not a field that is initialized when
+	// a directory is created, so we can reach this point if a directory is
+	// created and gets a bubble called on it outside of the health loop
+	// before the health loop has been able to set the LastHealthCheckTime.
+	if dirMetadata.AggregateLastHealthCheckTime.IsZero() {
+		dirMetadata.AggregateLastHealthCheckTime = time.Now()
+		// Check for the dependency to disable the LastHealthCheckTime
+		// correction, (LHCT = LastHealthCheckTime).
+		if !r.deps.Disrupt("DisableLHCTCorrection") {
+			// Queue a bubble to bubble the directory, ignore the return channel
+			// as we do not want to block on this update.
+			r.log.Debugf("Found zero time for ALHCT at '%v'", dirMetadata.sp)
+			_ = r.staticBubbleScheduler.callQueueBubble(dirMetadata.sp)
+		}
+	}
+
+	// Update aggregate fields.
+	metadata.AggregateNumFiles += dirMetadata.AggregateNumFiles
+	metadata.AggregateNumStuckChunks += dirMetadata.AggregateNumStuckChunks
+	metadata.AggregateNumSubDirs += dirMetadata.AggregateNumSubDirs
+	metadata.AggregateRepairSize += dirMetadata.AggregateRepairSize
+	metadata.AggregateSize += dirMetadata.AggregateSize
+	metadata.AggregateStuckSize += dirMetadata.AggregateStuckSize
+
+	// Add 1 to the AggregateNumSubDirs to account for this subdirectory.
+	metadata.AggregateNumSubDirs++
+
+	// Update siadir fields
+	metadata.NumSubDirs++
+
+	// Track the values that compare against files
+	aggregateDirectoryMetadata(metadata, dirMetadata.AggregateHealth, dirMetadata.AggregateRemoteHealth, dirMetadata.AggregateStuckHealth,
+		dirMetadata.AggregateMinRedundancy, dirMetadata.AggregateLastHealthCheckTime, dirMetadata.AggregateModTime)
+}
+
+// callCalculateDirectoryMetadata calculates the new values for the
+// directory's metadata and tracks the value, either worst or best, for each to
+// be bubbled up
+func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir.Metadata, error) {
+	return r.managedCalculateDirectoryMetadata(siaPath, nil, false)
+}
+
+// callCalculateIncrementalDirectoryMetadata calculates the new values for the
+// directory's metadata like callCalculateDirectoryMetadata. Instead of reading
+// the metadatas of the subdirectories from disk, the metadatas recorded during
+// the last calculation are used together with the childUpdates.
+func (r *Renter) callCalculateIncrementalDirectoryMetadata(siaPath modules.SiaPath, childUpdates map[modules.SiaPath]siadir.Metadata) (siadir.Metadata, error) {
+	return r.managedCalculateDirectoryMetadata(siaPath, childUpdates, true)
+}
+
+// managedCalculateDirectoryMetadata calculates the new values for the
+// directory's metadata. If incremental is true, the recorded metadatas of the
+// subdirectories are used if they match the subdirectories on disk.
+func (r *Renter) managedCalculateDirectoryMetadata(siaPath modules.SiaPath, childUpdates map[modules.SiaPath]siadir.Metadata, incremental bool) (siadir.Metadata, error) {
+	// Set default metadata values to start
+	metadata := newDirectoryMetadata()
+
 	// Read directory
 	fileinfos, err := r.staticFileSystem.ReadDir(siaPath)
 	if err != nil {
@@ -109,21 +216,42 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 	if err != nil {
 		r.log.Printf("failed to calculate file metadata: %v", err)
 	}
+	if err := r.staticFileSystem.SaveMetadataIndex(siaPath); err != nil {
+		r.log.Debugf("failed to save metadata index of %v: %v", siaPath, err)
+	}
 
-	// Get all the Directory Metadata
+	// Get all the Directory Metadata, either from the metadatas recorded
+	// during the last calculation or from disk.
 	//
 	// Note: We don't need to abort on error. It's likely that only one or a few
 	// directories failed and that the remaining metadatas are good to use.
-	dirMetadatas, err := r.managedDirectoryMetadatas(dirSiaPaths)
-	if err != nil {
-		r.log.Printf("failed to calculate file metadata: %v", err)
+	var dirMetadatas []bubbledSiaDirMetadata
+	var recorded bool
+	if incremental {
+		dirMetadatas, recorded = r.staticBubbleScheduler.managedUpdateChildMetadatas(siaPath, dirSiaPaths, childUpdates)
+	}
+	if !recorded {
+		dirMetadatas, err = r.managedDirectoryMetadatas(dirSiaPaths)
+		if err != nil {
+			r.log.Printf("failed to calculate file metadata: %v", err)
+		}
+
+		// Remember the metadatas of the subdirectories for incremental
+		// bubbles. If some of them are missing, the next bubble needs to read
+		// them again.
+		if err == nil {
+			r.staticBubbleScheduler.managedSetChildMetadatas(siaPath, dirMetadatas)
+		} else {
+			r.staticBubbleScheduler.managedForgetChildMetadatas(siaPath)
+		}
 	}
 
 	for len(bubbledMetadatas)+len(dirMetadatas) > 0 {
-		// Aggregate Fields
-		var aggregateHealth, aggregateRemoteHealth, aggregateStuckHealth, aggregateMinRedundancy float64
-		var aggregateLastHealthCheckTime, aggregateModTime time.Time
 		if len(bubbledMetadatas) > 0 {
+			// Aggregate Fields
+			var aggregateHealth, aggregateRemoteHealth, aggregateStuckHealth, aggregateMinRedundancy float64
+			var aggregateLastHealthCheckTime, aggregateModTime time.Time
+
 			// Get next file's metadata.
 			bubbledMetadata := bubbledMetadatas[0]
 			bubbledMetadatas = bubbledMetadatas[1:]
@@ -192,113 +320,52 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			}
 			metadata.Size += fileMetadata.Size
 			metadata.StuckHealth = math.Max(metadata.StuckHealth, fileMetadata.StuckHealth)
+
+			// Track the values that compare against sub directories
+			aggregateDirectoryMetadata(&metadata, aggregateHealth, aggregateRemoteHealth, aggregateStuckHealth, aggregateMinRedundancy, aggregateLastHealthCheckTime, aggregateModTime)
 		} else if len(dirMetadatas) > 0 {
 			// Get next dir's metadata.
-			dirMetadata := dirMetadatas[0]
+			r.addSubDirMetadata(&metadata, dirMetadatas[0])
 			dirMetadatas = dirMetadatas[1:]
-
-			// Check if the directory's AggregateLastHealthCheckTime is Zero. If so
-			// set the time to now and call bubble on that directory to try and fix
-			// the directories metadata.
-			//
-			// The LastHealthCheckTime is not a field that is initialized when
-			// a directory is created, so we can reach this point if a directory is
-			// created and gets a bubble called on it outside of the health loop
-			// before the health loop has been able to set the LastHealthCheckTime.
-			if dirMetadata.AggregateLastHealthCheckTime.IsZero() {
-				dirMetadata.AggregateLastHealthCheckTime = time.Now()
-				// Check for the dependency to disable the LastHealthCheckTime
-				// correction, (LHCT = LastHealthCheckTime).
-				if !r.deps.Disrupt("DisableLHCTCorrection") {
-					// Queue a bubble to bubble the directory, ignore the return channel
-					// as we do not want to block on this update.
-					r.log.Debugf("Found zero time for ALHCT at '%v'", dirMetadata.sp)
-					_ = r.staticBubbleScheduler.callQueueBubble(dirMetadata.sp)
-				}
-			}
-
-			// Record Values that compare against files
-			aggregateHealth = dirMetadata.AggregateHealth
-			aggregateStuckHealth = dirMetadata.AggregateStuckHealth
-			aggregateMinRedundancy = dirMetadata.AggregateMinRedundancy
-			aggregateLastHealthCheckTime = dirMetadata.AggregateLastHealthCheckTime
-			aggregateModTime = dirMetadata.AggregateModTime
-			aggregateRemoteHealth = dirMetadata.AggregateRemoteHealth
-
-			// Update aggregate fields.
-			metadata.AggregateNumFiles += dirMetadata.AggregateNumFiles
-			metadata.AggregateNumStuckChunks += dirMetadata.AggregateNumStuckChunks
-			metadata.AggregateNumSubDirs += dirMetadata.AggregateNumSubDirs
-			metadata.AggregateRepairSize += dirMetadata.AggregateRepairSize
-			metadata.AggregateSize += dirMetadata.AggregateSize
-			metadata.AggregateStuckSize += dirMetadata.AggregateStuckSize
-
-			// Add 1 to the AggregateNumSubDirs to account for this subdirectory.
-			metadata.AggregateNumSubDirs++
-
-			// Update siadir fields
-			metadata.NumSubDirs++
-		}
-		// Track the max value of aggregate health values
-		metadata.AggregateHealth = math.Max(metadata.AggregateHealth, aggregateHealth)
-		metadata.AggregateRemoteHealth = math.Max(metadata.AggregateRemoteHealth, aggregateRemoteHealth)
-		metadata.AggregateStuckHealth = math.Max(metadata.AggregateStuckHealth, aggregateStuckHealth)
-		// Track the min value for AggregateMinRedundancy
-		if aggregateMinRedundancy != -1 {
-			metadata.AggregateMinRedundancy = math.Min(metadata.AggregateMinRedundancy, aggregateMinRedundancy)
-		}
-		// Update LastHealthCheckTime
-		if aggregateLastHealthCheckTime.Before(metadata.AggregateLastHealthCheckTime) {
-			metadata.AggregateLastHealthCheckTime = aggregateLastHealthCheckTime
-		}
-		// Update ModTime
-		if aggregateModTime.After(metadata.AggregateModTime) {
-			metadata.AggregateModTime = aggregateModTime
 		}
 	}
 
-	// Sanity check on ModTime. If mod time is still zero it means there were no
-	// files or subdirectories. Set ModTime to now since we just updated this
-	// directory
-	if metadata.AggregateModTime.IsZero() {
-		metadata.AggregateModTime = time.Now()
-	}
-	if metadata.ModTime.IsZero() {
-		metadata.ModTime = time.Now()
-	}
-	// Sanity check on Redundancy. If MinRedundancy is still math.MaxFloat64
-	// then set it to -1 to indicate an empty directory
-	if metadata.AggregateMinRedundancy == math.MaxFloat64 {
-		metadata.AggregateMinRedundancy = -1
-	}
-	if metadata.MinRedundancy == math.MaxFloat64 {
-		metadata.MinRedundancy = -1
-	}
-
+	finalizeDirectoryMetadata(&metadata)
 	return metadata, nil
 }
 
+// aggregateMetadataEqual returns true if the aggregate fields of two
+// directory metadatas, which are used by the parent directory, are equal.
+func aggregateMetadataEqual(a, b siadir.Metadata) bool {
+	return a.AggregateHealth == b.AggregateHealth &&
+		a.AggregateLastHealthCheckTime.Equal(b.AggregateLastHealthCheckTime) &&
+		a.AggregateMinRedundancy == b.AggregateMinRedundancy &&
+		a.AggregateModTime.Equal(b.AggregateModTime) &&
+		a.AggregateNumFiles == b.AggregateNumFiles &&
+		a.AggregateNumStuckChunks == b.AggregateNumStuckChunks &&
+		a.AggregateNumSubDirs == b.AggregateNumSubDirs &&
+		a.AggregateRemoteHealth == b.AggregateRemoteHealth &&
+		a.AggregateRepairSize == b.AggregateRepairSize &&
+		a.AggregateSize == b.AggregateSize &&
+		a.AggregateStuckHealth == b.AggregateStuckHealth &&
+		a.AggregateStuckSize == b.AggregateStuckSize
+}
+
 // managedCachedFileMetadata returns the cached metadata information of
 // a siafiles that needs to be bubbled.
 func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaFileMetadata, error) {
-	// Open SiaFile in a read only state so that it doesn't need to be
-	// closed
-	sf, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	// Grab the metadata from the filesystem's metadata cache to avoid loading
+	// the SiaFile from disk.
+	md, err := r.staticFileSystem.CachedFileMetadata(siaPath)
 	if err != nil {
 		return bubbledSiaFileMetadata{}, err
 	}
-	defer func() {
-		err = errors.Compose(err, sf.Close())
-	}()
-
-	// Grab the metadata to pull the cached information from
-	md := sf.Metadata()
 
 	// Check if original file is on disk
-	_, err = os.Stat(sf.LocalPath())
+	_, err = os.Stat(md.LocalPath)
 	onDisk := err == nil
 	if !onDisk && md.CachedRedundancy < 1 {
-		r.log.Debugf("File not found on disk and possibly unrecoverable: LocalPath %v; SiaPath %v", sf.LocalPath(), siaPath.String())
+		r.log.Debugf("File not found on disk and possibly unrecoverable: LocalPath %v; SiaPath %v", md.LocalPath, siaPath.String())
 	}
 
 	// Return the metadata
@@ -306,16 +373,16 @@ func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaF
 		sp: siaPath,
 		bm: siafile.BubbledMetadata{
 			Health:              md.CachedHealth,
-			LastHealthCheckTime: sf.LastHealthCheckTime(),
-			ModTime:             sf.ModTime(),
+			LastHealthCheckTime: md.LastHealthCheckTime,
+			ModTime:             md.ModTime,
 			NumStuckChunks:      md.CachedNumStuckChunks,
 			OnDisk:              onDisk,
 			Redundancy:          md.CachedRedundancy,
 			RepairBytes:         md.CachedRepairBytes,
-			Size:                sf.Size(),
+			Size:                uint64(md.FileSize),
 			StuckHealth:         md.CachedStuckHealth,
 			StuckBytes:          md.CachedStuckBytes,
-			UID:                 sf.UID(),
+			UID:                 md.UID,
 		},
 	}, nil
 }
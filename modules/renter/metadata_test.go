@@ -6,7 +6,9 @@ import (
 	"strings"
 	"testing"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/siatest/dependencies"
 )
 
@@ -126,3 +128,133 @@ func TestDirectoryMetadatas(t *testing.T) {
 		t.Fatal("different metadatas")
 	}
 }
+
+// TestIncrementalDirectoryMetadata probes that updating the metadata of a
+// directory incrementally results in the same aggregate values as
+// recalculating it from scratch.
+func TestIncrementalDirectoryMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create renter
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	bs := r.staticBubbleScheduler
+
+	// Create a directory with files and two subdirectories, one of which
+	// contains a file as well.
+	parent := modules.RandomSiaPath()
+	subDirA, err := parent.Join("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subDirB, err := parent.Join("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, siaPath := range []modules.SiaPath{subDirA, subDirB} {
+		if err := r.CreateDir(siaPath, modules.DefaultDirPerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []modules.SiaPath{parent, parent, subDirA} {
+		siaPath, rsc := testingFileParams()
+		siaPath, err = dir.Join(siaPath.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rt.bubbleAll([]modules.SiaPath{subDirA, subDirB}); err != nil {
+		t.Fatal(err)
+	}
+	rt.managedBlockUntilBubblesComplete()
+
+	// Calculate the metadata of the parent from scratch. This records the
+	// metadatas of the subdirectories.
+	if _, err := r.callCalculateDirectoryMetadata(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	// checkIncremental is a helper to compare an incremental calculation of
+	// the parent's metadata to a full recalculation.
+	checkIncremental := func(childUpdates map[modules.SiaPath]siadir.Metadata) {
+		t.Helper()
+		incremental, err := r.callCalculateIncrementalDirectoryMetadata(parent, childUpdates)
+		if err != nil {
+			t.Fatal(err)
+		}
+		full, err := r.callCalculateDirectoryMetadata(parent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !aggregateMetadataEqual(incremental, full) {
+			t.Log("incremental:", incremental)
+			t.Log("full:", full)
+			t.Fatal("incremental metadata doesn't match full metadata")
+		}
+		if incremental.NumFiles != full.NumFiles || incremental.NumSubDirs != full.NumSubDirs || incremental.Size != full.Size {
+			t.Log("incremental:", incremental)
+			t.Log("full:", full)
+			t.Fatal("siadir fields of incremental metadata don't match full metadata")
+		}
+	}
+	checkIncremental(nil)
+
+	// Change the metadata of a subdirectory and update the parent with it.
+	md, err := r.managedDirectoryMetadata(subDirB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.AggregateHealth = 5
+	md.AggregateNumStuckChunks = 3
+	md.AggregateSize = 1e6
+	if err := rt.openAndUpdateDir(subDirB, md); err != nil {
+		t.Fatal(err)
+	}
+	checkIncremental(map[modules.SiaPath]siadir.Metadata{subDirB: md})
+
+	// The incremental calculation uses the child updates instead of reading
+	// the subdirectories from disk.
+	mdA, err := r.managedDirectoryMetadata(subDirA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdA.AggregateNumStuckChunks = 7
+	incremental, err := r.callCalculateIncrementalDirectoryMetadata(parent, map[modules.SiaPath]siadir.Metadata{subDirA: mdA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incremental.AggregateNumStuckChunks != mdA.AggregateNumStuckChunks+md.AggregateNumStuckChunks {
+		t.Fatal("unexpected number of stuck chunks", incremental.AggregateNumStuckChunks)
+	}
+
+	// Creating a directory invalidates the recorded metadatas of its
+	// ancestors.
+	subDirC, err := subDirA.Join("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(subDirC, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bs.managedUpdateChildMetadatas(parent, []modules.SiaPath{subDirA, subDirB}, nil); ok {
+		t.Fatal("recorded metadatas of ancestor weren't invalidated")
+	}
+}
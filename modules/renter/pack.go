@@ -0,0 +1,737 @@
+package renter
+
+// pack.go contains the packing of small files. A small file would occupy a
+// full sector on every host it is uploaded to, no matter how small it is.
+// Instead, small files are appended to a pack which is shared with other small
+// files. A pack is buffered on disk until it is full or until it has been open
+// for packFlushInterval. Then it is uploaded as a regular siafile to the pack
+// folder. An index maps every packed file to its pack and its offset within
+// the pack. The index is persisted as a log of the changed files and packs,
+// which is compacted when it is loaded.
+//
+// The space of deleted packed files is only reclaimed once all the files of a
+// pack are deleted, in which case the pack is deleted as well.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// packDirName is the name of the directory within the renter's persist
+	// directory which buffers the packs before they are uploaded.
+	packDirName = "packs"
+
+	// packIndexFilename is the name of the log which persists the index of
+	// the packed files.
+	packIndexFilename = "packs.log"
+
+	// packIndexCompatFilename is the name of the file which persisted the
+	// whole index of the packed files before it was persisted as a log.
+	packIndexCompatFilename = "packs.json"
+
+	// packIndexCompactThreshold is the number of entries the pack index log
+	// needs to have before it is compacted on load.
+	packIndexCompactThreshold = 1000
+)
+
+var (
+	// defaultPackSize is the size of a pack if the renter's settings don't
+	// specify one. It is the size of a chunk with the default erasure coding
+	// parameters, so a full pack doesn't waste any space.
+	defaultPackSize = uint64(modules.RenterDefaultDataPieces) * (modules.SectorSize - crypto.TypeDefaultRenter.Overhead())
+
+	// packFlushInterval is the time after which a pack is uploaded even if it
+	// isn't full.
+	packFlushInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 10 * time.Minute,
+		Testnet:  10 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// packIndexCompatMetadata is the header of the pack index which was
+	// persisted as a whole.
+	packIndexCompatMetadata = persist.Metadata{
+		Header:  "Renter Pack Index",
+		Version: "1.0",
+	}
+
+	// packIndexHeader is the header of the pack index log.
+	packIndexHeader = types.NewSpecifier("PackIndex\n")
+)
+
+var (
+	// errPackedFileExists is returned if a file is packed at a siapath which
+	// is already used by another packed file.
+	errPackedFileExists = errors.New("a packed file already exists at that siapath")
+
+	// errPackedFileNotFound is returned if there is no packed file at a
+	// siapath.
+	errPackedFileNotFound = errors.New("packed file not found")
+
+	// errPackedFileTooLarge is returned if a file doesn't fit into a pack.
+	errPackedFileTooLarge = errors.New("file is larger than the pack size")
+)
+
+type (
+	// packIndex is the persisted index of the packed files and their packs.
+	packIndex struct {
+		Files    map[string]packedFile `json:"files"`
+		Packs    map[string]*packInfo  `json:"packs"`
+		OpenPack string                `json:"openpack"`
+	}
+
+	// packedFile is the location of a packed file within its pack.
+	packedFile struct {
+		Pack   string `json:"pack"`
+		Offset uint64 `json:"offset"`
+		Size   uint64 `json:"size"`
+	}
+
+	// packInfo contains the state of a pack.
+	packInfo struct {
+		CreateTime time.Time `json:"createtime"`
+		Files      int       `json:"files"`
+		Sealed     bool      `json:"sealed"`
+		Size       uint64    `json:"size"`
+		Uploaded   bool      `json:"uploaded"`
+	}
+
+	// packIndexEntry is an entry of the pack index log. It sets the packed
+	// file at SiaPath, the pack with PackID or the open pack. A file or pack
+	// entry without a File or Pack removes the file or pack from the index.
+	packIndexEntry struct {
+		SiaPath  string      `json:"siapath,omitempty"`
+		File     *packedFile `json:"file,omitempty"`
+		PackID   string      `json:"packid,omitempty"`
+		Pack     *packInfo   `json:"pack,omitempty"`
+		OpenPack *string     `json:"openpack,omitempty"`
+	}
+
+	// packer packs small files into packs.
+	packer struct {
+		index     packIndex
+		uploading map[string]struct{}
+
+		// The files and packs which changed since the index was last
+		// persisted, as well as the persisted open pack.
+		changedFiles  map[string]struct{}
+		changedPacks  map[string]struct{}
+		persistedOpen string
+		indexFile     *persist.AppendOnlyPersist
+
+		mu sync.Mutex
+
+		staticDir        string
+		staticPersistDir string
+	}
+
+	// packedFileReader reads a packed file from its pack.
+	packedFileReader struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+// newPacker creates a new packer which stores its data within the provided
+// directory and loads the persisted index if there is one.
+func newPacker(persistDir string) (*packer, error) {
+	p := &packer{
+		index: packIndex{
+			Files: make(map[string]packedFile),
+			Packs: make(map[string]*packInfo),
+		},
+		uploading:        make(map[string]struct{}),
+		changedFiles:     make(map[string]struct{}),
+		changedPacks:     make(map[string]struct{}),
+		staticDir:        filepath.Join(persistDir, packDirName),
+		staticPersistDir: persistDir,
+	}
+	if err := os.MkdirAll(p.staticDir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "unable to create pack directory")
+	}
+	aop, reader, err := persist.NewAppendOnlyPersist(persistDir, packIndexFilename, packIndexHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open pack index")
+	}
+	p.indexFile = aop
+	numEntries, err := p.load(reader)
+	if err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to load pack index"), p.managedClose())
+	}
+
+	// Convert the index of older versions which was persisted as a whole.
+	compatPath := filepath.Join(persistDir, packIndexCompatFilename)
+	err = persist.LoadJSON(packIndexCompatMetadata, &p.index, compatPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Compose(errors.AddContext(err, "unable to load old pack index"), p.managedClose())
+	}
+	converted := err == nil
+
+	// Compact the log if it mostly consists of outdated entries.
+	live := len(p.index.Files) + len(p.index.Packs) + 1
+	if converted || (numEntries > packIndexCompactThreshold && numEntries > 2*live) {
+		if err := p.compact(); err != nil {
+			return nil, errors.Compose(errors.AddContext(err, "unable to compact pack index"), p.managedClose())
+		}
+	}
+	if converted {
+		if err := os.Remove(compatPath); err != nil {
+			return nil, errors.Compose(errors.AddContext(err, "unable to remove old pack index"), p.managedClose())
+		}
+	}
+	return p, nil
+}
+
+// load applies the entries of the pack index log to the index and returns the
+// number of entries.
+func (p *packer) load(r io.Reader) (int, error) {
+	var n int
+	dec := json.NewDecoder(r)
+	for {
+		var entry packIndexEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		n++
+		if entry.SiaPath != "" && entry.File != nil {
+			p.index.Files[entry.SiaPath] = *entry.File
+		} else if entry.SiaPath != "" {
+			delete(p.index.Files, entry.SiaPath)
+		}
+		if entry.PackID != "" && entry.Pack != nil {
+			p.index.Packs[entry.PackID] = entry.Pack
+		} else if entry.PackID != "" {
+			delete(p.index.Packs, entry.PackID)
+		}
+		if entry.OpenPack != nil {
+			p.index.OpenPack = *entry.OpenPack
+			p.persistedOpen = *entry.OpenPack
+		}
+	}
+}
+
+// compact replaces the pack index log with a log which only contains the
+// current state of the index.
+//
+// NOTE: p.mu needs to be held when calling this method.
+func (p *packer) compact() error {
+	tmpFilename := packIndexFilename + "_temp"
+	tmpPath := filepath.Join(p.staticPersistDir, tmpFilename)
+	if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	entries := make([]packIndexEntry, 0, len(p.index.Files)+len(p.index.Packs)+1)
+	for id, pack := range p.index.Packs {
+		entries = append(entries, packIndexEntry{PackID: id, Pack: pack})
+	}
+	for path, pf := range p.index.Files {
+		pf := pf
+		entries = append(entries, packIndexEntry{SiaPath: path, File: &pf})
+	}
+	openPack := p.index.OpenPack
+	entries = append(entries, packIndexEntry{OpenPack: &openPack})
+	data, err := marshalPackIndexEntries(entries)
+	if err != nil {
+		return err
+	}
+	tmp, _, err := persist.NewAppendOnlyPersist(p.staticPersistDir, tmpFilename, packIndexHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err := errors.Compose(err, tmp.Close()); err != nil {
+		return err
+	}
+
+	// Replace the log and reopen it.
+	if err := p.indexFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, p.indexFile.FilePath()); err != nil {
+		return err
+	}
+	aop, _, err := persist.NewAppendOnlyPersist(p.staticPersistDir, packIndexFilename, packIndexHeader, persist.MetadataVersionv156)
+	if err != nil {
+		return err
+	}
+	p.indexFile = aop
+	p.persistedOpen = openPack
+	p.changedFiles = make(map[string]struct{})
+	p.changedPacks = make(map[string]struct{})
+	return nil
+}
+
+// marshalPackIndexEntries marshals entries of the pack index log.
+func marshalPackIndexEntries(entries []packIndexEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// managedClose closes the pack index log.
+func (p *packer) managedClose() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.indexFile.Close()
+}
+
+// packSiaPath returns the siapath of the siafile of a pack.
+func packSiaPath(packID string) (modules.SiaPath, error) {
+	return modules.PackFolder.Join(packID)
+}
+
+// localPath returns the path of the local buffer of a pack.
+func (p *packer) localPath(packID string) string {
+	return filepath.Join(p.staticDir, packID)
+}
+
+// save appends the files and packs which changed since the last call to the
+// pack index log. If appending fails, the changes are kept to be persisted by
+// the next call.
+//
+// NOTE: p.mu needs to be held when calling this method.
+func (p *packer) save() error {
+	var entries []packIndexEntry
+	for path := range p.changedFiles {
+		entry := packIndexEntry{SiaPath: path}
+		if pf, exists := p.index.Files[path]; exists {
+			entry.File = &pf
+		}
+		entries = append(entries, entry)
+	}
+	for id := range p.changedPacks {
+		entries = append(entries, packIndexEntry{PackID: id, Pack: p.index.Packs[id]})
+	}
+	openPack := p.index.OpenPack
+	if openPack != p.persistedOpen {
+		entries = append(entries, packIndexEntry{OpenPack: &openPack})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	data, err := marshalPackIndexEntries(entries)
+	if err != nil {
+		return err
+	}
+	if _, err := p.indexFile.Write(data); err != nil {
+		return err
+	}
+	p.changedFiles = make(map[string]struct{})
+	p.changedPacks = make(map[string]struct{})
+	p.persistedOpen = openPack
+	return nil
+}
+
+// seal seals the open pack so that no more files are added to it. Packs
+// without any files left are dropped instead. It returns whether the sealed
+// pack needs to be uploaded.
+//
+// NOTE: p.mu needs to be held when calling this method.
+func (p *packer) seal() bool {
+	id := p.index.OpenPack
+	pack, exists := p.index.Packs[id]
+	p.index.OpenPack = ""
+	if !exists {
+		return false
+	}
+	if pack.Files == 0 {
+		p.drop(id)
+		return false
+	}
+	pack.Sealed = true
+	p.changedPacks[id] = struct{}{}
+	return true
+}
+
+// drop removes a pack which isn't uploaded from the index and removes its
+// local buffer.
+//
+// NOTE: p.mu needs to be held when calling this method.
+func (p *packer) drop(packID string) {
+	delete(p.index.Packs, packID)
+	p.changedPacks[packID] = struct{}{}
+	if err := os.Remove(p.localPath(packID)); err != nil && !os.IsNotExist(err) {
+		build.Critical("unable to remove pack buffer", err)
+	}
+}
+
+// managedAdd appends the data of a file to the open pack. If the data doesn't
+// fit into the open pack anymore, the open pack is sealed and a new one is
+// opened. The IDs of the packs which were sealed are returned.
+func (p *packer) managedAdd(siaPath modules.SiaPath, data []byte, packSize uint64) (sealed []string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, exists := p.index.Files[siaPath.String()]; exists {
+		return nil, errPackedFileExists
+	}
+	size := uint64(len(data))
+	if size > packSize {
+		return nil, errPackedFileTooLarge
+	}
+
+	// Seal the open pack if the file doesn't fit into it anymore.
+	if pack, exists := p.index.Packs[p.index.OpenPack]; exists && pack.Size+size > packSize {
+		id := p.index.OpenPack
+		if p.seal() {
+			sealed = append(sealed, id)
+		}
+	}
+	if p.index.OpenPack == "" {
+		p.index.OpenPack = hex.EncodeToString(fastrand.Bytes(16))
+		p.index.Packs[p.index.OpenPack] = &packInfo{CreateTime: time.Now()}
+		p.changedPacks[p.index.OpenPack] = struct{}{}
+	}
+	id := p.index.OpenPack
+	pack := p.index.Packs[id]
+
+	// Append the data to the local buffer of the pack.
+	f, err := os.OpenFile(p.localPath(id), os.O_RDWR|os.O_CREATE, modules.DefaultFilePerm)
+	if err != nil {
+		return sealed, errors.AddContext(err, "unable to open pack buffer")
+	}
+	_, err = f.WriteAt(data, int64(pack.Size))
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err != nil {
+		return sealed, errors.AddContext(err, "unable to write to pack buffer")
+	}
+	p.index.Files[siaPath.String()] = packedFile{
+		Pack:   id,
+		Offset: pack.Size,
+		Size:   size,
+	}
+	pack.Files++
+	pack.Size += size
+	p.changedFiles[siaPath.String()] = struct{}{}
+	p.changedPacks[id] = struct{}{}
+
+	// Seal the pack right away if it's full.
+	if pack.Size == packSize && p.seal() {
+		sealed = append(sealed, id)
+	}
+	return sealed, p.save()
+}
+
+// managedSealExpired seals the open pack if it has been open for longer than
+// the flush interval or if force is set. It returns the IDs of all sealed
+// packs which still need to be uploaded and aren't being uploaded already.
+func (p *packer) managedSealExpired(force bool) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pack, exists := p.index.Packs[p.index.OpenPack]; exists && (force || time.Since(pack.CreateTime) > packFlushInterval) {
+		p.seal()
+	}
+	var pending []string
+	for id, pack := range p.index.Packs {
+		if _, uploading := p.uploading[id]; pack.Sealed && !pack.Uploaded && !uploading {
+			pending = append(pending, id)
+		}
+	}
+	sort.Strings(pending)
+	return pending, p.save()
+}
+
+// PackUpload reads a small file from the reader and packs it into a pack which
+// is shared with other small files.
+func (r *Renter) PackUpload(siaPath modules.SiaPath, reader io.Reader) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	packSize := r.managedPackSize()
+	data, err := ioutil.ReadAll(io.LimitReader(reader, int64(packSize)+1))
+	if err != nil {
+		return errors.AddContext(err, "unable to read file")
+	}
+	sealed, err := r.staticPacker.managedAdd(siaPath, data, packSize)
+	for _, id := range sealed {
+		go r.threadedUploadPack(id)
+	}
+	return err
+}
+
+// PackedFiles returns the renter's packed files sorted by their siapath.
+func (r *Renter) PackedFiles() ([]modules.PackedFileInfo, error) {
+	p := r.staticPacker
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	infos := make([]modules.PackedFileInfo, 0, len(p.index.Files))
+	for path, pf := range p.index.Files {
+		info, err := p.info(path, pf)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].SiaPath.String() < infos[j].SiaPath.String()
+	})
+	return infos, nil
+}
+
+// info returns the PackedFileInfo of a packed file.
+//
+// NOTE: p.mu needs to be held when calling this method.
+func (p *packer) info(path string, pf packedFile) (modules.PackedFileInfo, error) {
+	var siaPath modules.SiaPath
+	if err := siaPath.LoadString(path); err != nil {
+		return modules.PackedFileInfo{}, err
+	}
+	packPath, err := packSiaPath(pf.Pack)
+	if err != nil {
+		return modules.PackedFileInfo{}, err
+	}
+	return modules.PackedFileInfo{
+		SiaPath:  siaPath,
+		Pack:     packPath,
+		Offset:   pf.Offset,
+		Size:     pf.Size,
+		Uploaded: p.index.Packs[pf.Pack].Uploaded,
+	}, nil
+}
+
+// PackedFile returns a reader for the data of the packed file at siaPath.
+// Files of packs which aren't uploaded yet are read from the local buffer of
+// the pack.
+func (r *Renter) PackedFile(siaPath modules.SiaPath) (_ io.ReadCloser, _ modules.PackedFileInfo, err error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, modules.PackedFileInfo{}, err
+	}
+	defer r.tg.Done()
+
+	p := r.staticPacker
+	p.mu.Lock()
+	pf, exists := p.index.Files[siaPath.String()]
+	if !exists {
+		p.mu.Unlock()
+		return nil, modules.PackedFileInfo{}, errPackedFileNotFound
+	}
+	info, err := p.info(siaPath.String(), pf)
+	if err != nil || !info.Uploaded {
+		// The local buffer is opened while holding the lock, since it is
+		// removed once the pack is uploaded.
+		var f *os.File
+		if err == nil {
+			f, err = os.Open(p.localPath(pf.Pack))
+		}
+		p.mu.Unlock()
+		if err != nil {
+			return nil, modules.PackedFileInfo{}, err
+		}
+		return packedFileReader{io.NewSectionReader(f, int64(pf.Offset), int64(pf.Size)), f}, info, nil
+	}
+	p.mu.Unlock()
+
+	// Stream the file from the uploaded pack.
+	_, streamer, err := r.Streamer(info.Pack, false, 0)
+	if err != nil {
+		return nil, modules.PackedFileInfo{}, errors.AddContext(err, "unable to open pack")
+	}
+	if _, err := streamer.Seek(int64(pf.Offset), io.SeekStart); err != nil {
+		return nil, modules.PackedFileInfo{}, errors.Compose(err, streamer.Close())
+	}
+	return packedFileReader{io.LimitReader(streamer, int64(pf.Size)), streamer}, info, nil
+}
+
+// DeletePackedFile deletes the packed file at siaPath. The pack of the file is
+// deleted once it doesn't contain any files anymore.
+func (r *Renter) DeletePackedFile(siaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	p := r.staticPacker
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pf, exists := p.index.Files[siaPath.String()]
+	if !exists {
+		return errPackedFileNotFound
+	}
+	delete(p.index.Files, siaPath.String())
+	pack := p.index.Packs[pf.Pack]
+	pack.Files--
+	p.changedFiles[siaPath.String()] = struct{}{}
+	p.changedPacks[pf.Pack] = struct{}{}
+
+	// Remove the pack if it's empty and sealed. Packs which are being uploaded
+	// are removed once the upload is done.
+	_, uploading := p.uploading[pf.Pack]
+	if pack.Files == 0 && pack.Sealed && !uploading {
+		if err := r.deletePack(pf.Pack); err != nil {
+			return err
+		}
+	}
+	return p.save()
+}
+
+// deletePack removes an empty pack from the index and deletes its
+// siafile if it was uploaded.
+//
+// NOTE: r.staticPacker.mu needs to be held when calling this method.
+func (r *Renter) deletePack(packID string) error {
+	p := r.staticPacker
+	if !p.index.Packs[packID].Uploaded {
+		p.drop(packID)
+		return nil
+	}
+	packPath, err := packSiaPath(packID)
+	if err != nil {
+		return err
+	}
+	err = r.DeleteFile(packPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete pack")
+	}
+	delete(p.index.Packs, packID)
+	p.changedPacks[packID] = struct{}{}
+	return nil
+}
+
+// FlushPacks seals the open pack and uploads all the packs which haven't been
+// uploaded yet. It blocks until the packs are uploaded.
+func (r *Renter) FlushPacks() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	pending, err := r.staticPacker.managedSealExpired(true)
+	if err != nil {
+		return err
+	}
+	for _, id := range pending {
+		err = errors.Compose(err, r.managedUploadPack(id))
+	}
+	return err
+}
+
+// managedPackSize returns the size of a pack.
+func (r *Renter) managedPackSize() uint64 {
+	id := r.mu.RLock()
+	packSize := r.persist.PackSize
+	r.mu.RUnlock(id)
+	if packSize == 0 {
+		return defaultPackSize
+	}
+	return packSize
+}
+
+// threadedFlushPacks periodically seals packs which have been open for too
+// long and uploads the sealed packs. It also retries uploads which failed.
+func (r *Renter) threadedFlushPacks() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(packFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-ticker.C:
+		}
+		pending, err := r.staticPacker.managedSealExpired(false)
+		if err != nil {
+			r.log.Println("WARN: unable to seal expired packs:", err)
+			continue
+		}
+		for _, id := range pending {
+			go r.threadedUploadPack(id)
+		}
+	}
+}
+
+// threadedUploadPack uploads a sealed pack.
+func (r *Renter) threadedUploadPack(packID string) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if err := r.managedUploadPack(packID); err != nil {
+		r.log.Printf("WARN: unable to upload pack %v: %v", packID, err)
+	}
+}
+
+// managedUploadPack uploads a sealed pack from its local buffer and removes
+// the buffer afterwards.
+func (r *Renter) managedUploadPack(packID string) (err error) {
+	p := r.staticPacker
+	p.mu.Lock()
+	pack, exists := p.index.Packs[packID]
+	if _, uploading := p.uploading[packID]; !exists || uploading || pack.Uploaded {
+		p.mu.Unlock()
+		return nil
+	}
+	p.uploading[packID] = struct{}{}
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.uploading, packID)
+		p.mu.Unlock()
+	}()
+
+	packPath, err := packSiaPath(packID)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(p.localPath(packID))
+	if err != nil {
+		return errors.AddContext(err, "unable to open pack buffer")
+	}
+	up := modules.FileUploadParams{
+		SiaPath:             packPath,
+		Force:               true,
+		DisablePartialChunk: true,
+		CipherType:          crypto.TypeDefaultRenter,
+	}
+	err = r.UploadStreamFromReader(up, f)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to upload pack")
+	}
+
+	// Mark the pack as uploaded and remove its local buffer. If all the files
+	// of the pack were deleted in the meantime, the pack is deleted right
+	// away.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pack.Uploaded = true
+	p.changedPacks[packID] = struct{}{}
+	if err := os.Remove(p.localPath(packID)); err != nil {
+		r.log.Printf("WARN: unable to remove buffer of pack %v: %v", packID, err)
+	}
+	if pack.Files == 0 {
+		err = r.deletePack(packID)
+	}
+	return errors.Compose(err, p.save())
+}
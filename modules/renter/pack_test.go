@@ -0,0 +1,267 @@
+package renter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestPackerAdd probes adding files to packs, sealing full packs and
+// persisting the pack index.
+func TestPackerAdd(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	p, err := newPacker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Files larger than the pack size can't be packed.
+	foo, bar, baz := modules.RandomSiaPath(), modules.RandomSiaPath(), modules.RandomSiaPath()
+	_, err = p.managedAdd(foo, fastrand.Bytes(101), 100)
+	if !errors.Contains(err, errPackedFileTooLarge) {
+		t.Fatal("expected errPackedFileTooLarge, got", err)
+	}
+
+	// Add two files which fit into a single pack.
+	sealed, err := p.managedAdd(foo, fastrand.Bytes(40), 100)
+	if err != nil || len(sealed) != 0 {
+		t.Fatal("unexpected result", sealed, err)
+	}
+	sealed, err = p.managedAdd(bar, fastrand.Bytes(50), 100)
+	if err != nil || len(sealed) != 0 {
+		t.Fatal("unexpected result", sealed, err)
+	}
+	_, err = p.managedAdd(bar, fastrand.Bytes(1), 100)
+	if !errors.Contains(err, errPackedFileExists) {
+		t.Fatal("expected errPackedFileExists, got", err)
+	}
+	first := p.index.OpenPack
+	if p.index.Files[bar.String()].Offset != 40 || p.index.Packs[first].Size != 90 {
+		t.Fatal("wrong layout of the pack", p.index.Files[bar.String()], p.index.Packs[first])
+	}
+
+	// A file which doesn't fit into the open pack anymore seals it.
+	sealed, err = p.managedAdd(baz, fastrand.Bytes(60), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sealed) != 1 || sealed[0] != first || !p.index.Packs[first].Sealed {
+		t.Fatal("open pack should have been sealed", sealed)
+	}
+	if p.index.OpenPack == first || p.index.Files[baz.String()].Pack != p.index.OpenPack {
+		t.Fatal("file should have been added to a new pack")
+	}
+
+	// Force sealing the second pack. Both packs need to be uploaded.
+	pending, err := p.managedSealExpired(false)
+	if err != nil || len(pending) != 1 {
+		t.Fatal("only the first pack should be pending", pending, err)
+	}
+	pending, err = p.managedSealExpired(true)
+	if err != nil || len(pending) != 2 || p.index.OpenPack != "" {
+		t.Fatal("both packs should be pending", pending, err)
+	}
+
+	// The index is persisted.
+	p2, err := newPacker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p2.index.Files) != 3 || len(p2.index.Packs) != 2 || !p2.index.Packs[first].Sealed {
+		t.Fatal("pack index wasn't persisted correctly", p2.index)
+	}
+}
+
+// TestPackIndexLog probes persisting the pack index as a log, compacting the
+// log and converting the index of older versions.
+func TestPackIndexLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	p, err := newPacker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// equal compares two indices in their persisted form, since the create
+	// times of packs lose their monotonic clock reading when persisted.
+	equal := func(a, b packIndex) bool {
+		aj, err1 := json.Marshal(a)
+		bj, err2 := json.Marshal(b)
+		return err1 == nil && err2 == nil && bytes.Equal(aj, bj)
+	}
+	reload := func() *packer {
+		t.Helper()
+		if err := p.managedClose(); err != nil {
+			t.Fatal(err)
+		}
+		p2, err := newPacker(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equal(p2.index, p.index) {
+			t.Fatal("pack index wasn't persisted correctly", p2.index, p.index)
+		}
+		return p2
+	}
+
+	// Add files to a couple of packs and remove some of them again.
+	var paths []modules.SiaPath
+	for i := 0; i < 10; i++ {
+		siaPath := modules.RandomSiaPath()
+		if _, err := p.managedAdd(siaPath, fastrand.Bytes(30), 100); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, siaPath)
+	}
+	p.mu.Lock()
+	for _, siaPath := range paths[:5] {
+		pf := p.index.Files[siaPath.String()]
+		delete(p.index.Files, siaPath.String())
+		p.index.Packs[pf.Pack].Files--
+		p.changedFiles[siaPath.String()] = struct{}{}
+		p.changedPacks[pf.Pack] = struct{}{}
+	}
+	err = p.save()
+	p.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = reload()
+
+	// Compacting the log shrinks it without changing the index.
+	before := p.indexFile.PersistLength()
+	p.mu.Lock()
+	err = p.compact()
+	p.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after := p.indexFile.PersistLength(); after >= before {
+		t.Fatal("log wasn't compacted", before, after)
+	}
+	p = reload()
+
+	// The index of older versions is converted into the log.
+	index := p.index
+	if err := p.managedClose(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, packIndexFilename)); err != nil {
+		t.Fatal(err)
+	}
+	compatPath := filepath.Join(dir, packIndexCompatFilename)
+	if err := persist.SaveJSON(packIndexCompatMetadata, index, compatPath); err != nil {
+		t.Fatal(err)
+	}
+	p, err = newPacker(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(p.index, index) {
+		t.Fatal("old pack index wasn't converted", p.index, index)
+	}
+	if _, err := os.Stat(compatPath); !os.IsNotExist(err) {
+		t.Fatal("old pack index wasn't removed", err)
+	}
+	reload()
+}
+
+// TestPackedFileLocal probes reading and deleting packed files whose pack
+// wasn't uploaded yet.
+func TestPackedFileLocal(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Pack two files.
+	foo, bar := modules.RandomSiaPath(), modules.RandomSiaPath()
+	fooData, barData := fastrand.Bytes(100), fastrand.Bytes(200)
+	if err := r.PackUpload(foo, bytes.NewReader(fooData)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PackUpload(bar, bytes.NewReader(barData)); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := r.PackedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 || infos[0].Uploaded || infos[1].Uploaded {
+		t.Fatal("unexpected packed files", infos)
+	}
+
+	// Read the files from the local buffer.
+	for _, f := range []struct {
+		siaPath modules.SiaPath
+		data    []byte
+	}{{foo, fooData}, {bar, barData}} {
+		reader, info, err := r.PackedFile(f.siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err := errors.Compose(err, reader.Close()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, f.data) || info.Size != uint64(len(f.data)) {
+			t.Fatal("wrong data of packed file")
+		}
+	}
+
+	// Delete both files. The open pack isn't removed until it is sealed.
+	if err := r.DeletePackedFile(foo); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.PackedFile(foo); !errors.Contains(err, errPackedFileNotFound) {
+		t.Fatal("expected errPackedFileNotFound, got", err)
+	}
+	if err := r.DeletePackedFile(bar); err != nil {
+		t.Fatal(err)
+	}
+	p := r.staticPacker
+	id := p.index.OpenPack
+	if _, exists := p.index.Packs[id]; !exists {
+		t.Fatal("open pack shouldn't be removed")
+	}
+
+	// Sealing the empty pack drops it without uploading it.
+	if err := r.FlushPacks(); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := p.index.Packs[id]; exists {
+		t.Fatal("empty pack should have been dropped")
+	}
+	if _, err := os.Stat(p.localPath(id)); !os.IsNotExist(err) {
+		t.Fatal("buffer of the empty pack should have been removed", err)
+	}
+}
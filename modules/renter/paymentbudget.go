@@ -0,0 +1,407 @@
+package renter
+
+// paymentbudget.go allows third-party applications to pay hosts for MDM
+// programs from the renter's ephemeral accounts. Every program is paid from a
+// payment budget which limits how much an application may spend. The cost of a
+// program is reserved from the budget before it is executed and the budget is
+// charged the payment minus the host's refund once the program is done. A
+// receipt is kept for every program.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// paymentBudgetsFilename is the name of the file which persists the
+	// payment budgets.
+	paymentBudgetsFilename = "paymentbudgets.json"
+
+	// maxPaymentReceipts is the maximum number of receipts which are kept per
+	// budget. Older receipts are dropped.
+	maxPaymentReceipts = 1000
+)
+
+var (
+	// paymentBudgetsMetadata is the header of the persisted payment budgets.
+	paymentBudgetsMetadata = persist.Metadata{
+		Header:  "Renter Payment Budgets",
+		Version: "1.0",
+	}
+
+	// ErrPaymentBudgetExceeded is returned if a program costs more than
+	// what's left of its payment budget.
+	ErrPaymentBudgetExceeded = errors.New("the cost of the program exceeds the remaining payment budget")
+
+	// ErrPaymentBudgetExpired is returned if a program is paid from a budget
+	// which has expired.
+	ErrPaymentBudgetExpired = errors.New("the payment budget has expired")
+
+	// ErrUnknownPaymentBudget is returned if a payment budget doesn't exist.
+	ErrUnknownPaymentBudget = errors.New("unknown payment budget")
+)
+
+type (
+	// paymentBudget is a payment budget together with its receipts and the
+	// cost of the programs which are currently executed.
+	paymentBudget struct {
+		Budget   modules.PaymentBudget    `json:"budget"`
+		Receipts []modules.PaymentReceipt `json:"receipts"`
+
+		reserved types.Currency
+	}
+
+	// paymentBudgets keeps track of the renter's payment budgets.
+	paymentBudgets struct {
+		budgets map[string]*paymentBudget
+		mu      sync.Mutex
+
+		staticPath string
+	}
+
+	// paymentBudgetsPersist is the persisted form of the payment budgets.
+	paymentBudgetsPersist struct {
+		Budgets []*paymentBudget `json:"budgets"`
+	}
+)
+
+// newPaymentBudgetID returns a new random ID for a payment budget or a
+// receipt.
+func newPaymentBudgetID() string {
+	return hex.EncodeToString(fastrand.Bytes(16))
+}
+
+// remaining returns how much is left of a budget.
+func (pb *paymentBudget) remaining() types.Currency {
+	used := pb.Budget.Spent.Add(pb.reserved)
+	if used.Cmp(pb.Budget.Limit) >= 0 {
+		return types.ZeroCurrency
+	}
+	return pb.Budget.Limit.Sub(used)
+}
+
+// newPaymentBudgets creates the payment budgets and loads the persisted
+// budgets from the provided directory if there are any.
+func newPaymentBudgets(persistDir string) (*paymentBudgets, error) {
+	pbs := &paymentBudgets{
+		budgets:    make(map[string]*paymentBudget),
+		staticPath: filepath.Join(persistDir, paymentBudgetsFilename),
+	}
+	var p paymentBudgetsPersist
+	err := persist.LoadJSON(paymentBudgetsMetadata, &p, pbs.staticPath)
+	if os.IsNotExist(err) {
+		return pbs, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load payment budgets")
+	}
+	for _, pb := range p.Budgets {
+		pbs.budgets[pb.Budget.ID] = pb
+	}
+	return pbs, nil
+}
+
+// save persists the payment budgets. The caller must hold the lock.
+func (pbs *paymentBudgets) save() error {
+	p := paymentBudgetsPersist{
+		Budgets: make([]*paymentBudget, 0, len(pbs.budgets)),
+	}
+	for _, pb := range pbs.budgets {
+		p.Budgets = append(p.Budgets, pb)
+	}
+	sort.Slice(p.Budgets, func(i, j int) bool {
+		return p.Budgets[i].Budget.Created.Before(p.Budgets[j].Budget.Created)
+	})
+	return persist.SaveJSON(paymentBudgetsMetadata, p, pbs.staticPath)
+}
+
+// managedCreate creates a new payment budget.
+func (pbs *paymentBudgets) managedCreate(name string, limit types.Currency, duration time.Duration) (modules.PaymentBudget, error) {
+	if duration < 0 {
+		return modules.PaymentBudget{}, errors.New("the duration of a payment budget can't be negative")
+	}
+	budget := modules.PaymentBudget{
+		ID:      newPaymentBudgetID(),
+		Name:    name,
+		Limit:   limit,
+		Created: time.Now(),
+	}
+	if duration > 0 {
+		budget.Expiry = budget.Created.Add(duration)
+	}
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	pbs.budgets[budget.ID] = &paymentBudget{Budget: budget}
+	if err := pbs.save(); err != nil {
+		delete(pbs.budgets, budget.ID)
+		return modules.PaymentBudget{}, errors.AddContext(err, "unable to persist the payment budget")
+	}
+	return budget, nil
+}
+
+// managedBudgets returns all payment budgets sorted by their creation time.
+func (pbs *paymentBudgets) managedBudgets() []modules.PaymentBudget {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	budgets := make([]modules.PaymentBudget, 0, len(pbs.budgets))
+	for _, pb := range pbs.budgets {
+		budgets = append(budgets, pb.Budget)
+	}
+	sort.Slice(budgets, func(i, j int) bool {
+		return budgets[i].Created.Before(budgets[j].Created)
+	})
+	return budgets
+}
+
+// managedBudget returns a payment budget and its receipts.
+func (pbs *paymentBudgets) managedBudget(id string) (modules.PaymentBudget, []modules.PaymentReceipt, error) {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	pb, exists := pbs.budgets[id]
+	if !exists {
+		return modules.PaymentBudget{}, nil, ErrUnknownPaymentBudget
+	}
+	receipts := append([]modules.PaymentReceipt{}, pb.Receipts...)
+	return pb.Budget, receipts, nil
+}
+
+// managedSetLimit changes the limit of a payment budget.
+func (pbs *paymentBudgets) managedSetLimit(id string, limit types.Currency) error {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	pb, exists := pbs.budgets[id]
+	if !exists {
+		return ErrUnknownPaymentBudget
+	}
+	pb.Budget.Limit = limit
+	return errors.AddContext(pbs.save(), "unable to persist the payment budget")
+}
+
+// managedDelete deletes a payment budget.
+func (pbs *paymentBudgets) managedDelete(id string) error {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	if _, exists := pbs.budgets[id]; !exists {
+		return ErrUnknownPaymentBudget
+	}
+	delete(pbs.budgets, id)
+	return errors.AddContext(pbs.save(), "unable to persist the payment budgets")
+}
+
+// managedReserve reserves the cost of a program from a payment budget.
+func (pbs *paymentBudgets) managedReserve(id string, cost types.Currency) error {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	pb, exists := pbs.budgets[id]
+	if !exists {
+		return ErrUnknownPaymentBudget
+	}
+	if !pb.Budget.Expiry.IsZero() && time.Now().After(pb.Budget.Expiry) {
+		return ErrPaymentBudgetExpired
+	}
+	if cost.Cmp(pb.remaining()) > 0 {
+		return errors.AddContext(ErrPaymentBudgetExceeded, fmt.Sprintf("program costs %v, remaining budget is %v", cost.HumanString(), pb.remaining().HumanString()))
+	}
+	pb.reserved = pb.reserved.Add(cost)
+	return nil
+}
+
+// managedSettle releases the reservation of a program and charges the budget
+// the cost of the receipt. If the budget was deleted while the program was
+// executed, the receipt is dropped.
+func (pbs *paymentBudgets) managedSettle(reserved types.Currency, receipt modules.PaymentReceipt) error {
+	pbs.mu.Lock()
+	defer pbs.mu.Unlock()
+	pb, exists := pbs.budgets[receipt.BudgetID]
+	if !exists {
+		return nil
+	}
+	if pb.reserved.Cmp(reserved) < 0 {
+		build.Critical("payment budget reserved less than what's released")
+		pb.reserved = types.ZeroCurrency
+	} else {
+		pb.reserved = pb.reserved.Sub(reserved)
+	}
+	pb.Budget.Spent = pb.Budget.Spent.Add(receipt.Cost)
+	if receipt.Error == "" {
+		pb.Budget.Programs++
+	}
+	pb.Receipts = append(pb.Receipts, receipt)
+	if len(pb.Receipts) > maxPaymentReceipts {
+		pb.Receipts = pb.Receipts[len(pb.Receipts)-maxPaymentReceipts:]
+	}
+	return errors.AddContext(pbs.save(), "unable to persist the payment budget")
+}
+
+// CreatePaymentBudget creates a budget which third-party applications use to
+// pay hosts for MDM programs from the renter's ephemeral accounts.
+func (r *Renter) CreatePaymentBudget(name string, limit types.Currency, duration time.Duration) (modules.PaymentBudget, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.PaymentBudget{}, err
+	}
+	defer r.tg.Done()
+	return r.staticPaymentBudgets.managedCreate(name, limit, duration)
+}
+
+// PaymentBudgets returns all payment budgets.
+func (r *Renter) PaymentBudgets() ([]modules.PaymentBudget, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticPaymentBudgets.managedBudgets(), nil
+}
+
+// PaymentBudget returns a payment budget and the receipts of the programs which
+// were paid from it.
+func (r *Renter) PaymentBudget(id string) (modules.PaymentBudget, []modules.PaymentReceipt, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.PaymentBudget{}, nil, err
+	}
+	defer r.tg.Done()
+	return r.staticPaymentBudgets.managedBudget(id)
+}
+
+// SetPaymentBudgetLimit changes the limit of a payment budget. Lowering the
+// limit below what was already spent prevents further payments from the
+// budget.
+func (r *Renter) SetPaymentBudgetLimit(id string, limit types.Currency) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticPaymentBudgets.managedSetLimit(id, limit)
+}
+
+// DeletePaymentBudget deletes a payment budget and its receipts.
+func (r *Renter) DeletePaymentBudget(id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticPaymentBudgets.managedDelete(id)
+}
+
+// managedProgramWorker returns the worker of the host if it is able to pay the
+// host for a program.
+func (r *Renter) managedProgramWorker(host types.SiaPublicKey) (*worker, error) {
+	w, err := r.staticWorkerPool.callWorker(host)
+	if err != nil {
+		return nil, errNoWorker
+	}
+	if !w.staticPriceTable().staticValid() {
+		return nil, errors.New("the renter doesn't have a valid price table for the host")
+	}
+	if w.managedOnMaintenanceCooldown() {
+		return nil, errors.New("the host's worker is on maintenance cooldown")
+	}
+	return w, nil
+}
+
+// ProgramCost returns the cost of executing an MDM program on a host, including
+// the bandwidth.
+func (r *Renter) ProgramCost(host types.SiaPublicKey, instructions []modules.ProgramInstruction) (types.Currency, error) {
+	if err := r.tg.Add(); err != nil {
+		return types.ZeroCurrency, err
+	}
+	defer r.tg.Done()
+	w, err := r.managedProgramWorker(host)
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	cp, err := w.staticBuildProgram(instructions)
+	if err != nil {
+		return types.ZeroCurrency, err
+	}
+	return cp.cost, nil
+}
+
+// ExecuteProgram executes an MDM program on a host the renter has a contract
+// with. The program is paid from the ephemeral account with the host and
+// charged to the given payment budget. Programs which cost more than maxCost
+// are rejected, unless maxCost is zero.
+func (r *Renter) ExecuteProgram(budgetID string, host types.SiaPublicKey, instructions []modules.ProgramInstruction, maxCost types.Currency) (modules.ProgramResult, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ProgramResult{}, err
+	}
+	defer r.tg.Done()
+
+	// Check that the worker is able to pay the host.
+	w, err := r.managedProgramWorker(host)
+	if err != nil {
+		return modules.ProgramResult{}, err
+	}
+	cp, err := w.staticBuildProgram(instructions)
+	if err != nil {
+		return modules.ProgramResult{}, err
+	}
+	cost := cp.cost
+	if !maxCost.IsZero() && cost.Cmp(maxCost) > 0 {
+		return modules.ProgramResult{}, fmt.Errorf("the program costs %v which exceeds the max cost of %v", cost.HumanString(), maxCost.HumanString())
+	}
+	if w.staticAccount.managedAvailableBalance().Cmp(cost) < 0 {
+		return modules.ProgramResult{}, errors.New("the ephemeral account with the host has an insufficient balance")
+	}
+
+	// Reserve the cost and execute the program.
+	if err := r.staticPaymentBudgets.managedReserve(budgetID, cost); err != nil {
+		return modules.ProgramResult{}, err
+	}
+	receipt := modules.PaymentReceipt{
+		ID:            newPaymentBudgetID(),
+		BudgetID:      budgetID,
+		Time:          time.Now(),
+		HostPublicKey: host,
+		Instructions:  len(instructions),
+		Payment:       cost,
+	}
+	var fcid types.FileContractID
+	if cp.usesContract {
+		fcid = w.staticCache().staticContractID
+	}
+	responses, _, err := w.managedExecuteProgram(cp.program, cp.data, fcid, categoryProgram, cost)
+	var outputs []modules.ProgramOutput
+	if err != nil {
+		// The payment isn't tracked as spent if the execution failed.
+		receipt.Refund = cost
+		receipt.Error = err.Error()
+	} else {
+		for i, resp := range responses {
+			receipt.Refund = receipt.Refund.Add(resp.FailureRefund)
+
+			// Registry reads of entries which don't exist are refunded.
+			if resp.Error == nil && instructions[i].Type == modules.ProgramInstructionReadRegistry && resp.OutputLength == 0 {
+				w.staticAccount.managedTrackDeposit(cp.refunds[i])
+				w.staticAccount.managedCommitDeposit(cp.refunds[i], true)
+				receipt.Refund = receipt.Refund.Add(cp.refunds[i])
+			}
+		}
+		if receipt.Refund.Cmp(cost) < 0 {
+			receipt.Cost = cost.Sub(receipt.Refund)
+		}
+		outputs = w.staticDecodeProgramOutputs(instructions, responses)
+	}
+	if errSettle := r.staticPaymentBudgets.managedSettle(cost, receipt); errSettle != nil {
+		r.log.Println("ERROR: unable to settle payment budget:", errSettle)
+	}
+	if err != nil {
+		return modules.ProgramResult{}, errors.AddContext(err, "unable to execute program")
+	}
+	return modules.ProgramResult{
+		Receipt: receipt,
+		Outputs: outputs,
+	}, nil
+}
@@ -0,0 +1,121 @@
+package renter
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestPaymentBudgets probes the bookkeeping and persistence of payment budgets.
+func TestPaymentBudgets(t *testing.T) {
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	pbs, err := newPaymentBudgets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budget, err := pbs.managedCreate("app", types.NewCurrency64(100), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budget.ID == "" || !budget.Expiry.IsZero() {
+		t.Fatal("unexpected budget", budget)
+	}
+
+	// Reserve part of the budget. A second program which costs more than the
+	// remainder is rejected.
+	if err := pbs.managedReserve(budget.ID, types.NewCurrency64(60)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pbs.managedReserve(budget.ID, types.NewCurrency64(50)); !errors.Contains(err, ErrPaymentBudgetExceeded) {
+		t.Fatal("expected budget to be exceeded", err)
+	}
+
+	// Settle the program with a refund. The budget is only charged the cost.
+	receipt := modules.PaymentReceipt{
+		ID:       newPaymentBudgetID(),
+		BudgetID: budget.ID,
+		Payment:  types.NewCurrency64(60),
+		Refund:   types.NewCurrency64(20),
+		Cost:     types.NewCurrency64(40),
+	}
+	if err := pbs.managedSettle(types.NewCurrency64(60), receipt); err != nil {
+		t.Fatal(err)
+	}
+	if err := pbs.managedReserve(budget.ID, types.NewCurrency64(60)); err != nil {
+		t.Fatal(err)
+	}
+	failed := modules.PaymentReceipt{
+		ID:       newPaymentBudgetID(),
+		BudgetID: budget.ID,
+		Payment:  types.NewCurrency64(60),
+		Refund:   types.NewCurrency64(60),
+		Error:    "failed",
+	}
+	if err := pbs.managedSettle(types.NewCurrency64(60), failed); err != nil {
+		t.Fatal(err)
+	}
+	budget, receipts, err := pbs.managedBudget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !budget.Spent.Equals64(40) || budget.Programs != 1 || len(receipts) != 2 {
+		t.Fatal("unexpected budget", budget, receipts)
+	}
+
+	// The budgets survive a reload.
+	pbs, err = newPaymentBudgets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, receipts, err := pbs.managedBudget(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Spent.Equals(budget.Spent) || loaded.Name != "app" || len(receipts) != 2 || receipts[0].ID != receipt.ID {
+		t.Fatal("budget wasn't persisted", loaded, receipts)
+	}
+
+	// Lowering the limit below what was spent prevents further payments.
+	if err := pbs.managedSetLimit(budget.ID, types.NewCurrency64(30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pbs.managedReserve(budget.ID, types.NewCurrency64(1)); !errors.Contains(err, ErrPaymentBudgetExceeded) {
+		t.Fatal("expected budget to be exceeded", err)
+	}
+
+	// Expired budgets can't be used.
+	expiring, err := pbs.managedCreate("expiring", types.NewCurrency64(100), time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if err := pbs.managedReserve(expiring.ID, types.NewCurrency64(1)); !errors.Contains(err, ErrPaymentBudgetExpired) {
+		t.Fatal("expected budget to be expired", err)
+	}
+	if budgets := pbs.managedBudgets(); len(budgets) != 2 || budgets[0].ID != budget.ID {
+		t.Fatal("unexpected budgets", budgets)
+	}
+
+	// Deleted budgets are gone.
+	if err := pbs.managedDelete(budget.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := pbs.managedBudget(budget.ID); !errors.Contains(err, ErrUnknownPaymentBudget) {
+		t.Fatal("expected unknown budget", err)
+	}
+	if err := pbs.managedReserve(budget.ID, types.ZeroCurrency); !errors.Contains(err, ErrUnknownPaymentBudget) {
+		t.Fatal("expected unknown budget", err)
+	}
+}
@@ -3,6 +3,7 @@ package renter
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/writeaheadlog"
@@ -60,6 +61,42 @@ type (
 		MaxUploadSpeed   int64
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+
+		AccountMaxBalanceAtRisk types.Currency
+		AccountRefillThreshold  types.Currency
+
+		DownloadOverdrive uint64
+		StreamOverdrive   uint64
+		AdaptiveOverdrive bool
+
+		LowLatencyFraction float64
+		LowLatencyBudget   time.Duration
+
+		DownloadStallTimeout time.Duration
+
+		UploadMemory   uint64
+		DownloadMemory uint64
+		RepairMemory   uint64
+
+		SpillDir string
+		SpillCap uint64
+
+		PackSize uint64
+
+		RepairsPaused      bool
+		RepairPauseStart   time.Time
+		RepairPauseEndTime time.Time
+
+		MaxDownloadCost      types.Currency
+		MaxRegistryReadCost  types.Currency
+		MaxRegistryWriteCost types.Currency
+		MaxUploadCost        types.Currency
+
+		Gouging modules.GougingSettings
+
+		// CustodyTransfer contains the files of an unconfirmed custody
+		// transfer.
+		CustodyTransfer []modules.SiaPath
 	}
 )
 
@@ -110,7 +147,15 @@ func (r *Renter) managedLoadSettings() error {
 
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
-	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
+	err = r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
+	if err != nil {
+		return err
+	}
+
+	// Resize the memory managers, which were initialized with the default
+	// budgets.
+	r.setMemoryBudgets(r.persist.UploadMemory, r.persist.DownloadMemory, r.persist.RepairMemory)
+	return nil
 }
 
 // managedInitPersist handles all of the persistence initialization, such as creating
@@ -197,5 +242,10 @@ func (r *Renter) managedInitPersist() error {
 	if err != nil && !errors.Contains(err, filesystem.ErrExists) {
 		return err
 	}
+	// Remove the leftovers of reshards which were interrupted by a shutdown.
+	err = fs.DeleteDir(modules.ReshardFolder)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return err
+	}
 	return nil
 }
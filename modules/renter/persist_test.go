@@ -6,13 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
 )
 
 // testingFileParams generates the ErasureCoder with random dataPieces and
@@ -95,6 +98,57 @@ func TestRenterSaveLoad(t *testing.T) {
 	newUpSpeed := int64(500e3)
 	settings.MaxDownloadSpeed = newDownSpeed
 	settings.MaxUploadSpeed = newUpSpeed
+
+	// A refill threshold above the max balance at risk should be rejected.
+	newMaxBalance := types.SiacoinPrecision.Mul64(2)
+	newRefillThreshold := types.SiacoinPrecision
+	settings.AccountMaxBalanceAtRisk = newMaxBalance
+	settings.AccountRefillThreshold = newMaxBalance.Add64(1)
+	err = rt.renter.SetSettings(settings)
+	if !errors.Contains(err, errAccountRefillThresholdTooHigh) {
+		t.Fatal("unexpected error", err)
+	}
+	settings.AccountRefillThreshold = newRefillThreshold
+
+	// An overdrive above the max should be rejected.
+	settings.DownloadOverdrive = maxDownloadOverdrive + 1
+	err = rt.renter.SetSettings(settings)
+	if !errors.Contains(err, errOverdriveTooHigh) {
+		t.Fatal("unexpected error", err)
+	}
+	settings.DownloadOverdrive = 1
+	settings.StreamOverdrive = 2
+	settings.AdaptiveOverdrive = true
+
+	// A low latency fraction above 1 should be rejected.
+	settings.LowLatencyFraction = 1.5
+	err = rt.renter.SetSettings(settings)
+	if !errors.Contains(err, errLowLatencyFractionInvalid) {
+		t.Fatal("unexpected error", err)
+	}
+	settings.LowLatencyFraction = 0.5
+	settings.LowLatencyBudget = 100 * time.Millisecond
+	settings.DownloadStallTimeout = time.Minute
+	settings.UploadMemory = 1 << 20
+	settings.DownloadMemory = 1 << 21
+	settings.RepairMemory = 1 << 22
+	settings.SpillDir = filepath.Join(rt.dir, "spill")
+	settings.SpillCap = 1 << 30
+	settings.PackSize = 1 << 12
+	settings.MaxDownloadCost = types.NewCurrency64(1)
+	settings.MaxRegistryReadCost = types.NewCurrency64(2)
+	settings.MaxRegistryWriteCost = types.NewCurrency64(3)
+	settings.MaxUploadCost = types.NewCurrency64(4)
+
+	// A gouging fraction above 1 should be rejected.
+	upload, priceTable := 2.0, 0.0
+	settings.Gouging.UploadUsageFraction = &upload
+	err = rt.renter.SetSettings(settings)
+	if !errors.Contains(err, errGougingFractionInvalid) {
+		t.Fatal("unexpected error", err)
+	}
+	upload = 0.5
+	settings.Gouging.PriceTableMaxFraction = &priceTable
 	err = rt.renter.SetSettings(settings)
 	if err != nil {
 		t.Fatal(err)
@@ -146,6 +200,40 @@ func TestRenterSaveLoad(t *testing.T) {
 	if newSettings.MaxUploadSpeed != newUpSpeed {
 		t.Error("upload settings not being persisted correctly")
 	}
+	if !newSettings.AccountMaxBalanceAtRisk.Equals(newMaxBalance) || !newSettings.AccountRefillThreshold.Equals(newRefillThreshold) {
+		t.Error("account refill settings not being persisted correctly")
+	}
+	if newSettings.DownloadOverdrive != 1 || newSettings.StreamOverdrive != 2 || !newSettings.AdaptiveOverdrive {
+		t.Error("overdrive settings not being persisted correctly")
+	}
+	if newSettings.LowLatencyFraction != 0.5 || newSettings.LowLatencyBudget != 100*time.Millisecond {
+		t.Error("low latency settings not being persisted correctly")
+	}
+	if newSettings.DownloadStallTimeout != time.Minute {
+		t.Error("download stall timeout not being persisted correctly")
+	}
+	if newSettings.UploadMemory != 1<<20 || newSettings.DownloadMemory != 1<<21 || newSettings.RepairMemory != 1<<22 {
+		t.Error("memory budgets not being persisted correctly")
+	}
+	if rt.renter.repairMemoryManager.callStatus().PriorityBase != 1<<22 {
+		t.Error("repair memory budget not applied after load")
+	}
+	if newSettings.SpillDir != filepath.Join(rt.dir, "spill") || newSettings.SpillCap != 1<<30 {
+		t.Error("spill settings not being persisted correctly")
+	}
+	if newSettings.PackSize != 1<<12 {
+		t.Error("pack size not being persisted correctly")
+	}
+	if !newSettings.MaxDownloadCost.Equals64(1) || !newSettings.MaxRegistryReadCost.Equals64(2) || !newSettings.MaxRegistryWriteCost.Equals64(3) || !newSettings.MaxUploadCost.Equals64(4) {
+		t.Error("cost ceilings not being persisted correctly")
+	}
+	gs := newSettings.Gouging
+	if gs.UploadUsageFraction == nil || *gs.UploadUsageFraction != 0.5 || gs.PriceTableMaxFraction == nil || *gs.PriceTableMaxFraction != 0 || gs.DownloadUsageFraction != nil {
+		t.Error("gouging settings not being persisted correctly", gs)
+	}
+	if gt := rt.renter.managedGougingThresholds(); gt.upload != 0.5 || gt.priceTable != 0 || gt.download != defaultDownloadGougingFraction {
+		t.Error("gouging settings not applied after load", gt)
+	}
 
 	// Check that SiaFileSet loaded the renter's file
 	_, err = rt.renter.staticFileSystem.OpenSiaFile(siapath)
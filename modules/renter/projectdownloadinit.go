@@ -215,10 +215,10 @@ func (pdc *projectDownloadChunk) initialWorkerHeap(unresolvedWorkers []*pcwsUnre
 		for _, pieceDownload := range piece {
 			w := pieceDownload.worker
 			pt := w.staticPriceTable().staticPriceTable
-			allowance := w.staticCache().staticRenterAllowance
+			cache := w.staticCache()
 
 			// Ignore this worker if its host is considered to be price gouging.
-			err := checkProjectDownloadGouging(pt, allowance)
+			err := checkProjectDownloadGouging(pt, cache.staticRenterAllowance, cache.staticGougingThresholds.download)
 			if err != nil {
 				continue
 			}
@@ -530,8 +530,10 @@ func (pdc *projectDownloadChunk) launchInitialWorkers() error {
 
 // checkProjectDownloadGouging verifies the cost of executing the jobs performed
 // by the project download are reasonable in relation to the user's allowance
-// and the amount of data they intend to download
-func checkProjectDownloadGouging(pt modules.RPCPriceTable, allowance modules.Allowance) error {
+// and the amount of data they intend to download. The fraction is the fraction
+// of the expected download which the allowance has to cover, 0 disables the
+// check against the allowance funds.
+func checkProjectDownloadGouging(pt modules.RPCPriceTable, allowance modules.Allowance, fraction float64) error {
 	// Check whether the download bandwidth price is too high.
 	if !allowance.MaxDownloadBandwidthPrice.IsZero() && allowance.MaxDownloadBandwidthPrice.Cmp(pt.DownloadBandwidthCost) < 0 {
 		return fmt.Errorf("download bandwidth price of host is %v, which is above the maximum allowed by the allowance: %v - price gouging protection enabled", pt.DownloadBandwidthCost, allowance.MaxDownloadBandwidthPrice)
@@ -587,7 +589,7 @@ func checkProjectDownloadGouging(pt modules.RPCPriceTable, allowance modules.All
 	// insufficient to cover a fraction of the expense to download the amount of
 	// data the user intends to download
 	totalCost := costProject.Mul64(numProjects)
-	reducedCost := totalCost.Div64(downloadGougingFractionDenom)
+	reducedCost := totalCost.MulFloat(fraction)
 	if reducedCost.Cmp(allowance.Funds) > 0 {
 		return fmt.Errorf("combined PDBR pricing of host yields %v, which is more than the renter is willing to pay for downloads: %v - price gouging protection enabled", reducedCost, allowance.Funds)
 	}
@@ -343,7 +343,7 @@ func TestProjectDownloadGouging(t *testing.T) {
 
 	// verify happy case
 	pt := newDefaultPriceTable()
-	err := checkProjectDownloadGouging(pt, allowance)
+	err := checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal("unexpected price gouging failure", err)
 	}
@@ -351,7 +351,7 @@ func TestProjectDownloadGouging(t *testing.T) {
 	// verify max download bandwidth price gouging
 	pt = newDefaultPriceTable()
 	pt.DownloadBandwidthCost = allowance.MaxDownloadBandwidthPrice.Add64(1)
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "download bandwidth price") {
 		t.Fatalf("expected download bandwidth price gouging error, instead error was '%v'", err)
 	}
@@ -359,7 +359,7 @@ func TestProjectDownloadGouging(t *testing.T) {
 	// verify max upload bandwidth price gouging
 	pt = newDefaultPriceTable()
 	pt.UploadBandwidthCost = allowance.MaxUploadBandwidthPrice.Add64(1)
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "upload bandwidth price") {
 		t.Fatalf("expected upload bandwidth price gouging error, instead error was '%v'", err)
 	}
@@ -367,7 +367,7 @@ func TestProjectDownloadGouging(t *testing.T) {
 	// update the expected download to be non zero and verify the default prices
 	allowance.ExpectedDownload = 1 << 30 // 1GiB
 	pt = newDefaultPriceTable()
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal("unexpected price gouging failure", err)
 	}
@@ -384,7 +384,7 @@ func TestProjectDownloadGouging(t *testing.T) {
 	// Cost breakdown:
 	// - cost per PDBR 266.4 mS
 	// - total cost to fulfil expected download 4.365 KS
-	// - reduced cost after applying downloadGougingFractionDenom: 1.091 KS
+	// - reduced cost after applying defaultDownloadGougingFraction: 1.091 KS
 	// - exceeding the allowance of 1 KS, which is what we are after
 	pt.UploadBandwidthCost = allowance.MaxUploadBandwidthPrice
 	pt.DownloadBandwidthCost = allowance.MaxDownloadBandwidthPrice
@@ -392,14 +392,14 @@ func TestProjectDownloadGouging(t *testing.T) {
 	pt.InitBaseCost = pt.InitBaseCost.Add(pS.Mul64(250))
 	pt.ReadBaseCost = pt.ReadBaseCost.Add(pS.Mul64(250))
 	pt.MemoryTimeCost = pt.MemoryTimeCost.Add(pS.Mul64(250))
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "combined PDBR pricing of host yields") {
 		t.Fatalf("expected PDBR price gouging error, instead error was '%v'", err)
 	}
 
 	// verify these checks are ignored if the funds are 0
 	allowance.Funds = types.ZeroCurrency
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal("unexpected price gouging failure", err)
 	}
@@ -410,28 +410,28 @@ func TestProjectDownloadGouging(t *testing.T) {
 	// in a price gouging error
 	pt = newDefaultPriceTable()
 	pt.InitBaseCost = types.SiacoinPrecision.Mul64(100)
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "combined PDBR pricing of host yields") {
 		t.Fatalf("expected PDBR price gouging error, instead error was '%v'", err)
 	}
 
 	pt = newDefaultPriceTable()
 	pt.ReadBaseCost = types.SiacoinPrecision
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "combined PDBR pricing of host yields") {
 		t.Fatalf("expected PDBR price gouging error, instead error was '%v'", err)
 	}
 
 	pt = newDefaultPriceTable()
 	pt.ReadLengthCost = types.SiacoinPrecision
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "combined PDBR pricing of host yields") {
 		t.Fatalf("expected PDBR price gouging error, instead error was '%v'", err)
 	}
 
 	pt = newDefaultPriceTable()
 	pt.MemoryTimeCost = types.SiacoinPrecision
-	err = checkProjectDownloadGouging(pt, allowance)
+	err = checkProjectDownloadGouging(pt, allowance, defaultDownloadGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "combined PDBR pricing of host yields") {
 		t.Fatalf("expected PDBR price gouging error, instead error was '%v'", err)
 	}
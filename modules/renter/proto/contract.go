@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -23,6 +24,7 @@ const (
 	updateNameInsertContract = "insertContract"
 	updateNameSetHeader      = "setHeader"
 	updateNameSetRoot        = "setRoot"
+	updateNameTruncateRoots  = "truncateRoots"
 
 	// decodeMaxSizeMultiplier is multiplied with the size of an encoded object
 	// to allocated a bit of extra space for decoding.
@@ -51,6 +53,13 @@ type updateSetRoot struct {
 	Index int
 }
 
+// updateTruncateRoots is an update which removes the sector roots at the given
+// index and above of a filecontract with the specified id.
+type updateTruncateRoots struct {
+	ID       types.FileContractID
+	NumRoots int
+}
+
 // contractHeader holds all the information about a contract apart from the
 // sector roots themselves.
 type contractHeader struct {
@@ -384,6 +393,18 @@ func (c *SafeContract) makeUpdateSetRoot(root crypto.Hash, index int) writeahead
 	}
 }
 
+// makeUpdateTruncateRoots creates an update that truncates the roots to a
+// given number of roots.
+func (c *SafeContract) makeUpdateTruncateRoots(numRoots int) writeaheadlog.Update {
+	return writeaheadlog.Update{
+		Name: updateNameTruncateRoots,
+		Instructions: encoding.Marshal(updateTruncateRoots{
+			ID:       c.header.ID(),
+			NumRoots: numRoots,
+		}),
+	}
+}
+
 // makeUpdateRefCounterAppend creates a WAL update that sets a given
 // refcounter value. If there is no open refcounter update session this method
 // will open one. This update session will be closed when we apply the update.
@@ -457,6 +478,12 @@ func (c *SafeContract) applySetRoot(root crypto.Hash, index int) error {
 	return c.merkleRoots.insert(index, root)
 }
 
+// applyTruncateRoots directly truncates the roots on disk without going
+// through a WAL transaction.
+func (c *SafeContract) applyTruncateRoots(numRoots int) error {
+	return c.merkleRoots.truncate(numRoots)
+}
+
 // managedRecordAppendIntent creates a WAL update that adds a new sector to the
 // contract and queues this update for application.
 func (c *SafeContract) managedRecordAppendIntent(rev types.FileContractRevision, root crypto.Hash, storageCost, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
@@ -492,6 +519,105 @@ func (c *SafeContract) managedRecordAppendIntent(rev types.FileContractRevision,
 	return t, nil
 }
 
+// managedRecordWriteIntent creates a WAL update that applies the sector roots
+// changed by a write to the contract and queues this update for application.
+// The contract has numRoots roots afterwards.
+func (c *SafeContract) managedRecordWriteIntent(rev types.FileContractRevision, roots map[int]crypto.Hash, numRoots int, storageCost, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// construct new header
+	// NOTE: this header will not include the host signature
+	newHeader := c.header
+	newHeader.Transaction.FileContractRevisions = []types.FileContractRevision{rev}
+	newHeader.Transaction.TransactionSignatures = nil
+	newHeader.StorageSpending = newHeader.StorageSpending.Add(storageCost)
+	newHeader.UploadSpending = newHeader.UploadSpending.Add(bandwidthCost)
+
+	updates := []writeaheadlog.Update{c.makeUpdateSetHeader(newHeader)}
+	if numRoots < c.merkleRoots.len() {
+		updates = append(updates, c.makeUpdateTruncateRoots(numRoots))
+	}
+	indices := make([]int, 0, len(roots))
+	for index := range roots {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		updates = append(updates, c.makeUpdateSetRoot(roots[index], index))
+	}
+	if build.Release == "testing" {
+		for i := c.merkleRoots.len(); i < numRoots; i++ {
+			rcUpdate, err := c.makeUpdateRefCounterAppend()
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to create a refcounter update")
+			}
+			updates = append(updates, rcUpdate)
+		}
+	}
+	t, err := c.newWalTxn(updates)
+	if err != nil {
+		return nil, err
+	}
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return nil, err
+	}
+	c.unappliedTxns = append(c.unappliedTxns, t)
+	return t, nil
+}
+
+// managedRootsAfterWrite returns the sector roots which are changed by
+// applying the actions of a write to the contract and the number of roots
+// afterwards.
+func (c *SafeContract) managedRootsAfterWrite(actions []modules.LoopWriteAction) (map[int]crypto.Hash, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := make(map[int]crypto.Hash)
+	numRoots := c.merkleRoots.len()
+	rootAt := func(i uint64) (crypto.Hash, error) {
+		if i >= uint64(numRoots) {
+			return crypto.Hash{}, fmt.Errorf("sector index %v out of range", i)
+		}
+		if root, ok := changed[int(i)]; ok {
+			return root, nil
+		}
+		roots, err := c.merkleRoots.merkleRootsFromIndexFromDisk(int(i), int(i)+1)
+		if err != nil {
+			return crypto.Hash{}, err
+		}
+		return roots[0], nil
+	}
+	for _, action := range actions {
+		switch action.Type {
+		case modules.WriteActionAppend:
+			changed[numRoots] = crypto.MerkleRoot(action.Data)
+			numRoots++
+		case modules.WriteActionTrim:
+			if action.A > uint64(numRoots) {
+				return nil, 0, fmt.Errorf("can't trim %v of %v sectors", action.A, numRoots)
+			}
+			numRoots -= int(action.A)
+			for index := range changed {
+				if index >= numRoots {
+					delete(changed, index)
+				}
+			}
+		case modules.WriteActionSwap:
+			a, err := rootAt(action.A)
+			if err != nil {
+				return nil, 0, err
+			}
+			b, err := rootAt(action.B)
+			if err != nil {
+				return nil, 0, err
+			}
+			changed[int(action.A)], changed[int(action.B)] = b, a
+		default:
+			return nil, 0, fmt.Errorf("unsupported action type %v", action.Type)
+		}
+	}
+	return changed, numRoots, nil
+}
+
 // managedCommitAppend ignores the header update in the given transaction and
 // instead applies a new one based on the provided signedTxn. This is necessary
 // if we run into a desync of contract revisions between renter and host.
@@ -523,6 +649,14 @@ func (c *SafeContract) managedCommitAppend(t *unappliedWalTxn, signedTxn types.T
 			if err := c.applySetRoot(sru.Root, sru.Index); err != nil {
 				return err
 			}
+		case updateNameTruncateRoots:
+			var tru updateTruncateRoots
+			if err := encoding.Unmarshal(u.Instructions, &tru); err != nil {
+				return err
+			}
+			if err := c.applyTruncateRoots(tru.NumRoots); err != nil {
+				return err
+			}
 		case updateNameRCWriteAt:
 			if err = c.applyRefCounterUpdate(u); err != nil {
 				return errors.AddContext(err, "failed to apply refcounter update")
@@ -679,6 +813,14 @@ func (c *SafeContract) managedCommitTxns() error {
 				if err := c.applySetRoot(u.Root, u.Index); err != nil {
 					return err
 				}
+			case updateNameTruncateRoots:
+				var u updateTruncateRoots
+				if err := encoding.Unmarshal(update.Instructions, &u); err != nil {
+					return err
+				}
+				if err := c.applyTruncateRoots(u.NumRoots); err != nil {
+					return err
+				}
 			case updateNameRCWriteAt:
 				if err := c.applyRefCounterUpdate(update); err != nil {
 					return err
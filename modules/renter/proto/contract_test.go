@@ -936,6 +936,81 @@ func TestContractRecordCommitAppendIntent(t *testing.T) {
 	}
 }
 
+// TestContractRecordCommitWriteIntent tests that the roots of a contract
+// match its revision after committing writes with appends, swaps and trims.
+func TestContractRecordCommitWriteIntent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create contract set and add a contract with some roots
+	dir := build.TempDir(filepath.Join("proto", t.Name()))
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(dir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []crypto.Hash{{1}, {2}, {3}}
+	contract, err := cs.managedInsertContract(newExportTestHeader(1, 100, roots), roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := cs.managedMustAcquire(t, contract.ID)
+
+	// write applies the actions to the contract and to the expected roots.
+	write := func(actions []modules.LoopWriteAction, expected []crypto.Hash) {
+		t.Helper()
+		changed, numRoots, err := sc.managedRootsAfterWrite(actions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev := sc.LastRevision()
+		rev.NewRevisionNumber++
+		rev.NewFileSize = uint64(numRoots) * modules.SectorSize
+		rev.NewFileMerkleRoot = cachedMerkleRoot(expected)
+		walTxn, err := sc.managedRecordWriteIntent(rev, changed, numRoots, types.ZeroCurrency, types.ZeroCurrency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sc.managedCommitAppend(walTxn, rev.ToTransaction(), types.ZeroCurrency, types.ZeroCurrency); err != nil {
+			t.Fatal(err)
+		}
+		ec, err := newExportedContract(sc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ec.Roots, expected) {
+			t.Fatal("roots don't match", ec.Roots, expected)
+		}
+		if err := ec.validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Append a sector.
+	data := fastrand.Bytes(int(modules.SectorSize))
+	newRoot := crypto.MerkleRoot(data)
+	write([]modules.LoopWriteAction{
+		{Type: modules.WriteActionAppend, Data: data},
+	}, []crypto.Hash{{1}, {2}, {3}, newRoot})
+
+	// Replace the first sector like Session.Replace.
+	data = fastrand.Bytes(int(modules.SectorSize))
+	replaceRoot := crypto.MerkleRoot(data)
+	write([]modules.LoopWriteAction{
+		{Type: modules.WriteActionAppend, Data: data},
+		{Type: modules.WriteActionSwap, A: 0, B: 4},
+		{Type: modules.WriteActionTrim, A: 1},
+	}, []crypto.Hash{replaceRoot, {2}, {3}, newRoot})
+
+	// Trim more sectors than the contract has.
+	if _, _, err := sc.managedRootsAfterWrite([]modules.LoopWriteAction{{Type: modules.WriteActionTrim, A: 5}}); err == nil {
+		t.Fatal("expected trimming too many sectors to fail")
+	}
+	cs.Return(sc)
+}
+
 // TestContractRecordCommitRenewAndClearIntent tests recording and committing
 // downloads and makes sure they use the wal correctly.
 func TestContractRecordCommitRenewAndClearIntent(t *testing.T) {
@@ -0,0 +1,226 @@
+package proto
+
+import (
+	"io"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// exportedContractMaxSize is the maximum number of bytes that are
+	// allocated when decoding a single exported contract. Most of it is
+	// taken up by the merkle roots of the contract.
+	exportedContractMaxSize = 1 << 30
+)
+
+var (
+	// errImportedRootsMismatch is returned if the merkle roots of an imported
+	// contract don't match the latest revision of the contract.
+	errImportedRootsMismatch = errors.New("merkle roots of the contract don't match its latest revision")
+
+	// errImportedContractCollision is returned if a transferred contract can't
+	// be imported because the set already contains it or a contract with the
+	// same host.
+	errImportedContractCollision = errors.New("set already contains the contract or a contract with its host")
+)
+
+// exportedContract is a contract of a set, including its secret key and
+// merkle roots, as it is written by ExportContracts.
+type exportedContract struct {
+	Header contractHeader
+	Roots  []crypto.Hash
+}
+
+// validate returns an error if the exported contract is invalid.
+func (ec *exportedContract) validate() error {
+	if err := ec.Header.validate(); err != nil {
+		return err
+	}
+	rev := ec.Header.LastRevision()
+	if uint64(len(ec.Roots))*modules.SectorSize != rev.NewFileSize {
+		return errImportedRootsMismatch
+	}
+	if cachedMerkleRoot(ec.Roots) != rev.NewFileMerkleRoot {
+		return errImportedRootsMismatch
+	}
+	return nil
+}
+
+// ExportContracts writes all contracts of the set, including their secret keys
+// and merkle roots, to w. Every contract is prefixed by a 'true' bool and the
+// last contract is followed by a 'false' bool, which allows for exporting the
+// contracts one by one without holding all of their roots in memory.
+func (cs *ContractSet) ExportContracts(w io.Writer) error {
+	enc := encoding.NewEncoder(w)
+	for _, id := range cs.IDs() {
+		sc, ok := cs.Acquire(id)
+		if !ok {
+			continue // contract was deleted in the meantime
+		}
+		ec, err := newExportedContract(sc)
+		cs.Return(sc)
+		if err != nil {
+			return err
+		}
+		if err := enc.EncodeAll(true, ec); err != nil {
+			return errors.AddContext(err, "failed to write contract")
+		}
+	}
+	return enc.Encode(false)
+}
+
+// TransferContracts writes all contracts of the set to w like ExportContracts
+// and calls commit afterwards. The contracts are held during the whole
+// transfer, so they can't be revised after being written. If commit succeeds,
+// the contracts are removed from the set and returned. Otherwise the set stays
+// unchanged.
+func (cs *ContractSet) TransferContracts(w io.Writer, commit func() error) ([]modules.RenterContract, error) {
+	var held []*SafeContract
+	defer func() {
+		for _, sc := range held {
+			cs.Return(sc)
+		}
+	}()
+	enc := encoding.NewEncoder(w)
+	for _, id := range cs.IDs() {
+		sc, ok := cs.Acquire(id)
+		if !ok {
+			continue // contract was deleted in the meantime
+		}
+		held = append(held, sc)
+		ec, err := newExportedContract(sc)
+		if err != nil {
+			return nil, err
+		}
+		if err := enc.EncodeAll(true, ec); err != nil {
+			return nil, errors.AddContext(err, "failed to write contract")
+		}
+	}
+	if err := enc.Encode(false); err != nil {
+		return nil, err
+	}
+	if err := commit(); err != nil {
+		return nil, err
+	}
+
+	// The contracts were handed over. Remove them from the set.
+	transferred := make([]modules.RenterContract, 0, len(held))
+	for _, sc := range held {
+		transferred = append(transferred, sc.Metadata())
+		cs.Delete(sc)
+	}
+	held = nil
+	return transferred, nil
+}
+
+// newExportedContract creates the exported version of an acquired contract.
+func newExportedContract(sc *SafeContract) (exportedContract, error) {
+	sc.mu.Lock()
+	header := sc.header
+	sc.mu.Unlock()
+	roots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		return exportedContract{}, errors.AddContext(err, "failed to read merkle roots of contract "+header.ID().String())
+	}
+	return exportedContract{
+		Header: header,
+		Roots:  roots,
+	}, nil
+}
+
+// readExportedContracts reads contracts that were written by ExportContracts
+// from r and calls fn for every valid contract.
+func readExportedContracts(r io.Reader, fn func(ec exportedContract) error) error {
+	for {
+		var more bool
+		var ec exportedContract
+		if err := encoding.NewDecoder(r, encoding.DefaultAllocLimit).Decode(&more); err != nil {
+			return errors.AddContext(err, "failed to read contract")
+		}
+		if !more {
+			return nil
+		}
+		if err := encoding.NewDecoder(r, exportedContractMaxSize).Decode(&ec); err != nil {
+			return errors.AddContext(err, "failed to read contract")
+		}
+		if err := ec.validate(); err != nil {
+			return errors.AddContext(err, "invalid contract "+ec.Header.ID().String())
+		}
+		if err := fn(ec); err != nil {
+			return err
+		}
+	}
+}
+
+// managedCollides returns whether the set already contains the exported
+// contract or a contract with its host.
+func (cs *ContractSet) managedCollides(ec exportedContract) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	_, exists := cs.contracts[ec.Header.ID()]
+	_, hostExists := cs.pubKeys[ec.Header.HostPublicKey().String()]
+	return exists || hostExists
+}
+
+// ImportContracts reads contracts that were written by ExportContracts from r
+// and inserts them into the set. Contracts that are already part of the set,
+// contracts with hosts the set already has a contract with and contracts that
+// end at or before the provided block height are skipped. The imported
+// contracts are returned.
+func (cs *ContractSet) ImportContracts(r io.Reader, blockHeight types.BlockHeight) ([]modules.RenterContract, error) {
+	var imported []modules.RenterContract
+	err := readExportedContracts(r, func(ec exportedContract) error {
+		// Skip contracts we already know about and expired contracts.
+		if cs.managedCollides(ec) || ec.Header.EndHeight() <= blockHeight {
+			return nil
+		}
+		rc, err := cs.managedInsertContract(ec.Header, ec.Roots)
+		if err != nil {
+			return errors.AddContext(err, "failed to insert contract "+ec.Header.ID().String())
+		}
+		imported = append(imported, rc)
+		return nil
+	})
+	return imported, err
+}
+
+// ImportTransferredContracts reads contracts that were written by
+// TransferContracts from r and inserts them into the set. Unlike
+// ImportContracts, it doesn't skip contracts that collide with the set since
+// the data stored with them would become unreachable. If any of the contracts
+// collides with the set, no contract is imported. Contracts that end at or
+// before the provided block height are skipped. The imported contracts are
+// returned.
+func (cs *ContractSet) ImportTransferredContracts(r io.Reader, blockHeight types.BlockHeight) ([]modules.RenterContract, error) {
+	// Read and check all contracts before inserting any of them.
+	var ecs []exportedContract
+	hosts := make(map[string]struct{})
+	err := readExportedContracts(r, func(ec exportedContract) error {
+		host := ec.Header.HostPublicKey().String()
+		if _, exists := hosts[host]; exists || cs.managedCollides(ec) {
+			return errors.AddContext(errImportedContractCollision, "failed to import contract "+ec.Header.ID().String())
+		}
+		hosts[host] = struct{}{}
+		if ec.Header.EndHeight() > blockHeight {
+			ecs = append(ecs, ec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var imported []modules.RenterContract
+	for _, ec := range ecs {
+		rc, err := cs.managedInsertContract(ec.Header, ec.Roots)
+		if err != nil {
+			return imported, errors.AddContext(err, "failed to insert contract "+ec.Header.ID().String())
+		}
+		imported = append(imported, rc)
+	}
+	return imported, nil
+}
@@ -0,0 +1,243 @@
+package proto
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newExportTestHeader creates a contract header with the given id, host key
+// and merkle roots for testing contract exports.
+func newExportTestHeader(id byte, endHeight types.BlockHeight, roots []crypto.Hash) contractHeader {
+	return contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID:             types.FileContractID{id},
+				NewFileSize:          uint64(len(roots)) * modules.SectorSize,
+				NewFileMerkleRoot:    cachedMerkleRoot(roots),
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				NewWindowStart:       endHeight,
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{{}, {Key: []byte{id}}},
+				},
+			}},
+		},
+		SecretKey: crypto.SecretKey{id},
+	}
+}
+
+// TestExportImportContracts tests exporting the contracts of a set and
+// importing them into another set.
+func TestExportImportContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("proto", t.Name())
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(filepath.Join(testDir, "src"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a contract with roots, one without roots and an expired one.
+	roots := []crypto.Hash{{}, {}, {}}
+	for i := range roots {
+		fastrand.Read(roots[i][:])
+	}
+	headers := []contractHeader{
+		newExportTestHeader(1, 100, roots),
+		newExportTestHeader(2, 100, nil),
+		newExportTestHeader(3, 10, nil),
+	}
+	for i, h := range headers {
+		r := roots
+		if i > 0 {
+			r = nil
+		}
+		if _, err := cs.managedInsertContract(h, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Export the contracts.
+	var buf bytes.Buffer
+	if err := cs.ExportContracts(&buf); err != nil {
+		t.Fatal(err)
+	}
+	export := buf.Bytes()
+
+	// Import them into a set that already has a contract with the host of the
+	// second contract.
+	cs2, err := NewContractSet(filepath.Join(testDir, "dst"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := newExportTestHeader(2, 100, nil)
+	existing.Transaction.FileContractRevisions[0].ParentID = types.FileContractID{4}
+	if _, err := cs2.managedInsertContract(existing, nil); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := cs2.ImportContracts(bytes.NewReader(export), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 1 || imported[0].ID != headers[0].ID() {
+		t.Fatal("expected only the first contract to be imported", imported)
+	}
+
+	// The imported contract should have the same header and roots.
+	sc := cs2.managedMustAcquire(t, headers[0].ID())
+	importedRoots, err := sc.merkleRoots.merkleRoots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoding.Marshal(sc.header), encoding.Marshal(headers[0])) {
+		t.Fatal("imported header doesn't match")
+	}
+	if !reflect.DeepEqual(importedRoots, roots) {
+		t.Fatal("imported roots don't match")
+	}
+	cs2.Return(sc)
+
+	// Importing again is a no-op.
+	imported, err = cs2.ImportContracts(bytes.NewReader(export), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 0 {
+		t.Fatal("contracts were imported twice", len(imported))
+	}
+
+	// A contract whose roots don't match its revision is rejected.
+	var invalid bytes.Buffer
+	cs3, err := NewContractSet(filepath.Join(testDir, "invalid"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newExportTestHeader(5, 100, roots)
+	if _, err := cs3.managedInsertContract(h, roots[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs3.ExportContracts(&invalid); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cs2.ImportContracts(&invalid, 50)
+	if !errors.Contains(err, errImportedRootsMismatch) {
+		t.Fatal("expected roots mismatch but got", err)
+	}
+
+	// A contract with empty roots that don't match its revision is rejected
+	// as well.
+	var unknown bytes.Buffer
+	cs4, err := NewContractSet(filepath.Join(testDir, "unknown"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = newExportTestHeader(6, 100, roots)
+	if _, err := cs4.managedInsertContract(h, make([]crypto.Hash, len(roots))); err != nil {
+		t.Fatal(err)
+	}
+	if err := cs4.ExportContracts(&unknown); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cs2.ImportContracts(&unknown, 50)
+	if !errors.Contains(err, errImportedRootsMismatch) {
+		t.Fatal("expected roots mismatch but got", err)
+	}
+}
+
+// TestTransferContracts tests that transferred contracts are only removed from
+// the set if the transfer was committed.
+func TestTransferContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("proto", t.Name())
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(filepath.Join(testDir, "src"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := []crypto.Hash{{1}, {2}}
+	headers := []contractHeader{
+		newExportTestHeader(1, 100, roots),
+		newExportTestHeader(2, 100, nil),
+	}
+	if _, err := cs.managedInsertContract(headers[0], roots); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cs.managedInsertContract(headers[1], nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed commit leaves the set unchanged.
+	errCommit := errors.New("commit failed")
+	var buf bytes.Buffer
+	_, err = cs.TransferContracts(&buf, func() error { return errCommit })
+	if !errors.Contains(err, errCommit) {
+		t.Fatal("expected commit error but got", err)
+	}
+	if cs.Len() != 2 {
+		t.Fatal("contracts were removed after failed commit", cs.Len())
+	}
+
+	// A successful commit removes the contracts. The transfer can be imported
+	// like an export.
+	buf.Reset()
+	var committed []byte
+	transferred, err := cs.TransferContracts(&buf, func() error {
+		committed = append([]byte(nil), buf.Bytes()...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transferred) != 2 || cs.Len() != 0 {
+		t.Fatal("unexpected transfer", len(transferred), cs.Len())
+	}
+	cs2, err := NewContractSet(filepath.Join(testDir, "dst"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := cs2.ImportTransferredContracts(bytes.NewReader(committed), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 {
+		t.Fatal("expected both contracts to be imported", len(imported))
+	}
+
+	// A transfer that collides with a contract of the set isn't imported at
+	// all.
+	cs3, err := NewContractSet(filepath.Join(testDir, "collision"), rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := newExportTestHeader(2, 100, nil)
+	existing.Transaction.FileContractRevisions[0].ParentID = types.FileContractID{3}
+	if _, err := cs3.managedInsertContract(existing, nil); err != nil {
+		t.Fatal(err)
+	}
+	_, err = cs3.ImportTransferredContracts(bytes.NewReader(committed), 50)
+	if !errors.Contains(err, errImportedContractCollision) {
+		t.Fatal("expected collision but got", err)
+	}
+	if cs3.Len() != 1 {
+		t.Fatal("contracts of a colliding transfer were imported", cs3.Len())
+	}
+}
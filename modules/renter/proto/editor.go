@@ -234,7 +234,7 @@ func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc
 	c, err := (&net.Dialer{
 		Cancel:  cancel,
 		Timeout: 45 * time.Second, // TODO: Constant
-	}).Dial("tcp", string(host.NetAddress))
+	}).Dial("tcp", string(host.DialAddress()))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -245,6 +245,26 @@ func (mr *merkleRoots) insert(index int, root crypto.Hash) error {
 	return nil
 }
 
+// truncate removes the roots at index n and above.
+func (mr *merkleRoots) truncate(n int) error {
+	if n >= mr.numMerkleRoots {
+		return nil
+	}
+	if err := mr.rootsFile.Truncate(int64(n * crypto.HashSize)); err != nil {
+		return errors.AddContext(err, "failed to truncate file")
+	}
+	mr.numMerkleRoots = n
+	// Drop the cached subTrees which contain truncated roots and load the
+	// remaining roots of the last one.
+	mr.cachedSubTrees = mr.cachedSubTrees[:n/merkleRootsPerCache]
+	roots, err := mr.merkleRootsFromIndexFromDisk(len(mr.cachedSubTrees)*merkleRootsPerCache, n)
+	if err != nil {
+		return errors.AddContext(err, "failed to read uncached roots")
+	}
+	mr.uncachedRoots = roots
+	return nil
+}
+
 // isIndexCached determines if the root at index i is already cached in
 // mr.cachedSubTree or if it is still in mr.uncachedRoots. It will return true
 // or false and the index of the root in the corresponding data structure.
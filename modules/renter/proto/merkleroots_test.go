@@ -378,6 +378,49 @@ func TestDelete(t *testing.T) {
 	}
 }
 
+// TestTruncate tests truncating the merkle roots to a number of roots within
+// and at the boundary of a cached subTree.
+func TestTruncate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir(t.Name())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(path.Join(dir, "file.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merkleRoots := newMerkleRoots(file)
+	for i := 0; i < 3*merkleRootsPerCache; i++ {
+		var hash crypto.Hash
+		fastrand.Read(hash[:])
+		if err := merkleRoots.push(hash); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, n := range []int{3*merkleRootsPerCache + 1, 2*merkleRootsPerCache + 5, 2 * merkleRootsPerCache, merkleRootsPerCache - 1, 0} {
+		expected := n
+		if expected > merkleRoots.len() {
+			expected = merkleRoots.len()
+		}
+		if err := merkleRoots.truncate(n); err != nil {
+			t.Fatal(err)
+		}
+		if merkleRoots.len() != expected {
+			t.Fatalf("expected %v roots but got %v", expected, merkleRoots.len())
+		}
+		loadedRoots, _, err := loadExistingMerkleRootsFromSection(merkleRoots.rootsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cmpRoots(loadedRoots, merkleRoots); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestMerkleRootsRandom creates a large number of merkle roots and runs random
 // valid operations on them that shouldn't result in any errors.
 func TestMerkleRootsRandom(t *testing.T) {
@@ -287,9 +287,11 @@ func (s *Session) write(sc *SafeContract, actions []modules.LoopWriteAction) (_
 	// record the change we are about to make to the contract. If we lose power
 	// mid-revision, this allows us to restore either the pre-revision or
 	// post-revision contract.
-	//
-	// TODO: update this for non-local root storage
-	walTxn, err := sc.managedRecordAppendIntent(rev, crypto.Hash{}, storagePrice, bandwidthPrice)
+	roots, numRoots, err := sc.managedRootsAfterWrite(actions)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	walTxn, err := sc.managedRecordWriteIntent(rev, roots, numRoots, storagePrice, bandwidthPrice)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -881,15 +883,16 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 
 	// If we are using a custom resolver we need to replace the domain name
 	// with 127.0.0.1 to be able to dial the host.
+	dialAddr := host.DialAddress()
 	if cs.staticDeps.Disrupt("customResolver") {
-		port := host.NetAddress.Port()
-		host.NetAddress = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
+		port := dialAddr.Port()
+		dialAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
 	}
 
 	c, err := (&net.Dialer{
 		Cancel:  cancel,
 		Timeout: sessionDialTimeout,
-	}).Dial("tcp", string(host.NetAddress))
+	}).Dial("tcp", string(dialAddr))
 	if err != nil {
 		return nil, errors.AddContext(err, "unsuccessful dial when creating a new session")
 	}
@@ -317,7 +317,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, spk types.SiaPublicKey
 		// TODO: use 'checkProjectDownloadGouging' gouging for some basic
 		// protection. Should be replaced as part of the gouging overhaul.
 		pt := worker.staticPriceTable().staticPriceTable
-		err := checkProjectDownloadGouging(pt, cache.staticRenterAllowance)
+		err := checkProjectDownloadGouging(pt, cache.staticRenterAllowance, cache.staticGougingThresholds.registry)
 		if err != nil {
 			r.log.Debugf("price gouging detected in worker %v, err: %v\n", worker.staticHostPubKeyStr, err)
 			continue
@@ -475,7 +475,7 @@ func (r *Renter) managedUpdateRegistry(ctx context.Context, spk types.SiaPublicK
 		if !ok || err != nil {
 			continue
 		}
-		err = checkUploadGouging(cache.staticRenterAllowance, host.HostExternalSettings)
+		err = checkUploadGouging(cache.staticRenterAllowance, host.HostExternalSettings, cache.staticGougingThresholds.registry)
 		if err != nil {
 			r.log.Debugf("price gouging detected in worker %v, err: %v\n", worker.staticHostPubKeyStr, err)
 			continue
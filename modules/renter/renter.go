@@ -93,6 +93,14 @@ type hostContractor interface {
 	// ChurnStatus returns contract churn stats for the current period.
 	ChurnStatus() modules.ContractorChurnStatus
 
+	// FormationPlan returns the plan the contractor would execute to form
+	// contracts for the provided allowance without forming any contracts.
+	FormationPlan(a modules.Allowance) (modules.ContractFormationPlan, error)
+
+	// SpendingForecast projects the spending of the allowance to the end of
+	// the current period.
+	SpendingForecast() (modules.SpendingForecast, error)
+
 	// ContractUtility returns the utility field for a given contract, along
 	// with a bool indicating if it exists.
 	ContractUtility(types.SiaPublicKey) (modules.ContractUtility, bool)
@@ -105,6 +113,32 @@ type hostContractor interface {
 	// began.
 	CurrentPeriod() types.BlockHeight
 
+	// ExportContracts writes the contracts, including their secret keys and
+	// merkle roots, to w.
+	ExportContracts(w io.Writer) error
+
+	// ImportContracts imports contracts written by ExportContracts from r and
+	// returns the number of imported contracts.
+	ImportContracts(r io.Reader) (int, error)
+
+	// ImportTransferredContracts imports contracts written by
+	// TransferContracts from r. It fails without importing any contract if
+	// one of them collides with the existing contracts.
+	ImportTransferredContracts(r io.Reader) (int, error)
+
+	// TransferContracts writes the contracts to w like ExportContracts and
+	// stops using them if commit succeeds. It returns the number of
+	// transferred contracts.
+	TransferContracts(w io.Writer, commit func() error) (int, error)
+
+	// ConfirmTransferredContracts archives the contracts of an unconfirmed
+	// transfer.
+	ConfirmTransferredContracts() (int, error)
+
+	// CancelTransferredContracts restores the contracts of an unconfirmed
+	// transfer.
+	CancelTransferredContracts() (int, error)
+
 	// InitRecoveryScan starts scanning the whole blockchain for recoverable
 	// contracts within a separate thread.
 	InitRecoveryScan() error
@@ -141,13 +175,19 @@ type hostContractor interface {
 	// isn't available for recovery or something went wrong.
 	RecoverableContracts() []modules.RecoverableContract
 
-	// RecoveryScanStatus returns a bool indicating if a scan for recoverable
-	// contracts is in progress and if it is, the current progress of the scan.
-	RecoveryScanStatus() (bool, types.BlockHeight)
+	// RecoveryStatus returns the progress of the most recent scan for
+	// recoverable contracts and the number of contracts it found and
+	// recovered.
+	RecoveryStatus() modules.ContractRecoveryStatus
 
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// UnrecoverableContracts returns the recoverable contracts that the
+	// contractor failed to recover together with the reason of the most
+	// recent failure.
+	UnrecoverableContracts() []modules.UnrecoverableContract
+
 	// RenewContract takes an established connection to a host and renews the
 	// given contract with that host.
 	RenewContract(conn net.Conn, fcid types.FileContractID, params modules.ContractParams, txnBuilder modules.TransactionBuilder, tpool modules.TransactionPool, hdb modules.HostDB, pt *modules.RPCPriceTable) (modules.RenterContract, []types.Transaction, error)
@@ -198,6 +238,27 @@ type Renter struct {
 	downloadHistory   map[modules.DownloadID]*download
 	downloadHistoryMu sync.Mutex
 
+	// URL uploads. The uploads have their own mutex because they are always
+	// accessed in isolation.
+	urlUploads   map[modules.SiaPath]*urlUpload
+	urlUploadsMu sync.Mutex
+
+	// Changes of the erasure coding parameters of files.
+	reshards   map[modules.SiaPath]*reshard
+	reshardsMu sync.Mutex
+
+	// Packing of small files.
+	staticPacker *packer
+
+	// Cumulative stats of the renter.
+	staticStats *renterStats
+
+	// Budgets of third-party applications which pay hosts for MDM programs.
+	staticPaymentBudgets *paymentBudgets
+
+	// Settings and reports of the audits of the renter's files.
+	staticAudits *audits
+
 	// Upload management.
 	uploadHeap    uploadHeap
 	directoryHeap directoryHeap
@@ -265,6 +326,7 @@ type Renter struct {
 	staticMux                          *siamux.SiaMux
 	memoryManager                      *memoryManager
 	staticUploadChunkDistributionQueue *uploadChunkDistributionQueue
+	staticUploadSpill                  *uploadSpill
 }
 
 // Close closes the Renter and its dependencies
@@ -588,6 +650,26 @@ func (r *Renter) setBandwidthLimits(downloadSpeed int64, uploadSpeed int64) erro
 	return nil
 }
 
+// setMemoryBudgets will resize the memory managers of user uploads, user
+// downloads and repairs. A zero budget resets a memory manager to its default
+// budget.
+func (r *Renter) setMemoryBudgets(upload, download, repair uint64) {
+	if upload == 0 {
+		upload = userUploadMemoryDefault
+	}
+	if download == 0 {
+		download = userDownloadMemoryDefault
+	}
+	repairPriority := repair / 4
+	if repair == 0 {
+		repair = repairMemoryDefault
+		repairPriority = repairMemoryPriorityDefault
+	}
+	r.userUploadMemoryManager.callSetBase(upload, userUploadMemoryPriorityDefault)
+	r.userDownloadMemoryManager.callSetBase(download, userDownloadMemoryPriorityDefault)
+	r.repairMemoryManager.callSetBase(repair, repairPriority)
+}
+
 // SetSettings will update the settings for the renter.
 //
 // NOTE: This function can't be atomic. Typically we try to have user requests
@@ -604,6 +686,28 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	target, threshold := accountRefillPolicy(s.AccountMaxBalanceAtRisk, s.AccountRefillThreshold)
+	if threshold.Cmp(target) > 0 {
+		return errAccountRefillThresholdTooHigh
+	}
+	if s.DownloadOverdrive > maxDownloadOverdrive || s.StreamOverdrive > maxDownloadOverdrive {
+		return errOverdriveTooHigh
+	}
+	if s.LowLatencyFraction < 0 || s.LowLatencyFraction > 1 {
+		return errLowLatencyFractionInvalid
+	}
+	if s.LowLatencyBudget < 0 {
+		return errLowLatencyBudgetNegative
+	}
+	if s.DownloadStallTimeout < 0 {
+		return errDownloadStallTimeoutNegative
+	}
+	if s.SpillDir != "" && !filepath.IsAbs(s.SpillDir) {
+		return errSpillDirNotAbsolute
+	}
+	if err := validateGougingSettings(s.Gouging); err != nil {
+		return err
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -620,10 +724,32 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 		return err
 	}
 
+	// Set the memory budgets.
+	r.setMemoryBudgets(s.UploadMemory, s.DownloadMemory, s.RepairMemory)
+
 	// Save the changes.
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.AccountMaxBalanceAtRisk = s.AccountMaxBalanceAtRisk
+	r.persist.AccountRefillThreshold = s.AccountRefillThreshold
+	r.persist.DownloadOverdrive = s.DownloadOverdrive
+	r.persist.StreamOverdrive = s.StreamOverdrive
+	r.persist.AdaptiveOverdrive = s.AdaptiveOverdrive
+	r.persist.LowLatencyFraction = s.LowLatencyFraction
+	r.persist.LowLatencyBudget = s.LowLatencyBudget
+	r.persist.DownloadStallTimeout = s.DownloadStallTimeout
+	r.persist.UploadMemory = s.UploadMemory
+	r.persist.DownloadMemory = s.DownloadMemory
+	r.persist.RepairMemory = s.RepairMemory
+	r.persist.SpillDir = s.SpillDir
+	r.persist.SpillCap = s.SpillCap
+	r.persist.PackSize = s.PackSize
+	r.persist.MaxDownloadCost = s.MaxDownloadCost
+	r.persist.MaxRegistryReadCost = s.MaxRegistryReadCost
+	r.persist.MaxRegistryWriteCost = s.MaxRegistryWriteCost
+	r.persist.MaxUploadCost = s.MaxUploadCost
+	r.persist.Gouging = copyGougingSettings(s.Gouging)
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -724,6 +850,40 @@ func (r *Renter) Host(spk types.SiaPublicKey) (modules.HostDBEntry, bool, error)
 // hostdb is completed.
 func (r *Renter) InitialScanComplete() (bool, error) { return r.hostDB.InitialScanComplete() }
 
+// HostDBExportSnapshot returns a snapshot of all hosts known to the renter's
+// hostdb.
+func (r *Renter) HostDBExportSnapshot() (modules.HostDBSnapshot, error) {
+	return r.hostDB.ExportSnapshot()
+}
+
+// HostDBImportSnapshot imports the hosts of a snapshot into the renter's
+// hostdb.
+func (r *Renter) HostDBImportSnapshot(snapshot modules.HostDBSnapshot) (modules.HostDBImportResult, error) {
+	return r.hostDB.ImportSnapshot(snapshot)
+}
+
+// HostDBScanSettings returns the settings which control how the renter's
+// hostdb scans hosts.
+func (r *Renter) HostDBScanSettings() (modules.HostDBScanSettings, error) {
+	return r.hostDB.ScanSettings()
+}
+
+// SetHostDBScanSettings sets the settings which control how the renter's
+// hostdb scans hosts.
+func (r *Renter) SetHostDBScanSettings(settings modules.HostDBScanSettings) error {
+	return r.hostDB.SetScanSettings(settings)
+}
+
+// ScanHost scans a host right away and updates its entry in the hostdb.
+func (r *Renter) ScanHost(pk types.SiaPublicKey) error {
+	return r.hostDB.ScanHost(pk)
+}
+
+// MarkHostOffline records a failed scan for a host without scanning it.
+func (r *Renter) MarkHostOffline(pk types.SiaPublicKey) error {
+	return r.hostDB.MarkHostOffline(pk)
+}
+
 // ScoreBreakdown returns the score breakdown
 func (r *Renter) ScoreBreakdown(e modules.HostDBEntry) (modules.HostScoreBreakdown, error) {
 	return r.hostDB.ScoreBreakdown(e)
@@ -772,16 +932,40 @@ func (r *Renter) ContractorChurnStatus() modules.ContractorChurnStatus {
 	return r.hostContractor.ChurnStatus()
 }
 
+// ContractFormationPlan returns the plan the contractor would execute to form
+// contracts for the provided allowance without forming any contracts.
+func (r *Renter) ContractFormationPlan(a modules.Allowance) (modules.ContractFormationPlan, error) {
+	return r.hostContractor.FormationPlan(a)
+}
+
+// SpendingForecast projects the spending of the allowance to the end of the
+// current period and compares the estimated cost of renewing the contracts
+// with the funds of the wallet.
+func (r *Renter) SpendingForecast() (modules.SpendingForecast, error) {
+	forecast, err := r.hostContractor.SpendingForecast()
+	if err != nil {
+		return modules.SpendingForecast{}, err
+	}
+	forecast.WalletFunds, _, _, err = r.w.ConfirmedBalance()
+	if err != nil {
+		return modules.SpendingForecast{}, errors.AddContext(err, "failed to get the wallet balance")
+	}
+	if forecast.WalletFunds.Cmp(forecast.EstimatedRenewCost) < 0 {
+		forecast.Warnings = append(forecast.Warnings, fmt.Sprintf("the wallet holds %v which is less than the estimated cost of %v to renew the contracts", forecast.WalletFunds.HumanString(), forecast.EstimatedRenewCost.HumanString()))
+	}
+	return forecast, nil
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (r *Renter) InitRecoveryScan() error {
 	return r.hostContractor.InitRecoveryScan()
 }
 
-// RecoveryScanStatus returns a bool indicating if a scan for recoverable
-// contracts is in progress and if it is, the current progress of the scan.
-func (r *Renter) RecoveryScanStatus() (bool, types.BlockHeight) {
-	return r.hostContractor.RecoveryScanStatus()
+// RecoveryStatus returns the progress of the most recent scan for recoverable
+// contracts.
+func (r *Renter) RecoveryStatus() modules.ContractRecoveryStatus {
+	return r.hostContractor.RecoveryStatus()
 }
 
 // OldContracts returns an array of host contractor's oldContracts
@@ -799,6 +983,12 @@ func (r *Renter) RecoverableContracts() []modules.RecoverableContract {
 	return r.hostContractor.RecoverableContracts()
 }
 
+// UnrecoverableContracts returns the host contractor's unrecoverable
+// contracts.
+func (r *Renter) UnrecoverableContracts() []modules.UnrecoverableContract {
+	return r.hostContractor.UnrecoverableContracts()
+}
+
 // RefreshedContract returns a bool indicating if the contract was previously
 // refreshed
 func (r *Renter) RefreshedContract(fcid types.FileContractID) bool {
@@ -817,6 +1007,27 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
+	id := r.mu.RLock()
+	maxBalance := r.persist.AccountMaxBalanceAtRisk
+	threshold := r.persist.AccountRefillThreshold
+	downloadOverdrive := r.persist.DownloadOverdrive
+	streamOverdrive := r.persist.StreamOverdrive
+	adaptiveOverdrive := r.persist.AdaptiveOverdrive
+	lowLatencyFraction := r.persist.LowLatencyFraction
+	lowLatencyBudget := r.persist.LowLatencyBudget
+	downloadStallTimeout := r.persist.DownloadStallTimeout
+	uploadMemory := r.persist.UploadMemory
+	downloadMemory := r.persist.DownloadMemory
+	repairMemory := r.persist.RepairMemory
+	spillDir := r.persist.SpillDir
+	spillCap := r.persist.SpillCap
+	packSize := r.persist.PackSize
+	maxDownloadCost := r.persist.MaxDownloadCost
+	maxRegistryReadCost := r.persist.MaxRegistryReadCost
+	maxRegistryWriteCost := r.persist.MaxRegistryWriteCost
+	maxUploadCost := r.persist.MaxUploadCost
+	gouging := copyGougingSettings(r.persist.Gouging)
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
@@ -826,6 +1037,25 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		AccountMaxBalanceAtRisk: maxBalance,
+		AccountRefillThreshold:  threshold,
+		DownloadOverdrive:       downloadOverdrive,
+		StreamOverdrive:         streamOverdrive,
+		AdaptiveOverdrive:       adaptiveOverdrive,
+		LowLatencyFraction:      lowLatencyFraction,
+		LowLatencyBudget:        lowLatencyBudget,
+		DownloadStallTimeout:    downloadStallTimeout,
+		UploadMemory:            uploadMemory,
+		DownloadMemory:          downloadMemory,
+		RepairMemory:            repairMemory,
+		SpillDir:                spillDir,
+		SpillCap:                spillCap,
+		PackSize:                packSize,
+		MaxDownloadCost:         maxDownloadCost,
+		MaxRegistryReadCost:     maxRegistryReadCost,
+		MaxRegistryWriteCost:    maxRegistryWriteCost,
+		MaxUploadCost:           maxUploadCost,
+		Gouging:                 gouging,
 	}, nil
 }
 
@@ -911,6 +1141,8 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		},
 
 		downloadHistory: make(map[modules.DownloadID]*download),
+		urlUploads:      make(map[modules.SiaPath]*urlUpload),
+		reshards:        make(map[modules.SiaPath]*reshard),
 
 		cs:             cs,
 		deps:           deps,
@@ -928,6 +1160,7 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.staticBubbleScheduler = newBubbleScheduler(r)
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
+	r.staticUploadSpill = newUploadSpill()
 	r.staticRRS = newReadRegistryStats(ReadRegistryBackgroundTimeout, readRegistryStatsInterval, readRegistryStatsDecay, readRegistryStatsPercentile)
 	close(r.uploadHeap.pauseChan)
 
@@ -979,6 +1212,51 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Resume the repairs once a pause from before the restart ends.
+	if r.managedRepairsPaused() {
+		id := r.mu.RLock()
+		end := r.persist.RepairPauseEndTime
+		r.mu.RUnlock(id)
+		r.callScheduleRepairResume(end)
+	}
+
+	// Load the index of the packed files.
+	r.staticPacker, err = newPacker(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.AfterStop(r.staticPacker.managedClose); err != nil {
+		return nil, err
+	}
+
+	// Load the stats and persist them one last time on shutdown.
+	r.staticStats, err = newRenterStats(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.tg.AfterStop(r.staticStats.managedSave); err != nil {
+		return nil, err
+	}
+	go r.threadedPersistStats()
+
+	// Load the payment budgets.
+	r.staticPaymentBudgets, err = newPaymentBudgets(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the audit settings and reports.
+	r.staticAudits, err = newAudits(r.persistDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the spill files of the previous run.
+	err = r.managedPruneSpillDir()
+	if err != nil {
+		r.log.Println("WARN: unable to prune spill directory:", err)
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
@@ -1035,6 +1313,7 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	// consensus set.
 	// Spin up the workers for the work pool.
 	go r.threadedDownloadLoop()
+	go r.threadedFlushPacks()
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
@@ -1043,6 +1322,7 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	go r.threadedAuditLoop()
 	return nil
 }
 
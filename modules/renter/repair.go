@@ -470,6 +470,17 @@ func (r *Renter) threadedStuckFileLoop() {
 			return
 		}
 
+		// Block while repairs are paused. Resuming the repairs signals the
+		// stuckChunkFound channel.
+		if r.managedRepairsPaused() {
+			select {
+			case <-r.tg.StopChan():
+				return
+			case <-r.uploadHeap.stuckChunkFound:
+			}
+			continue
+		}
+
 		// As we add stuck chunks to the upload heap we want to remember the
 		// directories they came from so we can call bubble to update the
 		// filesystem
@@ -704,8 +715,9 @@ func (r *Renter) callPrepareForBubble(rootDir modules.SiaPath, force bool) (*uni
 
 // managedUpdateFileMetadatasParams updates the metadata of all siafiles within
 // a dir with the provided parameters.  This can be very expensive for large
-// directories and should therefore only happen sparingly.
-func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, offlineMap map[string]bool, goodForRenewMap map[string]bool, contracts map[string]modules.RenterContract, used []types.SiaPublicKey) error {
+// directories and should therefore only happen sparingly. If since is not zero,
+// only the siafiles which were modified after since are updated.
+func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, since time.Time, offlineMap map[string]bool, goodForRenewMap map[string]bool, contracts map[string]modules.RenterContract, used []types.SiaPublicKey) error {
 	// Read the fileinfos from the directory
 	fis, err := r.staticFileSystem.ReadDir(dirSiaPath)
 	if err != nil {
@@ -750,6 +762,9 @@ func (r *Renter) managedUpdateFileMetadatasParams(dirSiaPath modules.SiaPath, of
 		if ext != modules.SiaFileExtension {
 			continue
 		}
+		if !since.IsZero() && !fi.ModTime().After(since) {
+			continue
+		}
 		fName := strings.TrimSuffix(fi.Name(), modules.SiaFileExtension)
 		fileSiaPath, err := dirSiaPath.Join(fName)
 		if err != nil {
@@ -24,7 +24,7 @@ import (
 func (rt *renterTester) updateFileMetadatas(dirSiaPath modules.SiaPath) error {
 	// Get cached offline and goodforrenew maps.
 	offlineMap, goodForRenewMap, contracts, used := rt.renter.callRenterContractsAndUtilities()
-	return rt.renter.managedUpdateFileMetadatasParams(dirSiaPath, offlineMap, goodForRenewMap, contracts, used)
+	return rt.renter.managedUpdateFileMetadatasParams(dirSiaPath, time.Time{}, offlineMap, goodForRenewMap, contracts, used)
 }
 
 // timeEquals is a helper function for checking if two times are equal
@@ -0,0 +1,160 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedRepairsPaused returns whether the repair of existing files is paused.
+func (r *Renter) managedRepairsPaused() bool {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	end := r.persist.RepairPauseEndTime
+	return r.persist.RepairsPaused && (end.IsZero() || time.Now().Before(end))
+}
+
+// managedWakeRepairLoops signals the repair and stuck loops to check for work
+// again. The loops block while repairs are paused and are woken up when
+// repairs resume.
+func (r *Renter) managedWakeRepairLoops() {
+	select {
+	case r.uploadHeap.repairNeeded <- struct{}{}:
+	default:
+	}
+	select {
+	case r.uploadHeap.stuckChunkFound <- struct{}{}:
+	default:
+	}
+}
+
+// callScheduleRepairResume wakes up the repair loops once a pause ends. Waking
+// the loops after the pause was changed is harmless since they check whether
+// repairs are paused when they wake up.
+func (r *Renter) callScheduleRepairResume(end time.Time) {
+	if end.IsZero() {
+		return
+	}
+	time.AfterFunc(time.Until(end), func() {
+		if err := r.tg.Add(); err != nil {
+			return
+		}
+		defer r.tg.Done()
+		r.repairLog.Println("Repairs have been resumed after the pause ended")
+		r.managedWakeRepairLoops()
+	})
+}
+
+// PauseRepairs pauses the repair of existing files, while uploads of new files
+// continue. If duration is 0, repairs are paused until they are resumed. The
+// pause persists across restarts.
+func (r *Renter) PauseRepairs(duration time.Duration) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	var end time.Time
+	if duration > 0 {
+		end = time.Now().Add(duration)
+	}
+	id := r.mu.Lock()
+	if !r.persist.RepairsPaused {
+		r.persist.RepairPauseStart = time.Now()
+	}
+	r.persist.RepairsPaused = true
+	r.persist.RepairPauseEndTime = end
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to persist the repair pause")
+	}
+	r.repairLog.Println("Repairs have been paused until", end)
+	r.callScheduleRepairResume(end)
+	return nil
+}
+
+// ResumeRepairs resumes the repair of existing files.
+func (r *Renter) ResumeRepairs() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	id := r.mu.Lock()
+	r.persist.RepairsPaused = false
+	r.persist.RepairPauseStart = time.Time{}
+	r.persist.RepairPauseEndTime = time.Time{}
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "unable to persist the repair pause")
+	}
+	r.repairLog.Println("Repairs have been resumed")
+	r.managedWakeRepairLoops()
+	return nil
+}
+
+// managedRepairSectorCost returns the average cost of uploading a sector to the
+// hosts of the contracts which are good for upload, for the remaining duration
+// of the contracts.
+func (r *Renter) managedRepairSectorCost() types.Currency {
+	_, _, contracts := r.managedContractUtilityMaps()
+	height := r.cs.Height()
+	total := types.ZeroCurrency
+	var n uint64
+	for _, c := range contracts {
+		if !c.Utility.GoodForUpload {
+			continue
+		}
+		host, ok, err := r.hostDB.Host(c.HostPublicKey)
+		if err != nil || !ok {
+			continue
+		}
+		total = total.Add(uploadSectorCost(host.HostExternalSettings, height, c.EndHeight))
+		n++
+	}
+	if n == 0 {
+		return types.ZeroCurrency
+	}
+	return total.Div64(n)
+}
+
+// RepairBacklog returns the repair work of the renter and whether repairs are
+// paused.
+func (r *Renter) RepairBacklog() (modules.RepairBacklog, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RepairBacklog{}, err
+	}
+	defer r.tg.Done()
+	root, err := r.staticFileSystem.DirInfo(modules.RootSiaPath())
+	if err != nil {
+		return modules.RepairBacklog{}, errors.AddContext(err, "unable to get the root directory's metadata")
+	}
+
+	var backlog modules.RepairBacklog
+	id := r.mu.RLock()
+	if r.persist.RepairsPaused {
+		backlog.PauseStart = r.persist.RepairPauseStart
+		backlog.PauseEndTime = r.persist.RepairPauseEndTime
+	}
+	r.mu.RUnlock(id)
+	backlog.Paused = r.managedRepairsPaused()
+
+	r.uploadHeap.mu.Lock()
+	backlog.QueuedChunks = r.uploadHeap.heap.Len()
+	backlog.RepairingChunks = len(r.uploadHeap.repairingChunks)
+	r.uploadHeap.mu.Unlock()
+
+	backlog.StuckChunks = root.AggregateNumStuckChunks
+	backlog.RepairBytes = root.AggregateRepairSize
+	backlog.StuckBytes = root.AggregateStuckSize
+	backlog.LastHealthCheckTime = root.AggregateLastHealthCheckTime
+
+	// The repair and stuck bytes are multiples of the sector size since they
+	// are computed from the missing pieces.
+	sectors := (backlog.RepairBytes + backlog.StuckBytes) / modules.SectorSize
+	backlog.EstimatedCost = r.managedRepairSectorCost().Mul64(sectors)
+	return backlog, nil
+}
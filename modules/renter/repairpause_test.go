@@ -0,0 +1,143 @@
+package renter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/siatest/dependencies"
+	"go.sia.tech/siad/types"
+)
+
+// TestRepairPause probes pausing and resuming repairs.
+func TestRepairPause(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	backlog, err := rt.renter.RepairBacklog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Paused || !backlog.PauseStart.IsZero() {
+		t.Fatal("repairs shouldn't be paused", backlog)
+	}
+
+	// Create a file with multiple chunks. Only the second chunk has a piece.
+	path, err := rt.createZeroByteFileOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 1)
+	siaPath, err := modules.NewSiaPath("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.staticFileSystem.NewSiaFile(siaPath, path, rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), 2*modules.SectorSize, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	numChunks := int(f.NumChunks())
+	if numChunks < 2 {
+		t.Fatal("not enough chunks", numChunks)
+	}
+	err = f.AddPiece(types.SiaPublicKey{Key: fastrand.Bytes(32)}, 1, 0, crypto.Hash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rt.renter.staticWorkerPool.mu.Lock()
+	for i := 0; i < numChunks; i++ {
+		rt.renter.staticWorkerPool.workers[fmt.Sprint(i)] = &worker{}
+	}
+	rt.renter.staticWorkerPool.mu.Unlock()
+	buildChunks := func() []*unfinishedUploadChunk {
+		return rt.renter.managedBuildUnfinishedChunks(f, make(map[string]struct{}), targetUnstuckChunks, make(map[string]bool), make(map[string]bool), rt.renter.repairMemoryManager)
+	}
+	if uucs := buildChunks(); len(uucs) != numChunks {
+		t.Fatal("expected all chunks, got", len(uucs))
+	}
+
+	// While repairs are paused, only the chunks which were never uploaded are
+	// built.
+	if err := rt.renter.PauseRepairs(0); err != nil {
+		t.Fatal(err)
+	}
+	uucs := buildChunks()
+	if len(uucs) != numChunks-1 {
+		t.Fatal("expected all chunks but the repair, got", len(uucs))
+	}
+	for _, uuc := range uucs {
+		if uuc.staticIndex == 1 {
+			t.Fatal("repair shouldn't be built while repairs are paused")
+		}
+	}
+
+	// The pause persists across restarts.
+	rt.renter, err = rt.reloadRenter(rt.renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backlog, err = rt.renter.RepairBacklog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !backlog.Paused || backlog.PauseStart.IsZero() || !backlog.PauseEndTime.IsZero() {
+		t.Fatal("repairs should be paused until they are resumed", backlog)
+	}
+
+	// Resuming clears the pause.
+	if err := rt.renter.ResumeRepairs(); err != nil {
+		t.Fatal(err)
+	}
+	backlog, err = rt.renter.RepairBacklog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Paused || !backlog.PauseStart.IsZero() {
+		t.Fatal("repairs shouldn't be paused", backlog)
+	}
+
+	// A pause with a duration ends on its own.
+	if err := rt.renter.PauseRepairs(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.renter.managedRepairsPaused() {
+		t.Fatal("repairs should be paused")
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if rt.renter.managedRepairsPaused() {
+			return errors.New("repairs are still paused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,328 @@
+package renter
+
+// reshard.go contains the logic for changing the erasure coding parameters of
+// an existing file. The renter streams the file's data into a new siafile with
+// the new parameters in the reshard folder. Once the new siafile reached the
+// redundancy of its erasure coding parameters, it replaces the original one.
+// Until then the original file stays downloadable and if the new siafile
+// doesn't reach that redundancy in time, the original file is kept.
+
+import (
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// reshardRedundancyCheckInterval is how often the redundancy of a
+	// re-encoded file is checked while waiting for it to be fully uploaded.
+	reshardRedundancyCheckInterval = build.Select(build.Var{
+		Dev:      time.Second * 5,
+		Standard: time.Minute,
+		Testnet:  time.Minute,
+		Testing:  time.Millisecond * 100,
+	}).(time.Duration)
+
+	// reshardRedundancyTimeout is how long the renter waits for a re-encoded
+	// file to reach the redundancy of its erasure coding parameters before
+	// giving up and keeping the original file.
+	reshardRedundancyTimeout = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 24,
+		Testnet:  time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+var (
+	// errReshardFileChanged is returned if the file was deleted, renamed or
+	// replaced while it was being resharded.
+	errReshardFileChanged = errors.New("file was changed while it was being resharded")
+
+	// errReshardInProgress is returned if a file is already being resharded.
+	errReshardInProgress = errors.New("file is already being resharded")
+
+	// errReshardSameErasureCode is returned if a file is resharded with the
+	// erasure coding parameters it already uses.
+	errReshardSameErasureCode = errors.New("file already uses the provided erasure coding parameters")
+
+	// errReshardExternalKey is returned if a file which is encrypted with an
+	// external key is resharded.
+	errReshardExternalKey = errors.New("files which are encrypted with an external key can't be resharded")
+
+	// errReshardRedundancyTimeout is returned if the re-encoded file didn't
+	// reach the redundancy of its erasure coding parameters in time.
+	errReshardRedundancyTimeout = errors.New("re-encoded file didn't reach its target redundancy in time, the original file was kept")
+
+	// errReshardInterrupted is returned if the renter shuts down before the
+	// re-encoded file reached its target redundancy.
+	errReshardInterrupted = errors.New("reshard was interrupted by shutdown, the original file was kept")
+)
+
+type (
+	// reshard tracks the progress of changing the erasure coding parameters of
+	// a file.
+	reshard struct {
+		atomicBytesReencoded uint64
+
+		staticChunkSize    uint64
+		staticDataPieces   int
+		staticNumChunks    uint64
+		staticParityPieces int
+		staticSiaPath      modules.SiaPath
+		staticStartTime    time.Time
+
+		completed bool
+		endTime   time.Time
+		err       error
+		mu        sync.Mutex
+	}
+
+	// reshardReader wraps the stream of the original file to track how much of
+	// it has been re-encoded.
+	reshardReader struct {
+		r  io.Reader
+		rs *reshard
+	}
+)
+
+// Read implements the io.Reader interface.
+func (rr *reshardReader) Read(b []byte) (int, error) {
+	n, err := rr.r.Read(b)
+	atomic.AddUint64(&rr.rs.atomicBytesReencoded, uint64(n))
+	return n, err
+}
+
+// info returns the ReshardInfo of the reshard.
+func (rs *reshard) info() modules.ReshardInfo {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	info := modules.ReshardInfo{
+		DataPieces:   rs.staticDataPieces,
+		NumChunks:    rs.staticNumChunks,
+		ParityPieces: rs.staticParityPieces,
+		SiaPath:      rs.staticSiaPath,
+
+		ChunksReencoded: atomic.LoadUint64(&rs.atomicBytesReencoded) / rs.staticChunkSize,
+		Completed:       rs.completed,
+		EndTime:         rs.endTime,
+		StartTime:       rs.staticStartTime,
+	}
+	if rs.completed && rs.err == nil {
+		info.ChunksReencoded = rs.staticNumChunks
+	}
+	if info.ChunksReencoded > rs.staticNumChunks {
+		info.ChunksReencoded = rs.staticNumChunks
+	}
+	if rs.err != nil {
+		info.Error = rs.err.Error()
+	}
+	return info
+}
+
+// Reshard changes the erasure coding parameters of the file at siaPath to ec.
+// The file is downloaded, re-encoded and re-uploaded in the background and
+// stays downloadable until the new version of the file replaces it.
+func (r *Renter) Reshard(siaPath modules.SiaPath, ec modules.ErasureCoder) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Resharding replaces the file.
+	if err := r.managedCheckFileLocks(siaPath); err != nil {
+		return err
+	}
+	if err := r.managedCheckFileReadOnly(siaPath); err != nil {
+		return err
+	}
+	node, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, node.Close())
+		}
+	}()
+	if node.ErasureCode().Identifier() == ec.Identifier() {
+		return errReshardSameErasureCode
+	}
+	if node.MasterKeyID() != "" {
+		return errReshardExternalKey
+	}
+
+	// Track the reshard.
+	chunkSize := (modules.SectorSize - node.MasterKeyType().Overhead()) * uint64(ec.MinPieces())
+	rs := &reshard{
+		staticChunkSize:    chunkSize,
+		staticDataPieces:   ec.MinPieces(),
+		staticNumChunks:    (node.Size() + chunkSize - 1) / chunkSize,
+		staticParityPieces: ec.NumPieces() - ec.MinPieces(),
+		staticSiaPath:      siaPath,
+		staticStartTime:    time.Now(),
+	}
+	r.reshardsMu.Lock()
+	if prev, exists := r.reshards[siaPath]; exists && !prev.info().Completed {
+		r.reshardsMu.Unlock()
+		return errReshardInProgress
+	}
+	r.reshards[siaPath] = rs
+	r.reshardsMu.Unlock()
+
+	go r.threadedReshard(rs, node, ec)
+	return nil
+}
+
+// Reshards returns the renter's reshards since startup sorted by their start
+// time, most recent first.
+func (r *Renter) Reshards() []modules.ReshardInfo {
+	r.reshardsMu.Lock()
+	reshards := make([]*reshard, 0, len(r.reshards))
+	for _, rs := range r.reshards {
+		reshards = append(reshards, rs)
+	}
+	r.reshardsMu.Unlock()
+
+	infos := make([]modules.ReshardInfo, 0, len(reshards))
+	for _, rs := range reshards {
+		infos = append(infos, rs.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.After(infos[j].StartTime)
+	})
+	return infos
+}
+
+// threadedReshard uploads the data of the file with the new erasure coding
+// parameters and replaces the original file with the new one afterwards.
+func (r *Renter) threadedReshard(rs *reshard, node *filesystem.FileNode, ec modules.ErasureCoder) {
+	var err error
+	defer func() {
+		if err != nil {
+			r.log.Printf("WARN: failed to reshard %v: %v", rs.staticSiaPath, err)
+		}
+		rs.mu.Lock()
+		rs.completed = true
+		rs.endTime = time.Now()
+		rs.err = err
+		rs.mu.Unlock()
+	}()
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	if err = r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	err = r.managedReshard(rs, node, ec)
+}
+
+// managedReshard performs the work of threadedReshard.
+func (r *Renter) managedReshard(rs *reshard, node *filesystem.FileNode, ec modules.ErasureCoder) (err error) {
+	// The new version of the file is uploaded to the reshard folder.
+	uid := node.UID()
+	tmpPath, err := modules.ReshardFolder.Join(string(uid))
+	if err != nil {
+		return err
+	}
+	originalDeleted := false
+	defer func() {
+		// Once the original file is gone, the new version must be kept.
+		if err == nil || originalDeleted {
+			return
+		}
+		if errDelete := r.DeleteFile(tmpPath); errDelete != nil && !errors.Contains(errDelete, filesystem.ErrNotExist) {
+			err = errors.Compose(err, errDelete)
+		}
+	}()
+
+	// Stream the original file into the new one.
+	streamer, err := r.StreamerByNode(node, false)
+	if err != nil {
+		return errors.AddContext(err, "unable to create streamer for the original file")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+	up := modules.FileUploadParams{
+		SiaPath:             tmpPath,
+		ErasureCode:         ec,
+		Force:               true,
+		DisablePartialChunk: true,
+		Sparse:              node.Sparse(),
+		CipherType:          node.MasterKeyType(),
+	}
+	newNode, err := r.callUploadStreamFromReader(up, &reshardReader{r: streamer, rs: rs})
+	if err != nil {
+		return errors.AddContext(err, "unable to upload the re-encoded file")
+	}
+	err = errors.Compose(newNode.SetLocalPath(node.LocalPath()), newNode.SetTags(node.Tags()))
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to update the metadata of the re-encoded file"), newNode.Close())
+	}
+
+	// The upload returns as soon as the data is available. Wait for the
+	// remaining pieces before the original file is replaced.
+	err = r.managedAwaitReshardRedundancy(newNode, ec)
+	err = errors.Compose(err, newNode.Close())
+	if err != nil {
+		return err
+	}
+
+	// Replace the original file if it hasn't changed in the meantime.
+	current, err := r.staticFileSystem.OpenSiaFile(rs.staticSiaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return errReshardFileChanged
+	} else if err != nil {
+		return err
+	}
+	currentUID := current.UID()
+	if err := current.Close(); err != nil {
+		return err
+	}
+	if currentUID != uid {
+		return errReshardFileChanged
+	}
+	if err := r.DeleteFile(rs.staticSiaPath); err != nil {
+		return errors.AddContext(err, "unable to delete the original file")
+	}
+	originalDeleted = true
+	if err := r.RenameFile(tmpPath, rs.staticSiaPath); err != nil {
+		return errors.AddContext(err, "unable to move the re-encoded file, it remains at "+tmpPath.String())
+	}
+	return nil
+}
+
+// managedAwaitReshardRedundancy blocks until the re-encoded file reaches the
+// redundancy of its erasure coding parameters.
+func (r *Renter) managedAwaitReshardRedundancy(node *filesystem.FileNode, ec modules.ErasureCoder) error {
+	target := float64(ec.NumPieces()) / float64(ec.MinPieces())
+	timeout := time.After(reshardRedundancyTimeout)
+	for {
+		offline, goodForRenew, _ := r.managedContractUtilityMaps()
+		redundancy, _, err := node.Redundancy(offline, goodForRenew)
+		if err != nil {
+			return errors.AddContext(err, "unable to get the redundancy of the re-encoded file")
+		}
+		if redundancy >= target {
+			return nil
+		}
+		select {
+		case <-r.tg.StopChan():
+			return errReshardInterrupted
+		case <-timeout:
+			return errReshardRedundancyTimeout
+		case <-time.After(reshardRedundancyCheckInterval):
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestReshardInfo is a unit test for the progress reported by a reshard.
+func TestReshardInfo(t *testing.T) {
+	t.Parallel()
+
+	rs := &reshard{
+		staticChunkSize: 10,
+		staticNumChunks: 3,
+	}
+
+	// Reading through the reshardReader tracks the re-encoded chunks.
+	data := fastrand.Bytes(25)
+	read, err := ioutil.ReadAll(&reshardReader{r: bytes.NewReader(data), rs: rs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) {
+		t.Fatal("wrong data")
+	}
+	if info := rs.info(); info.ChunksReencoded != 2 || info.Completed {
+		t.Fatal("wrong progress", info.ChunksReencoded, info.Completed)
+	}
+
+	// A failed reshard reports the error and its progress.
+	rs.completed = true
+	rs.err = errors.New("failure")
+	if info := rs.info(); info.ChunksReencoded != 2 || info.Error != "failure" {
+		t.Fatal("wrong info", info.ChunksReencoded, info.Error)
+	}
+
+	// A successful reshard reports all chunks as re-encoded, including the
+	// partial last one.
+	rs.err = nil
+	if info := rs.info(); info.ChunksReencoded != 3 || info.Error != "" {
+		t.Fatal("wrong info", info.ChunksReencoded, info.Error)
+	}
+}
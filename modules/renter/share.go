@@ -0,0 +1,453 @@
+package renter
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/cipher"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/crypto/twofish"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// shareBundleVersion is the version of the share bundle format.
+	shareBundleVersion = "1.0"
+
+	// shareBundleTypeShare and shareBundleTypeCustody are the types of share
+	// bundles. Shares give the recipient read access to files while custody
+	// transfers hand the files and the contracts storing them over.
+	shareBundleTypeShare   = "share"
+	shareBundleTypeCustody = "custody"
+
+	// shareKeySpecifier is used to derive the key pair which is used to
+	// receive shares from the renter's seed.
+	shareKeySpecifier = types.NewSpecifier("sharekey")
+
+	// errShareExpired is returned when importing a share after its expiry.
+	errShareExpired = errors.New("the share has expired")
+
+	// errShareRecipient is returned when importing a share which was created
+	// for a different renter.
+	errShareRecipient = errors.New("the share was created for a different recipient")
+
+	// errShareExternalKey is returned when sharing a file which is encrypted
+	// with an external key.
+	errShareExternalKey = errors.New("files which are encrypted with an external key can't be shared")
+)
+
+// shareBundleHeader defines the structure of a share bundle's JSON header. On
+// top of the fields of a backup header, it contains the secret the bundle is
+// encrypted with. The secret is wrapped for the recipient using a key derived
+// from an ephemeral key pair and the recipient's share key.
+type shareBundleHeader struct {
+	backupHeader
+	Type         string                 `json:"type"`
+	Recipient    crypto.X25519PublicKey `json:"recipient"`
+	EphemeralKey crypto.X25519PublicKey `json:"ephemeralkey"`
+	WrappedKey   crypto.Ciphertext      `json:"wrappedkey"`
+}
+
+// wrapShareSecret encrypts the secret of a share bundle together with the
+// share's expiry for the recipient. Only the recipient's secret share key can
+// unwrap it.
+func wrapShareSecret(secret []byte, expiry int64, recipient crypto.X25519PublicKey) (crypto.X25519PublicKey, crypto.Ciphertext, error) {
+	xsk, xpk := crypto.GenerateX25519KeyPair()
+	wrapEntropy := crypto.DeriveSharedSecret(xsk, recipient)
+	defer fastrand.Read(wrapEntropy[:])
+	key, err := crypto.NewSiaKey(crypto.TypeTwofish, wrapEntropy[:])
+	if err != nil {
+		return crypto.X25519PublicKey{}, nil, err
+	}
+	return xpk, key.EncryptBytes(encoding.MarshalAll(secret, expiry)), nil
+}
+
+// unwrapShareSecret decrypts the secret of a share bundle and the share's
+// expiry using the recipient's secret share key.
+func unwrapShareSecret(sh shareBundleHeader, xsk crypto.X25519SecretKey) (secret []byte, expiry int64, err error) {
+	wrapEntropy := crypto.DeriveSharedSecret(xsk, sh.EphemeralKey)
+	defer fastrand.Read(wrapEntropy[:])
+	key, err := crypto.NewSiaKey(crypto.TypeTwofish, wrapEntropy[:])
+	if err != nil {
+		return nil, 0, err
+	}
+	plaintext, err := key.DecryptBytes(sh.WrappedKey)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "failed to unwrap the share's key")
+	}
+	err = encoding.UnmarshalAll(plaintext, &secret, &expiry)
+	return secret, expiry, err
+}
+
+// managedShareKeyPair derives the key pair which is used to receive shares
+// from the renter's seed.
+func (r *Renter) managedShareKeyPair() (crypto.X25519SecretKey, crypto.X25519PublicKey, error) {
+	ws, err := r.w.PrimaryKeySeed()
+	if err != nil {
+		return crypto.X25519SecretKey{}, crypto.X25519PublicKey{}, errors.AddContext(err, "failed to get wallet's primary key seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	entropy := crypto.HashAll(rs, shareKeySpecifier)
+	defer fastrand.Read(entropy[:])
+	xsk, xpk := crypto.GenerateX25519KeyPairDeterministic(entropy)
+	return xsk, xpk, nil
+}
+
+// ShareKey returns the public key other renters use to create shares for the
+// renter.
+func (r *Renter) ShareKey() (crypto.X25519PublicKey, error) {
+	if err := r.tg.Add(); err != nil {
+		return crypto.X25519PublicKey{}, err
+	}
+	defer r.tg.Done()
+	xsk, xpk, err := r.managedShareKeyPair()
+	fastrand.Read(xsk[:])
+	return xpk, err
+}
+
+// shareBundleWriter writes a share bundle. Like a backup, the bundle starts
+// with the checksum of its body followed by a JSON header. The body is a
+// compressed and encrypted tar archive.
+type shareBundleWriter struct {
+	f        *os.File
+	gzw      *gzip.Writer
+	tw       *tar.Writer
+	h        hash.Hash
+	finished bool
+}
+
+// newShareBundleWriter creates a share bundle of the given type for the
+// recipient at dst and writes its header.
+func newShareBundleWriter(dst, bundleType string, recipient crypto.X25519PublicKey, expiry time.Time) (_ *shareBundleWriter, err error) {
+	var expiryUnix int64
+	if !expiry.IsZero() {
+		expiryUnix = expiry.Unix()
+	}
+	f, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, f.Close(), os.Remove(dst))
+		}
+	}()
+
+	// Prepare the header and wrap the body for encryption.
+	secret := fastrand.Bytes(crypto.EntropySize)
+	defer fastrand.Read(secret)
+	sh := shareBundleHeader{
+		backupHeader: backupHeader{
+			Version:    shareBundleVersion,
+			Encryption: encryptionTwofish,
+			IV:         fastrand.Bytes(twofish.BlockSize),
+		},
+		Type:      bundleType,
+		Recipient: recipient,
+	}
+	sh.EphemeralKey, sh.WrappedKey, err = wrapShareSecret(secret, expiryUnix, recipient)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to wrap the share's key")
+	}
+	c, err := twofish.NewCipher(secret)
+	if err != nil {
+		return nil, err
+	}
+	body := cipher.StreamWriter{
+		S: cipher.NewCTR(c, sh.IV),
+		W: f,
+	}
+
+	// Skip the checksum for now and write the header.
+	if _, err := f.Seek(crypto.HashSize, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(f).Encode(sh); err != nil {
+		return nil, err
+	}
+
+	// Hash the compressed archive before encrypting it.
+	h := crypto.NewHash()
+	gzw := gzip.NewWriter(io.MultiWriter(body, h))
+	return &shareBundleWriter{
+		f:   f,
+		gzw: gzw,
+		tw:  tar.NewWriter(gzw),
+		h:   h,
+	}, nil
+}
+
+// writeEntry adds an entry with the provided name and data to the bundle.
+func (bw *shareBundleWriter) writeEntry(name string, data []byte) error {
+	err := bw.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0600,
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = bw.tw.Write(data)
+	return err
+}
+
+// finish completes the bundle by writing its checksum and syncs it to disk.
+func (bw *shareBundleWriter) finish() error {
+	if err := errors.Compose(bw.tw.Close(), bw.gzw.Close()); err != nil {
+		return err
+	}
+	if _, err := bw.f.WriteAt(bw.h.Sum(nil), 0); err != nil {
+		return err
+	}
+	if err := bw.f.Sync(); err != nil {
+		return err
+	}
+	bw.finished = true
+	return nil
+}
+
+// close closes the bundle. Unfinished bundles are removed.
+func (bw *shareBundleWriter) close() error {
+	err := bw.f.Close()
+	if !bw.finished {
+		err = errors.Compose(err, os.Remove(bw.f.Name()))
+	}
+	return err
+}
+
+// managedReadShareBundle reads a share bundle of the given type which was
+// created for the renter. The checksum of the bundle is verified before fn is
+// called for every entry of the bundle's archive.
+func (r *Renter) managedReadShareBundle(src, bundleType string, fn func(header *tar.Header, tr *tar.Reader) error) (err error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+
+	// Read the checksum and the header.
+	var chks crypto.Hash
+	if _, err := io.ReadFull(f, chks[:]); err != nil {
+		return err
+	}
+	headerLine, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return errors.AddContext(err, "failed to read header")
+	}
+	var sh shareBundleHeader
+	if err := json.Unmarshal(headerLine, &sh); err != nil {
+		return errors.AddContext(err, "failed to decode header")
+	}
+	if sh.Version != shareBundleVersion {
+		return errors.New("unknown version")
+	}
+	if sh.Type != bundleType {
+		return fmt.Errorf("expected a bundle of type %v but got %v", bundleType, sh.Type)
+	}
+	bodyOffset := int64(crypto.HashSize + len(headerLine))
+
+	// Unwrap the secret of the bundle.
+	xsk, xpk, err := r.managedShareKeyPair()
+	defer fastrand.Read(xsk[:])
+	if err != nil {
+		return err
+	}
+	if sh.Recipient != xpk {
+		return errShareRecipient
+	}
+	secret, expiry, err := unwrapShareSecret(sh, xsk)
+	defer fastrand.Read(secret)
+	if err != nil {
+		return err
+	}
+	if expiry != 0 && time.Now().Unix() > expiry {
+		return errShareExpired
+	}
+
+	// Verify the checksum of the body before reading any entries.
+	if _, err := f.Seek(bodyOffset, io.SeekStart); err != nil {
+		return err
+	}
+	body, err := wrapReaderInCipher(f, sh.backupHeader, secret)
+	if err != nil {
+		return err
+	}
+	h := crypto.NewHash()
+	if _, err := io.Copy(h, body); err != nil {
+		return err
+	}
+	if !bytes.Equal(h.Sum(nil), chks[:]) {
+		return errors.New("checksum doesn't match")
+	}
+
+	// Read the entries.
+	if _, err := f.Seek(bodyOffset, io.SeekStart); err != nil {
+		return err
+	}
+	body, err = wrapReaderInCipher(f, sh.backupHeader, secret)
+	if err != nil {
+		return err
+	}
+	gzr, err := gzip.NewReader(body)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return errors.AddContext(err, "could not get next entry in the tar archive")
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// siaPathFromShareEntry returns the siapath of a siafile within a share
+// bundle relative to the directory at siaPath.
+func siaPathFromShareEntry(siaPath modules.SiaPath, name string) (modules.SiaPath, error) {
+	if !strings.HasSuffix(name, modules.SiaFileExtension) {
+		return modules.SiaPath{}, fmt.Errorf("illegal file name: %s", name)
+	}
+	relPath, err := modules.NewSiaPath(strings.TrimSuffix(name, modules.SiaFileExtension))
+	if err != nil || relPath.String() != strings.TrimSuffix(name, modules.SiaFileExtension) {
+		return modules.SiaPath{}, fmt.Errorf("illegal file name: %s", name)
+	}
+	return siaPath.Join(relPath.String())
+}
+
+// ExportShare writes the siafiles at siaPaths to a share bundle at dst. The
+// bundle is encrypted with a random secret which is wrapped for the recipient's
+// share key together with the share's expiry. A zero expiry means that the
+// share doesn't expire. Since the siafiles contain the keys of the files, only
+// the recipient can decrypt the files' data.
+func (r *Renter) ExportShare(siaPaths []modules.SiaPath, dst string, recipient crypto.X25519PublicKey, expiry time.Time) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if len(siaPaths) == 0 {
+		return errors.New("no files to share")
+	}
+
+	bw, err := newShareBundleWriter(dst, shareBundleTypeShare, recipient, expiry)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, bw.close())
+	}()
+	names := make(map[string]struct{})
+	for _, siaPath := range siaPaths {
+		name := siaPath.Name() + modules.SiaFileExtension
+		if _, exists := names[name]; exists {
+			return fmt.Errorf("multiple files are named %v", siaPath.Name())
+		}
+		names[name] = struct{}{}
+		if err := r.managedTarSharedSiaFile(bw.tw, siaPath, name); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to add %v to the share", siaPath))
+		}
+	}
+	return bw.finish()
+}
+
+// managedTarSharedSiaFile adds the siafile at siaPath to the archive of a share
+// bundle using the provided name.
+func (r *Renter) managedTarSharedSiaFile(tw *tar.Writer, siaPath modules.SiaPath, name string) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if entry.MasterKeyID() != "" {
+		return errShareExternalKey
+	}
+	sr, err := entry.SnapshotReader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, sr.Close())
+	}()
+	fi, err := sr.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, sr)
+	return err
+}
+
+// ImportShare imports the siafiles of a share bundle created for the renter by
+// ExportShare into the directory at siaPath. Files which were imported before
+// are skipped and files whose names are taken get a unique name. It returns
+// the number of files within the share. The imported files are read-only, so
+// they are never repaired and can't be modified, moved or locked. They can
+// still be deleted. The expiry of the share only gates the import, imported
+// files stay downloadable after it.
+func (r *Renter) ImportShare(src string, siaPath modules.SiaPath) (int, error) {
+	if err := r.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer r.tg.Done()
+
+	var n int
+	err := r.managedReadShareBundle(src, shareBundleTypeShare, func(header *tar.Header, tr *tar.Reader) error {
+		if strings.Contains(header.Name, "/") {
+			return fmt.Errorf("illegal file name: %s", header.Name)
+		}
+		fileSiaPath, err := siaPathFromShareEntry(siaPath, header.Name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return errors.AddContext(err, "could not load the shared file in memory")
+		}
+		if err := r.staticFileSystem.AddSharedSiaFileFromReader(bytes.NewReader(b), fileSiaPath); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("could not add shared file %v", fileSiaPath))
+		}
+		n++
+		return nil
+	})
+	if n > 0 {
+		_ = r.staticBubbleScheduler.callQueueBubble(siaPath)
+	}
+	return n, err
+}
@@ -0,0 +1,148 @@
+package renter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestShare probes exporting files to a share bundle and importing it again.
+func TestShare(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file with a local copy.
+	path, err := rt.createZeroByteFileOnDisk()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 1)
+	siaPath, err := modules.NewSiaPath("dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rt.renter.staticFileSystem.NewSiaFile(siaPath, path, rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), modules.SectorSize, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The share key is deterministic.
+	shareKey, err := rt.renter.ShareKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shareKey2, err := rt.renter.ShareKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shareKey != shareKey2 || shareKey == (crypto.X25519PublicKey{}) {
+		t.Fatal("unexpected share key", shareKey)
+	}
+
+	// A share for a different recipient can't be imported.
+	dir := rt.dir
+	_, otherKey := crypto.GenerateX25519KeyPair()
+	dst := filepath.Join(dir, "other.share")
+	if err := rt.renter.ExportShare([]modules.SiaPath{siaPath}, dst, otherKey, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.ImportShare(dst, modules.RootSiaPath()); !errors.Contains(err, errShareRecipient) {
+		t.Fatal("expected errShareRecipient, got", err)
+	}
+
+	// An expired share can't be imported.
+	dst = filepath.Join(dir, "expired.share")
+	if err := rt.renter.ExportShare([]modules.SiaPath{siaPath}, dst, shareKey, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.renter.ImportShare(dst, modules.RootSiaPath()); !errors.Contains(err, errShareExpired) {
+		t.Fatal("expected errShareExpired, got", err)
+	}
+
+	// A failed export doesn't leave a bundle behind.
+	dst = filepath.Join(dir, "missing.share")
+	missing, err := modules.NewSiaPath("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.ExportShare([]modules.SiaPath{siaPath, missing}, dst, shareKey, time.Time{}); err == nil {
+		t.Fatal("expected export of a missing file to fail")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("bundle of failed export wasn't removed", err)
+	}
+
+	// Import a valid share into a different directory. The local path of the
+	// file isn't shared.
+	dst = filepath.Join(dir, "valid.share")
+	if err := rt.renter.ExportShare([]modules.SiaPath{siaPath}, dst, shareKey, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	sharedDir, err := modules.NewSiaPath("shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := rt.renter.ImportShare(dst, sharedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatal("unexpected number of imported files", n)
+	}
+	sharedPath, err := sharedDir.Join(siaPath.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rt.renter.staticFileSystem.OpenSiaFile(sharedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if f.LocalPath() != "" {
+		t.Fatal("local path was shared", f.LocalPath())
+	}
+	if f.Size() != modules.SectorSize || f.ErasureCode().NumPieces() != 2 {
+		t.Fatal("unexpected shared file", f.Size(), f.ErasureCode().NumPieces())
+	}
+
+	// The shared file is read-only. It can't be moved or locked but it can be
+	// deleted.
+	if fi, err := rt.renter.File(sharedPath); err != nil || !fi.ReadOnly {
+		t.Fatal("shared file isn't read-only", fi.ReadOnly, err)
+	}
+	if fi, err := rt.renter.File(siaPath); err != nil || fi.ReadOnly {
+		t.Fatal("original file is read-only", fi.ReadOnly, err)
+	}
+	if err := rt.renter.RenameFile(sharedPath, siaPath); !errors.Contains(err, modules.ErrReadOnly) {
+		t.Fatal("expected ErrReadOnly, got", err)
+	}
+	if err := rt.renter.SetFileLock(sharedPath, time.Now().Add(time.Hour)); !errors.Contains(err, modules.ErrReadOnly) {
+		t.Fatal("expected ErrReadOnly, got", err)
+	}
+	if err := rt.renter.DeleteFile(sharedPath); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -249,7 +249,7 @@ func (r *Renter) DownloadBackup(dst string, name string) (err error) {
 	if err != nil {
 		return err
 	}
-	s := r.managedStreamer(snap, false)
+	s := r.managedStreamer(snap, false, 0)
 	_, err = io.Copy(dstFile, s)
 	return errors.Compose(err, s.Close())
 }
@@ -308,10 +308,10 @@ func (r *Renter) managedSaveSnapshot(meta modules.UploadedBackup) error {
 
 // managedDownloadSnapshotTable will fetch the snapshot table from the host.
 func (r *Renter) managedDownloadSnapshotTable(host *worker) ([]snapshotEntry, error) {
-	// Get the wallet seed.
-	ws, _, err := r.w.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := r.w.PrimaryKeySeed()
 	if err != nil {
-		return nil, errors.AddContext(err, "failed to get wallet's primary seed")
+		return nil, errors.AddContext(err, "failed to get wallet's primary key seed")
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
 	rs := modules.DeriveRenterSeed(ws)
@@ -457,10 +457,10 @@ func (r *Renter) managedDownloadSnapshot(uid [16]byte) (ub modules.UploadedBacku
 	}
 	defer r.tg.Done()
 
-	// Get the wallet seed.
-	ws, _, err := r.w.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := r.w.PrimaryKeySeed()
 	if err != nil {
-		return modules.UploadedBackup{}, nil, errors.AddContext(err, "failed to get wallet's primary seed")
+		return modules.UploadedBackup{}, nil, errors.AddContext(err, "failed to get wallet's primary key seed")
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
 	rs := modules.DeriveRenterSeed(ws)
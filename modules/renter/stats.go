@@ -0,0 +1,285 @@
+package renter
+
+// stats.go contains the cumulative statistics of the renter. The stats are
+// persisted regularly and on shutdown, so they survive restarts. To be able to
+// report the stats of a period, a snapshot of the stats is taken every
+// statsSnapshotInterval and the last maxStatsSnapshots snapshots are persisted
+// together with the stats.
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// statsFilename is the name of the file which persists the renter's
+	// stats.
+	statsFilename = "stats.json"
+
+	// maxStatsSnapshots is the maximum number of snapshots of the stats which
+	// are kept.
+	maxStatsSnapshots = 720
+)
+
+var (
+	// statsMetadata is the header of the persisted stats.
+	statsMetadata = persist.Metadata{
+		Header:  "Renter Stats",
+		Version: "1.0",
+	}
+
+	// statsPersistInterval is the interval at which the stats are persisted.
+	statsPersistInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: 5 * time.Minute,
+		Testnet:  5 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// statsSnapshotInterval is the interval at which snapshots of the stats
+	// are taken.
+	statsSnapshotInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// renterStats keeps track of the renter's cumulative stats.
+	renterStats struct {
+		current   modules.RenterCumulativeStats
+		snapshots []modules.RenterCumulativeStats
+		mu        sync.Mutex
+
+		staticPath string
+	}
+
+	// statsPersist is the persisted form of the renter's stats.
+	statsPersist struct {
+		Current   modules.RenterCumulativeStats   `json:"current"`
+		Snapshots []modules.RenterCumulativeStats `json:"snapshots"`
+	}
+)
+
+// newRenterStats creates new renter stats and loads the persisted stats from
+// the provided directory if there are any.
+func newRenterStats(persistDir string) (*renterStats, error) {
+	rs := &renterStats{
+		staticPath: filepath.Join(persistDir, statsFilename),
+	}
+	var sp statsPersist
+	err := persist.LoadJSON(statsMetadata, &sp, rs.staticPath)
+	if os.IsNotExist(err) {
+		rs.current.Since = time.Now()
+		return rs, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load renter stats")
+	}
+	rs.current = sp.Current
+	rs.snapshots = sp.Snapshots
+	return rs, nil
+}
+
+// managedAddDownload adds a successful piece download to the stats.
+func (rs *renterStats) managedAddDownload(category spendingCategory, bytes uint64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.current.DownloadJobs++
+	if category == categoryRepairDownload {
+		rs.current.RepairDownloadedBytes += bytes
+	} else {
+		rs.current.DownloadedBytes += bytes
+	}
+}
+
+// managedAddFailedDownload adds a failed piece download to the stats.
+func (rs *renterStats) managedAddFailedDownload() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.current.FailedDownloadJobs++
+}
+
+// managedAddUpload adds the data uploaded to a host and its cost to the stats.
+func (rs *renterStats) managedAddUpload(repair bool, bytes uint64, cost types.Currency) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	s := &rs.current.Spending
+	if repair {
+		rs.current.RepairUploadedBytes += bytes
+		s.RepairUploads = s.RepairUploads.Add(cost)
+	} else {
+		rs.current.UploadedBytes += bytes
+		s.Uploads = s.Uploads.Add(cost)
+	}
+}
+
+// managedAddUploadJob adds a finished piece upload to the stats.
+func (rs *renterStats) managedAddUploadJob(failed bool) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if failed {
+		rs.current.FailedUploadJobs++
+	} else {
+		rs.current.UploadJobs++
+	}
+}
+
+// managedAddSpending adds money spent from an ephemeral account to the stats.
+func (rs *renterStats) managedAddSpending(category spendingCategory, amount types.Currency) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	s := &rs.current.Spending
+	switch category {
+	case categoryDownload:
+		s.Downloads = s.Downloads.Add(amount)
+	case categoryRegistryRead:
+		s.RegistryReads = s.RegistryReads.Add(amount)
+	case categoryRegistryWrite:
+		s.RegistryWrites = s.RegistryWrites.Add(amount)
+	case categoryRepairDownload:
+		s.RepairDownloads = s.RepairDownloads.Add(amount)
+	case categoryRepairUpload:
+		s.RepairUploads = s.RepairUploads.Add(amount)
+	case categorySnapshotDownload:
+		s.SnapshotDownloads = s.SnapshotDownloads.Add(amount)
+	case categorySnapshotUpload:
+		s.SnapshotUploads = s.SnapshotUploads.Add(amount)
+	case categorySubscription:
+		s.Subscriptions = s.Subscriptions.Add(amount)
+	case categoryUpload:
+		s.Uploads = s.Uploads.Add(amount)
+	case categoryProgram:
+		s.Programs = s.Programs.Add(amount)
+	default:
+		build.Critical("unknown spending category", category)
+	}
+}
+
+// managedSave persists the stats.
+func (rs *renterStats) managedSave() error {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return persist.SaveJSON(statsMetadata, statsPersist{
+		Current:   rs.current,
+		Snapshots: rs.snapshots,
+	}, rs.staticPath)
+}
+
+// managedSnapshot takes a snapshot of the stats if the latest snapshot is
+// older than the snapshot interval.
+func (rs *renterStats) managedSnapshot() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	now := time.Now()
+	if n := len(rs.snapshots); n > 0 && now.Sub(rs.snapshots[n-1].Time) < statsSnapshotInterval {
+		return
+	}
+	snapshot := rs.current
+	snapshot.Time = now
+	rs.snapshots = append(rs.snapshots, snapshot)
+	if len(rs.snapshots) > maxStatsSnapshots {
+		rs.snapshots = rs.snapshots[len(rs.snapshots)-maxStatsSnapshots:]
+	}
+}
+
+// managedStats returns the current stats. If since is not zero, the stats are
+// returned as the difference to the latest snapshot taken at or before since.
+// If there is no such snapshot, the stats are returned in full.
+func (rs *renterStats) managedStats(since time.Time) modules.RenterCumulativeStats {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	current := rs.current
+	current.Time = time.Now()
+	if since.IsZero() {
+		return current
+	}
+	base := modules.RenterCumulativeStats{
+		Time: current.Since,
+	}
+	for _, snapshot := range rs.snapshots {
+		if snapshot.Time.After(since) {
+			break
+		}
+		base = snapshot
+	}
+	return statsDelta(current, base)
+}
+
+// statsDelta returns the difference between the stats and an earlier snapshot
+// of them.
+func statsDelta(stats, base modules.RenterCumulativeStats) modules.RenterCumulativeStats {
+	s, b := stats.Spending, base.Spending
+	return modules.RenterCumulativeStats{
+		Since: base.Time,
+		Time:  stats.Time,
+
+		DownloadedBytes:       stats.DownloadedBytes - base.DownloadedBytes,
+		RepairDownloadedBytes: stats.RepairDownloadedBytes - base.RepairDownloadedBytes,
+		RepairUploadedBytes:   stats.RepairUploadedBytes - base.RepairUploadedBytes,
+		UploadedBytes:         stats.UploadedBytes - base.UploadedBytes,
+
+		DownloadJobs:       stats.DownloadJobs - base.DownloadJobs,
+		FailedDownloadJobs: stats.FailedDownloadJobs - base.FailedDownloadJobs,
+		FailedUploadJobs:   stats.FailedUploadJobs - base.FailedUploadJobs,
+		UploadJobs:         stats.UploadJobs - base.UploadJobs,
+
+		Spending: modules.RenterCumulativeSpending{
+			Downloads:         s.Downloads.Sub(b.Downloads),
+			RegistryReads:     s.RegistryReads.Sub(b.RegistryReads),
+			RegistryWrites:    s.RegistryWrites.Sub(b.RegistryWrites),
+			RepairDownloads:   s.RepairDownloads.Sub(b.RepairDownloads),
+			RepairUploads:     s.RepairUploads.Sub(b.RepairUploads),
+			SnapshotDownloads: s.SnapshotDownloads.Sub(b.SnapshotDownloads),
+			SnapshotUploads:   s.SnapshotUploads.Sub(b.SnapshotUploads),
+			Subscriptions:     s.Subscriptions.Sub(b.Subscriptions),
+			Uploads:           s.Uploads.Sub(b.Uploads),
+			Programs:          s.Programs.Sub(b.Programs),
+		},
+	}
+}
+
+// CumulativeStats returns the renter's cumulative stats. If since is not zero,
+// the stats are returned as the difference to the latest snapshot of the stats
+// taken at or before since.
+func (r *Renter) CumulativeStats(since time.Time) (modules.RenterCumulativeStats, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterCumulativeStats{}, err
+	}
+	defer r.tg.Done()
+	return r.staticStats.managedStats(since), nil
+}
+
+// threadedPersistStats periodically takes snapshots of the stats and persists
+// them.
+func (r *Renter) threadedPersistStats() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	ticker := time.NewTicker(statsPersistInterval)
+	defer ticker.Stop()
+	for {
+		r.staticStats.managedSnapshot()
+		if err := r.staticStats.managedSave(); err != nil {
+			r.log.Println("WARN: unable to persist renter stats:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-ticker.C:
+		}
+	}
+}
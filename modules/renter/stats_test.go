@@ -0,0 +1,108 @@
+package renter
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestRenterStats probes updating, persisting and loading the renter's
+// cumulative stats.
+func TestRenterStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	rs, err := newRenterStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.current.Since.IsZero() {
+		t.Fatal("start of new stats should be set")
+	}
+
+	// Update the stats.
+	rs.managedAddUpload(false, 10, types.NewCurrency64(1))
+	rs.managedAddUpload(true, 20, types.NewCurrency64(2))
+	rs.managedAddUploadJob(false)
+	rs.managedAddUploadJob(true)
+	rs.managedAddDownload(categoryDownload, 30)
+	rs.managedAddDownload(categoryRepairDownload, 40)
+	rs.managedAddFailedDownload()
+	rs.managedAddSpending(categoryDownload, types.NewCurrency64(3))
+	rs.managedAddSpending(categoryRegistryRead, types.NewCurrency64(4))
+
+	stats := rs.managedStats(time.Time{})
+	if stats.UploadedBytes != 10 || stats.RepairUploadedBytes != 20 || stats.DownloadedBytes != 30 || stats.RepairDownloadedBytes != 40 {
+		t.Fatal("wrong bytes", stats)
+	}
+	if stats.UploadJobs != 1 || stats.FailedUploadJobs != 1 || stats.DownloadJobs != 2 || stats.FailedDownloadJobs != 1 {
+		t.Fatal("wrong job counts", stats)
+	}
+	s := stats.Spending
+	if !s.Uploads.Equals64(1) || !s.RepairUploads.Equals64(2) || !s.Downloads.Equals64(3) || !s.RegistryReads.Equals64(4) {
+		t.Fatal("wrong spending", s)
+	}
+
+	// Persist and load the stats.
+	rs.managedSnapshot()
+	if err := rs.managedSave(); err != nil {
+		t.Fatal(err)
+	}
+	rs, err = newRenterStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := rs.managedStats(time.Time{})
+	if !loaded.Since.Equal(stats.Since) || loaded.UploadedBytes != 10 || !loaded.Spending.RegistryReads.Equals64(4) {
+		t.Fatal("stats weren't persisted", loaded)
+	}
+	if len(rs.snapshots) != 1 {
+		t.Fatal("snapshot wasn't persisted", len(rs.snapshots))
+	}
+}
+
+// TestRenterStatsSince probes the deltas of the stats since a point in time.
+func TestRenterStatsSince(t *testing.T) {
+	rs := &renterStats{}
+	start := time.Now().Add(-time.Hour)
+	rs.current.Since = start
+
+	// Without snapshots, the deltas are the full stats.
+	rs.managedAddUpload(false, 10, types.NewCurrency64(1))
+	stats := rs.managedStats(time.Now())
+	if !stats.Since.Equal(start) || stats.UploadedBytes != 10 || !stats.Spending.Uploads.Equals64(1) {
+		t.Fatal("wrong stats", stats)
+	}
+
+	// Take a snapshot and update the stats again.
+	rs.managedSnapshot()
+	snapshotTime := rs.snapshots[0].Time
+	rs.managedAddUpload(false, 5, types.NewCurrency64(2))
+
+	// The deltas since before the snapshot cover the full stats.
+	stats = rs.managedStats(snapshotTime.Add(-time.Nanosecond))
+	if !stats.Since.Equal(start) || stats.UploadedBytes != 15 || !stats.Spending.Uploads.Equals64(3) {
+		t.Fatal("wrong stats", stats)
+	}
+	// The deltas since the snapshot only cover the latest update.
+	stats = rs.managedStats(snapshotTime)
+	if !stats.Since.Equal(snapshotTime) || stats.UploadedBytes != 5 || !stats.Spending.Uploads.Equals64(2) {
+		t.Fatal("wrong stats", stats)
+	}
+
+	// Snapshots are only taken once per interval.
+	rs.managedSnapshot()
+	if len(rs.snapshots) != 1 {
+		t.Fatal("snapshot shouldn't have been taken", len(rs.snapshots))
+	}
+}
@@ -23,6 +23,10 @@ import (
 var (
 	// ErrUploadDirectory is returned if the user tries to upload a directory.
 	ErrUploadDirectory = errors.New("cannot upload directory")
+
+	// errExternalKeyNotStreamed is returned if a file which is not streamed is
+	// uploaded with an external key.
+	errExternalKeyNotStreamed = errors.New("external keys are only supported by streamed uploads")
 )
 
 // Upload instructs the renter to start tracking a file. The renter will
@@ -52,14 +56,28 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
 
+	// Check that the file fits into the quotas of its directories.
+	if err := r.managedCheckDirQuotas(up.SiaPath, uint64(sourceInfo.Size()), up.Force); err != nil {
+		return err
+	}
+
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Files shared by other renters can't be overwritten.
 	if up.Force {
+		if err := r.managedCheckFileReadOnly(up.SiaPath); err != nil {
+			return err
+		}
 		err := r.DeleteFile(up.SiaPath)
 		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 			return errors.AddContext(err, "unable to delete existing file")
 		}
 	}
 
+	// External keys would have to be kept around until the upload is done.
+	if up.MasterKeyID != "" {
+		return errExternalKeyNotStreamed
+	}
+
 	// Fill in any missing upload params with sensible defaults.
 	if up.ErasureCode == nil {
 		up.ErasureCode = modules.NewRSSubCodeDefault()
@@ -100,6 +118,11 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
+	if up.Sparse {
+		if err := entry.SetSparse(true); err != nil {
+			return errors.Compose(errors.AddContext(err, "could not mark the new sia file as sparse"), entry.Close())
+		}
+	}
 
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
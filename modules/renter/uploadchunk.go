@@ -32,6 +32,7 @@ type unfinishedUploadChunk struct {
 	// Information about the chunk, namely where it exists within the file.
 	fileRecentlySuccessful bool // indicates if the file the chunk is from had a recent successful repair
 	health                 float64
+	hole                   bool // indicates if the chunk of a sparse file only contains zeros and doesn't need to be uploaded
 	length                 uint64
 	staticMemoryNeeded     uint64 // memory needed in bytes
 	memoryReleased         uint64 // memory that has been returned of memoryNeeded
@@ -44,10 +45,25 @@ type unfinishedUploadChunk struct {
 
 	staticMemoryManager *memoryManager
 
+	// spill is the spill file of the chunk if the chunk was spilled to disk
+	// instead of waiting for memory. It is set before the chunk's data is
+	// fetched and never changes afterwards.
+	spill *chunkSpill
+
 	// Static cached fields.
 	staticIndex    uint64
 	staticSiaPath  string
 	staticPriority bool // indicates if the chunk should get access to priority memory
+	staticRepair   bool // indicates if pieces of the chunk were uploaded before
+
+	// staticRepairPriority is the repair priority hinted by the tags of the
+	// file. Chunks with a higher value are repaired first.
+	staticRepairPriority int
+
+	// staticMasterKey is the key used to encrypt the chunk's pieces. For
+	// files which are encrypted with an external key, it is the key which was
+	// provided for the upload.
+	staticMasterKey crypto.CipherKey
 
 	// The logical data is the data that is presented to the user when the user
 	// requests the chunk. The physical data is all of the pieces that get
@@ -118,6 +134,12 @@ type unfinishedUploadChunk struct {
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
 	workersStandby   []*worker           // workers that can be used if other workers fail.
 
+	// Low latency hosts are preferred for some of the pieces of the chunk. Other
+	// workers don't take the pieces reserved for them.
+	lowLatencyHosts           map[string]struct{} // hosts with a low measured latency.
+	lowLatencyHostsPending    map[string]struct{} // low latency hosts that haven't taken a piece or dropped the chunk yet.
+	lowLatencyPiecesRemaining int                 // number of pieces that still should go to low latency hosts.
+
 	cancelMU sync.Mutex     // cancelMU needs to be held when adding to cancelWG and reading/writing canceled.
 	canceled bool           // cancel the work on this chunk.
 	cancelWG sync.WaitGroup // WaitGroup to wait on after canceling the uploadchunk.
@@ -236,7 +258,7 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
 // index i and then encrypt it.
 func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.staticMasterKey)
 }
 
 // padAndEncryptPiece will add padding to a piece and then encrypt it.
@@ -375,12 +397,19 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		}
 	}
 
-	// Fetch the logical data for the chunk.
+	// Fetch the logical data for the chunk. The pieces of a spilled chunk are
+	// written to its spill file right away.
 	err = r.managedFetchLogicalChunkData(chunk)
+	if err == nil && chunk.spill != nil && !chunk.hole {
+		err = errors.AddContext(chunk.spill.managedWritePieces(chunk.logicalChunkData), "unable to spill the chunk to disk")
+	}
+	if chunk.spill != nil {
+		chunk.spill.managedFinishBuild()
+	}
 	if err != nil {
 		// Return the erasure coding memory. This is not handled by the cleanup
 		// code.
-		chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
+		chunk.returnMemory(erasureCodingMemory + pieceCompletedMemory)
 
 		chunk.mu.Lock()
 		// Add the amount of freed EC memory to the chunk.
@@ -419,7 +448,7 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	}
 	// Return the erasure coding memory. This is not handled by the data
 	// fetching, where the erasure coding occurs.
-	chunk.staticMemoryManager.Return(erasureCodingMemory + pieceCompletedMemory)
+	chunk.returnMemory(erasureCodingMemory + pieceCompletedMemory)
 	chunk.memoryReleased += erasureCodingMemory + pieceCompletedMemory
 	// Swap the physical chunk data and the logical chunk data. There is
 	// probably no point to having both, given that we perform such a clean
@@ -428,6 +457,22 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 	chunk.physicalChunkData = chunk.logicalChunkData
 	chunk.logicalChunkData = nil
 
+	// A hole doesn't need to be distributed to the workers. It is recorded in
+	// the file instead and the chunk is finished right away.
+	if chunk.hole {
+		err = chunk.fileEntry.SetHole(chunk.staticIndex)
+		chunk.mu.Lock()
+		if err != nil {
+			r.repairLog.Printf("Unable to mark chunk %v of %s as a hole: %v", chunk.staticIndex, chunk.staticSiaPath, err)
+		} else {
+			chunk.piecesCompleted = chunk.staticPiecesNeeded
+		}
+		chunk.workersRemaining = 0
+		chunk.mu.Unlock()
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Sanity check - we should have at least as many physical data pieces as we
 	// do elements in our piece usage.
 	if len(chunk.physicalChunkData) < len(chunk.pieceUsage) {
@@ -497,11 +542,46 @@ func (uc *unfinishedUploadChunk) staticReadLogicalData(r io.Reader) (uint64, err
 	if err != nil {
 		return 0, err
 	}
-	// Encode the data pieces, forming the chunk's logical data.
-	//
+	uc.staticEncodeLogicalData(dataPieces)
+	return total, nil
+}
+
+// staticEncodeLogicalData encodes the data pieces, forming the chunk's logical
+// data. If the chunk belongs to a sparse file, only contains zeros and hasn't
+// been uploaded before, it is marked as a hole instead and no pieces are
+// created for it.
+func (uc *unfinishedUploadChunk) staticEncodeLogicalData(dataPieces [][]byte) {
+	if uc.fileEntry.Sparse() && !uc.staticHasExpectedPieceRoots() && isZeroData(dataPieces) {
+		uc.hole = true
+		uc.logicalChunkData = make([][]byte, uc.fileEntry.ErasureCode().NumPieces())
+		return
+	}
 	// TODO: Ideally there is a way to only encode the shards that we need.
 	uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
-	return total, nil
+}
+
+// staticHasExpectedPieceRoots returns whether any of the chunk's pieces were
+// uploaded before.
+func (uc *unfinishedUploadChunk) staticHasExpectedPieceRoots() bool {
+	var zeroHash crypto.Hash
+	for _, root := range uc.staticExpectedPieceRoots {
+		if root != zeroHash {
+			return true
+		}
+	}
+	return false
+}
+
+// isZeroData returns whether the provided data pieces only contain zeros.
+func isZeroData(dataPieces [][]byte) bool {
+	for _, piece := range dataPieces {
+		for _, b := range piece {
+			if b != 0 {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // staticFetchLogicalDataFromReader will load the logical data for a chunk from
@@ -579,7 +659,7 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 		if err != nil {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
-		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+		uc.staticEncodeLogicalData(dataPieces)
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -705,6 +785,8 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 				r.log.Println("WARN: unable to close file entry for chunk", uc.fileEntry.SiaFilePath())
 			}
 		}
+		// Remove the spill file of the chunk.
+		r.managedCloseSpill(uc)
 		// Remove the chunk from the repairingChunks map
 		r.uploadHeap.managedMarkRepairDone(uc)
 		// Signal garbage collector to free memory before returning it to the manager.
@@ -713,10 +795,11 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	}
 	// If required, return the memory to the renter.
 	if memoryReleased > 0 {
-		uc.staticMemoryManager.Return(memoryReleased)
+		uc.returnMemory(memoryReleased)
 	}
 	// Make sure file is closed for canceled chunks when all workers are done
 	if canceled && workersRemaining == 0 && !chunkComplete {
+		r.managedCloseSpill(uc)
 		err := uc.fileEntry.Close()
 		if err != nil {
 			r.log.Println("WARN: unable to close file entry for chunk", uc.fileEntry.SiaFilePath())
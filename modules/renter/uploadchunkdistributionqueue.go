@@ -296,6 +296,10 @@ func (r *Renter) managedDistributeChunkToWorkers(uc *unfinishedUploadChunk) bool
 		return false
 	}
 
+	// Reserve some of the pieces for the low latency workers before any worker
+	// receives the chunk.
+	r.managedReserveLowLatencyPieces(uc, workers)
+
 	// Give the chunk to each worker, marking the number of workers that have
 	// received the chunk. Only count the worker if the worker's upload queue
 	// accepts the job.
@@ -25,6 +25,7 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/types"
 )
 
@@ -78,18 +79,22 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 	//      than all other chunks. An example would be if the upload of a single
 	//      chunk is a blocking task.
 	//
-	//  2) File Recently Successful Chunks
+	//  2) Repair Priority Hints
+	//    - These are chunks of files tagged by the user with a higher repair
+	//      priority, see modules.RepairPriorityTag
+	//
+	//  3) File Recently Successful Chunks
 	//    - These are stuck chunks that are from a file that recently had a
 	//      successful repair
 	//
-	//  3) Stuck Chunks
+	//  4) Stuck Chunks
 	//    - These are chunks added by the stuck loop
 	//
-	//  4) Remote Chunks
+	//  5) Remote Chunks
 	//    - These are chunks of a siafile that do not have a local file to repair
 	//    from
 	//
-	//  5) Worst Health Chunk
+	//  6) Worst Health Chunk
 	//    - The base priority of chunks in the heap is by the worst health
 
 	// Check for Priority chunks
@@ -103,6 +108,14 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 		return false
 	}
 
+	// Check for Repair Priority Hints
+	//
+	// If the chunks have different repair priorities, prioritize the higher
+	// one.
+	if uch[i].staticRepairPriority != uch[j].staticRepairPriority {
+		return uch[i].staticRepairPriority > uch[j].staticRepairPriority
+	}
+
 	// Check for File Recently Successful Chunks
 	//
 	// If only chunk i's file was recently successful, return true to prioritize
@@ -519,6 +532,10 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		r.log.Println("WARN: unable to get 'stuck' status:", err)
 		return nil, errors.AddContext(err, "unable to get 'stuck' status")
 	}
+	masterKey, err := entry.ChunkMasterKey(chunkIndex)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get the master key of the chunk")
+	}
 	_, err = os.Stat(entryCopy.LocalPath())
 	onDisk := err == nil
 	uuc := &unfinishedUploadChunk{
@@ -534,8 +551,9 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		onDisk:         onDisk,
 		staticPriority: priority,
 
-		staticIndex:   chunkIndex,
-		staticSiaPath: entryCopy.SiaFilePath(),
+		staticIndex:     chunkIndex,
+		staticMasterKey: masterKey,
+		staticSiaPath:   entryCopy.SiaFilePath(),
 
 		staticMemoryManager: mm,
 
@@ -545,7 +563,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		// TODO: Currently we request memory for all of the pieces as well
 		// as the minimum pieces, but we perhaps don't need to request all
 		// of that.
-		staticMemoryNeeded:  entry.PieceSize()*uint64(entry.ErasureCode().NumPieces()+entry.ErasureCode().MinPieces()) + uint64(entry.ErasureCode().NumPieces())*entry.MasterKey().Type().Overhead(),
+		staticMemoryNeeded:  entry.PieceSize()*uint64(entry.ErasureCode().NumPieces()+entry.ErasureCode().MinPieces()) + uint64(entry.ErasureCode().NumPieces())*entry.MasterKeyType().Overhead(),
 		staticMinimumPieces: entry.ErasureCode().MinPieces(),
 		staticPiecesNeeded:  entry.ErasureCode().NumPieces(),
 		stuck:               stuck,
@@ -608,6 +626,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		// a local (and therefore potentially altered or corrupt) file.
 		if len(pieceSet) > 0 {
 			uuc.staticExpectedPieceRoots[pieceIndex] = pieceSet[0].MerkleRoot
+			uuc.staticRepair = true
 		}
 	}
 	// Now that we have calculated the completed pieces for the chunk we can
@@ -616,6 +635,35 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 	return uuc, nil
 }
 
+// managedRepairPriority returns the repair priority hinted by the
+// modules.RepairPriorityTag of the file or, if the file isn't tagged, of its
+// closest tagged parent dir. High priority is 1, low priority is -1 and no hint
+// is 0.
+func (r *Renter) managedRepairPriority(entry *filesystem.FileNode) int {
+	priority, ok := entry.Tags()[modules.RepairPriorityTag]
+	dir, err := r.staticFileSystem.FileSiaPath(entry).Dir()
+	for !ok && err == nil {
+		var md siadir.Metadata
+		md, err = r.managedDirMetadata(dir)
+		if err != nil {
+			break
+		}
+		priority, ok = md.Tags[modules.RepairPriorityTag]
+		if dir.IsRoot() {
+			break
+		}
+		dir, err = dir.Dir()
+	}
+	switch priority {
+	case modules.RepairPriorityHigh:
+		return 1
+	case modules.RepairPriorityLow:
+		return -1
+	default:
+		return 0
+	}
+}
+
 // managedBuildUnfinishedChunks will pull all of the unfinished chunks out of a
 // file.
 //
@@ -626,6 +674,13 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 // finish would then close the Entry and consequentially impact the remaining
 // chunks.
 func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts map[string]struct{}, target repairTarget, offline, goodForRenew map[string]bool, mm *memoryManager) []*unfinishedUploadChunk {
+	// Files which are encrypted with an external key can only be uploaded
+	// while the user provides the key. Files shared by other renters are
+	// repaired by the sharer.
+	if entry.MasterKeyID() != "" || entry.ReadOnly() {
+		return nil
+	}
+
 	// If we don't have enough workers for the file, don't repair it right now.
 	minPieces := entry.ErasureCode().MinPieces()
 	r.staticWorkerPool.mu.RLock()
@@ -678,6 +733,7 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 	}
 
 	// Assemble the set of chunks.
+	repairPriority := r.managedRepairPriority(entry)
 	newUnfinishedChunks := make([]*unfinishedUploadChunk, 0, len(chunkIndexes))
 	for _, index := range chunkIndexes {
 		// Sanity check: fileUID should not be the empty value.
@@ -691,13 +747,25 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 			r.log.Debugln("Error when building an unfinished chunk:", err)
 			continue
 		}
+		chunk.staticRepairPriority = repairPriority
 		newUnfinishedChunks = append(newUnfinishedChunks, chunk)
 	}
 
 	// Iterate through the set of newUnfinishedChunks and remove any that are
 	// completed or are not downloadable.
+	repairsPaused := r.managedRepairsPaused()
 	incompleteChunks := newUnfinishedChunks[:0]
 	for _, chunk := range newUnfinishedChunks {
+		// While repairs are paused, only chunks which were never uploaded are
+		// added to the heap.
+		if repairsPaused && (chunk.staticRepair || target == targetStuckChunks) {
+			err := r.managedSetStuckAndClose(chunk, false)
+			if err != nil {
+				r.log.Debugln("WARN: unable to close chunk of paused repair:", err)
+			}
+			continue
+		}
+
 		// Check the chunk status. A chunk is repairable if it can be fully
 		// downloaded, or if the source file is available on disk. We also check
 		// if the chunk needs repair, which is only true if more than a certain
@@ -1309,9 +1377,13 @@ func (r *Renter) managedPushChunkForRepair(uuc *unfinishedUploadChunk, ct chunkT
 func (r *Renter) managedPrepareNextChunk(uuc *unfinishedUploadChunk) error {
 	// Grab the next chunk, loop until we have enough memory, update the amount
 	// of memory available, and then spin up a thread to asynchronously handle
-	// the rest of the chunk tasks.
-	if !uuc.staticMemoryManager.Request(context.Background(), uuc.staticMemoryNeeded, uuc.staticPriority) {
-		return errors.New("couldn't request memory")
+	// the rest of the chunk tasks. If the memory is not available right away,
+	// the chunk is spilled to disk instead if possible.
+	if !uuc.staticMemoryManager.TryRequest(uuc.staticMemoryNeeded, uuc.staticPriority) {
+		uuc.spill = r.managedSpillChunk(uuc)
+		if uuc.spill == nil && !uuc.staticMemoryManager.Request(context.Background(), uuc.staticMemoryNeeded, uuc.staticPriority) {
+			return errors.New("couldn't request memory")
+		}
 	}
 	go r.threadedFetchAndRepairChunk(uuc)
 	return nil
@@ -1394,6 +1466,14 @@ func (r *Renter) managedRepairLoop() error {
 			return nil
 		}
 		chunkPath := nextChunk.staticSiaPath
+
+		// Skip the repairs which were added to the heap before repairs were
+		// paused.
+		if (nextChunk.staticRepair || nextChunk.stuck) && r.managedRepairsPaused() {
+			nextChunk.fileEntry.Close()
+			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			continue
+		}
 		r.repairLog.Printf("Repairing chunk %v of %s, currently have %v out of %v pieces", nextChunk.staticIndex, chunkPath, nextChunk.piecesCompleted, nextChunk.staticPiecesNeeded)
 
 		// Make sure we have enough workers for this chunk to reach minimum
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"bytes"
+	"container/heap"
 	"fmt"
 	"io"
 	"os"
@@ -43,11 +44,86 @@ func TestUploadHeap(t *testing.T) {
 	t.Run("HeapMaps", testUploadHeapMaps)
 	t.Run("PauseChan", testUploadHeapPauseChan)
 	t.Run("RemoteChunks", testAddRemoteChunksToHeap)
+	t.Run("RepairPriority", testRepairPriority)
 
 	// Regression Tests
 	t.Run("Regression_SwitchStuckStatus", testChunkSwitchStuckStatus)
 }
 
+// testRepairPriority tests that the repair priority of files is hinted by the
+// tags of the files and their dirs, and that it is used to sort the chunks.
+func testRepairPriority(t *testing.T) {
+	// Create Renter
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file in a sub dir.
+	dirSiaPath, err := modules.NewSiaPath("prio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := dirSiaPath.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsc, _ := modules.NewRSCode(1, 1)
+	err = rt.renter.staticFileSystem.NewSiaFile(siaPath, "", rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), 10e3, persist.DefaultDiskPermissionsTest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without tags there is no hint.
+	if p := rt.renter.managedRepairPriority(f); p != 0 {
+		t.Fatal("unexpected priority", p)
+	}
+	// The file should inherit the priority of its closest tagged dir.
+	tags := map[string]string{modules.RepairPriorityTag: modules.RepairPriorityHigh}
+	if err := rt.renter.SetDirTags(dirSiaPath, tags); err != nil {
+		t.Fatal(err)
+	}
+	if p := rt.renter.managedRepairPriority(f); p != 1 {
+		t.Fatal("unexpected priority", p)
+	}
+	// The tag of the file should override the tag of the dir.
+	tags = map[string]string{modules.RepairPriorityTag: modules.RepairPriorityLow}
+	if err := rt.renter.SetFileTags(siaPath, tags); err != nil {
+		t.Fatal(err)
+	}
+	if p := rt.renter.managedRepairPriority(f); p != -1 {
+		t.Fatal("unexpected priority", p)
+	}
+
+	// A chunk with a higher repair priority should be popped before a chunk
+	// with a worse health.
+	uch := uploadChunkHeap{
+		&unfinishedUploadChunk{health: 1},
+		&unfinishedUploadChunk{health: 0.5, staticRepairPriority: 1},
+		&unfinishedUploadChunk{health: 2, staticRepairPriority: -1},
+	}
+	heap.Init(&uch)
+	for _, health := range []float64{0.5, 1, 2} {
+		if c := heap.Pop(&uch).(*unfinishedUploadChunk); c.health != health {
+			t.Fatalf("expected chunk with health %v but got %v", health, c.health)
+		}
+	}
+}
+
 // testManagedBuildUnfinishedChunks probes managedBuildUnfinishedChunks to make
 // sure that the correct chunks are being added to the heap
 func testManagedBuildUnfinishedChunks(t *testing.T) {
@@ -0,0 +1,118 @@
+package renter
+
+// uploadlocality.go contains the logic for preferring hosts with a low measured
+// latency for a fraction of the pieces of each chunk. Interactive workloads get
+// fast first-byte times from those hosts while the remaining redundancy can go
+// to cheaper, more distant hosts.
+//
+// When a chunk is distributed, a number of its pieces are reserved for the low
+// latency hosts. Other workers go on standby instead of taking a reserved
+// piece. Once a low latency worker drops the chunk, the reservation shrinks
+// and the standby workers are notified, so the chunk never waits for hosts
+// which won't upload it.
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+var (
+	// errLowLatencyBudgetNegative is returned if the latency budget of low
+	// latency hosts is negative.
+	errLowLatencyBudgetNegative = errors.New("low latency budget can't be negative")
+
+	// errLowLatencyFractionInvalid is returned if the fraction of pieces
+	// reserved for low latency hosts is not between 0 and 1.
+	errLowLatencyFractionInvalid = errors.New("low latency fraction must be between 0 and 1")
+)
+
+// lowLatencyPiecesReserved returns the number of the chunk's pieces which are
+// currently reserved for low latency hosts. A reservation is only kept as long
+// as there are low latency hosts left which might take the piece.
+//
+// NOTE: uc.mu needs to be held when calling this method.
+func (uc *unfinishedUploadChunk) lowLatencyPiecesReserved() int {
+	reserved := uc.lowLatencyPiecesRemaining
+	if pending := len(uc.lowLatencyHostsPending); pending < reserved {
+		reserved = pending
+	}
+	return reserved
+}
+
+// lowLatencyPieces returns the number of a chunk's pieces that should be
+// uploaded to low latency hosts.
+func lowLatencyPieces(fraction float64, piecesNeeded int) int {
+	return int(math.Ceil(fraction * float64(piecesNeeded)))
+}
+
+// selectLowLatencyHosts returns the hosts which count as low latency hosts. If
+// a budget is set, these are the hosts with a latency within the budget.
+// Otherwise they are the numPieces hosts with the lowest latency. Hosts without
+// a measured latency are never selected.
+func selectLowLatencyHosts(latencies map[string]time.Duration, budget time.Duration, numPieces int) map[string]struct{} {
+	hosts := make([]string, 0, len(latencies))
+	for host, latency := range latencies {
+		if latency <= 0 || (budget > 0 && latency > budget) {
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+	if budget == 0 {
+		sort.Slice(hosts, func(i, j int) bool {
+			return latencies[hosts[i]] < latencies[hosts[j]]
+		})
+		if len(hosts) > numPieces {
+			hosts = hosts[:numPieces]
+		}
+	}
+	selected := make(map[string]struct{}, len(hosts))
+	for _, host := range hosts {
+		selected[host] = struct{}{}
+	}
+	return selected
+}
+
+// managedReserveLowLatencyPieces reserves pieces of the chunk for the low
+// latency hosts among the provided workers according to the renter's
+// settings. Low latency hosts which already store a piece of the chunk count
+// towards the reservation.
+func (r *Renter) managedReserveLowLatencyPieces(uc *unfinishedUploadChunk, workers []*worker) {
+	id := r.mu.RLock()
+	fraction := r.persist.LowLatencyFraction
+	budget := r.persist.LowLatencyBudget
+	r.mu.RUnlock(id)
+	if fraction == 0 {
+		return
+	}
+	numPieces := lowLatencyPieces(fraction, uc.staticPiecesNeeded)
+
+	// Measure the latency of the workers by the time of their has sector jobs
+	// which are cheap and therefore mostly made up of the round trip.
+	latencies := make(map[string]time.Duration, len(workers))
+	for _, w := range workers {
+		latencies[w.staticHostPubKeyStr] = w.staticJobHasSectorQueue.callExpectedJobTime()
+	}
+	hosts := selectLowLatencyHosts(latencies, budget, numPieces)
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.lowLatencyHosts = hosts
+	uc.lowLatencyHostsPending = make(map[string]struct{}, len(hosts))
+	for host := range hosts {
+		if _, unused := uc.unusedHosts[host]; unused {
+			uc.lowLatencyHostsPending[host] = struct{}{}
+		} else {
+			numPieces--
+		}
+	}
+	if unclaimed := uc.staticPiecesNeeded - uc.piecesCompleted - uc.piecesRegistered; numPieces > unclaimed {
+		numPieces = unclaimed
+	}
+	if numPieces < 0 {
+		numPieces = 0
+	}
+	uc.lowLatencyPiecesRemaining = numPieces
+}
@@ -0,0 +1,64 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLowLatencyPieces is a unit test for lowLatencyPieces.
+func TestLowLatencyPieces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		fraction     float64
+		piecesNeeded int
+		expected     int
+	}{
+		{0, 30, 0},
+		{0.1, 30, 3},
+		{0.25, 30, 8},
+		{0.5, 1, 1},
+		{1, 30, 30},
+	}
+	for _, test := range tests {
+		if n := lowLatencyPieces(test.fraction, test.piecesNeeded); n != test.expected {
+			t.Errorf("%v of %v: expected %v but got %v", test.fraction, test.piecesNeeded, test.expected, n)
+		}
+	}
+}
+
+// TestSelectLowLatencyHosts is a unit test for selectLowLatencyHosts.
+func TestSelectLowLatencyHosts(t *testing.T) {
+	t.Parallel()
+
+	latencies := map[string]time.Duration{
+		"a": 50 * time.Millisecond,
+		"b": 10 * time.Millisecond,
+		"c": 200 * time.Millisecond,
+		"d": 0, // not measured yet
+		"e": 100 * time.Millisecond,
+	}
+	tests := []struct {
+		budget    time.Duration
+		numPieces int
+		expected  []string
+	}{
+		// Without a budget the fastest hosts are selected.
+		{0, 2, []string{"a", "b"}},
+		{0, 10, []string{"a", "b", "c", "e"}},
+		// With a budget all the hosts within the budget are selected.
+		{100 * time.Millisecond, 1, []string{"a", "b", "e"}},
+		{time.Millisecond, 1, nil},
+	}
+	for _, test := range tests {
+		hosts := selectLowLatencyHosts(latencies, test.budget, test.numPieces)
+		if len(hosts) != len(test.expected) {
+			t.Fatalf("budget %v, %v pieces: expected %v hosts but got %v", test.budget, test.numPieces, test.expected, hosts)
+		}
+		for _, host := range test.expected {
+			if _, exists := hosts[host]; !exists {
+				t.Fatalf("budget %v, %v pieces: expected %v hosts but got %v", test.budget, test.numPieces, test.expected, hosts)
+			}
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package renter
+
+// uploadspill.go contains the disk spill of upload and repair chunks. When the
+// memory manager of a chunk can't grant the memory for the chunk right away,
+// the chunk is built without memory from the memory manager and its physical
+// pieces are written to a spill file on disk instead of being held in memory
+// until they are uploaded. Workers read their piece back from the spill file
+// right before uploading it.
+//
+// Only one spilled chunk is built at a time, which limits the memory used
+// outside of the memory manager to the memory of a single chunk. The size of
+// all spill files is limited by the spill cap of the renter's settings.
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// spillDirName is the name of the directory within the renter's persist
+	// directory which holds the spill files if no spill directory is set.
+	spillDirName = "spill"
+
+	// spillFilePrefix is the prefix of the names of spill files.
+	spillFilePrefix = "uploadspill-"
+)
+
+var (
+	// errSpillDirNotAbsolute is returned if the spill directory is not an
+	// absolute path.
+	errSpillDirNotAbsolute = errors.New("spill directory must be an absolute path")
+
+	// errSpillClosed is returned when reading a piece from a spill file which
+	// was already closed.
+	errSpillClosed = errors.New("spill file was already closed")
+)
+
+type (
+	// uploadSpill keeps track of the disk space used by spill files and makes
+	// sure only one spilled chunk is built at a time.
+	uploadSpill struct {
+		used uint64
+		mu   sync.Mutex
+
+		// staticBuildSlot holds an element while a spilled chunk is being
+		// built.
+		staticBuildSlot chan struct{}
+	}
+
+	// chunkSpill is the spill file of a single chunk. It stores the physical
+	// pieces of the chunk which haven't been uploaded yet.
+	chunkSpill struct {
+		closed  bool
+		file    *os.File
+		offsets []int64
+		lengths []int
+		mu      sync.Mutex
+
+		staticSize        uint64
+		staticUploadSpill *uploadSpill
+	}
+)
+
+// newUploadSpill creates a new uploadSpill.
+func newUploadSpill() *uploadSpill {
+	return &uploadSpill{
+		staticBuildSlot: make(chan struct{}, 1),
+	}
+}
+
+// managedReserve reserves size bytes of the spill cap. It returns false if
+// there is not enough space left.
+func (us *uploadSpill) managedReserve(size, spillCap uint64) bool {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if us.used+size > spillCap {
+		return false
+	}
+	us.used += size
+	return true
+}
+
+// managedRelease releases size bytes of the spill cap.
+func (us *uploadSpill) managedRelease(size uint64) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+	if size > us.used {
+		build.Critical("more spill space released than reserved")
+		size = us.used
+	}
+	us.used -= size
+}
+
+// managedSpillSettings returns the directory and the cap of the spill files.
+// An empty spill directory in the settings means the default directory is
+// used.
+func (r *Renter) managedSpillSettings() (string, uint64) {
+	id := r.mu.RLock()
+	dir := r.persist.SpillDir
+	spillCap := r.persist.SpillCap
+	r.mu.RUnlock(id)
+	if dir == "" {
+		dir = filepath.Join(r.persistDir, spillDirName)
+	}
+	return dir, spillCap
+}
+
+// managedSpillChunk tries to create a spill file for the chunk. If the spill
+// cap allows for the chunk, it blocks until no other spilled chunk is being
+// built. It returns nil if the chunk can't be spilled, in which case the chunk
+// needs to wait for memory instead. Otherwise the caller needs to call
+// managedFinishBuild on the returned spill once the chunk is built.
+func (r *Renter) managedSpillChunk(uc *unfinishedUploadChunk) *chunkSpill {
+	dir, spillCap := r.managedSpillSettings()
+	if spillCap == 0 {
+		return nil
+	}
+	// The memory needed by the chunk is an upper bound for the size of its
+	// spill file.
+	us := r.staticUploadSpill
+	size := uc.staticMemoryNeeded
+	if !us.managedReserve(size, spillCap) {
+		return nil
+	}
+	select {
+	case us.staticBuildSlot <- struct{}{}:
+	case <-r.tg.StopChan():
+		us.managedRelease(size)
+		return nil
+	}
+
+	// Create the spill file.
+	err := os.MkdirAll(dir, modules.DefaultDirPerm)
+	var f *os.File
+	if err == nil {
+		f, err = ioutil.TempFile(dir, spillFilePrefix)
+	}
+	if err != nil {
+		r.log.Printf("WARN: unable to create spill file for chunk %v of %s: %v", uc.staticIndex, uc.staticSiaPath, err)
+		<-us.staticBuildSlot
+		us.managedRelease(size)
+		return nil
+	}
+	return &chunkSpill{
+		file:              f,
+		offsets:           make([]int64, len(uc.pieceUsage)),
+		lengths:           make([]int, len(uc.pieceUsage)),
+		staticSize:        size,
+		staticUploadSpill: us,
+	}
+}
+
+// managedPruneSpillDir removes the spill files which were left behind by a
+// previous run of the renter.
+func (r *Renter) managedPruneSpillDir() error {
+	dir, _ := r.managedSpillSettings()
+	paths, err := filepath.Glob(filepath.Join(dir, spillFilePrefix+"*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		err = errors.Compose(err, os.Remove(path))
+	}
+	return err
+}
+
+// managedFinishBuild signals that the spilled chunk was built, which allows
+// the next spilled chunk to be built.
+func (cs *chunkSpill) managedFinishBuild() {
+	<-cs.staticUploadSpill.staticBuildSlot
+}
+
+// managedWritePieces writes the pieces to the spill file and drops them from
+// memory.
+func (cs *chunkSpill) managedWritePieces(pieces [][]byte) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.closed {
+		return errSpillClosed
+	}
+	var offset int64
+	for i, piece := range pieces {
+		if piece == nil {
+			continue
+		}
+		_, err := cs.file.WriteAt(piece, offset)
+		if err != nil {
+			return errors.AddContext(err, "unable to write piece to spill file")
+		}
+		cs.offsets[i] = offset
+		cs.lengths[i] = len(piece)
+		offset += int64(len(piece))
+		pieces[i] = nil
+	}
+	return nil
+}
+
+// managedReadPiece reads a piece from the spill file.
+func (cs *chunkSpill) managedReadPiece(pieceIndex uint64) ([]byte, error) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.closed {
+		return nil, errSpillClosed
+	}
+	piece := make([]byte, cs.lengths[pieceIndex])
+	_, err := cs.file.ReadAt(piece, cs.offsets[pieceIndex])
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read piece from spill file")
+	}
+	return piece, nil
+}
+
+// managedClose closes and removes the spill file and releases its space. It is
+// safe to call it multiple times.
+func (cs *chunkSpill) managedClose() error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.closed {
+		return nil
+	}
+	cs.closed = true
+	cs.staticUploadSpill.managedRelease(cs.staticSize)
+	return errors.Compose(cs.file.Close(), os.Remove(cs.file.Name()))
+}
+
+// managedPieceData returns the physical data of a piece of the chunk. The data
+// is read from the spill file if the chunk was spilled to disk.
+func (uc *unfinishedUploadChunk) managedPieceData(pieceIndex uint64) ([]byte, error) {
+	if uc.spill != nil {
+		return uc.spill.managedReadPiece(pieceIndex)
+	}
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	return uc.physicalChunkData[pieceIndex], nil
+}
+
+// returnMemory returns memory of the chunk to its memory manager. Spilled
+// chunks didn't get any memory from the memory manager, so nothing is
+// returned for them.
+func (uc *unfinishedUploadChunk) returnMemory(amount uint64) {
+	if uc.spill != nil {
+		return
+	}
+	uc.staticMemoryManager.Return(amount)
+}
+
+// managedCloseSpill closes the spill file of the chunk if it was spilled.
+func (r *Renter) managedCloseSpill(uc *unfinishedUploadChunk) {
+	if uc.spill == nil {
+		return
+	}
+	if err := uc.spill.managedClose(); err != nil {
+		r.log.Printf("WARN: unable to remove spill file of chunk %v of %s: %v", uc.staticIndex, uc.staticSiaPath, err)
+	}
+}
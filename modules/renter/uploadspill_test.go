@@ -0,0 +1,144 @@
+package renter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestUploadSpillReserve is a unit test for reserving and releasing space of
+// the spill cap.
+func TestUploadSpillReserve(t *testing.T) {
+	us := newUploadSpill()
+	if !us.managedReserve(60, 100) {
+		t.Fatal("reservation within the cap should succeed")
+	}
+	if us.managedReserve(60, 100) {
+		t.Fatal("reservation exceeding the cap should fail")
+	}
+	if !us.managedReserve(40, 100) {
+		t.Fatal("reservation up to the cap should succeed")
+	}
+	us.managedRelease(60)
+	if !us.managedReserve(60, 100) {
+		t.Fatal("reservation should succeed after releasing space")
+	}
+	if us.used != 100 {
+		t.Fatal("wrong amount of used space", us.used)
+	}
+}
+
+// TestChunkSpill probes writing pieces to a spill file, reading them back and
+// closing the spill file.
+func TestChunkSpill(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Without a cap, chunks aren't spilled.
+	uc := &unfinishedUploadChunk{
+		pieceUsage:         make([]bool, 3),
+		staticMemoryNeeded: 30,
+	}
+	if r.managedSpillChunk(uc) != nil {
+		t.Fatal("chunk shouldn't be spilled without a spill cap")
+	}
+
+	// Set a spill cap which allows for a single chunk.
+	settings, err := r.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.SpillCap = 50
+	err = r.SetSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := r.managedSpillChunk(uc)
+	if cs == nil {
+		t.Fatal("chunk should be spilled")
+	}
+	cs.managedFinishBuild()
+	if r.managedSpillChunk(uc) != nil {
+		t.Fatal("chunk shouldn't be spilled beyond the spill cap")
+	}
+
+	// Write the pieces. The second piece was already uploaded.
+	pieces := [][]byte{fastrand.Bytes(10), nil, fastrand.Bytes(10)}
+	expected := [][]byte{pieces[0], nil, pieces[2]}
+	err = cs.managedWritePieces(pieces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, piece := range pieces {
+		if piece != nil {
+			t.Fatalf("piece %v wasn't dropped from memory", i)
+		}
+	}
+	uc.spill = cs
+	for _, i := range []uint64{0, 2} {
+		piece, err := uc.managedPieceData(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(piece, expected[i]) {
+			t.Fatalf("piece %v doesn't match", i)
+		}
+	}
+
+	// Closing the spill removes the file and releases its space.
+	path := cs.file.Name()
+	if filepath.Dir(path) != filepath.Join(r.persistDir, spillDirName) {
+		t.Fatal("spill file not in the default spill directory", path)
+	}
+	err = errors.Compose(cs.managedClose(), cs.managedClose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("spill file wasn't removed", err)
+	}
+	if _, err := uc.managedPieceData(0); !errors.Contains(err, errSpillClosed) {
+		t.Fatal("expected errSpillClosed but got", err)
+	}
+	cs = r.managedSpillChunk(uc)
+	if cs == nil {
+		t.Fatal("chunk should be spilled after closing the previous spill")
+	}
+	cs.managedFinishBuild()
+
+	// Leftover spill files are pruned.
+	err = r.managedPruneSpillDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cs.file.Name()); !os.IsNotExist(err) {
+		t.Fatal("spill file wasn't pruned", err)
+	}
+	if err := cs.file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The spill directory needs to be absolute.
+	settings.SpillDir = "relative"
+	err = r.SetSettings(settings)
+	if !errors.Contains(err, errSpillDirNotAbsolute) {
+		t.Fatal("expected errSpillDirNotAbsolute but got", err)
+	}
+}
@@ -11,21 +11,29 @@ import (
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/types"
 )
 
+var (
+	// errAppendUnaligned is returned when appending to a file whose size is
+	// not a multiple of its chunk size.
+	errAppendUnaligned = errors.New("can only append to files whose size is a multiple of their chunk size")
+)
+
 // Upload Streaming Overview:
 // Most of the logic that enables upload streaming can be found within
 // UploadStreamFromReader and the StreamShard. As seen at the beginning of the
-// big for - loop in UploadStreamFromReader, the streamer currently always
-// assumes that the data provided by the user starts at index 0 of chunk 0. In
-// every iteration the siafile is grown by a single chunk to prepare for the
-// upload of the next chunk. To allow the upload code to repair a chunk from a
-// stream, the stream is passed into the unfinished chunk as a new field. If the
-// upload code detects a stream, it will use that instead of a local file to
-// fetch the chunk's logical data. As soon as the upload code is done fetching
-// the logical data, it will close that streamer to signal the loop that it's
-// save to upload another chunk.
+// big for - loop in UploadStreamFromReader, the streamer assumes that the data
+// provided by the user starts at index 0 of chunk 0, unless it is appended to
+// an existing file in which case it starts at index 0 of the chunk following
+// the file's data. In every iteration the siafile is grown by a single chunk
+// to prepare for the upload of the next chunk. To allow the upload code to
+// repair a chunk from a stream, the stream is passed into the unfinished chunk
+// as a new field. If the upload code detects a stream, it will use that instead
+// of a local file to fetch the chunk's logical data. As soon as the upload code
+// is done fetching the logical data, it will close that streamer to signal the
+// loop that it's save to upload another chunk.
 // This is possible due to the custom StreamShard type which is a wrapper for a
 // io.Reader with a channel which is closed when the StreamShard is closed.
 
@@ -137,6 +145,16 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // SiaFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
 	siaPath, ec, force, repair, cipherType := up.SiaPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
+	// Appending to a file works like a repair of the file's new chunks.
+	if up.Append {
+		if ec != nil {
+			return nil, errors.New("can't provide erasure code settings when appending")
+		}
+		if force || repair {
+			return nil, errors.New("'append' can't be set together with 'force' or 'repair'")
+		}
+		return r.managedInitAppendStream(siaPath)
+	}
 	// Check if ec was set. If not use defaults.
 	var err error
 	if ec == nil && !repair {
@@ -151,6 +169,22 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
 
+	// Check that the directories of the file aren't full. The size of a
+	// stream isn't known in advance, so it is only rejected if a single byte
+	// wouldn't fit.
+	if !repair {
+		if err := r.managedCheckDirQuotas(siaPath, 1, force); err != nil {
+			return nil, err
+		}
+	}
+
+	// Files shared by other renters can't be overwritten or repaired.
+	if force || repair {
+		if err := r.managedCheckFileReadOnly(siaPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if force {
 		err := r.DeleteFile(siaPath)
@@ -177,18 +211,58 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	}
 
 	// If there's a cipherKey defined already use that, otherwise generate a new
-	// key of the given cipherType.
+	// key of the given cipherType. External keys are only persisted by their
+	// ID.
 	cipherKey := up.CipherKey
 	if up.CipherKey == nil {
 		cipherKey = crypto.GenerateSiaKey(cipherType)
 	}
+	if up.MasterKeyID != "" {
+		if up.CipherKey == nil || up.CipherKey.Type() == crypto.TypePlain {
+			return nil, errors.New("an external key must be provided together with its ID")
+		}
+		cipherKey = siafile.NewExternalMasterKey(up.MasterKeyID, up.CipherKey)
+	}
 
 	// Create the Siafile and add to renter
 	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenSiaFile(siaPath)
+	fileNode, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if up.Sparse {
+		if err := fileNode.SetSparse(true); err != nil {
+			return nil, errors.Compose(err, fileNode.Close())
+		}
+	}
+	return fileNode, nil
+}
+
+// managedInitAppendStream opens the existing SiaFile at siaPath for appending
+// data to it.
+func (r *Renter) managedInitAppendStream(siaPath modules.SiaPath) (*filesystem.FileNode, error) {
+	// Check that the directories of the file aren't full and that the file
+	// may be modified.
+	if err := r.managedCheckDirQuotas(siaPath, 1, false); err != nil {
+		return nil, err
+	}
+	if err := r.managedCheckFileLocks(siaPath); err != nil {
+		return nil, err
+	}
+	if err := r.managedCheckFileReadOnly(siaPath); err != nil {
+		return nil, err
+	}
+	fileNode, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if fileNode.HasPartialChunk() || fileNode.Size()%fileNode.ChunkSize() != 0 {
+		return nil, errors.Compose(errAppendUnaligned, fileNode.Close())
+	}
+	return fileNode, nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -218,6 +292,19 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 	}()
 
+	// Files which are encrypted with an external key are uploaded with the
+	// key that was provided for the upload. All other chunks are uploaded with
+	// the key the siafile holds for them.
+	var masterKey crypto.CipherKey
+	if fileNode.MasterKeyID() != "" {
+		if err := fileNode.VerifyMasterKey(up.CipherKey); err != nil {
+			return nil, err
+		}
+		masterKey = up.CipherKey
+	} else if (up.Append || up.Repair) && up.CipherKey != nil {
+		return nil, errors.New("a key can only be provided for files which are encrypted with an external key")
+	}
+
 	// Check if stream has at least one byte. No need to upload empty data.
 	peek := []byte{0}
 	_, err = io.ReadFull(reader, peek)
@@ -248,8 +335,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	// Read the chunks we want to upload one by one from the input stream using
 	// shards. A shard will signal completion after reading the input but
 	// before the upload is done.
+	//
+	// When appending, the first chunk is the one following the existing data.
+	var firstChunk uint64
+	if up.Append {
+		firstChunk = fileNode.Size() / fileNode.ChunkSize()
+	}
 	var chunks []*unfinishedUploadChunk
-	for chunkIndex := uint64(0); ; chunkIndex++ {
+	for chunkIndex := firstChunk; ; chunkIndex++ {
 		// Disrupt the upload by closing the reader and simulating losing
 		// connectivity during the upload.
 		if r.deps.Disrupt("DisruptUploadStream") {
@@ -270,6 +363,9 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		if err != nil {
 			return nil, errors.AddContext(err, "unable to fetch chunk for stream")
 		}
+		if masterKey != nil {
+			uuc.staticMasterKey = masterKey
+		}
 
 		// Create a new shard set it to be the source reader of the chunk.
 		ss := NewStreamShard(reader, peek)
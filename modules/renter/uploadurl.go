@@ -0,0 +1,313 @@
+package renter
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errInvalidURLScheme is returned if the URL of a URL upload doesn't use
+	// the http or https scheme.
+	errInvalidURLScheme = errors.New("url must use the http or https scheme")
+
+	// errForbiddenURLAddress is returned if the URL of a URL upload resolves
+	// to an address of the node itself or of a local network.
+	errForbiddenURLAddress = errors.New("url resolves to a local or private address")
+
+	// urlUploadAllowLoopback indicates whether the data of URL uploads may be
+	// fetched from loopback addresses. It is only allowed in testing builds
+	// since dev builds are also run as real nodes.
+	urlUploadAllowLoopback = build.Select(build.Var{
+		Standard: false,
+		Testnet:  false,
+		Dev:      false,
+		Testing:  true,
+	}).(bool)
+
+	// urlUploadForbiddenNets are the address ranges besides the loopback,
+	// link-local, multicast and unspecified addresses which the data of URL
+	// uploads can't be fetched from. IPv4-mapped IPv6 addresses are checked
+	// against the IPv4 ranges.
+	urlUploadForbiddenNets = parseCIDRs(
+		"0.0.0.0/8",          // "this" network
+		"10.0.0.0/8",         // private
+		"100.64.0.0/10",      // carrier-grade NAT
+		"172.16.0.0/12",      // private
+		"192.0.0.0/24",       // IETF protocol assignments
+		"192.168.0.0/16",     // private
+		"198.18.0.0/15",      // benchmarking
+		"240.0.0.0/4",        // reserved
+		"255.255.255.255/32", // limited broadcast
+		"64:ff9b::/96",       // NAT64
+		"64:ff9b:1::/48",     // local-use NAT64
+		"fc00::/7",           // unique local
+	)
+
+	// urlUploadClient is the client used to fetch the data of URL uploads. It
+	// has no overall timeout since the content might be large. The addresses
+	// are checked when dialing, after the DNS resolution and for every
+	// redirect, so that a server can't make the renter fetch data from its
+	// own network. A proxy isn't used since it would dial on the renter's
+	// behalf.
+	urlUploadClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: urlUploadResponseTimeout,
+				Control: urlUploadDialControl,
+			}).DialContext,
+			ResponseHeaderTimeout: urlUploadResponseTimeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return validateUploadURL(req.URL.String())
+		},
+	}
+)
+
+type (
+	// urlUpload tracks the progress of an upload for which the renter fetches
+	// the data from a URL.
+	urlUpload struct {
+		atomicFetched uint64
+
+		staticMaxSize   uint64
+		staticSiaPath   modules.SiaPath
+		staticStartTime time.Time
+		staticURL       string
+
+		completed bool
+		endTime   time.Time
+		err       error
+		size      uint64
+		mu        sync.Mutex
+	}
+
+	// urlUploadReader wraps the body of the response for a URL upload to
+	// track the progress of the upload and to enforce its maximum size.
+	urlUploadReader struct {
+		r io.Reader
+		u *urlUpload
+	}
+)
+
+// Read implements the io.Reader interface.
+func (ur *urlUploadReader) Read(b []byte) (int, error) {
+	n, err := ur.r.Read(b)
+	fetched := atomic.AddUint64(&ur.u.atomicFetched, uint64(n))
+	if fetched > ur.u.staticMaxSize {
+		return n, modules.ErrURLUploadTooLarge
+	}
+	return n, err
+}
+
+// info returns the URLUploadInfo of the upload.
+func (u *urlUpload) info() modules.URLUploadInfo {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	info := modules.URLUploadInfo{
+		MaxSize: u.staticMaxSize,
+		SiaPath: u.staticSiaPath,
+		Size:    u.size,
+		URL:     u.staticURL,
+
+		Completed: u.completed,
+		EndTime:   u.endTime,
+		Fetched:   atomic.LoadUint64(&u.atomicFetched),
+		StartTime: u.staticStartTime,
+	}
+	if u.err != nil {
+		info.Error = u.err.Error()
+	}
+	return info
+}
+
+// validateUploadURL checks that the URL of a URL upload is an absolute http or
+// https URL.
+func validateUploadURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.AddContext(err, "unable to parse url")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errInvalidURLScheme
+	}
+	if u.Host == "" {
+		return errors.New("url is missing a host")
+	}
+	return nil
+}
+
+// parseCIDRs parses a list of CIDR notation IP address ranges.
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			build.Critical("invalid cidr", cidr, err)
+			continue
+		}
+		nets = append(nets, ipnet)
+	}
+	return nets
+}
+
+// checkURLUploadIP returns an error if the data of a URL upload can't be
+// fetched from the provided IP address.
+func checkURLUploadIP(ip net.IP, allowLoopback bool) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() && allowLoopback {
+		return nil
+	}
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return errForbiddenURLAddress
+	}
+	for _, ipnet := range urlUploadForbiddenNets {
+		if ipnet.Contains(ip) {
+			return errForbiddenURLAddress
+		}
+	}
+	return nil
+}
+
+// urlUploadDialControl is the Control function of the dialer of the
+// urlUploadClient. It is called with the resolved address right before
+// connecting, so a DNS answer can't change the address after it was checked.
+func urlUploadDialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errForbiddenURLAddress
+	}
+	return checkURLUploadIP(ip, urlUploadAllowLoopback)
+}
+
+// UploadFromURL fetches the data from the provided HTTP(S) URL and uploads it
+// to the Sia network. The size of the upload is limited by maxSize, or by
+// DefaultURLUploadMaxSize if maxSize is zero. The server has to report the
+// size of the content, which is checked against the limit before fetching
+// it. If the content turns out to exceed the limit while it is being fetched,
+// the partially uploaded file is deleted.
+func (r *Renter) UploadFromURL(up modules.FileUploadParams, rawURL string, maxSize uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := validateUploadURL(rawURL); err != nil {
+		return err
+	}
+	if maxSize == 0 {
+		maxSize = DefaultURLUploadMaxSize
+	}
+
+	// Track the upload.
+	u := &urlUpload{
+		staticMaxSize:   maxSize,
+		staticSiaPath:   up.SiaPath,
+		staticStartTime: time.Now(),
+		staticURL:       rawURL,
+	}
+	r.urlUploadsMu.Lock()
+	r.pruneURLUploads()
+	r.urlUploads[up.SiaPath] = u
+	r.urlUploadsMu.Unlock()
+	defer func() {
+		u.mu.Lock()
+		u.completed = true
+		u.endTime = time.Now()
+		u.err = err
+		u.mu.Unlock()
+	}()
+
+	// Fetch the data.
+	req, err := http.NewRequestWithContext(r.tg.StopCtx(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return errors.AddContext(err, "unable to create request")
+	}
+	resp, err := urlUploadClient.Do(req)
+	if err != nil {
+		return errors.AddContext(err, "unable to fetch url")
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unable to fetch url: server responded with status %v", resp.Status)
+	}
+	if resp.ContentLength < 0 {
+		return modules.ErrURLUploadUnknownSize
+	}
+	u.mu.Lock()
+	u.size = uint64(resp.ContentLength)
+	u.mu.Unlock()
+	if uint64(resp.ContentLength) > maxSize {
+		return modules.ErrURLUploadTooLarge
+	}
+
+	// Upload the data.
+	err = r.UploadStreamFromReader(up, &urlUploadReader{r: resp.Body, u: u})
+	if errors.Contains(err, modules.ErrURLUploadTooLarge) {
+		err = errors.Compose(err, r.DeleteFile(up.SiaPath))
+	}
+	return err
+}
+
+// expired returns whether the upload was completed more than
+// urlUploadRetention ago.
+func (u *urlUpload) expired() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.completed && time.Since(u.endTime) > urlUploadRetention
+}
+
+// pruneURLUploads removes the expired URL uploads. The caller must hold the
+// urlUploadsMu.
+func (r *Renter) pruneURLUploads() {
+	for siaPath, u := range r.urlUploads {
+		if u.expired() {
+			delete(r.urlUploads, siaPath)
+		}
+	}
+}
+
+// URLUploads returns the renter's URL uploads which are in progress or were
+// completed within urlUploadRetention sorted by their start time, most recent
+// first.
+func (r *Renter) URLUploads() []modules.URLUploadInfo {
+	r.urlUploadsMu.Lock()
+	r.pruneURLUploads()
+	uploads := make([]*urlUpload, 0, len(r.urlUploads))
+	for _, u := range r.urlUploads {
+		uploads = append(uploads, u)
+	}
+	r.urlUploadsMu.Unlock()
+
+	infos := make([]modules.URLUploadInfo, 0, len(uploads))
+	for _, u := range uploads {
+		infos = append(infos, u.info())
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartTime.After(infos[j].StartTime)
+	})
+	return infos
+}
@@ -0,0 +1,180 @@
+package renter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestValidateUploadURL is a unit test for validateUploadURL.
+func TestValidateUploadURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"http://example.com/file", true},
+		{"https://example.com:8080/file?a=b", true},
+		{"ftp://example.com/file", false},
+		{"file:///etc/passwd", false},
+		{"example.com/file", false},
+		{"http:///file", false},
+		{"://", false},
+	}
+	for _, test := range tests {
+		if err := validateUploadURL(test.url); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid to be %v but got %v", test.url, test.valid, err)
+		}
+	}
+}
+
+// TestCheckURLUploadIP is a unit test for checkURLUploadIP.
+func TestCheckURLUploadIP(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"100.64.0.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:192.168.1.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::5db8:d822", false},
+		{"64:ff9b:1::1", false},
+		{"192.0.0.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"198.20.0.1", true},
+		{"240.0.0.1", false},
+		{"255.255.255.254", false},
+		{"255.255.255.255", false},
+	}
+	for _, test := range tests {
+		err := checkURLUploadIP(net.ParseIP(test.ip), false)
+		if (err == nil) != test.allowed {
+			t.Errorf("%v: expected allowed to be %v but got %v", test.ip, test.allowed, err)
+		}
+	}
+	if err := checkURLUploadIP(net.ParseIP("127.0.0.1"), true); err != nil {
+		t.Fatal("loopback should be allowed", err)
+	}
+}
+
+// TestURLUploadClientForbiddenAddress tests that the urlUploadClient doesn't
+// connect to forbidden addresses, neither directly nor after a redirect.
+func TestURLUploadClientForbiddenAddress(t *testing.T) {
+	t.Parallel()
+
+	_, err := urlUploadClient.Get("http://10.0.0.1/")
+	if err == nil || !strings.Contains(err.Error(), errForbiddenURLAddress.Error()) {
+		t.Fatal("expected request to be rejected", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+	_, err = urlUploadClient.Get(server.URL)
+	if err == nil || !strings.Contains(err.Error(), errForbiddenURLAddress.Error()) {
+		t.Fatal("expected redirect to be rejected", err)
+	}
+}
+
+// TestURLUploadReader is a unit test for the urlUploadReader.
+func TestURLUploadReader(t *testing.T) {
+	t.Parallel()
+
+	data := fastrand.Bytes(100)
+
+	// All the data is read and tracked.
+	u := &urlUpload{staticMaxSize: uint64(2 * len(data))}
+	read, err := ioutil.ReadAll(&urlUploadReader{r: bytes.NewReader(data), u: u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(read, data) || u.info().Fetched != uint64(len(data)) {
+		t.Fatal("wrong data or progress", u.info().Fetched)
+	}
+
+	// A maximum size equal to the size of the data is fine.
+	u = &urlUpload{staticMaxSize: uint64(len(data))}
+	_, err = ioutil.ReadAll(&urlUploadReader{r: bytes.NewReader(data), u: u})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A smaller maximum size causes an error.
+	u = &urlUpload{staticMaxSize: uint64(len(data) - 1)}
+	_, err = io.Copy(ioutil.Discard, &urlUploadReader{r: bytes.NewReader(data), u: u})
+	if !errors.Contains(err, modules.ErrURLUploadTooLarge) {
+		t.Fatal("expected ErrURLUploadTooLarge", err)
+	}
+}
+
+// TestURLUploadsPrune tests that completed URL uploads are forgotten once
+// they expire.
+func TestURLUploadsPrune(t *testing.T) {
+	t.Parallel()
+
+	newSiaPath := func(name string) modules.SiaPath {
+		siaPath, err := modules.NewSiaPath(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	r := &Renter{
+		urlUploads: map[modules.SiaPath]*urlUpload{
+			newSiaPath("running"): {staticSiaPath: newSiaPath("running")},
+			newSiaPath("recent"): {
+				staticSiaPath: newSiaPath("recent"),
+				completed:     true,
+				endTime:       time.Now(),
+			},
+			newSiaPath("expired"): {
+				staticSiaPath: newSiaPath("expired"),
+				completed:     true,
+				endTime:       time.Now().Add(-urlUploadRetention - time.Second),
+			},
+		},
+	}
+	uploads := r.URLUploads()
+	if len(uploads) != 2 || len(r.urlUploads) != 2 {
+		t.Fatal("expected 2 uploads but got", len(uploads), len(r.urlUploads))
+	}
+	for _, u := range uploads {
+		if u.SiaPath.Equals(newSiaPath("expired")) {
+			t.Fatal("expired upload wasn't pruned")
+		}
+	}
+}
@@ -70,6 +70,7 @@ type (
 		atomicAccountBalanceCheckRunning uint64         // used for a sanity check
 		atomicCache                      unsafe.Pointer // points to a workerCache object
 		atomicCacheUpdating              uint64         // ensures only one cache update happens at a time
+		atomicDownloadStalls             uint64         // number of stalled piece downloads from the host
 		atomicPriceTable                 unsafe.Pointer // points to a workerPriceTable object
 		atomicPriceTableUpdateRunning    uint64         // used for a sanity check
 
@@ -98,9 +99,8 @@ type (
 		// The staticAccount represent the renter's ephemeral account on the
 		// host. It keeps track of the available balance in the account, the
 		// worker has a refill mechanism that keeps the account balance filled
-		// up until the staticBalanceTarget.
-		staticAccount       *account
-		staticBalanceTarget types.Currency
+		// up until the balance target in the worker cache.
+		staticAccount *account
 
 		// The loop state contains information about the worker loop. It is
 		// mostly atomic variables that the worker uses to ratelimit the
@@ -220,21 +220,11 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 		return nil, errors.AddContext(err, "could not open account")
 	}
 
-	// set the balance target to 1SC
-	//
-	// TODO: check that the balance target  makes sense in function of the
-	// amount of MDM programs it can run with that amount of money
-	balanceTarget := types.SiacoinPrecision
-	if r.deps.Disrupt("DisableFunding") {
-		balanceTarget = types.ZeroCurrency
-	}
-
 	w := &worker{
 		staticHostPubKey:    hostPubKey,
 		staticHostPubKeyStr: hostPubKey.String(),
 
-		staticAccount:       account,
-		staticBalanceTarget: balanceTarget,
+		staticAccount: account,
 
 		staticRegistryCache: newRegistryCache(registryCacheSize),
 
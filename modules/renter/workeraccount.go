@@ -28,12 +28,15 @@ const (
 	// WithdrawalMessage's expiry height.
 	withdrawalValidityPeriod = 6
 
-	// fundAccountGougingPercentageThreshold is the percentage threshold, in
-	// relation to the allowance, at which we consider the cost of funding an
-	// account to be too expensive. E.g. the cost of funding the account as many
-	// times as necessary to spend the total allowance should never exceed 1% of
-	// the total allowance.
-	fundAccountGougingPercentageThreshold = .01
+	// defaultFundAccountGougingFraction is the fraction of the allowance at
+	// which we consider the cost of funding an account to be too expensive.
+	// E.g. the cost of funding the account as many times as necessary to spend
+	// the total allowance should never exceed 1% of the total allowance.
+	defaultFundAccountGougingFraction = .01
+
+	// maxRecentAccountRefills is the number of recent refill attempts that an
+	// account keeps track of for monitoring purposes.
+	maxRecentAccountRefills = 10
 )
 
 const (
@@ -50,9 +53,14 @@ const (
 	categorySnapshotUpload
 	categorySubscription
 	categoryUpload
+	categoryProgram
 )
 
 var (
+	// errAccountRefillThresholdTooHigh is returned if the account refill
+	// threshold is higher than the max balance at risk.
+	errAccountRefillThresholdTooHigh = errors.New("account refill threshold can't be higher than the account max balance at risk")
+
 	// accountIdleCheckFrequency establishes how frequently the sync function
 	// should check whether the worker is idle. A relatively high frequency is
 	// okay, because this function only runs while the worker is frozen and
@@ -128,6 +136,10 @@ type (
 		// actions are downloads, registry reads, registry writes, etc.
 		spending spendingDetails
 
+		// Refill tracking. Only the most recent refills are kept.
+		numRefills    uint64
+		recentRefills []modules.WorkerAccountRefill
+
 		// Error tracking.
 		recentErr         error
 		recentErrTime     time.Time
@@ -172,6 +184,7 @@ type (
 		snapshotUploads   types.Currency
 		subscriptions     types.Currency
 		uploads           types.Currency
+		programs          types.Currency
 	}
 
 	// spendingCategory defines an enum that represent a category in the
@@ -206,6 +219,8 @@ func (s *spendingDetails) update(category spendingCategory, amount types.Currenc
 		s.subscriptions = s.subscriptions.Add(amount)
 	case categoryUpload:
 		s.uploads = s.uploads.Add(amount)
+	case categoryProgram:
+		s.programs = s.programs.Add(amount)
 	default:
 		build.Critical("category is not handled, developer error")
 	}
@@ -444,8 +459,16 @@ func (a *account) managedStatus() modules.WorkerAccountStatus {
 	}
 
 	return modules.WorkerAccountStatus{
-		AvailableBalance: a.availableBalance(),
-		NegativeBalance:  a.negativeBalance,
+		AvailableBalance:   a.availableBalance(),
+		NegativeBalance:    a.negativeBalance,
+		PendingDeposits:    a.pendingDeposits,
+		PendingWithdrawals: a.pendingWithdrawals,
+
+		BalanceDriftPositive: a.balanceDriftPositive,
+		BalanceDriftNegative: a.balanceDriftNegative,
+
+		NumRefills:    a.numRefills,
+		RecentRefills: append([]modules.WorkerAccountRefill{}, a.recentRefills...),
 
 		RecentErr:         recentErrStr,
 		RecentErrTime:     a.recentErrTime,
@@ -461,6 +484,26 @@ func (a *account) managedTrackDeposit(amount types.Currency) {
 	a.pendingDeposits = a.pendingDeposits.Add(amount)
 }
 
+// managedTrackRefill keeps track of a refill attempt of the given amount and
+// its outcome.
+func (a *account) managedTrackRefill(amount types.Currency, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	refill := modules.WorkerAccountRefill{
+		Amount: amount,
+		Time:   time.Now(),
+	}
+	if err != nil {
+		refill.Error = err.Error()
+	}
+	a.numRefills++
+	a.recentRefills = append(a.recentRefills, refill)
+	if len(a.recentRefills) > maxRecentAccountRefills {
+		a.recentRefills = a.recentRefills[len(a.recentRefills)-maxRecentAccountRefills:]
+	}
+}
+
 // managedTrackWithdrawal keeps track of pending withdrawals by adding the given
 // amount to the 'pendingWithdrawals' field.
 func (a *account) managedTrackWithdrawal(amount types.Currency) {
@@ -490,6 +533,7 @@ func (a *account) trackSpending(category spendingCategory, amount types.Currency
 
 	// update the spending metrics
 	a.spending.update(category, amount)
+	a.staticRenter.staticStats.managedAddSpending(category, amount)
 
 	// every time we update we write the account to disk
 	err := a.persist()
@@ -607,7 +651,7 @@ func (w *worker) managedNeedsToRefillAccount() bool {
 		return false
 	}
 
-	return w.staticAccount.managedNeedsToRefill(w.staticBalanceTarget.Div64(2))
+	return w.staticAccount.managedNeedsToRefill(w.staticCache().staticAccountRefillThreshold)
 }
 
 // managedNeedsToSyncAccountBalanceToHost returns true if the renter needs to
@@ -631,11 +675,15 @@ func (w *worker) managedRefillAccount() {
 	if w.renter.deps.Disrupt("DisableFunding") {
 		return // don't refill account
 	}
-	// The account balance dropped to below half the balance target, refill. Use
+	// The account balance dropped to below the refill threshold, refill. Use
 	// the max expected balance when refilling to avoid exceeding any host
-	// maximums.
+	// maximums or the balance target.
+	balanceTarget := w.staticCache().staticAccountBalanceTarget
 	balance := w.staticAccount.managedMaxExpectedBalance()
-	amount := w.staticBalanceTarget.Sub(balance)
+	if balance.Cmp(balanceTarget) >= 0 {
+		return // the balance target was lowered
+	}
+	amount := balanceTarget.Sub(balance)
 	pt := w.staticPriceTable().staticPriceTable
 
 	// If the target amount is larger than the remaining money, adjust the
@@ -661,6 +709,7 @@ func (w *worker) managedRefillAccount() {
 		// need to be refilled until the worker has spent up the funds in the
 		// account.
 		w.staticAccount.managedCommitDeposit(amount, err == nil)
+		w.staticAccount.managedTrackRefill(amount, err)
 
 		// Track the outcome of the account refill - this ensures a proper
 		// working of the maintenance cooldown mechanism.
@@ -695,7 +744,8 @@ func (w *worker) managedRefillAccount() {
 	}()
 
 	// check the current price table for gouging errors
-	err = checkFundAccountGouging(w.staticPriceTable().staticPriceTable, w.staticCache().staticRenterAllowance, w.staticBalanceTarget)
+	cache := w.staticCache()
+	err = checkFundAccountGouging(w.staticPriceTable().staticPriceTable, cache.staticRenterAllowance, balanceTarget, cache.staticGougingThresholds.fundAccount)
 	if err != nil {
 		return
 	}
@@ -887,14 +937,40 @@ func (w *worker) staticHostAccountBalance() (_ types.Currency, err error) {
 	return resp.Balance, nil
 }
 
+// accountRefillPolicy returns the balance target and refill threshold for
+// ephemeral accounts given the corresponding renter settings. Zero values are
+// replaced by their defaults, the default refill threshold is half the balance
+// target.
+func accountRefillPolicy(maxBalanceAtRisk, refillThreshold types.Currency) (target, threshold types.Currency) {
+	target = maxBalanceAtRisk
+	if target.IsZero() {
+		target = modules.DefaultAccountMaxBalanceAtRisk
+	}
+	threshold = refillThreshold
+	if threshold.IsZero() {
+		threshold = target.Div64(2)
+	}
+	return target, threshold
+}
+
+// managedAccountRefillPolicy returns the balance target and refill threshold
+// for the renter's ephemeral accounts.
+func (r *Renter) managedAccountRefillPolicy() (target, threshold types.Currency) {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return accountRefillPolicy(r.persist.AccountMaxBalanceAtRisk, r.persist.AccountRefillThreshold)
+}
+
 // checkFundAccountGouging verifies the cost of funding an ephemeral account on
 // the host is reasonable, if deemed unreasonable we will block the refill and
-// the worker will eventually be put into cooldown.
-func checkFundAccountGouging(pt modules.RPCPriceTable, allowance modules.Allowance, targetBalance types.Currency) error {
+// the worker will eventually be put into cooldown. The fraction is the fraction
+// of the allowance which may be spent on funding the account, 0 disables the
+// check.
+func checkFundAccountGouging(pt modules.RPCPriceTable, allowance modules.Allowance, targetBalance types.Currency, fraction float64) error {
 	// If there is no allowance, price gouging checks have to be disabled,
 	// because there is no baseline for understanding what might count as price
 	// gouging.
-	if allowance.Funds.IsZero() {
+	if allowance.Funds.IsZero() || fraction == 0 {
 		return nil
 	}
 
@@ -914,8 +990,8 @@ func checkFundAccountGouging(pt modules.RPCPriceTable, allowance modules.Allowan
 	// The cost of funding is considered too expensive if the total cost is
 	// above a certain % of the allowance.
 	totalFundAccountCost := pt.FundAccountCost.Mul64(numRefills)
-	if totalFundAccountCost.Cmp(allowance.Funds.MulFloat(fundAccountGougingPercentageThreshold)) > 0 {
-		return fmt.Errorf("fund account cost %v is considered too high, the total cost of refilling the account to spend the total allowance exceeds %v%% of the allowance - price gouging protection enabled", pt.FundAccountCost, fundAccountGougingPercentageThreshold)
+	if totalFundAccountCost.Cmp(allowance.Funds.MulFloat(fraction)) > 0 {
+		return fmt.Errorf("fund account cost %v is considered too high, the total cost of refilling the account to spend the total allowance exceeds %v%% of the allowance - price gouging protection enabled", pt.FundAccountCost, fraction*100)
 	}
 
 	return nil
@@ -58,6 +58,8 @@ func TestAccount(t *testing.T) {
 	t.Run("CheckFundAccountGouging", testAccountCheckFundAccountGouging)
 	t.Run("Constants", testAccountConstants)
 	t.Run("MinMaxExpectedBalance", testAccountMinAndMaxExpectedBalance)
+	t.Run("RefillPolicy", testAccountRefillPolicy)
+	t.Run("TrackRefill", testAccountTrackRefill)
 	t.Run("TrackSpending", testAccountTrackSpending)
 	t.Run("SyncBalance", testAccountSyncBalance)
 
@@ -115,7 +117,7 @@ func testAccountCheckFundAccountGouging(t *testing.T) {
 
 	// verify happy case
 	pt := newDefaultPriceTable()
-	err := checkFundAccountGouging(pt, allowance, targetBalance)
+	err := checkFundAccountGouging(pt, allowance, targetBalance, defaultFundAccountGougingFraction)
 	if err != nil {
 		t.Fatal("unexpected price gouging failure")
 	}
@@ -125,7 +127,7 @@ func testAccountCheckFundAccountGouging(t *testing.T) {
 	// value for the given parameters (1000SC funds and TB of 1SC)
 	pt = newDefaultPriceTable()
 	pt.FundAccountCost = types.SiacoinPrecision.MulFloat(0.075)
-	err = checkFundAccountGouging(pt, allowance, targetBalance)
+	err = checkFundAccountGouging(pt, allowance, targetBalance, defaultFundAccountGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "fund account cost") {
 		t.Fatalf("expected fund account cost gouging error, instead error was '%v'", err)
 	}
@@ -252,6 +254,62 @@ func testAccountSyncBalance(t *testing.T) {
 	}
 }
 
+// testAccountRefillPolicy is a small unit test that verifies the defaults of
+// the account refill policy.
+func testAccountRefillPolicy(t *testing.T) {
+	t.Parallel()
+
+	// no settings should result in the defaults
+	target, threshold := accountRefillPolicy(types.ZeroCurrency, types.ZeroCurrency)
+	if !target.Equals(modules.DefaultAccountMaxBalanceAtRisk) {
+		t.Fatal("unexpected target", target)
+	}
+	if !threshold.Equals(modules.DefaultAccountMaxBalanceAtRisk.Div64(2)) {
+		t.Fatal("unexpected threshold", threshold)
+	}
+
+	// the default threshold should follow the target
+	maxBalance := types.SiacoinPrecision.Mul64(10)
+	target, threshold = accountRefillPolicy(maxBalance, types.ZeroCurrency)
+	if !target.Equals(maxBalance) || !threshold.Equals(maxBalance.Div64(2)) {
+		t.Fatal("unexpected policy", target, threshold)
+	}
+
+	// a custom threshold should be used as is
+	refillThreshold := types.SiacoinPrecision.Mul64(9)
+	target, threshold = accountRefillPolicy(maxBalance, refillThreshold)
+	if !target.Equals(maxBalance) || !threshold.Equals(refillThreshold) {
+		t.Fatal("unexpected policy", target, threshold)
+	}
+}
+
+// testAccountTrackRefill is a small unit test that verifies the account keeps
+// track of its most recent refills.
+func testAccountTrackRefill(t *testing.T) {
+	t.Parallel()
+
+	a := new(account)
+	for i := 0; i < maxRecentAccountRefills+1; i++ {
+		a.managedTrackRefill(types.NewCurrency64(uint64(i)), nil)
+	}
+	a.managedTrackRefill(types.NewCurrency64(1), errors.New("failure"))
+
+	status := a.managedStatus()
+	if status.NumRefills != maxRecentAccountRefills+2 {
+		t.Fatal("unexpected number of refills", status.NumRefills)
+	}
+	if len(status.RecentRefills) != maxRecentAccountRefills {
+		t.Fatal("unexpected number of recent refills", len(status.RecentRefills))
+	}
+	if !status.RecentRefills[0].Amount.Equals64(2) {
+		t.Fatal("oldest refills should have been dropped", status.RecentRefills[0].Amount)
+	}
+	last := status.RecentRefills[len(status.RecentRefills)-1]
+	if last.Error != "failure" || last.Time.IsZero() {
+		t.Fatal("unexpected refill", last)
+	}
+}
+
 // testAccountTrackSpending is a small unit test that verifies the functionality
 // of the method 'trackSpending' on the account
 func testAccountTrackSpending(t *testing.T) {
@@ -274,6 +332,7 @@ func testAccountTrackSpending(t *testing.T) {
 	// create an account
 	a := new(account)
 	a.staticFile = f
+	a.staticRenter = &Renter{staticStats: &renterStats{}}
 
 	// verify initial state
 	hasting := types.NewCurrency64(1)
@@ -310,6 +369,12 @@ func testAccountTrackSpending(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 
+	// verify the spending is reflected in the renter's stats
+	stats := a.staticRenter.staticStats.managedStats(time.Time{})
+	if !stats.Spending.Downloads.Equals(hasting.Mul64(1)) || !stats.Spending.Uploads.Equals(hasting.Mul64(9)) {
+		t.Fatal("spending not tracked in the renter's stats", stats.Spending)
+	}
+
 	// check category sanity check
 	func() {
 		defer func() {
@@ -490,7 +555,7 @@ func testWorkerAccountHostAccountBalance(t *testing.T, wt *workerTester) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if !balance.Equals(w.staticBalanceTarget) {
+	if !balance.Equals(w.staticCache().staticAccountBalanceTarget) {
 		t.Fatal(err)
 	}
 }
@@ -512,7 +577,7 @@ func testWorkerAccountSyncAccountBalanceToHostCritical(t *testing.T, wt *workerT
 	}
 
 	// track a deposit to simulate an ongoing fund
-	w.staticAccount.managedTrackDeposit(w.staticBalanceTarget)
+	w.staticAccount.managedTrackDeposit(w.staticCache().staticAccountBalanceTarget)
 
 	// trigger the account balance sync and expect it to panic
 	defer func() {
@@ -103,6 +103,7 @@ type (
 		SpendingSnapshotUploads   types.Currency
 		SpendingSubscriptions     types.Currency
 		SpendingUploads           types.Currency
+		SpendingPrograms          types.Currency
 	}
 
 	// accountPersistenceV150 is how the account persistence struct looked
@@ -161,6 +162,7 @@ func (a *account) persist() error {
 		SpendingSnapshotUploads:   a.spending.snapshotUploads,
 		SpendingSubscriptions:     a.spending.subscriptions,
 		SpendingUploads:           a.spending.uploads,
+		SpendingPrograms:          a.spending.programs,
 	}
 
 	_, err := a.staticFile.WriteAt(accountData.bytes(), a.staticOffset)
@@ -235,7 +237,8 @@ func (am *accountManager) managedOpenAccount(hostKey types.SiaPublicKey) (acc *a
 		staticFile:   am.staticFile,
 		staticOffset: int64(offset),
 
-		staticReady: make(chan struct{}),
+		staticReady:  make(chan struct{}),
+		staticRenter: am.staticRenter,
 	}
 	am.accounts[hostKey.String()] = acc
 	am.mu.Unlock()
@@ -567,6 +570,7 @@ func (am *accountManager) readAccountAt(offset int64) (*account, error) {
 			snapshotUploads:   accountData.SpendingSnapshotUploads,
 			subscriptions:     accountData.SpendingSubscriptions,
 			uploads:           accountData.SpendingUploads,
+			programs:          accountData.SpendingPrograms,
 		},
 
 		staticReady:  make(chan struct{}),
@@ -574,6 +578,7 @@ func (am *accountManager) readAccountAt(offset int64) (*account, error) {
 
 		staticOffset: offset,
 		staticFile:   am.staticFile,
+		staticRenter: am.staticRenter,
 	}
 	close(acc.staticReady)
 	return acc, nil
@@ -34,6 +34,18 @@ type (
 		staticHostMuxAddress  string
 		staticSynced          bool
 
+		// The balance target and refill threshold of the worker's ephemeral
+		// account.
+		staticAccountBalanceTarget   types.Currency
+		staticAccountRefillThreshold types.Currency
+
+		// The maximum costs of single operations on the worker's host.
+		staticCostCeilings costCeilings
+
+		// The thresholds of the price gouging checks with the defaults
+		// applied.
+		staticGougingThresholds gougingThresholds
+
 		staticLastUpdate time.Time
 	}
 )
@@ -70,6 +82,15 @@ func (w *worker) managedUpdateCache() {
 		return
 	}
 
+	// Grab the account refill policy.
+	//
+	// TODO: check that the balance target makes sense in function of the
+	// amount of MDM programs it can run with that amount of money
+	balanceTarget, refillThreshold := w.renter.managedAccountRefillPolicy()
+	if w.renter.deps.Disrupt("DisableFunding") {
+		balanceTarget, refillThreshold = types.ZeroCurrency, types.ZeroCurrency
+	}
+
 	// Create the cache object.
 	newCache := &workerCache{
 		staticBlockHeight:     w.renter.cs.Height(),
@@ -80,6 +101,12 @@ func (w *worker) managedUpdateCache() {
 		staticRenterAllowance: w.renter.hostContractor.Allowance(),
 		staticSynced:          w.renter.cs.Synced(),
 
+		staticAccountBalanceTarget:   balanceTarget,
+		staticAccountRefillThreshold: refillThreshold,
+
+		staticCostCeilings:      w.renter.managedCostCeilings(),
+		staticGougingThresholds: w.renter.managedGougingThresholds(),
+
 		staticLastUpdate: time.Now(),
 	}
 
@@ -0,0 +1,68 @@
+package renter
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// costCeilings contains the maximum amounts the renter is willing to pay a
+// single host for an operation. A zero ceiling means there is no ceiling.
+type costCeilings struct {
+	download      types.Currency
+	registryRead  types.Currency
+	registryWrite types.Currency
+	upload        types.Currency
+}
+
+// managedCostCeilings returns the cost ceilings from the renter's settings.
+func (r *Renter) managedCostCeilings() costCeilings {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return costCeilings{
+		download:      r.persist.MaxDownloadCost,
+		registryRead:  r.persist.MaxRegistryReadCost,
+		registryWrite: r.persist.MaxRegistryWriteCost,
+		upload:        r.persist.MaxUploadCost,
+	}
+}
+
+// checkCost returns an error wrapping modules.ErrCostCeilingExceeded if the
+// cost of an operation within the given spending category exceeds the
+// corresponding ceiling.
+func (cc costCeilings) checkCost(category spendingCategory, cost types.Currency) error {
+	var ceiling types.Currency
+	var operation string
+	switch category {
+	case categoryDownload, categoryRepairDownload, categorySnapshotDownload:
+		ceiling, operation = cc.download, "download"
+	case categoryRegistryRead:
+		ceiling, operation = cc.registryRead, "registry read"
+	case categoryRegistryWrite:
+		ceiling, operation = cc.registryWrite, "registry write"
+	case categoryRepairUpload, categorySnapshotUpload, categoryUpload:
+		ceiling, operation = cc.upload, "upload"
+	default:
+		return nil
+	}
+	if ceiling.IsZero() || cost.Cmp(ceiling) <= 0 {
+		return nil
+	}
+	return errors.AddContext(modules.ErrCostCeilingExceeded, fmt.Sprintf("%v cost of %v is above the ceiling of %v", operation, cost.HumanString(), ceiling.HumanString()))
+}
+
+// uploadSectorCost returns the cost of uploading a full sector to a host with
+// the given settings and storing it until the end height.
+func uploadSectorCost(hostSettings modules.HostExternalSettings, blockHeight, endHeight types.BlockHeight) types.Currency {
+	var duration types.BlockHeight
+	if endHeight > blockHeight {
+		duration = endHeight - blockHeight
+	}
+	blockBytes := types.NewCurrency64(modules.SectorSize * uint64(duration))
+	storageCost := hostSettings.StoragePrice.Mul(blockBytes)
+	bandwidthCost := hostSettings.UploadBandwidthPrice.Mul64(modules.SectorSize)
+	return storageCost.Add(bandwidthCost)
+}
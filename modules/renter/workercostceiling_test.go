@@ -0,0 +1,71 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCostCeilingsCheckCost is a unit test for checkCost.
+func TestCostCeilingsCheckCost(t *testing.T) {
+	t.Parallel()
+
+	cc := costCeilings{
+		download:      types.NewCurrency64(10),
+		registryRead:  types.NewCurrency64(20),
+		registryWrite: types.NewCurrency64(30),
+	}
+	tests := []struct {
+		category spendingCategory
+		cost     uint64
+		exceeded bool
+	}{
+		{categoryDownload, 10, false},
+		{categoryDownload, 11, true},
+		{categoryRepairDownload, 11, true},
+		{categorySnapshotDownload, 11, true},
+		{categoryRegistryRead, 20, false},
+		{categoryRegistryRead, 21, true},
+		{categoryRegistryWrite, 30, false},
+		{categoryRegistryWrite, 31, true},
+		{categoryUpload, 1000, false}, // no ceiling
+		{categorySubscription, 1000, false},
+	}
+	for i, test := range tests {
+		err := cc.checkCost(test.category, types.NewCurrency64(test.cost))
+		if errors.Contains(err, modules.ErrCostCeilingExceeded) != test.exceeded {
+			t.Errorf("%v: unexpected error %v", i, err)
+		}
+	}
+
+	// Set an upload ceiling.
+	cc.upload = types.NewCurrency64(100)
+	if err := cc.checkCost(categoryUpload, types.NewCurrency64(101)); !errors.Contains(err, modules.ErrCostCeilingExceeded) {
+		t.Fatal("unexpected error", err)
+	}
+	if err := cc.checkCost(categoryRepairUpload, types.NewCurrency64(100)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestUploadSectorCost is a unit test for uploadSectorCost.
+func TestUploadSectorCost(t *testing.T) {
+	t.Parallel()
+
+	hes := modules.HostExternalSettings{
+		StoragePrice:         types.NewCurrency64(2),
+		UploadBandwidthPrice: types.NewCurrency64(3),
+	}
+	expected := types.NewCurrency64(modules.SectorSize * 10 * 2).Add(types.NewCurrency64(modules.SectorSize * 3))
+	if cost := uploadSectorCost(hes, 90, 100); !cost.Equals(expected) {
+		t.Fatal("wrong cost", cost, expected)
+	}
+	// Past the end height only the bandwidth is paid for.
+	expected = types.NewCurrency64(modules.SectorSize * 3)
+	if cost := uploadSectorCost(hes, 110, 100); !cost.Equals(expected) {
+		t.Fatal("wrong cost", cost, expected)
+	}
+}
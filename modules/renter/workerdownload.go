@@ -5,6 +5,7 @@ package renter
 // coordinating resource management between the workers operating on a chunk.
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 
@@ -14,12 +15,12 @@ import (
 )
 
 const (
-	// downloadGougingFractionDenom sets the fraction to 1/4 because the renter
-	// should have enough money to download at least a fraction of the amount of
-	// data they intend to download. In practice, this ends up being a farily
-	// weak gouging filter because a massive portion of the allowance tends to
-	// be assigned to storage, and this does not account for that.
-	downloadGougingFractionDenom = 4
+	// defaultDownloadGougingFraction sets the fraction to 1/4 because the
+	// renter should have enough money to download at least a fraction of the
+	// amount of data they intend to download. In practice, this ends up being a
+	// farily weak gouging filter because a massive portion of the allowance
+	// tends to be assigned to storage, and this does not account for that.
+	defaultDownloadGougingFraction = 0.25
 )
 
 // segmentsForRecovery calculates the first segment and how many segments we
@@ -54,13 +55,15 @@ func sectorOffsetAndLength(chunkFetchOffset, chunkFetchLength uint64, rs modules
 
 // checkDownloadGouging looks at the current renter allowance and the active
 // settings for a host and determines whether a backup fetch should be halted
-// due to price gouging.
+// due to price gouging. The fraction is the fraction of the expected download
+// which the allowance has to cover, 0 disables the check against the
+// allowance funds.
 //
 // NOTE: Currently this function treats all downloads being the stream download
 // size and assumes that data is actually being appended to the host. As the
 // worker gains more modification actions on the host, this check can be split
 // into different checks that vary based on the operation being performed.
-func checkDownloadGouging(allowance modules.Allowance, pt *modules.RPCPriceTable) error {
+func checkDownloadGouging(allowance modules.Allowance, pt *modules.RPCPriceTable, fraction float64) error {
 	// Check whether the base RPC price is too high.
 	rpcCost := modules.MDMReadCost(pt, modules.StreamDownloadSize)
 	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(rpcCost) < 0 {
@@ -90,7 +93,7 @@ func checkDownloadGouging(allowance modules.Allowance, pt *modules.RPCPriceTable
 	singleDownloadCost := rpcCost.Add(pt.DownloadBandwidthCost.Mul64(modules.StreamDownloadSize))
 	fullCostPerByte := singleDownloadCost.Div64(modules.StreamDownloadSize)
 	allowanceDownloadCost := fullCostPerByte.Mul64(allowance.ExpectedDownload)
-	reducedCost := allowanceDownloadCost.Div64(downloadGougingFractionDenom)
+	reducedCost := allowanceDownloadCost.MulFloat(fraction)
 	if reducedCost.Cmp(allowance.Funds) > 0 {
 		errStr := fmt.Sprintf("combined download pricing of host yields %v, which is more than the renter is willing to pay for the download: %v - price gouging protection enabled", reducedCost, allowance.Funds)
 		return errors.New(errStr)
@@ -122,7 +125,8 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 
 	// Before performing the download, check for price gouging.
 	allowance := w.renter.hostContractor.Allowance()
-	err := checkDownloadGouging(allowance, &w.staticPriceTable().staticPriceTable)
+	gouging := w.staticCache().staticGougingThresholds
+	err := checkDownloadGouging(allowance, &w.staticPriceTable().staticPriceTable, gouging.download)
 	if err != nil {
 		w.renter.log.Debugln("worker downloader is not being used because price gouging was detected:", err)
 		udc.managedUnregisterWorker(w)
@@ -130,10 +134,16 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	}
 
 	// Fetch the sector. If fetching the sector fails, the worker needs to be
-	// unregistered with the chunk.
+	// unregistered with the chunk. The fetch is watched for stalls and
+	// canceled if another worker can take over.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
 	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	ctx, cancel := context.WithCancel(w.renter.tg.StopCtx())
+	defer cancel()
+	done := make(chan struct{})
+	go w.threadedWatchPieceDownload(udc, cancel, done)
+	pieceData, err := w.ReadSectorLowPrio(ctx, udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	close(done)
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
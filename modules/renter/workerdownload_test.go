@@ -138,7 +138,7 @@ func TestCheckDownloadGouging(t *testing.T) {
 		// Funds is set such that the tests come out to an easy, round number.
 		// One siacoin is multiplied by the number of elements that are checked
 		// for gouging, and then divided by the gounging denominator.
-		Funds: types.SiacoinPrecision.Mul64(3).Div64(downloadGougingFractionDenom).Sub(oneCurrency),
+		Funds: types.SiacoinPrecision.Mul64(3).MulFloat(defaultDownloadGougingFraction).Sub(oneCurrency),
 
 		ExpectedDownload: modules.StreamDownloadSize, // 1 stream download operation.
 	}
@@ -153,7 +153,7 @@ func TestCheckDownloadGouging(t *testing.T) {
 		DownloadBandwidthCost: types.SiacoinPrecision.Div64(modules.StreamDownloadSize),
 	}
 
-	err := checkDownloadGouging(minAllowance, minPriceTable)
+	err := checkDownloadGouging(minAllowance, minPriceTable, defaultDownloadGougingFraction)
 	if err == nil {
 		t.Fatal("expecting price gouging check to fail:", err)
 	}
@@ -161,19 +161,19 @@ func TestCheckDownloadGouging(t *testing.T) {
 	// Drop the host prices one field at a time.
 	newPriceTable := minPriceTable
 	newPriceTable.ReadBaseCost = minPriceTable.ReadBaseCost.Mul64(100).Div64(101)
-	err = checkDownloadGouging(minAllowance, newPriceTable)
+	err = checkDownloadGouging(minAllowance, newPriceTable, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
 	newPriceTable = minPriceTable
 	newPriceTable.DownloadBandwidthCost = minPriceTable.DownloadBandwidthCost.Mul64(100).Div64(101)
-	err = checkDownloadGouging(minAllowance, newPriceTable)
+	err = checkDownloadGouging(minAllowance, newPriceTable, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
 	newPriceTable = minPriceTable
 	newPriceTable.ReadLengthCost = minPriceTable.ReadLengthCost.Mul64(100).Div64(101)
-	err = checkDownloadGouging(minAllowance, newPriceTable)
+	err = checkDownloadGouging(minAllowance, newPriceTable, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -189,7 +189,7 @@ func TestCheckDownloadGouging(t *testing.T) {
 	maxAllowance.MaxUploadBandwidthPrice = oneCurrency
 
 	// The max allowance should have no issues with price gouging.
-	err = checkDownloadGouging(maxAllowance, minPriceTable)
+	err = checkDownloadGouging(maxAllowance, minPriceTable, defaultDownloadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -197,7 +197,7 @@ func TestCheckDownloadGouging(t *testing.T) {
 	// Should fail if the MaxRPCPrice is dropped.
 	failAllowance := maxAllowance
 	failAllowance.MaxRPCPrice = types.SiacoinPrecision.Sub(oneCurrency)
-	err = checkDownloadGouging(failAllowance, minPriceTable)
+	err = checkDownloadGouging(failAllowance, minPriceTable, defaultDownloadGougingFraction)
 	if err == nil {
 		t.Fatal("expecting price gouging check to fail")
 	}
@@ -205,7 +205,7 @@ func TestCheckDownloadGouging(t *testing.T) {
 	// Should fail if the MaxDownloadBandwidthPrice is dropped.
 	failAllowance = maxAllowance
 	failAllowance.MaxDownloadBandwidthPrice = minPriceTable.DownloadBandwidthCost.Sub(oneCurrency)
-	err = checkDownloadGouging(failAllowance, minPriceTable)
+	err = checkDownloadGouging(failAllowance, minPriceTable, defaultDownloadGougingFraction)
 	if err == nil {
 		t.Fatal("expecting price gouging check to fail")
 	}
@@ -19,6 +19,12 @@ var (
 	ErrJobDiscarded = errors.New("job is being discarded")
 )
 
+const (
+	// maxRecentJobTimes is the number of recent job times that a job queue
+	// keeps track of for monitoring purposes.
+	maxRecentJobTimes = 10
+)
+
 type (
 	// jobGeneric implements the basic functionality for a job.
 	jobGeneric struct {
@@ -50,6 +56,8 @@ type (
 		consecutiveFailures uint64
 		recentErr           error
 		recentErrTime       time.Time
+		recentJobTimes      []time.Duration
+		timeoutStats        map[uint64]*jobTimeoutStats
 
 		staticWorkerObj *worker // name conflict with staticWorker method
 		mu              sync.Mutex
@@ -83,6 +91,10 @@ type (
 		// provided error.
 		callDiscardAll(error)
 
+		// callJobTimeout returns the adaptive timeout for a job of the given
+		// class.
+		callJobTimeout(uint64) time.Duration
+
 		// callReportFailure should be called on the queue every time that a job
 		// fails, and include the error associated with the failure.
 		callReportFailure(error)
@@ -94,6 +106,10 @@ type (
 		// callStatus returns the status of the queue
 		callStatus() workerJobQueueStatus
 
+		// callUpdateJobTimeout should be called on the queue every time that a
+		// job finishes to update the adaptive timeout of the job's class.
+		callUpdateJobTimeout(uint64, time.Duration, error)
+
 		// staticWorker will return the worker of the job queue.
 		staticWorker() *worker
 	}
@@ -105,6 +121,7 @@ type (
 		consecutiveFailures uint64
 		recentErr           error
 		recentErrTime       time.Time
+		recentJobTimes      []time.Duration
 	}
 )
 
@@ -262,6 +279,16 @@ func (jq *jobGenericQueue) callStatus() workerJobQueueStatus {
 		consecutiveFailures: jq.consecutiveFailures,
 		recentErr:           jq.recentErr,
 		recentErrTime:       jq.recentErrTime,
+		recentJobTimes:      append([]time.Duration{}, jq.recentJobTimes...),
+	}
+}
+
+// recordJobTime adds the time it took to complete a successful job to the
+// recent job times of the queue.
+func (jq *jobGenericQueue) recordJobTime(jobTime time.Duration) {
+	jq.recentJobTimes = append(jq.recentJobTimes, jobTime)
+	if len(jq.recentJobTimes) > maxRecentJobTimes {
+		jq.recentJobTimes = jq.recentJobTimes[len(jq.recentJobTimes)-maxRecentJobTimes:]
 	}
 }
 
@@ -477,6 +477,35 @@ func TestWorkerJobGeneric(t *testing.T) {
 	}
 }
 
+// TestRecentJobTimes tests that a job queue keeps track of the times of the
+// most recent jobs.
+func TestRecentJobTimes(t *testing.T) {
+	t.Parallel()
+
+	w := new(worker)
+	w.renter = new(Renter)
+	jq := newJobGenericQueue(w)
+
+	// Record more job times than the queue keeps track of.
+	jq.mu.Lock()
+	for i := 1; i <= maxRecentJobTimes+2; i++ {
+		jq.recordJobTime(time.Duration(i) * time.Millisecond)
+	}
+	jq.mu.Unlock()
+
+	// Only the most recent job times should be returned, oldest first.
+	jobTimes := jq.callStatus().recentJobTimes
+	if len(jobTimes) != maxRecentJobTimes {
+		t.Fatal("unexpected number of job times", len(jobTimes))
+	}
+	if jobTimes[0] != 3*time.Millisecond || jobTimes[maxRecentJobTimes-1] != (maxRecentJobTimes+2)*time.Millisecond {
+		t.Fatal("unexpected job times", jobTimes)
+	}
+	if ms := jobTimesInMs(jobTimes); ms[0] != 3 {
+		t.Fatal("unexpected job times in ms", ms)
+	}
+}
+
 // TestQueueMemoryLeak makes sure that adding jobs to a queue in a tight loop
 // won't cause too many allocated objects in memory.
 func TestQueueMemoryLeak(t *testing.T) {
@@ -90,6 +90,7 @@ func (j *jobHasSector) callExecute() {
 	w := j.staticQueue.staticWorker()
 	availables, err := j.managedHasSector()
 	jobTime := time.Since(start)
+	j.staticQueue.callUpdateJobTimeout(0, jobTime, err)
 
 	// Send the response.
 	response := &jobHasSectorResponse{
@@ -151,7 +152,8 @@ func (j *jobHasSector) managedHasSector() ([]bool, error) {
 	// Execute the program and parse the responses.
 	hasSectors := make([]bool, 0, len(program))
 	var responses []programResponse
-	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryDownload, cost)
+	timeout := j.staticQueue.callJobTimeout(0)
+	responses, _, err := w.managedExecuteProgramWithTimeout(program, programData, types.FileContractID{}, categoryDownload, cost, timeout)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to execute program for has sector job")
 	}
@@ -199,6 +201,7 @@ func (jq *jobHasSectorQueue) callExpectedJobTime() time.Duration {
 func (jq *jobHasSectorQueue) callUpdateJobTimeMetrics(jobTime time.Duration) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
+	jq.recordJobTime(jobTime)
 	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobHasSectorPerformanceDecay)
 }
 
@@ -129,12 +129,17 @@ func (j *jobRead) managedFinishExecute(readData []byte, readErr error, readJobTi
 		j.staticQueue.staticWorker().renter.log.Print("managedFinishExecute: launch failed", err)
 	}
 
-	// Report success or failure to the queue.
+	// Update the timeout for reads of this length.
+	j.staticQueue.callUpdateJobTimeout(readJobTimeoutClass(j.staticLength), readJobTime, readErr)
+
+	// Report success or failure to the queue and the renter's stats.
 	if readErr != nil {
 		j.staticQueue.callReportFailure(readErr)
+		w.renter.staticStats.managedAddFailedDownload()
 		return
 	}
 	j.staticQueue.callReportSuccess()
+	w.renter.staticStats.managedAddDownload(j.staticJobReadMetadata().staticSpendingCategory, uint64(len(readData)))
 
 	// Job succeeded.
 	//
@@ -158,7 +163,8 @@ func (j *jobRead) callExpectedBandwidth() (ul, dl uint64) {
 // proof.
 func (j *jobRead) managedRead(w *worker, program modules.Program, programData []byte, cost types.Currency) ([]programResponse, error) {
 	// execute it
-	responses, _, err := w.managedExecuteProgram(program, programData, w.staticCache().staticContractID, j.staticJobReadMetadata().staticSpendingCategory, cost)
+	timeout := j.staticQueue.callJobTimeout(readJobTimeoutClass(j.staticLength))
+	responses, _, err := w.managedExecuteProgramWithTimeout(program, programData, w.staticCache().staticContractID, j.staticJobReadMetadata().staticSpendingCategory, cost, timeout)
 	if err != nil {
 		return []programResponse{}, err
 	}
@@ -253,6 +259,7 @@ func (jq *jobReadQueue) callExpectedJobCost(length uint64) types.Currency {
 func (jq *jobReadQueue) callUpdateJobTimeMetrics(length uint64, jobTime time.Duration) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
+	jq.recordJobTime(jobTime)
 	if length <= 1<<16 {
 		jq.weightedJobTime64k = expMovingAvg(jq.weightedJobTime64k, float64(jobTime), jobReadPerformanceDecay)
 	} else if length <= 1<<20 {
@@ -83,7 +83,8 @@ func parseSignedRegistryValueResponse(resp []byte, needPKAndTweak bool, version
 }
 
 // lookupsRegistry looks up a registry on the host and verifies its signature.
-func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash) (*modules.SignedRegistryValue, error) {
+// The lookup fails if the host doesn't respond within the given timeout.
+func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash, timeout time.Duration) (*modules.SignedRegistryValue, error) {
 	// Create the program.
 	pt := w.staticPriceTable().staticPriceTable
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadRegistry doesn't depend on it.
@@ -108,7 +109,7 @@ func lookupRegistry(w *worker, spk types.SiaPublicKey, tweak crypto.Hash) (*modu
 	cost = cost.Add(bandwidthCost)
 
 	// Execute the program and parse the responses.
-	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryRegistryRead, cost)
+	responses, _, err := w.managedExecuteProgramWithTimeout(program, programData, types.FileContractID{}, categoryRegistryRead, cost, timeout)
 	if err != nil {
 		return nil, errors.AddContext(err, "Unable to execute program")
 	}
@@ -199,7 +200,9 @@ func (j *jobReadRegistry) callExecute() {
 	}
 
 	// Read the value.
-	srv, err := lookupRegistry(w, j.staticSiaPublicKey, j.staticTweak)
+	timeout := j.staticQueue.callJobTimeout(0)
+	srv, err := lookupRegistry(w, j.staticSiaPublicKey, j.staticTweak, timeout)
+	j.staticQueue.callUpdateJobTimeout(0, time.Since(start), err)
 	if err != nil {
 		sendResponse(nil, err)
 		j.staticQueue.callReportFailure(err)
@@ -233,6 +236,7 @@ func (j *jobReadRegistry) callExecute() {
 	// Update the performance stats on the queue.
 	jq := j.staticQueue.(*jobReadRegistryQueue)
 	jq.mu.Lock()
+	jq.recordJobTime(jobTime)
 	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobReadRegistryPerformanceDecay)
 	jq.mu.Unlock()
 }
@@ -48,7 +48,7 @@ func TestRenewContract(t *testing.T) {
 	}
 
 	// Get the wallet seed
-	seed, _, err := wt.rt.wallet.PrimarySeed()
+	seed, err := wt.rt.wallet.PrimaryKeySeed()
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -339,7 +339,7 @@ func TestRenewContractEmptyPriceTableUID(t *testing.T) {
 	}
 
 	// Get the wallet seed
-	seed, _, err := wt.rt.wallet.PrimarySeed()
+	seed, err := wt.rt.wallet.PrimaryKeySeed()
 	if err != nil {
 		t.Fatal(err)
 	}
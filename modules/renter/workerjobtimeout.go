@@ -0,0 +1,142 @@
+package renter
+
+import (
+	"time"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// jobTimeoutAvgDecay is the weight of a new job time in the smoothed
+	// average job time of a job timeout estimate.
+	jobTimeoutAvgDecay = 0.125
+
+	// jobTimeoutDeviationDecay is the weight of a new deviation in the smoothed
+	// deviation of the job time of a job timeout estimate.
+	jobTimeoutDeviationDecay = 0.25
+
+	// jobTimeoutDeviationMultiplier is the number of deviations that are added
+	// to the average job time to get the timeout of a job.
+	jobTimeoutDeviationMultiplier = 4
+
+	// jobTimeoutAvgMultiplier is the minimum multiple of the average job time
+	// that a job is given before it times out. This prevents hosts with a very
+	// consistent performance from timing out on the slightest hiccup.
+	jobTimeoutAvgMultiplier = 3
+
+	// jobTimeoutMaxBackoff is the maximum number of times the timeout of a job
+	// is doubled after consecutive timeouts.
+	jobTimeoutMaxBackoff = 4
+
+	// jobTimeoutMinSamples is the number of successful jobs that need to be
+	// recorded before the timeout of a job is based on the historic job
+	// times. Until then the default RPC deadline is used.
+	jobTimeoutMinSamples = 5
+)
+
+// minJobTimeout is the lowest timeout a job can have, no matter how fast the
+// host usually is.
+var minJobTimeout = build.Select(build.Var{
+	Standard: 10 * time.Second,
+	Testnet:  10 * time.Second,
+	Testing:  5 * time.Second,
+	Dev:      5 * time.Second,
+}).(time.Duration)
+
+// jobTimeoutStats tracks the historic job times of a type of job on a host to
+// compute an adaptive timeout for it. Similar to the retransmission timeout of
+// TCP, the timeout is the smoothed average job time plus a multiple of its
+// smoothed deviation. Every job that times out doubles the timeout until a job
+// succeeds again, which allows slow hosts to recover from a timeout that was
+// too tight.
+type jobTimeoutStats struct {
+	avgJobTime   float64
+	avgDeviation float64
+	numSamples   uint64
+	backoff      uint64
+}
+
+// timeout returns the current timeout for a job.
+func (jts *jobTimeoutStats) timeout() time.Duration {
+	if jts.numSamples < jobTimeoutMinSamples {
+		return defaultRPCDeadline
+	}
+	timeout := time.Duration(jts.avgJobTime + jobTimeoutDeviationMultiplier*jts.avgDeviation)
+	if minTimeout := time.Duration(jobTimeoutAvgMultiplier * jts.avgJobTime); timeout < minTimeout {
+		timeout = minTimeout
+	}
+	if timeout < minJobTimeout {
+		timeout = minJobTimeout
+	}
+	timeout <<= jts.backoff
+	if timeout > defaultRPCDeadline {
+		timeout = defaultRPCDeadline
+	}
+	return timeout
+}
+
+// update updates the stats with the time it took to execute a job. A failed job
+// that took at least as long as the timeout is considered to have timed out
+// and increases the backoff. Other failures don't affect the stats since their
+// job time says little about the latency of the host.
+func (jts *jobTimeoutStats) update(jobTime time.Duration, err error) {
+	if err != nil {
+		if jobTime >= jts.timeout() && jts.backoff < jobTimeoutMaxBackoff {
+			jts.backoff++
+		}
+		return
+	}
+	jts.backoff = 0
+	if jts.numSamples == 0 {
+		jts.avgJobTime = float64(jobTime)
+		jts.avgDeviation = float64(jobTime) / 2
+	} else {
+		deviation := float64(jobTime) - jts.avgJobTime
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		jts.avgDeviation = expMovingAvg(jts.avgDeviation, deviation, jobTimeoutDeviationDecay)
+		jts.avgJobTime = expMovingAvg(jts.avgJobTime, float64(jobTime), jobTimeoutAvgDecay)
+	}
+	jts.numSamples++
+}
+
+// callJobTimeout returns the timeout for a job of the given class. The class
+// allows for a queue to track separate timeouts for jobs with different
+// characteristics, e.g. different read lengths.
+func (jq *jobGenericQueue) callJobTimeout(class uint64) time.Duration {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	jts, exists := jq.timeoutStats[class]
+	if !exists {
+		return defaultRPCDeadline
+	}
+	return jts.timeout()
+}
+
+// callUpdateJobTimeout updates the timeout of the given job class with the
+// time it took to execute a job and the error it returned.
+func (jq *jobGenericQueue) callUpdateJobTimeout(class uint64, jobTime time.Duration, err error) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	if jq.timeoutStats == nil {
+		jq.timeoutStats = make(map[uint64]*jobTimeoutStats)
+	}
+	jts, exists := jq.timeoutStats[class]
+	if !exists {
+		jts = &jobTimeoutStats{}
+		jq.timeoutStats[class] = jts
+	}
+	jts.update(jobTime, err)
+}
+
+// readJobTimeoutClass returns the job timeout class of a read job with the
+// given length. The classes match the buckets of the read job time metrics.
+func readJobTimeoutClass(length uint64) uint64 {
+	if length <= 1<<16 {
+		return 1 << 16
+	} else if length <= 1<<20 {
+		return 1 << 20
+	}
+	return 1 << 22
+}
@@ -0,0 +1,88 @@
+package renter
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestJobTimeoutStats is a unit test for the adaptive job timeouts.
+func TestJobTimeoutStats(t *testing.T) {
+	t.Parallel()
+
+	// Without enough samples the default deadline is used.
+	var jts jobTimeoutStats
+	for i := 0; i < jobTimeoutMinSamples-1; i++ {
+		jts.update(time.Second, nil)
+	}
+	if timeout := jts.timeout(); timeout != defaultRPCDeadline {
+		t.Fatalf("expected default timeout %v but got %v", defaultRPCDeadline, timeout)
+	}
+
+	// After enough fast jobs the timeout drops to the minimum.
+	for i := 0; i < 100; i++ {
+		jts.update(time.Millisecond, nil)
+	}
+	if timeout := jts.timeout(); timeout != minJobTimeout {
+		t.Fatalf("expected min timeout %v but got %v", minJobTimeout, timeout)
+	}
+
+	// Failures which don't time out don't change the timeout.
+	jts.update(time.Millisecond, errors.New("failure"))
+	if timeout := jts.timeout(); timeout != minJobTimeout {
+		t.Fatalf("expected min timeout %v but got %v", minJobTimeout, timeout)
+	}
+
+	// Every timeout doubles the timeout up to the max backoff.
+	for i := uint64(1); i <= jobTimeoutMaxBackoff+1; i++ {
+		jts.update(jts.timeout(), errors.New("timeout"))
+		backoff := i
+		if backoff > jobTimeoutMaxBackoff {
+			backoff = jobTimeoutMaxBackoff
+		}
+		expected := minJobTimeout << backoff
+		if expected > defaultRPCDeadline {
+			expected = defaultRPCDeadline
+		}
+		if timeout := jts.timeout(); timeout != expected {
+			t.Fatalf("%v: expected timeout %v but got %v", i, expected, timeout)
+		}
+	}
+
+	// A success resets the backoff.
+	jts.update(time.Millisecond, nil)
+	if timeout := jts.timeout(); timeout != minJobTimeout {
+		t.Fatalf("expected min timeout %v but got %v", minJobTimeout, timeout)
+	}
+
+	// Slow but consistent jobs get a timeout well above their job time.
+	jobTime := minJobTimeout / 2
+	jts = jobTimeoutStats{}
+	for i := 0; i < 100; i++ {
+		jts.update(jobTime, nil)
+	}
+	expected := jobTime * jobTimeoutAvgMultiplier
+	if expected > defaultRPCDeadline {
+		expected = defaultRPCDeadline
+	}
+	if timeout := jts.timeout(); timeout < expected {
+		t.Fatalf("expected timeout of at least %v but got %v", expected, timeout)
+	}
+}
+
+// TestJobGenericQueueJobTimeout tests that the job queue tracks the timeouts of
+// different job classes separately.
+func TestJobGenericQueueJobTimeout(t *testing.T) {
+	t.Parallel()
+
+	jq := newJobGenericQueue(nil)
+	for i := 0; i < jobTimeoutMinSamples; i++ {
+		jq.callUpdateJobTimeout(readJobTimeoutClass(1<<16), time.Millisecond, nil)
+	}
+	if timeout := jq.callJobTimeout(readJobTimeoutClass(1 << 16)); timeout != minJobTimeout {
+		t.Fatalf("expected timeout %v but got %v", minJobTimeout, timeout)
+	}
+	if timeout := jq.callJobTimeout(readJobTimeoutClass(1 << 22)); timeout != defaultRPCDeadline {
+		t.Fatalf("expected timeout %v but got %v", defaultRPCDeadline, timeout)
+	}
+}
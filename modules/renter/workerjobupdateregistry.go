@@ -114,6 +114,15 @@ func (j *jobUpdateRegistry) callExecute() {
 	// in the future in case we are certain that a host can't contain those
 	// errors.
 	rv, err := j.managedUpdateRegistry()
+
+	// Update the timeout. A host that responds with an existing entry still
+	// responded in time.
+	timeoutErr := err
+	if modules.IsRegistryEntryExistErr(err) {
+		timeoutErr = nil
+	}
+	j.staticQueue.callUpdateJobTimeout(0, time.Since(start), timeoutErr)
+
 	if modules.IsRegistryEntryExistErr(err) {
 		// Report the failure if the host can't provide a signed registry entry
 		// with the error.
@@ -166,6 +175,7 @@ func (j *jobUpdateRegistry) callExecute() {
 	// Update the performance stats on the queue.
 	jq := j.staticQueue.(*jobUpdateRegistryQueue)
 	jq.mu.Lock()
+	jq.recordJobTime(jobTime)
 	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobUpdateRegistryPerformanceDecay)
 	jq.mu.Unlock()
 }
@@ -203,7 +213,8 @@ func (j *jobUpdateRegistry) managedUpdateRegistry() (modules.SignedRegistryValue
 
 	// Execute the program and parse the responses.
 	var responses []programResponse
-	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, categoryRegistryWrite, cost)
+	timeout := j.staticQueue.callJobTimeout(0)
+	responses, _, err := w.managedExecuteProgramWithTimeout(program, programData, types.FileContractID{}, categoryRegistryWrite, cost, timeout)
 	if err != nil {
 		return modules.SignedRegistryValue{}, errors.AddContext(err, "Unable to execute program")
 	}
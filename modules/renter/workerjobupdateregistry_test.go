@@ -52,7 +52,7 @@ func TestUpdateRegistryJob(t *testing.T) {
 	}
 
 	// Manually try to read the entry from the host.
-	lookedUpRV, err := lookupRegistry(wt.worker, spk, tweak)
+	lookedUpRV, err := lookupRegistry(wt.worker, spk, tweak, defaultRPCDeadline)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -171,7 +171,7 @@ func TestUpdateRegistryJob(t *testing.T) {
 	wt.staticJobUpdateRegistryQueue.mu.Unlock()
 
 	// Manually try to read the entry from the host.
-	lookedUpRV, err = lookupRegistry(wt.worker, spk, tweak)
+	lookedUpRV, err = lookupRegistry(wt.worker, spk, tweak, defaultRPCDeadline)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -190,7 +190,7 @@ func TestUpdateRegistryJob(t *testing.T) {
 	}
 
 	// Manually try to read the entry from the host.
-	lookedUpRV, err = lookupRegistry(wt.worker, spk, tweak)
+	lookedUpRV, err = lookupRegistry(wt.worker, spk, tweak, defaultRPCDeadline)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -238,7 +238,7 @@ func TestUpdateRegistryLyingHost(t *testing.T) {
 	}
 
 	// Manually try to read the entry from the host.
-	lookedUpRV, err := lookupRegistry(wt.worker, spk, tweak)
+	lookedUpRV, err := lookupRegistry(wt.worker, spk, tweak, defaultRPCDeadline)
 	if err != nil {
 		t.Fatal(err)
 	}
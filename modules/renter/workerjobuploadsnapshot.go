@@ -211,10 +211,10 @@ func (w *worker) initJobUploadSnapshotQueue() {
 
 // managedUploadSnapshotHost uploads a snapshot to a single host.
 func (r *Renter) managedUploadSnapshotHost(meta modules.UploadedBackup, dotSia []byte, host contractor.Session, w *worker) error {
-	// Get the wallet seed.
-	ws, _, err := r.w.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := r.w.PrimaryKeySeed()
 	if err != nil {
-		return errors.AddContext(err, "failed to get wallet's primary seed")
+		return errors.AddContext(err, "failed to get wallet's primary key seed")
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
 	rs := modules.DeriveRenterSeed(ws)
@@ -145,7 +145,19 @@ func TestWorkerMaintenanceRefillLowContractFunds(t *testing.T) {
 	funds := contract.RenterFunds
 
 	// set the target to the balance.
-	w.staticBalanceTarget = funds
+	settings, err := w.renter.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings.AccountMaxBalanceAtRisk = funds
+	err = w.renter.SetSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.managedUpdateCache()
+	if !w.staticCache().staticAccountBalanceTarget.Equals(funds) {
+		t.Fatal("balance target wasn't updated")
+	}
 
 	// trigger a refill.
 	w.managedRefillAccount()
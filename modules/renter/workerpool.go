@@ -158,6 +158,20 @@ func (r *Renter) WorkerPoolStatus() (modules.WorkerPoolStatus, error) {
 	return r.staticWorkerPool.callStatus(), nil
 }
 
+// WorkerStatus returns the current status of the worker for the host with the
+// given public key.
+func (r *Renter) WorkerStatus(hostPubKey types.SiaPublicKey) (modules.WorkerStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.WorkerStatus{}, err
+	}
+	defer r.tg.Done()
+	w, err := r.staticWorkerPool.callWorker(hostPubKey)
+	if err != nil {
+		return modules.WorkerStatus{}, err
+	}
+	return w.callStatus(), nil
+}
+
 // callWorkers will safely grab the list of workers in the worker pool. This
 // function must be used instead of accessing the worker map directly in any
 // situation where the workers are being used as opposed to just counted,
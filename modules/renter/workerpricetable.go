@@ -15,12 +15,11 @@ import (
 )
 
 const (
-	// updatePriceTableGougingPercentageThreshold is the percentage threshold,
-	// in relation to the allowance, at which we consider the cost of updating
-	// the price table to be too expensive. E.g. the cost of updating the price
-	// table over the total allowance period should never exceed 1% of the total
-	// allowance.
-	updatePriceTableGougingPercentageThreshold = .01
+	// defaultPriceTableGougingFraction is the fraction of the allowance at
+	// which we consider the cost of updating the price table to be too
+	// expensive. E.g. the cost of updating the price table over the total
+	// allowance period should never exceed 1% of the total allowance.
+	defaultPriceTableGougingFraction = .01
 )
 
 var (
@@ -81,6 +80,9 @@ type (
 		// The time at which the price table expires.
 		staticExpiryTime time.Time
 
+		// The time at which the price table was fetched from the host.
+		staticFetchTime time.Time
+
 		// The time at which the worker scheduled a price table update manually.
 		// We limit the amount of times this can occur because the host might
 		// take advantage of this mechanism and have the renter constantly
@@ -253,6 +255,7 @@ func (w *worker) staticUpdatePriceTable() {
 		pt := &workerPriceTable{
 			staticPriceTable:       currentPT.staticPriceTable,
 			staticExpiryTime:       currentPT.staticExpiryTime,
+			staticFetchTime:        currentPT.staticFetchTime,
 			staticLastForcedUpdate: currentPT.staticLastForcedUpdate,
 			staticUpdateTime:       cd,
 			staticRecentErr:        err,
@@ -286,7 +289,8 @@ func (w *worker) staticUpdatePriceTable() {
 
 	// write the specifier
 	start := time.Now()
-	err = modules.RPCWrite(stream, modules.RPCUpdatePriceTable)
+	compress := build.VersionCmp(w.staticCache().staticHostVersion, modules.MinRPCCompressionVersion) >= 0
+	err = modules.RPCWriteID(stream, modules.RPCUpdatePriceTable, compress)
 	if err != nil {
 		err = errors.AddContext(err, "unable to write price table specifier")
 		return
@@ -301,6 +305,15 @@ func (w *worker) staticUpdatePriceTable() {
 	}
 	elapsed = time.Since(start)
 
+	// decompress the price table if necessary
+	if compress {
+		uptr.PriceTableJSON, err = modules.DecompressRPCPayload(uptr.PriceTableJSON, modules.RPCMinLen)
+		if err != nil {
+			err = errors.AddContext(err, "unable to decompress price table")
+			return
+		}
+	}
+
 	// decode the JSON
 	var pt modules.RPCPriceTable
 	err = json.Unmarshal(uptr.PriceTableJSON, &pt)
@@ -310,17 +323,23 @@ func (w *worker) staticUpdatePriceTable() {
 	}
 
 	// check for gouging before paying
-	err = checkUpdatePriceTableGouging(pt, w.staticCache().staticRenterAllowance)
+	cache := w.staticCache()
+	err = checkUpdatePriceTableGouging(pt, cache.staticRenterAllowance, cache.staticGougingThresholds.priceTable)
 	if err != nil {
 		err = errors.Compose(err, errors.AddContext(errPriceTableGouging, fmt.Sprintf("host %v", w.staticHostPubKeyStr)))
 		w.renter.log.Println("ERROR: ", err)
 		return
 	}
+	err = checkBaseRPCGouging(pt, cache.staticRenterAllowance, cache.staticGougingThresholds.baseRPC)
+	if err != nil {
+		err = errors.Compose(err, errors.AddContext(errBaseRPCGouging, fmt.Sprintf("host %v", w.staticHostPubKeyStr)))
+		w.renter.log.Println("ERROR: ", err)
+		return
+	}
 
 	// Before we pay for the price table we validate the host's block height,
 	// this is necessary because we use the host's block height when making
 	// payments by ephemeral account.
-	cache := w.staticCache()
 	if !hostBlockHeightWithinTolerance(cache.staticSynced, cache.staticBlockHeight, pt.HostBlockHeight) {
 		err = errors.AddContext(errHostBlockHeightNotWithinTolerance, fmt.Sprintf("renter height: %v synced: %v, host height: %v", cache.staticBlockHeight, cache.staticSynced, pt.HostBlockHeight))
 		return
@@ -369,6 +388,7 @@ func (w *worker) staticUpdatePriceTable() {
 	wpt := &workerPriceTable{
 		staticPriceTable:       pt,
 		staticExpiryTime:       expiryTime,
+		staticFetchTime:        now,
 		staticUpdateTime:       newUpdateTime,
 		staticLastForcedUpdate: currentPT.staticLastForcedUpdate,
 		staticRecentErr:        currentPT.staticRecentErr,
@@ -379,8 +399,9 @@ func (w *worker) staticUpdatePriceTable() {
 
 // checkUpdatePriceTableGouging verifies the cost of updating the price table is
 // reasonable, if deemed unreasonable we will reject it and this worker will be
-// put into cooldown.
-func checkUpdatePriceTableGouging(pt modules.RPCPriceTable, allowance modules.Allowance) error {
+// put into cooldown. The fraction is the fraction of the allowance which may be
+// spent on updating the price table, 0 disables the cost check.
+func checkUpdatePriceTableGouging(pt modules.RPCPriceTable, allowance modules.Allowance, fraction float64) error {
 	// If there is no allowance, price gouging checks have to be disabled,
 	// because there is no baseline for understanding what might count as price
 	// gouging.
@@ -402,9 +423,12 @@ func checkUpdatePriceTableGouging(pt modules.RPCPriceTable, allowance modules.Al
 
 	// The cost of updating is considered too expensive if the total cost is
 	// above a certain % of the allowance.
+	if fraction == 0 {
+		return nil
+	}
 	totalUpdateCost := pt.UpdatePriceTableCost.Mul64(uint64(numUpdates))
-	if totalUpdateCost.Cmp(allowance.Funds.MulFloat(updatePriceTableGougingPercentageThreshold)) > 0 {
-		return fmt.Errorf("update price table cost %v is considered too high, the total cost over the entire duration of the allowance periods exceeds %v%% of the allowance - price gouging protection enabled", pt.UpdatePriceTableCost, updatePriceTableGougingPercentageThreshold)
+	if totalUpdateCost.Cmp(allowance.Funds.MulFloat(fraction)) > 0 {
+		return fmt.Errorf("update price table cost %v is considered too high, the total cost over the entire duration of the allowance periods exceeds %v%% of the allowance - price gouging protection enabled", pt.UpdatePriceTableCost, fraction*100)
 	}
 
 	return nil
@@ -57,14 +57,15 @@ func TestUpdatePriceTableHostHeightLeeway(t *testing.T) {
 
 	// corrupt the synced property on the worker's cache
 	ptr := unsafe.Pointer(&workerCache{
-		staticBlockHeight:     hbh + 2*priceTableHostBlockHeightLeeWay,
-		staticContractID:      wc.staticContractID,
-		staticContractUtility: wc.staticContractUtility,
-		staticHostMuxAddress:  wc.staticHostMuxAddress,
-		staticHostVersion:     wc.staticHostVersion,
-		staticRenterAllowance: wc.staticRenterAllowance,
-		staticSynced:          wc.staticSynced,
-		staticLastUpdate:      wc.staticLastUpdate,
+		staticBlockHeight:       hbh + 2*priceTableHostBlockHeightLeeWay,
+		staticContractID:        wc.staticContractID,
+		staticContractUtility:   wc.staticContractUtility,
+		staticHostMuxAddress:    wc.staticHostMuxAddress,
+		staticHostVersion:       wc.staticHostVersion,
+		staticRenterAllowance:   wc.staticRenterAllowance,
+		staticSynced:            wc.staticSynced,
+		staticGougingThresholds: wc.staticGougingThresholds,
+		staticLastUpdate:        wc.staticLastUpdate,
 	})
 	atomic.StorePointer(&w.atomicCache, ptr)
 
@@ -104,7 +105,7 @@ func TestUpdatePriceTableGouging(t *testing.T) {
 
 	// verify happy case
 	pt := newDefaultPriceTable()
-	err := checkUpdatePriceTableGouging(pt, allowance)
+	err := checkUpdatePriceTableGouging(pt, allowance, defaultPriceTableGougingFraction)
 	if err != nil {
 		t.Fatal("unexpected price gouging failure")
 	}
@@ -119,8 +120,8 @@ func TestUpdatePriceTableGouging(t *testing.T) {
 	// increase the update price table cost so that the total cost of updating
 	// it for the entire allowance period exceeds the allowed percentage of the
 	// total allowance.
-	pt.UpdatePriceTableCost = allowance.Funds.MulFloat(updatePriceTableGougingPercentageThreshold * 2).Div64(uint64(numUpdates))
-	err = checkUpdatePriceTableGouging(pt, allowance)
+	pt.UpdatePriceTableCost = allowance.Funds.MulFloat(defaultPriceTableGougingFraction * 2).Div64(uint64(numUpdates))
+	err = checkUpdatePriceTableGouging(pt, allowance, defaultPriceTableGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "update price table cost") {
 		t.Fatalf("expected update price table cost gouging error, instead error was '%v'", err)
 	}
@@ -128,12 +129,12 @@ func TestUpdatePriceTableGouging(t *testing.T) {
 	// verify unacceptable validity case
 	pt = newDefaultPriceTable()
 	pt.Validity = 0
-	err = checkUpdatePriceTableGouging(pt, allowance)
+	err = checkUpdatePriceTableGouging(pt, allowance, defaultPriceTableGougingFraction)
 	if err == nil || !strings.Contains(err.Error(), "update price table validity") {
 		t.Fatalf("expected update price table validity gouging error, instead error was '%v'", err)
 	}
 	pt.Validity = minAcceptedPriceTableValidity
-	err = checkUpdatePriceTableGouging(pt, allowance)
+	err = checkUpdatePriceTableGouging(pt, allowance, defaultPriceTableGougingFraction)
 	if err != nil {
 		t.Fatalf("unexpected update price table validity gouging error: %v", err)
 	}
@@ -0,0 +1,216 @@
+package renter
+
+// workerprogram.go builds the MDM programs which third-party applications
+// execute on hosts and decodes their outputs. Only instructions which don't
+// modify the renter's contract are supported. Write instructions like append
+// require the renter to sign a new revision of the contract as part of the
+// program, which is left to the upload code.
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxProgramInstructions is the maximum number of instructions of a
+	// program which is executed on behalf of a third-party application.
+	maxProgramInstructions = 100
+)
+
+type (
+	// customProgram is an MDM program which is executed on behalf of a
+	// third-party application.
+	customProgram struct {
+		program modules.Program
+		data    []byte
+
+		// cost is the cost of the program including the bandwidth.
+		cost types.Currency
+
+		// refunds contains the refunds of the registry reads in case the
+		// entries don't exist.
+		refunds []types.Currency
+
+		// usesContract indicates whether the program accesses the renter's
+		// contract with the host.
+		usesContract bool
+	}
+)
+
+// staticBuildProgram builds the MDM program of the given instructions.
+func (w *worker) staticBuildProgram(instructions []modules.ProgramInstruction) (customProgram, error) {
+	if len(instructions) == 0 {
+		return customProgram{}, errors.New("the program doesn't contain any instructions")
+	}
+	if len(instructions) > maxProgramInstructions {
+		return customProgram{}, fmt.Errorf("the program contains more than %v instructions", maxProgramInstructions)
+	}
+	pt := w.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since none of the instructions depend on it.
+	cp := customProgram{
+		refunds: make([]types.Currency, len(instructions)),
+	}
+	var ul, dl uint64
+	for i, instruction := range instructions {
+		var iul, idl uint64
+		var err error
+		switch instruction.Type {
+		case modules.ProgramInstructionHasSector:
+			pb.AddHasSectorInstruction(instruction.MerkleRoot)
+			iul, idl = hasSectorJobExpectedBandwidth(1)
+		case modules.ProgramInstructionReadSector:
+			if instruction.Length == 0 || instruction.Offset+instruction.Length > modules.SectorSize {
+				err = errors.New("the range of the read is out of bounds")
+				break
+			}
+			pb.AddReadSectorInstruction(instruction.Length, instruction.Offset, instruction.MerkleRoot, instruction.MerkleProof)
+			iul, idl = readSectorJobExpectedBandwidth(instruction.Length)
+		case modules.ProgramInstructionReadOffset:
+			if instruction.Length == 0 || instruction.Length > modules.SectorSize {
+				err = errors.New("the length of the read is out of bounds")
+				break
+			}
+			pb.AddReadOffsetInstruction(instruction.Length, instruction.Offset, instruction.MerkleProof)
+			iul, idl = readSectorJobExpectedBandwidth(instruction.Length)
+			cp.usesContract = true
+		case modules.ProgramInstructionReadRegistry:
+			spk, tweak := instruction.PublicKey, instruction.Tweak
+			if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.5") < 0 {
+				cp.refunds[i], err = pb.V154AddReadRegistryInstruction(spk, tweak)
+			} else if build.VersionCmp(w.staticCache().staticHostVersion, "1.5.6") < 0 {
+				cp.refunds[i], err = pb.V156AddReadRegistryInstruction(spk, tweak)
+			} else {
+				cp.refunds[i], err = pb.AddReadRegistryInstruction(spk, tweak, modules.ReadRegistryVersionNoType)
+			}
+			iul, idl = readRegistryJobExpectedBandwidth()
+		case modules.ProgramInstructionRevision:
+			pb.AddRevisionInstruction()
+			iul, idl = ethernetMTU, ethernetMTU
+			cp.usesContract = true
+		default:
+			err = fmt.Errorf("unsupported instruction type %q", instruction.Type)
+		}
+		if err != nil {
+			return customProgram{}, errors.AddContext(err, fmt.Sprintf("invalid instruction %v", i))
+		}
+		ul += iul
+		dl += idl
+	}
+	cp.program, cp.data = pb.Program()
+	cost, _, _ := pb.Cost(true)
+	cp.cost = cost.Add(modules.MDMBandwidthCost(pt, ul, dl))
+	return cp, nil
+}
+
+// staticDecodeProgramOutputs decodes the responses of a program according to
+// the types of its instructions. Outputs which fail verification are marked
+// with an error. The proofs of readoffset instructions are verified against
+// the most recent revision which was returned by the program.
+func (w *worker) staticDecodeProgramOutputs(instructions []modules.ProgramInstruction, responses []programResponse) []modules.ProgramOutput {
+	var revision *types.FileContractRevision
+	outputs := make([]modules.ProgramOutput, 0, len(responses))
+	for i, resp := range responses {
+		output := modules.ProgramOutput{
+			Output: resp.Output,
+			Proof:  resp.Proof,
+		}
+		if resp.Error != nil {
+			output.Error = resp.Error.Error()
+			outputs = append(outputs, output)
+			continue
+		}
+		instruction := instructions[i]
+		var err error
+		switch instruction.Type {
+		case modules.ProgramInstructionHasSector:
+			if len(resp.Output) != 1 {
+				err = errors.New("invalid has sector output")
+				break
+			}
+			hasSector := resp.Output[0] == 1
+			output.HasSector = &hasSector
+		case modules.ProgramInstructionReadSector:
+			if !instruction.MerkleProof {
+				break
+			}
+			proofStart := int(instruction.Offset) / crypto.SegmentSize
+			proofEnd := int(instruction.Offset+instruction.Length) / crypto.SegmentSize
+			if !crypto.VerifyRangeProof(resp.Output, resp.Proof, proofStart, proofEnd, instruction.MerkleRoot) {
+				err = errors.New("proof verification failed")
+			}
+		case modules.ProgramInstructionReadOffset:
+			if !instruction.MerkleProof || revision == nil {
+				break
+			}
+			proofStart := int(instruction.Offset) / crypto.SegmentSize
+			proofEnd := int(instruction.Offset+instruction.Length) / crypto.SegmentSize
+			if !crypto.VerifyMixedRangeProof(resp.Output, resp.Proof, revision.NewFileMerkleRoot, proofStart, proofEnd) {
+				err = errors.New("proof verification failed")
+			}
+		case modules.ProgramInstructionReadRegistry:
+			if len(resp.Output) == 0 {
+				break
+			}
+			output.RegistryValue, err = decodeProgramRegistryValue(instruction, resp.Output)
+		case modules.ProgramInstructionRevision:
+			revision, err = w.staticDecodeProgramRevision(resp.Output)
+			output.Revision = revision
+		}
+		if err != nil {
+			output.Error = err.Error()
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs
+}
+
+// decodeProgramRegistryValue decodes the output of a readregistry instruction
+// and verifies the entry's signature.
+func decodeProgramRegistryValue(instruction modules.ProgramInstruction, output []byte) (*modules.ProgramRegistryValue, error) {
+	_, _, data, revision, sig, entryType, err := parseSignedRegistryValueResponse(output, false, modules.ReadRegistryVersionNoType)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to parse registry value")
+	}
+	rv := modules.NewSignedRegistryValue(instruction.Tweak, data, revision, sig, entryType)
+	if err := rv.Verify(instruction.PublicKey.ToPublicKey()); err != nil {
+		return nil, errors.AddContext(err, "failed to verify registry value's signature")
+	}
+	return &modules.ProgramRegistryValue{
+		Data:      data,
+		Revision:  revision,
+		Type:      entryType,
+		Signature: sig,
+	}, nil
+}
+
+// staticDecodeProgramRevision decodes the output of a revision instruction and
+// verifies that the revision was signed by the renter.
+func (w *worker) staticDecodeProgramRevision(output []byte) (*types.FileContractRevision, error) {
+	var revResp modules.MDMInstructionRevisionResponse
+	if err := encoding.Unmarshal(output, &revResp); err != nil {
+		return nil, errors.AddContext(err, "failed to unmarshal revision")
+	}
+	txn := revResp.RevisionTxn
+	if len(txn.TransactionSignatures) != 2 || len(txn.FileContractRevisions) != 1 {
+		return nil, errors.New("invalid revision transaction")
+	}
+	cpk, ok := w.renter.hostContractor.ContractPublicKey(w.staticHostPubKey)
+	if !ok {
+		return nil, errors.New("failed to get public key for contract")
+	}
+	var signature crypto.Signature
+	copy(signature[:], txn.RenterSignature().Signature)
+	hash := txn.SigHash(0, w.staticCache().staticBlockHeight)
+	if err := crypto.VerifyHash(hash, cpk, signature); err != nil {
+		return nil, errors.AddContext(err, "failed to verify signature on revision")
+	}
+	rev := txn.FileContractRevisions[0]
+	return &rev, nil
+}
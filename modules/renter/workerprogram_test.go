@@ -0,0 +1,87 @@
+package renter
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestBuildProgram probes building and decoding the MDM programs of
+// third-party applications.
+func TestBuildProgram(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Valid programs have a cost. Only programs which access the contract
+	// use it.
+	sector := fastrand.Bytes(int(modules.SectorSize))
+	root := crypto.MerkleRoot(sector)
+	instructions := []modules.ProgramInstruction{
+		{Type: modules.ProgramInstructionHasSector, MerkleRoot: root},
+		{Type: modules.ProgramInstructionReadSector, MerkleRoot: root, Length: crypto.SegmentSize, MerkleProof: true},
+	}
+	cp, err := wt.staticBuildProgram(instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.program) != len(instructions) || cp.cost.IsZero() || cp.usesContract {
+		t.Fatal("unexpected program", len(cp.program), cp.cost, cp.usesContract)
+	}
+	cp, err = wt.staticBuildProgram([]modules.ProgramInstruction{{Type: modules.ProgramInstructionRevision}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cp.usesContract {
+		t.Fatal("revision should use the contract")
+	}
+
+	// Invalid programs are rejected.
+	invalid := [][]modules.ProgramInstruction{
+		nil,
+		make([]modules.ProgramInstruction, maxProgramInstructions+1),
+		{{Type: "append"}},
+		{{Type: modules.ProgramInstructionReadSector, Offset: modules.SectorSize, Length: 1}},
+		{{Type: modules.ProgramInstructionReadOffset}},
+	}
+	for i, instructions := range invalid {
+		if _, err := wt.staticBuildProgram(instructions); err == nil {
+			t.Fatal("expected program to be rejected", i)
+		}
+	}
+
+	// Outputs are decoded and proofs are verified.
+	proof := crypto.MerkleRangeProof(sector, 0, 1)
+	responses := []programResponse{
+		{Output: []byte{1}},
+		{Output: sector[:crypto.SegmentSize]},
+	}
+	responses[1].Proof = proof
+	outputs := wt.staticDecodeProgramOutputs(instructions, responses)
+	if len(outputs) != 2 || outputs[0].HasSector == nil || !*outputs[0].HasSector {
+		t.Fatal("has sector output wasn't decoded", outputs)
+	}
+	if outputs[1].Error != "" {
+		t.Fatal("valid proof wasn't accepted", outputs[1].Error)
+	}
+	responses[1].Output = fastrand.Bytes(crypto.SegmentSize)
+	outputs = wt.staticDecodeProgramOutputs(instructions, responses)
+	if !strings.Contains(outputs[1].Error, "proof verification failed") {
+		t.Fatal("invalid proof was accepted", outputs[1].Error)
+	}
+}
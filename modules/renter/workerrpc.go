@@ -48,6 +48,12 @@ type programResponse struct {
 
 // managedExecuteProgram performs the ExecuteProgramRPC on the host
 func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, cost types.Currency) (responses []programResponse, limit mux.BandwidthLimit, err error) {
+	return w.managedExecuteProgramWithTimeout(p, data, fcid, category, cost, defaultRPCDeadline)
+}
+
+// managedExecuteProgramWithTimeout performs the ExecuteProgramRPC on the host
+// and fails if the RPC doesn't complete within the given timeout.
+func (w *worker) managedExecuteProgramWithTimeout(p modules.Program, data []byte, fcid types.FileContractID, category spendingCategory, cost types.Currency, timeout time.Duration) (responses []programResponse, limit mux.BandwidthLimit, err error) {
 	// Defer a function that schedules a price table update in case we received
 	// an error that indicates the host deems our price table invalid.
 	defer func() {
@@ -56,6 +62,13 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		}
 	}()
 
+	// abort the program if its cost exceeds the renter's cost ceiling, a host
+	// might have raised its prices since the job was scheduled.
+	err = w.staticCache().staticCostCeilings.checkCost(category, cost)
+	if err != nil {
+		return
+	}
+
 	// track the withdrawal
 	var refund types.Currency
 	w.staticAccount.managedTrackWithdrawal(cost)
@@ -76,6 +89,13 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 		}
 	}()
 
+	// override the default deadline of the stream with the timeout.
+	err = stream.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		err = errors.AddContext(err, "Unable to set the stream deadline")
+		return
+	}
+
 	// set the limit return var.
 	limit = stream.Limit()
 
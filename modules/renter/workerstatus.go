@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"sync/atomic"
 	"time"
 
 	"go.sia.tech/siad/modules"
@@ -50,6 +51,7 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		DownloadCoolDownTime:  downloadCoolDownTime,
 		DownloadOnCoolDown:    downloadOnCoolDown,
 		DownloadQueueSize:     downloadQueueSize,
+		DownloadStalls:        atomic.LoadUint64(&w.atomicDownloadStalls),
 		DownloadTerminated:    downloadTerminated,
 
 		// Upload information
@@ -69,8 +71,9 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		MaintenanceCoolDownTime:  maintenanceCoolDownTime,
 
 		// Account Information
-		AccountBalanceTarget: w.staticBalanceTarget,
-		AccountStatus:        w.staticAccount.managedStatus(),
+		AccountBalanceTarget:   cache.staticAccountBalanceTarget,
+		AccountRefillThreshold: cache.staticAccountRefillThreshold,
+		AccountStatus:          w.staticAccount.managedStatus(),
 
 		// Price Table Information
 		PriceTableStatus: w.staticPriceTableStatus(),
@@ -98,10 +101,18 @@ func (w *worker) staticPriceTableStatus() modules.WorkerPriceTableStatus {
 		recentErrStr = pt.staticRecentErr.Error()
 	}
 
+	var age time.Duration
+	if !pt.staticFetchTime.IsZero() {
+		age = time.Since(pt.staticFetchTime)
+	}
+
 	return modules.WorkerPriceTableStatus{
 		ExpiryTime: pt.staticExpiryTime,
 		UpdateTime: pt.staticUpdateTime,
 
+		FetchTime: pt.staticFetchTime,
+		Age:       age,
+
 		Active: time.Now().Before(pt.staticExpiryTime),
 
 		RecentErr:     recentErrStr,
@@ -125,6 +136,9 @@ func (w *worker) callReadJobStatus() modules.WorkerReadJobsStatus {
 		}
 		return 0
 	}
+	jobTimeoutInMs := func(l uint64) uint64 {
+		return uint64(jrq.callJobTimeout(readJobTimeoutClass(l)).Milliseconds())
+	}
 
 	return modules.WorkerReadJobsStatus{
 		AvgJobTime64k:       avgJobTimeInMs(1 << 16),
@@ -132,8 +146,14 @@ func (w *worker) callReadJobStatus() modules.WorkerReadJobsStatus {
 		AvgJobTime4m:        avgJobTimeInMs(1 << 22),
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		JobTimeout64k:       jobTimeoutInMs(1 << 16),
+		JobTimeout1m:        jobTimeoutInMs(1 << 20),
+		JobTimeout4m:        jobTimeoutInMs(1 << 22),
+		OnCooldown:          time.Now().Before(status.cooldownUntil),
+		OnCooldownUntil:     status.cooldownUntil,
 		RecentErr:           recentErrString,
 		RecentErrTime:       status.recentErrTime,
+		RecentJobTimes:      jobTimesInMs(status.recentJobTimes),
 	}
 }
 
@@ -153,8 +173,12 @@ func (w *worker) callHasSectorJobStatus() modules.WorkerHasSectorJobsStatus {
 		AvgJobTime:          avgJobTimeInMs,
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		JobTimeout:          uint64(hsq.callJobTimeout(0).Milliseconds()),
+		OnCooldown:          time.Now().Before(status.cooldownUntil),
+		OnCooldownUntil:     status.cooldownUntil,
 		RecentErr:           recentErrStr,
 		RecentErrTime:       status.recentErrTime,
+		RecentJobTimes:      jobTimesInMs(status.recentJobTimes),
 	}
 }
 
@@ -170,11 +194,22 @@ func callGenericWorkerJobStatus(queue *jobGenericQueue) modules.WorkerGenericJob
 	return modules.WorkerGenericJobsStatus{
 		ConsecutiveFailures: status.consecutiveFailures,
 		JobQueueSize:        status.size,
+		JobTimeout:          uint64(queue.callJobTimeout(0).Milliseconds()),
 		OnCooldown:          time.Now().Before(status.cooldownUntil),
 		OnCooldownUntil:     status.cooldownUntil,
 		RecentErr:           recentErrStr,
 		RecentErrTime:       status.recentErrTime,
+		RecentJobTimes:      jobTimesInMs(status.recentJobTimes),
+	}
+}
+
+// jobTimesInMs converts the recent job times of a queue to milliseconds.
+func jobTimesInMs(jobTimes []time.Duration) []uint64 {
+	ms := make([]uint64, 0, len(jobTimes))
+	for _, jobTime := range jobTimes {
+		ms = append(ms, uint64(jobTime.Milliseconds()))
 	}
+	return ms
 }
 
 // callUpdateRegistryJobsStatus returns the status for the ReadRegistry queue.
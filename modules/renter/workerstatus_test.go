@@ -50,9 +50,11 @@ func TestWorkerAccountStatus(t *testing.T) {
 	a := w.staticAccount
 	status := a.managedStatus()
 	if !(!status.AvailableBalance.IsZero() &&
-		status.AvailableBalance.Equals(w.staticBalanceTarget) &&
+		status.AvailableBalance.Equals(w.staticCache().staticAccountBalanceTarget) &&
 		status.RecentErr == "" &&
-		status.RecentErrTime == time.Time{}) {
+		status.RecentErrTime == time.Time{} &&
+		status.NumRefills > 0 &&
+		len(status.RecentRefills) > 0) {
 		t.Fatal("Unexpected account status", ToJSON(status))
 	}
 
@@ -128,6 +130,9 @@ func TestWorkerPriceTableStatus(t *testing.T) {
 		status.RecentErrTime == time.Time{}) {
 		t.Fatal("Unexpected price table status", ToJSON(status))
 	}
+	if status.FetchTime.IsZero() || status.Age <= 0 || status.FetchTime.After(time.Now()) {
+		t.Fatal("Unexpected price table age", ToJSON(status))
+	}
 
 	// close the host to ensure the update PT call fails
 	err = wt.host.Close()
@@ -240,6 +245,7 @@ func TestWorkerReadJobStatus(t *testing.T) {
 	if err := build.Retry(100, 100*time.Millisecond, func() error {
 		status = w.callReadJobStatus()
 		if !(status.ConsecutiveFailures == 1 &&
+			status.OnCooldown &&
 			status.RecentErr != "" &&
 			status.RecentErrTime != time.Time{}) {
 			return fmt.Errorf("Unexpected read job status %v", ToJSON(status))
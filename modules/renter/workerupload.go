@@ -13,21 +13,22 @@ import (
 )
 
 const (
-	// uploadGougingFractionDenom sets the gouging fraction to 1/4 based on the
-	// idea that the user should be able to hit at least some fraction of their
-	// desired upload volume using some fraction of hosts.
-	uploadGougingFractionDenom = 4
+	// defaultUploadGougingFraction sets the gouging fraction to 1/4 based on
+	// the idea that the user should be able to hit at least some fraction of
+	// their desired upload volume using some fraction of hosts.
+	defaultUploadGougingFraction = 0.25
 )
 
 // checkUploadGouging looks at the current renter allowance and the active
 // settings for a host and determines whether an upload should be halted due to
-// price gouging.
+// price gouging. The fraction is the fraction of the expected storage which the
+// allowance has to cover, 0 disables the check against the allowance funds.
 //
 // NOTE: Currently this function treats all uploads as being the stream upload
 // size and assumes that data is actually being appended to the host. As the
 // worker gains more modification actions on the host, this check can be split
 // into different checks that vary based on the operation being performed.
-func checkUploadGouging(allowance modules.Allowance, hostSettings modules.HostExternalSettings) error {
+func checkUploadGouging(allowance modules.Allowance, hostSettings modules.HostExternalSettings, fraction float64) error {
 	// Check whether the base RPC price is too high.
 	if !allowance.MaxRPCPrice.IsZero() && allowance.MaxRPCPrice.Cmp(hostSettings.BaseRPCPrice) < 0 {
 		errStr := fmt.Sprintf("rpc price of host is %v, which is above the maximum allowed by the allowance: %v", hostSettings.BaseRPCPrice, allowance.MaxRPCPrice)
@@ -66,7 +67,7 @@ func checkUploadGouging(allowance modules.Allowance, hostSettings modules.HostEx
 	singleUploadCost := hostSettings.SectorAccessPrice.Add(hostSettings.BaseRPCPrice).Add(hostSettings.UploadBandwidthPrice.Mul64(modules.StreamUploadSize)).Add(hostSettings.StoragePrice.Mul64(uint64(allowance.Period)).Mul64(modules.StreamUploadSize))
 	fullCostPerByte := singleUploadCost.Div64(modules.StreamUploadSize)
 	allowanceStorageCost := fullCostPerByte.Mul64(allowance.ExpectedStorage)
-	reducedCost := allowanceStorageCost.Div64(uploadGougingFractionDenom)
+	reducedCost := allowanceStorageCost.MulFloat(fraction)
 	if reducedCost.Cmp(allowance.Funds) > 0 {
 		errStr := fmt.Sprintf("combined upload pricing of host yields %v, which is more than the renter is willing to pay for storage: %v - price gouging protection enabled", reducedCost, allowance.Funds)
 		return errors.New(errStr)
@@ -83,7 +84,15 @@ func checkUploadGouging(allowance modules.Allowance, hostSettings modules.HostEx
 func (w *worker) managedDropChunk(uc *unfinishedUploadChunk) {
 	uc.mu.Lock()
 	uc.workersRemaining--
+	_, lowLatencyPending := uc.lowLatencyHostsPending[w.staticHostPubKeyStr]
+	delete(uc.lowLatencyHostsPending, w.staticHostPubKeyStr)
 	uc.mu.Unlock()
+
+	// If the worker was a low latency worker, the pieces reserved for it might
+	// have to be taken by the standby workers instead.
+	if lowLatencyPending {
+		uc.managedNotifyStandbyWorkers()
+	}
 	w.renter.managedCleanUpUploadChunk(uc)
 }
 
@@ -194,25 +203,43 @@ func (w *worker) managedPerformUploadChunkJob() {
 	// Before performing the upload, check for price gouging.
 	allowance := w.renter.hostContractor.Allowance()
 	hostSettings := e.HostSettings()
-	err = checkUploadGouging(allowance, hostSettings)
+	err = checkUploadGouging(allowance, hostSettings, w.staticCache().staticGougingThresholds.upload)
 	if err != nil && !w.renter.deps.Disrupt("DisableUploadGouging") {
 		failureErr := errors.AddContext(err, "worker uploader is not being used because price gouging was detected")
 		w.managedUploadFailed(uc, pieceIndex, failureErr)
 		return
 	}
 
+	// Abort the upload if the cost of the sector exceeds the renter's cost
+	// ceiling.
+	cache := w.staticCache()
+	sectorCost := uploadSectorCost(hostSettings, cache.staticBlockHeight, e.EndHeight())
+	err = cache.staticCostCeilings.checkCost(categoryUpload, sectorCost)
+	if err != nil {
+		failureErr := errors.AddContext(err, "worker aborted the upload")
+		w.managedUploadFailed(uc, pieceIndex, failureErr)
+		return
+	}
+
 	// Perform the upload, and update the failure stats based on the success of
 	// the upload attempt.
 	//
 	// Ignore the error if it's a ErrMaxVirtualSectors coming from a pre-1.5.5
 	// host.
-	root, err := e.Upload(uc.physicalChunkData[pieceIndex])
+	piece, err := uc.managedPieceData(pieceIndex)
+	if err != nil {
+		failureErr := errors.AddContext(err, "worker failed to fetch the piece")
+		w.managedUploadFailed(uc, pieceIndex, failureErr)
+		return
+	}
+	root, err := e.Upload(piece)
 	ignoreErr := build.VersionCmp(hostSettings.Version, "1.5.5") < 0 && err != nil && strings.Contains(err.Error(), modules.ErrMaxVirtualSectors.Error())
 	if err != nil && !ignoreErr {
 		failureErr := fmt.Errorf("Worker failed to upload root %v via the editor: %v", root, err)
 		w.managedUploadFailed(uc, pieceIndex, failureErr)
 		return
 	}
+	w.renter.staticStats.managedAddUpload(uc.staticRepair, uint64(len(piece)), sectorCost)
 	w.mu.Lock()
 	w.uploadConsecutiveFailures = 0
 	w.mu.Unlock()
@@ -231,14 +258,15 @@ func (w *worker) managedPerformUploadChunkJob() {
 	// Upload is complete. Update the state of the chunk and the renter's memory
 	// available to reflect the completed upload.
 	uc.mu.Lock()
-	releaseSize := len(uc.physicalChunkData[pieceIndex])
+	releaseSize := len(piece)
 	uc.piecesRegistered--
 	uc.piecesCompleted++
 	uc.physicalChunkData[pieceIndex] = nil
 	uc.memoryReleased += uint64(releaseSize)
 	uc.chunkSuccessProcessTimes = append(uc.chunkSuccessProcessTimes, time.Now())
 	uc.mu.Unlock()
-	uc.staticMemoryManager.Return(uint64(releaseSize))
+	uc.returnMemory(uint64(releaseSize))
+	w.renter.staticStats.managedAddUploadJob(false)
 	w.renter.managedCleanUpUploadChunk(uc)
 }
 
@@ -279,8 +307,14 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	}
 
 	// If the worker does not need help, add the worker to the set of standby
-	// chunks.
-	needsHelp := uc.staticPiecesNeeded > uc.piecesCompleted+uc.piecesRegistered
+	// chunks. This is also the case if all the remaining pieces are reserved
+	// for low latency hosts and the worker isn't one of them.
+	unclaimedPieces := uc.staticPiecesNeeded - uc.piecesCompleted - uc.piecesRegistered
+	_, lowLatency := uc.lowLatencyHosts[w.staticHostPubKeyStr]
+	needsHelp := unclaimedPieces > 0
+	if !lowLatency && unclaimedPieces <= uc.lowLatencyPiecesReserved() {
+		needsHelp = false
+	}
 	if !needsHelp {
 		uc.workersStandby = append(uc.workersStandby, w)
 		uc.mu.Unlock()
@@ -307,6 +341,12 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 		return nil, 0
 	}
 	delete(uc.unusedHosts, w.staticHostPubKey.String())
+	if lowLatency {
+		delete(uc.lowLatencyHostsPending, w.staticHostPubKeyStr)
+		if uc.lowLatencyPiecesRemaining > 0 {
+			uc.lowLatencyPiecesRemaining--
+		}
+	}
 	uc.piecesRegistered++
 	uc.workersRemaining--
 	uc.mu.Unlock()
@@ -317,6 +357,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 // chunk.
 func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint64, failureErr error) {
 	w.renter.repairLog.Printf("Worker upload failed. Worker: %v, Chunk: %v of %s, Error: %v", w.staticHostPubKey, uc.staticIndex, uc.staticSiaPath, failureErr)
+	w.renter.staticStats.managedAddUploadJob(true)
 	// Mark the failure in the worker if the gateway says we are online. It's
 	// not the worker's fault if we are offline.
 	if w.renter.g.Online() && !(strings.Contains(failureErr.Error(), siafile.ErrDeleted.Error()) || errors.Contains(failureErr, siafile.ErrDeleted)) {
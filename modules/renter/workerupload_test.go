@@ -23,7 +23,7 @@ func TestCheckUploadGouging(t *testing.T) {
 		// Funds is set such that the tests come out to an easy, round number.
 		// One siacoin is multiplied by the number of elements that are checked
 		// for gouging, and then divided by the gounging denominator.
-		Funds:  types.SiacoinPrecision.Mul64(4).Div64(uploadGougingFractionDenom).Sub(oneCurrency),
+		Funds:  types.SiacoinPrecision.Mul64(4).MulFloat(defaultUploadGougingFraction).Sub(oneCurrency),
 		Period: 1, // 1 block.
 
 		ExpectedStorage: modules.StreamUploadSize, // 1 stream upload operation.
@@ -40,7 +40,7 @@ func TestCheckUploadGouging(t *testing.T) {
 		StoragePrice:         types.SiacoinPrecision.Div64(modules.StreamUploadSize),
 	}
 
-	err := checkUploadGouging(minAllowance, minHostSettings)
+	err := checkUploadGouging(minAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err == nil {
 		t.Fatal("expecting price gouging check to fail:", err)
 	}
@@ -48,25 +48,25 @@ func TestCheckUploadGouging(t *testing.T) {
 	// Drop the host prices one field at a time.
 	newHostSettings := minHostSettings
 	newHostSettings.BaseRPCPrice = minHostSettings.BaseRPCPrice.Mul64(100).Div64(101)
-	err = checkUploadGouging(minAllowance, newHostSettings)
+	err = checkUploadGouging(minAllowance, newHostSettings, defaultUploadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
 	newHostSettings = minHostSettings
 	newHostSettings.SectorAccessPrice = minHostSettings.SectorAccessPrice.Mul64(100).Div64(101)
-	err = checkUploadGouging(minAllowance, newHostSettings)
+	err = checkUploadGouging(minAllowance, newHostSettings, defaultUploadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
 	newHostSettings = minHostSettings
 	newHostSettings.UploadBandwidthPrice = minHostSettings.UploadBandwidthPrice.Mul64(100).Div64(101)
-	err = checkUploadGouging(minAllowance, newHostSettings)
+	err = checkUploadGouging(minAllowance, newHostSettings, defaultUploadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
 	newHostSettings = minHostSettings
 	newHostSettings.StoragePrice = minHostSettings.StoragePrice.Mul64(100).Div64(101)
-	err = checkUploadGouging(minAllowance, newHostSettings)
+	err = checkUploadGouging(minAllowance, newHostSettings, defaultUploadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -83,7 +83,7 @@ func TestCheckUploadGouging(t *testing.T) {
 	maxAllowance.MaxUploadBandwidthPrice = types.SiacoinPrecision.Div64(modules.StreamUploadSize).Add(oneCurrency)
 
 	// The max allowance should have no issues with price gouging.
-	err = checkUploadGouging(maxAllowance, minHostSettings)
+	err = checkUploadGouging(maxAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -91,7 +91,7 @@ func TestCheckUploadGouging(t *testing.T) {
 	// Should fail if the MaxRPCPrice is dropped.
 	failAllowance := maxAllowance
 	failAllowance.MaxRPCPrice = types.SiacoinPrecision.Sub(oneCurrency)
-	err = checkUploadGouging(failAllowance, minHostSettings)
+	err = checkUploadGouging(failAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err == nil {
 		t.Error("expecting price gouging check to fail")
 	}
@@ -99,7 +99,7 @@ func TestCheckUploadGouging(t *testing.T) {
 	// Should fail if the MaxSectorAccessPrice is dropped.
 	failAllowance = maxAllowance
 	failAllowance.MaxSectorAccessPrice = types.SiacoinPrecision.Sub(oneCurrency)
-	err = checkUploadGouging(failAllowance, minHostSettings)
+	err = checkUploadGouging(failAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err == nil {
 		t.Error("expecting price gouging check to fail")
 	}
@@ -107,7 +107,7 @@ func TestCheckUploadGouging(t *testing.T) {
 	// Should fail if the MaxStoragePrice is dropped.
 	failAllowance = maxAllowance
 	failAllowance.MaxStoragePrice = types.SiacoinPrecision.Div64(modules.StreamUploadSize).Sub(oneCurrency)
-	err = checkUploadGouging(failAllowance, minHostSettings)
+	err = checkUploadGouging(failAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err == nil {
 		t.Error("expecting price gouging check to fail")
 	}
@@ -115,7 +115,7 @@ func TestCheckUploadGouging(t *testing.T) {
 	// Should fail if the MaxUploadBandwidthPrice is dropped.
 	failAllowance = maxAllowance
 	failAllowance.MaxUploadBandwidthPrice = types.SiacoinPrecision.Div64(modules.StreamUploadSize).Sub(oneCurrency)
-	err = checkUploadGouging(failAllowance, minHostSettings)
+	err = checkUploadGouging(failAllowance, minHostSettings, defaultUploadGougingFraction)
 	if err == nil {
 		t.Error("expecting price gouging check to fail")
 	}
@@ -557,6 +557,63 @@ func testProcessUploadChunkNotGoodForUpload(t *testing.T, chunk func(wt *workerT
 	wt.mu.Unlock()
 }
 
+// testProcessUploadChunkReservedForLowLatency tests processing a chunk whose
+// remaining pieces are reserved for low latency hosts.
+func testProcessUploadChunkReservedForLowLatency(t *testing.T, chunk func(wt *workerTester) *unfinishedUploadChunk) {
+	t.Parallel()
+
+	// create worker.
+	wt, err := newWorkerTesterCustomDependency(t.Name(), &dependencies.DependencyDisableWorker{}, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Reserve the 2 remaining pieces for 2 other low latency hosts.
+	uuc := chunk(wt)
+	uuc.mu.Lock()
+	uuc.piecesRegistered = uuc.staticPiecesNeeded - 2
+	for i := 0; i < uuc.piecesRegistered; i++ {
+		uuc.pieceUsage[i] = true
+	}
+	uuc.lowLatencyHosts = map[string]struct{}{"a": {}, "b": {}}
+	uuc.lowLatencyHostsPending = map[string]struct{}{"a": {}, "b": {}}
+	uuc.lowLatencyPiecesRemaining = 2
+	uuc.mu.Unlock()
+	_ = uuc.staticMemoryManager.Request(context.Background(), modules.SectorSize*uint64(uuc.staticPiecesNeeded), true)
+
+	// The worker shouldn't take a reserved piece.
+	nc, _ := wt.managedProcessUploadChunk(uuc)
+	if nc != nil {
+		t.Fatal("next chunk should be nil")
+	}
+	uuc.mu.Lock()
+	if uuc.piecesRegistered != uuc.staticPiecesNeeded-2 {
+		t.Fatalf("piecesRegistered %v != %v", uuc.piecesRegistered, uuc.staticPiecesNeeded-2)
+	}
+
+	// Once one of the low latency hosts is gone, the worker can take the piece
+	// which is no longer reserved.
+	delete(uuc.lowLatencyHostsPending, "a")
+	uuc.mu.Unlock()
+	nc, _ = wt.managedProcessUploadChunk(uuc)
+	if nc == nil {
+		t.Fatal("next chunk shouldn't be nil")
+	}
+	uuc.mu.Lock()
+	if uuc.piecesRegistered != uuc.staticPiecesNeeded-1 {
+		t.Fatalf("piecesRegistered %v != %v", uuc.piecesRegistered, uuc.staticPiecesNeeded-1)
+	}
+	if uuc.lowLatencyPiecesRemaining != 2 {
+		t.Fatalf("lowLatencyPiecesRemaining %v != %v", uuc.lowLatencyPiecesRemaining, 2)
+	}
+	uuc.mu.Unlock()
+}
+
 // TestProcessUploadChunk is a unit test for managedProcessUploadChunk.
 func TestProcessUploadChunk(t *testing.T) {
 	if testing.Short() {
@@ -608,4 +665,7 @@ func TestProcessUploadChunk(t *testing.T) {
 	t.Run("NotGoodForUpload", func(t *testing.T) {
 		testProcessUploadChunkNotGoodForUpload(t, chunk)
 	})
+	t.Run("ReservedForLowLatency", func(t *testing.T) {
+		testProcessUploadChunkReservedForLowLatency(t, chunk)
+	})
 }
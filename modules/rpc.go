@@ -1,10 +1,13 @@
 package modules
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"io"
+	"io/ioutil"
 	"strings"
 	"time"
 
@@ -55,6 +58,10 @@ var (
 	// table has expired.
 	ErrPriceTableExpired = errors.New("Price table requested is expired")
 
+	// ErrRPCPayloadTooLarge is returned when a decompressed RPC payload
+	// exceeds its maximum length.
+	ErrRPCPayloadTooLarge = errors.New("decompressed RPC payload is too large")
+
 	// SubscriptionPeriod is the duration by which a period gets extended after
 	// a payment.
 	SubscriptionPeriod = build.Select(build.Var{
@@ -197,6 +204,11 @@ var (
 
 	// RPCRenewContract specifier
 	RPCRenewContract = types.NewSpecifier("RenewContract")
+
+	// RPCCompression is sent by the renter before the specifier of an RPC to
+	// indicate that it accepts compressed payloads. Only compressible
+	// payloads like the price table are compressed, sector data never is.
+	RPCCompression = types.NewSpecifier("Compression")
 )
 
 type (
@@ -441,6 +453,48 @@ func RPCWriteAll(w io.Writer, objs ...interface{}) error {
 	return nil
 }
 
+// RPCWriteID writes the specifier of an RPC to the stream. If compression is
+// true, the specifier is preceded by RPCCompression. Compression should only
+// be requested from hosts which support at least MinRPCCompressionVersion.
+func RPCWriteID(w io.Writer, id types.Specifier, compression bool) error {
+	if compression {
+		return RPCWriteAll(w, RPCCompression, id)
+	}
+	return RPCWrite(w, id)
+}
+
+// CompressRPCPayload compresses an RPC payload using gzip.
+func CompressRPCPayload(payload []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write(payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressRPCPayload decompresses an RPC payload which was compressed with
+// CompressRPCPayload. An error is returned if the decompressed payload is
+// larger than maxLen.
+func DecompressRPCPayload(payload []byte, maxLen uint64) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := ioutil.ReadAll(io.LimitReader(zr, int64(maxLen)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(decompressed)) > maxLen {
+		return nil, ErrRPCPayloadTooLarge
+	}
+	return decompressed, zr.Close()
+}
+
 // RPCWriteError writes the given error to the stream.
 func RPCWriteError(w io.Writer, err error) error {
 	re, ok := err.(*RPCError)
@@ -213,3 +213,73 @@ func TestIsPriceTableInvalidErr(t *testing.T) {
 		}
 	}
 }
+
+// TestRPCCompression verifies the functionality of RPCWriteID,
+// CompressRPCPayload and DecompressRPCPayload.
+func TestRPCCompression(t *testing.T) {
+	t.Parallel()
+
+	// Without compression only the id is written.
+	stream := new(bytes.Buffer)
+	err := RPCWriteID(stream, RPCUpdatePriceTable, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var id types.Specifier
+	if err = RPCRead(stream, &id); err != nil {
+		t.Fatal(err)
+	}
+	if id != RPCUpdatePriceTable || stream.Len() != 0 {
+		t.Fatal("unexpected id", id)
+	}
+
+	// With compression the id is preceded by RPCCompression.
+	err = RPCWriteID(stream, RPCUpdatePriceTable, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RPCRead(stream, &id); err != nil {
+		t.Fatal(err)
+	}
+	if id != RPCCompression {
+		t.Fatal("unexpected id", id)
+	}
+	if err = RPCRead(stream, &id); err != nil {
+		t.Fatal(err)
+	}
+	if id != RPCUpdatePriceTable {
+		t.Fatal("unexpected id", id)
+	}
+
+	// Compress a JSON payload and decompress it again.
+	payload, err := json.Marshal(RPCPriceTable{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := CompressRPCPayload(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(payload) {
+		t.Fatal("payload wasn't compressed", len(compressed), len(payload))
+	}
+	decompressed, err := DecompressRPCPayload(compressed, uint64(len(payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, payload) {
+		t.Fatal("decompressed payload doesn't match")
+	}
+
+	// Decompressing into a too small limit should fail.
+	_, err = DecompressRPCPayload(compressed, uint64(len(payload)-1))
+	if !errors.Contains(err, ErrRPCPayloadTooLarge) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Decompressing garbage should fail.
+	_, err = DecompressRPCPayload(fastrand.Bytes(64), RPCMinLen)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
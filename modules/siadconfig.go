@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/ratelimit"
 
@@ -19,6 +20,10 @@ type (
 		WriteBPS           int64  `json:"writebps"`
 		PacketSize         uint64 `json:"packetsize"`
 
+		// Alert related fields
+		AcknowledgedAlerts []AlertID             `json:"acknowledgedalerts,omitempty"`
+		SilencedAlerts     map[AlertID]time.Time `json:"silencedalerts,omitempty"`
+
 		// path of config on disk.
 		path string
 		mu   sync.Mutex
@@ -59,6 +64,29 @@ func (cfg *SiadConfig) SetRatelimit(readBPS, writeBPS int64) error {
 	return cfg.save()
 }
 
+// AlertStates returns the acknowledged alerts and the silenced alerts with the
+// time their silence expires.
+func (cfg *SiadConfig) AlertStates() ([]AlertID, map[AlertID]time.Time) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	acknowledged := append([]AlertID(nil), cfg.AcknowledgedAlerts...)
+	silenced := make(map[AlertID]time.Time)
+	for id, until := range cfg.SilencedAlerts {
+		silenced[id] = until
+	}
+	return acknowledged, silenced
+}
+
+// SetAlertStates sets the acknowledged and silenced alerts in the config and
+// persists it to disk.
+func (cfg *SiadConfig) SetAlertStates(acknowledged []AlertID, silenced map[AlertID]time.Time) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.AcknowledgedAlerts = acknowledged
+	cfg.SilencedAlerts = silenced
+	return cfg.save()
+}
+
 // save saves the config to disk.
 func (cfg *SiadConfig) save() error {
 	return persist.SaveJSON(configMetadata, cfg, cfg.path)
@@ -21,6 +21,14 @@ const (
 	SiaMuxDir = "siamux"
 )
 
+var (
+	// siaMuxPersistMetadata is the metadata of the siamux's persistence file.
+	siaMuxPersistMetadata = persist.Metadata{
+		Header:  "SiaMux",
+		Version: "1.4.2.1",
+	}
+)
+
 // NewSiaMux returns a new SiaMux object
 func NewSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string) (*siamux.SiaMux, *os.File, error) {
 	// can't use relative path
@@ -52,8 +60,12 @@ func NewSiaMux(siaMuxDir, siaDir, tcpaddress, wsaddress string) (*siamux.SiaMux,
 	}
 
 	// create a siamux, if the host's persistence file is at v120 we want to
-	// recycle the host's key pair to use in the siamux
+	// recycle the host's key pair to use in the siamux. The same is true if
+	// the host's key pair was restored from a backup.
 	pubKey, privKey, compat := compatLoadKeysFromHost(siaDir)
+	if !compat {
+		pubKey, privKey, compat = loadRestoredKeysFromHost(siaMuxDir, siaDir)
+	}
 	if compat {
 		m, err := siamux.CompatV1421NewWithKeyPair(tcpaddress, wsaddress, logger.Logger, siaMuxDir, privKey, pubKey)
 		if err != nil {
@@ -111,6 +123,31 @@ func compatLoadKeysFromHost(persistDir string) (pubKey mux.ED25519PublicKey, pri
 	return
 }
 
+// loadRestoredKeysFromHost will load the host's keypair from its persistence
+// file if it doesn't match the keypair of the siamux. That is the case after
+// the host's keys were restored from a backup.
+func loadRestoredKeysFromHost(siaMuxDir, siaDir string) (pubKey mux.ED25519PublicKey, privKey mux.ED25519SecretKey, restored bool) {
+	hk := struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+		SecretKey crypto.SecretKey   `json:"secretkey"`
+	}{}
+	err := persist.LoadJSON(Hostv151PersistMetadata, &hk, filepath.Join(siaDir, HostDir, HostSettingsFile))
+	if err != nil || len(hk.PublicKey.Key) != len(pubKey) {
+		return
+	}
+	mk := struct {
+		PubKey mux.ED25519PublicKey `json:"pubkey"`
+	}{}
+	err = persist.LoadJSON(siaMuxPersistMetadata, &mk, filepath.Join(siaMuxDir, "siamux.json"))
+	if err != nil {
+		return
+	}
+	copy(pubKey[:], hk.PublicKey.Key)
+	copy(privKey[:], hk.SecretKey[:])
+	restored = pubKey != mk.PubKey
+	return
+}
+
 // compatV143MigrateSiaMux migrates the SiaMux from the root dir of the sia data
 // dir to the siamux subdir.
 func compatV143MigrateSiaMux(siaMuxDir, siaDir string) error {
@@ -53,6 +53,14 @@ var (
 	// accessible data.
 	HomeFolder = NewGlobalSiaPath("/home")
 
+	// PackFolder is the Sia folder where the renter stores the packs which
+	// contain small files packed together.
+	PackFolder = NewGlobalSiaPath("/var/packs")
+
+	// ReshardFolder is the Sia folder where the renter stores the new versions
+	// of files whose erasure coding parameters are being changed.
+	ReshardFolder = NewGlobalSiaPath("/var/reshard")
+
 	// UserFolder is the Sia folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalSiaPath("/home/user")
 )
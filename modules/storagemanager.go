@@ -15,6 +15,22 @@ const (
 )
 
 type (
+	// StorageFolderBackup contains the information required to reconnect an
+	// existing storage folder to a storage manager.
+	StorageFolderBackup struct {
+		Capacity uint64 `json:"capacity"` // bytes
+		Index    uint16 `json:"index"`
+		Path     string `json:"path"`
+	}
+
+	// StorageManagerBackup contains the state of a storage manager which can't
+	// be recovered from the storage folders themselves. It doesn't contain any
+	// sector data.
+	StorageManagerBackup struct {
+		SectorSalt     crypto.Hash           `json:"sectorsalt"`
+		StorageFolders []StorageFolderBackup `json:"storagefolders"`
+	}
+
 	// StorageFolderMetadata contains metadata about a storage folder that is
 	// tracked by the storage folder manager.
 	StorageFolderMetadata struct {
@@ -37,9 +53,18 @@ type (
 		// Certain operations on a storage folder can take a long time (Add,
 		// Remove, and Resize). The fields below indicate the progress of any
 		// long running operations that might be under way in the storage
-		// folder. Progress is always reported in bytes.
+		// folder. Progress is always reported in bytes. ProgressETA is the
+		// estimated time in seconds until the operation completes.
 		ProgressNumerator   uint64
 		ProgressDenominator uint64
+		ProgressETA         uint64 `json:"progresseta"`
+
+		// ResizeTarget is the size in bytes the storage folder is being
+		// resized to, or 0 if no resize is in progress. Interrupted resizes
+		// are resumed after a restart. ResizeError is the error of the last
+		// failed resize of the storage folder.
+		ResizeTarget uint64 `json:"resizetarget"`
+		ResizeError  string `json:"resizeerror"`
 	}
 
 	// A StorageManager is responsible for managing storage folders and
@@ -62,6 +87,11 @@ type (
 		// a given root or not.
 		HasSector(crypto.Hash) bool
 
+		// AddVirtualSector adds a virtual sector for a sector which is already
+		// stored. It fails if the sector doesn't exist, checking for it
+		// atomically with adding the reference.
+		AddVirtualSector(sectorRoot crypto.Hash) error
+
 		// AddSectorBatch is a performance optimization over AddSector when
 		// adding a bunch of virtual sectors. It is necessary because otherwise
 		// potentially thousands or even tens-of-thousands of fsync calls would
@@ -117,6 +147,13 @@ type (
 		// storage folder.
 		ResetStorageFolderHealth(index uint16) error
 
+		// RestoreStorageFolders reconnects the storage folders of a backup to
+		// the manager. Only the sectors with the provided roots are restored,
+		// all other sectors in the storage folders are considered to be free.
+		// The storage folders need to exist on disk and the manager can't have
+		// any storage folders of its own yet.
+		RestoreStorageFolders(backup StorageManagerBackup, sectorRoots []crypto.Hash) error
+
 		// ResizeStorageFolder will grow or shrink a storage folder in the
 		// manager. The manager may not check that there is enough space
 		// on-disk to support growing the storage folder, but should gracefully
@@ -132,5 +169,9 @@ type (
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
+
+		// StorageFoldersBackup returns the information required to reconnect
+		// the manager's storage folders after its persist dir was lost.
+		StorageFoldersBackup() StorageManagerBackup
 	}
 )
@@ -90,6 +90,16 @@ type (
 )
 
 type (
+	// A FeeHistogramBucket contains the unconfirmed transaction sets paying a
+	// fee per byte of at least MinFeePerByte, but less than the MinFeePerByte
+	// of the next bucket.
+	FeeHistogramBucket struct {
+		MinFeePerByte types.Currency `json:"minfeeperbyte"`
+		Sets          int            `json:"sets"`
+		Transactions  int            `json:"transactions"`
+		Size          uint64         `json:"size"`
+	}
+
 	// A TransactionPoolSubscriber receives updates about the confirmed and
 	// unconfirmed set from the transaction pool. Generally, there is no need to
 	// subscribe to both the consensus set and the transaction pool.
@@ -105,7 +115,8 @@ type (
 		Alerter
 
 		// AcceptTransactionSet accepts a set of potentially interdependent
-		// transactions.
+		// transactions. A set that double spends unconfirmed transactions
+		// replaces them if it pays enough additional fees.
 		AcceptTransactionSet([]types.Transaction) error
 
 		// Broadcast broadcasts a transaction set to all of the transaction pool's
@@ -115,6 +126,11 @@ type (
 		// Close is necessary for clean shutdown (e.g. during testing).
 		Close() error
 
+		// EvictTransaction removes a transaction and the unconfirmed
+		// transactions depending on it from the transaction pool, returning
+		// the IDs of the removed transactions.
+		EvictTransaction(types.TransactionID) ([]types.TransactionID, error)
+
 		// FeeEstimation returns an estimation for how high the transaction fee
 		// needs to be per byte. The minimum recommended targets getting accepted
 		// in ~3 blocks, and the maximum recommended targets getting accepted
@@ -123,6 +139,16 @@ type (
 		// within 10 blocks.
 		FeeEstimation() (minimumRecommended, maximumRecommended types.Currency)
 
+		// FeeEstimationForTarget returns the recommended fee per byte for a
+		// transaction to be confirmed within 'target' blocks. It is based on
+		// the fees of the transactions confirmed in recent blocks and is never
+		// lower than the minimum recommended fee.
+		FeeEstimationForTarget(target types.BlockHeight) types.Currency
+
+		// FeeHistogram returns the distribution of the fees per byte paid by
+		// the unconfirmed transaction sets, sorted by increasing fee.
+		FeeHistogram() []FeeHistogramBucket
+
 		// PurgeTransactionPool is a temporary function available to the miner. In
 		// the event that a miner mines an unacceptable block, the transaction pool
 		// will be purged to clear out the transaction pool and get rid of the
@@ -130,6 +156,11 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// SimulateAcceptTransactionSet checks whether a transaction set would
+		// be accepted by AcceptTransactionSet without adding it to the
+		// transaction pool or broadcasting it.
+		SimulateAcceptTransactionSet([]types.Transaction) error
+
 		// Transaction returns the transaction and unconfirmed parents
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
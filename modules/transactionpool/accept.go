@@ -60,6 +60,12 @@ func relatedObjectIDs(ts []types.Transaction) []ObjectID {
 	return oids
 }
 
+// lowMinerFeesError returns errLowMinerFees with the fees paid by a
+// transaction set and the fees it would need to pay.
+func lowMinerFeesError(setSize uint64, setFees, requiredFees types.Currency) error {
+	return errors.AddContext(errLowMinerFees, fmt.Sprintf("set of %v bytes pays %v but needs to pay %v", setSize, setFees.HumanString(), requiredFees.HumanString()))
+}
+
 // requiredFeesToExtendTpoolAtSize returns the fees that should be required to
 // extend the transaction pool for a given size of transaction pool.
 //
@@ -193,7 +199,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []m
 	if requiredFees.Cmp(setFees) > 0 {
 		// TODO: check if there is an existing set with lower fees that we can
 		// kick out.
-		return nil, errLowMinerFees
+		return nil, lowMinerFeesError(setSize, setFees, requiredFees)
 	}
 
 	// Check that the transaction set is valid.
@@ -285,7 +291,12 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 		for _, txn := range ts {
 			tp.log.Debugln(txn.ID())
 		}
-		return nil, errLowMinerFees
+		return nil, lowMinerFeesError(setSize, setFees, requiredFees)
+	}
+
+	// If the set double spends transactions of the pool, try to replace them.
+	if doubleSpends := tp.doubleSpends(ts); len(doubleSpends) > 0 {
+		return tp.replaceTransactions(ts, doubleSpends, txnFn)
 	}
 
 	// Check for conflicts with other transactions, which would indicate a
@@ -333,20 +344,26 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 	return ts, nil
 }
 
-// submitTransactionSet will submit a transaction set to the transaction pool
-// and return the minimum superset for that transaction set.
-func (tp *TransactionPool) submitTransactionSet(ts []types.Transaction) ([]types.Transaction, error) {
+// lockedTryTransactionSet calls fn with a function that validates a
+// transaction set against the current consensus state. The consensus set is
+// locked while fn runs.
+func (tp *TransactionPool) lockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error {
 	// assert on consensus set to get special method
 	cs, ok := tp.consensusSet.(interface {
 		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
 	})
 	if !ok {
-		return nil, errors.New("consensus set does not support LockedTryTransactionSet method")
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
 	}
+	return cs.LockedTryTransactionSet(fn)
+}
 
+// submitTransactionSet will submit a transaction set to the transaction pool
+// and return the minimum superset for that transaction set.
+func (tp *TransactionPool) submitTransactionSet(ts []types.Transaction) ([]types.Transaction, error) {
 	var superset []types.Transaction
 	var acceptErr error
-	err := cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+	err := tp.lockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
 		tp.mu.Lock()
 		defer tp.mu.Unlock()
 
@@ -405,6 +422,27 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 	return nil
 }
 
+// SimulateAcceptTransactionSet checks whether a transaction set would be
+// accepted by AcceptTransactionSet. The transaction pool is left unchanged and
+// the set is not broadcast.
+func (tp *TransactionPool) SimulateAcceptTransactionSet(ts []types.Transaction) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	return tp.lockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+
+		// Accept the set and undo all changes to the pool afterwards.
+		snapshot := tp.snapshot()
+		defer tp.restore(snapshot)
+		_, err := tp.acceptTransactionSet(ts, txnFn)
+		return err
+	})
+}
+
 // relayTransactionSet is an RPC that accepts a transaction set from a peer. If
 // the accept is successful, the transaction will be relayed to the gateway's
 // other peers.
@@ -432,9 +470,12 @@ func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
 
 	// Read the transaction.
 	var ts []types.Transaction
-	err = encoding.ReadObject(conn, &ts, types.BlockSizeLimit)
+	err = modules.ReadPeerObject(conn, &ts, types.BlockSizeLimit)
 	if err != nil {
 		return err
 	}
+	if err := tp.managedCheckReplacementRate(conn.RPCAddr().Host(), ts); err != nil {
+		return err
+	}
 	return tp.AcceptTransactionSet(ts)
 }
@@ -130,6 +130,70 @@ func TestConflictingTransactionSets(t *testing.T) {
 	}
 }
 
+// TestSimulateAcceptTransactionSet checks that simulating the acceptance of a
+// transaction set doesn't change the transaction pool.
+func TestSimulateAcceptTransactionSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a set and a double spend with lower fees.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(fund); err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSetDoubleSpend := make([]types.Transaction, len(txnSet))
+	copy(txnSetDoubleSpend, txnSet)
+	txnIndex := len(txnSet) - 1
+	txnSet[txnIndex].MinerFees = append(txnSet[txnIndex].MinerFees, fund)
+	txnSetDoubleSpend[txnIndex].SiacoinOutputs = append(txnSetDoubleSpend[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+
+	// Simulating a valid set should succeed without adding it to the pool.
+	if err := tpt.tpool.SimulateAcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("simulation shouldn't add transactions to the pool")
+	}
+
+	// Once the set is accepted, simulating the double spend should fail
+	// without changing the pool.
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.SimulateAcceptTransactionSet(txnSetDoubleSpend)
+	if !errors.Contains(err, errReplacementFeeTooLow) {
+		t.Fatal("expected errReplacementFeeTooLow, got", err)
+	}
+	err = tpt.tpool.SimulateAcceptTransactionSet(txnSet)
+	if !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		t.Fatal("expected ErrDuplicateTransactionSet, got", err)
+	}
+	if len(tpt.tpool.TransactionList()) != len(txnSet) {
+		t.Fatal("simulation shouldn't change the pool")
+	}
+	if _, _, exists := tpt.tpool.Transaction(txnSet[txnIndex].ID()); !exists {
+		t.Fatal("simulation shouldn't change the pool")
+	}
+}
+
 // TestCheckMinerFees probes the checkMinerFees method of the
 // transaction pool.
 func TestCheckMinerFees(t *testing.T) {
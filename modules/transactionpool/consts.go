@@ -69,11 +69,42 @@ var (
 	}
 )
 
+// Variables related to fee estimation.
+var (
+	// feeEstimationTargetPercentiles are the fractions of the space of a block,
+	// starting from the lowest fee, which a transaction needs to outbid to be
+	// confirmed within 1, 2, 3, ... blocks. Unused block space counts as
+	// space paying no fee. The last percentile is used for all higher
+	// targets.
+	feeEstimationTargetPercentiles = []float64{0.9, 0.75, 0.5, 0.25}
+)
+
 // Variables related to the size and ease-of-entry of the transaction pool.
 var (
 	// minEstimation defines a sane minimum fee per byte for transactions.  This
 	// will typically be only suggested as a fee in the absence of congestion.
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
+	// feeHistogramMultipliers are the lower bounds of the buckets of the fee
+	// histogram as multiples of minEstimation.
+	feeHistogramMultipliers = []uint64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+
+	// replacementFeeIncrement is the fee per byte a transaction set needs to
+	// pay on top of the fees of the transactions it replaces. It prevents
+	// peers from flooding the network with replacements that barely increase
+	// the fees.
+	replacementFeeIncrement = minEstimation
+)
+
+// Constants related to the rate limiting of replacements relayed by peers.
+const (
+	// maxPeerReplacements is the number of transaction sets replacing
+	// unconfirmed transactions a peer may relay per peerReplacementWindow.
+	maxPeerReplacements = 10
+
+	// peerReplacementWindow is the window in which the replacements relayed
+	// by a peer are counted.
+	peerReplacementWindow = time.Minute
 )
 
 // Variables related to propagating transactions through the network.
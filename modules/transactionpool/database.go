@@ -74,8 +74,9 @@ type (
 	// medianPersist is the json object that gets stored in the database so that
 	// the transaction pool can persist its block based fee estimations.
 	medianPersist struct {
-		RecentMedians   []types.Currency
-		RecentMedianFee types.Currency
+		RecentMedians    []types.Currency
+		RecentMedianFee  types.Currency
+		RecentTargetFees [][]types.Currency
 	}
 )
 
@@ -176,6 +176,7 @@ func (tp *TransactionPool) initPersist() error {
 	if !errors.Contains(err, errNilFeeMedian) {
 		tp.recentMedians = mp.RecentMedians
 		tp.recentMedianFee = mp.RecentMedianFee
+		tp.recentTargetFees = mp.RecentTargetFees
 	}
 
 	// Subscribe to the consensus set using the most recent consensus change.
@@ -0,0 +1,469 @@
+package transactionpool
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errReplacementFeeTooLow is returned when a transaction set double spends
+	// transactions of the pool without paying enough fees to replace them.
+	errReplacementFeeTooLow = errors.New("transaction set double spends unconfirmed transactions without paying enough fees to replace them")
+
+	// errReplacementRateLimited is returned when a peer relays more
+	// transaction sets replacing unconfirmed transactions than allowed.
+	errReplacementRateLimited = errors.New("peer relayed too many replacements of unconfirmed transactions")
+
+	// errTransactionNotFound is returned when evicting a transaction that is
+	// not in the transaction pool.
+	errTransactionNotFound = errors.New("transaction is not in the transaction pool")
+)
+
+// tpoolSnapshot is a copy of the unconfirmed transactions of the pool. It is
+// used to undo all changes of a simulated acceptance of a transaction set.
+type tpoolSnapshot struct {
+	knownObjects        map[ObjectID]modules.TransactionSetID
+	transactionHeights  map[types.TransactionID]types.BlockHeight
+	transactionSets     map[modules.TransactionSetID][]types.Transaction
+	transactionSetDiffs map[modules.TransactionSetID]*modules.ConsensusChange
+	transactionListSize int
+}
+
+// peerReplacements counts the replacements relayed by a peer within the
+// current window.
+type peerReplacements struct {
+	count       int
+	windowStart time.Time
+}
+
+// evictionLog is an undo log of the changes made to the pool while evicting
+// transactions. Only the previous state of the affected sets, objects and
+// transactions is recorded, so undoing a failed replacement doesn't require
+// copying the whole pool.
+type evictionLog struct {
+	knownObjects        map[ObjectID]loggedObject
+	transactionHeights  map[types.TransactionID]types.BlockHeight
+	transactionSets     map[modules.TransactionSetID][]types.Transaction
+	transactionSetDiffs map[modules.TransactionSetID]*modules.ConsensusChange
+	transactionListSize int
+}
+
+// loggedObject is the state of a known object of the pool before it was
+// changed.
+type loggedObject struct {
+	setID  modules.TransactionSetID
+	exists bool
+}
+
+// snapshot returns a copy of the unconfirmed transactions of the pool.
+func (tp *TransactionPool) snapshot() tpoolSnapshot {
+	s := tpoolSnapshot{
+		knownObjects:        make(map[ObjectID]modules.TransactionSetID, len(tp.knownObjects)),
+		transactionHeights:  make(map[types.TransactionID]types.BlockHeight, len(tp.transactionHeights)),
+		transactionSets:     make(map[modules.TransactionSetID][]types.Transaction, len(tp.transactionSets)),
+		transactionSetDiffs: make(map[modules.TransactionSetID]*modules.ConsensusChange, len(tp.transactionSetDiffs)),
+		transactionListSize: tp.transactionListSize,
+	}
+	for k, v := range tp.knownObjects {
+		s.knownObjects[k] = v
+	}
+	for k, v := range tp.transactionHeights {
+		s.transactionHeights[k] = v
+	}
+	for k, v := range tp.transactionSets {
+		s.transactionSets[k] = v
+	}
+	for k, v := range tp.transactionSetDiffs {
+		s.transactionSetDiffs[k] = v
+	}
+	return s
+}
+
+// restore replaces the unconfirmed transactions of the pool with a snapshot.
+func (tp *TransactionPool) restore(s tpoolSnapshot) {
+	tp.knownObjects = s.knownObjects
+	tp.transactionHeights = s.transactionHeights
+	tp.transactionSets = s.transactionSets
+	tp.transactionSetDiffs = s.transactionSetDiffs
+	tp.transactionListSize = s.transactionListSize
+}
+
+// newEvictionLog returns an empty undo log for the current state of the pool.
+func (tp *TransactionPool) newEvictionLog() *evictionLog {
+	return &evictionLog{
+		knownObjects:        make(map[ObjectID]loggedObject),
+		transactionHeights:  make(map[types.TransactionID]types.BlockHeight),
+		transactionSets:     make(map[modules.TransactionSetID][]types.Transaction),
+		transactionSetDiffs: make(map[modules.TransactionSetID]*modules.ConsensusChange),
+		transactionListSize: tp.transactionListSize,
+	}
+}
+
+// logSet records the state of a transaction set before it is changed. A set
+// which doesn't exist is recorded as nil.
+func (tp *TransactionPool) logSet(l *evictionLog, setID modules.TransactionSetID) {
+	if _, logged := l.transactionSets[setID]; logged {
+		return
+	}
+	l.transactionSets[setID] = tp.transactionSets[setID]
+	l.transactionSetDiffs[setID] = tp.transactionSetDiffs[setID]
+}
+
+// logObject records the state of a known object before it is changed.
+func (tp *TransactionPool) logObject(l *evictionLog, oid ObjectID) {
+	if _, logged := l.knownObjects[oid]; logged {
+		return
+	}
+	setID, exists := tp.knownObjects[oid]
+	l.knownObjects[oid] = loggedObject{setID: setID, exists: exists}
+}
+
+// undo reverts the changes recorded in an eviction log.
+func (tp *TransactionPool) undo(l *evictionLog) {
+	for setID, set := range l.transactionSets {
+		if set == nil {
+			delete(tp.transactionSets, setID)
+			delete(tp.transactionSetDiffs, setID)
+			continue
+		}
+		tp.transactionSets[setID] = set
+		tp.transactionSetDiffs[setID] = l.transactionSetDiffs[setID]
+	}
+	for oid, o := range l.knownObjects {
+		if o.exists {
+			tp.knownObjects[oid] = o.setID
+		} else {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	for id, height := range l.transactionHeights {
+		tp.transactionHeights[id] = height
+	}
+	tp.transactionListSize = l.transactionListSize
+}
+
+// spentOutputs returns the siacoin and siafund outputs spent by the
+// transactions along with the ID of the transaction spending them.
+func spentOutputs(ts []types.Transaction) map[ObjectID]types.TransactionID {
+	spent := make(map[ObjectID]types.TransactionID)
+	for _, txn := range ts {
+		id := txn.ID()
+		for _, sci := range txn.SiacoinInputs {
+			spent[ObjectID(sci.ParentID)] = id
+		}
+		for _, sfi := range txn.SiafundInputs {
+			spent[ObjectID(sfi.ParentID)] = id
+		}
+	}
+	return spent
+}
+
+// doubleSpends returns the IDs of the transactions in the pool which spend an
+// output that is also spent by a different transaction of ts, along with the
+// IDs of the sets containing them.
+func (tp *TransactionPool) doubleSpends(ts []types.Transaction) map[types.TransactionID]modules.TransactionSetID {
+	doubleSpends := make(map[types.TransactionID]modules.TransactionSetID)
+	for oid, spender := range spentOutputs(ts) {
+		setID, exists := tp.knownObjects[oid]
+		if !exists {
+			continue
+		}
+		poolSpender, exists := spentOutputs(tp.transactionSets[setID])[oid]
+		if exists && poolSpender != spender {
+			doubleSpends[poolSpender] = setID
+		}
+	}
+	return doubleSpends
+}
+
+// removeTransactionSet removes a transaction set from the pool and records
+// the changes in the eviction log.
+func (tp *TransactionPool) removeTransactionSet(l *evictionLog, setID modules.TransactionSetID) {
+	tp.logSet(l, setID)
+	set := tp.transactionSets[setID]
+	oids := relatedObjectIDs(set)
+	if cc, exists := tp.transactionSetDiffs[setID]; exists {
+		for _, diff := range cc.SiacoinOutputDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+		for _, diff := range cc.FileContractDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+		for _, diff := range cc.SiafundOutputDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+	}
+	for _, oid := range oids {
+		if tp.knownObjects[oid] == setID {
+			tp.logObject(l, oid)
+			delete(tp.knownObjects, oid)
+		}
+	}
+	tp.transactionListSize -= len(encoding.Marshal(set))
+	delete(tp.transactionSets, setID)
+	delete(tp.transactionSetDiffs, setID)
+}
+
+// addTransactionSet adds a transaction set which is known to be valid on its
+// own to the pool and records the changes in the eviction log.
+func (tp *TransactionPool) addTransactionSet(l *evictionLog, set []types.Transaction, cc modules.ConsensusChange) {
+	setID := modules.TransactionSetID(crypto.HashObject(set))
+	tp.logSet(l, setID)
+	oids := relatedObjectIDs(set)
+	for _, diff := range cc.SiacoinOutputDiffs {
+		oids = append(oids, ObjectID(diff.ID))
+	}
+	for _, diff := range cc.FileContractDiffs {
+		oids = append(oids, ObjectID(diff.ID))
+	}
+	for _, diff := range cc.SiafundOutputDiffs {
+		oids = append(oids, ObjectID(diff.ID))
+	}
+	for _, oid := range oids {
+		tp.logObject(l, oid)
+		tp.knownObjects[oid] = setID
+	}
+	tp.transactionSets[setID] = set
+	tp.transactionSetDiffs[setID] = &cc
+	tp.transactionListSize += len(encoding.Marshal(set))
+}
+
+// planEviction returns the transactions which are removed when evicting the
+// transactions with the given IDs from the given sets, which includes the
+// transactions depending on them, and the transactions of the sets which are
+// kept. Dependent transactions are always merged into the same set, so only
+// the sets containing the evicted transactions need to be considered. The
+// pool isn't changed.
+func (tp *TransactionPool) planEviction(ids map[types.TransactionID]struct{}, setIDs map[modules.TransactionSetID]struct{}) ([]types.Transaction, map[modules.TransactionSetID][]types.Transaction) {
+	var removed []types.Transaction
+	kept := make(map[modules.TransactionSetID][]types.Transaction)
+	for setID := range setIDs {
+		// Sets are ordered by dependency, so the children of a removed
+		// transaction always come after it.
+		set := tp.transactionSets[setID]
+		created := make(map[ObjectID]struct{})
+		var setKept []types.Transaction
+		for _, txn := range set {
+			remove := false
+			if _, exists := ids[txn.ID()]; exists {
+				remove = true
+			}
+			for _, oid := range parentObjectIDs(txn) {
+				if _, exists := created[oid]; exists {
+					remove = true
+				}
+			}
+			if !remove {
+				setKept = append(setKept, txn)
+				continue
+			}
+			removed = append(removed, txn)
+			for i := range txn.SiacoinOutputs {
+				created[ObjectID(txn.SiacoinOutputID(uint64(i)))] = struct{}{}
+			}
+			for i := range txn.FileContracts {
+				created[ObjectID(txn.FileContractID(uint64(i)))] = struct{}{}
+			}
+			for i := range txn.SiafundOutputs {
+				created[ObjectID(txn.SiafundOutputID(uint64(i)))] = struct{}{}
+			}
+		}
+		if len(setKept) < len(set) {
+			kept[setID] = setKept
+		}
+	}
+	return removed, kept
+}
+
+// evictTransactions removes the transactions of an eviction plan from the
+// pool. The sets they were removed from are replaced by the transactions which
+// are kept. The changes are recorded in the eviction log, which has to be
+// undone if an error is returned.
+func (tp *TransactionPool) evictTransactions(l *evictionLog, removed []types.Transaction, kept map[modules.TransactionSetID][]types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+	for setID := range kept {
+		tp.removeTransactionSet(l, setID)
+	}
+	for _, txn := range removed {
+		id := txn.ID()
+		if height, exists := tp.transactionHeights[id]; exists {
+			if _, logged := l.transactionHeights[id]; !logged {
+				l.transactionHeights[id] = height
+			}
+			delete(tp.transactionHeights, id)
+		}
+	}
+
+	// Add the kept transactions back to the pool. They were valid together
+	// with the removed transactions and don't depend on them, so they are
+	// valid without them.
+	for _, set := range kept {
+		if len(set) == 0 {
+			continue
+		}
+		cc, err := txnFn(set)
+		if err != nil {
+			return errors.AddContext(err, "unable to keep the remaining transactions")
+		}
+		tp.addTransactionSet(l, set, cc)
+	}
+	return nil
+}
+
+// parentObjectIDs returns the IDs of the objects a transaction depends on.
+func parentObjectIDs(txn types.Transaction) []ObjectID {
+	var oids []ObjectID
+	for _, sci := range txn.SiacoinInputs {
+		oids = append(oids, ObjectID(sci.ParentID))
+	}
+	for _, fcr := range txn.FileContractRevisions {
+		oids = append(oids, ObjectID(fcr.ParentID))
+	}
+	for _, sp := range txn.StorageProofs {
+		oids = append(oids, ObjectID(sp.ParentID))
+	}
+	for _, sfi := range txn.SiafundInputs {
+		oids = append(oids, ObjectID(sfi.ParentID))
+	}
+	return oids
+}
+
+// minerFees returns the sum of the miner fees of the transactions.
+func minerFees(ts []types.Transaction) types.Currency {
+	var fees types.Currency
+	for _, txn := range ts {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return fees
+}
+
+// checkReplacementFees checks that a transaction set pays enough fees to
+// replace transactions of the pool. It needs to pay a higher fee per byte
+// than the replaced transactions, and at least their fees plus
+// replacementFeeIncrement for every byte of the set.
+func checkReplacementFees(ts, replaced []types.Transaction) error {
+	setSize := uint64(len(encoding.Marshal(ts)))
+	replacedSize := uint64(len(encoding.Marshal(replaced)))
+	setFees := minerFees(ts)
+	replacedFees := minerFees(replaced)
+
+	requiredFees := replacedFees.Add(replacementFeeIncrement.Mul64(setSize))
+	if setFees.Cmp(requiredFees) < 0 {
+		return errors.AddContext(errReplacementFeeTooLow, fmt.Sprintf("set pays %v but needs to pay at least %v", setFees.HumanString(), requiredFees.HumanString()))
+	}
+	if setFees.Mul64(replacedSize).Cmp(replacedFees.Mul64(setSize)) <= 0 {
+		return errors.AddContext(errReplacementFeeTooLow, "set needs to pay a higher fee per byte than the transactions it replaces")
+	}
+	return nil
+}
+
+// replaceTransactions replaces the transactions of the pool that are double
+// spent by a transaction set. The transactions depending on the replaced
+// transactions are removed as well. The fees are checked before the pool is
+// changed and the pool is left unchanged if the set can't replace them.
+func (tp *TransactionPool) replaceTransactions(ts []types.Transaction, doubleSpends map[types.TransactionID]modules.TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) ([]types.Transaction, error) {
+	ids := make(map[types.TransactionID]struct{}, len(doubleSpends))
+	setIDs := make(map[modules.TransactionSetID]struct{})
+	for id, setID := range doubleSpends {
+		ids[id] = struct{}{}
+		setIDs[setID] = struct{}{}
+	}
+	replaced, kept := tp.planEviction(ids, setIDs)
+	if err := checkReplacementFees(ts, replaced); err != nil {
+		return nil, err
+	}
+
+	l := tp.newEvictionLog()
+	err := tp.evictTransactions(l, replaced, kept, txnFn)
+	var superset []types.Transaction
+	if err == nil {
+		superset, err = tp.acceptTransactionSet(ts, txnFn)
+	}
+	if err != nil {
+		tp.undo(l)
+		return nil, err
+	}
+	for _, txn := range replaced {
+		tp.log.Debugln("Replaced transaction", txn.ID())
+	}
+	return superset, nil
+}
+
+// managedCheckReplacementRate checks whether a peer may relay a transaction
+// set. Sets replacing unconfirmed transactions count towards the limit of
+// maxPeerReplacements per peerReplacementWindow of the peer's host, since
+// replacing transactions is more expensive than accepting a set.
+func (tp *TransactionPool) managedCheckReplacementRate(host string, ts []types.Transaction) error {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	if len(tp.doubleSpends(ts)) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for h, pr := range tp.peerReplacements {
+		if now.Sub(pr.windowStart) > peerReplacementWindow {
+			delete(tp.peerReplacements, h)
+		}
+	}
+	pr, exists := tp.peerReplacements[host]
+	if !exists {
+		pr = &peerReplacements{windowStart: now}
+		tp.peerReplacements[host] = pr
+	}
+	if pr.count >= maxPeerReplacements {
+		return errReplacementRateLimited
+	}
+	pr.count++
+	return nil
+}
+
+// EvictTransaction removes a transaction and the unconfirmed transactions
+// depending on it from the transaction pool. The IDs of the removed
+// transactions are returned. Peers that still have the transaction may relay
+// it again.
+func (tp *TransactionPool) EvictTransaction(id types.TransactionID) ([]types.TransactionID, error) {
+	if err := tp.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer tp.tg.Done()
+
+	var evicted []types.TransactionID
+	err := tp.lockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+
+		// Find the set containing the transaction.
+		setIDs := make(map[modules.TransactionSetID]struct{})
+		for setID, set := range tp.transactionSets {
+			for _, txn := range set {
+				if txn.ID() == id {
+					setIDs[setID] = struct{}{}
+				}
+			}
+		}
+		removed, kept := tp.planEviction(map[types.TransactionID]struct{}{id: {}}, setIDs)
+		if len(removed) == 0 {
+			return errTransactionNotFound
+		}
+		l := tp.newEvictionLog()
+		if err := tp.evictTransactions(l, removed, kept, txnFn); err != nil {
+			tp.undo(l)
+			return err
+		}
+		for _, txn := range removed {
+			evicted = append(evicted, txn.ID())
+			tp.log.Debugln("Evicted transaction", txn.ID())
+		}
+		tp.updateSubscribersTransactions()
+		return nil
+	})
+	return evicted, err
+}
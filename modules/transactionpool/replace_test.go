@@ -0,0 +1,301 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestReplaceByFee checks that a transaction set double spending unconfirmed
+// transactions replaces them only if it pays enough additional fees.
+func TestReplaceByFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two sets spending the same output. The first transaction of the
+	// sets is shared, the second one either pays a miner fee or creates a
+	// siacoin output.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(fund); err != nil {
+		t.Fatal(err)
+	}
+	lowFeeSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lowFeeSet) != 2 {
+		t.Fatal("test is invalid unless the transaction set has two transactions")
+	}
+	highFeeSet := make([]types.Transaction, len(lowFeeSet))
+	copy(highFeeSet, lowFeeSet)
+	lowFeeSet[1].SiacoinOutputs = append(lowFeeSet[1].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	highFeeSet[1].MinerFees = append(highFeeSet[1].MinerFees, fund)
+
+	// The high fee set should replace the low fee set but keep the shared
+	// parent.
+	if err := tpt.tpool.AcceptTransactionSet(lowFeeSet); err != nil {
+		t.Fatal(err)
+	}
+	if err := tpt.tpool.AcceptTransactionSet(highFeeSet); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, exists := tpt.tpool.Transaction(lowFeeSet[1].ID()); exists {
+		t.Fatal("replaced transaction is still in the pool")
+	}
+	for _, txn := range highFeeSet {
+		if _, _, exists := tpt.tpool.Transaction(txn.ID()); !exists {
+			t.Fatal("replacement transaction is not in the pool")
+		}
+	}
+	if len(tpt.tpool.TransactionList()) != 2 {
+		t.Fatal("unexpected number of transactions", len(tpt.tpool.TransactionList()))
+	}
+
+	// The low fee set shouldn't be able to replace the high fee set.
+	err = tpt.tpool.AcceptTransactionSet(lowFeeSet)
+	if !errors.Contains(err, errReplacementFeeTooLow) {
+		t.Fatal("expected errReplacementFeeTooLow, got", err)
+	}
+	if _, _, exists := tpt.tpool.Transaction(highFeeSet[1].ID()); !exists {
+		t.Fatal("transaction was replaced by a transaction with lower fees")
+	}
+
+	// Neither should a set paying the same fees.
+	sameFeeSet := make([]types.Transaction, len(lowFeeSet))
+	copy(sameFeeSet, lowFeeSet)
+	sameFeeSet[1].SiacoinOutputs = nil
+	sameFeeSet[1].MinerFees = []types.Currency{fund.Div64(2), fund.Div64(2)}
+	err = tpt.tpool.AcceptTransactionSet(sameFeeSet)
+	if !errors.Contains(err, errReplacementFeeTooLow) {
+		t.Fatal("expected errReplacementFeeTooLow, got", err)
+	}
+
+	// The replacement should be mined.
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("transactions weren't mined")
+	}
+	confirmed, err := tpt.tpool.TransactionConfirmed(highFeeSet[1].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !confirmed {
+		t.Fatal("replacement transaction wasn't confirmed")
+	}
+}
+
+// TestReplaceByFeeUndo checks that the pool is left unchanged if a
+// replacement paying enough fees turns out to be invalid.
+func TestReplaceByFeeUndo(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a set and a replacement which spends more than its inputs.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(fund); err != nil {
+		t.Fatal(err)
+	}
+	set, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set) != 2 {
+		t.Fatal("test is invalid unless the transaction set has two transactions")
+	}
+	invalidSet := make([]types.Transaction, len(set))
+	copy(invalidSet, set)
+	set[1].SiacoinOutputs = append(set[1].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	invalidSet[1].MinerFees = append(invalidSet[1].MinerFees, fund.Mul64(2))
+	if err := tpt.tpool.AcceptTransactionSet(set); err != nil {
+		t.Fatal(err)
+	}
+	tpt.tpool.mu.Lock()
+	size := tpt.tpool.transactionListSize
+	numObjects := len(tpt.tpool.knownObjects)
+	tpt.tpool.mu.Unlock()
+
+	// The replacement should fail without changing the pool.
+	if err := tpt.tpool.AcceptTransactionSet(invalidSet); err == nil {
+		t.Fatal("invalid replacement was accepted")
+	}
+	for _, txn := range set {
+		if _, _, exists := tpt.tpool.Transaction(txn.ID()); !exists {
+			t.Fatal("transaction was removed from the pool")
+		}
+	}
+	tpt.tpool.mu.Lock()
+	defer tpt.tpool.mu.Unlock()
+	if len(tpt.tpool.transactionSets) != 1 || tpt.tpool.transactionListSize != size || len(tpt.tpool.knownObjects) != numObjects {
+		t.Fatal("pool was changed", len(tpt.tpool.transactionSets), tpt.tpool.transactionListSize, size, len(tpt.tpool.knownObjects), numObjects)
+	}
+	for _, txn := range set {
+		if _, exists := tpt.tpool.transactionHeights[txn.ID()]; !exists {
+			t.Fatal("height of transaction was removed")
+		}
+	}
+}
+
+// TestReplacementRateLimit checks that peers can only relay a limited number
+// of replacements.
+func TestReplacementRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(fund); err != nil {
+		t.Fatal(err)
+	}
+	set, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replacement := make([]types.Transaction, len(set))
+	copy(replacement, set)
+	set[1].SiacoinOutputs = append(set[1].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	replacement[1].MinerFees = append(replacement[1].MinerFees, fund)
+	if err := tpt.tpool.AcceptTransactionSet(set); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sets which don't replace transactions aren't limited.
+	for i := 0; i < 2*maxPeerReplacements; i++ {
+		if err := tpt.tpool.managedCheckReplacementRate("1.2.3.4", set); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Replacements are limited per host.
+	for i := 0; i < maxPeerReplacements; i++ {
+		if err := tpt.tpool.managedCheckReplacementRate("1.2.3.4", replacement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tpt.tpool.managedCheckReplacementRate("1.2.3.4", replacement); !errors.Contains(err, errReplacementRateLimited) {
+		t.Fatal("expected errReplacementRateLimited, got", err)
+	}
+	if err := tpt.tpool.managedCheckReplacementRate("5.6.7.8", replacement); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestEvictTransaction checks that evicting a transaction removes it and its
+// children from the pool.
+func TestEvictTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a set with a parent and a child transaction.
+	fund := types.SiacoinPrecision
+	txnBuilder, err := tpt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txnBuilder.FundSiacoins(fund); err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddMinerFee(fund)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnSet) != 2 {
+		t.Fatal("test is invalid unless the transaction set has two transactions")
+	}
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+
+	// Evicting the child should keep the parent.
+	evicted, err := tpt.tpool.EvictTransaction(txnSet[1].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 1 || evicted[0] != txnSet[1].ID() {
+		t.Fatal("unexpected evicted transactions", evicted)
+	}
+	if txns := tpt.tpool.TransactionList(); len(txns) != 1 || txns[0].ID() != txnSet[0].ID() {
+		t.Fatal("parent should still be in the pool", txns)
+	}
+
+	// Evicting the parent should also evict the child.
+	if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	evicted, err = tpt.tpool.EvictTransaction(txnSet[0].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != 2 {
+		t.Fatal("unexpected evicted transactions", evicted)
+	}
+	if txns := tpt.tpool.TransactionList(); len(txns) != 0 {
+		t.Fatal("pool should be empty", txns)
+	}
+	if _, err := tpt.tpool.EvictTransaction(txnSet[0].ID()); !errors.Contains(err, errTransactionNotFound) {
+		t.Fatal("expected errTransactionNotFound, got", err)
+	}
+
+	// Subscribers should have been notified.
+	unconfirmed, err := tpt.wallet.UnconfirmedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unconfirmed) != 0 {
+		t.Fatal("wallet still has unconfirmed transactions", len(unconfirmed))
+	}
+}
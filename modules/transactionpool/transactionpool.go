@@ -3,11 +3,13 @@ package transactionpool
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/demotemutex"
+	"gitlab.com/NebulousLabs/encoding"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -55,11 +57,19 @@ type (
 		transactionSetDiffs map[modules.TransactionSetID]*modules.ConsensusChange
 		transactionListSize int
 
+		// peerReplacements tracks the replacements of unconfirmed
+		// transactions relayed by each peer host to rate limit them.
+		peerReplacements map[string]*peerReplacements
+
 		// Variables related to the blockchain.
 		blockHeight     types.BlockHeight
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
 
+		// recentTargetFees contains the fees per byte of the recent blocks at
+		// the feeEstimationTargetPercentiles.
+		recentTargetFees [][]types.Currency
+
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
 		// transaction pool, all prior consensus changes are sent to the new
@@ -105,6 +115,7 @@ func NewCustomTPool(cs modules.ConsensusSet, g modules.Gateway, persistDir strin
 		transactionHeights:  make(map[types.TransactionID]types.BlockHeight),
 		transactionSets:     make(map[modules.TransactionSetID][]types.Transaction),
 		transactionSetDiffs: make(map[modules.TransactionSetID]*modules.ConsensusChange),
+		peerReplacements:    make(map[string]*peerReplacements),
 
 		deps:       deps,
 		persistDir: persistDir,
@@ -194,6 +205,66 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	return
 }
 
+// FeeEstimationForTarget returns the recommended fee per byte for a
+// transaction to be confirmed within 'target' blocks.
+func (tp *TransactionPool) FeeEstimationForTarget(target types.BlockHeight) types.Currency {
+	min, _ := tp.FeeEstimation()
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	return feeForTarget(tp.recentTargetFees, min, target)
+}
+
+// feeForTarget returns the median of the fees the recent blocks required at
+// the percentile of the confirmation target. The fee is never lower than the
+// minimum recommended fee.
+func feeForTarget(recentTargetFees [][]types.Currency, min types.Currency, target types.BlockHeight) types.Currency {
+	if len(recentTargetFees) == 0 {
+		return min
+	}
+	var i int
+	if target > 1 {
+		i = len(feeEstimationTargetPercentiles) - 1
+		if target <= types.BlockHeight(i) {
+			i = int(target) - 1
+		}
+	}
+	fees := make([]types.Currency, len(recentTargetFees))
+	for j, blockFees := range recentTargetFees {
+		fees[j] = blockFees[i]
+	}
+	sort.Slice(fees, func(i, j int) bool {
+		return fees[i].Cmp(fees[j]) < 0
+	})
+	if fee := fees[len(fees)/2]; fee.Cmp(min) > 0 {
+		return fee
+	}
+	return min
+}
+
+// FeeHistogram returns the distribution of the fees per byte paid by the
+// unconfirmed transaction sets. The buckets are sorted by increasing fee.
+func (tp *TransactionPool) FeeHistogram() []modules.FeeHistogramBucket {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+
+	buckets := make([]modules.FeeHistogramBucket, len(feeHistogramMultipliers))
+	for i, m := range feeHistogramMultipliers {
+		buckets[i].MinFeePerByte = minEstimation.Mul64(m)
+	}
+	for _, set := range tp.transactionSets {
+		size := uint64(len(encoding.Marshal(set)))
+		feePerByte := minerFees(set).Div64(size)
+		i := len(buckets) - 1
+		for buckets[i].MinFeePerByte.Cmp(feePerByte) > 0 {
+			i--
+		}
+		buckets[i].Sets++
+		buckets[i].Transactions += len(set)
+		buckets[i].Size += size
+	}
+	return buckets
+}
+
 // TransactionList returns a list of all transactions in the transaction pool.
 // The transactions are provided in an order that can acceptably be put into a
 // block.
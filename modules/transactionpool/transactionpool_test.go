@@ -4,6 +4,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
@@ -369,6 +370,126 @@ func TestFeeEstimation(t *testing.T) {
 	}
 }
 
+// TestFeeForTarget checks that the fee for a confirmation target is the median
+// of the fees of the recent blocks at the target's percentile.
+func TestFeeForTarget(t *testing.T) {
+	fees := func(fees ...uint64) []types.Currency {
+		c := make([]types.Currency, len(fees))
+		for i, fee := range fees {
+			c[i] = types.NewCurrency64(fee)
+		}
+		return c
+	}
+	recent := [][]types.Currency{
+		fees(900, 700, 500, 200),
+		fees(800, 600, 400, 100),
+		fees(1000, 800, 600, 300),
+	}
+	min := types.NewCurrency64(150)
+	tests := []struct {
+		target types.BlockHeight
+		fee    types.Currency
+	}{
+		{0, types.NewCurrency64(900)},
+		{1, types.NewCurrency64(900)},
+		{2, types.NewCurrency64(700)},
+		{3, types.NewCurrency64(500)},
+		{4, types.NewCurrency64(200)},
+		{100, types.NewCurrency64(200)},
+	}
+	for _, test := range tests {
+		if fee := feeForTarget(recent, min, test.target); !fee.Equals(test.fee) {
+			t.Errorf("target %v: expected fee %v, got %v", test.target, test.fee, fee)
+		}
+	}
+	// the fee should never be lower than the minimum
+	if fee := feeForTarget(recent, types.NewCurrency64(1000), 1); !fee.Equals64(1000) {
+		t.Error("expected the minimum fee, got", fee)
+	}
+	// without recent blocks the minimum is returned
+	if fee := feeForTarget(nil, min, 1); !fee.Equals(min) {
+		t.Error("expected the minimum fee, got", fee)
+	}
+}
+
+// TestFeeHistogram probes the FeeHistogram method of the transaction pool.
+func TestFeeHistogram(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The histogram of an empty pool should have empty buckets.
+	buckets := tpt.tpool.FeeHistogram()
+	if len(buckets) != len(feeHistogramMultipliers) {
+		t.Fatal("unexpected number of buckets", len(buckets))
+	}
+	for i, b := range buckets {
+		if b.Sets != 0 || b.Transactions != 0 || b.Size != 0 {
+			t.Fatal("expected empty bucket", b)
+		}
+		if i > 0 && b.MinFeePerByte.Cmp(buckets[i-1].MinFeePerByte) <= 0 {
+			t.Fatal("buckets should be sorted by increasing fee")
+		}
+	}
+
+	// Add a set without fees and a set with fees.
+	var sets [][]types.Transaction
+	for _, fee := range []types.Currency{types.ZeroCurrency, types.SiacoinPrecision} {
+		txnBuilder, err := tpt.wallet.StartTransaction()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := txnBuilder.FundSiacoins(fee.Add(types.SiacoinPrecision)); err != nil {
+			t.Fatal(err)
+		}
+		txnBuilder.AddSiacoinOutput(types.SiacoinOutput{Value: types.SiacoinPrecision})
+		if !fee.IsZero() {
+			txnBuilder.AddMinerFee(fee)
+		}
+		txnSet, err := txnBuilder.Sign(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tpt.tpool.AcceptTransactionSet(txnSet); err != nil {
+			t.Fatal(err)
+		}
+		sets = append(sets, txnSet)
+	}
+
+	// Each set should be in the bucket matching its fee per byte.
+	buckets = tpt.tpool.FeeHistogram()
+	var total int
+	for _, b := range buckets {
+		total += b.Sets
+	}
+	if total != len(sets) {
+		t.Fatal("expected every set to be in a bucket", buckets)
+	}
+	for _, set := range sets {
+		fee := modules.CalculateFee(set)
+		i := len(buckets) - 1
+		for buckets[i].MinFeePerByte.Cmp(fee) > 0 {
+			i--
+		}
+		b := buckets[i]
+		if b.Sets != 1 || b.Transactions != len(set) || b.Size != uint64(len(encoding.Marshal(set))) {
+			t.Fatal("unexpected bucket for fee", fee, b)
+		}
+	}
+	if buckets[0].Sets != 1 {
+		t.Fatal("set without fees should be in the first bucket", buckets[0])
+	}
+}
+
 // TestTpoolScalability fills the whole transaction pool with complex
 // transactions, then mines enough blocks to empty it out. Running sequentially,
 // the test should take less than 250ms per mb that the transaction pool fills
@@ -220,6 +220,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Strip out all of the transactions in this block.
 			tp.recentMedians = tp.recentMedians[:len(tp.recentMedians)-1]
 		}
+		if len(tp.recentTargetFees) > 0 {
+			tp.recentTargetFees = tp.recentTargetFees[:len(tp.recentTargetFees)-1]
+		}
 	}
 
 	for _, block := range cc.AppliedBlocks {
@@ -287,11 +290,28 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 		}
 
+		// Record the fees at the percentiles of the confirmation targets.
+		targetFees := make([]types.Currency, len(feeEstimationTargetPercentiles))
+		for i, percentile := range feeEstimationTargetPercentiles {
+			progress = 0
+			for _, fee := range fees {
+				progress += fee.size
+				if float64(progress) > float64(types.BlockSizeLimit)*percentile {
+					targetFees[i] = fee.fee
+					break
+				}
+			}
+		}
+		tp.recentTargetFees = append(tp.recentTargetFees, targetFees)
+
 		// If there are more than 10 blocks recorded in the txnsPerBlock, strip
 		// off the oldest blocks.
 		for len(tp.recentMedians) > blockFeeEstimationDepth {
 			tp.recentMedians = tp.recentMedians[1:]
 		}
+		for len(tp.recentTargetFees) > blockFeeEstimationDepth {
+			tp.recentTargetFees = tp.recentTargetFees[1:]
+		}
 	}
 	// Grab the median of the recent medians. Copy to a new slice so the sorting
 	// doesn't screw up the slice.
@@ -317,8 +337,9 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		tp.log.Println("ERROR: could not update the block height:", err)
 	}
 	err = tp.putFeeMedian(tp.dbTx, medianPersist{
-		RecentMedians:   tp.recentMedians,
-		RecentMedianFee: tp.recentMedianFee,
+		RecentMedians:    tp.recentMedians,
+		RecentMedianFee:  tp.recentMedianFee,
+		RecentTargetFees: tp.recentTargetFees,
 	})
 	if err != nil {
 		tp.log.Println("ERROR: could not update the transaction pool median fee information:", err)
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 	"unicode"
 
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
+	"golang.org/x/crypto/pbkdf2"
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
@@ -23,15 +25,41 @@ const (
 	// addresses to prevent accidental spending.
 	SeedChecksumSize = 6
 
+	// SeedPassphraseIterations is the number of PBKDF2 iterations used to
+	// derive a seed from a seed and a passphrase.
+	SeedPassphraseIterations = 100000
+
 	// WalletDir is the directory that contains the wallet persistence.
 	WalletDir = "wallet"
 )
 
+const (
+	// WalletEventUnconfirmed is emitted when a transaction affecting the
+	// wallet appears in the transaction pool.
+	WalletEventUnconfirmed = "unconfirmed"
+
+	// WalletEventConfirmed is emitted when a transaction affecting the
+	// wallet is confirmed in a block.
+	WalletEventConfirmed = "confirmed"
+
+	// WalletEventConfirmation is emitted when a recently confirmed
+	// transaction receives another confirmation.
+	WalletEventConfirmation = "confirmation"
+
+	// WalletEventReverted is emitted when the block confirming a transaction
+	// affecting the wallet is reverted.
+	WalletEventReverted = "reverted"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
 	ErrBadEncryptionKey = errors.New("provided encryption key is incorrect")
 
+	// ErrBadSeedPassphrase is returned when unlocking a wallet with the
+	// wrong seed passphrase.
+	ErrBadSeedPassphrase = errors.New("provided seed passphrase is incorrect")
+
 	// ErrIncompleteTransactions is returned if the wallet has incomplete
 	// transactions being built that are using all of the current outputs, and
 	// therefore the wallet is unable to spend money despite it not technically
@@ -46,6 +74,14 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrSeedPassphraseRequired is returned when unlocking a wallet which
+	// uses a seed passphrase without providing one.
+	ErrSeedPassphraseRequired = errors.New("wallet uses a seed passphrase, it must be provided to unlock the wallet")
+
+	// ErrWatchOnlyWallet is returned when an action requires a seed or secret
+	// keys but the wallet is watch-only.
+	ErrWatchOnlyWallet = errors.New("wallet is watch-only and has no seed or secret keys")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -117,6 +153,82 @@ type (
 		ConfirmedOutgoingValue types.Currency `json:"confirmedoutgoingvalue"`
 	}
 
+	// WalletRescanProgress describes the progress of the latest rescan
+	// started with Rescan.
+	WalletRescanProgress struct {
+		Rescanning   bool              `json:"rescanning"`
+		Cancelled    bool              `json:"cancelled"`
+		StartHeight  types.BlockHeight `json:"startheight"`
+		Height       types.BlockHeight `json:"height"`
+		TargetHeight types.BlockHeight `json:"targetheight"`
+		StartTime    time.Time         `json:"starttime"`
+		ETA          time.Duration     `json:"eta"`
+		Error        string            `json:"error,omitempty"`
+	}
+
+	// SweepOptions configures a seed sweep. If neither Coins nor Funds is
+	// set, all outputs of the seed are swept. Otherwise only the given
+	// amounts are swept and the remainder is sent back to the seed. If
+	// DryRun is set, the sweep is only planned and nothing is broadcast.
+	SweepOptions struct {
+		Coins  types.Currency
+		Funds  types.Currency
+		DryRun bool
+	}
+
+	// SweepOutput is an output found when scanning the blockchain for the
+	// outputs of a seed. Swept indicates whether the output is spent by the
+	// sweep.
+	SweepOutput struct {
+		ID         types.OutputID   `json:"id"`
+		FundType   types.Specifier  `json:"fundtype"`
+		UnlockHash types.UnlockHash `json:"unlockhash"`
+		Value      types.Currency   `json:"value"`
+		Swept      bool             `json:"swept"`
+	}
+
+	// SweepPlan describes a seed sweep. Coins and Funds are the amounts sent
+	// to the wallet and Fee is the total miner fee of the sweep. The
+	// remaining amounts stay with the seed. TransactionIDs is only set if the
+	// sweep was broadcast.
+	SweepPlan struct {
+		Outputs        []SweepOutput         `json:"outputs"`
+		Coins          types.Currency        `json:"coins"`
+		Funds          types.Currency        `json:"funds"`
+		Fee            types.Currency        `json:"fee"`
+		RemainingCoins types.Currency        `json:"remainingcoins"`
+		RemainingFunds types.Currency        `json:"remainingfunds"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// WalletHistoryEntry is a confirmed transaction of an exported wallet
+	// history. The balances are the running balances of the wallet after the
+	// transaction was confirmed. The untracked values are the parts of the
+	// outgoing values which the wallet received without a transaction in its
+	// history, e.g. contract payouts. They are added to the balances before
+	// the outgoing values are subtracted.
+	WalletHistoryEntry struct {
+		TransactionID         types.TransactionID `json:"transactionid"`
+		ConfirmationHeight    types.BlockHeight   `json:"confirmationheight"`
+		ConfirmationTimestamp types.Timestamp     `json:"confirmationtimestamp"`
+
+		SiacoinIncoming  types.Currency `json:"siacoinincoming"`
+		SiacoinUntracked types.Currency `json:"siacoinuntracked"`
+		SiacoinOutgoing  types.Currency `json:"siacoinoutgoing"`
+		SiacoinBalance   types.Currency `json:"siacoinbalance"`
+		MinerFee         types.Currency `json:"minerfee"`
+
+		SiafundIncoming  types.Currency `json:"siafundincoming"`
+		SiafundUntracked types.Currency `json:"siafunduntracked"`
+		SiafundOutgoing  types.Currency `json:"siafundoutgoing"`
+		SiafundBalance   types.Currency `json:"siafundbalance"`
+
+		// The fiat fields are only set if a fiat currency was requested.
+		FiatCurrency string  `json:"fiatcurrency,omitempty"`
+		FiatPrice    float64 `json:"fiatprice,omitempty"`
+		FiatValue    float64 `json:"fiatvalue,omitempty"`
+	}
+
 	// A UnspentOutput is a SiacoinOutput or SiafundOutput that the wallet
 	// is tracking.
 	UnspentOutput struct {
@@ -125,9 +237,87 @@ type (
 		UnlockHash         types.UnlockHash  `json:"unlockhash"`
 		Value              types.Currency    `json:"value"`
 		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
+		Frozen             bool              `json:"frozen"`
 		IsWatchOnly        bool              `json:"iswatchonly"`
 	}
 
+	// OfflineTransaction is an unsigned transaction together with all the
+	// data required to verify and sign it on an offline machine.
+	OfflineTransaction struct {
+		Transaction types.Transaction `json:"transaction"`
+		ToSign      []crypto.Hash     `json:"tosign"`
+
+		// Height is the height used to compute the signature hashes.
+		Height types.BlockHeight `json:"height"`
+
+		// Inputs are the outputs spent by the siacoin inputs of the
+		// transaction.
+		Inputs []OfflineTransactionInput `json:"inputs"`
+
+		// Parents are the transactions which created the outputs spent by
+		// the transaction, as far as they are known. They allow the signer to
+		// verify the values of Inputs.
+		Parents []types.Transaction `json:"parents"`
+
+		// SigHashes contains the signature hash of each of the transaction's
+		// TransactionSignatures.
+		SigHashes []crypto.Hash `json:"sighashes"`
+	}
+
+	// OfflineTransactionInput is an output spent by an OfflineTransaction.
+	OfflineTransactionInput struct {
+		ParentID   types.SiacoinOutputID `json:"parentid"`
+		UnlockHash types.UnlockHash      `json:"unlockhash"`
+		Value      types.Currency        `json:"value"`
+	}
+
+	// WalletEvent notifies about a transaction affecting the addresses of the
+	// wallet. Addresses contains the wallet addresses the transaction spends
+	// from or sends to. Confirmations is 0 for unconfirmed and reverted
+	// transactions.
+	WalletEvent struct {
+		Type               string              `json:"type"`
+		TransactionID      types.TransactionID `json:"transactionid"`
+		Addresses          []types.UnlockHash  `json:"addresses"`
+		SiacoinIncoming    types.Currency      `json:"siacoinincoming"`
+		SiacoinOutgoing    types.Currency      `json:"siacoinoutgoing"`
+		SiafundIncoming    types.Currency      `json:"siafundincoming"`
+		SiafundOutgoing    types.Currency      `json:"siafundoutgoing"`
+		ConfirmationHeight types.BlockHeight   `json:"confirmationheight"`
+		Confirmations      uint64              `json:"confirmations"`
+		Timestamp          time.Time           `json:"timestamp"`
+	}
+
+	// WalletEventSubscriber receives the events of a wallet.
+	WalletEventSubscriber interface {
+		// ReceiveWalletEvent is called for every event of the wallet. It is
+		// called while the wallet is locked and must not block.
+		ReceiveWalletEvent(WalletEvent)
+	}
+
+	// WalletWebhook is a URL the wallet POSTs its events to.
+	WalletWebhook struct {
+		URL string `json:"url"`
+	}
+
+	// PriceSource provides the fiat price of a siacoin at a point in time. It
+	// is used to value the transactions of an exported wallet history.
+	PriceSource interface {
+		// SiacoinPrice returns the price of one siacoin in the given fiat
+		// currency at the given time.
+		SiacoinPrice(currency string, timestamp types.Timestamp) (float64, error)
+	}
+
+	// TransactionSigner signs transactions using secret keys which are kept
+	// outside of the wallet, e.g. on a hardware wallet.
+	TransactionSigner interface {
+		// SignTransaction signs the TransactionSignature at sigIndex of txn
+		// with the secret key belonging to pk. The signer receives the whole
+		// transaction, which allows it to show the outputs and fees to the
+		// user and to compute the signature hash at height itself.
+		SignTransaction(txn types.Transaction, sigIndex int, height types.BlockHeight, pk types.SiaPublicKey) (crypto.Signature, error)
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -278,6 +468,12 @@ type (
 		// a different directory or deleted.
 		Encrypt(masterKey crypto.CipherKey) (Seed, error)
 
+		// EncryptWithPassphrase is the same as Encrypt, but the keys of the
+		// wallet are derived from the seed and the passphrase. The seed alone
+		// is not sufficient to recover the wallet's keys and the passphrase
+		// must be provided every time the wallet is unlocked.
+		EncryptWithPassphrase(masterKey crypto.CipherKey, passphrase string) (Seed, error)
+
 		// Reset will reset the wallet, clearing the database and returning it to
 		// the unencrypted state. Reset can only be called on a wallet that has
 		// already been encrypted.
@@ -294,6 +490,16 @@ type (
 		// until the blockchain is fully synced.
 		InitFromSeed(masterKey crypto.CipherKey, seed Seed) error
 
+		// InitFromSeedWithPassphrase is the same as InitFromSeed, but the keys
+		// of the wallet are derived from the seed and the passphrase.
+		InitFromSeedWithPassphrase(masterKey crypto.CipherKey, seed Seed, passphrase string) error
+
+		// InitWatchOnly encrypts the wallet using the input key without
+		// creating a seed. A watch-only wallet has no secret keys, it only
+		// tracks the addresses and public keys which are added to its watch
+		// set and can build unsigned transactions spending from them.
+		InitWatchOnly(masterKey crypto.CipherKey) error
+
 		// Lock deletes all keys in memory and prevents the wallet from being
 		// used to spend coins or extract keys until 'Unlock' is called.
 		Lock() error
@@ -318,6 +524,14 @@ type (
 		// derived from the master key.
 		UnlockAsync(masterKey crypto.CipherKey) <-chan error
 
+		// UnlockWithPassphrase is the same as Unlock, but for wallets which
+		// were initialized with a seed passphrase.
+		UnlockWithPassphrase(masterKey crypto.CipherKey, passphrase string) error
+
+		// UnlockAsyncWithPassphrase is the same as UnlockAsync, but for
+		// wallets which were initialized with a seed passphrase.
+		UnlockAsyncWithPassphrase(masterKey crypto.CipherKey, passphrase string) <-chan error
+
 		// ChangeKey changes the wallet's materKey from masterKey to newKey,
 		// re-encrypting the wallet with the provided key.
 		ChangeKey(masterKey crypto.CipherKey, newKey crypto.CipherKey) error
@@ -326,6 +540,10 @@ type (
 		// the wallet.
 		IsMasterKey(masterKey crypto.CipherKey) (bool, error)
 
+		// SeedPassphraseRequired returns whether the wallet was initialized
+		// with a seed passphrase which must be provided to unlock it.
+		SeedPassphraseRequired() (bool, error)
+
 		// ChangeKeyWithSeed is the same as ChangeKey but uses the primary seed
 		// instead of the current masterKey.
 		ChangeKeyWithSeed(seed Seed, newKey crypto.CipherKey) error
@@ -395,6 +613,12 @@ type (
 		// generated from the seed.
 		PrimarySeed() (Seed, uint64, error)
 
+		// PrimaryKeySeed returns the seed the keys of the wallet and the
+		// renter are derived from. It is derived from the primary seed and the
+		// seed passphrase, or equal to the primary seed if the wallet doesn't
+		// use a passphrase.
+		PrimaryKeySeed() (Seed, error)
+
 		// SignTransaction signs txn using secret keys known to the wallet.
 		// The transaction should be complete with the exception of the
 		// Signature fields of each TransactionSignature referenced by toSign.
@@ -406,6 +630,12 @@ type (
 		// outputs, minus the fee. If only siafunds were found, the fee is
 		// deducted from the wallet.
 		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
+
+		// SweepSeedWithOptions scans the blockchain for outputs generated
+		// from seed and sweeps them into the wallet according to opts. It
+		// returns the plan of the sweep, which allows for reviewing the
+		// outputs and fees of a sweep before broadcasting it.
+		SweepSeedWithOptions(seed Seed, opts SweepOptions) (SweepPlan, error)
 	}
 
 	// SiacoinSenderMulti is the minimal interface for an object that can send
@@ -423,6 +653,9 @@ type (
 		EncryptionManager
 		KeyManager
 
+		// AddressLabels returns the labels of all labeled addresses.
+		AddressLabels() (map[types.UnlockHash]string, error)
+
 		// AddUnlockConditions adds a set of UnlockConditions to the wallet database.
 		AddUnlockConditions(uc types.UnlockConditions) error
 
@@ -433,6 +666,23 @@ type (
 		// the blockchain to search for transactions containing the addresses.
 		AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
+		// AddWatchPublicKeys adds the standard addresses of the given public
+		// keys to the watch set and stores their unlock conditions, which
+		// allows the wallet to build unsigned transactions spending from
+		// them.
+		AddWatchPublicKeys(pks []types.SiaPublicKey, unused bool) error
+
+		// BuildUnsignedTransaction builds a transaction which sends the given
+		// outputs, funded by watched outputs with known unlock conditions.
+		// Any change is sent to changeAddr. The returned transaction contains
+		// empty signatures for the returned parent ids, which have to be
+		// signed offline before the transaction can be broadcast.
+		BuildUnsignedTransaction(outputs []types.SiacoinOutput, fee types.Currency, changeAddr types.UnlockHash) (types.Transaction, []crypto.Hash, error)
+
+		// BroadcastOfflineTransaction verifies that an OfflineTransaction
+		// was fully signed and submits it to the transaction pool.
+		BroadcastOfflineTransaction(otxn OfflineTransaction) (types.TransactionID, error)
+
 		// Close permits clean shutdown during testing and serving.
 		Close() error
 
@@ -441,6 +691,30 @@ type (
 		// refund transactions.
 		ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siacoinClaimBalance types.Currency, err error)
 
+		// FreezeOutputs prevents the wallet from using the given outputs to
+		// fund transactions until they are unfrozen.
+		FreezeOutputs(ids []types.OutputID) error
+
+		// ExportOfflineTransaction creates an OfflineTransaction containing
+		// the data required to sign txn on an offline machine.
+		ExportOfflineTransaction(txn types.Transaction, toSign []crypto.Hash) (OfflineTransaction, error)
+
+		// LabelBalance returns the confirmed siacoin and siafund balance of
+		// the addresses with the given label.
+		LabelBalance(label string) (siacoinBalance, siafundBalance types.Currency, err error)
+
+		// LabelTransactions returns the confirmed and unconfirmed transactions
+		// related to the addresses with the given label.
+		LabelTransactions(label string) (confirmed, unconfirmed []ProcessedTransaction, err error)
+
+		// SetAddressLabel assigns a label to an address. Addresses sharing a
+		// label form an account. An empty label removes the label.
+		SetAddressLabel(addr types.UnlockHash, label string) error
+
+		// UnfreezeOutputs allows the wallet to use the given outputs to fund
+		// transactions again.
+		UnfreezeOutputs(ids []types.OutputID) error
+
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
 		// Outgoing funds and incoming funds are reported separately. Refund
 		// outputs are included, meaning that sending a single coin to
@@ -464,6 +738,14 @@ type (
 		// wallet only stores transactions that are related to the wallet.
 		Transaction(types.TransactionID) (ProcessedTransaction, bool, error)
 
+		// History returns the confirmed transactions of the wallet with
+		// timestamps in [start, end] together with the running balances of
+		// the wallet. The first 'offset' entries are skipped and at most
+		// 'limit' entries are returned, unless limit is 0. If currency is not
+		// empty, the entries are valued in that fiat currency using the
+		// wallet's price source.
+		History(start, end types.Timestamp, offset, limit uint64, currency string) ([]WalletHistoryEntry, error)
+
 		// Transactions returns all of the transactions that were confirmed at
 		// heights [startHeight, endHeight]. Unconfirmed transactions are not
 		// included.
@@ -484,6 +766,20 @@ type (
 		// rebuild its transaction history.
 		RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
+		// Rescan rescans the blockchain starting at startHeight in the
+		// background, e.g. to find the outputs of keys which were imported
+		// without a rescan. A start height of 0 rescans the whole blockchain.
+		Rescan(startHeight types.BlockHeight) error
+
+		// RescanProgress returns the progress of the latest rescan started
+		// with Rescan.
+		RescanProgress() (WalletRescanProgress, error)
+
+		// CancelRescan cancels the rescan started with Rescan. The wallet
+		// resumes the rescan when it's unlocked again or when a new rescan
+		// is started.
+		CancelRescan() error
+
 		// Rescanning reports whether the wallet is currently rescanning the
 		// blockchain.
 		Rescanning() (bool, error)
@@ -507,8 +803,26 @@ type (
 		// SendSiacoinsFeeIncluded sends siacoins with fees included.
 		SendSiacoinsFeeIncluded(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
+		// SendSiacoinsFeeIncludedTarget is like SendSiacoinsFeeIncluded, but
+		// uses a fee that targets the transaction to be confirmed within
+		// 'target' blocks.
+		SendSiacoinsFeeIncludedTarget(amount types.Currency, dest types.UnlockHash, target types.BlockHeight) ([]types.Transaction, error)
+
+		// SendSiacoinsFromOutputs sends siacoins to the given outputs using
+		// exactly the given wallet outputs as inputs. Any change is sent to
+		// changeAddr, or to a new wallet address if changeAddr is empty.
+		SendSiacoinsFromOutputs(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput, changeAddr types.UnlockHash) ([]types.Transaction, error)
+
 		SiacoinSenderMulti
 
+		// SendSiacoinsMultiTarget is like SendSiacoinsMulti, but uses a fee
+		// that targets the transaction to be confirmed within 'target' blocks.
+		SendSiacoinsMultiTarget(outputs []types.SiacoinOutput, target types.BlockHeight) ([]types.Transaction, error)
+
+		// SendSiacoinsTarget is like SendSiacoins, but uses a fee that targets
+		// the transaction to be confirmed within 'target' blocks.
+		SendSiacoinsTarget(amount types.Currency, dest types.UnlockHash, target types.BlockHeight) ([]types.Transaction, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -519,6 +833,22 @@ type (
 		// considered to be Dust.
 		DustThreshold() (types.Currency, error)
 
+		// SubscribeEvents subscribes s to the events of the wallet.
+		SubscribeEvents(s WalletEventSubscriber) error
+
+		// UnsubscribeEvents removes s from the subscribers of the wallet's
+		// events.
+		UnsubscribeEvents(s WalletEventSubscriber)
+
+		// AddWebhook adds a webhook the wallet POSTs its events to.
+		AddWebhook(url string) error
+
+		// RemoveWebhook removes the webhook with the given url.
+		RemoveWebhook(url string) error
+
+		// Webhooks returns the webhooks of the wallet.
+		Webhooks() ([]WalletWebhook, error)
+
 		// UnspentOutputs returns the unspent outputs tracked by the wallet.
 		UnspentOutputs() ([]UnspentOutput, error)
 
@@ -529,6 +859,9 @@ type (
 		// WatchAddresses returns the set of addresses that the wallet is
 		// currently watching.
 		WatchAddresses() ([]types.UnlockHash, error)
+
+		// WatchOnly returns whether or not the wallet is watch-only.
+		WatchOnly() (bool, error)
 	}
 
 	// WalletSettings control the behavior of the Wallet.
@@ -607,3 +940,16 @@ func StringToSeed(str string, did mnemonics.DictionaryID) (Seed, error) {
 	}
 	return seed, nil
 }
+
+// SeedWithPassphrase derives the seed the keys of a wallet are generated from
+// using the wallet's seed and a passphrase. An empty passphrase returns the
+// seed unchanged.
+func SeedWithPassphrase(seed Seed, passphrase string) Seed {
+	if passphrase == "" {
+		return seed
+	}
+	var derived Seed
+	salt := append([]byte("sia seed passphrase"), passphrase...)
+	copy(derived[:], pbkdf2.Key(seed[:], salt, SeedPassphraseIterations, len(derived), crypto.NewHash))
+	return derived
+}
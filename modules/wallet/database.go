@@ -26,6 +26,12 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketAddressLabels maps an UnlockHash to the label the user assigned to
+	// it. Addresses sharing a label form an account.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketFrozenOutputs contains the OutputIDs of the outputs that the user
+	// froze. The wallet doesn't use frozen outputs to fund transactions.
+	bucketFrozenOutputs = []byte("bucketFrozenOutputs")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -46,16 +52,22 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketWebhooks maps the URL of a webhook to the webhook. The wallet
+	// POSTs its events to the webhooks.
+	bucketWebhooks = []byte("bucketWebhooks")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketAddressLabels,
+		bucketFrozenOutputs,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
 		bucketUnlockConditions,
 		bucketWallet,
+		bucketWebhooks,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -70,8 +82,10 @@ var (
 	keySiafundPool            = []byte("keySiafundPool")
 	keySpendableKeyFiles      = []byte("keySpendableKeyFiles")
 	keySalt                   = []byte("keyUID")
+	keySeedPassphrase         = []byte("keySeedPassphrase")
 	keyWalletPassword         = []byte("keyWalletPassword")
 	keyWatchedAddrs           = []byte("keyWatchedAddrs")
+	keyWatchOnly              = []byte("keyWatchOnly")
 )
 
 // threadedDBUpdate commits the active database transaction and starts a new
@@ -232,6 +246,36 @@ func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
 
+func dbPutAddressLabel(tx *bolt.Tx, addr types.UnlockHash, label string) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), addr, label)
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutWebhook(tx *bolt.Tx, hook modules.WalletWebhook) error {
+	return dbPut(tx.Bucket(bucketWebhooks), hook.URL, hook)
+}
+func dbDeleteWebhook(tx *bolt.Tx, url string) error {
+	return dbDelete(tx.Bucket(bucketWebhooks), url)
+}
+func dbForEachWebhook(tx *bolt.Tx, fn func(string, modules.WalletWebhook)) error {
+	return dbForEach(tx.Bucket(bucketWebhooks), fn)
+}
+
+func dbPutFrozenOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbPut(tx.Bucket(bucketFrozenOutputs), id, true)
+}
+func dbIsFrozenOutput(tx *bolt.Tx, id types.OutputID) bool {
+	return tx.Bucket(bucketFrozenOutputs).Get(encoding.Marshal(id)) != nil
+}
+func dbDeleteFrozenOutput(tx *bolt.Tx, id types.OutputID) error {
+	return dbDelete(tx.Bucket(bucketFrozenOutputs), id)
+}
+
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
 }
@@ -23,6 +23,10 @@ var (
 	errScanInProgress    = errors.New("another wallet rescan is already underway")
 	errUnencryptedWallet = errors.New("wallet has not been encrypted yet")
 
+	// errSeedPassphraseNotUsed is returned when unlocking a wallet which
+	// doesn't use a seed passphrase with a passphrase.
+	errSeedPassphraseNotUsed = errors.New("wallet doesn't use a seed passphrase")
+
 	// verificationPlaintext is the plaintext used to verify encryption keys.
 	// By storing the corresponding ciphertext for a given key, we can later
 	// verify that a key is correct by using it to decrypt the ciphertext and
@@ -47,6 +51,14 @@ func walletPasswordEncryptionKey(seed modules.Seed, salt walletSalt) (key crypto
 	return
 }
 
+// seedPassphraseKey creates an encryption key that is used to verify the seed
+// passphrase of the wallet. It is derived from the seed the wallet's keys are
+// generated from.
+func seedPassphraseKey(keySeed modules.Seed, salt walletSalt) (key crypto.CipherKey) {
+	key = crypto.NewWalletKey(crypto.HashAll("seedpassphrase", keySeed, salt))
+	return
+}
+
 // verifyEncryption verifies that key properly decrypts the ciphertext to a
 // preset plaintext.
 func verifyEncryption(key crypto.CipherKey, encrypted crypto.Ciphertext) error {
@@ -72,8 +84,28 @@ func checkMasterKey(tx *bolt.Tx, masterKey crypto.CipherKey) error {
 	return verifyEncryption(uk, encryptedVerification)
 }
 
-// initEncryption initializes and encrypts the primary SeedFile.
-func (w *Wallet) initEncryption(masterKey crypto.CipherKey, seed modules.Seed, progress uint64) (modules.Seed, error) {
+// checkSeedPassphrase verifies the seed passphrase of the wallet and returns
+// the seed the primary keys are derived from.
+func checkSeedPassphrase(tx *bolt.Tx, seed modules.Seed, passphrase string) (modules.Seed, error) {
+	encryptedVerification := tx.Bucket(bucketWallet).Get(keySeedPassphrase)
+	if encryptedVerification == nil {
+		if passphrase != "" {
+			return modules.Seed{}, errSeedPassphraseNotUsed
+		}
+		return seed, nil
+	} else if passphrase == "" {
+		return modules.Seed{}, modules.ErrSeedPassphraseRequired
+	}
+	keySeed := modules.SeedWithPassphrase(seed, passphrase)
+	if err := verifyEncryption(seedPassphraseKey(keySeed, dbGetWalletSalt(tx)), encryptedVerification); err != nil {
+		return modules.Seed{}, modules.ErrBadSeedPassphrase
+	}
+	return keySeed, nil
+}
+
+// initEncryption initializes and encrypts the primary SeedFile. If passphrase
+// isn't empty, the wallet's keys are derived from the seed and the passphrase.
+func (w *Wallet) initEncryption(masterKey crypto.CipherKey, seed modules.Seed, passphrase string, progress uint64) (modules.Seed, error) {
 	wb := w.dbTx.Bucket(bucketWallet)
 	// Check if the wallet encryption key has already been set.
 	if wb.Get(keyEncryptionVerification) != nil {
@@ -111,6 +143,16 @@ func (w *Wallet) initEncryption(masterKey crypto.CipherKey, seed modules.Seed, p
 		return modules.Seed{}, err
 	}
 
+	// Store the verification of the seed passphrase. The passphrase itself
+	// is never stored.
+	if passphrase != "" {
+		spk := seedPassphraseKey(modules.SeedWithPassphrase(seed, passphrase), dbGetWalletSalt(w.dbTx))
+		err = wb.Put(keySeedPassphrase, spk.EncryptBytes(verificationPlaintext))
+		if err != nil {
+			return modules.Seed{}, err
+		}
+	}
+
 	// on future startups, this field will be set by w.initPersist
 	w.encrypted = true
 
@@ -147,11 +189,11 @@ func (w *Wallet) managedMasterKey(seed modules.Seed) (crypto.CipherKey, error) {
 // managedUnlock loads all of the encrypted file structures into wallet memory. Even
 // after loading, the structures are kept encrypted, but some data such as
 // addresses are decrypted so that the wallet knows what to track.
-func (w *Wallet) managedUnlock(masterKey crypto.CipherKey) <-chan error {
+func (w *Wallet) managedUnlock(masterKey crypto.CipherKey, passphrase string) <-chan error {
 	errChan := make(chan error, 1)
 
 	// Blocking unlock
-	lastChange, err := w.managedBlockingUnlock(masterKey)
+	lastChange, err := w.managedBlockingUnlock(masterKey, passphrase)
 	if err != nil {
 		errChan <- err
 		return errChan
@@ -177,15 +219,18 @@ func (w *Wallet) managedUnlock(masterKey crypto.CipherKey) <-chan error {
 }
 
 // managedBlockingUnlock handles the blocking part of hte managedUnlock method.
-func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.ConsensusChangeID, error) {
+func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey, passphrase string) (modules.ConsensusChangeID, error) {
 	w.mu.RLock()
 	unlocked := w.unlocked
 	encrypted := w.encrypted
+	watchOnly := w.watchOnly
 	w.mu.RUnlock()
 	if unlocked {
 		return modules.ConsensusChangeID{}, errAlreadyUnlocked
 	} else if !encrypted {
 		return modules.ConsensusChangeID{}, errUnencryptedWallet
+	} else if watchOnly {
+		return w.managedBlockingUnlockWatchOnly(masterKey)
 	}
 
 	// Load db objects into memory.
@@ -253,8 +298,13 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 		if err != nil {
 			return err
 		}
-		w.integrateSeed(primarySeed, primarySeedProgress)
+		primaryKeySeed, err := checkSeedPassphrase(w.dbTx, primarySeed, passphrase)
+		if err != nil {
+			return err
+		}
+		w.integrateSeed(primaryKeySeed, primarySeedProgress)
 		w.primarySeed = primarySeed
+		w.primaryKeySeed = primaryKeySeed
 		w.regenerateLookahead(primarySeedProgress)
 
 		// auxiliarySeedFiles
@@ -302,6 +352,33 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 	return lastChange, nil
 }
 
+// managedBlockingUnlockWatchOnly handles the blocking part of the
+// managedUnlock method for watch-only wallets, which only need to load their
+// watched addresses.
+func (w *Wallet) managedBlockingUnlockWatchOnly(masterKey crypto.CipherKey) (modules.ConsensusChangeID, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// verify masterKey
+	err := checkMasterKey(w.dbTx, masterKey)
+	if err != nil {
+		return modules.ConsensusChangeID{}, err
+	}
+
+	// watchedAddrs
+	var watchedAddrs []types.UnlockHash
+	err = encoding.Unmarshal(w.dbTx.Bucket(bucketWallet).Get(keyWatchedAddrs), &watchedAddrs)
+	if err != nil {
+		return modules.ConsensusChangeID{}, err
+	}
+	for _, addr := range watchedAddrs {
+		w.watchedAddrs[addr] = struct{}{}
+	}
+
+	w.unlocked = true
+	return dbGetConsensusChangeID(w.dbTx), nil
+}
+
 // managedAsyncUnlock handles the async part of hte managedUnlock method.
 func (w *Wallet) managedAsyncUnlock(lastChange modules.ConsensusChangeID) error {
 	// Subscribe to the consensus set if this is the first unlock for the
@@ -380,6 +457,7 @@ func (w *Wallet) wipeSecrets() {
 		crypto.SecureWipe(w.seeds[i][:])
 	}
 	crypto.SecureWipe(w.primarySeed[:])
+	crypto.SecureWipe(w.primaryKeySeed[:])
 	w.seeds = w.seeds[:0]
 }
 
@@ -406,6 +484,13 @@ func (w *Wallet) Encrypted() (bool, error) {
 // reset the wallet, the wallet files must be moved to a different directory
 // or deleted.
 func (w *Wallet) Encrypt(masterKey crypto.CipherKey) (modules.Seed, error) {
+	return w.EncryptWithPassphrase(masterKey, "")
+}
+
+// EncryptWithPassphrase is the same as Encrypt, but the keys of the wallet
+// are derived from the primary seed and the passphrase. The passphrase must be
+// provided every time the wallet is unlocked.
+func (w *Wallet) EncryptWithPassphrase(masterKey crypto.CipherKey, passphrase string) (modules.Seed, error) {
 	if err := w.tg.Add(); err != nil {
 		return modules.Seed{}, err
 	}
@@ -422,7 +507,51 @@ func (w *Wallet) Encrypt(masterKey crypto.CipherKey) (modules.Seed, error) {
 		masterKey = crypto.NewWalletKey(crypto.HashObject(seed))
 	}
 	// Initial seed progress is 0.
-	return w.initEncryption(masterKey, seed, 0)
+	return w.initEncryption(masterKey, seed, passphrase, 0)
+}
+
+// InitWatchOnly encrypts the wallet using masterKey without creating a seed.
+// The resulting wallet has no secret keys, it only tracks the addresses and
+// public keys added to its watch set. The wallet will still be locked after
+// InitWatchOnly is called.
+func (w *Wallet) InitWatchOnly(masterKey crypto.CipherKey) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	if masterKey == nil {
+		return modules.ErrBadEncryptionKey
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wb := w.dbTx.Bucket(bucketWallet)
+	if wb.Get(keyEncryptionVerification) != nil {
+		return errReencrypt
+	}
+	err := wb.Put(keyWatchOnly, []byte{1})
+	if err != nil {
+		return err
+	}
+	uk := saltedEncryptionKey(masterKey, dbGetWalletSalt(w.dbTx))
+	err = wb.Put(keyEncryptionVerification, uk.EncryptBytes(verificationPlaintext))
+	if err != nil {
+		return err
+	}
+	w.encrypted = true
+	w.watchOnly = true
+	return nil
+}
+
+// WatchOnly returns whether or not the wallet is watch-only.
+func (w *Wallet) WatchOnly() (bool, error) {
+	if err := w.tg.Add(); err != nil {
+		return false, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.watchOnly, nil
 }
 
 // Reset will reset the wallet, clearing the database and returning it to
@@ -454,8 +583,12 @@ func (w *Wallet) Reset() error {
 	w.lookahead = make(map[types.UnlockHash]uint64)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
+	w.eventsUnconfirmed = make(map[types.TransactionID]struct{})
+	w.eventsConfirming = make(map[types.TransactionID]modules.WalletEvent)
 	w.unlocked = false
 	w.encrypted = false
+	w.watchOnly = false
+	w.managedStopWebhooks()
 
 	return nil
 }
@@ -465,6 +598,12 @@ func (w *Wallet) Reset() error {
 // reason, InitFromSeed should not be called until the blockchain is fully
 // synced.
 func (w *Wallet) InitFromSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
+	return w.InitFromSeedWithPassphrase(masterKey, seed, "")
+}
+
+// InitFromSeedWithPassphrase is the same as InitFromSeed, but the keys of the
+// wallet are derived from the seed and the passphrase.
+func (w *Wallet) InitFromSeedWithPassphrase(masterKey crypto.CipherKey, seed modules.Seed, passphrase string) error {
 	if err := w.tg.Add(); err != nil {
 		return err
 	}
@@ -486,7 +625,7 @@ func (w *Wallet) InitFromSeed(masterKey crypto.CipherKey, seed modules.Seed) err
 	defer w.scanLock.Unlock()
 
 	// estimate the primarySeedProgress by scanning the blockchain
-	s := newSeedScanner(seed, w.log)
+	s := newSeedScanner(modules.SeedWithPassphrase(seed, passphrase), w.log)
 	if err := s.scan(w.cs, w.tg.StopChan()); err != nil {
 		return err
 	}
@@ -501,7 +640,7 @@ func (w *Wallet) InitFromSeed(masterKey crypto.CipherKey, seed modules.Seed) err
 	// initialize the wallet with the appropriate seed progress
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	_, err = w.initEncryption(masterKey, seed, progress)
+	_, err = w.initEncryption(masterKey, seed, passphrase, progress)
 	return err
 }
 
@@ -574,6 +713,12 @@ func (w *Wallet) IsMasterKey(masterKey crypto.CipherKey) (bool, error) {
 // UnlockAsync will decrypt the wallet seed and load all of the addresses into
 // memory.
 func (w *Wallet) UnlockAsync(masterKey crypto.CipherKey) <-chan error {
+	return w.UnlockAsyncWithPassphrase(masterKey, "")
+}
+
+// UnlockAsyncWithPassphrase is the same as UnlockAsync, but for wallets which
+// were initialized with a seed passphrase.
+func (w *Wallet) UnlockAsyncWithPassphrase(masterKey crypto.CipherKey, passphrase string) <-chan error {
 	errChan := make(chan error, 1)
 	defer close(errChan)
 	// By having the wallet's ThreadGroup track the Unlock method, we ensure
@@ -598,7 +743,7 @@ func (w *Wallet) UnlockAsync(masterKey crypto.CipherKey) <-chan error {
 
 	// Initialize all of the keys in the wallet under a lock. While holding the
 	// lock, also grab the subscriber status.
-	return w.managedUnlock(masterKey)
+	return w.managedUnlock(masterKey, passphrase)
 }
 
 // Unlock will decrypt the wallet seed and load all of the addresses into
@@ -607,16 +752,49 @@ func (w *Wallet) Unlock(masterKey crypto.CipherKey) error {
 	return <-w.UnlockAsync(masterKey)
 }
 
+// UnlockWithPassphrase is the same as Unlock, but for wallets which were
+// initialized with a seed passphrase.
+func (w *Wallet) UnlockWithPassphrase(masterKey crypto.CipherKey, passphrase string) error {
+	return <-w.UnlockAsyncWithPassphrase(masterKey, passphrase)
+}
+
+// SeedPassphraseRequired returns whether the wallet was initialized with a
+// seed passphrase which must be provided to unlock it.
+func (w *Wallet) SeedPassphraseRequired() (bool, error) {
+	if err := w.tg.Add(); err != nil {
+		return false, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.dbTx.Bucket(bucketWallet).Get(keySeedPassphrase) != nil, nil
+}
+
 // managedChangeKey safely performs the database operations required to change
 // the wallet's encryption key.
 func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.CipherKey) error {
 	w.mu.Lock()
 	encrypted := w.encrypted
+	watchOnly := w.watchOnly
 	w.mu.Unlock()
 	if !encrypted {
 		return errUnencryptedWallet
 	}
 
+	// watch-only wallets don't have any key files, only the encryption
+	// verification needs to be updated
+	if watchOnly {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		err := checkMasterKey(w.dbTx, masterKey)
+		if err != nil {
+			return errors.AddContext(err, "unable to verify master key")
+		}
+		uk := saltedEncryptionKey(newKey, dbGetWalletSalt(w.dbTx))
+		err = w.dbTx.Bucket(bucketWallet).Put(keyEncryptionVerification, uk.EncryptBytes(verificationPlaintext))
+		return errors.AddContext(err, "unable to put key encryption verification into db")
+	}
+
 	// grab the current seed files
 	var primarySeedFile seedFile
 	var auxiliarySeedFiles []seedFile
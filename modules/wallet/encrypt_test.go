@@ -645,3 +645,126 @@ func TestChangeKeyWithSeedCompatV141(t *testing.T) {
 		t.Fatal("wallet with changed key did not have the same balance")
 	}
 }
+
+// TestSeedPassphrase tests initializing and unlocking a wallet with a seed
+// passphrase.
+func TestSeedPassphrase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createBlankWalletTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	const passphrase = "correct horse battery staple"
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	seed, err := wt.wallet.EncryptWithPassphrase(masterKey, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if required, err := wt.wallet.SeedPassphraseRequired(); err != nil || !required {
+		t.Fatal("expected passphrase to be required", required, err)
+	}
+
+	// the wallet can only be unlocked with the correct passphrase
+	if err := wt.wallet.Unlock(masterKey); !errors.Contains(err, modules.ErrSeedPassphraseRequired) {
+		t.Fatal("expected ErrSeedPassphraseRequired, got", err)
+	}
+	if err := wt.wallet.UnlockWithPassphrase(masterKey, "wrong"); !errors.Contains(err, modules.ErrBadSeedPassphrase) {
+		t.Fatal("expected ErrBadSeedPassphrase, got", err)
+	}
+	if err := wt.wallet.UnlockWithPassphrase(masterKey, passphrase); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys should be derived from the seed and the passphrase while the
+	// primary seed stays the same
+	primarySeed, _, err := wt.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if primarySeed != seed {
+		t.Fatal("primary seed doesn't match the returned seed")
+	}
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySeed := modules.SeedWithPassphrase(seed, passphrase)
+	if uc.UnlockHash() != generateSpendableKey(keySeed, 0).UnlockConditions.UnlockHash() {
+		t.Fatal("address wasn't derived from the seed and the passphrase")
+	}
+	if uc.UnlockHash() == generateSpendableKey(seed, 0).UnlockConditions.UnlockHash() {
+		t.Fatal("address was derived from the seed alone")
+	}
+	if ks, err := wt.wallet.PrimaryKeySeed(); err != nil || ks != keySeed {
+		t.Fatal("key seed wasn't derived from the seed and the passphrase", err)
+	}
+
+	// fund the wallet
+	for i := types.BlockHeight(0); i <= types.MaturityDelay; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bal, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bal.IsZero() {
+		t.Fatal("wallet should have a balance")
+	}
+
+	// initializing a wallet from the seed alone doesn't find the funds
+	dir := filepath.Join(build.TempDir(modules.WalletDir, t.Name()+"1"), modules.WalletDir)
+	w, err := New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.InitFromSeed(nil, seed); err != nil {
+		t.Fatal(err)
+	}
+	sk := crypto.NewWalletKey(crypto.HashObject(seed))
+	if required, err := w.SeedPassphraseRequired(); err != nil || required {
+		t.Fatal("expected passphrase not to be required", required, err)
+	}
+	if err := w.UnlockWithPassphrase(sk, passphrase); !errors.Contains(err, errSeedPassphraseNotUsed) {
+		t.Fatal("expected errSeedPassphraseNotUsed, got", err)
+	}
+	if err := w.Unlock(sk); err != nil {
+		t.Fatal(err)
+	}
+	if bal, _, _, err := w.ConfirmedBalance(); err != nil || !bal.IsZero() {
+		t.Fatal("wallet without passphrase shouldn't have a balance", bal, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// initializing a wallet from the seed and the passphrase finds them
+	dir = filepath.Join(build.TempDir(modules.WalletDir, t.Name()+"2"), modules.WalletDir)
+	w, err = New(wt.cs, wt.tpool, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := w.InitFromSeedWithPassphrase(nil, seed, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.UnlockWithPassphrase(sk, passphrase); err != nil {
+		t.Fatal(err)
+	}
+	if newBal, _, _, err := w.ConfirmedBalance(); err != nil || !newBal.Equals(bal) {
+		t.Fatalf("expected balance %v, got %v (%v)", bal, newBal, err)
+	}
+}
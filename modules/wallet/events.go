@@ -0,0 +1,383 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// eventConfirmationDepth is the number of confirmations up to which the
+	// wallet emits confirmation events for a transaction.
+	eventConfirmationDepth = 6
+
+	// webhookQueueSize is the number of events queued for a webhook. If the
+	// queue is full, new events are dropped.
+	webhookQueueSize = 1000
+
+	// webhookTimeout is the timeout of a single webhook request.
+	webhookTimeout = 30 * time.Second
+
+	// webhookMaxAttempts is the number of times the wallet tries to deliver
+	// an event to a webhook.
+	webhookMaxAttempts = 3
+)
+
+var (
+	// webhookRetryInterval is the time the wallet waits before retrying to
+	// deliver an event to a webhook.
+	webhookRetryInterval = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 10 * time.Second,
+		Testing:  100 * time.Millisecond,
+		Testnet:  10 * time.Second,
+	}).(time.Duration)
+
+	// errInvalidWebhookURL is returned when adding a webhook with a URL that
+	// isn't an absolute http or https URL.
+	errInvalidWebhookURL = errors.New("webhook URL must be an absolute http or https URL")
+
+	// errUnknownWebhook is returned when removing a webhook that doesn't
+	// exist.
+	errUnknownWebhook = errors.New("webhook doesn't exist")
+
+	// errWebhookExists is returned when adding a webhook that already
+	// exists.
+	errWebhookExists = errors.New("webhook already exists")
+)
+
+// webhook is a WalletEventSubscriber which POSTs the events of the wallet to
+// a URL. Events are queued and delivered in order by a separate thread.
+type webhook struct {
+	staticURL    string
+	staticClient *http.Client
+	staticQueue  chan modules.WalletEvent
+	staticStop   chan struct{}
+	staticLog    *persist.Logger
+}
+
+// newWebhook creates a webhook for the given url.
+func newWebhook(url string, log *persist.Logger) *webhook {
+	return &webhook{
+		staticURL:    url,
+		staticClient: &http.Client{Timeout: webhookTimeout},
+		staticQueue:  make(chan modules.WalletEvent, webhookQueueSize),
+		staticStop:   make(chan struct{}),
+		staticLog:    log,
+	}
+}
+
+// ReceiveWalletEvent implements modules.WalletEventSubscriber by queueing the
+// event for delivery.
+func (hook *webhook) ReceiveWalletEvent(e modules.WalletEvent) {
+	select {
+	case hook.staticQueue <- e:
+	default:
+		hook.staticLog.Printf("WARN: queue of webhook %v is full, dropping %v event of %v", hook.staticURL, e.Type, e.TransactionID)
+	}
+}
+
+// post POSTs an event to the webhook.
+func (hook *webhook) post(e modules.WalletEvent) (err error) {
+	js, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	resp, err := hook.staticClient.Post(hook.staticURL, "application/json", bytes.NewReader(js))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// threadedDeliverWebhook delivers the queued events of a webhook until the
+// webhook is removed or the wallet shuts down.
+func (w *Wallet) threadedDeliverWebhook(hook *webhook) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	for {
+		var e modules.WalletEvent
+		select {
+		case e = <-hook.staticQueue:
+		case <-hook.staticStop:
+			return
+		case <-w.tg.StopChan():
+			return
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := hook.post(e)
+			if err == nil {
+				break
+			}
+			if attempt == webhookMaxAttempts {
+				w.log.Printf("WARN: failed to deliver %v event of %v to webhook %v: %v", e.Type, e.TransactionID, hook.staticURL, err)
+				break
+			}
+			select {
+			case <-time.After(webhookRetryInterval):
+			case <-hook.staticStop:
+				return
+			case <-w.tg.StopChan():
+				return
+			}
+		}
+	}
+}
+
+// managedLoadWebhooks loads the webhooks from the database and starts
+// delivering events to them.
+func (w *Wallet) managedLoadWebhooks() error {
+	var urls []string
+	w.mu.Lock()
+	err := dbForEachWebhook(w.dbTx, func(url string, _ modules.WalletWebhook) {
+		urls = append(urls, url)
+	})
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, url := range urls {
+		w.managedStartWebhook(url)
+	}
+	return nil
+}
+
+// managedStartWebhook subscribes a webhook for url to the wallet's events.
+func (w *Wallet) managedStartWebhook(url string) {
+	hook := newWebhook(url, w.log)
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	if _, exists := w.webhooks[url]; exists {
+		return
+	}
+	w.webhooks[url] = hook
+	w.eventSubscribers[hook] = struct{}{}
+	go w.threadedDeliverWebhook(hook)
+}
+
+// managedStopWebhooks unsubscribes and stops all webhooks.
+func (w *Wallet) managedStopWebhooks() {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	for url, hook := range w.webhooks {
+		delete(w.eventSubscribers, hook)
+		delete(w.webhooks, url)
+		close(hook.staticStop)
+	}
+}
+
+// AddWebhook adds a webhook the wallet POSTs its events to.
+func (w *Wallet) AddWebhook(rawURL string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidWebhookURL
+	}
+
+	w.eventsMu.Lock()
+	_, exists := w.webhooks[rawURL]
+	w.eventsMu.Unlock()
+	if exists {
+		return errWebhookExists
+	}
+
+	w.mu.Lock()
+	err = dbPutWebhook(w.dbTx, modules.WalletWebhook{URL: rawURL})
+	if err == nil {
+		err = w.syncDB()
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	w.managedStartWebhook(rawURL)
+	return nil
+}
+
+// RemoveWebhook removes the webhook with the given url. Events which haven't
+// been delivered yet are dropped.
+func (w *Wallet) RemoveWebhook(url string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.eventsMu.Lock()
+	hook, exists := w.webhooks[url]
+	if exists {
+		delete(w.eventSubscribers, hook)
+		delete(w.webhooks, url)
+		close(hook.staticStop)
+	}
+	w.eventsMu.Unlock()
+	if !exists {
+		return errUnknownWebhook
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbDeleteWebhook(w.dbTx, url); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// Webhooks returns the webhooks of the wallet sorted by URL.
+func (w *Wallet) Webhooks() ([]modules.WalletWebhook, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	hooks := make([]modules.WalletWebhook, 0, len(w.webhooks))
+	for url := range w.webhooks {
+		hooks = append(hooks, modules.WalletWebhook{URL: url})
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].URL < hooks[j].URL
+	})
+	return hooks, nil
+}
+
+// SubscribeEvents subscribes s to the events of the wallet.
+func (w *Wallet) SubscribeEvents(s modules.WalletEventSubscriber) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	w.eventSubscribers[s] = struct{}{}
+	return nil
+}
+
+// UnsubscribeEvents removes s from the subscribers of the wallet's events.
+func (w *Wallet) UnsubscribeEvents(s modules.WalletEventSubscriber) {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	delete(w.eventSubscribers, s)
+}
+
+// emitEvent sends an event to all subscribers.
+func (w *Wallet) emitEvent(e modules.WalletEvent) {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	for s := range w.eventSubscribers {
+		s.ReceiveWalletEvent(e)
+	}
+}
+
+// emitConfirmations emits confirmation events for the recently confirmed
+// transactions of the wallet. Transactions are no longer tracked once they
+// reach eventConfirmationDepth confirmations.
+func (w *Wallet) emitConfirmations(height types.BlockHeight) {
+	// sort the transactions to emit the events in order of confirmation
+	ids := make([]types.TransactionID, 0, len(w.eventsConfirming))
+	for id := range w.eventsConfirming {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		hi, hj := w.eventsConfirming[ids[i]].ConfirmationHeight, w.eventsConfirming[ids[j]].ConfirmationHeight
+		if hi != hj {
+			return hi < hj
+		}
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+
+	for _, id := range ids {
+		e := w.eventsConfirming[id]
+		confirmations := eventConfirmations(e.ConfirmationHeight, height)
+		if confirmations > e.Confirmations {
+			e.Type = modules.WalletEventConfirmation
+			e.Confirmations = confirmations
+			e.Timestamp = time.Now()
+			w.emitEvent(e)
+		}
+		if confirmations >= eventConfirmationDepth {
+			delete(w.eventsConfirming, id)
+		} else {
+			w.eventsConfirming[id] = e
+		}
+	}
+}
+
+// eventConfirmations returns the number of confirmations of a transaction
+// confirmed at confirmationHeight when the blockchain is at height.
+func eventConfirmations(confirmationHeight, height types.BlockHeight) uint64 {
+	if height < confirmationHeight {
+		return 0
+	}
+	return uint64(height-confirmationHeight) + 1
+}
+
+// newWalletEvent creates an event of the given type for a processed
+// transaction.
+func newWalletEvent(typ string, pt modules.ProcessedTransaction, confirmations uint64) modules.WalletEvent {
+	h := historyEntry(pt, make(map[types.OutputID]struct{}))
+	e := modules.WalletEvent{
+		Type:            typ,
+		TransactionID:   pt.TransactionID,
+		SiacoinIncoming: h.SiacoinIncoming,
+		SiacoinOutgoing: h.SiacoinOutgoing,
+		SiafundIncoming: h.SiafundIncoming,
+		SiafundOutgoing: h.SiafundOutgoing,
+		Confirmations:   confirmations,
+		Timestamp:       time.Now(),
+	}
+	if pt.ConfirmationHeight != types.BlockHeight(math.MaxUint64) {
+		e.ConfirmationHeight = pt.ConfirmationHeight
+	}
+
+	// collect the wallet addresses affected by the transaction
+	seen := make(map[types.UnlockHash]struct{})
+	addAddress := func(walletAddress bool, addr types.UnlockHash) {
+		if _, ok := seen[addr]; walletAddress && !ok {
+			seen[addr] = struct{}{}
+			e.Addresses = append(e.Addresses, addr)
+		}
+	}
+	for _, input := range pt.Inputs {
+		addAddress(input.WalletAddress, input.RelatedAddress)
+	}
+	for _, output := range pt.Outputs {
+		addAddress(output.WalletAddress, output.RelatedAddress)
+	}
+	return e
+}
+
+// revertEvent stops tracking the confirmations of a reverted transaction and
+// emits a reverted event for it if emit is set.
+func (w *Wallet) revertEvent(pt modules.ProcessedTransaction, emit bool) {
+	delete(w.eventsConfirming, pt.TransactionID)
+	if emit {
+		w.emitEvent(newWalletEvent(modules.WalletEventReverted, pt, 0))
+	}
+}
@@ -0,0 +1,218 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// eventRecorder is a WalletEventSubscriber which records the received events.
+type eventRecorder struct {
+	events []modules.WalletEvent
+	mu     sync.Mutex
+}
+
+// ReceiveWalletEvent implements modules.WalletEventSubscriber.
+func (r *eventRecorder) ReceiveWalletEvent(e modules.WalletEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+// eventsOf returns the recorded events of a transaction.
+func (r *eventRecorder) eventsOf(txid types.TransactionID) []modules.WalletEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []modules.WalletEvent
+	for _, e := range r.events {
+		if e.TransactionID == txid {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// TestWalletEvents tests that subscribers receive events for unconfirmed and
+// confirmed transactions, including confirmation updates.
+func TestWalletEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	r := new(eventRecorder)
+	if err := wt.wallet.SubscribeEvents(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// send coins to ourselves
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), uc.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	events := r.eventsOf(txid)
+	if len(events) != 1 || events[0].Type != modules.WalletEventUnconfirmed {
+		t.Fatal("expected a single unconfirmed event, got", events)
+	}
+	if len(events[0].Addresses) == 0 || events[0].SiacoinIncoming.IsZero() || events[0].SiacoinOutgoing.IsZero() {
+		t.Fatal("unexpected event", events[0])
+	}
+
+	// mine enough blocks to reach the confirmation depth and some more
+	for i := 0; i < eventConfirmationDepth+2; i++ {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events = r.eventsOf(txid)
+	if len(events) != eventConfirmationDepth+1 {
+		t.Fatalf("expected %v events, got %v", eventConfirmationDepth+1, len(events))
+	}
+	if events[1].Type != modules.WalletEventConfirmed || events[1].Confirmations != 1 || events[1].ConfirmationHeight == 0 {
+		t.Fatal("unexpected confirmed event", events[1])
+	}
+	for i, e := range events[2:] {
+		if e.Type != modules.WalletEventConfirmation || e.Confirmations != uint64(i+2) {
+			t.Fatal("unexpected confirmation event", e)
+		}
+	}
+
+	// unsubscribed subscribers don't receive any events
+	wt.wallet.UnsubscribeEvents(r)
+	txns, err = wt.wallet.SendSiacoins(types.SiacoinPrecision, uc.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := r.eventsOf(txns[len(txns)-1].ID()); len(events) != 0 {
+		t.Fatal("expected no events after unsubscribing, got", events)
+	}
+}
+
+// TestWalletWebhooks tests adding, removing and delivering events to
+// webhooks.
+func TestWalletWebhooks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// create a server recording the events. The first request fails to
+	// test retries.
+	r := new(eventRecorder)
+	var requests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requests++
+		fail := requests == 1
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var e modules.WalletEvent
+		if err := json.NewDecoder(req.Body).Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.ReceiveWalletEvent(e)
+	}))
+	defer server.Close()
+
+	// invalid URLs are rejected
+	for _, url := range []string{"", "localhost:1234", "ftp://localhost/events", "/events"} {
+		if err := wt.wallet.AddWebhook(url); !errors.Contains(err, errInvalidWebhookURL) {
+			t.Fatalf("expected errInvalidWebhookURL for %q, got %v", url, err)
+		}
+	}
+	if err := wt.wallet.RemoveWebhook(server.URL); !errors.Contains(err, errUnknownWebhook) {
+		t.Fatal("expected errUnknownWebhook, got", err)
+	}
+
+	if err := wt.wallet.AddWebhook(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.AddWebhook(server.URL); !errors.Contains(err, errWebhookExists) {
+		t.Fatal("expected errWebhookExists, got", err)
+	}
+	hooks, err := wt.wallet.Webhooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].URL != server.URL {
+		t.Fatal("unexpected webhooks", hooks)
+	}
+
+	// the event of a transaction should be delivered
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), uc.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		events := r.eventsOf(txid)
+		if len(events) != 1 || events[0].Type != modules.WalletEventUnconfirmed {
+			return fmt.Errorf("expected a single unconfirmed event, got %v", events)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the webhook should be persisted
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet, err = New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hooks, err := wt.wallet.Webhooks(); err != nil || len(hooks) != 1 {
+		t.Fatal("expected webhook to be persisted", hooks, err)
+	}
+
+	// removed webhooks aren't persisted
+	if err := wt.wallet.RemoveWebhook(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if hooks, err := wt.wallet.Webhooks(); err != nil || len(hooks) != 0 {
+		t.Fatal("expected no webhooks", hooks, err)
+	}
+}
@@ -0,0 +1,233 @@
+package wallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// priceSourceTimeout is the timeout for a request to an http price source.
+const priceSourceTimeout = 30 * time.Second
+
+var (
+	// errInvalidHistoryRange is returned when the start of a requested
+	// history is after its end.
+	errInvalidHistoryRange = errors.New("start of history is after its end")
+
+	// errNoPriceSource is returned when a wallet history is requested in a
+	// fiat currency but no price source is set.
+	errNoPriceSource = errors.New("no price source set")
+)
+
+type (
+	// httpPriceSource is a PriceSource which queries the price of a siacoin
+	// from an http endpoint. The endpoint receives the currency and the unix
+	// timestamp as query parameters and responds with a JSON object
+	// containing the price.
+	httpPriceSource struct {
+		staticURL    string
+		staticClient *http.Client
+	}
+
+	// priceResponse is the response of an http price source.
+	priceResponse struct {
+		Price float64 `json:"price"`
+	}
+)
+
+// NewHTTPPriceSource returns a PriceSource which queries prices from the
+// given url, e.g. http://localhost:8080/price?currency=usd&timestamp=1600000000.
+func NewHTTPPriceSource(url string) modules.PriceSource {
+	return &httpPriceSource{
+		staticURL:    url,
+		staticClient: &http.Client{Timeout: priceSourceTimeout},
+	}
+}
+
+// SiacoinPrice implements modules.PriceSource.
+func (ps *httpPriceSource) SiacoinPrice(currency string, timestamp types.Timestamp) (price float64, err error) {
+	values := url.Values{}
+	values.Set("currency", currency)
+	values.Set("timestamp", fmt.Sprint(timestamp))
+	resp, err := ps.staticClient.Get(ps.staticURL + "?" + values.Encode())
+	if err != nil {
+		return 0, errors.AddContext(err, "failed to query price source")
+	}
+	defer func() {
+		err = errors.Compose(err, resp.Body.Close())
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("price source returned status %v", resp.StatusCode)
+	}
+	var pr priceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return 0, errors.AddContext(err, "failed to decode price")
+	}
+	return pr.Price, nil
+}
+
+// SetPriceSource sets the price source used to value the transactions of an
+// exported wallet history. Setting it to nil disables fiat valuation.
+func (w *Wallet) SetPriceSource(ps modules.PriceSource) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.priceSource = ps
+}
+
+// History returns the confirmed transactions of the wallet with timestamps in
+// [start, end] together with the running balances of the wallet.
+func (w *Wallet) History(start, end types.Timestamp, offset, limit uint64, currency string) ([]modules.WalletHistoryEntry, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if start > end {
+		return nil, errInvalidHistoryRange
+	}
+
+	w.mu.Lock()
+	priceSource := w.priceSource
+	entries, err := w.history(start, end, offset, limit)
+	w.mu.Unlock()
+	if err != nil || currency == "" {
+		return entries, err
+	}
+	if priceSource == nil {
+		return nil, errNoPriceSource
+	}
+
+	// value the entries without holding the lock since the price source
+	// might be slow. Transactions confirmed in the same block share a
+	// timestamp, so prices are cached.
+	prices := make(map[types.Timestamp]float64)
+	for i := range entries {
+		e := &entries[i]
+		price, ok := prices[e.ConfirmationTimestamp]
+		if !ok {
+			price, err = priceSource.SiacoinPrice(currency, e.ConfirmationTimestamp)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to get siacoin price")
+			}
+			prices[e.ConfirmationTimestamp] = price
+		}
+		e.FiatCurrency = currency
+		e.FiatPrice = price
+		e.FiatValue = (siacoinsToFloat(e.SiacoinIncoming) - siacoinsToFloat(e.SiacoinOutgoing)) * price
+	}
+	return entries, nil
+}
+
+// history computes the running balances of all confirmed transactions and
+// returns the entries within the requested range.
+func (w *Wallet) history(start, end types.Timestamp, offset, limit uint64) ([]modules.WalletHistoryEntry, error) {
+	if err := w.syncDB(); err != nil {
+		return nil, err
+	}
+
+	var entries []modules.WalletHistoryEntry
+	var scBalance, sfBalance types.Currency
+	var skipped uint64
+	var pt modules.ProcessedTransaction
+	unspent := make(map[types.OutputID]struct{})
+	err := w.dbTx.Bucket(bucketProcessedTransactions).ForEach(func(_, ptBytes []byte) error {
+		if err := decodeProcessedTransaction(ptBytes, &pt); err != nil {
+			return err
+		}
+		e := historyEntry(pt, unspent)
+		// the running balances cover all transactions, even the ones outside
+		// of the requested range. Outputs which were received without a
+		// processed transaction, like contract payouts, are added to the
+		// balance when they are spent.
+		scBalance = scBalance.Add(e.SiacoinUntracked).Add(e.SiacoinIncoming)
+		sfBalance = sfBalance.Add(e.SiafundUntracked).Add(e.SiafundIncoming)
+		if scBalance.Cmp(e.SiacoinOutgoing) < 0 || sfBalance.Cmp(e.SiafundOutgoing) < 0 {
+			return fmt.Errorf("transaction %v spends more than the wallet's balance", e.TransactionID)
+		}
+		scBalance = scBalance.Sub(e.SiacoinOutgoing)
+		sfBalance = sfBalance.Sub(e.SiafundOutgoing)
+		e.SiacoinBalance, e.SiafundBalance = scBalance, sfBalance
+
+		if pt.ConfirmationTimestamp < start || pt.ConfirmationTimestamp > end {
+			return nil
+		}
+		if skipped < offset {
+			skipped++
+			return nil
+		}
+		if limit == 0 || uint64(len(entries)) < limit {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// historyEntry computes the values of a history entry from a processed
+// transaction. unspent contains the wallet outputs received by earlier
+// processed transactions which weren't spent yet. Inputs spending any other
+// outputs are reported as untracked and the outputs received by the
+// transaction are added to unspent.
+func historyEntry(pt modules.ProcessedTransaction, unspent map[types.OutputID]struct{}) modules.WalletHistoryEntry {
+	e := modules.WalletHistoryEntry{
+		TransactionID:         pt.TransactionID,
+		ConfirmationHeight:    pt.ConfirmationHeight,
+		ConfirmationTimestamp: pt.ConfirmationTimestamp,
+	}
+	for _, input := range pt.Inputs {
+		if !input.WalletAddress {
+			continue
+		}
+		_, tracked := unspent[input.ParentID]
+		delete(unspent, input.ParentID)
+		switch input.FundType {
+		case types.SpecifierSiacoinInput:
+			e.SiacoinOutgoing = e.SiacoinOutgoing.Add(input.Value)
+			if !tracked {
+				e.SiacoinUntracked = e.SiacoinUntracked.Add(input.Value)
+			}
+		case types.SpecifierSiafundInput:
+			e.SiafundOutgoing = e.SiafundOutgoing.Add(input.Value)
+			if !tracked {
+				e.SiafundUntracked = e.SiafundUntracked.Add(input.Value)
+			}
+		}
+	}
+	for _, output := range pt.Outputs {
+		// the wallet only pays the miner fee if it funded the transaction
+		if output.FundType == types.SpecifierMinerFee && !e.SiacoinOutgoing.IsZero() {
+			e.MinerFee = e.MinerFee.Add(output.Value)
+		}
+		if !output.WalletAddress {
+			continue
+		}
+		switch output.FundType {
+		case types.SpecifierSiacoinOutput, types.SpecifierMinerPayout:
+			e.SiacoinIncoming = e.SiacoinIncoming.Add(output.Value)
+			unspent[output.ID] = struct{}{}
+		case types.SpecifierClaimOutput:
+			// the id of a claim output is the id of the siafund output it
+			// was claimed from.
+			e.SiacoinIncoming = e.SiacoinIncoming.Add(output.Value)
+			unspent[types.OutputID(types.SiafundOutputID(output.ID).SiaClaimOutputID())] = struct{}{}
+		case types.SpecifierSiafundOutput:
+			e.SiafundIncoming = e.SiafundIncoming.Add(output.Value)
+			unspent[output.ID] = struct{}{}
+		}
+	}
+	return e
+}
+
+// siacoinsToFloat converts a siacoin amount in hastings to a float of
+// siacoins.
+func siacoinsToFloat(c types.Currency) float64 {
+	f, _ := new(big.Rat).SetFrac(c.Big(), types.SiacoinPrecision.Big()).Float64()
+	return f
+}
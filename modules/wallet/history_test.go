@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// testPriceSource is a PriceSource which returns a fixed price.
+type testPriceSource struct {
+	price float64
+}
+
+// SiacoinPrice implements modules.PriceSource.
+func (ps testPriceSource) SiacoinPrice(currency string, _ types.Timestamp) (float64, error) {
+	if currency != "usd" {
+		return 0, errors.New("unknown currency")
+	}
+	return ps.price, nil
+}
+
+// TestHistory probes the running balances, filtering and pagination of the
+// wallet history.
+func TestHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// send some coins and confirm the transaction
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the running balances should add up
+	entries, err := wt.wallet.History(0, math.MaxUint64, 0, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 {
+		t.Fatal("expected history entries")
+	}
+	var balance types.Currency
+	for _, e := range entries {
+		// all outputs of the wallet were received by processed transactions
+		if !e.SiacoinUntracked.IsZero() || !e.SiafundUntracked.IsZero() {
+			t.Fatal("unexpected untracked value", e.SiacoinUntracked, e.SiafundUntracked)
+		}
+		balance = balance.Add(e.SiacoinIncoming).Sub(e.SiacoinOutgoing)
+		if !e.SiacoinBalance.Equals(balance) {
+			t.Fatalf("expected running balance %v, got %v", balance, e.SiacoinBalance)
+		}
+		if e.FiatCurrency != "" {
+			t.Fatal("fiat fields shouldn't be set")
+		}
+	}
+
+	// the send should be the last entry and include the fee
+	last := entries[len(entries)-1]
+	sent := txns[len(txns)-1]
+	if last.TransactionID != sent.ID() {
+		t.Fatal("expected the last entry to be the sent transaction")
+	}
+	if !last.MinerFee.Equals(sent.MinerFees[0]) {
+		t.Fatalf("expected miner fee %v, got %v", sent.MinerFees[0], last.MinerFee)
+	}
+	if !last.SiacoinOutgoing.Sub(last.SiacoinIncoming).Equals(types.SiacoinPrecision.Mul64(10).Add(sent.MinerFees[0])) {
+		t.Fatal("unexpected net value of sent transaction")
+	}
+
+	// pagination should return a window of the full history
+	page, err := wt.wallet.History(0, math.MaxUint64, 1, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(page, entries[1:3]) {
+		t.Fatal("page doesn't match history")
+	}
+
+	// filtering by time should only return entries within the range while
+	// keeping the running balances
+	ts := last.ConfirmationTimestamp
+	filtered, err := wt.wallet.History(ts, ts, 0, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range filtered {
+		if e.ConfirmationTimestamp != ts {
+			t.Fatal("entry outside of the requested range", e.ConfirmationTimestamp)
+		}
+	}
+	if len(filtered) == 0 || !reflect.DeepEqual(filtered[len(filtered)-1], last) {
+		t.Fatal("expected the sent transaction to be in the filtered history")
+	}
+	if _, err := wt.wallet.History(ts, ts-1, 0, 0, ""); !errors.Contains(err, errInvalidHistoryRange) {
+		t.Fatal("expected errInvalidHistoryRange, got", err)
+	}
+
+	// valuing the history requires a price source
+	if _, err := wt.wallet.History(0, math.MaxUint64, 0, 0, "usd"); !errors.Contains(err, errNoPriceSource) {
+		t.Fatal("expected errNoPriceSource, got", err)
+	}
+	wt.wallet.SetPriceSource(testPriceSource{price: 0.5})
+	valued, err := wt.wallet.History(0, math.MaxUint64, 0, 0, "usd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range valued {
+		net := siacoinsToFloat(e.SiacoinIncoming) - siacoinsToFloat(e.SiacoinOutgoing)
+		if e.FiatCurrency != "usd" || e.FiatPrice != 0.5 || e.FiatValue != net*0.5 {
+			t.Fatal("unexpected fiat valuation", e)
+		}
+		if !e.SiacoinBalance.Equals(entries[i].SiacoinBalance) {
+			t.Fatal("valuation changed the running balance")
+		}
+	}
+	if _, err := wt.wallet.History(0, math.MaxUint64, 0, 0, "eur"); err == nil {
+		t.Fatal("expected price source error to be returned")
+	}
+}
+
+// TestHTTPPriceSource tests querying prices from an http price source.
+func TestHTTPPriceSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("currency") != "usd" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		price := 0.01
+		if req.FormValue("timestamp") == "1600000000" {
+			price = 0.002
+		}
+		_ = json.NewEncoder(w).Encode(priceResponse{Price: price})
+	}))
+	defer server.Close()
+
+	ps := NewHTTPPriceSource(server.URL)
+	price, err := ps.SiacoinPrice("usd", 1600000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price != 0.002 {
+		t.Fatal("unexpected price", price)
+	}
+	if _, err := ps.SiacoinPrice("eur", 1600000000); err == nil {
+		t.Fatal("expected error for unknown currency")
+	}
+}
+
+// TestHistoryEntryUntracked is a unit test for the untracked values of
+// historyEntry.
+func TestHistoryEntryUntracked(t *testing.T) {
+	tracked, untracked := types.OutputID{1}, types.OutputID{2}
+	unspent := make(map[types.OutputID]struct{})
+
+	// receive an output
+	e := historyEntry(modules.ProcessedTransaction{
+		Outputs: []modules.ProcessedOutput{
+			{ID: tracked, FundType: types.SpecifierSiacoinOutput, WalletAddress: true, Value: types.NewCurrency64(10)},
+			{ID: types.OutputID{3}, FundType: types.SpecifierSiacoinOutput, Value: types.NewCurrency64(20)},
+		},
+	}, unspent)
+	if !e.SiacoinIncoming.Equals64(10) || !e.SiacoinUntracked.IsZero() {
+		t.Fatal("wrong values", e.SiacoinIncoming, e.SiacoinUntracked)
+	}
+
+	// spend it together with an output which was never received
+	e = historyEntry(modules.ProcessedTransaction{
+		Inputs: []modules.ProcessedInput{
+			{ParentID: tracked, FundType: types.SpecifierSiacoinInput, WalletAddress: true, Value: types.NewCurrency64(10)},
+			{ParentID: untracked, FundType: types.SpecifierSiacoinInput, WalletAddress: true, Value: types.NewCurrency64(5)},
+		},
+	}, unspent)
+	if !e.SiacoinOutgoing.Equals64(15) || !e.SiacoinUntracked.Equals64(5) {
+		t.Fatal("wrong values", e.SiacoinOutgoing, e.SiacoinUntracked)
+	}
+	if len(unspent) != 0 {
+		t.Fatal("spent output wasn't removed", unspent)
+	}
+}
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// maxLabelLength is the maximum length of an address label.
+const maxLabelLength = 64
+
+// errLabelTooLong is returned when trying to set a label longer than
+// maxLabelLength.
+var errLabelTooLong = errors.New("label is too long")
+
+// AddressLabels returns the labels of all labeled addresses.
+func (w *Wallet) AddressLabels() (map[types.UnlockHash]string, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	labels := make(map[types.UnlockHash]string)
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		labels[addr] = label
+	})
+	return labels, err
+}
+
+// SetAddressLabel assigns a label to an address. Addresses sharing a label
+// form an account. An empty label removes the label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(label) > maxLabelLength {
+		return errLabelTooLong
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if label == "" {
+		err = dbDeleteAddressLabel(w.dbTx, addr)
+	} else {
+		err = dbPutAddressLabel(w.dbTx, addr, label)
+	}
+	if err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// LabelBalance returns the confirmed siacoin and siafund balance of the
+// addresses with the given label.
+func (w *Wallet) LabelBalance(label string) (siacoinBalance, siafundBalance types.Currency, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.ZeroCurrency, types.ZeroCurrency, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	addrs, err := w.labeledAddresses(label)
+	if err != nil {
+		return types.ZeroCurrency, types.ZeroCurrency, err
+	}
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, ok := addrs[sco.UnlockHash]; ok {
+			siacoinBalance = siacoinBalance.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return types.ZeroCurrency, types.ZeroCurrency, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if _, ok := addrs[sfo.UnlockHash]; ok {
+			siafundBalance = siafundBalance.Add(sfo.Value)
+		}
+	})
+	return
+}
+
+// LabelTransactions returns the confirmed and unconfirmed transactions related
+// to the addresses with the given label. Confirmed transactions are returned
+// in chronological order.
+func (w *Wallet) LabelTransactions(label string) (confirmed, unconfirmed []modules.ProcessedTransaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err = w.syncDB(); err != nil {
+		return nil, nil, err
+	}
+
+	addrs, err := w.labeledAddresses(label)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// collect the indices of the confirmed transactions without duplicates
+	seen := make(map[uint64]struct{})
+	var indices []uint64
+	for addr := range addrs {
+		txnIndices, _ := dbGetAddrTransactions(w.dbTx, addr)
+		for _, i := range txnIndices {
+			if _, ok := seen[i]; !ok {
+				seen[i] = struct{}{}
+				indices = append(indices, i)
+			}
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		return indices[i] < indices[j]
+	})
+	for _, i := range indices {
+		pt, err := dbGetProcessedTransaction(w.dbTx, i)
+		if err != nil {
+			continue
+		}
+		confirmed = append(confirmed, pt)
+	}
+
+	// filter the unconfirmed transactions
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		relevant := false
+		for _, input := range pt.Inputs {
+			_, ok := addrs[input.RelatedAddress]
+			relevant = relevant || ok
+		}
+		for _, output := range pt.Outputs {
+			_, ok := addrs[output.RelatedAddress]
+			relevant = relevant || ok
+		}
+		if relevant {
+			unconfirmed = append(unconfirmed, pt)
+		}
+	}
+	return confirmed, unconfirmed, nil
+}
+
+// labeledAddresses returns the set of addresses with the given label.
+func (w *Wallet) labeledAddresses(label string) (map[types.UnlockHash]struct{}, error) {
+	addrs := make(map[types.UnlockHash]struct{})
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, l string) {
+		if l == label {
+			addrs[addr] = struct{}{}
+		}
+	})
+	return addrs, err
+}
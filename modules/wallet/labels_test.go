@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAddressLabels probes setting and removing address labels.
+func TestAddressLabels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	uc1, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uc2, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr1, addr2 := uc1.UnlockHash(), uc2.UnlockHash()
+
+	// labels that are too long should be rejected
+	err = wt.wallet.SetAddressLabel(addr1, strings.Repeat("a", maxLabelLength+1))
+	if err == nil || !strings.Contains(err.Error(), errLabelTooLong.Error()) {
+		t.Fatal("expected errLabelTooLong, got", err)
+	}
+
+	if err := wt.wallet.SetAddressLabel(addr1, "savings"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetAddressLabel(addr2, "savings"); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := wt.wallet.AddressLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 2 || labels[addr1] != "savings" || labels[addr2] != "savings" {
+		t.Fatal("unexpected labels", labels)
+	}
+
+	// relabel the first address and remove the label of the second one
+	if err := wt.wallet.SetAddressLabel(addr1, "spending"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetAddressLabel(addr2, ""); err != nil {
+		t.Fatal(err)
+	}
+	labels, err = wt.wallet.AddressLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[addr1] != "spending" {
+		t.Fatal("unexpected labels", labels)
+	}
+}
+
+// TestLabelBalanceAndTransactions checks that the balance and transactions of
+// a label only include the labeled addresses.
+func TestLabelBalanceAndTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// an unused label has no balance and no transactions
+	sc, sf, err := wt.wallet.LabelBalance("savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.IsZero() || !sf.IsZero() {
+		t.Fatal("expected empty balance", sc, sf)
+	}
+
+	// label two addresses and send coins to both of them
+	var addrs []types.UnlockHash
+	for i := 0; i < 2; i++ {
+		uc, err := wt.wallet.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, uc.UnlockHash())
+		if err := wt.wallet.SetAddressLabel(uc.UnlockHash(), "savings"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	amount := types.SiacoinPrecision.Mul64(100)
+	_, err = wt.wallet.SendSiacoinsMulti([]types.SiacoinOutput{
+		{Value: amount, UnlockHash: addrs[0]},
+		{Value: amount, UnlockHash: addrs[1]},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the transaction should show up as unconfirmed
+	confirmed, unconfirmed, err := wt.wallet.LabelTransactions("savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 0 || len(unconfirmed) != 1 {
+		t.Fatalf("expected 0 confirmed and 1 unconfirmed transactions, got %v and %v", len(confirmed), len(unconfirmed))
+	}
+
+	// after mining a block the transaction should be confirmed and counted
+	// once in the balance of the label
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	confirmed, unconfirmed, err = wt.wallet.LabelTransactions("savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(confirmed) != 1 || len(unconfirmed) != 0 {
+		t.Fatalf("expected 1 confirmed and 0 unconfirmed transactions, got %v and %v", len(confirmed), len(unconfirmed))
+	}
+	sc, sf, err = wt.wallet.LabelBalance("savings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.Equals(amount.Mul64(2)) || !sf.IsZero() {
+		t.Fatal("unexpected label balance", sc, sf)
+	}
+
+	// other labels should not be affected
+	sc, _, err = wt.wallet.LabelBalance("spending")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sc.IsZero() {
+		t.Fatal("expected empty balance", sc)
+	}
+}
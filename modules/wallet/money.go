@@ -1,8 +1,10 @@
 package wallet
 
 import (
+	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -11,6 +13,10 @@ import (
 // siacoins.
 const estimatedTransactionSize = 750
 
+// errInvalidConfirmationTarget is returned when sending coins with a
+// confirmation target of zero blocks.
+var errInvalidConfirmationTarget = errors.New("confirmation target must be at least 1 block")
+
 // sortedOutputs is a struct containing a slice of siacoin outputs and their
 // corresponding ids. sortedOutputs can be sorted using the sort package.
 type sortedOutputs struct {
@@ -123,6 +129,24 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]t
 	return w.managedSendSiacoins(amount, fee, dest)
 }
 
+// SendSiacoinsTarget creates a transaction sending 'amount' to 'dest' with a
+// fee that targets the transaction to be confirmed within 'target' blocks. The
+// transaction is submitted to the transaction pool and is also returned. Fees
+// are added to the amount sent.
+func (w *Wallet) SendSiacoinsTarget(amount types.Currency, dest types.UnlockHash, target types.BlockHeight) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+	if target == 0 {
+		return nil, errInvalidConfirmationTarget
+	}
+
+	fee := w.tpool.FeeEstimationForTarget(target).Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoins(amount, fee, dest)
+}
+
 // SendSiacoinsFeeIncluded creates a transaction sending 'amount' to 'dest'. The
 // transaction is submitted to the transaction pool and is also returned. Fees
 // are subtracted from the amount sent.
@@ -135,6 +159,30 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 
 	_, fee := w.tpool.FeeEstimation()
 	fee = fee.Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoinsFeeIncluded(amount, fee, dest)
+}
+
+// SendSiacoinsFeeIncludedTarget creates a transaction sending 'amount' to
+// 'dest' with a fee that targets the transaction to be confirmed within
+// 'target' blocks. The transaction is submitted to the transaction pool and is
+// also returned. Fees are subtracted from the amount sent.
+func (w *Wallet) SendSiacoinsFeeIncludedTarget(amount types.Currency, dest types.UnlockHash, target types.BlockHeight) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+	if target == 0 {
+		return nil, errInvalidConfirmationTarget
+	}
+
+	fee := w.tpool.FeeEstimationForTarget(target).Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoinsFeeIncluded(amount, fee, dest)
+}
+
+// managedSendSiacoinsFeeIncluded creates a transaction sending 'amount' minus
+// 'fee' to 'dest'.
+func (w *Wallet) managedSendSiacoinsFeeIncluded(amount, fee types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
 	// Don't allow sending an amount equal to the fee, as zero spending is not
 	// allowed and would error out later.
 	if amount.Cmp(fee) <= 0 {
@@ -154,6 +202,7 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 
 	w.mu.RLock()
 	unlocked := w.unlocked
+	watchOnly := w.watchOnly
 	w.mu.RUnlock()
 	if !unlocked {
 		w.log.Println("Attempt to send coins has failed - wallet is locked")
@@ -164,6 +213,9 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 		Value:      amount,
 		UnlockHash: dest,
 	}
+	if watchOnly {
+		return w.managedSendWithSigner([]types.SiacoinOutput{output}, fee)
+	}
 
 	txnBuilder, err := w.StartTransaction()
 	if err != nil {
@@ -212,6 +264,31 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	defer w.tg.Done()
 	w.log.Println("Beginning call to SendSiacoinsMulti")
 
+	_, feePerByte := w.tpool.FeeEstimation()
+	feePerByte = feePerByte.Mul64(2) // We don't want send-to-many transactions to fail.
+	return w.managedSendSiacoinsMulti(outputs, feePerByte)
+}
+
+// SendSiacoinsMultiTarget creates a transaction that includes the specified
+// outputs with a fee that targets the transaction to be confirmed within
+// 'target' blocks. The transaction is submitted to the transaction pool and is
+// also returned.
+func (w *Wallet) SendSiacoinsMultiTarget(outputs []types.SiacoinOutput, target types.BlockHeight) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		err = modules.ErrWalletShutdown
+		return nil, err
+	}
+	defer w.tg.Done()
+	if target == 0 {
+		return nil, errInvalidConfirmationTarget
+	}
+
+	return w.managedSendSiacoinsMulti(outputs, w.tpool.FeeEstimationForTarget(target))
+}
+
+// managedSendSiacoinsMulti creates a transaction that includes the specified
+// outputs, paying 'feePerByte' for the estimated size of the transaction.
+func (w *Wallet) managedSendSiacoinsMulti(outputs []types.SiacoinOutput, feePerByte types.Currency) (txns []types.Transaction, err error) {
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
 		return nil, errors.New("cannot send siacoin until fully synced")
@@ -219,12 +296,19 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 
 	w.mu.RLock()
 	unlocked := w.unlocked
+	watchOnly := w.watchOnly
 	w.mu.RUnlock()
 	if !unlocked {
 		w.log.Println("Attempt to send coins has failed - wallet is locked")
 		return nil, modules.ErrLockedWallet
 	}
 
+	// Add estimated transaction fee.
+	tpoolFee := feePerByte.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
+	if watchOnly {
+		return w.managedSendWithSigner(outputs, tpoolFee)
+	}
+
 	txnBuilder, err := w.StartTransaction()
 	if err != nil {
 		return nil, err
@@ -234,11 +318,6 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 			txnBuilder.Drop()
 		}
 	}()
-
-	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
-	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
 
 	// Calculate total cost to wallet.
@@ -365,3 +444,182 @@ func (so sortedOutputs) Swap(i, j int) {
 	so.ids[i], so.ids[j] = so.ids[j], so.ids[i]
 	so.outputs[i], so.outputs[j] = so.outputs[j], so.outputs[i]
 }
+
+// SendSiacoinsFromOutputs sends siacoins to the given outputs using exactly
+// the given wallet outputs as inputs, which allows the user to control which
+// outputs and addresses are linked by a transaction. Any change is sent to
+// changeAddr, or to a new wallet address if changeAddr is empty. The
+// transaction is submitted to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromOutputs(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput, changeAddr types.UnlockHash) (txns []types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(inputs) == 0 {
+		return nil, errors.New("transaction needs at least one input")
+	}
+	if len(outputs) == 0 {
+		return nil, errors.New("transaction needs at least one output")
+	}
+
+	// Check if consensus is synced
+	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
+		return nil, errors.New("cannot send siacoin until fully synced")
+	}
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, err
+	}
+	_, feePerByte := w.tpool.FeeEstimation()
+
+	txn, err := func() (types.Transaction, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return types.Transaction{}, modules.ErrLockedWallet
+		}
+		consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+
+		// outputs spent by pending transactions can't be used
+		pending := make(map[types.OutputID]struct{})
+		for _, pt := range w.unconfirmedProcessedTransactions {
+			for _, input := range pt.Inputs {
+				if input.WalletAddress {
+					pending[input.ParentID] = struct{}{}
+				}
+			}
+		}
+
+		// add the inputs
+		txn := types.Transaction{
+			SiacoinOutputs: append([]types.SiacoinOutput(nil), outputs...),
+		}
+		var funded types.Currency
+		used := make(map[types.SiacoinOutputID]struct{})
+		for _, id := range inputs {
+			if _, ok := used[id]; ok {
+				return types.Transaction{}, errors.New("output " + id.String() + " is used more than once")
+			}
+			used[id] = struct{}{}
+			var sco types.SiacoinOutput
+			if err := dbGet(w.dbTx.Bucket(bucketSiacoinOutputs), id, &sco); err != nil {
+				return types.Transaction{}, errors.New("unknown output " + id.String())
+			}
+			if _, ok := w.keys[sco.UnlockHash]; !ok {
+				return types.Transaction{}, errors.New("wallet doesn't have the keys to spend output " + id.String())
+			}
+			if _, ok := pending[types.OutputID(id)]; ok {
+				return types.Transaction{}, errors.New("output " + id.String() + " is spent by a pending transaction")
+			}
+			if err := w.checkOutput(w.dbTx, consensusHeight, id, sco, dustThreshold); err != nil {
+				return types.Transaction{}, errors.AddContext(err, "can't spend output "+id.String())
+			}
+			txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+				ParentID:         id,
+				UnlockConditions: w.keys[sco.UnlockHash].UnlockConditions,
+			})
+			funded = funded.Add(sco.Value)
+		}
+
+		// estimate the fee using the size of the transaction including the
+		// change output and signatures
+		change := types.SiacoinOutput{Value: funded, UnlockHash: changeAddr}
+		size := len(encoding.Marshal(append(txn.SiacoinOutputs, change))) + len(encoding.Marshal(txn.SiacoinInputs))
+		size += len(inputs) * (len(encoding.Marshal(types.TransactionSignature{})) + crypto.SignatureSize)
+		fee := feePerByte.Mul64(uint64(size))
+		spent := fee
+		for _, sco := range outputs {
+			spent = spent.Add(sco.Value)
+		}
+		if funded.Cmp(spent) < 0 {
+			return types.Transaction{}, modules.ErrLowBalance
+		}
+		txn.MinerFees = []types.Currency{fee}
+		if change.Value = funded.Sub(spent); change.Value.Cmp(dustThreshold) > 0 {
+			if change.UnlockHash == (types.UnlockHash{}) {
+				uc, err := w.nextPrimarySeedAddress(w.dbTx)
+				if err != nil {
+					return types.Transaction{}, err
+				}
+				change.UnlockHash = uc.UnlockHash()
+			}
+			txn.SiacoinOutputs = append(txn.SiacoinOutputs, change)
+		} else {
+			txn.MinerFees[0] = txn.MinerFees[0].Add(change.Value)
+		}
+
+		// sign the inputs and mark them as spent
+		for _, sci := range txn.SiacoinInputs {
+			addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()], consensusHeight)
+		}
+		for _, sci := range txn.SiacoinInputs {
+			if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+				return types.Transaction{}, err
+			}
+		}
+		return txn, nil
+	}()
+	if err != nil {
+		w.log.Println("Attempt to send coins from outputs has failed:", err)
+		return nil, err
+	}
+
+	txnSet := []types.Transaction{txn}
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.log.Println("Attempt to send coins has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	w.log.Println("Submitted a siacoin transfer transaction from selected outputs with fees", txn.MinerFees[0].HumanString(), "ID:", txn.ID())
+	return txnSet, nil
+}
+
+// FreezeOutputs prevents the wallet from using the given outputs to fund
+// transactions until they are unfrozen.
+func (w *Wallet) FreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	for _, id := range ids {
+		sc := w.dbTx.Bucket(bucketSiacoinOutputs).Get(encoding.Marshal(id)) != nil
+		sf := w.dbTx.Bucket(bucketSiafundOutputs).Get(encoding.Marshal(id)) != nil
+		if !sc && !sf {
+			return errors.New("unknown output " + id.String())
+		}
+	}
+	for _, id := range ids {
+		if err := dbPutFrozenOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
+}
+
+// UnfreezeOutputs allows the wallet to use the given outputs to fund
+// transactions again.
+func (w *Wallet) UnfreezeOutputs(ids []types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	for _, id := range ids {
+		if err := dbDeleteFrozenOutput(w.dbTx, id); err != nil {
+			return err
+		}
+	}
+	return w.syncDB()
+}
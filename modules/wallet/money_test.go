@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"sort"
+	"strings"
 	"testing"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -94,6 +95,57 @@ func TestSendSiacoins(t *testing.T) {
 	}
 }
 
+// TestSendSiacoinsTarget checks that sending siacoins with a confirmation
+// target pays the fee estimated for that target.
+func TestSendSiacoinsTarget(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// a target of zero blocks is invalid
+	_, err = wt.wallet.SendSiacoinsTarget(types.SiacoinPrecision, types.UnlockHash{}, 0)
+	if !errors.Contains(err, errInvalidConfirmationTarget) {
+		t.Fatal("expected errInvalidConfirmationTarget, got", err)
+	}
+
+	for _, target := range []types.BlockHeight{1, 2, 10} {
+		txns, err := wt.wallet.SendSiacoinsTarget(types.SiacoinPrecision, types.UnlockHash{}, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := wt.tpool.FeeEstimationForTarget(target).Mul64(estimatedTransactionSize)
+		txn := txns[len(txns)-1]
+		if len(txn.MinerFees) != 1 || !txn.MinerFees[0].Equals(expected) {
+			t.Fatalf("target %v: expected fee %v, got %v", target, expected, txn.MinerFees)
+		}
+	}
+	// a closer target should never pay less than a later one
+	if wt.tpool.FeeEstimationForTarget(1).Cmp(wt.tpool.FeeEstimationForTarget(10)) < 0 {
+		t.Fatal("fee for the next block is lower than the fee for 10 blocks")
+	}
+
+	txns, err := wt.wallet.SendSiacoinsMultiTarget([]types.SiacoinOutput{
+		{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{1}},
+		{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{2}},
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := wt.tpool.FeeEstimationForTarget(2).Mul64(1000 + 60*2)
+	if txn := txns[len(txns)-1]; len(txn.MinerFees) != 1 || !txn.MinerFees[0].Equals(expected) {
+		t.Fatalf("expected fee %v, got %v", expected, txn.MinerFees)
+	}
+}
+
 // TestSendSiacoinsFeeIncluded probes the SendSiacoins method of the wallet with
 // feeIncluded=true.
 func TestSendSiacoinsFeeIncluded(t *testing.T) {
@@ -410,3 +462,176 @@ func TestSendSiacoinsAcceptTxnSetFailed(t *testing.T) {
 		t.Fatalf("SendSiacoins failed: %v", err)
 	}
 }
+
+// TestSendSiacoinsFromOutputs probes the SendSiacoinsFromOutputs method of the
+// wallet.
+func TestSendSiacoinsFromOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// pick a siacoin output
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input modules.UnspentOutput
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput && o.Value.Cmp(input.Value) > 0 {
+			input = o
+		}
+	}
+	if input.Value.IsZero() {
+		t.Fatal("no siacoin output found")
+	}
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// send coins using the output
+	id := types.SiacoinOutputID(input.ID)
+	sco := []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{}}}
+	txns, err := wt.wallet.SendSiacoinsFromOutputs([]types.SiacoinOutputID{id}, sco, uc.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 {
+		t.Fatal("expected a single transaction", len(txns))
+	}
+	txn := txns[0]
+	if len(txn.SiacoinInputs) != 1 || txn.SiacoinInputs[0].ParentID != id {
+		t.Fatal("transaction doesn't spend the selected output", txn.SiacoinInputs)
+	}
+	if len(txn.SiacoinOutputs) != 2 || txn.SiacoinOutputs[1].UnlockHash != uc.UnlockHash() {
+		t.Fatal("change wasn't sent to the change address", txn.SiacoinOutputs)
+	}
+	if !txn.SiacoinOutputs[0].Value.Add(txn.SiacoinOutputs[1].Value).Add(txn.MinerFees[0]).Equals(input.Value) {
+		t.Fatal("inputs and outputs don't match")
+	}
+
+	// the output can't be spent twice
+	_, err = wt.wallet.SendSiacoinsFromOutputs([]types.SiacoinOutputID{id}, sco, types.UnlockHash{})
+	if err == nil {
+		t.Fatal("expected spending the output twice to fail")
+	}
+	wt.miner.AddBlock()
+	_, err = wt.wallet.SendSiacoinsFromOutputs([]types.SiacoinOutputID{id}, sco, types.UnlockHash{})
+	if err == nil {
+		t.Fatal("expected spending a spent output to fail")
+	}
+
+	// spending more than the output should fail
+	change := types.SiacoinOutputID(txn.SiacoinOutputID(1))
+	sco[0].Value = txn.SiacoinOutputs[1].Value
+	_, err = wt.wallet.SendSiacoinsFromOutputs([]types.SiacoinOutputID{change}, sco, types.UnlockHash{})
+	if !errors.Contains(err, modules.ErrLowBalance) {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+}
+
+// TestFreezeOutputs tests that frozen outputs aren't used to fund
+// transactions.
+func TestFreezeOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// freezing an unknown output should fail
+	if err := wt.wallet.FreezeOutputs([]types.OutputID{{1}}); err == nil {
+		t.Fatal("expected freezing an unknown output to fail")
+	}
+
+	// freeze all siacoin outputs
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []types.OutputID
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput {
+			ids = append(ids, o.ID)
+		}
+	}
+	if err := wt.wallet.FreezeOutputs(ids); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err = wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if o.FundType == types.SpecifierSiacoinOutput && !o.Frozen {
+			t.Fatal("output should be frozen", o.ID)
+		}
+	}
+
+	// the wallet shouldn't be able to fund transactions
+	_, err = wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err == nil || !strings.Contains(err.Error(), modules.ErrLowBalance.Error()) {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+	sco := []types.SiacoinOutput{{Value: types.SiacoinPrecision, UnlockHash: types.UnlockHash{}}}
+	_, err = wt.wallet.SendSiacoinsFromOutputs([]types.SiacoinOutputID{types.SiacoinOutputID(ids[0])}, sco, types.UnlockHash{})
+	if !errors.Contains(err, errFrozenOutput) {
+		t.Fatal("expected errFrozenOutput, got", err)
+	}
+
+	// unfreeze the outputs again
+	if err := wt.wallet.UnfreezeOutputs(ids); err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// freeze the confirmed outputs spent by the transaction; they should be
+	// pruned once the transaction is confirmed
+	confirmed := make(map[types.OutputID]struct{})
+	for _, id := range ids {
+		confirmed[id] = struct{}{}
+	}
+	var spent []types.OutputID
+	for _, txn := range txns {
+		for _, sci := range txn.SiacoinInputs {
+			if _, ok := confirmed[types.OutputID(sci.ParentID)]; ok {
+				spent = append(spent, types.OutputID(sci.ParentID))
+			}
+		}
+	}
+	if len(spent) == 0 {
+		t.Fatal("transaction didn't spend any confirmed outputs")
+	}
+	if err := wt.wallet.FreezeOutputs(spent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	for _, id := range spent {
+		if dbIsFrozenOutput(wt.wallet.dbTx, id) {
+			t.Error("spent output wasn't pruned", id)
+		}
+	}
+	wt.wallet.mu.Unlock()
+}
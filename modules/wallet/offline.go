@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"errors"
 	"math"
+	"sort"
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
+// errOfflineTransactionMismatch is returned when the data of an
+// OfflineTransaction doesn't match its transaction.
+var errOfflineTransactionMismatch = errors.New("offline transaction data doesn't match the transaction")
+
 // UnspentOutputs returns the unspent outputs tracked by the wallet.
 func (w *Wallet) UnspentOutputs() ([]modules.UnspentOutput, error) {
 	if err := w.tg.Add(); err != nil {
@@ -32,6 +37,7 @@ func (w *Wallet) UnspentOutputs() ([]modules.UnspentOutput, error) {
 			ID:         types.OutputID(scoid),
 			UnlockHash: sco.UnlockHash,
 			Value:      sco.Value,
+			Frozen:     dbIsFrozenOutput(w.dbTx, types.OutputID(scoid)),
 		})
 	})
 	dbForEachSiafundOutput(w.dbTx, func(sfoid types.SiafundOutputID, sfo types.SiafundOutput) {
@@ -40,6 +46,7 @@ func (w *Wallet) UnspentOutputs() ([]modules.UnspentOutput, error) {
 			ID:         types.OutputID(sfoid),
 			UnlockHash: sfo.UnlockHash,
 			Value:      sfo.Value,
+			Frozen:     dbIsFrozenOutput(w.dbTx, types.OutputID(sfoid)),
 		})
 	})
 
@@ -157,29 +164,49 @@ func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash) e
 	defer w.tg.Done()
 
 	w.mu.Lock()
-	defer w.mu.Unlock()
 	if !w.unlocked {
+		w.mu.Unlock()
 		return modules.ErrLockedWallet
 	}
 	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
 	if err != nil {
+		w.mu.Unlock()
 		return err
 	}
 
-	// if toSign is empty, sign all inputs that we have keys for
+	// if toSign is empty, sign all inputs that we have keys for or that the
+	// external signer can sign
 	if len(toSign) == 0 {
+		canSign := func(uh types.UnlockHash) bool {
+			_, ok := w.keys[uh]
+			_, watched := w.watchedAddrs[uh]
+			return ok || (watched && w.signer != nil)
+		}
 		for _, sci := range txn.SiacoinInputs {
-			if _, ok := w.keys[sci.UnlockConditions.UnlockHash()]; ok {
+			if canSign(sci.UnlockConditions.UnlockHash()) {
 				toSign = append(toSign, crypto.Hash(sci.ParentID))
 			}
 		}
 		for _, sfi := range txn.SiafundInputs {
-			if _, ok := w.keys[sfi.UnlockConditions.UnlockHash()]; ok {
+			if canSign(sfi.UnlockConditions.UnlockHash()) {
 				toSign = append(toSign, crypto.Hash(sfi.ParentID))
 			}
 		}
 	}
-	return signTransaction(txn, w.keys, toSign, consensusHeight)
+	w.mu.Unlock()
+
+	// inputs of watched addresses are signed by the external signer without
+	// holding the lock, since it might wait for user confirmation
+	local, external := w.managedExternalSignatures(*txn, toSign)
+	if len(local) > 0 {
+		w.mu.RLock()
+		err = signTransaction(txn, w.keys, local, consensusHeight)
+		w.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+	}
+	return w.managedSignExternal(txn, external, consensusHeight)
 }
 
 // SignTransaction signs txn using secret keys derived from seed. The
@@ -212,6 +239,175 @@ func SignTransaction(txn *types.Transaction, seed modules.Seed, toSign []crypto.
 	return signTransaction(txn, keys, toSign, height)
 }
 
+// ExportOfflineTransaction creates an OfflineTransaction containing txn, the
+// values of the outputs it spends and its signature hashes, which is all the
+// data required to verify and sign txn on an offline machine. The transactions
+// which created the spent outputs are included as well if the wallet knows
+// them, which allows the signer to verify the values of the outputs. If toSign
+// is empty, all TransactionSignatures without a signature are marked for
+// signing.
+func (w *Wallet) ExportOfflineTransaction(txn types.Transaction, toSign []crypto.Hash) (modules.OfflineTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.OfflineTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.OfflineTransaction{}, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.OfflineTransaction{}, err
+	}
+
+	// if toSign is empty, sign all signatures which are missing
+	if len(toSign) == 0 {
+		for _, sig := range txn.TransactionSignatures {
+			if len(sig.Signature) == 0 {
+				toSign = append(toSign, sig.ParentID)
+			}
+		}
+	}
+	for _, id := range toSign {
+		found := false
+		for _, sig := range txn.TransactionSignatures {
+			found = found || sig.ParentID == id
+		}
+		if !found {
+			return modules.OfflineTransaction{}, errors.New("toSign references signatures not present in transaction")
+		}
+	}
+
+	otxn := modules.OfflineTransaction{
+		Transaction: txn,
+		ToSign:      toSign,
+		Height:      height,
+	}
+	parents := make(map[types.TransactionID]struct{})
+	for _, sci := range txn.SiacoinInputs {
+		var sco types.SiacoinOutput
+		err := dbGet(w.dbTx.Bucket(bucketSiacoinOutputs), sci.ParentID, &sco)
+		if err != nil {
+			return modules.OfflineTransaction{}, errors.New("transaction spends unknown output " + sci.ParentID.String())
+		}
+		otxn.Inputs = append(otxn.Inputs, modules.OfflineTransactionInput{
+			ParentID:   sci.ParentID,
+			UnlockHash: sco.UnlockHash,
+			Value:      sco.Value,
+		})
+		parent, ok, err := w.outputParent(sci.ParentID, sco.UnlockHash)
+		if err != nil {
+			return modules.OfflineTransaction{}, err
+		}
+		if _, exists := parents[parent.ID()]; ok && !exists {
+			parents[parent.ID()] = struct{}{}
+			otxn.Parents = append(otxn.Parents, parent)
+		}
+	}
+	for i := range txn.TransactionSignatures {
+		otxn.SigHashes = append(otxn.SigHashes, txn.SigHash(i, height))
+	}
+	return otxn, nil
+}
+
+// outputParent returns the transaction which created the siacoin output with
+// the given id. Outputs which weren't created by a transaction, e.g. miner
+// payouts, don't have a parent.
+func (w *Wallet) outputParent(id types.SiacoinOutputID, uh types.UnlockHash) (types.Transaction, bool, error) {
+	txnIndices, err := dbGetAddrTransactions(w.dbTx, uh)
+	if err != nil {
+		return types.Transaction{}, false, err
+	}
+	for _, i := range txnIndices {
+		pt, err := dbGetProcessedTransaction(w.dbTx, i)
+		if err != nil {
+			return types.Transaction{}, false, err
+		}
+		for j := range pt.Transaction.SiacoinOutputs {
+			if pt.Transaction.SiacoinOutputID(uint64(j)) == id {
+				return pt.Transaction, true, nil
+			}
+		}
+	}
+	return types.Transaction{}, false, nil
+}
+
+// BroadcastOfflineTransaction verifies that the transaction of an
+// OfflineTransaction was fully signed and submits it to the transaction pool.
+func (w *Wallet) BroadcastOfflineTransaction(otxn modules.OfflineTransaction) (types.TransactionID, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.TransactionID{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	txn := otxn.Transaction
+	if err := txn.StandaloneValid(w.cs.Height()); err != nil {
+		return types.TransactionID{}, errors.New("transaction is not valid: " + err.Error())
+	}
+	if err := w.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		return types.TransactionID{}, err
+	}
+	return txn.ID(), nil
+}
+
+// VerifyOfflineTransactionInputs verifies the values of the inputs of otxn
+// against the parent transactions supplied with it and returns which of the
+// inputs were verified. The signature hashes don't commit to the values of the
+// spent outputs, so the values of inputs without a parent transaction can't be
+// trusted.
+func VerifyOfflineTransactionInputs(otxn modules.OfflineTransaction) ([]bool, error) {
+	outputs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, parent := range otxn.Parents {
+		for i, sco := range parent.SiacoinOutputs {
+			outputs[parent.SiacoinOutputID(uint64(i))] = sco
+		}
+	}
+	verified := make([]bool, len(otxn.Inputs))
+	for i, input := range otxn.Inputs {
+		sco, ok := outputs[input.ParentID]
+		if !ok {
+			continue
+		}
+		if !sco.Value.Equals(input.Value) || sco.UnlockHash != input.UnlockHash {
+			return nil, errOfflineTransactionMismatch
+		}
+		verified[i] = true
+	}
+	return verified, nil
+}
+
+// SignOfflineTransaction signs an OfflineTransaction using secret keys
+// derived from seed. Before signing, it verifies that the signature hashes and
+// inputs of otxn match its transaction and that the values of the inputs match
+// the supplied parent transactions. The values of inputs without a parent
+// transaction are not verified, see VerifyOfflineTransactionInputs.
+func SignOfflineTransaction(otxn *modules.OfflineTransaction, seed modules.Seed) error {
+	txn := &otxn.Transaction
+	if len(otxn.SigHashes) != len(txn.TransactionSignatures) {
+		return errOfflineTransactionMismatch
+	}
+	for i, sigHash := range otxn.SigHashes {
+		if txn.SigHash(i, otxn.Height) != sigHash {
+			return errOfflineTransactionMismatch
+		}
+	}
+	if len(otxn.Inputs) != len(txn.SiacoinInputs) {
+		return errOfflineTransactionMismatch
+	}
+	for i, sci := range txn.SiacoinInputs {
+		input := otxn.Inputs[i]
+		if input.ParentID != sci.ParentID || input.UnlockHash != sci.UnlockConditions.UnlockHash() {
+			return errOfflineTransactionMismatch
+		}
+	}
+	if _, err := VerifyOfflineTransactionInputs(*otxn); err != nil {
+		return err
+	}
+	return SignTransaction(txn, seed, otxn.ToSign, otxn.Height)
+}
+
 // signTransaction signs the specified inputs of txn using the specified keys.
 // It returns an error if any of the specified inputs cannot be signed.
 func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendableKey, toSign []crypto.Hash, height types.BlockHeight) error {
@@ -355,6 +551,156 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 	return nil
 }
 
+// AddWatchPublicKeys adds the standard addresses of the given public keys to
+// the watch set. The unlock conditions of the addresses are stored in the
+// wallet database, which allows for building unsigned transactions spending
+// from them.
+func (w *Wallet) AddWatchPublicKeys(pks []types.SiaPublicKey, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	addrs := make([]types.UnlockHash, 0, len(pks))
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		for _, pk := range pks {
+			uc := types.UnlockConditions{
+				PublicKeys:         []types.SiaPublicKey{pk},
+				SignaturesRequired: 1,
+			}
+			if err := dbPutUnlockConditions(w.dbTx, uc); err != nil {
+				return err
+			}
+			addrs = append(addrs, uc.UnlockHash())
+		}
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	return w.AddWatchAddresses(addrs, unused)
+}
+
+// BuildUnsignedTransaction builds a transaction which sends the given outputs
+// and is funded by watched siacoin outputs with known unlock conditions. Any
+// change is sent to changeAddr, or to the address of the first input if
+// changeAddr is empty. If fee is zero, it is estimated using the transaction
+// pool. The returned transaction contains empty signatures for the returned
+// parent ids, which need to be signed offline before the transaction can be
+// broadcast.
+//
+// The wallet doesn't keep track of the outputs spent by unsigned transactions
+// until they are broadcast, so building multiple unsigned transactions at once
+// might result in them spending the same outputs.
+func (w *Wallet) BuildUnsignedTransaction(outputs []types.SiacoinOutput, fee types.Currency, changeAddr types.UnlockHash) (types.Transaction, []crypto.Hash, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(outputs) == 0 {
+		return types.Transaction{}, nil, errors.New("transaction needs at least one output")
+	}
+	if fee.IsZero() {
+		_, fee = w.tpool.FeeEstimation()
+		fee = fee.Mul64(estimatedTransactionSize)
+	}
+	amount := fee
+	for _, sco := range outputs {
+		amount = amount.Add(sco.Value)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.Transaction{}, nil, modules.ErrLockedWallet
+	}
+
+	// don't use outputs which are spent in pending transactions
+	pending := make(map[types.OutputID]struct{})
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		for _, input := range pt.Inputs {
+			if input.WalletAddress {
+				pending[input.ParentID] = struct{}{}
+			}
+		}
+	}
+
+	// gather the watched outputs which we know the unlock conditions of
+	var so sortedOutputs
+	var ucs []types.UnlockConditions
+	dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, watched := w.watchedAddrs[sco.UnlockHash]; !watched {
+			return
+		}
+		if _, spent := pending[types.OutputID(scoid)]; spent {
+			return
+		}
+		if dbIsFrozenOutput(w.dbTx, types.OutputID(scoid)) {
+			return
+		}
+		uc, err := dbGetUnlockConditions(w.dbTx, sco.UnlockHash)
+		if err != nil {
+			return
+		}
+		so.ids = append(so.ids, scoid)
+		so.outputs = append(so.outputs, sco)
+		ucs = append(ucs, uc)
+	})
+
+	// fund the transaction, starting with the largest outputs
+	order := make([]int, len(so.outputs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return so.outputs[order[i]].Value.Cmp(so.outputs[order[j]].Value) > 0
+	})
+	txn := types.Transaction{
+		SiacoinOutputs: append([]types.SiacoinOutput(nil), outputs...),
+		MinerFees:      []types.Currency{fee},
+	}
+	var toSign []crypto.Hash
+	var funded types.Currency
+	for _, i := range order {
+		if funded.Cmp(amount) >= 0 {
+			break
+		}
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: ucs[i],
+		})
+		for j := uint64(0); j < ucs[i].SignaturesRequired; j++ {
+			txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+				ParentID:       crypto.Hash(so.ids[i]),
+				CoveredFields:  types.FullCoveredFields,
+				PublicKeyIndex: j,
+			})
+		}
+		toSign = append(toSign, crypto.Hash(so.ids[i]))
+		funded = funded.Add(so.outputs[i].Value)
+	}
+	if funded.Cmp(amount) < 0 {
+		return types.Transaction{}, nil, modules.ErrLowBalance
+	}
+
+	// send the change back
+	if change := funded.Sub(amount); !change.IsZero() {
+		if changeAddr == (types.UnlockHash{}) {
+			changeAddr = txn.SiacoinInputs[0].UnlockConditions.UnlockHash()
+		}
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+			Value:      change,
+			UnlockHash: changeAddr,
+		})
+	}
+	return txn, toSign, nil
+}
+
 // RemoveWatchAddresses instructs the wallet to stop tracking a set of
 // addresses and delete their associated transactions. If none of the
 // addresses have appeared in the blockchain, the unused flag may be set to
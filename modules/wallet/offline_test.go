@@ -1,11 +1,16 @@
 package wallet
 
 import (
+	"fmt"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -455,3 +460,245 @@ func TestUnlockConditions(t *testing.T) {
 		t.Fatal("expected error when requested unlock conditions of random address")
 	}
 }
+
+// TestWatchOnlyWallet tests initializing a watch-only wallet, watching public
+// keys and building unsigned transactions that are signed offline.
+func TestWatchOnlyWallet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// create a watch-only wallet on the same consensus set and tpool
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, "watchonly"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if err := w.InitWatchOnly(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.InitWatchOnly(masterKey); !errors.Contains(err, errReencrypt) {
+		t.Fatal("expected errReencrypt, got", err)
+	}
+	if watchOnly, err := w.WatchOnly(); err != nil || !watchOnly {
+		t.Fatal("expected wallet to be watch-only", watchOnly, err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// the wallet has no seed
+	if _, _, err := w.PrimarySeed(); !errors.Contains(err, modules.ErrWatchOnlyWallet) {
+		t.Fatal("expected ErrWatchOnlyWallet, got", err)
+	}
+	if _, err := w.NextAddress(); !errors.Contains(err, modules.ErrWatchOnlyWallet) {
+		t.Fatal("expected ErrWatchOnlyWallet, got", err)
+	}
+
+	// watch the public key of a key that is kept offline and fund it
+	sk := generateSpendableKey(modules.Seed{}, 1234)
+	addr := sk.UnlockConditions.UnlockHash()
+	err = w.AddWatchPublicKeys(sk.UnlockConditions.PublicKeys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funding := types.SiacoinPrecision.Mul64(100)
+	_, err = wt.wallet.SendSiacoins(funding, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.miner.AddBlock()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, _, _, err := w.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if !balance.Equals(funding) {
+			return fmt.Errorf("expected balance %v, got %v", funding, balance)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// building a transaction exceeding the balance should fail
+	fee := types.SiacoinPrecision
+	outputs := []types.SiacoinOutput{{Value: funding, UnlockHash: types.UnlockHash{}}}
+	_, _, err = w.BuildUnsignedTransaction(outputs, fee, types.UnlockHash{})
+	if !errors.Contains(err, modules.ErrLowBalance) {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+
+	// build a transaction sending some of the coins to the void
+	outputs[0].Value = types.SiacoinPrecision.Mul64(10)
+	txn, toSign, err := w.BuildUnsignedTransaction(outputs, fee, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(toSign) != 1 || len(txn.SiacoinInputs) != 1 || len(txn.TransactionSignatures) != 1 {
+		t.Fatal("unexpected transaction", txn, toSign)
+	}
+	if len(txn.SiacoinOutputs) != 2 || txn.SiacoinOutputs[1].UnlockHash != addr {
+		t.Fatal("expected change to be sent back to the input address", txn.SiacoinOutputs)
+	}
+	change := funding.Sub(outputs[0].Value).Sub(fee)
+	if !txn.SiacoinOutputs[1].Value.Equals(change) {
+		t.Fatal("unexpected change", txn.SiacoinOutputs[1].Value, change)
+	}
+
+	// sign the transaction offline and broadcast it
+	sig := crypto.SignHash(txn.SigHash(0, wt.cs.Height()), sk.SecretKeys[0])
+	txn.TransactionSignatures[0].Signature = sig[:]
+	err = wt.tpool.AcceptTransactionSet([]types.Transaction{txn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.addBlockNoPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, _, _, err := w.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if !balance.Equals(change) {
+			return fmt.Errorf("expected balance %v, got %v", change, balance)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// lock and unlock the wallet again, the watched address should persist
+	if err := w.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(crypto.GenerateSiaKey(crypto.TypeDefaultWallet)); !errors.Contains(err, modules.ErrBadEncryptionKey) {
+		t.Fatal("expected ErrBadEncryptionKey, got", err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := w.WatchAddresses()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(addrs) != 1 || addrs[0] != addr {
+		t.Fatal("expecting addr to be watched, got", addrs)
+	}
+}
+
+// TestOfflineTransaction tests exporting a transaction, signing it with a seed
+// and broadcasting the signed transaction.
+func TestOfflineTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// watch the first key of an offline seed and fund it
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	sk := generateSpendableKey(seed, 0)
+	err = wt.wallet.AddWatchPublicKeys(sk.UnlockConditions.PublicKeys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), sk.UnlockConditions.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.miner.AddBlock()
+
+	// build and export a transaction
+	outputs := []types.SiacoinOutput{{Value: types.SiacoinPrecision.Mul64(10), UnlockHash: types.UnlockHash{}}}
+	txn, toSign, err := wt.wallet.BuildUnsignedTransaction(outputs, types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	otxn, err := wt.wallet.ExportOfflineTransaction(txn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(otxn.ToSign, toSign) {
+		t.Fatal("unexpected toSign", otxn.ToSign, toSign)
+	}
+	if len(otxn.Inputs) != 1 || !otxn.Inputs[0].Value.Equals(types.SiacoinPrecision.Mul64(100)) {
+		t.Fatal("unexpected inputs", otxn.Inputs)
+	}
+	if len(otxn.SigHashes) != 1 || otxn.SigHashes[0] != txn.SigHash(0, otxn.Height) {
+		t.Fatal("unexpected sighashes", otxn.SigHashes)
+	}
+	if verified, err := VerifyOfflineTransactionInputs(otxn); err != nil || !reflect.DeepEqual(verified, []bool{true}) {
+		t.Fatal("inputs should be verified by the parent transactions", verified, err)
+	}
+
+	// without parents the inputs can't be verified
+	unverified := otxn
+	unverified.Parents = nil
+	if verified, err := VerifyOfflineTransactionInputs(unverified); err != nil || !reflect.DeepEqual(verified, []bool{false}) {
+		t.Fatal("inputs shouldn't be verified without parent transactions", verified, err)
+	}
+
+	// broadcasting the unsigned transaction should fail
+	if _, err := wt.wallet.BroadcastOfflineTransaction(otxn); err == nil {
+		t.Fatal("expected broadcasting an unsigned transaction to fail")
+	}
+
+	// tampering with the transaction should be detected when signing
+	tampered := otxn
+	tampered.Transaction.SiacoinOutputs = append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...)
+	tampered.Transaction.SiacoinOutputs[0].UnlockHash = sk.UnlockConditions.UnlockHash()
+	if err := SignOfflineTransaction(&tampered, seed); !errors.Contains(err, errOfflineTransactionMismatch) {
+		t.Fatal("expected errOfflineTransactionMismatch, got", err)
+	}
+	tampered = otxn
+	tampered.Inputs = []modules.OfflineTransactionInput{otxn.Inputs[0]}
+	tampered.Inputs[0].Value = types.SiacoinPrecision.Mul64(11)
+	if err := SignOfflineTransaction(&tampered, seed); !errors.Contains(err, errOfflineTransactionMismatch) {
+		t.Fatal("expected errOfflineTransactionMismatch, got", err)
+	}
+
+	// sign and broadcast the transaction
+	if err := SignOfflineTransaction(&otxn, seed); err != nil {
+		t.Fatal(err)
+	}
+	txid, err := wt.wallet.BroadcastOfflineTransaction(otxn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txid != txn.ID() {
+		t.Fatal("unexpected transaction id")
+	}
+	err = wt.addBlockNoPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := wt.wallet.Transaction(txid); err != nil || !ok {
+		t.Fatal("transaction not found in wallet", ok, err)
+	}
+}
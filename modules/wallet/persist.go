@@ -92,8 +92,9 @@ func (w *Wallet) openDB(filename string) (err error) {
 			}
 		}
 
-		// check whether wallet is encrypted
+		// check whether wallet is encrypted and whether it is watch-only
 		w.encrypted = tx.Bucket(bucketWallet).Get(keyEncryptionVerification) != nil
+		w.watchOnly = tx.Bucket(bucketWallet).Get(keyWatchOnly) != nil
 		return nil
 	})
 	return err
@@ -0,0 +1,204 @@
+package wallet
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errNoRescan is returned when cancelling a rescan while no rescan started
+// with Rescan is in progress.
+var errNoRescan = errors.New("no rescan in progress")
+
+// Rescan rescans the blockchain starting at startHeight in the background.
+// Transactions confirmed at or above the start height are removed from the
+// wallet's history and recreated by the rescan. The start height can't exceed
+// the wallet's current height since the wallet hasn't seen the blocks above
+// it.
+func (w *Wallet) Rescan(startHeight types.BlockHeight) (err error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer func() {
+		if err != nil {
+			w.scanLock.Unlock()
+		}
+	}()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	height, err := dbGetConsensusHeight(w.dbTx)
+	w.mu.RUnlock()
+	if !unlocked {
+		return modules.ErrLockedWallet
+	}
+	if err != nil {
+		return err
+	}
+	if startHeight > height {
+		startHeight = height
+	}
+
+	// find the consensus change to resubscribe from. The returned start
+	// height might be lower than the requested one if the blockchain was
+	// reorged around it.
+	ccid := modules.ConsensusChangeBeginning
+	if startHeight > 0 {
+		ccid, startHeight, err = w.cs.ConsensusChangeAtHeight(startHeight - 1)
+		if err != nil {
+			return errors.AddContext(err, "failed to find consensus change to rescan from")
+		}
+	}
+
+	// wait for any ongoing subscription to finish. The lock is held until the
+	// rescan is done.
+	w.subscribedMu.Lock()
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	cancel := make(chan struct{})
+	w.mu.Lock()
+	err = func() error {
+		// remove the transactions which are recreated by the rescan
+		for {
+			pt, err := dbGetLastProcessedTransaction(w.dbTx)
+			if err != nil || pt.ConfirmationHeight < startHeight {
+				break
+			}
+			if err := dbDeleteLastProcessedTransaction(w.dbTx); err != nil {
+				return err
+			}
+		}
+		w.unconfirmedProcessedTransactions = nil
+
+		if err := dbPutConsensusChangeID(w.dbTx, ccid); err != nil {
+			return err
+		}
+		var resumeHeight types.BlockHeight
+		if startHeight > 0 {
+			resumeHeight = startHeight - 1
+		}
+		if err := dbPutConsensusHeight(w.dbTx, resumeHeight); err != nil {
+			return err
+		}
+		w.rescanProgress = modules.WalletRescanProgress{
+			Rescanning:  true,
+			StartHeight: startHeight,
+			StartTime:   time.Now(),
+		}
+		w.rescanCancel = cancel
+		return w.syncDB()
+	}()
+	w.mu.Unlock()
+	if err != nil {
+		// resubscribe from wherever the wallet is now
+		w.subscribed = false
+		w.subscribedMu.Unlock()
+		return err
+	}
+
+	w.log.Printf("Rescanning the blockchain starting at height %v", startHeight)
+	go w.threadedRescan(ccid, cancel)
+	return nil
+}
+
+// threadedRescan resubscribes the wallet to the consensus set starting at
+// ccid. It expects the scanLock and the subscribedMu to be locked and unlocks
+// them when the rescan is done.
+func (w *Wallet) threadedRescan(ccid modules.ConsensusChangeID, cancel chan struct{}) {
+	defer w.scanLock.Unlock()
+	defer w.subscribedMu.Unlock()
+
+	// stop the rescan when either the wallet shuts down or the rescan is
+	// cancelled
+	stop := make(chan struct{})
+	var once sync.Once
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-w.tg.StopChan():
+		case <-cancel:
+		case <-done:
+			return
+		}
+		once.Do(func() { close(stop) })
+	}()
+
+	err := w.cs.ConsensusSetSubscribe(w, ccid, stop)
+	if err == nil {
+		w.tpool.TransactionPoolSubscribe(w)
+	}
+	w.subscribed = err == nil
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rescanProgress.Rescanning = false
+	w.rescanCancel = nil
+	select {
+	case <-cancel:
+		w.rescanProgress.Cancelled = true
+		w.log.Println("Rescan was cancelled")
+		return
+	default:
+	}
+	if err != nil {
+		w.rescanProgress.Error = err.Error()
+		w.log.Println("ERROR: rescan failed:", err)
+		return
+	}
+	w.log.Println("Finished rescanning the blockchain")
+}
+
+// RescanProgress returns the progress of the latest rescan started with
+// Rescan.
+func (w *Wallet) RescanProgress() (modules.WalletRescanProgress, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WalletRescanProgress{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	targetHeight := w.cs.Height()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	progress := w.rescanProgress
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.WalletRescanProgress{}, err
+	}
+	progress.Height = height
+	progress.TargetHeight = targetHeight
+
+	// estimate the remaining time using the average time per block so far
+	scanned := int64(height) - int64(progress.StartHeight) + 1
+	remaining := int64(targetHeight) - int64(height)
+	if progress.Rescanning && scanned > 0 && remaining > 0 {
+		elapsed := time.Since(progress.StartTime)
+		progress.ETA = time.Duration(int64(elapsed) / scanned * remaining)
+	}
+	return progress, nil
+}
+
+// CancelRescan cancels the rescan started with Rescan.
+func (w *Wallet) CancelRescan() error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.rescanCancel == nil {
+		return errNoRescan
+	}
+	close(w.rescanCancel)
+	w.rescanCancel = nil
+	return nil
+}
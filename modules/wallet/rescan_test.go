@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// waitForRescan waits for the rescan started with Rescan to finish and returns
+// its final progress.
+func waitForRescan(w *Wallet) (progress modules.WalletRescanProgress, err error) {
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		progress, err = w.RescanProgress()
+		if err != nil {
+			return err
+		}
+		if progress.Rescanning {
+			return errors.New("wallet is still rescanning")
+		}
+		return nil
+	})
+	return
+}
+
+// TestRescan tests rescanning the blockchain from a height to find the outputs
+// of an address which was added without a rescan.
+func TestRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// send coins to an address which isn't watched yet
+	sk := generateSpendableKey(modules.Seed{}, 1234)
+	addr := sk.UnlockConditions.UnlockHash()
+	amount := types.SiacoinPrecision.Mul64(10)
+	if _, err := wt.wallet.SendSiacoins(amount, addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	sendHeight := wt.cs.Height()
+
+	// watching the address without a rescan doesn't find the output
+	before, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.Transactions(0, sendHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.AddWatchAddresses([]types.UnlockHash{addr}, true); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _, _, err := wt.wallet.ConfirmedBalance(); err != nil || !balance.Equals(before) {
+		t.Fatal("balance shouldn't change without a rescan", balance, before, err)
+	}
+
+	// rescan from the height the coins were sent at
+	if err := wt.wallet.Rescan(sendHeight); err != nil {
+		t.Fatal(err)
+	}
+	progress, err := waitForRescan(wt.wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Cancelled || progress.Error != "" {
+		t.Fatal("rescan didn't finish", progress)
+	}
+	if progress.StartHeight > sendHeight || progress.Height != wt.cs.Height() || progress.TargetHeight != wt.cs.Height() {
+		t.Fatal("unexpected progress", progress)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, _, _, err := wt.wallet.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if !balance.Equals(before.Add(amount)) {
+			return fmt.Errorf("expected balance %v, got %v", before.Add(amount), balance)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the rescanned transactions should replace the old ones
+	rescanned, err := wt.wallet.Transactions(0, sendHeight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rescanned) != len(txns) {
+		t.Fatalf("expected %v transactions, got %v", len(txns), len(rescanned))
+	}
+	for i := range txns {
+		if rescanned[i].TransactionID != txns[i].TransactionID {
+			t.Fatal("transactions don't match after rescan")
+		}
+	}
+
+	// a start height above the wallet's height rescans the latest block
+	if err := wt.wallet.Rescan(wt.cs.Height() + 100); err != nil {
+		t.Fatal(err)
+	}
+	progress, err = waitForRescan(wt.wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.StartHeight > wt.cs.Height() || progress.Height != wt.cs.Height() {
+		t.Fatal("unexpected progress", progress)
+	}
+}
+
+// TestCancelRescan tests cancelling and resuming a rescan.
+func TestCancelRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if err := wt.wallet.CancelRescan(); !errors.Contains(err, errNoRescan) {
+		t.Fatal("expected errNoRescan, got", err)
+	}
+	before, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// start a full rescan and cancel it. The rescan might finish before it
+	// is cancelled.
+	if err := wt.wallet.Rescan(0); err != nil {
+		t.Fatal(err)
+	}
+	cancelErr := wt.wallet.CancelRescan()
+	if cancelErr != nil && !errors.Contains(cancelErr, errNoRescan) {
+		t.Fatal(cancelErr)
+	}
+	progress, err := waitForRescan(wt.wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelErr == nil && !progress.Cancelled {
+		t.Fatal("expected rescan to be cancelled", progress)
+	}
+
+	// starting another rescan resumes from where the cancelled one stopped
+	if err := wt.wallet.Rescan(progress.Height + 1); err != nil {
+		t.Fatal(err)
+	}
+	progress, err = waitForRescan(wt.wallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Cancelled || progress.Height != wt.cs.Height() {
+		t.Fatal("unexpected progress", progress)
+	}
+	balance, _, _, err := wt.wallet.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(before) {
+		t.Fatalf("expected balance %v after rescan, got %v", before, balance)
+	}
+}
@@ -1,7 +1,9 @@
 package wallet
 
 import (
+	"bytes"
 	"runtime"
+	"sort"
 	"sync"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -14,8 +16,34 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// sweepOutputSize is the approx. size in bytes of an output and
+	// accompanying signature.
+	sweepOutputSize = 350
+
+	// sweepMaxOutputs is the approx. number of outputs that a transaction
+	// can handle.
+	sweepMaxOutputs = 50
+)
+
 var (
 	errKnownSeed = errors.New("seed is already known")
+
+	// errNothingToSweep is returned when no outputs were found for a seed.
+	errNothingToSweep = errors.New("nothing to sweep")
+
+	// errSweepFeeTooHigh is returned when the transaction fee of a sweep
+	// exceeds the value of the swept outputs.
+	errSweepFeeTooHigh = errors.New("transaction fee exceeds value of swept outputs")
+
+	// errInsufficientSweepCoins and errInsufficientSweepFunds are returned
+	// when a partial sweep requests more than the seed contains.
+	errInsufficientSweepCoins = errors.New("seed doesn't contain enough siacoins to sweep the requested amount and the fee")
+	errInsufficientSweepFunds = errors.New("seed doesn't contain enough siafunds to sweep the requested amount")
+
+	// errPartialSweepTooLarge is returned when a partial sweep needs more
+	// outputs than fit into a single transaction.
+	errPartialSweepTooLarge = errors.New("partial sweep requires too many outputs for a single transaction")
 )
 
 type (
@@ -30,6 +58,21 @@ type (
 		EncryptionVerification crypto.Ciphertext
 		Seed                   crypto.Ciphertext
 	}
+
+	// sweepTxn describes a transaction of a seed sweep. coins and funds are
+	// sent to the wallet, coinChange and fundChange are sent back to the
+	// seed. walletFee is the part of the fee paid by the wallet because the
+	// swept siacoins don't cover it.
+	sweepTxn struct {
+		siacoinOutputs []scannedOutput
+		siafundOutputs []scannedOutput
+		fee            types.Currency
+		walletFee      types.Currency
+		coins          types.Currency
+		funds          types.Currency
+		coinChange     types.Currency
+		fundChange     types.Currency
+	}
 )
 
 // generateSpendableKey creates the keys and unlock conditions for seed at a
@@ -100,7 +143,7 @@ func (w *Wallet) regenerateLookahead(start uint64) {
 	maxKeys := maxLookahead(start)
 	existingKeys := uint64(len(w.lookahead))
 
-	for i, k := range generateKeys(w.primarySeed, start+existingKeys, maxKeys-existingKeys) {
+	for i, k := range generateKeys(w.primaryKeySeed, start+existingKeys, maxKeys-existingKeys) {
 		w.lookahead[k.UnlockConditions.UnlockHash()] = start + existingKeys + uint64(i)
 	}
 }
@@ -115,10 +158,13 @@ func (w *Wallet) integrateSeed(seed modules.Seed, n uint64) {
 
 // nextPrimarySeedAddress fetches the next n addresses from the primary seed.
 func (w *Wallet) nextPrimarySeedAddresses(tx *bolt.Tx, n uint64) ([]types.UnlockConditions, error) {
-	// Check that the wallet has been unlocked.
+	// Check that the wallet has been unlocked and has a seed.
 	if !w.unlocked {
 		return []types.UnlockConditions{}, modules.ErrLockedWallet
 	}
+	if w.watchOnly {
+		return []types.UnlockConditions{}, modules.ErrWatchOnlyWallet
+	}
 
 	// Check how many unused addresses we have available.
 	neededUnused := uint64(len(w.unusedKeys))
@@ -143,7 +189,7 @@ func (w *Wallet) nextPrimarySeedAddresses(tx *bolt.Tx, n uint64) ([]types.Unlock
 		// Integrate the next keys into the wallet, and return the unlock
 		// conditions. Also remove new keys from the future keys and update them
 		// according to new progress
-		spendableKeys := generateKeys(w.primarySeed, progress, n)
+		spendableKeys := generateKeys(w.primaryKeySeed, progress, n)
 		ucs = make([]types.UnlockConditions, 0, len(spendableKeys))
 		for _, spendableKey := range spendableKeys {
 			w.keys[spendableKey.UnlockConditions.UnlockHash()] = spendableKey
@@ -194,6 +240,9 @@ func (w *Wallet) AllSeeds() ([]modules.Seed, error) {
 	if !w.unlocked {
 		return nil, modules.ErrLockedWallet
 	}
+	if w.watchOnly {
+		return nil, modules.ErrWatchOnlyWallet
+	}
 	return append([]modules.Seed{w.primarySeed}, w.seeds...), nil
 }
 
@@ -210,6 +259,9 @@ func (w *Wallet) PrimarySeed() (modules.Seed, uint64, error) {
 	if !w.unlocked {
 		return modules.Seed{}, 0, modules.ErrLockedWallet
 	}
+	if w.watchOnly {
+		return modules.Seed{}, 0, modules.ErrWatchOnlyWallet
+	}
 	progress, err := dbGetPrimarySeedProgress(w.dbTx)
 	if err != nil {
 		return modules.Seed{}, 0, err
@@ -225,6 +277,26 @@ func (w *Wallet) PrimarySeed() (modules.Seed, uint64, error) {
 	return w.primarySeed, remaining, nil
 }
 
+// PrimaryKeySeed returns the seed the keys of the wallet and the renter are
+// derived from. Unlike the primary seed, it can't be recovered from the wallet's
+// persistence alone if the wallet uses a seed passphrase.
+func (w *Wallet) PrimaryKeySeed() (modules.Seed, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.Seed{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.Seed{}, modules.ErrLockedWallet
+	}
+	if w.watchOnly {
+		return modules.Seed{}, modules.ErrWatchOnlyWallet
+	}
+	return w.primaryKeySeed, nil
+}
+
 // MarkAddressUnused marks the provided address as unused which causes it to be
 // handed out by a subsequent call to `NextAddresses` again.
 func (w *Wallet) MarkAddressUnused(addrs ...types.UnlockConditions) error {
@@ -320,6 +392,10 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 		w.mu.RUnlock()
 		return modules.ErrLockedWallet
 	}
+	if w.watchOnly {
+		w.mu.RUnlock()
+		return modules.ErrWatchOnlyWallet
+	}
 	for _, wSeed := range append([]modules.Seed{w.primarySeed}, w.seeds...) {
 		if seed == wSeed {
 			w.mu.RUnlock()
@@ -408,13 +484,21 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 // transaction fee. It returns the total value of the outputs, minus the fee.
 // If only siafunds were found, the fee is deducted from the wallet.
 func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err error) {
-	if err = w.tg.Add(); err != nil {
-		return types.Currency{}, types.Currency{}, modules.ErrWalletShutdown
+	plan, err := w.SweepSeedWithOptions(seed, modules.SweepOptions{})
+	return plan.Coins, plan.Funds, err
+}
+
+// SweepSeedWithOptions scans the blockchain for outputs generated from seed
+// and sweeps them into the wallet according to opts. A partial sweep spends
+// the largest outputs first and sends the change back to the seed.
+func (w *Wallet) SweepSeedWithOptions(seed modules.Seed, opts modules.SweepOptions) (modules.SweepPlan, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.SweepPlan{}, modules.ErrWalletShutdown
 	}
 	defer w.tg.Done()
 
 	if !w.scanLock.TryLock() {
-		return types.Currency{}, types.Currency{}, errScanInProgress
+		return modules.SweepPlan{}, errScanInProgress
 	}
 	defer w.scanLock.Unlock()
 
@@ -422,48 +506,30 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 	match := seed == w.primarySeed
 	w.mu.RUnlock()
 	if match {
-		return types.Currency{}, types.Currency{}, errors.New("cannot sweep primary seed")
+		return modules.SweepPlan{}, errors.New("cannot sweep primary seed")
 	}
 
 	if !w.cs.Synced() {
-		return types.Currency{}, types.Currency{}, errors.New("cannot sweep until blockchain is synced")
+		return modules.SweepPlan{}, errors.New("cannot sweep until blockchain is synced")
 	}
 
-	// get an address to spend into
-	w.mu.Lock()
-	uc, err := w.nextPrimarySeedAddress(w.dbTx)
-	height, err2 := dbGetConsensusHeight(w.dbTx)
-	w.mu.Unlock()
-	if err != nil {
-		return types.Currency{}, types.Currency{}, err
-	}
-	if err2 != nil {
-		return types.Currency{}, types.Currency{}, err2
-	}
-	defer func() {
-		if err != nil {
-			w.managedMarkAddressUnused(uc)
-		}
-	}()
-
 	// scan blockchain for outputs, filtering out 'dust' (outputs that cost
 	// more in fees than they are worth)
 	s := newSeedScanner(seed, w.log)
 	_, maxFee := w.tpool.FeeEstimation()
-	const outputSize = 350 // approx. size in bytes of an output and accompanying signature
-	const maxOutputs = 50  // approx. number of outputs that a transaction can handle
-	s.dustThreshold = maxFee.Mul64(outputSize)
-	if err = s.scan(w.cs, w.tg.StopChan()); err != nil {
-		return
+	s.dustThreshold = maxFee.Mul64(sweepOutputSize)
+	if err := s.scan(w.cs, w.tg.StopChan()); err != nil {
+		return modules.SweepPlan{}, err
 	}
 
 	if len(s.siacoinOutputs) == 0 && len(s.siafundOutputs) == 0 {
 		// if we aren't sweeping any coins or funds, then just return an
 		// error; no reason to proceed
-		return types.Currency{}, types.Currency{}, errors.New("nothing to sweep")
+		return modules.SweepPlan{}, errNothingToSweep
 	}
 
-	// Flatten map to slice
+	// Flatten map to slice and sort the outputs by value so that partial
+	// sweeps spend as few outputs as possible.
 	var siacoinOutputs, siafundOutputs []scannedOutput
 	for _, sco := range s.siacoinOutputs {
 		siacoinOutputs = append(siacoinOutputs, sco)
@@ -471,148 +537,309 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 	for _, sfo := range s.siafundOutputs {
 		siafundOutputs = append(siafundOutputs, sfo)
 	}
+	sortScannedOutputs(siacoinOutputs)
+	sortScannedOutputs(siafundOutputs)
 
-	for len(siacoinOutputs) > 0 || len(siafundOutputs) > 0 {
-		// process up to maxOutputs siacoinOutputs
-		txnSiacoinOutputs := make([]scannedOutput, maxOutputs)
-		n := copy(txnSiacoinOutputs, siacoinOutputs)
-		txnSiacoinOutputs = txnSiacoinOutputs[:n]
-		siacoinOutputs = siacoinOutputs[n:]
-
-		// process up to (maxOutputs-n) siafundOutputs
-		txnSiafundOutputs := make([]scannedOutput, maxOutputs-n)
-		n = copy(txnSiafundOutputs, siafundOutputs)
-		txnSiafundOutputs = txnSiafundOutputs[:n]
-		siafundOutputs = siafundOutputs[n:]
-
-		var txnCoins, txnFunds types.Currency
-
-		// construct a transaction that spends the outputs
-		tb, err := w.StartTransaction()
+	txns, err := planSweep(siacoinOutputs, siafundOutputs, maxFee, opts)
+	if err != nil {
+		return modules.SweepPlan{}, err
+	}
+	plan := newSweepPlan(seed, siacoinOutputs, siafundOutputs, txns)
+	if opts.DryRun {
+		return plan, nil
+	}
+
+	// get an address to spend into
+	w.mu.Lock()
+	uc, err := w.nextPrimarySeedAddress(w.dbTx)
+	height, err2 := dbGetConsensusHeight(w.dbTx)
+	w.mu.Unlock()
+	if err != nil {
+		return modules.SweepPlan{}, err
+	}
+	if err2 != nil {
+		return modules.SweepPlan{}, err2
+	}
+
+	for _, st := range txns {
+		txid, err := w.managedSweepTransaction(seed, st, uc, height)
 		if err != nil {
-			return types.ZeroCurrency, types.ZeroCurrency, err
-		}
-		defer func() {
-			if err != nil {
-				tb.Drop()
+			// the address can be reused if nothing was broadcast yet
+			if len(plan.TransactionIDs) == 0 {
+				w.managedMarkAddressUnused(uc)
 			}
-		}()
-		var sweptCoins, sweptFunds types.Currency // total values of swept outputs
-		for _, output := range txnSiacoinOutputs {
-			// construct a siacoin input that spends the output
-			sk := generateSpendableKey(seed, output.seedIndex)
-			tb.AddSiacoinInput(types.SiacoinInput{
-				ParentID:         types.SiacoinOutputID(output.id),
-				UnlockConditions: sk.UnlockConditions,
-			})
-			// add a signature for the input
-			sweptCoins = sweptCoins.Add(output.value)
+			return modules.SweepPlan{}, err
 		}
-		for _, output := range txnSiafundOutputs {
-			// construct a siafund input that spends the output
-			sk := generateSpendableKey(seed, output.seedIndex)
-			tb.AddSiafundInput(types.SiafundInput{
-				ParentID:         types.SiafundOutputID(output.id),
-				UnlockConditions: sk.UnlockConditions,
-			})
-			// add a signature for the input
-			sweptFunds = sweptFunds.Add(output.value)
+		plan.TransactionIDs = append(plan.TransactionIDs, txid)
+	}
+	return plan, nil
+}
+
+// managedSweepTransaction builds, signs and broadcasts a transaction of a seed
+// sweep. It returns the ID of the sweep transaction.
+func (w *Wallet) managedSweepTransaction(seed modules.Seed, st sweepTxn, uc types.UnlockConditions, height types.BlockHeight) (_ types.TransactionID, err error) {
+	// construct a transaction that spends the outputs
+	tb, err := w.StartTransaction()
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	defer func() {
+		if err != nil {
+			tb.Drop()
 		}
+	}()
+	for _, output := range st.siacoinOutputs {
+		sk := generateSpendableKey(seed, output.seedIndex)
+		tb.AddSiacoinInput(types.SiacoinInput{
+			ParentID:         types.SiacoinOutputID(output.id),
+			UnlockConditions: sk.UnlockConditions,
+		})
+	}
+	for _, output := range st.siafundOutputs {
+		// the siacoins claimed by spending the siafunds are sent to the
+		// wallet as well
+		sk := generateSpendableKey(seed, output.seedIndex)
+		tb.AddSiafundInput(types.SiafundInput{
+			ParentID:         types.SiafundOutputID(output.id),
+			UnlockConditions: sk.UnlockConditions,
+			ClaimUnlockHash:  uc.UnlockHash(),
+		})
+	}
+	tb.AddMinerFee(st.fee)
+
+	// add the outputs to the wallet and the change outputs back to the seed
+	if !st.coins.IsZero() {
+		tb.AddSiacoinOutput(types.SiacoinOutput{
+			Value:      st.coins,
+			UnlockHash: uc.UnlockHash(),
+		})
+	}
+	if !st.funds.IsZero() {
+		tb.AddSiafundOutput(types.SiafundOutput{
+			Value:      st.funds,
+			UnlockHash: uc.UnlockHash(),
+		})
+	}
+	if !st.coinChange.IsZero() {
+		tb.AddSiacoinOutput(types.SiacoinOutput{
+			Value:      st.coinChange,
+			UnlockHash: generateSpendableKey(seed, st.siacoinOutputs[0].seedIndex).UnlockConditions.UnlockHash(),
+		})
+	}
+	if !st.fundChange.IsZero() {
+		tb.AddSiafundOutput(types.SiafundOutput{
+			Value:      st.fundChange,
+			UnlockHash: generateSpendableKey(seed, st.siafundOutputs[0].seedIndex).UnlockConditions.UnlockHash(),
+		})
+	}
+
+	// If the swept siacoins don't cover the fee, we need to fund the rest
+	// of the fee using the existing wallet balance.
+	if !st.walletFee.IsZero() {
+		err = tb.FundSiacoins(st.walletFee)
+		if err != nil {
+			return types.TransactionID{}, errors.AddContext(err, "couldn't pay transaction fee on swept funds")
+		}
+	}
 
-		// estimate the transaction size and fee. NOTE: this equation doesn't
-		// account for other fields in the transaction, but since we are
-		// multiplying by maxFee, lowballing is ok
-		estTxnSize := (len(txnSiacoinOutputs) + len(txnSiafundOutputs)) * outputSize
-		estFee := maxFee.Mul64(uint64(estTxnSize))
-		tb.AddMinerFee(estFee)
+	// add signatures for all coins and funds (manually, since tb doesn't have
+	// access to the signing keys)
+	txn, parents := tb.View()
+	for _, output := range st.siacoinOutputs {
+		sk := generateSpendableKey(seed, output.seedIndex)
+		addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(output.id), sk, height)
+	}
+	for _, sfo := range st.siafundOutputs {
+		sk := generateSpendableKey(seed, sfo.seedIndex)
+		addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(sfo.id), sk, height)
+	}
+	// Usually, all the inputs will come from swept outputs. However, there is
+	// an edge case in which inputs will be added from the wallet. To cover
+	// this case, we iterate through the SiacoinInputs and add a signature for
+	// any input that belongs to the wallet.
+	w.mu.RLock()
+	for _, input := range txn.SiacoinInputs {
+		if key, ok := w.keys[input.UnlockConditions.UnlockHash()]; ok {
+			addSignatures(&txn, types.FullCoveredFields, input.UnlockConditions, crypto.Hash(input.ParentID), key, height)
+		}
+	}
+	w.mu.RUnlock()
+
+	// submit the transactions
+	txnSet := append(parents, txn)
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		return types.TransactionID{}, err
+	}
 
-		// calculate total siacoin payout
-		if sweptCoins.Cmp(estFee) > 0 {
-			txnCoins = sweptCoins.Sub(estFee)
+	w.log.Println("Creating a transaction set to sweep a seed, IDs:")
+	for _, txn := range txnSet {
+		w.log.Println("\t", txn.ID())
+	}
+	return txn.ID(), nil
+}
+
+// planSweep splits the outputs found for a seed into the transactions of a
+// sweep.
+func planSweep(siacoinOutputs, siafundOutputs []scannedOutput, maxFee types.Currency, opts modules.SweepOptions) ([]sweepTxn, error) {
+	if !opts.Coins.IsZero() || !opts.Funds.IsZero() {
+		st, err := planPartialSweep(siacoinOutputs, siafundOutputs, maxFee, opts.Coins, opts.Funds)
+		if err != nil {
+			return nil, err
 		}
-		txnFunds = sweptFunds
+		return []sweepTxn{st}, nil
+	}
 
-		switch {
-		case txnCoins.IsZero() && txnFunds.IsZero():
+	var txns []sweepTxn
+	for len(siacoinOutputs) > 0 || len(siafundOutputs) > 0 {
+		// process up to sweepMaxOutputs siacoinOutputs and fill the rest of
+		// the transaction with siafundOutputs
+		var st sweepTxn
+		n := len(siacoinOutputs)
+		if n > sweepMaxOutputs {
+			n = sweepMaxOutputs
+		}
+		st.siacoinOutputs, siacoinOutputs = siacoinOutputs[:n], siacoinOutputs[n:]
+		m := len(siafundOutputs)
+		if m > sweepMaxOutputs-n {
+			m = sweepMaxOutputs - n
+		}
+		st.siafundOutputs, siafundOutputs = siafundOutputs[:m], siafundOutputs[m:]
+
+		// calculate total payouts. If the swept siacoins don't cover the fee,
+		// the wallet pays the rest.
+		st.fee = sweepFee(maxFee, n+m)
+		sweptCoins := sumScannedOutputs(st.siacoinOutputs)
+		if sweptCoins.Cmp(st.fee) > 0 {
+			st.coins = sweptCoins.Sub(st.fee)
+		} else {
+			st.walletFee = st.fee.Sub(sweptCoins)
+		}
+		st.funds = sumScannedOutputs(st.siafundOutputs)
+		if st.coins.IsZero() && st.funds.IsZero() {
 			// if we aren't sweeping any coins or funds, then just return an
 			// error; no reason to proceed
-			return types.Currency{}, types.Currency{}, errors.New("transaction fee exceeds value of swept outputs")
-
-		case !txnCoins.IsZero() && txnFunds.IsZero():
-			// if we're sweeping coins but not funds, add a siacoin output for
-			// them
-			tb.AddSiacoinOutput(types.SiacoinOutput{
-				Value:      txnCoins,
-				UnlockHash: uc.UnlockHash(),
-			})
+			return nil, errSweepFeeTooHigh
+		}
+		txns = append(txns, st)
+	}
+	return txns, nil
+}
 
-		case txnCoins.IsZero() && !txnFunds.IsZero():
-			// if we're sweeping funds but not coins, add a siafund output for
-			// them. This is tricky because we still need to pay for the
-			// transaction fee, but we can't simply subtract the fee from the
-			// output value like we can with swept coins. Instead, we need to fund
-			// the fee using the existing wallet balance.
-			tb.AddSiafundOutput(types.SiafundOutput{
-				Value:      txnFunds,
-				UnlockHash: uc.UnlockHash(),
-			})
-			err = tb.FundSiacoins(estFee)
-			if err != nil {
-				return types.Currency{}, types.Currency{}, errors.New("couldn't pay transaction fee on swept funds: " + err.Error())
+// planPartialSweep plans a single transaction which sweeps the given amounts
+// of coins and funds. The outputs are expected to be sorted by value in
+// descending order.
+func planPartialSweep(siacoinOutputs, siafundOutputs []scannedOutput, maxFee, coins, funds types.Currency) (sweepTxn, error) {
+	var st sweepTxn
+
+	// select the siafund outputs first since the fee depends on the number of
+	// inputs
+	var sweptFunds types.Currency
+	for _, sfo := range siafundOutputs {
+		if sweptFunds.Cmp(funds) >= 0 {
+			break
+		}
+		st.siafundOutputs = append(st.siafundOutputs, sfo)
+		sweptFunds = sweptFunds.Add(sfo.value)
+	}
+	if sweptFunds.Cmp(funds) < 0 {
+		return sweepTxn{}, errInsufficientSweepFunds
+	}
+	st.funds = funds
+	st.fundChange = sweptFunds.Sub(funds)
+	st.fee = sweepFee(maxFee, len(st.siafundOutputs))
+
+	// if no coins are swept, the wallet pays the fee
+	if coins.IsZero() {
+		st.walletFee = st.fee
+	} else {
+		var sweptCoins types.Currency
+		for _, sco := range siacoinOutputs {
+			if sweptCoins.Cmp(coins.Add(st.fee)) >= 0 {
+				break
 			}
+			st.siacoinOutputs = append(st.siacoinOutputs, sco)
+			sweptCoins = sweptCoins.Add(sco.value)
+			st.fee = sweepFee(maxFee, len(st.siacoinOutputs)+len(st.siafundOutputs))
+		}
+		if sweptCoins.Cmp(coins.Add(st.fee)) < 0 {
+			return sweepTxn{}, errInsufficientSweepCoins
+		}
+		st.coins = coins
+		st.coinChange = sweptCoins.Sub(coins).Sub(st.fee)
 
-		case !txnCoins.IsZero() && !txnFunds.IsZero():
-			// if we're sweeping both coins and funds, add a siacoin output and a
-			// siafund output
-			tb.AddSiacoinOutput(types.SiacoinOutput{
-				Value:      txnCoins,
-				UnlockHash: uc.UnlockHash(),
-			})
-			tb.AddSiafundOutput(types.SiafundOutput{
-				Value:      txnFunds,
-				UnlockHash: uc.UnlockHash(),
-			})
+		// change that isn't worth an output is added to the fee
+		if st.coinChange.Cmp(maxFee.Mul64(sweepOutputSize)) <= 0 {
+			st.fee = st.fee.Add(st.coinChange)
+			st.coinChange = types.ZeroCurrency
 		}
+	}
 
-		// add signatures for all coins and funds (manually, since tb doesn't have
-		// access to the signing keys)
-		txn, parents := tb.View()
-		for _, output := range txnSiacoinOutputs {
-			sk := generateSpendableKey(seed, output.seedIndex)
-			addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(output.id), sk, height)
+	if len(st.siacoinOutputs)+len(st.siafundOutputs) > sweepMaxOutputs {
+		return sweepTxn{}, errPartialSweepTooLarge
+	}
+	return st, nil
+}
+
+// newSweepPlan summarizes the outputs and transactions of a sweep.
+func newSweepPlan(seed modules.Seed, siacoinOutputs, siafundOutputs []scannedOutput, txns []sweepTxn) modules.SweepPlan {
+	var plan modules.SweepPlan
+	swept := make(map[types.OutputID]struct{})
+	for _, st := range txns {
+		for _, sco := range st.siacoinOutputs {
+			swept[sco.id] = struct{}{}
 		}
-		for _, sfo := range txnSiafundOutputs {
-			sk := generateSpendableKey(seed, sfo.seedIndex)
-			addSignatures(&txn, types.FullCoveredFields, sk.UnlockConditions, crypto.Hash(sfo.id), sk, height)
+		for _, sfo := range st.siafundOutputs {
+			swept[sfo.id] = struct{}{}
 		}
-		// Usually, all the inputs will come from swept outputs. However, there is
-		// an edge case in which inputs will be added from the wallet. To cover
-		// this case, we iterate through the SiacoinInputs and add a signature for
-		// any input that belongs to the wallet.
-		w.mu.RLock()
-		for _, input := range txn.SiacoinInputs {
-			if key, ok := w.keys[input.UnlockConditions.UnlockHash()]; ok {
-				addSignatures(&txn, types.FullCoveredFields, input.UnlockConditions, crypto.Hash(input.ParentID), key, height)
+		plan.Coins = plan.Coins.Add(st.coins)
+		plan.Funds = plan.Funds.Add(st.funds)
+		plan.Fee = plan.Fee.Add(st.fee)
+		plan.RemainingCoins = plan.RemainingCoins.Add(st.coinChange)
+		plan.RemainingFunds = plan.RemainingFunds.Add(st.fundChange)
+	}
+
+	addOutputs := func(outputs []scannedOutput, fundType types.Specifier, remaining *types.Currency) {
+		for _, o := range outputs {
+			_, isSwept := swept[o.id]
+			if !isSwept {
+				*remaining = remaining.Add(o.value)
 			}
+			plan.Outputs = append(plan.Outputs, modules.SweepOutput{
+				ID:         o.id,
+				FundType:   fundType,
+				UnlockHash: generateSpendableKey(seed, o.seedIndex).UnlockConditions.UnlockHash(),
+				Value:      o.value,
+				Swept:      isSwept,
+			})
 		}
-		w.mu.RUnlock()
-
-		// Append transaction to txnSet
-		txnSet := append(parents, txn)
+	}
+	addOutputs(siacoinOutputs, types.SpecifierSiacoinOutput, &plan.RemainingCoins)
+	addOutputs(siafundOutputs, types.SpecifierSiafundOutput, &plan.RemainingFunds)
+	return plan
+}
 
-		// submit the transactions
-		err = w.tpool.AcceptTransactionSet(txnSet)
-		if err != nil {
-			return types.ZeroCurrency, types.ZeroCurrency, err
-		}
+// sweepFee estimates the fee of a sweep transaction spending n outputs. NOTE:
+// this equation doesn't account for other fields in the transaction, but
+// since we are multiplying by maxFee, lowballing is ok.
+func sweepFee(maxFee types.Currency, n int) types.Currency {
+	return maxFee.Mul64(uint64(n * sweepOutputSize))
+}
 
-		w.log.Println("Creating a transaction set to sweep a seed, IDs:")
-		for _, txn := range txnSet {
-			w.log.Println("\t", txn.ID())
+// sortScannedOutputs sorts outputs by value in descending order.
+func sortScannedOutputs(outputs []scannedOutput) {
+	sort.Slice(outputs, func(i, j int) bool {
+		if cmp := outputs[i].value.Cmp(outputs[j].value); cmp != 0 {
+			return cmp > 0
 		}
+		return bytes.Compare(outputs[i].id[:], outputs[j].id[:]) < 0
+	})
+}
 
-		coins = coins.Add(txnCoins)
-		funds = funds.Add(txnFunds)
+// sumScannedOutputs returns the total value of outputs.
+func sumScannedOutputs(outputs []scannedOutput) (sum types.Currency) {
+	for _, o := range outputs {
+		sum = sum.Add(o.value)
 	}
 	return
 }
@@ -53,6 +53,14 @@ func TestPrimarySeed(t *testing.T) {
 	if remaining != maxScanKeys {
 		t.Error("primary seed is returning the wrong number of remaining addresses")
 	}
+	// Without a seed passphrase the keys are derived from the primary seed.
+	keySeed, err := wt.wallet.PrimaryKeySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keySeed != primarySeed {
+		t.Error("PrimaryKeySeed doesn't match the primary seed of a wallet without passphrase")
+	}
 	_, err = wt.wallet.NextAddress()
 	if err != nil {
 		t.Fatal(err)
@@ -564,6 +572,111 @@ func TestSweepSeedCoinsAndFunds(t *testing.T) {
 	}
 }
 
+// TestSweepSeedPartial tests planning a sweep and sweeping only part of the
+// coins and funds of a seed.
+func TestSweepSeedPartial(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Load the key into the wallet.
+	err = wt.wallet.LoadSiagKeys(wt.walletMasterKey, []string{"../../types/siag0of1of1.siakey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send two siacoin outputs and a siafund output to the seed.
+	seed := modules.Seed{1, 2, 3}
+	addr := generateSpendableKey(seed, 1).UnlockConditions.UnlockHash()
+	if _, err := wt.wallet.SendSiafunds(types.NewCurrency64(12), addr); err != nil {
+		t.Fatal(err)
+	}
+	for _, sc := range []uint64{100, 50} {
+		if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(sc), addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := types.SiacoinPrecision.Mul64(150)
+
+	// A dry run of a full sweep should sweep all outputs without
+	// broadcasting anything.
+	plan, err := wt.wallet.SweepSeedWithOptions(seed, modules.SweepOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Outputs) != 3 || len(plan.TransactionIDs) != 0 {
+		t.Fatal("unexpected plan", plan)
+	}
+	for _, o := range plan.Outputs {
+		if !o.Swept || o.UnlockHash != addr {
+			t.Fatal("expected all outputs to be swept", o)
+		}
+	}
+	if !plan.Coins.Add(plan.Fee).Equals(total) || !plan.Funds.Equals64(12) || !plan.RemainingCoins.IsZero() || !plan.RemainingFunds.IsZero() {
+		t.Fatal("unexpected plan", plan)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("dry run shouldn't broadcast transactions")
+	}
+
+	// Sweeping more than the seed contains should fail.
+	_, err = wt.wallet.SweepSeedWithOptions(seed, modules.SweepOptions{Funds: types.NewCurrency64(13)})
+	if !errors.Contains(err, errInsufficientSweepFunds) {
+		t.Fatal("expected errInsufficientSweepFunds, got", err)
+	}
+	_, err = wt.wallet.SweepSeedWithOptions(seed, modules.SweepOptions{Coins: total})
+	if !errors.Contains(err, errInsufficientSweepCoins) {
+		t.Fatal("expected errInsufficientSweepCoins, got", err)
+	}
+
+	// Sweep part of the coins and funds. Only the largest siacoin output
+	// should be spent.
+	coins, funds := types.SiacoinPrecision.Mul64(30), types.NewCurrency64(5)
+	plan, err = wt.wallet.SweepSeedWithOptions(seed, modules.SweepOptions{Coins: coins, Funds: funds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Coins.Equals(coins) || !plan.Funds.Equals(funds) || !plan.RemainingFunds.Equals64(7) || len(plan.TransactionIDs) != 1 {
+		t.Fatal("unexpected plan", plan)
+	}
+	if !plan.Coins.Add(plan.Fee).Add(plan.RemainingCoins).Equals(total) {
+		t.Fatal("plan doesn't add up", plan)
+	}
+	for _, o := range plan.Outputs {
+		largest := o.FundType == types.SpecifierSiacoinOutput && o.Value.Equals(types.SiacoinPrecision.Mul64(100))
+		if o.FundType == types.SpecifierSiacoinOutput && o.Swept != largest {
+			t.Fatal("expected only the largest siacoin output to be swept", o)
+		}
+	}
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The change should have been sent back to the seed.
+	remaining, err := wt.wallet.SweepSeedWithOptions(seed, modules.SweepOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !remaining.Funds.Equals(plan.RemainingFunds) || !remaining.Coins.Add(remaining.Fee).Equals(plan.RemainingCoins) {
+		t.Fatal("remaining outputs don't match the plan", remaining, plan)
+	}
+}
+
 // TestGenerateKeys tests that the generateKeys function correctly generates a
 // key for every index specified.
 func TestGenerateKeys(t *testing.T) {
@@ -0,0 +1,234 @@
+package wallet
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// signerDialTimeout is the timeout for connecting to an external signer.
+	signerDialTimeout = 5 * time.Second
+
+	// signerTimeout is the timeout for an external signer to respond to a
+	// signing request. It is generous since hardware wallets usually require
+	// the user to confirm every signature on the device.
+	signerTimeout = 2 * time.Minute
+)
+
+var (
+	// errNoSigner is returned when an input can't be signed with the wallet's
+	// keys and no external signer is set.
+	errNoSigner = errors.New("no external signer set")
+
+	// errInvalidSignature is returned when an external signer returns a
+	// signature which doesn't verify.
+	errInvalidSignature = errors.New("external signer returned an invalid signature")
+)
+
+type (
+	// socketSigner is a TransactionSigner which delegates signing to an
+	// external process listening on a local socket. This can be a generic
+	// signer or a bridge to a hardware wallet like a Ledger device.
+	//
+	// Each request is sent on a new connection as a single JSON object and
+	// answered with a single JSON object. A request contains the whole
+	// transaction so that the signer can show its outputs and fees before
+	// signing. The transaction is included in the Sia binary encoding as well
+	// since that is what hardware wallets like the Sia Ledger app expect.
+	socketSigner struct {
+		staticNetwork string
+		staticAddress string
+	}
+
+	// signerRequest is the request sent to an external signer. Hash is the
+	// signature hash of the TransactionSignature at SignatureIndex at Height.
+	// Signers which can compute it from the transaction should do so instead
+	// of signing Hash blindly.
+	signerRequest struct {
+		PublicKey          types.SiaPublicKey `json:"publickey"`
+		Transaction        types.Transaction  `json:"transaction"`
+		EncodedTransaction []byte             `json:"encodedtransaction"`
+		SignatureIndex     int                `json:"signatureindex"`
+		Height             types.BlockHeight  `json:"height"`
+		Hash               crypto.Hash        `json:"hash"`
+	}
+
+	// signerResponse is the response of an external signer.
+	signerResponse struct {
+		Signature []byte `json:"signature"`
+		Error     string `json:"error"`
+	}
+)
+
+// NewSocketSigner returns a TransactionSigner which delegates signing to an
+// external signer listening on address. If address is an absolute path, it
+// is interpreted as a unix socket, otherwise as a tcp address.
+func NewSocketSigner(address string) modules.TransactionSigner {
+	network := "tcp"
+	if filepath.IsAbs(address) {
+		network = "unix"
+	}
+	return &socketSigner{
+		staticNetwork: network,
+		staticAddress: address,
+	}
+}
+
+// SignTransaction implements modules.TransactionSigner.
+func (ss *socketSigner) SignTransaction(txn types.Transaction, sigIndex int, height types.BlockHeight, pk types.SiaPublicKey) (sig crypto.Signature, err error) {
+	if sigIndex < 0 || sigIndex >= len(txn.TransactionSignatures) {
+		return crypto.Signature{}, errors.New("signature index out of bounds")
+	}
+	conn, err := net.DialTimeout(ss.staticNetwork, ss.staticAddress, signerDialTimeout)
+	if err != nil {
+		return crypto.Signature{}, errors.AddContext(err, "failed to connect to external signer")
+	}
+	defer func() {
+		err = errors.Compose(err, conn.Close())
+	}()
+	if err := conn.SetDeadline(time.Now().Add(signerTimeout)); err != nil {
+		return crypto.Signature{}, err
+	}
+
+	err = json.NewEncoder(conn).Encode(signerRequest{
+		PublicKey:          pk,
+		Transaction:        txn,
+		EncodedTransaction: encoding.Marshal(txn),
+		SignatureIndex:     sigIndex,
+		Height:             height,
+		Hash:               txn.SigHash(sigIndex, height),
+	})
+	if err != nil {
+		return crypto.Signature{}, errors.AddContext(err, "failed to send request to external signer")
+	}
+	var resp signerResponse
+	err = json.NewDecoder(conn).Decode(&resp)
+	if err != nil {
+		return crypto.Signature{}, errors.AddContext(err, "failed to read response from external signer")
+	}
+	if resp.Error != "" {
+		return crypto.Signature{}, errors.New("external signer failed to sign: " + resp.Error)
+	}
+	if len(resp.Signature) != len(sig) {
+		return crypto.Signature{}, errInvalidSignature
+	}
+	copy(sig[:], resp.Signature)
+	return sig, nil
+}
+
+// SetTransactionSigner sets the signer used to sign inputs of watched
+// addresses which the wallet has no secret keys for. Setting it to nil
+// disables external signing.
+func (w *Wallet) SetTransactionSigner(signer modules.TransactionSigner) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.signer = signer
+}
+
+// externalSignature is a signature of a transaction that needs to be created
+// by an external signer.
+type externalSignature struct {
+	index int
+	pk    types.SiaPublicKey
+}
+
+// managedExternalSignatures returns the signatures in toSign which can't be
+// created with the wallet's keys but belong to inputs with known unlock
+// conditions. The remaining ids are returned to be signed by the wallet.
+func (w *Wallet) managedExternalSignatures(txn types.Transaction, toSign []crypto.Hash) (local []crypto.Hash, external []externalSignature) {
+	ucs := make(map[crypto.Hash]types.UnlockConditions)
+	for _, sci := range txn.SiacoinInputs {
+		ucs[crypto.Hash(sci.ParentID)] = sci.UnlockConditions
+	}
+	for _, sfi := range txn.SiafundInputs {
+		ucs[crypto.Hash(sfi.ParentID)] = sfi.UnlockConditions
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, id := range toSign {
+		uc, ok := ucs[id]
+		if !ok {
+			local = append(local, id)
+			continue
+		}
+		if _, ok := w.keys[uc.UnlockHash()]; ok {
+			local = append(local, id)
+			continue
+		}
+		if _, ok := w.watchedAddrs[uc.UnlockHash()]; !ok {
+			local = append(local, id)
+			continue
+		}
+		for i, sig := range txn.TransactionSignatures {
+			if sig.ParentID == id && sig.PublicKeyIndex < uint64(len(uc.PublicKeys)) {
+				external = append(external, externalSignature{
+					index: i,
+					pk:    uc.PublicKeys[sig.PublicKeyIndex],
+				})
+			}
+		}
+	}
+	return
+}
+
+// managedSignExternal fills in the given signatures of txn using the wallet's
+// external signer.
+func (w *Wallet) managedSignExternal(txn *types.Transaction, sigs []externalSignature, height types.BlockHeight) error {
+	if len(sigs) == 0 {
+		return nil
+	}
+	w.mu.RLock()
+	signer := w.signer
+	w.mu.RUnlock()
+	if signer == nil {
+		return errNoSigner
+	}
+	for _, es := range sigs {
+		sigHash := txn.SigHash(es.index, height)
+		sig, err := signer.SignTransaction(*txn, es.index, height, es.pk)
+		if err != nil {
+			return err
+		}
+		var pk crypto.PublicKey
+		copy(pk[:], es.pk.Key)
+		if crypto.VerifyHash(sigHash, pk, sig) != nil {
+			return errInvalidSignature
+		}
+		txn.TransactionSignatures[es.index].Signature = sig[:]
+	}
+	return nil
+}
+
+// managedSendWithSigner funds a transaction sending outputs from the watched
+// addresses, signs it using the external signer and submits it to the
+// transaction pool.
+func (w *Wallet) managedSendWithSigner(outputs []types.SiacoinOutput, fee types.Currency) ([]types.Transaction, error) {
+	w.mu.RLock()
+	signer := w.signer
+	w.mu.RUnlock()
+	if signer == nil {
+		return nil, modules.ErrWatchOnlyWallet
+	}
+	txn, toSign, err := w.BuildUnsignedTransaction(outputs, fee, types.UnlockHash{})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to fund transaction")
+	}
+	if err := w.SignTransaction(&txn, toSign); err != nil {
+		return nil, errors.AddContext(err, "unable to sign transaction")
+	}
+	txnSet := []types.Transaction{txn}
+	if err := w.tpool.AcceptTransactionSet(txnSet); err != nil {
+		return nil, errors.AddContext(err, "unable to get transaction accepted")
+	}
+	return txnSet, nil
+}
@@ -0,0 +1,210 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newTestSigner starts an external signer which signs with the secret keys of
+// sk and returns its address. Like a hardware wallet, it computes the signature
+// hash from the transaction instead of trusting the hash of the request.
+func newTestSigner(t *testing.T, sk spendableKey) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := l.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			var req signerRequest
+			var resp signerResponse
+			var txn types.Transaction
+			if err := json.NewDecoder(conn).Decode(&req); err != nil {
+				resp.Error = err.Error()
+			} else if err := encoding.Unmarshal(req.EncodedTransaction, &txn); err != nil {
+				resp.Error = err.Error()
+			} else if txn.ID() != req.Transaction.ID() || req.SignatureIndex >= len(txn.TransactionSignatures) {
+				resp.Error = "invalid transaction"
+			} else if txn.SigHash(req.SignatureIndex, req.Height) != req.Hash {
+				resp.Error = "hash mismatch"
+			} else {
+				resp.Error = "unknown public key"
+				for _, key := range sk.SecretKeys {
+					pk := key.PublicKey()
+					if bytes.Equal(req.PublicKey.Key, pk[:]) {
+						sig := crypto.SignHash(txn.SigHash(req.SignatureIndex, req.Height), key)
+						resp.Signature, resp.Error = sig[:], ""
+					}
+				}
+			}
+			_ = json.NewEncoder(conn).Encode(resp)
+			_ = conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// TestSocketSigner tests signing transactions using an external signer.
+func TestSocketSigner(t *testing.T) {
+	sk := generateSpendableKey(modules.Seed{}, 0)
+	signer := NewSocketSigner(newTestSigner(t, sk))
+
+	// sign a transaction with the known key
+	var parentID types.SiacoinOutputID
+	fastrand.Read(parentID[:])
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parentID,
+			UnlockConditions: sk.UnlockConditions,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.SiacoinPrecision}},
+		MinerFees:      []types.Currency{types.SiacoinPrecision},
+		TransactionSignatures: []types.TransactionSignature{{
+			ParentID:      crypto.Hash(parentID),
+			CoveredFields: types.FullCoveredFields,
+		}},
+	}
+	pk := sk.UnlockConditions.PublicKeys[0]
+	sig, err := signer.SignTransaction(txn, 0, 10, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.VerifyHash(txn.SigHash(0, 10), sk.SecretKeys[0].PublicKey(), sig) != nil {
+		t.Fatal("invalid signature")
+	}
+
+	// signing with an unknown key should fail
+	unknown := generateSpendableKey(modules.Seed{}, 1).UnlockConditions.PublicKeys[0]
+	if _, err := signer.SignTransaction(txn, 0, 10, unknown); err == nil {
+		t.Fatal("expected signing with an unknown key to fail")
+	}
+
+	// signing a signature which doesn't exist should fail
+	if _, err := signer.SignTransaction(txn, 1, 10, pk); err == nil {
+		t.Fatal("expected signing an invalid index to fail")
+	}
+
+	// a signer which isn't listening should fail
+	if _, err := NewSocketSigner("127.0.0.1:1").SignTransaction(txn, 0, 10, pk); err == nil {
+		t.Fatal("expected connecting to fail")
+	}
+}
+
+// TestWatchOnlyWalletSigner tests sending coins from a watch-only wallet which
+// delegates signing to an external signer.
+func TestWatchOnlyWalletSigner(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// create a watch-only wallet watching a key held by the signer
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, "watchonly"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	masterKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if err := w.InitWatchOnly(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	sk := generateSpendableKey(modules.Seed{}, 1234)
+	err = w.AddWatchPublicKeys(sk.UnlockConditions.PublicKeys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fund the watched address
+	funding := types.SiacoinPrecision.Mul64(100)
+	_, err = wt.wallet.SendSiacoins(funding, sk.UnlockConditions.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.miner.AddBlock()
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, _, _, err := w.ConfirmedBalance()
+		if err != nil {
+			return err
+		}
+		if !balance.Equals(funding) {
+			return fmt.Errorf("expected balance %v, got %v", funding, balance)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without a signer, sending should fail
+	_, err = w.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if !errors.Contains(err, modules.ErrWatchOnlyWallet) {
+		t.Fatal("expected ErrWatchOnlyWallet, got", err)
+	}
+
+	// a signer returning signatures for the wrong key should be rejected
+	w.SetTransactionSigner(NewSocketSigner(newTestSigner(t, generateSpendableKey(modules.Seed{}, 1))))
+	_, err = w.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err == nil {
+		t.Fatal("expected sending with the wrong signer to fail")
+	}
+
+	// with the right signer the transaction should be accepted
+	w.SetTransactionSigner(NewSocketSigner(newTestSigner(t, sk)))
+	txns, err := w.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 {
+		t.Fatal("expected a single transaction", len(txns))
+	}
+	err = wt.addBlockNoPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if _, ok, err := w.Transaction(txns[0].ID()); err != nil || !ok {
+			return fmt.Errorf("transaction not found %v %v", ok, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -22,6 +22,10 @@ var (
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errFrozenOutput indicates an output is not spendable because it was
+	// frozen by the user.
+	errFrozenOutput = errors.New("output is frozen")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
@@ -101,6 +105,10 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	if output.Value.Cmp(dustThreshold) < 0 {
 		return errDustOutput
 	}
+	// Check that the output wasn't frozen by the user.
+	if dbIsFrozenOutput(tx, types.OutputID(id)) {
+		return errFrozenOutput
+	}
 	// Check that this output has not recently been spent by the wallet.
 	spendHeight, err := dbGetSpentOutput(tx, types.OutputID(id))
 	if err == nil {
@@ -393,6 +401,9 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 		if consensusHeight < outputUnlockConditions.Timelock {
 			continue
 		}
+		if dbIsFrozenOutput(tb.wallet.dbTx, types.OutputID(sfoid)) {
+			continue
+		}
 
 		// Add a siafund input for this output.
 		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
@@ -88,6 +88,9 @@ func (w *Wallet) loadSpendableKey(masterKey crypto.CipherKey, sk spendableKey) e
 	if !w.unlocked {
 		return modules.ErrLockedWallet
 	}
+	if w.watchOnly {
+		return modules.ErrWatchOnlyWallet
+	}
 
 	// Check for duplicates.
 	_, exists := w.keys[sk.UnlockConditions.UnlockHash()]
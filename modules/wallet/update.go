@@ -47,7 +47,7 @@ func (w *Wallet) advanceSeedLookahead(index uint64) (bool, error) {
 	newProgress := index + 1
 
 	// Add spendable keys and remove them from lookahead
-	spendableKeys := generateKeys(w.primarySeed, progress, newProgress-progress)
+	spendableKeys := generateKeys(w.primaryKeySeed, progress, newProgress-progress)
 	for _, key := range spendableKeys {
 		w.keys[key.UnlockConditions.UnlockHash()] = key
 		delete(w.lookahead, key.UnlockConditions.UnlockHash())
@@ -116,7 +116,7 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 			err = dbPutSiacoinOutput(tx, diff.ID, diff.SiacoinOutput)
 		} else {
 			w.log.Println("Wallet has lost a spendable siacoin output:", diff.ID, "::", diff.SiacoinOutput.Value.HumanString())
-			err = dbDeleteSiacoinOutput(tx, diff.ID)
+			err = errors.Compose(dbDeleteSiacoinOutput(tx, diff.ID), dbDeleteFrozenOutput(tx, types.OutputID(diff.ID)))
 		}
 		if err != nil {
 			w.log.Severe("Could not update siacoin output:", err)
@@ -135,7 +135,7 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 			err = dbPutSiafundOutput(tx, diff.ID, diff.SiafundOutput)
 		} else {
 			w.log.Println("Wallet has lost a spendable siafund output:", diff.ID, "::", diff.SiafundOutput.Value)
-			err = dbDeleteSiafundOutput(tx, diff.ID)
+			err = errors.Compose(dbDeleteSiafundOutput(tx, diff.ID), dbDeleteFrozenOutput(tx, types.OutputID(diff.ID)))
 		}
 		if err != nil {
 			w.log.Severe("Could not update siafund output:", err)
@@ -158,8 +158,9 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 }
 
 // revertHistory reverts any transaction history that was destroyed by reverted
-// blocks in the consensus change.
-func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
+// blocks in the consensus change. If emit is set, reverted events are emitted
+// for the transactions.
+func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block, emit bool) error {
 	for _, block := range reverted {
 		// Remove any transactions that have been reverted.
 		for i := len(block.Transactions) - 1; i >= 0; i-- {
@@ -176,6 +177,7 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 					w.log.Severe("Could not revert transaction:", err)
 					return err
 				}
+				w.revertEvent(pt, emit)
 			}
 		}
 
@@ -193,6 +195,7 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 					w.log.Severe("Could not revert transaction:", err)
 					return err
 				}
+				w.revertEvent(pt, emit)
 				break // there will only ever be one miner transaction
 			}
 		}
@@ -470,7 +473,8 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 }
 
 // applyHistory applies any transaction history that the applied blocks
-// introduced.
+// introduced. If the consensus change is synced, confirmed events are emitted
+// for the recently confirmed transactions.
 func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 	spentSiacoinOutputs := computeSpentSiacoinOutputSet(cc.SiacoinOutputDiffs)
 	spentSiafundOutputs := computeSpentSiafundOutputSet(cc.SiafundOutputDiffs)
@@ -488,6 +492,12 @@ func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 			if err != nil {
 				return errors.AddContext(err, "could not put processed transaction")
 			}
+			confirmations := eventConfirmations(pt.ConfirmationHeight, cc.BlockHeight)
+			if cc.Synced && confirmations <= eventConfirmationDepth {
+				e := newWalletEvent(modules.WalletEventConfirmed, pt, confirmations)
+				w.emitEvent(e)
+				w.eventsConfirming[pt.TransactionID] = e
+			}
 		}
 	}
 
@@ -515,7 +525,7 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to update confirmed set:", err)
 		w.dbRollback = true
 	}
-	if err := w.revertHistory(w.dbTx, cc.RevertedBlocks); err != nil {
+	if err := w.revertHistory(w.dbTx, cc.RevertedBlocks, cc.Synced); err != nil {
 		w.log.Severe("ERROR: failed to revert consensus change:", err)
 		w.dbRollback = true
 	}
@@ -523,6 +533,9 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
 	}
+	if cc.Synced {
+		w.emitConfirmations(cc.BlockHeight)
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
@@ -580,6 +593,23 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 		// Set the unconfirmed preocessed transactions to the pruned set.
 		w.unconfirmedProcessedTransactions = newUPT
 	}
+	defer func() {
+		// Forget about the emitted transactions which are no longer
+		// unconfirmed. Transactions which are dropped and re-added within
+		// the same diff aren't emitted again.
+		if len(droppedTransactions) == 0 {
+			return
+		}
+		current := make(map[types.TransactionID]struct{}, len(w.unconfirmedProcessedTransactions))
+		for _, pt := range w.unconfirmedProcessedTransactions {
+			current[pt.TransactionID] = struct{}{}
+		}
+		for id := range w.eventsUnconfirmed {
+			if _, exists := current[id]; !exists {
+				delete(w.eventsUnconfirmed, id)
+			}
+		}
+	}()
 
 	// Scroll through all of the diffs and add any new transactions.
 	for _, unconfirmedTxnSet := range diff.AppliedTransactions {
@@ -647,6 +677,10 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 				})
 			}
 			w.unconfirmedProcessedTransactions = append(w.unconfirmedProcessedTransactions, pt)
+			if _, emitted := w.eventsUnconfirmed[pt.TransactionID]; !emitted {
+				w.eventsUnconfirmed[pt.TransactionID] = struct{}{}
+				w.emitEvent(newWalletEvent(modules.WalletEventUnconfirmed, pt, 0))
+			}
 		}
 	}
 }
@@ -55,8 +55,14 @@ type Wallet struct {
 	// wallet.
 	encrypted   bool
 	unlocked    bool
+	watchOnly   bool
 	primarySeed modules.Seed
 
+	// primaryKeySeed is the seed the primary keys are generated from. It is
+	// derived from the primary seed and the seed passphrase, or equal to the
+	// primary seed if the wallet doesn't use a passphrase.
+	primaryKeySeed modules.Seed
+
 	// Fields that handle the subscriptions to the cs and tpool. subscribedMu
 	// needs to be locked when subscribed is accessed and while calling the
 	// subscribing methods on the tpool and consensusset.
@@ -80,6 +86,29 @@ type Wallet struct {
 	lookahead    map[types.UnlockHash]uint64
 	watchedAddrs map[types.UnlockHash]struct{}
 
+	// rescanProgress tracks the progress of the latest rescan started with
+	// Rescan. rescanCancel is closed to cancel it.
+	rescanProgress modules.WalletRescanProgress
+	rescanCancel   chan struct{}
+
+	// eventSubscribers receive the events of the wallet, including the
+	// webhooks. They are protected by eventsMu. eventsUnconfirmed contains
+	// the unconfirmed transactions which were already emitted and
+	// eventsConfirming the recently confirmed transactions which receive
+	// confirmation events. They are protected by mu.
+	eventsMu          sync.Mutex
+	eventSubscribers  map[modules.WalletEventSubscriber]struct{}
+	webhooks          map[string]*webhook
+	eventsUnconfirmed map[types.TransactionID]struct{}
+	eventsConfirming  map[types.TransactionID]modules.WalletEvent
+
+	// priceSource values the transactions of an exported wallet history.
+	priceSource modules.PriceSource
+
+	// signer signs inputs of watched addresses for which the wallet doesn't
+	// have the secret keys, e.g. by delegating to a hardware wallet.
+	signer modules.TransactionSigner
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -154,6 +183,9 @@ func (w *Wallet) LastAddresses(n uint64) ([]types.UnlockHash, error) {
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	if w.watchOnly {
+		return nil, modules.ErrWatchOnlyWallet
+	}
 
 	// Get the current seed progress from disk.
 	var seedProgress uint64
@@ -170,7 +202,7 @@ func (w *Wallet) LastAddresses(n uint64) ([]types.UnlockHash, error) {
 	}
 	start := seedProgress - n
 	// Generate the keys.
-	keys := generateKeys(w.primarySeed, start, n)
+	keys := generateKeys(w.primaryKeySeed, start, n)
 	uhs := make([]types.UnlockHash, 0, len(keys))
 	for i := len(keys) - 1; i >= 0; i-- {
 		uhs = append(uhs, keys[i].UnlockConditions.UnlockHash())
@@ -208,6 +240,11 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
+		eventSubscribers:  make(map[modules.WalletEventSubscriber]struct{}),
+		webhooks:          make(map[string]*webhook),
+		eventsUnconfirmed: make(map[types.TransactionID]struct{}),
+		eventsConfirming:  make(map[types.TransactionID]modules.WalletEvent),
+
 		persistDir: persistDir,
 
 		deps: deps,
@@ -216,6 +253,9 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 	if err != nil {
 		return nil, err
 	}
+	if err := w.managedLoadWebhooks(); err != nil {
+		return nil, errors.Compose(err, w.Close())
+	}
 	return w, nil
 }
 
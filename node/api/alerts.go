@@ -0,0 +1,391 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// AlertEventRegistered is the event of the notification that is sent
+	// when an alert is registered.
+	AlertEventRegistered = "registered"
+	// AlertEventReminder is the event of the notification that is sent
+	// periodically for alerts that haven't been acknowledged.
+	AlertEventReminder = "reminder"
+	// AlertEventResolved is the event of the notification that is sent when
+	// an alert that hasn't been acknowledged is unregistered.
+	AlertEventResolved = "resolved"
+
+	// alertIDNotificationFailed is the id of the alert that is registered if
+	// a notification couldn't be sent.
+	alertIDNotificationFailed = "alert-notification-failed"
+
+	// alertNotificationTimeout is the timeout for sending a notification.
+	alertNotificationTimeout = 30 * time.Second
+)
+
+var (
+	// alertDispatchInterval is the interval at which the alerts of the
+	// modules are checked for changes.
+	alertDispatchInterval = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Testnet:  30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// alertReminderInterval is the interval at which notifications about
+	// alerts that haven't been acknowledged are repeated.
+	alertReminderInterval = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Testnet:  24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// errUnknownAlert is returned when acknowledging an alert that isn't
+	// registered.
+	errUnknownAlert = errors.New("alert is not registered")
+)
+
+// StartAlertDispatcher starts sending notifications about the alerts of the
+// modules. It must be called after SetModules.
+func (api *API) StartAlertDispatcher() {
+	api.staticAlerts.Start(func() []modules.Alert {
+		api.routerMu.RLock()
+		defer api.routerMu.RUnlock()
+		crit, err, warn, info := api.alerts()
+		return append(append(append(crit, err...), warn...), info...)
+	})
+}
+
+// StopAlertDispatcher stops sending notifications about alerts.
+func (api *API) StopAlertDispatcher() {
+	api.staticAlerts.Stop()
+}
+
+type (
+	// AlertNotification is the payload of the notifications that are sent
+	// about alerts.
+	AlertNotification struct {
+		Event string        `json:"event"`
+		Alert modules.Alert `json:"alert"`
+	}
+
+	// alertDispatcher tracks the registered alerts, their acknowledgements and
+	// silences, and sends notifications about them.
+	alertDispatcher struct {
+		// active contains the registered alerts and notified the time at
+		// which the last notification about each of them was sent.
+		active       map[modules.AlertID]modules.Alert
+		notified     map[modules.AlertID]time.Time
+		acknowledged map[modules.AlertID]struct{}
+		silenced     map[modules.AlertID]time.Time
+
+		notifications modules.ConfigFileAlerts
+		minSeverity   modules.AlertSeverity
+
+		staticAlerter *modules.GenericAlerter
+		staticConfig  *modules.SiadConfig
+		staticStop    chan struct{}
+		staticDone    chan struct{}
+		started       bool
+		mu            sync.Mutex
+	}
+)
+
+// newAlertDispatcher returns an alert dispatcher that persists the
+// acknowledgements and silences in the provided config.
+func newAlertDispatcher(cfg *modules.SiadConfig) *alertDispatcher {
+	ad := &alertDispatcher{
+		active:       make(map[modules.AlertID]modules.Alert),
+		notified:     make(map[modules.AlertID]time.Time),
+		acknowledged: make(map[modules.AlertID]struct{}),
+		silenced:     make(map[modules.AlertID]time.Time),
+		minSeverity:  modules.SeverityWarning,
+
+		staticAlerter: modules.NewAlerter("alerts"),
+		staticConfig:  cfg,
+		staticStop:    make(chan struct{}),
+		staticDone:    make(chan struct{}),
+	}
+	if cfg != nil {
+		acknowledged, silenced := cfg.AlertStates()
+		for _, id := range acknowledged {
+			ad.acknowledged[id] = struct{}{}
+		}
+		ad.silenced = silenced
+	}
+	return ad
+}
+
+// isSilenced returns whether an alert is silenced. The caller must hold the
+// lock.
+func (ad *alertDispatcher) isSilenced(id modules.AlertID) bool {
+	until, ok := ad.silenced[id]
+	return ok && time.Now().Before(until)
+}
+
+// annotate sets the acknowledged and silenced fields of the alerts.
+func (ad *alertDispatcher) annotate(alerts []modules.Alert) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	for i := range alerts {
+		_, alerts[i].Acknowledged = ad.acknowledged[alerts[i].ID]
+		alerts[i].Silenced = ad.isSilenced(alerts[i].ID)
+	}
+}
+
+// silences returns the silenced alerts with the time their silence expires.
+func (ad *alertDispatcher) silences() map[modules.AlertID]time.Time {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	silences := make(map[modules.AlertID]time.Time)
+	for id, until := range ad.silenced {
+		if time.Now().Before(until) {
+			silences[id] = until
+		}
+	}
+	return silences
+}
+
+// persist saves the acknowledgements and silences. The caller must hold the
+// lock.
+func (ad *alertDispatcher) persist() error {
+	if ad.staticConfig == nil {
+		return nil
+	}
+	acknowledged := make([]modules.AlertID, 0, len(ad.acknowledged))
+	for id := range ad.acknowledged {
+		acknowledged = append(acknowledged, id)
+	}
+	silenced := make(map[modules.AlertID]time.Time)
+	for id, until := range ad.silenced {
+		silenced[id] = until
+	}
+	return ad.staticConfig.SetAlertStates(acknowledged, silenced)
+}
+
+// Acknowledge acknowledges a registered alert. No notifications are sent about
+// acknowledged alerts. The acknowledgement is removed once the alert is
+// unregistered.
+func (ad *alertDispatcher) Acknowledge(id modules.AlertID) error {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	ad.acknowledged[id] = struct{}{}
+	return ad.persist()
+}
+
+// Silence suppresses all notifications about an alert for the provided
+// duration. Alerts can be silenced before they are registered. A duration of
+// 0 removes the silence.
+func (ad *alertDispatcher) Silence(id modules.AlertID, d time.Duration) error {
+	if d < 0 {
+		return errors.New("duration can't be negative")
+	}
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	if d == 0 {
+		delete(ad.silenced, id)
+	} else {
+		ad.silenced[id] = time.Now().Add(d)
+	}
+	return ad.persist()
+}
+
+// SetNotifications replaces the notification settings.
+func (ad *alertDispatcher) SetNotifications(notifications modules.ConfigFileAlerts) error {
+	minSeverity := modules.AlertSeverity(modules.SeverityWarning)
+	if notifications.MinSeverity != "" {
+		var err error
+		minSeverity, err = modules.ParseAlertSeverity(notifications.MinSeverity)
+		if err != nil {
+			return err
+		}
+	}
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	ad.notifications = notifications
+	ad.minSeverity = minSeverity
+	return nil
+}
+
+// Notifications returns the notification settings.
+func (ad *alertDispatcher) Notifications() modules.ConfigFileAlerts {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	return ad.notifications
+}
+
+// Start starts dispatching notifications about the alerts returned by
+// alerts.
+func (ad *alertDispatcher) Start(alerts func() []modules.Alert) {
+	ad.mu.Lock()
+	defer ad.mu.Unlock()
+	if ad.started {
+		build.Critical("alert dispatcher was already started")
+		return
+	}
+	ad.started = true
+	go func() {
+		defer close(ad.staticDone)
+		ticker := time.NewTicker(alertDispatchInterval)
+		defer ticker.Stop()
+		for {
+			ad.managedDispatch(alerts())
+			select {
+			case <-ad.staticStop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop stops dispatching notifications and waits for the notifications in
+// progress.
+func (ad *alertDispatcher) Stop() {
+	ad.mu.Lock()
+	started := ad.started
+	ad.started = false
+	ad.mu.Unlock()
+	if !started {
+		return
+	}
+	close(ad.staticStop)
+	<-ad.staticDone
+}
+
+// managedDispatch compares the registered alerts to the previously registered
+// ones and sends the resulting notifications.
+func (ad *alertDispatcher) managedDispatch(alerts []modules.Alert) {
+	ad.mu.Lock()
+	var notifications []AlertNotification
+	notify := func(event string, alert modules.Alert) {
+		_, acknowledged := ad.acknowledged[alert.ID]
+		if alert.Severity < ad.minSeverity || ad.isSilenced(alert.ID) || acknowledged {
+			return
+		}
+		notifications = append(notifications, AlertNotification{Event: event, Alert: alert})
+		ad.notified[alert.ID] = time.Now()
+	}
+
+	// Notify about new alerts and remind of the ones that haven't been
+	// acknowledged.
+	current := make(map[modules.AlertID]modules.Alert)
+	for _, alert := range alerts {
+		if alert.ID == "" {
+			continue
+		}
+		current[alert.ID] = alert
+		if _, ok := ad.active[alert.ID]; !ok {
+			notify(AlertEventRegistered, alert)
+		} else if time.Since(ad.notified[alert.ID]) > alertReminderInterval {
+			notify(AlertEventReminder, alert)
+		}
+	}
+
+	// Notify about resolved alerts and forget the acknowledgements of all
+	// alerts that aren't registered anymore.
+	for id, alert := range ad.active {
+		if _, ok := current[id]; !ok {
+			notify(AlertEventResolved, alert)
+			delete(ad.notified, id)
+		}
+	}
+	ad.active = current
+	changed := false
+	for id := range ad.acknowledged {
+		if _, ok := current[id]; !ok {
+			delete(ad.acknowledged, id)
+			changed = true
+		}
+	}
+
+	// Remove expired silences.
+	for id, until := range ad.silenced {
+		if time.Now().After(until) {
+			delete(ad.silenced, id)
+			changed = true
+		}
+	}
+	var err error
+	if changed {
+		err = ad.persist()
+	}
+	settings := ad.notifications
+	ad.mu.Unlock()
+
+	// Send the notifications.
+	for _, n := range notifications {
+		err = errors.Compose(err, sendAlertNotification(settings, n))
+	}
+	if err != nil {
+		ad.staticAlerter.RegisterAlert(alertIDNotificationFailed, "unable to send alert notifications", err.Error(), modules.SeverityWarning)
+	} else if len(notifications) > 0 {
+		ad.staticAlerter.UnregisterAlert(alertIDNotificationFailed)
+	}
+}
+
+// sendAlertNotification sends a notification to all webhooks and email
+// recipients.
+func sendAlertNotification(settings modules.ConfigFileAlerts, n AlertNotification) error {
+	var errs []error
+	for _, webhook := range settings.Webhooks {
+		errs = append(errs, errors.AddContext(postAlertWebhook(webhook, n), "unable to call webhook"))
+	}
+	if len(settings.Email.To) > 0 {
+		errs = append(errs, errors.AddContext(sendAlertEmail(settings.Email, n), "unable to send email"))
+	}
+	return errors.Compose(errs...)
+}
+
+// postAlertWebhook posts a notification as JSON to a webhook.
+func postAlertWebhook(webhook string, n AlertNotification) error {
+	b, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: alertNotificationTimeout}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v returned %v", webhook, resp.Status)
+	}
+	return nil
+}
+
+// sendAlertEmail emails a notification.
+func sendAlertEmail(settings modules.ConfigFileEmail, n AlertNotification) error {
+	var auth smtp.Auth
+	if settings.Username != "" {
+		host := strings.Split(settings.SMTPServer, ":")[0]
+		auth = smtp.PlainAuth("", settings.Username, settings.Password, host)
+	}
+	subject := fmt.Sprintf("[Sia] %v alert %v: %v", n.Alert.Severity, n.Event, n.Alert.Msg)
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %v\r\n", settings.From)
+	fmt.Fprintf(&body, "To: %v\r\n", strings.Join(settings.To, ", "))
+	fmt.Fprintf(&body, "Subject: %v\r\n\r\n", subject)
+	fmt.Fprintf(&body, "Module:   %v\r\n", n.Alert.Module)
+	fmt.Fprintf(&body, "Severity: %v\r\n", n.Alert.Severity)
+	fmt.Fprintf(&body, "ID:       %v\r\n", n.Alert.ID)
+	fmt.Fprintf(&body, "Time:     %v\r\n", n.Alert.Time.Format(time.RFC1123))
+	fmt.Fprintf(&body, "Message:  %v\r\n", n.Alert.Msg)
+	fmt.Fprintf(&body, "Cause:    %v\r\n", n.Alert.Cause)
+	return smtp.SendMail(settings.SMTPServer, auth, settings.From, settings.To, body.Bytes())
+}
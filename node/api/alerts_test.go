@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestAlertDispatcher probes the notifications sent by the alert dispatcher.
+func TestAlertDispatcher(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a webhook that records the notifications.
+	var mu sync.Mutex
+	var received []AlertNotification
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var n AlertNotification
+		if err := json.NewDecoder(req.Body).Decode(&n); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		received = append(received, n)
+		mu.Unlock()
+	}))
+	defer webhook.Close()
+	events := func() (events []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, n := range received {
+			events = append(events, n.Event+":"+string(n.Alert.ID))
+		}
+		received = nil
+		return
+	}
+
+	ad := newAlertDispatcher(nil)
+	err := ad.SetNotifications(modules.ConfigFileAlerts{
+		MinSeverity: "error",
+		Webhooks:    []string{webhook.URL},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crit := modules.Alert{ID: "crit", Severity: modules.SeverityCritical}
+	warn := modules.Alert{ID: "warn", Severity: modules.SeverityWarning}
+
+	// Only alerts of at least the minimum severity should be notified, once.
+	ad.managedDispatch([]modules.Alert{crit, warn})
+	ad.managedDispatch([]modules.Alert{crit, warn})
+	if e := events(); len(e) != 1 || e[0] != "registered:crit" {
+		t.Fatal("unexpected notifications", e)
+	}
+
+	// Resolved alerts should be notified.
+	ad.managedDispatch(nil)
+	if e := events(); len(e) != 1 || e[0] != "resolved:crit" {
+		t.Fatal("unexpected notifications", e)
+	}
+
+	// Acknowledged alerts should not be notified.
+	ad.managedDispatch([]modules.Alert{crit})
+	if err := ad.Acknowledge(crit.ID); err != nil {
+		t.Fatal(err)
+	}
+	ad.managedDispatch(nil)
+	if e := events(); len(e) != 1 || e[0] != "registered:crit" {
+		t.Fatal("unexpected notifications", e)
+	}
+	// The acknowledgement should be removed once the alert is resolved.
+	alerts := []modules.Alert{crit}
+	ad.annotate(alerts)
+	if alerts[0].Acknowledged {
+		t.Fatal("acknowledgement should have been removed")
+	}
+
+	// Silenced alerts should not be notified until the silence expires.
+	if err := ad.Silence(crit.ID, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	ad.managedDispatch([]modules.Alert{crit})
+	ad.annotate(alerts)
+	if !alerts[0].Silenced {
+		t.Fatal("alert should be silenced")
+	}
+	if err := ad.Silence(crit.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	ad.managedDispatch(nil)
+	if e := events(); len(e) != 1 || e[0] != "resolved:crit" {
+		t.Fatal("unexpected notifications", e)
+	}
+
+	// Failed notifications should be reported as an alert.
+	webhook.Close()
+	ad.managedDispatch([]modules.Alert{crit})
+	_, _, w, _ := ad.staticAlerter.Alerts()
+	if len(w) != 1 || w[0].ID != alertIDNotificationFailed {
+		t.Fatal("expected notification failure alert", w)
+	}
+}
@@ -116,26 +116,64 @@ type (
 		renter              modules.Renter
 		tpool               modules.TransactionPool
 		wallet              modules.Wallet
-		staticConfigModules configModules
+		loadedConfigModules configModules
 		modulesSet          bool
 
+		// nodeAlerter reports the alerts of the node itself, e.g. modules
+		// that failed to load. moduleManager starts and stops modules at
+		// runtime.
+		nodeAlerter   modules.Alerter
+		moduleManager ModuleManager
+
+		// staticAlerts tracks the acknowledgements and silences of alerts and
+		// sends notifications about them.
+		staticAlerts *alertDispatcher
+
+		// staticDirTransfers tracks the recursive directory uploads and
+		// downloads.
+		staticDirTransfers dirTransfers
+
 		downloadMu sync.Mutex
 		downloads  map[modules.DownloadID]func()
 		router     http.Handler
 		routerMu   sync.RWMutex
 
+		// staticModulesRouter serves the calls that start and stop modules.
+		// They replace the router and therefore can't be served by it.
+		staticModulesRouter http.Handler
+
 		requiredUserAgent string
 		requiredPassword  string
 		Shutdown          func() error
 		siadConfig        *modules.SiadConfig
 
+		// staticConfigFile is the path of the config file of siad and
+		// staticConfigFlags contains the effective values of siad's flags.
+		staticConfigFile  string
+		staticConfigFlags map[string]string
+
+		// staticCORSPolicy describes which cross-origin requests browsers are
+		// allowed to send.
+		staticCORSPolicy CORSPolicy
+
+		// staticAuditLog records the calls which change the state of the
+		// node. It is nil if the audit log is disabled.
+		staticAuditLog *auditLog
+
+		// staticRateLimiter enforces the rate limits and concurrency caps of
+		// the API. It is nil if no limits are set.
+		staticRateLimiter *rateLimiter
+
+		// staticOperations tracks the uploads, downloads and rescans which are
+		// in progress so that they can be drained before shutting down.
+		staticOperations *operationTracker
+
 		staticStartTime time.Time
 
 		staticDeps modules.Dependencies
 	}
 
-	// configModules contains booleans that indicate if a module was part of the
-	// configuration when the API was created
+	// configModules contains booleans that indicate if a module is loaded
 	configModules struct {
 		Accounting      bool `json:"accounting"`
 		Consensus       bool `json:"consensus"`
@@ -151,6 +189,41 @@ type (
 
 // api.ServeHTTP implements the http.Handler interface.
 func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r, answered := api.handleCORS(w, r)
+	if answered {
+		return
+	}
+	r, ok := handleAPIVersion(w, r)
+	if !ok {
+		return
+	}
+	if api.staticAuditLog != nil && isAudited(r) {
+		api.serveAudited(w, r, api.serveRoute)
+		return
+	}
+	api.serveRoute(w, r)
+}
+
+// serveRoute passes a request on to the router serving its route.
+func (api *API) serveRoute(w http.ResponseWriter, r *http.Request) {
+	if api.staticRateLimiter != nil {
+		done, ok := api.staticRateLimiter.managedAdmit(w, r)
+		if !ok {
+			return
+		}
+		defer done()
+	}
+	if kind := operationKind(r); kind != "" {
+		if !api.staticOperations.managedStart(kind) {
+			WriteError(w, Error{"siad is shutting down"}, http.StatusServiceUnavailable)
+			return
+		}
+		defer api.staticOperations.managedDone(kind)
+	}
+	if strings.HasPrefix(r.URL.Path, "/daemon/modules/") {
+		api.staticModulesRouter.ServeHTTP(w, r)
+		return
+	}
 	api.routerMu.RLock()
 	api.router.ServeHTTP(w, r)
 	api.routerMu.RUnlock()
@@ -161,6 +234,13 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 	if api.modulesSet {
 		build.Critical("can't call SetModules more than once")
 	}
+	api.setModules(acc, cs, e, g, h, m, r, tp, w)
+	api.modulesSet = true
+	api.buildHTTPRoutes()
+}
+
+// setModules sets the modules of the API.
+func (api *API) setModules(acc modules.Accounting, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) {
 	api.accounting = acc
 	api.cs = cs
 	api.explorer = e
@@ -170,7 +250,7 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 	api.renter = r
 	api.tpool = tp
 	api.wallet = w
-	api.staticConfigModules = configModules{
+	api.loadedConfigModules = configModules{
 		Accounting:      api.accounting != nil,
 		Consensus:       api.cs != nil,
 		Explorer:        api.explorer != nil,
@@ -181,8 +261,13 @@ func (api *API) SetModules(acc modules.Accounting, cs modules.ConsensusSet, e mo
 		TransactionPool: api.tpool != nil,
 		Wallet:          api.wallet != nil,
 	}
-	api.modulesSet = true
-	api.buildHTTPRoutes()
+}
+
+// SetNodeAlerter sets the alerter of the node, whose alerts are reported by
+// /daemon/alerts next to the alerts of the modules. It must be called before
+// SetModules.
+func (api *API) SetNodeAlerter(a modules.Alerter) {
+	api.nodeAlerter = a
 }
 
 // StartTime returns the time at which the API started
@@ -218,12 +303,15 @@ func NewCustom(cfg *modules.SiadConfig, requiredUserAgent string, requiredPasswo
 		requiredPassword:  requiredPassword,
 		siadConfig:        cfg,
 
-		staticDeps:      deps,
-		staticStartTime: time.Now(),
+		staticAlerts:     newAlertDispatcher(cfg),
+		staticDeps:       deps,
+		staticOperations: newOperationTracker(),
+		staticStartTime:  time.Now(),
 	}
 
 	// Register API handlers
 	api.buildHTTPRoutes()
+	api.staticModulesRouter = api.newModulesRouter()
 
 	return api
 }
@@ -0,0 +1,445 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// auditDBFilename is the filename of the audit log database.
+	auditDBFilename = "audit.db"
+
+	// auditDefaultLimit is the number of entries returned by /daemon/audit if
+	// no limit is provided.
+	auditDefaultLimit = 100
+
+	// auditMaxErrorSize is the maximum number of bytes of an error response
+	// which are read to record the error of a call.
+	auditMaxErrorSize = 4096
+
+	// auditRedacted replaces the values of redacted parameters.
+	auditRedacted = "[redacted]"
+
+	// alertIDAuditLogFailed is the id of the alert that is registered if a
+	// call couldn't be recorded in the audit log.
+	alertIDAuditLogFailed = "audit-log-failed"
+
+	// AuditMethodGRPC is the method of the audit entries of calls to the gRPC
+	// API.
+	AuditMethodGRPC = "GRPC"
+)
+
+var (
+	// auditMetadata is the metadata of the audit log database.
+	auditMetadata = persist.Metadata{
+		Header:  "Sia API Audit Log",
+		Version: "1.5.10",
+	}
+
+	// bucketAuditEntries maps the timestamp and sequence number of an entry to
+	// the JSON encoded entry.
+	bucketAuditEntries = []byte("AuditEntries")
+
+	// auditPruneInterval is the interval at which entries which exceeded the
+	// retention period are removed from the audit log.
+	auditPruneInterval = build.Select(build.Var{
+		Standard: time.Hour,
+		Testnet:  time.Hour,
+		Dev:      time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// secretNames are substrings of the names of parameters and settings
+	// whose values are redacted in the audit log and diagnostics bundles.
+	secretNames = []string{"password", "passphrase", "secret", "seed", "token", "privatekey", "apikey", "encryptionkey"}
+
+	// auditedGETRoutes are the routes which change state even though they
+	// are requested with GET.
+	auditedGETRoutes = []string{"/daemon/stop"}
+
+	// errAuditLogDisabled is returned when querying the audit log while it is
+	// disabled.
+	errAuditLogDisabled = errors.New("the audit log is disabled, enable it by starting siad with --api-audit-log")
+)
+
+type (
+	// AuditEntry is an entry of the audit log. It describes a call to the API
+	// which changes the state of the node. The Method of calls to the gRPC API
+	// is AuditMethodGRPC and their Endpoint is the full name of the method.
+	AuditEntry struct {
+		Timestamp time.Time `json:"timestamp"`
+		Method    string    `json:"method"`
+		Endpoint  string    `json:"endpoint"`
+
+		// Params are the query string and form parameters of the call, or the
+		// fields of the request of gRPC calls. The values of secrets like
+		// passwords and seeds are redacted.
+		Params url.Values `json:"params"`
+
+		SourceIP string `json:"sourceip"`
+
+		// Authenticated indicates whether the call was authenticated with the
+		// API password.
+		Authenticated bool `json:"authenticated"`
+
+		// StatusCode is the status code of the response and Error the error
+		// message of failed calls.
+		StatusCode int    `json:"statuscode"`
+		Error      string `json:"error,omitempty"`
+	}
+
+	// DaemonAuditGet contains the entries of the audit log, oldest first.
+	DaemonAuditGet struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+
+	// auditLog records the calls to the API which change the state of the
+	// node and removes entries once they exceed the retention period.
+	auditLog struct {
+		staticDB        *persist.BoltDatabase
+		staticRetention time.Duration
+		staticStop      chan struct{}
+		staticDone      chan struct{}
+	}
+
+	// auditResponseWriter captures the status code and error of a response.
+	auditResponseWriter struct {
+		http.ResponseWriter
+		status int
+		body   bytes.Buffer
+	}
+)
+
+// OpenAuditLog opens the audit log in the provided directory. Entries are
+// removed once they are older than the retention period. A retention period
+// of 0 keeps entries forever. It must be called before the API is served.
+func (api *API) OpenAuditLog(dir string, retention time.Duration) error {
+	al, err := newAuditLog(dir, retention)
+	if err != nil {
+		return err
+	}
+	api.staticAuditLog = al
+	return nil
+}
+
+// CloseAuditLog closes the audit log if it was opened.
+func (api *API) CloseAuditLog() error {
+	if api.staticAuditLog == nil {
+		return nil
+	}
+	return api.staticAuditLog.Close()
+}
+
+// newAuditLog opens the audit log database and starts pruning it.
+func newAuditLog(dir string, retention time.Duration) (*auditLog, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	db, err := persist.OpenDatabase(auditMetadata, filepath.Join(dir, auditDBFilename))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open audit log database")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketAuditEntries)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Compose(err, db.Close())
+	}
+	al := &auditLog{
+		staticDB:        db,
+		staticRetention: retention,
+		staticStop:      make(chan struct{}),
+		staticDone:      make(chan struct{}),
+	}
+	go al.threadedPrune()
+	return al, nil
+}
+
+// auditKey returns the database key of an entry. Keys are ordered by the
+// timestamp of the entries, the sequence number distinguishes entries with
+// the same timestamp.
+func auditKey(timestamp time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(timestamp.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// Close stops pruning the audit log and closes its database.
+func (al *auditLog) Close() error {
+	close(al.staticStop)
+	<-al.staticDone
+	return al.staticDB.Close()
+}
+
+// Record adds an entry to the audit log.
+func (al *auditLog) Record(entry AuditEntry) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return al.staticDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAuditEntries)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(auditKey(entry.Timestamp, seq), value)
+	})
+}
+
+// Entries returns up to limit of the most recent entries between since and
+// until whose endpoint starts with the provided prefix, oldest first.
+func (al *auditLog) Entries(since, until time.Time, endpoint string, limit int) ([]AuditEntry, error) {
+	entries := make([]AuditEntry, 0)
+	err := al.staticDB.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketAuditEntries).Cursor()
+		start := auditKey(since, 0)
+		k, v := c.Seek(auditKey(until.Add(time.Nanosecond), 0))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && bytes.Compare(k, start) >= 0 && len(entries) < limit; k, v = c.Prev() {
+			var entry AuditEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			if strings.HasPrefix(entry.Endpoint, endpoint) {
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	// Return the entries oldest first.
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, err
+}
+
+// prune removes the entries which are older than the provided time.
+func (al *auditLog) prune(before time.Time) error {
+	return al.staticDB.Update(func(tx *bolt.Tx) error {
+		// Collect the keys first since deleting while iterating skips
+		// entries.
+		b := tx.Bucket(bucketAuditEntries)
+		c := b.Cursor()
+		end := auditKey(before, 0)
+		var keys [][]byte
+		for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Next() {
+			keys = append(keys, k)
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// threadedPrune periodically removes the entries which exceeded the retention
+// period until the audit log is closed.
+func (al *auditLog) threadedPrune() {
+	defer close(al.staticDone)
+	if al.staticRetention == 0 {
+		return
+	}
+	for {
+		// Pruning is retried in the next interval if it fails.
+		_ = al.prune(time.Now().Add(-al.staticRetention))
+		select {
+		case <-al.staticStop:
+			return
+		case <-time.After(auditPruneInterval):
+		}
+	}
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (aw *auditResponseWriter) WriteHeader(status int) {
+	aw.status = status
+	aw.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter. The beginning of error responses is
+// kept to record the error.
+func (aw *auditResponseWriter) Write(b []byte) (int, error) {
+	if aw.status >= http.StatusBadRequest && aw.body.Len() < auditMaxErrorSize {
+		n := auditMaxErrorSize - aw.body.Len()
+		if n > len(b) {
+			n = len(b)
+		}
+		aw.body.Write(b[:n])
+	}
+	return aw.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher.
+func (aw *auditResponseWriter) Flush() {
+	if f, ok := aw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// errorMessage returns the error message of an error response.
+func (aw *auditResponseWriter) errorMessage() string {
+	if aw.status < http.StatusBadRequest {
+		return ""
+	}
+	var apiErr Error
+	if err := json.Unmarshal(aw.body.Bytes(), &apiErr); err == nil && apiErr.Message != "" {
+		return apiErr.Message
+	}
+	return http.StatusText(aw.status)
+}
+
+// isAudited returns whether a call changes the state of the node and needs to
+// be recorded in the audit log.
+func isAudited(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		for _, route := range auditedGETRoutes {
+			if req.URL.Path == route {
+				return true
+			}
+		}
+		return false
+	case http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// isSecretName returns whether the name of a parameter or setting indicates
+// that its value is a secret. Underscores are ignored to match the field names
+// of the gRPC API.
+func isSecretName(name string) bool {
+	lower := strings.ToLower(strings.Replace(name, "_", "", -1))
+	for _, s := range secretNames {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactParams returns a copy of the parameters of a call with the values of
+// secrets redacted.
+func redactParams(params url.Values) url.Values {
+	redacted := make(url.Values, len(params))
+	for name, values := range params {
+		if !isSecretName(name) {
+			redacted[name] = append([]string(nil), values...)
+			continue
+		}
+		redacted[name] = make([]string, len(values))
+		for i := range values {
+			redacted[name][i] = auditRedacted
+		}
+	}
+	return redacted
+}
+
+// serveAudited serves a call and records it in the audit log.
+func (api *API) serveAudited(w http.ResponseWriter, req *http.Request, serve func(http.ResponseWriter, *http.Request)) {
+	aw := &auditResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	serve(aw, req)
+
+	// The form is only parsed if the handler read it. Otherwise only the
+	// query string is recorded, since the body might not be a form.
+	params := req.Form
+	if params == nil {
+		params = req.URL.Query()
+	}
+	sourceIP, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		sourceIP = req.RemoteAddr
+	}
+	api.RecordAudit(AuditEntry{
+		Timestamp:     time.Now(),
+		Method:        req.Method,
+		Endpoint:      req.URL.Path,
+		Params:        params,
+		SourceIP:      sourceIP,
+		Authenticated: api.requiredPassword != "" && hasPassword(req, api.requiredPassword),
+		StatusCode:    aw.status,
+		Error:         aw.errorMessage(),
+	})
+}
+
+// RecordAudit records a call which changes the state of the node in the audit
+// log if it is enabled. The values of secret parameters are redacted. It is
+// used to record the calls to the gRPC API.
+func (api *API) RecordAudit(entry AuditEntry) {
+	if api.staticAuditLog == nil {
+		return
+	}
+	entry.Params = redactParams(entry.Params)
+	alerter := api.staticAlerts.staticAlerter
+	if err := api.staticAuditLog.Record(entry); err != nil {
+		alerter.RegisterAlert(alertIDAuditLogFailed, "unable to record API calls in the audit log", err.Error(), modules.SeverityError)
+	} else {
+		alerter.UnregisterAlert(alertIDAuditLogFailed)
+	}
+}
+
+// daemonAuditHandlerGET handles the API call that queries the audit log.
+func (api *API) daemonAuditHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if api.staticAuditLog == nil {
+		WriteError(w, Error{errAuditLogDisabled.Error()}, http.StatusBadRequest)
+		return
+	}
+	since, until := time.Unix(0, 0), time.Now()
+	for _, param := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := req.FormValue(param.name)
+		if v == "" {
+			continue
+		}
+		unix, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse " + param.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		*param.t = time.Unix(unix, 0)
+	}
+	limit := auditDefaultLimit
+	if l := req.FormValue("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			WriteError(w, Error{"limit must be a positive integer"}, http.StatusBadRequest)
+			return
+		}
+	}
+	entries, err := api.staticAuditLog.Entries(since, until, req.FormValue("endpoint"), limit)
+	if err != nil {
+		WriteError(w, Error{"unable to query audit log: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, DaemonAuditGet{Entries: entries})
+}
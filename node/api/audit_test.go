@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestAuditLog probes the recording and querying of the audit log.
+func TestAuditLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("api", t.Name())
+	cfg, err := modules.NewConfig(filepath.Join(dir, modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "password", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err := api.OpenAuditLog(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := api.CloseAuditLog(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// serve sends an authenticated request to the API.
+	serve := func(method, path string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(values.Encode()))
+		req.Header.Set("User-Agent", "Sia-Agent")
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("", "password")
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+	// entries queries the audit log.
+	entries := func(query string) []AuditEntry {
+		w := serve(http.MethodGet, "/daemon/audit?"+query, nil)
+		if w.Code != http.StatusOK {
+			t.Fatal("unexpected status code", w.Code, w.Body.String())
+		}
+		var dag DaemonAuditGet
+		if err := json.NewDecoder(w.Body).Decode(&dag); err != nil {
+			t.Fatal(err)
+		}
+		return dag.Entries
+	}
+
+	// Calls which don't change state are not recorded.
+	serve(http.MethodGet, "/daemon/version", nil)
+	if e := entries(""); len(e) != 0 {
+		t.Fatal("expected no entries", e)
+	}
+
+	// Record a failing call with a secret parameter in the query string. The
+	// body is only recorded if the handler parses the form.
+	serve(http.MethodPost, "/v1/wallet/unlock?encryptionpassword=foo", nil)
+	serve(http.MethodPost, "/daemon/alerts/acknowledge", url.Values{"id": {"foo"}, "seed": {"bar"}})
+	e := entries("")
+	if len(e) != 2 {
+		t.Fatal("expected 2 entries", e)
+	}
+	unlock := e[0]
+	if unlock.Method != http.MethodPost || unlock.Endpoint != "/wallet/unlock" || !unlock.Authenticated || unlock.SourceIP != "192.0.2.1" {
+		t.Fatal("wrong entry", unlock)
+	}
+	if unlock.StatusCode != StatusModuleNotLoaded || unlock.Error == "" {
+		t.Fatal("wrong result", unlock)
+	}
+	if unlock.Params.Get("encryptionpassword") != auditRedacted {
+		t.Fatal("password wasn't redacted", unlock.Params)
+	}
+	if ack := e[1]; ack.Params.Get("id") != "foo" || ack.Params.Get("seed") != auditRedacted || ack.StatusCode != http.StatusBadRequest {
+		t.Fatal("wrong entry", ack)
+	}
+
+	// Query by endpoint and limit.
+	if e := entries("endpoint=/daemon"); len(e) != 1 || e[0].Endpoint != "/daemon/alerts/acknowledge" {
+		t.Fatal("wrong entries", e)
+	}
+	if e := entries("limit=1"); len(e) != 1 || e[0].Endpoint != "/daemon/alerts/acknowledge" {
+		t.Fatal("expected most recent entry", e)
+	}
+	if e := entries("until=1"); len(e) != 0 {
+		t.Fatal("expected no entries", e)
+	}
+
+	// Pruning removes the entries exceeding the retention period.
+	if err := api.staticAuditLog.prune(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if e := entries(""); len(e) != 0 {
+		t.Fatal("expected no entries", e)
+	}
+}
+
+// TestRedactParams is a unit test for redactParams.
+func TestRedactParams(t *testing.T) {
+	params := url.Values{
+		"siapath":            {"foo"},
+		"encryptionpassword": {"bar"},
+		"Seed":               {"baz", "qux"},
+		"sia_path":           {"foo"},
+		"private_key":        {"bar"},
+	}
+	redacted := redactParams(params)
+	if redacted.Get("siapath") != "foo" {
+		t.Fatal("siapath shouldn't be redacted")
+	}
+	if redacted.Get("encryptionpassword") != auditRedacted {
+		t.Fatal("password should be redacted")
+	}
+	if s := redacted["Seed"]; len(s) != 2 || s[0] != auditRedacted || s[1] != auditRedacted {
+		t.Fatal("seed should be redacted", s)
+	}
+	if redacted.Get("sia_path") != "foo" || redacted.Get("private_key") != auditRedacted {
+		t.Fatal("gRPC field names should be redacted like parameters", redacted)
+	}
+	if params.Get("encryptionpassword") != "bar" {
+		t.Fatal("params were modified")
+	}
+}
+
+// TestAuditLogExternalKey tests that the external encryption key of a streamed
+// upload is redacted in the audit log.
+func TestAuditLogExternalKey(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("api", t.Name())
+	cfg, err := modules.NewConfig(filepath.Join(dir, modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err := api.OpenAuditLog(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := api.CloseAuditLog(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload with an external key. The renter isn't loaded so the upload
+	// fails but is recorded anyway.
+	req := httptest.NewRequest(http.MethodPost, "/renter/uploadstream/foo?encryptionkey=bar&keyid=baz&ciphertype=threefish", strings.NewReader("data"))
+	req.Header.Set("User-Agent", "Sia-Agent")
+	api.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries, err := api.staticAuditLog.Entries(time.Unix(0, 0), time.Now(), "", auditDefaultLimit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Endpoint != "/renter/uploadstream/foo" {
+		t.Fatal("expected upload to be recorded", entries)
+	}
+	params := entries[0].Params
+	if params.Get("encryptionkey") != auditRedacted {
+		t.Fatal("encryption key wasn't redacted", params)
+	}
+	if params.Get("keyid") != "baz" || params.Get("ciphertype") != "threefish" {
+		t.Fatal("wrong params", params)
+	}
+}
@@ -2,12 +2,14 @@ package client
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/node/api"
@@ -15,6 +17,10 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// tlsTransports maps the TLS configs of clients to the http transports using
+// them.
+var tlsTransports sync.Map
+
 type (
 	// A Client makes requests to the siad HTTP API.
 	Client struct {
@@ -38,6 +44,15 @@ type (
 		// receives a redirect status code.
 		// For more see https://golang.org/pkg/net/http/#Client
 		CheckRedirect func(req *http.Request, via []*http.Request) error
+
+		// TLSConfig is the optional TLS config used to connect to the siad
+		// server. If set, the API is requested over https.
+		TLSConfig *tls.Config
+
+		// APIVersion is the optional version of the API which is requested. If
+		// not set, the unversioned routes are requested, which are served by
+		// version 1 of the API.
+		APIVersion int
 	}
 
 	// A UnsafeClient is a Client with additional access to unsafe methods that
@@ -79,7 +94,7 @@ func (uc *UnsafeClient) GetWithHeaders(resource string, headers http.Header) (*h
 		}
 	}
 
-	httpClient := http.Client{CheckRedirect: uc.CheckRedirect}
+	httpClient := uc.newHTTPClient()
 	return httpClient.Do(req)
 }
 
@@ -107,10 +122,33 @@ func DefaultOptions() (Options, error) {
 	}, nil
 }
 
+// newHTTPClient returns the http client used to make requests to the siad
+// server.
+func (c *Client) newHTTPClient() *http.Client {
+	httpClient := &http.Client{CheckRedirect: c.CheckRedirect}
+	if c.TLSConfig != nil {
+		// Share the transport between the clients using the same config to
+		// reuse connections.
+		transport, _ := tlsTransports.LoadOrStore(c.TLSConfig, &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: c.TLSConfig,
+		})
+		httpClient.Transport = transport.(*http.Transport)
+	}
+	return httpClient
+}
+
 // NewRequest constructs a request to the siad HTTP API, setting the correct
 // User-Agent and Basic Auth. The resource path must begin with /.
 func (c *Client) NewRequest(method, resource string, body io.Reader) (*http.Request, error) {
-	url := "http://" + c.Address + resource
+	scheme := "http://"
+	if c.TLSConfig != nil {
+		scheme = "https://"
+	}
+	if c.APIVersion > 0 {
+		resource = fmt.Sprintf("/v%d%s", c.APIVersion, resource)
+	}
+	url := scheme + c.Address + resource
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
@@ -173,7 +211,7 @@ func (c *Client) getReaderResponse(resource string) (http.Header, io.ReadCloser,
 	if err != nil {
 		return nil, nil, errors.AddContext(err, "failed to construct GET request")
 	}
-	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	httpClient := c.newHTTPClient()
 	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, nil, errors.AddContext(err, "GET request failed")
@@ -211,7 +249,7 @@ func (c *Client) getRawPartialResponse(resource string, from, to uint64) ([]byte
 	}
 	req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", from, to-1))
 
-	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	httpClient := c.newHTTPClient()
 	res, err := httpClient.Do(req)
 	if err != nil {
 		return nil, errors.AddContext(err, "GET request failed")
@@ -268,7 +306,7 @@ func (c *Client) head(resource string) (int, http.Header, error) {
 	if err != nil {
 		return 0, nil, errors.AddContext(err, "failed to construct HEAD request")
 	}
-	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	httpClient := c.newHTTPClient()
 	res, err := httpClient.Do(req)
 	if err != nil {
 		return 0, nil, errors.AddContext(err, "HEAD request failed")
@@ -302,7 +340,7 @@ func (c *Client) postRawResponseWithHeaders(resource string, body io.Reader, hea
 		}
 	}
 
-	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	httpClient := c.newHTTPClient()
 	res, err := httpClient.Do(req)
 	if err != nil {
 		return http.Header{}, nil, errors.AddContext(err, "POST request failed")
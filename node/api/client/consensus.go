@@ -5,13 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
+	"golang.org/x/net/websocket"
 )
 
 // ConsensusGet requests the /consensus api resource
@@ -32,6 +32,40 @@ func (c *Client) ConsensusBlocksHeightGet(height types.BlockHeight) (cbg api.Con
 	return
 }
 
+// ConsensusSnapshotGet writes a snapshot of the consensus set at the given
+// height to w.
+func (c *Client) ConsensusSnapshotGet(w io.Writer, height types.BlockHeight) error {
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/consensus/snapshot?height=%v", height))
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(body)
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// ConsensusChangesSubscribe connects to the /consensus/changes websocket
+// endpoint. The consensus changes following ccid, followed by all new changes,
+// can be read from the returned connection using websocket.JSON.Receive. The
+// caller must close the connection.
+func (c *Client) ConsensusChangesSubscribe(ccid modules.ConsensusChangeID) (*websocket.Conn, error) {
+	resource := fmt.Sprintf("/consensus/changes/%s", ccid)
+	config, err := websocket.NewConfig("ws://"+c.Address+resource, "http://"+c.Address)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.NewRequest("GET", resource, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct websocket request: %w", err)
+	}
+	config.Header = req.Header
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to /consensus/changes: %w", err)
+	}
+	return conn, nil
+}
+
 // ConsensusSubscribeSingle streams consensus changes from the
 // /consensus/subscribe endpoint to the provided subscriber. Multiple calls may
 // be required before the subscriber is fully caught up. It returns the latest
@@ -44,7 +78,7 @@ func (c *Client) ConsensusSubscribeSingle(subscriber modules.ConsensusSetSubscri
 		return ccid, err
 	}
 	req.Cancel = cancel
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.newHTTPClient().Do(req)
 	if err != nil {
 		return ccid, err
 	}
@@ -1,10 +1,16 @@
 package client
 
 import (
+	"encoding/json"
+	"io"
 	"net/url"
 	"strconv"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/persist"
 )
 
 // DaemonGlobalRateLimitPost uses the /daemon/settings endpoint to change the
@@ -24,6 +30,113 @@ func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	return
 }
 
+// DaemonAlertsUnacknowledgedGet requests the /daemon/alerts resource and only
+// returns the alerts that are neither acknowledged nor silenced.
+func (c *Client) DaemonAlertsUnacknowledgedGet() (dag api.DaemonAlertsGet, err error) {
+	err = c.get("/daemon/alerts?unacknowledged=true", &dag)
+	return
+}
+
+// DaemonAlertsAcknowledgePost uses the /daemon/alerts/acknowledge endpoint to
+// acknowledge an alert.
+func (c *Client) DaemonAlertsAcknowledgePost(id modules.AlertID) (err error) {
+	values := url.Values{}
+	values.Set("id", string(id))
+	err = c.post("/daemon/alerts/acknowledge", values.Encode(), nil)
+	return
+}
+
+// DaemonAlertsSilencePost uses the /daemon/alerts/silence endpoint to silence
+// an alert for the provided duration. A duration of 0 removes the silence.
+func (c *Client) DaemonAlertsSilencePost(id modules.AlertID, d time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("id", string(id))
+	values.Set("duration", d.String())
+	err = c.post("/daemon/alerts/silence", values.Encode(), nil)
+	return
+}
+
+// DaemonConfigGet requests the /daemon/config resource.
+func (c *Client) DaemonConfigGet() (dcg api.DaemonConfigGet, err error) {
+	err = c.get("/daemon/config", &dcg)
+	return
+}
+
+// DaemonConfigReloadPost uses the /daemon/config/reload endpoint to reload the
+// config file of the daemon.
+func (c *Client) DaemonConfigReloadPost() (dcrp api.DaemonConfigReloadPost, err error) {
+	err = c.post("/daemon/config/reload", "", &dcrp)
+	return
+}
+
+// DaemonAuditGet requests the /daemon/audit resource. It returns up to limit of
+// the most recent entries between since and until whose endpoint starts with
+// the provided prefix.
+func (c *Client) DaemonAuditGet(since, until time.Time, endpoint string, limit int) (dag api.DaemonAuditGet, err error) {
+	values := url.Values{}
+	values.Set("since", strconv.FormatInt(since.Unix(), 10))
+	values.Set("until", strconv.FormatInt(until.Unix(), 10))
+	values.Set("endpoint", endpoint)
+	values.Set("limit", strconv.Itoa(limit))
+	err = c.get("/daemon/audit?"+values.Encode(), &dag)
+	return
+}
+
+// DaemonDiagnosticsGet requests the /daemon/diagnostics resource and returns
+// the zip archive of the diagnostics bundle. If cpuProfile is not zero, the
+// bundle includes a CPU profile of that duration.
+func (c *Client) DaemonDiagnosticsGet(cpuProfile time.Duration) ([]byte, error) {
+	values := url.Values{}
+	values.Set("cpuprofile", strconv.Itoa(int(cpuProfile.Seconds())))
+	_, bundle, err := c.getRawResponse("/daemon/diagnostics?" + values.Encode())
+	return bundle, err
+}
+
+// DaemonLogsGet requests the /daemon/logs resource. An empty module returns
+// the entries of all modules.
+func (c *Client) DaemonLogsGet(module string, level persist.LogLevel, limit int) (dlg api.DaemonLogsGet, err error) {
+	values := url.Values{}
+	values.Set("module", module)
+	values.Set("level", level.String())
+	values.Set("limit", strconv.Itoa(limit))
+	err = c.get("/daemon/logs?"+values.Encode(), &dlg)
+	return
+}
+
+// DaemonLogLevelsGet requests the /daemon/logs/levels resource.
+func (c *Client) DaemonLogLevelsGet() (dllg api.DaemonLogLevelsGet, err error) {
+	err = c.get("/daemon/logs/levels", &dllg)
+	return
+}
+
+// DaemonLogLevelsPost uses the /daemon/logs/levels endpoint to set the log
+// level of a module. An empty module sets the default level.
+func (c *Client) DaemonLogLevelsPost(module string, level persist.LogLevel) (err error) {
+	values := url.Values{}
+	values.Set("module", module)
+	values.Set("level", level.String())
+	err = c.post("/daemon/logs/levels", values.Encode(), nil)
+	return
+}
+
+// DaemonModulesStartPost uses the /daemon/modules/start endpoint to start a
+// module of the running daemon.
+func (c *Client) DaemonModulesStartPost(module string) (err error) {
+	values := url.Values{}
+	values.Set("module", module)
+	err = c.post("/daemon/modules/start", values.Encode(), nil)
+	return
+}
+
+// DaemonModulesStopPost uses the /daemon/modules/stop endpoint to stop a
+// module of the running daemon.
+func (c *Client) DaemonModulesStopPost(module string) (err error) {
+	values := url.Values{}
+	values.Set("module", module)
+	err = c.post("/daemon/modules/stop", values.Encode(), nil)
+	return
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -63,6 +176,38 @@ func (c *Client) DaemonStopGet() (err error) {
 	return
 }
 
+// DaemonStopDrainGet stops the daemon using the /daemon/stop endpoint after
+// waiting up to timeout for the operations in progress to complete. progress is
+// called for every progress report of the daemon. The last report is returned.
+func (c *Client) DaemonStopDrainGet(timeout time.Duration, progress func(api.DaemonStopProgress)) (last api.DaemonStopProgress, err error) {
+	values := url.Values{}
+	values.Set("drain", "true")
+	values.Set("timeout", strconv.FormatUint(uint64(timeout.Seconds()), 10))
+	_, body, err := c.getReaderResponse("/daemon/stop?" + values.Encode())
+	if err != nil {
+		return api.DaemonStopProgress{}, err
+	}
+	defer drainAndClose(body)
+	dec := json.NewDecoder(body)
+	for {
+		var p api.DaemonStopProgress
+		err := dec.Decode(&p)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return last, errors.AddContext(err, "could not read progress")
+		}
+		if progress != nil {
+			progress(p)
+		}
+		last = p
+	}
+	if last.Phase != api.DrainPhaseStopping {
+		return last, errors.New("daemon didn't report that it is stopping")
+	}
+	return last, nil
+}
+
 // DaemonUpdateGet checks for an available daemon update.
 func (c *Client) DaemonUpdateGet() (dig api.DaemonUpdateGet, err error) {
 	err = c.get("/daemon/update", &dig)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/url"
 	"strconv"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 
@@ -109,3 +110,76 @@ func (c *Client) GatewaySetBlocklistPost(addresses []string) (err error) {
 	err = c.post("/gateway/blocklist", string(data), nil)
 	return
 }
+
+// GatewayBansGet uses the /gateway/bans endpoint to request the Gateway's
+// active bans.
+func (c *Client) GatewayBansGet() (gbg api.GatewayBansGET, err error) {
+	err = c.get("/gateway/bans", &gbg)
+	return
+}
+
+// GatewayBanPost uses the /gateway/bans endpoint to ban a subnet for a
+// duration. A duration of 0 bans the subnet permanently.
+func (c *Client) GatewayBanPost(subnet string, duration time.Duration, reason string) (err error) {
+	values := url.Values{}
+	values.Set("action", "add")
+	values.Set("subnet", subnet)
+	values.Set("duration", duration.String())
+	values.Set("reason", reason)
+	err = c.post("/gateway/bans", values.Encode(), nil)
+	return
+}
+
+// GatewayUnbanPost uses the /gateway/bans endpoint to remove the ban of a
+// subnet.
+func (c *Client) GatewayUnbanPost(subnet string) (err error) {
+	values := url.Values{}
+	values.Set("action", "remove")
+	values.Set("subnet", subnet)
+	err = c.post("/gateway/bans", values.Encode(), nil)
+	return
+}
+
+// GatewayPeersGet uses the /gateway/peers endpoint to request the quality
+// metrics of the Gateway's peers.
+func (c *Client) GatewayPeersGet() (gpg api.GatewayPeersGET, err error) {
+	err = c.get("/gateway/peers", &gpg)
+	return
+}
+
+// GatewayPersistentPeersGet uses the /gateway/persistentpeers endpoint to
+// request the Gateway's persistent peers.
+func (c *Client) GatewayPersistentPeersGet() (gppg api.GatewayPersistentPeersGET, err error) {
+	err = c.get("/gateway/persistentpeers", &gppg)
+	return
+}
+
+// GatewayAddPersistentPeerPost uses the /gateway/persistentpeers endpoint to
+// add a persistent peer to the Gateway.
+func (c *Client) GatewayAddPersistentPeerPost(address modules.NetAddress) (err error) {
+	values := url.Values{}
+	values.Set("action", "add")
+	values.Set("netaddress", string(address))
+	err = c.post("/gateway/persistentpeers", values.Encode(), nil)
+	return
+}
+
+// GatewayRemovePersistentPeerPost uses the /gateway/persistentpeers endpoint
+// to remove a persistent peer from the Gateway.
+func (c *Client) GatewayRemovePersistentPeerPost(address modules.NetAddress) (err error) {
+	values := url.Values{}
+	values.Set("action", "remove")
+	values.Set("netaddress", string(address))
+	err = c.post("/gateway/persistentpeers", values.Encode(), nil)
+	return
+}
+
+// GatewayReachabilityPost uses the /gateway/reachability endpoint to ask the
+// Gateway's peers to dial back a port. An empty port checks the Gateway's own
+// port.
+func (c *Client) GatewayReachabilityPost(port string) (grp api.GatewayReachabilityPOST, err error) {
+	values := url.Values{}
+	values.Set("port", port)
+	err = c.post("/gateway/reachability", values.Encode(), &grp)
+	return
+}
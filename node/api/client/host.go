@@ -53,6 +53,9 @@ const (
 	HostParamMaxReviseBatchSize = HostParam("maxrevisebatchsize")
 	// HostParamNetAddress is the announced netaddress of the host.
 	HostParamNetAddress = HostParam("netaddress")
+	// HostParamAlternativeNetAddresses is a comma separated list of addresses
+	// which are announced in addition to the netaddress.
+	HostParamAlternativeNetAddresses = HostParam("alternativenetaddresses")
 	// HostParamEphemeralAccountExpiry is the maximum amount of time an
 	// ephemeral account can be inactive before it expires and gets deleted.
 	HostParamEphemeralAccountExpiry = HostParam("ephemeralaccountexpiry")
@@ -68,6 +71,24 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamRegistryEvictionPolicy is the policy used by the host to make
+	// room for new registry entries once the registry is full.
+	HostParamRegistryEvictionPolicy = HostParam("registryevictionpolicy")
+	// HostParamRegistryExpiryGracePeriod is the number of blocks an expired
+	// registry entry is kept before it is pruned.
+	HostParamRegistryExpiryGracePeriod = HostParam("registryexpirygraceperiod")
+	// HostParamDynamicPricing indicates if the host adjusts its prices
+	// automatically based on utilization and demand.
+	HostParamDynamicPricing = HostParam("dynamicpricing")
+	// HostParamMaxDownloadBandwidthPrice is the max download bandwidth price
+	// in hastings/byte used by dynamic pricing.
+	HostParamMaxDownloadBandwidthPrice = HostParam("maxdownloadbandwidthprice")
+	// HostParamMaxStoragePrice is the max storage price in
+	// hastings/byte/block used by dynamic pricing.
+	HostParamMaxStoragePrice = HostParam("maxstorageprice")
+	// HostParamMaxUploadBandwidthPrice is the max upload bandwidth price in
+	// hastings/byte used by dynamic pricing.
+	HostParamMaxUploadBandwidthPrice = HostParam("maxuploadbandwidthprice")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -84,6 +105,29 @@ func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// HostAnnounceCheckedPost uses the /host/announce/checked endpoint to announce
+// the host after checking that it is reachable. An empty address uses the
+// configured or detected address of the host. If dryRun is true, the
+// announcement is only validated.
+func (c *Client) HostAnnounceCheckedPost(address modules.NetAddress, dryRun bool) (hacp api.HostAnnounceCheckedPOST, err error) {
+	values := url.Values{}
+	if address != "" {
+		values.Set("netaddress", string(address))
+	}
+	values.Set("dryrun", strconv.FormatBool(dryRun))
+	err = c.post("/host/announce/checked", values.Encode(), &hacp)
+	return
+}
+
+// HostBackupPost uses the /host/backup endpoint to create a backup of the
+// host's critical state at the provided destination.
+func (c *Client) HostBackupPost(dst string) (err error) {
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post("/host/backup", values.Encode(), nil)
+	return
+}
+
 // HostContractInfoGet uses the /host/contracts endpoint to get information
 // about contracts on the host.
 func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
@@ -98,6 +142,25 @@ func (c *Client) HostContractGet(obligationID types.FileContractID) (cg api.Host
 	return
 }
 
+// HostEarningsGet requests the /host/earnings endpoint. An endHeight of 0
+// means that the range is open-ended.
+func (c *Client) HostEarningsGet(startHeight, endHeight types.BlockHeight) (heg api.HostEarningsGET, err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(startHeight))
+	values.Set("endheight", fmt.Sprint(endHeight))
+	err = c.get("/host/earnings?"+values.Encode(), &heg)
+	return
+}
+
+// HostObligationsGet requests the /host/obligations endpoint. The proofs due
+// within the next 'window' blocks are aggregated.
+func (c *Client) HostObligationsGet(window types.BlockHeight) (hog api.HostObligationsGET, err error) {
+	values := url.Values{}
+	values.Set("window", fmt.Sprint(window))
+	err = c.get("/host/obligations?"+values.Encode(), &hog)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -123,6 +186,12 @@ func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
 	return
 }
 
+// HostPriceHistoryGet requests the /host/pricehistory endpoint.
+func (c *Client) HostPriceHistoryGet() (phg api.HostPriceHistoryGET, err error) {
+	err = c.get("/host/pricehistory", &phg)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -153,6 +222,27 @@ func (c *Client) HostStorageFoldersResizePost(path string, size uint64) (err err
 	return
 }
 
+// HostStorageFoldersResizeAsyncPost uses the /host/storage/folders/resize
+// endpoint to start resizing a storage folder without waiting for the resize
+// to complete.
+func (c *Client) HostStorageFoldersResizeAsyncPost(path string, size uint64) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("newsize", strconv.FormatUint(size, 10))
+	values.Set("async", "true")
+	err = c.post("/host/storage/folders/resize", values.Encode(), nil)
+	return
+}
+
+// HostRestorePost uses the /host/restore endpoint to restore a backup created
+// with /host/backup.
+func (c *Client) HostRestorePost(src string) (err error) {
+	values := url.Values{}
+	values.Set("source", src)
+	err = c.post("/host/restore", values.Encode(), nil)
+	return
+}
+
 // HostStorageGet requests the /host/storage endpoint.
 func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	err = c.get("/host/storage", &sg)
@@ -165,3 +255,13 @@ func (c *Client) HostStorageSectorsDeletePost(root crypto.Hash) (err error) {
 	err = c.post("/host/storage/sectors/delete/"+root.String(), "", nil)
 	return
 }
+
+// HostStorageSectorsAccessGet requests the /host/storage/sectors/access
+// endpoint to get how frequently the host's sectors are read, including up to
+// limit of the most frequently read sectors.
+func (c *Client) HostStorageSectorsAccessGet(limit int) (ssag api.StorageSectorsAccessGET, err error) {
+	values := url.Values{}
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get("/host/storage/sectors/access?"+values.Encode(), &ssag)
+	return
+}
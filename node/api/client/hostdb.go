@@ -2,6 +2,9 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
 
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
@@ -54,3 +57,73 @@ func (c *Client) HostDbHostsGet(pk types.SiaPublicKey) (hhg api.HostdbHostsGET,
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbSnapshotGet requests the /hostdb/snapshot GET endpoint to export a
+// snapshot of the hostdb.
+func (c *Client) HostDbSnapshotGet() (snapshot modules.HostDBSnapshot, err error) {
+	err = c.get("/hostdb/snapshot", &snapshot)
+	return
+}
+
+// HostDbSnapshotPost requests the /hostdb/snapshot POST endpoint to import a
+// snapshot into the hostdb.
+func (c *Client) HostDbSnapshotPost(snapshot modules.HostDBSnapshot) (result modules.HostDBImportResult, err error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return
+	}
+	err = c.post("/hostdb/snapshot", string(data), &result)
+	return
+}
+
+// HostDbScanSettingsGet requests the /hostdb/scansettings GET endpoint.
+func (c *Client) HostDbScanSettingsGet() (hssg api.HostdbScanSettingsGET, err error) {
+	err = c.get("/hostdb/scansettings", &hssg)
+	return
+}
+
+// HostDbScanSettingsPost requests the /hostdb/scansettings POST endpoint. The
+// scan interval is rounded down to seconds.
+func (c *Client) HostDbScanSettingsPost(settings modules.HostDBScanSettings) (err error) {
+	values := url.Values{}
+	values.Set("scaninterval", fmt.Sprint(uint64(settings.ScanInterval.Seconds())))
+	values.Set("scanthreads", fmt.Sprint(settings.ScanThreads))
+	values.Set("maxscanbandwidth", fmt.Sprint(settings.MaxScanBandwidth))
+	err = c.post("/hostdb/scansettings", values.Encode(), nil)
+	return
+}
+
+// HostDbHostsScanPost requests the /hostdb/hosts/:pubkey/scan endpoint to scan
+// a host right away.
+func (c *Client) HostDbHostsScanPost(pk types.SiaPublicKey) (err error) {
+	err = c.post("/hostdb/hosts/"+pk.String()+"/scan", "", nil)
+	return
+}
+
+// HostDbHostsOfflinePost requests the /hostdb/hosts/:pubkey/offline endpoint
+// to mark a host offline.
+func (c *Client) HostDbHostsOfflinePost(pk types.SiaPublicKey) (err error) {
+	err = c.post("/hostdb/hosts/"+pk.String()+"/offline", "", nil)
+	return
+}
+
+// HostDbBenchmarkGet requests the /hostdb/benchmark GET endpoint to get the
+// most recent benchmarks of all benchmarked hosts.
+func (c *Client) HostDbBenchmarkGet() (hbg api.HostdbBenchmarkGET, err error) {
+	err = c.get("/hostdb/benchmark", &hbg)
+	return
+}
+
+// HostDbBenchmarkPost requests the /hostdb/benchmark POST endpoint to
+// benchmark hosts. If no hosts are given, all hosts the renter has contracts
+// with are benchmarked.
+func (c *Client) HostDbBenchmarkPost(hosts ...types.SiaPublicKey) (hbg api.HostdbBenchmarkGET, err error) {
+	keys := make([]string, 0, len(hosts))
+	for _, pk := range hosts {
+		keys = append(keys, pk.String())
+	}
+	values := url.Values{}
+	values.Set("hosts", strings.Join(keys, ","))
+	err = c.post("/hostdb/benchmark", values.Encode(), &hbg)
+	return
+}
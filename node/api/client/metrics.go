@@ -0,0 +1,8 @@
+package client
+
+// MetricsGet requests the /metrics resource and returns the metrics in the
+// Prometheus text exposition format.
+func (c *Client) MetricsGet() (string, error) {
+	_, resp, err := c.getRawResponse("/metrics")
+	return string(resp), err
+}
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"encoding/json"
+
 	"gitlab.com/NebulousLabs/encoding"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
 )
@@ -46,3 +49,21 @@ func (c *Client) MinerStopGet() (err error) {
 	err = c.get("/miner/stop", nil)
 	return
 }
+
+// MinerTemplateGet uses the /miner/template endpoint to get the block template
+// settings of the miner.
+func (c *Client) MinerTemplateGet() (mtg api.MinerTemplateGET, err error) {
+	err = c.get("/miner/template", &mtg)
+	return
+}
+
+// MinerTemplatePost uses the /miner/template endpoint to change the block
+// template settings of the miner.
+func (c *Client) MinerTemplatePost(settings modules.MinerTemplateSettings) (err error) {
+	data, err := json.Marshal(api.MinerTemplatePOST{Settings: settings})
+	if err != nil {
+		return err
+	}
+	err = c.post("/miner/template", string(data), nil)
+	return
+}
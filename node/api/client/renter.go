@@ -1,17 +1,22 @@
 package client
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/url"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -25,6 +30,21 @@ type (
 		sent   bool
 		values url.Values
 	}
+
+	// RenterSearchRequestGet is a helper type to be able to build a file
+	// search request.
+	RenterSearchRequestGet struct {
+		c      *Client
+		values url.Values
+	}
+
+	// RenterListRequestGet is a helper type to be able to build a paginated
+	// directory listing request.
+	RenterListRequestGet struct {
+		c       *Client
+		siaPath modules.SiaPath
+		values  url.Values
+	}
 )
 
 // RenterPostPartialAllowance starts an allowance request which can be extended
@@ -87,6 +107,23 @@ func (a *AllowanceRequestPost) WithMaxPeriodChurn(maxPeriodChurn uint64) *Allowa
 	return a
 }
 
+// WithMaxPeriodContractChurn adds the maxperiodcontractchurn field to the
+// request.
+func (a *AllowanceRequestPost) WithMaxPeriodContractChurn(maxContractChurn uint64) *AllowanceRequestPost {
+	a.values.Set("maxperiodcontractchurn", fmt.Sprint(maxContractChurn))
+	return a
+}
+
+// WithRenewWindowPolicy adds the renewwindowpolicy field to the request.
+func (a *AllowanceRequestPost) WithRenewWindowPolicy(policy modules.RenewWindowPolicy) *AllowanceRequestPost {
+	if policy == modules.RenewWindowPolicyStart {
+		a.values.Set("renewwindowpolicy", "start")
+	} else {
+		a.values.Set("renewwindowpolicy", string(policy))
+	}
+	return a
+}
+
 // WithMaxRPCPrice adds the maxrpcprice field to the request.
 func (a *AllowanceRequestPost) WithMaxRPCPrice(price types.Currency) *AllowanceRequestPost {
 	a.values.Set("maxrpcprice", price.String())
@@ -133,6 +170,115 @@ func (a *AllowanceRequestPost) Send() (err error) {
 	return
 }
 
+// RenterSearch starts a file search request which can be extended using its
+// methods.
+func (c *Client) RenterSearch() *RenterSearchRequestGet {
+	return &RenterSearchRequestGet{c: c, values: make(url.Values)}
+}
+
+// InDir restricts the search to a directory.
+func (s *RenterSearchRequestGet) InDir(siaPath modules.SiaPath) *RenterSearchRequestGet {
+	s.values.Set("siapath", siaPath.String())
+	return s
+}
+
+// WithName adds the name field to the request.
+func (s *RenterSearchRequestGet) WithName(name string) *RenterSearchRequestGet {
+	s.values.Set("name", name)
+	return s
+}
+
+// WithRegex adds the regex field to the request.
+func (s *RenterSearchRequestGet) WithRegex(regex string) *RenterSearchRequestGet {
+	s.values.Set("regex", regex)
+	return s
+}
+
+// WithSizeRange adds the minsize and maxsize fields to the request.
+func (s *RenterSearchRequestGet) WithSizeRange(minSize, maxSize uint64) *RenterSearchRequestGet {
+	s.values.Set("minsize", fmt.Sprint(minSize))
+	s.values.Set("maxsize", fmt.Sprint(maxSize))
+	return s
+}
+
+// WithHealthRange adds the minhealth and maxhealth fields to the request.
+func (s *RenterSearchRequestGet) WithHealthRange(minHealth, maxHealth float64) *RenterSearchRequestGet {
+	s.values.Set("minhealth", fmt.Sprint(minHealth))
+	s.values.Set("maxhealth", fmt.Sprint(maxHealth))
+	return s
+}
+
+// WithModifiedRange adds the modifiedafter and modifiedbefore fields to the
+// request.
+func (s *RenterSearchRequestGet) WithModifiedRange(after, before time.Time) *RenterSearchRequestGet {
+	s.values.Set("modifiedafter", fmt.Sprint(after.Unix()))
+	s.values.Set("modifiedbefore", fmt.Sprint(before.Unix()))
+	return s
+}
+
+// WithTags adds the tags field to the request. Each tag is either a key that
+// files must have or a key=value pair that files must match.
+func (s *RenterSearchRequestGet) WithTags(tags ...string) *RenterSearchRequestGet {
+	s.values.Set("tags", strings.Join(tags, ","))
+	return s
+}
+
+// SortBy adds the sortby and order fields to the request.
+func (s *RenterSearchRequestGet) SortBy(field string, desc bool) *RenterSearchRequestGet {
+	s.values.Set("sortby", field)
+	if desc {
+		s.values.Set("order", "desc")
+	}
+	return s
+}
+
+// Page adds the offset and limit fields to the request.
+func (s *RenterSearchRequestGet) Page(offset, limit int) *RenterSearchRequestGet {
+	s.values.Set("offset", fmt.Sprint(offset))
+	s.values.Set("limit", fmt.Sprint(limit))
+	return s
+}
+
+// Get sends the request.
+func (s *RenterSearchRequestGet) Get() (rsg api.RenterSearchGET, err error) {
+	err = s.c.get("/renter/search?"+s.values.Encode(), &rsg)
+	return
+}
+
+// RenterList creates a new request to list the directory tree below a
+// directory.
+func (c *Client) RenterList(siaPath modules.SiaPath) *RenterListRequestGet {
+	return &RenterListRequestGet{c: c, siaPath: siaPath, values: make(url.Values)}
+}
+
+// Depth adds the depth field to the request. A depth of 0 lists the whole
+// tree.
+func (l *RenterListRequestGet) Depth(depth int) *RenterListRequestGet {
+	l.values.Set("depth", fmt.Sprint(depth))
+	return l
+}
+
+// Page adds the token and limit fields to the request. The token of the first
+// page is empty.
+func (l *RenterListRequestGet) Page(token string, limit int) *RenterListRequestGet {
+	l.values.Set("token", token)
+	l.values.Set("limit", fmt.Sprint(limit))
+	return l
+}
+
+// Fields adds the fields field to the request. Only the selected fields of
+// the entries are set in the response.
+func (l *RenterListRequestGet) Fields(fields ...string) *RenterListRequestGet {
+	l.values.Set("fields", strings.Join(fields, ","))
+	return l
+}
+
+// Get sends the request.
+func (l *RenterListRequestGet) Get() (rlg api.RenterListGET, err error) {
+	err = l.c.get(fmt.Sprintf("/renter/list/%s?%s", escapeSiaPath(l.siaPath), l.values.Encode()), &rlg)
+	return
+}
+
 // escapeSiaPath escapes the siapath to make it safe to use within a URL. This
 // should only be used on SiaPaths which are used as part of the URL path.
 // Paths within the query have to be escaped with url.PathEscape.
@@ -147,6 +293,17 @@ func escapeSiaPath(siaPath modules.SiaPath) string {
 	return strings.Join(escapedSegments, "/")
 }
 
+// encodeTags encodes tags as a comma separated list of key=value pairs sorted
+// by key.
+func encodeTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 // RenterCleanPost uses the /renter/clean endpoint to clean any lost files from
 // the renter
 func (c *Client) RenterCleanPost() (err error) {
@@ -161,6 +318,17 @@ func (c *Client) RenterContractorChurnStatus() (churnStatus modules.ContractorCh
 	return
 }
 
+// RenterStatsGet uses the /renter/stats endpoint to request the renter's
+// cumulative stats. If since is not zero, the stats since then are requested.
+func (c *Client) RenterStatsGet(since time.Time) (stats modules.RenterCumulativeStats, err error) {
+	values := url.Values{}
+	if !since.IsZero() {
+		values.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	err = c.get("/renter/stats?"+values.Encode(), &stats)
+	return
+}
+
 // RenterContractCancelPost uses the /renter/contract/cancel endpoint to cancel
 // a contract
 func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
@@ -181,6 +349,96 @@ func (c *Client) RenterAllContractsGet() (rc api.RenterContracts, err error) {
 	return
 }
 
+// RenterContractsExportPost uses the /renter/contracts/export endpoint to
+// export the renter's contracts to dst.
+func (c *Client) RenterContractsExportPost(dst string) (err error) {
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post("/renter/contracts/export", values.Encode(), nil)
+	return
+}
+
+// RenterContractsImportPost uses the /renter/contracts/import endpoint to
+// import the contracts exported to src.
+func (c *Client) RenterContractsImportPost(src string) (rcip api.RenterContractsImportPOST, err error) {
+	values := url.Values{}
+	values.Set("source", src)
+	err = c.post("/renter/contracts/import", values.Encode(), &rcip)
+	return
+}
+
+// RenterShareKeyGet requests the /renter/share/key resource and returns the
+// hex encoded share key of the renter.
+func (c *Client) RenterShareKeyGet() (rskg api.RenterShareKeyGET, err error) {
+	err = c.get("/renter/share/key", &rskg)
+	return
+}
+
+// RenterShareExportPost uses the /renter/share/export endpoint to export the
+// files at siaPaths to a share bundle at dst for the renter with the provided
+// share key. A zero expiry means that the share doesn't expire.
+func (c *Client) RenterShareExportPost(siaPaths []modules.SiaPath, dst, recipient string, expiry time.Time) (err error) {
+	values := url.Values{}
+	for _, siaPath := range siaPaths {
+		values.Add("siapath", siaPath.String())
+	}
+	values.Set("destination", dst)
+	values.Set("recipient", recipient)
+	if !expiry.IsZero() {
+		values.Set("expiry", strconv.FormatInt(expiry.Unix(), 10))
+	}
+	err = c.post("/renter/share/export", values.Encode(), nil)
+	return
+}
+
+// RenterShareImportPost uses the /renter/share/import endpoint to import the
+// files of the share bundle at src into the directory at siaPath.
+func (c *Client) RenterShareImportPost(src string, siaPath modules.SiaPath) (rsip api.RenterShareImportPOST, err error) {
+	values := url.Values{}
+	values.Set("source", src)
+	values.Set("siapath", siaPath.String())
+	err = c.post("/renter/share/import", values.Encode(), &rsip)
+	return
+}
+
+// RenterCustodyTransferPost uses the /renter/custody/transfer endpoint to hand
+// the files within the directory at siaPath and all contracts over to the
+// renter with the provided share key.
+func (c *Client) RenterCustodyTransferPost(siaPath modules.SiaPath, dst, recipient string) (ct modules.CustodyTransfer, err error) {
+	values := url.Values{}
+	values.Set("siapath", siaPath.String())
+	values.Set("destination", dst)
+	values.Set("recipient", recipient)
+	err = c.post("/renter/custody/transfer", values.Encode(), &ct)
+	return
+}
+
+// RenterCustodyConfirmPost uses the /renter/custody/confirm endpoint to
+// confirm the unconfirmed custody transfer after the recipient imported the
+// custody bundle.
+func (c *Client) RenterCustodyConfirmPost() (ct modules.CustodyTransfer, err error) {
+	err = c.post("/renter/custody/confirm", "", &ct)
+	return
+}
+
+// RenterCustodyCancelPost uses the /renter/custody/cancel endpoint to cancel
+// the unconfirmed custody transfer and restore the transferred contracts.
+func (c *Client) RenterCustodyCancelPost() (ct modules.CustodyTransfer, err error) {
+	err = c.post("/renter/custody/cancel", "", &ct)
+	return
+}
+
+// RenterCustodyImportPost uses the /renter/custody/import endpoint to import
+// the files and contracts of the custody bundle at src. The files are imported
+// into the directory at siaPath.
+func (c *Client) RenterCustodyImportPost(src string, siaPath modules.SiaPath) (ct modules.CustodyTransfer, err error) {
+	values := url.Values{}
+	values.Set("source", src)
+	values.Set("siapath", siaPath.String())
+	err = c.post("/renter/custody/import", values.Encode(), &ct)
+	return
+}
+
 // RenterContractsGet requests the /renter/contracts resource and returns
 // Contracts and ActiveContracts
 func (c *Client) RenterContractsGet() (rc api.RenterContracts, err error) {
@@ -247,6 +505,51 @@ func (c *Client) RenterRecoverableContractsGet() (rc api.RenterContracts, err er
 	return
 }
 
+// RenterUnrecoverableContractsGet requests the /renter/contracts resource with
+// the unrecoverable flag set to true
+func (c *Client) RenterUnrecoverableContractsGet() (rc api.RenterContracts, err error) {
+	values := url.Values{}
+	values.Set("unrecoverable", fmt.Sprint(true))
+	err = c.get("/renter/contracts?"+values.Encode(), &rc)
+	return
+}
+
+// renterBulkPost uses the /renter/bulk endpoint to apply an action to the files
+// matching a glob pattern.
+func (c *Client) renterBulkPost(values url.Values, pattern string, root, dryRun bool) (rbp api.RenterBulkPOST, err error) {
+	values.Set("pattern", pattern)
+	values.Set("root", fmt.Sprint(root))
+	values.Set("dryrun", fmt.Sprint(dryRun))
+	err = c.post("/renter/bulk", values.Encode(), &rbp)
+	return
+}
+
+// RenterBulkDeletePost uses the /renter/bulk endpoint to delete the files
+// matching a glob pattern.
+func (c *Client) RenterBulkDeletePost(pattern string, root, dryRun bool) (api.RenterBulkPOST, error) {
+	values := url.Values{}
+	values.Set("action", "delete")
+	return c.renterBulkPost(values, pattern, root, dryRun)
+}
+
+// RenterBulkDownloadPost uses the /renter/bulk endpoint to download the files
+// matching a glob pattern to a local directory. The downloads are async.
+func (c *Client) RenterBulkDownloadPost(pattern, destination string, root, dryRun bool) (api.RenterBulkPOST, error) {
+	values := url.Values{}
+	values.Set("action", "download")
+	values.Set("destination", destination)
+	return c.renterBulkPost(values, pattern, root, dryRun)
+}
+
+// RenterBulkStuckPost uses the /renter/bulk endpoint to set the stuck status of
+// the files matching a glob pattern.
+func (c *Client) RenterBulkStuckPost(pattern string, stuck, root, dryRun bool) (api.RenterBulkPOST, error) {
+	values := url.Values{}
+	values.Set("action", "stuck")
+	values.Set("stuck", fmt.Sprint(stuck))
+	return c.renterBulkPost(values, pattern, root, dryRun)
+}
+
 // RenterCancelDownloadPost requests the /renter/download/cancel endpoint to
 // cancel an ongoing doing.
 func (c *Client) RenterCancelDownloadPost(id modules.DownloadID) (err error) {
@@ -289,6 +592,27 @@ func (c *Client) RenterDownloadGet(siaPath modules.SiaPath, destination string,
 	return modules.DownloadID(h.Get("ID")), nil
 }
 
+// RenterDownloadDirGet uses the /renter/downloaddir endpoint to download a
+// directory to a local destination. Subdirectories are only downloaded if
+// recursive is set and existing local files are skipped unless force is set.
+func (c *Client) RenterDownloadDirGet(siaPath modules.SiaPath, destination string, recursive, root, force bool) (rdt api.RenterDirTransfer, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("recursive", fmt.Sprint(recursive))
+	values.Set("root", fmt.Sprint(root))
+	values.Set("force", fmt.Sprint(force))
+	err = c.get(fmt.Sprintf("/renter/downloaddir/%s?%s", sp, values.Encode()), &rdt)
+	return
+}
+
+// RenterDirTransferGet uses the /renter/dirtransfer endpoint to get the
+// progress of a directory upload or download.
+func (c *Client) RenterDirTransferGet(id string) (rdt api.RenterDirTransfer, err error) {
+	err = c.get("/renter/dirtransfer/"+id, &rdt)
+	return
+}
+
 // RenterDownloadInfoGet uses the /renter/downloadinfo endpoint to fetch
 // information about a download from the history.
 func (c *Client) RenterDownloadInfoGet(uid modules.DownloadID) (di api.DownloadInfo, err error) {
@@ -430,6 +754,19 @@ func (c *Client) RenterDownloadHTTPResponseGet(siaPath modules.SiaPath, offset,
 	return modules.DownloadID(h.Get("ID")), resp, nil
 }
 
+// RenterDownloadExternalKeyGet uses the /renter/download endpoint to download
+// a file which is encrypted with an external key and returns its data in the
+// response body.
+func (c *Client) RenterDownloadExternalKeyGet(siaPath modules.SiaPath, key crypto.CipherKey) ([]byte, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("httpresp", fmt.Sprint(true))
+	values.Set("encryptionkey", hex.EncodeToString(key.Key()))
+	values.Set("ciphertype", key.Type().String())
+	_, resp, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	return resp, err
+}
+
 // RenterFileRootGet uses the /renter/file/:siapath endpoint to query a file.
 // It passes the `root=true` flag to indicate an absolute path.
 func (c *Client) RenterFileRootGet(siaPath modules.SiaPath) (rf api.RenterFile, err error) {
@@ -469,6 +806,8 @@ func (c *Client) RenterPostAllowance(allowance modules.Allowance) error {
 	a = a.WithExpectedDownload(allowance.ExpectedDownload)
 	a = a.WithExpectedRedundancy(allowance.ExpectedRedundancy)
 	a = a.WithMaxPeriodChurn(allowance.MaxPeriodChurn)
+	a = a.WithMaxPeriodContractChurn(allowance.MaxPeriodContractChurn)
+	a = a.WithRenewWindowPolicy(allowance.RenewWindowPolicy)
 	return a.Send()
 }
 
@@ -479,6 +818,54 @@ func (c *Client) RenterAllowanceCancelPost() (err error) {
 	return
 }
 
+// RenterAllowancePlanGet requests the /renter/allowance/plan endpoint's
+// resources. Fields of the allowance that are not set default to the renter's
+// current allowance.
+func (c *Client) RenterAllowancePlanGet(allowance modules.Allowance) (rapg api.RenterAllowancePlanGET, err error) {
+	values := url.Values{}
+	if !allowance.Funds.IsZero() {
+		values.Set("funds", allowance.Funds.String())
+	}
+	if allowance.Hosts != 0 {
+		values.Set("hosts", fmt.Sprint(allowance.Hosts))
+	}
+	if allowance.Period != 0 {
+		values.Set("period", fmt.Sprint(allowance.Period))
+	}
+	if allowance.RenewWindow != 0 {
+		values.Set("renewwindow", fmt.Sprint(allowance.RenewWindow))
+	}
+	if allowance.ExpectedStorage != 0 {
+		values.Set("expectedstorage", fmt.Sprint(allowance.ExpectedStorage))
+	}
+	if allowance.ExpectedUpload != 0 {
+		values.Set("expectedupload", fmt.Sprint(allowance.ExpectedUpload))
+	}
+	if allowance.ExpectedDownload != 0 {
+		values.Set("expecteddownload", fmt.Sprint(allowance.ExpectedDownload))
+	}
+	if allowance.ExpectedRedundancy != 0 {
+		values.Set("expectedredundancy", fmt.Sprint(allowance.ExpectedRedundancy))
+	}
+	if allowance.MaxPeriodChurn != 0 {
+		values.Set("maxperiodchurn", fmt.Sprint(allowance.MaxPeriodChurn))
+	}
+	if allowance.MaxPeriodContractChurn != 0 {
+		values.Set("maxperiodcontractchurn", fmt.Sprint(allowance.MaxPeriodContractChurn))
+	}
+	if allowance.RenewWindowPolicy != modules.RenewWindowPolicyStart {
+		values.Set("renewwindowpolicy", string(allowance.RenewWindowPolicy))
+	}
+	err = c.get("/renter/allowance/plan?"+values.Encode(), &rapg)
+	return
+}
+
+// RenterForecastGet requests the /renter/forecast endpoint's resources.
+func (c *Client) RenterForecastGet() (rfg api.RenterForecastGET, err error) {
+	err = c.get("/renter/forecast", &rfg)
+	return
+}
+
 // RenterPricesGet requests the /renter/prices endpoint's resources.
 func (c *Client) RenterPricesGet(allowance modules.Allowance) (rpg api.RenterPricesGET, err error) {
 	query := fmt.Sprintf("?funds=%v&hosts=%v&period=%v&renewwindow=%v",
@@ -497,6 +884,129 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterAccountRefillPolicyPost uses the /renter endpoint to change the
+// renter's ephemeral account refill policy.
+func (c *Client) RenterAccountRefillPolicyPost(maxBalanceAtRisk, refillThreshold types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("accountmaxbalanceatrisk", maxBalanceAtRisk.String())
+	values.Set("accountrefillthreshold", refillThreshold.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterOverdrivePost uses the /renter endpoint to change the renter's
+// download and stream overdrive settings.
+func (c *Client) RenterOverdrivePost(downloadOverdrive, streamOverdrive uint64, adaptive bool) (err error) {
+	values := url.Values{}
+	values.Set("downloadoverdrive", fmt.Sprint(downloadOverdrive))
+	values.Set("streamoverdrive", fmt.Sprint(streamOverdrive))
+	values.Set("adaptiveoverdrive", fmt.Sprint(adaptive))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterLowLatencyPost uses the /renter endpoint to change the fraction of each
+// chunk's pieces that are preferably uploaded to low latency hosts and the
+// latency budget of those hosts.
+func (c *Client) RenterLowLatencyPost(fraction float64, budget time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("lowlatencyfraction", fmt.Sprint(fraction))
+	values.Set("lowlatencybudget", budget.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterDownloadStallTimeoutPost uses the /renter endpoint to change the time
+// after which a piece download is considered stalled.
+func (c *Client) RenterDownloadStallTimeoutPost(timeout time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("downloadstalltimeout", timeout.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterMemoryPost uses the /renter endpoint to change the memory budgets of
+// user uploads, user downloads and repairs.
+func (c *Client) RenterMemoryPost(upload, download, repair uint64) (err error) {
+	values := url.Values{}
+	values.Set("uploadmemory", fmt.Sprint(upload))
+	values.Set("downloadmemory", fmt.Sprint(download))
+	values.Set("repairmemory", fmt.Sprint(repair))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterSpillPost uses the /renter endpoint to change the directory and the cap
+// of the disk spill of upload and repair chunks.
+func (c *Client) RenterSpillPost(dir string, spillCap uint64) (err error) {
+	values := url.Values{}
+	values.Set("spilldir", dir)
+	values.Set("spillcap", fmt.Sprint(spillCap))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterPackSizePost uses the /renter endpoint to change the size of the packs
+// which small files are packed into.
+func (c *Client) RenterPackSizePost(packSize uint64) (err error) {
+	values := url.Values{}
+	values.Set("packsize", fmt.Sprint(packSize))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterCostCeilingsPost uses the /renter endpoint to change the renter's
+// per-operation cost ceilings.
+func (c *Client) RenterCostCeilingsPost(maxDownload, maxRegistryRead, maxRegistryWrite, maxUpload types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("maxdownloadcost", maxDownload.String())
+	values.Set("maxregistryreadcost", maxRegistryRead.String())
+	values.Set("maxregistrywritecost", maxRegistryWrite.String())
+	values.Set("maxuploadcost", maxUpload.String())
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterGougingPost uses the /renter endpoint to change the thresholds of the
+// renter's price gouging checks. Unset thresholds are reset to their defaults.
+func (c *Client) RenterGougingPost(gs modules.GougingSettings) (err error) {
+	values := url.Values{}
+	setFraction := func(name string, f *float64) {
+		if f == nil {
+			values.Set(name, "default")
+		} else {
+			values.Set(name, fmt.Sprint(*f))
+		}
+	}
+	setFraction("baserpcmaxfraction", gs.BaseRPCMaxFraction)
+	setFraction("downloadusagefraction", gs.DownloadUsageFraction)
+	setFraction("fundaccountmaxfraction", gs.FundAccountMaxFraction)
+	setFraction("pricetablemaxfraction", gs.PriceTableMaxFraction)
+	setFraction("registryusagefraction", gs.RegistryUsageFraction)
+	setFraction("uploadusagefraction", gs.UploadUsageFraction)
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterGougingGet requests the /renter/gouging endpoint's resources.
+func (c *Client) RenterGougingGet() (rgg api.RenterGougingGET, err error) {
+	err = c.get("/renter/gouging", &rgg)
+	return
+}
+
+// RenterConcatPost uses the /renter/concat/:siapath endpoint to concatenate
+// the parts into a new file.
+func (c *Client) RenterConcatPost(siaPath modules.SiaPath, parts []modules.SiaPath, root bool) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	for _, part := range parts {
+		values.Add("part", part.String())
+	}
+	values.Set("root", fmt.Sprint(root))
+	err = c.post(fmt.Sprintf("/renter/concat/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.SiaPath, root bool) (err error) {
 	spo := escapeSiaPath(siaPathOld)
@@ -568,6 +1078,28 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck boo
 	return
 }
 
+// RenterSetFileLockPost uses the /renter/file/*siapath endpoint to lock a file
+// against deletion and modification until lockedUntil.
+func (c *Client) RenterSetFileLockPost(siaPath modules.SiaPath, root bool, lockedUntil time.Time) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("lockeduntil", fmt.Sprint(lockedUntil.Unix()))
+	values.Set("root", fmt.Sprint(root))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterSetFileTagsPost uses the /renter/file/*siapath endpoint to replace the
+// tags of a file.
+func (c *Client) RenterSetFileTagsPost(siaPath modules.SiaPath, root bool, tags map[string]string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("tags", encodeTags(tags))
+	values.Set("root", fmt.Sprint(root))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -586,6 +1118,33 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.SiaPath, dat
 	return
 }
 
+// RenterUploadSparsePost uses the /renter/upload endpoint to upload a file as
+// a sparse file. Chunks of the file which only contain zeros are recorded as
+// holes instead of being uploaded.
+func (c *Client) RenterUploadSparsePost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("sparse", strconv.FormatBool(true))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUploadDirPost uses the /renter/uploaddir endpoint to upload a local
+// directory and its subdirectories to a siapath.
+func (c *Client) RenterUploadDirPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64, force bool) (rdt api.RenterDirTransfer, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post(fmt.Sprintf("/renter/uploaddir/%s", sp), values.Encode(), &rdt)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.SiaPath) (err error) {
@@ -608,6 +1167,126 @@ func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.SiaPath, da
 	return err
 }
 
+// RenterUploadStreamExternalKeyPost uploads data using a stream and encrypts
+// it with an external key. The key isn't stored by the renter and needs to be
+// provided again to download the file.
+func (c *Client) RenterUploadStreamExternalKeyPost(r io.Reader, siaPath modules.SiaPath, keyID string, key crypto.CipherKey, dataPieces, parityPieces uint64, force bool) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("stream", strconv.FormatBool(true))
+	values.Set("keyid", keyID)
+	values.Set("encryptionkey", hex.EncodeToString(key.Key()))
+	values.Set("ciphertype", key.Type().String())
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamSparsePost uploads data using a stream as a sparse file.
+// Chunks of the data which only contain zeros are recorded as holes instead of
+// being uploaded.
+func (c *Client) RenterUploadStreamSparsePost(r io.Reader, siaPath modules.SiaPath, dataPieces, parityPieces uint64) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("sparse", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamAppendPost appends the data provided by r to an existing
+// siafile using a stream. The size of the siafile must be a multiple of its
+// chunk size.
+func (c *Client) RenterUploadStreamAppendPost(r io.Reader, siaPath modules.SiaPath) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("append", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadURLPost uses the /renter/uploadurl endpoint to upload a file
+// whose data the renter fetches from the provided URL. A non-zero maxSize
+// limits the size of the upload. If async is true, the call returns before the
+// upload is done.
+func (c *Client) RenterUploadURLPost(sourceURL string, siaPath modules.SiaPath, dataPieces, parityPieces, maxSize uint64, force, async bool) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("url", sourceURL)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("maxsize", strconv.FormatUint(maxSize, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("async", strconv.FormatBool(async))
+	return c.post(fmt.Sprintf("/renter/uploadurl/%s?%s", sp, values.Encode()), "", nil)
+}
+
+// RenterUploadURLGet uses the /renter/uploadurl endpoint to list the renter's
+// URL uploads.
+func (c *Client) RenterUploadURLGet() (uploads api.RenterURLUploadsGET, err error) {
+	err = c.get("/renter/uploadurl", &uploads)
+	return
+}
+
+// RenterReshardPost uses the /renter/reshard endpoint to change the erasure
+// coding parameters of a file.
+func (c *Client) RenterReshardPost(siaPath modules.SiaPath, dataPieces, parityPieces uint64) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	return c.post(fmt.Sprintf("/renter/reshard/%s?%s", sp, values.Encode()), "", nil)
+}
+
+// RenterReshardGet uses the /renter/reshard endpoint to list the renter's
+// changes of the erasure coding parameters of files.
+func (c *Client) RenterReshardGet() (reshards api.RenterReshardsGET, err error) {
+	err = c.get("/renter/reshard", &reshards)
+	return
+}
+
+// RenterPackPost uses the /renter/pack endpoint to upload a small file which is
+// packed into a shared pack.
+func (c *Client) RenterPackPost(siaPath modules.SiaPath, data []byte) error {
+	sp := escapeSiaPath(siaPath)
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/pack/%s", sp), bytes.NewReader(data))
+	return err
+}
+
+// RenterPackGet uses the /renter/pack endpoint to download a packed file.
+func (c *Client) RenterPackGet(siaPath modules.SiaPath) (data []byte, err error) {
+	sp := escapeSiaPath(siaPath)
+	_, data, err = c.getRawResponse(fmt.Sprintf("/renter/pack/%s", sp))
+	return
+}
+
+// RenterPacksGet uses the /renter/packs endpoint to list the renter's packed
+// files.
+func (c *Client) RenterPacksGet() (packs api.RenterPacksGET, err error) {
+	err = c.get("/renter/packs", &packs)
+	return
+}
+
+// RenterPacksFlushPost uses the /renter/packs/flush endpoint to upload all
+// packs which haven't been uploaded yet.
+func (c *Client) RenterPacksFlushPost() (err error) {
+	err = c.post("/renter/packs/flush", "", nil)
+	return
+}
+
+// RenterPackDeletePost uses the /renter/packdelete endpoint to delete a packed
+// file.
+func (c *Client) RenterPackDeletePost(siaPath modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.post(fmt.Sprintf("/renter/packdelete/%s", sp), "", nil)
+	return
+}
+
 // RenterUploadStreamRepairPost a siafile using a stream. If the data provided
 // by r is not the same as the previously uploaded data, the data will be
 // corrupted.
@@ -652,6 +1331,39 @@ func (c *Client) RenterDirDeletePost(siaPath modules.SiaPath) (err error) {
 	return
 }
 
+// RenterDirSetQuotaPost uses the /renter/dir/ endpoint to set the quota of a
+// directory. A quota of 0 removes the quota.
+func (c *Client) RenterDirSetQuotaPost(siaPath modules.SiaPath, quota uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setquota")
+	values.Set("quota", strconv.FormatUint(quota, 10))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetLockPost uses the /renter/dir/ endpoint to lock a directory and
+// its contents against deletion and modification until lockedUntil.
+func (c *Client) RenterDirSetLockPost(siaPath modules.SiaPath, lockedUntil time.Time) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setlock")
+	values.Set("lockeduntil", fmt.Sprint(lockedUntil.Unix()))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetTagsPost uses the /renter/dir/ endpoint to replace the tags of a
+// directory.
+func (c *Client) RenterDirSetTagsPost(siaPath modules.SiaPath, tags map[string]string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "settags")
+	values.Set("tags", encodeTags(tags))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRenamePost uses the /renter/dir/ endpoint to rename a directory for the
 // renter
 func (c *Client) RenterDirRenamePost(siaPath, newSiaPath modules.SiaPath) (err error) {
@@ -749,6 +1461,127 @@ func (c *Client) RenterUploadsResumePost() (err error) {
 	return
 }
 
+// RenterRepairGet uses the /renter/repair endpoint to request the renter's
+// repair backlog.
+func (c *Client) RenterRepairGet() (backlog modules.RepairBacklog, err error) {
+	err = c.get("/renter/repair", &backlog)
+	return
+}
+
+// RenterRepairPausePost uses the /renter/repair/pause endpoint to pause the
+// repair of existing files. A duration of 0 pauses repairs until they are
+// resumed.
+func (c *Client) RenterRepairPausePost(duration time.Duration) (err error) {
+	values := url.Values{}
+	values.Set("duration", fmt.Sprint(uint64(math.Round(duration.Seconds()))))
+	err = c.post("/renter/repair/pause", values.Encode(), nil)
+	return
+}
+
+// RenterRepairResumePost uses the /renter/repair/resume endpoint to resume the
+// repair of existing files.
+func (c *Client) RenterRepairResumePost() (err error) {
+	err = c.post("/renter/repair/resume", "", nil)
+	return
+}
+
+// RenterPaymentBudgetsGet uses the /renter/paymentbudgets endpoint to list the
+// renter's payment budgets.
+func (c *Client) RenterPaymentBudgetsGet() (rpbg api.RenterPaymentBudgetsGET, err error) {
+	err = c.get("/renter/paymentbudgets", &rpbg)
+	return
+}
+
+// RenterPaymentBudgetsPost uses the /renter/paymentbudgets endpoint to create a
+// payment budget. A duration of 0 creates a budget which doesn't expire.
+func (c *Client) RenterPaymentBudgetsPost(name string, limit types.Currency, duration time.Duration) (budget modules.PaymentBudget, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("limit", limit.String())
+	values.Set("duration", fmt.Sprint(uint64(math.Round(duration.Seconds()))))
+	err = c.post("/renter/paymentbudgets", values.Encode(), &budget)
+	return
+}
+
+// RenterPaymentBudgetGet uses the /renter/paymentbudget/:id endpoint to get a
+// payment budget and its receipts.
+func (c *Client) RenterPaymentBudgetGet(id string) (rpbg api.RenterPaymentBudgetGET, err error) {
+	err = c.get("/renter/paymentbudget/"+id, &rpbg)
+	return
+}
+
+// RenterPaymentBudgetPost uses the /renter/paymentbudget/:id endpoint to change
+// the limit of a payment budget.
+func (c *Client) RenterPaymentBudgetPost(id string, limit types.Currency) (err error) {
+	values := url.Values{}
+	values.Set("limit", limit.String())
+	err = c.post("/renter/paymentbudget/"+id, values.Encode(), nil)
+	return
+}
+
+// RenterPaymentBudgetDeletePost uses the /renter/paymentbudget/:id/delete
+// endpoint to delete a payment budget.
+func (c *Client) RenterPaymentBudgetDeletePost(id string) (err error) {
+	err = c.post("/renter/paymentbudget/"+id+"/delete", "", nil)
+	return
+}
+
+// RenterPaymentBudgetExecutePost uses the /renter/paymentbudget/:id/execute
+// endpoint to execute an MDM program on a host which is paid from the payment
+// budget. A zero maxCost doesn't limit the cost beyond the budget.
+func (c *Client) RenterPaymentBudgetExecutePost(id string, host types.SiaPublicKey, instructions []modules.ProgramInstruction, maxCost types.Currency) (result modules.ProgramResult, err error) {
+	data, err := json.Marshal(api.RenterProgramPOST{
+		HostPublicKey: host,
+		Instructions:  instructions,
+		MaxCost:       maxCost,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/renter/paymentbudget/"+id+"/execute", string(data), &result)
+	return
+}
+
+// RenterProgramCostPost uses the /renter/programcost endpoint to get the cost
+// of executing an MDM program on a host.
+func (c *Client) RenterProgramCostPost(host types.SiaPublicKey, instructions []modules.ProgramInstruction) (rpcp api.RenterProgramCostPOST, err error) {
+	data, err := json.Marshal(api.RenterProgramPOST{
+		HostPublicKey: host,
+		Instructions:  instructions,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/renter/programcost", string(data), &rpcp)
+	return
+}
+
+// RenterAuditsGet requests the /renter/audits endpoint to get the audit
+// settings and the reports of the most recent audits.
+func (c *Client) RenterAuditsGet() (rag api.RenterAuditsGET, err error) {
+	err = c.get("/renter/audits", &rag)
+	return
+}
+
+// RenterAuditsPost uses the /renter/audits endpoint to change the audit
+// settings.
+func (c *Client) RenterAuditsPost(settings modules.AuditSettings) (err error) {
+	values := url.Values{}
+	values.Set("interval", fmt.Sprint(uint64(settings.Interval.Seconds())))
+	values.Set("samples", fmt.Sprint(settings.Samples))
+	err = c.post("/renter/audits", values.Encode(), nil)
+	return
+}
+
+// RenterAuditPost uses the /renter/audit endpoint to audit the given number
+// of random chunks of the renter's files.
+func (c *Client) RenterAuditPost(samples uint64) (report modules.AuditReport, err error) {
+	values := url.Values{}
+	values.Set("samples", fmt.Sprint(samples))
+	err = c.post("/renter/audit", values.Encode(), &report)
+	return
+}
+
 // RenterPost uses the /renter POST endpoint to set fields of the renter. Values
 // are encoded as a query string in the body
 func (c *Client) RenterPost(values url.Values) (err error) {
@@ -763,6 +1596,13 @@ func (c *Client) RenterWorkersGet() (wps modules.WorkerPoolStatus, err error) {
 	return
 }
 
+// RenterWorkerGet uses the /renter/workers/:pubkey endpoint to get the current
+// status of the renter's worker for a single host.
+func (c *Client) RenterWorkerGet(hostPubKey types.SiaPublicKey) (ws modules.WorkerStatus, err error) {
+	err = c.get("/renter/workers/"+hostPubKey.String(), &ws)
+	return
+}
+
 // RenterBubblePost uses the /renter/bubble endpoint to manually trigger an
 // update to the directories metadata.
 func (c *Client) RenterBubblePost(siaPath modules.SiaPath, force, recursive bool) (err error) {
@@ -782,3 +1622,15 @@ func (c *Client) RenterFileHosts(siaPath modules.SiaPath) (hosts []modules.HostD
 	err = c.get("/renter/hosts/"+sp, &hosts)
 	return
 }
+
+// RenterFileChecksumsGet requests the /renter/checksums/*siapath endpoint to
+// get the piece roots and proofs of up to limit chunks of a file starting at
+// the chunk with index offset.
+func (c *Client) RenterFileChecksumsGet(siaPath modules.SiaPath, offset, limit uint64) (fc modules.FileChecksums, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get(fmt.Sprintf("/renter/checksums/%s?%s", sp, values.Encode()), &fc)
+	return
+}
@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/url"
 
 	"gitlab.com/NebulousLabs/encoding"
@@ -15,6 +16,23 @@ func (c *Client) TransactionPoolFeeGet() (tfg api.TpoolFeeGET, err error) {
 	return
 }
 
+// TransactionPoolFeeEstimateGet uses the /tpool/fee/estimate endpoint to get
+// the recommended fee per byte for a transaction to be confirmed within
+// 'target' blocks.
+func (c *Client) TransactionPoolFeeEstimateGet(target types.BlockHeight) (tfeg api.TpoolFeeEstimateGET, err error) {
+	values := url.Values{}
+	values.Set("target", fmt.Sprint(target))
+	err = c.get("/tpool/fee/estimate?"+values.Encode(), &tfeg)
+	return
+}
+
+// TransactionPoolFeeHistogramGet uses the /tpool/fee/histogram endpoint to get
+// the distribution of the fees paid by the transactions in the tpool.
+func (c *Client) TransactionPoolFeeHistogramGet() (tfhg api.TpoolFeeHistogramGET, err error) {
+	err = c.get("/tpool/fee/histogram", &tfhg)
+	return
+}
+
 // TransactionPoolRawPost uses the /tpool/raw endpoint to send a raw
 // transaction to the transaction pool.
 func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.Transaction) (err error) {
@@ -25,9 +43,27 @@ func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.T
 	return
 }
 
+// TransactionPoolSimulatePost uses the /tpool/simulate endpoint to check
+// whether the transaction pool would accept a raw transaction without
+// broadcasting it.
+func (c *Client) TransactionPoolSimulatePost(txn types.Transaction, parents []types.Transaction) (tsp api.TpoolSimulatePOST, err error) {
+	values := url.Values{}
+	values.Set("transaction", base64.StdEncoding.EncodeToString(encoding.Marshal(txn)))
+	values.Set("parents", base64.StdEncoding.EncodeToString(encoding.Marshal(parents)))
+	err = c.post("/tpool/simulate", values.Encode(), &tsp)
+	return
+}
+
 // TransactionPoolTransactionsGet uses the /tpool/transactions endpoint to get the
 // transactions of the tpool
 func (c *Client) TransactionPoolTransactionsGet() (tptg api.TpoolTxnsGET, err error) {
 	err = c.get("/tpool/transactions", &tptg)
 	return
 }
+
+// TransactionPoolEvictPost uses the /tpool/evict/:id endpoint to evict a
+// transaction and the transactions depending on it from the tpool.
+func (c *Client) TransactionPoolEvictPost(txid types.TransactionID) (tep api.TpoolEvictPOST, err error) {
+	err = c.post("/tpool/evict/"+txid.String(), "", &tep)
+	return
+}
@@ -12,6 +12,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
+	"golang.org/x/net/websocket"
 )
 
 // WalletAddressGet requests a new address from the /wallet/address endpoint
@@ -91,12 +92,127 @@ func (c *Client) WalletInitSeedPost(seed, password string, force bool) (err erro
 	return
 }
 
+// WalletInitPassphrasePost uses the /wallet/init endpoint to initialize and
+// encrypt a wallet whose keys are derived from the generated seed and a seed
+// passphrase.
+func (c *Client) WalletInitPassphrasePost(password, passphrase string, force bool) (wip api.WalletInitPOST, err error) {
+	values := url.Values{}
+	values.Set("encryptionpassword", password)
+	values.Set("passphrase", passphrase)
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post("/wallet/init", values.Encode(), &wip)
+	return
+}
+
+// WalletInitSeedPassphrasePost uses the /wallet/init/seed endpoint to
+// initialize and encrypt a wallet using a given seed and seed passphrase.
+func (c *Client) WalletInitSeedPassphrasePost(seed, password, passphrase string, force bool) (err error) {
+	values := url.Values{}
+	values.Set("seed", seed)
+	values.Set("encryptionpassword", password)
+	values.Set("passphrase", passphrase)
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post("/wallet/init/seed", values.Encode(), nil)
+	return
+}
+
+// WalletInitWatchOnlyPost uses the /wallet/init/watchonly endpoint to
+// initialize and encrypt a watch-only wallet.
+func (c *Client) WalletInitWatchOnlyPost(password string, force bool) (err error) {
+	values := url.Values{}
+	values.Set("encryptionpassword", password)
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post("/wallet/init/watchonly", values.Encode(), nil)
+	return
+}
+
+// WalletFreezePost uses the /wallet/freeze endpoint to prevent the wallet
+// from using the given outputs to fund transactions.
+func (c *Client) WalletFreezePost(ids []types.OutputID) error {
+	json, err := json.Marshal(api.WalletFreezePOST{
+		IDs: ids,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/freeze", string(json), nil)
+}
+
+// WalletUnfreezePost uses the /wallet/freeze endpoint to allow the wallet to
+// use the given outputs to fund transactions again.
+func (c *Client) WalletUnfreezePost(ids []types.OutputID) error {
+	json, err := json.Marshal(api.WalletFreezePOST{
+		IDs:      ids,
+		Unfreeze: true,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/freeze", string(json), nil)
+}
+
 // WalletGet requests the /wallet api resource
 func (c *Client) WalletGet() (wg api.WalletGET, err error) {
 	err = c.get("/wallet", &wg)
 	return
 }
 
+// WalletHistoryGet requests the /wallet/history endpoint and returns the
+// confirmed transactions of the wallet with timestamps in [start, end] and the
+// running balances of the wallet. If currency is not empty, the transactions
+// are valued in that fiat currency.
+func (c *Client) WalletHistoryGet(start, end types.Timestamp, offset, limit uint64, currency string) (whg api.WalletHistoryGET, err error) {
+	err = c.get("/wallet/history?"+walletHistoryQuery(start, end, offset, limit, currency).Encode(), &whg)
+	return
+}
+
+// WalletHistoryCSVGet requests the /wallet/history endpoint and returns the
+// history of the wallet encoded as CSV.
+func (c *Client) WalletHistoryCSVGet(start, end types.Timestamp, offset, limit uint64, currency string) ([]byte, error) {
+	values := walletHistoryQuery(start, end, offset, limit, currency)
+	values.Set("format", "csv")
+	_, resp, err := c.getRawResponse("/wallet/history?" + values.Encode())
+	return resp, err
+}
+
+// walletHistoryQuery creates the query string parameters of a /wallet/history
+// request.
+func walletHistoryQuery(start, end types.Timestamp, offset, limit uint64, currency string) url.Values {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(start))
+	values.Set("end", fmt.Sprint(end))
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("limit", fmt.Sprint(limit))
+	if currency != "" {
+		values.Set("currency", currency)
+	}
+	return values
+}
+
+// WalletLabelsGet requests the /wallet/labels endpoint and returns the labels
+// of all labeled addresses.
+func (c *Client) WalletLabelsGet() (wlg api.WalletLabelsGET, err error) {
+	err = c.get("/wallet/labels", &wlg)
+	return
+}
+
+// WalletLabelsPost uses the /wallet/labels endpoint to assign a label to an
+// address. An empty label removes the label.
+func (c *Client) WalletLabelsPost(addr types.UnlockHash, label string) (err error) {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("label", label)
+	err = c.post("/wallet/labels", values.Encode(), nil)
+	return
+}
+
+// WalletLabelGet requests the /wallet/labels/:label endpoint and returns the
+// addresses, balances and transactions of the addresses with the label.
+func (c *Client) WalletLabelGet(label string) (wlg api.WalletLabelGET, err error) {
+	err = c.get("/wallet/labels/"+url.PathEscape(label), &wlg)
+	return
+}
+
 // WalletLastAddressesGet returns the count last addresses generated by the
 // wallet in reverse order. That means the last generated address will be the
 // first one in the slice.
@@ -111,6 +227,29 @@ func (c *Client) WalletLockPost() (err error) {
 	return
 }
 
+// WalletRescanGet requests the /wallet/rescan endpoint and returns the
+// progress of the latest rescan.
+func (c *Client) WalletRescanGet() (wrg api.WalletRescanGET, err error) {
+	err = c.get("/wallet/rescan", &wrg)
+	return
+}
+
+// WalletRescanPost uses the /wallet/rescan endpoint to rescan the blockchain
+// starting at startHeight.
+func (c *Client) WalletRescanPost(startHeight types.BlockHeight) (err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(startHeight))
+	err = c.post("/wallet/rescan", values.Encode(), nil)
+	return
+}
+
+// WalletRescanCancelPost uses the /wallet/rescan/cancel endpoint to cancel
+// the ongoing rescan.
+func (c *Client) WalletRescanCancelPost() (err error) {
+	err = c.post("/wallet/rescan/cancel", "", nil)
+	return
+}
+
 // WalletSeedPost uses the /wallet/seed endpoint to add a seed to the wallet's list
 // of seeds.
 func (c *Client) WalletSeedPost(seed, password string) (err error) {
@@ -152,6 +291,59 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsTargetPost uses the /wallet/siacoins api endpoint to send
+// money to a single address with a fee that targets the transaction to be
+// confirmed within 'target' blocks.
+func (c *Client) WalletSiacoinsTargetPost(amount types.Currency, destination types.UnlockHash, feeIncluded bool, target types.BlockHeight) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	values.Set("feeIncluded", strconv.FormatBool(feeIncluded))
+	values.Set("target", fmt.Sprint(target))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// WalletSiacoinsMultiTargetPost uses the /wallet/siacoins api endpoint to send
+// money to multiple addresses at once with a fee that targets the transaction
+// to be confirmed within 'target' blocks.
+func (c *Client) WalletSiacoinsMultiTargetPost(outputs []types.SiacoinOutput, target types.BlockHeight) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("outputs", string(marshaledOutputs))
+	values.Set("target", fmt.Sprint(target))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// WalletOfflineBroadcastPost uses the /wallet/offline/broadcast endpoint to
+// broadcast a signed offline transaction.
+func (c *Client) WalletOfflineBroadcastPost(otxn modules.OfflineTransaction) (wobr api.WalletOfflineBroadcastPOSTResp, err error) {
+	json, err := json.Marshal(otxn)
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/offline/broadcast", string(json), &wobr)
+	return
+}
+
+// WalletOfflineExportPost uses the /wallet/offline/export endpoint to export
+// an unsigned transaction for signing on an offline machine.
+func (c *Client) WalletOfflineExportPost(txn types.Transaction, toSign []crypto.Hash) (otxn modules.OfflineTransaction, err error) {
+	json, err := json.Marshal(api.WalletOfflineExportPOSTParams{
+		Transaction: txn,
+		ToSign:      toSign,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/offline/export", string(json), &otxn)
+	return
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -165,6 +357,43 @@ func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (ws
 	return
 }
 
+// WalletUnsignedTransactionPost uses the /wallet/unsignedtransaction api
+// endpoint to build an unsigned transaction funded by watched outputs.
+func (c *Client) WalletUnsignedTransactionPost(outputs []types.SiacoinOutput, fee types.Currency, changeAddr types.UnlockHash) (wutr api.WalletUnsignedTransactionPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletUnsignedTransactionPOSTParams{
+		Outputs:       outputs,
+		Fee:           fee,
+		ChangeAddress: changeAddr,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/unsignedtransaction", string(json), &wutr)
+	return
+}
+
+// WalletSiacoinsFromOutputsPost uses the /wallet/siacoins api endpoint to send
+// money to multiple siacoin outputs using exactly the given wallet outputs as
+// inputs.
+func (c *Client) WalletSiacoinsFromOutputsPost(inputs []types.SiacoinOutputID, outputs []types.SiacoinOutput, changeAddr types.UnlockHash) (wsp api.WalletSiacoinsPOST, err error) {
+	inputsJSON, err := json.Marshal(inputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	outputsJSON, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values := url.Values{}
+	values.Set("inputs", string(inputsJSON))
+	values.Set("outputs", string(outputsJSON))
+	if changeAddr != (types.UnlockHash{}) {
+		values.Set("changeaddress", changeAddr.String())
+	}
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
 // WalletSiafundsPost uses the /wallet/siafunds api endpoint to send siafunds
 // to a single address.
 func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiafundsPOST, err error) {
@@ -194,6 +423,24 @@ func (c *Client) WalletSweepPost(seed string) (wsp api.WalletSweepPOST, err erro
 	return
 }
 
+// WalletSweepOptionsPost uses the /wallet/sweep/seed endpoint to sweep the
+// given amounts of coins and funds from a seed into the current wallet. If
+// both are zero, the whole seed is swept. If dryRun is set, the sweep is only
+// planned.
+func (c *Client) WalletSweepOptionsPost(seed string, coins, funds types.Currency, dryRun bool) (wsp api.WalletSweepPOST, err error) {
+	values := url.Values{}
+	values.Set("seed", seed)
+	if !coins.IsZero() {
+		values.Set("coins", coins.String())
+	}
+	if !funds.IsZero() {
+		values.Set("funds", funds.String())
+	}
+	values.Set("dryrun", strconv.FormatBool(dryRun))
+	err = c.post("/wallet/sweep/seed", values.Encode(), &wsp)
+	return
+}
+
 // WalletTransactionsGet requests the/wallet/transactions api resource for a
 // certain startheight and endheight
 func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight types.BlockHeight) (wtg api.WalletTransactionsGET, err error) {
@@ -218,6 +465,16 @@ func (c *Client) WalletUnlockPost(password string) (err error) {
 	return
 }
 
+// WalletUnlockPassphrasePost uses the /wallet/unlock endpoint to unlock a
+// wallet which was initialized with a seed passphrase.
+func (c *Client) WalletUnlockPassphrasePost(password, passphrase string) (err error) {
+	values := url.Values{}
+	values.Set("encryptionpassword", password)
+	values.Set("passphrase", passphrase)
+	err = c.post("/wallet/unlock", values.Encode(), nil)
+	return
+}
+
 // WalletUnlockConditionsGet requests the /wallet/unlockconditions endpoint
 // and returns the UnlockConditions of addr.
 func (c *Client) WalletUnlockConditionsGet(addr types.UnlockHash) (wucg api.WalletUnlockConditionsGET, err error) {
@@ -254,6 +511,22 @@ func (c *Client) WalletWatchAddPost(addrs []types.UnlockHash, unused bool) error
 	return c.post("/wallet/watch", string(json), nil)
 }
 
+// WalletWatchPublicKeysPost uses the /wallet/watch endpoint to add the
+// standard addresses of a set of public keys to the watch set. The unused flag
+// should be set to true if the addresses have never appeared in the
+// blockchain.
+func (c *Client) WalletWatchPublicKeysPost(pks []types.SiaPublicKey, unused bool) error {
+	json, err := json.Marshal(api.WalletWatchPOST{
+		PublicKeys: pks,
+		Remove:     false,
+		Unused:     unused,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/watch", string(json), nil)
+}
+
 // WalletWatchRemovePost uses the /wallet/watch endpoint to remove a set of
 // addresses from the watch set. The unused flag should be set to true if the
 // addresses have never appeared in the blockchain.
@@ -269,6 +542,52 @@ func (c *Client) WalletWatchRemovePost(addrs []types.UnlockHash, unused bool) er
 	return c.post("/wallet/watch", string(json), nil)
 }
 
+// WalletEventsSubscribe connects to the /wallet/events websocket endpoint. The
+// events of the wallet can be read from the returned connection using
+// websocket.JSON.Receive. The caller must close the connection.
+func (c *Client) WalletEventsSubscribe() (*websocket.Conn, error) {
+	config, err := websocket.NewConfig("ws://"+c.Address+"/wallet/events", "http://"+c.Address)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.NewRequest("GET", "/wallet/events", nil)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to construct websocket request")
+	}
+	config.Header = req.Header
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to connect to /wallet/events")
+	}
+	return conn, nil
+}
+
+// WalletWebhooksGet requests the /wallet/webhooks endpoint to get the
+// webhooks of the wallet.
+func (c *Client) WalletWebhooksGet() (wwg api.WalletWebhooksGET, err error) {
+	err = c.get("/wallet/webhooks", &wwg)
+	return
+}
+
+// WalletWebhooksAddPost uses the /wallet/webhooks endpoint to add a webhook
+// the wallet POSTs its events to.
+func (c *Client) WalletWebhooksAddPost(webhookURL string) (err error) {
+	values := url.Values{}
+	values.Set("url", webhookURL)
+	err = c.post("/wallet/webhooks", values.Encode(), nil)
+	return
+}
+
+// WalletWebhooksRemovePost uses the /wallet/webhooks endpoint to remove a
+// webhook.
+func (c *Client) WalletWebhooksRemovePost(webhookURL string) (err error) {
+	values := url.Values{}
+	values.Set("url", webhookURL)
+	values.Set("remove", "true")
+	err = c.post("/wallet/webhooks", values.Encode(), nil)
+	return
+}
+
 // Wallet033xPost uses the /wallet/033x endpoint to load a v0.3.3.x wallet into
 // the current wallet.
 func (c *Client) Wallet033xPost(path, password string) (err error) {
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/big"
 	"net/http"
+	"sync"
 
 	"github.com/julienschmidt/httprouter"
+	"golang.org/x/net/websocket"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/build"
@@ -115,6 +118,62 @@ type ConsensusBlocksGetSiafundOutput struct {
 	UnlockHash types.UnlockHash      `json:"unlockhash"`
 }
 
+// ConsensusChangeGET is a consensus change sent to the websocket subscribers
+// of /consensus/changes, with tags to support idiomatic json encodings.
+type ConsensusChangeGET struct {
+	ID                         modules.ConsensusChangeID      `json:"id"`
+	BlockHeight                types.BlockHeight              `json:"blockheight"`
+	RevertedBlocks             []types.Block                  `json:"revertedblocks"`
+	AppliedBlocks              []types.Block                  `json:"appliedblocks"`
+	RevertedDiffs              []modules.ConsensusChangeDiffs `json:"reverteddiffs"`
+	AppliedDiffs               []modules.ConsensusChangeDiffs `json:"applieddiffs"`
+	ChildTarget                types.Target                   `json:"childtarget"`
+	MinimumValidChildTimestamp types.Timestamp                `json:"minimumvalidchildtimestamp"`
+	Synced                     bool                           `json:"synced"`
+}
+
+// consensusChangeStreamBuffer is the number of consensus changes buffered for
+// a websocket connection to /consensus/changes.
+const consensusChangeStreamBuffer = 100
+
+// consensusChangeWebsocket is a ConsensusSetSubscriber which buffers the
+// consensus changes for a websocket connection. While the subscriber is
+// catching up, sending a change blocks until the connection accepts it. Once
+// it is caught up, changes are sent without blocking the consensus set and the
+// connection is closed if it can't keep up, since changes can't be skipped.
+type consensusChangeWebsocket struct {
+	changes  chan modules.ConsensusChange
+	closed   chan struct{}
+	overflow chan struct{}
+	live     bool
+	mu       sync.Mutex
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (s *consensusChangeWebsocket) ProcessConsensusChange(cc modules.ConsensusChange) {
+	s.mu.Lock()
+	live := s.live
+	s.mu.Unlock()
+	if !live {
+		select {
+		case s.changes <- cc:
+		case <-s.closed:
+		}
+		return
+	}
+	select {
+	case s.changes <- cc:
+	default:
+		s.mu.Lock()
+		select {
+		case <-s.overflow:
+		default:
+			close(s.overflow)
+		}
+		s.mu.Unlock()
+	}
+}
+
 // RegisterRoutesConsensus is a helper function to register all consensus routes.
 func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet) {
 	router.GET("/consensus", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -123,6 +182,12 @@ func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet)
 	router.GET("/consensus/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusBlocksHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/changes/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusChangesHandler(cs, w, req, ps)
+	})
+	router.GET("/consensus/snapshot", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		consensusSnapshotHandler(cs, w, req, ps)
+	})
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
@@ -306,6 +371,35 @@ func consensusBlocksHandler(cs modules.ConsensusSet, w http.ResponseWriter, req
 	WriteJSON(w, consensusBlocksGetFromBlock(b, h, d))
 }
 
+// consensusSnapshotHandler handles the API calls to the /consensus/snapshot
+// endpoint.
+func consensusSnapshotHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	current := cs.Height()
+	height := current
+	if h := req.FormValue("height"); h != "" {
+		if _, err := fmt.Sscan(h, &height); err != nil {
+			WriteError(w, Error{"failed to parse block height"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if height > current {
+		WriteError(w, Error{fmt.Sprintf("height %v is above the current height %v", height, current)}, http.StatusBadRequest)
+		return
+	}
+	if current-height > modules.ConsensusSnapshotMaxDepth {
+		WriteError(w, Error{fmt.Sprintf("height %v is more than %v blocks below the current height %v", height, modules.ConsensusSnapshotMaxDepth, current)}, http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	err := cs.ExportSnapshot(w, height)
+	if err != nil {
+		// We can't call WriteError here; the client is expecting binary. The
+		// client detects the truncated snapshot when importing it.
+		return
+	}
+}
+
 // consensusValidateTransactionsetHandler handles the API calls to
 // /consensus/validate/transactionset.
 func consensusValidateTransactionsetHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -346,6 +440,85 @@ func consensusSubscribeHandler(cs modules.ConsensusSet, w http.ResponseWriter, r
 	}
 }
 
+// consensusChangesHandler handles websocket connections to
+// /consensus/changes/:id. The consensus changes following the provided change
+// ID are sent to the connection as JSON messages, followed by all new changes
+// until the connection is closed.
+func consensusChangesHandler(cs modules.ConsensusSet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var ccid modules.ConsensusChangeID
+	if err := (*crypto.Hash)(&ccid).LoadString(ps.ByName("id")); err != nil {
+		WriteError(w, Error{"could not decode ID: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		stream := &consensusChangeWebsocket{
+			changes:  make(chan modules.ConsensusChange, consensusChangeStreamBuffer),
+			closed:   make(chan struct{}),
+			overflow: make(chan struct{}),
+		}
+		// the client doesn't send any messages, reading only detects that the
+		// connection was closed
+		go func() {
+			io.Copy(ioutil.Discard, conn)
+			close(stream.closed)
+		}()
+
+		// subscribe in a goroutine, the subscriber blocks until the changes
+		// it is catching up on are sent
+		errCh := make(chan error, 1)
+		go func() {
+			err := cs.ConsensusSetSubscribe(stream, ccid, stream.closed)
+			stream.mu.Lock()
+			stream.live = true
+			stream.mu.Unlock()
+			errCh <- err
+		}()
+		defer func() {
+			conn.Close()
+			if errCh != nil {
+				<-errCh
+			}
+			cs.Unsubscribe(stream)
+		}()
+
+		for {
+			select {
+			case cc := <-stream.changes:
+				if err := websocket.JSON.Send(conn, consensusChangeGETFromChange(cc)); err != nil {
+					return
+				}
+			case err := <-errCh:
+				errCh = nil
+				if err != nil {
+					websocket.JSON.Send(conn, Error{"failed to subscribe to consensus changes: " + err.Error()})
+					return
+				}
+			case <-stream.overflow:
+				return
+			case <-stream.closed:
+				return
+			}
+		}
+	}}.ServeHTTP(w, req)
+}
+
+// consensusChangeGETFromChange converts a modules.ConsensusChange to a
+// ConsensusChangeGET.
+func consensusChangeGETFromChange(cc modules.ConsensusChange) ConsensusChangeGET {
+	return ConsensusChangeGET{
+		ID:                         cc.ID,
+		BlockHeight:                cc.BlockHeight,
+		RevertedBlocks:             cc.RevertedBlocks,
+		AppliedBlocks:              cc.AppliedBlocks,
+		RevertedDiffs:              cc.RevertedDiffs,
+		AppliedDiffs:               cc.AppliedDiffs,
+		ChildTarget:                cc.ChildTarget,
+		MinimumValidChildTimestamp: cc.MinimumValidChildTimestamp,
+		Synced:                     cc.Synced,
+	}
+}
+
 type consensusChangeStreamer struct {
 	e *encoding.Encoder
 }
@@ -1,14 +1,23 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/consensus"
 	"go.sia.tech/siad/types"
+	"golang.org/x/net/websocket"
 )
 
 // TestConsensusGet probes the GET call to /consensus.
@@ -107,6 +116,94 @@ func TestConsensusValidateTransactionSet(t *testing.T) {
 	}
 }
 
+// TestConsensusChanges probes the /consensus/changes websocket endpoint.
+func TestConsensusChanges(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// dial connects to the endpoint, starting after the given change.
+	addr := st.server.listener.Addr().String()
+	dial := func(ccid modules.ConsensusChangeID) *websocket.Conn {
+		config, err := websocket.NewConfig("ws://"+addr+"/consensus/changes/"+ccid.String(), "http://"+addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Header.Set("User-Agent", "Sia-Agent")
+		conn, err := websocket.DialConfig(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	// replay all changes from the beginning
+	conn := dial(modules.ConsensusChangeBeginning)
+	var changes []ConsensusChangeGET
+	for len(changes) == 0 || changes[len(changes)-1].BlockHeight != st.cs.Height() {
+		var cc ConsensusChangeGET
+		if err := websocket.JSON.Receive(conn, &cc); err != nil {
+			t.Fatal(err)
+		}
+		changes = append(changes, cc)
+	}
+	if len(changes[0].AppliedBlocks) != 1 || changes[0].AppliedBlocks[0].ID() != types.GenesisID {
+		t.Fatal("first change should apply the genesis block")
+	}
+
+	// new changes should be streamed
+	b, err := st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cc ConsensusChangeGET
+	if err := websocket.JSON.Receive(conn, &cc); err != nil {
+		t.Fatal(err)
+	}
+	if len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0].ID() != b.ID() || cc.BlockHeight != st.cs.Height() {
+		t.Fatal("unexpected consensus change", cc.BlockHeight)
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// resuming from a change should start with the following change
+	conn = dial(changes[2].ID)
+	if err := websocket.JSON.Receive(conn, &cc); err != nil {
+		t.Fatal(err)
+	}
+	if cc.ID != changes[3].ID {
+		t.Fatal("expected the change following the provided change")
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// unknown changes should return an error
+	var ccid modules.ConsensusChangeID
+	fastrand.Read(ccid[:])
+	conn = dial(ccid)
+	var apiErr Error
+	if err := websocket.JSON.Receive(conn, &apiErr); err != nil {
+		t.Fatal(err)
+	}
+	if apiErr.Message == "" {
+		t.Fatal("expected an error")
+	}
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestIntegrationConsensusSubscribe probes the /consensus/subscribe endpoint.
 func TestIntegrationConsensusSubscribe(t *testing.T) {
 	if testing.Short() {
@@ -168,3 +265,62 @@ func TestIntegrationConsensusSubscribe(t *testing.T) {
 		}
 	}
 }
+
+// TestConsensusSnapshot probes the /consensus/snapshot endpoint.
+func TestConsensusSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Export a snapshot below the current height and import it.
+	height := st.cs.Height() - 1
+	resp, err := HttpGET(fmt.Sprintf("http://%v/consensus/snapshot?height=%v", st.server.listener.Addr(), height))
+	if err != nil {
+		t.Fatal("unable to make an http request", err)
+	}
+	defer func() {
+		err := resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if non2xx(resp.StatusCode) {
+		t.Fatal(decodeError(resp))
+	}
+	snapshot, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksum, err := consensus.SnapshotChecksum(bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	persistDir := filepath.Join(build.TempDir("api", t.Name()+"-import"), modules.ConsensusDir)
+	imported, err := consensus.ImportSnapshot(bytes.NewReader(snapshot), checksum, persistDir, consensus.DatabaseBolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != height {
+		t.Fatalf("expected height %v, got %v", height, imported)
+	}
+
+	// Snapshots above the current height can't be exported.
+	err = st.getAPI(fmt.Sprintf("/consensus/snapshot?height=%v", st.cs.Height()+1), nil)
+	if err == nil {
+		t.Fatal("expected an error when exporting above the current height")
+	}
+
+	// Snapshots too far below the current height can't be exported either.
+	if st.cs.Height() > modules.ConsensusSnapshotMaxDepth {
+		err = st.getAPI(fmt.Sprintf("/consensus/snapshot?height=%v", st.cs.Height()-modules.ConsensusSnapshotMaxDepth-1), nil)
+		if err == nil {
+			t.Fatal("expected an error when exporting too far below the current height")
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// defaultCORSHeaders are the request headers browsers are allowed to send
+	// if the CORS policy doesn't specify any.
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Range", "User-Agent"}
+
+	// defaultCORSMethods are the methods browsers are allowed to use if the
+	// CORS policy doesn't specify any.
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost}
+
+	// corsMaxAge is the duration for which browsers may cache the response to
+	// a preflight request.
+	corsMaxAge = 10 * time.Minute
+)
+
+type (
+	// CORSPolicy describes which cross-origin requests browsers are allowed to
+	// send to the API. An empty policy disables CORS.
+	CORSPolicy struct {
+		// AllowedOrigins are the origins which may access the API, e.g.
+		// "https://example.com". "*" allows all origins.
+		AllowedOrigins []string
+
+		// AllowedMethods and AllowedHeaders are the methods and request
+		// headers which may be used by cross-origin requests. They default to
+		// defaultCORSMethods and defaultCORSHeaders.
+		AllowedMethods []string
+		AllowedHeaders []string
+	}
+
+	// corsOriginKey is the context key which marks requests from an origin
+	// that is explicitly allowed by the CORS policy.
+	corsOriginKey struct{}
+)
+
+// SetCORSPolicy sets the CORS policy of the API. It must be called before the
+// API is served.
+func (api *API) SetCORSPolicy(policy CORSPolicy) {
+	if len(policy.AllowedMethods) == 0 {
+		policy.AllowedMethods = defaultCORSMethods
+	}
+	if len(policy.AllowedHeaders) == 0 {
+		policy.AllowedHeaders = defaultCORSHeaders
+	}
+	api.staticCORSPolicy = policy
+}
+
+// allowOrigin returns whether the origin is allowed by the policy and whether
+// it is allowed explicitly rather than by the "*" wildcard.
+func (p CORSPolicy) allowOrigin(origin string) (allowed bool, explicit bool) {
+	for _, o := range p.AllowedOrigins {
+		if strings.EqualFold(o, origin) {
+			return true, true
+		}
+		if o == "*" {
+			allowed = true
+		}
+	}
+	return allowed, false
+}
+
+// allowMethod returns whether the method is allowed by the policy.
+func (p CORSPolicy) allowMethod(method string) bool {
+	for _, m := range p.AllowedMethods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCORS sets the CORS headers of the response for requests from an
+// allowed origin and answers preflight requests. It returns the request to
+// serve, which is marked if its origin is explicitly allowed, and whether the
+// request was answered already.
+//
+// Requests from explicitly allowed origins don't need to set the required user
+// agent, since browsers don't allow for setting it. The user agent check
+// protects against other websites accessing the API, which the CORS policy
+// takes care of for those origins. Authentication is still required.
+func (api *API) handleCORS(w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	policy := api.staticCORSPolicy
+	origin := req.Header.Get("Origin")
+	if len(policy.AllowedOrigins) == 0 || origin == "" {
+		return req, false
+	}
+	w.Header().Add("Vary", "Origin")
+	allowed, explicit := policy.allowOrigin(origin)
+	if !allowed {
+		return req, false
+	}
+
+	// Answer preflight requests without passing them on to the router, since
+	// browsers don't send the user agent or credentials with them.
+	preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+	if preflight && !policy.allowMethod(req.Header.Get("Access-Control-Request-Method")) {
+		w.WriteHeader(http.StatusForbidden)
+		return req, true
+	}
+
+	if explicit {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		req = req.WithContext(context.WithValue(req.Context(), corsOriginKey{}, origin))
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	}
+	if !preflight {
+		return req, false
+	}
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+	w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+	w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(corsMaxAge.Seconds())))
+	w.WriteHeader(http.StatusNoContent)
+	return req, true
+}
+
+// isCORSOriginAllowed returns whether the request was sent from an origin
+// which is explicitly allowed by the CORS policy.
+func isCORSOriginAllowed(req *http.Request) bool {
+	return req.Context().Value(corsOriginKey{}) != nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestCORS probes the handling of cross-origin requests by the API.
+func TestCORS(t *testing.T) {
+	cfg, err := modules.NewConfig(filepath.Join(build.TempDir("api", t.Name()), modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "password", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	// serve sends a request with the provided origin to the API.
+	serve := func(method, path, origin, userAgent string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Origin", origin)
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+	preflight := http.Header{"Access-Control-Request-Method": []string{http.MethodGet}}
+
+	// Without a policy, cross-origin requests are rejected as before.
+	w := serve(http.MethodOptions, "/daemon/version", "https://example.com", "", preflight)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Code != http.StatusBadRequest {
+		t.Fatal("preflight shouldn't be answered without a policy", w.Code, w.Header())
+	}
+
+	// Allow an origin.
+	api.SetCORSPolicy(CORSPolicy{AllowedOrigins: []string{"https://example.com"}})
+
+	// Preflight requests from the origin are answered.
+	w = serve(http.MethodOptions, "/daemon/version", "https://example.com", "", preflight)
+	if w.Code != http.StatusNoContent {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://example.com" || w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" {
+		t.Fatal("unexpected preflight headers", w.Header())
+	}
+	// Methods which aren't allowed are rejected.
+	w = serve(http.MethodOptions, "/daemon/version", "https://example.com", "", http.Header{"Access-Control-Request-Method": []string{http.MethodDelete}})
+	if w.Code != http.StatusForbidden {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	// Preflight requests from other origins are not answered.
+	w = serve(http.MethodOptions, "/daemon/version", "https://other.com", "", preflight)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" || w.Code == http.StatusNoContent {
+		t.Fatal("preflight from other origin shouldn't be answered", w.Code, w.Header())
+	}
+
+	// Requests from the origin don't need to set the user agent.
+	w = serve(http.MethodGet, "/daemon/version", "https://example.com", "", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "https://example.com" {
+		t.Fatal("request from allowed origin failed", w.Code, w.Header())
+	}
+	// They still need to authenticate.
+	w = serve(http.MethodGet, "/daemon/logs", "https://example.com", "", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	// Requests from other origins need to set the user agent.
+	w = serve(http.MethodGet, "/daemon/version", "https://other.com", "", nil)
+	if w.Code != http.StatusBadRequest || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("request from other origin should fail", w.Code, w.Header())
+	}
+
+	// Allow all origins. Requests still need to set the user agent.
+	api.SetCORSPolicy(CORSPolicy{AllowedOrigins: []string{"*"}})
+	w = serve(http.MethodGet, "/daemon/version", "https://other.com", "", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatal("request without user agent should fail", w.Code)
+	}
+	w = serve(http.MethodGet, "/daemon/version", "https://other.com", "Sia-Agent", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("request from any origin failed", w.Code, w.Header())
+	}
+}
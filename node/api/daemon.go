@@ -13,7 +13,9 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/inconshreveable/go-update"
 
@@ -25,11 +27,16 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/types"
 )
 
 const (
+	// daemonLogsDefaultLimit is the number of log entries returned by
+	// /daemon/logs if no limit is provided.
+	daemonLogsDefaultLimit = 100
+
 	// The developer key is used to sign updates and other important Sia-
 	// related information.
 	developerKey = `-----BEGIN PGP PUBLIC KEY BLOCK-----
@@ -72,6 +79,10 @@ type (
 		ErrorAlerts    []modules.Alert `json:"erroralerts"`
 		WarningAlerts  []modules.Alert `json:"warningalerts"`
 		InfoAlerts     []modules.Alert `json:"infoalerts"`
+
+		// Silences contains the silenced alerts with the time their silence
+		// expires.
+		Silences map[modules.AlertID]time.Time `json:"silences"`
 	}
 
 	// DaemonVersionGet contains information about the running daemon's version.
@@ -127,6 +138,19 @@ type (
 		SiacoinPrecision types.Currency `json:"siacoinprecision"`
 	}
 
+	// DaemonLogsGet contains the recent log entries of the daemon, oldest
+	// first.
+	DaemonLogsGet struct {
+		Entries []persist.LogEntry `json:"entries"`
+	}
+
+	// DaemonLogLevelsGet contains the default log level and the levels of the
+	// modules that have logged or have a level set.
+	DaemonLogLevelsGet struct {
+		DefaultLevel persist.LogLevel            `json:"defaultlevel"`
+		Levels       map[string]persist.LogLevel `json:"levels"`
+	}
+
 	// DaemonStackGet contains information about the daemon's stack.
 	DaemonStackGet struct {
 		Stack string `json:"stack"`
@@ -300,67 +324,173 @@ func updateToRelease(version string) (err error) {
 	return nil
 }
 
-// daemonAlertsHandlerGET handles the API call that returns the alerts of all
-// loaded modules.
-func (api *API) daemonAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	// initialize slices to avoid "null" in response.
-	crit := make([]modules.Alert, 0, 6)
-	err := make([]modules.Alert, 0, 6)
-	warn := make([]modules.Alert, 0, 6)
-	info := make([]modules.Alert, 0, 6)
+// alerts returns the alerts of the node and all loaded modules.
+func (api *API) alerts() (crit, err, warn, info []modules.Alert) {
+	alerters := []modules.Alerter{api.staticAlerts.staticAlerter}
+	if api.nodeAlerter != nil {
+		alerters = append(alerters, api.nodeAlerter)
+	}
 	if api.gateway != nil {
-		c, e, w, i := api.gateway.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-		info = append(info, i...)
+		alerters = append(alerters, api.gateway)
 	}
 	if api.cs != nil {
-		c, e, w, i := api.cs.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-		info = append(info, i...)
+		alerters = append(alerters, api.cs)
 	}
 	if api.tpool != nil {
-		c, e, w, i := api.tpool.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-		info = append(info, i...)
+		alerters = append(alerters, api.tpool)
 	}
 	if api.wallet != nil {
-		c, e, w, i := api.wallet.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-		info = append(info, i...)
+		alerters = append(alerters, api.wallet)
 	}
 	if api.renter != nil {
-		c, e, w, i := api.renter.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-		info = append(info, i...)
+		alerters = append(alerters, api.renter)
 	}
 	if api.host != nil {
-		c, e, w, i := api.host.Alerts()
+		alerters = append(alerters, api.host)
+	}
+	for _, a := range alerters {
+		c, e, w, i := a.Alerts()
 		crit = append(crit, c...)
 		err = append(err, e...)
 		warn = append(warn, w...)
 		info = append(info, i...)
 	}
+	return
+}
+
+// daemonAlertsHandlerGET handles the API call that returns the alerts of all
+// loaded modules.
+func (api *API) daemonAlertsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var unacknowledged bool
+	if u := req.FormValue("unacknowledged"); u != "" {
+		var err error
+		unacknowledged, err = strconv.ParseBool(u)
+		if err != nil {
+			WriteError(w, Error{"unable to parse unacknowledged: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, api.daemonAlerts(unacknowledged))
+}
+
+// daemonAlerts returns the alerts of the node and all loaded modules. Alerts
+// which were acknowledged or silenced are omitted if unacknowledged is set.
+func (api *API) daemonAlerts(unacknowledged bool) DaemonAlertsGet {
+	// filter annotates the alerts and removes the handled ones if requested.
+	// It initializes the slices to avoid "null" in response.
+	filter := func(alerts []modules.Alert) []modules.Alert {
+		api.staticAlerts.annotate(alerts)
+		filtered := make([]modules.Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			if !unacknowledged || (!alert.Acknowledged && !alert.Silenced) {
+				filtered = append(filtered, alert)
+			}
+		}
+		return filtered
+	}
+	c, e, wa, i := api.alerts()
+	crit, err, warn, info := filter(c), filter(e), filter(wa), filter(i)
 	// Sort alerts by severity. Critical first, then Error and finally Warning.
-	alerts := append(append(crit, append(err, warn...)...), info...)
-	WriteJSON(w, DaemonAlertsGet{
+	alerts := append(append(append(append([]modules.Alert{}, crit...), err...), warn...), info...)
+	return DaemonAlertsGet{
 		Alerts:         alerts,
 		CriticalAlerts: crit,
 		ErrorAlerts:    err,
 		WarningAlerts:  warn,
 		InfoAlerts:     info,
+		Silences:       api.staticAlerts.silences(),
+	}
+}
+
+// daemonAlertsAcknowledgeHandlerPOST handles the API call that acknowledges
+// an alert.
+func (api *API) daemonAlertsAcknowledgeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := modules.AlertID(req.FormValue("id"))
+	crit, err, warn, info := api.alerts()
+	registered := false
+	for _, alert := range append(append(append(crit, err...), warn...), info...) {
+		registered = registered || alert.ID == id
+	}
+	if !registered {
+		WriteError(w, Error{errors.AddContext(errUnknownAlert, string(id)).Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.staticAlerts.Acknowledge(id); err != nil {
+		WriteError(w, Error{"unable to acknowledge alert: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// daemonAlertsSilenceHandlerPOST handles the API call that silences an alert.
+func (api *API) daemonAlertsSilenceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := modules.AlertID(req.FormValue("id"))
+	if id == "" {
+		WriteError(w, Error{"id is required"}, http.StatusBadRequest)
+		return
+	}
+	d, err := time.ParseDuration(req.FormValue("duration"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse duration: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.staticAlerts.Silence(id, d); err != nil {
+		WriteError(w, Error{"unable to silence alert: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// daemonLogsHandlerGET handles the API call that returns the recent log
+// entries of the daemon, optionally filtered by module and minimum level.
+func (api *API) daemonLogsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	level := persist.LogLevelDebug
+	if l := req.FormValue("level"); l != "" {
+		var err error
+		level, err = persist.ParseLogLevel(l)
+		if err != nil {
+			WriteError(w, Error{"unable to parse level: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := daemonLogsDefaultLimit
+	if l := req.FormValue("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			WriteError(w, Error{"limit must be a positive integer"}, http.StatusBadRequest)
+			return
+		}
+	}
+	entries := persist.RecentLogEntries(req.FormValue("module"), level, limit)
+	if entries == nil {
+		entries = make([]persist.LogEntry, 0)
+	}
+	WriteJSON(w, DaemonLogsGet{Entries: entries})
+}
+
+// daemonLogLevelsHandlerGET handles the API call that returns the log levels
+// of the daemon.
+func (api *API) daemonLogLevelsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	defaultLevel, levels := persist.LogLevels()
+	WriteJSON(w, DaemonLogLevelsGet{
+		DefaultLevel: defaultLevel,
+		Levels:       levels,
 	})
 }
 
+// daemonLogLevelsHandlerPOST handles the API call that sets the log level of
+// a module, or the default level if no module is provided.
+func (api *API) daemonLogLevelsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	level, err := persist.ParseLogLevel(req.FormValue("level"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse level: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	persist.SetLogLevel(req.FormValue("module"), level)
+	WriteSuccess(w)
+}
+
 // daemonUpdateHandlerGET handles the API call that checks for an update.
 func (api *API) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	version, err := fetchLatestVersion()
@@ -493,10 +623,34 @@ func (api *API) daemonVersionHandler(w http.ResponseWriter, _ *http.Request, _ h
 	WriteJSON(w, DaemonVersion{Version: build.NodeVersion, GitRevision: build.GitRevision, BuildTime: build.BuildTime})
 }
 
-// daemonStopHandler handles the API call to stop the daemon cleanly.
-func (api *API) daemonStopHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	// can't write after we stop the server, so lie a bit.
-	WriteSuccess(w)
+// daemonStopHandler handles the API call to stop the daemon cleanly. In drain
+// mode, the operations in progress are completed before stopping.
+func (api *API) daemonStopHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var drain bool
+	if d := req.FormValue("drain"); d != "" {
+		var err error
+		drain, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse drain: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	timeout := drainDefaultTimeout
+	if t := req.FormValue("timeout"); t != "" {
+		seconds, err := strconv.ParseUint(t, 10, 32)
+		if err != nil {
+			WriteError(w, Error{"unable to parse timeout: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		timeout = time.Duration(seconds) * time.Second
+	}
+
+	if drain {
+		api.managedDrain(w, timeout)
+	} else {
+		// can't write after we stop the server, so lie a bit.
+		WriteSuccess(w)
+	}
 
 	// Shutdown in a separate goroutine to prevent a deadlock.
 	go func() {
@@ -513,7 +667,7 @@ func (api *API) daemonSettingsHandlerGET(w http.ResponseWriter, _ *http.Request,
 	WriteJSON(w, DaemonSettingsGet{
 		MaxDownloadSpeed: gmds,
 		MaxUploadSpeed:   gmus,
-		Modules:          api.staticConfigModules,
+		Modules:          api.loadedConfigModules,
 	})
 }
 
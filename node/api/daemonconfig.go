@@ -0,0 +1,212 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+var (
+	// errNoConfigFile is returned when reloading the config file of a daemon
+	// that was started without one.
+	errNoConfigFile = errors.New("siad was started without a config file")
+)
+
+type (
+	// DaemonConfigGet contains the path of the config file and the effective
+	// configuration of the daemon.
+	DaemonConfigGet struct {
+		Path   string             `json:"path"`
+		Config modules.ConfigFile `json:"config"`
+	}
+
+	// DaemonConfigReloadPost contains the daemon settings of the reloaded
+	// config file that differ from the running daemon and only take effect
+	// after a restart.
+	DaemonConfigReloadPost struct {
+		RestartRequired []string `json:"restartrequired"`
+	}
+)
+
+// SetConfigFile sets the path of the config file and the effective values of
+// the flags of siad. It must be called before the API is served.
+func (api *API) SetConfigFile(path string, flags map[string]string) {
+	api.staticConfigFile = path
+	api.staticConfigFlags = flags
+}
+
+// ReloadConfigFile loads the config file and applies its dynamic settings to
+// the loaded modules. It returns the daemon settings that require a restart to
+// take effect.
+func (api *API) ReloadConfigFile() ([]string, error) {
+	if api.staticConfigFile == "" {
+		return nil, errNoConfigFile
+	}
+	cf, err := modules.LoadConfigFile(api.staticConfigFile)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load config file")
+	}
+	var restartRequired []string
+	for name, value := range cf.Daemon {
+		current, ok := api.staticConfigFlags[name]
+		if !ok {
+			return nil, errors.Compose(modules.ErrInvalidConfigFile, errors.New("unknown daemon setting "+name))
+		}
+		if value != current {
+			restartRequired = append(restartRequired, name)
+		}
+	}
+	sort.Strings(restartRequired)
+	return restartRequired, api.applyConfigFile(cf)
+}
+
+// applyConfigFile applies the dynamic settings of a config file. Sections of
+// modules that are not loaded are ignored.
+func (api *API) applyConfigFile(cf modules.ConfigFile) error {
+	// Set the log levels.
+	if cf.Log.Level != "" {
+		level, err := persist.ParseLogLevel(cf.Log.Level)
+		if err != nil {
+			return err
+		}
+		persist.SetLogLevel("", level)
+	}
+	for module, l := range cf.Log.Modules {
+		level, err := persist.ParseLogLevel(l)
+		if err != nil {
+			return err
+		}
+		persist.SetLogLevel(module, level)
+	}
+
+	// Set the global ratelimit.
+	if cf.Ratelimit != (modules.ConfigFileRatelimit{}) {
+		readBPS, writeBPS, _ := modules.GlobalRateLimits.Limits()
+		if cf.Ratelimit.MaxDownloadSpeed != nil {
+			readBPS = *cf.Ratelimit.MaxDownloadSpeed
+		}
+		if cf.Ratelimit.MaxUploadSpeed != nil {
+			writeBPS = *cf.Ratelimit.MaxUploadSpeed
+		}
+		if err := api.siadConfig.SetRatelimit(readBPS, writeBPS); err != nil {
+			return errors.AddContext(err, "unable to set ratelimit")
+		}
+	}
+
+	// Replace the notification settings.
+	if cf.Alerts != nil {
+		if err := api.staticAlerts.SetNotifications(*cf.Alerts); err != nil {
+			return errors.AddContext(err, "unable to set alert notifications")
+		}
+	}
+
+	// Update the settings of the host and renter.
+	if api.host != nil && cf.Host != (modules.ConfigFileHost{}) {
+		his, err := cf.Host.Apply(api.host.InternalSettings())
+		if err != nil {
+			return errors.AddContext(err, "unable to apply host settings")
+		}
+		if err := api.host.SetInternalSettings(his); err != nil {
+			return errors.AddContext(err, "unable to set host settings")
+		}
+	}
+	if api.renter != nil && cf.Renter != (modules.ConfigFileRenter{}) {
+		rs, err := api.renter.Settings()
+		if err != nil {
+			return errors.AddContext(err, "unable to get renter settings")
+		}
+		if err := api.renter.SetSettings(cf.Renter.Apply(rs)); err != nil {
+			return errors.AddContext(err, "unable to set renter settings")
+		}
+	}
+	return nil
+}
+
+// effectiveConfig returns the configuration the daemon is currently running
+// with.
+func (api *API) effectiveConfig() (modules.ConfigFile, error) {
+	cf := modules.ConfigFile{
+		Daemon: make(map[string]string),
+	}
+	for name, value := range api.staticConfigFlags {
+		cf.Daemon[name] = value
+	}
+	defaultLevel, levels := persist.LogLevels()
+	cf.Log.Level = defaultLevel.String()
+	cf.Log.Modules = make(map[string]string)
+	for module, level := range levels {
+		cf.Log.Modules[module] = level.String()
+	}
+
+	readBPS, writeBPS, _ := modules.GlobalRateLimits.Limits()
+	cf.Ratelimit.MaxDownloadSpeed = &readBPS
+	cf.Ratelimit.MaxUploadSpeed = &writeBPS
+
+	// The SMTP password is not returned.
+	notifications := api.staticAlerts.Notifications()
+	notifications.Email.Password = ""
+	cf.Alerts = &notifications
+
+	if api.host != nil {
+		his := api.host.InternalSettings()
+		cf.Host = modules.ConfigFileHost{
+			AcceptingContracts: &his.AcceptingContracts,
+			MaxDuration:        &his.MaxDuration,
+			WindowSize:         &his.WindowSize,
+
+			Collateral:       his.Collateral.String() + "H",
+			CollateralBudget: his.CollateralBudget.String() + "H",
+			MaxCollateral:    his.MaxCollateral.String() + "H",
+
+			MinContractPrice:          his.MinContractPrice.String() + "H",
+			MinDownloadBandwidthPrice: his.MinDownloadBandwidthPrice.String() + "H",
+			MinStoragePrice:           his.MinStoragePrice.String() + "H",
+			MinUploadBandwidthPrice:   his.MinUploadBandwidthPrice.String() + "H",
+		}
+	}
+	if api.renter != nil {
+		rs, err := api.renter.Settings()
+		if err != nil {
+			return modules.ConfigFile{}, errors.AddContext(err, "unable to get renter settings")
+		}
+		cf.Renter = modules.ConfigFileRenter{
+			IPViolationCheck: &rs.IPViolationCheck,
+			MaxDownloadSpeed: &rs.MaxDownloadSpeed,
+			MaxUploadSpeed:   &rs.MaxUploadSpeed,
+		}
+	}
+	return cf, nil
+}
+
+// daemonConfigHandlerGET handles the API call that returns the effective
+// configuration of the daemon without secrets.
+func (api *API) daemonConfigHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	cf, err := api.redactedConfig()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, DaemonConfigGet{
+		Path:   api.staticConfigFile,
+		Config: cf,
+	})
+}
+
+// daemonConfigReloadHandlerPOST handles the API call that reloads the config
+// file.
+func (api *API) daemonConfigReloadHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	restartRequired, err := api.ReloadConfigFile()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if restartRequired == nil {
+		restartRequired = make([]string, 0)
+	}
+	WriteJSON(w, DaemonConfigReloadPost{RestartRequired: restartRequired})
+}
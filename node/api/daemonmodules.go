@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errNoModuleManager is returned when starting or stopping modules of an
+	// API that can't manage its modules.
+	errNoModuleManager = errors.New("modules can't be started or stopped at runtime")
+)
+
+// ModuleManager starts and stops the modules of a running node.
+type ModuleManager interface {
+	StartModule(module string) error
+	StopModule(module string) error
+}
+
+// SetModuleManager sets the manager that starts and stops modules at runtime.
+func (api *API) SetModuleManager(mm ModuleManager) {
+	api.routerMu.Lock()
+	defer api.routerMu.Unlock()
+	api.moduleManager = mm
+}
+
+// ReplaceModules replaces the modules of the API after a module was started or
+// stopped at runtime. It waits for the calls in progress to finish.
+func (api *API) ReplaceModules(acc modules.Accounting, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) {
+	api.routerMu.Lock()
+	defer api.routerMu.Unlock()
+	api.setModules(acc, cs, e, g, h, m, r, tp, w)
+	api.router = api.newRouter()
+}
+
+// newModulesRouter returns the router that serves the calls that start and
+// stop modules.
+func (api *API) newModulesRouter() http.Handler {
+	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(api.UnrecognizedCallHandler)
+	router.RedirectTrailingSlash = false
+	router.POST("/daemon/modules/start", RequirePassword(api.daemonModulesStartHandlerPOST, api.requiredPassword))
+	router.POST("/daemon/modules/stop", RequirePassword(api.daemonModulesStopHandlerPOST, api.requiredPassword))
+	return timeoutHandler(RequireUserAgent(router, api.requiredUserAgent), httpServerTimeout)
+}
+
+// managedModuleManager returns the module manager of the API.
+func (api *API) managedModuleManager() ModuleManager {
+	api.routerMu.RLock()
+	defer api.routerMu.RUnlock()
+	return api.moduleManager
+}
+
+// daemonModulesStartHandlerPOST handles the API call that starts a module.
+func (api *API) daemonModulesStartHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	mm := api.managedModuleManager()
+	if mm == nil {
+		WriteError(w, Error{errNoModuleManager.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := mm.StartModule(req.FormValue("module")); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// daemonModulesStopHandlerPOST handles the API call that stops a module.
+func (api *API) daemonModulesStopHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	mm := api.managedModuleManager()
+	if mm == nil {
+		WriteError(w, Error{errNoModuleManager.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := mm.StopModule(req.FormValue("module")); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
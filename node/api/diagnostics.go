@@ -0,0 +1,230 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// diagnosticsMaxCPUProfile is the maximum duration of the CPU profile
+	// which can be included in a diagnostics bundle.
+	diagnosticsMaxCPUProfile = time.Minute
+)
+
+type (
+	// DiagnosticsInfo contains information about the daemon and its
+	// environment. It is included in diagnostics bundles.
+	DiagnosticsInfo struct {
+		Version     string    `json:"version"`
+		GitRevision string    `json:"gitrevision"`
+		BuildTime   string    `json:"buildtime"`
+		GoVersion   string    `json:"goversion"`
+		OS          string    `json:"os"`
+		Arch        string    `json:"arch"`
+		NumCPU      int       `json:"numcpu"`
+		Goroutines  int       `json:"goroutines"`
+		StartTime   time.Time `json:"starttime"`
+		Time        time.Time `json:"time"`
+	}
+
+	// diagnosticsFile is a file of a diagnostics bundle.
+	diagnosticsFile struct {
+		name  string
+		write func(*bytes.Buffer) error
+	}
+)
+
+// writeDiagnosticsJSON returns a function which writes an object as indented
+// JSON.
+func writeDiagnosticsJSON(obj interface{}) func(*bytes.Buffer) error {
+	return func(buf *bytes.Buffer) error {
+		enc := json.NewEncoder(buf)
+		enc.SetIndent("", "  ")
+		return enc.Encode(obj)
+	}
+}
+
+// writeDiagnosticsProfile returns a function which writes a runtime profile.
+func writeDiagnosticsProfile(name string, debug int) func(*bytes.Buffer) error {
+	return func(buf *bytes.Buffer) error {
+		p := pprof.Lookup(name)
+		if p == nil {
+			return fmt.Errorf("unknown profile %v", name)
+		}
+		return p.WriteTo(buf, debug)
+	}
+}
+
+// redactedConfig returns the effective config of the daemon without secrets.
+// Webhooks are redacted since their URLs commonly contain tokens.
+func (api *API) redactedConfig() (modules.ConfigFile, error) {
+	cf, err := api.effectiveConfig()
+	if err != nil {
+		return modules.ConfigFile{}, err
+	}
+	for name := range cf.Daemon {
+		if isSecretName(name) {
+			cf.Daemon[name] = auditRedacted
+		}
+	}
+	if cf.Alerts != nil {
+		// The webhooks are shared with the alert dispatcher and need to be
+		// copied before redacting them.
+		webhooks := make([]string, len(cf.Alerts.Webhooks))
+		for i, webhook := range cf.Alerts.Webhooks {
+			u, err := url.Parse(webhook)
+			if err != nil {
+				webhooks[i] = auditRedacted
+				continue
+			}
+			webhooks[i] = u.Scheme + "://" + u.Host + "/" + auditRedacted
+		}
+		cf.Alerts.Webhooks = webhooks
+		if cf.Alerts.Email.Username != "" {
+			cf.Alerts.Email.Username = auditRedacted
+		}
+	}
+	return cf, nil
+}
+
+// diagnosticsFiles returns the files of a diagnostics bundle. Files of modules
+// that aren't loaded are omitted.
+func (api *API) diagnosticsFiles() []diagnosticsFile {
+	files := []diagnosticsFile{
+		{"info.json", writeDiagnosticsJSON(DiagnosticsInfo{
+			Version:     build.NodeVersion,
+			GitRevision: build.GitRevision,
+			BuildTime:   build.BuildTime,
+			GoVersion:   runtime.Version(),
+			OS:          runtime.GOOS,
+			Arch:        runtime.GOARCH,
+			NumCPU:      runtime.NumCPU(),
+			Goroutines:  runtime.NumGoroutine(),
+			StartTime:   api.staticStartTime,
+			Time:        time.Now(),
+		})},
+		{"logs.json", writeDiagnosticsJSON(DaemonLogsGet{
+			Entries: persist.RecentLogEntries("", persist.LogLevelDebug, math.MaxInt32),
+		})},
+		{"goroutines.txt", writeDiagnosticsProfile("goroutine", 2)},
+		{"heap.pprof", writeDiagnosticsProfile("heap", 0)},
+		{"config.json", func(buf *bytes.Buffer) error {
+			cf, err := api.redactedConfig()
+			if err != nil {
+				return err
+			}
+			return writeDiagnosticsJSON(DaemonConfigGet{Path: api.staticConfigFile, Config: cf})(buf)
+		}},
+		{"alerts.json", writeDiagnosticsJSON(api.daemonAlerts(false))},
+		{"metrics.txt", func(buf *bytes.Buffer) error {
+			_, err := buf.Write(api.metrics())
+			return err
+		}},
+	}
+	if api.staticAuditLog != nil {
+		files = append(files, diagnosticsFile{"audit.json", func(buf *bytes.Buffer) error {
+			entries, err := api.staticAuditLog.Entries(time.Unix(0, 0), time.Now(), "", auditDefaultLimit)
+			if err != nil {
+				return err
+			}
+			return writeDiagnosticsJSON(DaemonAuditGet{Entries: entries})(buf)
+		}})
+	}
+	return files
+}
+
+// writeDiagnosticsBundle writes a zip archive with the diagnostics of the
+// daemon. Files that can't be gathered are replaced by a file containing the
+// error, so that a partial bundle is still useful.
+func (api *API) writeDiagnosticsBundle(buf *bytes.Buffer, cpuProfile []byte) error {
+	zw := zip.NewWriter(buf)
+	files := api.diagnosticsFiles()
+	if cpuProfile != nil {
+		files = append(files, diagnosticsFile{"cpu.pprof", func(buf *bytes.Buffer) error {
+			_, err := buf.Write(cpuProfile)
+			return err
+		}})
+	}
+	for _, f := range files {
+		var content bytes.Buffer
+		name := f.name
+		if err := f.write(&content); err != nil {
+			name += ".error"
+			content.Reset()
+			content.WriteString(err.Error())
+		}
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(content.Bytes()); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// daemonDiagnosticsHandlerGET handles the API call that gathers a diagnostics
+// bundle.
+func (api *API) daemonDiagnosticsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var cpuDuration time.Duration
+	if s := req.FormValue("cpuprofile"); s != "" {
+		seconds, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse cpuprofile: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		cpuDuration = time.Duration(seconds) * time.Second
+		if cpuDuration > diagnosticsMaxCPUProfile {
+			WriteError(w, Error{fmt.Sprintf("cpuprofile can't exceed %v seconds", diagnosticsMaxCPUProfile.Seconds())}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Record the CPU profile first so that the other files reflect the state
+	// at the end of the profile.
+	var cpuProfile []byte
+	if cpuDuration > 0 {
+		var buf bytes.Buffer
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			WriteError(w, Error{"unable to start CPU profile: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		select {
+		case <-time.After(cpuDuration):
+		case <-req.Context().Done():
+		}
+		pprof.StopCPUProfile()
+		cpuProfile = buf.Bytes()
+	}
+
+	var bundle bytes.Buffer
+	if err := api.writeDiagnosticsBundle(&bundle, cpuProfile); err != nil {
+		WriteError(w, Error{errors.AddContext(err, "unable to create diagnostics bundle").Error()}, http.StatusInternalServerError)
+		return
+	}
+	filename := fmt.Sprintf("siad-diagnostics-%v.zip", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Write(bundle.Bytes())
+}
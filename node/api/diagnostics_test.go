@@ -0,0 +1,110 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestDiagnosticsBundle probes the contents of the diagnostics bundle.
+func TestDiagnosticsBundle(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	cfg, err := modules.NewConfig(filepath.Join(build.TempDir("api", t.Name()), modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	api.SetConfigFile("", map[string]string{"api-addr": "localhost:9980", "temp-password": "true"})
+	webhook := "https://example.com/hooks/token"
+	err = api.staticAlerts.SetNotifications(modules.ConfigFileAlerts{
+		Webhooks: []string{webhook},
+		Email:    modules.ConfigFileEmail{Username: "user", Password: "password"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/daemon/diagnostics", nil)
+	req.Header.Set("User-Agent", "Sia-Agent")
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatal("wrong content type", w.Header().Get("Content-Type"))
+	}
+
+	// Read the files of the bundle.
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name], err = ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Close()
+	}
+	for _, name := range []string{"info.json", "logs.json", "goroutines.txt", "heap.pprof", "config.json", "alerts.json", "metrics.txt"} {
+		if len(files[name]) == 0 {
+			t.Fatal("missing file", name, len(files))
+		}
+	}
+	if _, exists := files["audit.json"]; exists {
+		t.Fatal("audit log shouldn't be included if it's disabled")
+	}
+	if !strings.Contains(string(files["goroutines.txt"]), "goroutine") {
+		t.Fatal("goroutine profile is missing the stacks")
+	}
+
+	// Secrets are redacted from the config.
+	var dcg DaemonConfigGet
+	if err := json.Unmarshal(files["config.json"], &dcg); err != nil {
+		t.Fatal(err)
+	}
+	if dcg.Config.Daemon["api-addr"] != "localhost:9980" || dcg.Config.Daemon["temp-password"] != auditRedacted {
+		t.Fatal("wrong daemon settings", dcg.Config.Daemon)
+	}
+	alerts := dcg.Config.Alerts
+	if alerts == nil || len(alerts.Webhooks) != 1 || strings.Contains(alerts.Webhooks[0], "token") {
+		t.Fatal("webhook wasn't redacted", alerts)
+	}
+	if alerts.Email.Username != auditRedacted || alerts.Email.Password != "" {
+		t.Fatal("email credentials weren't redacted", alerts.Email)
+	}
+	if api.staticAlerts.Notifications().Webhooks[0] != webhook {
+		t.Fatal("redacting the bundle modified the webhooks")
+	}
+
+	// /daemon/config is redacted as well.
+	req = httptest.NewRequest(http.MethodGet, "/daemon/config", nil)
+	req.Header.Set("User-Agent", "Sia-Agent")
+	w = httptest.NewRecorder()
+	api.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "token") || strings.Contains(w.Body.String(), `"user"`) {
+		t.Fatal("config wasn't redacted", w.Body.String())
+	}
+}
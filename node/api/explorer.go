@@ -3,8 +3,10 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -12,6 +14,16 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// explorerDefaultPageSize is the number of transactions returned by
+	// /explorer/addresses if no limit is provided.
+	explorerDefaultPageSize = 100
+
+	// explorerMaxPageSize is the maximum number of transactions returned by
+	// /explorer/addresses.
+	explorerMaxPageSize = 1000
+)
+
 type (
 	// ExplorerBlock is a block with some extra information such as the id and
 	// height. This information is provided for programs that may not be
@@ -74,6 +86,46 @@ type (
 		Transaction  ExplorerTransaction   `json:"transaction"`
 		Transactions []ExplorerTransaction `json:"transactions"`
 	}
+
+	// ExplorerAddressGET is the object returned by a GET request to
+	// /explorer/addresses/:address. TransactionIDs contains the requested page
+	// of transaction ids, newest first, and Blocks and Transactions contain
+	// the corresponding blocks and transactions. Balance is only set if the
+	// output index of the explorer is enabled.
+	ExplorerAddressGET struct {
+		Balance           *modules.ExplorerBalance `json:"balance,omitempty"`
+		TotalTransactions uint64                   `json:"totaltransactions"`
+		TransactionIDs    []types.TransactionID    `json:"transactionids"`
+		Blocks            []ExplorerBlock          `json:"blocks"`
+		Transactions      []ExplorerTransaction    `json:"transactions"`
+	}
+
+	// ExplorerFileContractGET is the object returned by a GET request to
+	// /explorer/filecontracts/:id.
+	ExplorerFileContractGET struct {
+		FileContract          types.FileContract           `json:"filecontract"`
+		Revisions             []types.FileContractRevision `json:"revisions"`
+		StorageProofSubmitted bool                         `json:"storageproofsubmitted"`
+		TransactionIDs        []types.TransactionID        `json:"transactionids"`
+	}
+
+	// ExplorerSiacoinOutputGET is the object returned by a GET request to
+	// /explorer/siacoinoutputs/:id. Spent is only set if the output index of
+	// the explorer is enabled.
+	ExplorerSiacoinOutputGET struct {
+		SiacoinOutput  types.SiacoinOutput   `json:"siacoinoutput"`
+		Spent          *bool                 `json:"spent,omitempty"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// ExplorerSiafundOutputGET is the object returned by a GET request to
+	// /explorer/siafundoutputs/:id. Spent is only set if the output index of
+	// the explorer is enabled.
+	ExplorerSiafundOutputGET struct {
+		SiafundOutput  types.SiafundOutput   `json:"siafundoutput"`
+		Spent          *bool                 `json:"spent,omitempty"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
 )
 
 // RegisterRoutesExplorer is a helper function to register all explorer routes.
@@ -81,12 +133,24 @@ func RegisterRoutesExplorer(router *httprouter.Router, e modules.Explorer, cs mo
 	router.GET("/explorer", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHandler(e, w, req, ps)
 	})
+	router.GET("/explorer/addresses/:address", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerAddressHandler(e, w, req, ps)
+	})
 	router.GET("/explorer/blocks/:height", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerBlocksHandler(e, cs, w, req, ps)
 	})
+	router.GET("/explorer/filecontracts/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerFileContractHandler(e, w, req, ps)
+	})
 	router.GET("/explorer/hashes/:hash", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		explorerHashHandler(e, w, req, ps)
 	})
+	router.GET("/explorer/siacoinoutputs/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerSiacoinOutputHandler(e, w, req, ps)
+	})
+	router.GET("/explorer/siafundoutputs/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		explorerSiafundOutputHandler(e, w, req, ps)
+	})
 }
 
 // buildExplorerTransaction takes a transaction and the height + id of the
@@ -210,6 +274,130 @@ func buildExplorerBlock(explorer modules.Explorer, height types.BlockHeight, blo
 	}
 }
 
+// explorerAddressHandler handles API calls to /explorer/addresses/:address.
+func explorerAddressHandler(explorer modules.Explorer, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("address"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// The empty unlock hash appears in too many transactions to look it up.
+	if addr == (types.UnlockHash{}) {
+		WriteError(w, Error{"can't lookup the empty unlock hash"}, http.StatusBadRequest)
+		return
+	}
+	var offset uint64
+	if str := req.FormValue("offset"); str != "" {
+		offset, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := uint64(explorerDefaultPageSize)
+	if str := req.FormValue("limit"); str != "" {
+		limit, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if limit == 0 || limit > explorerMaxPageSize {
+			WriteError(w, Error{fmt.Sprintf("limit must be between 1 and %v", explorerMaxPageSize)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	var eag ExplorerAddressGET
+	balance, err := explorer.UnlockHashBalance(addr)
+	if err == nil {
+		eag.Balance = &balance
+	} else if !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		WriteError(w, Error{"unable to get balance: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	eag.TransactionIDs, eag.TotalTransactions = explorer.UnlockHashTransactions(addr, offset, limit)
+	eag.Transactions, eag.Blocks = buildTransactionSet(explorer, eag.TransactionIDs)
+	WriteJSON(w, eag)
+}
+
+// explorerFileContractHandler handles API calls to
+// /explorer/filecontracts/:id.
+func explorerFileContractHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.FileContractID(hash)
+	fc, revisions, exists, proofExists := explorer.FileContractHistory(id)
+	if !exists {
+		WriteError(w, Error{"file contract not found"}, http.StatusNotFound)
+		return
+	}
+	WriteJSON(w, ExplorerFileContractGET{
+		FileContract:          fc,
+		Revisions:             revisions,
+		StorageProofSubmitted: proofExists,
+		TransactionIDs:        explorer.FileContractID(id),
+	})
+}
+
+// explorerSiacoinOutputHandler handles API calls to
+// /explorer/siacoinoutputs/:id.
+func explorerSiacoinOutputHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiacoinOutputID(hash)
+	sco, exists := explorer.SiacoinOutput(id)
+	if !exists {
+		WriteError(w, Error{"siacoin output not found"}, http.StatusNotFound)
+		return
+	}
+	esog := ExplorerSiacoinOutputGET{
+		SiacoinOutput:  sco,
+		TransactionIDs: explorer.SiacoinOutputID(id),
+	}
+	spent, err := explorer.SiacoinOutputSpent(id)
+	if err == nil {
+		esog.Spent = &spent
+	} else if !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		WriteError(w, Error{"unable to check whether the output was spent: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, esog)
+}
+
+// explorerSiafundOutputHandler handles API calls to
+// /explorer/siafundoutputs/:id.
+func explorerSiafundOutputHandler(explorer modules.Explorer, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	id := types.SiafundOutputID(hash)
+	sfo, exists := explorer.SiafundOutput(id)
+	if !exists {
+		WriteError(w, Error{"siafund output not found"}, http.StatusNotFound)
+		return
+	}
+	esog := ExplorerSiafundOutputGET{
+		SiafundOutput:  sfo,
+		TransactionIDs: explorer.SiafundOutputID(id),
+	}
+	spent, err := explorer.SiafundOutputSpent(id)
+	if err == nil {
+		esog.Spent = &spent
+	} else if !errors.Contains(err, modules.ErrExplorerIndexDisabled) {
+		WriteError(w, Error{"unable to check whether the output was spent: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, esog)
+}
+
 // explorerHandler handles API calls to /explorer/blocks/:height.
 func explorerBlocksHandler(e modules.Explorer, cs modules.ConsensusSet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Parse the height that's being requested.
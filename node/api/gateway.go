@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -20,6 +21,8 @@ type (
 
 		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
 		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+
+		Connectivity modules.GatewayConnectivity `json:"connectivity"`
 	}
 
 	// GatewayBandwidthGET contains the bandwidth usage of the gateway
@@ -41,6 +44,26 @@ type (
 		Blacklist []string `json:"blacklist"` // deprecated, kept for backwards compatibility
 		Blocklist []string `json:"blocklist"`
 	}
+
+	// GatewayBansGET contains the active bans of the gateway.
+	GatewayBansGET struct {
+		Bans []modules.PeerBan `json:"bans"`
+	}
+
+	// GatewayPeersGET contains the quality metrics of the gateway's peers.
+	GatewayPeersGET struct {
+		Peers []modules.PeerStats `json:"peers"`
+	}
+
+	// GatewayPersistentPeersGET contains the persistent peers of the gateway.
+	GatewayPersistentPeersGET struct {
+		PersistentPeers []modules.NetAddress `json:"persistentpeers"`
+	}
+
+	// GatewayReachabilityPOST contains the result of a reachability check.
+	GatewayReachabilityPOST struct {
+		Reachable bool `json:"reachable"`
+	}
 )
 
 // RegisterRoutesGateway is a helper function to register all gateway routes.
@@ -66,6 +89,24 @@ func RegisterRoutesGateway(router *httprouter.Router, g modules.Gateway, require
 	router.POST("/gateway/blocklist", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayBlocklistHandlerPOST(g, w, req, ps)
 	}, requiredPassword))
+	router.GET("/gateway/bans", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayBansHandlerGET(g, w, req, ps)
+	})
+	router.POST("/gateway/bans", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayBansHandlerPOST(g, w, req, ps)
+	}, requiredPassword))
+	router.GET("/gateway/peers", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayPeersHandlerGET(g, w, req, ps)
+	})
+	router.GET("/gateway/persistentpeers", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayPersistentPeersHandlerGET(g, w, req, ps)
+	})
+	router.POST("/gateway/persistentpeers", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayPersistentPeersHandlerPOST(g, w, req, ps)
+	}, requiredPassword))
+	router.POST("/gateway/reachability", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayReachabilityHandlerPOST(g, w, req, ps)
+	}, requiredPassword))
 
 	// Deprecated fields
 	router.GET("/gateway/blacklist", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -86,7 +127,7 @@ func gatewayHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.R
 	if peers == nil {
 		peers = make([]modules.Peer, 0)
 	}
-	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.Online(), mds, mus})
+	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.Online(), mds, mus, gateway.Connectivity()})
 }
 
 // gatewayHandlerPOST handles the API call changing gateway specific settings.
@@ -223,3 +264,111 @@ func gatewayBlocklistHandlerPOST(gateway modules.Gateway, w http.ResponseWriter,
 
 	WriteSuccess(w)
 }
+
+// gatewayBansHandlerGET handles the API call to get the gateway's bans.
+func gatewayBansHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, GatewayBansGET{
+		Bans: gateway.Bans(),
+	})
+}
+
+// gatewayBansHandlerPOST handles the API call to ban or unban a subnet.
+func gatewayBansHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	subnet := req.FormValue("subnet")
+	if subnet == "" {
+		WriteError(w, Error{"subnet must be specified"}, http.StatusBadRequest)
+		return
+	}
+
+	switch action := req.FormValue("action"); action {
+	case "add":
+		// Parse the duration. (optional parameter)
+		var duration time.Duration
+		if d := req.FormValue("duration"); d != "" {
+			var err error
+			duration, err = time.ParseDuration(d)
+			if err != nil {
+				WriteError(w, Error{"unable to parse duration: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if err := gateway.Ban(subnet, duration, req.FormValue("reason")); err != nil {
+			WriteError(w, Error{"failed to ban subnet: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	case "remove":
+		if err := gateway.Unban(subnet); err != nil {
+			WriteError(w, Error{"failed to unban subnet: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	default:
+		WriteError(w, Error{fmt.Sprintf("invalid action %q, must be 'add' or 'remove'", action)}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// gatewayPeersHandlerGET handles the API call asking for the quality metrics
+// of the gateway's peers.
+func gatewayPeersHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, GatewayPeersGET{
+		Peers: gateway.PeerStats(),
+	})
+}
+
+// gatewayPersistentPeersHandlerGET handles the API call to get the gateway's
+// persistent peers.
+func gatewayPersistentPeersHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, GatewayPersistentPeersGET{
+		PersistentPeers: gateway.PersistentPeers(),
+	})
+}
+
+// gatewayPersistentPeersHandlerPOST handles the API call to add or remove a
+// persistent peer.
+func gatewayPersistentPeersHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr := modules.NetAddress(req.FormValue("netaddress"))
+	if addr == "" {
+		WriteError(w, Error{"netaddress must be specified"}, http.StatusBadRequest)
+		return
+	}
+
+	switch action := req.FormValue("action"); action {
+	case "add":
+		if err := gateway.AddPersistentPeer(addr); err != nil {
+			WriteError(w, Error{"failed to add persistent peer: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	case "remove":
+		if err := gateway.RemovePersistentPeer(addr); err != nil {
+			WriteError(w, Error{"failed to remove persistent peer: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	default:
+		WriteError(w, Error{fmt.Sprintf("invalid action %q, must be 'add' or 'remove'", action)}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// gatewayReachabilityHandlerPOST handles the API call asking the gateway's
+// peers to dial back a port.
+func gatewayReachabilityHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	port := req.FormValue("port")
+	if port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			WriteError(w, Error{"invalid port: " + port}, http.StatusBadRequest)
+			return
+		}
+	}
+	reachable, err := gateway.CheckReachability(port)
+	if err != nil {
+		WriteError(w, Error{"unable to check reachability: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, GatewayReachabilityPOST{
+		Reachable: reachable,
+	})
+}
@@ -0,0 +1,129 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"go.sia.tech/siad/node/api"
+)
+
+// auditedMethods are the methods which change the state of the node or write
+// to its disk and are recorded in the audit log of the API.
+var auditedMethods = map[string]bool{
+	"/siad.v1.WalletService/SendSiacoins": true,
+	"/siad.v1.RenterService/Upload":       true,
+	"/siad.v1.RenterService/Download":     true,
+	"/siad.v1.HostService/Announce":       true,
+}
+
+// auditServerStream keeps the request of a streaming call to record it in the
+// audit log.
+type auditServerStream struct {
+	grpc.ServerStream
+	req interface{}
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (as *auditServerStream) RecvMsg(m interface{}) error {
+	err := as.ServerStream.RecvMsg(m)
+	if err == nil && as.req == nil {
+		as.req = m
+	}
+	return err
+}
+
+// unaryAuditInterceptor records the unary calls of the audited methods in the
+// audit log.
+func (s *Server) unaryAuditInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !auditedMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	resp, err := handler(ctx, req)
+	s.recordAudit(ctx, info.FullMethod, req, err)
+	return resp, err
+}
+
+// streamAuditInterceptor records the streaming calls of the audited methods in
+// the audit log once they are done. The request isn't recorded if the call
+// failed before it was received.
+func (s *Server) streamAuditInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !auditedMethods[info.FullMethod] {
+		return handler(srv, ss)
+	}
+	as := &auditServerStream{ServerStream: ss}
+	err := handler(srv, as)
+	s.recordAudit(ss.Context(), info.FullMethod, as.req, err)
+	return err
+}
+
+// recordAudit records a call to method in the audit log of the API.
+func (s *Server) recordAudit(ctx context.Context, method string, req interface{}, err error) {
+	if s.staticAPI == nil {
+		return
+	}
+	var errMsg string
+	if err != nil {
+		errMsg = status.Convert(err).Message()
+	}
+	s.staticAPI.RecordAudit(api.AuditEntry{
+		Timestamp:     time.Now(),
+		Method:        api.AuditMethodGRPC,
+		Endpoint:      method,
+		Params:        requestParams(req),
+		SourceIP:      peerIP(ctx),
+		Authenticated: s.staticPassword != "" && s.authenticate(ctx) == nil,
+		StatusCode:    httpStatusFromCode(status.Code(err)),
+		Error:         errMsg,
+	})
+}
+
+// requestParams returns the fields of a request which are set as the
+// parameters of an audit entry.
+func requestParams(req interface{}) url.Values {
+	params := make(url.Values)
+	m, ok := req.(proto.Message)
+	if !ok {
+		return params
+	}
+	m.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		params.Set(string(fd.Name()), v.String())
+		return true
+	})
+	return params
+}
+
+// httpStatusFromCode returns the HTTP status code corresponding to a gRPC
+// status code, which is recorded in the audit log.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
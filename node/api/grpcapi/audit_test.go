@@ -0,0 +1,118 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+)
+
+// newTestAPI returns an HTTP API with the audit log enabled which requires
+// the provided password.
+func newTestAPI(t *testing.T, password string) *api.API {
+	dir := build.TempDir("grpcapi", t.Name())
+	cfg, err := modules.NewConfig(filepath.Join(dir, modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := api.New(cfg, "Sia-Agent", password, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	if err := a.OpenAuditLog(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := a.CloseAuditLog(); err != nil {
+			t.Error(err)
+		}
+	})
+	return a
+}
+
+// auditEntries returns the entries of the audit log of the API.
+func auditEntries(t *testing.T, a *api.API, password string) []api.AuditEntry {
+	req := httptest.NewRequest(http.MethodGet, "/daemon/audit", nil)
+	req.Header.Set("User-Agent", "Sia-Agent")
+	req.SetBasicAuth("", password)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code, w.Body.String())
+	}
+	var dag api.DaemonAuditGet
+	if err := json.NewDecoder(w.Body).Decode(&dag); err != nil {
+		t.Fatal(err)
+	}
+	return dag.Entries
+}
+
+// TestAuditInterceptors tests that the unary and streaming calls which change
+// the state of the node are recorded in the audit log.
+func TestAuditInterceptors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	a := newTestAPI(t, "foo")
+	addr := serveTest(t, NewServer("foo", nil, a))
+	conn := dialTest(t, addr, "foo")
+	ctx := context.Background()
+
+	// Calls which don't change state are not recorded.
+	if _, err := NewDaemonServiceClient(conn).Version(ctx, &VersionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if e := auditEntries(t, a, "foo"); len(e) != 0 {
+		t.Fatal("expected no entries", e)
+	}
+
+	// Make an authenticated unary and streaming call and an unauthenticated
+	// call. The modules aren't loaded so the authenticated calls fail.
+	_, err := NewWalletServiceClient(conn).SendSiacoins(ctx, &SendSiacoinsRequest{Amount: "1", Destination: "bar"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected error", err)
+	}
+	upload, err := NewRenterServiceClient(conn).Upload(ctx, &UploadRequest{Source: "/foo", SiaPath: "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := upload.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatal("unexpected error", err)
+	}
+	_, err = NewHostServiceClient(dialTest(t, addr, "")).Announce(ctx, &AnnounceRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatal("unexpected error", err)
+	}
+
+	e := auditEntries(t, a, "foo")
+	if len(e) != 3 {
+		t.Fatal("expected 3 entries", e)
+	}
+	send, up, announce := e[0], e[1], e[2]
+	if send.Method != api.AuditMethodGRPC || send.Endpoint != "/siad.v1.WalletService/SendSiacoins" || !send.Authenticated || send.SourceIP != "127.0.0.1" {
+		t.Fatal("wrong entry", send)
+	}
+	if send.StatusCode != http.StatusServiceUnavailable || send.Error != "wallet module is not loaded" {
+		t.Fatal("wrong result", send)
+	}
+	if send.Params.Get("amount") != "1" || send.Params.Get("destination") != "bar" {
+		t.Fatal("wrong params", send.Params)
+	}
+	if up.Endpoint != "/siad.v1.RenterService/Upload" || !up.Authenticated || up.StatusCode != http.StatusServiceUnavailable {
+		t.Fatal("wrong entry", up)
+	}
+	if up.Params.Get("source") != "/foo" || up.Params.Get("sia_path") != "bar" {
+		t.Fatal("wrong params", up.Params)
+	}
+	if announce.Endpoint != "/siad.v1.HostService/Announce" || announce.Authenticated || announce.StatusCode != http.StatusUnauthorized {
+		t.Fatal("wrong entry", announce)
+	}
+}
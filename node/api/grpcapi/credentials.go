@@ -0,0 +1,36 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// passwordCredentials authenticates calls with the API password using HTTP
+// basic auth.
+type passwordCredentials struct {
+	password      string
+	tlsConnection bool
+}
+
+// NewPasswordCredentials returns credentials for clients which authenticate
+// their calls with the API password. If tls is set, the credentials are only
+// sent over connections secured with TLS.
+func NewPasswordCredentials(password string, tls bool) credentials.PerRPCCredentials {
+	return passwordCredentials{
+		password:      password,
+		tlsConnection: tls,
+	}
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (pc passwordCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(":" + pc.password))
+	return map[string]string{"authorization": "Basic " + auth}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (pc passwordCredentials) RequireTransportSecurity() bool {
+	return pc.tlsConnection
+}
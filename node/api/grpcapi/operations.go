@@ -0,0 +1,26 @@
+package grpcapi
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errShuttingDown is returned by the calls which start an expensive operation
+// while siad is draining them to shut down.
+var errShuttingDown = status.Error(codes.Unavailable, "siad is shutting down")
+
+// streamOperationInterceptor registers the uploads and downloads with the
+// operations of the API until their stream ends, so that a draining shutdown
+// waits for them. New uploads and downloads are rejected while draining.
+func (s *Server) streamOperationInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	kind := operationKinds[info.FullMethod]
+	if s.staticAPI == nil || kind == "" {
+		return handler(srv, ss)
+	}
+	if !s.staticAPI.StartOperation(kind) {
+		return errShuttingDown
+	}
+	defer s.staticAPI.OperationDone(kind)
+	return handler(srv, ss)
+}
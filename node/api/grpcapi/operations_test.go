@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/node/api"
+)
+
+// TestOperationInterceptor tests that a draining shutdown waits for the
+// uploads and downloads of the gRPC API and rejects new ones.
+func TestOperationInterceptor(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	a := newTestAPI(t, "")
+	stopped := make(chan struct{})
+	a.Shutdown = func() error {
+		close(stopped)
+		return nil
+	}
+	s := NewServer("", nil, a)
+	call := func(method string, handler grpc.StreamHandler) error {
+		return s.streamOperationInterceptor(nil, &testServerStream{}, &grpc.StreamServerInfo{FullMethod: method}, handler)
+	}
+	noop := func(interface{}, grpc.ServerStream) error { return nil }
+
+	// Start an upload which blocks until it is released.
+	started, release, uploaded := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		uploaded <- call("/siad.v1.RenterService/Upload", func(interface{}, grpc.ServerStream) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	// Stop the daemon while the upload is in progress.
+	stopDone := make(chan *httptest.ResponseRecorder)
+	go func() {
+		req := httptest.NewRequest(http.MethodGet, "/daemon/stop?drain=true&timeout=60", nil)
+		req.Header.Set("User-Agent", "Sia-Agent")
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, req)
+		stopDone <- w
+	}()
+
+	// New downloads are rejected once draining, other calls are served.
+	err := build.Retry(100, 10*time.Millisecond, func() error {
+		if err := call("/siad.v1.RenterService/Download", noop); status.Code(err) != codes.Unavailable {
+			return errors.New("not draining yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := call("/siad.v1.ConsensusService/SubscribeBlocks", noop); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("daemon stopped before the upload completed")
+	case <-time.After(time.Second):
+	}
+
+	// Once the upload is complete, the daemon stops.
+	close(release)
+	if err := <-uploaded; err != nil {
+		t.Fatal(err)
+	}
+	w := <-stopDone
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon wasn't stopped")
+	}
+	var first api.DaemonStopProgress
+	if err := json.NewDecoder(strings.NewReader(w.Body.String())).Decode(&first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Phase != api.DrainPhaseDraining || first.Uploads != 1 {
+		t.Fatal("wrong initial progress", first)
+	}
+}
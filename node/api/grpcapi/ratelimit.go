@@ -0,0 +1,65 @@
+package grpcapi
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"go.sia.tech/siad/node/api"
+)
+
+// operationKinds are the kinds of expensive operations of the API requested by
+// the methods which start them.
+var operationKinds = map[string]string{
+	"/siad.v1.RenterService/Upload":   api.OperationUploads,
+	"/siad.v1.RenterService/Download": api.OperationDownloads,
+}
+
+// admit checks the rate limits and concurrency caps of the API for a call to
+// method. Rejected calls fail with ResourceExhausted and the number of seconds
+// after which the client should retry is set in the retry-after header. An
+// admitted call must call the returned function once it was served.
+func (s *Server) admit(ctx context.Context, method string, setHeader func(metadata.MD) error) (func(), error) {
+	if s.staticAPI == nil {
+		return func() {}, nil
+	}
+	done, retryAfter, err := s.staticAPI.AdmitCall(peerIP(ctx), method, operationKinds[method])
+	if err != nil {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		_ = setHeader(metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return done, nil
+}
+
+// unaryLimitInterceptor applies the rate limits of the API to unary calls.
+func (s *Server) unaryLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	done, err := s.admit(ctx, info.FullMethod, func(md metadata.MD) error {
+		return grpc.SetHeader(ctx, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+	return handler(ctx, req)
+}
+
+// streamLimitInterceptor applies the rate limits and concurrency caps of the
+// API to streaming calls. Uploads and downloads count towards the caps until
+// their stream ends.
+func (s *Server) streamLimitInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	done, err := s.admit(ss.Context(), info.FullMethod, ss.SetHeader)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return handler(srv, ss)
+}
@@ -0,0 +1,102 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"go.sia.tech/siad/node/api"
+)
+
+// testServerStream is a grpc.ServerStream of a call from a local client.
+type testServerStream struct {
+	grpc.ServerStream
+	header metadata.MD
+}
+
+// Context implements grpc.ServerStream.
+func (ts *testServerStream) Context() context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	})
+}
+
+// SetHeader implements grpc.ServerStream.
+func (ts *testServerStream) SetHeader(md metadata.MD) error {
+	ts.header = metadata.Join(ts.header, md)
+	return nil
+}
+
+// TestLimitInterceptors tests that calls to the gRPC API are subject to the
+// rate limits and concurrency caps of the API.
+func TestLimitInterceptors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Allow a single call.
+	a := newTestAPI(t, "")
+	a.SetRateLimitPolicy(api.RateLimitPolicy{RequestsPerSecond: 0.001, Burst: 1})
+	conn := dialTest(t, serveTest(t, NewServer("", nil, a)), "")
+	daemon := NewDaemonServiceClient(conn)
+	if _, err := daemon.Version(context.Background(), &VersionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	var header metadata.MD
+	_, err := daemon.Version(context.Background(), &VersionRequest{}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatal("expected call to be rate limited", err)
+	}
+	if ra := header.Get("retry-after"); len(ra) != 1 || ra[0] == "0" {
+		t.Fatal("expected retry-after header", header)
+	}
+
+	// Cap the number of concurrent uploads and start an upload which blocks
+	// until it is released.
+	a = newTestAPI(t, "")
+	a.SetRateLimitPolicy(api.RateLimitPolicy{MaxUploads: 1})
+	s := NewServer("", nil, a)
+	call := func(method string, handler grpc.StreamHandler) (*testServerStream, error) {
+		ts := &testServerStream{}
+		return ts, s.streamLimitInterceptor(nil, ts, &grpc.StreamServerInfo{FullMethod: method}, handler)
+	}
+	noop := func(interface{}, grpc.ServerStream) error { return nil }
+	started, release, uploaded := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		_, err := call("/siad.v1.RenterService/Upload", func(interface{}, grpc.ServerStream) error {
+			close(started)
+			<-release
+			return nil
+		})
+		uploaded <- err
+	}()
+	<-started
+
+	// Another upload should be rejected while downloads are not capped.
+	ts, err := call("/siad.v1.RenterService/Upload", noop)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatal("expected upload to be rejected", err)
+	}
+	if ra := ts.header.Get("retry-after"); len(ra) != 1 || ra[0] != "1" {
+		t.Fatal("expected retry-after header", ts.header)
+	}
+	if _, err := call("/siad.v1.RenterService/Download", noop); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the first upload is done, uploads are admitted again.
+	close(release)
+	if err := <-uploaded; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call("/siad.v1.RenterService/Upload", noop); err != nil {
+		t.Fatal(err)
+	}
+}
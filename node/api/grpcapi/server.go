@@ -0,0 +1,212 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+)
+
+var (
+	// progressInterval is the interval at which the progress of uploads and
+	// downloads is checked and streamed to the client.
+	progressInterval = build.Select(build.Var{
+		Dev:      time.Second,
+		Standard: time.Second,
+		Testnet:  time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// errAuthentication is returned if a call doesn't provide the correct API
+	// password.
+	errAuthentication = status.Error(codes.Unauthenticated, "API authentication failed")
+)
+
+// Server serves the gRPC API of siad. The modules can be replaced at runtime,
+// since they can be started and stopped while siad is running.
+type Server struct {
+	cs     modules.ConsensusSet
+	host   modules.Host
+	renter modules.Renter
+	wallet modules.Wallet
+	mu     sync.RWMutex
+
+	staticAPI      *api.API
+	staticGRPC     *grpc.Server
+	staticPassword string
+}
+
+// NewServer creates a new gRPC server. Calls need to authenticate with the
+// password using HTTP basic auth if it is not empty. If tlsConfig is not nil,
+// the API is served over TLS. The calls which change the state of the node are
+// recorded in the audit log of httpAPI, the calls are subject to its rate
+// limits and its draining shutdown waits for the uploads and downloads.
+// httpAPI may be nil.
+func NewServer(password string, tlsConfig *tls.Config, httpAPI *api.API) *Server {
+	s := &Server{
+		staticAPI:      httpAPI,
+		staticPassword: password,
+	}
+	// The audit interceptors come first to also record the calls which are
+	// rejected. Like in the HTTP API, the rate limits and operations apply
+	// before authenticating.
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryAuditInterceptor, s.unaryLimitInterceptor, s.unaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(s.streamAuditInterceptor, s.streamLimitInterceptor, s.streamOperationInterceptor, s.streamAuthInterceptor),
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	s.staticGRPC = grpc.NewServer(opts...)
+	RegisterDaemonServiceServer(s.staticGRPC, &daemonServer{})
+	RegisterConsensusServiceServer(s.staticGRPC, &consensusServer{s: s})
+	RegisterWalletServiceServer(s.staticGRPC, &walletServer{s: s})
+	RegisterRenterServiceServer(s.staticGRPC, &renterServer{s: s})
+	RegisterHostServiceServer(s.staticGRPC, &hostServer{s: s})
+	return s
+}
+
+// Serve serves the gRPC API on the listener until the server is stopped.
+func (s *Server) Serve(l net.Listener) error {
+	return s.staticGRPC.Serve(l)
+}
+
+// Stop stops the server and closes all open connections and streams.
+func (s *Server) Stop() {
+	s.staticGRPC.Stop()
+}
+
+// SetModules sets the modules of the server. Modules which aren't loaded are
+// nil, in which case the calls of their service fail.
+func (s *Server) SetModules(cs modules.ConsensusSet, h modules.Host, r modules.Renter, w modules.Wallet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cs = cs
+	s.host = h
+	s.renter = r
+	s.wallet = w
+}
+
+// managedConsensusSet returns the consensus set or an error if it isn't
+// loaded.
+func (s *Server) managedConsensusSet() (modules.ConsensusSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cs == nil {
+		return nil, errModuleNotLoaded("consensus")
+	}
+	return s.cs, nil
+}
+
+// managedHost returns the host or an error if it isn't loaded.
+func (s *Server) managedHost() (modules.Host, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.host == nil {
+		return nil, errModuleNotLoaded("host")
+	}
+	return s.host, nil
+}
+
+// managedRenter returns the renter or an error if it isn't loaded.
+func (s *Server) managedRenter() (modules.Renter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.renter == nil {
+		return nil, errModuleNotLoaded("renter")
+	}
+	return s.renter, nil
+}
+
+// managedWallet returns the wallet or an error if it isn't loaded.
+func (s *Server) managedWallet() (modules.Wallet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.wallet == nil {
+		return nil, errModuleNotLoaded("wallet")
+	}
+	return s.wallet, nil
+}
+
+// authenticate checks that the metadata of a call contains the API password.
+func (s *Server) authenticate(ctx context.Context) error {
+	if s.staticPassword == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		password, ok := parseBasicAuth(auth)
+		if ok && subtle.ConstantTimeCompare([]byte(password), []byte(s.staticPassword)) == 1 {
+			return nil
+		}
+	}
+	return errAuthentication
+}
+
+// unaryAuthInterceptor authenticates unary calls.
+func (s *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuthInterceptor authenticates streaming calls.
+func (s *Server) streamAuthInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authenticate(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// parseBasicAuth returns the password of the value of an HTTP basic auth
+// header. Usernames are ignored.
+func parseBasicAuth(auth string) (string, bool) {
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", false
+	}
+	i := strings.IndexByte(string(b), ':')
+	if i < 0 {
+		return "", false
+	}
+	return string(b[i+1:]), true
+}
+
+// peerIP returns the IP address of the client of a call.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	addr := p.Addr.String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// errModuleNotLoaded returns the error of a call to a module which isn't
+// loaded.
+func errModuleNotLoaded(module string) error {
+	return status.Errorf(codes.Unavailable, "%v module is not loaded", module)
+}
@@ -0,0 +1,115 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// serveTest serves s on a local listener and returns its address.
+func serveTest(t *testing.T, s *Server) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = s.Serve(l)
+	}()
+	t.Cleanup(s.Stop)
+	return l.Addr().String()
+}
+
+// dialTest returns a connection to the server at addr which authenticates its
+// calls with password.
+func dialTest(t *testing.T, addr, password string) *grpc.ClientConn {
+	opts := []grpc.DialOption{grpc.WithInsecure()}
+	if password != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(NewPasswordCredentials(password, false)))
+	}
+	conn, err := grpc.Dial(addr, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return conn
+}
+
+// TestParseBasicAuth tests parsing the password from HTTP basic auth headers.
+func TestParseBasicAuth(t *testing.T) {
+	t.Parallel()
+
+	encode := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	tests := []struct {
+		auth     string
+		password string
+		ok       bool
+	}{
+		{"Basic " + encode(":foo"), "foo", true},
+		{"basic " + encode("user:foo"), "foo", true},
+		{"Basic " + encode("user:foo:bar"), "foo:bar", true},
+		{"Basic " + encode(":"), "", true},
+		{"Basic " + encode("foo"), "", false},
+		{"Basic foo", "", false},
+		{"Bearer " + encode(":foo"), "", false},
+		{"Basic", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		password, ok := parseBasicAuth(test.auth)
+		if password != test.password || ok != test.ok {
+			t.Errorf("%q: expected (%q, %v), got (%q, %v)", test.auth, test.password, test.ok, password, ok)
+		}
+	}
+}
+
+// TestAuthInterceptors tests that unary and streaming calls need to provide
+// the API password.
+func TestAuthInterceptors(t *testing.T) {
+	t.Parallel()
+
+	// callCodes makes a unary and a streaming call authenticated with password
+	// and returns their status codes.
+	callCodes := func(serverPassword, password string) (codes.Code, codes.Code) {
+		conn := dialTest(t, serveTest(t, NewServer(serverPassword, nil, nil)), password)
+		_, err := NewDaemonServiceClient(conn).Version(context.Background(), &VersionRequest{})
+		unary := status.Code(err)
+		stream, err := NewConsensusServiceClient(conn).SubscribeBlocks(context.Background(), &SubscribeBlocksRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = stream.Recv()
+		return unary, status.Code(err)
+	}
+
+	// Calls with a missing or wrong password should fail.
+	for _, password := range []string{"", "bar", "fo", "foo2"} {
+		unary, stream := callCodes("foo", password)
+		if unary != codes.Unauthenticated || stream != codes.Unauthenticated {
+			t.Fatalf("%q: expected calls to be unauthenticated, got %v and %v", password, unary, stream)
+		}
+	}
+
+	// Calls with the correct password should pass the interceptors. The
+	// consensus set isn't loaded so the stream fails after authenticating.
+	unary, stream := callCodes("foo", "foo")
+	if unary != codes.OK || stream != codes.Unavailable {
+		t.Fatal("expected calls to be authenticated, got", unary, stream)
+	}
+
+	// Without a password, any call is authenticated.
+	unary, stream = callCodes("", "bar")
+	if unary != codes.OK || stream != codes.Unavailable {
+		t.Fatal("expected calls to be authenticated, got", unary, stream)
+	}
+}
@@ -0,0 +1,452 @@
+package grpcapi
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/types"
+)
+
+// blockUpdateBufferSize is the number of block updates which are buffered
+// for a subscriber before it is considered too slow and its subscription is
+// ended.
+const blockUpdateBufferSize = 100
+
+type (
+	// daemonServer implements DaemonServiceServer.
+	daemonServer struct {
+		UnimplementedDaemonServiceServer
+	}
+
+	// consensusServer implements ConsensusServiceServer.
+	consensusServer struct {
+		UnimplementedConsensusServiceServer
+		s *Server
+	}
+
+	// walletServer implements WalletServiceServer.
+	walletServer struct {
+		UnimplementedWalletServiceServer
+		s *Server
+	}
+
+	// renterServer implements RenterServiceServer.
+	renterServer struct {
+		UnimplementedRenterServiceServer
+		s *Server
+	}
+
+	// hostServer implements HostServiceServer.
+	hostServer struct {
+		UnimplementedHostServiceServer
+		s *Server
+	}
+
+	// blockSubscriber is the consensus set subscriber of a SubscribeBlocks
+	// call.
+	blockSubscriber struct {
+		overflow bool
+		updates  chan *BlockUpdate
+		mu       sync.Mutex
+	}
+)
+
+// Version implements DaemonServiceServer.
+func (*daemonServer) Version(context.Context, *VersionRequest) (*VersionResponse, error) {
+	return &VersionResponse{
+		Version:     build.NodeVersion,
+		GitRevision: build.GitRevision,
+		BuildTime:   build.BuildTime,
+	}, nil
+}
+
+// Consensus implements ConsensusServiceServer.
+func (cs *consensusServer) Consensus(context.Context, *ConsensusRequest) (*ConsensusResponse, error) {
+	c, err := cs.s.managedConsensusSet()
+	if err != nil {
+		return nil, err
+	}
+	return &ConsensusResponse{
+		Synced:       c.Synced(),
+		Height:       uint64(c.Height()),
+		CurrentBlock: c.CurrentBlock().ID().String(),
+	}, nil
+}
+
+// SubscribeBlocks implements ConsensusServiceServer.
+func (cs *consensusServer) SubscribeBlocks(_ *SubscribeBlocksRequest, stream ConsensusService_SubscribeBlocksServer) error {
+	c, err := cs.s.managedConsensusSet()
+	if err != nil {
+		return err
+	}
+	ctx := stream.Context()
+	bs := &blockSubscriber{
+		updates: make(chan *BlockUpdate, blockUpdateBufferSize),
+	}
+	if err := c.ConsensusSetSubscribe(bs, modules.ConsensusChangeRecent, ctx.Done()); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer c.Unsubscribe(bs)
+
+	// Confirm the subscription with the current state.
+	if err := stream.Send(&BlockUpdate{Height: uint64(c.Height()), Synced: c.Synced()}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case update, ok := <-bs.updates:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber didn't keep up with the block updates")
+			}
+			if err := stream.Send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber. The
+// updates are buffered to not block the consensus set. If the buffer is full,
+// the updates channel is closed which ends the subscription.
+func (bs *blockSubscriber) ProcessConsensusChange(cc modules.ConsensusChange) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if bs.overflow {
+		return
+	}
+	update := &BlockUpdate{
+		Height: uint64(cc.BlockHeight),
+		Synced: cc.Synced,
+	}
+	for _, b := range cc.RevertedBlocks {
+		update.RevertedBlocks = append(update.RevertedBlocks, b.ID().String())
+	}
+	for _, b := range cc.AppliedBlocks {
+		update.AppliedBlocks = append(update.AppliedBlocks, b.ID().String())
+	}
+	select {
+	case bs.updates <- update:
+	default:
+		bs.overflow = true
+		close(bs.updates)
+	}
+}
+
+// Balance implements WalletServiceServer.
+func (ws *walletServer) Balance(context.Context, *BalanceRequest) (*BalanceResponse, error) {
+	w, err := ws.s.managedWallet()
+	if err != nil {
+		return nil, err
+	}
+	siacoins, siafunds, claim, err := w.ConfirmedBalance()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	outgoing, incoming, err := w.UnconfirmedBalance()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &BalanceResponse{
+		ConfirmedSiacoins:           siacoins.String(),
+		UnconfirmedIncomingSiacoins: incoming.String(),
+		UnconfirmedOutgoingSiacoins: outgoing.String(),
+		Siafunds:                    siafunds.String(),
+		SiafundClaim:                claim.String(),
+	}, nil
+}
+
+// Address implements WalletServiceServer.
+func (ws *walletServer) Address(context.Context, *AddressRequest) (*AddressResponse, error) {
+	w, err := ws.s.managedWallet()
+	if err != nil {
+		return nil, err
+	}
+	uc, err := w.NextAddress()
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &AddressResponse{
+		Address: uc.UnlockHash().String(),
+	}, nil
+}
+
+// SendSiacoins implements WalletServiceServer.
+func (ws *walletServer) SendSiacoins(_ context.Context, req *SendSiacoinsRequest) (*SendSiacoinsResponse, error) {
+	w, err := ws.s.managedWallet()
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(req.Amount, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "could not read amount")
+	}
+	var dest types.UnlockHash
+	if err := dest.LoadString(req.Destination); err != nil {
+		return nil, status.Error(codes.InvalidArgument, "could not read destination: "+err.Error())
+	}
+	txns, err := w.SendSiacoins(types.NewCurrency(amount), dest)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	resp := &SendSiacoinsResponse{}
+	for _, txn := range txns {
+		resp.TransactionIds = append(resp.TransactionIds, txn.ID().String())
+	}
+	return resp, nil
+}
+
+// Contracts implements RenterServiceServer.
+func (rs *renterServer) Contracts(context.Context, *ContractsRequest) (*ContractsResponse, error) {
+	r, err := rs.s.managedRenter()
+	if err != nil {
+		return nil, err
+	}
+	resp := &ContractsResponse{}
+	for _, c := range r.Contracts() {
+		resp.Contracts = append(resp.Contracts, &Contract{
+			Id:            c.ID.String(),
+			HostPublicKey: c.HostPublicKey.String(),
+			StartHeight:   uint64(c.StartHeight),
+			EndHeight:     uint64(c.EndHeight),
+			Size:          c.Size(),
+			RenterFunds:   c.RenterFunds.String(),
+			TotalCost:     c.TotalCost.String(),
+		})
+	}
+	return resp, nil
+}
+
+// Files implements RenterServiceServer.
+func (rs *renterServer) Files(_ context.Context, req *FilesRequest) (*FilesResponse, error) {
+	r, err := rs.s.managedRenter()
+	if err != nil {
+		return nil, err
+	}
+	siaPath, err := parseSiaPath(req.SiaPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []modules.FileInfo
+	var mu sync.Mutex
+	err = r.FileList(siaPath, req.Recursive, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		files = append(files, fi)
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	resp := &FilesResponse{}
+	for _, fi := range files {
+		f, err := newFile(fi)
+		if err != nil {
+			return nil, err
+		}
+		resp.Files = append(resp.Files, f)
+	}
+	return resp, nil
+}
+
+// Upload implements RenterServiceServer.
+func (rs *renterServer) Upload(req *UploadRequest, stream RenterService_UploadServer) error {
+	r, err := rs.s.managedRenter()
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(req.Source) {
+		return status.Error(codes.InvalidArgument, "source must be an absolute path")
+	}
+	siaPath, err := parseSiaPath(req.SiaPath)
+	if err != nil {
+		return err
+	}
+	ec, err := api.NewErasureCoder(int(req.DataPieces), int(req.ParityPieces))
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "unable to parse erasure code settings: "+err.Error())
+	}
+	err = r.Upload(modules.FileUploadParams{
+		Source:              req.Source,
+		SiaPath:             siaPath,
+		ErasureCode:         ec,
+		Force:               req.Force,
+		DisablePartialChunk: true,
+		CipherType:          crypto.TypeDefaultRenter,
+	})
+	if err != nil {
+		return status.Error(codes.Internal, "upload failed: "+err.Error())
+	}
+
+	// Stream the progress until the file is fully uploaded.
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		fi, err := r.File(siaPath)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		f, err := newFile(fi)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&FileProgress{File: f}); err != nil {
+			return err
+		}
+		if fi.UploadProgress >= 100 {
+			return nil
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Download implements RenterServiceServer.
+func (rs *renterServer) Download(req *DownloadRequest, stream RenterService_DownloadServer) error {
+	r, err := rs.s.managedRenter()
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(req.Destination) {
+		return status.Error(codes.InvalidArgument, "destination must be an absolute path")
+	}
+	siaPath, err := parseSiaPath(req.SiaPath)
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	id, start, cancel, err := r.DownloadAsync(modules.RenterDownloadParameters{
+		Destination: req.Destination,
+		Length:      req.Length,
+		Offset:      req.Offset,
+		SiaPath:     siaPath,
+	}, func(error) error {
+		close(done)
+		return nil
+	})
+	if err != nil {
+		return status.Error(codes.Internal, "download creation failed: "+err.Error())
+	}
+	if err := start(); err != nil {
+		return status.Error(codes.Internal, "download failed: "+err.Error())
+	}
+
+	// Stream the progress until the download completes.
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		var finished bool
+		select {
+		case <-stream.Context().Done():
+			cancel()
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-done:
+			finished = true
+		case <-ticker.C:
+		}
+		info, ok := r.DownloadByUID(id)
+		if !ok {
+			return status.Error(codes.NotFound, "download not found")
+		}
+		if info.Error != "" {
+			return status.Error(codes.Internal, "download failed: "+info.Error)
+		}
+		err := stream.Send(&DownloadProgress{
+			Length:    info.Length,
+			Received:  info.Received,
+			Completed: info.Completed,
+		})
+		if err != nil {
+			cancel()
+			return err
+		}
+		if finished {
+			return nil
+		}
+	}
+}
+
+// Host implements HostServiceServer.
+func (hs *hostServer) Host(context.Context, *HostRequest) (*HostResponse, error) {
+	h, err := hs.s.managedHost()
+	if err != nil {
+		return nil, err
+	}
+	is := h.InternalSettings()
+	fm := h.FinancialMetrics()
+	return &HostResponse{
+		PublicKey:                h.PublicKey().String(),
+		NetAddress:               string(is.NetAddress),
+		AcceptingContracts:       is.AcceptingContracts,
+		ContractCount:            fm.ContractCount,
+		StorageRevenue:           fm.StorageRevenue.String(),
+		DownloadBandwidthRevenue: fm.DownloadBandwidthRevenue.String(),
+		UploadBandwidthRevenue:   fm.UploadBandwidthRevenue.String(),
+		LockedStorageCollateral:  fm.LockedStorageCollateral.String(),
+	}, nil
+}
+
+// Announce implements HostServiceServer.
+func (hs *hostServer) Announce(_ context.Context, req *AnnounceRequest) (*AnnounceResponse, error) {
+	h, err := hs.s.managedHost()
+	if err != nil {
+		return nil, err
+	}
+	if req.NetAddress != "" {
+		err = h.AnnounceAddress(modules.NetAddress(req.NetAddress))
+	} else {
+		err = h.Announce()
+	}
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &AnnounceResponse{}, nil
+}
+
+// parseSiaPath parses a siapath of a request and rebases it onto the user's
+// home folder.
+func parseSiaPath(path string) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(path)
+	if err != nil {
+		return modules.SiaPath{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if siaPath.IsRoot() {
+		return modules.UserFolder, nil
+	}
+	siaPath, err = modules.UserFolder.Join(siaPath.String())
+	if err != nil {
+		return modules.SiaPath{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return siaPath, nil
+}
+
+// newFile converts the renter's info about a file into its API representation
+// with the siapath relative to the user's home folder.
+func newFile(fi modules.FileInfo) (*File, error) {
+	siaPath, err := fi.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &File{
+		SiaPath:        siaPath.String(),
+		Size:           fi.Filesize,
+		Available:      fi.Available,
+		Recoverable:    fi.Recoverable,
+		Health:         fi.Health,
+		Redundancy:     fi.Redundancy,
+		UploadProgress: fi.UploadProgress,
+	}, nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -13,6 +15,13 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// defaultSectorAccessLimit is the number of the most frequently read
+	// sectors returned by /host/storage/sectors/access if no limit is
+	// provided.
+	defaultSectorAccessLimit = 10
+)
+
 var (
 	// errNoPath is returned when a call fails to provide a nonempty string
 	// for the path parameter.
@@ -57,9 +66,28 @@ type (
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		PriceTable           modules.RPCPriceTable            `json:"pricetable"`
 		PublicKey            types.SiaPublicKey               `json:"publickey"`
+		RegistryMetrics      modules.HostRegistryMetrics      `json:"registrymetrics"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
 	}
 
+	// HostAnnounceCheckedPOST contains the report of a checked host
+	// announcement returned by a POST request to /host/announce/checked.
+	HostAnnounceCheckedPOST struct {
+		modules.HostAnnounceReport
+	}
+
+	// HostEarningsGET contains the breakdown of the host's earnings returned
+	// by a GET request to /host/earnings.
+	HostEarningsGET struct {
+		modules.HostEarningsReport
+	}
+
+	// HostObligationsGET contains the risk report of the host's unresolved
+	// storage obligations returned by a GET request to /host/obligations.
+	HostObligationsGET struct {
+		modules.HostObligationRiskReport
+	}
+
 	// HostEstimateScoreGET contains the information that is returned from a
 	// /host/estimatescore call.
 	HostEstimateScoreGET struct {
@@ -67,12 +95,25 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostPriceHistoryGET contains the price changes made by the host's
+	// dynamic pricing engine. It is returned by a GET request to
+	// /host/pricehistory.
+	HostPriceHistoryGET struct {
+		PriceChanges []modules.HostPriceChange `json:"pricechanges"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
 	StorageGET struct {
 		Folders []modules.StorageFolderMetadata `json:"folders"`
 	}
+
+	// StorageSectorsAccessGET contains how frequently the host's sectors are
+	// read. It is returned by a GET request to /host/storage/sectors/access.
+	StorageSectorsAccessGET struct {
+		modules.HostSectorAccessStats
+	}
 )
 
 // RegisterRoutesHost is a helper function to register all host routes.
@@ -87,6 +128,15 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/announce", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostAnnounceHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/announce/checked", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAnnounceCheckedHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostBackupHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.POST("/host/restore", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRestoreHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 	router.GET("/host/contracts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractInfoHandler(h, w, req, ps)
 	})
@@ -96,6 +146,15 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/earnings", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostEarningsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/obligations", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostObligationsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/pricehistory", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPriceHistoryHandlerGET(h, w, req, ps)
+	})
 
 	// Calls pertaining to the storage manager that the host uses.
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -110,6 +169,9 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/storage/folders/resize", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersResizeHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.GET("/host/storage/sectors/access", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageSectorsAccessHandlerGET(h, w, req, ps)
+	})
 	router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageSectorsDeleteHandler(h, w, req, ps)
 	}, requiredPassword))
@@ -170,6 +232,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 	ws := host.WorkingStatus()
 	pk := host.PublicKey()
 	pt := host.PriceTable()
+	rm := host.RegistryMetrics()
 	hg := HostGET{
 		ConnectabilityStatus: cs,
 		ExternalSettings:     es,
@@ -178,6 +241,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 		NetworkMetrics:       nm,
 		PriceTable:           pt,
 		PublicKey:            pk,
+		RegistryMetrics:      rm,
 		WorkingStatus:        ws,
 	}
 
@@ -203,6 +267,63 @@ func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.R
 	})
 }
 
+// hostEarningsHandlerGET handles GET requests to the /host/earnings API
+// endpoint, returning a breakdown of the host's earnings.
+func hostEarningsHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var startHeight, endHeight types.BlockHeight
+	if s := req.FormValue("startheight"); s != "" {
+		_, err := fmt.Sscan(s, &startHeight)
+		if err != nil {
+			WriteError(w, Error{"failed to parse startheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if s := req.FormValue("endheight"); s != "" {
+		_, err := fmt.Sscan(s, &endHeight)
+		if err != nil {
+			WriteError(w, Error{"failed to parse endheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if endHeight != 0 && endHeight < startHeight {
+		WriteError(w, Error{"endheight can't be smaller than startheight"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostEarningsGET{
+		HostEarningsReport: host.EarningsReport(startHeight, endHeight),
+	})
+}
+
+// hostObligationsHandlerGET handles GET requests to the /host/obligations API
+// endpoint, returning the risk report of the host's unresolved storage
+// obligations.
+func hostObligationsHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	window := types.BlocksPerDay
+	if s := req.FormValue("window"); s != "" {
+		_, err := fmt.Sscan(s, &window)
+		if err != nil {
+			WriteError(w, Error{"failed to parse window: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := host.ObligationRiskReport(window)
+	if err != nil {
+		WriteError(w, Error{"failed to create obligation risk report: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostObligationsGET{
+		HostObligationRiskReport: report,
+	})
+}
+
+// hostPriceHistoryHandlerGET handles GET requests to the /host/pricehistory
+// API endpoint, returning the price changes made by the dynamic pricing engine.
+func hostPriceHistoryHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostPriceHistoryGET{
+		PriceChanges: host.PriceHistory(),
+	})
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -257,6 +378,16 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 		}
 		settings.WindowSize = x
 	}
+	if addrs := req.FormValue("alternativenetaddresses"); addrs != "" || req.Form["alternativenetaddresses"] != nil {
+		// An empty list clears the alternative addresses.
+		var x []modules.NetAddress
+		for _, addr := range strings.Split(addrs, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				x = append(x, modules.NetAddress(addr))
+			}
+		}
+		settings.AlternativeNetAddresses = x
+	}
 
 	if req.FormValue("collateral") != "" {
 		var x types.Currency
@@ -366,6 +497,49 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	if req.FormValue("registryevictionpolicy") != "" {
+		settings.RegistryEvictionPolicy = modules.HostRegistryEvictionPolicy(req.FormValue("registryevictionpolicy"))
+	}
+	if req.FormValue("registryexpirygraceperiod") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("registryexpirygraceperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.RegistryExpiryGracePeriod = x
+	}
+	if req.FormValue("dynamicpricing") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("dynamicpricing"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DynamicPricing = x
+	}
+	if req.FormValue("maxdownloadbandwidthprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxdownloadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxDownloadBandwidthPrice = x
+	}
+	if req.FormValue("maxstorageprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxstorageprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxStoragePrice = x
+	}
+	if req.FormValue("maxuploadbandwidthprice") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxuploadbandwidthprice"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxUploadBandwidthPrice = x
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -474,6 +648,64 @@ func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// hostAnnounceCheckedHandlerPOST handles the API call to announce the host after
+// detecting or verifying its address and checking that it is reachable.
+func hostAnnounceCheckedHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dryRun, err := scanBool(req.FormValue("dryrun"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse dryrun: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	report, err := host.CheckedAnnounce(modules.NetAddress(req.FormValue("netaddress")), dryRun)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostAnnounceCheckedPOST{report})
+}
+
+// hostBackupHandlerPOST handles the API calls to /host/backup, creating a
+// backup of the host's critical state.
+func hostBackupHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that destination was specified.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	if err := host.CreateBackup(dst); err != nil {
+		WriteError(w, Error{"failed to create backup: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRestoreHandlerPOST handles the API calls to /host/restore, restoring a
+// backup created with /host/backup.
+func hostRestoreHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	if err := host.LoadBackup(src); err != nil {
+		WriteError(w, Error{"failed to load backup: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func storageHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -520,6 +752,21 @@ func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
 	}
+	var async bool
+	if a := req.FormValue("async"); a != "" {
+		async, err = scanBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse async: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if async {
+		// Run the resize in the background. Errors are reported in the
+		// storage folder's metadata.
+		go host.ResizeStorageFolder(uint16(folderIndex), newSize, false)
+		WriteSuccess(w)
+		return
+	}
 	err = host.ResizeStorageFolder(uint16(folderIndex), newSize, false)
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
@@ -553,6 +800,23 @@ func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *
 	WriteSuccess(w)
 }
 
+// storageSectorsAccessHandlerGET handles GET requests to the
+// /host/storage/sectors/access API endpoint, returning how frequently the
+// host's sectors are read.
+func storageSectorsAccessHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limit := defaultSectorAccessLimit
+	if s := req.FormValue("limit"); s != "" {
+		_, err := fmt.Sscan(s, &limit)
+		if err != nil || limit < 0 {
+			WriteError(w, Error{"failed to parse limit: must be a non-negative integer"}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, StorageSectorsAccessGET{
+		HostSectorAccessStats: host.SectorAccessStats(limit),
+	})
+}
+
 // storageSectorsDeleteHandler handles the call to delete a sector from the
 // storage manager.
 func storageSectorsDeleteHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
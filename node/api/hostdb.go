@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -50,6 +53,19 @@ type (
 		NetAddresses []string `json:"netaddresses"`
 	}
 
+	// HostdbBenchmarkGET contains the benchmarks of hosts.
+	HostdbBenchmarkGET struct {
+		Hosts []modules.HostBenchmarkResult `json:"hosts"`
+	}
+
+	// HostdbScanSettingsGET contains the settings which control how the
+	// hostdb scans hosts. A value of 0 means the hostdb's default is used.
+	HostdbScanSettingsGET struct {
+		ScanInterval     uint64 `json:"scaninterval"` // seconds
+		ScanThreads      int    `json:"scanthreads"`
+		MaxScanBandwidth int64  `json:"maxscanbandwidth"` // bytes per second
+	}
+
 	// HostdbFilterModePOST contains the information needed to set the the
 	// FilterMode of the hostDB
 	HostdbFilterModePOST struct {
@@ -211,3 +227,158 @@ func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Req
 	}
 	WriteSuccess(w)
 }
+
+// hostdbSnapshotHandlerGET handles the API call to export a snapshot of the
+// hostdb.
+func (api *API) hostdbSnapshotHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	snapshot, err := api.renter.HostDBExportSnapshot()
+	if err != nil {
+		WriteError(w, Error{"unable to export hostdb snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, snapshot)
+}
+
+// hostdbSnapshotHandlerPOST handles the API call to import a snapshot into the
+// hostdb.
+func (api *API) hostdbSnapshotHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var snapshot modules.HostDBSnapshot
+	err := json.NewDecoder(req.Body).Decode(&snapshot)
+	if err != nil {
+		WriteError(w, Error{"invalid snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	result, err := api.renter.HostDBImportSnapshot(snapshot)
+	if err != nil {
+		WriteError(w, Error{"unable to import hostdb snapshot: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, result)
+}
+
+// hostdbScanSettingsHandlerGET handles the API call to get the settings which
+// control how the hostdb scans hosts.
+func (api *API) hostdbScanSettingsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.HostDBScanSettings()
+	if err != nil {
+		WriteError(w, Error{"unable to get scan settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostdbScanSettingsGET{
+		ScanInterval:     uint64(settings.ScanInterval.Seconds()),
+		ScanThreads:      settings.ScanThreads,
+		MaxScanBandwidth: settings.MaxScanBandwidth,
+	})
+}
+
+// hostdbScanSettingsHandlerPOST handles the API call to set the settings which
+// control how the hostdb scans hosts. Settings which are not provided are left
+// unchanged.
+func (api *API) hostdbScanSettingsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.HostDBScanSettings()
+	if err != nil {
+		WriteError(w, Error{"unable to get scan settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if s := req.FormValue("scaninterval"); s != "" {
+		interval, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse scaninterval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.ScanInterval = time.Duration(interval) * time.Second
+	}
+	if s := req.FormValue("scanthreads"); s != "" {
+		threads, err := strconv.Atoi(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse scanthreads: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.ScanThreads = threads
+	}
+	if s := req.FormValue("maxscanbandwidth"); s != "" {
+		bandwidth, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxscanbandwidth: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MaxScanBandwidth = bandwidth
+	}
+	if err := api.renter.SetHostDBScanSettings(settings); err != nil {
+		WriteError(w, Error{"unable to set scan settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbHostsScanHandlerPOST handles the API call to scan a host right away.
+func (api *API) hostdbHostsScanHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.ScanHost(pk); err != nil {
+		WriteError(w, Error{"unable to scan host: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbHostsOfflineHandlerPOST handles the API call to mark a host offline.
+func (api *API) hostdbHostsOfflineHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.MarkHostOffline(pk); err != nil {
+		WriteError(w, Error{"unable to mark host offline: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostdbBenchmarkHandlerGET handles the API call to get the most recent
+// benchmarks of all benchmarked hosts.
+func (api *API) hostdbBenchmarkHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	hosts, err := api.renter.AllHosts()
+	if err != nil {
+		WriteError(w, Error{"unable to get hosts: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	results := make([]modules.HostBenchmarkResult, 0)
+	for _, host := range hosts {
+		if host.Benchmark == nil {
+			continue
+		}
+		results = append(results, modules.HostBenchmarkResult{
+			PublicKey:     host.PublicKey,
+			HostBenchmark: *host.Benchmark,
+		})
+	}
+	WriteJSON(w, HostdbBenchmarkGET{Hosts: results})
+}
+
+// hostdbBenchmarkHandlerPOST handles the API call to benchmark hosts. The
+// hosts are passed as a comma separated list of public keys. If no hosts are
+// passed, all hosts the renter has contracts with are benchmarked.
+func (api *API) hostdbBenchmarkHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var hosts []types.SiaPublicKey
+	if s := req.FormValue("hosts"); s != "" {
+		for _, str := range strings.Split(s, ",") {
+			var pk types.SiaPublicKey
+			if err := pk.LoadString(str); err != nil {
+				WriteError(w, Error{"unable to parse host public key: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			hosts = append(hosts, pk)
+		}
+	}
+	results, err := api.renter.BenchmarkHosts(hosts)
+	if err != nil {
+		WriteError(w, Error{"unable to benchmark hosts: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostdbBenchmarkGET{Hosts: results})
+}
@@ -0,0 +1,317 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// metricsContentType is the content type of the Prometheus text exposition
+	// format served by /metrics.
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// metricsNamespace prefixes the names of all exported metrics.
+	metricsNamespace = "sia"
+)
+
+type (
+	// metricsWriter writes metrics in the Prometheus text exposition format.
+	metricsWriter struct {
+		buf bytes.Buffer
+	}
+
+	// metricSample is a single labeled sample of a metric.
+	metricSample struct {
+		labels map[string]string
+		value  float64
+	}
+)
+
+// labelEscaper escapes label values as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writeFamily writes the HELP and TYPE lines of a metric followed by its
+// samples.
+func (mw *metricsWriter) writeFamily(name, help, typ string, samples ...metricSample) {
+	name = metricsNamespace + "_" + name
+	fmt.Fprintf(&mw.buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(&mw.buf, "# TYPE %s %s\n", name, typ)
+	for _, s := range samples {
+		mw.buf.WriteString(name)
+		if len(s.labels) > 0 {
+			keys := make([]string, 0, len(s.labels))
+			for k := range s.labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			pairs := make([]string, len(keys))
+			for i, k := range keys {
+				pairs[i] = k + `="` + labelEscaper.Replace(s.labels[k]) + `"`
+			}
+			mw.buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+		}
+		mw.buf.WriteString(" " + strconv.FormatFloat(s.value, 'g', -1, 64) + "\n")
+	}
+}
+
+// counter writes a single unlabeled counter.
+func (mw *metricsWriter) counter(name, help string, value float64) {
+	mw.writeFamily(name, help, "counter", metricSample{value: value})
+}
+
+// gauge writes a single unlabeled gauge.
+func (mw *metricsWriter) gauge(name, help string, value float64) {
+	mw.writeFamily(name, help, "gauge", metricSample{value: value})
+}
+
+// boolValue converts a bool to a metric value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// currencyValue converts a currency to a metric value in hastings. Values that
+// can't be represented exactly lose precision.
+func currencyValue(c types.Currency) float64 {
+	f, _ := c.Float64()
+	return f
+}
+
+// metricsHandler handles the API call to /metrics. Every module that is loaded
+// contributes its metrics. A module that fails to report a metric is skipped
+// rather than failing the whole scrape.
+func (api *API) metricsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", metricsContentType)
+	w.Write(api.metrics())
+}
+
+// metrics returns the metrics of the loaded modules in the Prometheus text
+// exposition format.
+func (api *API) metrics() []byte {
+	var mw metricsWriter
+	mw.gauge("uptime_seconds", "Number of seconds since siad started.", time.Since(api.staticStartTime).Seconds())
+	if api.cs != nil {
+		api.writeConsensusMetrics(&mw)
+	}
+	if api.gateway != nil {
+		api.writeGatewayMetrics(&mw)
+	}
+	if api.host != nil {
+		api.writeHostMetrics(&mw)
+	}
+	if api.renter != nil {
+		api.writeRenterMetrics(&mw)
+	}
+	if api.tpool != nil {
+		api.writeTransactionPoolMetrics(&mw)
+	}
+	if api.wallet != nil {
+		api.writeWalletMetrics(&mw)
+	}
+	return mw.buf.Bytes()
+}
+
+// writeConsensusMetrics writes the metrics of the consensus set.
+func (api *API) writeConsensusMetrics(mw *metricsWriter) {
+	cb := api.cs.CurrentBlock()
+	mw.gauge("consensus_height", "Height of the current block.", float64(api.cs.Height()))
+	mw.gauge("consensus_synced", "Whether the consensus set is synced with the network.", boolValue(api.cs.Synced()))
+	mw.gauge("consensus_block_timestamp_seconds", "Timestamp of the current block.", float64(cb.Timestamp))
+	target, _ := api.cs.ChildTarget(cb.ID())
+	mw.gauge("consensus_difficulty", "Difficulty of the next block.", currencyValue(target.Difficulty()))
+}
+
+// writeGatewayMetrics writes the metrics of the gateway.
+func (api *API) writeGatewayMetrics(mw *metricsWriter) {
+	var inbound, outbound float64
+	for _, p := range api.gateway.Peers() {
+		if p.Inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	mw.writeFamily("gateway_peers", "Number of connected peers.", "gauge",
+		metricSample{labels: map[string]string{"direction": "inbound"}, value: inbound},
+		metricSample{labels: map[string]string{"direction": "outbound"}, value: outbound},
+	)
+	up, down, _, err := api.gateway.BandwidthCounters()
+	if err == nil {
+		mw.writeFamily("gateway_bandwidth_bytes_total", "Number of bytes transferred with peers.", "counter",
+			metricSample{labels: map[string]string{"direction": "download"}, value: float64(down)},
+			metricSample{labels: map[string]string{"direction": "upload"}, value: float64(up)},
+		)
+	}
+}
+
+// writeHostMetrics writes the metrics of the host.
+func (api *API) writeHostMetrics(mw *metricsWriter) {
+	// Count the storage obligations by status, and the proofs that the host
+	// has constructed and confirmed.
+	statuses := make(map[string]float64)
+	var constructed, confirmed, dataSize float64
+	for _, so := range api.host.StorageObligations() {
+		status := strings.ToLower(strings.TrimPrefix(so.ObligationStatus, "obligation"))
+		statuses[status]++
+		constructed += boolValue(so.ProofConstructed)
+		confirmed += boolValue(so.ProofConfirmed)
+		if so.ObligationStatus == "obligationUnresolved" {
+			dataSize += float64(so.DataSize)
+		}
+	}
+	var obligations []metricSample
+	for _, status := range []string{"unresolved", "rejected", "succeeded", "failed"} {
+		obligations = append(obligations, metricSample{labels: map[string]string{"status": status}, value: statuses[status]})
+	}
+	mw.writeFamily("host_storage_obligations", "Number of storage obligations by status.", "gauge", obligations...)
+	mw.writeFamily("host_storage_proofs", "Number of storage proofs of the storage obligations.", "gauge",
+		metricSample{labels: map[string]string{"state": "constructed"}, value: constructed},
+		metricSample{labels: map[string]string{"state": "confirmed"}, value: confirmed},
+	)
+	mw.gauge("host_stored_bytes", "Size of the data of the unresolved storage obligations.", dataSize)
+
+	var total, remaining float64
+	for _, sf := range api.host.StorageFolders() {
+		total += float64(sf.Capacity)
+		remaining += float64(sf.CapacityRemaining)
+	}
+	mw.gauge("host_storage_capacity_bytes", "Total capacity of the storage folders.", total)
+	mw.gauge("host_storage_remaining_bytes", "Remaining capacity of the storage folders.", remaining)
+
+	fm := api.host.FinancialMetrics()
+	mw.gauge("host_contracts", "Number of active contracts.", float64(fm.ContractCount))
+	mw.gauge("host_locked_collateral_hastings", "Collateral locked in active contracts.", currencyValue(fm.LockedStorageCollateral))
+	mw.gauge("host_risked_collateral_hastings", "Collateral at risk in active contracts.", currencyValue(fm.RiskedStorageCollateral))
+	mw.counter("host_storage_revenue_hastings_total", "Storage revenue of completed contracts.", currencyValue(fm.StorageRevenue))
+	mw.counter("host_lost_collateral_hastings_total", "Collateral lost in failed contracts.", currencyValue(fm.LostStorageCollateral))
+
+	nm := api.host.NetworkMetrics()
+	mw.writeFamily("host_rpc_calls_total", "Number of RPC calls made to the host by type.", "counter",
+		metricSample{labels: map[string]string{"rpc": "download"}, value: float64(nm.DownloadCalls)},
+		metricSample{labels: map[string]string{"rpc": "error"}, value: float64(nm.ErrorCalls)},
+		metricSample{labels: map[string]string{"rpc": "formcontract"}, value: float64(nm.FormContractCalls)},
+		metricSample{labels: map[string]string{"rpc": "renew"}, value: float64(nm.RenewCalls)},
+		metricSample{labels: map[string]string{"rpc": "revise"}, value: float64(nm.ReviseCalls)},
+		metricSample{labels: map[string]string{"rpc": "settings"}, value: float64(nm.SettingsCalls)},
+		metricSample{labels: map[string]string{"rpc": "unrecognized"}, value: float64(nm.UnrecognizedCalls)},
+	)
+
+	rm := api.host.RegistryMetrics()
+	mw.gauge("host_registry_entries", "Number of entries stored in the registry.", float64(rm.EntriesStored))
+	mw.gauge("host_registry_capacity_entries", "Number of entries the registry can store.", float64(rm.EntriesTotal))
+	mw.writeFamily("host_registry_updates_total", "Number of registry updates by result.", "counter",
+		metricSample{labels: map[string]string{"result": "accepted"}, value: float64(rm.Updates)},
+		metricSample{labels: map[string]string{"result": "rejected"}, value: float64(rm.RejectedUpdates)},
+	)
+	mw.counter("host_registry_evicted_entries_total", "Number of registry entries evicted to make room for new entries.", float64(rm.EvictedEntries))
+	mw.counter("host_registry_pruned_entries_total", "Number of expired registry entries pruned.", float64(rm.PrunedEntries))
+}
+
+// writeRenterMetrics writes the metrics of the renter.
+func (api *API) writeRenterMetrics(mw *metricsWriter) {
+	mw.gauge("renter_contracts", "Number of active contracts.", float64(len(api.renter.Contracts())))
+
+	if settings, err := api.renter.Settings(); err == nil {
+		mw.gauge("renter_uploads_paused", "Whether uploads and repairs are paused.", boolValue(settings.UploadsStatus.Paused))
+	}
+	if dis, err := api.renter.DirList(modules.RootSiaPath()); err == nil && len(dis) > 0 {
+		root := dis[0]
+		mw.gauge("renter_files", "Number of files.", float64(root.AggregateNumFiles))
+		mw.gauge("renter_files_bytes", "Total size of the files.", float64(root.AggregateSize))
+		mw.gauge("renter_repair_bytes", "Amount of data that needs to be uploaded or repaired.", float64(root.AggregateRepairSize))
+		mw.gauge("renter_stuck_bytes", "Amount of data that needs to be repaired but is stuck.", float64(root.AggregateStuckSize))
+		mw.gauge("renter_stuck_chunks", "Number of stuck chunks.", float64(root.AggregateNumStuckChunks))
+		mw.gauge("renter_health", "Health of the least healthy file, where values above 1 are unrecoverable.", root.AggregateHealth)
+		mw.gauge("renter_min_redundancy", "Redundancy of the least redundant file.", root.AggregateMinRedundancy)
+	}
+
+	var active float64
+	for _, d := range api.renter.DownloadHistory() {
+		if !d.Completed {
+			active++
+		}
+	}
+	mw.gauge("renter_downloads_active", "Number of downloads in progress.", active)
+
+	wps, err := api.renter.WorkerPoolStatus()
+	if err != nil {
+		return
+	}
+	var downloadQueue, uploadQueue float64
+	for _, ws := range wps.Workers {
+		downloadQueue += float64(ws.DownloadQueueSize)
+		uploadQueue += float64(ws.UploadQueueSize)
+	}
+	mw.gauge("renter_workers", "Number of workers.", float64(wps.NumWorkers))
+	mw.writeFamily("renter_worker_queue_size", "Number of jobs queued across all workers.", "gauge",
+		metricSample{labels: map[string]string{"queue": "download"}, value: downloadQueue},
+		metricSample{labels: map[string]string{"queue": "upload"}, value: uploadQueue},
+	)
+	mw.writeFamily("renter_workers_on_cooldown", "Number of workers on cooldown.", "gauge",
+		metricSample{labels: map[string]string{"type": "download"}, value: float64(wps.TotalDownloadCoolDown)},
+		metricSample{labels: map[string]string{"type": "maintenance"}, value: float64(wps.TotalMaintenanceCoolDown)},
+		metricSample{labels: map[string]string{"type": "upload"}, value: float64(wps.TotalUploadCoolDown)},
+	)
+}
+
+// writeTransactionPoolMetrics writes the metrics of the transaction pool.
+func (api *API) writeTransactionPoolMetrics(mw *metricsWriter) {
+	var size float64
+	txns := api.tpool.TransactionList()
+	for _, txn := range txns {
+		size += float64(txn.MarshalSiaSize())
+	}
+	mw.gauge("tpool_transactions", "Number of unconfirmed transactions.", float64(len(txns)))
+	mw.gauge("tpool_bytes", "Size of the unconfirmed transactions.", size)
+	minFee, maxFee := api.tpool.FeeEstimation()
+	mw.writeFamily("tpool_fee_estimate_hastings", "Recommended transaction fee per byte.", "gauge",
+		metricSample{labels: map[string]string{"bound": "min"}, value: currencyValue(minFee)},
+		metricSample{labels: map[string]string{"bound": "max"}, value: currencyValue(maxFee)},
+	)
+}
+
+// writeWalletMetrics writes the metrics of the wallet. Balances are only
+// reported while the wallet is unlocked.
+func (api *API) writeWalletMetrics(mw *metricsWriter) {
+	unlocked, err := api.wallet.Unlocked()
+	if err != nil {
+		return
+	}
+	mw.gauge("wallet_unlocked", "Whether the wallet is unlocked.", boolValue(unlocked))
+	if rescanning, err := api.wallet.Rescanning(); err == nil {
+		mw.gauge("wallet_rescanning", "Whether the wallet is rescanning the blockchain.", boolValue(rescanning))
+	}
+	if height, err := api.wallet.Height(); err == nil {
+		mw.gauge("wallet_height", "Height of the most recent block processed by the wallet.", float64(height))
+	}
+	if !unlocked {
+		return
+	}
+	siacoins, siafunds, claims, err := api.wallet.ConfirmedBalance()
+	if err != nil {
+		return
+	}
+	mw.gauge("wallet_siacoin_balance_hastings", "Confirmed siacoin balance.", currencyValue(siacoins))
+	mw.gauge("wallet_siafund_balance", "Confirmed siafund balance.", currencyValue(siafunds))
+	mw.gauge("wallet_siafund_claim_balance_hastings", "Siacoins claimable from the siafund pool.", currencyValue(claims))
+	outgoing, incoming, err := api.wallet.UnconfirmedBalance()
+	if err != nil {
+		return
+	}
+	mw.writeFamily("wallet_unconfirmed_hastings", "Siacoins in unconfirmed transactions.", "gauge",
+		metricSample{labels: map[string]string{"direction": "incoming"}, value: currencyValue(incoming)},
+		metricSample{labels: map[string]string{"direction": "outgoing"}, value: currencyValue(outgoing)},
+	)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestMetricsGET probes the GET call to /metrics.
+func TestMetricsGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Monitoring tools can't set the user agent, so the endpoint must be
+	// reachable without it.
+	resp, err := http.Get("http://" + st.server.listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status code", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != metricsContentType {
+		t.Fatal("unexpected content type", ct)
+	}
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := string(b)
+
+	expected := []string{
+		fmt.Sprintf("sia_consensus_height %v\n", st.cs.Height()),
+		"# TYPE sia_gateway_bandwidth_bytes_total counter\n",
+		`sia_host_storage_obligations{status="unresolved"} 0` + "\n",
+		"sia_renter_contracts 0\n",
+		"sia_tpool_transactions 0\n",
+		"sia_wallet_unlocked 1\n",
+	}
+	for _, e := range expected {
+		if !strings.Contains(metrics, e) {
+			t.Errorf("metrics don't contain %q:\n%v", e, metrics)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -19,6 +20,17 @@ type (
 		CPUMining        bool `json:"cpumining"`
 		StaleBlocksMined int  `json:"staleblocksmined"`
 	}
+
+	// MinerTemplateGET contains the settings used by the miner to build block
+	// templates.
+	MinerTemplateGET struct {
+		Settings modules.MinerTemplateSettings `json:"settings"`
+	}
+
+	// MinerTemplatePOST is the body of a POST request to /miner/template.
+	MinerTemplatePOST struct {
+		Settings modules.MinerTemplateSettings `json:"settings"`
+	}
 )
 
 // RegisterRoutesMiner is a helper function to register all miner routes.
@@ -35,6 +47,12 @@ func RegisterRoutesMiner(router *httprouter.Router, m modules.Miner, requiredPas
 	router.POST("/miner/header", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		minerHeaderHandlerPOST(m, w, req, ps)
 	}, requiredPassword))
+	router.GET("/miner/template", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		minerTemplateHandlerGET(m, w, req, ps)
+	})
+	router.POST("/miner/template", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		minerTemplateHandlerPOST(m, w, req, ps)
+	}, requiredPassword))
 	router.GET("/miner/start", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		minerStartHandler(m, w, req, ps)
 	}, requiredPassword))
@@ -111,3 +129,26 @@ func minerBlockHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *http
 	}
 	WriteSuccess(w)
 }
+
+// minerTemplateHandlerGET handles the API call that returns the block template
+// settings of the miner.
+func minerTemplateHandlerGET(miner modules.Miner, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, MinerTemplateGET{miner.TemplateSettings()})
+}
+
+// minerTemplateHandlerPOST handles the API call that changes the block
+// template settings of the miner.
+func minerTemplateHandlerPOST(miner modules.Miner, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params MinerTemplatePOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = miner.SetTemplateSettings(params.Settings)
+	if err != nil {
+		WriteError(w, Error{"failed to set the template settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// OperationUploads, OperationDownloads and OperationRescans are the kinds
+	// of expensive operations which are tracked by the API.
+	OperationUploads   = "uploads"
+	OperationDownloads = "downloads"
+	OperationRescans   = "rescans"
+
+	// DrainPhaseDraining, DrainPhaseFlushing and DrainPhaseStopping are the
+	// phases of a draining shutdown.
+	DrainPhaseDraining = "draining"
+	DrainPhaseFlushing = "flushing"
+	DrainPhaseStopping = "stopping"
+)
+
+var (
+	// drainDefaultTimeout is the maximum duration for which a draining
+	// shutdown waits for operations to complete if no timeout is provided.
+	drainDefaultTimeout = 5 * time.Minute
+
+	// drainProgressInterval is the interval at which the progress of a
+	// draining shutdown is reported.
+	drainProgressInterval = build.Select(build.Var{
+		Standard: time.Second,
+		Testnet:  time.Second,
+		Dev:      time.Second,
+		Testing:  50 * time.Millisecond,
+	}).(time.Duration)
+
+	// operationRoutes are the methods and path prefixes of the routes of each
+	// kind of expensive operation.
+	operationRoutes = map[string][]string{
+		OperationUploads: {
+			"POST /renter/pack/",
+			"POST /renter/upload/",
+			"POST /renter/uploaddir/",
+			"POST /renter/uploadstream/",
+			"POST /renter/uploadurl/",
+		},
+		OperationDownloads: {
+			"GET /renter/download/",
+			"GET /renter/downloadasync/",
+			"GET /renter/downloaddir/",
+			"GET /renter/stream/",
+		},
+		OperationRescans: {
+			"POST /wallet/init/seed",
+			"POST /wallet/rescan",
+			"POST /wallet/seed",
+			"POST /wallet/sweep/seed",
+		},
+	}
+)
+
+// DaemonStopProgress reports the progress of a draining shutdown. The counts
+// are the operations which are still in progress.
+type DaemonStopProgress struct {
+	Phase string `json:"phase"`
+
+	// Uploads, Downloads and Rescans are the calls to the API which are
+	// being served.
+	Uploads   int `json:"uploads"`
+	Downloads int `json:"downloads"`
+	Rescans   int `json:"rescans"`
+
+	// BackgroundDownloads and BackgroundURLUploads are the downloads and URL
+	// uploads of the renter which continue after the call which started them
+	// returned.
+	BackgroundDownloads  int `json:"backgrounddownloads"`
+	BackgroundURLUploads int `json:"backgroundurluploads"`
+
+	// TimedOut is set if the operations didn't complete before the timeout
+	// and Error contains the error of flushing the renter's packs.
+	TimedOut bool   `json:"timedout,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// operationTracker tracks the expensive operations which are served by the
+// API. Once it is draining, no new operations are started.
+type operationTracker struct {
+	inFlight map[string]int
+	draining bool
+	mu       sync.Mutex
+}
+
+// newOperationTracker returns a new operation tracker.
+func newOperationTracker() *operationTracker {
+	return &operationTracker{
+		inFlight: make(map[string]int),
+	}
+}
+
+// operationKind returns the kind of expensive operation requested by a
+// request, or an empty string if it doesn't request one.
+func operationKind(req *http.Request) string {
+	route := req.Method + " " + req.URL.Path
+	for kind, routes := range operationRoutes {
+		for _, prefix := range routes {
+			if strings.HasPrefix(route, prefix) {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// managedStart registers the start of an operation. It returns false if the
+// tracker is draining and the operation must not be started.
+func (ot *operationTracker) managedStart(kind string) bool {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	if ot.draining {
+		return false
+	}
+	ot.inFlight[kind]++
+	return true
+}
+
+// managedDone registers the end of an operation.
+func (ot *operationTracker) managedDone(kind string) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.inFlight[kind]--
+}
+
+// StartOperation registers the start of an operation of the provided kind
+// which isn't served by the HTTP API, like the uploads and downloads of the
+// gRPC API, so that a draining shutdown waits for it to complete. It returns
+// false if siad is shutting down and the operation must not be started.
+// Otherwise OperationDone must be called once the operation is done.
+func (api *API) StartOperation(kind string) bool {
+	return api.staticOperations.managedStart(kind)
+}
+
+// OperationDone registers the end of an operation started with
+// StartOperation.
+func (api *API) OperationDone(kind string) {
+	api.staticOperations.managedDone(kind)
+}
+
+// managedDrain stops new operations from being started.
+func (ot *operationTracker) managedDrain() {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	ot.draining = true
+}
+
+// managedInFlight returns the number of operations of a kind which are in
+// progress.
+func (ot *operationTracker) managedInFlight(kind string) int {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+	return ot.inFlight[kind]
+}
+
+// done returns whether all operations are complete.
+func (p DaemonStopProgress) done() bool {
+	return p.Uploads+p.Downloads+p.Rescans+p.BackgroundDownloads+p.BackgroundURLUploads == 0
+}
+
+// managedStopProgress returns the operations which are still in progress.
+func (api *API) managedStopProgress(phase string) DaemonStopProgress {
+	p := DaemonStopProgress{
+		Phase:     phase,
+		Uploads:   api.staticOperations.managedInFlight(OperationUploads),
+		Downloads: api.staticOperations.managedInFlight(OperationDownloads),
+		Rescans:   api.staticOperations.managedInFlight(OperationRescans),
+	}
+	if api.renter != nil {
+		for _, d := range api.renter.DownloadHistory() {
+			if !d.Completed {
+				p.BackgroundDownloads++
+			}
+		}
+		for _, u := range api.renter.URLUploads() {
+			if !u.Completed {
+				p.BackgroundURLUploads++
+			}
+		}
+	}
+	return p
+}
+
+// managedDrain stops new operations from being started and waits up to the
+// timeout for the operations in progress to complete. Afterwards the packs of
+// the renter are flushed. The progress is written to w as a stream of JSON
+// objects.
+func (api *API) managedDrain(w http.ResponseWriter, timeout time.Duration) {
+	api.staticOperations.managedDrain()
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	report := func(p DaemonStopProgress) {
+		_ = enc.Encode(p)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	deadline := time.After(timeout)
+	p := api.managedStopProgress(DrainPhaseDraining)
+	report(p)
+	for !p.done() && !p.TimedOut {
+		select {
+		case <-deadline:
+			p.TimedOut = true
+		case <-time.After(drainProgressInterval):
+		}
+		timedOut := p.TimedOut
+		p = api.managedStopProgress(DrainPhaseDraining)
+		p.TimedOut = timedOut
+		report(p)
+	}
+
+	// Flush the packs of the renter so that the files which were packed are
+	// uploaded before shutting down.
+	if api.renter != nil && !p.TimedOut {
+		report(api.managedStopProgress(DrainPhaseFlushing))
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- api.renter.FlushPacks()
+		}()
+		p = api.managedStopProgress(DrainPhaseStopping)
+		select {
+		case err := <-errChan:
+			if err != nil {
+				p.Error = err.Error()
+			}
+		case <-deadline:
+			p.TimedOut = true
+		}
+		report(p)
+		return
+	}
+	p.Phase = DrainPhaseStopping
+	report(p)
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestOperationKind probes the classification of requests into operations.
+func TestOperationKind(t *testing.T) {
+	tests := []struct {
+		method, path, kind string
+	}{
+		{http.MethodPost, "/renter/upload/foo", OperationUploads},
+		{http.MethodGet, "/renter/upload/foo", ""},
+		{http.MethodGet, "/renter/stream/foo", OperationDownloads},
+		{http.MethodPost, "/wallet/rescan", OperationRescans},
+		{http.MethodGet, "/daemon/version", ""},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if kind := operationKind(req); kind != test.kind {
+			t.Errorf("%v %v: expected %q, got %q", test.method, test.path, test.kind, kind)
+		}
+	}
+}
+
+// TestDrainShutdown probes stopping the daemon in drain mode.
+func TestDrainShutdown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// newAPI creates an API whose Shutdown signals the returned channel.
+	newAPI := func(name string) (*API, chan struct{}) {
+		cfg, err := modules.NewConfig(filepath.Join(build.TempDir("api", t.Name(), name), modules.ConfigName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		api := New(cfg, "Sia-Agent", "", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		stopped := make(chan struct{})
+		api.Shutdown = func() error {
+			close(stopped)
+			return nil
+		}
+		return api, stopped
+	}
+	// serve sends a request to the API.
+	serve := func(api *API, method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("User-Agent", "Sia-Agent")
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+	// progress decodes the progress reports of a response.
+	progress := func(w *httptest.ResponseRecorder) []DaemonStopProgress {
+		var reports []DaemonStopProgress
+		dec := json.NewDecoder(strings.NewReader(w.Body.String()))
+		for dec.More() {
+			var p DaemonStopProgress
+			if err := dec.Decode(&p); err != nil {
+				t.Fatal(err)
+			}
+			reports = append(reports, p)
+		}
+		return reports
+	}
+
+	// Start an upload and stop the daemon while it is in progress.
+	api, stopped := newAPI("drain")
+	if !api.staticOperations.managedStart(OperationUploads) {
+		t.Fatal("upload should be started")
+	}
+	stopDone := make(chan *httptest.ResponseRecorder)
+	go func() {
+		stopDone <- serve(api, http.MethodGet, "/daemon/stop?drain=true&timeout=60")
+	}()
+	err := build.Retry(100, 10*time.Millisecond, func() error {
+		if api.staticOperations.managedStart(OperationDownloads) {
+			api.staticOperations.managedDone(OperationDownloads)
+			return errors.New("not draining yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New operations are rejected, other calls are served.
+	if w := serve(api, http.MethodPost, "/renter/upload/foo"); w.Code != http.StatusServiceUnavailable {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	if w := serve(api, http.MethodGet, "/daemon/version"); w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("daemon stopped before the upload completed")
+	case <-time.After(5 * drainProgressInterval):
+	}
+
+	// Once the upload is complete, the daemon stops.
+	api.staticOperations.managedDone(OperationUploads)
+	w := <-stopDone
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon wasn't stopped")
+	}
+	if w.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatal("wrong content type", w.Header().Get("Content-Type"))
+	}
+	reports := progress(w)
+	if len(reports) < 3 || reports[0].Phase != DrainPhaseDraining || reports[0].Uploads != 1 {
+		t.Fatal("wrong initial progress", reports)
+	}
+	last := reports[len(reports)-1]
+	if last.Phase != DrainPhaseStopping || last.Uploads != 0 || last.TimedOut {
+		t.Fatal("wrong final progress", last)
+	}
+
+	// If the operations don't complete before the timeout, the daemon is
+	// stopped anyway.
+	api, stopped = newAPI("timeout")
+	api.staticOperations.managedStart(OperationRescans)
+	w = serve(api, http.MethodGet, "/daemon/stop?drain=true&timeout=0")
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("daemon wasn't stopped")
+	}
+	reports = progress(w)
+	last = reports[len(reports)-1]
+	if last.Phase != DrainPhaseStopping || last.Rescans != 1 || !last.TimedOut {
+		t.Fatal("wrong final progress", last)
+	}
+
+	// Invalid parameters are rejected.
+	api, _ = newAPI("invalid")
+	if w := serve(api, http.MethodGet, "/daemon/stop?drain=maybe"); w.Code != http.StatusBadRequest {
+		t.Fatal("unexpected status code", w.Code)
+	}
+}
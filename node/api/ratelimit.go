@@ -0,0 +1,252 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// operationRetryAfter is the number of seconds after which clients are
+	// asked to retry an expensive operation which was rejected because too
+	// many of them are in progress.
+	operationRetryAfter = 1
+
+	// rateLimitSweepInterval is the interval at which the buckets of clients
+	// which haven't made any requests recently are removed.
+	rateLimitSweepInterval = time.Minute
+)
+
+type (
+	// RateLimitPolicy describes the rate limits of the API and the maximum
+	// number of expensive operations which are served concurrently. Zero
+	// values disable the respective limit.
+	RateLimitPolicy struct {
+		// RequestsPerSecond and Burst limit the requests of each client,
+		// which are identified by their IP address. Burst defaults to the
+		// number of requests per second.
+		RequestsPerSecond float64
+		Burst             int
+
+		// EndpointLimits are additional limits of each client for the
+		// endpoints with a certain prefix. The limit with the longest
+		// matching prefix applies.
+		EndpointLimits []EndpointRateLimit
+
+		// MaxUploads, MaxDownloads and MaxRescans cap the number of uploads,
+		// downloads and wallet rescans which are served concurrently.
+		MaxUploads   int
+		MaxDownloads int
+		MaxRescans   int
+	}
+
+	// EndpointRateLimit limits the requests of each client to the endpoints
+	// with a certain prefix.
+	EndpointRateLimit struct {
+		Prefix            string
+		RequestsPerSecond float64
+		Burst             int
+	}
+
+	// rateLimiter enforces a RateLimitPolicy.
+	rateLimiter struct {
+		staticPolicy RateLimitPolicy
+
+		// buckets contains the token buckets of the clients, keyed by the
+		// client and the prefix of the endpoint limit.
+		buckets   map[string]*tokenBucket
+		lastSweep time.Time
+		mu        sync.Mutex
+
+		// staticUploads, staticDownloads and staticRescans are semaphores
+		// which are nil if the concurrency of the operation isn't capped.
+		staticUploads   chan struct{}
+		staticDownloads chan struct{}
+		staticRescans   chan struct{}
+	}
+
+	// tokenBucket is a token bucket which is refilled at a fixed rate up to
+	// its burst.
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+		rate   float64
+		burst  float64
+	}
+)
+
+// SetRateLimitPolicy sets the rate limits of the API. It must be called before
+// the API is served.
+func (api *API) SetRateLimitPolicy(policy RateLimitPolicy) {
+	api.staticRateLimiter = newRateLimiter(policy)
+}
+
+// newRateLimiter returns a rate limiter enforcing the provided policy.
+func newRateLimiter(policy RateLimitPolicy) *rateLimiter {
+	semaphore := func(n int) chan struct{} {
+		if n <= 0 {
+			return nil
+		}
+		return make(chan struct{}, n)
+	}
+	return &rateLimiter{
+		staticPolicy:    policy,
+		buckets:         make(map[string]*tokenBucket),
+		lastSweep:       time.Now(),
+		staticUploads:   semaphore(policy.MaxUploads),
+		staticDownloads: semaphore(policy.MaxDownloads),
+		staticRescans:   semaphore(policy.MaxRescans),
+	}
+}
+
+// defaultBurst returns the burst of a limit which doesn't specify one.
+func defaultBurst(rate float64, burst int) float64 {
+	if burst > 0 {
+		return float64(burst)
+	}
+	return math.Max(1, math.Ceil(rate))
+}
+
+// refill adds the tokens which accumulated since the last refill.
+func (tb *tokenBucket) refill(now time.Time) {
+	tb.tokens = math.Min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	tb.last = now
+}
+
+// take takes a token from the bucket and returns whether a token was
+// available. Otherwise it returns the time until the next token is available.
+func (tb *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	tb.refill(now)
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+}
+
+// endpointLimit returns the endpoint limit with the longest prefix matching
+// the path of a request.
+func (p RateLimitPolicy) endpointLimit(path string) (EndpointRateLimit, bool) {
+	var limit EndpointRateLimit
+	found := false
+	for _, el := range p.EndpointLimits {
+		if strings.HasPrefix(path, el.Prefix) && (!found || len(el.Prefix) > len(limit.Prefix)) {
+			limit, found = el, true
+		}
+	}
+	return limit, found
+}
+
+// managedTake takes a token from the bucket of the client for the provided
+// key. It returns whether the request is allowed and otherwise the time until
+// it would be allowed.
+func (rl *rateLimiter) managedTake(key string, rate float64, burst int) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+
+	// Remove the buckets which are full again, they are equivalent to new
+	// buckets.
+	if now.Sub(rl.lastSweep) > rateLimitSweepInterval {
+		for k, tb := range rl.buckets {
+			if tb.refill(now); tb.tokens >= tb.burst {
+				delete(rl.buckets, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	tb, exists := rl.buckets[key]
+	if !exists {
+		b := defaultBurst(rate, burst)
+		tb = &tokenBucket{tokens: b, last: now, rate: rate, burst: b}
+		rl.buckets[key] = tb
+	}
+	return tb.take(now)
+}
+
+// operationSemaphore returns the semaphore capping the concurrency of the
+// provided kind of operation, or nil if it isn't capped.
+func (rl *rateLimiter) operationSemaphore(kind string) chan struct{} {
+	switch kind {
+	case OperationUploads:
+		return rl.staticUploads
+	case OperationDownloads:
+		return rl.staticDownloads
+	case OperationRescans:
+		return rl.staticRescans
+	}
+	return nil
+}
+
+// managedAdmitCall checks the rate limits of a client for a call to an
+// endpoint and the concurrency cap of the kind of operation it requests. It
+// returns a function which must be called once an admitted call was served.
+// Rejected calls return an error and the duration after which the client
+// should retry.
+func (rl *rateLimiter) managedAdmitCall(client, endpoint, kind string) (func(), time.Duration, error) {
+	policy := rl.staticPolicy
+	if policy.RequestsPerSecond > 0 {
+		if ok, wait := rl.managedTake(client, policy.RequestsPerSecond, policy.Burst); !ok {
+			return nil, wait, errors.New("rate limit exceeded")
+		}
+	}
+	if el, exists := policy.endpointLimit(endpoint); exists && el.RequestsPerSecond > 0 {
+		if ok, wait := rl.managedTake(client+" "+el.Prefix, el.RequestsPerSecond, el.Burst); !ok {
+			return nil, wait, fmt.Errorf("rate limit of %v exceeded", el.Prefix)
+		}
+	}
+
+	semaphore := rl.operationSemaphore(kind)
+	if semaphore == nil {
+		return func() {}, 0, nil
+	}
+	select {
+	case semaphore <- struct{}{}:
+	default:
+		return nil, operationRetryAfter * time.Second, errors.New("too many operations of this kind in progress")
+	}
+	return func() { <-semaphore }, 0, nil
+}
+
+// managedAdmit checks the rate limits and concurrency caps for a request and
+// rejects it with 429 Too Many Requests if it exceeds them. It returns whether
+// the request may be served and a function which must be called once it was
+// served.
+func (rl *rateLimiter) managedAdmit(w http.ResponseWriter, req *http.Request) (func(), bool) {
+	client, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		client = req.RemoteAddr
+	}
+	done, retryAfter, err := rl.managedAdmitCall(client, req.URL.Path, operationKind(req))
+	if err != nil {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		WriteError(w, Error{err.Error()}, http.StatusTooManyRequests)
+		return nil, false
+	}
+	return done, true
+}
+
+// AdmitCall checks the rate limits and concurrency caps of the API for a call
+// which isn't served by the HTTP API, like the calls to the gRPC API. The
+// client is identified by its IP address and kind is the kind of operation
+// the call requests, if any. An admitted call must call done once it was
+// served. Rejected calls return an error and the duration after which the
+// client should retry.
+func (api *API) AdmitCall(client, endpoint, kind string) (done func(), retryAfter time.Duration, err error) {
+	if api.staticRateLimiter == nil {
+		return func() {}, 0, nil
+	}
+	return api.staticRateLimiter.managedAdmitCall(client, endpoint, kind)
+}
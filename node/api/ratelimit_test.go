@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestRateLimit probes the rate limits of the API.
+func TestRateLimit(t *testing.T) {
+	cfg, err := modules.NewConfig(filepath.Join(build.TempDir("api", t.Name()), modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	api.SetRateLimitPolicy(RateLimitPolicy{
+		RequestsPerSecond: 0.001,
+		Burst:             3,
+		EndpointLimits: []EndpointRateLimit{
+			{Prefix: "/daemon", RequestsPerSecond: 100},
+			{Prefix: "/daemon/constants", RequestsPerSecond: 0.001},
+		},
+	})
+
+	// serve sends a request from the provided client to the API.
+	serve := func(client, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", "Sia-Agent")
+		req.RemoteAddr = client + ":1234"
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	// The endpoint limit with the longest prefix applies in addition to the
+	// limit of the client.
+	if w := serve("1.2.3.4", "/daemon/constants"); w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	w := serve("1.2.3.4", "/daemon/constants")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("missing Retry-After header")
+	}
+
+	// The burst of the client is used up by the third request.
+	if w := serve("1.2.3.4", "/daemon/version"); w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code)
+	}
+	if w := serve("1.2.3.4", "/daemon/version"); w.Code != http.StatusTooManyRequests {
+		t.Fatal("unexpected status code", w.Code)
+	}
+
+	// Other clients are not affected.
+	if w := serve("5.6.7.8", "/daemon/version"); w.Code != http.StatusOK {
+		t.Fatal("unexpected status code", w.Code)
+	}
+}
+
+// TestOperationCap probes the concurrency caps of expensive operations.
+func TestOperationCap(t *testing.T) {
+	rl := newRateLimiter(RateLimitPolicy{MaxUploads: 1})
+	upload := httptest.NewRequest(http.MethodPost, "/renter/upload/foo", nil)
+
+	done, ok := rl.managedAdmit(httptest.NewRecorder(), upload)
+	if !ok {
+		t.Fatal("first upload should be admitted")
+	}
+	w := httptest.NewRecorder()
+	if _, ok := rl.managedAdmit(w, upload); ok {
+		t.Fatal("second upload shouldn't be admitted")
+	}
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "1" {
+		t.Fatal("wrong response", w.Code, w.Header())
+	}
+
+	// Downloads aren't capped.
+	download := httptest.NewRequest(http.MethodGet, "/renter/download/foo", nil)
+	if _, ok := rl.managedAdmit(httptest.NewRecorder(), download); !ok {
+		t.Fatal("download should be admitted")
+	}
+
+	// Once the first upload is done, another one is admitted.
+	done()
+	if _, ok := rl.managedAdmit(httptest.NewRecorder(), upload); !ok {
+		t.Fatal("upload should be admitted")
+	}
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"sort"
@@ -23,10 +27,21 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/modules/renter/contractor"
+	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
+const (
+	// checksumsDefaultPageSize is the number of chunks returned by
+	// /renter/checksums if no limit is provided.
+	checksumsDefaultPageSize = 100
+
+	// checksumsMaxPageSize is the maximum number of chunks returned by
+	// /renter/checksums.
+	checksumsMaxPageSize = 1000
+)
+
 var (
 	// requiredHosts specifies the minimum number of hosts that must be set in
 	// the renter settings for the renter settings to be valid. This minimum is
@@ -92,6 +107,19 @@ type (
 		MemoryStatus modules.MemoryStatus `json:"memorystatus"`
 	}
 
+	// RenterBulkPOST contains the files matched by a call to /renter/bulk
+	// and the errors of the files the action failed for.
+	RenterBulkPOST struct {
+		SiaPaths []modules.SiaPath `json:"siapaths"`
+		Errors   []RenterBulkError `json:"errors"`
+	}
+
+	// RenterBulkError is the error of a file that a bulk action failed for.
+	RenterBulkError struct {
+		SiaPath modules.SiaPath `json:"siapath"`
+		Error   string          `json:"error"`
+	}
+
 	// RenterContract represents a contract formed by the renter.
 	RenterContract struct {
 		// Amount of contract funds that have been spent on downloads.
@@ -152,13 +180,14 @@ type (
 		InactiveContracts []RenterContract `json:"inactivecontracts"`
 
 		// Current Fields
-		ActiveContracts           []RenterContract              `json:"activecontracts"`
-		PassiveContracts          []RenterContract              `json:"passivecontracts"`
-		RefreshedContracts        []RenterContract              `json:"refreshedcontracts"`
-		DisabledContracts         []RenterContract              `json:"disabledcontracts"`
-		ExpiredContracts          []RenterContract              `json:"expiredcontracts"`
-		ExpiredRefreshedContracts []RenterContract              `json:"expiredrefreshedcontracts"`
-		RecoverableContracts      []modules.RecoverableContract `json:"recoverablecontracts"`
+		ActiveContracts           []RenterContract                `json:"activecontracts"`
+		PassiveContracts          []RenterContract                `json:"passivecontracts"`
+		RefreshedContracts        []RenterContract                `json:"refreshedcontracts"`
+		DisabledContracts         []RenterContract                `json:"disabledcontracts"`
+		ExpiredContracts          []RenterContract                `json:"expiredcontracts"`
+		ExpiredRefreshedContracts []RenterContract                `json:"expiredrefreshedcontracts"`
+		RecoverableContracts      []modules.RecoverableContract   `json:"recoverablecontracts"`
+		UnrecoverableContracts    []modules.UnrecoverableContract `json:"unrecoverablecontracts"`
 	}
 
 	// RenterDirectory lists the files and directories contained in the queried
@@ -199,11 +228,25 @@ type (
 		modules.RenterPriceEstimation
 		modules.Allowance
 	}
+	// RenterAllowancePlanGET contains the contract formation plan for a
+	// proposed allowance.
+	RenterAllowancePlanGET struct {
+		modules.ContractFormationPlan
+	}
+	// RenterGougingGET contains the hosts which are filtered by the renter's
+	// price gouging checks.
+	RenterGougingGET struct {
+		modules.GougingReport
+	}
+	// RenterForecastGET contains the projected spending of the renter's
+	// allowance for the current period.
+	RenterForecastGET struct {
+		modules.SpendingForecast
+	}
 	// RenterRecoveryStatusGET returns information about potential contract
 	// recovery scans.
 	RenterRecoveryStatusGET struct {
-		ScanInProgress bool              `json:"scaninprogress"`
-		ScannedHeight  types.BlockHeight `json:"scannedheight"`
+		modules.ContractRecoveryStatus
 	}
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
@@ -226,6 +269,24 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterContractsImportPOST contains the number of contracts imported by
+	// /renter/contracts/import.
+	RenterContractsImportPOST struct {
+		Imported int `json:"imported"`
+	}
+
+	// RenterShareKeyGET contains the hex encoded public key other renters use
+	// to create shares for the renter.
+	RenterShareKeyGET struct {
+		ShareKey string `json:"sharekey"`
+	}
+
+	// RenterShareImportPOST contains the number of files imported by
+	// /renter/share/import.
+	RenterShareImportPOST struct {
+		Imported int `json:"imported"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -242,6 +303,57 @@ type (
 		ParityPieces int `json:"paritypieces"`
 	}
 
+	// RenterURLUploadsGET lists the renter's URL uploads.
+	RenterURLUploadsGET struct {
+		Uploads []modules.URLUploadInfo `json:"uploads"`
+	}
+
+	// RenterReshardsGET lists the renter's changes of the erasure coding
+	// parameters of files.
+	RenterReshardsGET struct {
+		Reshards []modules.ReshardInfo `json:"reshards"`
+	}
+
+	// RenterPacksGET lists the renter's small files which are packed into
+	// shared packs.
+	RenterPacksGET struct {
+		PackedFiles []modules.PackedFileInfo `json:"packedfiles"`
+	}
+
+	// RenterPaymentBudgetsGET lists the renter's payment budgets.
+	RenterPaymentBudgetsGET struct {
+		Budgets []modules.PaymentBudget `json:"budgets"`
+	}
+
+	// RenterPaymentBudgetGET contains a payment budget and the receipts of
+	// the programs which were paid from it.
+	RenterPaymentBudgetGET struct {
+		Budget   modules.PaymentBudget    `json:"budget"`
+		Receipts []modules.PaymentReceipt `json:"receipts"`
+	}
+
+	// RenterProgramPOST is the body of a request to execute an MDM program on
+	// a host. A zero MaxCost doesn't limit the cost beyond the payment
+	// budget.
+	RenterProgramPOST struct {
+		HostPublicKey types.SiaPublicKey           `json:"hostpublickey"`
+		Instructions  []modules.ProgramInstruction `json:"instructions"`
+		MaxCost       types.Currency               `json:"maxcost"`
+	}
+
+	// RenterProgramCostPOST contains the cost of executing an MDM program on
+	// a host.
+	RenterProgramCostPOST struct {
+		Cost types.Currency `json:"cost"`
+	}
+
+	// RenterAuditsGET contains the audit settings and the reports of the
+	// most recent audits.
+	RenterAuditsGET struct {
+		Settings modules.AuditSettings `json:"settings"`
+		Reports  []modules.AuditReport `json:"reports"`
+	}
+
 	// DownloadInfo contains all client-facing information of a file.
 	DownloadInfo struct {
 		Destination     string          `json:"destination"`     // The destination of the download.
@@ -459,10 +571,10 @@ func (api *API) renterBackupsCreateHandlerPOST(w http.ResponseWriter, req *http.
 		_ = os.RemoveAll(backupPath)
 	}()
 
-	// Get the wallet seed.
-	ws, _, err := api.wallet.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := api.wallet.PrimaryKeySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{"failed to get wallet's primary key seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -506,10 +618,10 @@ func (api *API) renterBackupsRestoreHandlerGET(w http.ResponseWriter, req *http.
 		WriteError(w, Error{"failed to download backup: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-	// Get the wallet seed.
-	ws, _, err := api.wallet.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := api.wallet.PrimaryKeySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{"failed to get wallet's primary key seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -539,10 +651,10 @@ func (api *API) renterBackupHandlerPOST(w http.ResponseWriter, req *http.Request
 		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
-	// Get the wallet seed.
-	ws, _, err := api.wallet.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := api.wallet.PrimaryKeySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{"failed to get wallet's primary key seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -559,6 +671,294 @@ func (api *API) renterBackupHandlerPOST(w http.ResponseWriter, req *http.Request
 	WriteSuccess(w)
 }
 
+// managedContractExportSecret derives the secret used to encrypt contract
+// exports from the wallet's key seed.
+func (api *API) managedContractExportSecret() (crypto.Hash, error) {
+	ws, err := api.wallet.PrimaryKeySeed()
+	if err != nil {
+		return crypto.Hash{}, errors.New("failed to get wallet's primary key seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	return crypto.HashAll(rs, modules.ContractExportKeySpecifier), nil
+}
+
+// renterContractsExportHandlerPOST handles the API calls to
+// /renter/contracts/export
+func (api *API) renterContractsExportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that destination was specified.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Derive the secret and wipe it afterwards.
+	secret, err := api.managedContractExportSecret()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer fastrand.Read(secret[:])
+	// Export the contracts.
+	if err := api.renter.ExportContracts(dst, secret[:32]); err != nil {
+		WriteError(w, Error{"failed to export contracts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractsImportHandlerPOST handles the API calls to
+// /renter/contracts/import
+func (api *API) renterContractsImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Derive the secret and wipe it afterwards.
+	secret, err := api.managedContractExportSecret()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer fastrand.Read(secret[:])
+	// Import the contracts.
+	n, err := api.renter.ImportContracts(src, secret[:32])
+	if err != nil {
+		WriteError(w, Error{"failed to import contracts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterContractsImportPOST{Imported: n})
+}
+
+// parseShareKey parses a hex encoded share key.
+func parseShareKey(s string) (crypto.X25519PublicKey, error) {
+	var xpk crypto.X25519PublicKey
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != len(xpk) {
+		return crypto.X25519PublicKey{}, errors.New("expected a hex encoded share key")
+	}
+	copy(xpk[:], b)
+	return xpk, nil
+}
+
+// renterShareKeyHandlerGET handles the API calls to /renter/share/key
+func (api *API) renterShareKeyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	xpk, err := api.renter.ShareKey()
+	if err != nil {
+		WriteError(w, Error{"failed to get share key: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterShareKeyGET{ShareKey: hex.EncodeToString(xpk[:])})
+}
+
+// renterShareExportHandlerPOST handles the API calls to /renter/share/export
+func (api *API) renterShareExportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that destination was specified.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the recipient's share key.
+	recipient, err := parseShareKey(req.FormValue("recipient"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse recipient: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the optional expiry.
+	var expiry time.Time
+	if req.FormValue("expiry") != "" {
+		timestamp, err := strconv.ParseInt(req.FormValue("expiry"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'expiry' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		expiry = time.Unix(timestamp, 0)
+	}
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the siapaths of the shared files.
+	if len(req.Form["siapath"]) == 0 {
+		WriteError(w, Error{"siapath not specified"}, http.StatusBadRequest)
+		return
+	}
+	var siaPaths []modules.SiaPath
+	for _, s := range req.Form["siapath"] {
+		siaPath, err := modules.NewSiaPath(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse siapath: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !root {
+			siaPath, err = rebaseInputSiaPath(siaPath)
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		siaPaths = append(siaPaths, siaPath)
+	}
+	// Export the share.
+	if err := api.renter.ExportShare(siaPaths, dst, recipient, expiry); err != nil {
+		WriteError(w, Error{"failed to export share: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterShareImportHandlerPOST handles the API calls to /renter/share/import
+func (api *API) renterShareImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the directory the files are imported into.
+	siaPath, err := parseBundleSiaPath(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Import the share.
+	n, err := api.renter.ImportShare(src, siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to import share: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterShareImportPOST{Imported: n})
+}
+
+// renterCustodyTransferHandlerPOST handles the API calls to
+// /renter/custody/transfer
+func (api *API) renterCustodyTransferHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that destination was specified.
+	dst := req.FormValue("destination")
+	if dst == "" {
+		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the recipient's share key.
+	recipient, err := parseShareKey(req.FormValue("recipient"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse recipient: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseBundleSiaPath(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Transfer the custody.
+	ct, err := api.renter.TransferCustody(siaPath, dst, recipient)
+	if err != nil {
+		WriteError(w, Error{"failed to transfer custody: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ct)
+}
+
+// renterCustodyImportHandlerPOST handles the API calls to
+// /renter/custody/import
+func (api *API) renterCustodyImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Check that source was specified.
+	src := req.FormValue("source")
+	if src == "" {
+		WriteError(w, Error{"source not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The source needs to be an absolute path.
+	if !filepath.IsAbs(src) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := parseBundleSiaPath(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Import the custody bundle.
+	ct, err := api.renter.ImportCustody(src, siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to import custody: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ct)
+}
+
+// renterCustodyConfirmHandlerPOST handles the API calls to
+// /renter/custody/confirm
+func (api *API) renterCustodyConfirmHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ct, err := api.renter.ConfirmCustodyTransfer()
+	if err != nil {
+		WriteError(w, Error{"failed to confirm custody transfer: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ct)
+}
+
+// renterCustodyCancelHandlerPOST handles the API calls to
+// /renter/custody/cancel
+func (api *API) renterCustodyCancelHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	ct, err := api.renter.CancelCustodyTransfer()
+	if err != nil {
+		WriteError(w, Error{"failed to cancel custody transfer: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ct)
+}
+
+// parseBundleSiaPath parses the optional 'siapath' and 'root' args of the
+// share and custody endpoints. The siapath defaults to the root directory.
+func parseBundleSiaPath(req *http.Request) (modules.SiaPath, error) {
+	root, err := scanBool(req.FormValue("root"))
+	if err != nil {
+		return modules.SiaPath{}, errors.AddContext(err, "unable to parse root flag")
+	}
+	siaPath := modules.RootSiaPath()
+	if s := req.FormValue("siapath"); s != "" {
+		siaPath, err = modules.NewSiaPath(s)
+		if err != nil {
+			return modules.SiaPath{}, errors.AddContext(err, "unable to parse siapath")
+		}
+	}
+	if !root {
+		return rebaseInputSiaPath(siaPath)
+	}
+	return siaPath, nil
+}
+
 // renterBackupHandlerPOST handles the API calls to /renter/recoverbackup
 func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Check that source was specified.
@@ -572,10 +972,10 @@ func (api *API) renterLoadBackupHandlerPOST(w http.ResponseWriter, req *http.Req
 		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
 		return
 	}
-	// Get the wallet seed.
-	ws, _, err := api.wallet.PrimarySeed()
+	// Get the wallet's key seed.
+	ws, err := api.wallet.PrimaryKeySeed()
 	if err != nil {
-		WriteError(w, Error{"failed to get wallet's primary seed"}, http.StatusInternalServerError)
+		WriteError(w, Error{"failed to get wallet's primary key seed"}, http.StatusInternalServerError)
 		return
 	}
 	// Derive the renter seed and wipe the memory once we are done using it.
@@ -601,7 +1001,13 @@ func parseErasureCodingParameters(strDataPieces, strParityPieces string) (module
 	if err != nil {
 		return nil, err
 	}
+	return NewErasureCoder(dataPieces, parityPieces)
+}
 
+// NewErasureCoder creates the erasure coder for the provided data and parity
+// pieces after checking that they provide the required redundancy. It returns
+// nil if both are zero, in which case the renter's defaults are used.
+func NewErasureCoder(dataPieces, parityPieces int) (modules.ErasureCoder, error) {
 	// Check if data and parity pieces were set
 	if dataPieces == 0 && parityPieces == 0 {
 		return nil, nil
@@ -685,250 +1091,253 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httpr
 	})
 }
 
-// renterHandlerPOST handles the API call to set the Renter's settings. This API
-// call handles multiple settings and so each setting is optional on it's own.
-// Groups of settings, such as the allowance, have certain requirements if they
-// are being set in which case certain fields are no longer optional.
-func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	// Get the existing settings
-	settings, err := api.renter.Settings()
-	if err != nil {
-		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
-		return
-	}
-
+// parseAllowance updates the provided allowance with the allowance fields of
+// the request and validates the result. Unset fields are set to sane defaults.
+func parseAllowance(req *http.Request, allowance modules.Allowance) (modules.Allowance, error) {
 	// Scan for all allowance fields
 	var hostsSet, renewWindowSet, expectedStorageSet,
 		expectedUploadSet, expectedDownloadSet, expectedRedundancySet, maxPeriodChurnSet bool
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse funds")
 		}
-		settings.Allowance.Funds = funds
+		allowance.Funds = funds
 	}
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse hosts: " + err.Error())
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("insufficient number of hosts, need at least %v but have %v", requiredHosts, hosts)
 		}
-		settings.Allowance.Hosts = hosts
+		allowance.Hosts = hosts
 		hostsSet = true
 	}
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse period: " + err.Error())
 		}
-		settings.Allowance.Period = types.BlockHeight(period)
+		allowance.Period = types.BlockHeight(period)
 	}
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse renewwindow: " + err.Error())
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)
 		}
-		settings.Allowance.RenewWindow = types.BlockHeight(renewWindow)
+		allowance.RenewWindow = types.BlockHeight(renewWindow)
 		renewWindowSet = true
 	}
 	if es := req.FormValue("expectedstorage"); es != "" {
 		var expectedStorage uint64
 		if _, err := fmt.Sscan(es, &expectedStorage); err != nil {
-			WriteError(w, Error{"unable to parse expectedStorage: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse expectedStorage: " + err.Error())
 		}
-		settings.Allowance.ExpectedStorage = expectedStorage
+		allowance.ExpectedStorage = expectedStorage
 		expectedStorageSet = true
 	}
 	if euf := req.FormValue("expectedupload"); euf != "" {
 		var expectedUpload uint64
 		if _, err := fmt.Sscan(euf, &expectedUpload); err != nil {
-			WriteError(w, Error{"unable to parse expectedUpload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse expectedUpload: " + err.Error())
 		}
-		settings.Allowance.ExpectedUpload = expectedUpload
+		allowance.ExpectedUpload = expectedUpload
 		expectedUploadSet = true
 	}
 	if edf := req.FormValue("expecteddownload"); edf != "" {
 		var expectedDownload uint64
 		if _, err := fmt.Sscan(edf, &expectedDownload); err != nil {
-			WriteError(w, Error{"unable to parse expectedDownload: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse expectedDownload: " + err.Error())
 		}
-		settings.Allowance.ExpectedDownload = expectedDownload
+		allowance.ExpectedDownload = expectedDownload
 		expectedDownloadSet = true
 	}
 	if er := req.FormValue("expectedredundancy"); er != "" {
 		var expectedRedundancy float64
 		if _, err := fmt.Sscan(er, &expectedRedundancy); err != nil {
-			WriteError(w, Error{"unable to parse expectedRedundancy: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse expectedRedundancy: " + err.Error())
 		}
-		settings.Allowance.ExpectedRedundancy = expectedRedundancy
+		allowance.ExpectedRedundancy = expectedRedundancy
 		expectedRedundancySet = true
 	}
 	if mpc := req.FormValue("maxperiodchurn"); mpc != "" {
 		var maxPeriodChurn uint64
 		if _, err := fmt.Sscan(mpc, &maxPeriodChurn); err != nil {
-			WriteError(w, Error{"unable to parse new max churn per period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse new max churn per period: " + err.Error())
 		}
-		settings.Allowance.MaxPeriodChurn = maxPeriodChurn
+		allowance.MaxPeriodChurn = maxPeriodChurn
 		maxPeriodChurnSet = true
 	}
+	if mcc := req.FormValue("maxperiodcontractchurn"); mcc != "" {
+		var maxContractChurn uint64
+		if _, err := fmt.Sscan(mcc, &maxContractChurn); err != nil {
+			return modules.Allowance{}, errors.New("unable to parse max contract churn per period: " + err.Error())
+		}
+		allowance.MaxPeriodContractChurn = maxContractChurn
+	}
+	if rwp := req.FormValue("renewwindowpolicy"); rwp != "" {
+		policy := modules.RenewWindowPolicy(rwp)
+		if policy == "start" {
+			policy = modules.RenewWindowPolicyStart
+		}
+		if !policy.IsValid() {
+			return modules.Allowance{}, contractor.ErrAllowanceInvalidRenewWindowPolicy
+		}
+		allowance.RenewWindowPolicy = policy
+	}
 	if str := req.FormValue("maxrpcprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxrpcprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxrpcprice")
 		}
-		settings.Allowance.MaxRPCPrice = price
+		allowance.MaxRPCPrice = price
 	}
 	if str := req.FormValue("maxcontractprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxcontractprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxcontractprice")
 		}
-		settings.Allowance.MaxContractPrice = price
+		allowance.MaxContractPrice = price
 	}
 	if str := req.FormValue("maxdownloadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxdownloadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxdownloadbandwidthprice")
 		}
-		settings.Allowance.MaxDownloadBandwidthPrice = price
+		allowance.MaxDownloadBandwidthPrice = price
 	}
 	if str := req.FormValue("maxsectoraccessprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxsectoraccessprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxsectoraccessprice")
 		}
-		settings.Allowance.MaxSectorAccessPrice = price
+		allowance.MaxSectorAccessPrice = price
 	}
 	if str := req.FormValue("maxstorageprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxstorageprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxstorageprice")
 		}
-		settings.Allowance.MaxStoragePrice = price
+		allowance.MaxStoragePrice = price
 	}
 	if str := req.FormValue("maxuploadbandwidthprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
-			WriteError(w, Error{"unable to parse maxuploadbandwidthprice"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse maxuploadbandwidthprice")
 		}
-		settings.Allowance.MaxUploadBandwidthPrice = price
+		allowance.MaxUploadBandwidthPrice = price
 	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
-	zeroFunds := settings.Allowance.Funds.Cmp(types.ZeroCurrency) == 0
-	zeroPeriod := settings.Allowance.Period == 0
+	zeroFunds := allowance.Funds.Cmp(types.ZeroCurrency) == 0
+	zeroPeriod := allowance.Period == 0
 	if zeroFunds && zeroPeriod {
 		// If both the funds and period are zero then the allowance should be
 		// cancelled. Make sure that the rest of the fields are zeroed out
-		settings.Allowance = modules.Allowance{}
-	} else if !reflect.DeepEqual(settings.Allowance, modules.Allowance{}) {
+		allowance = modules.Allowance{}
+	} else if !reflect.DeepEqual(allowance, modules.Allowance{}) {
 		// Allowance has been set at least partially. Validate that all fields
 		// are set correctly
 
 		// If Funds is still 0 return an error since we need the user to set the
 		// period initially
 		if zeroFunds {
-			WriteError(w, Error{ErrFundsNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrFundsNeedToBeSet
 		}
 
 		// If Period is still 0 return an error since we need the user to set
 		// the period initially
 		if zeroPeriod {
-			WriteError(w, Error{ErrPeriodNeedToBeSet.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, ErrPeriodNeedToBeSet
 		}
 
 		// If the user set Hosts to 0 return an error, otherwise if Hosts was
 		// not set by the user then set it to the sane default
-		if settings.Allowance.Hosts == 0 && hostsSet {
-			WriteError(w, Error{contractor.ErrAllowanceNoHosts.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.Hosts == 0 {
-			settings.Allowance.Hosts = modules.DefaultAllowance.Hosts
+		if allowance.Hosts == 0 && hostsSet {
+			return modules.Allowance{}, contractor.ErrAllowanceNoHosts
+		} else if allowance.Hosts == 0 {
+			allowance.Hosts = modules.DefaultAllowance.Hosts
 		}
 
 		// If the user set the Renew Window to 0 return an error, otherwise if
 		// the Renew Window was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.RenewWindow == 0 && renewWindowSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroWindow.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.RenewWindow == 0 {
-			settings.Allowance.RenewWindow = settings.Allowance.Period / 2
+		if allowance.RenewWindow == 0 && renewWindowSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroWindow
+		} else if allowance.RenewWindow == 0 {
+			allowance.RenewWindow = allowance.Period / 2
 		}
 
 		// If the user set ExpectedStorage to 0 return an error, otherwise if
 		// ExpectedStorage was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedStorage == 0 && expectedStorageSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedStorage.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedStorage == 0 {
-			settings.Allowance.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
+		if allowance.ExpectedStorage == 0 && expectedStorageSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedStorage
+		} else if allowance.ExpectedStorage == 0 {
+			allowance.ExpectedStorage = modules.DefaultAllowance.ExpectedStorage
 		}
 
 		// If the user set ExpectedUpload to 0 return an error, otherwise if
 		// ExpectedUpload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedUpload == 0 && expectedUploadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedUpload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedUpload == 0 {
-			settings.Allowance.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
+		if allowance.ExpectedUpload == 0 && expectedUploadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedUpload
+		} else if allowance.ExpectedUpload == 0 {
+			allowance.ExpectedUpload = modules.DefaultAllowance.ExpectedUpload
 		}
 
 		// If the user set ExpectedDownload to 0 return an error, otherwise if
 		// ExpectedDownload was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedDownload == 0 && expectedDownloadSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedDownload.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedDownload == 0 {
-			settings.Allowance.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
+		if allowance.ExpectedDownload == 0 && expectedDownloadSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedDownload
+		} else if allowance.ExpectedDownload == 0 {
+			allowance.ExpectedDownload = modules.DefaultAllowance.ExpectedDownload
 		}
 
 		// If the user set ExpectedRedundancy to 0 return an error, otherwise if
 		// ExpectedRedundancy was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.ExpectedRedundancy == 0 && expectedRedundancySet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroExpectedRedundancy.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.ExpectedRedundancy == 0 {
-			settings.Allowance.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
+		if allowance.ExpectedRedundancy == 0 && expectedRedundancySet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroExpectedRedundancy
+		} else if allowance.ExpectedRedundancy == 0 {
+			allowance.ExpectedRedundancy = modules.DefaultAllowance.ExpectedRedundancy
 		}
 
 		// If the user set MaxPeriodChurn to 0 return an error, otherwise if
 		// MaxPeriodChurn was not set by the user then set it to the sane
 		// default
-		if settings.Allowance.MaxPeriodChurn == 0 && maxPeriodChurnSet {
-			WriteError(w, Error{contractor.ErrAllowanceZeroMaxPeriodChurn.Error()}, http.StatusBadRequest)
-			return
-		} else if settings.Allowance.MaxPeriodChurn == 0 {
-			settings.Allowance.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
+		if allowance.MaxPeriodChurn == 0 && maxPeriodChurnSet {
+			return modules.Allowance{}, contractor.ErrAllowanceZeroMaxPeriodChurn
+		} else if allowance.MaxPeriodChurn == 0 {
+			allowance.MaxPeriodChurn = modules.DefaultAllowance.MaxPeriodChurn
 		}
 	}
+	return allowance, nil
+}
+
+// renterHandlerPOST handles the API call to set the Renter's settings. This API
+// call handles multiple settings and so each setting is optional on it's own.
+// Groups of settings, such as the allowance, have certain requirements if they
+// are being set in which case certain fields are no longer optional.
+func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Get the existing settings
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable able to get renter settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Scan for all allowance fields
+	settings.Allowance, err = parseAllowance(req, settings.Allowance)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Scan the download speed limit. (optional parameter)
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
@@ -949,6 +1358,163 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.MaxUploadSpeed = uploadSpeed
 	}
 
+	// Scan the account refill policy. (optional parameters)
+	if mb := req.FormValue("accountmaxbalanceatrisk"); mb != "" {
+		maxBalance, ok := scanAmount(mb)
+		if !ok {
+			WriteError(w, Error{"unable to parse accountmaxbalanceatrisk"}, http.StatusBadRequest)
+			return
+		}
+		settings.AccountMaxBalanceAtRisk = maxBalance
+	}
+	if rt := req.FormValue("accountrefillthreshold"); rt != "" {
+		threshold, ok := scanAmount(rt)
+		if !ok {
+			WriteError(w, Error{"unable to parse accountrefillthreshold"}, http.StatusBadRequest)
+			return
+		}
+		settings.AccountRefillThreshold = threshold
+	}
+
+	// Scan the cost ceilings. (optional parameters)
+	costCeilings := []struct {
+		name    string
+		ceiling *types.Currency
+	}{
+		{"maxdownloadcost", &settings.MaxDownloadCost},
+		{"maxregistryreadcost", &settings.MaxRegistryReadCost},
+		{"maxregistrywritecost", &settings.MaxRegistryWriteCost},
+		{"maxuploadcost", &settings.MaxUploadCost},
+	}
+	for _, cc := range costCeilings {
+		if c := req.FormValue(cc.name); c != "" {
+			ceiling, ok := scanAmount(c)
+			if !ok {
+				WriteError(w, Error{"unable to parse " + cc.name}, http.StatusBadRequest)
+				return
+			}
+			*cc.ceiling = ceiling
+		}
+	}
+
+	// Scan the overdrive settings. (optional parameters)
+	if do := req.FormValue("downloadoverdrive"); do != "" {
+		if _, err := fmt.Sscan(do, &settings.DownloadOverdrive); err != nil {
+			WriteError(w, Error{"unable to parse downloadoverdrive: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if so := req.FormValue("streamoverdrive"); so != "" {
+		if _, err := fmt.Sscan(so, &settings.StreamOverdrive); err != nil {
+			WriteError(w, Error{"unable to parse streamoverdrive: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if ao := req.FormValue("adaptiveoverdrive"); ao != "" {
+		adaptive, err := scanBool(ao)
+		if err != nil {
+			WriteError(w, Error{"unable to parse adaptiveoverdrive: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.AdaptiveOverdrive = adaptive
+	}
+
+	// Scan the low latency settings. (optional parameters)
+	if llf := req.FormValue("lowlatencyfraction"); llf != "" {
+		fraction, err := strconv.ParseFloat(llf, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse lowlatencyfraction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.LowLatencyFraction = fraction
+	}
+	if llb := req.FormValue("lowlatencybudget"); llb != "" {
+		budget, err := time.ParseDuration(llb)
+		if err != nil {
+			WriteError(w, Error{"unable to parse lowlatencybudget: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.LowLatencyBudget = budget
+	}
+
+	// Scan the gouging thresholds. (optional parameters) "default" resets a
+	// threshold to its default.
+	gougingFractions := []struct {
+		name     string
+		fraction **float64
+	}{
+		{"baserpcmaxfraction", &settings.Gouging.BaseRPCMaxFraction},
+		{"downloadusagefraction", &settings.Gouging.DownloadUsageFraction},
+		{"fundaccountmaxfraction", &settings.Gouging.FundAccountMaxFraction},
+		{"pricetablemaxfraction", &settings.Gouging.PriceTableMaxFraction},
+		{"registryusagefraction", &settings.Gouging.RegistryUsageFraction},
+		{"uploadusagefraction", &settings.Gouging.UploadUsageFraction},
+	}
+	for _, gf := range gougingFractions {
+		f := req.FormValue(gf.name)
+		if f == "" {
+			continue
+		} else if f == "default" {
+			*gf.fraction = nil
+			continue
+		}
+		fraction, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse " + gf.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		*gf.fraction = &fraction
+	}
+
+	// Scan the download stall timeout. (optional parameter)
+	if dst := req.FormValue("downloadstalltimeout"); dst != "" {
+		timeout, err := time.ParseDuration(dst)
+		if err != nil {
+			WriteError(w, Error{"unable to parse downloadstalltimeout: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.DownloadStallTimeout = timeout
+	}
+
+	// Scan the memory budgets. (optional parameters)
+	if um := req.FormValue("uploadmemory"); um != "" {
+		if _, err := fmt.Sscan(um, &settings.UploadMemory); err != nil {
+			WriteError(w, Error{"unable to parse uploadmemory: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if dm := req.FormValue("downloadmemory"); dm != "" {
+		if _, err := fmt.Sscan(dm, &settings.DownloadMemory); err != nil {
+			WriteError(w, Error{"unable to parse downloadmemory: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if rm := req.FormValue("repairmemory"); rm != "" {
+		if _, err := fmt.Sscan(rm, &settings.RepairMemory); err != nil {
+			WriteError(w, Error{"unable to parse repairmemory: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Scan the spill settings. (optional parameters)
+	if sd, ok := req.Form["spilldir"]; ok {
+		settings.SpillDir = sd[0]
+	}
+	if sc := req.FormValue("spillcap"); sc != "" {
+		if _, err := fmt.Sscan(sc, &settings.SpillCap); err != nil {
+			WriteError(w, Error{"unable to parse spillcap: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Scan the pack size. (optional parameter)
+	if ps := req.FormValue("packsize"); ps != "" {
+		if _, err := fmt.Sscan(ps, &settings.PackSize); err != nil {
+			WriteError(w, Error{"unable to parse packsize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Scan the checkforipviolation flag.
 	if ipc := req.FormValue("checkforipviolation"); ipc != "" {
 		var ipviolationcheck bool
@@ -990,6 +1556,52 @@ func (api *API) renterAllowanceCancelHandlerPOST(w http.ResponseWriter, _ *http.
 	WriteSuccess(w)
 }
 
+// renterAllowancePlanHandlerGET handles the API call to preview the contracts
+// the contractor would form for an allowance. The allowance fields are parsed
+// like for /renter [POST] and default to the current allowance.
+func (api *API) renterAllowancePlanHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Get the existing settings
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the proposed allowance.
+	allowance, err := parseAllowance(req, settings.Allowance)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	plan, err := api.renter.ContractFormationPlan(allowance)
+	if err != nil {
+		WriteError(w, Error{"failed to create contract formation plan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAllowancePlanGET{plan})
+}
+
+// renterGougingHandlerGET handles the API call to report which hosts are
+// filtered by which price gouging rule.
+func (api *API) renterGougingHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := api.renter.GougingReport()
+	if err != nil {
+		WriteError(w, Error{"failed to create gouging report: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterGougingGET{report})
+}
+
+// renterForecastHandlerGET handles the API call to project the spending of the
+// allowance to the end of the current period.
+func (api *API) renterForecastHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	forecast, err := api.renter.SpendingForecast()
+	if err != nil {
+		WriteError(w, Error{"failed to create spending forecast: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterForecastGET{forecast})
+}
+
 // renterCleanHandlerPOST handles the API call to clean lost files from a Renter.
 func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	var deleteErrs error
@@ -1055,9 +1667,12 @@ func (api *API) renterContractCancelHandler(w http.ResponseWriter, req *http.Req
 //
 // Recoverable contracts are contracts of the renter that are recovered from the
 // blockchain by using the renter's seed.
+//
+// Unrecoverable contracts are recoverable contracts that failed to be
+// recovered.
 func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Parse flags
-	var disabled, inactive, expired, recoverable bool
+	var disabled, inactive, expired, recoverable, unrecoverable bool
 	var err error
 	if s := req.FormValue("disabled"); s != "" {
 		disabled, err = scanBool(s)
@@ -1087,6 +1702,13 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	if s := req.FormValue("unrecoverable"); s != "" {
+		unrecoverable, err = scanBool(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse unrecoverable: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 
 	// Parse the renter's contracts into their appropriate categories
 	contracts := api.parseRenterContracts(disabled, inactive, expired)
@@ -1098,6 +1720,13 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	}
 	contracts.RecoverableContracts = recoverableContracts
 
+	// Get unrecoverable contracts
+	var unrecoverableContracts []modules.UnrecoverableContract
+	if unrecoverable {
+		unrecoverableContracts = api.renter.UnrecoverableContracts()
+	}
+	contracts.UnrecoverableContracts = unrecoverableContracts
+
 	WriteJSON(w, contracts)
 }
 
@@ -1268,6 +1897,26 @@ func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Reque
 	WriteJSON(w, api.renter.ContractorChurnStatus())
 }
 
+// renterStatsHandlerGET handles the API call to request the renter's
+// cumulative stats.
+func (api *API) renterStatsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since time.Time
+	if sinceStr := req.FormValue("since"); sinceStr != "" {
+		sinceInt, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `since` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		since = time.Unix(sinceInt, 0)
+	}
+	stats, err := api.renter.CumulativeStats(since)
+	if err != nil {
+		WriteError(w, Error{"unable to get renter stats: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, stats)
+}
+
 // renterDownloadsHandler handles the API call to request the download queue.
 func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var downloads []DownloadInfo
@@ -1423,10 +2072,8 @@ func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Req
 
 // renterRecoveryScanHandlerGET handles the API call to /renter/recoveryscan.
 func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	scanInProgress, height := api.renter.RecoveryScanStatus()
 	WriteJSON(w, RenterRecoveryStatusGET{
-		ScanInProgress: scanInProgress,
-		ScannedHeight:  height,
+		ContractRecoveryStatus: api.renter.RecoveryStatus(),
 	})
 }
 
@@ -1472,6 +2119,57 @@ func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// renterConcatHandler handles the API call to concatenate multiple files into
+// a new file.
+func (api *API) renterConcatHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the siaPath of the new file.
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Determine whether the user is requesting a user siapath, or a root siapath.
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the siapaths of the parts.
+	if len(req.Form["part"]) == 0 {
+		WriteError(w, Error{"part not specified"}, http.StatusBadRequest)
+		return
+	}
+	var parts []modules.SiaPath
+	for _, s := range req.Form["part"] {
+		part, err := modules.NewSiaPath(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse part: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !root {
+			part, err = rebaseInputSiaPath(part)
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		parts = append(parts, part)
+	}
+	err = api.renter.ConcatFiles(siaPath, parts)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterFileHandler handles GET requests to the /renter/file/:siapath API endpoint.
 func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Determine the siapath that the user wants to get the file from.
@@ -1523,6 +2221,8 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
 	stuck := req.FormValue("stuck")
+	lockedUntil := req.FormValue("lockeduntil")
+	tags, setTags := req.Form["tags"]
 	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
 		WriteError(w, Error{"unable to parse root flag: " + err.Error()}, http.StatusBadRequest)
@@ -1559,6 +2259,25 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle locking the file.
+	if lockedUntil != "" {
+		timestamp, err := strconv.ParseInt(lockedUntil, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'lockeduntil' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.renter.SetFileLock(siaPath, time.Unix(timestamp, 0)); err != nil {
+			WriteError(w, Error{"failed to lock file: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Handle changing the tags of a file. An empty value removes all the tags.
+	if setTags {
+		if err := api.renter.SetFileTags(siaPath, parseTags(strings.Join(tags, ","))); err != nil {
+			WriteError(w, Error{"failed to change file tags: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
@@ -1713,6 +2432,165 @@ func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// globDir returns the siapath of the deepest directory containing every file
+// that can match a glob pattern.
+func globDir(pattern string) (modules.SiaPath, error) {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?[\\"); i >= 0 {
+		prefix = pattern[:i]
+	}
+	i := strings.LastIndex(prefix, "/")
+	if i <= 0 {
+		return modules.RootSiaPath(), nil
+	}
+	return modules.NewSiaPath(prefix[:i])
+}
+
+// renterBulkHandlerPOST handles the API call to apply an action to all the
+// files matching a glob pattern.
+func (api *API) renterBulkHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the pattern.
+	pattern := strings.Trim(req.FormValue("pattern"), "/")
+	if pattern == "" {
+		WriteError(w, Error{"pattern not specified"}, http.StatusBadRequest)
+		return
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		WriteError(w, Error{"invalid pattern: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	dir, err := globDir(pattern)
+	if err != nil {
+		WriteError(w, Error{"invalid pattern: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the action and its parameters.
+	action := req.FormValue("action")
+	var destination string
+	var stuck bool
+	switch action {
+	case "delete":
+	case "download":
+		destination = req.FormValue("destination")
+		if !filepath.IsAbs(destination) {
+			WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+			return
+		}
+	case "stuck":
+		stuck, err = scanBool(req.FormValue("stuck"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'stuck' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	default:
+		WriteError(w, Error{"action must be one of delete, download or stuck"}, http.StatusBadRequest)
+		return
+	}
+	var dryRun bool
+	if d := req.FormValue("dryrun"); d != "" {
+		dryRun, err = scanBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dryrun' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The pattern is relative to the user folder unless root is set.
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	base := modules.RootSiaPath()
+	if !root {
+		base = modules.UserFolder
+		dir, err = rebaseInputSiaPath(dir)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Collect the matching files.
+	var siaPaths []modules.SiaPath
+	var mu sync.Mutex
+	err = api.renter.FileList(dir, true, true, func(fi modules.FileInfo) {
+		rel, err := fi.SiaPath.Rebase(base, modules.RootSiaPath())
+		if err != nil {
+			return
+		}
+		if match, _ := path.Match(pattern, rel.String()); !match {
+			return
+		}
+		mu.Lock()
+		siaPaths = append(siaPaths, rel)
+		mu.Unlock()
+	})
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sort.Slice(siaPaths, func(i, j int) bool {
+		return siaPaths[i].String() < siaPaths[j].String()
+	})
+	resp := RenterBulkPOST{SiaPaths: siaPaths}
+	if dryRun {
+		WriteJSON(w, resp)
+		return
+	}
+
+	// Apply the action to every file. A failure doesn't stop the others.
+	for _, rel := range siaPaths {
+		siaPath, err := base.Join(rel.String())
+		if err == nil {
+			switch action {
+			case "delete":
+				err = api.renter.DeleteFile(siaPath)
+			case "download":
+				_, err = api.startAsyncDownload(siaPath, filepath.Join(destination, filepath.FromSlash(rel.String())))
+			case "stuck":
+				err = api.renter.SetFileStuck(siaPath, stuck)
+			}
+		}
+		if err != nil {
+			resp.Errors = append(resp.Errors, RenterBulkError{
+				SiaPath: rel,
+				Error:   err.Error(),
+			})
+		}
+	}
+	WriteJSON(w, resp)
+}
+
+// startAsyncDownload starts an async download of a file to destination,
+// creating the parent directories of destination if necessary. The download can
+// be cancelled like the downloads started by /renter/downloadasync.
+func (api *API) startAsyncDownload(siaPath modules.SiaPath, destination string) (modules.DownloadID, error) {
+	if err := os.MkdirAll(filepath.Dir(destination), modules.DefaultDirPerm); err != nil {
+		return "", errors.AddContext(err, "unable to create the destination directory")
+	}
+	params := modules.RenterDownloadParameters{
+		Async:       true,
+		Destination: destination,
+		SiaPath:     siaPath,
+	}
+	var id modules.DownloadID
+	id, start, cancel, err := api.renter.DownloadAsync(params, func(_ error) error {
+		api.downloadMu.Lock()
+		delete(api.downloads, id)
+		api.downloadMu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return "", errors.AddContext(err, "download creation failed")
+	}
+	api.downloadMu.Lock()
+	api.downloads[id] = cancel
+	api.downloadMu.Unlock()
+	return id, errors.AddContext(start(), "download failed")
+}
+
 // renterCancelDownloadHandler handles the API call to cancel a download.
 func (api *API) renterCancelDownloadHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the id.
@@ -1867,12 +2745,24 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	overdrive, err := scanOverdrive(req)
+	if err != nil {
+		return modules.RenterDownloadParameters{}, err
+	}
+
+	cipherKey, err := scanExternalKey(req.FormValue("encryptionkey"), req.FormValue("ciphertype"))
+	if err != nil {
+		return modules.RenterDownloadParameters{}, err
+	}
+
 	dp := modules.RenterDownloadParameters{
+		CipherKey:        cipherKey,
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
 		Async:            async,
 		Length:           length,
 		Offset:           offset,
+		Overdrive:        overdrive,
 		SiaPath:          siaPath,
 	}
 	if httpresp {
@@ -1882,14 +2772,56 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	return dp, nil
 }
 
-// renterStreamHandler handles downloads from the /renter/stream endpoint
-func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+// scanExternalKey parses the optional external key of an upload or download.
+// The key is hex encoded and uses the renter's default cipher type unless a
+// cipher type is provided.
+func scanExternalKey(keyStr, cipherTypeStr string) (crypto.CipherKey, error) {
+	if keyStr == "" {
+		if cipherTypeStr != "" {
+			return nil, errors.New("a cipher type can only be provided together with an encryption key")
+		}
+		return nil, nil
+	}
+	ct := crypto.TypeDefaultRenter
+	if cipherTypeStr != "" {
+		if err := ct.FromString(cipherTypeStr); err != nil {
+			return nil, errors.AddContext(err, "unable to parse cipher type")
+		}
+	}
+	if ct == crypto.TypePlain {
+		return nil, errors.New("plaintext can't be used for an encryption key")
+	}
+	entropy, err := hex.DecodeString(keyStr)
 	if err != nil {
-		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
-		return
+		return nil, errors.AddContext(err, "unable to decode encryption key")
 	}
-	root, err := scanBool(req.FormValue("root"))
+	key, err := crypto.NewSiaKey(ct, entropy)
+	if err != nil {
+		return nil, errors.AddContext(err, "invalid encryption key")
+	}
+	return key, nil
+}
+
+// scanOverdrive parses the optional overdrive parameter of a download or
+// stream request.
+func scanOverdrive(req *http.Request) (uint64, error) {
+	var overdrive uint64
+	if o := req.FormValue("overdrive"); o != "" {
+		if _, err := fmt.Sscan(o, &overdrive); err != nil {
+			return 0, errors.AddContext(err, "unable to parse overdrive")
+		}
+	}
+	return overdrive, nil
+}
+
+// renterStreamHandler handles downloads from the /renter/stream endpoint
+func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := scanBool(req.FormValue("root"))
 	if err != nil {
 		err = errors.AddContext(err, "error parsing the root flag")
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
@@ -1912,7 +2844,12 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
-	fileName, streamer, err := api.renter.Streamer(siaPath, disableLocalFetch)
+	overdrive, err := scanOverdrive(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fileName, streamer, err := api.renter.Streamer(siaPath, disableLocalFetch, overdrive)
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("failed to create download streamer: %v", err)},
 			http.StatusInternalServerError)
@@ -1943,6 +2880,15 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Check whether zero chunks should be recorded as holes
+	sparse := false
+	if s := req.FormValue("sparse"); s != "" {
+		sparse, err = strconv.ParseBool(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'sparse' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
 	if err != nil {
@@ -1967,10 +2913,15 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		Sparse:              sparse,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
 	})
+	if errors.Contains(err, modules.ErrDirQuotaExceeded) {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInsufficientStorage)
+		return
+	}
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
 		return
@@ -2032,6 +2983,48 @@ func (api *API) renterUploadsPauseHandler(w http.ResponseWriter, req *http.Reque
 	WriteSuccess(w)
 }
 
+// renterRepairHandlerGET handles the API call to request the renter's repair
+// backlog.
+func (api *API) renterRepairHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	backlog, err := api.renter.RepairBacklog()
+	if err != nil {
+		WriteError(w, Error{"unable to get repair backlog: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, backlog)
+}
+
+// renterRepairPauseHandlerPOST handles the API call to pause the repair of
+// existing files.
+func (api *API) renterRepairPauseHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var duration time.Duration
+	if durationStr := req.FormValue("duration"); durationStr != "" {
+		durationInt, err := strconv.ParseUint(durationStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		duration = time.Second * time.Duration(durationInt)
+	}
+	err := api.renter.PauseRepairs(duration)
+	if err != nil {
+		WriteError(w, Error{"failed to pause repairs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterRepairResumeHandlerPOST handles the API call to resume the repair of
+// existing files.
+func (api *API) renterRepairResumeHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	err := api.renter.ResumeRepairs()
+	if err != nil {
+		WriteError(w, Error{"failed to resume repairs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterUploadsResumeHandler handles the api call to resume the renter's
 // uploads, this includes repairs
 func (api *API) renterUploadsResumeHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -2070,9 +3063,27 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 			return
 		}
 	}
+	// Check whether the data should be appended to an existing file
+	appendData := false
+	if a := queryForm.Get("append"); a != "" {
+		appendData, err = strconv.ParseBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'append' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether zero chunks should be recorded as holes
+	sparse := false
+	if s := queryForm.Get("sparse"); s != "" {
+		sparse, err = strconv.ParseBool(s)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'sparse' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
-	if err != nil && !repair {
+	if err != nil && !repair && !appendData {
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
@@ -2080,6 +3091,25 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	if appendData && ec != nil {
+		WriteError(w, Error{"can't provide erasure code settings when appending"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the optional external key.
+	cipherKey, err := scanExternalKey(queryForm.Get("encryptionkey"), queryForm.Get("ciphertype"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	keyID := queryForm.Get("keyid")
+	if keyID != "" && cipherKey == nil {
+		WriteError(w, Error{"a key id can only be provided together with an encryption key"}, http.StatusBadRequest)
+		return
+	}
+	if keyID == "" && cipherKey != nil && !repair && !appendData {
+		WriteError(w, Error{"an encryption key requires a key id"}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -2097,11 +3127,127 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
+		Append:      appendData,
+		Sparse:      sparse,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
+
+		CipherKey:   cipherKey,
+		MasterKeyID: keyID,
 	}
 	err = api.renter.UploadStreamFromReader(up, req.Body)
+	if errors.Contains(err, modules.ErrDirQuotaExceeded) {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInsufficientStorage)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterUploadURLHandlerGET handles the API call to list the renter's URL
+// uploads.
+func (api *API) renterUploadURLHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	uploads := api.renter.URLUploads()
+	for i := range uploads {
+		siaPath, err := uploads[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, Error{"unable to trim the user sia path from a url upload: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		uploads[i].SiaPath = siaPath
+	}
+	WriteJSON(w, RenterURLUploadsGET{
+		Uploads: uploads,
+	})
+}
+
+// renterUploadURLHandlerPOST handles the API call to upload a file whose data
+// the renter fetches from a URL.
+func (api *API) renterUploadURLHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	sourceURL := queryForm.Get("url")
+	if sourceURL == "" {
+		WriteError(w, Error{"url must be provided"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the optional maximum size.
+	var maxSize uint64
+	if ms := queryForm.Get("maxsize"); ms != "" {
+		maxSize, err = strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'maxsize' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether existing file should be overwritten
+	force := false
+	if f := queryForm.Get("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether the call should return before the upload is done
+	async := false
+	if a := queryForm.Get("async"); a != "" {
+		async, err = strconv.ParseBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'async' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Call the renter to upload the file.
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	up := modules.FileUploadParams{
+		SiaPath:     siaPath,
+		ErasureCode: ec,
+		Force:       force,
+
+		// NOTE: can make this an optional param.
+		CipherType: crypto.TypeDefaultRenter,
+	}
+	if async {
+		// The outcome of the upload is available through GET
+		// /renter/uploadurl.
+		go api.renter.UploadFromURL(up, sourceURL, maxSize)
+		WriteSuccess(w)
+		return
+	}
+	err = api.renter.UploadFromURL(up, sourceURL, maxSize)
+	if errors.Contains(err, modules.ErrDirQuotaExceeded) {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInsufficientStorage)
+		return
+	}
+	if errors.Contains(err, modules.ErrURLUploadTooLarge) {
+		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil {
 		WriteError(w, Error{"upload failed: " + err.Error()}, http.StatusInternalServerError)
 		return
@@ -2109,6 +3255,148 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	WriteSuccess(w)
 }
 
+// renterReshardHandlerGET handles the API call to list the renter's changes of
+// the erasure coding parameters of files.
+func (api *API) renterReshardHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	reshards := api.renter.Reshards()
+	for i := range reshards {
+		siaPath, err := reshards[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, Error{"unable to trim the user sia path from a reshard: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		reshards[i].SiaPath = siaPath
+	}
+	WriteJSON(w, RenterReshardsGET{
+		Reshards: reshards,
+	})
+}
+
+// renterReshardHandlerPOST handles the API call to change the erasure coding
+// parameters of a file.
+func (api *API) renterReshardHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the erasure coder. Both values are required since there is no
+	// point in resharding a file to the defaults implicitly.
+	if req.FormValue("datapieces") == "" || req.FormValue("paritypieces") == "" {
+		WriteError(w, Error{"datapieces and paritypieces must be provided"}, http.StatusBadRequest)
+		return
+	}
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ec == nil {
+		WriteError(w, Error{"datapieces and paritypieces can't both be zero"}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.Reshard(siaPath, ec)
+	if err != nil {
+		WriteError(w, Error{"unable to reshard file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// packSiaPathParam parses the siapath of a packed file from the params of a
+// request and prepends the user folder to it.
+func packSiaPathParam(ps httprouter.Params) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	return rebaseInputSiaPath(siaPath)
+}
+
+// renterPacksHandlerGET handles the API call to list the renter's packed
+// files.
+func (api *API) renterPacksHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	files, err := api.renter.PackedFiles()
+	if err != nil {
+		WriteError(w, Error{"unable to get packed files: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	for i := range files {
+		siaPath, err := files[i].SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			WriteError(w, Error{"unable to trim the user sia path from a packed file: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		files[i].SiaPath = siaPath
+	}
+	WriteJSON(w, RenterPacksGET{
+		PackedFiles: files,
+	})
+}
+
+// renterPacksFlushHandlerPOST handles the API call to upload all packs which
+// haven't been uploaded yet.
+func (api *API) renterPacksFlushHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.FlushPacks(); err != nil {
+		WriteError(w, Error{"unable to flush packs: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterPackHandlerGET handles the API call to download a packed file.
+func (api *API) renterPackHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	siaPath, err := packSiaPathParam(ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	reader, info, err := api.renter.PackedFile(siaPath)
+	if err != nil {
+		WriteError(w, Error{"unable to open packed file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer reader.Close()
+	w.Header().Set("Content-Length", fmt.Sprint(info.Size))
+	_, _ = io.Copy(w, reader)
+}
+
+// renterPackHandlerPOST handles the API call to upload a small file which is
+// packed into a shared pack. The body of the request is the file's data.
+func (api *API) renterPackHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := packSiaPathParam(ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.PackUpload(siaPath, req.Body)
+	if err != nil {
+		WriteError(w, Error{"unable to pack file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterPackDeleteHandlerPOST handles the API call to delete a packed file.
+func (api *API) renterPackDeleteHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	siaPath, err := packSiaPathParam(ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.DeletePackedFile(siaPath)
+	if err != nil {
+		WriteError(w, Error{"unable to delete packed file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterValidateSiaPathHandler handles the API call that validates a siapath
 func (api *API) renterValidateSiaPathHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Try and create a new siapath, this will validate the potential siapath
@@ -2270,6 +3558,44 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "setquota" {
+		quota, err := strconv.ParseUint(req.FormValue("quota"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'quota': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirQuota(siaPath, quota)
+		if err != nil {
+			WriteError(w, Error{"failed to change directory quota: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setlock" {
+		timestamp, err := strconv.ParseInt(req.FormValue("lockeduntil"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'lockeduntil': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetDirLock(siaPath, time.Unix(timestamp, 0))
+		if err != nil {
+			WriteError(w, Error{"failed to lock directory: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "settags" {
+		// An empty value removes all the tags.
+		err := api.renter.SetDirTags(siaPath, parseTags(req.FormValue("tags")))
+		if err != nil {
+			WriteError(w, Error{"failed to change directory tags: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
@@ -2306,6 +3632,22 @@ func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ h
 	WriteJSON(w, workerPoolStatus)
 }
 
+// renterWorkerHandler handles the API call to check the status of the worker
+// for a single host.
+func (api *API) renterWorkerHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse host pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	workerStatus, err := api.renter.WorkerStatus(pk)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, workerStatus)
+}
+
 func (api *API) renterFileHostsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Determine the siapath that the user wants to get the file from.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -2337,3 +3679,253 @@ func (api *API) renterFileHostsHandler(w http.ResponseWriter, req *http.Request,
 
 	WriteJSON(w, hosts)
 }
+
+// renterFileChecksumsHandlerGET handles the API call to get the piece roots of
+// a file and the proofs which allow for verifying them.
+func (api *API) renterFileChecksumsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var offset uint64
+	if str := req.FormValue("offset"); str != "" {
+		offset, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := uint64(checksumsDefaultPageSize)
+	if str := req.FormValue("limit"); str != "" {
+		limit, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse limit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if limit == 0 || limit > checksumsMaxPageSize {
+			WriteError(w, Error{fmt.Sprintf("limit must be between 1 and %v", checksumsMaxPageSize)}, http.StatusBadRequest)
+			return
+		}
+	}
+	checksums, err := api.renter.FileChecksums(siaPath, offset, limit)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to get checksums: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, checksums)
+}
+
+// renterPaymentBudgetsHandlerGET handles the API call to list the renter's
+// payment budgets.
+func (api *API) renterPaymentBudgetsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	budgets, err := api.renter.PaymentBudgets()
+	if err != nil {
+		WriteError(w, Error{"unable to get payment budgets: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterPaymentBudgetsGET{Budgets: budgets})
+}
+
+// renterPaymentBudgetsHandlerPOST handles the API call to create a payment
+// budget.
+func (api *API) renterPaymentBudgetsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limit, ok := scanAmount(req.FormValue("limit"))
+	if !ok {
+		WriteError(w, Error{"unable to parse limit"}, http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if durationStr := req.FormValue("duration"); durationStr != "" {
+		durationInt, err := strconv.ParseUint(durationStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		duration = time.Second * time.Duration(durationInt)
+	}
+	budget, err := api.renter.CreatePaymentBudget(req.FormValue("name"), limit, duration)
+	if err != nil {
+		WriteError(w, Error{"unable to create payment budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, budget)
+}
+
+// renterPaymentBudgetHandlerGET handles the API call to get a payment budget
+// and its receipts.
+func (api *API) renterPaymentBudgetHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	budget, receipts, err := api.renter.PaymentBudget(ps.ByName("id"))
+	if errors.Contains(err, renter.ErrUnknownPaymentBudget) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to get payment budget: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterPaymentBudgetGET{
+		Budget:   budget,
+		Receipts: receipts,
+	})
+}
+
+// renterPaymentBudgetHandlerPOST handles the API call to change the limit of a
+// payment budget.
+func (api *API) renterPaymentBudgetHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	limit, ok := scanAmount(req.FormValue("limit"))
+	if !ok {
+		WriteError(w, Error{"unable to parse limit"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.SetPaymentBudgetLimit(ps.ByName("id"), limit)
+	if errors.Contains(err, renter.ErrUnknownPaymentBudget) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to set payment budget limit: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterPaymentBudgetDeleteHandlerPOST handles the API call to delete a payment
+// budget.
+func (api *API) renterPaymentBudgetDeleteHandlerPOST(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := api.renter.DeletePaymentBudget(ps.ByName("id"))
+	if errors.Contains(err, renter.ErrUnknownPaymentBudget) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to delete payment budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterPaymentBudgetExecuteHandlerPOST handles the API call to execute an MDM
+// program on a host which is paid from a payment budget.
+func (api *API) renterPaymentBudgetExecuteHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var params RenterProgramPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	result, err := api.renter.ExecuteProgram(ps.ByName("id"), params.HostPublicKey, params.Instructions, params.MaxCost)
+	if errors.Contains(err, renter.ErrUnknownPaymentBudget) {
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to execute program: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, result)
+}
+
+// renterProgramCostHandlerPOST handles the API call to get the cost of
+// executing an MDM program on a host.
+func (api *API) renterProgramCostHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params RenterProgramPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	cost, err := api.renter.ProgramCost(params.HostPublicKey, params.Instructions)
+	if err != nil {
+		WriteError(w, Error{"unable to get program cost: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterProgramCostPOST{Cost: cost})
+}
+
+// renterAuditsHandlerGET handles the API call to get the audit settings and
+// the reports of the most recent audits.
+func (api *API) renterAuditsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.AuditSettings()
+	if err != nil {
+		WriteError(w, Error{"unable to get audit settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	reports, err := api.renter.AuditReports()
+	if err != nil {
+		WriteError(w, Error{"unable to get audit reports: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterAuditsGET{
+		Settings: settings,
+		Reports:  reports,
+	})
+}
+
+// renterAuditsHandlerPOST handles the API call to change the audit settings.
+func (api *API) renterAuditsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.AuditSettings()
+	if err != nil {
+		WriteError(w, Error{"unable to get audit settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if intervalStr := req.FormValue("interval"); intervalStr != "" {
+		interval, err := strconv.ParseUint(intervalStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Interval = time.Second * time.Duration(interval)
+	}
+	if samplesStr := req.FormValue("samples"); samplesStr != "" {
+		settings.Samples, err = strconv.ParseUint(samplesStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse samples: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetAuditSettings(settings); err != nil {
+		WriteError(w, Error{"unable to set audit settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterAuditHandlerPOST handles the API call to audit the renter's files.
+func (api *API) renterAuditHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := api.renter.AuditSettings()
+	if err != nil {
+		WriteError(w, Error{"unable to get audit settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	samples := settings.Samples
+	if samplesStr := req.FormValue("samples"); samplesStr != "" {
+		samples, err = strconv.ParseUint(samplesStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse samples: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := api.renter.Audit(samples)
+	if err != nil {
+		WriteError(w, Error{"unable to audit files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultListLimit is the number of entries returned by /renter/list if no
+	// limit is specified.
+	defaultListLimit = 1000
+)
+
+type (
+	// RenterListGET contains a page of the directories and files of a
+	// directory tree. NextToken is passed to get the next page and is empty
+	// if there are no more entries.
+	RenterListGET struct {
+		Directories []modules.DirectoryInfo `json:"directories"`
+		Files       []modules.FileInfo      `json:"files"`
+		NextToken   string                  `json:"nexttoken"`
+	}
+
+	// renterListFieldsGET is the response of /renter/list if only some of
+	// the fields of the entries are requested. It has the same JSON layout as
+	// RenterListGET.
+	renterListFieldsGET struct {
+		Directories []map[string]interface{} `json:"directories"`
+		Files       []map[string]interface{} `json:"files"`
+		NextToken   string                   `json:"nexttoken"`
+	}
+
+	// listEntry is a directory or a file of a listing.
+	listEntry struct {
+		siaPath string
+		dir     *modules.DirectoryInfo
+		file    *modules.FileInfo
+	}
+)
+
+// encodeListToken encodes the siapath of the last entry of a page as a
+// continuation token.
+func encodeListToken(siaPath string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(siaPath))
+}
+
+// decodeListToken decodes a continuation token into the siapath of the last
+// entry of the previous page.
+func decodeListToken(token string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	return string(b), err
+}
+
+// selectFields returns the JSON fields of v that are in fields. The siapath is
+// always returned.
+func selectFields(v interface{}, fields map[string]struct{}) (map[string]interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Decode numbers as json.Number to not lose the precision of uint64s.
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	for k := range m {
+		if _, ok := fields[k]; !ok && k != "siapath" {
+			delete(m, k)
+		}
+	}
+	return m, nil
+}
+
+// managedListTree returns the directories and files of the tree below siaPath
+// up to the given depth, or the whole tree if depth is 0. The siapaths of the
+// entries are rebased onto base.
+func (api *API) managedListTree(siaPath, base modules.SiaPath, depth int, cached bool) ([]listEntry, error) {
+	var entries []listEntry
+	var mu sync.Mutex
+	dirs := []modules.SiaPath{siaPath}
+	for d := 1; len(dirs) > 0; d++ {
+		var next []modules.SiaPath
+		for _, dir := range dirs {
+			// The first directory is the directory itself.
+			dis, err := api.renter.DirList(dir)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to get directory contents")
+			}
+			for i := 1; i < len(dis); i++ {
+				if depth == 0 || d < depth {
+					next = append(next, dis[i].SiaPath)
+				}
+				dis[i].SiaPath, err = dis[i].SiaPath.Rebase(base, modules.RootSiaPath())
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, listEntry{siaPath: dis[i].SiaPath.String(), dir: &dis[i]})
+			}
+			err = api.renter.FileList(dir, false, cached, func(fi modules.FileInfo) {
+				sp, err := fi.SiaPath.Rebase(base, modules.RootSiaPath())
+				if err != nil {
+					return
+				}
+				fi.SiaPath = sp
+				mu.Lock()
+				entries = append(entries, listEntry{siaPath: sp.String(), file: &fi})
+				mu.Unlock()
+			})
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to get file infos")
+			}
+		}
+		dirs = next
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].siaPath < entries[j].siaPath
+	})
+	return entries, nil
+}
+
+// renterListHandlerGET handles the API call to list a page of the directories
+// and files of a directory tree.
+func (api *API) renterListHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the parameters.
+	var err error
+	depth := 1
+	if d := req.FormValue("depth"); d != "" {
+		depth, err = strconv.Atoi(d)
+		if err != nil || depth < 0 {
+			WriteError(w, Error{"unable to parse 'depth'"}, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultListLimit
+	if l := req.FormValue("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			WriteError(w, Error{"unable to parse 'limit'"}, http.StatusBadRequest)
+			return
+		}
+	}
+	var after string
+	if t := req.FormValue("token"); t != "" {
+		after, err = decodeListToken(t)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'token': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var fields map[string]struct{}
+	if f := req.FormValue("fields"); f != "" {
+		fields = make(map[string]struct{})
+		for _, field := range strings.Split(f, ",") {
+			fields[strings.TrimSpace(field)] = struct{}{}
+		}
+	}
+	cached := true
+	if c := req.FormValue("cached"); c != "" {
+		cached, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'cached' arg"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the directory to list.
+	siaPath := modules.RootSiaPath()
+	if str := ps.ByName("siapath"); str != "" && str != "/" {
+		siaPath, err = modules.NewSiaPath(str)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	base := modules.RootSiaPath()
+	if !root {
+		base = modules.UserFolder
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := api.managedListTree(siaPath, base, depth, cached)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	// Select the page following the token.
+	start := sort.Search(len(entries), func(i int) bool {
+		return after == "" || entries[i].siaPath > after
+	})
+	end := len(entries)
+	if end-start > limit {
+		end = start + limit
+	}
+	page := entries[start:end]
+	var nextToken string
+	if end < len(entries) {
+		nextToken = encodeListToken(page[len(page)-1].siaPath)
+	}
+
+	// Return the page with all fields.
+	if fields == nil {
+		rlg := RenterListGET{
+			Directories: []modules.DirectoryInfo{},
+			Files:       []modules.FileInfo{},
+			NextToken:   nextToken,
+		}
+		for _, e := range page {
+			if e.dir != nil {
+				rlg.Directories = append(rlg.Directories, *e.dir)
+			} else {
+				rlg.Files = append(rlg.Files, *e.file)
+			}
+		}
+		WriteJSON(w, rlg)
+		return
+	}
+
+	// Return the page with the selected fields.
+	rlg := renterListFieldsGET{
+		Directories: []map[string]interface{}{},
+		Files:       []map[string]interface{}{},
+		NextToken:   nextToken,
+	}
+	for _, e := range page {
+		var v interface{} = e.file
+		if e.dir != nil {
+			v = e.dir
+		}
+		m, err := selectFields(v, fields)
+		if err != nil {
+			WriteError(w, Error{"failed to select fields: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if e.dir != nil {
+			rlg.Directories = append(rlg.Directories, m)
+		} else {
+			rlg.Files = append(rlg.Files, m)
+		}
+	}
+	WriteJSON(w, rlg)
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultSearchLimit is the number of files returned by /renter/search
+	// if no limit is specified.
+	defaultSearchLimit = 100
+)
+
+type (
+	// RenterSearchGET contains a page of the files matching a search and the
+	// total number of matching files.
+	RenterSearchGET struct {
+		Files []modules.FileInfo `json:"files"`
+		Total int                `json:"total"`
+	}
+
+	// fileQuery contains the filters of a file search. A file matches the
+	// query if it passes all of them.
+	fileQuery struct {
+		name           string
+		regex          *regexp.Regexp
+		minSize        uint64
+		maxSize        uint64
+		minHealth      float64
+		maxHealth      float64
+		modifiedAfter  time.Time
+		modifiedBefore time.Time
+		tags           map[string]string
+	}
+)
+
+// parseTags parses a comma separated list of key=value tags. Tags without a
+// value have an empty value. Whitespace around the keys and values is trimmed,
+// tags with an empty key are dropped and later tags replace earlier tags with
+// the same key.
+func parseTags(s string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(s, ",") {
+		kv := strings.SplitN(tag, "=", 2)
+		key := strings.TrimSpace(kv[0])
+		if key == "" {
+			continue
+		}
+		var value string
+		if len(kv) == 2 {
+			value = strings.TrimSpace(kv[1])
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// parseFileQuery parses the filters of a file search from the form values of
+// a request.
+func parseFileQuery(req *http.Request) (q fileQuery, err error) {
+	q.name = strings.ToLower(req.FormValue("name"))
+	if r := req.FormValue("regex"); r != "" {
+		q.regex, err = regexp.Compile(r)
+		if err != nil {
+			return fileQuery{}, errors.AddContext(err, "unable to parse 'regex'")
+		}
+	}
+	parseUint := func(key string, def uint64) (uint64, error) {
+		if v := req.FormValue(key); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			return n, errors.AddContext(err, "unable to parse '"+key+"'")
+		}
+		return def, nil
+	}
+	parseFloat := func(key string, def float64) (float64, error) {
+		if v := req.FormValue(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			return f, errors.AddContext(err, "unable to parse '"+key+"'")
+		}
+		return def, nil
+	}
+	parseTime := func(key string) (time.Time, error) {
+		if v := req.FormValue(key); v != "" {
+			unix, err := strconv.ParseInt(v, 10, 64)
+			return time.Unix(unix, 0), errors.AddContext(err, "unable to parse '"+key+"'")
+		}
+		return time.Time{}, nil
+	}
+	if q.minSize, err = parseUint("minsize", 0); err != nil {
+		return fileQuery{}, err
+	}
+	if q.maxSize, err = parseUint("maxsize", math.MaxUint64); err != nil {
+		return fileQuery{}, err
+	}
+	if q.minHealth, err = parseFloat("minhealth", math.Inf(-1)); err != nil {
+		return fileQuery{}, err
+	}
+	if q.maxHealth, err = parseFloat("maxhealth", math.Inf(1)); err != nil {
+		return fileQuery{}, err
+	}
+	if q.modifiedAfter, err = parseTime("modifiedafter"); err != nil {
+		return fileQuery{}, err
+	}
+	if q.modifiedBefore, err = parseTime("modifiedbefore"); err != nil {
+		return fileQuery{}, err
+	}
+	if t := req.FormValue("tags"); t != "" {
+		q.tags = parseTags(t)
+	}
+	return q, nil
+}
+
+// matches returns true if the file passes all the filters of the query. The
+// siapath of the file is matched against the regex as it is returned to the
+// user.
+func (q fileQuery) matches(fi modules.FileInfo, siaPath modules.SiaPath) bool {
+	if q.name != "" && !strings.Contains(strings.ToLower(fi.Name()), q.name) {
+		return false
+	}
+	if q.regex != nil && !q.regex.MatchString(siaPath.String()) {
+		return false
+	}
+	if fi.Filesize < q.minSize || fi.Filesize > q.maxSize {
+		return false
+	}
+	if fi.Health < q.minHealth || fi.Health > q.maxHealth {
+		return false
+	}
+	if !q.modifiedAfter.IsZero() && fi.ModificationTime.Before(q.modifiedAfter) {
+		return false
+	}
+	if !q.modifiedBefore.IsZero() && fi.ModificationTime.After(q.modifiedBefore) {
+		return false
+	}
+	for key, value := range q.tags {
+		v, exists := fi.Tags[key]
+		if !exists || (value != "" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortFileInfos sorts the files by the given field, or by siapath if the field
+// is unknown. Files with equal values are sorted by siapath.
+func sortFileInfos(fis []modules.FileInfo, sortBy string, desc bool) {
+	less := func(a, b modules.FileInfo) bool { return false }
+	switch sortBy {
+	case "size":
+		less = func(a, b modules.FileInfo) bool { return a.Filesize < b.Filesize }
+	case "health":
+		less = func(a, b modules.FileInfo) bool { return a.Health < b.Health }
+	case "modtime":
+		less = func(a, b modules.FileInfo) bool { return a.ModificationTime.Before(b.ModificationTime) }
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		a, b := fis[i], fis[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		} else if less(b, a) {
+			return false
+		}
+		return a.SiaPath.String() < b.SiaPath.String()
+	})
+}
+
+// renterSearchHandlerGET handles the API call to search the files of the
+// renter.
+func (api *API) renterSearchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	q, err := parseFileQuery(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse the sorting and pagination parameters.
+	sortBy := req.FormValue("sortby")
+	switch sortBy {
+	case "", "siapath", "size", "health", "modtime":
+	default:
+		WriteError(w, Error{"sortby must be one of siapath, size, health or modtime"}, http.StatusBadRequest)
+		return
+	}
+	var desc bool
+	switch order := req.FormValue("order"); order {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		WriteError(w, Error{"order must be either asc or desc"}, http.StatusBadRequest)
+		return
+	}
+	offset, limit := 0, defaultSearchLimit
+	if o := req.FormValue("offset"); o != "" {
+		offset, err = strconv.Atoi(o)
+		if err != nil || offset < 0 {
+			WriteError(w, Error{"unable to parse 'offset'"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if l := req.FormValue("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			WriteError(w, Error{"unable to parse 'limit'"}, http.StatusBadRequest)
+			return
+		}
+	}
+	cached := true
+	if c := req.FormValue("cached"); c != "" {
+		cached, err = strconv.ParseBool(c)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'cached' arg"}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the directory to search, which defaults to the root of the user's
+	// files.
+	siaPath := modules.RootSiaPath()
+	if sp := req.FormValue("siapath"); sp != "" {
+		siaPath, err = modules.NewSiaPath(sp)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	base := modules.RootSiaPath()
+	if !root {
+		base = modules.UserFolder
+		siaPath, err = rebaseInputSiaPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Collect the matching files.
+	files := []modules.FileInfo{}
+	var mu sync.Mutex
+	err = api.renter.FileList(siaPath, true, cached, func(fi modules.FileInfo) {
+		rel, err := fi.SiaPath.Rebase(base, modules.RootSiaPath())
+		if err != nil || !q.matches(fi, rel) {
+			return
+		}
+		fi.SiaPath = rel
+		mu.Lock()
+		files = append(files, fi)
+		mu.Unlock()
+	})
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sortFileInfos(files, sortBy, desc)
+
+	// Return the requested page.
+	total := len(files)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if total-offset > limit {
+		end = offset + limit
+	}
+	WriteJSON(w, RenterSearchGET{
+		Files: files[offset:end],
+		Total: total,
+	})
+}
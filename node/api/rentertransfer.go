@@ -0,0 +1,487 @@
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// dirTransferRetention is the time after which the completed directory
+	// transfers are no longer tracked by the API.
+	dirTransferRetention = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Testnet:  24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+const (
+	// dirTransferUpload and dirTransferDownload are the types of the
+	// directory transfers.
+	dirTransferUpload   = "upload"
+	dirTransferDownload = "download"
+)
+
+type (
+	// RenterDirTransfer reports the progress of a recursive directory upload
+	// or download.
+	RenterDirTransfer struct {
+		ID        string          `json:"id"`
+		Type      string          `json:"type"`
+		SiaPath   modules.SiaPath `json:"siapath"`
+		LocalPath string          `json:"localpath"`
+		StartTime time.Time       `json:"starttime"`
+
+		Completed        bool    `json:"completed"`
+		CompletedFiles   int     `json:"completedfiles"`
+		FailedFiles      int     `json:"failedfiles"`
+		SkippedFiles     int     `json:"skippedfiles"`
+		TotalFiles       int     `json:"totalfiles"`
+		TotalBytes       uint64  `json:"totalbytes"`
+		TransferredBytes uint64  `json:"transferredbytes"`
+		Progress         float64 `json:"progress"`
+
+		Files []RenterDirTransferFile `json:"files"`
+	}
+
+	// RenterDirTransferFile reports the progress of a single file of a
+	// directory transfer.
+	RenterDirTransferFile struct {
+		SiaPath     modules.SiaPath `json:"siapath"`
+		LocalPath   string          `json:"localpath"`
+		Size        uint64          `json:"size"`
+		Transferred uint64          `json:"transferred"`
+		Completed   bool            `json:"completed"`
+		Skipped     bool            `json:"skipped"`
+		Error       string          `json:"error"`
+	}
+
+	// dirTransfer is a directory transfer tracked by the API. It is not
+	// modified after being created, the progress of the files is queried from
+	// the renter.
+	dirTransfer struct {
+		staticID        string
+		staticType      string
+		staticSiaPath   modules.SiaPath
+		staticLocalPath string
+		staticStartTime time.Time
+		staticFiles     []dirTransferFile
+	}
+
+	// dirTransferFile is a single file of a directory transfer. siaPath is the
+	// siapath relative to the root and displayPath the siapath returned to
+	// the user.
+	dirTransferFile struct {
+		siaPath     modules.SiaPath
+		displayPath modules.SiaPath
+		localPath   string
+		size        uint64
+		downloadID  modules.DownloadID
+		skipped     bool
+		err         error
+	}
+
+	// dirTransfers contains the directory transfers started through the API.
+	dirTransfers struct {
+		transfers map[string]*dirTransfer
+		mu        sync.Mutex
+	}
+)
+
+// add starts tracking a directory transfer.
+func (dts *dirTransfers) add(dt *dirTransfer) {
+	dts.mu.Lock()
+	defer dts.mu.Unlock()
+	if dts.transfers == nil {
+		dts.transfers = make(map[string]*dirTransfer)
+	}
+	dts.transfers[dt.staticID] = dt
+}
+
+// prune stops tracking the directory transfers which were started more than
+// dirTransferRetention ago and are completed.
+func (dts *dirTransfers) prune(completed func(*dirTransfer) bool) {
+	dts.mu.Lock()
+	var expired []*dirTransfer
+	for _, dt := range dts.transfers {
+		if time.Since(dt.staticStartTime) > dirTransferRetention {
+			expired = append(expired, dt)
+		}
+	}
+	dts.mu.Unlock()
+
+	// Check whether the transfers are completed without holding the lock
+	// since it requires querying the renter.
+	for _, dt := range expired {
+		if !completed(dt) {
+			continue
+		}
+		dts.mu.Lock()
+		delete(dts.transfers, dt.staticID)
+		dts.mu.Unlock()
+	}
+}
+
+// get returns the directory transfer with the given id.
+func (dts *dirTransfers) get(id string) (*dirTransfer, bool) {
+	dts.mu.Lock()
+	defer dts.mu.Unlock()
+	dt, exists := dts.transfers[id]
+	return dt, exists
+}
+
+// newDirTransfer creates a directory transfer with a random id.
+func newDirTransfer(typ string, siaPath modules.SiaPath, localPath string, files []dirTransferFile) *dirTransfer {
+	return &dirTransfer{
+		staticID:        hex.EncodeToString(fastrand.Bytes(16)),
+		staticType:      typ,
+		staticSiaPath:   siaPath,
+		staticLocalPath: localPath,
+		staticStartTime: time.Now(),
+		staticFiles:     files,
+	}
+}
+
+// dirTransferStatus returns the progress of a directory transfer. The
+// progress of uploads is based on the upload progress of the files and the
+// progress of downloads on the download history of the renter.
+func (api *API) dirTransferStatus(dt *dirTransfer) RenterDirTransfer {
+	status := RenterDirTransfer{
+		ID:         dt.staticID,
+		Type:       dt.staticType,
+		SiaPath:    dt.staticSiaPath,
+		LocalPath:  dt.staticLocalPath,
+		StartTime:  dt.staticStartTime,
+		TotalFiles: len(dt.staticFiles),
+		Files:      make([]RenterDirTransferFile, 0, len(dt.staticFiles)),
+	}
+	for _, f := range dt.staticFiles {
+		file := RenterDirTransferFile{
+			SiaPath:   f.displayPath,
+			LocalPath: f.localPath,
+			Size:      f.size,
+			Skipped:   f.skipped,
+		}
+		switch {
+		case f.skipped:
+			file.Completed = true
+		case f.err != nil:
+			file.Completed = true
+			file.Error = f.err.Error()
+		case dt.staticType == dirTransferUpload:
+			fi, err := api.renter.File(f.siaPath)
+			if err != nil {
+				file.Error = err.Error()
+				file.Completed = true
+				break
+			}
+			progress := fi.UploadProgress
+			if progress > 100 {
+				progress = 100
+			}
+			file.Transferred = uint64(float64(f.size) * progress / 100)
+			file.Completed = progress >= 100
+		case dt.staticType == dirTransferDownload:
+			di, exists := api.renter.DownloadByUID(f.downloadID)
+			if !exists {
+				file.Error = "download is no longer in the download history"
+				file.Completed = true
+				break
+			}
+			file.Transferred = di.Received
+			file.Completed = di.Completed
+			file.Error = di.Error
+		}
+
+		if !file.Skipped {
+			status.TotalBytes += file.Size
+			status.TransferredBytes += file.Transferred
+		}
+		switch {
+		case file.Skipped:
+			status.SkippedFiles++
+		case file.Error != "":
+			status.FailedFiles++
+		case file.Completed:
+			status.CompletedFiles++
+		}
+		status.Files = append(status.Files, file)
+	}
+	status.Completed = status.CompletedFiles+status.FailedFiles+status.SkippedFiles == status.TotalFiles
+	if status.TotalBytes > 0 {
+		status.Progress = 100 * float64(status.TransferredBytes) / float64(status.TotalBytes)
+	} else if status.Completed {
+		status.Progress = 100
+	}
+	return status
+}
+
+// renterDirTransferHandlerGET handles the API call to get the progress of a
+// directory transfer.
+func (api *API) renterDirTransferHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	dt, exists := api.staticDirTransfers.get(ps.ByName("id"))
+	if !exists {
+		WriteError(w, Error{"directory transfer not found"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, api.dirTransferStatus(dt))
+}
+
+// managedAddDirTransfer starts tracking a directory transfer and stops
+// tracking the expired ones.
+func (api *API) managedAddDirTransfer(dt *dirTransfer) {
+	api.staticDirTransfers.prune(func(dt *dirTransfer) bool {
+		return api.dirTransferStatus(dt).Completed
+	})
+	api.staticDirTransfers.add(dt)
+}
+
+// renterUploadDirHandler handles the API call to upload a local directory
+// tree to a siapath, preserving its structure.
+func (api *API) renterUploadDirHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Get the source path.
+	source := req.FormValue("source")
+	if !filepath.IsAbs(source) {
+		WriteError(w, Error{"source must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	if fi, err := os.Stat(source); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	} else if !fi.IsDir() {
+		WriteError(w, Error{"source must be a directory"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing files should be overwritten.
+	var err error
+	force := false
+	if f := req.FormValue("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Parse the siapath.
+	displayPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err := rebaseInputSiaPath(displayPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Create the directories and collect the files of the tree. A path which
+	// can't be read or created doesn't stop the transfer, it is reported as
+	// failed.
+	var files []dirTransferFile
+	err = filepath.Walk(source, func(path string, info os.FileInfo, walkErr error) error {
+		file := dirTransferFile{
+			siaPath:     siaPath,
+			displayPath: displayPath,
+			localPath:   path,
+		}
+		rel, err := filepath.Rel(source, path)
+		if err == nil && rel != "." {
+			file.siaPath, err = siaPath.Join(filepath.ToSlash(rel))
+			if err == nil {
+				file.displayPath, err = displayPath.Join(filepath.ToSlash(rel))
+			}
+		}
+		if err == nil {
+			err = walkErr
+		}
+		if err == nil && info.IsDir() {
+			err = api.renter.CreateDir(file.siaPath, info.Mode().Perm())
+			if errors.Contains(err, filesystem.ErrExists) {
+				return nil
+			}
+			err = errors.AddContext(err, "failed to create directory")
+		}
+		if err != nil {
+			file.err = err
+			files = append(files, file)
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Only regular files are uploaded.
+		if info.IsDir() || !info.Mode().IsRegular() {
+			return nil
+		}
+		file.size = uint64(info.Size())
+		files = append(files, file)
+		return nil
+	})
+	if err != nil {
+		WriteError(w, Error{"unable to read source directory: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Upload the files. A failure doesn't stop the other uploads.
+	for i := range files {
+		if files[i].err != nil {
+			continue
+		}
+		files[i].err = api.renter.Upload(modules.FileUploadParams{
+			Source:              files[i].localPath,
+			SiaPath:             files[i].siaPath,
+			ErasureCode:         ec,
+			Force:               force,
+			DisablePartialChunk: true,
+			CipherType:          crypto.TypeDefaultRenter,
+		})
+	}
+	dt := newDirTransfer(dirTransferUpload, displayPath, source, files)
+	api.managedAddDirTransfer(dt)
+	WriteJSON(w, api.dirTransferStatus(dt))
+}
+
+// renterDownloadDirHandler handles the API call to download a siadir to a
+// local directory, preserving its structure. The files are downloaded
+// asynchronously. Subdirectories are downloaded unless recursive is false.
+func (api *API) renterDownloadDirHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Get the destination path.
+	destination := req.FormValue("destination")
+	if !filepath.IsAbs(destination) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing files should be overwritten.
+	var err error
+	force := false
+	if f := req.FormValue("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	recursive := true
+	if r := req.FormValue("recursive"); r != "" {
+		recursive, err = strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'recursive' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the siapath.
+	displayPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, base := displayPath, modules.RootSiaPath()
+	if !root {
+		base = modules.UserFolder
+		siaPath, err = rebaseInputSiaPath(displayPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Create the local directories.
+	if err := api.createLocalDirs(siaPath, destination, recursive); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Collect the files of the siadir.
+	var fis []modules.FileInfo
+	var mu sync.Mutex
+	err = api.renter.FileList(siaPath, recursive, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		fis = append(fis, fi)
+		mu.Unlock()
+	})
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sort.Slice(fis, func(i, j int) bool {
+		return fis[i].SiaPath.String() < fis[j].SiaPath.String()
+	})
+
+	// Download the files. Existing files are skipped unless force is set and a
+	// failure doesn't stop the other downloads.
+	files := make([]dirTransferFile, 0, len(fis))
+	for _, fi := range fis {
+		file := dirTransferFile{
+			siaPath: fi.SiaPath,
+			size:    fi.Filesize,
+		}
+		rel, err := fi.SiaPath.Rebase(siaPath, modules.RootSiaPath())
+		if err == nil {
+			file.displayPath, err = fi.SiaPath.Rebase(base, modules.RootSiaPath())
+		}
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		file.localPath = filepath.Join(destination, filepath.FromSlash(rel.String()))
+		if _, err := os.Stat(file.localPath); err == nil && !force {
+			file.skipped = true
+		} else {
+			file.downloadID, file.err = api.startAsyncDownload(fi.SiaPath, file.localPath)
+		}
+		files = append(files, file)
+	}
+	dt := newDirTransfer(dirTransferDownload, displayPath, destination, files)
+	api.managedAddDirTransfer(dt)
+	WriteJSON(w, api.dirTransferStatus(dt))
+}
+
+// createLocalDirs creates a local directory for the siadir at siaPath and, if
+// recursive is set, all of its subdirectories.
+func (api *API) createLocalDirs(siaPath modules.SiaPath, destination string, recursive bool) error {
+	dis, err := api.renter.DirList(siaPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(destination, modules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "unable to create the destination directory")
+	}
+	if !recursive {
+		return nil
+	}
+	// The first directory is the directory itself.
+	for i := 1; i < len(dis); i++ {
+		err := api.createLocalDirs(dis[i].SiaPath, filepath.Join(destination, dis[i].SiaPath.Name()), true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
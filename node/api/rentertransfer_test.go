@@ -0,0 +1,38 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestDirTransfersPrune tests that only the completed directory transfers
+// which are older than the retention period are pruned.
+func TestDirTransfersPrune(t *testing.T) {
+	t.Parallel()
+
+	var dts dirTransfers
+	expired := time.Now().Add(-dirTransferRetention - time.Second)
+	newTransfer := func(start time.Time) *dirTransfer {
+		dt := newDirTransfer(dirTransferUpload, modules.RootSiaPath(), "/", nil)
+		dt.staticStartTime = start
+		dts.add(dt)
+		return dt
+	}
+	recent := newTransfer(time.Now())
+	running := newTransfer(expired)
+	completed := newTransfer(expired)
+
+	dts.prune(func(dt *dirTransfer) bool {
+		return dt != running
+	})
+	for _, dt := range []*dirTransfer{recent, running} {
+		if _, exists := dts.get(dt.staticID); !exists {
+			t.Fatal("transfer shouldn't be pruned", dt.staticStartTime)
+		}
+	}
+	if _, exists := dts.get(completed.staticID); exists {
+		t.Fatal("expired transfer should be pruned")
+	}
+}
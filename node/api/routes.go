@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"net/http"
 	"strings"
 	"time"
@@ -22,10 +23,18 @@ var (
 	}).(time.Duration)
 )
 
-// buildHttpRoutes sets up and returns an * httprouter.Router.
+// buildHTTPRoutes builds the router of the api and replaces the current one.
+func (api *API) buildHTTPRoutes() {
+	router := api.newRouter()
+	api.routerMu.Lock()
+	api.router = router
+	api.routerMu.Unlock()
+}
+
+// newRouter sets up and returns an * httprouter.Router.
 // it connected the Router to the given api using the required
 // parameters: requiredUserAgent and requiredPassword
-func (api *API) buildHTTPRoutes() {
+func (api *API) newRouter() http.Handler {
 	router := httprouter.New()
 	requiredPassword := api.requiredPassword
 	requiredUserAgent := api.requiredUserAgent
@@ -35,7 +44,16 @@ func (api *API) buildHTTPRoutes() {
 
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
+	router.POST("/daemon/alerts/acknowledge", RequirePassword(api.daemonAlertsAcknowledgeHandlerPOST, requiredPassword))
+	router.POST("/daemon/alerts/silence", RequirePassword(api.daemonAlertsSilenceHandlerPOST, requiredPassword))
+	router.GET("/daemon/audit", RequirePassword(api.daemonAuditHandlerGET, requiredPassword))
+	router.GET("/daemon/config", RequirePassword(api.daemonConfigHandlerGET, requiredPassword))
+	router.POST("/daemon/config/reload", RequirePassword(api.daemonConfigReloadHandlerPOST, requiredPassword))
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
+	router.GET("/daemon/diagnostics", RequirePassword(api.daemonDiagnosticsHandlerGET, requiredPassword))
+	router.GET("/daemon/logs", RequirePassword(api.daemonLogsHandlerGET, requiredPassword))
+	router.GET("/daemon/logs/levels", api.daemonLogLevelsHandlerGET)
+	router.POST("/daemon/logs/levels", RequirePassword(api.daemonLogLevelsHandlerPOST, requiredPassword))
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
 	router.GET("/daemon/stack", api.daemonStackHandlerGET)
@@ -46,6 +64,9 @@ func (api *API) buildHTTPRoutes() {
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
 
+	// Metrics API Calls
+	router.GET("/metrics", RequirePassword(api.metricsHandler, requiredPassword))
+
 	// Consensus API Calls
 	if api.cs != nil {
 		RegisterRoutesConsensus(router, api.cs)
@@ -81,14 +102,24 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter", api.renterHandlerGET)
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
 		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
+		router.GET("/renter/allowance/plan", api.renterAllowancePlanHandlerGET)
+		router.GET("/renter/forecast", api.renterForecastHandlerGET)
+		router.GET("/renter/gouging", api.renterGougingHandlerGET)
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
+		router.POST("/renter/bulk", RequirePassword(api.renterBulkHandlerPOST, requiredPassword))
 		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
 		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
 		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
 		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.POST("/renter/contracts/export", RequirePassword(api.renterContractsExportHandlerPOST, requiredPassword))
+		router.POST("/renter/contracts/import", RequirePassword(api.renterContractsImportHandlerPOST, requiredPassword))
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
+		router.POST("/renter/custody/cancel", RequirePassword(api.renterCustodyCancelHandlerPOST, requiredPassword))
+		router.POST("/renter/custody/confirm", RequirePassword(api.renterCustodyConfirmHandlerPOST, requiredPassword))
+		router.POST("/renter/custody/import", RequirePassword(api.renterCustodyImportHandlerPOST, requiredPassword))
+		router.POST("/renter/custody/transfer", RequirePassword(api.renterCustodyTransferHandlerPOST, requiredPassword))
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
@@ -98,36 +129,78 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/renter/prices", api.renterPricesHandler)
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.GET("/renter/search", api.renterSearchHandlerGET)
+		router.POST("/renter/share/export", RequirePassword(api.renterShareExportHandlerPOST, requiredPassword))
+		router.POST("/renter/share/import", RequirePassword(api.renterShareImportHandlerPOST, requiredPassword))
+		router.GET("/renter/share/key", api.renterShareKeyHandlerGET)
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
 		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
 		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
 
+		router.POST("/renter/concat/*siapath", RequirePassword(api.renterConcatHandler, requiredPassword))
 		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
 		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
+		router.GET("/renter/downloaddir/*siapath", RequirePassword(api.renterDownloadDirHandler, requiredPassword))
+		router.GET("/renter/dirtransfer/:id", api.renterDirTransferHandlerGET)
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.POST("/renter/uploaddir/*siapath", RequirePassword(api.renterUploadDirHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
+		router.GET("/renter/reshard", api.renterReshardHandlerGET)
+		router.POST("/renter/reshard/*siapath", RequirePassword(api.renterReshardHandlerPOST, requiredPassword))
+		router.GET("/renter/packs", api.renterPacksHandlerGET)
+		router.GET("/renter/paymentbudgets", api.renterPaymentBudgetsHandlerGET)
+		router.POST("/renter/paymentbudgets", RequirePassword(api.renterPaymentBudgetsHandlerPOST, requiredPassword))
+		router.GET("/renter/paymentbudget/:id", api.renterPaymentBudgetHandlerGET)
+		router.POST("/renter/paymentbudget/:id", RequirePassword(api.renterPaymentBudgetHandlerPOST, requiredPassword))
+		router.POST("/renter/paymentbudget/:id/delete", RequirePassword(api.renterPaymentBudgetDeleteHandlerPOST, requiredPassword))
+		router.POST("/renter/paymentbudget/:id/execute", RequirePassword(api.renterPaymentBudgetExecuteHandlerPOST, requiredPassword))
+		router.POST("/renter/programcost", api.renterProgramCostHandlerPOST)
+		router.GET("/renter/audits", api.renterAuditsHandlerGET)
+		router.POST("/renter/audits", RequirePassword(api.renterAuditsHandlerPOST, requiredPassword))
+		router.POST("/renter/audit", RequirePassword(api.renterAuditHandlerPOST, requiredPassword))
+		router.GET("/renter/stats", api.renterStatsHandlerGET)
+		router.POST("/renter/packs/flush", RequirePassword(api.renterPacksFlushHandlerPOST, requiredPassword))
+		router.GET("/renter/pack/*siapath", api.renterPackHandlerGET)
+		router.POST("/renter/pack/*siapath", RequirePassword(api.renterPackHandlerPOST, requiredPassword))
+		router.POST("/renter/packdelete/*siapath", RequirePassword(api.renterPackDeleteHandlerPOST, requiredPassword))
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
+		router.GET("/renter/repair", api.renterRepairHandlerGET)
+		router.POST("/renter/repair/pause", RequirePassword(api.renterRepairPauseHandlerPOST, requiredPassword))
+		router.POST("/renter/repair/resume", RequirePassword(api.renterRepairResumeHandlerPOST, requiredPassword))
 		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
+		router.GET("/renter/uploadurl", api.renterUploadURLHandlerGET)
+		router.POST("/renter/uploadurl/*siapath", RequirePassword(api.renterUploadURLHandlerPOST, requiredPassword))
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
+		router.GET("/renter/workers/:pubkey", api.renterWorkerHandler)
 		router.GET("/renter/hosts/*siapath", api.renterFileHostsHandler)
+		router.GET("/renter/checksums/*siapath", api.renterFileChecksumsHandlerGET)
 
 		// Directory endpoints
 		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
 		router.GET("/renter/dir/*siapath", api.renterDirHandlerGET)
+		router.GET("/renter/list/*siapath", api.renterListHandlerGET)
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
+		router.GET("/hostdb/benchmark", api.hostdbBenchmarkHandlerGET)
+		router.POST("/hostdb/benchmark", RequirePassword(api.hostdbBenchmarkHandlerPOST, requiredPassword))
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.POST("/hostdb/hosts/:pubkey/offline", RequirePassword(api.hostdbHostsOfflineHandlerPOST, requiredPassword))
+		router.POST("/hostdb/hosts/:pubkey/scan", RequirePassword(api.hostdbHostsScanHandlerPOST, requiredPassword))
 		router.GET("/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
 		router.POST("/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
+		router.GET("/hostdb/snapshot", api.hostdbSnapshotHandlerGET)
+		router.GET("/hostdb/scansettings", api.hostdbScanSettingsHandlerGET)
+		router.POST("/hostdb/scansettings", RequirePassword(api.hostdbScanSettingsHandlerPOST, requiredPassword))
+		router.POST("/hostdb/snapshot", RequirePassword(api.hostdbSnapshotHandlerPOST, requiredPassword))
 
 		// Renter watchdog endpoints.
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
@@ -139,7 +212,7 @@ func (api *API) buildHTTPRoutes() {
 
 	// Transaction pool API Calls
 	if api.tpool != nil {
-		RegisterRoutesTransactionPool(router, api.tpool)
+		RegisterRoutesTransactionPool(router, api.tpool, requiredPassword)
 	}
 
 	// Wallet API Calls
@@ -148,10 +221,7 @@ func (api *API) buildHTTPRoutes() {
 	}
 
 	// Apply UserAgent middleware and return the Router
-	api.routerMu.Lock()
-	api.router = timeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout)
-	api.routerMu.Unlock()
-	return
+	return timeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout)
 }
 
 // timeoutHandler is a middleware that enforces a specific timeout on the route
@@ -163,7 +233,12 @@ func timeoutHandler(h http.Handler, timeout time.Duration) http.Handler {
 		defer cancel()
 
 		// Add the new context to the request and call the handler.
-		h.ServeHTTP(w, req.WithContext(ctx))
+		r := req.WithContext(ctx)
+		h.ServeHTTP(w, r)
+
+		// Pass the form parsed by the handler back to the caller, which
+		// records it in the audit log.
+		req.Form, req.PostForm = r.Form, r.PostForm
 	})
 }
 
@@ -171,7 +246,7 @@ func timeoutHandler(h http.Handler, timeout time.Duration) http.Handler {
 // UserAgent that contains the specified string.
 func RequireUserAgent(h http.Handler, ua string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-		if !strings.Contains(req.UserAgent(), ua) && !isUnrestricted(req) {
+		if !strings.Contains(req.UserAgent(), ua) && !isUnrestricted(req) && !isCORSOriginAllowed(req) {
 			WriteError(w, Error{"Browser access disabled due to security vulnerability. Use Sia-UI or siac."}, http.StatusBadRequest)
 			return
 		}
@@ -188,8 +263,7 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 		return h
 	}
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-		_, pass, ok := req.BasicAuth()
-		if !ok || pass != password {
+		if !hasPassword(req, password) {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
 			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
 			return
@@ -198,7 +272,16 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 }
 
-// isUnrestricted checks if a request may bypass the useragent check.
+// hasPassword checks if a request provides the API password in its basic auth
+// header. The password is compared in constant time.
+func hasPassword(req *http.Request, password string) bool {
+	_, pass, ok := req.BasicAuth()
+	return ok && subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1
+}
+
+// isUnrestricted checks if a request may bypass the useragent check. Metrics
+// are scraped by monitoring tools that can't set the user agent, but require
+// the API password instead.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || req.URL.Path == "/metrics"
 }
@@ -4,11 +4,13 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -22,6 +24,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/node/api/grpcapi"
 	"go.sia.tech/siad/types"
 )
 
@@ -30,6 +33,8 @@ import (
 type Server struct {
 	api               *api.API
 	apiServer         *http.Server
+	grpcListener      net.Listener
+	grpcServer        *grpcapi.Server
 	listener          net.Listener
 	node              *node.Node
 	requiredUserAgent string
@@ -40,7 +45,8 @@ type Server struct {
 
 	closeChan chan struct{}
 
-	closeMu sync.Mutex
+	closeMu   sync.Mutex
+	modulesMu sync.Mutex
 }
 
 // serve listens for and handles API calls. It is a blocking function.
@@ -67,10 +73,16 @@ func (srv *Server) Close() error {
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
 		err = errors.Compose(err, srv.serveErr)
 	}
-	// Shutdown modules.
+	// Stop serving the gRPC API.
+	if srv.grpcServer != nil {
+		srv.grpcServer.Stop()
+	}
+	// Stop sending notifications and shutdown modules.
+	srv.api.StopAlertDispatcher()
 	if srv.node != nil {
 		err = errors.Compose(err, srv.node.Close())
 	}
+	err = errors.Compose(err, srv.api.CloseAuditLog())
 	return errors.AddContext(err, "error while closing server")
 }
 
@@ -84,6 +96,15 @@ func (srv *Server) APIAddress() string {
 	return srv.listener.Addr().String()
 }
 
+// GRPCAddress returns the address of the gRPC API or an empty string if the
+// gRPC API isn't served.
+func (srv *Server) GRPCAddress() string {
+	if srv.grpcListener == nil {
+		return ""
+	}
+	return srv.grpcListener.Addr().String()
+}
+
 // GatewayAddress returns the underlying node's gateway address
 func (srv *Server) GatewayAddress() modules.NetAddress {
 	return srv.node.Gateway.Address()
@@ -116,6 +137,43 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// ReloadConfigFile reloads the config file of the server and applies its
+// dynamic settings. It returns the daemon settings that require a restart to
+// take effect.
+func (srv *Server) ReloadConfigFile() ([]string, error) {
+	return srv.api.ReloadConfigFile()
+}
+
+// StartModule starts a module of the node and adds it to the API.
+func (srv *Server) StartModule(module string) error {
+	srv.modulesMu.Lock()
+	defer srv.modulesMu.Unlock()
+	if err := srv.node.StartModule(module); err != nil {
+		return err
+	}
+	srv.managedReplaceModules()
+	return nil
+}
+
+// StopModule removes a module from the API and stops it.
+func (srv *Server) StopModule(module string) error {
+	srv.modulesMu.Lock()
+	defer srv.modulesMu.Unlock()
+	err := srv.node.StopModule(module)
+	srv.managedReplaceModules()
+	return err
+}
+
+// managedReplaceModules replaces the modules of the API with the modules of
+// the node.
+func (srv *Server) managedReplaceModules() {
+	n := srv.node
+	srv.api.ReplaceModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
+	if srv.grpcServer != nil {
+		srv.grpcServer.SetModules(n.ConsensusSet, n.Host, n.Renter, n.Wallet)
+	}
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
@@ -162,11 +220,19 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 	var errChan <-chan error
 	var n *node.Node
 	s, err := func() (*Server, error) {
-		// Create the server listener.
+		// Create the server listener and wrap it in a TLS listener if the API
+		// should be served over TLS.
+		tlsConfig, err := apiTLSConfig(nodeParams)
+		if err != nil {
+			return nil, errors.AddContext(err, "invalid API TLS settings")
+		}
 		listener, err := net.Listen("tcp", APIaddr)
 		if err != nil {
 			return nil, err
 		}
+		if tlsConfig != nil {
+			listener = tls.NewListener(listener, tlsConfig)
+		}
 
 		// Load the config file.
 		cfg, err := modules.NewConfig(filepath.Join(nodeParams.Dir, modules.ConfigName))
@@ -176,6 +242,19 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Create the api for the server.
 		api := api.New(cfg, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		api.SetConfigFile(nodeParams.ConfigFile, nodeParams.ConfigFlags)
+		api.SetCORSPolicy(apiCORSPolicy(nodeParams))
+		rateLimits, err := apiRateLimitPolicy(nodeParams)
+		if err != nil {
+			return nil, errors.AddContext(err, "invalid API rate limit settings")
+		}
+		api.SetRateLimitPolicy(rateLimits)
+		if nodeParams.APIAuditLog {
+			err = api.OpenAuditLog(nodeParams.Dir, nodeParams.APIAuditRetention)
+			if err != nil {
+				return nil, errors.AddContext(err, "failed to open API audit log")
+			}
+		}
 		srv := &Server{
 			api: api,
 			apiServer: &http.Server{
@@ -208,6 +287,20 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 		// Set the shutdown method to allow the api to shutdown the server.
 		api.Shutdown = srv.Close
 
+		// Serve the gRPC API if requested. It shares the password, TLS
+		// settings and audit log with the HTTP API.
+		if nodeParams.GRPCAddress != "" {
+			grpcListener, err := net.Listen("tcp", nodeParams.GRPCAddress)
+			if err != nil {
+				return nil, errors.Compose(errors.AddContext(err, "unable to listen for gRPC API"), srv.listener.Close())
+			}
+			srv.grpcListener = grpcListener
+			srv.grpcServer = grpcapi.NewServer(requiredPassword, tlsConfig, api)
+			go func() {
+				_ = srv.grpcServer.Serve(grpcListener)
+			}()
+		}
+
 		// Spin up a goroutine that serves the API and closes srv.done when
 		// finished.
 		go func() {
@@ -236,7 +329,20 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Server wasn't shut down. Add node and replace modules.
 		srv.node = n
+		api.SetNodeAlerter(n)
 		api.SetModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
+		api.SetModuleManager(srv)
+		if srv.grpcServer != nil {
+			srv.grpcServer.SetModules(n.ConsensusSet, n.Host, n.Renter, n.Wallet)
+		}
+
+		// Apply the dynamic settings of the config file.
+		if nodeParams.ConfigFile != "" {
+			if _, err := api.ReloadConfigFile(); err != nil {
+				return srv, errors.AddContext(err, "unable to apply config file")
+			}
+		}
+		api.StartAlertDispatcher()
 		return srv, nil
 	}()
 	if err != nil {
@@ -276,3 +382,59 @@ func isAddrInUseErr(err error) bool {
 	}
 	return false
 }
+
+// apiCORSPolicy returns the CORS policy of the API server for the provided
+// node params.
+func apiCORSPolicy(params node.NodeParams) api.CORSPolicy {
+	return api.CORSPolicy{
+		AllowedOrigins: params.APICORSOrigins,
+		AllowedMethods: params.APICORSMethods,
+		AllowedHeaders: params.APICORSHeaders,
+	}
+}
+
+// apiRateLimitPolicy returns the rate limit policy of the API server for the
+// provided node params.
+func apiRateLimitPolicy(params node.NodeParams) (api.RateLimitPolicy, error) {
+	policy := api.RateLimitPolicy{
+		RequestsPerSecond: params.APIRateLimit,
+		Burst:             params.APIRateBurst,
+		MaxUploads:        params.APIMaxUploads,
+		MaxDownloads:      params.APIMaxDownloads,
+		MaxRescans:        params.APIMaxRescans,
+	}
+	for _, limit := range params.APIEndpointRateLimits {
+		el, err := parseEndpointRateLimit(limit)
+		if err != nil {
+			return api.RateLimitPolicy{}, err
+		}
+		policy.EndpointLimits = append(policy.EndpointLimits, el)
+	}
+	return policy, nil
+}
+
+// parseEndpointRateLimit parses an endpoint rate limit in the form
+// "prefix=rate[:burst]".
+func parseEndpointRateLimit(limit string) (api.EndpointRateLimit, error) {
+	i := strings.LastIndexByte(limit, '=')
+	if i <= 0 {
+		return api.EndpointRateLimit{}, fmt.Errorf("endpoint rate limit %q must be of the form prefix=rate[:burst]", limit)
+	}
+	el := api.EndpointRateLimit{Prefix: limit[:i]}
+	rate, burst := limit[i+1:], ""
+	if j := strings.IndexByte(rate, ':'); j >= 0 {
+		rate, burst = rate[:j], rate[j+1:]
+	}
+	var err error
+	el.RequestsPerSecond, err = strconv.ParseFloat(rate, 64)
+	if err != nil || el.RequestsPerSecond <= 0 {
+		return api.EndpointRateLimit{}, fmt.Errorf("rate of endpoint rate limit %q must be a positive number", limit)
+	}
+	if burst != "" {
+		el.Burst, err = strconv.Atoi(burst)
+		if err != nil || el.Burst <= 0 {
+			return api.EndpointRateLimit{}, fmt.Errorf("burst of endpoint rate limit %q must be a positive integer", limit)
+		}
+	}
+	return el, nil
+}
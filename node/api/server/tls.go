@@ -0,0 +1,148 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/crypto/acme/autocert"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/node"
+)
+
+const (
+	// acmeCacheDir is the name of the directory within the sia directory which
+	// caches the account key and the certificates obtained via ACME.
+	acmeCacheDir = "acme"
+)
+
+var (
+	// certCheckInterval is the minimum interval between two checks of whether
+	// the certificate files changed.
+	certCheckInterval = build.Select(build.Var{
+		Dev:      time.Second,
+		Standard: 10 * time.Second,
+		Testnet:  10 * time.Second,
+		Testing:  10 * time.Millisecond,
+	}).(time.Duration)
+
+	// errTLSCertAndACME is returned if both a certificate and ACME domains are
+	// provided.
+	errTLSCertAndACME = errors.New("a TLS certificate and ACME domains can't both be provided")
+
+	// errTLSCertWithoutKey is returned if only one of the certificate and key
+	// files is provided.
+	errTLSCertWithoutKey = errors.New("both a TLS certificate and key file need to be provided")
+)
+
+// certReloader serves the certificate of a certificate and key file and
+// reloads it once the files change, which allows for replacing the certificate
+// without restarting siad.
+type certReloader struct {
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+	lastCheck   time.Time
+	mu          sync.Mutex
+
+	staticCertFile string
+	staticKeyFile  string
+}
+
+// newCertReloader creates a certReloader and loads the certificate.
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{
+		staticCertFile: certFile,
+		staticKeyFile:  keyFile,
+	}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+// load loads the certificate from the files.
+//
+// NOTE: cr.mu needs to be held when calling this method.
+func (cr *certReloader) load() error {
+	certInfo, err := os.Stat(cr.staticCertFile)
+	if err != nil {
+		return errors.AddContext(err, "unable to stat TLS certificate")
+	}
+	keyInfo, err := os.Stat(cr.staticKeyFile)
+	if err != nil {
+		return errors.AddContext(err, "unable to stat TLS key")
+	}
+	cert, err := tls.LoadX509KeyPair(cr.staticCertFile, cr.staticKeyFile)
+	if err != nil {
+		return errors.AddContext(err, "unable to load TLS certificate")
+	}
+	cr.cert = &cert
+	cr.certModTime = certInfo.ModTime()
+	cr.keyModTime = keyInfo.ModTime()
+	return nil
+}
+
+// GetCertificate returns the certificate. If the files changed since the
+// certificate was loaded, the certificate is reloaded first. The previous
+// certificate is kept if the new one can't be loaded, e.g. because only one of
+// the files was replaced so far.
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if time.Since(cr.lastCheck) < certCheckInterval {
+		return cr.cert, nil
+	}
+	cr.lastCheck = time.Now()
+	certInfo, err1 := os.Stat(cr.staticCertFile)
+	keyInfo, err2 := os.Stat(cr.staticKeyFile)
+	if err1 != nil || err2 != nil {
+		return cr.cert, nil
+	}
+	if certInfo.ModTime().Equal(cr.certModTime) && keyInfo.ModTime().Equal(cr.keyModTime) {
+		return cr.cert, nil
+	}
+	_ = cr.load()
+	return cr.cert, nil
+}
+
+// apiTLSConfig returns the TLS config of the API server for the provided node
+// params. It returns nil if the API should not be served over TLS.
+func apiTLSConfig(params node.NodeParams) (*tls.Config, error) {
+	withCert := params.APITLSCertFile != "" || params.APITLSKeyFile != ""
+	withACME := len(params.APITLSACMEDomains) > 0
+	switch {
+	case withCert && withACME:
+		return nil, errTLSCertAndACME
+	case withCert:
+		if params.APITLSCertFile == "" || params.APITLSKeyFile == "" {
+			return nil, errTLSCertWithoutKey
+		}
+		cr, err := newCertReloader(params.APITLSCertFile, params.APITLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{
+			GetCertificate: cr.GetCertificate,
+			MinVersion:     tls.VersionTLS12,
+		}, nil
+	case withACME:
+		// The certificates are obtained via the TLS-ALPN-01 challenge, which
+		// is answered by the API listener itself.
+		m := &autocert.Manager{
+			Cache:      autocert.DirCache(filepath.Join(params.Dir, acmeCacheDir)),
+			Email:      params.APITLSACMEEmail,
+			HostPolicy: autocert.HostWhitelist(params.APITLSACMEDomains...),
+			Prompt:     autocert.AcceptTOS,
+		}
+		tlsConfig := m.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	default:
+		return nil, nil
+	}
+}
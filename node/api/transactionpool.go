@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -21,6 +22,19 @@ type (
 		Maximum types.Currency `json:"maximum"`
 	}
 
+	// TpoolFeeEstimateGET contains the recommended fee per byte for a
+	// transaction to be confirmed within the target number of blocks.
+	TpoolFeeEstimateGET struct {
+		Target     types.BlockHeight `json:"target"`
+		FeePerByte types.Currency    `json:"feeperbyte"`
+	}
+
+	// TpoolFeeHistogramGET contains the distribution of the fees paid by the
+	// transactions in the tpool.
+	TpoolFeeHistogramGET struct {
+		Buckets []modules.FeeHistogramBucket `json:"buckets"`
+	}
+
 	// TpoolRawGET contains the requested transaction encoded to the raw
 	// format, along with the id of that transaction.
 	TpoolRawGET struct {
@@ -39,14 +53,34 @@ type (
 	TpoolTxnsGET struct {
 		Transactions []types.Transaction `json:"transactions"`
 	}
+
+	// TpoolSimulatePOST contains the result of simulating the acceptance of a
+	// transaction set.
+	TpoolSimulatePOST struct {
+		Accepted   bool           `json:"accepted"`
+		Error      string         `json:"error,omitempty"`
+		FeePerByte types.Currency `json:"feeperbyte"`
+	}
+
+	// TpoolEvictPOST contains the IDs of the transactions evicted from the
+	// tpool.
+	TpoolEvictPOST struct {
+		Evicted []types.TransactionID `json:"evicted"`
+	}
 )
 
 // RegisterRoutesTransactionPool is a helper function to register all
 // transaction pool routes.
-func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool) {
+func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool, requiredPassword string) {
 	router.GET("/tpool/fee", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolFeeHandlerGET(tpool, w, req, ps)
 	})
+	router.GET("/tpool/fee/estimate", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolFeeEstimateHandlerGET(tpool, w, req, ps)
+	})
+	router.GET("/tpool/fee/histogram", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolFeeHistogramHandlerGET(tpool, w, req, ps)
+	})
 	router.GET("/tpool/raw/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolRawHandlerGET(tpool, w, req, ps)
 	})
@@ -59,6 +93,12 @@ func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.Tran
 	router.GET("/tpool/transactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolTransactionsHandler(tpool, w, req, ps)
 	})
+	router.POST("/tpool/simulate", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolSimulateHandlerPOST(tpool, w, req, ps)
+	})
+	router.POST("/tpool/evict/:id", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolEvictHandlerPOST(tpool, w, req, ps)
+	}, requiredPassword))
 }
 
 // decodeTransactionID will decode a transaction id from a string.
@@ -81,6 +121,20 @@ func tpoolFeeHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 	})
 }
 
+// tpoolFeeEstimateHandlerGET returns the recommended fee per byte for a
+// transaction to be confirmed within the target number of blocks.
+func tpoolFeeEstimateHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	target, err := strconv.ParseUint(req.FormValue("target"), 10, 64)
+	if err != nil || target == 0 {
+		WriteError(w, Error{"target must be a positive number of blocks"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, TpoolFeeEstimateGET{
+		Target:     types.BlockHeight(target),
+		FeePerByte: tpool.FeeEstimationForTarget(types.BlockHeight(target)),
+	})
+}
+
 // tpoolRawHandlerGET will provide the raw byte representation of a
 // transaction that matches the input id.
 func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
@@ -102,22 +156,19 @@ func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 	})
 }
 
-// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
-// it to the transaction pool, relaying it to the transaction pool's peers
-// regardless of if the set is accepted.
-func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// decodeTransactionSet decodes the transaction and its parents from the
+// "transaction" and "parents" form values of a request. JSON, base64, and raw
+// binary are accepted.
+func decodeTransactionSet(req *http.Request) ([]types.Transaction, error) {
 	var parents []types.Transaction
 	var txn types.Transaction
-
-	// JSON, base64, and raw binary are accepted
 	if err := json.Unmarshal([]byte(req.FormValue("parents")), &parents); err != nil {
 		rawParents, err := base64.StdEncoding.DecodeString(req.FormValue("parents"))
 		if err != nil {
 			rawParents = []byte(req.FormValue("parents"))
 		}
 		if err := encoding.Unmarshal(rawParents, &parents); err != nil {
-			WriteError(w, Error{"error decoding parents: " + err.Error()}, http.StatusBadRequest)
-			return
+			return nil, errors.AddContext(err, "error decoding parents")
 		}
 	}
 	if err := json.Unmarshal([]byte(req.FormValue("transaction")), &txn); err != nil {
@@ -126,15 +177,53 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 			rawTransaction = []byte(req.FormValue("transaction"))
 		}
 		if err := encoding.Unmarshal(rawTransaction, &txn); err != nil {
-			WriteError(w, Error{"error decoding transaction: " + err.Error()}, http.StatusBadRequest)
-			return
+			return nil, errors.AddContext(err, "error decoding transaction")
 		}
 	}
+	return append(parents, txn), nil
+}
+
+// tpoolFeeHistogramHandlerGET returns the distribution of the fees paid by the
+// transactions in the transaction pool.
+func tpoolFeeHistogramHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, TpoolFeeHistogramGET{
+		Buckets: tpool.FeeHistogram(),
+	})
+}
+
+// tpoolSimulateHandlerPOST takes a raw encoded transaction set and checks
+// whether the transaction pool would accept it, without adding it to the pool
+// or broadcasting it.
+func tpoolSimulateHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := decodeTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	tsp := TpoolSimulatePOST{
+		Accepted:   true,
+		FeePerByte: modules.CalculateFee(txnSet),
+	}
+	if err := tpool.SimulateAcceptTransactionSet(txnSet); err != nil {
+		tsp.Accepted = false
+		tsp.Error = err.Error()
+	}
+	WriteJSON(w, tsp)
+}
+
+// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
+// it to the transaction pool, relaying it to the transaction pool's peers
+// regardless of if the set is accepted.
+func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := decodeTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 	// Broadcast the transaction set, so that they are passed to any peers that
 	// may have rejected them earlier.
-	txnSet := append(parents, txn)
 	tpool.Broadcast(txnSet)
-	err := tpool.AcceptTransactionSet(txnSet)
+	err = tpool.AcceptTransactionSet(txnSet)
 	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
 		WriteError(w, Error{"error accepting transaction set: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -160,6 +249,24 @@ func tpoolConfirmedGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *
 	})
 }
 
+// tpoolEvictHandlerPOST evicts a transaction and the transactions depending on
+// it from the transaction pool.
+func tpoolEvictHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	txid, err := decodeTransactionID(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error decoding transaction id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	evicted, err := tpool.EvictTransaction(txid)
+	if err != nil {
+		WriteError(w, Error{"error evicting transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, TpoolEvictPOST{
+		Evicted: evicted,
+	})
+}
+
 // tpoolTransactionsHandler returns the current transactions of the transaction
 // pool
 func tpoolTransactionsHandler(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/base64"
+	"fmt"
 	"net/url"
 	"strings"
 	"testing"
@@ -205,6 +206,42 @@ func TestTransactionPoolFee(t *testing.T) {
 	}
 }
 
+// TestTransactionPoolFeeEstimate tests the /tpool/fee/estimate endpoint.
+func TestTransactionPoolFeeEstimate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.panicClose()
+
+	// the recent blocks don't contain any fees, so every target returns the
+	// minimum recommended fee
+	min, _ := st.tpool.FeeEstimation()
+	for target, expected := range map[types.BlockHeight]types.Currency{1: min, 10: min} {
+		var tfeg TpoolFeeEstimateGET
+		err = st.getAPI(fmt.Sprintf("/tpool/fee/estimate?target=%v", target), &tfeg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tfeg.Target != target || !tfeg.FeePerByte.Equals(expected) {
+			t.Fatalf("target %v: expected fee %v, got %v", target, expected, tfeg.FeePerByte)
+		}
+	}
+
+	// a missing or zero target should be rejected
+	var tfeg TpoolFeeEstimateGET
+	if err := st.getAPI("/tpool/fee/estimate", &tfeg); err == nil {
+		t.Fatal("expected an error without a target")
+	}
+	if err := st.getAPI("/tpool/fee/estimate?target=0", &tfeg); err == nil {
+		t.Fatal("expected an error for a target of 0")
+	}
+}
+
 // TestTransactionPoolConfirmed tests the /tpool/confirmed endpoint.
 func TestTransactionPoolConfirmed(t *testing.T) {
 	if testing.Short() {
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiVersionHeader is the response header which contains the version of
+	// the API that served the request.
+	apiVersionHeader = "Sia-API-Version"
+
+	// latestAPIVersion is the latest version of the API. Requests without a
+	// version prefix are served by version 1 to stay compatible with existing
+	// clients.
+	latestAPIVersion = 2
+)
+
+type (
+	// deprecatedRoute is a route which is deprecated in version 1 of the API
+	// and removed from later versions.
+	deprecatedRoute struct {
+		method string
+		// path is the path of the route. A trailing "/*" matches any suffix,
+		// like the catch-all parameters of the router.
+		path string
+
+		// deprecated is the time at which the route was deprecated and sunset
+		// is the time after which it might be removed from version 1 as well.
+		// The sunset is optional.
+		deprecated time.Time
+		sunset     time.Time
+
+		// successor is the route which replaces the deprecated one.
+		successor string
+	}
+)
+
+// deprecatedRoutes are the routes which are deprecated in version 1 of the
+// API and removed from later versions.
+var deprecatedRoutes = []deprecatedRoute{
+	{
+		method:     http.MethodPost,
+		path:       "/renter/backup",
+		deprecated: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		successor:  "/renter/backups/create",
+	},
+	{
+		method:     http.MethodGet,
+		path:       "/renter/downloadasync/*",
+		deprecated: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		successor:  "/renter/download/*siapath?async=true",
+	},
+	{
+		method:     http.MethodGet,
+		path:       "/renter/files",
+		deprecated: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		successor:  "/renter/list/*siapath",
+	},
+	{
+		method:     http.MethodPost,
+		path:       "/renter/recoverbackup",
+		deprecated: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+		successor:  "/renter/backups/restore",
+	},
+}
+
+// matches returns whether the route matches the method and path of a request.
+func (dr deprecatedRoute) matches(method, path string) bool {
+	if method != dr.method {
+		return false
+	}
+	if prefix := strings.TrimSuffix(dr.path, "*"); prefix != dr.path {
+		return strings.HasPrefix(path, prefix) || path == strings.TrimSuffix(prefix, "/")
+	}
+	return path == dr.path
+}
+
+// setHeaders sets the deprecation headers of a response.
+func (dr deprecatedRoute) setHeaders(h http.Header) {
+	h.Set("Deprecation", fmt.Sprintf("@%d", dr.deprecated.Unix()))
+	if !dr.sunset.IsZero() {
+		h.Set("Sunset", dr.sunset.UTC().Format(http.TimeFormat))
+	}
+	h.Add("Link", fmt.Sprintf("<%v>; rel=\"successor-version\"", dr.successor))
+}
+
+// findDeprecatedRoute returns the deprecated route matching the method and
+// path of a request.
+func findDeprecatedRoute(method, path string) (deprecatedRoute, bool) {
+	for _, dr := range deprecatedRoutes {
+		if dr.matches(method, path) {
+			return dr, true
+		}
+	}
+	return deprecatedRoute{}, false
+}
+
+// splitAPIVersion splits the path of a request into the requested version of
+// the API and the path of the route. Paths without a version prefix request
+// version 1. A version of 0 indicates an unsupported version.
+func splitAPIVersion(path string) (int, string) {
+	if !strings.HasPrefix(path, "/v") {
+		return 1, path
+	}
+	end := strings.IndexByte(path[1:], '/') + 1
+	if end == 0 {
+		end = len(path)
+	}
+	version, err := strconv.Atoi(path[2:end])
+	if err != nil || strconv.Itoa(version) != path[2:end] {
+		// Not a version prefix.
+		return 1, path
+	}
+	if version < 1 || version > latestAPIVersion {
+		return 0, path
+	}
+	route := path[end:]
+	if route == "" {
+		route = "/"
+	}
+	return version, route
+}
+
+// handleAPIVersion strips the version prefix from the path of a request, sets
+// the version and deprecation headers of the response and rejects requests
+// for unsupported versions and for routes which were removed from the
+// requested version. It returns the request to serve and whether it should be
+// served.
+func handleAPIVersion(w http.ResponseWriter, req *http.Request) (*http.Request, bool) {
+	version, path := splitAPIVersion(req.URL.Path)
+	if version == 0 {
+		WriteError(w, Error{fmt.Sprintf("unsupported API version, the latest version is v%v", latestAPIVersion)}, http.StatusNotFound)
+		return req, false
+	}
+	w.Header().Set(apiVersionHeader, strconv.Itoa(version))
+
+	if dr, deprecated := findDeprecatedRoute(req.Method, path); deprecated {
+		if version > 1 {
+			WriteError(w, Error{fmt.Sprintf("%v was removed in v%v of the API, use %v instead", path, version, dr.successor)}, http.StatusGone)
+			return req, false
+		}
+		dr.setHeaders(w.Header())
+	}
+	if path == req.URL.Path {
+		return req, true
+	}
+
+	// Serve the request with the path of the route.
+	r := req.Clone(req.Context())
+	r.URL.Path = path
+	r.URL.RawPath = strings.TrimPrefix(r.URL.RawPath, fmt.Sprintf("/v%d", version))
+	return r, true
+}
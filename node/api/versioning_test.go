@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSplitAPIVersion is a unit test for splitAPIVersion.
+func TestSplitAPIVersion(t *testing.T) {
+	tests := []struct {
+		path    string
+		version int
+		route   string
+	}{
+		{"/daemon/version", 1, "/daemon/version"},
+		{"/v1/daemon/version", 1, "/daemon/version"},
+		{"/v2/daemon/version", 2, "/daemon/version"},
+		{"/v2", 2, "/"},
+		{"/v3/daemon/version", 0, "/v3/daemon/version"},
+		{"/v0/daemon/version", 0, "/v0/daemon/version"},
+		{"/v02/daemon/version", 1, "/v02/daemon/version"},
+		{"/vx/daemon/version", 1, "/vx/daemon/version"},
+		{"/v", 1, "/v"},
+	}
+	for _, test := range tests {
+		version, route := splitAPIVersion(test.path)
+		if version != test.version || route != test.route {
+			t.Errorf("%v: expected %v %v but got %v %v", test.path, test.version, test.route, version, route)
+		}
+	}
+}
+
+// TestAPIVersioning probes the handling of versioned and deprecated routes by
+// the API.
+func TestAPIVersioning(t *testing.T) {
+	cfg, err := modules.NewConfig(filepath.Join(build.TempDir("api", t.Name()), modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := New(cfg, "Sia-Agent", "", nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	// serve sends a request to the API.
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("User-Agent", "Sia-Agent")
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, req)
+		return w
+	}
+
+	// Routes are served by all versions.
+	for path, version := range map[string]string{
+		"/daemon/version":    "1",
+		"/v1/daemon/version": "1",
+		"/v2/daemon/version": "2",
+	} {
+		w := serve(http.MethodGet, path)
+		if w.Code != http.StatusOK {
+			t.Fatal("unexpected status code", path, w.Code)
+		}
+		if w.Header().Get(apiVersionHeader) != version {
+			t.Fatal("wrong version", path, w.Header().Get(apiVersionHeader))
+		}
+		if w.Header().Get("Deprecation") != "" {
+			t.Fatal("route shouldn't be deprecated", path)
+		}
+	}
+	// Unsupported versions are rejected.
+	if w := serve(http.MethodGet, "/v3/daemon/version"); w.Code != http.StatusNotFound {
+		t.Fatal("unexpected status code", w.Code)
+	}
+
+	// Deprecated routes are marked in version 1.
+	for _, path := range []string{"/renter/downloadasync/foo/bar", "/v1/renter/downloadasync/foo/bar"} {
+		w := serve(http.MethodGet, path)
+		if w.Header().Get("Deprecation") == "" {
+			t.Fatal("missing deprecation header", path)
+		}
+		if link := w.Header().Get("Link"); link != `</renter/download/*siapath?async=true>; rel="successor-version"` {
+			t.Fatal("wrong link header", path, link)
+		}
+	}
+	// The methods of a deprecated route need to match.
+	if w := serve(http.MethodGet, "/renter/backup"); w.Header().Get("Deprecation") != "" {
+		t.Fatal("GET /renter/backup isn't deprecated")
+	}
+	// Deprecated routes are removed from version 2.
+	w := serve(http.MethodPost, "/v2/renter/backup")
+	if w.Code != http.StatusGone {
+		t.Fatal("unexpected status code", w.Code)
+	}
+}
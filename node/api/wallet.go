@@ -1,17 +1,23 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
 	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/websocket"
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -21,10 +27,12 @@ import (
 type (
 	// WalletGET contains general information about the wallet.
 	WalletGET struct {
-		Encrypted  bool              `json:"encrypted"`
-		Height     types.BlockHeight `json:"height"`
-		Rescanning bool              `json:"rescanning"`
-		Unlocked   bool              `json:"unlocked"`
+		Encrypted              bool              `json:"encrypted"`
+		Height                 types.BlockHeight `json:"height"`
+		Rescanning             bool              `json:"rescanning"`
+		SeedPassphraseRequired bool              `json:"seedpassphraserequired"`
+		Unlocked               bool              `json:"unlocked"`
+		WatchOnly              bool              `json:"watchonly"`
 
 		ConfirmedSiacoinBalance     types.Currency `json:"confirmedsiacoinbalance"`
 		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
@@ -48,12 +56,66 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletFreezePOST contains the set of outputs to freeze or unfreeze.
+	WalletFreezePOST struct {
+		IDs      []types.OutputID `json:"ids"`
+		Unfreeze bool             `json:"unfreeze"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
 		PrimarySeed string `json:"primaryseed"`
 	}
 
+	// WalletAddressLabel is an address together with its label.
+	WalletAddressLabel struct {
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+	}
+
+	// WalletHistoryGET contains the exported history of the wallet.
+	WalletHistoryGET struct {
+		Entries []modules.WalletHistoryEntry `json:"entries"`
+	}
+
+	// WalletLabelsGET contains the labels of all labeled addresses.
+	WalletLabelsGET struct {
+		Labels []WalletAddressLabel `json:"labels"`
+	}
+
+	// WalletLabelGET contains the addresses, balances and transactions of
+	// the addresses with a label.
+	WalletLabelGET struct {
+		Label     string             `json:"label"`
+		Addresses []types.UnlockHash `json:"addresses"`
+
+		ConfirmedSiacoinBalance types.Currency `json:"confirmedsiacoinbalance"`
+		SiafundBalance          types.Currency `json:"siafundbalance"`
+
+		ConfirmedTransactions   []modules.ProcessedTransaction `json:"confirmedtransactions"`
+		UnconfirmedTransactions []modules.ProcessedTransaction `json:"unconfirmedtransactions"`
+	}
+
+	// WalletOfflineBroadcastPOSTResp contains the id of a transaction
+	// broadcast using /wallet/offline/broadcast.
+	WalletOfflineBroadcastPOSTResp struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+
+	// WalletOfflineExportPOSTParams contains the unsigned transaction and the
+	// set of inputs to export for offline signing.
+	WalletOfflineExportPOSTParams struct {
+		Transaction types.Transaction `json:"transaction"`
+		ToSign      []crypto.Hash     `json:"tosign"`
+	}
+
+	// WalletRescanGET contains the progress of the latest rescan started
+	// with /wallet/rescan.
+	WalletRescanGET struct {
+		modules.WalletRescanProgress
+	}
+
 	// WalletSiacoinsPOST contains the transaction sent in the POST call to
 	// /wallet/siacoins.
 	WalletSiacoinsPOST struct {
@@ -88,10 +150,16 @@ type (
 	}
 
 	// WalletSweepPOST contains the coins and funds returned by a call to
-	// /wallet/sweep.
+	// /wallet/sweep together with the plan of the sweep.
 	WalletSweepPOST struct {
 		Coins types.Currency `json:"coins"`
 		Funds types.Currency `json:"funds"`
+
+		Fee            types.Currency        `json:"fee"`
+		RemainingCoins types.Currency        `json:"remainingcoins"`
+		RemainingFunds types.Currency        `json:"remainingfunds"`
+		Outputs        []modules.SweepOutput `json:"outputs"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
 	// WalletTransactionGETid contains the transaction returned by a call to
@@ -125,6 +193,21 @@ type (
 		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
 	}
 
+	// WalletUnsignedTransactionPOSTParams contains the outputs, fee and change
+	// address of a transaction to be built from watched outputs.
+	WalletUnsignedTransactionPOSTParams struct {
+		Outputs       []types.SiacoinOutput `json:"outputs"`
+		Fee           types.Currency        `json:"fee"`
+		ChangeAddress types.UnlockHash      `json:"changeaddress"`
+	}
+
+	// WalletUnsignedTransactionPOSTResp contains an unsigned transaction and
+	// the parent ids of the inputs that need to be signed.
+	WalletUnsignedTransactionPOSTResp struct {
+		Transaction types.Transaction `json:"transaction"`
+		ToSign      []crypto.Hash     `json:"tosign"`
+	}
+
 	// WalletUnspentGET contains the unspent outputs tracked by the wallet.
 	// The MaturityHeight field of each output indicates the height of the
 	// block that the output appeared in.
@@ -148,9 +231,10 @@ type (
 	// WalletWatchPOST contains the set of addresses to add or remove from the
 	// watch set.
 	WalletWatchPOST struct {
-		Addresses []types.UnlockHash `json:"addresses"`
-		Remove    bool               `json:"remove"`
-		Unused    bool               `json:"unused"`
+		Addresses  []types.UnlockHash   `json:"addresses"`
+		PublicKeys []types.SiaPublicKey `json:"publickeys"`
+		Remove     bool                 `json:"remove"`
+		Unused     bool                 `json:"unused"`
 	}
 
 	// WalletWatchGET contains the set of addresses that the wallet is
@@ -158,8 +242,32 @@ type (
 	WalletWatchGET struct {
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
+
+	// WalletWebhooksGET contains the webhooks the wallet POSTs its events to.
+	WalletWebhooksGET struct {
+		Webhooks []modules.WalletWebhook `json:"webhooks"`
+	}
+
+	// walletEventStream is a WalletEventSubscriber which buffers the events of
+	// the wallet for a websocket connection. Events are dropped if the
+	// connection can't keep up.
+	walletEventStream struct {
+		events chan modules.WalletEvent
+	}
 )
 
+// walletEventStreamBuffer is the number of events buffered for a websocket
+// connection to /wallet/events.
+const walletEventStreamBuffer = 100
+
+// ReceiveWalletEvent implements modules.WalletEventSubscriber.
+func (s *walletEventStream) ReceiveWalletEvent(e modules.WalletEvent) {
+	select {
+	case s.events <- e:
+	default:
+	}
+}
+
 // RegisterRoutesWallet is a helper function to register all wallet routes.
 func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requiredPassword string) {
 	router.GET("/wallet", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -180,18 +288,54 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.GET("/wallet/backup", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletBackupHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/events", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletEventsHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/freeze", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletFreezeHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/init", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitHandler(wallet, w, req, ps)
 	}, requiredPassword))
 	router.POST("/wallet/init/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletInitSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/init/watchonly", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletInitWatchOnlyHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/history", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletHistoryHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/labels", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelsHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/labels/:label", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletLabelHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
 	router.POST("/wallet/lock", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletLockHandler(wallet, w, req, ps)
 	}, requiredPassword))
 	router.POST("/wallet/seed", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/offline/broadcast", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletOfflineBroadcastHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/offline/export", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletOfflineExportHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/rescan", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletRescanHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/rescan", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletRescanHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/rescan/cancel", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletRescanCancelHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/seeds", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSeedsHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -240,12 +384,21 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSignHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/unsignedtransaction", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletUnsignedTransactionHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletWatchHandlerGET(wallet, w, req, ps)
 	}, requiredPassword))
 	router.POST("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletWatchHandlerPOST(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/webhooks", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletWebhooksHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/webhooks", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletWebhooksHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
 }
 
 // encryptionKeys enumerates the possible encryption keys that can be derived
@@ -301,11 +454,23 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	watchOnly, err := wallet.WatchOnly()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	passphraseRequired, err := wallet.SeedPassphraseRequired()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, WalletGET{
-		Encrypted:  encrypted,
-		Unlocked:   unlocked,
-		Rescanning: rescanning,
-		Height:     height,
+		Encrypted:              encrypted,
+		Unlocked:               unlocked,
+		Rescanning:             rescanning,
+		Height:                 height,
+		SeedPassphraseRequired: passphraseRequired,
+		WatchOnly:              watchOnly,
 
 		ConfirmedSiacoinBalance:     siacoinBal,
 		UnconfirmedOutgoingSiacoins: siacoinsOut,
@@ -406,6 +571,60 @@ func walletBackupHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 	WriteSuccess(w)
 }
 
+// walletEventsHandler handles websocket connections to /wallet/events. The
+// events of the wallet are sent to the connection as JSON messages until it is
+// closed.
+func walletEventsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	stream := &walletEventStream{
+		events: make(chan modules.WalletEvent, walletEventStreamBuffer),
+	}
+	if err := wallet.SubscribeEvents(stream); err != nil {
+		WriteError(w, Error{"failed to subscribe to wallet events: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer wallet.UnsubscribeEvents(stream)
+
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		// the client doesn't send any messages, reading only detects that the
+		// connection was closed
+		closed := make(chan struct{})
+		go func() {
+			io.Copy(ioutil.Discard, conn)
+			close(closed)
+		}()
+		for {
+			select {
+			case e := <-stream.events:
+				if err := websocket.JSON.Send(conn, e); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	}}.ServeHTTP(w, req)
+}
+
+// walletFreezeHandler handles API calls to /wallet/freeze.
+func walletFreezeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var wfp WalletFreezePOST
+	err := json.NewDecoder(req.Body).Decode(&wfp)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if wfp.Unfreeze {
+		err = wallet.UnfreezeOutputs(wfp.IDs)
+	} else {
+		err = wallet.FreezeOutputs(wfp.IDs)
+	}
+	if err != nil {
+		WriteError(w, Error{"failed to update frozen outputs: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletInitHandler handles API calls to /wallet/init.
 func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.CipherKey
@@ -420,7 +639,7 @@ func walletInitHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 			return
 		}
 	}
-	seed, err := wallet.Encrypt(encryptionKey)
+	seed, err := wallet.EncryptWithPassphrase(encryptionKey, req.FormValue("passphrase"))
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -464,7 +683,7 @@ func walletInitSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 		}
 	}
 
-	err = wallet.InitFromSeed(encryptionKey, seed)
+	err = wallet.InitFromSeedWithPassphrase(encryptionKey, seed, req.FormValue("passphrase"))
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/init/seed: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -472,6 +691,204 @@ func walletInitSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 	WriteSuccess(w)
 }
 
+// walletInitWatchOnlyHandler handles API calls to /wallet/init/watchonly.
+func walletInitWatchOnlyHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if req.FormValue("encryptionpassword") == "" {
+		WriteError(w, Error{"error when calling /wallet/init/watchonly: encryptionpassword is required"}, http.StatusBadRequest)
+		return
+	}
+	encryptionKey := crypto.NewWalletKey(crypto.HashObject(req.FormValue("encryptionpassword")))
+
+	if req.FormValue("force") == "true" {
+		err := wallet.Reset()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/init/watchonly: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := wallet.InitWatchOnly(encryptionKey)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/init/watchonly: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletHistoryHandler handles GET calls to /wallet/history.
+func walletHistoryHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// parse the optional numeric parameters
+	params := map[string]uint64{
+		"start":  0,
+		"end":    math.MaxUint64,
+		"offset": 0,
+		"limit":  0,
+	}
+	for name := range params {
+		str := req.FormValue(name)
+		if str == "" {
+			continue
+		}
+		val, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `" + name + "` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		params[name] = val
+	}
+	format := req.FormValue("format")
+	if format != "" && format != "json" && format != "csv" {
+		WriteError(w, Error{"format must be either 'json' or 'csv'"}, http.StatusBadRequest)
+		return
+	}
+
+	entries, err := wallet.History(types.Timestamp(params["start"]), types.Timestamp(params["end"]), params["offset"], params["limit"], req.FormValue("currency"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if format != "csv" {
+		WriteJSON(w, WalletHistoryGET{Entries: entries})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"transactionid", "confirmationheight", "confirmationtime", "siacoinincoming", "siacoinuntracked", "siacoinoutgoing", "minerfee", "siacoinbalance", "siafundincoming", "siafunduntracked", "siafundoutgoing", "siafundbalance", "fiatcurrency", "fiatprice", "fiatvalue"})
+	for _, e := range entries {
+		var fiatPrice, fiatValue string
+		if e.FiatCurrency != "" {
+			fiatPrice = strconv.FormatFloat(e.FiatPrice, 'f', -1, 64)
+			fiatValue = strconv.FormatFloat(e.FiatValue, 'f', 2, 64)
+		}
+		_ = cw.Write([]string{
+			e.TransactionID.String(),
+			fmt.Sprint(e.ConfirmationHeight),
+			time.Unix(int64(e.ConfirmationTimestamp), 0).UTC().Format(time.RFC3339),
+			e.SiacoinIncoming.String(),
+			e.SiacoinUntracked.String(),
+			e.SiacoinOutgoing.String(),
+			e.MinerFee.String(),
+			e.SiacoinBalance.String(),
+			e.SiafundIncoming.String(),
+			e.SiafundUntracked.String(),
+			e.SiafundOutgoing.String(),
+			e.SiafundBalance.String(),
+			e.FiatCurrency,
+			fiatPrice,
+			fiatValue,
+		})
+	}
+	cw.Flush()
+}
+
+// walletLabelsHandlerGET handles GET calls to /wallet/labels.
+func walletLabelsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	labels, err := wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	wlg := WalletLabelsGET{
+		Labels: make([]WalletAddressLabel, 0, len(labels)),
+	}
+	for addr, label := range labels {
+		wlg.Labels = append(wlg.Labels, WalletAddressLabel{
+			Address: addr,
+			Label:   label,
+		})
+	}
+	sort.Slice(wlg.Labels, func(i, j int) bool {
+		if wlg.Labels[i].Label != wlg.Labels[j].Label {
+			return wlg.Labels[i].Label < wlg.Labels[j].Label
+		}
+		return wlg.Labels[i].Address.String() < wlg.Labels[j].Address.String()
+	})
+	WriteJSON(w, wlg)
+}
+
+// walletLabelsHandlerPOST handles POST calls to /wallet/labels.
+func walletLabelsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"could not read address from POST call to /wallet/labels"}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.SetAddressLabel(addr, req.FormValue("label"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletLabelHandlerGET handles GET calls to /wallet/labels/:label.
+func walletLabelHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	label := ps.ByName("label")
+	labels, err := wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels/:label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	wlg := WalletLabelGET{
+		Label:     label,
+		Addresses: []types.UnlockHash{},
+	}
+	for addr, l := range labels {
+		if l == label {
+			wlg.Addresses = append(wlg.Addresses, addr)
+		}
+	}
+	sort.Slice(wlg.Addresses, func(i, j int) bool {
+		return wlg.Addresses[i].String() < wlg.Addresses[j].String()
+	})
+	wlg.ConfirmedSiacoinBalance, wlg.SiafundBalance, err = wallet.LabelBalance(label)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels/:label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	wlg.ConfirmedTransactions, wlg.UnconfirmedTransactions, err = wallet.LabelTransactions(label)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels/:label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, wlg)
+}
+
+// walletOfflineBroadcastHandler handles API calls to
+// /wallet/offline/broadcast.
+func walletOfflineBroadcastHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var otxn modules.OfflineTransaction
+	err := json.NewDecoder(req.Body).Decode(&otxn)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txid, err := wallet.BroadcastOfflineTransaction(otxn)
+	if err != nil {
+		WriteError(w, Error{"failed to broadcast transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletOfflineBroadcastPOSTResp{
+		TransactionID: txid,
+	})
+}
+
+// walletOfflineExportHandler handles API calls to /wallet/offline/export.
+func walletOfflineExportHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletOfflineExportPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	otxn, err := wallet.ExportOfflineTransaction(params.Transaction, params.ToSign)
+	if err != nil {
+		WriteError(w, Error{"failed to export transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, otxn)
+}
+
 // walletSeedHandler handles API calls to /wallet/seed.
 func walletSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the dictionary + phrase
@@ -538,6 +955,43 @@ func walletLockHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Req
 	WriteSuccess(w)
 }
 
+// walletRescanHandlerGET handles GET calls to /wallet/rescan.
+func walletRescanHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	progress, err := wallet.RescanProgress()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/rescan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletRescanGET{WalletRescanProgress: progress})
+}
+
+// walletRescanHandlerPOST handles POST calls to /wallet/rescan.
+func walletRescanHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var startHeight uint64
+	if str := req.FormValue("startheight"); str != "" {
+		var err error
+		startHeight, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := wallet.Rescan(types.BlockHeight(startHeight)); err != nil {
+		WriteError(w, Error{"error when calling /wallet/rescan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletRescanCancelHandler handles POST calls to /wallet/rescan/cancel.
+func walletRescanCancelHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := wallet.CancelRescan(); err != nil {
+		WriteError(w, Error{"error when calling /wallet/rescan/cancel: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletSeedsHandler handles API calls to /wallet/seeds.
 func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	dictionary := mnemonics.DictionaryID(req.FormValue("dictionary"))
@@ -581,8 +1035,68 @@ func walletSeedsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.
 
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// an optional confirmation target replaces the default fee
+	var target types.BlockHeight
+	if targetStr := req.FormValue("target"); targetStr != "" {
+		t, err := strconv.ParseUint(targetStr, 10, 64)
+		if err != nil || t == 0 {
+			WriteError(w, Error{"could not read target from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+			return
+		}
+		target = types.BlockHeight(t)
+	}
+
 	var txns []types.Transaction
-	if req.FormValue("outputs") != "" {
+	if req.FormValue("inputs") != "" {
+		// explicitly selected inputs
+		if req.FormValue("feeIncluded") != "" {
+			WriteError(w, Error{"cannot supply both 'inputs' and feeIncluded parameter"}, http.StatusBadRequest)
+			return
+		}
+		if target != 0 {
+			WriteError(w, Error{"cannot supply both 'inputs' and target parameter"}, http.StatusBadRequest)
+			return
+		}
+		var inputs []types.SiacoinOutputID
+		err := json.Unmarshal([]byte(req.FormValue("inputs")), &inputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode inputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var outputs []types.SiacoinOutput
+		if req.FormValue("outputs") != "" {
+			err = json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
+			if err != nil {
+				WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		} else {
+			amount, ok := scanAmount(req.FormValue("amount"))
+			if !ok {
+				WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+				return
+			}
+			dest, err := scanAddress(req.FormValue("destination"))
+			if err != nil {
+				WriteError(w, Error{"could not read address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+				return
+			}
+			outputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+		}
+		var changeAddr types.UnlockHash
+		if req.FormValue("changeaddress") != "" {
+			changeAddr, err = scanAddress(req.FormValue("changeaddress"))
+			if err != nil {
+				WriteError(w, Error{"could not read changeaddress from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+				return
+			}
+		}
+		txns, err = wallet.SendSiacoinsFromOutputs(inputs, outputs, changeAddr)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	} else if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
 		if req.FormValue("amount") != "" || req.FormValue("destination") != "" || req.FormValue("feeIncluded") != "" {
 			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair and/or feeIncluded parameter"}, http.StatusInternalServerError)
@@ -595,7 +1109,11 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = wallet.SendSiacoinsMulti(outputs)
+		if target != 0 {
+			txns, err = wallet.SendSiacoinsMultiTarget(outputs, target)
+		} else {
+			txns, err = wallet.SendSiacoinsMulti(outputs)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -618,9 +1136,14 @@ func walletSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *ht
 			return
 		}
 
-		if feeIncluded {
+		switch {
+		case feeIncluded && target != 0:
+			txns, err = wallet.SendSiacoinsFeeIncludedTarget(amount, dest, target)
+		case feeIncluded:
 			txns, err = wallet.SendSiacoinsFeeIncluded(amount, dest)
-		} else {
+		case target != 0:
+			txns, err = wallet.SendSiacoinsTarget(amount, dest, target)
+		default:
 			txns, err = wallet.SendSiacoins(amount, dest)
 		}
 		if err != nil {
@@ -680,14 +1203,43 @@ func walletSweepSeedHandler(wallet modules.Wallet, w http.ResponseWriter, req *h
 		return
 	}
 
-	coins, funds, err := wallet.SweepSeed(seed)
+	// Parse the optional amounts of a partial sweep.
+	var opts modules.SweepOptions
+	if str := req.FormValue("coins"); str != "" {
+		coins, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"could not read coins from POST call to /wallet/sweep/seed"}, http.StatusBadRequest)
+			return
+		}
+		opts.Coins = coins
+	}
+	if str := req.FormValue("funds"); str != "" {
+		funds, ok := scanAmount(str)
+		if !ok {
+			WriteError(w, Error{"could not read funds from POST call to /wallet/sweep/seed"}, http.StatusBadRequest)
+			return
+		}
+		opts.Funds = funds
+	}
+	opts.DryRun, err = scanBool(req.FormValue("dryrun"))
+	if err != nil {
+		WriteError(w, Error{"could not read dryrun from POST call to /wallet/sweep/seed"}, http.StatusBadRequest)
+		return
+	}
+
+	plan, err := wallet.SweepSeedWithOptions(seed, opts)
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/sweep/seed: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
 	WriteJSON(w, WalletSweepPOST{
-		Coins: coins,
-		Funds: funds,
+		Coins:          plan.Coins,
+		Funds:          plan.Funds,
+		Fee:            plan.Fee,
+		RemainingCoins: plan.RemainingCoins,
+		RemainingFunds: plan.RemainingFunds,
+		Outputs:        plan.Outputs,
+		TransactionIDs: plan.TransactionIDs,
 	})
 }
 
@@ -791,7 +1343,7 @@ func walletUnlockHandler(wallet modules.Wallet, w http.ResponseWriter, req *http
 	potentialKeys, _ := encryptionKeys(req.FormValue("encryptionpassword"))
 	var err error
 	for _, key := range potentialKeys {
-		errChan := wallet.UnlockAsync(key)
+		errChan := wallet.UnlockAsyncWithPassphrase(key, req.FormValue("passphrase"))
 		var unlockErr error
 		select {
 		case unlockErr = <-errChan:
@@ -947,8 +1499,17 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	if wwpp.Remove && len(wwpp.PublicKeys) > 0 {
+		WriteError(w, Error{"public keys can't be removed from the watch set, remove their addresses instead"}, http.StatusBadRequest)
+		return
+	}
 	if wwpp.Remove {
 		err = wallet.RemoveWatchAddresses(wwpp.Addresses, wwpp.Unused)
+	} else if len(wwpp.PublicKeys) > 0 {
+		err = wallet.AddWatchPublicKeys(wwpp.PublicKeys, wwpp.Unused)
+		if err == nil && len(wwpp.Addresses) > 0 {
+			err = wallet.AddWatchAddresses(wwpp.Addresses, wwpp.Unused)
+		}
 	} else {
 		err = wallet.AddWatchAddresses(wwpp.Addresses, wwpp.Unused)
 	}
@@ -958,3 +1519,59 @@ func walletWatchHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *h
 	}
 	WriteSuccess(w)
 }
+
+// walletUnsignedTransactionHandler handles API calls to
+// /wallet/unsignedtransaction.
+func walletUnsignedTransactionHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletUnsignedTransactionPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, toSign, err := wallet.BuildUnsignedTransaction(params.Outputs, params.Fee, params.ChangeAddress)
+	if err != nil {
+		WriteError(w, Error{"failed to build transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletUnsignedTransactionPOSTResp{
+		Transaction: txn,
+		ToSign:      toSign,
+	})
+}
+
+// walletWebhooksHandlerGET handles GET calls to /wallet/webhooks.
+func walletWebhooksHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	hooks, err := wallet.Webhooks()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/webhooks: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletWebhooksGET{
+		Webhooks: hooks,
+	})
+}
+
+// walletWebhooksHandlerPOST handles POST calls to /wallet/webhooks.
+func walletWebhooksHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	url := req.FormValue("url")
+	if url == "" {
+		WriteError(w, Error{"url must be provided to /wallet/webhooks"}, http.StatusBadRequest)
+		return
+	}
+	remove, err := scanBool(req.FormValue("remove"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse remove: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if remove {
+		err = wallet.RemoveWebhook(url)
+	} else {
+		err = wallet.AddWebhook(url)
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/webhooks: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -12,6 +13,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/net/websocket"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -459,10 +461,22 @@ func TestIntegrationWalletSweepSeedPOST(t *testing.T) {
 	seed, _, _ := w.PrimarySeed()
 	seedStr, _ := modules.SeedToString(seed, "english")
 
-	// Sweep the coins we sent
-	var wsp WalletSweepPOST
+	// Plan the sweep without broadcasting it
+	var plan WalletSweepPOST
 	qs := url.Values{}
 	qs.Set("seed", seedStr)
+	qs.Set("dryrun", "true")
+	err = st.postAPI("/wallet/sweep/seed", qs, &plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Outputs) == 0 || len(plan.TransactionIDs) != 0 || plan.Fee.IsZero() {
+		t.Fatal("unexpected sweep plan", plan)
+	}
+
+	// Sweep the coins we sent
+	var wsp WalletSweepPOST
+	qs.Del("dryrun")
 	err = st.postAPI("/wallet/sweep/seed", qs, &wsp)
 	if err != nil {
 		t.Fatal(err)
@@ -471,6 +485,9 @@ func TestIntegrationWalletSweepSeedPOST(t *testing.T) {
 	if wsp.Coins.Cmp(types.SiacoinPrecision.Mul64(80)) <= 0 {
 		t.Fatalf("swept fewer coins (%v SC) than expected %v+", wsp.Coins.Div(types.SiacoinPrecision), 80)
 	}
+	if !wsp.Coins.Equals(plan.Coins) || len(wsp.TransactionIDs) == 0 {
+		t.Fatal("sweep doesn't match the plan", wsp, plan)
+	}
 
 	// Add a block so that the sweep transaction is processed
 	_, err = st.miner.AddBlock()
@@ -1810,3 +1827,256 @@ func TestWalletTransactionsGetAddr(t *testing.T) {
 		t.Errorf("There should be exactly 0 unconfirmed and 1 confirmed related txns")
 	}
 }
+
+// TestWalletHistory tests the /wallet/history endpoint.
+func TestWalletHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var whg WalletHistoryGET
+	if err := st.getAPI("/wallet/history", &whg); err != nil {
+		t.Fatal(err)
+	}
+	if len(whg.Entries) == 0 {
+		t.Fatal("expected history entries")
+	}
+
+	// the CSV export should contain a header and the same entries
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/wallet/history?format=csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err := errors.Compose(err, resp.Body.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(whg.Entries)+1 || records[0][0] != "transactionid" {
+		t.Fatalf("expected %v csv records, got %v", len(whg.Entries)+1, len(records))
+	}
+	for i, e := range whg.Entries {
+		if records[i+1][0] != e.TransactionID.String() || records[i+1][7] != e.SiacoinBalance.String() {
+			t.Fatal("csv record doesn't match entry", records[i+1])
+		}
+	}
+
+	// invalid parameters should be rejected
+	if err := st.getAPI("/wallet/history?format=xml", &whg); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	if err := st.getAPI("/wallet/history?currency=usd", &whg); err == nil {
+		t.Fatal("expected an error without a price source")
+	}
+}
+
+// TestWalletRescan tests rescanning the blockchain through the API.
+func TestWalletRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var before WalletGET
+	if err := st.getAPI("/wallet", &before); err != nil {
+		t.Fatal(err)
+	}
+
+	// cancelling without a rescan should fail
+	if err := st.stdPostAPI("/wallet/rescan/cancel", nil); err == nil {
+		t.Fatal("expected an error when no rescan is in progress")
+	}
+	if err := st.stdPostAPI("/wallet/rescan", url.Values{"startheight": {"foo"}}); err == nil {
+		t.Fatal("expected an error for an invalid start height")
+	}
+
+	// rescan from height 2 and wait for it to finish
+	if err := st.stdPostAPI("/wallet/rescan", url.Values{"startheight": {"2"}}); err != nil {
+		t.Fatal(err)
+	}
+	var wrg WalletRescanGET
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if err := st.getAPI("/wallet/rescan", &wrg); err != nil {
+			return err
+		}
+		if wrg.Rescanning {
+			return errors.New("wallet is still rescanning")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrg.Cancelled || wrg.Error != "" || wrg.StartHeight > 2 || wrg.Height != st.cs.Height() {
+		t.Fatal("unexpected rescan progress", wrg)
+	}
+
+	// the balance shouldn't change
+	var after WalletGET
+	if err := st.getAPI("/wallet", &after); err != nil {
+		t.Fatal(err)
+	}
+	if !after.ConfirmedSiacoinBalance.Equals(before.ConfirmedSiacoinBalance) {
+		t.Fatalf("expected balance %v, got %v", before.ConfirmedSiacoinBalance, after.ConfirmedSiacoinBalance)
+	}
+}
+
+// TestWalletWebhooks tests the /wallet/webhooks and /wallet/events endpoints.
+func TestWalletWebhooks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// add, list and remove a webhook
+	hookURL := "http://localhost:1234/events"
+	if err := st.stdPostAPI("/wallet/webhooks", url.Values{"url": {"localhost:1234"}}); err == nil {
+		t.Fatal("expected an error for an invalid url")
+	}
+	if err := st.stdPostAPI("/wallet/webhooks", url.Values{"url": {hookURL}}); err != nil {
+		t.Fatal(err)
+	}
+	var wwg WalletWebhooksGET
+	if err := st.getAPI("/wallet/webhooks", &wwg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wwg.Webhooks) != 1 || wwg.Webhooks[0].URL != hookURL {
+		t.Fatal("unexpected webhooks", wwg.Webhooks)
+	}
+	if err := st.stdPostAPI("/wallet/webhooks", url.Values{"url": {hookURL}, "remove": {"true"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet/webhooks", &wwg); err != nil {
+		t.Fatal(err)
+	}
+	if len(wwg.Webhooks) != 0 {
+		t.Fatal("expected no webhooks", wwg.Webhooks)
+	}
+
+	// subscribe to the events over a websocket
+	addr := st.server.listener.Addr().String()
+	config, err := websocket.NewConfig("ws://"+addr+"/wallet/events", "http://"+addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Header.Set("User-Agent", "Sia-Agent")
+	conn, err := websocket.DialConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// send coins to ourselves and wait for the events
+	var wag WalletAddressGET
+	if err := st.getAPI("/wallet/address", &wag); err != nil {
+		t.Fatal(err)
+	}
+	sendValues := url.Values{
+		"amount":      {types.SiacoinPrecision.String()},
+		"destination": {wag.Address.String()},
+	}
+	var wsp WalletSiacoinsPOST
+	if err := st.postAPI("/wallet/siacoins", sendValues, &wsp); err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	var eventTypes []string
+	for len(eventTypes) < 2 {
+		var e modules.WalletEvent
+		if err := websocket.JSON.Receive(conn, &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.TransactionID == txid {
+			eventTypes = append(eventTypes, e.Type)
+		}
+	}
+	if eventTypes[0] != modules.WalletEventUnconfirmed || eventTypes[1] != modules.WalletEventConfirmed {
+		t.Fatal("unexpected events", eventTypes)
+	}
+}
+
+// TestWalletSeedPassphrase tests initializing and unlocking a wallet with a
+// seed passphrase through the api.
+func TestWalletSeedPassphrase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testdir := build.TempDir("api", t.Name())
+	key := crypto.NewWalletKey(crypto.HashObject("testpass"))
+	st, err := assembleServerTester(key, testdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var wg WalletGET
+	if err := st.getAPI("/wallet", &wg); err != nil {
+		t.Fatal(err)
+	}
+	if wg.SeedPassphraseRequired {
+		t.Fatal("wallet shouldn't require a seed passphrase")
+	}
+
+	// reinitialize the wallet with a seed passphrase
+	if err := st.stdPostAPI("/wallet/lock", nil); err != nil {
+		t.Fatal(err)
+	}
+	initValues := url.Values{}
+	initValues.Set("force", "true")
+	initValues.Set("encryptionpassword", "testpass")
+	initValues.Set("passphrase", "passphrase")
+	if err := st.stdPostAPI("/wallet/init", initValues); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/wallet", &wg); err != nil {
+		t.Fatal(err)
+	}
+	if !wg.SeedPassphraseRequired {
+		t.Fatal("wallet should require a seed passphrase")
+	}
+
+	// unlocking requires the correct passphrase
+	unlockValues := url.Values{}
+	unlockValues.Set("encryptionpassword", "testpass")
+	if err := st.stdPostAPI("/wallet/unlock", unlockValues); err == nil || !strings.Contains(err.Error(), modules.ErrSeedPassphraseRequired.Error()) {
+		t.Fatal("expected ErrSeedPassphraseRequired, got", err)
+	}
+	unlockValues.Set("passphrase", "wrong")
+	if err := st.stdPostAPI("/wallet/unlock", unlockValues); err == nil || !strings.Contains(err.Error(), modules.ErrBadSeedPassphrase.Error()) {
+		t.Fatal("expected ErrBadSeedPassphrase, got", err)
+	}
+	unlockValues.Set("passphrase", "passphrase")
+	if err := st.stdPostAPI("/wallet/unlock", unlockValues); err != nil {
+		t.Fatal(err)
+	}
+	if unlocked, err := st.wallet.Unlocked(); err != nil || !unlocked {
+		t.Fatal("wallet should be unlocked", err)
+	}
+}
@@ -0,0 +1,218 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// ErrModuleLoaded is returned when starting a module that is already
+	// loaded.
+	ErrModuleLoaded = errors.New("module is already loaded")
+	// ErrModuleNotLoaded is returned when stopping a module that is not
+	// loaded.
+	ErrModuleNotLoaded = errors.New("module is not loaded")
+	// ErrUnknownModule is returned for names that don't belong to a module.
+	ErrUnknownModule = errors.New("unknown module")
+
+	// errDependencyNotLoaded is returned when starting a module whose
+	// dependencies are not loaded.
+	errDependencyNotLoaded = errors.New("dependency is not loaded")
+	// errDependentLoaded is returned when stopping a module that other loaded
+	// modules depend on.
+	errDependentLoaded = errors.New("module is required by a loaded module")
+)
+
+var (
+	// requiredDependencies maps the modules to the modules they can't be
+	// created without.
+	requiredDependencies = map[string][]string{
+		"accounting":      {"wallet"},
+		"consensus":       {"gateway"},
+		"explorer":        {"consensus"},
+		"gateway":         {},
+		"host":            {"consensus", "gateway", "transactionpool", "wallet"},
+		"miner":           {"consensus", "transactionpool", "wallet"},
+		"renter":          {"consensus", "gateway", "transactionpool", "wallet"},
+		"transactionpool": {"consensus", "gateway"},
+		"wallet":          {"consensus", "transactionpool"},
+	}
+
+	// optionalDependencies maps the modules to the modules they use if they
+	// are loaded when the module is created.
+	optionalDependencies = map[string][]string{
+		"accounting": {"host", "miner", "renter"},
+	}
+)
+
+// dependencies returns the required and optional dependencies of a module.
+func dependencies(module string) []string {
+	deps := append([]string(nil), requiredDependencies[module]...)
+	return append(deps, optionalDependencies[module]...)
+}
+
+// loaded returns whether a module is loaded. The caller must hold n.mu.
+func (n *Node) loaded(module string) bool {
+	switch module {
+	case "accounting":
+		return n.Accounting != nil
+	case "consensus":
+		return n.ConsensusSet != nil
+	case "explorer":
+		return n.Explorer != nil
+	case "gateway":
+		return n.Gateway != nil
+	case "host":
+		return n.Host != nil
+	case "miner":
+		return n.Miner != nil
+	case "renter":
+		return n.Renter != nil
+	case "transactionpool":
+		return n.TransactionPool != nil
+	case "wallet":
+		return n.Wallet != nil
+	}
+	return false
+}
+
+// LoadedModules returns the names of the loaded modules in alphabetical
+// order.
+func (n *Node) LoadedModules() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var loaded []string
+	for module := range requiredDependencies {
+		if n.loaded(module) {
+			loaded = append(loaded, module)
+		}
+	}
+	sort.Strings(loaded)
+	return loaded
+}
+
+// StartModule creates a module of the running node with the parameters the
+// node was created with. All required dependencies of the module must be
+// loaded. Errors that occur after the synchronous part of the startup are
+// reported as alerts.
+func (n *Node) StartModule(module string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := requiredDependencies[module]; !ok {
+		return errors.AddContext(ErrUnknownModule, module)
+	}
+	if n.loaded(module) {
+		return errors.AddContext(ErrModuleLoaded, module)
+	}
+	for _, dep := range requiredDependencies[module] {
+		if !n.loaded(dep) {
+			return errors.AddContext(errDependencyNotLoaded, dep)
+		}
+	}
+
+	// Create the module with the loaded modules passed in as custom modules.
+	params := n.params
+	params.AllowDegradedStartup = false
+	params.CreateAccounting = module == "accounting"
+	params.CreateConsensusSet = module == "consensus"
+	params.CreateExplorer = module == "explorer"
+	params.CreateGateway = module == "gateway"
+	params.CreateHost = module == "host"
+	params.CreateMiner = module == "miner"
+	params.CreateRenter = module == "renter"
+	params.CreateTransactionPool = module == "transactionpool"
+	params.CreateWallet = module == "wallet"
+	params.Accounting = n.Accounting
+	params.ConsensusSet = n.ConsensusSet
+	params.Explorer = n.Explorer
+	params.Gateway = n.Gateway
+	params.Host = n.Host
+	params.Miner = n.Miner
+	params.Renter = n.Renter
+	params.TransactionPool = n.TransactionPool
+	params.Wallet = n.Wallet
+	params.SiaMux = n.Mux
+	created, errChan := New(params, time.Now())
+	if err := modules.PeekErr(errChan); err != nil {
+		return errors.AddContext(err, "unable to start "+module)
+	}
+	n.Accounting = created.Accounting
+	n.ConsensusSet = created.ConsensusSet
+	n.Explorer = created.Explorer
+	n.Gateway = created.Gateway
+	n.Host = created.Host
+	n.Miner = created.Miner
+	n.Renter = created.Renter
+	n.TransactionPool = created.TransactionPool
+	n.Wallet = created.Wallet
+	delete(n.FailedModules, module)
+	delete(n.failedAt, module)
+
+	go func() {
+		if err := <-errChan; err != nil {
+			n.mu.Lock()
+			n.FailedModules[module] = err
+			n.failedAt[module] = time.Now()
+			n.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// StopModule closes a module of the running node. Modules that are used by
+// other loaded modules can't be stopped.
+func (n *Node) StopModule(module string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := requiredDependencies[module]; !ok {
+		return errors.AddContext(ErrUnknownModule, module)
+	}
+	if !n.loaded(module) {
+		return errors.AddContext(ErrModuleNotLoaded, module)
+	}
+	var dependents []string
+	for m := range requiredDependencies {
+		if !n.loaded(m) {
+			continue
+		}
+		for _, dep := range dependencies(m) {
+			if dep == module {
+				dependents = append(dependents, m)
+			}
+		}
+	}
+	if len(dependents) > 0 {
+		sort.Strings(dependents)
+		return errors.AddContext(errDependentLoaded, fmt.Sprint(dependents))
+	}
+
+	var err error
+	switch module {
+	case "accounting":
+		err, n.Accounting = n.Accounting.Close(), nil
+	case "consensus":
+		err, n.ConsensusSet = n.ConsensusSet.Close(), nil
+	case "explorer":
+		err, n.Explorer = n.Explorer.Close(), nil
+	case "gateway":
+		err, n.Gateway = n.Gateway.Close(), nil
+	case "host":
+		err, n.Host = n.Host.Close(), nil
+	case "miner":
+		err, n.Miner = n.Miner.Close(), nil
+	case "renter":
+		err, n.Renter = n.Renter.Close(), nil
+	case "transactionpool":
+		err, n.TransactionPool = n.TransactionPool.Close(), nil
+	case "wallet":
+		err, n.Wallet = n.Wallet.Close(), nil
+	}
+	delete(n.FailedModules, module)
+	delete(n.failedAt, module)
+	return errors.AddContext(err, "unable to stop "+module)
+}
@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 
 	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
@@ -99,18 +101,78 @@ type NodeParams struct {
 	// Dependencies for storage monitor supporting dependency injection.
 	StorageManagerDeps modules.Dependencies
 
-	// Custom settings for siamux
+	// Custom settings for siamux. If SiaMux is provided, it is used instead of
+	// creating a new one.
+	SiaMux           *siamux.SiaMux
 	SiaMuxTCPAddress string
 	SiaMuxWSAddress  string
 
+	// AllowDegradedStartup starts the node without the modules that fail to
+	// load, and the modules that depend on them, instead of returning an
+	// error. The failures are reported as critical alerts.
+	AllowDegradedStartup bool
+
 	// Custom settings for modules
-	Allowance      modules.Allowance
-	Bootstrap      bool
-	UseUPNP        bool
-	HostAddress    string
-	HostStorage    uint64
-	RPCAddress     string
-	WalletPassword string
+	Allowance         modules.Allowance
+	Bootstrap         bool
+	ConsensusDatabase string
+	ConsensusSnapshot string
+	ExplorerIndex     bool
+	UseUPNP           bool
+	HostAddress       string
+	HostStorage       uint64
+	RPCAddress        string
+	WalletPassword    string
+	WalletSigner      string
+	WalletPriceSource string
+
+	// The trusted checksum of the header of ConsensusSnapshot. The snapshot
+	// is only imported if it matches.
+	ConsensusSnapshotChecksum crypto.Hash
+
+	// The config file of siad and the effective values of siad's flags. The
+	// dynamic settings of the config file are applied once the modules are
+	// loaded.
+	ConfigFile  string
+	ConfigFlags map[string]string
+
+	// The address the gRPC API listens on. The gRPC API is only served if it
+	// is set.
+	GRPCAddress string
+
+	// TLS settings of the API server. If a certificate and key file are
+	// provided, the API is served over TLS and the certificate is reloaded
+	// when the files change. If ACME domains are provided instead,
+	// certificates for them are obtained and renewed automatically.
+	APITLSCertFile    string
+	APITLSKeyFile     string
+	APITLSACMEDomains []string
+	APITLSACMEEmail   string
+
+	// CORS policy of the API server. No cross-origin requests are allowed if
+	// no origins are provided.
+	APICORSOrigins []string
+	APICORSMethods []string
+	APICORSHeaders []string
+
+	// Audit log settings of the API server. If enabled, the calls which
+	// change the state of the node are recorded and kept for the retention
+	// period. A retention period of 0 keeps them forever.
+	APIAuditLog       bool
+	APIAuditRetention time.Duration
+
+	// Rate limits of the API server. APIRateLimit limits the requests per
+	// second of each client and APIEndpointRateLimits adds limits for the
+	// endpoints with a certain prefix in the form "prefix=rate[:burst]". The
+	// maximum number of uploads, downloads and wallet rescans which are served
+	// concurrently is capped by the APIMax fields. Zero values disable the
+	// limits.
+	APIRateLimit          float64
+	APIRateBurst          int
+	APIEndpointRateLimits []string
+	APIMaxUploads         int
+	APIMaxDownloads       int
+	APIMaxRescans         int
 
 	// Initialize node from existing seed.
 	PrimarySeed string
@@ -143,9 +205,57 @@ type Node struct {
 	TransactionPool modules.TransactionPool
 	Wallet          modules.Wallet
 
+	// FailedModules contains the errors of the modules that failed to load
+	// during a degraded startup by module name.
+	FailedModules map[string]error
+	failedAt      map[string]time.Time
+
 	// The high level directory where all the persistence gets stored for the
 	// modules.
 	Dir string
+
+	// params are the parameters the node was created with. They are used to
+	// start modules at runtime.
+	params NodeParams
+	mu     sync.Mutex
+}
+
+// errDependencyFailed is returned for modules that were not loaded during a
+// degraded startup because one of their dependencies failed to load.
+var errDependencyFailed = errors.New("dependency failed to load")
+
+// Alerts implements modules.Alerter. Every module that failed to load is
+// reported as a critical alert.
+func (n *Node) Alerts() (crit, err, warn, info []modules.Alert) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	names := make([]string, 0, len(n.FailedModules))
+	for name := range n.FailedModules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		crit = append(crit, modules.Alert{
+			ID:       modules.AlertIDModuleLoadFailed(name),
+			Time:     n.failedAt[name],
+			Cause:    n.FailedModules[name].Error(),
+			Module:   name,
+			Msg:      "module failed to load and is disabled until siad is restarted",
+			Severity: modules.SeverityCritical,
+		})
+	}
+	return
+}
+
+// checkDependencies returns an error if one of the dependencies of a module
+// failed to load.
+func checkDependencies(failed map[string]error, module string) error {
+	for _, dep := range dependencies(module) {
+		if _, ok := failed[dep]; ok {
+			return errors.AddContext(errDependencyFailed, dep)
+		}
+	}
+	return nil
 }
 
 // NumModules returns how many of the major modules the given NodeParams would
@@ -172,7 +282,7 @@ func (np NodeParams) NumModules() (n int) {
 	if np.CreateMiner || np.Miner != nil {
 		n++
 	}
-	if !np.CreateExplorer || np.Explorer != nil {
+	if np.CreateExplorer || np.Explorer != nil {
 		n++
 	}
 	if np.CreateAccounting || np.Accounting != nil {
@@ -181,6 +291,32 @@ func (np NodeParams) NumModules() (n int) {
 	return
 }
 
+// importConsensusSnapshot initializes the consensus database with the snapshot
+// at path, which has to match the trusted checksum. Nothing is imported if the
+// database already exists.
+func importConsensusSnapshot(path string, checksum crypto.Hash, persistDir, dbBackend string) (err error) {
+	if checksum == (crypto.Hash{}) {
+		return errors.New("a trusted snapshot checksum is required to import a consensus snapshot")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	height, err := consensus.ImportSnapshot(f, checksum, persistDir, dbBackend)
+	if errors.Contains(err, consensus.ErrSnapshotDatabaseExists) {
+		printlnRelease("Consensus database already exists, skipping snapshot import.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	printfRelease("Imported consensus snapshot at height %v.\n", height)
+	return nil
+}
+
 // printlnRelease is a wrapper that only prints to stdout in release builds.
 func printlnRelease(a ...interface{}) {
 	if build.Release == "standard" || build.Release == "testnet" {
@@ -236,7 +372,10 @@ func (n *Node) Close() (err error) {
 	}
 	if n.Mux != nil {
 		printlnRelease("Closing siamux...")
-		err = errors.Compose(err, n.Mux.Close(), n.muxLog.Close())
+		err = errors.Compose(err, n.Mux.Close())
+	}
+	if n.muxLog != nil {
+		err = errors.Compose(err, n.muxLog.Close())
 	}
 	return err
 }
@@ -279,10 +418,35 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 	}
 
 	// Create the siamux.
-	mux, muxLog, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
-	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create siamux"))
-		return nil, errChan
+	mux := params.SiaMux
+	var muxLog *os.File
+	if mux == nil {
+		mux, muxLog, err = modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
+		if err != nil {
+			errChan <- errors.Extend(err, errors.New("unable to create siamux"))
+			return nil, errChan
+		}
+	}
+
+	// During a degraded startup, modules that fail to load are recorded and
+	// skipped instead of aborting the startup.
+	failed := make(map[string]error)
+	failedAt := make(map[string]time.Time)
+	skipFailed := func(module string, err error) bool {
+		if !params.AllowDegradedStartup {
+			return false
+		}
+		printfRelease("  Unable to load %v, continuing without it: %v\n", module, err)
+		failed[module] = err
+		failedAt[module] = time.Now()
+		return true
+	}
+	// closedErrChan replaces the error channels of modules that failed to
+	// load.
+	closedErrChan := func() <-chan error {
+		c := make(chan error)
+		close(c)
+		return c
 	}
 
 	// Load all modules
@@ -312,8 +476,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return gateway.NewCustomGateway(params.RPCAddress, params.Bootstrap, params.UseUPNP, filepath.Join(dir, modules.GatewayDir), gatewayDeps)
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create gateway"))
-		return nil, errChan
+		if !skipFailed("gateway", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create gateway"))
+			return nil, errChan
+		}
+		g = nil
 	}
 
 	// Consensus.
@@ -330,22 +497,41 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateConsensusSet {
 			return nil, c
 		}
+		if err := checkDependencies(failed, "consensus"); err != nil {
+			c <- err
+			return nil, c
+		}
 		i++
 		printfRelease("(%d/%d) Loading consensus...\n", i, numModules)
 		consensusSetDeps := params.ConsensusSetDeps
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
-		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		consensusDatabase := params.ConsensusDatabase
+		if consensusDatabase == "" {
+			consensusDatabase = consensus.DatabaseBolt
+		}
+		consensusDir := filepath.Join(dir, modules.ConsensusDir)
+		if params.ConsensusSnapshot != "" {
+			err := importConsensusSnapshot(params.ConsensusSnapshot, params.ConsensusSnapshotChecksum, consensusDir, consensusDatabase)
+			if err != nil {
+				c <- errors.AddContext(err, "unable to import consensus snapshot")
+				return nil, c
+			}
+		}
+		return consensus.NewCustomConsensusSetWithDatabase(g, params.Bootstrap, consensusDir, consensusDatabase, consensusSetDeps)
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create consensus set"))
-		return nil, errChan
+		if !skipFailed("consensus", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create consensus set"))
+			return nil, errChan
+		}
+		cs, errChanCS = nil, closedErrChan()
 	}
 
 	// Explorer.
 	e, err := func() (modules.Explorer, error) {
-		if !params.CreateExplorer && params.Explorer != nil {
+		if params.CreateExplorer && params.Explorer != nil {
 			return nil, errors.New("cannot create explorer and also use custom explorer")
 		}
 		if params.Explorer != nil {
@@ -354,7 +540,10 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateExplorer {
 			return nil, nil
 		}
-		e, err := explorer.New(cs, filepath.Join(dir, modules.ExplorerDir))
+		if err := checkDependencies(failed, "explorer"); err != nil {
+			return nil, err
+		}
+		e, err := explorer.NewCustomExplorer(cs, filepath.Join(dir, modules.ExplorerDir), params.ExplorerIndex)
 		if err != nil {
 			return nil, err
 		}
@@ -363,8 +552,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return e, nil
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create explorer"))
-		return nil, errChan
+		if !skipFailed("explorer", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create explorer"))
+			return nil, errChan
+		}
+		e = nil
 	}
 
 	// Transaction Pool.
@@ -378,6 +570,9 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateTransactionPool {
 			return nil, nil
 		}
+		if err := checkDependencies(failed, "transactionpool"); err != nil {
+			return nil, err
+		}
 		tpoolDeps := params.TPoolDeps
 		if tpoolDeps == nil {
 			tpoolDeps = modules.ProdDependencies
@@ -387,8 +582,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return transactionpool.NewCustomTPool(cs, g, filepath.Join(dir, modules.TransactionPoolDir), tpoolDeps)
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create transaction pool"))
-		return nil, errChan
+		if !skipFailed("transactionpool", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create transaction pool"))
+			return nil, errChan
+		}
+		tp = nil
 	}
 
 	// Wallet.
@@ -402,16 +600,35 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateWallet {
 			return nil, nil
 		}
+		if err := checkDependencies(failed, "wallet"); err != nil {
+			return nil, err
+		}
 		walletDeps := params.WalletDeps
 		if walletDeps == nil {
 			walletDeps = modules.ProdDependencies
 		}
 		i++
 		printfRelease("(%d/%d) Loading wallet...\n", i, numModules)
+		var signer modules.TransactionSigner
+		if params.WalletSigner != "" {
+			signer = wallet.NewSocketSigner(params.WalletSigner)
+		}
+		var priceSource modules.PriceSource
+		if params.WalletPriceSource != "" {
+			priceSource = wallet.NewHTTPPriceSource(params.WalletPriceSource)
+		}
 		wallet, err := wallet.NewCustomWallet(cs, tp, filepath.Join(dir, modules.WalletDir), walletDeps)
 		if err != nil {
 			return nil, err
 		}
+		// delegate signing of watched addresses to an external signer
+		if signer != nil {
+			wallet.SetTransactionSigner(signer)
+		}
+		// value exported wallet history using an external price source
+		if priceSource != nil {
+			wallet.SetPriceSource(priceSource)
+		}
 		// automatically unlock the wallet if the password is provided
 		if len(params.WalletPassword) != 0 {
 			printfRelease("  Wallet password found, attempting to unlock wallet...\n")
@@ -425,8 +642,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return wallet, nil
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create wallet"))
-		return nil, errChan
+		if !skipFailed("wallet", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create wallet"))
+			return nil, errChan
+		}
+		w = nil
 	}
 
 	// Miner.
@@ -440,6 +660,9 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateMiner {
 			return nil, nil
 		}
+		if err := checkDependencies(failed, "miner"); err != nil {
+			return nil, err
+		}
 		i++
 		printfRelease("(%d/%d) Loading miner...\n", i, numModules)
 		m, err := miner.New(cs, tp, w, filepath.Join(dir, modules.MinerDir))
@@ -449,8 +672,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return m, nil
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create miner"))
-		return nil, errChan
+		if !skipFailed("miner", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create miner"))
+			return nil, errChan
+		}
+		m = nil
 	}
 
 	// Host.
@@ -464,6 +690,9 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateHost {
 			return nil, nil
 		}
+		if err := checkDependencies(failed, "host"); err != nil {
+			return nil, err
+		}
 		if params.HostAddress == "" {
 			params.HostAddress = "localhost:0"
 		}
@@ -481,8 +710,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return host, err
 	}()
 	if err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create host"))
-		return nil, errChan
+		if !skipFailed("host", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create host"))
+			return nil, errChan
+		}
+		h = nil
 	}
 
 	// Renter.
@@ -501,6 +733,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 			close(c)
 			return nil, c
 		}
+		if err := checkDependencies(failed, "renter"); err != nil {
+			c <- err
+			close(c)
+			return nil, c
+		}
 		contractorDeps := params.ContractorDeps
 		if contractorDeps == nil {
 			contractorDeps = modules.ProdDependencies
@@ -563,8 +800,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return renter, c
 	}()
 	if err := modules.PeekErr(errChanRenter); err != nil {
-		errChan <- errors.Extend(err, errors.New("unable to create renter"))
-		return nil, errChan
+		if !skipFailed("renter", err) {
+			errChan <- errors.Extend(err, errors.New("unable to create renter"))
+			return nil, errChan
+		}
+		r, errChanRenter = nil, closedErrChan()
 	}
 
 	// Accounting.
@@ -578,6 +818,9 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if !params.CreateAccounting {
 			return nil, nil
 		}
+		if err := checkDependencies(failed, "accounting"); err != nil {
+			return nil, err
+		}
 		accoutingDeps := params.AccountingDeps
 		if accoutingDeps == nil {
 			accoutingDeps = modules.ProdDependencies
@@ -595,8 +838,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return acc, nil
 	}()
 	if err != nil {
-		errChan <- errors.AddContext(err, "unable to create accounting module")
-		return nil, errChan
+		if !skipFailed("accounting", err) {
+			errChan <- errors.AddContext(err, "unable to create accounting module")
+			return nil, errChan
+		}
+		acc = nil
 	}
 
 	// Setup complete
@@ -620,6 +866,11 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		TransactionPool: tp,
 		Wallet:          w,
 
+		FailedModules: failed,
+		failedAt:      failedAt,
+
 		Dir: dir,
+
+		params: params,
 	}, errChan
 }
@@ -0,0 +1,151 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/explorer"
+)
+
+// TestNewDegraded verifies that a node started with AllowDegradedStartup
+// loads the healthy modules and reports the modules that failed to load.
+func TestNewDegraded(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// corruptWallet returns a node directory with a corrupt wallet database.
+	corruptWallet := func(name string) string {
+		dir := build.TempDir("node", t.Name(), name)
+		walletDir := filepath.Join(dir, modules.WalletDir)
+		if err := os.MkdirAll(walletDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		err := ioutil.WriteFile(filepath.Join(walletDir, modules.WalletDir+".db"), []byte("corrupt"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	// Without degraded startup the node should fail to start.
+	_, errChan := New(Miner(corruptWallet("strict")), time.Now())
+	if err := <-errChan; err == nil {
+		t.Fatal("expected startup to fail")
+	}
+
+	// With degraded startup the wallet and the miner depending on it should
+	// be skipped.
+	params := Miner(corruptWallet("degraded"))
+	params.AllowDegradedStartup = true
+	n, errChan := New(params, time.Now())
+	if err := modules.PeekErr(errChan); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if n.Gateway == nil || n.ConsensusSet == nil || n.TransactionPool == nil {
+		t.Fatal("healthy modules should be loaded")
+	}
+	if n.Wallet != nil || n.Miner != nil {
+		t.Fatal("failed modules should be nil")
+	}
+	if len(n.FailedModules) != 2 {
+		t.Fatal("expected 2 failed modules, got", len(n.FailedModules))
+	}
+	if err := n.FailedModules["miner"]; !errors.Contains(err, errDependencyFailed) {
+		t.Fatal("miner should have failed because of its dependency:", err)
+	}
+
+	// The failures should be reported as critical alerts.
+	crit, _, _, _ := n.Alerts()
+	if len(crit) != 2 {
+		t.Fatal("expected 2 critical alerts, got", len(crit))
+	}
+	if crit[0].Module != "miner" || crit[1].Module != "wallet" {
+		t.Fatal("unexpected alerts", crit)
+	}
+	if crit[1].Cause != n.FailedModules["wallet"].Error() {
+		t.Fatal("alert should contain the error of the module", crit[1].Cause)
+	}
+}
+
+// TestNumModules verifies that NumModules only counts the explorer if it is
+// created or provided.
+func TestNumModules(t *testing.T) {
+	if n := (NodeParams{}).NumModules(); n != 0 {
+		t.Fatal("expected 0 modules, got", n)
+	}
+	if n := (NodeParams{CreateExplorer: true}).NumModules(); n != 1 {
+		t.Fatal("expected 1 module, got", n)
+	}
+	if n := (NodeParams{Explorer: &explorer.Explorer{}}).NumModules(); n != 1 {
+		t.Fatal("expected 1 module, got", n)
+	}
+	if n := Miner("").NumModules(); n != 5 {
+		t.Fatal("expected 5 modules, got", n)
+	}
+}
+
+// TestNewCustomExplorer verifies that a custom explorer can be provided
+// without creating one but not while also creating one.
+func TestNewCustomExplorer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	custom := &explorer.Explorer{}
+
+	// Creating and providing an explorer should fail.
+	params := NodeParams{
+		CreateExplorer: true,
+		Explorer:       custom,
+		Dir:            build.TempDir("node", t.Name(), "both"),
+	}
+	_, errChan := New(params, time.Now())
+	if err := <-errChan; err == nil {
+		t.Fatal("expected creating and providing an explorer to fail")
+	}
+
+	// Only providing an explorer should use it.
+	params = NodeParams{
+		Explorer: custom,
+		Dir:      build.TempDir("node", t.Name(), "custom"),
+	}
+	n, errChan := New(params, time.Now())
+	if err := modules.PeekErr(errChan); err != nil {
+		t.Fatal(err)
+	}
+	if n.Explorer != custom {
+		t.Fatal("expected the custom explorer to be used")
+	}
+	// The custom explorer wasn't initialized, so it can't be closed.
+	n.Explorer = nil
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCheckDependencies verifies that checkDependencies reports failed
+// required and optional dependencies of a module.
+func TestCheckDependencies(t *testing.T) {
+	failed := map[string]error{"renter": errors.New("failed")}
+	if err := checkDependencies(failed, "explorer"); err != nil {
+		t.Fatal("explorer doesn't depend on the renter:", err)
+	}
+	if err := checkDependencies(failed, "accounting"); !errors.Contains(err, errDependencyFailed) {
+		t.Fatal("accounting should fail because of the renter:", err)
+	}
+	failed = map[string]error{"consensus": errors.New("failed")}
+	if err := checkDependencies(failed, "explorer"); !errors.Contains(err, errDependencyFailed) {
+		t.Fatal("explorer should fail because of the consensus set:", err)
+	}
+}
@@ -3,6 +3,7 @@ package persist
 import (
 	"io"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/log"
 	"go.sia.tech/siad/build"
 )
@@ -33,12 +34,18 @@ func printCommitHash(logger *log.Logger) {
 }
 
 // NewFileLogger returns a logger that logs to logFilename. The file is opened
-// in append mode, and created if it does not exist.
+// in append mode, and created if it does not exist. The entries are attributed
+// to the module named after the file, and are filtered, formatted and rotated
+// according to the log configuration.
 func NewFileLogger(logFilename string) (*Logger, error) {
-	logger, err := log.NewFileLogger(logFilename, options)
+	lf, err := newLogFile(logFilename)
 	if err != nil {
 		return nil, err
 	}
+	logger, err := log.NewLogger(lf, options)
+	if err != nil {
+		return nil, errors.Compose(err, lf.Close())
+	}
 	printCommitHash(logger)
 	return &Logger{logger}, nil
 }
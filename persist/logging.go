@@ -0,0 +1,378 @@
+package persist
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+)
+
+// LogLevel is the severity of a log entry. Entries below the level of their
+// module are discarded.
+type LogLevel int
+
+const (
+	// LogLevelDebug is the level of debug entries, which are only written
+	// by debug builds.
+	LogLevelDebug LogLevel = iota
+	// LogLevelInfo is the level of entries without a severity prefix.
+	LogLevelInfo
+	// LogLevelWarn is the level of entries prefixed with WARN.
+	LogLevelWarn
+	// LogLevelError is the level of entries prefixed with ERROR, SEVERE or
+	// CRITICAL.
+	LogLevelError
+)
+
+// logTimeLayout is the layout of the timestamp that the loggers prefix each
+// entry with.
+const logTimeLayout = "2006/01/02 15:04:05.000000"
+
+var (
+	// logRecentEntries is the number of recent entries that are kept in
+	// memory for each module.
+	logRecentEntries = build.Select(build.Var{
+		Standard: 1000,
+		Testnet:  1000,
+		Dev:      1000,
+		Testing:  10,
+	}).(int)
+
+	// ErrUnknownLogLevel is returned when parsing an unknown log level.
+	ErrUnknownLogLevel = errors.New("unknown log level")
+)
+
+type (
+	// LogConfig configures the files written by the loggers returned by
+	// NewFileLogger.
+	LogConfig struct {
+		// JSON writes each entry as a JSON object instead of plain text.
+		JSON bool
+
+		// MaxSize is the size in bytes at which a log file is rotated, and
+		// MaxAge the age. Zero disables the respective rotation.
+		MaxSize int64
+		MaxAge  time.Duration
+
+		// MaxBackups is the number of rotated files kept next to each log
+		// file. Older files are deleted.
+		MaxBackups int
+	}
+
+	// LogEntry is a single entry written by a logger.
+	LogEntry struct {
+		Time    time.Time `json:"time"`
+		Level   LogLevel  `json:"level"`
+		Module  string    `json:"module"`
+		File    string    `json:"file,omitempty"`
+		Message string    `json:"message"`
+	}
+
+	// logRegistry holds the log configuration, the levels of the modules and
+	// their recent entries. It is shared by all loggers of the process.
+	logRegistry struct {
+		config       LogConfig
+		defaultLevel LogLevel
+		levels       map[string]LogLevel
+		recent       map[string][]LogEntry
+		mu           sync.RWMutex
+	}
+
+	// logFile is the writer of a file logger. It parses the lines written by
+	// the logger, filters them by the level of the module and rotates the
+	// file.
+	logFile struct {
+		closed bool
+		f      *os.File
+		opened time.Time
+		size   int64
+
+		staticModule string
+		staticPath   string
+		mu           sync.Mutex
+	}
+)
+
+// logs is the log registry of the process.
+var logs = &logRegistry{
+	defaultLevel: LogLevelInfo,
+	levels:       make(map[string]LogLevel),
+	recent:       make(map[string][]LogEntry),
+}
+
+// String implements fmt.Stringer.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "debug"
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarn:
+		return "warn"
+	case LogLevelError:
+		return "error"
+	default:
+		return fmt.Sprintf("LogLevel(%d)", int(l))
+	}
+}
+
+// ParseLogLevel parses the string representation of a log level.
+func ParseLogLevel(s string) (LogLevel, error) {
+	for l := LogLevelDebug; l <= LogLevelError; l++ {
+		if strings.EqualFold(s, l.String()) {
+			return l, nil
+		}
+	}
+	return 0, errors.AddContext(ErrUnknownLogLevel, s)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (l LogLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (l *LogLevel) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	level, err := ParseLogLevel(s)
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// SetLogConfig sets the configuration of the log files. It only applies to
+// loggers created afterwards.
+func SetLogConfig(config LogConfig) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	logs.config = config
+}
+
+// SetLogLevel sets the level of a module. An empty module sets the level of
+// all modules without a level of their own.
+func SetLogLevel(module string, level LogLevel) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	if module == "" {
+		logs.defaultLevel = level
+		return
+	}
+	logs.levels[module] = level
+}
+
+// LogLevels returns the default level and the effective level of every module
+// that has logged or has a level set.
+func LogLevels() (LogLevel, map[string]LogLevel) {
+	logs.mu.RLock()
+	defer logs.mu.RUnlock()
+	levels := make(map[string]LogLevel)
+	for module := range logs.recent {
+		levels[module] = logs.defaultLevel
+	}
+	for module, level := range logs.levels {
+		levels[module] = level
+	}
+	return logs.defaultLevel, levels
+}
+
+// RecentLogEntries returns up to n of the most recent entries of at least the
+// provided level, oldest first. An empty module returns the entries of all
+// modules.
+func RecentLogEntries(module string, level LogLevel, n int) []LogEntry {
+	logs.mu.RLock()
+	var entries []LogEntry
+	for m, recent := range logs.recent {
+		if module != "" && m != module {
+			continue
+		}
+		for _, e := range recent {
+			if e.Level >= level {
+				entries = append(entries, e)
+			}
+		}
+	}
+	logs.mu.RUnlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// level returns the level of a module.
+func (lr *logRegistry) level(module string) LogLevel {
+	lr.mu.RLock()
+	defer lr.mu.RUnlock()
+	if level, ok := lr.levels[module]; ok {
+		return level
+	}
+	return lr.defaultLevel
+}
+
+// record adds an entry to the recent entries of its module.
+func (lr *logRegistry) record(e LogEntry) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	recent := append(lr.recent[e.Module], e)
+	if len(recent) > logRecentEntries {
+		recent = recent[len(recent)-logRecentEntries:]
+	}
+	lr.recent[e.Module] = recent
+}
+
+// parseLogEntry parses a line written by a logger. Lines that don't start with
+// a timestamp and file are kept in full as the message.
+func parseLogEntry(module string, line []byte) LogEntry {
+	e := LogEntry{
+		Time:    time.Now().UTC(),
+		Module:  module,
+		Message: strings.TrimSuffix(string(line), "\n"),
+	}
+	if len(e.Message) > len(logTimeLayout) {
+		if t, err := time.Parse(logTimeLayout, e.Message[:len(logTimeLayout)]); err == nil {
+			e.Time = t
+			e.Message = strings.TrimPrefix(e.Message[len(logTimeLayout):], " ")
+			if i := strings.Index(e.Message, ": "); i > 0 && !strings.Contains(e.Message[:i], " ") {
+				e.File = e.Message[:i]
+				e.Message = e.Message[i+2:]
+			}
+		}
+	}
+	e.Level = parseLogLevelPrefix(e.Message)
+	return e
+}
+
+// parseLogLevelPrefix returns the level of a message based on its prefix.
+func parseLogLevelPrefix(msg string) LogLevel {
+	switch {
+	case strings.HasPrefix(msg, "[DEBUG]"):
+		return LogLevelDebug
+	case strings.HasPrefix(msg, "WARN"):
+		return LogLevelWarn
+	case strings.HasPrefix(msg, "ERROR"), strings.HasPrefix(msg, "SEVERE"), strings.HasPrefix(msg, "CRITICAL"):
+		return LogLevelError
+	default:
+		return LogLevelInfo
+	}
+}
+
+// newLogFile opens the log file of a module in append mode, creating it if it
+// does not exist.
+func newLogFile(path string) (*logFile, error) {
+	lf := &logFile{
+		staticModule: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		staticPath:   path,
+	}
+	if err := lf.open(); err != nil {
+		return nil, err
+	}
+	return lf, nil
+}
+
+// open opens the file of the logFile.
+func (lf *logFile) open() error {
+	f, err := os.OpenFile(lf.staticPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Compose(err, f.Close())
+	}
+	lf.f = f
+	lf.size = fi.Size()
+	lf.opened = time.Now()
+	return nil
+}
+
+// rotate renames the file to the first backup, shifting the existing backups
+// and deleting the oldest, and opens a new file.
+func (lf *logFile) rotate(maxBackups int) error {
+	if err := lf.f.Close(); err != nil {
+		return err
+	}
+	backup := func(i int) string { return fmt.Sprintf("%s.%d", lf.staticPath, i) }
+	if maxBackups > 0 {
+		for i := maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(backup(i), backup(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(lf.staticPath, backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(lf.staticPath); err != nil {
+		return err
+	}
+	return lf.open()
+}
+
+// Write implements io.Writer. The logger calls Write once per entry.
+func (lf *logFile) Write(b []byte) (int, error) {
+	e := parseLogEntry(lf.staticModule, b)
+	if e.Level < logs.level(lf.staticModule) {
+		return len(b), nil
+	}
+	logs.record(e)
+
+	logs.mu.RLock()
+	config := logs.config
+	logs.mu.RUnlock()
+	out := b
+	if config.JSON {
+		js, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		out = append(js, '\n')
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	// Sanity check - the logger should not be used after it was closed.
+	if lf.closed {
+		options.Critical("cannot write to the log file after it has been closed")
+		return 0, os.ErrClosed
+	}
+	tooLarge := config.MaxSize > 0 && lf.size > 0 && lf.size+int64(len(out)) > config.MaxSize
+	tooOld := config.MaxAge > 0 && time.Since(lf.opened) > config.MaxAge
+	if tooLarge || tooOld {
+		if err := lf.rotate(config.MaxBackups); err != nil {
+			return 0, errors.AddContext(err, "unable to rotate log file")
+		}
+	}
+	n, err := lf.f.Write(out)
+	lf.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Close syncs and closes the file.
+func (lf *logFile) Close() error {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	// Sanity check - close should not have been called yet.
+	if lf.closed {
+		options.Critical("cannot close the log file; already closed")
+		return nil
+	}
+	lf.closed = true
+	return errors.Compose(lf.f.Sync(), lf.f.Close())
+}
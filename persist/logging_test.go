@@ -0,0 +1,139 @@
+package persist
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestParseLogEntry probes parseLogEntry.
+func TestParseLogEntry(t *testing.T) {
+	e := parseLogEntry("renter", []byte("2021/03/04 05:06:07.000008 upload.go:12: WARN: upload failed\n"))
+	if e.Module != "renter" || e.File != "upload.go:12" || e.Message != "WARN: upload failed" || e.Level != LogLevelWarn {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+	if e.Time.Year() != 2021 || e.Time.Nanosecond() != 8000 {
+		t.Fatal("unexpected time", e.Time)
+	}
+
+	// Lines without a timestamp are kept in full.
+	e = parseLogEntry("renter", []byte("ERROR: something: happened\n"))
+	if e.File != "" || e.Message != "ERROR: something: happened" || e.Level != LogLevelError {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	for _, s := range []string{"debug", "INFO", "Warn", "error"} {
+		if _, err := ParseLogLevel(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := ParseLogLevel("verbose"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestFileLoggerLevels checks that entries below the level of a module are
+// neither written nor recorded, and that entries are written as JSON.
+func TestFileLoggerLevels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir(persistDir, t.Name())
+	if err := os.MkdirAll(dir, defaultDirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	SetLogConfig(LogConfig{JSON: true})
+	defer SetLogConfig(LogConfig{})
+
+	module := strings.ToLower(t.Name())
+	SetLogLevel(module, LogLevelWarn)
+	path := filepath.Join(dir, module+".log")
+	logger, err := NewFileLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Println("INFO: dropped")
+	logger.Println("WARN: kept")
+	logger.Println("ERROR: kept too")
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []LogEntry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e LogEntry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 2 || entries[0].Message != "WARN: kept" || entries[1].Level != LogLevelError {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	recent := RecentLogEntries(module, LogLevelError, 10)
+	if len(recent) != 1 || recent[0].Message != "ERROR: kept too" {
+		t.Fatalf("unexpected recent entries %+v", recent)
+	}
+	if _, levels := LogLevels(); levels[module] != LogLevelWarn {
+		t.Fatal("unexpected level", levels[module])
+	}
+}
+
+// TestFileLoggerRotation checks that log files are rotated once they exceed
+// the maximum size and that only the configured number of backups is kept.
+func TestFileLoggerRotation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir(persistDir, t.Name())
+	if err := os.MkdirAll(dir, defaultDirPermissions); err != nil {
+		t.Fatal(err)
+	}
+	SetLogConfig(LogConfig{MaxSize: 200, MaxBackups: 2})
+	defer SetLogConfig(LogConfig{})
+
+	path := filepath.Join(dir, strings.ToLower(t.Name())+".log")
+	logger, err := NewFileLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		logger.Printf("entry %v", i)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 3 {
+		t.Fatalf("expected the log file and 2 backups, got %v files", len(fis))
+	}
+	for _, fi := range fis {
+		if fi.Size() > 200 {
+			t.Fatalf("%v exceeds the maximum size: %v bytes", fi.Name(), fi.Size())
+		}
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "SHUTDOWN") {
+		t.Fatal("last entry is not in the current log file")
+	}
+}
@@ -1,7 +1,19 @@
 package daemon
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -9,11 +21,17 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/fastrand"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api/client"
+	"go.sia.tech/siad/node/api/grpcapi"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/siatest"
 )
@@ -314,6 +332,142 @@ func TestDaemonStack(t *testing.T) {
 	}
 }
 
+// TestDaemonConfigFile tests that the config file is applied at startup and
+// can be reloaded through the API.
+func TestDaemonConfigFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(testDir, modules.ConfigFileName)
+	writeConfig := func(s string) {
+		if err := ioutil.WriteFile(configFile, []byte(s), persist.DefaultDiskPermissionsTest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeConfig("ratelimit:\n  maxdownloadspeed: 1000\n  maxuploadspeed: 2000\n")
+
+	// Create a new server with the config file.
+	params := node.Gateway(filepath.Join(testDir, "node"))
+	params.ConfigFile = configFile
+	params.ConfigFlags = map[string]string{"modules": "g"}
+	testNode, err := siatest.NewCleanNode(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.DaemonGlobalRateLimitPost(0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The ratelimit should have been applied at startup.
+	dcg, err := testNode.DaemonConfigGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dcg.Path != configFile || dcg.Config.Daemon["modules"] != "g" {
+		t.Fatalf("unexpected config %+v", dcg)
+	}
+	if *dcg.Config.Ratelimit.MaxDownloadSpeed != 1000 || *dcg.Config.Ratelimit.MaxUploadSpeed != 2000 {
+		t.Fatal("ratelimit wasn't applied")
+	}
+
+	// Change the config file and reload it.
+	writeConfig("daemon:\n  modules: gct\nratelimit:\n  maxdownloadspeed: 3000\n")
+	dcrp, err := testNode.DaemonConfigReloadPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dcrp.RestartRequired) != 1 || dcrp.RestartRequired[0] != "modules" {
+		t.Fatal("expected modules to require a restart", dcrp.RestartRequired)
+	}
+	dsg, err := testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsg.MaxDownloadSpeed != 3000 || dsg.MaxUploadSpeed != 2000 {
+		t.Fatal("ratelimit wasn't reloaded", dsg.MaxDownloadSpeed, dsg.MaxUploadSpeed)
+	}
+
+	// An invalid config file should be rejected without changing anything.
+	writeConfig("ratelimit:\n  maxdownloadspeed: -1\n")
+	if _, err := testNode.DaemonConfigReloadPost(); err == nil || !strings.Contains(err.Error(), modules.ErrInvalidConfigFile.Error()) {
+		t.Fatal("expected invalid config file error, got", err)
+	}
+	dsg, err = testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dsg.MaxDownloadSpeed != 3000 {
+		t.Fatal("invalid config file was applied")
+	}
+}
+
+// TestDaemonLogs tests the /daemon/logs endpoints.
+func TestDaemonLogs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The gateway should have logged its startup.
+	dlg, err := testNode.DaemonLogsGet(modules.GatewayDir, persist.LogLevelInfo, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dlg.Entries) == 0 {
+		t.Fatal("no log entries")
+	}
+	for _, e := range dlg.Entries {
+		if e.Module != modules.GatewayDir {
+			t.Fatal("entry of wrong module", e.Module)
+		}
+	}
+
+	// Raise the level of the gateway.
+	err = testNode.DaemonLogLevelsPost(modules.GatewayDir, persist.LogLevelError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.DaemonLogLevelsPost(modules.GatewayDir, persist.LogLevelInfo)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	dllg, err := testNode.DaemonLogLevelsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dllg.Levels[modules.GatewayDir] != persist.LogLevelError {
+		t.Fatal("unexpected gateway level", dllg.Levels[modules.GatewayDir])
+	}
+	if dllg.DefaultLevel != persist.LogLevelInfo {
+		t.Fatal("unexpected default level", dllg.DefaultLevel)
+	}
+}
+
 // TestDaemonProfile test the /dameon/profile endpoint.
 func TestDaemonProfile(t *testing.T) {
 	if testing.Short() {
@@ -368,3 +522,446 @@ func TestDaemonProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDaemonModules tests starting and stopping modules at runtime.
+func TestDaemonModules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server without a host.
+	testNode, err := siatest.NewCleanNode(node.Wallet(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if _, err := testNode.HostGet(); err == nil {
+		t.Fatal("host shouldn't be loaded")
+	}
+
+	// Unknown and loaded modules can't be started.
+	if err := testNode.DaemonModulesStartPost("foo"); err == nil || !strings.Contains(err.Error(), node.ErrUnknownModule.Error()) {
+		t.Fatal("expected unknown module error, got", err)
+	}
+	if err := testNode.DaemonModulesStartPost("wallet"); err == nil || !strings.Contains(err.Error(), node.ErrModuleLoaded.Error()) {
+		t.Fatal("expected module loaded error, got", err)
+	}
+
+	// Start the host.
+	if err := testNode.DaemonModulesStartPost("host"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNode.HostGet(); err != nil {
+		t.Fatal(err)
+	}
+	dsg, err := testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dsg.Modules.Host {
+		t.Fatal("host should be reported as loaded")
+	}
+
+	// The wallet can't be stopped while the host depends on it.
+	if err := testNode.DaemonModulesStopPost("wallet"); err == nil {
+		t.Fatal("wallet shouldn't be stoppable while the host is loaded")
+	}
+
+	// Stop the host and start it again.
+	if err := testNode.DaemonModulesStopPost("host"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNode.HostGet(); err == nil {
+		t.Fatal("host shouldn't be loaded")
+	}
+	if err := testNode.DaemonModulesStopPost("host"); err == nil || !strings.Contains(err.Error(), node.ErrModuleNotLoaded.Error()) {
+		t.Fatal("expected module not loaded error, got", err)
+	}
+	if err := testNode.DaemonModulesStartPost("host"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testNode.HostGet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDaemonAlertsAcknowledge tests acknowledging and silencing alerts.
+func TestDaemonAlertsAcknowledge(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server whose explorer fails to load without a consensus
+	// set.
+	params := node.Gateway(testDir)
+	params.CreateExplorer = true
+	params.AllowDegradedStartup = true
+	testNode, err := siatest.NewCleanNode(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = testNode.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The failure should be reported with a stable id.
+	id := modules.AlertIDModuleLoadFailed("explorer")
+	dag, err := testNode.DaemonAlertsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.CriticalAlerts) != 1 || dag.CriticalAlerts[0].ID != id || dag.CriticalAlerts[0].Time.IsZero() {
+		t.Fatal("unexpected alerts", dag.CriticalAlerts)
+	}
+
+	// Unknown alerts can't be acknowledged.
+	if err := testNode.DaemonAlertsAcknowledgePost("foo"); err == nil {
+		t.Fatal("shouldn't be able to acknowledge unknown alert")
+	}
+
+	// Acknowledge the alert.
+	if err := testNode.DaemonAlertsAcknowledgePost(id); err != nil {
+		t.Fatal(err)
+	}
+	dag, err = testNode.DaemonAlertsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.CriticalAlerts) != 1 || !dag.CriticalAlerts[0].Acknowledged {
+		t.Fatal("alert should be acknowledged", dag.CriticalAlerts)
+	}
+	dag, err = testNode.DaemonAlertsUnacknowledgedGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.CriticalAlerts) != 0 {
+		t.Fatal("acknowledged alert shouldn't be returned", dag.CriticalAlerts)
+	}
+
+	// Silence an alert that isn't registered yet.
+	if err := testNode.DaemonAlertsSilencePost("foo", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	dag, err = testNode.DaemonAlertsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if until, ok := dag.Silences["foo"]; !ok || until.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatal("alert should be silenced", dag.Silences)
+	}
+	if err := testNode.DaemonAlertsSilencePost("foo", 0); err != nil {
+		t.Fatal(err)
+	}
+	dag, err = testNode.DaemonAlertsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dag.Silences) != 0 {
+		t.Fatal("silence should have been removed", dag.Silences)
+	}
+}
+
+// TestDaemonAPITLS makes sure that the API can be served over TLS and that the
+// certificate is reloaded once it is replaced.
+func TestDaemonAPITLS(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+	certFile := filepath.Join(testDir, "cert.pem")
+	keyFile := filepath.Join(testDir, "key.pem")
+
+	// A certificate without a key should be rejected.
+	pool, err := writeTestCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := node.Gateway(filepath.Join(testDir, "nokey"))
+	params.APITLSCertFile = certFile
+	if _, err := siatest.NewCleanNode(params); err == nil {
+		t.Fatal("expected node without TLS key to fail")
+	}
+
+	// Create a new server which serves the API over TLS.
+	params = node.Gateway(filepath.Join(testDir, "node"))
+	params.APITLSCertFile = certFile
+	params.APITLSKeyFile = keyFile
+	testNode, err := siatest.NewCleanNode(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A plain HTTP request should fail.
+	if _, err := testNode.DaemonVersionGet(); err == nil {
+		t.Fatal("expected plain HTTP request to fail")
+	}
+	// A request over TLS should succeed.
+	c := testNode.Client
+	c.TLSConfig = &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	if _, err := c.DaemonVersionGet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the certificate. The server should serve the new one without a
+	// restart.
+	pool, err = writeTestCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.TLSConfig = &tls.Config{RootCAs: pool, ServerName: "localhost"}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := c.DaemonVersionGet()
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestCertificate writes a new self-signed certificate for localhost and
+// its key to the provided files. It returns a pool which contains the
+// certificate.
+func writeTestCertificate(certFile, keyFile string) (*x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(int64(fastrand.Intn(1 << 30))),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(keyFile, keyPEM, persist.DefaultDiskPermissionsTest); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(certFile, certPEM, persist.DefaultDiskPermissionsTest); err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	return pool, nil
+}
+
+// TestDaemonGRPC probes the gRPC API.
+func TestDaemonGRPC(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup with a renter which serves the gRPC API.
+	groupParams := siatest.GroupParams{
+		Hosts:  2,
+		Miners: 1,
+	}
+	testDir := daemonTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.GRPCAddress = "localhost:0"
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nodes[0]
+	addr := r.Server.GRPCAddress()
+
+	// Calls without the password should fail.
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = grpcapi.NewDaemonServiceClient(conn).Version(context.Background(), &grpcapi.VersionRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatal("expected unauthenticated call to fail", err)
+	}
+
+	// Connect with the password.
+	conn, err = grpc.Dial(addr, grpc.WithInsecure(), grpc.WithPerRPCCredentials(grpcapi.NewPasswordCredentials(r.Password, false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	daemon := grpcapi.NewDaemonServiceClient(conn)
+	consensus := grpcapi.NewConsensusServiceClient(conn)
+	wallet := grpcapi.NewWalletServiceClient(conn)
+	renter := grpcapi.NewRenterServiceClient(conn)
+	host := grpcapi.NewHostServiceClient(conn)
+
+	// Check the version.
+	version, err := daemon.Version(ctx, &grpcapi.VersionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version.Version != build.NodeVersion {
+		t.Fatal("wrong version", version.Version)
+	}
+
+	// Subscribe to blocks and mine one.
+	cg, err := r.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := consensus.Consensus(ctx, &grpcapi.ConsensusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Height != uint64(cg.Height) || c.CurrentBlock != cg.CurrentBlock.String() {
+		t.Fatal("wrong consensus", c, cg)
+	}
+	blocks, err := consensus.SubscribeBlocks(ctx, &grpcapi.SubscribeBlocksRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := blocks.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Miners()[0].MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	update, err := blocks.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Height != c.Height+1 || len(update.AppliedBlocks) != 1 {
+		t.Fatal("unexpected block update", update)
+	}
+
+	// Check the wallet.
+	balance, err := wallet.Balance(ctx, &grpcapi.BalanceRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.ConfirmedSiacoins == "0" {
+		t.Fatal("wallet should have a balance")
+	}
+	address, err := wallet.Address(ctx, &grpcapi.AddressRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wallet.SendSiacoins(ctx, &grpcapi.SendSiacoinsRequest{Amount: "1000", Destination: address.Address}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload a file and wait for the upload to finish.
+	contracts, err := renter.Contracts(ctx, &grpcapi.ContractsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contracts.Contracts) != len(tg.Hosts()) {
+		t.Fatal("wrong number of contracts", len(contracts.Contracts))
+	}
+	lf, err := r.FilesDir().NewFile(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload, err := renter.Upload(ctx, &grpcapi.UploadRequest{
+		Source:       lf.Path(),
+		SiaPath:      "grpc/file",
+		DataPieces:   1,
+		ParityPieces: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress *grpcapi.FileProgress
+	for {
+		p, err := upload.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		progress = p
+	}
+	if progress.File.SiaPath != "grpc/file" || progress.File.UploadProgress < 100 {
+		t.Fatal("upload didn't finish", progress.File)
+	}
+	files, err := renter.Files(ctx, &grpcapi.FilesRequest{SiaPath: "grpc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files.Files) != 1 || files.Files[0].Size != 100 {
+		t.Fatal("unexpected files", files.Files)
+	}
+
+	// Download the file.
+	destination := filepath.Join(r.FilesDir().Path(), "download")
+	download, err := renter.Download(ctx, &grpcapi.DownloadRequest{
+		SiaPath:     "grpc/file",
+		Destination: destination,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var completed bool
+	for {
+		p, err := download.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		completed = p.Completed
+	}
+	if !completed {
+		t.Fatal("download didn't complete")
+	}
+	data, err := ioutil.ReadFile(destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Equal(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// The renter has no host.
+	_, err = host.Host(ctx, &grpcapi.HostRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatal("expected host call to fail", err)
+	}
+}
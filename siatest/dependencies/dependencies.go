@@ -192,6 +192,12 @@ type (
 		modules.ProductionDependencies
 	}
 
+	// DependencyDisableDynamicPricingLoop prevents the host from periodically
+	// re-evaluating its prices in the background.
+	DependencyDisableDynamicPricingLoop struct {
+		modules.ProductionDependencies
+	}
+
 	// DependencyDefaultRenewSettings causes the contractor to use default
 	// settings when renewing a contract.
 	DependencyDefaultRenewSettings struct {
@@ -488,6 +494,11 @@ func (d *DependencyDisableRotateFingerprintBuckets) Disrupt(s string) bool {
 	return s == "DisableRotateFingerprintBuckets"
 }
 
+// Disrupt returns true if the correct string is provided.
+func (d *DependencyDisableDynamicPricingLoop) Disrupt(s string) bool {
+	return s == "DisableDynamicPricingLoop"
+}
+
 // Disrupt returns true if the correct string is provided.
 func (d *DependencyTimeoutOnHostGET) Disrupt(s string) bool {
 	return s == "TimeoutOnHostGET"
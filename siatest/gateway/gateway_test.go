@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"errors"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -346,3 +347,175 @@ func TestGatewayBandwidth(t *testing.T) {
 		t.Fatal("Expected Gateway 2 to have bandwidth usage after connecting with a peer")
 	}
 }
+
+// TestGatewayPeerPolicies probes the endpoints for persistent peers, bans and
+// peer metrics.
+func TestGatewayPeerPolicies(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create two gateways.
+	testDir := gatewayTestDir(t.Name())
+	g1, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "g1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "g2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	gg2, err := g2.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := gg2.NetAddress
+
+	// Add g2 as a persistent peer of g1.
+	if err := g1.GatewayAddPersistentPeerPost(addr); err != nil {
+		t.Fatal(err)
+	}
+	gppg, err := g1.GatewayPersistentPeersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gppg.PersistentPeers) != 1 || gppg.PersistentPeers[0] != addr {
+		t.Fatal("unexpected persistent peers", gppg.PersistentPeers)
+	}
+
+	// g1 should report the metrics of g2.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		gpg, err := g1.GatewayPeersGet()
+		if err != nil {
+			return err
+		}
+		for _, p := range gpg.Peers {
+			if p.NetAddress == addr && p.Persistent && !p.ConnectedSince.IsZero() {
+				return nil
+			}
+		}
+		return errors.New("persistent peer not connected")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Banning the subnet of g2 should disconnect it.
+	if err := g1.GatewayBanPost("127.0.0.0/8", time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	gbg, err := g1.GatewayBansGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gbg.Bans) != 1 || gbg.Bans[0].Subnet != "127.0.0.0/8" || gbg.Bans[0].Reason != "test" {
+		t.Fatal("unexpected bans", gbg.Bans)
+	}
+	gpg, err := g1.GatewayPeersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range gpg.Peers {
+		if p.NetAddress == addr {
+			t.Fatal("banned peer is still connected")
+		}
+	}
+
+	// Invalid requests should be rejected.
+	if err := g1.GatewayBanPost("foo", 0, ""); err == nil {
+		t.Fatal("expected invalid subnet to be rejected")
+	}
+	if err := g1.GatewayUnbanPost("1.2.3.4"); err == nil {
+		t.Fatal("expected unbanning a subnet that isn't banned to fail")
+	}
+
+	// Unban the subnet and remove the persistent peer.
+	if err := g1.GatewayUnbanPost("127.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.GatewayRemovePersistentPeerPost(addr); err != nil {
+		t.Fatal(err)
+	}
+	gbg, err = g1.GatewayBansGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gppg, err = g1.GatewayPersistentPeersGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gbg.Bans) != 0 || len(gppg.PersistentPeers) != 0 {
+		t.Fatal("ban and persistent peer should have been removed", gbg.Bans, gppg.PersistentPeers)
+	}
+}
+
+// TestGatewayReachability tests the reachability self-test of the gateway.
+func TestGatewayReachability(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create two gateways.
+	testDir := gatewayTestDir(t.Name())
+	g1, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "g1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g1.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g2, err := siatest.NewCleanNode(node.Gateway(filepath.Join(testDir, "g2")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g2.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without peers the check should fail.
+	if _, err := g1.GatewayReachabilityPost(""); err == nil {
+		t.Fatal("expected reachability check without peers to fail")
+	}
+	if _, err := g1.GatewayReachabilityPost("foo"); err == nil {
+		t.Fatal("expected invalid port to be rejected")
+	}
+
+	// Connect the gateways and check again.
+	gg2, err := g2.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.GatewayConnectPost(gg2.NetAddress); err != nil {
+		t.Fatal(err)
+	}
+	grp, err := g1.GatewayReachabilityPost("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !grp.Reachable {
+		t.Fatal("gateway should be reachable")
+	}
+	gg1, err := g1.GatewayGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := gg1.Connectivity; !c.Reachable || c.ReachabilityError != "" || c.LastReachabilityCheck.IsZero() {
+		t.Fatal("unexpected connectivity", c)
+	}
+}
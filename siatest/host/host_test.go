@@ -698,3 +698,139 @@ func TestHostGetPriceTable(t *testing.T) {
 		t.Fatal("wrong subscription notification cost")
 	}
 }
+
+// TestHostAnnounceChecked tests the checked announcement of the host.
+func TestHostAnnounceChecked(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	groupParams := siatest.GroupParams{
+		Hosts:  1,
+		Miners: 1,
+	}
+	testDir := hostTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Peers dial back the IP the gateway connects from, so the gateway needs
+	// to use the same IP as the host.
+	hostParams := node.Host(filepath.Join(testDir, "host"))
+	hostParams.RPCAddress = "127.0.0.1:0"
+	hostParams.HostAddress = "127.0.0.1:0"
+	nodes, err := tg.AddNodes(hostParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := nodes[0]
+	hg, err := h.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A dry run shouldn't change the announced address of the host.
+	hacp, err := h.HostAnnounceCheckedPost(hg.ExternalSettings.NetAddress, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hacp.DryRun || !hacp.Reachable || hacp.Fee.IsZero() {
+		t.Fatal("unexpected report", hacp)
+	}
+	if hacp.NetAddress != hg.ExternalSettings.NetAddress {
+		t.Fatalf("expected address %v but got %v", hg.ExternalSettings.NetAddress, hacp.NetAddress)
+	}
+	hg2, err := h.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hg2.InternalSettings.NetAddress != hg.InternalSettings.NetAddress {
+		t.Fatal("dry run changed the address of the host")
+	}
+
+	// An invalid address should be rejected.
+	if _, err := h.HostAnnounceCheckedPost("foo", true); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+
+	// Announce the host for real.
+	hacp, err = h.HostAnnounceCheckedPost(hg.ExternalSettings.NetAddress, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hacp.DryRun {
+		t.Fatal("announcement shouldn't be a dry run")
+	}
+	hg2, err = h.HostGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hg2.InternalSettings.NetAddress != hg.ExternalSettings.NetAddress {
+		t.Fatalf("expected address %v but got %v", hg.ExternalSettings.NetAddress, hg2.InternalSettings.NetAddress)
+	}
+}
+
+// TestHostSectorAccess tests that the host tracks the reads of its sectors.
+func TestHostSectorAccess(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	testDir := hostTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group:", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file and download it without fetching it from disk.
+	r := tg.Renters()[0]
+	_, rf, err := r.UploadNewFileBlocking(100, 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := r.DownloadToDiskWithDiskFetch(rf, false, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// At least one of the hosts should have served a read.
+	var reads uint64
+	for _, h := range tg.Hosts() {
+		ssag, err := h.HostStorageSectorsAccessGet(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ssag.SampleRate != 1 || ssag.StartTime.IsZero() || len(ssag.HotSectors) > 1 {
+			t.Fatal("unexpected stats", ssag)
+		}
+		if ssag.TotalReads > 0 && (ssag.TrackedSectors != 1 || len(ssag.HotSectors) != 1 || ssag.HotSectors[0].Reads != ssag.TotalReads) {
+			t.Fatal("expected the read sector to be tracked", ssag)
+		}
+		reads += ssag.TotalReads
+	}
+	if reads == 0 {
+		t.Fatal("expected the hosts to track the download")
+	}
+
+	// A negative limit should be rejected.
+	if _, err := tg.Hosts()[0].HostStorageSectorsAccessGet(-1); err == nil {
+		t.Fatal("expected negative limit to be rejected")
+	}
+}
@@ -3,7 +3,9 @@ package miner
 import (
 	"testing"
 
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/types"
 
 	"go.sia.tech/siad/node"
 )
@@ -37,3 +39,67 @@ func TestMinerEmptyBlock(t *testing.T) {
 		t.Fatalf("new blockheight should be %v but was %v", bh+1, newBH)
 	}
 }
+
+// TestMinerTemplate tests changing the block template settings of the miner
+// using the API.
+func TestMinerTemplate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create a miner for testing.
+	m, err := siatest.NewNode(node.AllModules(minerTestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings should be rejected.
+	settings := modules.MinerTemplateSettings{
+		Payouts: []modules.MinerPayoutSplit{{UnlockHash: types.UnlockHash{1}}},
+	}
+	if err := m.MinerTemplatePost(settings); err == nil {
+		t.Fatal("expected payout without weight to be rejected")
+	}
+
+	// Split the payout between two addresses.
+	settings.Payouts = []modules.MinerPayoutSplit{
+		{UnlockHash: types.UnlockHash{1}, Weight: 1},
+		{UnlockHash: types.UnlockHash{2}, Weight: 1},
+	}
+	if err := m.MinerTemplatePost(settings); err != nil {
+		t.Fatal(err)
+	}
+	mtg, err := m.MinerTemplateGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mtg.Settings.Payouts) != 2 || mtg.Settings.Payouts[1] != settings.Payouts[1] {
+		t.Fatal("unexpected settings", mtg.Settings)
+	}
+
+	// Mined blocks should pay both addresses.
+	if err := m.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	bh, err := m.BlockHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cbg, err := m.ConsensusBlocksHeightGet(bh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cbg.MinerPayouts) != 2 {
+		t.Fatal("unexpected number of payouts", len(cbg.MinerPayouts))
+	}
+	for i, payout := range cbg.MinerPayouts {
+		if payout.UnlockHash != settings.Payouts[i].UnlockHash {
+			t.Fatal("payout has the wrong address", payout.UnlockHash)
+		}
+	}
+}
@@ -73,6 +73,11 @@ func TestCreateLoadBackup(t *testing.T) {
 	if _, err := os.Stat(dirMDPath); os.IsExist(err) {
 		t.Fatal(".siadir file does exist:", dirMDPath)
 	}
+	// Tag the file.
+	fileTags := map[string]string{"foo": "bar"}
+	if err := r.RenterSetFileTagsPost(rf.SiaPath(), false, fileTags); err != nil {
+		t.Fatal(err)
+	}
 	// Create a backup.
 	backupPath := filepath.Join(r.FilesDir().Path(), "test.backup")
 	err = r.RenterCreateLocalBackupPost(backupPath)
@@ -140,6 +145,14 @@ func TestCreateLoadBackup(t *testing.T) {
 	if _, err := os.Stat(dirMDPath + "_1"); os.IsExist(err) {
 		t.Fatal(".siadir_1 file does exist:", err)
 	}
+	// The tags should be recovered too.
+	rfg, err := r.RenterFileGet(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rfg.File.Tags, fileTags) {
+		t.Fatal("file tags weren't recovered", rfg.File.Tags)
+	}
 	// The file should be available and ready for download again.
 	if _, _, err := r.DownloadByStream(rf); err != nil {
 		t.Fatal(err)
@@ -192,6 +205,91 @@ func TestCreateLoadBackup(t *testing.T) {
 	}
 }
 
+// TestLoadBackupDirMetadata tests that loading a backup recovers the metadata
+// of the directories in the user's home.
+func TestLoadBackupDirMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file to a subdir and tag the subdir.
+	r := tg.Renters()[0]
+	subDir, err := r.FilesDir().CreateDir("subDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf, err := subDir.NewFile(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UploadBlocking(lf, 1, 1, false); err != nil {
+		t.Fatal(err)
+	}
+	subDirSiaPath, err := modules.NewSiaPath("subDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirTags := map[string]string{modules.RepairPriorityTag: modules.RepairPriorityHigh}
+	if err := r.RenterDirSetTagsPost(subDirSiaPath, dirTags); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a backup and load it into a new renter using the same seed.
+	backupPath := filepath.Join(r.FilesDir().Path(), "test.backup")
+	if err := r.RenterCreateLocalBackupPost(backupPath); err != nil {
+		t.Fatal(err)
+	}
+	wsg, err := r.WalletSeedsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.RemoveNode(r); err != nil {
+		t.Fatal(err)
+	}
+	rt := node.RenterTemplate
+	rt.PrimarySeed = wsg.PrimarySeed
+	nodes, err := tg.AddNodes(rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = nodes[0]
+	if err := r.RenterRecoverLocalBackupPost(backupPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// The metadata of the subdir should be recovered in the user's home and
+	// not in the root of the filesystem.
+	rootDirPath := filepath.Join(r.Dir, modules.RenterDir, modules.FileSystemRoot, "subDir")
+	if _, err := os.Stat(rootDirPath); !os.IsNotExist(err) {
+		t.Fatal("dir was recovered outside of the user's home:", err)
+	}
+	rd, err := r.RenterDirGet(subDirSiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd.Directories[0].Tags, dirTags) {
+		t.Fatal("dir tags weren't recovered", rd.Directories[0].Tags)
+	}
+}
+
 // TestInterruptBackup tests that the renter can resume uploading a backup after
 // restarting.
 func TestInterruptBackup(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -1867,6 +1868,17 @@ func TestContractorChurnLimiter(t *testing.T) {
 			return errors.New("wrong host churned")
 		}
 
+		// The churn should be reported as an event.
+		if len(churnStatus.RecentEvents) != 1 {
+			return fmt.Errorf("expected 1 churn event but got %v", len(churnStatus.RecentEvents))
+		}
+		event := churnStatus.RecentEvents[0]
+		if !event.HostPublicKey.Equals(hostPubKey) || event.Prevented || event.Size != size || event.Reason == "" {
+			return fmt.Errorf("wrong churn event %v", event)
+		}
+		if churnStatus.CurrentPeriodContractChurn != 1 {
+			return fmt.Errorf("expected 1 churned contract but got %v", churnStatus.CurrentPeriodContractChurn)
+		}
 		return nil
 	})
 	if err != nil {
@@ -1923,6 +1935,16 @@ func TestContractorChurnLimiter(t *testing.T) {
 			return errors.New("wrong host churned")
 		}
 
+		// The contracts which weren't churned should be reported as well.
+		var prevented int
+		for _, event := range churnStatus.RecentEvents {
+			if event.Prevented {
+				prevented++
+			}
+		}
+		if prevented == 0 {
+			return errors.New("expected prevented churn events")
+		}
 		return nil
 	})
 	if err != nil {
@@ -2876,3 +2898,155 @@ func TestLargeRenewWindow(t *testing.T) {
 		t.Errorf("Expected NextPeriod to be %v but was %v", originalNextPeriod+allowance.Period, rg.NextPeriod)
 	}
 }
+
+// TestAllowancePlan tests that the /renter/allowance/plan endpoint returns a
+// plan for forming contracts without forming any contracts.
+func TestAllowancePlan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a few hosts.
+	groupParams := siatest.GroupParams{
+		Hosts:  3,
+		Miners: 1,
+	}
+	testDir := contractorTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a renter without an allowance.
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.SkipSetAllowance = true
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nodes[0]
+
+	// Request a plan for as many contracts as there are hosts.
+	allowance := siatest.DefaultAllowance
+	allowance.Hosts = uint64(len(tg.Hosts()))
+	plan, err := r.RenterAllowancePlanGet(allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.NeededContracts != allowance.Hosts || len(plan.Contracts) != len(tg.Hosts()) {
+		t.Fatalf("expected %v planned contracts but got %v", allowance.Hosts, len(plan.Contracts))
+	}
+	if len(plan.Warnings) != 0 {
+		t.Fatal("unexpected warnings", plan.Warnings)
+	}
+	if !plan.TotalFunding.Equals(plan.TotalFees.Add(plan.TotalLockedFunds)) {
+		t.Fatal("total funding doesn't equal the sum of fees and locked funds")
+	}
+	if !plan.FundsRemaining.Equals(allowance.Funds.Sub(plan.TotalFunding)) {
+		t.Fatal("wrong remaining funds", plan.FundsRemaining)
+	}
+	for _, pc := range plan.Contracts {
+		if pc.Fees.IsZero() || pc.LockedFunds.IsZero() || !pc.Funding.Equals(pc.Fees.Add(pc.LockedFunds)) {
+			t.Fatal("invalid planned contract", pc)
+		}
+	}
+
+	// Requesting more hosts than available should return a warning.
+	allowance.Hosts++
+	plan, err = r.RenterAllowancePlanGet(allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Contracts) != len(tg.Hosts()) || len(plan.Warnings) != 1 {
+		t.Fatal("expected a warning about missing hosts", len(plan.Contracts), plan.Warnings)
+	}
+
+	// An incomplete allowance should be rejected.
+	_, err = r.RenterAllowancePlanGet(modules.Allowance{Hosts: 1})
+	if err == nil || !strings.Contains(err.Error(), contractor.ErrAllowanceZeroFunds.Error()) {
+		t.Fatal("expected missing funds error but got", err)
+	}
+
+	// The renter shouldn't have formed any contracts.
+	rc, err := r.RenterAllContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.Contracts) != 0 {
+		t.Fatal("renter shouldn't have any contracts", len(rc.Contracts))
+	}
+}
+
+// TestSpendingForecast tests the /renter/forecast endpoint.
+func TestSpendingForecast(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group with a few hosts.
+	groupParams := siatest.GroupParams{
+		Hosts:  2,
+		Miners: 1,
+	}
+	testDir := contractorTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a renter without an allowance. It can't create a forecast.
+	renterParams := node.Renter(filepath.Join(testDir, "renter"))
+	renterParams.SkipSetAllowance = true
+	nodes, err := tg.AddNodes(renterParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := nodes[0]
+	if _, err := r.RenterForecastGet(); err == nil {
+		t.Fatal("expected forecast without an allowance to fail")
+	}
+
+	// Set the allowance and wait for the contracts.
+	allowance := siatest.DefaultAllowance
+	allowance.Hosts = uint64(len(tg.Hosts()))
+	if err := r.RenterPostAllowance(allowance); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		return siatest.CheckExpectedNumberOfContracts(r, len(tg.Hosts()), 0, 0, 0, 0, 0)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contract fees were spent and the contracts are expected to be
+	// renewed at the end of the period.
+	forecast, err := r.RenterForecastGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forecast.PeriodEnd != forecast.PeriodStart+allowance.Period {
+		t.Fatal("wrong period", forecast.PeriodStart, forecast.PeriodEnd)
+	}
+	if forecast.Spent.ContractFees.IsZero() || forecast.Projected.Total.Cmp(forecast.Spent.Total) < 0 {
+		t.Fatal("unexpected spending", forecast.Spent, forecast.Projected)
+	}
+	if forecast.EstimatedRenewCost.IsZero() || forecast.WalletFunds.IsZero() {
+		t.Fatal("unexpected renewal estimate", forecast.EstimatedRenewCost, forecast.WalletFunds)
+	}
+	if forecast.DepletionHeight != 0 || len(forecast.Warnings) != 0 {
+		t.Fatal("unexpected depletion", forecast.DepletionHeight, forecast.Warnings)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/node/api/client"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
@@ -1246,3 +1247,84 @@ func TestNetAddressFilterContracts(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestHostBenchmark checks that the renter can benchmark the hosts it has
+// contracts with and that the results are stored in the hostdb.
+func TestHostBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	testDir := hostdbTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	renter := tg.Renters()[0]
+
+	// Benchmark all hosts. The workers might not be ready right away.
+	var hbg api.HostdbBenchmarkGET
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		hbg, err = renter.HostDbBenchmarkPost()
+		if err != nil {
+			return err
+		}
+		if len(hbg.Hosts) != len(tg.Hosts()) {
+			return fmt.Errorf("expected %v results, got %v", len(tg.Hosts()), len(hbg.Hosts))
+		}
+		for _, result := range hbg.Hosts {
+			if result.Error != "" {
+				return errors.New(result.Error)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range hbg.Hosts {
+		if result.ReadLatency == 0 || result.ReadThroughput == 0 || result.WriteThroughput == 0 || result.RegistryReadLatency == 0 {
+			t.Fatal("benchmark is incomplete", result)
+		}
+	}
+
+	// The report contains the benchmarks and the hostdb uses them for
+	// scoring.
+	report, err := renter.HostDbBenchmarkGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Hosts) != len(hbg.Hosts) {
+		t.Fatal("wrong number of benchmarks in report", len(report.Hosts))
+	}
+	for _, result := range report.Hosts {
+		hhg, err := renter.HostDbHostsGet(result.PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hhg.Entry.Benchmark == nil || hhg.ScoreBreakdown.PerformanceAdjustment <= 0 {
+			t.Fatal("benchmark wasn't stored", hhg.Entry.Benchmark, hhg.ScoreBreakdown.PerformanceAdjustment)
+		}
+	}
+
+	// Hosts the renter doesn't have a contract with can't be benchmarked.
+	hbg, err = renter.HostDbBenchmarkPost(types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: make([]byte, 32)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hbg.Hosts) != 1 || hbg.Hosts[0].Error == "" {
+		t.Fatal("expected benchmark to fail", hbg.Hosts)
+	}
+}
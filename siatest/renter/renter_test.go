@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -25,6 +26,7 @@ import (
 	"go.sia.tech/siad/modules/host/contractmanager"
 	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/modules/renter/contractor"
+	"go.sia.tech/siad/modules/renter/filesystem"
 	"go.sia.tech/siad/modules/renter/filesystem/siadir"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
@@ -92,6 +94,12 @@ func TestRenterTwo(t *testing.T) {
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
+		{Name: "TestUploadSpill", Test: testUploadSpill},
+		{Name: "TestPackedFiles", Test: testPackedFiles},
+		{Name: "TestRenterCumulativeStats", Test: testRenterCumulativeStats},
+		{Name: "TestPaymentBudgets", Test: testPaymentBudgets},
+		{Name: "TestFileChecksums", Test: testFileChecksums},
+		{Name: "TestAudits", Test: testAudits},
 	}
 
 	// Run tests
@@ -278,6 +286,13 @@ func TestRenterFour(t *testing.T) {
 		{Name: "TestPauseAndResumeRepairAndUploads", Test: testPauseAndResumeRepairAndUploads},
 		{Name: "TestDownloadServedFromDisk", Test: testDownloadServedFromDisk},
 		{Name: "TestDirMode", Test: testDirMode},
+		{Name: "TestBulkOperations", Test: testBulkOperations},
+		{Name: "TestDirTransfers", Test: testDirTransfers},
+		{Name: "TestRenterSearch", Test: testRenterSearch},
+		{Name: "TestDirQuota", Test: testDirQuota},
+		{Name: "TestLocks", Test: testLocks},
+		{Name: "TestCustodyTransfer", Test: testCustodyTransfer},
+		{Name: "TestRenterList", Test: testRenterList},
 		{Name: "TestEscapeSiaPath", Test: testEscapeSiaPath}, // Runs last because it uploads many files
 	}
 
@@ -1113,6 +1128,15 @@ func testPriceTablesUpdated(t *testing.T, tg *siatest.TestGroup) {
 		t.Fatal(err)
 	}
 
+	// The status of the worker is also available on its own.
+	ws, err := r.RenterWorkerGet(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.HostPubKey.Equals(host) || ws.PriceTableStatus.FetchTime.IsZero() {
+		t.Fatal("unexpected worker status", ws.HostPubKey, ws.PriceTableStatus.FetchTime)
+	}
+
 	// Wait until after the price table is set to update, note we don't gain
 	// anything by waiting for this inside the build.Retry as we know when it
 	// won't trigger before the update time.
@@ -2937,6 +2961,366 @@ func TestRenterPersistData(t *testing.T) {
 	}
 }
 
+// testUploadSpill tests that uploads which don't get memory right away are
+// spilled to disk and still upload the right data.
+func testUploadSpill(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Shrink the memory budget of uploads so that chunks can't get memory while
+	// another chunk is uploading and enable the spill.
+	spillDir := filepath.Join(r.Dir, "testspill")
+	err := r.RenterMemoryPost(0, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterSpillPost(spillDir, 1<<30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := errors.Compose(r.RenterMemoryPost(0, 0, 0), r.RenterSpillPost("", 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rg.Settings.SpillDir != spillDir || rg.Settings.SpillCap != 1<<30 {
+		t.Fatal("spill settings not set", rg.Settings.SpillDir, rg.Settings.SpillCap)
+	}
+
+	// Upload a file with multiple chunks and download it again.
+	lf, rf, err := r.UploadNewFileBlocking(int(10*modules.SectorSize)+siatest.Fuzz(), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data, err := r.DownloadByStream(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = lf.Equal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// All the spill files should be removed once the chunks are uploaded.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		fis, err := ioutil.ReadDir(spillDir)
+		if err != nil {
+			return err
+		}
+		if len(fis) != 0 {
+			return fmt.Errorf("expected no spill files but got %v", len(fis))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testPackedFiles tests packing small files into a shared pack, uploading the
+// pack and downloading and deleting the packed files.
+func testPackedFiles(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Pack a few small files.
+	files := make(map[modules.SiaPath][]byte)
+	for i := 0; i < 3; i++ {
+		siaPath := modules.RandomSiaPath()
+		data := fastrand.Bytes(100 + fastrand.Intn(100))
+		err := r.RenterPackPost(siaPath, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[siaPath] = data
+	}
+	rpg, err := r.RenterPacksGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpg.PackedFiles) != len(files) {
+		t.Fatalf("expected %v packed files but got %v", len(files), len(rpg.PackedFiles))
+	}
+	pack := rpg.PackedFiles[0].Pack
+	for _, pf := range rpg.PackedFiles {
+		if _, exists := files[pf.SiaPath]; !exists {
+			t.Fatal("unexpected packed file", pf.SiaPath)
+		}
+		if pf.Uploaded || pf.Pack != pack {
+			t.Fatal("files should share a pack which isn't uploaded yet", pf)
+		}
+	}
+
+	// Upload the pack.
+	err = r.RenterPacksFlushPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpg, err = r.RenterPacksGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pf := range rpg.PackedFiles {
+		if !pf.Uploaded {
+			t.Fatal("pack should be uploaded", pf)
+		}
+	}
+	_, err = r.RenterFileRootGet(pack)
+	if err != nil {
+		t.Fatal("pack should exist as a siafile", err)
+	}
+
+	// Download the packed files.
+	for siaPath, data := range files {
+		downloaded, err := r.RenterPackGet(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(downloaded, data) {
+			t.Fatal("downloaded data doesn't match the packed data")
+		}
+	}
+
+	// Delete the packed files. The pack is deleted together with its last
+	// file.
+	for siaPath := range files {
+		err = r.RenterPackDeletePost(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	rpg, err = r.RenterPacksGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpg.PackedFiles) != 0 {
+		t.Fatal("expected no packed files", rpg.PackedFiles)
+	}
+	_, err = r.RenterFileRootGet(pack)
+	if err == nil || !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
+		t.Fatal("pack should have been deleted", err)
+	}
+}
+
+// testRenterCumulativeStats tests that uploads and downloads are reflected in
+// the renter's cumulative stats.
+func testRenterCumulativeStats(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	before, err := r.RenterStatsGet(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Upload a file and download it again.
+	_, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = r.DownloadByStream(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The stats should reflect the transfers.
+	after, err := r.RenterStatsGet(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.Since.Equal(before.Since) {
+		t.Fatal("start of the stats shouldn't change", before.Since, after.Since)
+	}
+	if after.UploadedBytes < before.UploadedBytes+2*modules.SectorSize || after.UploadJobs < before.UploadJobs+2 {
+		t.Fatal("upload not reflected in stats", before, after)
+	}
+	if after.DownloadedBytes <= before.DownloadedBytes || after.DownloadJobs <= before.DownloadJobs {
+		t.Fatal("download not reflected in stats", before, after)
+	}
+	if after.Spending.Uploads.Cmp(before.Spending.Uploads) <= 0 {
+		t.Fatal("upload spending not reflected in stats", before.Spending, after.Spending)
+	}
+
+	// The stats since now shouldn't exceed the full stats.
+	delta, err := r.RenterStatsGet(time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delta.Since.Before(after.Since) || delta.UploadedBytes > after.UploadedBytes || delta.Spending.Uploads.Cmp(after.Spending.Uploads) > 0 {
+		t.Fatal("wrong stats since now", delta, after)
+	}
+}
+
+// testPaymentBudgets tests executing MDM programs on a host which are paid
+// from a payment budget.
+func testPaymentBudgets(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	host, err := tg.Hosts()[0].HostPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	budget, err := r.RenterPaymentBudgetsPost("app", types.SiacoinPrecision, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check for a sector the host doesn't have and read a registry entry
+	// which doesn't exist. The worker might not be ready right away.
+	_, pk := crypto.GenerateKeyPair()
+	instructions := []modules.ProgramInstruction{
+		{Type: modules.ProgramInstructionHasSector, MerkleRoot: crypto.Hash{1}},
+		{Type: modules.ProgramInstructionReadRegistry, PublicKey: types.Ed25519PublicKey(pk), Tweak: crypto.Hash{2}},
+	}
+	var result modules.ProgramResult
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		result, err = r.RenterPaymentBudgetExecutePost(budget.ID, host, instructions, types.ZeroCurrency)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Outputs) != len(instructions) {
+		t.Fatal("wrong number of outputs", len(result.Outputs))
+	}
+	if hs := result.Outputs[0].HasSector; hs == nil || *hs {
+		t.Fatal("host shouldn't have the sector", result.Outputs[0])
+	}
+	if len(result.Outputs[1].Output) != 0 || result.Outputs[1].RegistryValue != nil {
+		t.Fatal("registry entry shouldn't exist", result.Outputs[1])
+	}
+	receipt := result.Receipt
+	if receipt.BudgetID != budget.ID || receipt.Cost.IsZero() || !receipt.Cost.Add(receipt.Refund).Equals(receipt.Payment) {
+		t.Fatal("invalid receipt", receipt)
+	}
+
+	// The budget is charged the cost of the program.
+	rpbg, err := r.RenterPaymentBudgetGet(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rpbg.Budget.Spent.Equals(receipt.Cost) || rpbg.Budget.Programs != 1 || len(rpbg.Receipts) != 1 || rpbg.Receipts[0].ID != receipt.ID {
+		t.Fatal("budget wasn't charged", rpbg)
+	}
+
+	// The cost of a program is known upfront.
+	rpcp, err := r.RenterProgramCostPost(host, instructions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rpcp.Cost.Equals(receipt.Payment) {
+		t.Fatal("wrong program cost", rpcp.Cost, receipt.Payment)
+	}
+	_, err = r.RenterPaymentBudgetExecutePost(budget.ID, host, instructions, rpcp.Cost.Sub64(1))
+	if err == nil || !strings.Contains(err.Error(), "exceeds the max cost") {
+		t.Fatal("expected max cost to be exceeded", err)
+	}
+
+	// Read the latest revision of the contract with the host together with
+	// data stored under the contract. The proof of the read is verified
+	// against the revision.
+	_, _, err = r.UploadNewFileBlocking(int(modules.SectorSize), 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contractInstructions := []modules.ProgramInstruction{
+		{Type: modules.ProgramInstructionRevision},
+		{Type: modules.ProgramInstructionReadOffset, Offset: 0, Length: 64 * crypto.SegmentSize, MerkleProof: true},
+	}
+	result, err = r.RenterPaymentBudgetExecutePost(budget.ID, host, contractInstructions, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, output := range result.Outputs {
+		if output.Error != "" {
+			t.Fatal(output.Error)
+		}
+	}
+	if rev := result.Outputs[0].Revision; rev == nil || rev.NewFileSize == 0 {
+		t.Fatal("expected revision of a contract with data", rev)
+	}
+	if len(result.Outputs[1].Output) != 64*crypto.SegmentSize {
+		t.Fatal("wrong amount of data", len(result.Outputs[1].Output))
+	}
+
+	// Write instructions aren't supported.
+	_, err = r.RenterPaymentBudgetExecutePost(budget.ID, host, []modules.ProgramInstruction{{Type: "append"}}, types.ZeroCurrency)
+	if err == nil || !strings.Contains(err.Error(), "unsupported instruction type") {
+		t.Fatal("expected append to be rejected", err)
+	}
+
+	// Programs which exceed the budget are rejected.
+	rpbg, err = r.RenterPaymentBudgetGet(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterPaymentBudgetPost(budget.ID, rpbg.Budget.Spent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterPaymentBudgetExecutePost(budget.ID, host, instructions, types.ZeroCurrency)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrPaymentBudgetExceeded.Error()) {
+		t.Fatal("expected budget to be exceeded", err)
+	}
+
+	// Deleted budgets can't be used anymore.
+	err = r.RenterPaymentBudgetDeletePost(budget.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpbsg, err := r.RenterPaymentBudgetsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rpbsg.Budgets) != 0 {
+		t.Fatal("expected no budgets", rpbsg.Budgets)
+	}
+	_, err = r.RenterPaymentBudgetGet(budget.ID)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrUnknownPaymentBudget.Error()) {
+		t.Fatal("expected unknown budget", err)
+	}
+}
+
+// testFileChecksums tests that the piece roots of an uploaded file can be
+// verified against the file's root.
+func testFileChecksums(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	fileSize := 2*int(siatest.ChunkSize(1, crypto.TypeDefaultRenter)) + 100
+	_, rf, err := r.UploadNewFileBlocking(fileSize, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fc, err := r.RenterFileChecksumsGet(rf.SiaPath(), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.FileSize != uint64(fileSize) || fc.NumChunks != 3 || len(fc.Chunks) != 3 {
+		t.Fatal("unexpected checksums", fc.FileSize, fc.NumChunks, len(fc.Chunks))
+	}
+	for _, chunk := range fc.Chunks {
+		for _, piece := range chunk.Pieces {
+			if piece.MerkleRoot == (crypto.Hash{}) || len(piece.Hosts) == 0 {
+				t.Fatal("piece wasn't uploaded", chunk.Index, piece)
+			}
+		}
+		if !fc.VerifyChunk(chunk) {
+			t.Fatal("chunk couldn't be verified", chunk.Index)
+		}
+	}
+
+	// Pages of chunks are verified against the same root.
+	page, err := r.RenterFileChecksumsGet(rf.SiaPath(), 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Root != fc.Root || len(page.Chunks) != 1 || !page.VerifyChunk(page.Chunks[0]) {
+		t.Fatal("unexpected page", page)
+	}
+	if _, err := r.RenterFileChecksumsGet(rf.SiaPath(), 0, 0); err == nil {
+		t.Fatal("expected a limit of 0 to be rejected")
+	}
+}
+
 // testZeroByteFile tests uploading and downloading a 0 and 1 byte file
 func testZeroByteFile(t *testing.T, tg *siatest.TestGroup) {
 	if len(tg.Hosts()) < 2 {
@@ -4701,52 +5085,660 @@ func testDirMode(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
-// TestWorkerStatus probes the WorkerPoolStatus
-func TestWorkerStatus(t *testing.T) {
-	if testing.Short() {
-		t.SkipNow()
+// testBulkOperations tests applying actions to the files matching a glob
+// pattern with the /renter/bulk endpoint.
+func testBulkOperations(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload three files into a dir.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	lfs := make(map[string]*siatest.LocalFile)
+	for _, name := range []string{"2020-01", "2020-02", "2021-01"} {
+		lf, err := r.FilesDir().NewFile(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := modules.NewSiaPath("bulk/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rf, err := r.Upload(lf, siaPath, dataPieces, parityPieces, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.WaitForFileAvailable(rf); err != nil {
+			t.Fatal(err)
+		}
+		lfs[siaPath.String()] = lf
 	}
-	t.Parallel()
 
-	// Create a testgroup.
-	groupParams := siatest.GroupParams{
-		Hosts:   2,
-		Miners:  1,
-		Renters: 1,
+	// An invalid pattern should be rejected.
+	if _, err := r.RenterBulkDeletePost("bulk/[", false, true); err == nil {
+		t.Fatal("expected invalid pattern to be rejected")
 	}
-	testDir := renterTestDir(t.Name())
-	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+
+	// A dry run should list the matching files without deleting them.
+	pattern := "bulk/2020-*"
+	rbp, err := r.RenterBulkDeletePost(pattern, false, true)
 	if err != nil {
-		t.Fatal("Failed to create group: ", err)
+		t.Fatal(err)
 	}
-	defer func() {
-		if err := tg.Close(); err != nil {
-			t.Fatal(err)
-		}
-	}()
-	r := tg.Renters()[0]
-	numHosts := len(tg.Hosts())
-
-	// Build Contract ID and PubKey maps
-	rc, err := r.RenterContractsGet()
+	if len(rbp.SiaPaths) != 2 || rbp.SiaPaths[0].String() != "bulk/2020-01" || rbp.SiaPaths[1].String() != "bulk/2020-02" {
+		t.Fatal("unexpected matching files", rbp.SiaPaths)
+	}
+	bulkDir, err := modules.NewSiaPath("bulk")
 	if err != nil {
 		t.Fatal(err)
 	}
-	contracts := make(map[types.FileContractID]struct{})
-	pks := make(map[string]struct{})
-	for _, c := range rc.ActiveContracts {
-		contracts[c.ID] = struct{}{}
-		pks[c.HostPublicKey.String()] = struct{}{}
+	rd, err := r.RenterDirGet(bulkDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) != 3 {
+		t.Fatal("dry run shouldn't delete files", len(rd.Files))
 	}
 
-	err = build.Retry(100, 1000*time.Millisecond, func() error {
-		// Get the worker status
-		rwg, err := r.RenterWorkersGet()
+	// Mark all the files as stuck.
+	rbp, err = r.RenterBulkStuckPost("bulk/*", true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbp.SiaPaths) != 3 || len(rbp.Errors) != 0 {
+		t.Fatal("unexpected result", rbp)
+	}
+	for _, sp := range rbp.SiaPaths {
+		rf, err := r.RenterFileGet(sp)
 		if err != nil {
 			t.Fatal(err)
 		}
+		if !rf.File.Stuck {
+			t.Fatal("file should be stuck", sp)
+		}
+	}
 
-		// There should be the same number of workers as Hosts
+	// Download the matching files. They should keep their paths relative to
+	// the destination.
+	destination := r.DownloadDir().Path()
+	rbp, err = r.RenterBulkDownloadPost(pattern, destination, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbp.SiaPaths) != 2 || len(rbp.Errors) != 0 {
+		t.Fatal("unexpected result", rbp)
+	}
+	for _, sp := range rbp.SiaPaths {
+		data, err := lfs[sp.String()].Data()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = build.Retry(100, 100*time.Millisecond, func() error {
+			downloaded, err := ioutil.ReadFile(filepath.Join(destination, filepath.FromSlash(sp.String())))
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(data, downloaded) {
+				return errors.New("downloaded data doesn't match")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Delete the matching files.
+	rbp, err = r.RenterBulkDeletePost(pattern, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rbp.SiaPaths) != 2 || len(rbp.Errors) != 0 {
+		t.Fatal("unexpected result", rbp)
+	}
+	rd, err = r.RenterDirGet(bulkDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd.Files) != 1 || rd.Files[0].SiaPath.String() != "bulk/2021-01" {
+		t.Fatal("unexpected remaining files", rd.Files)
+	}
+}
+
+// testDirTransfers tests uploading and downloading directory trees with the
+// /renter/uploaddir and /renter/downloaddir endpoints.
+func testDirTransfers(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Create a local directory tree with an empty subdirectory.
+	ld, err := r.NewLocalDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ld.PopulateDir(2, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ld.CreateDir("empty"); err != nil {
+		t.Fatal(err)
+	}
+	// waitForTransfer waits for a transfer to complete without errors.
+	waitForTransfer := func(id string) api.RenterDirTransfer {
+		var rdt api.RenterDirTransfer
+		err := build.Retry(100, 100*time.Millisecond, func() error {
+			var err error
+			rdt, err = r.RenterDirTransferGet(id)
+			if err != nil {
+				return err
+			}
+			if !rdt.Completed {
+				return errors.New("transfer isn't completed yet")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rdt.FailedFiles != 0 {
+			t.Fatal("transfer has failed files", rdt.Files)
+		}
+		return rdt
+	}
+
+	// Upload the tree.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	siaPath, err := modules.NewSiaPath(ld.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdt, err := r.RenterUploadDirPost(ld.Path(), siaPath, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rdt.Type != "upload" || rdt.TotalFiles != 6 {
+		t.Fatal("unexpected transfer", rdt)
+	}
+	rdt = waitForTransfer(rdt.ID)
+	if rdt.TransferredBytes != rdt.TotalBytes || rdt.Progress != 100 {
+		t.Fatal("unexpected progress", rdt.TransferredBytes, rdt.TotalBytes, rdt.Progress)
+	}
+	emptyDir, err := siaPath.Join("empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.RenterDirGet(emptyDir); err != nil {
+		t.Fatal("empty directory wasn't created", err)
+	}
+
+	// Download the tree.
+	destination := filepath.Join(r.DownloadDir().Path(), ld.Name())
+	rdt, err = r.RenterDownloadDirGet(siaPath, destination, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rdt.Type != "download" || rdt.TotalFiles != 6 {
+		t.Fatal("unexpected transfer", rdt)
+	}
+	waitForTransfer(rdt.ID)
+	err = filepath.Walk(ld.Path(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(ld.Path(), path)
+		if err != nil {
+			return err
+		}
+		downloaded, err := os.Stat(filepath.Join(destination, rel))
+		if err != nil {
+			return err
+		}
+		if info.IsDir() != downloaded.IsDir() {
+			return fmt.Errorf("%v has the wrong type", rel)
+		}
+		if info.IsDir() {
+			return nil
+		}
+		expected, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(filepath.Join(destination, rel))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected, data) {
+			return fmt.Errorf("%v doesn't match", rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Downloading again should skip the existing files.
+	rdt, err = r.RenterDownloadDirGet(siaPath, destination, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rdt.Completed || rdt.SkippedFiles != 6 {
+		t.Fatal("existing files should be skipped", rdt)
+	}
+
+	// An unreadable subdirectory should be reported as failed without
+	// stopping the upload of the other files. The permissions don't apply to
+	// root.
+	if os.Geteuid() == 0 {
+		return
+	}
+	ld, err = r.NewLocalDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ld.PopulateDir(1, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	unreadable, err := ld.CreateDir("unreadable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := unreadable.NewFile(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(unreadable.Path(), 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.Chmod(unreadable.Path(), modules.DefaultDirPerm); err != nil {
+			t.Error(err)
+		}
+	}()
+	siaPath, err = modules.NewSiaPath(ld.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rdt, err = r.RenterUploadDirPost(ld.Path(), siaPath, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rdt.FailedFiles != 1 || rdt.TotalFiles != 2 {
+		t.Fatal("unreadable directory should be reported as failed", rdt)
+	}
+}
+
+// testDirQuota tests that uploads exceeding the quota of a directory fail.
+func testDirQuota(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Create a dir with a quota.
+	dir, err := modules.NewSiaPath("quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterDirCreatePost(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterDirSetQuotaPost(dir, 1000); err != nil {
+		t.Fatal(err)
+	}
+	rd, err := r.RenterDirGet(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rd.Directories[0].Quota != 1000 {
+		t.Fatal("unexpected quota", rd.Directories[0].Quota)
+	}
+
+	// upload uploads a file of the given size into a sub dir of the dir.
+	upload := func(name string, size int) error {
+		lf, err := r.FilesDir().NewFile(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Upload(lf, siaPath, dataPieces, parityPieces, false)
+		return err
+	}
+	if err := upload("a", 600); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the usage of the dir to be updated.
+	bubblePath, err := dir.Rebase(modules.RootSiaPath(), modules.UserFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if err := r.RenterBubblePost(bubblePath, true, true); err != nil {
+			return err
+		}
+		rd, err := r.RenterDirGet(dir)
+		if err != nil {
+			return err
+		}
+		if rd.Directories[0].AggregateSize != 600 {
+			return fmt.Errorf("expected usage of 600 but got %v", rd.Directories[0].AggregateSize)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An upload into a sub dir exceeding the quota should fail.
+	err = upload("sub/b", 600)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrDirQuotaExceeded.Error()) {
+		t.Fatal("expected quota to be exceeded, got", err)
+	}
+	if err := upload("sub/b", 400); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the quota should allow the upload.
+	if err := r.RenterDirSetQuotaPost(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := upload("c", 600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testLocks tests that locked files and directories can't be deleted or
+// overwritten until their locks expire.
+func testLocks(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	dir, err := modules.NewSiaPath("locks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// upload uploads a file into the dir.
+	upload := func(name string, force bool) (modules.SiaPath, error) {
+		lf, err := r.FilesDir().NewFile(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.Upload(lf, siaPath, dataPieces, parityPieces, force)
+		return siaPath, err
+	}
+	siaPath, err := upload("a", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lock the file.
+	lockedUntil := time.Now().Add(10 * time.Second)
+	if err := r.RenterSetFileLockPost(siaPath, false, lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.LockedUntil.Unix() != lockedUntil.Unix() {
+		t.Fatal("unexpected lock", rf.File.LockedUntil)
+	}
+	err = r.RenterSetFileLockPost(siaPath, false, time.Now())
+	if err == nil || !strings.Contains(err.Error(), modules.ErrLockShortened.Error()) {
+		t.Fatal("expected lock to be kept, got", err)
+	}
+
+	// The file can't be deleted, moved or overwritten, neither can its dir.
+	newSiaPath, err := modules.NewSiaPath("locks-renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := []error{
+		r.RenterFileDeletePost(siaPath),
+		r.RenterRenamePost(siaPath, newSiaPath, false),
+		r.RenterDirDeletePost(dir),
+		r.RenterDirRenamePost(dir, newSiaPath),
+	}
+	_, err = upload("a", true)
+	errs = append(errs, err)
+	for i, err := range errs {
+		if err == nil || !strings.Contains(err.Error(), modules.ErrLocked.Error()) {
+			t.Fatal("expected operation to be rejected", i, err)
+		}
+	}
+
+	// Files can be added to a locked dir but not deleted.
+	if err := r.RenterDirSetLockPost(dir, lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err = upload("sub/b", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterFileDeletePost(siaPath)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrLocked.Error()) {
+		t.Fatal("expected deletion to be rejected", err)
+	}
+
+	// Once the locks expire, the dir can be deleted.
+	err = build.Retry(100, 200*time.Millisecond, func() error {
+		return r.RenterDirDeletePost(dir)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRenterList tests the paginated listing of directory trees with the
+// /renter/list endpoint.
+func testRenterList(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+
+	// Create a tree of files and dirs.
+	dir, err := modules.NewSiaPath("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "d1/b", "d1/d2/c"} {
+		lf, err := r.FilesDir().NewFile(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := dir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Upload(lf, siaPath, dataPieces, parityPieces, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d3, err := dir.Join("d3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterDirCreatePost(d3); err != nil {
+		t.Fatal(err)
+	}
+
+	// list lists a page and checks the siapaths of the returned dirs and
+	// files.
+	list := func(req *client.RenterListRequestGet, dirs, files []string) api.RenterListGET {
+		t.Helper()
+		rlg, err := req.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotDirs, gotFiles []string
+		for _, di := range rlg.Directories {
+			gotDirs = append(gotDirs, di.SiaPath.String())
+		}
+		for _, fi := range rlg.Files {
+			gotFiles = append(gotFiles, fi.SiaPath.String())
+		}
+		if !reflect.DeepEqual(gotDirs, dirs) || !reflect.DeepEqual(gotFiles, files) {
+			t.Fatalf("expected dirs %v and files %v but got %v and %v", dirs, files, gotDirs, gotFiles)
+		}
+		return rlg
+	}
+	list(r.RenterList(dir), []string{"list/d1", "list/d3"}, []string{"list/a"})
+	list(r.RenterList(dir).Depth(2), []string{"list/d1", "list/d1/d2", "list/d3"}, []string{"list/a", "list/d1/b"})
+
+	// Page through the whole tree.
+	rlg := list(r.RenterList(dir).Depth(0).Page("", 2), []string{"list/d1"}, []string{"list/a"})
+	rlg = list(r.RenterList(dir).Depth(0).Page(rlg.NextToken, 2), []string{"list/d1/d2"}, []string{"list/d1/b"})
+	rlg = list(r.RenterList(dir).Depth(0).Page(rlg.NextToken, 2), []string{"list/d3"}, []string{"list/d1/d2/c"})
+	if rlg.NextToken != "" {
+		t.Fatal("expected no more pages")
+	}
+	if _, err := r.RenterList(dir).Page("!", 2).Get(); err == nil {
+		t.Fatal("expected invalid token to be rejected")
+	}
+
+	// Only the selected fields should be returned.
+	rlg = list(r.RenterList(dir).Fields("filesize"), []string{"list/d1", "list/d3"}, []string{"list/a"})
+	if rlg.Files[0].Filesize != 100 || rlg.Files[0].LocalPath != "" {
+		t.Fatal("unexpected fields", rlg.Files[0])
+	}
+	rlg = list(r.RenterList(dir), []string{"list/d1", "list/d3"}, []string{"list/a"})
+	if rlg.Files[0].LocalPath == "" {
+		t.Fatal("expected all fields to be returned")
+	}
+}
+
+// testRenterSearch tests searching the files of the renter with the
+// /renter/search endpoint.
+func testRenterSearch(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload files of different sizes into a dir.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	searchDir, err := modules.NewSiaPath("search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPaths := make(map[string]modules.SiaPath)
+	for i, name := range []string{"a.txt", "b.log", "sub/c.txt"} {
+		lf, err := r.FilesDir().NewFile(100 * (i + 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		siaPath, err := searchDir.Join(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.Upload(lf, siaPath, dataPieces, parityPieces, false); err != nil {
+			t.Fatal(err)
+		}
+		siaPaths[name] = siaPath
+	}
+
+	// Tag two of the files.
+	if err := r.RenterSetFileTagsPost(siaPaths["a.txt"], false, map[string]string{"x": "1", "y": ""}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterSetFileTagsPost(siaPaths["sub/c.txt"], false, map[string]string{"y": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileGet(siaPaths["a.txt"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rf.File.Tags, map[string]string{"x": "1", "y": ""}) {
+		t.Fatal("unexpected tags", rf.File.Tags)
+	}
+
+	// search runs a search in the dir and checks the names of the returned
+	// files.
+	search := func(req *client.RenterSearchRequestGet, total int, names ...string) {
+		t.Helper()
+		rsg, err := req.InDir(searchDir).Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rsg.Total != total || len(rsg.Files) != len(names) {
+			t.Fatalf("expected %v of %v files but got %v of %v", len(names), total, len(rsg.Files), rsg.Total)
+		}
+		for i, name := range names {
+			if rsg.Files[i].SiaPath != siaPaths[name] {
+				t.Fatalf("expected %v at %v but got %v", siaPaths[name], i, rsg.Files[i].SiaPath)
+			}
+		}
+	}
+	search(r.RenterSearch(), 3, "a.txt", "b.log", "sub/c.txt")
+	search(r.RenterSearch().WithName(".TXT"), 2, "a.txt", "sub/c.txt")
+	search(r.RenterSearch().WithRegex("^search/sub/"), 1, "sub/c.txt")
+	search(r.RenterSearch().WithSizeRange(150, 400), 2, "b.log", "sub/c.txt")
+	search(r.RenterSearch().WithHealthRange(100, 200), 0)
+	search(r.RenterSearch().WithTags("y"), 2, "a.txt", "sub/c.txt")
+	search(r.RenterSearch().WithTags("x", "y"), 1, "a.txt")
+	search(r.RenterSearch().WithTags("y=2"), 1, "sub/c.txt")
+	search(r.RenterSearch().WithModifiedRange(time.Now().Add(time.Hour), time.Now().Add(2*time.Hour)), 0)
+	search(r.RenterSearch().SortBy("size", true), 3, "sub/c.txt", "b.log", "a.txt")
+	search(r.RenterSearch().SortBy("size", true).Page(1, 1), 3, "b.log")
+	search(r.RenterSearch().Page(5, 1), 3)
+	if _, err := r.RenterSearch().SortBy("name", false).Get(); err == nil {
+		t.Fatal("expected unknown sort field to be rejected")
+	}
+
+	// Clearing the tags should remove the file from the results.
+	if err := r.RenterSetFileTagsPost(siaPaths["a.txt"], false, nil); err != nil {
+		t.Fatal(err)
+	}
+	search(r.RenterSearch().WithTags("y"), 1, "sub/c.txt")
+}
+
+// TestWorkerStatus probes the WorkerPoolStatus
+func TestWorkerStatus(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+	numHosts := len(tg.Hosts())
+
+	// Build Contract ID and PubKey maps
+	rc, err := r.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts := make(map[types.FileContractID]struct{})
+	pks := make(map[string]struct{})
+	for _, c := range rc.ActiveContracts {
+		contracts[c.ID] = struct{}{}
+		pks[c.HostPublicKey.String()] = struct{}{}
+	}
+
+	err = build.Retry(100, 1000*time.Millisecond, func() error {
+		// Get the worker status
+		rwg, err := r.RenterWorkersGet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// There should be the same number of workers as Hosts
 		if rwg.NumWorkers != numHosts {
 			t.Fatalf("Expected NumWorkers to be %v but got %v", numHosts, rwg.NumWorkers)
 		}
@@ -5769,3 +6761,248 @@ func TestRenterFileHosts(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// testAudits tests auditing the retrievability of the renter's files.
+func testAudits(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	if _, _, err := r.UploadNewFileBlocking(int(siatest.ChunkSize(1, crypto.TypeDefaultRenter)), 1, 2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Audit the files. The pieces are downloaded from the hosts which
+	// requires the workers to be ready.
+	var report modules.AuditReport
+	err := build.Retry(100, 100*time.Millisecond, func() error {
+		var err error
+		report, err = r.RenterAuditPost(10)
+		if err != nil {
+			return err
+		}
+		for _, h := range report.Hosts {
+			if h.Passed == 0 {
+				return fmt.Errorf("no pieces passed the audit on %v: %v", h.HostKey, h.LastError)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Samples != 10 || len(report.Files) == 0 || len(report.Hosts) == 0 {
+		t.Fatal("unexpected report", report)
+	}
+	if err := report.VerifySignature(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The report is kept together with the settings.
+	settings := modules.AuditSettings{Interval: time.Hour, Samples: 20}
+	if err := r.RenterAuditsPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	rag, err := r.RenterAuditsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rag.Settings != settings || len(rag.Reports) == 0 || rag.Reports[0].ID != report.ID {
+		t.Fatal("unexpected audits", rag.Settings, len(rag.Reports))
+	}
+	if err := r.RenterAuditsPost(modules.AuditSettings{}); err == nil {
+		t.Fatal("expected settings without samples to be rejected")
+	}
+	if err := r.RenterAuditsPost(modules.AuditSettings{Samples: 50}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testCustodyTransfer tests handing the files of a renter over to another
+// renter.
+func testCustodyTransfer(t *testing.T, tg *siatest.TestGroup) {
+	// Add a renter which hands its files over and a renter which takes them
+	// over.
+	testDir := renterTestDir(t.Name())
+	nodes, err := tg.AddNodes(node.Renter(filepath.Join(testDir, "sender")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := nodes[0]
+	// The recipient has no contracts with the hosts of the transferred
+	// contracts.
+	recipientParams := node.Renter(filepath.Join(testDir, "recipient"))
+	recipientParams.SkipSetAllowance = true
+	nodes, err = tg.AddNodes(recipientParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := nodes[0]
+	defer func() {
+		if err := tg.RemoveNodeN(sender, recipient); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	lf, rf, err := sender.UploadNewFileBlocking(100, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Transfer the custody of all files.
+	rskg, err := recipient.RenterShareKeyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(sender.FilesDir().Path(), "custody")
+	ct, err := sender.RenterCustodyTransferPost(modules.RootSiaPath(), bundle, rskg.ShareKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Files != 1 || ct.Contracts != len(tg.Hosts()) {
+		t.Fatal("unexpected transfer", ct)
+	}
+	if _, err := sender.File(rf); err != nil {
+		t.Fatal("transferred file was deleted before the transfer was confirmed", err)
+	}
+	rc, err := sender.RenterContractsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rc.ActiveContracts) != 0 {
+		t.Fatal("transferred contracts are still active", len(rc.ActiveContracts))
+	}
+
+	// Import the bundle and download the file.
+	dir, err := modules.NewSiaPath("custody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recipient.RenterCustodyImportPost(bundle, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Confirm the transfer. The sender deletes the transferred file.
+	ct, err = sender.RenterCustodyConfirmPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ct.Files != 1 || ct.Contracts != len(tg.Hosts()) {
+		t.Fatal("unexpected confirmation", ct)
+	}
+	if _, err := sender.File(rf); err == nil {
+		t.Fatal("transferred file wasn't deleted")
+	}
+	siaPath, err := dir.Join(rf.SiaPath().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, data, err := recipient.RenterDownloadHTTPResponseGet(siaPath, 0, 100, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatal("downloaded data doesn't match")
+	}
+}
+
+// TestRenterGougingReport tests the gouging thresholds of the renter settings
+// and the /renter/gouging endpoint.
+func TestRenterGougingReport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for the test.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(renterTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// With the default thresholds no host should be filtered.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rgg, err := r.RenterGougingGet()
+		if err != nil {
+			return err
+		}
+		if rgg.NumHosts != len(tg.Hosts()) || len(rgg.Hosts) != 0 {
+			return fmt.Errorf("unexpected report %v", rgg.GougingReport)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Lower the price table threshold until every update is considered
+	// gouging. All hosts should be filtered by that rule only.
+	priceTable := 1e-30
+	if err := r.RenterGougingPost(modules.GougingSettings{PriceTableMaxFraction: &priceTable}); err != nil {
+		t.Fatal(err)
+	}
+	rgg, err := r.RenterGougingGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rgg.Settings.PriceTableMaxFraction != 1e-30 || len(rgg.Hosts) != len(tg.Hosts()) {
+		t.Fatal("unexpected report", rgg.GougingReport)
+	}
+	for _, host := range rgg.Hosts {
+		if len(host.Violations) != 1 || host.Violations[0].Rule != modules.GougingRulePriceTable {
+			t.Fatal("unexpected violations", host.Violations)
+		}
+	}
+
+	// Disabling the rule stops filtering the hosts.
+	priceTable = 0
+	if err := r.RenterGougingPost(modules.GougingSettings{PriceTableMaxFraction: &priceTable}); err != nil {
+		t.Fatal(err)
+	}
+	rgg, err = r.RenterGougingGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rgg.Settings.PriceTableMaxFraction != 0 || len(rgg.Hosts) != 0 {
+		t.Fatal("unexpected report", rgg.GougingReport)
+	}
+
+	// Invalid thresholds are rejected and resetting the thresholds restores
+	// the defaults.
+	upload := 2.0
+	if err := r.RenterGougingPost(modules.GougingSettings{UploadUsageFraction: &upload}); err == nil {
+		t.Fatal("expected invalid threshold to be rejected")
+	}
+	if err := r.RenterGougingPost(modules.GougingSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	rg, err := r.RenterGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rg.Settings.Gouging, modules.GougingSettings{}) {
+		t.Fatal("gouging settings weren't reset", rg.Settings.Gouging)
+	}
+	rgg, err = r.RenterGougingGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rgg.Settings.PriceTableMaxFraction != 0.01 || *rgg.Settings.BaseRPCMaxFraction != 0.01 || len(rgg.Hosts) != 0 {
+		t.Fatal("unexpected report", rgg.GougingReport)
+	}
+}
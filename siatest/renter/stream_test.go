@@ -4,15 +4,22 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
@@ -182,6 +189,12 @@ func TestRenterStream(t *testing.T) {
 		{Name: "TestStreamRepair", Test: testStreamRepair},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
 		{Name: "TestUploadStreamingWithBadDeps", Test: testUploadStreamingWithBadDeps},
+		{Name: "TestUploadURL", Test: testUploadURL},
+		{Name: "TestUploadStreamingAppend", Test: testUploadStreamingAppend},
+		{Name: "TestUploadStreamingConcat", Test: testUploadStreamingConcat},
+		{Name: "TestUploadStreamingSparse", Test: testUploadStreamingSparse},
+		{Name: "TestUploadStreamingExternalKey", Test: testUploadStreamingExternalKey},
+		{Name: "TestReshard", Test: testReshard},
 	}
 
 	// Run tests
@@ -344,6 +357,323 @@ func testUploadStreaming(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadStreamingAppend tests appending data to an existing file using the
+// upload streaming API.
+func testUploadStreamingAppend(t *testing.T, tg *siatest.TestGroup) {
+	// With a single data piece, the chunk size equals the sector size.
+	chunkSize := int(modules.SectorSize)
+	part1 := fastrand.Bytes(2 * chunkSize)
+	part2 := fastrand.Bytes(chunkSize)
+	part3 := fastrand.Bytes(chunkSize/2 + siatest.Fuzz() + 1)
+
+	// Upload the first part.
+	siaPath, err := modules.NewSiaPath("/append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	err = r.RenterUploadStreamPost(bytes.NewReader(part1), siaPath, 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Append the other parts.
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(part2), siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(part3), siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file is no longer chunk aligned, so appending again should fail.
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(part3), siaPath)
+	if err == nil || !strings.Contains(err.Error(), "multiple of their chunk size") {
+		t.Fatal("expected append to fail", err)
+	}
+
+	// Appending to a file that doesn't exist should fail too.
+	missing, err := modules.NewSiaPath("/append-missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(part2), missing)
+	if err == nil {
+		t.Fatal("expected append to fail")
+	}
+
+	// Download the file and compare it to the concatenated parts.
+	data := append(append(append([]byte{}, part1...), part2...), part3...)
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != uint64(len(data)) {
+		t.Fatalf("expected file to have size %v but was %v", len(data), rfg.File.Filesize)
+	}
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
+// testUploadStreamingSparse tests that chunks of zeros of a sparse file are
+// not uploaded but are still downloaded correctly.
+func testUploadStreamingSparse(t *testing.T, tg *siatest.TestGroup) {
+	// With a single data piece, the chunk size equals the sector size. The
+	// data has 2 chunks of zeros between 2 chunks of random data.
+	chunkSize := int(modules.SectorSize)
+	data := append(fastrand.Bytes(chunkSize), make([]byte, 2*chunkSize)...)
+	data = append(data, fastrand.Bytes(chunkSize/2+siatest.Fuzz()+1)...)
+
+	// Upload the data as a sparse file.
+	siaPath, err := modules.NewSiaPath("/sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	numPieces := uint64(len(tg.Hosts()))
+	err = r.RenterUploadStreamSparsePost(bytes.NewReader(data), siaPath, 1, numPieces-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The holes shouldn't have been uploaded but the file should still be
+	// fully uploaded.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.UploadProgress < 100 {
+			return fmt.Errorf("expected upload progress to be 100 but was %v", rfg.File.UploadProgress)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != uint64(len(data)) {
+		t.Fatalf("expected file to have size %v but was %v", len(data), rfg.File.Filesize)
+	}
+	if maxUploaded := 2 * numPieces * modules.SectorSize; rfg.File.UploadedBytes > maxUploaded {
+		t.Fatalf("expected at most %v uploaded bytes but got %v", maxUploaded, rfg.File.UploadedBytes)
+	}
+
+	// Download the whole file and a range spanning the end of the data and the
+	// start of the holes.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+	offset, length := uint64(chunkSize/2), uint64(chunkSize)
+	_, downloadedData, err = r.RenterDownloadHTTPResponseGet(siaPath, offset, length, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[offset:offset+length], downloadedData) {
+		t.Fatal("Downloaded range doesn't match uploaded data")
+	}
+}
+
+// testUploadStreamingExternalKey tests uploading a file which is encrypted with
+// a key that is provided by the user instead of being stored by the renter.
+func testUploadStreamingExternalKey(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	data := fastrand.Bytes(int(modules.SectorSize) + siatest.Fuzz() + 1)
+	siaPath, err := modules.NewSiaPath("/externalkey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := crypto.GenerateSiaKey(crypto.TypeDefaultRenter)
+	err = r.RenterUploadStreamExternalKeyPost(bytes.NewReader(data), siaPath, "mykey", key, 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.MasterKeyID != "mykey" || rfg.File.CipherType != key.Type().String() {
+		t.Fatal("unexpected key info", rfg.File.MasterKeyID, rfg.File.CipherType)
+	}
+
+	// The file can only be downloaded with the right key.
+	downloadedData, err := r.RenterDownloadExternalKeyGet(siaPath, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+	_, _, err = r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err == nil || !strings.Contains(err.Error(), siafile.ErrMasterKeyRequired.Error()) {
+		t.Fatal("expected download without a key to fail", err)
+	}
+	_, err = r.RenterDownloadExternalKeyGet(siaPath, crypto.GenerateSiaKey(crypto.TypeDefaultRenter))
+	if err == nil || !strings.Contains(err.Error(), siafile.ErrMasterKeyMismatch.Error()) {
+		t.Fatal("expected download with the wrong key to fail", err)
+	}
+}
+
+// testReshard tests changing the erasure coding parameters of a file.
+func testReshard(t *testing.T, tg *siatest.TestGroup) {
+	// Upload a file with a single data piece.
+	r := tg.Renters()[0]
+	numPieces := uint64(len(tg.Hosts()))
+	data := fastrand.Bytes(int(3*modules.SectorSize) + siatest.Fuzz())
+	siaPath, err := modules.NewSiaPath("/reshard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, 1, numPieces-1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid := rfg.File.UID
+
+	// Resharding to the same parameters should fail.
+	err = r.RenterReshardPost(siaPath, 1, numPieces-1)
+	if err == nil {
+		t.Fatal("expected resharding to the same parameters to fail")
+	}
+
+	// Reshard the file to 2 data pieces.
+	err = r.RenterReshardPost(siaPath, 2, numPieces-2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(600, 100*time.Millisecond, func() error {
+		rg, err := r.RenterReshardGet()
+		if err != nil {
+			return err
+		}
+		for _, rs := range rg.Reshards {
+			if !rs.SiaPath.Equals(siaPath) {
+				continue
+			}
+			if !rs.Completed {
+				return errors.New("reshard not completed yet")
+			}
+			if rs.Error != "" {
+				return fmt.Errorf("reshard failed: %v", rs.Error)
+			}
+			if rs.ChunksReencoded != rs.NumChunks || rs.NumChunks != 2 {
+				return fmt.Errorf("expected 2 of 2 chunks to be re-encoded but got %v of %v", rs.ChunksReencoded, rs.NumChunks)
+			}
+			return nil
+		}
+		return errors.New("reshard not found")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should have been replaced by the re-encoded one which already
+	// reached the redundancy of the new parameters.
+	expectedRedundancy := float64(numPieces) / 2
+	rfg, err = r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.UID == uid {
+		t.Fatal("file wasn't replaced")
+	}
+	if rfg.File.Redundancy < expectedRedundancy {
+		t.Fatalf("expected redundancy %v but got %v", expectedRedundancy, rfg.File.Redundancy)
+	}
+
+	// The data should be unchanged.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
+// testUploadStreamingConcat tests uploading the parts of a file as separate
+// files and concatenating them afterwards.
+func testUploadStreamingConcat(t *testing.T, tg *siatest.TestGroup) {
+	// With a single data piece, the chunk size equals the sector size.
+	chunkSize := int(modules.SectorSize)
+	data := [][]byte{
+		fastrand.Bytes(2 * chunkSize),
+		fastrand.Bytes(chunkSize),
+		fastrand.Bytes(chunkSize/2 + siatest.Fuzz() + 1),
+	}
+
+	// Upload the parts in reverse order as separate files.
+	r := tg.Renters()[0]
+	parts := make([]modules.SiaPath, len(data))
+	for i := len(data) - 1; i >= 0; i-- {
+		siaPath, err := modules.NewSiaPath(fmt.Sprintf("/concat/part%v", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.RenterUploadStreamPost(bytes.NewReader(data[i]), siaPath, 1, uint64(len(tg.Hosts())-1), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts[i] = siaPath
+	}
+
+	// Only the last part may end within a chunk.
+	siaPath, err := modules.NewSiaPath("/concat/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterConcatPost(siaPath, []modules.SiaPath{parts[2], parts[0]}, false)
+	if err == nil {
+		t.Fatal("expected concatenation to fail")
+	}
+	err = r.RenterConcatPost(siaPath, parts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range parts {
+		if _, err := r.RenterFileGet(part); err == nil || !strings.Contains(err.Error(), filesystem.ErrNotExist.Error()) {
+			t.Fatal("expected part to be deleted", err)
+		}
+	}
+
+	// Download the file and compare it to the concatenated parts.
+	var file []byte
+	for _, d := range data {
+		file = append(file, d...)
+	}
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != uint64(len(file)) {
+		t.Fatalf("expected file to have size %v but was %v", len(file), rfg.File.Filesize)
+	}
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(file)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(file, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
 // testUploadStreamingWithBadDeps uploads random data using the upload streaming
 // API, depending on a disrupt to cause a failure. This is a regression test
 // that would have caused a production build panic.
@@ -378,3 +708,111 @@ func testUploadStreamingWithBadDeps(t *testing.T, tg *siatest.TestGroup) {
 		t.Fatal("dependency injection should have caused the upload to fail")
 	}
 }
+
+// testUploadURL tests uploading a file whose data the renter fetches from a
+// URL.
+func testUploadURL(t *testing.T, tg *siatest.TestGroup) {
+	// Serve some random data.
+	fileSize := fastrand.Intn(2*int(modules.SectorSize)) + siatest.Fuzz() + 2 // between 1 and 2*SectorSize + 3 bytes
+	data := fastrand.Bytes(fileSize)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	// Upload the data.
+	siaPath, err := modules.NewSiaPath("/url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	err = r.RenterUploadURLPost(server.URL, siaPath, 1, uint64(len(tg.Hosts())-1), 0, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The upload should be completed.
+	uploads, err := r.RenterUploadURLGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads.Uploads) != 1 {
+		t.Fatal("expected 1 url upload but got", len(uploads.Uploads))
+	}
+	u := uploads.Uploads[0]
+	if !u.Completed || u.Error != "" || u.Fetched != uint64(fileSize) || u.URL != server.URL {
+		t.Fatal("unexpected url upload", u)
+	}
+
+	// Download the file again.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+
+	// Uploading the data with a smaller maximum size should fail.
+	tooLarge, err := modules.NewSiaPath("/url-too-large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadURLPost(server.URL, tooLarge, 1, uint64(len(tg.Hosts())-1), uint64(fileSize-1), false, false)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrURLUploadTooLarge.Error()) {
+		t.Fatal("expected upload to fail", err)
+	}
+	_, err = r.RenterFileGet(tooLarge)
+	if err == nil {
+		t.Fatal("file shouldn't exist")
+	}
+
+	// Uploading data of unknown size should fail.
+	chunked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(data[:1])
+		w.(http.Flusher).Flush()
+		_, _ = w.Write(data[1:])
+	}))
+	defer chunked.Close()
+	unknownSize, err := modules.NewSiaPath("/url-unknown-size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadURLPost(chunked.URL, unknownSize, 1, uint64(len(tg.Hosts())-1), 0, false, false)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrURLUploadUnknownSize.Error()) {
+		t.Fatal("expected upload to fail", err)
+	}
+
+	// An async upload should return right away and complete eventually.
+	async, err := modules.NewSiaPath("/url-async")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.RenterUploadURLPost(server.URL, async, 1, uint64(len(tg.Hosts())-1), 0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		uploads, err := r.RenterUploadURLGet()
+		if err != nil {
+			return err
+		}
+		for _, u := range uploads.Uploads {
+			if !u.SiaPath.Equals(async) {
+				continue
+			}
+			if !u.Completed {
+				return errors.New("upload not completed")
+			}
+			if u.Error != "" {
+				t.Fatal(u.Error)
+			}
+			return nil
+		}
+		return errors.New("upload not found")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -72,3 +72,148 @@ func TestTpoolTransactionsGet(t *testing.T) {
 		t.Fatal("expected no transactions got", len(tptg.Transactions))
 	}
 }
+
+// TestTpoolEvictPost probes the API end point evicting transactions from the
+// tpool.
+func TestTpoolEvictPost(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create testing directory.
+	testdir := tpoolTestDir(t.Name())
+
+	// Create a miner
+	miner, err := siatest.NewNode(node.Miner(filepath.Join(testdir, "miner")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := miner.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// miner sends a txn to itself
+	uc, err := miner.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = miner.WalletSiacoinsPost(types.SiacoinPrecision, uc.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tptg, err := miner.TransactionPoolTransactionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tptg.Transactions) != 2 {
+		t.Fatal("expected 2 transaction got", len(tptg.Transactions))
+	}
+
+	// Evicting the parent should evict both transactions.
+	tep, err := miner.TransactionPoolEvictPost(tptg.Transactions[0].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tep.Evicted) != 2 {
+		t.Fatal("expected 2 evicted transactions got", len(tep.Evicted))
+	}
+	tptg, err = miner.TransactionPoolTransactionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tptg.Transactions) != 0 {
+		t.Fatal("expected no transactions got", len(tptg.Transactions))
+	}
+
+	// Evicting a transaction that isn't in the pool should fail.
+	if _, err := miner.TransactionPoolEvictPost(types.TransactionID{}); err == nil {
+		t.Fatal("expected evicting an unknown transaction to fail")
+	}
+}
+
+// TestTpoolSimulatePost probes the API end points simulating the acceptance of
+// a transaction set and returning the fee histogram.
+func TestTpoolSimulatePost(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create testing directory.
+	testdir := tpoolTestDir(t.Name())
+
+	// Create a miner
+	miner, err := siatest.NewNode(node.Miner(filepath.Join(testdir, "miner")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := miner.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// miner sends a txn to itself and evicts it again to get a valid
+	// transaction set.
+	uc, err := miner.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = miner.WalletSiacoinsPost(types.SiacoinPrecision, uc.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tptg, err := miner.TransactionPoolTransactionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns := tptg.Transactions
+	if len(txns) != 2 {
+		t.Fatal("expected 2 transaction got", len(txns))
+	}
+	if _, err := miner.TransactionPoolEvictPost(txns[0].ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulating the set should succeed without adding it to the pool.
+	tsp, err := miner.TransactionPoolSimulatePost(txns[1], txns[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tsp.Accepted || tsp.Error != "" || tsp.FeePerByte.IsZero() {
+		t.Fatal("unexpected simulation result", tsp)
+	}
+	tptg, err = miner.TransactionPoolTransactionsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tptg.Transactions) != 0 {
+		t.Fatal("expected no transactions got", len(tptg.Transactions))
+	}
+
+	// Once the set is in the pool, it should show up in the histogram and
+	// simulating it again should report it as a duplicate.
+	if err := miner.TransactionPoolRawPost(txns[1], txns[:1]); err != nil {
+		t.Fatal(err)
+	}
+	tfhg, err := miner.TransactionPoolFeeHistogramGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sets, transactions int
+	for _, b := range tfhg.Buckets {
+		sets += b.Sets
+		transactions += b.Transactions
+	}
+	if sets != 1 || transactions != 2 {
+		t.Fatal("unexpected fee histogram", tfhg.Buckets)
+	}
+	tsp, err = miner.TransactionPoolSimulatePost(txns[1], txns[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tsp.Accepted || tsp.Error == "" {
+		t.Fatal("expected duplicate set to be rejected", tsp)
+	}
+}